@@ -0,0 +1,213 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// command is a node in the admin CLI command tree. Group commands dispatch to
+// their subcommands; leaf commands declare their flags in setup, which returns
+// the function that runs the command once the flags have been parsed. Keeping
+// flag declarations separate from execution lets the tree be described
+// (--help-json) and turned into shell completions without running anything.
+type command struct {
+	name        string
+	summary     string
+	description string
+	args        []string
+	examples    []string
+	subcommands []*command
+	setup       func(fs *flag.FlagSet) func()
+}
+
+// commandSpec is the machine-readable description of a command printed by --help-json.
+type commandSpec struct {
+	Name        string        `json:"name"`
+	Path        string        `json:"path"`
+	Summary     string        `json:"summary"`
+	Args        []string      `json:"args,omitempty"`
+	Flags       []flagSpec    `json:"flags,omitempty"`
+	Examples    []string      `json:"examples,omitempty"`
+	Subcommands []commandSpec `json:"subcommands,omitempty"`
+}
+
+// flagSpec describes a single command flag.
+type flagSpec struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Default  string `json:"default"`
+	Usage    string `json:"usage"`
+	Required bool   `json:"required"`
+}
+
+const binaryName = "alexander-admin"
+
+// execute runs the command with the given arguments. path holds the names of
+// the command and its parents, excluding the binary name.
+func (c *command) execute(path []string, args []string) {
+	if c.setup != nil {
+		c.run(path, args)
+		return
+	}
+
+	if len(args) == 0 {
+		c.printUsage(path)
+		os.Exit(1)
+	}
+
+	name := args[0]
+	if sub := c.subcommand(name); sub != nil {
+		sub.execute(append(path[:len(path):len(path)], name), args[1:])
+		return
+	}
+
+	switch name {
+	case "help", "-h", "--help":
+		c.printUsage(path)
+	case "--help-json", "-help-json":
+		c.printJSON(path)
+	default:
+		if len(path) == 0 {
+			fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n", name)
+		} else {
+			fmt.Fprintf(os.Stderr, "Unknown %s subcommand: %s\n", strings.Join(path, " "), name)
+		}
+		c.printUsage(path)
+		os.Exit(1)
+	}
+}
+
+// run parses the flags of a leaf command and runs it.
+func (c *command) run(path []string, args []string) {
+	fs := flag.NewFlagSet(strings.Join(path, " "), flag.ExitOnError)
+	run := c.setup(fs)
+	out.register(fs)
+	helpJSON := fs.Bool("help-json", false, "Describe this command and its flags as JSON")
+
+	if err := fs.Parse(args); err != nil {
+		os.Exit(1)
+	}
+
+	if *helpJSON {
+		c.printJSON(path)
+		return
+	}
+	run()
+}
+
+func (c *command) subcommand(name string) *command {
+	for _, sub := range c.subcommands {
+		if sub.name == name {
+			return sub
+		}
+	}
+	return nil
+}
+
+func (c *command) printUsage(path []string) {
+	description := c.description
+	if description == "" {
+		description = c.summary
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\nUsage:\n", description)
+	if len(path) == 0 {
+		fmt.Fprintf(&b, "  %s <command> [arguments]\n\nCommands:\n", binaryName)
+	} else {
+		fmt.Fprintf(&b, "  %s %s <subcommand> [arguments]\n\nSubcommands:\n", binaryName, strings.Join(path, " "))
+	}
+
+	width := 0
+	for _, sub := range c.subcommands {
+		width = max(width, len(sub.name))
+	}
+	for _, sub := range c.subcommands {
+		fmt.Fprintf(&b, "  %-*s  %s\n", width, sub.name, sub.summary)
+	}
+
+	if len(c.examples) > 0 {
+		b.WriteString("\nExamples:\n")
+		for _, example := range c.examples {
+			fmt.Fprintf(&b, "  %s\n", example)
+		}
+	}
+
+	if len(path) == 0 {
+		fmt.Fprintf(&b, "\nUse \"%s <command> --help\" for more information about a command.\n", binaryName)
+		fmt.Fprintf(&b, "Use \"%s --help-json\" to describe all commands and flags as JSON.\n", binaryName)
+	}
+	fmt.Print(b.String())
+}
+
+func (c *command) printJSON(path []string) {
+	jsonBytes, _ := json.MarshalIndent(c.describe(path), "", "  ")
+	fmt.Println(string(jsonBytes))
+}
+
+// describe returns the description of the command and all its subcommands.
+func (c *command) describe(path []string) commandSpec {
+	spec := commandSpec{
+		Name:     c.name,
+		Path:     strings.Join(append([]string{binaryName}, path...), " "),
+		Summary:  c.summary,
+		Args:     c.args,
+		Examples: c.examples,
+	}
+	if spec.Summary == "" {
+		spec.Summary = c.description
+	}
+
+	if c.setup != nil {
+		fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+		c.setup(fs)
+		(&outputOptions{format: formatTable}).register(fs)
+		fs.VisitAll(func(f *flag.Flag) {
+			spec.Flags = append(spec.Flags, flagSpec{
+				Name:     f.Name,
+				Type:     flagType(f),
+				Default:  f.DefValue,
+				Usage:    f.Usage,
+				Required: strings.Contains(f.Usage, "required)"),
+			})
+		})
+	}
+
+	for _, sub := range c.subcommands {
+		spec.Subcommands = append(spec.Subcommands, sub.describe(append(path[:len(path):len(path)], sub.name)))
+	}
+	return spec
+}
+
+// flagType returns the value type of a flag as shown in --help-json.
+func flagType(f *flag.Flag) string {
+	getter, ok := f.Value.(flag.Getter)
+	if !ok {
+		return "string"
+	}
+	switch getter.Get().(type) {
+	case bool:
+		return "bool"
+	case int, int64, uint, uint64:
+		return "int"
+	case float64:
+		return "float"
+	case time.Duration:
+		return "duration"
+	default:
+		return "string"
+	}
+}
+
+// walk calls fn for spec and each of its descendants, depth first. path holds
+// the subcommand names leading to each spec, excluding the binary name.
+func (spec commandSpec) walk(path []string, fn func(spec commandSpec, path []string)) {
+	fn(spec, path)
+	for _, sub := range spec.Subcommands {
+		sub.walk(append(path[:len(path):len(path)], sub.Name), fn)
+	}
+}
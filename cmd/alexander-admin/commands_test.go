@@ -0,0 +1,101 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// findSpec returns the description of the command at path.
+func findSpec(t *testing.T, root commandSpec, path ...string) commandSpec {
+	t.Helper()
+	spec := root
+	for _, name := range path {
+		found := false
+		for _, sub := range spec.Subcommands {
+			if sub.Name == name {
+				spec, found = sub, true
+				break
+			}
+		}
+		require.True(t, found, "no command %q", strings.Join(path, " "))
+	}
+	return spec
+}
+
+func TestCommandTree_Describe(t *testing.T) {
+	root := rootCommand().describe(nil)
+	assert.Equal(t, binaryName, root.Path)
+
+	root.walk(nil, func(spec commandSpec, path []string) {
+		where := strings.Join(path, " ")
+		assert.NotEmpty(t, spec.Summary, "%q has no summary", where)
+		assert.Equal(t, strings.Join(append([]string{binaryName}, path...), " "), spec.Path)
+
+		names := make(map[string]bool)
+		for _, sub := range spec.Subcommands {
+			assert.False(t, names[sub.Name], "%q has two subcommands %q", where, sub.Name)
+			names[sub.Name] = true
+		}
+
+		// Every leaf command takes the output flags
+		if len(spec.Subcommands) == 0 {
+			flags := make(map[string]bool)
+			for _, f := range spec.Flags {
+				flags[f.Name] = true
+			}
+			for _, name := range []string{"output", "o", "quiet", "q", "json"} {
+				assert.True(t, flags[name], "%q has no --%s flag", where, name)
+			}
+		}
+	})
+
+	create := findSpec(t, root, "user", "create")
+	var username, output *flagSpec
+	for i, f := range create.Flags {
+		switch f.Name {
+		case "username":
+			username = &create.Flags[i]
+		case "output":
+			output = &create.Flags[i]
+		}
+	}
+	require.NotNil(t, username)
+	assert.Equal(t, flagSpec{Name: "username", Type: "string", Usage: "Username (required)", Required: true}, *username)
+	require.NotNil(t, output)
+	assert.Equal(t, "table", output.Default)
+	assert.False(t, output.Required)
+}
+
+func TestCompletionScripts(t *testing.T) {
+	root := rootCommand().describe(nil)
+	bash := bashCompletion(root)
+	zsh := zshCompletion(root)
+	fish := fishCompletion(root)
+
+	// Every command can be completed in every shell
+	root.walk(nil, func(spec commandSpec, path []string) {
+		where := strings.Join(path, " ")
+		assert.Contains(t, bash, `"`+where+`") words=`, where)
+		assert.Contains(t, zsh, `"`+where+`") candidates=`, where)
+		if len(path) > 0 {
+			assert.Contains(t, fish, "-a '"+path[len(path)-1]+"'", where)
+		}
+	})
+	assert.Contains(t, bash, "complete -F _alexander_admin alexander-admin")
+	assert.Contains(t, zsh, "#compdef alexander-admin")
+
+	// Summaries with quotes stay within their word
+	assert.Equal(t, `'it'\''s'`, shellQuote("it's"))
+	assert.Equal(t, `'it\'s \\'`, fishQuote(`it's \`))
+
+	if _, err := exec.LookPath("bash"); err == nil {
+		cmd := exec.Command("bash", "-n")
+		cmd.Stdin = strings.NewReader(bash)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "bash completion does not parse: %s", out)
+	}
+}
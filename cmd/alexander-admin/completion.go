@@ -0,0 +1,217 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// =============================================================================
+// Shell Completion
+// =============================================================================
+
+func completionCommand() *command {
+	return &command{
+		name:    "completion",
+		summary: "Generate a shell completion script (bash, zsh, fish)",
+		args:    []string{"bash", "zsh", "fish"},
+		setup:   completionRun,
+		examples: []string{
+			"alexander-admin completion bash > /etc/bash_completion.d/alexander-admin",
+			"alexander-admin completion zsh > \"${fpath[1]}/_alexander-admin\"",
+			"alexander-admin completion fish > ~/.config/fish/completions/alexander-admin.fish",
+		},
+	}
+}
+
+func completionRun(fs *flag.FlagSet) func() {
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s completion bash|zsh|fish\n", binaryName)
+	}
+
+	return func() {
+		if fs.NArg() != 1 {
+			fmt.Fprintln(os.Stderr, "Error: a shell (bash, zsh or fish) is required")
+			fs.Usage()
+			os.Exit(1)
+		}
+
+		root := rootCommand().describe(nil)
+		switch fs.Arg(0) {
+		case "bash":
+			fmt.Print(bashCompletion(root))
+		case "zsh":
+			fmt.Print(zshCompletion(root))
+		case "fish":
+			fmt.Print(fishCompletion(root))
+		default:
+			fmt.Fprintf(os.Stderr, "Error: unsupported shell %q (use bash, zsh or fish)\n", fs.Arg(0))
+			os.Exit(1)
+		}
+	}
+}
+
+// completionWords returns what can follow a command: its subcommands, or the
+// flags and positional arguments of a leaf command.
+func completionWords(spec commandSpec) []string {
+	var words []string
+	for _, sub := range spec.Subcommands {
+		words = append(words, sub.Name)
+	}
+	for _, f := range spec.Flags {
+		words = append(words, "--"+f.Name)
+	}
+	words = append(words, spec.Args...)
+	return append(words, "--help-json")
+}
+
+// groupPaths returns the quoted case patterns matching the paths of commands
+// with subcommands; the bash and zsh scripts stop reading words at a leaf.
+func groupPaths(root commandSpec) string {
+	var patterns []string
+	root.walk(nil, func(spec commandSpec, path []string) {
+		if len(spec.Subcommands) > 0 {
+			patterns = append(patterns, `"`+strings.Join(path, " ")+`"`)
+		}
+	})
+	return strings.Join(patterns, "|")
+}
+
+// shellQuote quotes s for bash and zsh.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// fishQuote quotes s for fish.
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
+
+func bashCompletion(root commandSpec) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `# bash completion for %[1]s
+# Generated by "%[1]s completion bash".
+
+_alexander_admin() {
+    local cur="${COMP_WORDS[COMP_CWORD]}" path="" word i
+    for ((i = 1; i < COMP_CWORD; i++)); do
+        word="${COMP_WORDS[i]}"
+        [[ "$word" == -* ]] && continue
+        case "$path" in
+            %[2]s) path="${path:+$path }$word" ;;
+            *) break ;;
+        esac
+    done
+
+    local words=""
+    case "$path" in
+`, binaryName, groupPaths(root))
+
+	root.walk(nil, func(spec commandSpec, path []string) {
+		fmt.Fprintf(&b, "        %q) words=%q ;;\n", strings.Join(path, " "), strings.Join(completionWords(spec), " "))
+	})
+
+	fmt.Fprintf(&b, `    esac
+    COMPREPLY=($(compgen -W "$words" -- "$cur"))
+}
+
+complete -F _alexander_admin %s
+`, binaryName)
+	return b.String()
+}
+
+func zshCompletion(root commandSpec) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `#compdef %[1]s
+# Generated by "%[1]s completion zsh".
+
+_alexander_admin() {
+    local cmd_path="" word i
+    local -a candidates
+    for ((i = 2; i < CURRENT; i++)); do
+        word="${words[i]}"
+        [[ "$word" == -* ]] && continue
+        case "$cmd_path" in
+            %[2]s) cmd_path="${cmd_path:+$cmd_path }$word" ;;
+            *) break ;;
+        esac
+    done
+
+    case "$cmd_path" in
+`, binaryName, groupPaths(root))
+
+	root.walk(nil, func(spec commandSpec, path []string) {
+		var candidates []string
+		for _, sub := range spec.Subcommands {
+			candidates = append(candidates, shellQuote(sub.Name+":"+sub.Summary))
+		}
+		for _, f := range spec.Flags {
+			candidates = append(candidates, shellQuote("--"+f.Name+":"+f.Usage))
+		}
+		for _, arg := range spec.Args {
+			candidates = append(candidates, shellQuote(arg))
+		}
+		candidates = append(candidates, shellQuote("--help-json:Describe the command as JSON"))
+		fmt.Fprintf(&b, "        %q) candidates=(%s) ;;\n", strings.Join(path, " "), strings.Join(candidates, " "))
+	})
+
+	fmt.Fprintf(&b, `    esac
+    _describe -t commands '%[1]s' candidates
+}
+
+if [[ "${funcstack[1]}" == "_%[1]s" ]]; then
+    _alexander_admin "$@"
+else
+    compdef _alexander_admin %[1]s
+fi
+`, binaryName)
+	return b.String()
+}
+
+func fishCompletion(root commandSpec) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# fish completion for %[1]s\n# Generated by \"%[1]s completion fish\".\n\ncomplete -c %[1]s -f\n", binaryName)
+
+	root.walk(nil, func(spec commandSpec, path []string) {
+		seen := "__fish_use_subcommand"
+		if len(path) > 0 {
+			conditions := make([]string, 0, len(path))
+			for _, name := range path {
+				conditions = append(conditions, "__fish_seen_subcommand_from "+name)
+			}
+			seen = strings.Join(conditions, "; and ")
+		}
+
+		if len(spec.Subcommands) > 0 {
+			condition := seen
+			if len(path) > 0 {
+				var names []string
+				for _, sub := range spec.Subcommands {
+					names = append(names, sub.Name)
+				}
+				condition += "; and not __fish_seen_subcommand_from " + strings.Join(names, " ")
+			}
+			for _, sub := range spec.Subcommands {
+				fmt.Fprintf(&b, "complete -c %s -n %s -a %s -d %s\n",
+					binaryName, fishQuote(condition), fishQuote(sub.Name), fishQuote(sub.Summary))
+			}
+		}
+
+		for _, f := range spec.Flags {
+			requiresValue := ""
+			if f.Type != "bool" {
+				requiresValue = " -r"
+			}
+			fmt.Fprintf(&b, "complete -c %s -n %s -l %s%s -d %s\n",
+				binaryName, fishQuote(seen), f.Name, requiresValue, fishQuote(f.Usage))
+		}
+		if len(spec.Args) > 0 {
+			fmt.Fprintf(&b, "complete -c %s -n %s -a %s\n",
+				binaryName, fishQuote(seen), fishQuote(strings.Join(spec.Args, " ")))
+		}
+		fmt.Fprintf(&b, "complete -c %s -n %s -l help-json -d %s\n",
+			binaryName, fishQuote(seen), fishQuote("Describe the command as JSON"))
+	})
+	return b.String()
+}
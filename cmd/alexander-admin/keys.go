@@ -0,0 +1,356 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/pkg/crypto"
+	"github.com/prn-tf/alexander-storage/internal/pkg/keys"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+	"github.com/prn-tf/alexander-storage/internal/storage/filesystem"
+)
+
+// =============================================================================
+// Key Commands
+// =============================================================================
+
+// Keys are rotated by adding a new version to the keyring of the key
+// provider (keys.provider). Servers encrypt with the newest version and
+// decrypt with any version in the keyring; keys reencrypt then moves data
+// encrypted with older versions to the newest, after which they can be
+// removed from the keyring.
+
+func keysCommand() *command {
+	return &command{
+		name:        "keys",
+		summary:     "Show key versions and re-encrypt data after a key rotation",
+		description: "Key commands - restart servers with the new keyring before re-encrypting",
+		subcommands: []*command{
+			{name: "status", summary: "Show key versions and data encrypted with older versions", setup: keysStatus},
+			{name: "reencrypt", summary: "Re-encrypt secrets and blobs with the current key versions", setup: keysReencrypt},
+		},
+		examples: []string{
+			"alexander-admin keys status",
+			"alexander-admin keys reencrypt --dry-run",
+			"alexander-admin keys reencrypt --batch-size 500",
+		},
+	}
+}
+
+// sseKeyring returns the versions of the SSE master key, or exits if the
+// key provider has none.
+func (ac *adminContext) sseKeyring() keys.Keyring {
+	keyring, err := ac.keys.Keyring(ac.ctx, keys.SSEMasterKey)
+	if errors.Is(err, keys.ErrKeyNotFound) {
+		failUsage(nil, "SSE master key not configured: %v", err)
+	}
+	if err != nil {
+		fail("loading SSE master key", err)
+	}
+	return keyring
+}
+
+// reencryptStats counts the data found encrypted with older key versions
+// and the data re-encrypted.
+type reencryptStats struct {
+	Stale       int   `json:"stale"`
+	Reencrypted int   `json:"reencrypted"`
+	Bytes       int64 `json:"bytes,omitempty"`
+	Errors      int   `json:"errors"`
+}
+
+func keysStatus(fs *flag.FlagSet) func() {
+	return func() {
+		adminCtx, err := initAdminContext()
+		if err != nil {
+			fail("", err)
+		}
+		defer adminCtx.dbCloser()
+
+		result := map[string]interface{}{
+			"provider":               adminCtx.cfg.Keys.Provider,
+			"encryption_key_version": adminCtx.encryptor.CurrentVersion(),
+			"access_keys":            reencryptAccessKeys(adminCtx, true),
+			"replication_secrets":    reencryptReplicationSecrets(adminCtx, true),
+		}
+
+		sseKeys, err := adminCtx.keys.Keyring(adminCtx.ctx, keys.SSEMasterKey)
+		if err != nil && !errors.Is(err, keys.ErrKeyNotFound) {
+			fail("loading SSE master key", err)
+		}
+		if err == nil {
+			result["sse_master_key_version"] = sseKeys.Current().Version
+			result["blobs"] = reencryptBlobs(adminCtx, sseKeys, 1000, true)
+		}
+
+		printResult(result, nil, func() {
+			fmt.Printf("Key Provider: %s\n", adminCtx.cfg.Keys.Provider)
+			fmt.Printf("  Encryption Key:       version %d\n", adminCtx.encryptor.CurrentVersion())
+			if sseKeys != nil {
+				fmt.Printf("  SSE Master Key:       version %d\n", sseKeys.Current().Version)
+			} else {
+				fmt.Printf("  SSE Master Key:       not configured\n")
+			}
+			fmt.Printf("\nEncrypted With Older Versions:\n")
+			fmt.Printf("  Access Key Secrets:   %d\n", result["access_keys"].(reencryptStats).Stale)
+			fmt.Printf("  Replication Secrets:  %d\n", result["replication_secrets"].(reencryptStats).Stale)
+			if blobs, ok := result["blobs"].(reencryptStats); ok {
+				fmt.Printf("  Blobs:                %d (%s)\n", blobs.Stale, formatBytes(blobs.Bytes))
+			}
+		})
+	}
+}
+
+func keysReencrypt(fs *flag.FlagSet) func() {
+	batchSize := fs.Int("batch-size", 100, "Number of blobs to process per batch")
+	dryRun := fs.Bool("dry-run", false, "Show what would be re-encrypted without making changes")
+
+	return func() {
+		if *batchSize <= 0 {
+			failUsage(fs, "--batch-size must be positive")
+		}
+
+		adminCtx, err := initAdminContext()
+		if err != nil {
+			fail("", err)
+		}
+		defer adminCtx.dbCloser()
+
+		accessKeys := reencryptAccessKeys(adminCtx, *dryRun)
+		replicationSecrets := reencryptReplicationSecrets(adminCtx, *dryRun)
+		var blobs reencryptStats
+		sseKeys, err := adminCtx.keys.Keyring(adminCtx.ctx, keys.SSEMasterKey)
+		switch {
+		case err == nil:
+			blobs = reencryptBlobs(adminCtx, sseKeys, *batchSize, *dryRun)
+		case !errors.Is(err, keys.ErrKeyNotFound):
+			fail("loading SSE master key", err)
+		}
+
+		totalErrors := accessKeys.Errors + replicationSecrets.Errors + blobs.Errors
+		if !*dryRun {
+			var reencryptErr error
+			if totalErrors > 0 {
+				reencryptErr = fmt.Errorf("%d errors", totalErrors)
+			}
+			adminCtx.recordAudit(domain.AuditEvent{
+				Operation: "keys.reencrypt",
+				Detail: fmt.Sprintf("secrets=%d blobs=%d",
+					accessKeys.Reencrypted+replicationSecrets.Reencrypted, blobs.Reencrypted),
+			}, reencryptErr)
+		}
+
+		result := map[string]interface{}{
+			"access_keys":         accessKeys,
+			"replication_secrets": replicationSecrets,
+			"blobs":               blobs,
+			"dry_run":             *dryRun,
+		}
+		printResult(result, nil, func() {
+			fmt.Printf("\nRe-encryption Complete:\n")
+			if *dryRun {
+				fmt.Printf("  Access Key Secrets:   %d to re-encrypt\n", accessKeys.Stale)
+				fmt.Printf("  Replication Secrets:  %d to re-encrypt\n", replicationSecrets.Stale)
+				fmt.Printf("  Blobs:                %d to re-encrypt (%s)\n", blobs.Stale, formatBytes(blobs.Bytes))
+			} else {
+				fmt.Printf("  Access Key Secrets:   %d of %d re-encrypted\n", accessKeys.Reencrypted, accessKeys.Stale)
+				fmt.Printf("  Replication Secrets:  %d of %d re-encrypted\n", replicationSecrets.Reencrypted, replicationSecrets.Stale)
+				fmt.Printf("  Blobs:                %d of %d re-encrypted (%s)\n", blobs.Reencrypted, blobs.Stale, formatBytes(blobs.Bytes))
+			}
+			if totalErrors > 0 {
+				fmt.Printf("  Errors: %d\n", totalErrors)
+			}
+			if *dryRun {
+				fmt.Printf("\n(Dry run - no changes made)\n")
+			} else if totalErrors == 0 {
+				fmt.Printf("\n✅ Older key versions can now be removed from the keyrings.\n")
+			}
+		})
+	}
+}
+
+// reencryptAccessKeys re-encrypts access key secrets encrypted with an older
+// version of the encryption key. With dryRun it only counts them.
+func reencryptAccessKeys(adminCtx *adminContext, dryRun bool) reencryptStats {
+	var stats reencryptStats
+	current := adminCtx.encryptor.CurrentVersion()
+
+	const pageSize = 100
+	for offset := 0; ; offset += pageSize {
+		users, err := adminCtx.repos.User.List(adminCtx.ctx, repository.ListOptions{Offset: offset, Limit: pageSize})
+		if err != nil {
+			fail("listing users", err)
+		}
+
+		for _, user := range users.Items {
+			accessKeys, err := adminCtx.repos.AccessKey.ListByUserID(adminCtx.ctx, user.ID)
+			if err != nil {
+				fail("listing access keys", err)
+			}
+
+			for _, key := range accessKeys {
+				if adminCtx.encryptor.KeyVersion(key.EncryptedSecret) == current {
+					continue
+				}
+				stats.Stale++
+				if dryRun {
+					continue
+				}
+
+				encrypted, err := reencryptSecret(adminCtx.encryptor, key.EncryptedSecret)
+				if err == nil {
+					err = adminCtx.repos.AccessKey.UpdateSecret(adminCtx.ctx, key.ID, encrypted)
+				}
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error re-encrypting access key %s: %v\n", key.AccessKeyID, err)
+					stats.Errors++
+					continue
+				}
+				stats.Reencrypted++
+				progressf("Re-encrypted access key %s\n", key.AccessKeyID)
+			}
+		}
+
+		if len(users.Items) < pageSize {
+			return stats
+		}
+	}
+}
+
+// reencryptReplicationSecrets re-encrypts the destination secret keys of
+// replication configurations encrypted with an older version of the
+// encryption key. With dryRun it only counts them.
+func reencryptReplicationSecrets(adminCtx *adminContext, dryRun bool) reencryptStats {
+	var stats reencryptStats
+	current := adminCtx.encryptor.CurrentVersion()
+
+	buckets, err := adminCtx.repos.Bucket.List(adminCtx.ctx, 0)
+	if err != nil {
+		fail("listing buckets", err)
+	}
+
+	for _, bucket := range buckets {
+		config, err := adminCtx.repos.Replication.GetConfig(adminCtx.ctx, bucket.ID)
+		if errors.Is(err, domain.ErrReplicationConfigNotFound) {
+			continue
+		}
+		if err != nil {
+			fail("reading replication configuration", err)
+		}
+		if config.EncryptedSecretKey == "" || adminCtx.encryptor.KeyVersion(config.EncryptedSecretKey) == current {
+			continue
+		}
+		stats.Stale++
+		if dryRun {
+			continue
+		}
+
+		config.EncryptedSecretKey, err = reencryptSecret(adminCtx.encryptor, config.EncryptedSecretKey)
+		if err == nil {
+			err = adminCtx.repos.Replication.PutConfig(adminCtx.ctx, config)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error re-encrypting replication secret of bucket %s: %v\n", bucket.Name, err)
+			stats.Errors++
+			continue
+		}
+		stats.Reencrypted++
+		progressf("Re-encrypted replication secret of bucket %s\n", bucket.Name)
+	}
+	return stats
+}
+
+// reencryptSecret decrypts a secret with the key version that encrypted it
+// and encrypts it with the current version.
+func reencryptSecret(encryptor *crypto.Encryptor, encrypted string) (string, error) {
+	plaintext, err := encryptor.Decrypt(encrypted)
+	if err != nil {
+		return "", err
+	}
+	return encryptor.Encrypt(plaintext)
+}
+
+// reencryptBlobs re-encrypts blobs encrypted with an older version of the
+// SSE master key. With dryRun it only counts them.
+func reencryptBlobs(adminCtx *adminContext, keyring keys.Keyring, batchSize int, dryRun bool) reencryptStats {
+	var stats reencryptStats
+	current := keyring.Current()
+
+	encryptors := make(map[int]*crypto.SSEEncryptor, len(keyring))
+	for _, key := range keyring {
+		encryptor, err := crypto.NewSSEEncryptor(key.Material)
+		if err != nil {
+			fail("initializing SSE encryptor", err)
+		}
+		encryptors[key.Version] = encryptor
+	}
+
+	storageBackend, err := filesystem.NewStorage(filesystem.Config{
+		DataDir: adminCtx.cfg.Storage.DataDir,
+		TempDir: adminCtx.cfg.Storage.TempDir,
+	}, adminCtx.logger)
+	if err != nil {
+		fail("initializing storage", err)
+	}
+
+	after := ""
+	for {
+		blobs, err := adminCtx.repos.Blob.ListEncryptedBelowKeyVersion(adminCtx.ctx, current.Version, after, batchSize)
+		if err != nil {
+			fail("listing encrypted blobs", err)
+		}
+		if len(blobs) == 0 {
+			return stats
+		}
+
+		for _, blob := range blobs {
+			after = blob.ContentHash
+			stats.Stale++
+			stats.Bytes += blob.Size
+			if dryRun {
+				continue
+			}
+
+			if err := reencryptBlob(adminCtx, storageBackend, encryptors, current.Version, blob); err != nil {
+				fmt.Fprintf(os.Stderr, "Error re-encrypting blob %s: %v\n", blob.ContentHash, err)
+				stats.Errors++
+				continue
+			}
+			stats.Reencrypted++
+			progressf("Re-encrypted blob %s (%s)\n", blob.ContentHash, formatBytes(blob.Size))
+		}
+	}
+}
+
+// reencryptBlob rewrites a blob encrypted with the current key version and
+// records the new IV and version.
+func reencryptBlob(adminCtx *adminContext, storageBackend *filesystem.Storage, encryptors map[int]*crypto.SSEEncryptor, currentVersion int, blob *domain.Blob) error {
+	oldEncryptor, ok := encryptors[blob.EncryptionKeyVersion]
+	if !ok {
+		return fmt.Errorf("key version %d is not in the keyring", blob.EncryptionKeyVersion)
+	}
+
+	storagePath := storageBackend.GetPath(blob.ContentHash)
+	ciphertext, err := os.ReadFile(storagePath)
+	if err != nil {
+		return err
+	}
+	plaintext, err := oldEncryptor.DecryptBlob(ciphertext, blob.ContentHash)
+	if err != nil {
+		return err
+	}
+	newCiphertext, err := encryptors[currentVersion].EncryptBlob(plaintext, blob.ContentHash)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(storagePath, newCiphertext, 0600); err != nil {
+		return err
+	}
+
+	// The IV is the first 12 bytes of the new ciphertext
+	newIV := fmt.Sprintf("%x", newCiphertext[:12])
+	return adminCtx.repos.Blob.UpdateEncrypted(adminCtx.ctx, blob.ContentHash, newIV, currentVersion)
+}
@@ -0,0 +1,718 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/service"
+)
+
+// =============================================================================
+// Object Commands
+// =============================================================================
+
+// The object commands read and write objects through the service layer, as
+// the S3 API does, without request signing. Ownership checks are bypassed.
+
+func objectCommand() *command {
+	return &command{
+		name:        "object",
+		summary:     "Inspect and fix objects without an S3 client (ls, stat, cat, cp, rm, restore)",
+		description: "Object commands",
+		subcommands: []*command{
+			{name: "ls", summary: "List objects or versions in a bucket", setup: objectList},
+			{name: "stat", summary: "Show the metadata of an object", setup: objectStat},
+			{name: "cat", summary: "Write an object to stdout", setup: objectCat},
+			{name: "cp", summary: "Copy between local files and objects, or between objects", setup: objectCopy},
+			{name: "rm", summary: "Delete an object, a version, or all objects under a prefix", setup: objectRemove},
+			{name: "restore", summary: "Undelete an object or make an older version current again", setup: objectRestore},
+		},
+		examples: []string{
+			"alexander-admin object ls --bucket photos --prefix 2024/",
+			"alexander-admin object ls --bucket photos --prefix 2024/cat.jpg --versions",
+			"alexander-admin object stat --bucket photos --key 2024/cat.jpg",
+			"alexander-admin object cat --bucket photos --key notes.txt --version-id 3f1c...",
+			"alexander-admin object cp --from ./cat.jpg --to s3://photos/2024/cat.jpg",
+			"alexander-admin object cp --from s3://photos/2024/cat.jpg --to s3://archive/cat.jpg",
+			"alexander-admin object rm --bucket photos --key 2024/cat.jpg",
+			"alexander-admin object rm --bucket photos --prefix tmp/ --recursive",
+			"alexander-admin object restore --bucket photos --key 2024/cat.jpg",
+			"alexander-admin object restore --bucket photos --key 2024/cat.jpg --version-id 3f1c...",
+		},
+	}
+}
+
+// objectLocation is a cp source or destination: an object when bucket is
+// set, otherwise a local file, with "-" meaning stdin or stdout.
+type objectLocation struct {
+	bucket string
+	key    string
+	file   string
+}
+
+// parseObjectLocation parses a local path or s3://bucket/key.
+func parseObjectLocation(value string) (objectLocation, error) {
+	rest, ok := strings.CutPrefix(value, "s3://")
+	if !ok {
+		return objectLocation{file: value}, nil
+	}
+	bucket, key, _ := strings.Cut(rest, "/")
+	if bucket == "" {
+		return objectLocation{}, fmt.Errorf("%q has no bucket name", value)
+	}
+	return objectLocation{bucket: bucket, key: key}, nil
+}
+
+func (l objectLocation) String() string {
+	if l.bucket == "" {
+		return l.file
+	}
+	return "s3://" + l.bucket + "/" + l.key
+}
+
+func objectList(fs *flag.FlagSet) func() {
+	bucket := fs.String("bucket", "", "Bucket name (required)")
+	prefix := fs.String("prefix", "", "Only list keys starting with this prefix")
+	versions := fs.Bool("versions", false, "List every version and delete marker")
+	limit := fs.Int("limit", 0, "Stop after this many entries (0 = no limit)")
+
+	return func() {
+		if *bucket == "" {
+			failUsage(fs, "--bucket is required")
+		}
+
+		adminCtx, err := initAdminContext()
+		if err != nil {
+			fail("", err)
+		}
+		defer adminCtx.dbCloser()
+
+		storageBackend, err := initStorageBackend(adminCtx.cfg, adminCtx.logger)
+		if err != nil {
+			fail("initializing storage", err)
+		}
+		objects := newAdminObjectService(adminCtx, storageBackend)
+
+		var entries []objectEntry
+		if *versions {
+			entries, err = listObjectVersions(adminCtx.ctx, objects, *bucket, *prefix, *limit)
+		} else {
+			entries, err = listObjects(adminCtx.ctx, objects, *bucket, *prefix, *limit)
+		}
+		if err != nil {
+			fail("listing objects", err)
+		}
+
+		ids := make([]string, len(entries))
+		for i, e := range entries {
+			ids[i] = e.Key
+			if *versions {
+				ids[i] = e.VersionID
+			}
+		}
+		printResult(entries, ids, func() {
+			for _, e := range entries {
+				size := formatBytes(e.Size)
+				if e.DeleteMarker {
+					size = "(deleted)"
+				}
+				if !*versions {
+					fmt.Printf("%-20s %-12s %s\n", e.LastModified.Format("2006-01-02 15:04:05"), size, e.Key)
+					continue
+				}
+				latest := ""
+				if e.IsLatest {
+					latest = "latest"
+				}
+				fmt.Printf("%-20s %-12s %-36s %-6s %s\n",
+					e.LastModified.Format("2006-01-02 15:04:05"), size, e.VersionID, latest, e.Key)
+			}
+		})
+	}
+}
+
+// objectEntry is an object or version in the output of object ls.
+type objectEntry struct {
+	Key          string              `json:"key"`
+	VersionID    string              `json:"version_id,omitempty"`
+	IsLatest     bool                `json:"is_latest,omitempty"`
+	DeleteMarker bool                `json:"delete_marker,omitempty"`
+	Size         int64               `json:"size"`
+	ETag         string              `json:"etag,omitempty"`
+	StorageClass domain.StorageClass `json:"storage_class,omitempty"`
+	LastModified time.Time           `json:"last_modified"`
+}
+
+// listObjects pages through the objects under prefix, stopping after limit
+// objects if limit is positive.
+func listObjects(ctx context.Context, objects *service.ObjectService, bucket, prefix string, limit int) ([]objectEntry, error) {
+	entries := []objectEntry{}
+	input := service.ListObjectsInput{BucketName: bucket, Prefix: prefix, MaxKeys: 1000}
+	for {
+		page, err := objects.ListObjects(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range page.Contents {
+			entries = append(entries, objectEntry{
+				Key:          obj.Key,
+				Size:         obj.Size,
+				ETag:         obj.ETag,
+				StorageClass: obj.StorageClass,
+				LastModified: obj.LastModified,
+			})
+		}
+		if limit > 0 && len(entries) >= limit {
+			return entries[:limit], nil
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return entries, nil
+		}
+		input.ContinuationToken = page.NextContinuationToken
+	}
+}
+
+// listObjectVersions pages through the versions and delete markers under
+// prefix, stopping after limit entries if limit is positive.
+func listObjectVersions(ctx context.Context, objects *service.ObjectService, bucket, prefix string, limit int) ([]objectEntry, error) {
+	entries := []objectEntry{}
+	input := service.ListObjectVersionsInput{BucketName: bucket, Prefix: prefix, MaxKeys: 1000}
+	for {
+		page, err := objects.ListObjectVersions(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range page.Versions {
+			entries = append(entries, objectEntry{
+				Key:          v.Key,
+				VersionID:    v.VersionID,
+				IsLatest:     v.IsLatest,
+				Size:         v.Size,
+				ETag:         v.ETag,
+				StorageClass: v.StorageClass,
+				LastModified: v.LastModified,
+			})
+		}
+		for _, m := range page.DeleteMarkers {
+			entries = append(entries, objectEntry{
+				Key:          m.Key,
+				VersionID:    m.VersionID,
+				IsLatest:     m.IsLatest,
+				DeleteMarker: true,
+				LastModified: m.LastModified,
+			})
+		}
+		if limit > 0 && len(entries) >= limit {
+			return entries[:limit], nil
+		}
+		if !page.IsTruncated || page.NextKeyMarker == "" {
+			return entries, nil
+		}
+		input.KeyMarker, input.VersionIDMarker = page.NextKeyMarker, page.NextVersionIDMarker
+	}
+}
+
+func objectStat(fs *flag.FlagSet) func() {
+	bucket := fs.String("bucket", "", "Bucket name (required)")
+	key := fs.String("key", "", "Object key (required)")
+	versionID := fs.String("version-id", "", "Version to show instead of the latest")
+
+	return func() {
+		if *bucket == "" || *key == "" {
+			failUsage(fs, "--bucket and --key are required")
+		}
+
+		adminCtx, err := initAdminContext()
+		if err != nil {
+			fail("", err)
+		}
+		defer adminCtx.dbCloser()
+
+		storageBackend, err := initStorageBackend(adminCtx.cfg, adminCtx.logger)
+		if err != nil {
+			fail("initializing storage", err)
+		}
+
+		head, err := newAdminObjectService(adminCtx, storageBackend).HeadObject(adminCtx.ctx, service.HeadObjectInput{
+			BucketName: *bucket,
+			Key:        *key,
+			VersionID:  *versionID,
+		})
+		if err != nil {
+			fail("getting object", err)
+		}
+
+		result := map[string]interface{}{
+			"bucket":        *bucket,
+			"key":           *key,
+			"version_id":    head.VersionID,
+			"size":          head.ContentLength,
+			"content_type":  head.ContentType,
+			"etag":          head.ETag,
+			"last_modified": head.LastModified,
+			"storage_class": head.StorageClass,
+			"parts":         head.PartsCount,
+			"sequence":      head.Sequence,
+			"metadata":      head.Metadata,
+			"replication":   head.ReplicationStatus,
+			"expiration":    head.Expiration,
+		}
+		printResult(result, []string{head.VersionID}, func() {
+			fmt.Printf("Object: s3://%s/%s\n", *bucket, *key)
+			fmt.Printf("  Version ID:    %s\n", head.VersionID)
+			fmt.Printf("  Size:          %s (%d bytes)\n", formatBytes(head.ContentLength), head.ContentLength)
+			fmt.Printf("  Content Type:  %s\n", head.ContentType)
+			fmt.Printf("  ETag:          %s\n", head.ETag)
+			fmt.Printf("  Last Modified: %s\n", head.LastModified.Format("2006-01-02 15:04:05 MST"))
+			fmt.Printf("  Storage Class: %s\n", head.StorageClass)
+			if head.PartsCount > 0 {
+				fmt.Printf("  Parts:         %d\n", head.PartsCount)
+			}
+			if head.ReplicationStatus != "" {
+				fmt.Printf("  Replication:   %s\n", head.ReplicationStatus)
+			}
+			if head.Expiration != nil {
+				fmt.Printf("  Expires:       %s (rule %s)\n", head.Expiration.Date.Format("2006-01-02"), head.Expiration.RuleID)
+			}
+			for name, value := range head.Metadata {
+				fmt.Printf("  x-amz-meta-%s: %s\n", name, value)
+			}
+		})
+	}
+}
+
+func objectCat(fs *flag.FlagSet) func() {
+	bucket := fs.String("bucket", "", "Bucket name (required)")
+	key := fs.String("key", "", "Object key (required)")
+	versionID := fs.String("version-id", "", "Version to read instead of the latest")
+
+	return func() {
+		if *bucket == "" || *key == "" {
+			failUsage(fs, "--bucket and --key are required")
+		}
+
+		adminCtx, err := initAdminContext()
+		if err != nil {
+			fail("", err)
+		}
+		defer adminCtx.dbCloser()
+
+		storageBackend, err := initStorageBackend(adminCtx.cfg, adminCtx.logger)
+		if err != nil {
+			fail("initializing storage", err)
+		}
+
+		src := objectLocation{bucket: *bucket, key: *key}
+		if _, err := downloadObject(adminCtx.ctx, newAdminObjectService(adminCtx, storageBackend), src, *versionID, os.Stdout); err != nil {
+			fail("reading object", err)
+		}
+	}
+}
+
+// downloadObject writes an object to w and returns its size.
+func downloadObject(ctx context.Context, objects *service.ObjectService, src objectLocation, versionID string, w io.Writer) (int64, error) {
+	obj, err := objects.GetObject(ctx, service.GetObjectInput{
+		BucketName: src.bucket,
+		Key:        src.key,
+		VersionID:  versionID,
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer obj.Body.Close()
+	return io.Copy(w, obj.Body)
+}
+
+func objectCopy(fs *flag.FlagSet) func() {
+	from := fs.String("from", "", "Source: a local file, - for stdin, or s3://bucket/key (required)")
+	to := fs.String("to", "", "Destination: a local file, - for stdout, or s3://bucket/key; a key ending in / takes the source's name (required)")
+	versionID := fs.String("version-id", "", "Version of the source object to copy instead of the latest")
+	contentType := fs.String("content-type", "", "Content type of the new object (default: the source's, or application/octet-stream for files)")
+
+	return func() {
+		if *from == "" || *to == "" {
+			failUsage(fs, "--from and --to are required")
+		}
+		src, err := parseObjectLocation(*from)
+		if err != nil {
+			failUsage(fs, "--from: %v", err)
+		}
+		dst, err := parseObjectLocation(*to)
+		if err != nil {
+			failUsage(fs, "--to: %v", err)
+		}
+		if src.bucket == "" && dst.bucket == "" {
+			failUsage(fs, "--from or --to must be an s3://bucket/key")
+		}
+		if src.bucket != "" && (src.key == "" || strings.HasSuffix(src.key, "/")) {
+			failUsage(fs, "--from must name an object, not a prefix")
+		}
+		if *versionID != "" && src.bucket == "" {
+			failUsage(fs, "--version-id applies only to an s3:// source")
+		}
+		if dst.bucket != "" && (dst.key == "" || strings.HasSuffix(dst.key, "/")) {
+			name := path.Base(src.key)
+			if src.bucket == "" {
+				if src.file == "-" {
+					failUsage(fs, "--to must name a key when copying from stdin")
+				}
+				name = baseName(src.file)
+			}
+			dst.key += name
+		}
+
+		adminCtx, err := initAdminContext()
+		if err != nil {
+			fail("", err)
+		}
+		defer adminCtx.dbCloser()
+
+		storageBackend, err := initStorageBackend(adminCtx.cfg, adminCtx.logger)
+		if err != nil {
+			fail("initializing storage", err)
+		}
+		objects := newAdminObjectService(adminCtx, storageBackend)
+
+		// Download to a local file or stdout
+		if dst.bucket == "" {
+			size, err := downloadToFile(adminCtx.ctx, objects, src, *versionID, dst.file)
+			if err != nil {
+				fail("copying object", err)
+			}
+			if dst.file != "-" {
+				printResult(map[string]interface{}{"from": src.String(), "to": dst.file, "size": size}, []string{dst.file}, func() {
+					fmt.Printf("Copied %s to %s (%s).\n", src, dst.file, formatBytes(size))
+				})
+			}
+			return
+		}
+
+		var versionOut, stagingID string
+		var size int64
+		if src.bucket != "" {
+			var copied *service.CopyObjectOutput
+			copied, err = objects.CopyObject(adminCtx.ctx, service.CopyObjectInput{
+				SourceBucket:      src.bucket,
+				SourceKey:         src.key,
+				SourceVersionID:   *versionID,
+				DestBucket:        dst.bucket,
+				DestKey:           dst.key,
+				ContentType:       *contentType,
+				MetadataDirective: metadataDirective(*contentType),
+			})
+			if copied != nil {
+				versionOut, stagingID = copied.VersionID, copied.StagingID
+			}
+		} else {
+			var stored *service.PutObjectOutput
+			stored, size, err = uploadFile(adminCtx.ctx, objects, src.file, dst, *contentType)
+			if stored != nil {
+				versionOut, stagingID = stored.VersionID, stored.StagingID
+			}
+		}
+		adminCtx.recordAudit(domain.AuditEvent{
+			Operation:  "object.copy",
+			BucketName: dst.bucket,
+			ObjectKey:  dst.key,
+			Detail:     "from=" + src.String(),
+		}, err)
+		if err != nil {
+			fail("copying object", err)
+		}
+
+		result := map[string]interface{}{"from": src.String(), "to": dst.String(), "version_id": versionOut}
+		if stagingID != "" {
+			result["staging_id"] = stagingID
+		}
+		printResult(result, []string{dst.String()}, func() {
+			fmt.Printf("Copied %s to %s", src, dst)
+			if src.bucket == "" {
+				fmt.Printf(" (%s)", formatBytes(size))
+			}
+			fmt.Println(".")
+			if stagingID != "" {
+				fmt.Printf("The bucket quarantines uploads; staged as %s until promoted.\n", stagingID)
+			}
+		})
+	}
+}
+
+// metadataDirective keeps the source's metadata unless the content type is
+// replaced.
+func metadataDirective(contentType string) string {
+	if contentType != "" {
+		return "REPLACE"
+	}
+	return "COPY"
+}
+
+// baseName returns the last element of a local path, which may use either
+// slash on Windows.
+func baseName(file string) string {
+	if i := strings.LastIndexAny(file, `/\`); i >= 0 {
+		return file[i+1:]
+	}
+	return file
+}
+
+// downloadToFile writes an object to file, or to stdout for "-". A partly
+// written file is removed.
+func downloadToFile(ctx context.Context, objects *service.ObjectService, src objectLocation, versionID, file string) (int64, error) {
+	if file == "-" {
+		return downloadObject(ctx, objects, src, versionID, os.Stdout)
+	}
+
+	f, err := os.Create(file)
+	if err != nil {
+		return 0, err
+	}
+	size, err := downloadObject(ctx, objects, src, versionID, f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file)
+		return 0, err
+	}
+	return size, nil
+}
+
+// uploadFile stores a local file, or stdin for "-", as an object. Stdin is
+// buffered in a temporary file first since the size must be known.
+func uploadFile(ctx context.Context, objects *service.ObjectService, file string, dst objectLocation, contentType string) (*service.PutObjectOutput, int64, error) {
+	var f *os.File
+	if file == "-" {
+		tmp, err := os.CreateTemp("", "alexander-admin-*")
+		if err != nil {
+			return nil, 0, err
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+		if _, err := io.Copy(tmp, os.Stdin); err != nil {
+			return nil, 0, err
+		}
+		if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+			return nil, 0, err
+		}
+		f = tmp
+	} else {
+		opened, err := os.Open(file)
+		if err != nil {
+			return nil, 0, err
+		}
+		defer opened.Close()
+		f = opened
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+	if info.IsDir() {
+		return nil, 0, fmt.Errorf("%s is a directory", file)
+	}
+
+	stored, err := objects.PutObject(ctx, service.PutObjectInput{
+		BucketName:  dst.bucket,
+		Key:         dst.key,
+		Body:        f,
+		Size:        info.Size(),
+		ContentType: contentType,
+	})
+	return stored, info.Size(), err
+}
+
+func objectRemove(fs *flag.FlagSet) func() {
+	bucket := fs.String("bucket", "", "Bucket name (required)")
+	key := fs.String("key", "", "Object key to delete")
+	versionID := fs.String("version-id", "", "Permanently delete this version instead of adding a delete marker")
+	prefix := fs.String("prefix", "", "Delete every object under this prefix (with --recursive)")
+	recursive := fs.Bool("recursive", false, "Delete all objects under --prefix")
+	allVersions := fs.Bool("all-versions", false, "With --recursive, permanently delete every version and delete marker")
+	force := fs.Bool("force", false, "Skip confirmation")
+
+	return func() {
+		if *bucket == "" {
+			failUsage(fs, "--bucket is required")
+		}
+		if *recursive == (*key != "") {
+			failUsage(fs, "exactly one of --key and --recursive is required")
+		}
+		if *recursive && *versionID != "" {
+			failUsage(fs, "--version-id cannot be combined with --recursive")
+		}
+		if *allVersions && !*recursive {
+			failUsage(fs, "--all-versions requires --recursive")
+		}
+		if !*recursive && *prefix != "" {
+			failUsage(fs, "--prefix requires --recursive")
+		}
+
+		adminCtx, err := initAdminContext()
+		if err != nil {
+			fail("", err)
+		}
+		defer adminCtx.dbCloser()
+
+		storageBackend, err := initStorageBackend(adminCtx.cfg, adminCtx.logger)
+		if err != nil {
+			fail("initializing storage", err)
+		}
+		objects := newAdminObjectService(adminCtx, storageBackend)
+
+		var targets []objectEntry
+		switch {
+		case !*recursive:
+			targets = []objectEntry{{Key: *key, VersionID: *versionID}}
+		case *allVersions:
+			targets, err = listObjectVersions(adminCtx.ctx, objects, *bucket, *prefix, 0)
+		default:
+			targets, err = listObjects(adminCtx.ctx, objects, *bucket, *prefix, 0)
+		}
+		if err != nil {
+			fail("listing objects", err)
+		}
+
+		if *recursive && len(targets) == 0 {
+			progressf("Nothing to delete under s3://%s/%s.\n", *bucket, *prefix)
+			printResult([]string{}, nil, func() {})
+			return
+		}
+		if *recursive && !*force {
+			what := "objects"
+			if *allVersions {
+				what = "versions and delete markers (permanently)"
+			}
+			fmt.Printf("Delete %d %s under s3://%s/%s? (yes/no): ", len(targets), what, *bucket, *prefix)
+			var confirm string
+			fmt.Scanln(&confirm)
+			if strings.ToLower(confirm) != "yes" {
+				fmt.Println("Cancelled.")
+				return
+			}
+		}
+
+		type deleted struct {
+			Key                   string `json:"key"`
+			VersionID             string `json:"version_id,omitempty"`
+			DeleteMarkerVersionID string `json:"delete_marker_version_id,omitempty"`
+		}
+		results := []deleted{}
+		ids := []string{}
+		for _, t := range targets {
+			output, err := objects.DeleteObject(adminCtx.ctx, service.DeleteObjectInput{
+				BucketName: *bucket,
+				Key:        t.Key,
+				VersionID:  t.VersionID,
+			})
+			detail := ""
+			if t.VersionID != "" {
+				detail = "version_id=" + t.VersionID
+			}
+			adminCtx.recordAudit(domain.AuditEvent{
+				Operation:  "object.delete",
+				BucketName: *bucket,
+				ObjectKey:  t.Key,
+				Detail:     detail,
+			}, err)
+			if err != nil {
+				fail("deleting "+t.Key, err)
+			}
+			results = append(results, deleted{Key: t.Key, VersionID: t.VersionID, DeleteMarkerVersionID: output.DeleteMarkerVersionID})
+			ids = append(ids, t.Key)
+			if *recursive {
+				progressf("  deleted %s\n", describeVersion(t.Key, t.VersionID))
+			}
+		}
+
+		printResult(results, ids, func() {
+			if !*recursive {
+				fmt.Printf("Deleted %s.\n", describeVersion(*key, *versionID))
+				if results[0].DeleteMarkerVersionID != "" {
+					fmt.Printf("Versioning is enabled; added delete marker %s.\n", results[0].DeleteMarkerVersionID)
+				}
+				return
+			}
+			fmt.Printf("Deleted %d entries under s3://%s/%s.\n", len(results), *bucket, *prefix)
+		})
+	}
+}
+
+func objectRestore(fs *flag.FlagSet) func() {
+	bucket := fs.String("bucket", "", "Bucket name (required)")
+	key := fs.String("key", "", "Object key (required)")
+	versionID := fs.String("version-id", "", "Copy this version forward instead of removing the latest delete marker")
+
+	return func() {
+		if *bucket == "" || *key == "" {
+			failUsage(fs, "--bucket and --key are required")
+		}
+
+		adminCtx, err := initAdminContext()
+		if err != nil {
+			fail("", err)
+		}
+		defer adminCtx.dbCloser()
+
+		storageBackend, err := initStorageBackend(adminCtx.cfg, adminCtx.logger)
+		if err != nil {
+			fail("initializing storage", err)
+		}
+
+		restored, err := newAdminObjectService(adminCtx, storageBackend).RestoreObject(adminCtx.ctx, service.RestoreObjectInput{
+			BucketName: *bucket,
+			Key:        *key,
+			VersionID:  *versionID,
+		})
+		detail := ""
+		if *versionID != "" {
+			detail = "version_id=" + *versionID
+		}
+		adminCtx.recordAudit(domain.AuditEvent{
+			Operation:  "object.restore",
+			BucketName: *bucket,
+			ObjectKey:  *key,
+			Detail:     detail,
+		}, err)
+		if err != nil {
+			fail("restoring object", err)
+		}
+
+		result := map[string]interface{}{
+			"bucket":     *bucket,
+			"key":        *key,
+			"version_id": restored.VersionID,
+			"copied":     restored.Copied,
+			"sequence":   restored.Sequence,
+		}
+		if restored.DeleteMarkerVersionID != "" {
+			result["delete_marker_version_id"] = restored.DeleteMarkerVersionID
+		}
+		printResult(result, []string{restored.VersionID}, func() {
+			switch {
+			case restored.DeleteMarkerVersionID != "" && restored.VersionID == "":
+				fmt.Printf("Removed delete marker %s; %s has no earlier versions.\n", restored.DeleteMarkerVersionID, *key)
+			case restored.DeleteMarkerVersionID != "":
+				fmt.Printf("Removed delete marker %s; %s is current again.\n", restored.DeleteMarkerVersionID, describeVersion(*key, restored.VersionID))
+			case restored.Copied:
+				fmt.Printf("Copied version %s of %s forward as version %s.\n", *versionID, *key, restored.VersionID)
+			default:
+				fmt.Printf("%s is already the latest version.\n", describeVersion(*key, restored.VersionID))
+			}
+		})
+	}
+}
+
+func describeVersion(key, versionID string) string {
+	if versionID == "" {
+		return key
+	}
+	return key + " (version " + versionID + ")"
+}
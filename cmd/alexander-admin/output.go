@@ -0,0 +1,346 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"reflect"
+	"syscall"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+	"github.com/prn-tf/alexander-storage/internal/service"
+)
+
+// Exit codes. Each error category has its own code so scripts can react to
+// a failure without parsing the message.
+const (
+	exitFailure     = 1   // any error not in another category
+	exitUsage       = 2   // invalid flags, arguments or input, as for flag parse errors
+	exitNotFound    = 3   // the user, key, bucket or other resource does not exist
+	exitConflict    = 4   // the resource already exists or is in the wrong state
+	exitConnection  = 5   // the database or storage could not be reached
+	exitIntegrity   = 6   // a check found corrupt or missing blobs
+	exitInterrupted = 130 // stopped by Ctrl-C
+)
+
+// outputFormat selects how commands print their results.
+type outputFormat string
+
+const (
+	formatTable outputFormat = "table"
+	formatJSON  outputFormat = "json"
+	formatYAML  outputFormat = "yaml"
+)
+
+func (f *outputFormat) String() string { return string(*f) }
+
+func (f *outputFormat) Set(value string) error {
+	switch format := outputFormat(value); format {
+	case formatTable, formatJSON, formatYAML:
+		*f = format
+		return nil
+	default:
+		return fmt.Errorf("must be json, yaml or table")
+	}
+}
+
+// jsonShorthand is the --json flag, kept as a shorthand for --output json.
+type jsonShorthand struct{ format *outputFormat }
+
+func (j jsonShorthand) String() string   { return "false" }
+func (j jsonShorthand) IsBoolFlag() bool { return true }
+func (j jsonShorthand) Get() any         { return false }
+
+func (j jsonShorthand) Set(value string) error {
+	if value == "true" {
+		*j.format = formatJSON
+	}
+	return nil
+}
+
+// outputOptions holds the output flags shared by every leaf command.
+type outputOptions struct {
+	format outputFormat
+	quiet  bool
+}
+
+// out is set from the flags of the command being run.
+var out = outputOptions{format: formatTable}
+
+// register adds the output flags to fs. A name the command already uses for
+// something else, such as backup create's --output, is left to the command;
+// the short form still selects the format.
+func (o *outputOptions) register(fs *flag.FlagSet) {
+	for _, name := range []string{"output", "o"} {
+		if fs.Lookup(name) == nil {
+			fs.Var(&o.format, name, "Output `format`: json, yaml or table")
+		}
+	}
+	for _, name := range []string{"quiet", "q"} {
+		if fs.Lookup(name) == nil {
+			fs.BoolVar(&o.quiet, name, false, "Print only the IDs of the resources listed or changed")
+		}
+	}
+	fs.Var(jsonShorthand{&o.format}, "json", "Shorthand for --output json")
+}
+
+// structured reports whether results are printed as JSON or YAML.
+func (o *outputOptions) structured() bool {
+	return o.format == formatJSON || o.format == formatYAML
+}
+
+// human reports whether progress and confirmation messages are printed.
+// They are left out of structured and quiet output so stdout can be parsed.
+func (o *outputOptions) human() bool {
+	return !o.quiet && !o.structured()
+}
+
+// printResult prints the result of a command: only ids with --quiet, v as
+// JSON or YAML, or otherwise the human-readable form written by table.
+func printResult(v any, ids []string, table func()) {
+	switch {
+	case out.quiet:
+		for _, id := range ids {
+			fmt.Println(id)
+		}
+	case out.structured():
+		if err := writeStructured(os.Stdout, v); err != nil {
+			fail("formatting output", err)
+		}
+	default:
+		table()
+	}
+}
+
+// progressf prints a progress or confirmation message in table output.
+func progressf(format string, args ...any) {
+	if out.human() {
+		fmt.Printf(format, args...)
+	}
+}
+
+// writeStructured writes v as indented JSON or as YAML. YAML is converted
+// from the JSON encoding, so both formats use the same field names.
+func writeStructured(f *os.File, v any) error {
+	// An empty list is printed as [] rather than null
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice && rv.IsNil() {
+		v = []any{}
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if out.format == formatJSON {
+		_, err = fmt.Fprintln(f, string(data))
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var generic any
+	if err := decoder.Decode(&generic); err != nil {
+		return err
+	}
+	encoder := yaml.NewEncoder(f)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(yamlNumbers(generic)); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// yamlNumbers replaces the json.Numbers in v with integers or floats, which
+// YAML would otherwise quote as strings.
+func yamlNumbers(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			v[key] = yamlNumbers(value)
+		}
+	case []any:
+		for i, value := range v {
+			v[i] = yamlNumbers(value)
+		}
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+	}
+	return v
+}
+
+// =============================================================================
+// Errors
+// =============================================================================
+
+// codedError is an error whose exit code cannot be told from the error
+// itself, such as a failure to open the database.
+type codedError struct {
+	code int
+	err  error
+}
+
+func (e *codedError) Error() string { return e.err.Error() }
+func (e *codedError) Unwrap() error { return e.err }
+
+// withExitCode marks err as belonging to the category of code.
+func withExitCode(code int, err error) error {
+	return &codedError{code: code, err: err}
+}
+
+var (
+	notFoundErrors = []error{
+		repository.ErrNotFound,
+		domain.ErrUserNotFound,
+		domain.ErrAccessKeyNotFound,
+		domain.ErrBucketNotFound,
+		domain.ErrObjectNotFound,
+		domain.ErrObjectDeleted,
+		domain.ErrVersionNotFound,
+		domain.ErrBlobNotFound,
+		domain.ErrStagedObjectNotFound,
+		domain.ErrSnapshotNotFound,
+		domain.ErrMFADeviceNotFound,
+		domain.ErrTenantNotFound,
+		service.ErrUserNotFound,
+		service.ErrAccessKeyNotFound,
+		os.ErrNotExist,
+	}
+	conflictErrors = []error{
+		domain.ErrUserAlreadyExists,
+		domain.ErrBucketAlreadyExists,
+		domain.ErrBucketNotEmpty,
+		domain.ErrSnapshotAlreadyExists,
+		domain.ErrObjectNotDeleted,
+		domain.ErrMFADeviceExists,
+		domain.ErrTenantAlreadyExists,
+		domain.ErrTenantNotEmpty,
+		domain.ErrDefaultTenant,
+		service.ErrUserAlreadyExists,
+		service.ErrUserInactive,
+		service.ErrAccessKeyAlreadyExists,
+		service.ErrMaxAccessKeysReached,
+		service.ErrResidencyViolation,
+		domain.ErrBucketReadOnly,
+		domain.ErrServiceReadOnly,
+	}
+	validationErrors = []error{
+		domain.ErrInvalidUserRole,
+		domain.ErrInvalidPolicy,
+		domain.ErrBucketNameLength,
+		domain.ErrBucketNameFormat,
+		domain.ErrBucketNameIPFormat,
+		domain.ErrInvalidResidency,
+		domain.ErrInvalidObjectDefaults,
+		domain.ErrInvalidVersionID,
+		domain.ErrObjectKeyEmpty,
+		domain.ErrObjectKeyTooLong,
+		domain.ErrInvalidTenantName,
+		domain.ErrInvalidTenantLimits,
+		service.ErrInvalidUsername,
+		service.ErrInvalidPassword,
+		service.ErrInvalidEmail,
+		service.ErrInvalidExpiration,
+		service.ErrInvalidVersioningStatus,
+		service.ErrInvalidBackup,
+		service.ErrIncompatibleBackup,
+		service.ErrInvalidUsagePeriod,
+	}
+)
+
+// exitCode returns the exit code for the category of err.
+func exitCode(err error) int {
+	var coded *codedError
+	if errors.As(err, &coded) {
+		return coded.code
+	}
+
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.Canceled):
+		return exitInterrupted
+	case isAny(err, notFoundErrors):
+		return exitNotFound
+	case isAny(err, conflictErrors):
+		return exitConflict
+	case isAny(err, validationErrors):
+		return exitUsage
+	case errors.As(err, &netErr), errors.Is(err, syscall.ECONNREFUSED):
+		return exitConnection
+	default:
+		return exitFailure
+	}
+}
+
+func isAny(err error, targets []error) bool {
+	for _, target := range targets {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// errorCategories names the exit codes in structured error output.
+var errorCategories = map[int]string{
+	exitFailure:     "failure",
+	exitUsage:       "validation",
+	exitNotFound:    "not_found",
+	exitConflict:    "conflict",
+	exitConnection:  "connection",
+	exitIntegrity:   "integrity",
+	exitInterrupted: "interrupted",
+}
+
+// fail reports err and exits with the code of its category. action says
+// what failed, e.g. "creating user", and may be empty. With structured
+// output the error is written to stderr in the same format.
+func fail(action string, err error) {
+	exitWith(exitCode(err), action, err.Error())
+}
+
+// failUsage reports invalid flags or arguments and exits with exitUsage.
+// If fs is not nil, the command's flags are printed too.
+func failUsage(fs *flag.FlagSet, format string, args ...any) {
+	message := fmt.Sprintf(format, args...)
+	if fs != nil && !out.structured() {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", message)
+		fs.Usage()
+		os.Exit(exitUsage)
+	}
+	exitWith(exitUsage, "", message)
+}
+
+func exitWith(code int, action, message string) {
+	if out.structured() {
+		// Ignore a failure here; the exit code still tells what happened
+		_ = writeStructuredError(code, action, message)
+	} else if action != "" {
+		fmt.Fprintf(os.Stderr, "Error %s: %s\n", action, message)
+	} else {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", message)
+	}
+	os.Exit(code)
+}
+
+func writeStructuredError(code int, action, message string) error {
+	if action != "" {
+		message = action + ": " + message
+	}
+	return writeStructured(os.Stderr, map[string]any{
+		"error":     message,
+		"category":  errorCategories[code],
+		"exit_code": code,
+	})
+}
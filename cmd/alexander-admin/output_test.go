@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/service"
+)
+
+// TestMain runs the admin command itself when the test binary is started
+// by runAdmin, so exit codes and stderr can be checked.
+func TestMain(m *testing.M) {
+	if os.Getenv("ALEXANDER_ADMIN_TEST_MAIN") == "1" {
+		os.Args = append([]string{binaryName}, strings.Fields(os.Getenv("ALEXANDER_ADMIN_TEST_ARGS"))...)
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runAdmin runs the admin command with args against the SQLite database in
+// dir and returns its stdout, stderr and exit code.
+func runAdmin(t *testing.T, dir string, args ...string) (string, string, int) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	cmd.Env = append(os.Environ(),
+		"ALEXANDER_ADMIN_TEST_MAIN=1",
+		"ALEXANDER_ADMIN_TEST_ARGS="+strings.Join(args, " "),
+		"ALEXANDER_DATABASE_DRIVER=sqlite",
+		"ALEXANDER_DATABASE_PATH="+filepath.Join(dir, "alexander.db"),
+		"ALEXANDER_AUTH_ENCRYPTION_KEY=0123456789abcdef0123456789abcdef",
+		"ALEXANDER_LOGGING_LEVEL=error",
+	)
+	var stdout, stderr strings.Builder
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err := cmd.Run()
+	code := 0
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		code = exitErr.ExitCode()
+	} else {
+		require.NoError(t, err)
+	}
+	return stdout.String(), stderr.String(), code
+}
+
+// withOutput sets the global output options for the duration of the test.
+func withOutput(t *testing.T, o outputOptions) {
+	saved := out
+	out = o
+	t.Cleanup(func() { out = saved })
+}
+
+// captureStdout returns what fn writes to stdout.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	saved := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = saved }()
+
+	fn()
+	require.NoError(t, w.Close())
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestOutputOptions_Register(t *testing.T) {
+	var o outputOptions
+	fs := flag.NewFlagSet("user list", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	o.register(fs)
+	require.NoError(t, fs.Parse([]string{"-o", "yaml", "-q"}))
+	assert.Equal(t, formatYAML, o.format)
+	assert.True(t, o.quiet)
+	assert.True(t, o.structured())
+	assert.False(t, o.human())
+
+	o = outputOptions{format: formatTable}
+	require.NoError(t, fs.Parse([]string{"--json"}))
+	assert.Equal(t, formatJSON, o.format)
+
+	assert.Error(t, fs.Parse([]string{"--output", "xml"}))
+
+	// A command's own --output is left alone; -o still selects the format
+	o = outputOptions{format: formatTable}
+	fs = flag.NewFlagSet("backup create", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	path := fs.String("output", "", "Archive path")
+	o.register(fs)
+	require.NoError(t, fs.Parse([]string{"--output", "backup.tar.zst", "-o", "json"}))
+	assert.Equal(t, "backup.tar.zst", *path)
+	assert.Equal(t, formatJSON, o.format)
+	o.format = formatTable
+	assert.True(t, o.human())
+}
+
+func TestPrintResult(t *testing.T) {
+	users := []*domain.User{{ID: 7, Username: "alice"}, {ID: 9, Username: "bob"}}
+	ids := []string{"7", "9"}
+	table := func() { fmt.Println("table") }
+
+	withOutput(t, outputOptions{format: formatTable})
+	assert.Equal(t, "table\n", captureStdout(t, func() { printResult(users, ids, table) }))
+
+	// --quiet wins over the format
+	withOutput(t, outputOptions{format: formatJSON, quiet: true})
+	assert.Equal(t, "7\n9\n", captureStdout(t, func() { printResult(users, ids, table) }))
+
+	withOutput(t, outputOptions{format: formatJSON})
+	var decoded []map[string]any
+	require.NoError(t, json.Unmarshal([]byte(captureStdout(t, func() { printResult(users, ids, table) })), &decoded))
+	require.Len(t, decoded, 2)
+	assert.Equal(t, "alice", decoded[0]["username"])
+
+	// Numbers stay numbers in YAML and an empty list is not null
+	withOutput(t, outputOptions{format: formatYAML})
+	var generic []map[string]any
+	require.NoError(t, yaml.Unmarshal([]byte(captureStdout(t, func() { printResult(users, ids, table) })), &generic))
+	assert.Equal(t, 9, generic[1]["id"])
+	assert.Equal(t, "[]\n", captureStdout(t, func() { printResult([]*domain.User(nil), nil, table) }))
+
+	withOutput(t, outputOptions{format: formatJSON})
+	assert.Equal(t, "[]\n", captureStdout(t, func() { printResult([]*domain.User(nil), nil, table) }))
+	assert.Empty(t, captureStdout(t, func() { progressf("Created user %d\n", 7) }))
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"not found", fmt.Errorf("getting user: %w", domain.ErrUserNotFound), exitNotFound},
+		{"service not found", service.ErrUserNotFound, exitNotFound},
+		{"missing file", &os.PathError{Op: "open", Path: "backup.tar.zst", Err: os.ErrNotExist}, exitNotFound},
+		{"conflict", domain.ErrBucketNotEmpty, exitConflict},
+		{"validation", fmt.Errorf("restoring: %w", service.ErrInvalidBackup), exitUsage},
+		{"coded", withExitCode(exitIntegrity, errors.New("2 blobs missing")), exitIntegrity},
+		{"coded wins", withExitCode(exitConnection, domain.ErrUserNotFound), exitConnection},
+		{"interrupted", fmt.Errorf("listing: %w", context.Canceled), exitInterrupted},
+		{"connection", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, exitConnection},
+		{"other", errors.New("disk on fire"), exitFailure},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, exitCode(tt.err))
+			assert.NotEmpty(t, errorCategories[tt.want])
+		})
+	}
+}
+
+func TestAdminCommand_OutputAndExitCodes(t *testing.T) {
+	dir := t.TempDir()
+
+	stdout, _, code := runAdmin(t, dir, "user", "list", "--output", "json")
+	require.Equal(t, 0, code)
+	assert.Equal(t, "[]\n", stdout)
+
+	stdout, _, code = runAdmin(t, dir, "user", "create", "--username", "alice", "--email", "alice@example.com", "--quiet")
+	require.Equal(t, 0, code)
+	id := strings.TrimSpace(stdout)
+	require.NotEmpty(t, id)
+
+	stdout, _, code = runAdmin(t, dir, "user", "list", "-q")
+	require.Equal(t, 0, code)
+	assert.Equal(t, id+"\n", stdout)
+
+	stdout, _, code = runAdmin(t, dir, "user", "get", "--username", "alice", "-o", "yaml")
+	require.Equal(t, 0, code)
+	var user map[string]any
+	require.NoError(t, yaml.Unmarshal([]byte(stdout), &user))
+	assert.Equal(t, "alice", user["username"])
+	assert.Equal(t, id, fmt.Sprint(user["id"]))
+
+	// Errors are written to stderr, in the output format when structured
+	stdout, stderr, code := runAdmin(t, dir, "user", "get", "--username", "nobody", "--json")
+	assert.Equal(t, exitNotFound, code)
+	assert.Empty(t, stdout)
+	var failure map[string]any
+	require.NoError(t, json.Unmarshal([]byte(stderr), &failure), stderr)
+	assert.Equal(t, "not_found", failure["category"])
+	assert.Equal(t, float64(exitNotFound), failure["exit_code"])
+	assert.Contains(t, failure["error"], "getting user")
+
+	_, stderr, code = runAdmin(t, dir, "user", "create", "--username", "alice", "--email", "alice@example.com")
+	assert.Equal(t, exitConflict, code)
+	assert.Contains(t, stderr, "Error creating user")
+
+	_, stderr, code = runAdmin(t, dir, "user", "get")
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, "--id or --username is required")
+
+	_, _, code = runAdmin(t, dir, "user", "list", "--output", "xml")
+	assert.Equal(t, exitUsage, code)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/prn-tf/alexander-storage/internal/config"
+	"github.com/prn-tf/alexander-storage/internal/pkg/keys"
+)
+
+// The config command checks a configuration without starting the server:
+//
+//	alexander-server config validate [--config path] [--dev] [--data-dir dir]
+//	alexander-server config print [--config path] [--dev] [--data-dir dir] [--redact-secrets]
+//
+// Both load the configuration like the server does, from the file and the
+// environment variables, so print shows which of them won.
+
+const configUsage = `Usage:
+  alexander-server config validate [flags]   Load the configuration and check it
+  alexander-server config print [flags]      Print the effective configuration as YAML
+
+Flags:
+  --config path       Path to configuration file
+  --dev               Apply the defaults of --dev
+  --data-dir dir      Directory holding all data with --dev (default "./data")
+  --redact-secrets    Replace passwords, keys and tokens (print only)
+`
+
+// configCommand runs the config command with the arguments after "config"
+// and returns the exit code.
+func configCommand(args []string) int {
+	if len(args) == 0 || (args[0] != "validate" && args[0] != "print") {
+		fmt.Fprint(os.Stderr, configUsage)
+		return 2
+	}
+	subcommand := args[0]
+
+	flags := flag.NewFlagSet("config "+subcommand, flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, configUsage) }
+	configPath := flags.String("config", "", "Path to configuration file")
+	dev := flags.Bool("dev", false, "Apply the defaults of --dev")
+	dataDir := flags.String("data-dir", "./data", "Directory holding all data with --dev")
+	redactSecrets := flags.Bool("redact-secrets", false, "Replace passwords, keys and tokens")
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	cfg, err := loadConfig(*configPath, *dev, *dataDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	if subcommand == "print" {
+		return printConfig(cfg, *redactSecrets)
+	}
+	return validateConfig(cfg, *dev)
+}
+
+// printConfig writes the effective configuration to stdout.
+func printConfig(cfg *config.Config, redactSecrets bool) int {
+	out, err := yaml.Marshal(cfg.Settings(redactSecrets))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	source := cfg.File()
+	if source == "" {
+		source = "none, defaults and environment variables only"
+	}
+	fmt.Printf("# Configuration file: %s\n", source)
+	os.Stdout.Write(out)
+	return 0
+}
+
+// validateConfig reports the issues Check finds. Only errors fail it.
+func validateConfig(cfg *config.Config, dev bool) int {
+	issues := cfg.Check(context.Background())
+
+	failed := false
+	for _, issue := range issues {
+		// Development mode generates missing key files on the first start
+		if dev && cfg.Keys.Provider == "file" && issue.Key == "keys."+keys.EncryptionKey {
+			_, err := os.Stat(filepath.Join(cfg.Keys.Dir, keys.EncryptionKey))
+			if errors.Is(err, fs.ErrNotExist) {
+				issue.Warning = true
+				issue.Message = "will be generated on the first start"
+			}
+		}
+		fmt.Println(issue)
+		failed = failed || !issue.Warning
+	}
+
+	if cfg.File() != "" {
+		fmt.Printf("Configuration file: %s\n", cfg.File())
+	}
+	if failed {
+		fmt.Println("Configuration is invalid")
+		return 1
+	}
+	fmt.Printf("Configuration is valid (%d warnings)\n", len(issues))
+	return 0
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/prn-tf/alexander-storage/internal/pkg/redact"
+)
+
+// runConfig runs the config command and returns its exit code and stdout.
+func runConfig(t *testing.T, args ...string) (int, string) {
+	t.Helper()
+	var code int
+	printed := captureStdout(t, func() { code = configCommand(args) })
+	return code, printed
+}
+
+// writeConfig writes a configuration file with the given YAML and returns
+// its path.
+func writeConfig(t *testing.T, dir, contents string) string {
+	t.Helper()
+	path := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0600))
+	return path
+}
+
+func TestConfigCommand_Usage(t *testing.T) {
+	for _, args := range [][]string{nil, {"check"}, {"print", "--unknown"}} {
+		code, printed := runConfig(t, args...)
+		assert.Equal(t, 2, code, args)
+		assert.Empty(t, printed)
+	}
+
+	code, _ := runConfig(t, "validate", "--config", filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Equal(t, 1, code)
+}
+
+func TestConfigCommand_Print(t *testing.T) {
+	dir := t.TempDir()
+	const key = "0123456789abcdef0123456789abcdef"
+	path := writeConfig(t, dir, `
+server:
+  port: 9100
+database:
+  driver: sqlite
+  path: `+filepath.Join(dir, "alexander.db")+`
+storage:
+  data_dir: `+filepath.Join(dir, "blobs")+`
+  temp_dir: `+filepath.Join(dir, "temp")+`
+auth:
+  encryption_key: `+key+`
+`)
+
+	code, printed := runConfig(t, "print", "--config", path)
+	require.Equal(t, 0, code)
+	assert.True(t, strings.HasPrefix(printed, "# Configuration file: "+path+"\n"), printed)
+	var settings map[string]any
+	require.NoError(t, yaml.Unmarshal([]byte(printed), &settings))
+	assert.Equal(t, 9100, settings["server"].(map[string]any)["port"])
+	assert.Equal(t, key, settings["auth"].(map[string]any)["encryption_key"])
+
+	// Environment variables win over the file
+	t.Setenv("ALEXANDER_SERVER_PORT", "9200")
+	code, printed = runConfig(t, "print", "--config", path, "--redact-secrets")
+	require.Equal(t, 0, code)
+	require.NoError(t, yaml.Unmarshal([]byte(printed), &settings))
+	assert.Equal(t, 9200, settings["server"].(map[string]any)["port"])
+	assert.Equal(t, redact.Placeholder, settings["auth"].(map[string]any)["encryption_key"])
+	assert.NotContains(t, printed, key)
+}
+
+func TestConfigCommand_Validate(t *testing.T) {
+	dir := t.TempDir()
+
+	// The keys of --dev do not exist before the first start
+	code, printed := runConfig(t, "validate", "--dev", "--data-dir", dir)
+	assert.Equal(t, 0, code, printed)
+	assert.Contains(t, printed, "warning: keys.encryption_key: will be generated on the first start")
+	assert.Contains(t, printed, "Configuration is valid")
+
+	notADir := filepath.Join(dir, "blobs")
+	require.NoError(t, os.WriteFile(notADir, nil, 0600))
+	path := writeConfig(t, dir, `
+database:
+  driver: sqlite
+  path: `+filepath.Join(dir, "alexander.db")+`
+storage:
+  data_dir: `+notADir+`
+  temp_dir: `+filepath.Join(dir, "temp")+`
+auth:
+  encryption_key: 0123456789abcdef0123456789abcdef
+`)
+	code, printed = runConfig(t, "validate", "--config", path)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, printed, "error: storage.data_dir: "+notADir+" is not a directory")
+	assert.Contains(t, printed, "Configuration file: "+path)
+	assert.Contains(t, printed, "Configuration is invalid")
+}
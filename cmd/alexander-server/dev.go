@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/prn-tf/alexander-storage/internal/config"
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/pkg/crypto"
+	"github.com/prn-tf/alexander-storage/internal/pkg/keys"
+	"github.com/prn-tf/alexander-storage/internal/service"
+)
+
+// Development mode (--dev) runs a self-contained server for evaluation. The
+// configuration comes from config.LoadDev; on first run the keys are
+// generated into the data directory and an admin user with an access key is
+// created, whose credentials are printed once.
+
+// devAdminUsername is the user created on the first run in development mode.
+const devAdminUsername = "admin"
+
+// loadConfig loads the configuration, with the defaults of development mode
+// if dev is set.
+func loadConfig(path string, dev bool, dataDir string) (*config.Config, error) {
+	if dev {
+		return config.LoadDev(path, dataDir)
+	}
+	return config.Load(path)
+}
+
+// ensureDevKeys generates the keys of the file key provider that do not
+// exist yet. Losing them makes the stored secrets and encrypted blobs
+// unreadable, so existing files are never replaced.
+func ensureDevKeys(cfg *config.Config) error {
+	if cfg.Keys.Provider != "file" {
+		return nil
+	}
+	if err := os.MkdirAll(cfg.Keys.Dir, 0700); err != nil {
+		return fmt.Errorf("creating key directory: %w", err)
+	}
+
+	for _, name := range []string{keys.EncryptionKey, keys.SSEMasterKey} {
+		key, err := crypto.GenerateMasterKey()
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(cfg.Keys.Dir, name)
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("creating %s: %w", path, err)
+		}
+		_, err = f.WriteString(key + "\n")
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("writing %s: %w", path, err)
+		}
+		log.Info().Str("path", path).Msg("Generated development key")
+	}
+	return nil
+}
+
+// bootstrapDevAdmin creates an admin user and an access key if there are
+// no users yet, and prints their credentials.
+func bootstrapDevAdmin(ctx context.Context, cfg *config.Config, userService *service.UserService, iamService *service.IAMService) error {
+	users, err := userService.List(ctx, service.ListUsersInput{Limit: 1})
+	if err != nil {
+		return err
+	}
+	if users.TotalCount > 0 {
+		return nil
+	}
+
+	password, err := userService.PasswordPolicy().Generate(16)
+	if err != nil {
+		return fmt.Errorf("generating password: %w", err)
+	}
+	user, err := userService.Create(ctx, service.CreateUserInput{
+		Username: devAdminUsername,
+		Email:    devAdminUsername + "@localhost",
+		Password: password,
+		Role:     domain.RoleAdmin,
+	})
+	if err != nil {
+		return fmt.Errorf("creating admin user: %w", err)
+	}
+	key, err := iamService.CreateAccessKey(ctx, service.CreateAccessKeyInput{
+		UserID:      user.User.ID,
+		Description: "Created by --dev",
+	})
+	if err != nil {
+		return fmt.Errorf("creating access key: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("http://localhost:%d", cfg.Server.Port)
+
+	// Written to stdout rather than the log, which redacts secrets
+	fmt.Printf("\nDevelopment credentials (shown only once):\n")
+	fmt.Printf("  Endpoint:          %s\n", endpoint)
+	fmt.Printf("  Username:          %s\n", devAdminUsername)
+	fmt.Printf("  Password:          %s\n", password)
+	fmt.Printf("  Access Key ID:     %s\n", key.AccessKeyID)
+	fmt.Printf("  Secret Access Key: %s\n", key.SecretKey)
+	fmt.Printf("\nTry it:\n")
+	fmt.Printf("  export AWS_ACCESS_KEY_ID=%s AWS_SECRET_ACCESS_KEY=%s\n", key.AccessKeyID, key.SecretKey)
+	fmt.Printf("  aws --endpoint-url %s s3 mb s3://my-bucket\n\n", endpoint)
+	return nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/pkg/crypto"
+	"github.com/prn-tf/alexander-storage/internal/pkg/keys"
+	"github.com/prn-tf/alexander-storage/internal/repository/sqlite"
+	"github.com/prn-tf/alexander-storage/internal/service"
+)
+
+// captureStdout returns what fn writes to stdout.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	saved := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = saved }()
+
+	fn()
+	require.NoError(t, w.Close())
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(data)
+}
+
+// credential returns the value printed after label in the development
+// credentials.
+func credential(t *testing.T, printed, label string) string {
+	t.Helper()
+	for _, line := range strings.Split(printed, "\n") {
+		if value, ok := strings.CutPrefix(strings.TrimSpace(line), label+":"); ok {
+			return strings.TrimSpace(value)
+		}
+	}
+	t.Fatalf("no %q in %q", label, printed)
+	return ""
+}
+
+func TestDevMode(t *testing.T) {
+	ctx := context.Background()
+	dataDir := t.TempDir()
+
+	// Everything lives in the data directory, without a configuration file
+	// or any keys set
+	cfg, err := loadConfig("", true, dataDir)
+	require.NoError(t, err)
+	assert.Equal(t, "sqlite", cfg.Database.Driver)
+	assert.Equal(t, filepath.Join(dataDir, "alexander.db"), cfg.Database.Path)
+	assert.Equal(t, filepath.Join(dataDir, "blobs"), cfg.Storage.DataDir)
+	assert.Equal(t, "file", cfg.Keys.Provider)
+	assert.Equal(t, filepath.Join(dataDir, "keys"), cfg.Keys.Dir)
+	assert.False(t, cfg.Redis.Enabled)
+
+	// Keys are generated once and kept across restarts
+	require.NoError(t, ensureDevKeys(cfg))
+	keyPath := filepath.Join(cfg.Keys.Dir, keys.EncryptionKey)
+	info, err := os.Stat(keyPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	generated, err := os.ReadFile(keyPath)
+	require.NoError(t, err)
+	require.NoError(t, ensureDevKeys(cfg))
+	kept, err := os.ReadFile(keyPath)
+	require.NoError(t, err)
+	assert.Equal(t, generated, kept)
+
+	keyProvider, err := cfg.KeyProvider()
+	require.NoError(t, err)
+	encryptionKeys, err := keyProvider.Keyring(ctx, keys.EncryptionKey)
+	require.NoError(t, err)
+	encryptor, err := crypto.NewVersionedEncryptor(encryptionKeys.Materials(), encryptionKeys.Current().Version)
+	require.NoError(t, err)
+	_, err = keyProvider.Keyring(ctx, keys.SSEMasterKey)
+	require.NoError(t, err)
+
+	db, err := sqlite.NewDB(ctx, sqlite.DefaultConfig(cfg.Database.Path), zerolog.Nop())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, db.Migrate(ctx))
+	passwordPolicy, err := cfg.Auth.PasswordPolicy.Policy()
+	require.NoError(t, err)
+	userService := service.NewUserService(sqlite.NewUserRepository(db), passwordPolicy, zerolog.Nop())
+	iamService := service.NewIAMService(sqlite.NewAccessKeyRepository(db), sqlite.NewUserRepository(db), encryptor, zerolog.Nop(), service.IAMConfig{})
+
+	// The first run creates an admin and prints credentials that work
+	printed := captureStdout(t, func() {
+		require.NoError(t, bootstrapDevAdmin(ctx, cfg, userService, iamService))
+	})
+	assert.Equal(t, fmt.Sprintf("http://localhost:%d", cfg.Server.Port), credential(t, printed, "Endpoint"))
+	admin, err := userService.Authenticate(ctx, credential(t, printed, "Username"), credential(t, printed, "Password"))
+	require.NoError(t, err)
+	assert.Equal(t, devAdminUsername, admin.Username)
+	assert.Equal(t, domain.RoleAdmin, admin.Role)
+	key, err := iamService.VerifyAccessKey(ctx, credential(t, printed, "Access Key ID"))
+	require.NoError(t, err)
+	assert.Equal(t, credential(t, printed, "Secret Access Key"), key.SecretKey)
+	assert.Equal(t, admin.ID, key.UserID)
+
+	// Later runs leave the users alone and print nothing
+	printed = captureStdout(t, func() {
+		require.NoError(t, bootstrapDevAdmin(ctx, cfg, userService, iamService))
+	})
+	assert.Empty(t, printed)
+	users, err := userService.List(ctx, service.ListUsersInput{Limit: 10})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, users.TotalCount)
+}
@@ -4,7 +4,11 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"flag"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -12,22 +16,35 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
 	"github.com/prn-tf/alexander-storage/internal/auth"
+	"github.com/prn-tf/alexander-storage/internal/cache/lifecyclecache"
 	"github.com/prn-tf/alexander-storage/internal/cache/memory"
+	"github.com/prn-tf/alexander-storage/internal/cache/objectcache"
+	"github.com/prn-tf/alexander-storage/internal/cache/redis"
+	"github.com/prn-tf/alexander-storage/internal/cluster"
 	"github.com/prn-tf/alexander-storage/internal/config"
 	"github.com/prn-tf/alexander-storage/internal/handler"
 	"github.com/prn-tf/alexander-storage/internal/lock"
 	"github.com/prn-tf/alexander-storage/internal/metrics"
 	"github.com/prn-tf/alexander-storage/internal/middleware"
+	"github.com/prn-tf/alexander-storage/internal/migration"
+	"github.com/prn-tf/alexander-storage/internal/pkg/certs"
 	"github.com/prn-tf/alexander-storage/internal/pkg/crypto"
+	"github.com/prn-tf/alexander-storage/internal/pkg/keys"
+	"github.com/prn-tf/alexander-storage/internal/pkg/logfile"
+	"github.com/prn-tf/alexander-storage/internal/pkg/redact"
+	"github.com/prn-tf/alexander-storage/internal/pkg/syslog"
+	"github.com/prn-tf/alexander-storage/internal/pkg/telemetry"
 	"github.com/prn-tf/alexander-storage/internal/repository"
 	"github.com/prn-tf/alexander-storage/internal/repository/postgres"
 	"github.com/prn-tf/alexander-storage/internal/repository/sqlite"
 	"github.com/prn-tf/alexander-storage/internal/service"
 	"github.com/prn-tf/alexander-storage/internal/storage"
+	"github.com/prn-tf/alexander-storage/internal/storage/erasure"
 	"github.com/prn-tf/alexander-storage/internal/storage/filesystem"
 )
 
@@ -39,9 +56,41 @@ var (
 )
 
 func main() {
-	// Initialize logger
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		os.Exit(configCommand(os.Args[2:]))
+	}
+
+	configPath := flag.String("config", "", "Path to configuration file")
+	allowRoot := flag.Bool("allow-root", false, "Allow the server to run as root (not recommended)")
+	dev := flag.Bool("dev", false, "Run a self-contained development server: SQLite, local storage, generated keys and admin credentials")
+	dataDir := flag.String("data-dir", "./data", "Directory holding all data with --dev")
+	flag.Parse()
+
+	// Log to the console until the configuration is loaded
 	zerolog.TimeFieldFormat = time.RFC3339Nano
-	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr, TimeFormat: time.RFC3339})
+	log.Logger = log.Output(redact.Writer(zerolog.ConsoleWriter{Out: os.Stderr, TimeFormat: time.RFC3339}))
+
+	// Load configuration
+	load := func() (*config.Config, error) {
+		return loadConfig(*configPath, *dev, *dataDir)
+	}
+	cfg, err := load()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load configuration")
+	}
+
+	// Register configured secrets so they are redacted wherever they appear
+	redact.AddSecrets(cfg.Secrets()...)
+
+	// Initialize logger
+	logger, logFile, err := newLogger(cfg.Logging)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize logging")
+	}
+	if logFile != nil {
+		defer logFile.Close()
+	}
+	log.Logger = logger
 
 	log.Info().
 		Str("version", Version).
@@ -49,12 +98,6 @@ func main() {
 		Str("git_commit", GitCommit).
 		Msg("Starting Alexander Storage Server")
 
-	// Load configuration
-	cfg, err := config.Load("")
-	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to load configuration")
-	}
-
 	// Set log level
 	level, err := zerolog.ParseLevel(cfg.Logging.Level)
 	if err != nil {
@@ -62,11 +105,51 @@ func main() {
 	}
 	zerolog.SetGlobalLevel(level)
 
+	// Refuse to run with root privileges unless explicitly allowed
+	if filesystem.IsRoot() {
+		if !*allowRoot {
+			log.Fatal().Msg("Refusing to run as root; run as a dedicated user or pass --allow-root")
+		}
+		log.Warn().Msg("Running as root (--allow-root); this is not recommended")
+	}
+
+	// Initialize metrics
+	var m *metrics.Metrics
+	if cfg.Metrics.Enabled {
+		m = metrics.New()
+		log.Info().Int("port", cfg.Metrics.Port).Msg("Prometheus metrics enabled")
+	}
+
+	// Initialize tracing
+	var tracer *telemetry.Provider
+	if cfg.Telemetry.Enabled {
+		tracer, err = telemetry.NewProvider(telemetry.Config{
+			Endpoint:       cfg.Telemetry.Endpoint,
+			Headers:        cfg.Telemetry.Headers,
+			ServiceName:    cfg.Telemetry.ServiceName,
+			ServiceVersion: Version,
+			SampleRatio:    cfg.Telemetry.SampleRatio,
+			BatchSize:      cfg.Telemetry.BatchSize,
+			ExportInterval: cfg.Telemetry.ExportInterval,
+			ExportTimeout:  cfg.Telemetry.ExportTimeout,
+			Logger:         log.Logger,
+		})
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to initialize tracing")
+		}
+		telemetry.SetProvider(tracer)
+		log.Info().
+			Str("endpoint", cfg.Telemetry.Endpoint).
+			Float64("sample_ratio", cfg.Telemetry.SampleRatio).
+			Msg("OpenTelemetry tracing enabled")
+	}
+
 	// Initialize database and repositories based on driver
 	ctx := context.Background()
 	var repos *repository.Repositories
 	var dbCloser func()
 	var dbHealth repository.DatabaseHealth
+	var backfills []migration.Backfill
 
 	if cfg.Database.Driver == "sqlite" {
 		// SQLite / Embedded mode
@@ -78,34 +161,66 @@ func main() {
 		}
 
 		sqliteDB, err := sqlite.NewDB(ctx, sqlite.Config{
-			Path:            cfg.Database.Path,
-			MaxOpenConns:    cfg.Database.MaxOpenConns,
-			MaxIdleConns:    cfg.Database.MaxIdleConns,
-			ConnMaxLifetime: cfg.Database.ConnMaxLifetime,
-			JournalMode:     cfg.Database.JournalMode,
-			BusyTimeout:     cfg.Database.BusyTimeout,
-			CacheSize:       cfg.Database.CacheSize,
-			SynchronousMode: cfg.Database.SynchronousMode,
+			Path:               cfg.Database.Path,
+			MaxOpenConns:       cfg.Database.MaxOpenConns,
+			MaxIdleConns:       cfg.Database.MaxIdleConns,
+			ConnMaxLifetime:    cfg.Database.ConnMaxLifetime,
+			JournalMode:        cfg.Database.JournalMode,
+			BusyTimeout:        cfg.Database.BusyTimeout,
+			CacheSize:          cfg.Database.CacheSize,
+			SynchronousMode:    cfg.Database.SynchronousMode,
+			SlowQueryThreshold: cfg.Database.SlowQueryThreshold,
 		}, log.Logger)
 		if err != nil {
 			log.Fatal().Err(err).Msg("Failed to connect to SQLite database")
 		}
 		dbCloser = func() { sqliteDB.Close() }
 		dbHealth = sqliteDB
+		if m != nil {
+			m.RegisterDBPool("primary", sqlPoolStats(sqliteDB.DB()))
+		}
 
 		// Run migrations
 		if err := sqliteDB.Migrate(ctx); err != nil {
 			log.Fatal().Err(err).Msg("Failed to run SQLite migrations")
 		}
 
+		// Checkpoint the WAL and refresh planner statistics periodically;
+		// maintenance stops before the database is closed
+		maintenance := sqlite.NewMaintenance(sqliteDB, sqlite.MaintenanceConfig{
+			CheckpointInterval: cfg.Database.Maintenance.CheckpointInterval,
+			OptimizeInterval:   cfg.Database.Maintenance.OptimizeInterval,
+		}, log.Logger)
+		maintenance.Start()
+		dbCloser = func() {
+			maintenance.Stop()
+			sqliteDB.Close()
+		}
+
 		repos = &repository.Repositories{
-			User:      sqlite.NewUserRepository(sqliteDB),
-			AccessKey: sqlite.NewAccessKeyRepository(sqliteDB),
-			Bucket:    sqlite.NewBucketRepository(sqliteDB),
-			Object:    sqlite.NewObjectRepository(sqliteDB),
-			Blob:      sqlite.NewBlobRepository(sqliteDB),
-			Multipart: sqlite.NewMultipartRepository(sqliteDB),
+			User:        sqlite.NewUserRepository(sqliteDB),
+			AccessKey:   sqlite.NewAccessKeyRepository(sqliteDB),
+			Bucket:      sqlite.NewBucketRepository(sqliteDB),
+			Object:      sqlite.NewObjectRepository(sqliteDB),
+			Blob:        sqlite.NewBlobRepository(sqliteDB),
+			Multipart:   sqlite.NewMultipartRepository(sqliteDB),
+			Usage:       sqlite.NewUsageRepository(sqliteDB),
+			Event:       sqlite.NewEventRepository(sqliteDB),
+			Audit:       sqlite.NewAuditRepository(sqliteDB),
+			Backfill:    sqlite.NewBackfillRepository(sqliteDB),
+			Replication: sqlite.NewReplicationRepository(sqliteDB),
+			Lifecycle:   sqlite.NewLifecycleRepository(sqliteDB),
+			Staged:      sqlite.NewStagedObjectRepository(sqliteDB),
+			Batch:       sqlite.NewBatchRepository(sqliteDB),
+			Snapshot:    sqlite.NewBucketSnapshotRepository(sqliteDB),
+			Inventory:   sqlite.NewInventoryRepository(sqliteDB),
+			Website:     sqlite.NewWebsiteRepository(sqliteDB),
+			MFADevice:   sqlite.NewMFADeviceRepository(sqliteDB),
+			ReadOnly:    sqlite.NewReadOnlyRepository(sqliteDB),
+			Tenant:      sqlite.NewTenantRepository(sqliteDB),
+			TxManager:   sqlite.NewTxManager(sqliteDB),
 		}
+		backfills = sqlite.Backfills(sqliteDB)
 	} else {
 		// PostgreSQL mode (default)
 		log.Info().Str("driver", "postgres").Str("host", cfg.Database.Host).Msg("Using PostgreSQL database")
@@ -116,23 +231,58 @@ func main() {
 		}
 		dbCloser = func() { pgDB.Close() }
 		dbHealth = pgDB
+		if m != nil {
+			pgDB.SetReplicaObserver(m)
+			m.RegisterDBPool("primary", pgxPoolStats(pgDB.Pool))
+			if replicaPool := pgDB.ReplicaPool(); replicaPool != nil {
+				m.RegisterDBPool("replica", pgxPoolStats(replicaPool))
+			}
+		}
 
 		repos = &repository.Repositories{
-			User:      postgres.NewUserRepository(pgDB),
-			AccessKey: postgres.NewAccessKeyRepository(pgDB),
-			Bucket:    postgres.NewBucketRepository(pgDB),
-			Object:    postgres.NewObjectRepository(pgDB),
-			Blob:      postgres.NewBlobRepository(pgDB),
-			Multipart: postgres.NewMultipartRepository(pgDB),
+			User:        postgres.NewUserRepository(pgDB),
+			AccessKey:   postgres.NewAccessKeyRepository(pgDB),
+			Bucket:      postgres.NewBucketRepository(pgDB),
+			Object:      postgres.NewObjectRepository(pgDB),
+			Blob:        postgres.NewBlobRepository(pgDB),
+			Multipart:   postgres.NewMultipartRepository(pgDB),
+			Usage:       postgres.NewUsageRepository(pgDB),
+			Event:       postgres.NewEventRepository(pgDB),
+			Audit:       postgres.NewAuditRepository(pgDB),
+			Backfill:    postgres.NewBackfillRepository(pgDB),
+			Replication: postgres.NewReplicationRepository(pgDB),
+			Lifecycle:   postgres.NewLifecycleRepository(pgDB),
+			Staged:      postgres.NewStagedObjectRepository(pgDB),
+			Batch:       postgres.NewBatchRepository(pgDB),
+			Snapshot:    postgres.NewBucketSnapshotRepository(pgDB),
+			Inventory:   postgres.NewInventoryRepository(pgDB),
+			Website:     postgres.NewWebsiteRepository(pgDB),
+			MFADevice:   postgres.NewMFADeviceRepository(pgDB),
+			ReadOnly:    postgres.NewReadOnlyRepository(pgDB),
+			Tenant:      postgres.NewTenantRepository(pgDB),
+			ClusterNode: postgres.NewClusterNodeRepository(pgDB),
+			TxManager:   postgres.NewTxManager(pgDB),
 		}
+		backfills = postgres.Backfills(pgDB)
 	}
 	defer dbCloser()
 
 	log.Info().Msg("Connected to database")
 
+	// Run pending batched data migrations in the background
+	if cfg.Database.Backfill.Enabled && len(backfills) > 0 {
+		backfillRunner := migration.NewBackfillRunner(repos.Backfill, repos.TxManager, log.Logger, migration.BackfillConfig{
+			BatchSize: cfg.Database.Backfill.BatchSize,
+			Pause:     cfg.Database.Backfill.Pause,
+		})
+		backfillRunner.Start(backfills)
+		defer backfillRunner.Stop()
+	}
+
 	// Initialize cache and lock based on mode
 	var memCache *memory.Cache
 	var locker lock.Locker
+	var redisClient *redis.Client
 
 	if !cfg.Redis.Enabled || cfg.Database.IsEmbedded() {
 		// Single-node mode: use in-memory cache and locks
@@ -147,56 +297,170 @@ func main() {
 		memCache = memory.NewCache()
 		locker = lock.NewMemoryLocker()
 		defer memCache.Stop()
+
+		// Connect anyway so health checks report on Redis
+		redisClient, err = redis.NewClient(ctx, cfg.Redis, log.Logger)
+		if err != nil {
+			log.Warn().Err(err).Msg("Redis unreachable; omitting it from health checks")
+		} else {
+			defer redisClient.Close()
+		}
+	}
+
+	// Serve hot object lookups from the cache; writes invalidate the keys
+	// they change once their transaction commits
+	if cfg.ObjectCache.Enabled {
+		objectCache := objectcache.NewRepository(repos.Object, repos.Bucket, memCache, objectcache.Config{
+			TTL:            cfg.ObjectCache.TTL,
+			NegativeTTL:    cfg.ObjectCache.NegativeTTL,
+			Buckets:        cfg.ObjectCache.Buckets,
+			ExcludeBuckets: cfg.ObjectCache.ExcludeBuckets,
+		}, m, log.Logger)
+		repos.Object = objectCache
+		repos.TxManager = objectCache.TxManager(repos.TxManager)
+		log.Info().
+			Dur("ttl", cfg.ObjectCache.TTL).
+			Dur("negative_ttl", cfg.ObjectCache.NegativeTTL).
+			Msg("Object metadata cache enabled")
 	}
 
-	// Silence unused variable warning for cache (will be used for metadata caching in future)
-	_ = memCache
+	// Serve the lifecycle rules reported in x-amz-expiration from memory
+	repos.Lifecycle = lifecyclecache.NewRepository(repos.Lifecycle, cfg.Lifecycle.RuleCacheTTL)
 
-	// Initialize encryptor
-	encryptionKey, err := cfg.Auth.GetEncryptionKey()
+	// Initialize encryptor with every version of the encryption key
+	if *dev {
+		if err := ensureDevKeys(cfg); err != nil {
+			log.Fatal().Err(err).Msg("Failed to generate development keys")
+		}
+	}
+	keyProvider, err := cfg.KeyProvider()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid key provider configuration")
+	}
+	encryptionKeys, err := keyProvider.Keyring(ctx, keys.EncryptionKey)
 	if err != nil {
-		log.Fatal().Err(err).Msg("Invalid encryption key")
+		log.Fatal().Err(err).Str("provider", cfg.Keys.Provider).Msg("Failed to load encryption key")
 	}
-	encryptor, err := crypto.NewEncryptor(encryptionKey)
+	encryptor, err := crypto.NewVersionedEncryptor(encryptionKeys.Materials(), encryptionKeys.Current().Version)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to initialize encryptor")
 	}
+	log.Info().
+		Str("provider", cfg.Keys.Provider).
+		Int("key_version", encryptionKeys.Current().Version).
+		Msg("Encryption key loaded")
 
 	// Initialize storage backend
-	storageBackend, err := initStorageBackend(cfg, log.Logger)
+	storageBackend, erasureStorage, err := initStorageBackend(cfg, log.Logger)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to initialize storage backend")
 	}
+	// Runs last, flushing batched syncs once nothing writes anymore
+	defer func(local io.Closer) {
+		if err := local.Close(); err != nil {
+			log.Error().Err(err).Msg("Failed to close storage backend")
+		}
+	}(storageBackend.(io.Closer))
+
+	// Erasure-coded shards are verified and repaired in the background
+	if erasureStorage != nil && cfg.Storage.Erasure.ScrubInterval > 0 {
+		scrubber := erasure.NewScrubber(erasureStorage, cfg.Storage.Erasure.ScrubInterval, log.Logger)
+		scrubber.Start()
+		defer scrubber.Stop()
+	}
+
+	// In cluster mode blobs are placed across the nodes' local storage
+	if cfg.Cluster.Enabled {
+		placement, stopCluster, err := startCluster(ctx, cfg, repos, storageBackend, m)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to start cluster")
+		}
+		defer stopCluster()
+		storageBackend = placement
+	}
 
 	// Initialize services
-	iamService := service.NewIAMService(repos.AccessKey, repos.User, encryptor, log.Logger)
-	bucketService := service.NewBucketService(repos.Bucket, log.Logger)
-	objectService := service.NewObjectService(repos.Object, repos.Blob, repos.Bucket, storageBackend, locker, log.Logger)
-	multipartService := service.NewMultipartService(repos.Multipart, repos.Object, repos.Blob, repos.Bucket, storageBackend, locker, log.Logger)
+	passwordPolicy, err := cfg.Auth.PasswordPolicy.Policy()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load password policy")
+	}
+	userService := service.NewUserService(repos.User, passwordPolicy, log.Logger)
+	iamService := service.NewIAMService(repos.AccessKey, repos.User, encryptor, log.Logger, service.IAMConfig{
+		MaxAccessKeysPerUser: cfg.Auth.MaxAccessKeysPerUser,
+	})
+	if *dev {
+		if err := bootstrapDevAdmin(ctx, cfg, userService, iamService); err != nil {
+			log.Fatal().Err(err).Msg("Failed to create development credentials")
+		}
+	}
+	mfaService := service.NewMFAService(repos.MFADevice, repos.User, encryptor, log.Logger)
+	bucketService := service.NewBucketService(repos.Bucket, mfaService, log.Logger, service.BucketConfig{
+		DefaultRegion:  cfg.Auth.Region,
+		AllowedRegions: cfg.Auth.AllowedRegions,
+	})
 
-	// Initialize metrics
-	var m *metrics.Metrics
-	if cfg.Metrics.Enabled {
-		m = metrics.New()
-		log.Info().Int("port", cfg.Metrics.Port).Msg("Prometheus metrics enabled")
+	// Writes check the read-only mode the admin CLI and API switch
+	readOnlyService := service.NewReadOnlyService(repos.ReadOnly, log.Logger)
+	if readOnlyService.Enabled(ctx) {
+		log.Warn().Msg("Service is in read-only mode; writes are refused until it is turned off")
+	}
+	bucketService.SetReadOnlyService(readOnlyService)
+
+	// Buckets count towards the quotas of their owner's tenant
+	tenantService := service.NewTenantService(repos.Tenant, repos.User, repos.Bucket, service.TenantConfig{
+		NamespaceBuckets: cfg.Tenancy.NamespaceBuckets,
+	}, log.Logger)
+	bucketService.SetTenantService(tenantService)
+
+	// Object changes are only recorded when the watch API is enabled
+	var eventRepo repository.EventRepository
+	if cfg.Events.Enabled {
+		eventRepo = repos.Event
+	}
+
+	// New versions are only queued for replication when the worker runs
+	var replicationRepo repository.ReplicationRepository
+	if cfg.Replication.Enabled {
+		replicationRepo = repos.Replication
 	}
 
+	// Writes can only name a batch when the batch API is enabled
+	var batchRepo repository.BatchRepository
+	if cfg.Batch.Enabled {
+		batchRepo = repos.Batch
+	}
+
+	// Lifecycle rules only shorten multipart uploads when the scheduler
+	// applies them
+	var uploadLifecycleRepo repository.LifecycleRepository
+	if cfg.Lifecycle.Enabled {
+		uploadLifecycleRepo = repos.Lifecycle
+	}
+	objectService := service.NewObjectService(repos.Object, repos.Blob, repos.Bucket, eventRepo, replicationRepo, repos.Lifecycle, repos.Staged, batchRepo, mfaService, repos.TxManager, storageBackend, locker, m, log.Logger)
+	multipartService := service.NewMultipartService(repos.Multipart, repos.Object, repos.Blob, repos.Bucket, eventRepo, replicationRepo, uploadLifecycleRepo, repos.Staged, batchRepo, repos.TxManager, storageBackend, locker, m, log.Logger)
+	multipartLimits := service.MultipartLimits{
+		MinPartSize: cfg.Storage.Multipart.MinPartSize,
+		MaxPartSize: cfg.Storage.Multipart.MaxPartSize,
+		MaxParts:    cfg.Storage.Multipart.MaxParts,
+	}
+	multipartService.SetLimits(multipartLimits)
+	objectService.SetReadOnlyService(readOnlyService)
+	multipartService.SetReadOnlyService(readOnlyService)
+	objectService.SetTenantService(tenantService)
+	multipartService.SetTenantService(tenantService)
+
 	// Initialize garbage collector
 	var gc *service.GarbageCollector
 	if cfg.GC.Enabled {
 		gc = service.NewGarbageCollector(
 			repos.Blob,
+			eventRepo,
+			repos.TxManager,
 			storageBackend,
 			locker,
 			m,
 			log.Logger,
-			service.GCConfig{
-				Enabled:     cfg.GC.Enabled,
-				Interval:    cfg.GC.Interval,
-				GracePeriod: cfg.GC.GracePeriod,
-				BatchSize:   cfg.GC.BatchSize,
-				DryRun:      cfg.GC.DryRun,
-			},
+			gcConfig(cfg.GC),
 		)
 		gc.Start()
 		defer gc.Stop()
@@ -206,74 +470,583 @@ func main() {
 			Msg("Garbage collector started")
 	}
 
-	// Initialize rate limiter
-	var rateLimiter *middleware.RateLimiter
-	if cfg.RateLimit.Enabled {
-		rateLimiter = middleware.NewRateLimiter(
-			middleware.RateLimiterConfig{
-				RequestsPerSecond: cfg.RateLimit.RequestsPerSecond,
-				BurstSize:         cfg.RateLimit.BurstSize,
-				Enabled:           cfg.RateLimit.Enabled,
-				CleanupInterval:   5 * time.Minute,
+	// Corrupt and missing blobs are repaired from a copy of the data directory
+	var repairSource storage.Backend
+	if cfg.Scrub.RepairDir != "" && (cfg.Scrub.Enabled || cfg.GC.Verify.Enabled) {
+		repairSource, err = filesystem.NewStorage(filesystem.Config{
+			DataDir: cfg.Scrub.RepairDir,
+			TempDir: cfg.Storage.TempDir,
+		}, log.Logger)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to open scrub repair directory")
+		}
+	}
+
+	// Initialize blob integrity scrubber
+	if cfg.Scrub.Enabled {
+		scrubber := service.NewBlobScrubber(
+			repos.Blob,
+			storageBackend,
+			repairSource,
+			locker,
+			m,
+			log.Logger,
+			service.ScrubConfig{
+				Interval:       cfg.Scrub.Interval,
+				VerifyAfter:    cfg.Scrub.VerifyAfter,
+				BatchSize:      cfg.Scrub.BatchSize,
+				BytesPerSecond: cfg.Scrub.BytesPerSecond,
+				TempDir:        cfg.Storage.TempDir,
 			},
+		)
+		scrubber.Start()
+		defer scrubber.Stop()
+	}
+
+	// Initialize storage verifier
+	if cfg.GC.Verify.Enabled {
+		verifier := service.NewGCVerifier(
+			repos.Blob,
+			storageBackend,
+			repairSource,
+			locker,
 			m,
 			log.Logger,
+			service.GCVerifyConfig{
+				Interval:      cfg.GC.Verify.Interval,
+				GracePeriod:   cfg.GC.Verify.GracePeriod,
+				QuarantineDir: cfg.GC.Verify.QuarantineDir,
+				MaxFindings:   service.DefaultGCVerifyConfig().MaxFindings,
+				TempDir:       cfg.Storage.TempDir,
+			},
+		)
+		verifier.Start()
+		defer verifier.Stop()
+	}
+
+	// Initialize reference count reconciler
+	if cfg.GC.Reconcile.Enabled {
+		reconciler := service.NewGCReconciler(
+			repos.Blob,
+			locker,
+			m,
+			log.Logger,
+			service.GCReconcileConfig{
+				Interval:    cfg.GC.Reconcile.Interval,
+				BatchSize:   cfg.GC.Reconcile.BatchSize,
+				DryRun:      cfg.GC.Reconcile.DryRun,
+				MaxFindings: service.DefaultGCReconcileConfig().MaxFindings,
+			},
 		)
-		defer rateLimiter.Stop()
+		reconciler.Start()
+		defer reconciler.Stop()
+	}
+
+	// Initialize soft-deleted object purger
+	if cfg.GC.Purge.Enabled {
+		purger := service.NewObjectPurger(
+			repos.Object,
+			locker,
+			m,
+			log.Logger,
+			service.ObjectPurgeConfig{
+				Interval:  cfg.GC.Purge.Interval,
+				Retention: cfg.GC.Purge.Retention,
+				BatchSize: cfg.GC.Purge.BatchSize,
+			},
+		)
+		purger.Start()
+		defer purger.Stop()
+	}
+
+	// Initialize multipart upload reaper
+	if cfg.Storage.Multipart.ReapInterval > 0 {
+		reaperConfig := service.DefaultMultipartReaperConfig()
+		reaperConfig.Interval = cfg.Storage.Multipart.ReapInterval
+		reaper := service.NewMultipartReaper(multipartService, locker, m, log.Logger, reaperConfig)
+		reaper.Start()
+		defer reaper.Stop()
+	}
+
+	// Initialize lifecycle service; its scheduler applies the buckets' rules
+	lifecycleService := service.NewLifecycleService(
+		repos.Lifecycle,
+		repos.Object,
+		repos.Bucket,
+		repos.Blob,
+		eventRepo,
+		repos.TxManager,
+		locker,
+		m,
+		log.Logger,
+		lifecycleConfig(cfg.Lifecycle),
+	)
+	lifecycleService.SetReadOnlyService(readOnlyService)
+	if cfg.Lifecycle.Enabled {
+		lifecycleService.Start()
+		defer lifecycleService.Stop()
+	}
+
+	// Initialize rate limiter; it is installed even when disabled so that a
+	// configuration reload can enable it
+	rateLimiter := middleware.NewRateLimiter(
+		middleware.RateLimiterConfig{
+			RequestsPerSecond: cfg.RateLimit.RequestsPerSecond,
+			BurstSize:         cfg.RateLimit.BurstSize,
+			Enabled:           cfg.RateLimit.Enabled,
+			CleanupInterval:   5 * time.Minute,
+		},
+		m,
+		log.Logger,
+	)
+	defer rateLimiter.Stop()
+	if cfg.RateLimit.Enabled {
 		log.Info().
 			Float64("requests_per_second", cfg.RateLimit.RequestsPerSecond).
 			Int("burst_size", cfg.RateLimit.BurstSize).
 			Msg("Rate limiting enabled")
 	}
 
+	// Tenants without a request rate are not limited
+	tenantLimiter := middleware.NewTenantRateLimiter(tenantService, m, log.Logger)
+
 	// Initialize tracing middleware
 	tracing := middleware.NewTracing(m, log.Logger)
 
+	// Answer requests whose handler panics with InternalError
+	recovery := middleware.NewRecovery(m, log.Logger)
+
 	// Initialize auth middleware
 	accessKeyStore := service.NewAccessKeyStoreAdapter(iamService)
 	bucketACLChecker := service.NewBucketACLAdapter(bucketService)
+	objectACLChecker := service.NewObjectACLAdapter(objectService)
 	authConfig := auth.Config{
 		Region:           cfg.Auth.Region,
 		Service:          cfg.Auth.Service,
 		AllowAnonymous:   false,
-		SkipPaths:        []string{"/health", "/healthz", "/readyz"},
+		SkipPaths:        []string{"/health", "/healthz", "/livez", "/readyz", handler.CapabilitiesPath, handler.ManifestPath, handler.QuarantinePath},
+		SkipPrefixes:     []string{handler.AdminPathPrefix},
 		BucketACLChecker: bucketACLChecker,
+		ObjectACLChecker: objectACLChecker,
 	}
 	authMiddleware := handler.CreateAuthMiddleware(accessKeyStore, authConfig)
 
 	// Initialize handlers
 	bucketHandler := handler.NewBucketHandler(bucketService, log.Logger)
-	objectHandler := handler.NewObjectHandler(objectService, log.Logger)
-	multipartHandler := handler.NewMultipartHandler(multipartService, log.Logger)
+	objectHandler := handler.NewObjectHandler(objectService, cfg.Server.DetectContentType, log.Logger)
+	multipartHandler := handler.NewMultipartHandler(multipartService, cfg.Server.DetectContentType, log.Logger)
 
 	// Initialize health checker
-	healthChecker := handler.NewHealthChecker(handler.HealthCheckerConfig{
+	healthConfig := handler.HealthCheckerConfig{
 		DatabaseChecker: dbHealth,
 		StorageBackend:  storageBackend,
-		Logger:          log.Logger,
-		CacheTTL:        5 * time.Second,
+		Thresholds: handler.HealthThresholds{
+			DatabaseLatency: cfg.Health.DatabaseLatency,
+			StorageLatency:  cfg.Health.StorageLatency,
+			RedisLatency:    cfg.Health.RedisLatency,
+			GCLag:           cfg.Health.GCLag,
+		},
+		Logger:   log.Logger,
+		CacheTTL: 5 * time.Second,
+	}
+	if redisClient != nil {
+		healthConfig.RedisChecker = redisClient
+	}
+	if gc != nil {
+		healthConfig.GC = gc
+		if healthConfig.Thresholds.GCLag == 0 {
+			healthConfig.Thresholds.GCLag = 3 * cfg.GC.Interval
+		}
+	}
+	healthChecker := handler.NewHealthChecker(healthConfig)
+
+	// Initialize capability discovery
+	capabilitiesHandler := handler.NewCapabilitiesHandler(handler.CapabilitiesConfig{
+		Version:     Version,
+		MaxBodySize: cfg.Server.MaxBodySize,
+		Replication: cfg.Replication.Enabled,
+		Inventory:   cfg.Inventory.Enabled,
+		Website:     cfg.Website.Enabled,
+		Multipart:   multipartLimits,
 	})
 
+	// Initialize blob manifest API
+	var manifestHandler *handler.ManifestHandler
+	var manifestLimiter *middleware.RateLimiter
+	if cfg.Manifest.Enabled {
+		manifestService := service.NewManifestService(repos.Blob, cfg.Manifest.MaxPageSize, log.Logger)
+		manifestHandler = handler.NewManifestHandler(manifestService, cfg.Manifest.Token, log.Logger)
+		manifestLimiter = middleware.NewRateLimiter(
+			middleware.RateLimiterConfig{
+				RequestsPerSecond: cfg.Manifest.RequestsPerSecond,
+				BurstSize:         cfg.Manifest.BurstSize,
+				Enabled:           true,
+				CleanupInterval:   5 * time.Minute,
+			},
+			m,
+			log.Logger,
+		)
+		defer manifestLimiter.Stop()
+		log.Info().Int("max_page_size", cfg.Manifest.MaxPageSize).Msg("Blob manifest API enabled")
+	}
+
+	// Initialize upload quarantine API and expiry of unpromoted uploads
+	var quarantineHandler *handler.QuarantineHandler
+	if cfg.Quarantine.Enabled {
+		quarantineService := service.NewQuarantineService(
+			repos.Staged,
+			repos.Object,
+			repos.Blob,
+			repos.Bucket,
+			eventRepo,
+			replicationRepo,
+			repos.TxManager,
+			storageBackend,
+			locker,
+			log.Logger,
+			service.QuarantineConfig{
+				ExpireAfter: cfg.Quarantine.ExpireAfter,
+				Interval:    cfg.Quarantine.Interval,
+				BatchSize:   cfg.Quarantine.BatchSize,
+			},
+		)
+		quarantineService.SetReadOnlyService(readOnlyService)
+		quarantineService.Start()
+		defer quarantineService.Stop()
+		quarantineHandler = handler.NewQuarantineHandler(quarantineService, cfg.Quarantine.Token, log.Logger)
+		log.Info().Dur("expire_after", cfg.Quarantine.ExpireAfter).Msg("Upload quarantine API enabled")
+	}
+
+	// Initialize batch commit API and expiry of abandoned batches
+	var batchHandler *handler.BatchHandler
+	if cfg.Batch.Enabled {
+		config := service.DefaultBatchConfig()
+		config.ExpireAfter = cfg.Batch.ExpireAfter
+		config.Interval = cfg.Batch.Interval
+		batchService := service.NewBatchService(
+			repos.Batch,
+			repos.Staged,
+			repos.Object,
+			repos.Blob,
+			repos.Bucket,
+			eventRepo,
+			replicationRepo,
+			repos.TxManager,
+			locker,
+			log.Logger,
+			config,
+		)
+		batchService.SetReadOnlyService(readOnlyService)
+		batchService.Start()
+		defer batchService.Stop()
+		batchHandler = handler.NewBatchHandler(batchService, log.Logger)
+		log.Info().Dur("expire_after", cfg.Batch.ExpireAfter).Msg("Batch commit API enabled")
+	}
+
+	// Initialize bucket snapshot API and expiry of snapshots past their retention
+	var snapshotHandler *handler.SnapshotHandler
+	if cfg.Snapshots.Enabled {
+		config := service.DefaultSnapshotConfig()
+		config.DefaultRetention = cfg.Snapshots.DefaultRetention
+		config.Interval = cfg.Snapshots.Interval
+		snapshotService := service.NewSnapshotService(
+			repos.Snapshot,
+			repos.Bucket,
+			repos.TxManager,
+			storageBackend,
+			locker,
+			log.Logger,
+			config,
+		)
+		snapshotService.Start()
+		defer snapshotService.Stop()
+		snapshotHandler = handler.NewSnapshotHandler(snapshotService, log.Logger)
+		log.Info().Dur("default_retention", cfg.Snapshots.DefaultRetention).Msg("Bucket snapshot API enabled")
+	}
+
+	// Initialize bucket inventory configuration API and scheduled reports
+	var inventoryHandler *handler.InventoryHandler
+	if cfg.Inventory.Enabled {
+		config := service.DefaultInventoryConfig()
+		config.Interval = cfg.Inventory.Interval
+		config.MaxFileRows = cfg.Inventory.MaxFileRows
+		config.TempDir = cfg.Storage.TempDir
+		inventoryService := service.NewInventoryService(repos.Inventory, repos.Bucket, objectService, locker, log.Logger, config)
+		inventoryService.Start()
+		defer inventoryService.Stop()
+		inventoryHandler = handler.NewInventoryHandler(inventoryService, log.Logger)
+		log.Info().Dur("interval", cfg.Inventory.Interval).Msg("Bucket inventory reports enabled")
+	}
+
+	// Initialize bucket website configuration API and website endpoints
+	var websiteHandler *handler.WebsiteHandler
+	if cfg.Website.Enabled {
+		websiteService := service.NewWebsiteService(repos.Website, repos.Bucket, objectService, log.Logger)
+		websiteHandler = handler.NewWebsiteHandler(websiteService, cfg.Website.HostSuffix, log.Logger)
+		log.Info().
+			Int("port", cfg.Website.Port).
+			Str("host_suffix", cfg.Website.HostSuffix).
+			Msg("Bucket website hosting enabled")
+	}
+
+	// Initialize usage metering
+	var usageHandler *handler.UsageHandler
+	var metering *middleware.Metering
+	if cfg.Metering.Enabled {
+		meteringService := service.NewMeteringService(repos.Usage, repos.TxManager, log.Logger, service.MeteringConfig{
+			FlushInterval: cfg.Metering.FlushInterval,
+		})
+		meteringService.Start()
+		defer meteringService.Stop()
+		metering = middleware.NewMetering(meteringService)
+		usageHandler = handler.NewUsageHandler(meteringService, userService, bucketService, log.Logger)
+	}
+
+	// Initialize object change events (watch API)
+	var eventHandler *handler.EventHandler
+	if cfg.Events.Enabled {
+		eventService := service.NewEventService(repos.Event, repos.Bucket, log.Logger, service.EventConfig{
+			Retention:   cfg.Events.Retention,
+			SettleDelay: cfg.Events.SettleDelay,
+		})
+		eventService.Start()
+		defer eventService.Stop()
+		eventHandler = handler.NewEventHandler(eventService, cfg.Events.PollInterval, cfg.Events.HeartbeatInterval, log.Logger)
+		log.Info().Dur("retention", cfg.Events.Retention).Msg("Object change events enabled")
+	}
+
+	// Initialize admin REST API
+	var adminHandler *handler.AdminHandler
+	if cfg.AdminAPI.Enabled {
+		adminHandler = handler.NewAdminHandler(handler.AdminHandlerConfig{
+			UserService:      userService,
+			IAMService:       iamService,
+			BucketService:    bucketService,
+			GC:               gc,
+			Events:           eventHandler,
+			ReadOnly:         readOnlyService,
+			Tenants:          tenantService,
+			BlobStats:        repos.Blob,
+			Token:            cfg.AdminAPI.Token,
+			AllowClientCerts: cfg.AdminAPI.Port != 0 && cfg.AdminAPI.TLS.Enabled && cfg.AdminAPI.TLS.ClientCAFile != "",
+			Logger:           log.Logger,
+		})
+		log.Info().Int("port", cfg.AdminAPI.Port).Msg("Admin API enabled")
+	}
+
+	// Initialize bucket replication
+	var replicationHandler *handler.ReplicationHandler
+	if cfg.Replication.Enabled {
+		replicationService := service.NewReplicationService(repos.Replication, repos.Object, repos.Bucket, storageBackend, encryptor, m, log.Logger, service.ReplicationConfig{
+			Interval:    cfg.Replication.Interval,
+			BatchSize:   cfg.Replication.BatchSize,
+			Concurrency: cfg.Replication.Concurrency,
+			Lease:       cfg.Replication.Lease,
+			MaxAttempts: cfg.Replication.MaxAttempts,
+			MinBackoff:  cfg.Replication.MinBackoff,
+			MaxBackoff:  cfg.Replication.MaxBackoff,
+		})
+		replicationService.Start()
+		defer replicationService.Stop()
+		replicationHandler = handler.NewReplicationHandler(replicationService, log.Logger)
+		log.Info().Int("concurrency", cfg.Replication.Concurrency).Msg("Bucket replication enabled")
+	}
+
+	// Initialize audit log
+	var audit *middleware.Audit
+	var auditService *service.AuditService
+	if cfg.Audit.Enabled {
+		auditService, err = newAuditService(cfg.Audit, repos)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to initialize audit log")
+		}
+		auditService.Start()
+		defer auditService.Stop()
+		audit = middleware.NewAudit(auditService)
+	}
+
+	// Initialize self-service access key API
+	var keysHandler *handler.KeysHandler
+	if cfg.Auth.SelfServiceKeys {
+		keysHandler = handler.NewKeysHandler(iamService, auditService, log.Logger)
+	}
+
+	// Initialize access log
+	var accessLog *middleware.AccessLog
+	var accessLogFile *logfile.File
+	if cfg.AccessLog.Enabled {
+		accessLogger, file, err := newAccessLogger(cfg.AccessLog)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to initialize access log")
+		}
+		if file != nil {
+			accessLogFile = file
+			defer accessLogFile.Close()
+		}
+		accessLog = middleware.NewAccessLog(accessLogger)
+	}
+
+	// Limit request body sizes
+	bodyLimit := middleware.NewBodyLimit(middleware.BodyLimitConfig{
+		MaxObjectSize: cfg.Server.MaxBodySize,
+		MaxAPISize:    cfg.Server.MaxAPIBodySize,
+	}, log.Logger)
+
+	// Limit open connections and concurrent requests per connection
+	var connLimiter *middleware.ConnLimiter
+	if cfg.Server.MaxConnections > 0 || cfg.Server.MaxRequestsPerConnection > 0 {
+		connLimiter = middleware.NewConnLimiter(middleware.ConnLimiterConfig{
+			MaxConnections:           cfg.Server.MaxConnections,
+			MaxRequestsPerConnection: cfg.Server.MaxRequestsPerConnection,
+		}, m, log.Logger)
+	}
+
+	// Shed requests over the concurrency limits
+	var loadShedder *middleware.LoadShedder
+	if cfg.Server.MaxConcurrentReads > 0 || cfg.Server.MaxConcurrentWrites > 0 {
+		loadShedder = middleware.NewLoadShedder(middleware.LoadShedderConfig{
+			MaxReads:     cfg.Server.MaxConcurrentReads,
+			MaxWrites:    cfg.Server.MaxConcurrentWrites,
+			MaxQueued:    cfg.Server.MaxQueuedRequests,
+			QueueTimeout: cfg.Server.QueueTimeout,
+			Skip:         loadShedExempt,
+		}, m, log.Logger)
+		log.Info().
+			Int("max_reads", cfg.Server.MaxConcurrentReads).
+			Int("max_writes", cfg.Server.MaxConcurrentWrites).
+			Int("max_queued", cfg.Server.MaxQueuedRequests).
+			Msg("Load shedding enabled")
+	}
+
+	// The admin API shares the S3 port unless it has its own
+	var s3AdminHandler *handler.AdminHandler
+	if cfg.AdminAPI.Port == 0 {
+		s3AdminHandler = adminHandler
+	}
+
 	// Initialize router
 	router := handler.NewRouter(handler.RouterConfig{
-		BucketHandler:    bucketHandler,
-		ObjectHandler:    objectHandler,
-		MultipartHandler: multipartHandler,
-		HealthChecker:    healthChecker,
-		AuthMiddleware:   authMiddleware,
-		RateLimiter:      rateLimiter,
-		Tracing:          tracing,
-		Metrics:          m,
-		Logger:           log.Logger,
+		BucketHandler:      bucketHandler,
+		ObjectHandler:      objectHandler,
+		MultipartHandler:   multipartHandler,
+		EventHandler:       eventHandler,
+		ReplicationHandler: replicationHandler,
+		InventoryHandler:   inventoryHandler,
+		WebsiteHandler:     websiteHandler,
+		LifecycleHandler:   handler.NewLifecycleHandler(lifecycleService, log.Logger),
+		WebsiteHosts:       cfg.Website.Port == 0,
+		HealthChecker:      healthChecker,
+		Capabilities:       capabilitiesHandler,
+		ManifestHandler:    manifestHandler,
+		ManifestLimiter:    manifestLimiter,
+		QuarantineHandler:  quarantineHandler,
+		BatchHandler:       batchHandler,
+		SnapshotHandler:    snapshotHandler,
+		KeysHandler:        keysHandler,
+		UsageHandler:       usageHandler,
+		AdminHandler:       s3AdminHandler,
+		Metering:           metering,
+		Audit:              audit,
+		AccessLog:          accessLog,
+		AuthMiddleware:     authMiddleware,
+		BodyLimit:          bodyLimit,
+		RateLimiter:        rateLimiter,
+		TenantRateLimiter:  tenantLimiter,
+		ConnLimiter:        connLimiter,
+		LoadShedder:        loadShedder,
+		Recovery:           recovery,
+		Tracing:            tracing,
+		Metrics:            m,
+		Logger:             log.Logger,
 	})
 
 	// Create HTTP server
 	server := &http.Server{
-		Addr:         fmt.Sprintf(":%d", cfg.Server.Port),
-		Handler:      router.Handler(),
-		ReadTimeout:  cfg.Server.ReadTimeout,
-		WriteTimeout: cfg.Server.WriteTimeout,
-		IdleTimeout:  cfg.Server.IdleTimeout,
+		Addr:              fmt.Sprintf(":%d", cfg.Server.Port),
+		Handler:           router.Handler(),
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+		MaxHeaderBytes:    cfg.Server.MaxHeaderBytes,
+		Protocols:         new(http.Protocols),
+		HTTP2: &http.HTTP2Config{
+			MaxConcurrentStreams: cfg.Server.HTTP2.MaxConcurrentStreams,
+			MaxReadFrameSize:     cfg.Server.HTTP2.MaxReadFrameSize,
+		},
+	}
+	server.Protocols.SetHTTP1(true)
+	server.Protocols.SetHTTP2(cfg.Server.HTTP2.Enabled)
+	server.Protocols.SetUnencryptedHTTP2(cfg.Server.HTTP2.Enabled && cfg.Server.HTTP2.Cleartext)
+	server.SetKeepAlivesEnabled(cfg.Server.KeepAlive)
+	if connLimiter != nil {
+		server.ConnContext = connLimiter.ConnContext
+	}
+
+	// Serve HTTPS with certificates from files or ACME
+	var certManager *certs.Manager
+	if cfg.Server.TLS.Enabled {
+		certManager, err = newCertManager(cfg.Server.TLS)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to initialize TLS")
+		}
+		server.TLSConfig = certManager.TLSConfig()
+
+		certCtx, stopCerts := context.WithCancel(ctx)
+		defer stopCerts()
+		go certManager.Run(certCtx)
+	}
+
+	// Serve the admin API on its own port, optionally with mTLS
+	var adminServer *http.Server
+	var adminCertManager *certs.Manager
+	if adminHandler != nil && cfg.AdminAPI.Port != 0 {
+		adminServer = &http.Server{
+			Addr:              fmt.Sprintf(":%d", cfg.AdminAPI.Port),
+			Handler:           tracing.Middleware(recovery.Middleware(adminHandler)),
+			ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+			IdleTimeout:       cfg.Server.IdleTimeout,
+		}
+		if cfg.AdminAPI.TLS.Enabled {
+			adminCertManager, err = newCertManager(cfg.AdminAPI.TLS)
+			if err != nil {
+				log.Fatal().Err(err).Msg("Failed to initialize admin API TLS")
+			}
+			adminServer.TLSConfig = adminCertManager.TLSConfig()
+		}
+		go func() {
+			log.Info().
+				Int("port", cfg.AdminAPI.Port).
+				Bool("tls", adminCertManager != nil).
+				Msg("Admin API listening")
+			var err error
+			if adminCertManager != nil {
+				err = adminServer.ListenAndServeTLS("", "")
+			} else {
+				err = adminServer.ListenAndServe()
+			}
+			if err != nil && err != http.ErrServerClosed {
+				log.Fatal().Err(err).Msg("Admin API server failed")
+			}
+		}()
+	}
+
+	// Serve websites on their own port
+	var websiteServer *http.Server
+	if websiteHandler != nil && cfg.Website.Port != 0 {
+		websiteServer = &http.Server{
+			Addr:              fmt.Sprintf(":%d", cfg.Website.Port),
+			Handler:           tracing.Middleware(recovery.Middleware(websiteHandler)),
+			ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+			WriteTimeout:      cfg.Server.WriteTimeout,
+			IdleTimeout:       cfg.Server.IdleTimeout,
+		}
+		go func() {
+			log.Info().Int("port", cfg.Website.Port).Msg("Website endpoint listening")
+			if err := websiteServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Fatal().Err(err).Msg("Website server failed")
+			}
+		}()
 	}
 
 	// Start metrics server if enabled
@@ -296,22 +1069,82 @@ func main() {
 		}()
 	}
 
+	// Warm database caches before accepting requests
+	if cfg.Warmup.Enabled {
+		warmupConfig := service.WarmupConfig{
+			Lookback:      cfg.Warmup.Lookback,
+			MaxBuckets:    cfg.Warmup.MaxBuckets,
+			MaxAccessKeys: cfg.Warmup.MaxAccessKeys,
+			KeysPerBucket: cfg.Warmup.KeysPerBucket,
+			Timeout:       cfg.Warmup.Timeout,
+		}
+		if cfg.ObjectCache.Enabled {
+			warmupConfig.CachedKeysPerBucket = cfg.Warmup.CachedKeysPerBucket
+		}
+		warmupService := service.NewWarmupService(repos.Usage, repos.Bucket, repos.Object, repos.AccessKey, log.Logger, warmupConfig)
+		if _, err := warmupService.Run(ctx); err != nil {
+			log.Warn().Err(err).Msg("Warmup failed, starting with cold caches")
+		}
+	}
+
 	// Start server in goroutine
 	go func() {
 		log.Info().
 			Int("port", cfg.Server.Port).
 			Str("region", cfg.Auth.Region).
+			Bool("tls", certManager != nil).
 			Msg("Server listening")
 
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		ln, err := net.Listen("tcp", server.Addr)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Server failed")
+		}
+		if connLimiter != nil {
+			ln = connLimiter.Listener(ln)
+		}
+
+		if certManager != nil {
+			// Certificates come from TLSConfig
+			err = server.ServeTLS(ln, "", "")
+		} else {
+			err = server.Serve(ln)
+		}
+		if err != nil && err != http.ErrServerClosed {
 			log.Fatal().Err(err).Msg("Server failed")
 		}
 	}()
 
-	// Wait for shutdown signal
+	// Wait for shutdown signal; SIGHUP reloads the configuration, reopens
+	// the log files and reloads the TLS certificates
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-	<-sigChan
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	for sig := <-sigChan; sig == syscall.SIGHUP; sig = <-sigChan {
+		reloadConfig(load, rateLimiter, gc, lifecycleService)
+		if logFile != nil {
+			if err := logFile.Reopen(); err != nil {
+				log.Error().Err(err).Msg("Failed to reopen log file")
+			}
+		}
+		if accessLogFile != nil {
+			if err := accessLogFile.Reopen(); err != nil {
+				log.Error().Err(err).Msg("Failed to reopen access log")
+			}
+		}
+		if certManager != nil {
+			if err := certManager.Reload(); err != nil {
+				log.Error().Err(err).Msg("Failed to reload TLS certificates, keeping the current ones")
+			} else {
+				log.Info().Msg("TLS certificates reloaded")
+			}
+		}
+		if adminCertManager != nil {
+			if err := adminCertManager.Reload(); err != nil {
+				log.Error().Err(err).Msg("Failed to reload admin API TLS certificates, keeping the current ones")
+			} else {
+				log.Info().Msg("Admin API TLS certificates reloaded")
+			}
+		}
+	}
 
 	log.Info().Msg("Shutting down server...")
 
@@ -326,19 +1159,428 @@ func main() {
 		}
 	}
 
+	if adminServer != nil {
+		if err := adminServer.Shutdown(shutdownCtx); err != nil {
+			log.Error().Err(err).Msg("Admin API server shutdown error")
+		}
+	}
+
+	if websiteServer != nil {
+		if err := websiteServer.Shutdown(shutdownCtx); err != nil {
+			log.Error().Err(err).Msg("Website server shutdown error")
+		}
+	}
+
 	if err := server.Shutdown(shutdownCtx); err != nil {
 		log.Error().Err(err).Msg("Server shutdown error")
 	}
 
+	// Export the spans of the last requests
+	if tracer != nil {
+		if err := tracer.Shutdown(shutdownCtx); err != nil {
+			log.Error().Err(err).Msg("Tracing shutdown error")
+		}
+	}
+
 	log.Info().Msg("Server stopped")
 }
 
 // initStorageBackend initializes the storage backend based on configuration.
-func initStorageBackend(cfg *config.Config, logger zerolog.Logger) (storage.Backend, error) {
+// Blobs of buckets with a residency tag are routed to that tag's directories.
+// The erasure-coded storage is also returned when it is the default backend,
+// for scrubbing.
+func initStorageBackend(cfg *config.Config, logger zerolog.Logger) (storage.Backend, *erasure.Storage, error) {
 	// For now, we only support filesystem backend
 	// TODO: Add support for other backends (S3, Azure Blob, etc.)
-	return filesystem.NewStorage(filesystem.Config{
-		DataDir: cfg.Storage.DataDir,
-		TempDir: cfg.Storage.TempDir,
-	}, logger)
+	newFilesystem := func(dataDir, tempDir string) (*filesystem.Storage, error) {
+		return filesystem.NewStorage(filesystem.Config{
+			DataDir: dataDir,
+			TempDir: tempDir,
+			Permissions: filesystem.PermissionPolicy{
+				Enforce: cfg.Storage.Permissions.Enforce,
+				AutoFix: cfg.Storage.Permissions.AutoFix,
+			},
+			Sync:         filesystem.SyncPolicy(cfg.Storage.Sync),
+			SyncInterval: cfg.Storage.SyncInterval,
+			TempMaxAge:   cfg.Storage.TempMaxAge,
+			ReadAhead: storage.ReadAheadConfig{
+				Threshold:   cfg.Storage.ReadAhead.Threshold,
+				ChunkSize:   cfg.Storage.ReadAhead.ChunkSize,
+				Concurrency: cfg.Storage.ReadAhead.Concurrency,
+			},
+		}, logger)
+	}
+
+	var defaultBackend storage.Backend
+	var erasureStorage *erasure.Storage
+	var err error
+	if cfg.Storage.Backend == "erasure" {
+		erasureStorage, err = erasure.NewStorage(erasure.Config{
+			DataDirs:     cfg.Storage.Erasure.DataDirs,
+			TempDir:      cfg.Storage.TempDir,
+			DataShards:   cfg.Storage.Erasure.DataShards,
+			ParityShards: cfg.Storage.Erasure.ParityShards,
+			BlockSize:    cfg.Storage.Erasure.BlockSize,
+		}, logger)
+		defaultBackend = erasureStorage
+	} else {
+		defaultBackend, err = newFilesystem(cfg.Storage.DataDir, cfg.Storage.TempDir)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	backends := make(map[string]storage.Backend, len(cfg.Storage.Residency))
+	for tag, rc := range cfg.Storage.Residency {
+		backend, err := newFilesystem(rc.DataDir, rc.TempDir)
+		if err != nil {
+			return nil, nil, fmt.Errorf("residency %q: %w", tag, err)
+		}
+		backends[tag] = backend
+	}
+
+	// Always route, so tagged buckets are refused rather than written to the
+	// default backend when their residency is not configured on this node
+	return storage.NewResidencyRouter(defaultBackend, backends, logger), erasureStorage, nil
+}
+
+// gcConfig returns the garbage collector settings of a configuration.
+func gcConfig(cfg config.GCConfig) service.GCConfig {
+	return service.GCConfig{
+		Enabled:          cfg.Enabled,
+		Interval:         cfg.Interval,
+		GracePeriod:      cfg.GracePeriod,
+		BatchSize:        cfg.BatchSize,
+		Workers:          cfg.Workers,
+		ProgressInterval: cfg.ProgressInterval,
+		DryRun:           cfg.DryRun,
+		DeleteRetries:    cfg.DeleteRetries,
+		RetryBackoff:     cfg.RetryBackoff,
+	}
+}
+
+// lifecycleConfig returns the lifecycle scheduler settings of a
+// configuration.
+func lifecycleConfig(cfg config.LifecycleConfig) service.LifecycleConfig {
+	return service.LifecycleConfig{
+		Enabled:   cfg.Enabled,
+		Interval:  cfg.Interval,
+		BatchSize: cfg.BatchSize,
+	}
+}
+
+// pgxPoolStats returns the statistics of a PostgreSQL connection pool.
+func pgxPoolStats(pool *pgxpool.Pool) func() metrics.DBPoolStats {
+	return func() metrics.DBPoolStats {
+		stat := pool.Stat()
+		return metrics.DBPoolStats{
+			MaxOpen:      int(stat.MaxConns()),
+			InUse:        int(stat.AcquiredConns()),
+			Idle:         int(stat.IdleConns()),
+			WaitCount:    stat.EmptyAcquireCount(),
+			WaitDuration: stat.EmptyAcquireWaitTime(),
+		}
+	}
+}
+
+// sqlPoolStats returns the statistics of a database/sql connection pool.
+func sqlPoolStats(db *sql.DB) func() metrics.DBPoolStats {
+	return func() metrics.DBPoolStats {
+		stats := db.Stats()
+		return metrics.DBPoolStats{
+			MaxOpen:      stats.MaxOpenConnections,
+			InUse:        stats.InUse,
+			Idle:         stats.Idle,
+			WaitCount:    stats.WaitCount,
+			WaitDuration: stats.WaitDuration,
+		}
+	}
+}
+
+// reloadConfig re-reads the configuration file and applies the settings
+// that can change without interrupting requests: the log level, the rate
+// limits and the garbage collector and lifecycle scheduler settings. Other changes take effect on
+// the next restart. An invalid file leaves the running settings unchanged.
+func reloadConfig(load func() (*config.Config, error), rateLimiter *middleware.RateLimiter, gc *service.GarbageCollector, lifecycle *service.LifecycleService) {
+	cfg, err := load()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to reload configuration, keeping the current one")
+		return
+	}
+	redact.AddSecrets(cfg.Secrets()...)
+
+	level, err := zerolog.ParseLevel(cfg.Logging.Level)
+	if err != nil {
+		level = zerolog.InfoLevel
+	}
+	zerolog.SetGlobalLevel(level)
+
+	rateLimiter.SetLimits(cfg.RateLimit.RequestsPerSecond, cfg.RateLimit.BurstSize, cfg.RateLimit.Enabled)
+
+	if gc != nil {
+		gc.Reconfigure(gcConfig(cfg.GC))
+	}
+	lifecycle.Reconfigure(lifecycleConfig(cfg.Lifecycle))
+
+	log.Info().
+		Str("log_level", level.String()).
+		Bool("rate_limit", cfg.RateLimit.Enabled).
+		Float64("requests_per_second", cfg.RateLimit.RequestsPerSecond).
+		Int("burst_size", cfg.RateLimit.BurstSize).
+		Dur("gc_interval", cfg.GC.Interval).
+		Int("gc_batch_size", cfg.GC.BatchSize).
+		Dur("lifecycle_interval", cfg.Lifecycle.Interval).
+		Int("lifecycle_batch_size", cfg.Lifecycle.BatchSize).
+		Msg("Configuration reloaded")
+}
+
+// startCluster joins the cluster, serves the internal blob API over local
+// and starts the rebalancer. Returns the backend placing blobs across the
+// cluster and a function that leaves the cluster.
+func startCluster(ctx context.Context, cfg *config.Config, repos *repository.Repositories, local storage.Backend, m *metrics.Metrics) (storage.Backend, func(), error) {
+	address := cfg.Cluster.AdvertiseAddress(cfg.Server.Host)
+
+	server, err := cluster.NewServer(cluster.ServerConfig{
+		NodeID:            cfg.Cluster.NodeID,
+		Address:           address,
+		Role:              cluster.NodeRole(cfg.Cluster.NodeRole),
+		Secret:            cfg.Cluster.Secret,
+		HeartbeatInterval: cfg.Cluster.HeartbeatInterval,
+		HeartbeatTimeout:  cfg.Cluster.HeartbeatTimeout,
+	}, local, log.Logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := server.Start(); err != nil {
+		return nil, nil, err
+	}
+
+	// The internal API must be up before joining, since peers start
+	// placing blobs here as soon as this node is on the ring
+	internalServer := &http.Server{
+		Addr:        fmt.Sprintf(":%d", cfg.Cluster.GRPCPort),
+		Handler:     server.Handler(),
+		IdleTimeout: cfg.Server.IdleTimeout,
+	}
+	go func() {
+		log.Info().
+			Int("port", cfg.Cluster.GRPCPort).
+			Str("address", address).
+			Msg("Cluster API listening")
+		if err := internalServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal().Err(err).Msg("Cluster API failed")
+		}
+	}()
+
+	membership := cluster.NewMembership(repos.ClusterNode, cluster.MembershipConfig{
+		NodeID:            cfg.Cluster.NodeID,
+		Address:           address,
+		HeartbeatInterval: cfg.Cluster.HeartbeatInterval,
+		HeartbeatTimeout:  cfg.Cluster.HeartbeatTimeout,
+		VirtualNodes:      cfg.Cluster.VirtualNodes,
+	}, log.Logger)
+	if err := membership.Start(ctx); err != nil {
+		return nil, nil, fmt.Errorf("failed to join cluster: %w", err)
+	}
+
+	clientConfig := cluster.DefaultClientConfig()
+	clientConfig.Secret = cfg.Cluster.Secret
+	clients := cluster.NewClientPoolWithConfig(clientConfig, log.Logger)
+
+	placement := cluster.NewPlacementBackend(local, membership, clients, cfg.Cluster.ReplicationFactor, log.Logger)
+	rebalancer := cluster.NewRebalancer(repos.Blob, placement, m, log.Logger, cluster.RebalancerConfig{
+		Interval: cfg.Cluster.RebalanceInterval,
+	})
+	rebalancer.Start()
+
+	stop := func() {
+		rebalancer.Stop()
+		membership.Stop()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := internalServer.Shutdown(shutdownCtx); err != nil {
+			log.Error().Err(err).Msg("Cluster API shutdown error")
+		}
+		_ = clients.Close()
+		_ = server.Stop()
+	}
+	return placement, stop, nil
+}
+
+// loadShedExempt reports whether a request bypasses the load shedder:
+// health checks, which must answer under load, and bucket watch streams,
+// which would hold a slot for as long as they are open.
+func loadShedExempt(r *http.Request) bool {
+	switch r.URL.Path {
+	case "/health", "/livez", "/readyz", "/healthz":
+		return true
+	}
+	return r.URL.Query().Has("watch")
+}
+
+// newLogger creates the application logger, which scrubs secrets from all
+// output and tags the events logged for a request with its ID. The log
+// file, if any, is returned so it can be reopened and closed.
+func newLogger(cfg config.LoggingConfig) (zerolog.Logger, *logfile.File, error) {
+	var out io.Writer
+	var file *logfile.File
+	switch cfg.Output {
+	case "stderr":
+		out = os.Stderr
+	case "file":
+		var err error
+		file, err = logfile.Open(logfile.Config{
+			Path:       cfg.File,
+			MaxSize:    int64(cfg.MaxSizeMB) << 20,
+			MaxAge:     cfg.MaxAge,
+			MaxBackups: cfg.MaxBackups,
+		})
+		if err != nil {
+			return zerolog.Logger{}, nil, err
+		}
+		out = file
+	default:
+		out = os.Stdout
+	}
+
+	zerolog.TimeFieldFormat = cfg.TimeFormat
+	if cfg.Format == "console" {
+		out = zerolog.ConsoleWriter{Out: out, TimeFormat: cfg.TimeFormat, NoColor: file != nil}
+	}
+
+	logger := zerolog.New(redact.Writer(out)).With().Timestamp().Logger().
+		Hook(middleware.RequestLogHook{})
+	if sampler := logSampler(cfg.Sampling); sampler != nil {
+		logger = logger.Sample(sampler)
+	}
+	return logger, file, nil
+}
+
+// logSampler returns the sampler of the log levels, or nil if sampling is
+// disabled.
+func logSampler(cfg config.LogSamplingConfig) zerolog.Sampler {
+	if !cfg.Enabled {
+		return nil
+	}
+	level := func(ls config.LogLevelSamplingConfig) zerolog.Sampler {
+		if ls.Every <= 1 {
+			return nil
+		}
+		every := &zerolog.BasicSampler{N: uint32(ls.Every)}
+		if ls.Burst == 0 {
+			return every
+		}
+		return &zerolog.BurstSampler{Burst: uint32(ls.Burst), Period: cfg.Period, NextSampler: every}
+	}
+	return zerolog.LevelSampler{
+		TraceSampler: level(cfg.Trace),
+		DebugSampler: level(cfg.Debug),
+		InfoSampler:  level(cfg.Info),
+	}
+}
+
+// newAccessLogger creates the logger of the access log. The log file, if
+// any, is returned so it can be reopened and closed.
+func newAccessLogger(cfg config.AccessLogConfig) (zerolog.Logger, *logfile.File, error) {
+	var out io.Writer
+	var file *logfile.File
+	switch cfg.Output {
+	case "log":
+		log.Info().Msg("Access log enabled, writing to the application log")
+		return log.Logger.With().Str("component", "access").Logger(), nil, nil
+	case "stderr":
+		out = os.Stderr
+	case "file":
+		var err error
+		file, err = logfile.Open(logfile.Config{
+			Path:       cfg.File,
+			MaxSize:    int64(cfg.MaxSizeMB) << 20,
+			MaxBackups: cfg.MaxBackups,
+		})
+		if err != nil {
+			return zerolog.Logger{}, nil, err
+		}
+		out = file
+	default:
+		out = os.Stdout
+	}
+
+	log.Info().Str("output", cfg.Output).Str("file", cfg.File).Msg("Access log enabled")
+	return zerolog.New(redact.Writer(out)).With().Timestamp().Logger(), file, nil
+}
+
+// newAuditService creates the audit log with the configured file, syslog
+// and webhook sinks.
+func newAuditService(cfg config.AuditConfig, repos *repository.Repositories) (*service.AuditService, error) {
+	var sinks []service.AuditSink
+	if cfg.File != "" {
+		sink, err := service.NewFileAuditSink(cfg.File)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, service.NewFilteredAuditSink(sink, cfg.FileFilter.Severity(), cfg.FileFilter.CategoryList()))
+	}
+	if cfg.Syslog.Enabled {
+		facility, err := syslog.ParseFacility(cfg.Syslog.Facility)
+		if err != nil {
+			return nil, err
+		}
+		sink, err := service.NewSyslogAuditSink(syslog.Config{
+			Network:  cfg.Syslog.Network,
+			Address:  cfg.Syslog.Address,
+			Facility: facility,
+		})
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, service.NewFilteredAuditSink(sink, cfg.Syslog.Severity(), cfg.Syslog.CategoryList()))
+	}
+	if cfg.Webhook.Enabled {
+		sink := service.NewWebhookAuditSink(cfg.Webhook.URL, cfg.Webhook.Secret, cfg.Webhook.Timeout)
+		sinks = append(sinks, service.NewFilteredAuditSink(sink, cfg.Webhook.Severity(), cfg.Webhook.CategoryList()))
+	}
+
+	log.Info().
+		Str("file", cfg.File).
+		Bool("syslog", cfg.Syslog.Enabled).
+		Bool("webhook", cfg.Webhook.Enabled).
+		Msg("Audit log enabled")
+
+	return service.NewAuditService(repos.Audit, repos.TxManager, sinks, log.Logger, service.AuditConfig{
+		FlushInterval: cfg.FlushInterval,
+		Retention:     cfg.Retention.ByCategory(),
+		PruneInterval: cfg.Retention.PruneInterval,
+	}), nil
+}
+
+// newCertManager loads the TLS certificates of the S3 API listener.
+func newCertManager(cfg config.TLSConfig) (*certs.Manager, error) {
+	minVersion, err := certs.ParseVersion(cfg.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+	clientAuth, err := certs.ParseClientAuth(cfg.ClientAuth)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := certs.Options{
+		CertFile:     cfg.CertFile,
+		KeyFile:      cfg.KeyFile,
+		MinVersion:   minVersion,
+		ClientCAFile: cfg.ClientCAFile,
+		ClientAuth:   clientAuth,
+		Logger:       log.Logger,
+	}
+	if cfg.ACME.Enabled {
+		opts.ACME = &certs.ACMEOptions{
+			Domains:      cfg.ACME.Domains,
+			Email:        cfg.ACME.Email,
+			CacheDir:     cfg.ACME.CacheDir,
+			DirectoryURL: cfg.ACME.DirectoryURL,
+			RenewBefore:  cfg.ACME.RenewBefore,
+		}
+	}
+	return certs.New(opts)
 }
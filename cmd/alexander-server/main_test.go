@@ -0,0 +1,40 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/config"
+	"github.com/prn-tf/alexander-storage/internal/lock"
+	"github.com/prn-tf/alexander-storage/internal/middleware"
+	"github.com/prn-tf/alexander-storage/internal/service"
+)
+
+func TestReloadConfig(t *testing.T) {
+	saved := zerolog.GlobalLevel()
+	t.Cleanup(func() { zerolog.SetGlobalLevel(saved) })
+	zerolog.SetGlobalLevel(zerolog.InfoLevel)
+
+	cfg, err := loadConfig("", true, t.TempDir())
+	require.NoError(t, err)
+	assert.False(t, cfg.Lifecycle.Enabled, "stored lifecycle rules are only applied after opting in")
+	rateLimiter := middleware.NewRateLimiter(middleware.RateLimiterConfig{RequestsPerSecond: 10, BurstSize: 10, CleanupInterval: time.Minute}, nil, zerolog.Nop())
+	t.Cleanup(rateLimiter.Stop)
+	lifecycle := service.NewLifecycleService(nil, nil, nil, nil, nil, nil, lock.NewNoOpLocker(), nil, zerolog.Nop(), lifecycleConfig(cfg.Lifecycle))
+
+	// An invalid file keeps the running settings
+	reloadConfig(func() (*config.Config, error) { return nil, errors.New("invalid") }, rateLimiter, nil, lifecycle)
+	assert.Equal(t, zerolog.InfoLevel, zerolog.GlobalLevel())
+
+	cfg.Logging.Level = "warn"
+	cfg.Lifecycle.Interval = 10 * time.Minute
+	cfg.Lifecycle.BatchSize = 50
+	reloadConfig(func() (*config.Config, error) { return cfg, nil }, rateLimiter, nil, lifecycle)
+	assert.Equal(t, zerolog.WarnLevel, zerolog.GlobalLevel())
+	assert.Equal(t, service.LifecycleConfig{Enabled: cfg.Lifecycle.Enabled, Interval: 10 * time.Minute, BatchSize: 50}, lifecycleConfig(cfg.Lifecycle))
+}
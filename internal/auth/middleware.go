@@ -3,11 +3,14 @@ package auth
 
 import (
 	"context"
+	"encoding/xml"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/rs/zerolog/log"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
 )
 
 // AccessKeyStore defines the interface for retrieving access keys.
@@ -27,6 +30,13 @@ type BucketACLChecker interface {
 	GetBucketACL(ctx context.Context, bucketName string) (string, error)
 }
 
+// ObjectACLChecker defines the interface for checking object ACL permissions.
+type ObjectACLChecker interface {
+	// GetObjectACL returns the ACL of an object version, or of the latest
+	// version if versionID is empty. Returns empty string if not found.
+	GetObjectACL(ctx context.Context, bucketName, key, versionID string) (string, error)
+}
+
 // AccessKeyInfo contains the information needed for signature verification.
 type AccessKeyInfo struct {
 	// AccessKeyID is the public identifier.
@@ -41,11 +51,17 @@ type AccessKeyInfo struct {
 	// Username is the username of the user who owns this key.
 	Username string
 
+	// TenantID is the tenant of the user who owns this key.
+	TenantID int64
+
 	// IsActive indicates if the key is active.
 	IsActive bool
 
 	// ExpiresAt is the optional expiration time.
 	ExpiresAt *time.Time
+
+	// Policy optionally restricts what the key may do. Nil means full access.
+	Policy *domain.AccessKeyPolicy
 }
 
 // Config contains configuration for the auth middleware.
@@ -62,8 +78,16 @@ type Config struct {
 	// SkipPaths are paths that skip authentication.
 	SkipPaths []string
 
+	// SkipPrefixes are path prefixes that skip authentication, for APIs
+	// with their own authentication.
+	SkipPrefixes []string
+
 	// BucketACLChecker checks bucket ACL for anonymous access (optional).
 	BucketACLChecker BucketACLChecker
+
+	// ObjectACLChecker checks object ACL for anonymous reads of objects in
+	// buckets that do not allow them (optional).
+	ObjectACLChecker ObjectACLChecker
 }
 
 // DefaultConfig returns the default auth configuration.
@@ -77,6 +101,17 @@ func DefaultConfig() Config {
 	}
 }
 
+// extractObjectKey extracts the object key from the URL path.
+// S3-style path: /bucket-name/key
+func extractObjectKey(path string) string {
+	path = strings.TrimPrefix(path, "/")
+	parts := strings.SplitN(path, "/", 2)
+	if len(parts) == 2 {
+		return parts[1]
+	}
+	return ""
+}
+
 // extractBucketName extracts the bucket name from the URL path.
 // S3-style path: /bucket-name/key or /bucket-name
 func extractBucketName(path string) string {
@@ -104,6 +139,12 @@ func Middleware(store AccessKeyStore, config Config) func(http.Handler) http.Han
 					return
 				}
 			}
+			for _, prefix := range config.SkipPrefixes {
+				if strings.HasPrefix(r.URL.Path, prefix) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
 
 			// Determine auth type
 			authType := GetAuthType(r)
@@ -134,17 +175,27 @@ func Middleware(store AccessKeyStore, config Config) func(http.Handler) http.Han
 								return
 							}
 						}
+
+						// Objects can be public in private buckets
+						key := extractObjectKey(r.URL.Path)
+						if config.ObjectACLChecker != nil && isReadOperation(r.Method) && key != "" {
+							acl, err := config.ObjectACLChecker.GetObjectACL(r.Context(), bucketName, key, r.URL.Query().Get("versionId"))
+							if err == nil && acl == "public-read" {
+								next.ServeHTTP(w, r)
+								return
+							}
+						}
 					}
 				}
 
-				writeAuthError(w, ErrAccessDenied)
+				writeAuthError(w, r, ErrAccessDenied)
 				return
 
 			case AuthTypeSignedV4:
 				authCtx, err := handleSignedV4(r, store, config)
 				if err != nil {
 					log.Debug().Err(err).Str("path", r.URL.Path).Msg("SignedV4 authentication failed")
-					writeAuthError(w, err)
+					writeAuthError(w, r, err)
 					return
 				}
 				r = r.WithContext(context.WithValue(r.Context(), AuthContextKey, authCtx))
@@ -153,13 +204,13 @@ func Middleware(store AccessKeyStore, config Config) func(http.Handler) http.Han
 				authCtx, err := handlePresignedV4(r, store, config)
 				if err != nil {
 					log.Debug().Err(err).Str("path", r.URL.Path).Msg("PresignedV4 authentication failed")
-					writeAuthError(w, err)
+					writeAuthError(w, r, err)
 					return
 				}
 				r = r.WithContext(context.WithValue(r.Context(), AuthContextKey, authCtx))
 
 			default:
-				writeAuthError(w, ErrInvalidAuthorizationHeader)
+				writeAuthError(w, r, ErrInvalidAuthorizationHeader)
 				return
 			}
 
@@ -206,6 +257,11 @@ func handleSignedV4(r *http.Request, store AccessKeyStore, config Config) (*Auth
 		return nil, err
 	}
 
+	// Enforce scoped credentials
+	if err := checkPolicy(r, keyInfo); err != nil {
+		return nil, err
+	}
+
 	// Update last used timestamp (async, don't block request)
 	go func() {
 		_ = store.UpdateLastUsed(context.Background(), keyInfo.AccessKeyID)
@@ -214,6 +270,7 @@ func handleSignedV4(r *http.Request, store AccessKeyStore, config Config) (*Auth
 	return &AuthContext{
 		UserID:      keyInfo.UserID,
 		Username:    keyInfo.Username,
+		TenantID:    keyInfo.TenantID,
 		AccessKeyID: keyInfo.AccessKeyID,
 		Credential:  signedValues.Credential,
 		AuthType:    AuthTypeSignedV4,
@@ -248,19 +305,20 @@ func handlePresignedV4(r *http.Request, store AccessKeyStore, config Config) (*A
 		return nil, ErrInvalidAccessKeyID
 	}
 
-	// For presigned URLs, we need to reconstruct the canonical request
-	// The signed headers are in query params, and we verify against those
-	payloadHash := GetPayloadHash(r)
+	// Presigned URLs are signed before the body is known, so the canonical
+	// request always ends in UNSIGNED-PAYLOAD
+	if err := VerifySignature(r, keyInfo.SecretKey, *signedValues, UnsignedPayload); err != nil {
+		return nil, err
+	}
 
-	// Build canonical request for presigned URL
-	// Note: For presigned URLs, the query string includes auth params which need special handling
-	if err := VerifySignature(r, keyInfo.SecretKey, *signedValues, payloadHash); err != nil {
+	if err := checkPolicy(r, keyInfo); err != nil {
 		return nil, err
 	}
 
 	return &AuthContext{
 		UserID:      keyInfo.UserID,
 		Username:    keyInfo.Username,
+		TenantID:    keyInfo.TenantID,
 		AccessKeyID: keyInfo.AccessKeyID,
 		Credential:  signedValues.Credential,
 		AuthType:    AuthTypePresignedV4,
@@ -269,21 +327,29 @@ func handlePresignedV4(r *http.Request, store AccessKeyStore, config Config) (*A
 	}, nil
 }
 
-// writeAuthError writes an S3-compatible error response.
-func writeAuthError(w http.ResponseWriter, err error) {
+// writeAuthError writes an S3-compatible error response. The request ID is
+// the one the tracing middleware set on the response, if any.
+func writeAuthError(w http.ResponseWriter, r *http.Request, err error) {
 	authErr := NewAuthError(err)
+	authErr.Resource = r.URL.Path
+	authErr.RequestID = w.Header().Get("x-amz-request-id")
 
 	w.Header().Set("Content-Type", "application/xml")
 	w.WriteHeader(authErr.HTTPStatus)
 
-	// Write S3-style XML error response
-	xmlResponse := `<?xml version="1.0" encoding="UTF-8"?>
-<Error>
-    <Code>` + string(authErr.Code) + `</Code>
-    <Message>` + authErr.Message + `</Message>
-</Error>`
-
-	_, _ = w.Write([]byte(xmlResponse))
+	_, _ = w.Write([]byte(xml.Header))
+	_ = xml.NewEncoder(w).Encode(struct {
+		XMLName   xml.Name `xml:"Error"`
+		Code      string   `xml:"Code"`
+		Message   string   `xml:"Message"`
+		Resource  string   `xml:"Resource,omitempty"`
+		RequestID string   `xml:"RequestId,omitempty"`
+	}{
+		Code:      string(authErr.Code),
+		Message:   authErr.Message,
+		Resource:  authErr.Resource,
+		RequestID: authErr.RequestID,
+	})
 }
 
 // GetAuthContext retrieves the AuthContext from a request context.
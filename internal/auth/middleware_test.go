@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/stretchr/testify/require"
+)
+
+// staticKeyStore holds a single access key.
+type staticKeyStore struct {
+	key AccessKeyInfo
+}
+
+func (s staticKeyStore) GetActiveAccessKey(_ context.Context, accessKeyID string) (*AccessKeyInfo, error) {
+	if accessKeyID != s.key.AccessKeyID {
+		return nil, ErrInvalidAccessKeyID
+	}
+	key := s.key
+	return &key, nil
+}
+
+func (s staticKeyStore) UpdateLastUsed(context.Context, string) error {
+	return nil
+}
+
+// TestMiddleware_AcceptsSDKSignatures checks requests signed by the AWS SDK
+// as they arrive at a server, which moves the signed Host header to r.Host.
+func TestMiddleware_AcceptsSDKSignatures(t *testing.T) {
+	store := staticKeyStore{key: AccessKeyInfo{AccessKeyID: "AKID", SecretKey: "secret", UserID: 1, IsActive: true}}
+	server := httptest.NewServer(Middleware(store, Config{Region: "us-east-1", Service: "s3"})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.Copy(io.Discard, r.Body)
+			w.WriteHeader(http.StatusNoContent)
+		})))
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	creds := aws.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}
+	signer := v4.NewSigner(func(o *v4.SignerOptions) { o.DisableURIPathEscaping = true })
+
+	send := func(r *http.Request) int {
+		resp, err := http.DefaultClient.Do(r)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// Header signature of a request with a body
+	body := "hello"
+	sum := sha256.Sum256([]byte(body))
+	payloadHash := hex.EncodeToString(sum[:])
+	r, err := http.NewRequest(http.MethodPut, server.URL+"/photos/my%20file.txt?uploadId=u1&partNumber=1", strings.NewReader(body))
+	require.NoError(t, err)
+	r.Header.Set(XAmzContentSHA256Header, payloadHash)
+	require.NoError(t, signer.SignHTTP(ctx, creds, r, payloadHash, "s3", "us-east-1", time.Now()))
+	require.Equal(t, http.StatusNoContent, send(r))
+
+	// A tampered request is still rejected
+	r, err = http.NewRequest(http.MethodGet, server.URL+"/photos", nil)
+	require.NoError(t, err)
+	r.Header.Set(XAmzContentSHA256Header, EmptyStringSHA256)
+	require.NoError(t, signer.SignHTTP(ctx, creds, r, EmptyStringSHA256, "s3", "us-east-1", time.Now()))
+	r.URL.Path = "/other"
+	require.Equal(t, http.StatusForbidden, send(r))
+
+	// Presigned URL
+	r, err = http.NewRequest(http.MethodGet, server.URL+"/photos/cat.jpg", nil)
+	require.NoError(t, err)
+	r.URL.RawQuery = "X-Amz-Expires=300"
+	presigned, _, err := signer.PresignHTTP(ctx, creds, r, UnsignedPayload, "s3", "us-east-1", time.Now())
+	require.NoError(t, err)
+	r, err = http.NewRequest(http.MethodGet, presigned, nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, send(r))
+}
@@ -0,0 +1,226 @@
+// Package auth provides AWS Signature Version 4 authentication for Alexander Storage.
+package auth
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+)
+
+// Permission is an S3 action on a resource that a request needs.
+type Permission struct {
+	// Action is the S3 action name, e.g. "s3:GetObject".
+	Action string
+
+	// Resource is the bucket or object ARN, e.g. "arn:aws:s3:::photos/cat.jpg".
+	Resource string
+}
+
+// RequiredPermissions returns the permissions a request needs, following
+// the S3 action names used in IAM policies. CopyObject and UploadPartCopy
+// additionally need s3:GetObject on the copy source, and RenameObject needs
+// s3:DeleteObject on the renamed key.
+func RequiredPermissions(r *http.Request) []Permission {
+	query := r.URL.Query()
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	if path == "" {
+		return []Permission{{Action: "s3:ListAllMyBuckets", Resource: domain.PolicyResourcePrefix + "*"}}
+	}
+
+	bucketName, objectKey, _ := strings.Cut(path, "/")
+	if objectKey == "" {
+		return []Permission{{
+			Action:   bucketAction(r.Method, query),
+			Resource: domain.PolicyResourcePrefix + bucketName,
+		}}
+	}
+
+	perms := []Permission{{
+		Action:   objectAction(r.Method, query),
+		Resource: domain.PolicyResourcePrefix + bucketName + "/" + objectKey,
+	}}
+
+	if r.Method == http.MethodPut {
+		if source := copySourceResource(r.Header.Get("x-amz-copy-source")); source != "" {
+			perms = append(perms, Permission{Action: "s3:GetObject", Resource: source})
+		}
+		// A rename removes its source, which must be in the same bucket
+		if query.Has("renameObject") {
+			source := r.Header.Get("x-amz-rename-source")
+			if unescaped, err := url.PathUnescape(source); err == nil {
+				source = unescaped
+			}
+			if source = strings.TrimPrefix(source, "/"); source != "" {
+				perms = append(perms, Permission{Action: "s3:DeleteObject", Resource: domain.PolicyResourcePrefix + bucketName + "/" + source})
+			}
+		}
+	}
+
+	return perms
+}
+
+// bucketAction maps a bucket-level request to its S3 action.
+func bucketAction(method string, query url.Values) string {
+	switch {
+	case query.Has("versioning"):
+		if method == http.MethodPut {
+			return "s3:PutBucketVersioning"
+		}
+		return "s3:GetBucketVersioning"
+	case query.Has("inventory"):
+		if method == http.MethodGet {
+			return "s3:GetInventoryConfiguration"
+		}
+		// Deleting a configuration needs the same permission as putting one
+		return "s3:PutInventoryConfiguration"
+	case query.Has("website"):
+		switch method {
+		case http.MethodGet:
+			return "s3:GetBucketWebsite"
+		case http.MethodDelete:
+			return "s3:DeleteBucketWebsite"
+		}
+		return "s3:PutBucketWebsite"
+	case query.Has("acl"):
+		if method == http.MethodPut {
+			return "s3:PutBucketAcl"
+		}
+		return "s3:GetBucketAcl"
+	case query.Has("tagging"):
+		switch method {
+		case http.MethodGet:
+			return "s3:GetBucketTagging"
+		case http.MethodDelete:
+			return "s3:DeleteBucketTagging"
+		}
+		return "s3:PutBucketTagging"
+	case query.Has("replication"):
+		// The configuration holds the destination's credentials
+		switch method {
+		case http.MethodGet:
+			return "s3:GetReplicationConfiguration"
+		case http.MethodDelete:
+			return "s3:DeleteReplicationConfiguration"
+		}
+		return "s3:PutReplicationConfiguration"
+	case query.Has("lifecycle"):
+		if method == http.MethodGet {
+			return "s3:GetLifecycleConfiguration"
+		}
+		// Deleting the rules needs the same permission as putting them
+		return "s3:PutLifecycleConfiguration"
+	case query.Has("versions"):
+		return "s3:ListBucketVersions"
+	case query.Has("uploads"):
+		return "s3:ListBucketMultipartUploads"
+	case query.Has("location"):
+		return "s3:GetBucketLocation"
+	case query.Has("watch"):
+		return "s3:ListenBucketNotification"
+	case query.Has("accelerate"):
+		if method == http.MethodPut {
+			return "s3:PutAccelerateConfiguration"
+		}
+		return "s3:GetAccelerateConfiguration"
+	}
+
+	switch method {
+	case http.MethodPut:
+		return "s3:CreateBucket"
+	case http.MethodDelete:
+		return "s3:DeleteBucket"
+	case http.MethodPost:
+		// Multi-object delete
+		return "s3:DeleteObject"
+	default:
+		// GET lists objects; HEAD checks access to the bucket
+		return "s3:ListBucket"
+	}
+}
+
+// objectAction maps an object-level request to its S3 action.
+func objectAction(method string, query url.Values) string {
+	if query.Has("uploadId") {
+		switch method {
+		case http.MethodDelete:
+			return "s3:AbortMultipartUpload"
+		case http.MethodGet:
+			return "s3:ListMultipartUploadParts"
+		default:
+			// UploadPart and CompleteMultipartUpload
+			return "s3:PutObject"
+		}
+	}
+
+	// Without its own action any key that can upload could make objects public
+	if query.Has("acl") {
+		if method == http.MethodPut {
+			return "s3:PutObjectAcl"
+		}
+		return "s3:GetObjectAcl"
+	}
+	if query.Has("restore") && method == http.MethodPost {
+		return "s3:RestoreObject"
+	}
+
+	versioned := query.Get("versionId") != ""
+	switch method {
+	case http.MethodGet, http.MethodHead:
+		if versioned {
+			return "s3:GetObjectVersion"
+		}
+		return "s3:GetObject"
+	case http.MethodDelete:
+		if versioned {
+			return "s3:DeleteObjectVersion"
+		}
+		return "s3:DeleteObject"
+	default:
+		// PutObject, CopyObject and InitiateMultipartUpload
+		return "s3:PutObject"
+	}
+}
+
+// copySourceResource converts an x-amz-copy-source header ("/bucket/key" or
+// "bucket/key", URL-encoded, optionally with ?versionId=) to an object ARN.
+func copySourceResource(header string) string {
+	if header == "" {
+		return ""
+	}
+	source, _, _ := strings.Cut(header, "?")
+	if unescaped, err := url.PathUnescape(source); err == nil {
+		source = unescaped
+	}
+	source = strings.TrimPrefix(source, "/")
+	if source == "" {
+		return ""
+	}
+	return domain.PolicyResourcePrefix + source
+}
+
+// SourceIP returns the client IP of a request, or nil if it cannot be parsed.
+func SourceIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return net.ParseIP(host)
+}
+
+// checkPolicy enforces the access key's policy, if any, on the request.
+func checkPolicy(r *http.Request, keyInfo *AccessKeyInfo) error {
+	if keyInfo.Policy == nil {
+		return nil
+	}
+
+	ip := SourceIP(r)
+	for _, perm := range RequiredPermissions(r) {
+		if !keyInfo.Policy.IsAllowed(perm.Action, perm.Resource, ip) {
+			return ErrAccessDenied
+		}
+	}
+	return nil
+}
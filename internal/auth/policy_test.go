@@ -0,0 +1,149 @@
+// Package auth provides AWS Signature Version 4 authentication for Alexander Storage.
+package auth
+
+import (
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+)
+
+func TestRequiredPermissions(t *testing.T) {
+	tests := []struct {
+		method string
+		target string
+		header string
+		want   []Permission
+	}{
+		{"GET", "/", "", []Permission{{"s3:ListAllMyBuckets", "arn:aws:s3:::*"}}},
+		{"GET", "/photos?list-type=2", "", []Permission{{"s3:ListBucket", "arn:aws:s3:::photos"}}},
+		{"PUT", "/photos?versioning", "", []Permission{{"s3:PutBucketVersioning", "arn:aws:s3:::photos"}}},
+		{"DELETE", "/photos?inventory&id=daily", "", []Permission{{"s3:PutInventoryConfiguration", "arn:aws:s3:::photos"}}},
+		{"DELETE", "/photos?website", "", []Permission{{"s3:DeleteBucketWebsite", "arn:aws:s3:::photos"}}},
+		{"GET", "/photos?lifecycle", "", []Permission{{"s3:GetLifecycleConfiguration", "arn:aws:s3:::photos"}}},
+		{"PUT", "/photos?replication", "", []Permission{{"s3:PutReplicationConfiguration", "arn:aws:s3:::photos"}}},
+		{"PUT", "/photos?acl", "", []Permission{{"s3:PutBucketAcl", "arn:aws:s3:::photos"}}},
+		{"DELETE", "/photos?tagging", "", []Permission{{"s3:DeleteBucketTagging", "arn:aws:s3:::photos"}}},
+		{"PUT", "/photos/cat.jpg?acl", "", []Permission{{"s3:PutObjectAcl", "arn:aws:s3:::photos/cat.jpg"}}},
+		{"PUT", "/photos?lifecycle", "", []Permission{{"s3:PutLifecycleConfiguration", "arn:aws:s3:::photos"}}},
+		{"DELETE", "/photos?lifecycle", "", []Permission{{"s3:PutLifecycleConfiguration", "arn:aws:s3:::photos"}}},
+		{"GET", "/photos/a/b.jpg?versionId=v1", "", []Permission{{"s3:GetObjectVersion", "arn:aws:s3:::photos/a/b.jpg"}}},
+		{"DELETE", "/photos/b.jpg?uploadId=u1", "", []Permission{{"s3:AbortMultipartUpload", "arn:aws:s3:::photos/b.jpg"}}},
+		{"PUT", "/photos/copy.jpg", "/src/my%20file.jpg?versionId=v2", []Permission{
+			{"s3:PutObject", "arn:aws:s3:::photos/copy.jpg"},
+			{"s3:GetObject", "arn:aws:s3:::src/my file.jpg"},
+		}},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, tt.target, nil)
+		if tt.header != "" {
+			r.Header.Set("x-amz-copy-source", tt.header)
+		}
+		require.Equal(t, tt.want, RequiredPermissions(r), "%s %s", tt.method, tt.target)
+	}
+
+	r := httptest.NewRequest("PUT", "/photos/new.jpg?renameObject", nil)
+	r.Header.Set("x-amz-rename-source", "/old%20name.jpg")
+	require.Equal(t, []Permission{
+		{"s3:PutObject", "arn:aws:s3:::photos/new.jpg"},
+		{"s3:DeleteObject", "arn:aws:s3:::photos/old name.jpg"},
+	}, RequiredPermissions(r))
+}
+
+func TestCheckPolicy(t *testing.T) {
+	policy, err := domain.ParseAccessKeyPolicy([]byte(`{
+		"Version": "2012-10-17",
+		"Statement": [
+			{
+				"Effect": "Allow",
+				"Action": ["s3:Get*", "s3:PutObject"],
+				"Resource": "photos/public/*",
+				"Condition": {"IpAddress": {"aws:SourceIp": ["10.0.0.0/8", "192.168.1.7"]}}
+			},
+			{"Effect": "Allow", "Action": "s3:ListBucket", "Resource": "arn:aws:s3:::photos"},
+			{"Effect": "Deny", "Action": "s3:*", "Resource": "arn:aws:s3:::photos/public/secret*"}
+		]
+	}`))
+	require.NoError(t, err)
+	keyInfo := &AccessKeyInfo{Policy: policy}
+
+	check := func(method, target, remoteAddr string) error {
+		r := httptest.NewRequest(method, target, nil)
+		r.RemoteAddr = remoteAddr
+		return checkPolicy(r, keyInfo)
+	}
+
+	require.NoError(t, check("GET", "/photos/public/cat.jpg", "10.1.2.3:4000"))
+	require.NoError(t, check("PUT", "/photos/public/cat.jpg", "192.168.1.7:4000"))
+	require.NoError(t, check("GET", "/photos", "203.0.113.9:4000"))
+	require.ErrorIs(t, check("GET", "/photos/public/cat.jpg", "203.0.113.9:4000"), ErrAccessDenied)
+	require.ErrorIs(t, check("GET", "/photos/private/cat.jpg", "10.1.2.3:4000"), ErrAccessDenied)
+	require.ErrorIs(t, check("DELETE", "/photos/public/cat.jpg", "10.1.2.3:4000"), ErrAccessDenied)
+	require.ErrorIs(t, check("GET", "/photos/public/secret.txt", "10.1.2.3:4000"), ErrAccessDenied)
+	require.ErrorIs(t, check("GET", "/", "10.1.2.3:4000"), ErrAccessDenied)
+
+	// Keys without a policy keep full access
+	require.NoError(t, checkPolicy(httptest.NewRequest("DELETE", "/photos", nil), &AccessKeyInfo{}))
+
+	require.True(t, policy.IsAllowed("s3:GetObject", "arn:aws:s3:::photos/public/x", net.ParseIP("10.0.0.1")))
+}
+
+// TestCheckPolicy_Subresources checks that a key scoped to reading and
+// writing objects cannot change their ACLs or the configuration of their
+// bucket.
+func TestCheckPolicy_Subresources(t *testing.T) {
+	policy, err := domain.ParseAccessKeyPolicy([]byte(`{
+		"Version": "2012-10-17",
+		"Statement": [
+			{"Effect": "Allow", "Action": ["s3:GetObject", "s3:PutObject"], "Resource": "photos/*"},
+			{"Effect": "Allow", "Action": ["s3:ListBucket", "s3:CreateBucket", "s3:DeleteBucket"], "Resource": "photos"}
+		]
+	}`))
+	require.NoError(t, err)
+	objectsOnly := &AccessKeyInfo{Policy: policy}
+
+	for _, tt := range []struct {
+		method, target, action string
+	}{
+		{"GET", "/photos?lifecycle", "s3:GetLifecycleConfiguration"},
+		{"PUT", "/photos?lifecycle", "s3:PutLifecycleConfiguration"},
+		{"DELETE", "/photos?lifecycle", "s3:PutLifecycleConfiguration"},
+		{"GET", "/photos?replication", "s3:GetReplicationConfiguration"},
+		{"PUT", "/photos?replication", "s3:PutReplicationConfiguration"},
+		{"DELETE", "/photos?replication", "s3:DeleteReplicationConfiguration"},
+		{"GET", "/photos?acl", "s3:GetBucketAcl"},
+		{"PUT", "/photos?acl", "s3:PutBucketAcl"},
+		{"GET", "/photos?tagging", "s3:GetBucketTagging"},
+		{"PUT", "/photos?tagging", "s3:PutBucketTagging"},
+		{"DELETE", "/photos?tagging", "s3:DeleteBucketTagging"},
+		{"GET", "/photos/cat.jpg?acl", "s3:GetObjectAcl"},
+		{"PUT", "/photos/cat.jpg?acl", "s3:PutObjectAcl"},
+		{"POST", "/photos/cat.jpg?restore", "s3:RestoreObject"},
+	} {
+		r := httptest.NewRequest(tt.method, tt.target, nil)
+		require.ErrorIs(t, checkPolicy(r, objectsOnly), ErrAccessDenied, "%s %s", tt.method, tt.target)
+
+		granted, err := domain.ParseAccessKeyPolicy([]byte(`{"Statement": [{"Effect": "Allow", "Action": "` + tt.action + `", "Resource": "` + strings.TrimPrefix(r.URL.Path, "/") + `"}]}`))
+		require.NoError(t, err)
+		require.NoError(t, checkPolicy(r, &AccessKeyInfo{Policy: granted}), "%s %s", tt.method, tt.target)
+	}
+}
+
+func TestParseAccessKeyPolicyRejectsUnsupported(t *testing.T) {
+	for _, doc := range []string{
+		`{"Statement": []}`,
+		`{"Statement": [{"Effect": "Allow", "NotAction": "s3:GetObject", "Resource": "*"}]}`,
+		`{"Statement": [{"Effect": "Maybe", "Action": "s3:GetObject", "Resource": "*"}]}`,
+		`{"Statement": [{"Effect": "Allow", "Action": "iam:CreateUser", "Resource": "*"}]}`,
+		`{"Statement": [{"Effect": "Allow", "Action": "s3:*", "Resource": "*", "Condition": {"StringEquals": {"s3:prefix": "a"}}}]}`,
+		`{"Statement": [{"Effect": "Allow", "Action": "s3:*", "Resource": "*", "Condition": {"IpAddress": {"aws:SourceIp": "not-an-ip"}}}]}`,
+	} {
+		_, err := domain.ParseAccessKeyPolicy([]byte(doc))
+		require.ErrorIs(t, err, domain.ErrInvalidPolicy, doc)
+	}
+}
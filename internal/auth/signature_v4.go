@@ -52,11 +52,18 @@ func hmacSHA256(key, data []byte) []byte {
 
 // GetCanonicalRequest builds the canonical request string for signing.
 func GetCanonicalRequest(r *http.Request, signedHeaders []string, payloadHash string) string {
+	// Servers move the Host header to r.Host
+	headers := r.Header
+	if r.Host != "" && headers.Get("Host") == "" {
+		headers = headers.Clone()
+		headers.Set("Host", r.Host)
+	}
+
 	return buildCanonicalRequest(
 		r.Method,
 		getCanonicalURI(r.URL.Path),
 		getCanonicalQueryString(r.URL.Query()),
-		getCanonicalHeaders(r.Header, signedHeaders),
+		getCanonicalHeaders(headers, signedHeaders),
 		strings.Join(signedHeaders, ";"),
 		payloadHash,
 	)
@@ -171,11 +178,10 @@ func VerifySignature(
 
 	// Build string to sign
 	requestTime := signedValues.Credential.Scope.Date
-	// Try to get more precise time from X-Amz-Date header
-	if dateStr := r.Header.Get(XAmzDateHeader); dateStr != "" {
-		if t, err := time.Parse(ISO8601BasicFormat, dateStr); err == nil {
-			requestTime = t
-		}
+	// Try to get more precise time from X-Amz-Date, a header or, in
+	// presigned URLs, a query parameter
+	if t, err := GetRequestTime(r); err == nil {
+		requestTime = t
 	}
 
 	stringToSign := GetStringToSign(canonicalRequest, requestTime, signedValues.Credential.Scope)
@@ -109,6 +109,9 @@ type AuthContext struct {
 	// Username is the authenticated user's username.
 	Username string
 
+	// TenantID is the authenticated user's tenant.
+	TenantID int64
+
 	// AccessKeyID is the access key used for authentication.
 	AccessKeyID string
 
@@ -0,0 +1,133 @@
+// Package lifecyclecache provides a cache of each bucket's enabled lifecycle
+// rules in front of a LifecycleRepository.
+//
+// Object reads and writes report the rule that expires them, so the rules of
+// a bucket are looked up on every GET, HEAD and PUT. They rarely change, and
+// are cached in memory for a TTL. Rule changes made through the Repository
+// invalidate their bucket at once; changes made by other servers or the
+// admin CLI are seen once the TTL passes.
+package lifecyclecache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// DefaultTTL is how long rules are cached when no TTL is configured.
+const DefaultTTL = 30 * time.Second
+
+// entry is the enabled rules of a bucket as of a lookup.
+type entry struct {
+	rules   []*domain.LifecycleRule
+	expires time.Time
+}
+
+// Repository is a LifecycleRepository serving ListEnabledByBucket from a
+// cache. Writes go to the wrapped repository and invalidate the buckets
+// they touch.
+type Repository struct {
+	repository.LifecycleRepository
+
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[int64]entry
+
+	// generation changes with every invalidation, so a lookup that raced
+	// a write does not cache the rules it read before the write.
+	generation uint64
+}
+
+// NewRepository wraps rules with a cache keeping each bucket's enabled rules
+// for ttl, or DefaultTTL if ttl is not positive.
+func NewRepository(rules repository.LifecycleRepository, ttl time.Duration) *Repository {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &Repository{
+		LifecycleRepository: rules,
+		ttl:                 ttl,
+		now:                 time.Now,
+		entries:             make(map[int64]entry),
+	}
+}
+
+// ListEnabledByBucket returns the enabled rules of a bucket, from the cache
+// if they were looked up within the TTL. Callers must not modify the rules.
+func (r *Repository) ListEnabledByBucket(ctx context.Context, bucketID int64) ([]*domain.LifecycleRule, error) {
+	r.mu.Lock()
+	cached, ok := r.entries[bucketID]
+	generation := r.generation
+	r.mu.Unlock()
+	if ok && r.now().Before(cached.expires) {
+		return cached.rules, nil
+	}
+
+	rules, err := r.LifecycleRepository.ListEnabledByBucket(ctx, bucketID)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	if r.generation == generation {
+		r.entries[bucketID] = entry{rules: rules, expires: r.now().Add(r.ttl)}
+	}
+	r.mu.Unlock()
+	return rules, nil
+}
+
+// Create creates a rule and invalidates its bucket.
+func (r *Repository) Create(ctx context.Context, rule *domain.LifecycleRule) error {
+	defer r.invalidate(rule.BucketID)
+	return r.LifecycleRepository.Create(ctx, rule)
+}
+
+// Update updates a rule and invalidates its bucket.
+func (r *Repository) Update(ctx context.Context, rule *domain.LifecycleRule) error {
+	defer r.invalidate(rule.BucketID)
+	return r.LifecycleRepository.Update(ctx, rule)
+}
+
+// Delete deletes a rule and invalidates every bucket, as the rule's bucket
+// is not known without another lookup.
+func (r *Repository) Delete(ctx context.Context, id int64) error {
+	defer r.invalidateAll()
+	return r.LifecycleRepository.Delete(ctx, id)
+}
+
+// DeleteByBucketAndRuleID deletes a rule and invalidates its bucket.
+func (r *Repository) DeleteByBucketAndRuleID(ctx context.Context, bucketID int64, ruleID string) error {
+	defer r.invalidate(bucketID)
+	return r.LifecycleRepository.DeleteByBucketAndRuleID(ctx, bucketID, ruleID)
+}
+
+// DeleteByBucket deletes the rules of a bucket and invalidates it.
+func (r *Repository) DeleteByBucket(ctx context.Context, bucketID int64) error {
+	defer r.invalidate(bucketID)
+	return r.LifecycleRepository.DeleteByBucket(ctx, bucketID)
+}
+
+// invalidate drops the cached rules of a bucket. Writes invalidate even when
+// they fail, as a failed write may still have been applied.
+func (r *Repository) invalidate(bucketID int64) {
+	r.mu.Lock()
+	delete(r.entries, bucketID)
+	r.generation++
+	r.mu.Unlock()
+}
+
+// invalidateAll drops the cached rules of every bucket.
+func (r *Repository) invalidateAll() {
+	r.mu.Lock()
+	clear(r.entries)
+	r.generation++
+	r.mu.Unlock()
+}
+
+// Ensure Repository implements repository.LifecycleRepository
+var _ repository.LifecycleRepository = (*Repository)(nil)
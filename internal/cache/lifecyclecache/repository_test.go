@@ -0,0 +1,104 @@
+package lifecyclecache
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+	"github.com/prn-tf/alexander-storage/internal/repository/sqlite"
+)
+
+type fixture struct {
+	db       repository.LifecycleRepository // bypasses the cache
+	cached   *Repository
+	bucketID int64
+	now      time.Time
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	ctx := context.Background()
+
+	db, err := sqlite.NewDB(ctx, sqlite.DefaultConfig(filepath.Join(t.TempDir(), "alexander.db")), zerolog.Nop())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, db.Migrate(ctx))
+
+	user := domain.NewUser("owner", "owner@example.com", "hash")
+	require.NoError(t, sqlite.NewUserRepository(db).Create(ctx, user))
+	bucket := domain.NewBucket(user.ID, "logs")
+	require.NoError(t, sqlite.NewBucketRepository(db).Create(ctx, bucket))
+
+	rules := sqlite.NewLifecycleRepository(db)
+	f := &fixture{db: rules, bucketID: bucket.ID, now: time.Now()}
+	f.cached = NewRepository(rules, time.Minute)
+	f.cached.now = func() time.Time { return f.now }
+	return f
+}
+
+func (f *fixture) rule(ruleID string, days int) *domain.LifecycleRule {
+	rule := domain.NewLifecycleRule(f.bucketID, ruleID)
+	rule.ExpirationDays = &days
+	return rule
+}
+
+// enabled returns the IDs of the bucket's enabled rules, as the cache reports them.
+func (f *fixture) enabled(t *testing.T) []string {
+	t.Helper()
+	rules, err := f.cached.ListEnabledByBucket(context.Background(), f.bucketID)
+	require.NoError(t, err)
+	ids := make([]string, len(rules))
+	for i, rule := range rules {
+		ids[i] = rule.RuleID
+	}
+	return ids
+}
+
+func TestRepository_CachesUntilTTL(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	require.NoError(t, f.db.Create(ctx, f.rule("expire-logs", 7)))
+
+	assert.Equal(t, []string{"expire-logs"}, f.enabled(t))
+
+	// A change made elsewhere, e.g. with alexander-admin, is not seen ...
+	require.NoError(t, f.db.DeleteByBucket(ctx, f.bucketID))
+	assert.Equal(t, []string{"expire-logs"}, f.enabled(t))
+
+	// ... until the TTL passes
+	f.now = f.now.Add(time.Minute)
+	assert.Empty(t, f.enabled(t))
+}
+
+func TestRepository_WritesInvalidate(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	assert.Empty(t, f.enabled(t))
+
+	rule := f.rule("expire-logs", 7)
+	require.NoError(t, f.cached.Create(ctx, rule))
+	assert.Equal(t, []string{"expire-logs"}, f.enabled(t))
+
+	rule.Status = domain.LifecycleDisabled
+	require.NoError(t, f.cached.Update(ctx, rule))
+	assert.Empty(t, f.enabled(t))
+
+	rule.Status = domain.LifecycleEnabled
+	require.NoError(t, f.cached.Update(ctx, rule))
+	assert.Len(t, f.enabled(t), 1)
+
+	require.NoError(t, f.cached.Delete(ctx, rule.ID))
+	assert.Empty(t, f.enabled(t))
+
+	require.NoError(t, f.cached.Create(ctx, f.rule("expire-tmp", 1)))
+	assert.Len(t, f.enabled(t), 1)
+	require.NoError(t, f.cached.DeleteByBucketAndRuleID(ctx, f.bucketID, "expire-tmp"))
+	assert.Empty(t, f.enabled(t))
+}
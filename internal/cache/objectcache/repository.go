@@ -0,0 +1,283 @@
+// Package objectcache provides a read-through cache of object metadata in
+// front of an ObjectRepository.
+//
+// Only lookups whose context allows it (see repository.WithObjectCache) are
+// served from the cache. Misses are cached too, for a shorter time, so
+// repeated requests for a missing key do not each reach the database.
+//
+// Every write to a key replaces the key's generation token, and cached
+// entries are stored under the token current when they were read. A write
+// inside a transaction replaces the token after the commit, so an entry read
+// before the commit is never found again and clients read their own writes.
+package objectcache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/metrics"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// missing is cached for keys that have no object.
+var missing = []byte("null")
+
+// Config contains object cache configuration.
+type Config struct {
+	// TTL is how long found objects are cached. Default: 5 seconds.
+	TTL time.Duration
+
+	// NegativeTTL is how long misses are cached. Default: 1 second.
+	NegativeTTL time.Duration
+
+	// Buckets limits caching to these buckets. Empty caches every bucket.
+	Buckets []string
+
+	// ExcludeBuckets are never cached.
+	ExcludeBuckets []string
+}
+
+// Repository is an ObjectRepository serving GetByKey from a cache.
+// Writes go to the wrapped repository and invalidate the keys they touch.
+type Repository struct {
+	repository.ObjectRepository
+
+	buckets repository.BucketRepository
+	cache   repository.Cache
+	config  Config
+	metrics *metrics.Metrics
+	logger  zerolog.Logger
+
+	include map[string]bool
+	exclude map[string]bool
+
+	// enabled memoizes whether a bucket ID is cached; bucket names never change.
+	enabled sync.Map
+}
+
+// NewRepository wraps objects with a cache. Bucket names are resolved with
+// buckets to apply the per-bucket configuration. m may be nil.
+func NewRepository(
+	objects repository.ObjectRepository,
+	buckets repository.BucketRepository,
+	cache repository.Cache,
+	config Config,
+	m *metrics.Metrics,
+	logger zerolog.Logger,
+) *Repository {
+	if config.TTL <= 0 {
+		config.TTL = 5 * time.Second
+	}
+	if config.NegativeTTL <= 0 {
+		config.NegativeTTL = time.Second
+	}
+
+	r := &Repository{
+		ObjectRepository: objects,
+		buckets:          buckets,
+		cache:            cache,
+		config:           config,
+		metrics:          m,
+		logger:           logger.With().Str("component", "object_cache").Logger(),
+		include:          make(map[string]bool),
+		exclude:          make(map[string]bool),
+	}
+	for _, name := range config.Buckets {
+		r.include[name] = true
+	}
+	for _, name := range config.ExcludeBuckets {
+		r.exclude[name] = true
+	}
+	return r
+}
+
+// TxManager wraps inner so that keys written in a transaction are
+// invalidated once it has committed. Services must use the returned manager
+// for the cache to stay consistent with their transactions.
+func (r *Repository) TxManager(inner repository.TxManager) repository.TxManager {
+	return &txManager{TxManager: inner, repo: r}
+}
+
+// GetByKey returns the latest version of an object, from the cache if ctx allows it.
+func (r *Repository) GetByKey(ctx context.Context, bucketID int64, key string) (*domain.Object, error) {
+	if !repository.ObjectCacheFromContext(ctx) || inTx(ctx) || !r.cached(ctx, bucketID) {
+		return r.ObjectRepository.GetByKey(ctx, bucketID, key)
+	}
+
+	entryKey := r.entryKey(ctx, bucketID, key)
+	if data, err := r.cache.Get(ctx, entryKey); err == nil {
+		obj, decodeErr := decode(data)
+		if decodeErr == nil || errors.Is(decodeErr, domain.ErrObjectNotFound) {
+			r.recordAccess(true)
+			return obj, decodeErr
+		}
+		r.logger.Warn().Err(decodeErr).Str("key", key).Msg("discarding undecodable cache entry")
+	}
+	r.recordAccess(false)
+
+	obj, err := r.ObjectRepository.GetByKey(ctx, bucketID, key)
+	switch {
+	case err == nil:
+		if data, encodeErr := json.Marshal(obj); encodeErr == nil {
+			r.store(ctx, entryKey, data, r.config.TTL)
+		}
+	case errors.Is(err, domain.ErrObjectNotFound):
+		r.store(ctx, entryKey, missing, r.config.NegativeTTL)
+	}
+	return obj, err
+}
+
+// Create creates an object and invalidates its key.
+func (r *Repository) Create(ctx context.Context, obj *domain.Object) error {
+	if err := r.ObjectRepository.Create(ctx, obj); err != nil {
+		return err
+	}
+	r.invalidate(ctx, obj.BucketID, obj.Key)
+	return nil
+}
+
+// Update updates an object and invalidates its key.
+func (r *Repository) Update(ctx context.Context, obj *domain.Object) error {
+	if err := r.ObjectRepository.Update(ctx, obj); err != nil {
+		return err
+	}
+	r.invalidate(ctx, obj.BucketID, obj.Key)
+	return nil
+}
+
+// MarkNotLatest marks the latest version of a key as not latest and invalidates the key.
+func (r *Repository) MarkNotLatest(ctx context.Context, bucketID int64, key string) error {
+	if err := r.ObjectRepository.MarkNotLatest(ctx, bucketID, key); err != nil {
+		return err
+	}
+	r.invalidate(ctx, bucketID, key)
+	return nil
+}
+
+// MarkLatest marks a version as latest and invalidates its key.
+func (r *Repository) MarkLatest(ctx context.Context, id int64) error {
+	obj, lookupErr := r.ObjectRepository.GetByID(ctx, id)
+	if err := r.ObjectRepository.MarkLatest(ctx, id); err != nil {
+		return err
+	}
+	if lookupErr == nil {
+		r.invalidate(ctx, obj.BucketID, obj.Key)
+	}
+	return nil
+}
+
+// NextSequence takes the next write sequence of a key and invalidates it.
+// Writers take a sequence for every change, including removals.
+func (r *Repository) NextSequence(ctx context.Context, bucketID int64, key string) (int64, error) {
+	sequence, err := r.ObjectRepository.NextSequence(ctx, bucketID, key)
+	if err != nil {
+		return 0, err
+	}
+	r.invalidate(ctx, bucketID, key)
+	return sequence, nil
+}
+
+// Delete soft-deletes a version and invalidates its key.
+func (r *Repository) Delete(ctx context.Context, id int64) error {
+	obj, lookupErr := r.ObjectRepository.GetByID(ctx, id)
+	if err := r.ObjectRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+	if lookupErr == nil {
+		r.invalidate(ctx, obj.BucketID, obj.Key)
+	}
+	return nil
+}
+
+// DeleteAllVersions soft-deletes all versions of a key and invalidates it.
+func (r *Repository) DeleteAllVersions(ctx context.Context, bucketID int64, key string) ([]string, error) {
+	hashes, err := r.ObjectRepository.DeleteAllVersions(ctx, bucketID, key)
+	if err != nil {
+		return nil, err
+	}
+	r.invalidate(ctx, bucketID, key)
+	return hashes, nil
+}
+
+// cached reports whether objects of the bucket are cached.
+func (r *Repository) cached(ctx context.Context, bucketID int64) bool {
+	if len(r.include) == 0 && len(r.exclude) == 0 {
+		return true
+	}
+	if enabled, ok := r.enabled.Load(bucketID); ok {
+		return enabled.(bool)
+	}
+
+	bucket, err := r.buckets.GetByID(ctx, bucketID)
+	if err != nil {
+		return false
+	}
+	enabled := !r.exclude[bucket.Name] && (len(r.include) == 0 || r.include[bucket.Name])
+	r.enabled.Store(bucketID, enabled)
+	return enabled
+}
+
+// entryKey returns the cache key of the key's metadata under its current generation.
+func (r *Repository) entryKey(ctx context.Context, bucketID int64, key string) string {
+	generation := uuid.Nil.String()
+	if data, err := r.cache.Get(ctx, repository.CacheKey{}.ObjectGeneration(bucketID, key)); err == nil {
+		generation = string(data)
+	}
+	// Generations have a fixed length, so no two keys share an entry
+	return repository.CacheKey{}.ObjectMeta(bucketID, key) + "@" + generation
+}
+
+// invalidate replaces the generation of a key, or queues it until the
+// transaction in ctx commits.
+func (r *Repository) invalidate(ctx context.Context, bucketID int64, key string) {
+	if pending, ok := ctx.Value(pendingKey{}).(*pendingKeys); ok {
+		pending.add(bucketID, key)
+		return
+	}
+	r.bump(ctx, bucketID, key)
+}
+
+// bump gives a key a new generation. The generation outlives every entry
+// stored under the previous one; once it expires, the key falls back to the
+// nil generation, whose entries have expired as well.
+func (r *Repository) bump(ctx context.Context, bucketID int64, key string) {
+	ttl := max(r.config.TTL, r.config.NegativeTTL) + time.Minute
+	generation := []byte(uuid.New().String())
+	if err := r.cache.Set(context.WithoutCancel(ctx), repository.CacheKey{}.ObjectGeneration(bucketID, key), generation, ttl); err != nil {
+		r.logger.Warn().Err(err).Int64("bucket_id", bucketID).Str("key", key).Msg("failed to invalidate cached object")
+	}
+}
+
+// store caches an entry; a failure only costs a later database read.
+func (r *Repository) store(ctx context.Context, entryKey string, data []byte, ttl time.Duration) {
+	if err := r.cache.Set(ctx, entryKey, data, ttl); err != nil {
+		r.logger.Debug().Err(err).Msg("failed to cache object")
+	}
+}
+
+func (r *Repository) recordAccess(hit bool) {
+	if r.metrics != nil {
+		r.metrics.RecordCacheAccess("object", hit)
+	}
+}
+
+// decode returns the cached object, or ErrObjectNotFound for a cached miss.
+func decode(data []byte) (*domain.Object, error) {
+	if string(data) == string(missing) {
+		return nil, domain.ErrObjectNotFound
+	}
+	var obj domain.Object
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, err
+	}
+	return &obj, nil
+}
+
+var _ repository.ObjectRepository = (*Repository)(nil)
@@ -0,0 +1,187 @@
+package objectcache
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/cache/memory"
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+	"github.com/prn-tf/alexander-storage/internal/repository/sqlite"
+)
+
+const contentHash = "0000000000000000000000000000000000000000000000000000000000000001"
+
+type fixture struct {
+	db      repository.ObjectRepository // bypasses the cache
+	cached  *Repository
+	tx      repository.TxManager
+	buckets map[string]int64
+}
+
+func newFixture(t *testing.T, config Config, bucketNames ...string) *fixture {
+	t.Helper()
+	ctx := context.Background()
+
+	db, err := sqlite.NewDB(ctx, sqlite.DefaultConfig(filepath.Join(t.TempDir(), "alexander.db")), zerolog.Nop())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, db.Migrate(ctx))
+
+	user := domain.NewUser("owner", "owner@example.com", "hash")
+	require.NoError(t, sqlite.NewUserRepository(db).Create(ctx, user))
+	_, err = sqlite.NewBlobRepository(db).UpsertWithRefIncrement(ctx, contentHash, 4, "/blobs/1")
+	require.NoError(t, err)
+
+	bucketRepo := sqlite.NewBucketRepository(db)
+	buckets := make(map[string]int64)
+	for _, name := range bucketNames {
+		bucket := domain.NewBucket(user.ID, name)
+		require.NoError(t, bucketRepo.Create(ctx, bucket))
+		buckets[name] = bucket.ID
+	}
+
+	cache := memory.NewCache()
+	t.Cleanup(cache.Stop)
+
+	objects := sqlite.NewObjectRepository(db)
+	cached := NewRepository(objects, bucketRepo, cache, config, nil, zerolog.Nop())
+	return &fixture{
+		db:      objects,
+		cached:  cached,
+		tx:      cached.TxManager(sqlite.NewTxManager(db)),
+		buckets: buckets,
+	}
+}
+
+// put writes a new latest version of key through the cached repository,
+// as services do.
+func (f *fixture) put(t *testing.T, bucketID int64, key, contentType string) {
+	t.Helper()
+	err := f.tx.WithTx(context.Background(), func(ctx context.Context) error {
+		if err := f.cached.MarkNotLatest(ctx, bucketID, key); err != nil {
+			return err
+		}
+		return f.cached.Create(ctx, domain.NewObject(bucketID, key, contentHash, contentType, "etag", 4))
+	})
+	require.NoError(t, err)
+}
+
+// contentType reads key with the cache allowed and returns its content
+// type, or "" if it is missing.
+func (f *fixture) contentType(t *testing.T, bucketID int64, key string) string {
+	t.Helper()
+	obj, err := f.cached.GetByKey(repository.WithObjectCache(context.Background()), bucketID, key)
+	if errors.Is(err, domain.ErrObjectNotFound) {
+		return ""
+	}
+	require.NoError(t, err)
+	return obj.ContentType
+}
+
+// changeBehindCache changes the content type of the latest version of key
+// without invalidating the cache, which makes a cached entry observable.
+func (f *fixture) changeBehindCache(t *testing.T, bucketID int64, key, contentType string) {
+	t.Helper()
+	ctx := context.Background()
+	obj, err := f.db.GetByKey(ctx, bucketID, key)
+	require.NoError(t, err)
+	obj.ContentType = contentType
+	require.NoError(t, f.db.Update(ctx, obj))
+}
+
+func TestRepository_ServesCachedLookups(t *testing.T) {
+	f := newFixture(t, Config{}, "photos")
+	bucketID := f.buckets["photos"]
+
+	f.put(t, bucketID, "cat.jpg", "v1")
+	require.Equal(t, "v1", f.contentType(t, bucketID, "cat.jpg"))
+
+	f.changeBehindCache(t, bucketID, "cat.jpg", "behind")
+	require.Equal(t, "v1", f.contentType(t, bucketID, "cat.jpg"))
+
+	// Lookups that do not allow the cache read the database
+	obj, err := f.cached.GetByKey(context.Background(), bucketID, "cat.jpg")
+	require.NoError(t, err)
+	require.Equal(t, "behind", obj.ContentType)
+
+	// A committed write is visible to the next lookup
+	f.put(t, bucketID, "cat.jpg", "v2")
+	require.Equal(t, "v2", f.contentType(t, bucketID, "cat.jpg"))
+
+	// So is a write outside a transaction
+	obj, err = f.cached.GetByKey(context.Background(), bucketID, "cat.jpg")
+	require.NoError(t, err)
+	obj.ContentType = "v3"
+	require.NoError(t, f.cached.Update(context.Background(), obj))
+	require.Equal(t, "v3", f.contentType(t, bucketID, "cat.jpg"))
+
+	// Deleting the version invalidates the key, which is then missing
+	require.NoError(t, f.tx.WithTx(context.Background(), func(ctx context.Context) error {
+		return f.cached.Delete(ctx, obj.ID)
+	}))
+	require.Equal(t, "", f.contentType(t, bucketID, "cat.jpg"))
+}
+
+func TestRepository_CachesMisses(t *testing.T) {
+	f := newFixture(t, Config{}, "photos")
+	bucketID := f.buckets["photos"]
+
+	require.Equal(t, "", f.contentType(t, bucketID, "dog.jpg"))
+
+	// The miss is cached: an object created behind the cache stays hidden
+	require.NoError(t, f.db.Create(context.Background(), domain.NewObject(bucketID, "dog.jpg", contentHash, "behind", "etag", 4)))
+	require.Equal(t, "", f.contentType(t, bucketID, "dog.jpg"))
+
+	f.put(t, bucketID, "dog.jpg", "v1")
+	require.Equal(t, "v1", f.contentType(t, bucketID, "dog.jpg"))
+}
+
+func TestRepository_InvalidatesAfterCommit(t *testing.T) {
+	f := newFixture(t, Config{}, "photos")
+	bucketID := f.buckets["photos"]
+	f.put(t, bucketID, "cat.jpg", "v1")
+
+	err := f.tx.WithTx(context.Background(), func(ctx context.Context) error {
+		if err := f.cached.MarkNotLatest(ctx, bucketID, "cat.jpg"); err != nil {
+			return err
+		}
+		if err := f.cached.Create(ctx, domain.NewObject(bucketID, "cat.jpg", contentHash, "v2", "etag", 4)); err != nil {
+			return err
+		}
+
+		// Lookups inside the transaction bypass the cache
+		obj, err := f.cached.GetByKey(repository.WithObjectCache(ctx), bucketID, "cat.jpg")
+		require.NoError(t, err)
+		require.Equal(t, "v2", obj.ContentType)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "v2", f.contentType(t, bucketID, "cat.jpg"))
+}
+
+func TestRepository_PerBucketConfiguration(t *testing.T) {
+	f := newFixture(t, Config{ExcludeBuckets: []string{"uploads"}}, "photos", "uploads")
+	for name, bucketID := range f.buckets {
+		f.put(t, bucketID, "key", "v1")
+		require.Equal(t, "v1", f.contentType(t, bucketID, "key"))
+		f.changeBehindCache(t, bucketID, "key", "behind")
+
+		want := "v1"
+		if name == "uploads" {
+			want = "behind"
+		}
+		require.Equal(t, want, f.contentType(t, bucketID, "key"), name)
+	}
+
+	f = newFixture(t, Config{Buckets: []string{"photos"}}, "photos", "logs")
+	f.put(t, f.buckets["logs"], "key", "v1")
+	require.Equal(t, "v1", f.contentType(t, f.buckets["logs"], "key"))
+	f.changeBehindCache(t, f.buckets["logs"], "key", "behind")
+	require.Equal(t, "behind", f.contentType(t, f.buckets["logs"], "key"))
+}
@@ -0,0 +1,63 @@
+package objectcache
+
+import (
+	"context"
+	"sync"
+
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// pendingKey is the context key for the keys written in the active transaction.
+type pendingKey struct{}
+
+// pendingKeys collects the keys a transaction wrote.
+type pendingKeys struct {
+	mu   sync.Mutex
+	keys []objectKey
+}
+
+type objectKey struct {
+	bucketID int64
+	key      string
+}
+
+func (p *pendingKeys) add(bucketID int64, key string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.keys = append(p.keys, objectKey{bucketID: bucketID, key: key})
+}
+
+// inTx reports whether ctx belongs to a transaction of a wrapped TxManager.
+func inTx(ctx context.Context) bool {
+	_, ok := ctx.Value(pendingKey{}).(*pendingKeys)
+	return ok
+}
+
+// txManager invalidates the keys written in a transaction after it ends.
+type txManager struct {
+	repository.TxManager
+	repo *Repository
+}
+
+// WithTx executes fn within a transaction.
+func (m *txManager) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return m.WithTxOptions(ctx, repository.TxOptions{}, fn)
+}
+
+// WithTxOptions executes fn within a transaction with the given options.
+// Nested calls join the outer transaction, whose end invalidates their keys.
+func (m *txManager) WithTxOptions(ctx context.Context, opts repository.TxOptions, fn func(ctx context.Context) error) error {
+	if inTx(ctx) {
+		return m.TxManager.WithTxOptions(ctx, opts, fn)
+	}
+
+	pending := &pendingKeys{}
+	err := m.TxManager.WithTxOptions(context.WithValue(ctx, pendingKey{}, pending), opts, fn)
+
+	// A rolled back transaction changed nothing, but invalidating is cheap
+	// and safe if the commit's outcome is unknown
+	for _, k := range pending.keys {
+		m.repo.bump(ctx, k.bucketID, k.key)
+	}
+	return err
+}
@@ -2,7 +2,9 @@ package redis
 
 import (
 	"context"
+	"crypto/rand"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/prn-tf/alexander-storage/internal/repository"
@@ -11,7 +13,10 @@ import (
 // DistributedLock implements repository.DistributedLock using Redis.
 type DistributedLock struct {
 	client *Client
-	// token is used to verify lock ownership for release/extend operations
+
+	// tokens holds the ownership tokens of locks acquired with Acquire,
+	// used to verify ownership for release/extend operations
+	mu     sync.Mutex
 	tokens map[string]string
 }
 
@@ -25,12 +30,27 @@ func NewDistributedLock(client *Client) repository.DistributedLock {
 
 // generateToken creates a unique token for lock ownership.
 func generateToken() string {
-	return fmt.Sprintf("%d", time.Now().UnixNano())
+	return rand.Text()
 }
 
 // Acquire attempts to acquire a lock.
 // Returns true if the lock was acquired, false if it's held by another process.
 func (l *DistributedLock) Acquire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	token, err := l.AcquireToken(ctx, key, ttl)
+	if err != nil || token == "" {
+		return false, err
+	}
+
+	// Store token for later release/extend
+	l.mu.Lock()
+	l.tokens[key] = token
+	l.mu.Unlock()
+	return true, nil
+}
+
+// AcquireToken attempts to acquire a lock owned by the returned token.
+// Returns an empty token if the lock is held by another process.
+func (l *DistributedLock) AcquireToken(ctx context.Context, key string, ttl time.Duration) (string, error) {
 	if ttl <= 0 {
 		ttl = defaultLockTTL
 	}
@@ -41,19 +61,17 @@ func (l *DistributedLock) Acquire(ctx context.Context, key string, ttl time.Dura
 	// Try to acquire lock using SETNX
 	success, err := l.client.client.SetNX(ctx, lockKey, token, ttl).Result()
 	if err != nil {
-		return false, fmt.Errorf("failed to acquire lock: %w", err)
+		return "", fmt.Errorf("failed to acquire lock: %w", err)
 	}
-
-	if success {
-		// Store token for later release/extend
-		l.tokens[key] = token
-		l.client.logger.Debug().
-			Str("key", key).
-			Dur("ttl", ttl).
-			Msg("lock acquired")
+	if !success {
+		return "", nil
 	}
 
-	return success, nil
+	l.client.logger.Debug().
+		Str("key", key).
+		Dur("ttl", ttl).
+		Msg("lock acquired")
+	return token, nil
 }
 
 // AcquireWithRetry attempts to acquire a lock with retries.
@@ -84,7 +102,9 @@ func (l *DistributedLock) AcquireWithRetry(ctx context.Context, key string, ttl
 // Returns true if the lock was released, false if it wasn't held.
 func (l *DistributedLock) Release(ctx context.Context, key string) (bool, error) {
 	lockKey := prefixLock + key
+	l.mu.Lock()
 	token, exists := l.tokens[key]
+	l.mu.Unlock()
 	if !exists {
 		// We don't have a token, can't verify ownership
 		// Just try to delete (unsafe but necessary for interface compliance)
@@ -95,6 +115,20 @@ func (l *DistributedLock) Release(ctx context.Context, key string) (bool, error)
 		return result > 0, nil
 	}
 
+	released, err := l.ReleaseToken(ctx, key, token)
+	if err == nil {
+		// Either released or lost to another owner; the token is stale
+		l.mu.Lock()
+		delete(l.tokens, key)
+		l.mu.Unlock()
+	}
+	return released, err
+}
+
+// ReleaseToken releases a lock if it is still owned by token.
+func (l *DistributedLock) ReleaseToken(ctx context.Context, key, token string) (bool, error) {
+	lockKey := prefixLock + key
+
 	// Use Lua script to ensure we only delete if we own the lock
 	script := `
 		if redis.call("GET", KEYS[1]) == ARGV[1] then
@@ -110,7 +144,6 @@ func (l *DistributedLock) Release(ctx context.Context, key string) (bool, error)
 	}
 
 	if result > 0 {
-		delete(l.tokens, key)
 		l.client.logger.Debug().
 			Str("key", key).
 			Msg("lock released")
@@ -123,11 +156,18 @@ func (l *DistributedLock) Release(ctx context.Context, key string) (bool, error)
 // Extend extends the TTL of a held lock.
 // Returns true if the lock was extended, false if it's not held.
 func (l *DistributedLock) Extend(ctx context.Context, key string, ttl time.Duration) (bool, error) {
-	lockKey := prefixLock + key
+	l.mu.Lock()
 	token, exists := l.tokens[key]
+	l.mu.Unlock()
 	if !exists {
 		return false, nil
 	}
+	return l.ExtendToken(ctx, key, token, ttl)
+}
+
+// ExtendToken extends the TTL of a lock if it is still owned by token.
+func (l *DistributedLock) ExtendToken(ctx context.Context, key, token string, ttl time.Duration) (bool, error) {
+	lockKey := prefixLock + key
 
 	// Use Lua script to extend only if we own the lock
 	script := `
@@ -0,0 +1,300 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/storage"
+)
+
+// PlacementBackend is a storage.Backend that places each blob on the nodes
+// the ring assigns it to, so every node can serve every object while
+// keeping only its share of the blobs on local disk.
+//
+// Reads try the owners first, then this node, then every other live node,
+// so blobs remain readable while the rebalancer moves them after a
+// membership change.
+type PlacementBackend struct {
+	local      storage.Backend
+	membership *Membership
+	clients    *ClientPool
+	replicas   int
+	logger     zerolog.Logger
+}
+
+// NewPlacementBackend creates a backend that keeps replicationFactor copies
+// of each blob across the cluster, using local for this node's share.
+func NewPlacementBackend(local storage.Backend, membership *Membership, clients *ClientPool, replicationFactor int, logger zerolog.Logger) *PlacementBackend {
+	if replicationFactor <= 0 {
+		replicationFactor = 1
+	}
+	return &PlacementBackend{
+		local:      local,
+		membership: membership,
+		clients:    clients,
+		replicas:   replicationFactor,
+		logger:     logger.With().Str("component", "cluster-placement").Logger(),
+	}
+}
+
+// Store stores content on this node to compute its hash, then pushes it to
+// the blob's owners. The local copy is kept as a handoff if an owner could
+// not be reached, and dropped once all owners have the blob when this node
+// is not one of them. Fails if fewer than min(replication factor, live
+// nodes) nodes hold the blob.
+func (b *PlacementBackend) Store(ctx context.Context, reader io.Reader, size int64) (string, error) {
+	contentHash, err := b.local.Store(ctx, reader, size)
+	if err != nil {
+		return "", err
+	}
+
+	ring := b.membership.Ring()
+	owners := ring.Owners(contentHash, b.replicas)
+	self := b.membership.NodeID()
+
+	copies := 1
+	pushed := 0
+	for _, owner := range owners {
+		if owner == self {
+			continue
+		}
+		if err := b.push(ctx, owner, contentHash); err != nil {
+			b.logger.Warn().Err(err).
+				Str("content_hash", contentHash).
+				Str("node_id", owner).
+				Msg("Failed to place blob on owner, keeping local copy")
+			continue
+		}
+		copies++
+		pushed++
+	}
+
+	if required := min(b.replicas, len(ring.Nodes())); copies < required {
+		return "", fmt.Errorf("%w: blob %s stored on %d of %d nodes", ErrInsufficientNodes, contentHash, copies, required)
+	}
+
+	if !slices.Contains(owners, self) && pushed == len(owners) {
+		if err := b.local.Delete(ctx, contentHash); err != nil && !errors.Is(err, storage.ErrBlobNotFound) {
+			b.logger.Warn().Err(err).Str("content_hash", contentHash).Msg("Failed to drop local copy of placed blob")
+		}
+	}
+
+	return contentHash, nil
+}
+
+// Retrieve retrieves content from the first node that has it.
+func (b *PlacementBackend) Retrieve(ctx context.Context, contentHash string) (io.ReadCloser, error) {
+	var reader io.ReadCloser
+	err := b.fromAny(ctx, contentHash, func(nodeID string, client *Client) error {
+		var err error
+		if client == nil {
+			reader, err = b.local.Retrieve(ctx, contentHash)
+		} else {
+			reader, err = client.RetrieveBlob(ctx, contentHash)
+		}
+		return err
+	})
+	return reader, err
+}
+
+// RetrieveRange retrieves a byte range from the first node that has the blob.
+func (b *PlacementBackend) RetrieveRange(ctx context.Context, contentHash string, offset, length int64) (io.ReadCloser, error) {
+	var reader io.ReadCloser
+	err := b.fromAny(ctx, contentHash, func(nodeID string, client *Client) error {
+		var err error
+		if client == nil {
+			reader, err = retrieveRange(ctx, b.local, contentHash, offset, length)
+		} else {
+			reader, err = client.RetrieveBlobRange(ctx, contentHash, offset, length)
+		}
+		return err
+	})
+	return reader, err
+}
+
+// Delete deletes content from every live node. Returns
+// storage.ErrBlobNotFound only if no node had it.
+func (b *PlacementBackend) Delete(ctx context.Context, contentHash string) error {
+	deleted := false
+	var errs []error
+
+	err := b.local.Delete(ctx, contentHash)
+	switch {
+	case err == nil:
+		deleted = true
+	case !errors.Is(err, storage.ErrBlobNotFound):
+		errs = append(errs, err)
+	}
+
+	for _, nodeID := range b.membership.Ring().Nodes() {
+		if nodeID == b.membership.NodeID() {
+			continue
+		}
+		client, err := b.client(nodeID)
+		if err == nil {
+			err = client.DeleteBlob(ctx, contentHash)
+		}
+		switch {
+		case err == nil:
+			deleted = true
+		case !errors.Is(err, ErrBlobNotFound):
+			errs = append(errs, fmt.Errorf("node %s: %w", nodeID, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	if !deleted {
+		return storage.ErrBlobNotFound
+	}
+	return nil
+}
+
+// Exists checks if any node has the content.
+func (b *PlacementBackend) Exists(ctx context.Context, contentHash string) (bool, error) {
+	_, err := b.GetSize(ctx, contentHash)
+	if errors.Is(err, storage.ErrBlobNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// GetSize returns the size of the content from the first node that has it.
+func (b *PlacementBackend) GetSize(ctx context.Context, contentHash string) (int64, error) {
+	var size int64
+	err := b.fromAny(ctx, contentHash, func(nodeID string, client *Client) error {
+		var err error
+		if client == nil {
+			size, err = b.local.GetSize(ctx, contentHash)
+		} else {
+			size, err = client.BlobSize(ctx, contentHash)
+		}
+		return err
+	})
+	return size, err
+}
+
+// GetPath returns the local storage path for a content hash.
+func (b *PlacementBackend) GetPath(contentHash string) string {
+	return b.local.GetPath(contentHash)
+}
+
+// HealthCheck checks the local storage. Peers are checked through membership.
+func (b *PlacementBackend) HealthCheck(ctx context.Context) error {
+	return b.local.HealthCheck(ctx)
+}
+
+// candidates returns the nodes to look for a blob on: its owners, then this
+// node, then the other live nodes.
+func (b *PlacementBackend) candidates(ring *Ring, contentHash string) []string {
+	nodes := ring.Owners(contentHash, b.replicas)
+	if self := b.membership.NodeID(); !slices.Contains(nodes, self) {
+		nodes = append(nodes, self)
+	}
+	for _, nodeID := range ring.Nodes() {
+		if !slices.Contains(nodes, nodeID) {
+			nodes = append(nodes, nodeID)
+		}
+	}
+	return nodes
+}
+
+// fromAny calls fn for each candidate node until one succeeds. fn gets a
+// nil client for this node. Returns storage.ErrBlobNotFound if no node has
+// the blob, or the last error if a node could not answer.
+func (b *PlacementBackend) fromAny(ctx context.Context, contentHash string, fn func(nodeID string, client *Client) error) error {
+	var lastErr error
+	for _, nodeID := range b.candidates(b.membership.Ring(), contentHash) {
+		var client *Client
+		if nodeID != b.membership.NodeID() {
+			var err error
+			if client, err = b.client(nodeID); err != nil {
+				lastErr = err
+				continue
+			}
+		}
+
+		err := fn(nodeID, client)
+		if err == nil {
+			return nil
+		}
+		if errors.Is(err, storage.ErrBlobNotFound) || errors.Is(err, ErrBlobNotFound) {
+			continue
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		b.logger.Warn().Err(err).
+			Str("content_hash", contentHash).
+			Str("node_id", nodeID).
+			Msg("Failed to read blob from node")
+		lastErr = err
+	}
+
+	if lastErr != nil {
+		return lastErr
+	}
+	return storage.ErrBlobNotFound
+}
+
+// push copies a local blob to a node.
+func (b *PlacementBackend) push(ctx context.Context, nodeID, contentHash string) error {
+	client, err := b.client(nodeID)
+	if err != nil {
+		return err
+	}
+
+	size, err := b.local.GetSize(ctx, contentHash)
+	if err != nil {
+		return err
+	}
+	reader, err := b.local.Retrieve(ctx, contentHash)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	return client.TransferBlob(ctx, contentHash, size, reader)
+}
+
+// pull copies a blob from any other node that has it to this node.
+func (b *PlacementBackend) pull(ctx context.Context, contentHash string) error {
+	return b.fromAny(ctx, contentHash, func(nodeID string, client *Client) error {
+		if client == nil {
+			return storage.ErrBlobNotFound
+		}
+		reader, err := client.RetrieveBlob(ctx, contentHash)
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+
+		storedHash, err := b.local.Store(ctx, reader, -1)
+		if err != nil {
+			return err
+		}
+		if storedHash != contentHash {
+			_ = b.local.Delete(ctx, storedHash)
+			return fmt.Errorf("%w: node %s returned content hashing to %s", ErrTransferFailed, nodeID, storedHash)
+		}
+		return nil
+	})
+}
+
+// client returns the client of a live node.
+func (b *PlacementBackend) client(nodeID string) (*Client, error) {
+	address, ok := b.membership.Address(nodeID)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNodeUnavailable, nodeID)
+	}
+	return b.clients.GetClient(nodeID, address)
+}
+
+// Ensure PlacementBackend implements storage.Backend
+var _ storage.Backend = (*PlacementBackend)(nil)
@@ -3,10 +3,12 @@ package cluster
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
@@ -21,7 +23,11 @@ type ClientConfig struct {
 	// NodeID is the remote node's ID.
 	NodeID string
 
-	// Timeout is the default request timeout.
+	// Secret is the shared cluster secret sent with every request.
+	Secret string
+
+	// Timeout is the default request timeout. Blob transfers are only
+	// bounded by their context.
 	Timeout time.Duration
 
 	// MaxRetries is the maximum number of retry attempts.
@@ -40,9 +46,8 @@ func DefaultClientConfig() ClientConfig {
 	}
 }
 
-// Client implements NodeClient for communicating with a remote node.
-// Note: This is a simplified HTTP-based client. Full gRPC implementation
-// requires generated protobuf code.
+// Client implements NodeClient over the internal HTTP blob API served by
+// Server.Handler.
 type Client struct {
 	config     ClientConfig
 	logger     zerolog.Logger
@@ -72,79 +77,73 @@ func NewClient(config ClientConfig, logger zerolog.Logger) (*Client, error) {
 			Str("component", "cluster-client").
 			Str("remote_address", config.Address).
 			Logger(),
-		httpClient: &http.Client{
-			Timeout: config.Timeout,
-		},
+		httpClient: &http.Client{},
 	}, nil
 }
 
 // Ping checks if the node is alive and returns its status.
 func (c *Client) Ping(ctx context.Context) (*Node, error) {
-	c.mu.RLock()
-	if c.closed {
-		c.mu.RUnlock()
-		return nil, errors.New("client is closed")
+	ctx, cancel := context.WithTimeout(ctx, c.config.Timeout)
+	defer cancel()
+
+	resp, err := c.do(ctx, http.MethodGet, pingPath, nil, -1, nil)
+	if err != nil {
+		return nil, err
 	}
-	c.mu.RUnlock()
+	defer resp.Body.Close()
 
-	// TODO: Implement actual gRPC call when protobuf is generated
-	// For now, return a placeholder indicating the node is reachable
-	c.logger.Debug().Msg("Ping request")
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.statusError(resp)
+	}
 
-	return &Node{
-		ID:            c.config.NodeID,
-		Address:       c.config.Address,
-		Status:        NodeStatusHealthy,
-		LastHeartbeat: time.Now(),
-	}, nil
+	node := &Node{}
+	if err := json.NewDecoder(resp.Body).Decode(node); err != nil {
+		return nil, fmt.Errorf("failed to decode ping response: %w", err)
+	}
+	return node, nil
 }
 
-// TransferBlob transfers a blob to this node.
+// TransferBlob transfers a blob to this node. The transfer is retried only
+// if reader can be rewound.
 func (c *Client) TransferBlob(ctx context.Context, contentHash string, size int64, reader io.Reader) error {
-	c.mu.RLock()
-	if c.closed {
-		c.mu.RUnlock()
-		return errors.New("client is closed")
-	}
-	c.mu.RUnlock()
-
 	c.logger.Debug().
 		Str("content_hash", contentHash).
 		Int64("size", size).
 		Msg("Initiating blob transfer")
 
-	// TODO: Implement actual gRPC streaming call
-	// For now, simulate transfer with retry logic
+	seeker, canRetry := reader.(io.Seeker)
+	attempts := 1
+	if canRetry {
+		attempts = c.config.MaxRetries
+	}
+
 	var lastErr error
-	for attempt := 0; attempt < c.config.MaxRetries; attempt++ {
+	for attempt := 0; attempt < attempts; attempt++ {
 		if attempt > 0 {
 			select {
 			case <-ctx.Done():
 				return ctx.Err()
 			case <-time.After(c.config.RetryDelay):
 			}
+			if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+				return fmt.Errorf("%w: %v", ErrTransferFailed, err)
+			}
 		}
 
-		// Read all data (for retry capability)
-		data, err := io.ReadAll(reader)
+		resp, err := c.do(ctx, http.MethodPut, blobPath(contentHash), io.NopCloser(reader), size, nil)
 		if err != nil {
-			lastErr = fmt.Errorf("failed to read blob data: %w", err)
+			lastErr = err
 			continue
 		}
+		resp.Body.Close()
 
-		if int64(len(data)) != size {
-			lastErr = fmt.Errorf("size mismatch: expected %d, got %d", size, len(data))
-			continue
+		if resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusOK {
+			return nil
+		}
+		lastErr = c.statusError(resp)
+		if resp.StatusCode < http.StatusInternalServerError {
+			break
 		}
-
-		// TODO: Send via gRPC
-		c.logger.Info().
-			Str("content_hash", contentHash).
-			Int64("size", size).
-			Int("attempt", attempt+1).
-			Msg("Blob transfer simulated (gRPC not implemented)")
-
-		return nil
 	}
 
 	return fmt.Errorf("%w: %v", ErrTransferFailed, lastErr)
@@ -152,77 +151,145 @@ func (c *Client) TransferBlob(ctx context.Context, contentHash string, size int6
 
 // RetrieveBlob retrieves a blob from this node.
 func (c *Client) RetrieveBlob(ctx context.Context, contentHash string) (io.ReadCloser, error) {
-	c.mu.RLock()
-	if c.closed {
-		c.mu.RUnlock()
-		return nil, errors.New("client is closed")
-	}
-	c.mu.RUnlock()
-
-	c.logger.Debug().
-		Str("content_hash", contentHash).
-		Msg("Retrieving blob")
-
-	// TODO: Implement actual gRPC streaming call
-	// For now, return an error indicating not implemented
-	return nil, errors.New("gRPC not implemented - requires protobuf generation")
+	return c.retrieve(ctx, contentHash, nil)
 }
 
-// RetrieveBlobRange retrieves a range of bytes from a blob.
+// RetrieveBlobRange retrieves a range of bytes from a blob. A length of 0
+// reads to the end of the blob.
 func (c *Client) RetrieveBlobRange(ctx context.Context, contentHash string, offset, length int64) (io.ReadCloser, error) {
-	c.mu.RLock()
-	if c.closed {
-		c.mu.RUnlock()
-		return nil, errors.New("client is closed")
+	byteRange := "bytes=" + strconv.FormatInt(offset, 10) + "-"
+	if length > 0 {
+		byteRange += strconv.FormatInt(offset+length-1, 10)
 	}
-	c.mu.RUnlock()
+	return c.retrieve(ctx, contentHash, http.Header{"Range": []string{byteRange}})
+}
 
+// retrieve streams a blob or a range of it.
+func (c *Client) retrieve(ctx context.Context, contentHash string, header http.Header) (io.ReadCloser, error) {
 	c.logger.Debug().
 		Str("content_hash", contentHash).
-		Int64("offset", offset).
-		Int64("length", length).
-		Msg("Retrieving blob range")
+		Msg("Retrieving blob")
+
+	resp, err := c.do(ctx, http.MethodGet, blobPath(contentHash), nil, -1, header)
+	if err != nil {
+		return nil, err
+	}
 
-	// TODO: Implement actual gRPC streaming call
-	return nil, errors.New("gRPC not implemented - requires protobuf generation")
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusPartialContent:
+		return resp.Body, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, ErrBlobNotFound
+	default:
+		defer resp.Body.Close()
+		return nil, c.statusError(resp)
+	}
 }
 
 // DeleteBlob deletes a blob from this node.
 func (c *Client) DeleteBlob(ctx context.Context, contentHash string) error {
-	c.mu.RLock()
-	if c.closed {
-		c.mu.RUnlock()
-		return errors.New("client is closed")
-	}
-	c.mu.RUnlock()
+	ctx, cancel := context.WithTimeout(ctx, c.config.Timeout)
+	defer cancel()
 
 	c.logger.Debug().
 		Str("content_hash", contentHash).
 		Msg("Deleting blob")
 
-	// TODO: Implement actual gRPC call
-	c.logger.Info().
-		Str("content_hash", contentHash).
-		Msg("Blob deletion simulated (gRPC not implemented)")
+	resp, err := c.do(ctx, http.MethodDelete, blobPath(contentHash), nil, -1, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
 
-	return nil
+	switch resp.StatusCode {
+	case http.StatusNoContent, http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		return ErrBlobNotFound
+	default:
+		return c.statusError(resp)
+	}
 }
 
 // BlobExists checks if a blob exists on this node.
 func (c *Client) BlobExists(ctx context.Context, contentHash string) (bool, error) {
-	c.mu.RLock()
-	if c.closed {
-		c.mu.RUnlock()
-		return false, errors.New("client is closed")
+	ctx, cancel := context.WithTimeout(ctx, c.config.Timeout)
+	defer cancel()
+
+	resp, err := c.do(ctx, http.MethodHead, blobPath(contentHash), nil, -1, nil)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, c.statusError(resp)
+	}
+}
+
+// BlobSize returns the size of a blob on this node.
+func (c *Client) BlobSize(ctx context.Context, contentHash string) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.config.Timeout)
+	defer cancel()
+
+	resp, err := c.do(ctx, http.MethodHead, blobPath(contentHash), nil, -1, nil)
+	if err != nil {
+		return 0, err
 	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.ContentLength, nil
+	case http.StatusNotFound:
+		return 0, ErrBlobNotFound
+	default:
+		return 0, c.statusError(resp)
+	}
+}
+
+// do sends an authenticated request to the node. size is the body length,
+// or -1 without a body.
+func (c *Client) do(ctx context.Context, method, path string, body io.ReadCloser, size int64, header http.Header) (*http.Response, error) {
+	c.mu.RLock()
+	closed := c.closed
 	c.mu.RUnlock()
+	if closed {
+		return nil, errors.New("client is closed")
+	}
 
-	c.logger.Debug().
-		Str("content_hash", contentHash).
-		Msg("Checking blob existence")
+	req, err := http.NewRequestWithContext(ctx, method, "http://"+c.config.Address+path, body)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if size >= 0 {
+		req.ContentLength = size
+		if size == 0 {
+			req.Body = http.NoBody
+		}
+	}
+	req.Header.Set("Authorization", "Bearer "+c.config.Secret)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrConnectionFailed, err)
+	}
+	return resp, nil
+}
 
-	// TODO: Implement actual gRPC call
-	return false, errors.New("gRPC not implemented - requires protobuf generation")
+// statusError describes an unexpected response.
+func (c *Client) statusError(resp *http.Response) error {
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("node %s: %s: %s", c.config.Address, resp.Status, bytes.TrimSpace(message))
 }
 
 // Close closes the client connection.
@@ -242,16 +309,24 @@ func (c *Client) Close() error {
 
 // ClientPool manages a pool of clients to remote nodes.
 type ClientPool struct {
-	mu      sync.RWMutex
-	clients map[string]*Client // nodeID -> client
-	logger  zerolog.Logger
+	mu       sync.RWMutex
+	clients  map[string]*Client // nodeID -> client
+	template ClientConfig
+	logger   zerolog.Logger
 }
 
 // NewClientPool creates a new client pool.
 func NewClientPool(logger zerolog.Logger) *ClientPool {
+	return NewClientPoolWithConfig(DefaultClientConfig(), logger)
+}
+
+// NewClientPoolWithConfig creates a client pool whose clients use template
+// with the node's ID and address filled in.
+func NewClientPoolWithConfig(template ClientConfig, logger zerolog.Logger) *ClientPool {
 	return &ClientPool{
-		clients: make(map[string]*Client),
-		logger:  logger.With().Str("component", "client-pool").Logger(),
+		clients:  make(map[string]*Client),
+		template: template,
+		logger:   logger.With().Str("component", "client-pool").Logger(),
 	}
 }
 
@@ -261,7 +336,7 @@ func (p *ClientPool) GetClient(nodeID, address string) (*Client, error) {
 	client, exists := p.clients[nodeID]
 	p.mu.RUnlock()
 
-	if exists {
+	if exists && client.config.Address == address {
 		return client, nil
 	}
 
@@ -269,15 +344,19 @@ func (p *ClientPool) GetClient(nodeID, address string) (*Client, error) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	// Double-check after acquiring write lock
+	// Double-check after acquiring write lock; a node that restarted on a
+	// new address gets a new client
 	if client, exists = p.clients[nodeID]; exists {
-		return client, nil
+		if client.config.Address == address {
+			return client, nil
+		}
+		_ = client.Close()
 	}
 
-	client, err := NewClient(ClientConfig{
-		NodeID:  nodeID,
-		Address: address,
-	}, p.logger)
+	config := p.template
+	config.NodeID = nodeID
+	config.Address = address
+	client, err := NewClient(config, p.logger)
 	if err != nil {
 		return nil, err
 	}
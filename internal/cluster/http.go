@@ -0,0 +1,182 @@
+package cluster
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/prn-tf/alexander-storage/internal/storage"
+)
+
+// Paths of the internal blob API.
+const (
+	apiPrefix = "/_alexander/cluster"
+	pingPath  = apiPrefix + "/ping"
+	blobsPath = apiPrefix + "/blobs/"
+)
+
+// blobPath returns the API path of a blob.
+func blobPath(contentHash string) string {
+	return blobsPath + contentHash
+}
+
+// Handler returns the internal blob API. Every request must carry the
+// cluster secret as a bearer token.
+//
+//	GET    /_alexander/cluster/ping          node info
+//	GET    /_alexander/cluster/blobs/{hash}  blob content, Range supported
+//	HEAD   /_alexander/cluster/blobs/{hash}  existence and size
+//	PUT    /_alexander/cluster/blobs/{hash}  store a blob, verified by hash
+//	DELETE /_alexander/cluster/blobs/{hash}  delete a blob
+func (s *Server) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.authorized(r) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		if r.URL.Path == pingPath {
+			if r.Method != http.MethodGet {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(s.GetSelfInfo())
+			return
+		}
+
+		contentHash, ok := strings.CutPrefix(r.URL.Path, blobsPath)
+		if !ok || !storage.ValidContentHash(contentHash) {
+			http.NotFound(w, r)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			s.serveBlob(w, r, contentHash)
+		case http.MethodHead:
+			s.serveBlobHead(w, r, contentHash)
+		case http.MethodPut:
+			s.receiveBlob(w, r, contentHash)
+		case http.MethodDelete:
+			s.serveBlobDelete(w, r, contentHash)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// authorized checks the bearer token against the cluster secret.
+func (s *Server) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || s.config.Secret == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.config.Secret)) == 1
+}
+
+// serveBlob streams a blob or the byte range requested.
+func (s *Server) serveBlob(w http.ResponseWriter, r *http.Request, contentHash string) {
+	var (
+		reader io.ReadCloser
+		err    error
+		status = http.StatusOK
+	)
+	if header := r.Header.Get("Range"); header != "" {
+		offset, length, ok := parseByteRange(header)
+		if !ok {
+			http.Error(w, "invalid range", http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		reader, err = s.RetrieveBlobRange(r.Context(), contentHash, offset, length)
+		status = http.StatusPartialContent
+	} else {
+		reader, err = s.RetrieveBlob(r.Context(), contentHash)
+	}
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	defer reader.Close()
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.WriteHeader(status)
+	if _, err := io.Copy(w, reader); err != nil {
+		s.logger.Debug().Err(err).Str("content_hash", contentHash).Msg("Blob stream interrupted")
+	}
+}
+
+// serveBlobHead reports whether a blob exists and its size.
+func (s *Server) serveBlobHead(w http.ResponseWriter, r *http.Request, contentHash string) {
+	size, err := s.storage.GetSize(r.Context(), contentHash)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	w.WriteHeader(http.StatusOK)
+}
+
+// receiveBlob stores a blob pushed by a peer.
+func (s *Server) receiveBlob(w http.ResponseWriter, r *http.Request, contentHash string) {
+	if r.ContentLength < 0 {
+		http.Error(w, "content length required", http.StatusLengthRequired)
+		return
+	}
+	if err := s.TransferBlob(r.Context(), contentHash, r.ContentLength, r.Body); err != nil {
+		s.writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// serveBlobDelete deletes a blob.
+func (s *Server) serveBlobDelete(w http.ResponseWriter, r *http.Request, contentHash string) {
+	if err := s.DeleteBlob(r.Context(), contentHash); err != nil {
+		s.writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeError maps an error to a status code.
+func (s *Server) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrBlobNotFound), errors.Is(err, storage.ErrBlobNotFound):
+		http.Error(w, ErrBlobNotFound.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrTransferFailed):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	default:
+		s.logger.Error().Err(err).Msg("Cluster request failed")
+		http.Error(w, "internal error", http.StatusInternalServerError)
+	}
+}
+
+// parseByteRange parses a single "bytes=first-last" or "bytes=first-"
+// range into an offset and length, where 0 means to the end.
+func parseByteRange(header string) (offset, length int64, ok bool) {
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok {
+		return 0, 0, false
+	}
+	first, last, ok := strings.Cut(spec, "-")
+	if !ok {
+		return 0, 0, false
+	}
+	offset, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || offset < 0 {
+		return 0, 0, false
+	}
+	if last == "" {
+		return offset, 0, true
+	}
+	end, err := strconv.ParseInt(last, 10, 64)
+	if err != nil || end < offset {
+		return 0, 0, false
+	}
+	return offset, end - offset + 1, true
+}
@@ -1,4 +1,4 @@
-// Package cluster provides gRPC-based inter-node communication for
+// Package cluster provides inter-node communication and blob placement for
 // multi-node Alexander Storage deployments.
 package cluster
 
@@ -44,7 +44,7 @@ type Node struct {
 	// ID is the unique identifier for this node.
 	ID string `json:"id"`
 
-	// Address is the gRPC address (host:port) of this node.
+	// Address is the internal API address (host:port) of this node.
 	Address string `json:"address"`
 
 	// Role indicates the storage tier of this node.
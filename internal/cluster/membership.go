@@ -0,0 +1,222 @@
+package cluster
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// MembershipConfig contains cluster membership configuration.
+type MembershipConfig struct {
+	// NodeID is this node's ID.
+	NodeID string
+
+	// Address is the host:port of this node's internal blob API.
+	Address string
+
+	// HeartbeatInterval is how often this node announces itself and
+	// refreshes the member list.
+	HeartbeatInterval time.Duration
+
+	// HeartbeatTimeout is how long a node stays a member without a heartbeat.
+	HeartbeatTimeout time.Duration
+
+	// VirtualNodes is the number of ring positions per node.
+	VirtualNodes int
+}
+
+// Membership tracks the live nodes of the cluster through heartbeats
+// stored in the shared database, and maintains the placement ring.
+type Membership struct {
+	repo      repository.ClusterNodeRepository
+	config    MembershipConfig
+	logger    zerolog.Logger
+	startedAt time.Time
+
+	ring      atomic.Pointer[Ring]
+	addresses atomic.Pointer[map[string]string]
+
+	listenersMu sync.Mutex
+	listeners   []func(*Ring)
+
+	// Control
+	mu       sync.Mutex
+	running  bool
+	stopChan chan struct{}
+	doneChan chan struct{}
+}
+
+// NewMembership creates the membership of this node. The ring contains
+// only this node until the first refresh.
+func NewMembership(repo repository.ClusterNodeRepository, config MembershipConfig, logger zerolog.Logger) *Membership {
+	if config.HeartbeatInterval <= 0 {
+		config.HeartbeatInterval = DefaultServerConfig().HeartbeatInterval
+	}
+	if config.HeartbeatTimeout <= 0 {
+		config.HeartbeatTimeout = DefaultServerConfig().HeartbeatTimeout
+	}
+
+	m := &Membership{
+		repo:      repo,
+		config:    config,
+		logger:    logger.With().Str("component", "cluster-membership").Str("node_id", config.NodeID).Logger(),
+		startedAt: time.Now().UTC(),
+		stopChan:  make(chan struct{}),
+		doneChan:  make(chan struct{}),
+	}
+	m.ring.Store(NewRing([]string{config.NodeID}, config.VirtualNodes))
+	m.addresses.Store(&map[string]string{config.NodeID: config.Address})
+	return m
+}
+
+// NodeID returns this node's ID.
+func (m *Membership) NodeID() string {
+	return m.config.NodeID
+}
+
+// Ring returns the current placement ring.
+func (m *Membership) Ring() *Ring {
+	return m.ring.Load()
+}
+
+// Address returns the internal API address of a live node.
+func (m *Membership) Address(nodeID string) (string, bool) {
+	address, ok := (*m.addresses.Load())[nodeID]
+	return address, ok
+}
+
+// OnChange registers a function called with the new ring whenever a node
+// joins or leaves.
+func (m *Membership) OnChange(fn func(*Ring)) {
+	m.listenersMu.Lock()
+	defer m.listenersMu.Unlock()
+	m.listeners = append(m.listeners, fn)
+}
+
+// Start announces this node, loads the member list and keeps both up to date.
+func (m *Membership) Start(ctx context.Context) error {
+	m.mu.Lock()
+	if m.running {
+		m.mu.Unlock()
+		return nil
+	}
+	m.running = true
+	m.mu.Unlock()
+
+	if err := m.Refresh(ctx); err != nil {
+		m.mu.Lock()
+		m.running = false
+		m.mu.Unlock()
+		return err
+	}
+
+	m.logger.Info().
+		Str("address", m.config.Address).
+		Strs("nodes", m.Ring().Nodes()).
+		Msg("Joined cluster")
+
+	go m.runLoop()
+	return nil
+}
+
+// Stop stops the heartbeat and removes this node from the cluster, so the
+// other nodes take over its blobs without waiting for the timeout.
+func (m *Membership) Stop() {
+	m.mu.Lock()
+	if !m.running {
+		m.mu.Unlock()
+		return
+	}
+	m.running = false
+	m.mu.Unlock()
+
+	close(m.stopChan)
+	<-m.doneChan
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := m.repo.Delete(ctx, m.config.NodeID); err != nil {
+		m.logger.Warn().Err(err).Msg("Failed to leave cluster")
+		return
+	}
+	m.logger.Info().Msg("Left cluster")
+}
+
+// runLoop sends heartbeats until Stop is called.
+func (m *Membership) runLoop() {
+	defer close(m.doneChan)
+
+	ticker := time.NewTicker(m.config.HeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stopChan:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), m.config.HeartbeatInterval)
+			if err := m.Refresh(ctx); err != nil {
+				m.logger.Error().Err(err).Msg("Failed to refresh cluster membership")
+			}
+			cancel()
+		}
+	}
+}
+
+// Refresh sends a heartbeat and rebuilds the ring from the live nodes.
+func (m *Membership) Refresh(ctx context.Context) error {
+	err := m.repo.Heartbeat(ctx, &domain.ClusterNode{
+		ID:        m.config.NodeID,
+		Address:   m.config.Address,
+		StartedAt: m.startedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	nodes, err := m.repo.ListLive(ctx, m.config.HeartbeatTimeout)
+	if err != nil {
+		return err
+	}
+
+	ids := make([]string, 0, len(nodes))
+	addresses := make(map[string]string, len(nodes))
+	for _, node := range nodes {
+		ids = append(ids, node.ID)
+		addresses[node.ID] = node.Address
+	}
+	m.addresses.Store(&addresses)
+
+	previous := m.Ring().Nodes()
+	ring := NewRing(ids, m.config.VirtualNodes)
+	if slices.Equal(previous, ring.Nodes()) {
+		return nil
+	}
+	m.ring.Store(ring)
+
+	for _, id := range ring.Nodes() {
+		if !slices.Contains(previous, id) {
+			m.logger.Info().Str("joined", id).Msg("Cluster node joined")
+		}
+	}
+	for _, id := range previous {
+		if !ring.Contains(id) {
+			m.logger.Warn().Str("left", id).Msg("Cluster node left")
+		}
+	}
+
+	m.listenersMu.Lock()
+	listeners := slices.Clone(m.listeners)
+	m.listenersMu.Unlock()
+	for _, fn := range listeners {
+		fn(ring)
+	}
+	return nil
+}
@@ -0,0 +1,266 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/storage"
+	"github.com/prn-tf/alexander-storage/internal/storage/filesystem"
+)
+
+func blobHash(i int) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("blob-%d", i)))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestRing_Owners(t *testing.T) {
+	ring := NewRing([]string{"node-c", "node-a", "node-b", "node-a"}, 0)
+	assert.Equal(t, []string{"node-a", "node-b", "node-c"}, ring.Nodes())
+
+	owners := ring.Owners(blobHash(1), 2)
+	require.Len(t, owners, 2)
+	assert.NotEqual(t, owners[0], owners[1])
+	assert.Equal(t, owners, ring.Owners(blobHash(1), 2))
+
+	// More owners than nodes are clamped
+	assert.Len(t, ring.Owners(blobHash(1), 5), 3)
+	assert.Empty(t, NewRing(nil, 0).Owners(blobHash(1), 1))
+}
+
+func TestRing_JoinMovesOnlyItsShare(t *testing.T) {
+	before := NewRing([]string{"node-a", "node-b", "node-c"}, 0)
+	after := NewRing([]string{"node-a", "node-b", "node-c", "node-d"}, 0)
+
+	const blobs = 4000
+	moved := 0
+	for i := 0; i < blobs; i++ {
+		was, is := before.Owners(blobHash(i), 1)[0], after.Owners(blobHash(i), 1)[0]
+		if was != is {
+			// Blobs only move to the new node
+			assert.Equal(t, "node-d", is)
+			moved++
+		}
+	}
+	// About a quarter of the blobs move to the fourth node
+	assert.InDelta(t, blobs/4, moved, blobs/10)
+}
+
+// memoryNodeRepository is a ClusterNodeRepository shared by the nodes of a test.
+type memoryNodeRepository struct {
+	mu    sync.Mutex
+	nodes map[string]*domain.ClusterNode
+}
+
+func (r *memoryNodeRepository) Heartbeat(_ context.Context, node *domain.ClusterNode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	node.LastHeartbeat = time.Now()
+	copied := *node
+	r.nodes[node.ID] = &copied
+	return nil
+}
+
+func (r *memoryNodeRepository) ListLive(_ context.Context, timeout time.Duration) ([]*domain.ClusterNode, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var nodes []*domain.ClusterNode
+	for _, node := range r.nodes {
+		if time.Since(node.LastHeartbeat) <= timeout {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes, nil
+}
+
+func (r *memoryNodeRepository) Delete(_ context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.nodes, id)
+	return nil
+}
+
+type testNode struct {
+	id         string
+	local      *filesystem.Storage
+	membership *Membership
+	backend    *PlacementBackend
+}
+
+// newTestCluster starts nodes serving the internal API over httptest
+// servers, all refreshed so that every ring has every node.
+func newTestCluster(t *testing.T, ids []string, replicationFactor int) []*testNode {
+	t.Helper()
+	repo := &memoryNodeRepository{nodes: make(map[string]*domain.ClusterNode)}
+
+	clientConfig := DefaultClientConfig()
+	clientConfig.Secret = "cluster-secret"
+	clientConfig.MaxRetries = 1
+
+	nodes := make([]*testNode, 0, len(ids))
+	for _, id := range ids {
+		dir := t.TempDir()
+		local, err := filesystem.NewStorage(filesystem.Config{
+			DataDir: filepath.Join(dir, "blobs"),
+			TempDir: filepath.Join(dir, "tmp"),
+		}, zerolog.Nop())
+		require.NoError(t, err)
+
+		server, err := NewServer(ServerConfig{NodeID: id, Address: "pending", Secret: "cluster-secret"}, local, zerolog.Nop())
+		require.NoError(t, err)
+		httpServer := httptest.NewServer(server.Handler())
+		t.Cleanup(httpServer.Close)
+
+		membership := NewMembership(repo, MembershipConfig{
+			NodeID:  id,
+			Address: strings.TrimPrefix(httpServer.URL, "http://"),
+		}, zerolog.Nop())
+		clients := NewClientPoolWithConfig(clientConfig, zerolog.Nop())
+		t.Cleanup(func() { clients.Close() })
+
+		nodes = append(nodes, &testNode{
+			id:         id,
+			local:      local,
+			membership: membership,
+			backend:    NewPlacementBackend(local, membership, clients, replicationFactor, zerolog.Nop()),
+		})
+	}
+
+	refreshAll(t, nodes)
+	for _, node := range nodes {
+		require.Equal(t, ids, node.membership.Ring().Nodes())
+	}
+	return nodes
+}
+
+func refreshAll(t *testing.T, nodes []*testNode) {
+	t.Helper()
+	for range 2 {
+		for _, node := range nodes {
+			require.NoError(t, node.membership.Refresh(context.Background()))
+		}
+	}
+}
+
+// holders returns the IDs of the nodes with a local copy of a blob.
+func holders(t *testing.T, nodes []*testNode, contentHash string) []string {
+	t.Helper()
+	var ids []string
+	for _, node := range nodes {
+		exists, err := node.local.Exists(context.Background(), contentHash)
+		require.NoError(t, err)
+		if exists {
+			ids = append(ids, node.id)
+		}
+	}
+	return ids
+}
+
+func TestPlacementBackend_StoreOnOwners(t *testing.T) {
+	ctx := context.Background()
+	nodes := newTestCluster(t, []string{"node-a", "node-b", "node-c"}, 2)
+
+	data := []byte("placed across the cluster")
+	contentHash, err := nodes[0].backend.Store(ctx, bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+
+	owners := nodes[0].membership.Ring().Owners(contentHash, 2)
+	assert.ElementsMatch(t, owners, holders(t, nodes, contentHash))
+
+	// Every node serves the blob, whether it holds a copy or not
+	for _, node := range nodes {
+		reader, err := node.backend.Retrieve(ctx, contentHash)
+		require.NoError(t, err)
+		content, err := io.ReadAll(reader)
+		reader.Close()
+		require.NoError(t, err)
+		assert.Equal(t, data, content)
+
+		reader, err = node.backend.RetrieveRange(ctx, contentHash, 7, 6)
+		require.NoError(t, err)
+		content, err = io.ReadAll(reader)
+		reader.Close()
+		require.NoError(t, err)
+		assert.Equal(t, "across", string(content))
+
+		size, err := node.backend.GetSize(ctx, contentHash)
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(data)), size)
+	}
+
+	require.NoError(t, nodes[1].backend.Delete(ctx, contentHash))
+	assert.Empty(t, holders(t, nodes, contentHash))
+
+	exists, err := nodes[2].backend.Exists(ctx, contentHash)
+	require.NoError(t, err)
+	assert.False(t, exists)
+	assert.ErrorIs(t, nodes[2].backend.Delete(ctx, contentHash), storage.ErrBlobNotFound)
+}
+
+func TestPlacementBackend_RebalanceOnJoin(t *testing.T) {
+	ctx := context.Background()
+	nodes := newTestCluster(t, []string{"node-a", "node-b", "node-c", "node-d"}, 1)
+
+	// Store blobs while node-d is away, then let it join
+	away := nodes[3]
+	require.NoError(t, away.membership.repo.Delete(ctx, away.id))
+	for _, node := range nodes[:3] {
+		require.NoError(t, node.membership.Refresh(ctx))
+	}
+
+	hashes := make([]string, 0, 40)
+	for i := 0; i < 40; i++ {
+		data := []byte(fmt.Sprintf("object %d", i))
+		contentHash, err := nodes[i%3].backend.Store(ctx, bytes.NewReader(data), int64(len(data)))
+		require.NoError(t, err)
+		hashes = append(hashes, contentHash)
+	}
+
+	refreshAll(t, nodes)
+	ring := nodes[0].membership.Ring()
+	require.True(t, ring.Contains(away.id))
+
+	for _, node := range nodes {
+		for _, contentHash := range hashes {
+			_, err := node.backend.rebalance(ctx, contentHash)
+			require.NoError(t, err)
+		}
+	}
+
+	movedToJoined := 0
+	for _, contentHash := range hashes {
+		owner := ring.Owners(contentHash, 1)[0]
+		assert.Equal(t, []string{owner}, holders(t, nodes, contentHash))
+		if owner == away.id {
+			movedToJoined++
+		}
+	}
+	assert.Positive(t, movedToJoined)
+}
+
+func TestServerHandler_RequiresSecret(t *testing.T) {
+	nodes := newTestCluster(t, []string{"node-a"}, 1)
+	address, ok := nodes[0].membership.Address("node-a")
+	require.True(t, ok)
+
+	client, err := NewClient(ClientConfig{Address: address, Secret: "wrong", MaxRetries: 1}, zerolog.Nop())
+	require.NoError(t, err)
+	defer client.Close()
+
+	_, err = client.Ping(context.Background())
+	assert.ErrorContains(t, err, "401")
+}
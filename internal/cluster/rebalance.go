@@ -0,0 +1,268 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/metrics"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+	"github.com/prn-tf/alexander-storage/internal/storage"
+)
+
+// Rebalance actions.
+const (
+	rebalancePull    = "pull"
+	rebalanceHandoff = "handoff"
+)
+
+// RebalancerConfig contains rebalancer configuration.
+type RebalancerConfig struct {
+	// Interval is the time between full passes when membership is stable.
+	Interval time.Duration
+
+	// BatchSize is the number of blobs read per page.
+	BatchSize int
+}
+
+// DefaultRebalancerConfig returns sensible defaults.
+func DefaultRebalancerConfig() RebalancerConfig {
+	return RebalancerConfig{
+		Interval:  time.Hour,
+		BatchSize: 500,
+	}
+}
+
+// Rebalancer moves blobs to their owners after nodes join or leave. Each
+// node walks the blob table and fixes its own share: it pulls the blobs it
+// owns but lacks, and hands off the blobs it holds but no longer owns,
+// deleting its copy once every owner has one.
+type Rebalancer struct {
+	blobRepo repository.BlobRepository
+	backend  *PlacementBackend
+	metrics  *metrics.Metrics
+	logger   zerolog.Logger
+	config   RebalancerConfig
+
+	trigger chan struct{}
+
+	// Control
+	mu       sync.Mutex
+	running  bool
+	stopChan chan struct{}
+	doneChan chan struct{}
+}
+
+// NewRebalancer creates a rebalancer for the blobs placed by backend.
+func NewRebalancer(blobRepo repository.BlobRepository, backend *PlacementBackend, m *metrics.Metrics, logger zerolog.Logger, config RebalancerConfig) *Rebalancer {
+	if config.Interval <= 0 {
+		config.Interval = DefaultRebalancerConfig().Interval
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultRebalancerConfig().BatchSize
+	}
+
+	return &Rebalancer{
+		blobRepo: blobRepo,
+		backend:  backend,
+		metrics:  m,
+		logger:   logger.With().Str("component", "cluster-rebalancer").Logger(),
+		config:   config,
+		trigger:  make(chan struct{}, 1),
+		stopChan: make(chan struct{}),
+		doneChan: make(chan struct{}),
+	}
+}
+
+// Start runs a pass now, on every membership change and every interval.
+func (r *Rebalancer) Start() {
+	r.mu.Lock()
+	if r.running {
+		r.mu.Unlock()
+		return
+	}
+	r.running = true
+	r.mu.Unlock()
+
+	r.backend.membership.OnChange(func(ring *Ring) {
+		if r.metrics != nil {
+			r.metrics.ClusterNodes.Set(float64(len(ring.Nodes())))
+		}
+		r.Trigger()
+	})
+	if r.metrics != nil {
+		r.metrics.ClusterNodes.Set(float64(len(r.backend.membership.Ring().Nodes())))
+	}
+
+	r.logger.Info().
+		Dur("interval", r.config.Interval).
+		Msg("Starting cluster rebalancer")
+
+	r.Trigger()
+	go r.runLoop()
+}
+
+// Stop stops the rebalancer, interrupting a pass in progress.
+func (r *Rebalancer) Stop() {
+	r.mu.Lock()
+	if !r.running {
+		r.mu.Unlock()
+		return
+	}
+	r.running = false
+	r.mu.Unlock()
+
+	close(r.stopChan)
+	<-r.doneChan
+
+	r.logger.Info().Msg("Cluster rebalancer stopped")
+}
+
+// Trigger schedules a pass. Triggers during a pass coalesce into one more.
+func (r *Rebalancer) Trigger() {
+	select {
+	case r.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// runLoop runs passes until Stop is called.
+func (r *Rebalancer) runLoop() {
+	defer close(r.doneChan)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-r.stopChan
+		cancel()
+	}()
+
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stopChan:
+			return
+		case <-r.trigger:
+		case <-ticker.C:
+		}
+
+		start := time.Now()
+		moved, err := r.Run(ctx)
+		if err != nil && ctx.Err() == nil {
+			r.logger.Error().Err(err).Int("moved", moved).Msg("Rebalance pass failed")
+			continue
+		}
+		if moved > 0 {
+			r.logger.Info().
+				Int("moved", moved).
+				Dur("duration", time.Since(start)).
+				Msg("Rebalance pass completed")
+		}
+	}
+}
+
+// Run makes one pass over all blobs and returns the number moved. A blob
+// that cannot be moved is logged and left for the next pass.
+func (r *Rebalancer) Run(ctx context.Context) (int, error) {
+	before := time.Now()
+	afterHash := ""
+	moved := 0
+
+	for {
+		blobs, err := r.blobRepo.ListCreatedBefore(ctx, before, afterHash, r.config.BatchSize)
+		if err != nil {
+			return moved, fmt.Errorf("failed to list blobs: %w", err)
+		}
+
+		for _, blob := range blobs {
+			if ctx.Err() != nil {
+				return moved, ctx.Err()
+			}
+			// Composite and delta blobs have no content of their own, and
+			// unreferenced blobs are left to GC
+			if blob.IsComposite() || blob.IsDelta() || blob.RefCount <= 0 {
+				continue
+			}
+
+			action, err := r.backend.rebalance(ctx, blob.ContentHash)
+			if err != nil {
+				r.logger.Warn().Err(err).
+					Str("content_hash", blob.ContentHash).
+					Str("action", action).
+					Msg("Failed to rebalance blob")
+				if action != "" {
+					r.record(action, "error")
+				}
+				continue
+			}
+			if action == "" {
+				continue
+			}
+			r.record(action, "success")
+			moved++
+		}
+
+		if len(blobs) < r.config.BatchSize {
+			return moved, nil
+		}
+		afterHash = blobs[len(blobs)-1].ContentHash
+	}
+}
+
+// record counts a rebalanced blob.
+func (r *Rebalancer) record(action, result string) {
+	if r.metrics != nil {
+		r.metrics.RecordClusterRebalance(action, result)
+	}
+}
+
+// rebalance moves one blob toward its owners on the current ring and
+// returns the action needed, or "" if this node's copy is already placed.
+func (b *PlacementBackend) rebalance(ctx context.Context, contentHash string) (string, error) {
+	self := b.membership.NodeID()
+	owners := b.membership.Ring().Owners(contentHash, b.replicas)
+
+	local, err := b.local.Exists(ctx, contentHash)
+	if err != nil {
+		return "", err
+	}
+
+	if slices.Contains(owners, self) {
+		if local {
+			return "", nil
+		}
+		return rebalancePull, b.pull(ctx, contentHash)
+	}
+	if !local {
+		return "", nil
+	}
+
+	for _, owner := range owners {
+		client, err := b.client(owner)
+		if err != nil {
+			return rebalanceHandoff, err
+		}
+		exists, err := client.BlobExists(ctx, contentHash)
+		if err != nil {
+			return rebalanceHandoff, err
+		}
+		if exists {
+			continue
+		}
+		if err := b.push(ctx, owner, contentHash); err != nil {
+			return rebalanceHandoff, fmt.Errorf("node %s: %w", owner, err)
+		}
+	}
+
+	if err := b.local.Delete(ctx, contentHash); err != nil && !errors.Is(err, storage.ErrBlobNotFound) {
+		return rebalanceHandoff, err
+	}
+	return rebalanceHandoff, nil
+}
@@ -0,0 +1,100 @@
+package cluster
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"slices"
+	"sort"
+	"strconv"
+)
+
+// DefaultVirtualNodes is the number of ring positions per node. More
+// positions spread blobs more evenly at the cost of a larger ring.
+const DefaultVirtualNodes = 128
+
+// Ring is a consistent hash ring that places blobs on nodes. When a node
+// joins or leaves, only the blobs next to its positions change owners.
+// A Ring is immutable and safe for concurrent use.
+type Ring struct {
+	nodes  []string
+	points []ringPoint
+}
+
+// ringPoint is a virtual node: a position on the ring owned by a node.
+type ringPoint struct {
+	hash uint64
+	node string
+}
+
+// NewRing creates a ring over the given node IDs with vnodes positions each.
+func NewRing(nodeIDs []string, vnodes int) *Ring {
+	if vnodes <= 0 {
+		vnodes = DefaultVirtualNodes
+	}
+
+	nodes := slices.Clone(nodeIDs)
+	sort.Strings(nodes)
+	nodes = slices.Compact(nodes)
+
+	points := make([]ringPoint, 0, len(nodes)*vnodes)
+	for _, node := range nodes {
+		for i := 0; i < vnodes; i++ {
+			sum := sha256.Sum256([]byte(node + "#" + strconv.Itoa(i)))
+			points = append(points, ringPoint{hash: binary.BigEndian.Uint64(sum[:8]), node: node})
+		}
+	}
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].hash != points[j].hash {
+			return points[i].hash < points[j].hash
+		}
+		return points[i].node < points[j].node
+	})
+
+	return &Ring{nodes: nodes, points: points}
+}
+
+// Nodes returns the node IDs on the ring, sorted.
+func (r *Ring) Nodes() []string {
+	return slices.Clone(r.nodes)
+}
+
+// Contains reports whether nodeID is on the ring.
+func (r *Ring) Contains(nodeID string) bool {
+	_, found := slices.BinarySearch(r.nodes, nodeID)
+	return found
+}
+
+// Owners returns the n distinct nodes that hold a blob, in preference
+// order: the first node clockwise from the blob's position, then the next
+// distinct nodes. Fewer are returned if the ring has fewer than n nodes.
+func (r *Ring) Owners(contentHash string, n int) []string {
+	n = min(n, len(r.nodes))
+	if n <= 0 {
+		return nil
+	}
+
+	key := ringKey(contentHash)
+	start := sort.Search(len(r.points), func(i int) bool { return r.points[i].hash >= key })
+
+	owners := make([]string, 0, n)
+	for i := 0; len(owners) < n && i < len(r.points); i++ {
+		node := r.points[(start+i)%len(r.points)].node
+		if !slices.Contains(owners, node) {
+			owners = append(owners, node)
+		}
+	}
+	return owners
+}
+
+// ringKey returns the ring position of a blob. Content hashes are SHA-256
+// hex digests and already uniformly distributed; anything else is hashed.
+func ringKey(contentHash string) uint64 {
+	if len(contentHash) >= 16 {
+		if b, err := hex.DecodeString(contentHash[:16]); err == nil {
+			return binary.BigEndian.Uint64(b)
+		}
+	}
+	sum := sha256.Sum256([]byte(contentHash))
+	return binary.BigEndian.Uint64(sum[:8])
+}
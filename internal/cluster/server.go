@@ -1,4 +1,4 @@
-// Package cluster provides gRPC-based inter-node communication for
+// Package cluster provides inter-node communication and blob placement for
 // multi-node Alexander Storage deployments.
 package cluster
 
@@ -31,14 +31,17 @@ type rangeReader interface {
 	RetrieveRange(ctx context.Context, contentHash string, offset, length int64) (io.ReadCloser, error)
 }
 
-// ServerConfig contains configuration for the gRPC server.
+// ServerConfig contains configuration for the cluster server.
 type ServerConfig struct {
 	// NodeID is the unique identifier for this node.
 	NodeID string
 
-	// Address is the gRPC listen address (host:port).
+	// Address is the internal API listen address (host:port).
 	Address string
 
+	// Secret is the shared cluster secret peers must present.
+	Secret string
+
 	// Role is the storage tier of this node.
 	Role NodeRole
 
@@ -61,7 +64,7 @@ func DefaultServerConfig() ServerConfig {
 	}
 }
 
-// Server implements the NodeService gRPC server.
+// Server implements the node side of the internal blob API; see Handler.
 type Server struct {
 	config    ServerConfig
 	logger    zerolog.Logger
@@ -84,7 +87,7 @@ type Server struct {
 	wg         sync.WaitGroup
 }
 
-// NewServer creates a new cluster gRPC server.
+// NewServer creates a new cluster server over the node's local storage.
 func NewServer(config ServerConfig, blobStorage storage.Backend, logger zerolog.Logger) (*Server, error) {
 	if config.NodeID == "" {
 		return nil, errors.New("node ID is required")
@@ -117,9 +120,8 @@ func NewServer(config ServerConfig, blobStorage storage.Backend, logger zerolog.
 	}, nil
 }
 
-// Start begins the gRPC server.
-// Note: Full gRPC implementation requires generated protobuf code.
-// This is a placeholder for the server structure.
+// Start registers this node and begins health checks. Requests are served
+// by mounting Handler on an HTTP server.
 func (s *Server) Start() error {
 	s.logger.Info().
 		Str("node_id", s.config.NodeID).
@@ -282,17 +284,27 @@ func (s *Server) RetrieveBlob(ctx context.Context, contentHash string) (io.ReadC
 
 // RetrieveBlobRange retrieves a range of bytes from a blob.
 func (s *Server) RetrieveBlobRange(ctx context.Context, contentHash string, offset, length int64) (io.ReadCloser, error) {
+	reader, err := retrieveRange(ctx, s.storage, contentHash, offset, length)
+	if err != nil {
+		if errors.Is(err, storage.ErrBlobNotFound) {
+			return nil, ErrBlobNotFound
+		}
+		return nil, err
+	}
+	return reader, nil
+}
+
+// retrieveRange reads a range of a blob from a backend, falling back to
+// skipping ahead in the full blob when the backend has no range support.
+func retrieveRange(ctx context.Context, backend storage.Backend, contentHash string, offset, length int64) (io.ReadCloser, error) {
 	// Check if storage supports range retrieval
-	if rangeStorage, ok := s.storage.(rangeReader); ok {
+	if rangeStorage, ok := backend.(rangeReader); ok {
 		return rangeStorage.RetrieveRange(ctx, contentHash, offset, length)
 	}
 
 	// Fall back to full retrieval and seek
-	reader, err := s.storage.Retrieve(ctx, contentHash)
+	reader, err := backend.Retrieve(ctx, contentHash)
 	if err != nil {
-		if errors.Is(err, storage.ErrBlobNotFound) {
-			return nil, ErrBlobNotFound
-		}
 		return nil, err
 	}
 
@@ -0,0 +1,234 @@
+package config
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/prn-tf/alexander-storage/internal/pkg/keys"
+)
+
+// Issue is a problem found by Check.
+type Issue struct {
+	// Key is the configuration key the issue is about, such as "gc.interval".
+	Key string
+
+	// Warning is set for issues the server runs with, such as a disabled
+	// garbage collector. Other issues make it fail to start or misbehave.
+	Warning bool
+
+	Message string
+}
+
+func (i Issue) String() string {
+	severity := "error"
+	if i.Warning {
+		severity = "warning"
+	}
+	return fmt.Sprintf("%s: %s: %s", severity, i.Key, i.Message)
+}
+
+// Check runs the checks Validate cannot: it looks at the filesystem, loads
+// the keys from their provider, and compares settings with each other. It
+// assumes Validate passed.
+func (c *Config) Check(ctx context.Context) []Issue {
+	var issues []Issue
+	issues = append(issues, c.checkPaths()...)
+	issues = append(issues, c.checkKeys(ctx)...)
+	issues = append(issues, c.checkPorts()...)
+	issues = append(issues, c.checkGC()...)
+	return issues
+}
+
+// checkPaths checks that the directories the server writes to can be
+// written, or created if they do not exist yet.
+func (c *Config) checkPaths() []Issue {
+	type dir struct {
+		key, path string
+	}
+	var dirs []dir
+
+	switch c.Storage.Backend {
+	case "filesystem":
+		dirs = append(dirs, dir{"storage.data_dir", c.Storage.DataDir})
+	case "erasure":
+		for i, path := range c.Storage.Erasure.DataDirs {
+			dirs = append(dirs, dir{"storage.erasure.data_dirs[" + strconv.Itoa(i) + "]", path})
+		}
+	}
+	dirs = append(dirs, dir{"storage.temp_dir", c.Storage.TempDir})
+
+	tags := make([]string, 0, len(c.Storage.Residency))
+	for tag := range c.Storage.Residency {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	for _, tag := range tags {
+		rc := c.Storage.Residency[tag]
+		dirs = append(dirs,
+			dir{"storage.residency." + tag + ".data_dir", rc.DataDir},
+			dir{"storage.residency." + tag + ".temp_dir", rc.TempDir})
+	}
+
+	if c.Database.Driver == "sqlite" {
+		dirs = append(dirs, dir{"database.path", filepath.Dir(c.Database.Path)})
+	}
+	if c.Server.TLS.Enabled && c.Server.TLS.ACME.Enabled {
+		dirs = append(dirs, dir{"server.tls.acme.cache_dir", c.Server.TLS.ACME.CacheDir})
+	}
+	if c.GC.Verify.Enabled && c.GC.Verify.QuarantineDir != "" {
+		dirs = append(dirs, dir{"gc.verify.quarantine_dir", c.GC.Verify.QuarantineDir})
+	}
+	if c.Audit.Enabled && c.Audit.File != "" {
+		dirs = append(dirs, dir{"audit.file", filepath.Dir(c.Audit.File)})
+	}
+	if c.AccessLog.Enabled && c.AccessLog.Output == "file" {
+		dirs = append(dirs, dir{"access_log.file", filepath.Dir(c.AccessLog.File)})
+	}
+
+	var issues []Issue
+	for _, d := range dirs {
+		if d.path == "" {
+			continue
+		}
+		if err := checkWritable(d.path); err != nil {
+			issues = append(issues, Issue{Key: d.key, Message: err.Error()})
+		}
+	}
+	return issues
+}
+
+// checkWritable checks that dir, or the nearest of its parents that exists
+// if it does not, is a directory files can be created in.
+func checkWritable(dir string) error {
+	path := filepath.Clean(dir)
+	for {
+		info, err := os.Stat(path)
+		if err == nil {
+			if !info.IsDir() {
+				return fmt.Errorf("%s is not a directory", path)
+			}
+			break
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return fmt.Errorf("%s has no existing parent directory", dir)
+		}
+		path = parent
+	}
+
+	f, err := os.CreateTemp(path, ".alexander-check-*")
+	if err != nil {
+		return fmt.Errorf("%s is not writable: %w", path, err)
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// checkKeys loads the keys and the TLS certificate, which checks their
+// lengths and formats.
+func (c *Config) checkKeys(ctx context.Context) []Issue {
+	var issues []Issue
+
+	provider, err := c.KeyProvider()
+	if err != nil {
+		issues = append(issues, Issue{Key: "keys.provider", Message: err.Error()})
+	} else {
+		if _, err := provider.Keyring(ctx, keys.EncryptionKey); err != nil {
+			issues = append(issues, Issue{Key: "keys." + keys.EncryptionKey, Message: err.Error()})
+		}
+		// Only SSE-S3 needs the SSE master key
+		if _, err := provider.Keyring(ctx, keys.SSEMasterKey); err != nil && !errors.Is(err, keys.ErrKeyNotFound) {
+			issues = append(issues, Issue{Key: "keys." + keys.SSEMasterKey, Message: err.Error()})
+		}
+	}
+
+	if tlsCfg := c.Server.TLS; tlsCfg.Enabled && !tlsCfg.ACME.Enabled {
+		if _, err := tls.LoadX509KeyPair(tlsCfg.CertFile, tlsCfg.KeyFile); err != nil {
+			issues = append(issues, Issue{Key: "server.tls.cert_file", Message: err.Error()})
+		}
+	}
+	return issues
+}
+
+// checkPorts checks that no two listeners share a port.
+func (c *Config) checkPorts() []Issue {
+	type listener struct {
+		key  string
+		port int
+	}
+	listeners := []listener{{"server.port", c.Server.Port}}
+	if c.Metrics.Enabled {
+		listeners = append(listeners, listener{"metrics.port", c.Metrics.Port})
+	}
+	if c.AdminAPI.Enabled && c.AdminAPI.Port != 0 {
+		listeners = append(listeners, listener{"admin_api.port", c.AdminAPI.Port})
+	}
+	if c.Website.Enabled && c.Website.Port != 0 {
+		listeners = append(listeners, listener{"website.port", c.Website.Port})
+	}
+	if c.Cluster.Enabled {
+		listeners = append(listeners, listener{"cluster.grpc_port", c.Cluster.GRPCPort})
+	}
+
+	var issues []Issue
+	used := make(map[int]string, len(listeners))
+	for _, l := range listeners {
+		if other, ok := used[l.port]; ok {
+			issues = append(issues, Issue{Key: l.key, Message: fmt.Sprintf("port %d is also used by %s", l.port, other)})
+			continue
+		}
+		used[l.port] = l.key
+	}
+	return issues
+}
+
+// checkGC checks the settings of garbage collection and of the other
+// background jobs that expire data.
+func (c *Config) checkGC() []Issue {
+	var issues []Issue
+	gc := c.GC
+
+	if !gc.Enabled {
+		issues = append(issues, Issue{Key: "gc.enabled", Warning: true,
+			Message: "garbage collection is disabled; blobs of deleted objects are never removed from storage"})
+	} else {
+		if gc.Interval <= 0 {
+			issues = append(issues, Issue{Key: "gc.interval", Message: "must be positive"})
+		}
+		if gc.BatchSize <= 0 || gc.Workers <= 0 {
+			issues = append(issues, Issue{Key: "gc.batch_size", Message: "gc.batch_size and gc.workers must be positive"})
+		}
+		if gc.GracePeriod < 0 {
+			issues = append(issues, Issue{Key: "gc.grace_period", Message: "must not be negative"})
+		} else if gc.GracePeriod < c.Server.WriteTimeout {
+			issues = append(issues, Issue{Key: "gc.grace_period", Warning: true,
+				Message: fmt.Sprintf("%s is shorter than server.write_timeout (%s); blobs of uploads in progress may be collected", gc.GracePeriod, c.Server.WriteTimeout)})
+		}
+		if gc.DryRun {
+			issues = append(issues, Issue{Key: "gc.dry_run", Warning: true, Message: "garbage collection only logs what it would delete"})
+		}
+	}
+
+	if gc.Purge.Enabled && gc.Purge.Retention == 0 {
+		issues = append(issues, Issue{Key: "gc.purge.retention", Warning: true,
+			Message: "deleted object versions are purged on the next run and cannot be recovered"})
+	}
+
+	mp := c.Storage.Multipart
+	if mp.ReapInterval == 0 {
+		issues = append(issues, Issue{Key: "storage.multipart.reap_interval", Warning: true,
+			Message: "abandoned multipart uploads are never removed and keep their parts"})
+	} else if mp.UploadExpiration <= 0 {
+		issues = append(issues, Issue{Key: "storage.multipart.upload_expiration", Message: "must be positive when the reaper runs"})
+	}
+	return issues
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testKey is a valid auth.encryption_key.
+const testKey = "0123456789abcdef0123456789abcdef"
+
+// issueKeys returns the keys of the issues, with a "warning: " prefix for
+// warnings.
+func issueKeys(issues []Issue) []string {
+	var keys []string
+	for _, issue := range issues {
+		if issue.Warning {
+			keys = append(keys, "warning: "+issue.Key)
+		} else {
+			keys = append(keys, issue.Key)
+		}
+	}
+	return keys
+}
+
+func TestCheck(t *testing.T) {
+	dataDir := t.TempDir()
+	newConfig := func(t *testing.T) *Config {
+		t.Helper()
+		cfg, err := LoadDev("", dataDir)
+		require.NoError(t, err)
+		cfg.Keys.Provider = "config"
+		cfg.Auth.EncryptionKey = testKey
+		return cfg
+	}
+	notADir := filepath.Join(dataDir, "file")
+	require.NoError(t, os.WriteFile(notADir, nil, 0600))
+
+	tests := []struct {
+		name   string
+		modify func(cfg *Config)
+		want   []string
+	}{
+		{"valid", func(cfg *Config) {}, nil},
+		{"data dir is a file", func(cfg *Config) { cfg.Storage.DataDir = notADir }, []string{"storage.data_dir"}},
+		{"missing directories are created", func(cfg *Config) { cfg.Storage.DataDir = filepath.Join(dataDir, "a", "b") }, nil},
+		{"missing key", func(cfg *Config) { cfg.Auth.EncryptionKey = "" }, []string{"keys.encryption_key"}},
+		{"missing certificate", func(cfg *Config) {
+			cfg.Server.TLS.Enabled = true
+			cfg.Server.TLS.CertFile = filepath.Join(dataDir, "missing.pem")
+			cfg.Server.TLS.KeyFile = filepath.Join(dataDir, "missing.key")
+		}, []string{"server.tls.cert_file"}},
+		{"shared port", func(cfg *Config) {
+			cfg.Metrics.Enabled = true
+			cfg.Metrics.Port = cfg.Server.Port
+		}, []string{"metrics.port"}},
+		{"gc disabled", func(cfg *Config) { cfg.GC.Enabled = false }, []string{"warning: gc.enabled"}},
+		{"gc interval", func(cfg *Config) { cfg.GC.Interval = 0 }, []string{"gc.interval"}},
+		{"short grace period", func(cfg *Config) {
+			cfg.GC.GracePeriod = time.Second
+			cfg.Server.WriteTimeout = time.Minute
+		}, []string{"warning: gc.grace_period"}},
+		{"multipart reaper off", func(cfg *Config) { cfg.Storage.Multipart.ReapInterval = 0 }, []string{"warning: storage.multipart.reap_interval"}},
+		{"multipart expiration", func(cfg *Config) { cfg.Storage.Multipart.UploadExpiration = 0 }, []string{"storage.multipart.upload_expiration"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newConfig(t)
+			tt.modify(cfg)
+			issues := cfg.Check(context.Background())
+			assert.Equal(t, tt.want, issueKeys(issues), "%v", issues)
+		})
+	}
+}
+
+func TestIssue_String(t *testing.T) {
+	assert.Equal(t, "error: gc.interval: must be positive",
+		Issue{Key: "gc.interval", Message: "must be positive"}.String())
+	assert.Equal(t, "warning: gc.enabled: garbage collection is disabled",
+		Issue{Key: "gc.enabled", Warning: true, Message: "garbage collection is disabled"}.String())
+}
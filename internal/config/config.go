@@ -3,24 +3,58 @@
 package config
 
 import (
+	"encoding/hex"
 	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/spf13/viper"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/pkg/keys"
+	"github.com/prn-tf/alexander-storage/internal/pkg/redact"
+	"github.com/prn-tf/alexander-storage/internal/pkg/syslog"
 )
 
 // Config represents the complete application configuration.
 type Config struct {
-	Server    ServerConfig    `mapstructure:"server"`
-	Database  DatabaseConfig  `mapstructure:"database"`
-	Redis     RedisConfig     `mapstructure:"redis"`
-	Storage   StorageConfig   `mapstructure:"storage"`
-	Auth      AuthConfig      `mapstructure:"auth"`
-	Logging   LoggingConfig   `mapstructure:"logging"`
-	Metrics   MetricsConfig   `mapstructure:"metrics"`
-	RateLimit RateLimitConfig `mapstructure:"rate_limit"`
-	GC        GCConfig        `mapstructure:"gc"`
+	Server     ServerConfig     `mapstructure:"server"`
+	Database   DatabaseConfig   `mapstructure:"database"`
+	Redis      RedisConfig      `mapstructure:"redis"`
+	Storage    StorageConfig    `mapstructure:"storage"`
+	Auth       AuthConfig       `mapstructure:"auth"`
+	Keys       KeysConfig       `mapstructure:"keys"`
+	Logging    LoggingConfig    `mapstructure:"logging"`
+	Metrics    MetricsConfig    `mapstructure:"metrics"`
+	Health     HealthConfig     `mapstructure:"health"`
+	Telemetry  TelemetryConfig  `mapstructure:"telemetry"`
+	RateLimit  RateLimitConfig  `mapstructure:"rate_limit"`
+	GC         GCConfig         `mapstructure:"gc"`
+	Scrub      ScrubConfig      `mapstructure:"scrub"`
+	Manifest   ManifestConfig   `mapstructure:"manifest"`
+	AdminAPI   AdminAPIConfig   `mapstructure:"admin_api"`
+	Quarantine QuarantineConfig `mapstructure:"quarantine"`
+	Batch      BatchConfig      `mapstructure:"batch"`
+	Snapshots  SnapshotsConfig  `mapstructure:"snapshots"`
+	Inventory  InventoryConfig  `mapstructure:"inventory"`
+	Website    WebsiteConfig    `mapstructure:"website"`
+	Metering   MeteringConfig   `mapstructure:"metering"`
+	Events     EventsConfig     `mapstructure:"events"`
+	Warmup     WarmupConfig     `mapstructure:"warmup"`
+	Audit      AuditConfig      `mapstructure:"audit"`
+	AccessLog  AccessLogConfig  `mapstructure:"access_log"`
+
+	Replication ReplicationConfig `mapstructure:"replication"`
+	ObjectCache ObjectCacheConfig `mapstructure:"object_cache"`
+	Lifecycle   LifecycleConfig   `mapstructure:"lifecycle"`
+	Tenancy     TenancyConfig     `mapstructure:"tenancy"`
 
 	// Fusion Engine v2.0 configurations
 	Encryption EncryptionConfig `mapstructure:"encryption"`
@@ -28,6 +62,9 @@ type Config struct {
 	Cluster    ClusterConfig    `mapstructure:"cluster"`
 	Tiering    TieringConfig    `mapstructure:"tiering"`
 	Migration  MigrationConfig  `mapstructure:"migration"`
+
+	// file is the configuration file read, if any.
+	file string
 }
 
 // ServerConfig holds HTTP server settings.
@@ -39,6 +76,107 @@ type ServerConfig struct {
 	IdleTimeout     time.Duration `mapstructure:"idle_timeout"`
 	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
 	MaxBodySize     int64         `mapstructure:"max_body_size"`
+
+	// MaxAPIBodySize limits request bodies that carry no object data, such
+	// as bucket configuration, multipart completion and admin requests.
+	// Zero means unlimited.
+	MaxAPIBodySize int64 `mapstructure:"max_api_body_size"`
+
+	// ReadHeaderTimeout bounds reading request headers, so slow clients
+	// cannot hold connections open without sending a request.
+	ReadHeaderTimeout time.Duration `mapstructure:"read_header_timeout"`
+
+	// MaxHeaderBytes limits the size of request headers.
+	MaxHeaderBytes int `mapstructure:"max_header_bytes"`
+
+	// KeepAlive reuses connections for further requests.
+	KeepAlive bool `mapstructure:"keep_alive"`
+
+	// MaxConnections limits open connections; further connections wait.
+	// Zero means unlimited.
+	MaxConnections int `mapstructure:"max_connections"`
+
+	// MaxRequestsPerConnection limits the requests served at once on one
+	// (HTTP/2) connection; further requests get SlowDown. Zero means unlimited.
+	MaxRequestsPerConnection int `mapstructure:"max_requests_per_connection"`
+
+	// MaxConcurrentReads and MaxConcurrentWrites limit the GET and HEAD
+	// requests, and all other requests, served at once. Requests over the
+	// limit wait up to QueueTimeout in a queue of MaxQueuedRequests per
+	// kind, then get SlowDown. Zero means unlimited.
+	MaxConcurrentReads  int           `mapstructure:"max_concurrent_reads"`
+	MaxConcurrentWrites int           `mapstructure:"max_concurrent_writes"`
+	MaxQueuedRequests   int           `mapstructure:"max_queued_requests"`
+	QueueTimeout        time.Duration `mapstructure:"queue_timeout"`
+
+	// DetectContentType gives uploads sent without a Content-Type one
+	// guessed from the key's extension, instead of application/octet-stream.
+	DetectContentType bool `mapstructure:"detect_content_type"`
+
+	// HTTP2 configures HTTP/2, which multiplexes parallel uploads over
+	// one connection.
+	HTTP2 HTTP2Config `mapstructure:"http2"`
+
+	// TLS serves the S3 API over HTTPS.
+	TLS TLSConfig `mapstructure:"tls"`
+}
+
+// HTTP2Config holds HTTP/2 settings.
+type HTTP2Config struct {
+	// Enabled serves HTTP/2 to clients that negotiate it over TLS.
+	Enabled bool `mapstructure:"enabled"`
+
+	// Cleartext also serves HTTP/2 without TLS (h2c, prior knowledge),
+	// e.g. behind a load balancer that terminates TLS.
+	Cleartext bool `mapstructure:"cleartext"`
+
+	// MaxConcurrentStreams limits the streams a client may open at once
+	// on one connection. Zero uses the Go default (250).
+	MaxConcurrentStreams int `mapstructure:"max_concurrent_streams"`
+
+	// MaxReadFrameSize is the largest frame the server reads. Zero uses
+	// the Go default (1MB).
+	MaxReadFrameSize int `mapstructure:"max_read_frame_size"`
+}
+
+// TLSConfig holds HTTPS settings. Certificates come from CertFile and
+// KeyFile, which are reloaded on SIGHUP, or from ACME.
+type TLSConfig struct {
+	Enabled  bool   `mapstructure:"enabled"`
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+
+	// MinVersion is the minimum TLS version: "1.2" or "1.3".
+	MinVersion string `mapstructure:"min_version"`
+
+	// ClientCAFile enables mTLS: client certificates must be signed by one
+	// of the CAs in this PEM bundle.
+	ClientCAFile string `mapstructure:"client_ca_file"`
+
+	// ClientAuth is "require" (every client presents a certificate) or
+	// "verify_if_given" (only presented certificates are verified).
+	ClientAuth string `mapstructure:"client_auth"`
+
+	// ACME obtains and renews certificates automatically.
+	ACME ACMEConfig `mapstructure:"acme"`
+}
+
+// ACMEConfig holds automatic certificate management settings. Domains are
+// validated with the TLS-ALPN-01 challenge, so the server must be reachable
+// on port 443 under every domain.
+type ACMEConfig struct {
+	Enabled bool     `mapstructure:"enabled"`
+	Domains []string `mapstructure:"domains"`
+	Email   string   `mapstructure:"email"`
+
+	// CacheDir keeps the account key and certificate across restarts.
+	CacheDir string `mapstructure:"cache_dir"`
+
+	// DirectoryURL is the ACME directory; the default is Let's Encrypt.
+	DirectoryURL string `mapstructure:"directory_url"`
+
+	// RenewBefore renews certificates this long before they expire.
+	RenewBefore time.Duration `mapstructure:"renew_before"`
 }
 
 // DatabaseConfig holds database connection settings.
@@ -60,12 +198,79 @@ type DatabaseConfig struct {
 	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"`
 	ConnMaxIdleTime time.Duration `mapstructure:"conn_max_idle_time"`
 
+	// StatementCacheSize is the number of prepared statements each
+	// PostgreSQL connection keeps, so repeated queries skip parsing and can
+	// reuse their plan. The default matches pgx's own. Set 0 behind a pooler
+	// in transaction mode, such as PgBouncer. SQLite ignores it: its driver
+	// compiles a statement on every execution, even a prepared one, so
+	// there is nothing to cache.
+	StatementCacheSize int `mapstructure:"statement_cache_size"`
+
+	// SlowQueryThreshold is the duration from which a query is logged as
+	// slow, with the repository method that ran it and the bucket and key
+	// of the request. 0 disables slow query logging.
+	SlowQueryThreshold time.Duration `mapstructure:"slow_query_threshold"`
+
 	// SQLite settings (used when Driver is "sqlite")
 	Path            string `mapstructure:"path"`             // Path to SQLite database file
 	JournalMode     string `mapstructure:"journal_mode"`     // WAL, DELETE, TRUNCATE, etc.
 	BusyTimeout     int    `mapstructure:"busy_timeout"`     // Milliseconds to wait for locks
 	CacheSize       int    `mapstructure:"cache_size"`       // Page cache size (negative = KB)
 	SynchronousMode string `mapstructure:"synchronous_mode"` // NORMAL, FULL, OFF
+
+	// Maintenance schedules SQLite WAL checkpoints and PRAGMA optimize.
+	Maintenance DatabaseMaintenanceConfig `mapstructure:"maintenance"`
+
+	// Backfill controls batched data migrations, which the server runs in
+	// the background after schema migrations.
+	Backfill BackfillConfig `mapstructure:"backfill"`
+
+	// Replica configures a PostgreSQL read replica for listings.
+	Replica ReplicaConfig `mapstructure:"replica"`
+}
+
+// ReplicaConfig holds PostgreSQL read replica settings. The replica is
+// reached with the primary's credentials and pool settings.
+type ReplicaConfig struct {
+	// Enabled serves S3 listings from the replica while it is within MaxLag
+	// of the primary. Other reads and all writes always use the primary.
+	Enabled bool   `mapstructure:"enabled"`
+	Host    string `mapstructure:"host"`
+	Port    int    `mapstructure:"port"`
+
+	// MaxLag is the replication lag above which listings fall back to the primary.
+	MaxLag time.Duration `mapstructure:"max_lag"`
+
+	// CheckInterval is how often the replication lag is measured.
+	CheckInterval time.Duration `mapstructure:"check_interval"`
+
+	// ListFromPrimary serves all listings from the primary, as if every
+	// request carried the x-alexander-list-from-primary header.
+	ListFromPrimary bool `mapstructure:"list_from_primary"`
+}
+
+// DatabaseMaintenanceConfig holds periodic SQLite maintenance settings.
+type DatabaseMaintenanceConfig struct {
+	// CheckpointInterval is how often the write-ahead log is checkpointed
+	// and truncated, so it does not grow without bound. 0 disables it.
+	CheckpointInterval time.Duration `mapstructure:"checkpoint_interval"`
+
+	// OptimizeInterval is how often PRAGMA optimize refreshes the query
+	// planner statistics. 0 disables it.
+	OptimizeInterval time.Duration `mapstructure:"optimize_interval"`
+}
+
+// BackfillConfig holds batched data migration settings.
+type BackfillConfig struct {
+	// Enabled runs pending backfills in the background on startup. When
+	// disabled, run them with `alexander-admin backfill run`.
+	Enabled bool `mapstructure:"enabled"`
+
+	// BatchSize is the number of rows per batch (and per transaction).
+	BatchSize int `mapstructure:"batch_size"`
+
+	// Pause is the delay between batches.
+	Pause time.Duration `mapstructure:"pause"`
 }
 
 // DSN returns the PostgreSQL connection string.
@@ -77,6 +282,14 @@ func (c DatabaseConfig) DSN() string {
 	)
 }
 
+// ReplicaDSN returns the PostgreSQL connection string of the read replica.
+func (c DatabaseConfig) ReplicaDSN() string {
+	replica := c
+	replica.Host = c.Replica.Host
+	replica.Port = c.Replica.Port
+	return replica.DSN()
+}
+
 // IsEmbedded returns true if using an embedded database (SQLite).
 func (c DatabaseConfig) IsEmbedded() bool {
 	return c.Driver == "sqlite"
@@ -105,6 +318,70 @@ type StorageConfig struct {
 	TempDir   string                `mapstructure:"temp_dir"`
 	S3        S3StorageConfig       `mapstructure:"s3"`
 	Multipart MultipartUploadConfig `mapstructure:"multipart"`
+
+	// Permissions controls ownership and mode checks on data_dir and temp_dir at startup.
+	Permissions StoragePermissionsConfig `mapstructure:"permissions"`
+
+	// Sync controls when blobs written by the filesystem backend are
+	// flushed to disk: "off", "always" or "batch" (every sync_interval).
+	Sync         string        `mapstructure:"sync"`
+	SyncInterval time.Duration `mapstructure:"sync_interval"`
+
+	// TempMaxAge is the age past which partial files left in temp_dir by
+	// interrupted writes are deleted at startup. Zero keeps them.
+	TempMaxAge time.Duration `mapstructure:"temp_max_age"`
+
+	// ReadAhead reads large blobs from the filesystem backend in parallel chunks.
+	ReadAhead ReadAheadConfig `mapstructure:"read_ahead"`
+
+	// Residency maps bucket residency tags to the directories holding their
+	// blobs. Writes for a bucket whose tag has no entry here are refused.
+	Residency map[string]ResidencyStorageConfig `mapstructure:"residency"`
+
+	// Erasure configures the erasure backend, selected with backend: erasure.
+	Erasure ErasureStorageConfig `mapstructure:"erasure"`
+}
+
+// ReadAheadConfig holds parallel chunked read settings.
+type ReadAheadConfig struct {
+	// Threshold is the read size in bytes from which blobs are read in
+	// chunks. Zero disables chunked reads.
+	Threshold   int64 `mapstructure:"threshold"`
+	ChunkSize   int   `mapstructure:"chunk_size"`
+	Concurrency int   `mapstructure:"concurrency"`
+}
+
+// ErasureStorageConfig holds erasure-coded backend settings.
+type ErasureStorageConfig struct {
+	// DataDirs holds one directory per shard, ideally each on its own
+	// drive. Its length must be data_shards + parity_shards.
+	DataDirs []string `mapstructure:"data_dirs"`
+
+	DataShards   int `mapstructure:"data_shards"`
+	ParityShards int `mapstructure:"parity_shards"`
+
+	// BlockSize is the number of blob bytes encoded per stripe.
+	BlockSize int `mapstructure:"block_size"`
+
+	// ScrubInterval is the time between passes verifying and repairing
+	// every shard. Zero disables scrubbing.
+	ScrubInterval time.Duration `mapstructure:"scrub_interval"`
+}
+
+// ResidencyStorageConfig holds the storage directories for one residency tag.
+type ResidencyStorageConfig struct {
+	DataDir string `mapstructure:"data_dir"`
+	TempDir string `mapstructure:"temp_dir"`
+}
+
+// StoragePermissionsConfig holds filesystem security checks for storage directories.
+type StoragePermissionsConfig struct {
+	// Enforce refuses to start if a directory is not owned by the server user
+	// or is accessible by group/other and cannot be fixed.
+	Enforce bool `mapstructure:"enforce"`
+
+	// AutoFix tightens directory modes to 0700 when owned by the server user.
+	AutoFix bool `mapstructure:"auto_fix"`
 }
 
 // S3StorageConfig holds S3 backend settings (for future use).
@@ -119,10 +396,23 @@ type S3StorageConfig struct {
 
 // MultipartUploadConfig holds multipart upload settings.
 type MultipartUploadConfig struct {
-	MinPartSize      int64         `mapstructure:"min_part_size"`
-	MaxPartSize      int64         `mapstructure:"max_part_size"`
-	MaxParts         int           `mapstructure:"max_parts"`
+	// MinPartSize is the minimum size of every part of a completed upload
+	// but the last; CompleteMultipartUpload fails with EntityTooSmall
+	// otherwise. 0 allows parts of any size.
+	MinPartSize int64 `mapstructure:"min_part_size"`
+
+	// MaxPartSize is the maximum size of an uploaded part.
+	MaxPartSize int64 `mapstructure:"max_part_size"`
+
+	// MaxParts is the maximum number of parts a completed upload may have,
+	// at most 10000.
+	MaxParts int `mapstructure:"max_parts"`
+
 	UploadExpiration time.Duration `mapstructure:"upload_expiration"`
+
+	// ReapInterval is how often expired uploads are aborted and their
+	// parts released. Zero disables the reaper.
+	ReapInterval time.Duration `mapstructure:"reap_interval"`
 }
 
 // AuthConfig holds authentication settings.
@@ -135,83 +425,886 @@ type AuthConfig struct {
 	// Used with HKDF to derive per-blob encryption keys.
 	SSEMasterKey string `mapstructure:"sse_master_key"`
 
-	// Region is the default region for AWS v4 signature verification.
-	Region string `mapstructure:"region"`
+	// Region is the default region for AWS v4 signature verification and
+	// the region of buckets created without a location constraint.
+	Region string `mapstructure:"region"`
+
+	// AllowedRegions are the other regions buckets may be created in with
+	// a location constraint. Empty allows only Region.
+	AllowedRegions []string `mapstructure:"allowed_regions"`
+
+	// Service is the service name for AWS v4 signature verification.
+	Service string `mapstructure:"service"`
+
+	// PresignedURLExpiration is the default expiration time for presigned URLs.
+	PresignedURLExpiration time.Duration `mapstructure:"presigned_url_expiration"`
+
+	// MaxSignatureAge is the maximum age of a signature before it's considered expired.
+	MaxSignatureAge time.Duration `mapstructure:"max_signature_age"`
+
+	// LDAP configures dashboard logins backed by LDAP or Active Directory.
+	LDAP LDAPConfig `mapstructure:"ldap"`
+
+	// OIDC configures dashboard single sign-on with an OpenID Connect provider.
+	OIDC OIDCConfig `mapstructure:"oidc"`
+
+	// PasswordPolicy sets the rules for local user passwords.
+	PasswordPolicy PasswordPolicyConfig `mapstructure:"password_policy"`
+
+	// MaxAccessKeysPerUser is the maximum number of active access keys a
+	// user can have, whether created by an admin or by the user.
+	MaxAccessKeysPerUser int `mapstructure:"max_access_keys_per_user"`
+
+	// SelfServiceKeys lets users create, list and delete their own access
+	// keys through the /_alexander/keys API.
+	SelfServiceKeys bool `mapstructure:"self_service_keys"`
+}
+
+// LDAPConfig holds LDAP / Active Directory settings for dashboard logins.
+type LDAPConfig struct {
+	// Enabled turns on directory authentication for dashboard logins.
+	Enabled bool `mapstructure:"enabled"`
+
+	// URL is the directory server (ldap://host:389 or ldaps://host:636).
+	URL string `mapstructure:"url"`
+
+	// StartTLS upgrades ldap:// connections to TLS before binding.
+	StartTLS bool `mapstructure:"start_tls"`
+
+	// InsecureSkipVerify disables certificate verification (testing only).
+	InsecureSkipVerify bool `mapstructure:"insecure_skip_verify"`
+
+	// BindDN and BindPassword are the service account used to look up users.
+	BindDN       string `mapstructure:"bind_dn"`
+	BindPassword string `mapstructure:"bind_password"`
+
+	// BaseDN is the subtree searched for users.
+	BaseDN string `mapstructure:"base_dn"`
+
+	// UserFilter finds a user's entry; %s is replaced by the login name.
+	UserFilter string `mapstructure:"user_filter"`
+
+	// EmailAttribute and GroupAttribute name the entry's email and group DN attributes.
+	EmailAttribute string `mapstructure:"email_attribute"`
+	GroupAttribute string `mapstructure:"group_attribute"`
+
+	// AdminGroups, OperatorGroups and ReadOnlyGroups are group DNs whose
+	// members get the admin, operator and read-only dashboard roles.
+	AdminGroups    []string `mapstructure:"admin_groups"`
+	OperatorGroups []string `mapstructure:"operator_groups"`
+	ReadOnlyGroups []string `mapstructure:"read_only_groups"`
+
+	// ProvisionUsers creates a local user on the first successful directory login.
+	ProvisionUsers bool `mapstructure:"provision_users"`
+
+	// LocalFallback still accepts local passwords (e.g. a break-glass admin)
+	// when the directory rejects the login or is unreachable.
+	LocalFallback bool `mapstructure:"local_fallback"`
+
+	// Timeout bounds each directory request.
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// OIDCConfig holds OpenID Connect single sign-on settings for the dashboard.
+type OIDCConfig struct {
+	// Enabled adds a "Sign in with SSO" option to the dashboard login.
+	Enabled bool `mapstructure:"enabled"`
+
+	// Issuer is the provider's issuer URL (discovery is read from it).
+	Issuer string `mapstructure:"issuer"`
+
+	// ClientID and ClientSecret are the credentials registered with the provider.
+	ClientID     string `mapstructure:"client_id"`
+	ClientSecret string `mapstructure:"client_secret"`
+
+	// RedirectURL is the registered callback, ending in /dashboard/oidc/callback.
+	RedirectURL string `mapstructure:"redirect_url"`
+
+	// Scopes are requested in addition to "openid".
+	Scopes []string `mapstructure:"scopes"`
+
+	// EmailClaim is the ID token claim matched against local users' email.
+	EmailClaim string `mapstructure:"email_claim"`
+
+	// ProvisionUsers creates a local user on the first login from an unknown email.
+	ProvisionUsers bool `mapstructure:"provision_users"`
+
+	// DefaultRole is the role of provisioned users: "none", "read-only",
+	// "operator" or "admin". Users with no role cannot use the dashboard
+	// until an admin assigns one.
+	DefaultRole string `mapstructure:"default_role"`
+
+	// Timeout bounds each request to the provider.
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// PasswordPolicyConfig holds the rules for local user passwords. They apply
+// when a password is set, so existing passwords keep working.
+type PasswordPolicyConfig struct {
+	// MinLength is the minimum length in characters (at least 8).
+	MinLength int `mapstructure:"min_length"`
+
+	// RequireUpper, RequireLower, RequireDigit and RequireSymbol require at
+	// least one character of the class.
+	RequireUpper  bool `mapstructure:"require_upper"`
+	RequireLower  bool `mapstructure:"require_lower"`
+	RequireDigit  bool `mapstructure:"require_digit"`
+	RequireSymbol bool `mapstructure:"require_symbol"`
+
+	// Banned lists rejected passwords. BannedFile adds those of a file with
+	// one password per line, such as a list of common passwords.
+	Banned     []string `mapstructure:"banned"`
+	BannedFile string   `mapstructure:"banned_file"`
+}
+
+// Policy returns the password policy, reading BannedFile if set.
+func (c PasswordPolicyConfig) Policy() (domain.PasswordPolicy, error) {
+	policy := domain.PasswordPolicy{
+		MinLength:     c.MinLength,
+		RequireUpper:  c.RequireUpper,
+		RequireLower:  c.RequireLower,
+		RequireDigit:  c.RequireDigit,
+		RequireSymbol: c.RequireSymbol,
+		Banned:        c.Banned,
+	}
+	if c.BannedFile != "" {
+		data, err := os.ReadFile(c.BannedFile)
+		if err != nil {
+			return policy, fmt.Errorf("auth.password_policy.banned_file: %w", err)
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				policy.Banned = append(policy.Banned, line)
+			}
+		}
+	}
+	return policy, nil
+}
+
+// KeysConfig selects where the encryption keys are read from.
+type KeysConfig struct {
+	// Provider is "config" (auth.encryption_key and auth.sse_master_key),
+	// "env", "file", "vault" or "kms".
+	Provider string `mapstructure:"provider"`
+
+	// EnvPrefix prefixes the upper-cased key names with the env provider,
+	// as in ALEXANDER_KEY_ENCRYPTION_KEY.
+	EnvPrefix string `mapstructure:"env_prefix"`
+
+	// Dir holds one file per key name with the file provider.
+	Dir string `mapstructure:"dir"`
+
+	Vault KeysVaultConfig `mapstructure:"vault"`
+	KMS   KeysKMSConfig   `mapstructure:"kms"`
+}
+
+// KeysVaultConfig configures the Vault key provider, which reads a KV
+// version 2 secret with one field per key name.
+type KeysVaultConfig struct {
+	Address   string        `mapstructure:"address"`
+	Token     string        `mapstructure:"token"`
+	TokenFile string        `mapstructure:"token_file"`
+	Namespace string        `mapstructure:"namespace"`
+	Mount     string        `mapstructure:"mount"`
+	Path      string        `mapstructure:"path"`
+	Timeout   time.Duration `mapstructure:"timeout"`
+}
+
+// KeysKMSConfig configures the AWS KMS key provider. EncryptionKey and
+// SSEMasterKey hold data keys encrypted by KMS, as base64 ciphertext blobs.
+type KeysKMSConfig struct {
+	Region          string        `mapstructure:"region"`
+	Endpoint        string        `mapstructure:"endpoint"`
+	AccessKeyID     string        `mapstructure:"access_key_id"`
+	SecretAccessKey string        `mapstructure:"secret_access_key"`
+	EncryptionKey   string        `mapstructure:"encryption_key"`
+	SSEMasterKey    string        `mapstructure:"sse_master_key"`
+	Timeout         time.Duration `mapstructure:"timeout"`
+}
+
+// KeyProvider returns the configured key provider.
+func (c *Config) KeyProvider() (keys.Provider, error) {
+	switch c.Keys.Provider {
+	case "env":
+		return keys.NewEnvProvider(c.Keys.EnvPrefix), nil
+	case "file":
+		return keys.NewFileProvider(c.Keys.Dir), nil
+	case "vault":
+		vault := c.Keys.Vault
+		return keys.NewVaultProvider(keys.VaultConfig{
+			Address:    vault.Address,
+			Token:      vault.Token,
+			TokenFile:  vault.TokenFile,
+			Namespace:  vault.Namespace,
+			Mount:      vault.Mount,
+			Path:       vault.Path,
+			HTTPClient: &http.Client{Timeout: vault.Timeout},
+		}), nil
+	case "kms":
+		kms := c.Keys.KMS
+		return keys.NewKMSProvider(keys.KMSConfig{
+			Region:          kms.Region,
+			Endpoint:        kms.Endpoint,
+			AccessKeyID:     kms.AccessKeyID,
+			SecretAccessKey: kms.SecretAccessKey,
+			Keyrings: map[string]string{
+				keys.EncryptionKey: kms.EncryptionKey,
+				keys.SSEMasterKey:  kms.SSEMasterKey,
+			},
+			HTTPClient: &http.Client{Timeout: kms.Timeout},
+		}), nil
+	}
+
+	// The configuration file holds a single version of each key
+	keyrings := make(map[string]keys.Keyring)
+	if c.Auth.EncryptionKey != "" {
+		keyring, err := keys.NewKeyring(keys.Key{Version: 1, Material: []byte(c.Auth.EncryptionKey)})
+		if err != nil {
+			return nil, fmt.Errorf("auth.encryption_key: %w", err)
+		}
+		keyrings[keys.EncryptionKey] = keyring
+	}
+	if c.Auth.SSEMasterKey != "" {
+		material, err := hex.DecodeString(c.Auth.SSEMasterKey)
+		if err != nil {
+			return nil, fmt.Errorf("auth.sse_master_key must be hex-encoded: %w", err)
+		}
+		keyring, err := keys.NewKeyring(keys.Key{Version: 1, Material: material})
+		if err != nil {
+			return nil, fmt.Errorf("auth.sse_master_key: %w", err)
+		}
+		keyrings[keys.SSEMasterKey] = keyring
+	}
+	return keys.NewStaticProvider(keyrings), nil
+}
+
+// LoggingConfig holds logging settings.
+type LoggingConfig struct {
+	Level string `mapstructure:"level"`
+
+	// Format is "json", or "console" for human-readable lines.
+	Format string `mapstructure:"format"`
+
+	// Output is where lines are written: "stdout", "stderr" or "file".
+	Output string `mapstructure:"output"`
+
+	// TimeFormat is the Go time layout of timestamps.
+	TimeFormat string `mapstructure:"time_format"`
+
+	// File is the path of the log file for the "file" output.
+	File string `mapstructure:"file"`
+
+	// MaxSizeMB is the size at which the file is rotated; 0 disables
+	// rotation by size.
+	MaxSizeMB int `mapstructure:"max_size_mb"`
+
+	// MaxAge is how long the file is written to before it is rotated; 0
+	// disables rotation by age.
+	MaxAge time.Duration `mapstructure:"max_age"`
+
+	// MaxBackups is the number of rotated files kept.
+	MaxBackups int `mapstructure:"max_backups"`
+
+	// Sampling thins out frequent low-level events.
+	Sampling LogSamplingConfig `mapstructure:"sampling"`
+}
+
+// LogSamplingConfig holds per-level log sampling settings. Warnings and
+// errors are never sampled.
+type LogSamplingConfig struct {
+	// Enabled turns sampling on.
+	Enabled bool `mapstructure:"enabled"`
+
+	// Period is the interval the burst of each level applies to.
+	Period time.Duration `mapstructure:"period"`
+
+	Trace LogLevelSamplingConfig `mapstructure:"trace"`
+	Debug LogLevelSamplingConfig `mapstructure:"debug"`
+	Info  LogLevelSamplingConfig `mapstructure:"info"`
+}
+
+// LogLevelSamplingConfig holds the sampling of one level.
+type LogLevelSamplingConfig struct {
+	// Burst is the number of events logged per period before sampling
+	// starts.
+	Burst int `mapstructure:"burst"`
+
+	// Every logs one of every Every events past the burst; 0 or 1 logs
+	// them all.
+	Every int `mapstructure:"every"`
+}
+
+// MetricsConfig holds Prometheus metrics settings.
+type MetricsConfig struct {
+	// Enabled determines if metrics collection is active.
+	Enabled bool `mapstructure:"enabled"`
+
+	// Port is the port for the metrics HTTP server.
+	Port int `mapstructure:"port"`
+
+	// Path is the URL path for the metrics endpoint.
+	Path string `mapstructure:"path"`
+}
+
+// HealthConfig holds the thresholds of the health endpoints. A dependency
+// slower than its threshold is reported degraded; the server stays ready.
+type HealthConfig struct {
+	DatabaseLatency time.Duration `mapstructure:"database_latency"`
+	StorageLatency  time.Duration `mapstructure:"storage_latency"`
+	RedisLatency    time.Duration `mapstructure:"redis_latency"`
+
+	// GCLag is how long garbage collection may go without a run. Zero
+	// means three GC intervals.
+	GCLag time.Duration `mapstructure:"gc_lag"`
+}
+
+// TelemetryConfig holds OpenTelemetry tracing settings.
+type TelemetryConfig struct {
+	// Enabled determines if spans are recorded and exported.
+	Enabled bool `mapstructure:"enabled"`
+
+	// Endpoint is the base URL of the OTLP/HTTP receiver of the collector,
+	// e.g. http://otel-collector:4318.
+	Endpoint string `mapstructure:"endpoint"`
+
+	// Headers are sent with every export request, e.g. for authentication.
+	Headers map[string]string `mapstructure:"headers"`
+
+	// ServiceName is the service.name resource attribute of exported spans.
+	ServiceName string `mapstructure:"service_name"`
+
+	// SampleRatio is the fraction of new traces that are recorded (0-1).
+	// Requests with a traceparent header follow the caller's decision.
+	SampleRatio float64 `mapstructure:"sample_ratio"`
+
+	// BatchSize is the maximum number of spans per export request.
+	BatchSize int `mapstructure:"batch_size"`
+
+	// ExportInterval is how often queued spans are exported.
+	ExportInterval time.Duration `mapstructure:"export_interval"`
+
+	// ExportTimeout bounds each export request.
+	ExportTimeout time.Duration `mapstructure:"export_timeout"`
+}
+
+// RateLimitConfig holds rate limiting settings.
+type RateLimitConfig struct {
+	// Enabled determines if rate limiting is active.
+	Enabled bool `mapstructure:"enabled"`
+
+	// RequestsPerSecond is the rate of token refill per client.
+	RequestsPerSecond float64 `mapstructure:"requests_per_second"`
+
+	// BurstSize is the maximum number of tokens (burst capacity).
+	BurstSize int `mapstructure:"burst_size"`
+
+	// BandwidthEnabled enables bandwidth limiting.
+	BandwidthEnabled bool `mapstructure:"bandwidth_enabled"`
+
+	// BytesPerSecond is the bandwidth limit per client (in bytes).
+	BytesPerSecond int64 `mapstructure:"bytes_per_second"`
+}
+
+// GCConfig holds garbage collection settings.
+type GCConfig struct {
+	// Enabled determines if automatic garbage collection runs.
+	Enabled bool `mapstructure:"enabled"`
+
+	// Interval is how often to run garbage collection.
+	Interval time.Duration `mapstructure:"interval"`
+
+	// GracePeriod is how long to wait before deleting orphan blobs.
+	GracePeriod time.Duration `mapstructure:"grace_period"`
+
+	// BatchSize is the maximum number of blobs to process per run.
+	BatchSize int `mapstructure:"batch_size"`
+
+	// Workers is the number of blobs deleted concurrently.
+	Workers int `mapstructure:"workers"`
+
+	// ProgressInterval is how often a run logs its progress (0 = only at the end).
+	ProgressInterval time.Duration `mapstructure:"progress_interval"`
+
+	// DryRun logs what would be deleted without actually deleting.
+	DryRun bool `mapstructure:"dry_run"`
+
+	// DeleteRetries is how many times a failed storage deletion is retried per run.
+	DeleteRetries int `mapstructure:"delete_retries"`
+
+	// RetryBackoff is the initial delay between storage deletion retries (doubles each retry).
+	RetryBackoff time.Duration `mapstructure:"retry_backoff"`
+
+	// Verify schedules cross-checks of storage against the blob table.
+	Verify GCVerifyConfig `mapstructure:"verify"`
+
+	// Reconcile schedules corrections of drifted blob reference counts.
+	Reconcile GCReconcileConfig `mapstructure:"reconcile"`
+
+	// Purge schedules removal of soft-deleted object versions.
+	Purge GCPurgeConfig `mapstructure:"purge"`
+}
+
+// GCVerifyConfig holds settings for scheduled storage verification, which
+// finds stored files without a blob record and blob records without content.
+type GCVerifyConfig struct {
+	// Enabled determines if storage is periodically verified.
+	Enabled bool `mapstructure:"enabled"`
+
+	// Interval is how often to verify.
+	Interval time.Duration `mapstructure:"interval"`
+
+	// GracePeriod is how old files and blob records must be to be reported.
+	GracePeriod time.Duration `mapstructure:"grace_period"`
+
+	// QuarantineDir receives orphan files. Empty only reports them.
+	// Missing blobs are restored from scrub.repair_dir when it is set.
+	QuarantineDir string `mapstructure:"quarantine_dir"`
+}
+
+// GCReconcileConfig holds settings for scheduled reference count
+// reconciliation, which recounts the references held on each blob and
+// corrects its stored count.
+type GCReconcileConfig struct {
+	// Enabled determines if reference counts are periodically reconciled.
+	Enabled bool `mapstructure:"enabled"`
+
+	// Interval is how often to reconcile.
+	Interval time.Duration `mapstructure:"interval"`
+
+	// BatchSize is the number of blobs counted at a time.
+	BatchSize int `mapstructure:"batch_size"`
+
+	// DryRun reports drifted counts without correcting them.
+	DryRun bool `mapstructure:"dry_run"`
+}
+
+// GCPurgeConfig holds settings for purging soft-deleted object versions.
+// Deleted versions stay in the objects table until they are purged.
+type GCPurgeConfig struct {
+	// Enabled determines if soft-deleted versions are periodically purged.
+	Enabled bool `mapstructure:"enabled"`
+
+	// Interval is how often to purge.
+	Interval time.Duration `mapstructure:"interval"`
+
+	// Retention is how long soft-deleted versions are kept before they are purged.
+	Retention time.Duration `mapstructure:"retention"`
+
+	// BatchSize is the number of versions removed at a time.
+	BatchSize int `mapstructure:"batch_size"`
+}
+
+// ScrubConfig holds background blob integrity scrub settings.
+type ScrubConfig struct {
+	// Enabled determines if blobs are periodically re-hashed.
+	Enabled bool `mapstructure:"enabled"`
+
+	// Interval is how often to look for blobs due for verification.
+	Interval time.Duration `mapstructure:"interval"`
+
+	// VerifyAfter is how long ago a blob must have been verified to be
+	// verified again.
+	VerifyAfter time.Duration `mapstructure:"verify_after"`
+
+	// BatchSize is the number of blobs listed at a time.
+	BatchSize int `mapstructure:"batch_size"`
+
+	// BytesPerSecond limits the scrub read rate (0 = unlimited).
+	BytesPerSecond int64 `mapstructure:"bytes_per_second"`
+
+	// RepairDir is a filesystem copy of the data directory, such as a
+	// replica or cold-tier mirror, used to repair corrupt blobs. Empty
+	// disables repair; corrupt blobs are only flagged.
+	RepairDir string `mapstructure:"repair_dir"`
+}
+
+// AdminAPIConfig holds settings for the admin REST API, which manages
+// users, access keys and buckets for provisioning tools.
+type AdminAPIConfig struct {
+	// Enabled exposes the admin API.
+	Enabled bool `mapstructure:"enabled"`
+
+	// Token is the bearer token clients must present. Required unless
+	// clients authenticate with certificates on the admin listener.
+	Token string `mapstructure:"token"`
+
+	// Port, if set, serves the admin API on its own listener instead of
+	// under /_alexander/admin/ on the S3 port.
+	Port int `mapstructure:"port"`
+
+	// TLS serves the admin listener over HTTPS. With a client CA and
+	// client_auth "require", verified client certificates are accepted
+	// instead of the token.
+	TLS TLSConfig `mapstructure:"tls"`
+}
+
+// ManifestConfig holds settings for the blob manifest API used by external
+// scanning and audit tools.
+type ManifestConfig struct {
+	// Enabled exposes the manifest API.
+	Enabled bool `mapstructure:"enabled"`
+
+	// Token is the bearer token clients must present. Required when enabled.
+	Token string `mapstructure:"token"`
+
+	// MaxPageSize is the maximum number of blobs returned per page.
+	MaxPageSize int `mapstructure:"max_page_size"`
+
+	// RequestsPerSecond is the per-client request rate limit.
+	RequestsPerSecond float64 `mapstructure:"requests_per_second"`
+
+	// BurstSize is the per-client burst capacity.
+	BurstSize int `mapstructure:"burst_size"`
+}
+
+// QuarantineConfig holds settings for the upload quarantine API and the
+// expiry of unpromoted uploads in quarantine buckets.
+type QuarantineConfig struct {
+	// Enabled exposes the quarantine API and expires unpromoted uploads.
+	Enabled bool `mapstructure:"enabled"`
+
+	// Token is the bearer token approvers must present. Required when enabled.
+	Token string `mapstructure:"token"`
+
+	// ExpireAfter is how long an upload waits for promotion before it is discarded.
+	ExpireAfter time.Duration `mapstructure:"expire_after"`
+
+	// Interval is how often to look for expired uploads.
+	Interval time.Duration `mapstructure:"interval"`
+
+	// BatchSize is the number of expired uploads discarded at a time.
+	BatchSize int `mapstructure:"batch_size"`
+}
+
+// BatchConfig holds settings for the batch commit API, which publishes
+// staged writes to several buckets atomically.
+type BatchConfig struct {
+	// Enabled exposes the batch API and aborts expired batches.
+	Enabled bool `mapstructure:"enabled"`
+
+	// ExpireAfter is how long a batch stays open before it is aborted.
+	ExpireAfter time.Duration `mapstructure:"expire_after"`
+
+	// Interval is how often to look for expired batches.
+	Interval time.Duration `mapstructure:"interval"`
+}
+
+// SnapshotsConfig holds settings for bucket snapshots, read-only
+// point-in-time views of a bucket's latest object versions.
+type SnapshotsConfig struct {
+	// Enabled exposes the snapshot API and deletes expired snapshots.
+	Enabled bool `mapstructure:"enabled"`
+
+	// DefaultRetention is how long a snapshot is kept when it is taken
+	// without a retention. Zero keeps it until it is deleted.
+	DefaultRetention time.Duration `mapstructure:"default_retention"`
+
+	// Interval is how often to look for expired snapshots.
+	Interval time.Duration `mapstructure:"interval"`
+}
+
+// InventoryConfig holds settings for bucket inventory reports, scheduled
+// listings of a bucket's objects written to another bucket.
+type InventoryConfig struct {
+	// Enabled exposes the inventory configuration API and generates reports.
+	Enabled bool `mapstructure:"enabled"`
+
+	// Interval is how often to look for reports that are due.
+	Interval time.Duration `mapstructure:"interval"`
+
+	// MaxFileRows is the number of objects per report data file.
+	MaxFileRows int `mapstructure:"max_file_rows"`
+}
+
+// WebsiteConfig holds settings for static website hosting of public-read
+// buckets.
+type WebsiteConfig struct {
+	// Enabled exposes the website configuration API and serves websites.
+	Enabled bool `mapstructure:"enabled"`
+
+	// Port, if set, serves websites on their own listener. Otherwise they
+	// share the S3 port and are told apart by HostSuffix.
+	Port int `mapstructure:"port"`
+
+	// HostSuffix names buckets by subdomain: bucket "site" is served at
+	// site.<host_suffix>. Without it, on its own port, the whole host
+	// names the bucket, for CNAMEs matching bucket names.
+	HostSuffix string `mapstructure:"host_suffix"`
+}
+
+// MeteringConfig holds usage accounting settings.
+type MeteringConfig struct {
+	// Enabled records per-bucket, per-access-key usage for billing reports.
+	Enabled bool `mapstructure:"enabled"`
+
+	// FlushInterval is how often buffered counters are written to the database.
+	FlushInterval time.Duration `mapstructure:"flush_interval"`
+}
+
+// EventsConfig holds object change event settings.
+type EventsConfig struct {
+	// Enabled records object changes in the event outbox and serves the
+	// watch API (GET /{bucket}?watch).
+	Enabled bool `mapstructure:"enabled"`
+
+	// Retention is how long events are kept for clients resuming a watch.
+	Retention time.Duration `mapstructure:"retention"`
+
+	// PollInterval is how often watchers check the outbox for new events.
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+
+	// SettleDelay holds back events this recent, so events from concurrent
+	// transactions that commit out of ID order are not skipped.
+	SettleDelay time.Duration `mapstructure:"settle_delay"`
+
+	// HeartbeatInterval is how often idle watch streams get a keep-alive comment.
+	HeartbeatInterval time.Duration `mapstructure:"heartbeat_interval"`
+}
+
+// ReplicationConfig holds bucket replication settings.
+type ReplicationConfig struct {
+	// Enabled serves the bucket replication API (PUT /{bucket}?replication)
+	// and runs the worker that copies new versions to the destinations.
+	Enabled bool `mapstructure:"enabled"`
+
+	// Interval is how often the worker checks an idle queue.
+	Interval time.Duration `mapstructure:"interval"`
+
+	// BatchSize is the number of queued changes claimed at a time.
+	BatchSize int `mapstructure:"batch_size"`
+
+	// Concurrency is the number of changes replicated in parallel.
+	Concurrency int `mapstructure:"concurrency"`
+
+	// Lease is how long a claimed change is hidden from other servers.
+	Lease time.Duration `mapstructure:"lease"`
+
+	// MaxAttempts is how often a change is tried before its version is
+	// marked FAILED.
+	MaxAttempts int `mapstructure:"max_attempts"`
+
+	// MinBackoff is the delay before the first retry. It doubles with every
+	// failed attempt, up to MaxBackoff.
+	MinBackoff time.Duration `mapstructure:"min_backoff"`
+	MaxBackoff time.Duration `mapstructure:"max_backoff"`
+}
+
+// AccessLogConfig holds settings for the access log, which records one
+// line per request separately from the application log.
+type AccessLogConfig struct {
+	// Enabled determines if requests are logged.
+	Enabled bool `mapstructure:"enabled"`
+
+	// Output is where lines are written: "stdout", "stderr", "file", or
+	// "log" for the application log with component=access.
+	Output string `mapstructure:"output"`
+
+	// File is the path of the log file for the "file" output.
+	File string `mapstructure:"file"`
+
+	// MaxSizeMB is the size at which the file is rotated; 0 disables
+	// rotation, e.g. when logrotate rotates the file and sends SIGHUP.
+	MaxSizeMB int `mapstructure:"max_size_mb"`
+
+	// MaxBackups is the number of rotated files kept.
+	MaxBackups int `mapstructure:"max_backups"`
+}
+
+// AuditConfig holds audit log settings.
+type AuditConfig struct {
+	// Enabled records every write through the S3 API and every
+	// administrative action in the audit_log table.
+	Enabled bool `mapstructure:"enabled"`
+
+	// FlushInterval is how often queued API events are written.
+	FlushInterval time.Duration `mapstructure:"flush_interval"`
+
+	// File, if set, also appends every event to this file as JSON lines.
+	File string `mapstructure:"file"`
+
+	// FileFilter selects the events appended to File.
+	FileFilter AuditSinkFilter `mapstructure:"file_filter"`
+
+	// Syslog also sends every event to a syslog server.
+	Syslog AuditSyslogConfig `mapstructure:"syslog"`
+
+	// Webhook also posts every event to an HTTP endpoint.
+	Webhook AuditWebhookConfig `mapstructure:"webhook"`
+
+	// Retention prunes events by category once they are old enough.
+	Retention AuditRetentionConfig `mapstructure:"retention"`
+}
+
+// AuditSinkFilter selects the events sent to an audit sink.
+type AuditSinkFilter struct {
+	// MinSeverity is info, notice or warning. Empty sends every event.
+	MinSeverity string `mapstructure:"min_severity"`
+
+	// Categories lists operational, security or legal. Empty sends every
+	// category.
+	Categories []string `mapstructure:"categories"`
+}
+
+// Severity returns the minimum severity, empty if unset.
+func (f AuditSinkFilter) Severity() domain.AuditSeverity {
+	return domain.AuditSeverity(f.MinSeverity)
+}
+
+// CategoryList returns the categories.
+func (f AuditSinkFilter) CategoryList() []domain.AuditCategory {
+	categories := make([]domain.AuditCategory, len(f.Categories))
+	for i, category := range f.Categories {
+		categories[i] = domain.AuditCategory(category)
+	}
+	return categories
+}
+
+// validate checks the severity and category names.
+func (f AuditSinkFilter) validate(prefix string) error {
+	if f.MinSeverity != "" {
+		if _, err := domain.ParseAuditSeverity(f.MinSeverity); err != nil {
+			return fmt.Errorf("%s.min_severity: %w", prefix, err)
+		}
+	}
+	for _, category := range f.Categories {
+		if _, err := domain.ParseAuditCategory(category); err != nil {
+			return fmt.Errorf("%s.categories: %w", prefix, err)
+		}
+	}
+	return nil
+}
+
+// AuditWebhookConfig holds the HTTP destination of the audit log.
+type AuditWebhookConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+
+	// URL receives a POST with a JSON array of events on every flush.
+	URL string `mapstructure:"url"`
 
-	// Service is the service name for AWS v4 signature verification.
-	Service string `mapstructure:"service"`
+	// Secret, if set, signs each body with HMAC-SHA256 in the
+	// X-Alexander-Signature header.
+	Secret string `mapstructure:"secret"`
 
-	// PresignedURLExpiration is the default expiration time for presigned URLs.
-	PresignedURLExpiration time.Duration `mapstructure:"presigned_url_expiration"`
+	// Timeout bounds each request.
+	Timeout time.Duration `mapstructure:"timeout"`
 
-	// MaxSignatureAge is the maximum age of a signature before it's considered expired.
-	MaxSignatureAge time.Duration `mapstructure:"max_signature_age"`
+	AuditSinkFilter `mapstructure:",squash"`
+}
+
+// AuditRetentionConfig holds how long audit events are kept, per category.
+// Zero keeps events forever.
+type AuditRetentionConfig struct {
+	Operational time.Duration `mapstructure:"operational"`
+	Security    time.Duration `mapstructure:"security"`
+	Legal       time.Duration `mapstructure:"legal"`
+
+	// PruneInterval is how often expired events are deleted.
+	PruneInterval time.Duration `mapstructure:"prune_interval"`
 }
 
-// GetEncryptionKey returns the encryption key as a byte slice.
-// Returns an error if the key is not exactly 32 bytes.
-func (c AuthConfig) GetEncryptionKey() ([]byte, error) {
-	key := []byte(c.EncryptionKey)
-	if len(key) != 32 {
-		return nil, fmt.Errorf("encryption key must be exactly 32 bytes, got %d", len(key))
+// ByCategory returns the retention of each category.
+func (r AuditRetentionConfig) ByCategory() map[domain.AuditCategory]time.Duration {
+	return map[domain.AuditCategory]time.Duration{
+		domain.AuditCategoryOperational: r.Operational,
+		domain.AuditCategorySecurity:    r.Security,
+		domain.AuditCategoryLegal:       r.Legal,
 	}
-	return key, nil
 }
 
-// LoggingConfig holds logging settings.
-type LoggingConfig struct {
-	Level      string `mapstructure:"level"`
-	Format     string `mapstructure:"format"`
-	Output     string `mapstructure:"output"`
-	TimeFormat string `mapstructure:"time_format"`
+// AuditSyslogConfig holds the syslog destination of the audit log.
+type AuditSyslogConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+
+	// Network is udp, tcp, unix or unixgram.
+	Network string `mapstructure:"network"`
+
+	// Address is host:port, or a socket path such as /dev/log.
+	Address string `mapstructure:"address"`
+
+	// Facility is user, daemon, auth, authpriv or local0-local7.
+	Facility string `mapstructure:"facility"`
+
+	AuditSinkFilter `mapstructure:",squash"`
 }
 
-// MetricsConfig holds Prometheus metrics settings.
-type MetricsConfig struct {
-	// Enabled determines if metrics collection is active.
+// WarmupConfig holds startup cache warming settings.
+type WarmupConfig struct {
+	// Enabled reads hot buckets, object listings and access keys before the
+	// server starts accepting requests, so the first requests after a restart
+	// do not pay for a cold database cache.
 	Enabled bool `mapstructure:"enabled"`
 
-	// Port is the port for the metrics HTTP server.
-	Port int `mapstructure:"port"`
+	// Lookback is how much usage history (see metering) ranks hot buckets
+	// and access keys. Without usage data all buckets are warmed, up to MaxBuckets.
+	Lookback time.Duration `mapstructure:"lookback"`
 
-	// Path is the URL path for the metrics endpoint.
-	Path string `mapstructure:"path"`
+	// MaxBuckets is the number of buckets warmed.
+	MaxBuckets int `mapstructure:"max_buckets"`
+
+	// MaxAccessKeys is the number of access keys warmed.
+	MaxAccessKeys int `mapstructure:"max_access_keys"`
+
+	// KeysPerBucket is the number of object keys listed in each bucket.
+	KeysPerBucket int `mapstructure:"keys_per_bucket"`
+
+	// CachedKeysPerBucket is the number of listed keys per bucket whose
+	// metadata is loaded into the object cache, if it is enabled.
+	CachedKeysPerBucket int `mapstructure:"cached_keys_per_bucket"`
+
+	// Timeout bounds how long startup waits for the warmup.
+	Timeout time.Duration `mapstructure:"timeout"`
 }
 
-// RateLimitConfig holds rate limiting settings.
-type RateLimitConfig struct {
-	// Enabled determines if rate limiting is active.
+// ObjectCacheConfig holds settings of the object metadata cache, which
+// serves GetObject and HeadObject lookups of hot keys without a database
+// query. Writes through this server invalidate the keys they change. Each
+// server caches on its own, so others may serve a changed object's previous
+// metadata for up to TTL.
+type ObjectCacheConfig struct {
 	Enabled bool `mapstructure:"enabled"`
 
-	// RequestsPerSecond is the rate of token refill per client.
-	RequestsPerSecond float64 `mapstructure:"requests_per_second"`
+	// TTL is how long found objects are cached.
+	TTL time.Duration `mapstructure:"ttl"`
 
-	// BurstSize is the maximum number of tokens (burst capacity).
-	BurstSize int `mapstructure:"burst_size"`
+	// NegativeTTL is how long lookups of missing keys are cached, so
+	// repeated 404s do not each reach the database.
+	NegativeTTL time.Duration `mapstructure:"negative_ttl"`
 
-	// BandwidthEnabled enables bandwidth limiting.
-	BandwidthEnabled bool `mapstructure:"bandwidth_enabled"`
+	// Buckets limits caching to the named buckets. Empty caches all buckets.
+	Buckets []string `mapstructure:"buckets"`
 
-	// BytesPerSecond is the bandwidth limit per client (in bytes).
-	BytesPerSecond int64 `mapstructure:"bytes_per_second"`
+	// ExcludeBuckets are never cached, e.g. buckets whose objects change
+	// on every request.
+	ExcludeBuckets []string `mapstructure:"exclude_buckets"`
 }
 
-// GCConfig holds garbage collection settings.
-type GCConfig struct {
-	// Enabled determines if automatic garbage collection runs.
+// LifecycleConfig holds lifecycle rule settings.
+type LifecycleConfig struct {
+	// Enabled runs the scheduler applying the buckets' lifecycle rules. It
+	// is off by default: earlier releases stored rules without applying
+	// them, and turning it on deletes every object they match.
 	Enabled bool `mapstructure:"enabled"`
 
-	// Interval is how often to run garbage collection.
+	// Interval is how often the rules are applied.
 	Interval time.Duration `mapstructure:"interval"`
 
-	// GracePeriod is how long to wait before deleting orphan blobs.
-	GracePeriod time.Duration `mapstructure:"grace_period"`
-
-	// BatchSize is the maximum number of blobs to process per run.
+	// BatchSize is the maximum number of objects each action of a rule
+	// removes per run; the next run continues with the rest.
 	BatchSize int `mapstructure:"batch_size"`
 
-	// DryRun logs what would be deleted without actually deleting.
-	DryRun bool `mapstructure:"dry_run"`
+	// RuleCacheTTL is how long each bucket's rules are cached for the
+	// x-amz-expiration header of GET, HEAD and PUT. Rule changes made on
+	// another server or with alexander-admin are seen after at most this.
+	RuleCacheTTL time.Duration `mapstructure:"rule_cache_ttl"`
+}
+
+// TenancyConfig holds multi-tenancy settings. Tenants and their quotas and
+// rate limits are managed through the admin API and alexander-admin.
+type TenancyConfig struct {
+	// NamespaceBuckets requires the names of new buckets to start with the
+	// name of their owner's tenant and a hyphen, such as "acme-logs".
+	// Buckets of the default tenant are exempt.
+	NamespaceBuckets bool `mapstructure:"namespace_buckets"`
 }
 
 // EncryptionConfig holds encryption settings for Fusion Engine.
@@ -251,7 +1344,9 @@ type VersioningConfig struct {
 	MinSavingsThreshold float64 `mapstructure:"min_savings_threshold"`
 }
 
-// ClusterConfig holds multi-node cluster settings.
+// ClusterConfig holds multi-node cluster settings. Nodes discover each
+// other through heartbeats in the shared PostgreSQL database and place
+// blobs on their local disks by consistent hashing.
 type ClusterConfig struct {
 	// Enabled enables multi-node clustering.
 	Enabled bool `mapstructure:"enabled"`
@@ -262,14 +1357,19 @@ type ClusterConfig struct {
 	// NodeRole is the role of this node: "hot", "warm", or "cold".
 	NodeRole string `mapstructure:"node_role"`
 
-	// GRPCPort is the port for gRPC inter-node communication.
+	// GRPCPort is the port of the internal blob API other nodes use to
+	// fetch and place blobs.
 	GRPCPort int `mapstructure:"grpc_port"`
 
 	// GRPCAddress is the address other nodes use to reach this node.
 	// If empty, uses server.host:grpc_port.
 	GRPCAddress string `mapstructure:"grpc_address"`
 
-	// Nodes is the list of other nodes in the cluster.
+	// Secret is the shared secret nodes present to each other's internal API.
+	Secret string `mapstructure:"secret"`
+
+	// Nodes is the list of other nodes in the cluster. Unused: members are
+	// discovered through the database.
 	Nodes []NodeConfig `mapstructure:"nodes"`
 
 	// HeartbeatInterval is how often to send heartbeats.
@@ -278,13 +1378,28 @@ type ClusterConfig struct {
 	// HeartbeatTimeout is how long before a node is considered unhealthy.
 	HeartbeatTimeout time.Duration `mapstructure:"heartbeat_timeout"`
 
-	// ReplicationFactor is the default number of replicas for blobs.
+	// ReplicationFactor is the number of nodes each blob is stored on.
 	ReplicationFactor int `mapstructure:"replication_factor"`
+
+	// VirtualNodes is the number of hash ring positions per node.
+	VirtualNodes int `mapstructure:"virtual_nodes"`
+
+	// RebalanceInterval is the time between full rebalance passes when no
+	// node joins or leaves.
+	RebalanceInterval time.Duration `mapstructure:"rebalance_interval"`
+}
+
+// AdvertiseAddress returns the address other nodes use to reach this node.
+func (c ClusterConfig) AdvertiseAddress(serverHost string) string {
+	if c.GRPCAddress != "" {
+		return c.GRPCAddress
+	}
+	return net.JoinHostPort(serverHost, strconv.Itoa(c.GRPCPort))
 }
 
 // NodeConfig holds configuration for a remote node.
 type NodeConfig struct {
-	// Address is the gRPC address (host:port) of the node.
+	// Address is the internal API address (host:port) of the node.
 	Address string `mapstructure:"address"`
 
 	// Role is the role of this node: "hot", "warm", or "cold".
@@ -346,10 +1461,39 @@ type MigrationConfig struct {
 // Environment variables take precedence over file values.
 // Environment variables are prefixed with ALEXANDER_ and use _ as separator.
 func Load(configPath string) (*Config, error) {
+	return load(configPath, nil)
+}
+
+// LoadDev loads the configuration of development mode: an embedded server
+// keeping everything below dataDir, with SQLite, filesystem storage and keys
+// in files, without Redis or metrics, and logging for the console. These
+// replace the defaults, so a configuration file, which is only read if
+// configPath is set, and environment variables still override them.
+func LoadDev(configPath, dataDir string) (*Config, error) {
+	return load(configPath, func(v *viper.Viper) {
+		v.SetDefault("server.tls.acme.cache_dir", filepath.Join(dataDir, "acme"))
+		v.SetDefault("database.driver", "sqlite")
+		v.SetDefault("database.path", filepath.Join(dataDir, "alexander.db"))
+		v.SetDefault("storage.data_dir", filepath.Join(dataDir, "blobs"))
+		v.SetDefault("storage.temp_dir", filepath.Join(dataDir, "temp"))
+		v.SetDefault("keys.provider", "file")
+		v.SetDefault("keys.dir", filepath.Join(dataDir, "keys"))
+		v.SetDefault("redis.enabled", false)
+		v.SetDefault("metrics.enabled", false)
+		v.SetDefault("logging.format", "console")
+	})
+}
+
+// load reads the configuration. dev, if set, overrides the defaults and
+// disables the search for a configuration file.
+func load(configPath string, dev func(v *viper.Viper)) (*Config, error) {
 	v := viper.New()
 
 	// Set defaults
 	setDefaults(v)
+	if dev != nil {
+		dev(v)
+	}
 
 	// Environment variable configuration
 	v.SetEnvPrefix("ALEXANDER")
@@ -368,17 +1512,20 @@ func Load(configPath string) (*Config, error) {
 	}
 
 	// Read config file (optional - environment variables can be used instead)
-	if err := v.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			return nil, fmt.Errorf("error reading config file: %w", err)
+	if configPath != "" || dev == nil {
+		if err := v.ReadInConfig(); err != nil {
+			if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+				return nil, fmt.Errorf("error reading config file: %w", err)
+			}
+			// Config file not found is acceptable - use defaults and env vars
 		}
-		// Config file not found is acceptable - use defaults and env vars
 	}
 
 	var cfg Config
 	if err := v.Unmarshal(&cfg); err != nil {
 		return nil, fmt.Errorf("error unmarshaling config: %w", err)
 	}
+	cfg.file = v.ConfigFileUsed()
 
 	// Validate configuration
 	if err := cfg.Validate(); err != nil {
@@ -398,6 +1545,25 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("server.idle_timeout", 120*time.Second)
 	v.SetDefault("server.shutdown_timeout", 30*time.Second)
 	v.SetDefault("server.max_body_size", 5*1024*1024*1024) // 5GB
+	v.SetDefault("server.max_api_body_size", 10*1024*1024) // 10MB
+	v.SetDefault("server.read_header_timeout", 10*time.Second)
+	v.SetDefault("server.max_header_bytes", 1<<20) // 1MB
+	v.SetDefault("server.keep_alive", true)
+	v.SetDefault("server.max_connections", 0)
+	v.SetDefault("server.max_requests_per_connection", 0)
+	v.SetDefault("server.max_concurrent_reads", 0)
+	v.SetDefault("server.max_concurrent_writes", 0)
+	v.SetDefault("server.max_queued_requests", 1000)
+	v.SetDefault("server.queue_timeout", 5*time.Second)
+	v.SetDefault("server.detect_content_type", false)
+	v.SetDefault("server.http2.enabled", true)
+	v.SetDefault("server.http2.cleartext", false)
+	v.SetDefault("server.tls.enabled", false)
+	v.SetDefault("server.tls.min_version", "1.2")
+	v.SetDefault("server.tls.client_auth", "require")
+	v.SetDefault("server.tls.acme.enabled", false)
+	v.SetDefault("server.tls.acme.cache_dir", "./data/acme")
+	v.SetDefault("server.tls.acme.renew_before", 30*24*time.Hour)
 
 	// Database defaults
 	v.SetDefault("database.driver", "postgres")
@@ -411,12 +1577,24 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("database.max_idle_conns", 5)
 	v.SetDefault("database.conn_max_lifetime", 5*time.Minute)
 	v.SetDefault("database.conn_max_idle_time", 5*time.Minute)
+	v.SetDefault("database.statement_cache_size", 512)
+	v.SetDefault("database.slow_query_threshold", 500*time.Millisecond)
 	// SQLite defaults
 	v.SetDefault("database.path", "./data/alexander.db")
 	v.SetDefault("database.journal_mode", "WAL")
 	v.SetDefault("database.busy_timeout", 5000)
 	v.SetDefault("database.cache_size", -2000)
 	v.SetDefault("database.synchronous_mode", "NORMAL")
+	v.SetDefault("database.maintenance.checkpoint_interval", 5*time.Minute)
+	v.SetDefault("database.maintenance.optimize_interval", time.Hour)
+	v.SetDefault("database.backfill.enabled", true)
+	v.SetDefault("database.backfill.batch_size", 1000)
+	v.SetDefault("database.backfill.pause", 100*time.Millisecond)
+	v.SetDefault("database.replica.enabled", false)
+	v.SetDefault("database.replica.port", 5432)
+	v.SetDefault("database.replica.max_lag", 5*time.Second)
+	v.SetDefault("database.replica.check_interval", time.Second)
+	v.SetDefault("database.replica.list_from_primary", false)
 
 	// Redis defaults
 	v.SetDefault("redis.host", "localhost")
@@ -435,6 +1613,19 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("storage.multipart.max_part_size", 5*1024*1024*1024) // 5GB
 	v.SetDefault("storage.multipart.max_parts", 10000)
 	v.SetDefault("storage.multipart.upload_expiration", 7*24*time.Hour) // 7 days
+	v.SetDefault("storage.multipart.reap_interval", 1*time.Hour)
+	v.SetDefault("storage.sync", "off")
+	v.SetDefault("storage.sync_interval", 1*time.Second)
+	v.SetDefault("storage.temp_max_age", 24*time.Hour)
+	v.SetDefault("storage.read_ahead.threshold", 0)
+	v.SetDefault("storage.read_ahead.chunk_size", 1024*1024) // 1MB
+	v.SetDefault("storage.read_ahead.concurrency", 4)
+	v.SetDefault("storage.permissions.enforce", false)
+	v.SetDefault("storage.permissions.auto_fix", true)
+	v.SetDefault("storage.erasure.data_shards", 4)
+	v.SetDefault("storage.erasure.parity_shards", 2)
+	v.SetDefault("storage.erasure.block_size", 1024*1024) // 1MB
+	v.SetDefault("storage.erasure.scrub_interval", 24*time.Hour)
 
 	// Auth defaults
 	v.SetDefault("auth.encryption_key", "") // Must be provided
@@ -442,18 +1633,69 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("auth.service", "s3")
 	v.SetDefault("auth.presigned_url_expiration", 15*time.Minute)
 	v.SetDefault("auth.max_signature_age", 15*time.Minute)
+	v.SetDefault("auth.ldap.enabled", false)
+	v.SetDefault("auth.ldap.user_filter", "(uid=%s)")
+	v.SetDefault("auth.ldap.email_attribute", "mail")
+	v.SetDefault("auth.ldap.group_attribute", "memberOf")
+	v.SetDefault("auth.ldap.provision_users", true)
+	v.SetDefault("auth.ldap.local_fallback", true)
+	v.SetDefault("auth.ldap.timeout", 10*time.Second)
+	v.SetDefault("auth.oidc.enabled", false)
+	v.SetDefault("auth.oidc.scopes", []string{"email", "profile"})
+	v.SetDefault("auth.oidc.email_claim", "email")
+	v.SetDefault("auth.oidc.provision_users", false)
+	v.SetDefault("auth.oidc.default_role", "none")
+	v.SetDefault("auth.oidc.timeout", 10*time.Second)
+	v.SetDefault("auth.password_policy.min_length", 8)
+	v.SetDefault("auth.max_access_keys_per_user", 5)
+	v.SetDefault("auth.self_service_keys", true)
 
 	// Logging defaults
 	v.SetDefault("logging.level", "info")
 	v.SetDefault("logging.format", "json")
 	v.SetDefault("logging.output", "stdout")
 	v.SetDefault("logging.time_format", time.RFC3339)
+	v.SetDefault("logging.file", "")
+	v.SetDefault("logging.max_size_mb", 100)
+	v.SetDefault("logging.max_age", 0)
+	v.SetDefault("logging.max_backups", 5)
+	v.SetDefault("logging.sampling.enabled", false)
+	v.SetDefault("logging.sampling.period", 1*time.Second)
+	v.SetDefault("logging.sampling.trace.burst", 10)
+	v.SetDefault("logging.sampling.trace.every", 100)
+	v.SetDefault("logging.sampling.debug.burst", 100)
+	v.SetDefault("logging.sampling.debug.every", 10)
+	v.SetDefault("logging.sampling.info.burst", 0)
+	v.SetDefault("logging.sampling.info.every", 0)
+
+	// Key provider defaults
+	v.SetDefault("keys.provider", "config")
+	v.SetDefault("keys.env_prefix", "ALEXANDER_KEY_")
+	v.SetDefault("keys.vault.mount", "secret")
+	v.SetDefault("keys.vault.path", "alexander-storage")
+	v.SetDefault("keys.vault.timeout", 10*time.Second)
+	v.SetDefault("keys.kms.timeout", 10*time.Second)
 
 	// Metrics defaults
 	v.SetDefault("metrics.enabled", true)
 	v.SetDefault("metrics.port", 9091)
 	v.SetDefault("metrics.path", "/metrics")
 
+	// Health defaults
+	v.SetDefault("health.database_latency", 100*time.Millisecond)
+	v.SetDefault("health.storage_latency", 500*time.Millisecond)
+	v.SetDefault("health.redis_latency", 100*time.Millisecond)
+	v.SetDefault("health.gc_lag", 0)
+
+	// Telemetry defaults
+	v.SetDefault("telemetry.enabled", false)
+	v.SetDefault("telemetry.endpoint", "http://localhost:4318")
+	v.SetDefault("telemetry.service_name", "alexander-storage")
+	v.SetDefault("telemetry.sample_ratio", 0.1)
+	v.SetDefault("telemetry.batch_size", 512)
+	v.SetDefault("telemetry.export_interval", 5*time.Second)
+	v.SetDefault("telemetry.export_timeout", 10*time.Second)
+
 	// Rate limiting defaults
 	v.SetDefault("rate_limit.enabled", true)
 	v.SetDefault("rate_limit.requests_per_second", 100)
@@ -466,7 +1708,141 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("gc.interval", 1*time.Hour)
 	v.SetDefault("gc.grace_period", 24*time.Hour)
 	v.SetDefault("gc.batch_size", 1000)
+	v.SetDefault("gc.workers", 4)
+	v.SetDefault("gc.progress_interval", 30*time.Second)
 	v.SetDefault("gc.dry_run", false)
+	v.SetDefault("gc.delete_retries", 3)
+	v.SetDefault("gc.retry_backoff", 500*time.Millisecond)
+	v.SetDefault("gc.verify.enabled", false)
+	v.SetDefault("gc.verify.interval", 24*time.Hour)
+	v.SetDefault("gc.verify.grace_period", 1*time.Hour)
+	v.SetDefault("gc.verify.quarantine_dir", "")
+	v.SetDefault("gc.reconcile.enabled", false)
+	v.SetDefault("gc.reconcile.interval", 24*time.Hour)
+	v.SetDefault("gc.reconcile.batch_size", 1000)
+	v.SetDefault("gc.reconcile.dry_run", false)
+	v.SetDefault("gc.purge.enabled", true)
+	v.SetDefault("gc.purge.interval", 6*time.Hour)
+	v.SetDefault("gc.purge.retention", 7*24*time.Hour)
+	v.SetDefault("gc.purge.batch_size", 1000)
+
+	// Scrub defaults
+	v.SetDefault("scrub.enabled", false)
+	v.SetDefault("scrub.interval", 1*time.Hour)
+	v.SetDefault("scrub.verify_after", 30*24*time.Hour)
+	v.SetDefault("scrub.batch_size", 100)
+	v.SetDefault("scrub.bytes_per_second", 50*1024*1024)
+	v.SetDefault("scrub.repair_dir", "")
+
+	// Manifest API defaults
+	v.SetDefault("manifest.enabled", false)
+	v.SetDefault("manifest.token", "")
+	v.SetDefault("manifest.max_page_size", 1000)
+	v.SetDefault("manifest.requests_per_second", 10)
+	v.SetDefault("manifest.burst_size", 20)
+
+	// Admin API defaults
+	v.SetDefault("admin_api.enabled", false)
+	v.SetDefault("admin_api.token", "")
+	v.SetDefault("admin_api.port", 0)
+	v.SetDefault("admin_api.tls.enabled", false)
+	v.SetDefault("admin_api.tls.min_version", "1.2")
+	v.SetDefault("admin_api.tls.client_auth", "require")
+
+	// Quarantine defaults
+	v.SetDefault("quarantine.enabled", false)
+	v.SetDefault("quarantine.token", "")
+	v.SetDefault("quarantine.expire_after", 7*24*time.Hour)
+	v.SetDefault("quarantine.interval", 1*time.Hour)
+	v.SetDefault("quarantine.batch_size", 100)
+
+	// Batch defaults
+	v.SetDefault("batch.enabled", false)
+	v.SetDefault("batch.expire_after", 24*time.Hour)
+	v.SetDefault("batch.interval", 15*time.Minute)
+
+	// Snapshot defaults
+	v.SetDefault("snapshots.enabled", false)
+	v.SetDefault("snapshots.default_retention", 0)
+	v.SetDefault("snapshots.interval", 1*time.Hour)
+
+	// Inventory defaults
+	v.SetDefault("inventory.enabled", false)
+	v.SetDefault("inventory.interval", 1*time.Hour)
+	v.SetDefault("inventory.max_file_rows", 1000000)
+
+	// Website defaults
+	v.SetDefault("website.enabled", false)
+	v.SetDefault("website.port", 0)
+	v.SetDefault("website.host_suffix", "")
+
+	// Metering defaults
+	v.SetDefault("metering.enabled", false)
+	v.SetDefault("metering.flush_interval", 1*time.Minute)
+
+	// Event defaults
+	v.SetDefault("events.enabled", false)
+	v.SetDefault("events.retention", 24*time.Hour)
+	v.SetDefault("events.poll_interval", 1*time.Second)
+	v.SetDefault("events.settle_delay", 2*time.Second)
+	v.SetDefault("events.heartbeat_interval", 30*time.Second)
+
+	// Replication defaults
+	v.SetDefault("replication.enabled", false)
+	v.SetDefault("replication.interval", 5*time.Second)
+	v.SetDefault("replication.batch_size", 100)
+	v.SetDefault("replication.concurrency", 4)
+	v.SetDefault("replication.lease", 5*time.Minute)
+	v.SetDefault("replication.max_attempts", 10)
+	v.SetDefault("replication.min_backoff", 10*time.Second)
+	v.SetDefault("replication.max_backoff", 1*time.Hour)
+
+	// Access log defaults
+	v.SetDefault("access_log.enabled", false)
+	v.SetDefault("access_log.output", "stdout")
+	v.SetDefault("access_log.file", "")
+	v.SetDefault("access_log.max_size_mb", 100)
+	v.SetDefault("access_log.max_backups", 5)
+
+	// Audit defaults
+	v.SetDefault("audit.enabled", false)
+	v.SetDefault("audit.flush_interval", 1*time.Second)
+	v.SetDefault("audit.file", "")
+	v.SetDefault("audit.syslog.enabled", false)
+	v.SetDefault("audit.syslog.network", "udp")
+	v.SetDefault("audit.syslog.address", "localhost:514")
+	v.SetDefault("audit.syslog.facility", "authpriv")
+	v.SetDefault("audit.webhook.enabled", false)
+	v.SetDefault("audit.webhook.url", "")
+	v.SetDefault("audit.webhook.secret", "")
+	v.SetDefault("audit.webhook.timeout", 5*time.Second)
+	v.SetDefault("audit.retention.operational", 0)
+	v.SetDefault("audit.retention.security", 0)
+	v.SetDefault("audit.retention.legal", 0)
+	v.SetDefault("audit.retention.prune_interval", 1*time.Hour)
+
+	// Warmup defaults
+	v.SetDefault("warmup.enabled", false)
+	v.SetDefault("warmup.lookback", 24*time.Hour)
+	v.SetDefault("warmup.max_buckets", 20)
+	v.SetDefault("warmup.max_access_keys", 100)
+	v.SetDefault("warmup.keys_per_bucket", 1000)
+	v.SetDefault("warmup.cached_keys_per_bucket", 100)
+	v.SetDefault("warmup.timeout", 30*time.Second)
+
+	// Object cache defaults
+	v.SetDefault("object_cache.enabled", false)
+	v.SetDefault("object_cache.ttl", 5*time.Second)
+	v.SetDefault("object_cache.negative_ttl", time.Second)
+
+	// Lifecycle defaults
+	v.SetDefault("lifecycle.enabled", false)
+	v.SetDefault("lifecycle.interval", 1*time.Hour)
+	v.SetDefault("lifecycle.batch_size", 1000)
+	v.SetDefault("lifecycle.rule_cache_ttl", 30*time.Second)
+
+	// Tenancy defaults
+	v.SetDefault("tenancy.namespace_buckets", false)
 
 	// Encryption defaults (Fusion Engine v2.0)
 	v.SetDefault("encryption.scheme", "chacha20-poly1305-stream")
@@ -490,6 +1866,8 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("cluster.heartbeat_interval", 10*time.Second)
 	v.SetDefault("cluster.heartbeat_timeout", 30*time.Second)
 	v.SetDefault("cluster.replication_factor", 1)
+	v.SetDefault("cluster.virtual_nodes", 128)
+	v.SetDefault("cluster.rebalance_interval", 1*time.Hour)
 
 	// Tiering defaults (Fusion Engine v2.0)
 	v.SetDefault("tiering.enabled", false)
@@ -509,6 +1887,39 @@ func (c *Config) Validate() error {
 	if c.Server.Port < 1 || c.Server.Port > 65535 {
 		return fmt.Errorf("server.port must be between 1 and 65535")
 	}
+	if c.Server.MaxBodySize < 0 || c.Server.MaxAPIBodySize < 0 {
+		return fmt.Errorf("server.max_body_size and max_api_body_size must not be negative")
+	}
+	if c.Server.MaxHeaderBytes < 0 || c.Server.MaxConnections < 0 || c.Server.MaxRequestsPerConnection < 0 {
+		return fmt.Errorf("server.max_header_bytes, max_connections and max_requests_per_connection must not be negative")
+	}
+	if c.Server.MaxConcurrentReads < 0 || c.Server.MaxConcurrentWrites < 0 || c.Server.MaxQueuedRequests < 0 || c.Server.QueueTimeout < 0 {
+		return fmt.Errorf("server.max_concurrent_reads, max_concurrent_writes, max_queued_requests and queue_timeout must not be negative")
+	}
+	if h2 := c.Server.HTTP2; h2.MaxReadFrameSize != 0 && (h2.MaxReadFrameSize < 16<<10 || h2.MaxReadFrameSize > 16<<20-1) {
+		return fmt.Errorf("server.http2.max_read_frame_size must be between 16KB and 16MB")
+	}
+	if tls := c.Server.TLS; tls.Enabled {
+		if tls.ACME.Enabled {
+			if len(tls.ACME.Domains) == 0 {
+				return fmt.Errorf("server.tls.acme.domains is required when ACME is enabled")
+			}
+			if tls.ACME.CacheDir == "" {
+				return fmt.Errorf("server.tls.acme.cache_dir is required when ACME is enabled")
+			}
+			if tls.CertFile != "" || tls.KeyFile != "" {
+				return fmt.Errorf("server.tls.cert_file and key_file cannot be used with ACME")
+			}
+		} else if tls.CertFile == "" || tls.KeyFile == "" {
+			return fmt.Errorf("server.tls.cert_file and server.tls.key_file are required when TLS is enabled")
+		}
+		if tls.MinVersion != "1.2" && tls.MinVersion != "1.3" {
+			return fmt.Errorf("server.tls.min_version must be '1.2' or '1.3'")
+		}
+		if tls.ClientAuth != "require" && tls.ClientAuth != "verify_if_given" {
+			return fmt.Errorf("server.tls.client_auth must be 'require' or 'verify_if_given'")
+		}
+	}
 
 	// Validate database configuration
 	validDrivers := map[string]bool{"postgres": true, "sqlite": true}
@@ -531,13 +1942,96 @@ func (c *Config) Validate() error {
 			return fmt.Errorf("database.path is required for sqlite driver")
 		}
 	}
+	if c.Database.StatementCacheSize < 0 {
+		return fmt.Errorf("database.statement_cache_size must not be negative")
+	}
+	if c.Database.SlowQueryThreshold < 0 {
+		return fmt.Errorf("database.slow_query_threshold must not be negative")
+	}
+	if c.Database.Maintenance.CheckpointInterval < 0 {
+		return fmt.Errorf("database.maintenance.checkpoint_interval must not be negative")
+	}
+	if c.Database.Maintenance.OptimizeInterval < 0 {
+		return fmt.Errorf("database.maintenance.optimize_interval must not be negative")
+	}
+	if c.Database.Backfill.BatchSize <= 0 {
+		return fmt.Errorf("database.backfill.batch_size must be positive")
+	}
+	if c.Database.Replica.Enabled {
+		if c.Database.Driver != "postgres" {
+			return fmt.Errorf("database.replica requires the postgres driver")
+		}
+		if c.Database.Replica.Host == "" {
+			return fmt.Errorf("database.replica.host is required when the replica is enabled")
+		}
+		if c.Database.Replica.Port <= 0 || c.Database.Replica.Port > 65535 {
+			return fmt.Errorf("database.replica.port must be between 1 and 65535")
+		}
+		if c.Database.Replica.MaxLag <= 0 {
+			return fmt.Errorf("database.replica.max_lag must be positive")
+		}
+		if c.Database.Replica.CheckInterval <= 0 {
+			return fmt.Errorf("database.replica.check_interval must be positive")
+		}
+	}
 
 	// Validate storage configuration
 	if c.Storage.Backend == "" {
 		return fmt.Errorf("storage.backend is required")
 	}
-	if c.Storage.Backend == "filesystem" && c.Storage.DataDir == "" {
-		return fmt.Errorf("storage.data_dir is required for filesystem backend")
+	switch c.Storage.Backend {
+	case "filesystem":
+		if c.Storage.DataDir == "" {
+			return fmt.Errorf("storage.data_dir is required for filesystem backend")
+		}
+	case "erasure":
+		ec := c.Storage.Erasure
+		if ec.DataShards < 1 || ec.ParityShards < 1 || ec.DataShards+ec.ParityShards > 32 {
+			return fmt.Errorf("storage.erasure: data_shards and parity_shards must be at least 1 and total at most 32")
+		}
+		if len(ec.DataDirs) != ec.DataShards+ec.ParityShards {
+			return fmt.Errorf("storage.erasure.data_dirs must list %d directories, one per shard", ec.DataShards+ec.ParityShards)
+		}
+		if ec.BlockSize <= 0 {
+			return fmt.Errorf("storage.erasure.block_size must be positive")
+		}
+		if ec.ScrubInterval < 0 {
+			return fmt.Errorf("storage.erasure.scrub_interval must not be negative")
+		}
+	}
+	switch c.Storage.Sync {
+	case "off", "always", "batch":
+	default:
+		return fmt.Errorf("storage.sync must be off, always or batch")
+	}
+	if c.Storage.Sync == "batch" && c.Storage.SyncInterval <= 0 {
+		return fmt.Errorf("storage.sync_interval must be positive with storage.sync: batch")
+	}
+	if c.Storage.TempMaxAge < 0 {
+		return fmt.Errorf("storage.temp_max_age must not be negative")
+	}
+	if ra := c.Storage.ReadAhead; ra.Threshold < 0 || ra.ChunkSize <= 0 || ra.Concurrency <= 0 {
+		return fmt.Errorf("storage.read_ahead: threshold must not be negative, chunk_size and concurrency must be positive")
+	}
+	if mp := c.Storage.Multipart; mp.MinPartSize < 0 || mp.MaxPartSize <= 0 || mp.MinPartSize > mp.MaxPartSize {
+		return fmt.Errorf("storage.multipart: min_part_size must not be negative or exceed max_part_size")
+	}
+	if mp := c.Storage.Multipart; mp.MaxParts < 1 || mp.MaxParts > domain.MaxPartNumber {
+		return fmt.Errorf("storage.multipart.max_parts must be between 1 and %d", domain.MaxPartNumber)
+	}
+	if c.Storage.Multipart.ReapInterval < 0 {
+		return fmt.Errorf("storage.multipart.reap_interval must not be negative")
+	}
+	for tag, rc := range c.Storage.Residency {
+		if err := domain.ValidateResidency(tag); err != nil || tag == "" {
+			return fmt.Errorf("storage.residency.%s: invalid residency tag", tag)
+		}
+		if rc.DataDir == "" || rc.TempDir == "" {
+			return fmt.Errorf("storage.residency.%s: data_dir and temp_dir are required", tag)
+		}
+		if filepath.Clean(rc.DataDir) == filepath.Clean(c.Storage.DataDir) {
+			return fmt.Errorf("storage.residency.%s.data_dir must differ from storage.data_dir", tag)
+		}
 	}
 
 	// Validate auth configuration
@@ -546,6 +2040,396 @@ func (c *Config) Validate() error {
 			return fmt.Errorf("auth.encryption_key must be exactly 32 characters")
 		}
 	}
+	switch c.Keys.Provider {
+	case "", "config", "env":
+	case "file":
+		if c.Keys.Dir == "" {
+			return fmt.Errorf("keys.dir is required with the file key provider")
+		}
+	case "vault":
+		if c.Keys.Vault.Address == "" || c.Keys.Vault.Path == "" {
+			return fmt.Errorf("keys.vault.address and keys.vault.path are required with the vault key provider")
+		}
+		if c.Keys.Vault.Token == "" && c.Keys.Vault.TokenFile == "" {
+			return fmt.Errorf("keys.vault.token or keys.vault.token_file is required with the vault key provider")
+		}
+	case "kms":
+		if c.Keys.KMS.Region == "" {
+			return fmt.Errorf("keys.kms.region is required with the kms key provider")
+		}
+		if c.Keys.KMS.EncryptionKey == "" {
+			return fmt.Errorf("keys.kms.encryption_key is required with the kms key provider")
+		}
+	default:
+		return fmt.Errorf("keys.provider must be config, env, file, vault or kms")
+	}
+	if c.Auth.Region == "" {
+		return fmt.Errorf("auth.region is required")
+	}
+	for _, region := range c.Auth.AllowedRegions {
+		if region == "" {
+			return fmt.Errorf("auth.allowed_regions must not contain empty regions")
+		}
+	}
+	if ldap := c.Auth.LDAP; ldap.Enabled {
+		if !strings.HasPrefix(ldap.URL, "ldap://") && !strings.HasPrefix(ldap.URL, "ldaps://") {
+			return fmt.Errorf("auth.ldap.url must start with ldap:// or ldaps://")
+		}
+		if ldap.StartTLS && strings.HasPrefix(ldap.URL, "ldaps://") {
+			return fmt.Errorf("auth.ldap.start_tls cannot be used with an ldaps:// url")
+		}
+		if ldap.BaseDN == "" {
+			return fmt.Errorf("auth.ldap.base_dn is required when LDAP is enabled")
+		}
+		if !strings.Contains(ldap.UserFilter, "%s") {
+			return fmt.Errorf("auth.ldap.user_filter must contain %%s for the username")
+		}
+		if ldap.BindDN != "" && ldap.BindPassword == "" {
+			return fmt.Errorf("auth.ldap.bind_password is required when bind_dn is set")
+		}
+		if len(ldap.AdminGroups)+len(ldap.OperatorGroups)+len(ldap.ReadOnlyGroups) == 0 {
+			return fmt.Errorf("auth.ldap needs at least one group DN in admin_groups, operator_groups or read_only_groups")
+		}
+		if ldap.Timeout <= 0 {
+			return fmt.Errorf("auth.ldap.timeout must be positive")
+		}
+	}
+
+	if c.Auth.PasswordPolicy.MinLength < domain.MinPasswordLength {
+		return fmt.Errorf("auth.password_policy.min_length must be at least %d", domain.MinPasswordLength)
+	}
+	if c.Auth.MaxAccessKeysPerUser < 1 {
+		return fmt.Errorf("auth.max_access_keys_per_user must be positive")
+	}
+
+	if oidc := c.Auth.OIDC; oidc.Enabled {
+		if !strings.HasPrefix(oidc.Issuer, "https://") && !strings.HasPrefix(oidc.Issuer, "http://") {
+			return fmt.Errorf("auth.oidc.issuer must be an http(s) URL")
+		}
+		if oidc.ClientID == "" || oidc.ClientSecret == "" {
+			return fmt.Errorf("auth.oidc.client_id and auth.oidc.client_secret are required when OIDC is enabled")
+		}
+		if !strings.HasSuffix(oidc.RedirectURL, "/dashboard/oidc/callback") {
+			return fmt.Errorf("auth.oidc.redirect_url must end with /dashboard/oidc/callback")
+		}
+		if _, err := domain.ParseUserRole(oidc.DefaultRole); err != nil {
+			return fmt.Errorf("auth.oidc.default_role: %w", err)
+		}
+		if oidc.Timeout <= 0 {
+			return fmt.Errorf("auth.oidc.timeout must be positive")
+		}
+	}
+
+	// Validate admin API configuration
+	if api := c.AdminAPI; api.Enabled {
+		certAuth := api.TLS.Enabled && api.TLS.ClientCAFile != "" && api.TLS.ClientAuth == "require"
+		if len(api.Token) < 32 && !certAuth {
+			return fmt.Errorf("admin_api.token must be at least 32 characters unless admin_api.tls requires client certificates")
+		}
+		if api.Token != "" && len(api.Token) < 32 {
+			return fmt.Errorf("admin_api.token must be at least 32 characters")
+		}
+		if api.Port < 0 || api.Port > 65535 || (api.Port != 0 && api.Port == c.Server.Port) {
+			return fmt.Errorf("admin_api.port must be between 1 and 65535 and differ from server.port, or 0 to use the S3 port")
+		}
+		if api.TLS.Enabled {
+			if api.Port == 0 {
+				return fmt.Errorf("admin_api.tls requires admin_api.port; on the S3 port, server.tls applies")
+			}
+			if api.TLS.CertFile == "" || api.TLS.KeyFile == "" {
+				return fmt.Errorf("admin_api.tls.cert_file and admin_api.tls.key_file are required when admin API TLS is enabled")
+			}
+			if api.TLS.ACME.Enabled {
+				return fmt.Errorf("admin_api.tls does not support ACME")
+			}
+			if api.TLS.ClientAuth != "require" && api.TLS.ClientAuth != "verify_if_given" {
+				return fmt.Errorf("admin_api.tls.client_auth must be 'require' or 'verify_if_given'")
+			}
+		}
+	}
+
+	// Validate manifest configuration
+	if c.Manifest.Enabled {
+		if len(c.Manifest.Token) < 32 {
+			return fmt.Errorf("manifest.token must be at least 32 characters when manifest is enabled")
+		}
+		if c.Manifest.MaxPageSize < 1 {
+			return fmt.Errorf("manifest.max_page_size must be positive")
+		}
+	}
+
+	// Validate quarantine configuration
+	if c.Quarantine.Enabled {
+		if len(c.Quarantine.Token) < 32 {
+			return fmt.Errorf("quarantine.token must be at least 32 characters when quarantine is enabled")
+		}
+		if c.Quarantine.ExpireAfter <= 0 || c.Quarantine.Interval <= 0 {
+			return fmt.Errorf("quarantine.expire_after and quarantine.interval must be positive")
+		}
+		if c.Quarantine.BatchSize < 1 {
+			return fmt.Errorf("quarantine.batch_size must be positive")
+		}
+	}
+
+	// Validate batch configuration
+	if c.Batch.Enabled && (c.Batch.ExpireAfter <= 0 || c.Batch.Interval <= 0) {
+		return fmt.Errorf("batch.expire_after and batch.interval must be positive")
+	}
+
+	// Validate snapshot configuration
+	if c.Snapshots.Enabled && c.Snapshots.Interval <= 0 {
+		return fmt.Errorf("snapshots.interval must be positive")
+	}
+	if c.Snapshots.DefaultRetention < 0 {
+		return fmt.Errorf("snapshots.default_retention must not be negative")
+	}
+
+	// Validate inventory configuration
+	if c.Inventory.Enabled && (c.Inventory.Interval <= 0 || c.Inventory.MaxFileRows <= 0) {
+		return fmt.Errorf("inventory.interval and inventory.max_file_rows must be positive")
+	}
+
+	// Validate website configuration
+	if site := c.Website; site.Enabled {
+		if site.Port < 0 || site.Port > 65535 || (site.Port != 0 && site.Port == c.Server.Port) {
+			return fmt.Errorf("website.port must be between 1 and 65535 and differ from server.port, or 0 to use the S3 port")
+		}
+		if site.Port == 0 && site.HostSuffix == "" {
+			return fmt.Errorf("website.host_suffix is required when websites share the S3 port")
+		}
+	}
+
+	// Validate health thresholds
+	if h := c.Health; h.DatabaseLatency < 0 || h.StorageLatency < 0 || h.RedisLatency < 0 || h.GCLag < 0 {
+		return fmt.Errorf("health thresholds cannot be negative")
+	}
+
+	// Validate metering configuration
+	if c.Metering.Enabled && c.Metering.FlushInterval <= 0 {
+		return fmt.Errorf("metering.flush_interval must be positive")
+	}
+
+	// Validate events configuration
+	if c.Events.Enabled {
+		if c.Events.Retention <= 0 {
+			return fmt.Errorf("events.retention must be positive")
+		}
+		if c.Events.PollInterval <= 0 {
+			return fmt.Errorf("events.poll_interval must be positive")
+		}
+		if c.Events.SettleDelay < 0 {
+			return fmt.Errorf("events.settle_delay must not be negative")
+		}
+		if c.Events.HeartbeatInterval <= 0 {
+			return fmt.Errorf("events.heartbeat_interval must be positive")
+		}
+	}
+
+	// Validate replication configuration
+	if c.Replication.Enabled {
+		if c.Replication.Interval <= 0 {
+			return fmt.Errorf("replication.interval must be positive")
+		}
+		if c.Replication.BatchSize <= 0 {
+			return fmt.Errorf("replication.batch_size must be positive")
+		}
+		if c.Replication.Concurrency <= 0 {
+			return fmt.Errorf("replication.concurrency must be positive")
+		}
+		if c.Replication.Lease <= 0 {
+			return fmt.Errorf("replication.lease must be positive")
+		}
+		if c.Replication.MaxAttempts <= 0 {
+			return fmt.Errorf("replication.max_attempts must be positive")
+		}
+		if c.Replication.MinBackoff <= 0 || c.Replication.MaxBackoff < c.Replication.MinBackoff {
+			return fmt.Errorf("replication.min_backoff must be positive and not exceed replication.max_backoff")
+		}
+	}
+
+	// Validate cluster configuration
+	if c.Cluster.Enabled {
+		if c.Database.Driver != "postgres" {
+			return fmt.Errorf("cluster requires the postgres driver")
+		}
+		if c.Cluster.NodeID == "" {
+			return fmt.Errorf("cluster.node_id is required when cluster is enabled")
+		}
+		if c.Cluster.GRPCPort < 1 || c.Cluster.GRPCPort > 65535 {
+			return fmt.Errorf("cluster.grpc_port must be between 1 and 65535")
+		}
+		host, _, err := net.SplitHostPort(c.Cluster.AdvertiseAddress(c.Server.Host))
+		if err != nil || host == "" || net.ParseIP(host).IsUnspecified() {
+			return fmt.Errorf("cluster.grpc_address must be a host:port other nodes can reach")
+		}
+		if c.Cluster.Secret == "" {
+			return fmt.Errorf("cluster.secret is required when cluster is enabled")
+		}
+		if c.Cluster.ReplicationFactor < 1 {
+			return fmt.Errorf("cluster.replication_factor must be at least 1")
+		}
+		if c.Cluster.HeartbeatInterval <= 0 || c.Cluster.HeartbeatTimeout <= c.Cluster.HeartbeatInterval {
+			return fmt.Errorf("cluster.heartbeat_interval must be positive and less than cluster.heartbeat_timeout")
+		}
+		if c.Cluster.RebalanceInterval <= 0 {
+			return fmt.Errorf("cluster.rebalance_interval must be positive")
+		}
+		if len(c.Storage.Residency) > 0 {
+			return fmt.Errorf("storage.residency is not supported in cluster mode")
+		}
+	}
+
+	// Validate telemetry configuration
+	if c.Telemetry.Enabled {
+		if c.Telemetry.Endpoint == "" {
+			return fmt.Errorf("telemetry.endpoint is required when telemetry is enabled")
+		}
+		if c.Telemetry.SampleRatio < 0 || c.Telemetry.SampleRatio > 1 {
+			return fmt.Errorf("telemetry.sample_ratio must be between 0 and 1")
+		}
+		if c.Telemetry.BatchSize <= 0 {
+			return fmt.Errorf("telemetry.batch_size must be positive")
+		}
+	}
+
+	// Validate access log configuration
+	if c.AccessLog.Enabled {
+		switch c.AccessLog.Output {
+		case "stdout", "stderr", "log":
+		case "file":
+			if c.AccessLog.File == "" {
+				return fmt.Errorf("access_log.file is required for the file output")
+			}
+		default:
+			return fmt.Errorf("access_log.output must be 'stdout', 'stderr', 'file' or 'log'")
+		}
+		if c.AccessLog.MaxSizeMB < 0 || c.AccessLog.MaxBackups < 0 {
+			return fmt.Errorf("access_log.max_size_mb and access_log.max_backups must not be negative")
+		}
+	}
+
+	// Validate audit configuration
+	if c.Audit.Enabled {
+		if c.Audit.FlushInterval <= 0 {
+			return fmt.Errorf("audit.flush_interval must be positive")
+		}
+		if syslogCfg := c.Audit.Syslog; syslogCfg.Enabled {
+			switch syslogCfg.Network {
+			case "udp", "tcp", "unix", "unixgram":
+			default:
+				return fmt.Errorf("audit.syslog.network must be udp, tcp, unix or unixgram")
+			}
+			if syslogCfg.Address == "" {
+				return fmt.Errorf("audit.syslog.address is required when audit syslog is enabled")
+			}
+			if _, err := syslog.ParseFacility(syslogCfg.Facility); err != nil {
+				return fmt.Errorf("audit.syslog.facility: %w", err)
+			}
+			if err := syslogCfg.AuditSinkFilter.validate("audit.syslog"); err != nil {
+				return err
+			}
+		}
+		if err := c.Audit.FileFilter.validate("audit.file_filter"); err != nil {
+			return err
+		}
+		if webhook := c.Audit.Webhook; webhook.Enabled {
+			if u, err := url.Parse(webhook.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("audit.webhook.url must be an http or https URL")
+			}
+			if webhook.Timeout <= 0 {
+				return fmt.Errorf("audit.webhook.timeout must be positive")
+			}
+			if err := webhook.AuditSinkFilter.validate("audit.webhook"); err != nil {
+				return err
+			}
+		}
+		retention := c.Audit.Retention
+		if retention.Operational < 0 || retention.Security < 0 || retention.Legal < 0 {
+			return fmt.Errorf("audit.retention must not be negative")
+		}
+		if retention.PruneInterval <= 0 {
+			return fmt.Errorf("audit.retention.prune_interval must be positive")
+		}
+	}
+
+	// Validate storage verification configuration
+	if c.GC.Verify.Enabled {
+		if c.GC.Verify.Interval <= 0 {
+			return fmt.Errorf("gc.verify.interval must be positive")
+		}
+		if c.GC.Verify.GracePeriod < 0 {
+			return fmt.Errorf("gc.verify.grace_period cannot be negative")
+		}
+		if c.Cluster.Enabled {
+			return fmt.Errorf("gc.verify checks local storage and cannot be enabled in cluster mode")
+		}
+	}
+
+	// Validate reference count reconciliation configuration
+	if c.GC.Reconcile.Enabled {
+		if c.GC.Reconcile.Interval <= 0 || c.GC.Reconcile.BatchSize <= 0 {
+			return fmt.Errorf("gc.reconcile.interval and gc.reconcile.batch_size must be positive")
+		}
+	}
+
+	// Validate object purge configuration
+	if c.GC.Purge.Enabled {
+		if c.GC.Purge.Interval <= 0 || c.GC.Purge.BatchSize <= 0 {
+			return fmt.Errorf("gc.purge.interval and gc.purge.batch_size must be positive")
+		}
+		if c.GC.Purge.Retention < 0 {
+			return fmt.Errorf("gc.purge.retention cannot be negative")
+		}
+	}
+
+	// Validate scrub configuration
+	if c.Scrub.Enabled {
+		if c.Scrub.Interval <= 0 || c.Scrub.VerifyAfter <= 0 {
+			return fmt.Errorf("scrub.interval and scrub.verify_after must be positive")
+		}
+		if c.Scrub.BatchSize < 1 {
+			return fmt.Errorf("scrub.batch_size must be positive")
+		}
+		if c.Scrub.BytesPerSecond < 0 {
+			return fmt.Errorf("scrub.bytes_per_second cannot be negative")
+		}
+	}
+
+	// Validate warmup configuration
+	if c.Warmup.Enabled {
+		if c.Warmup.Lookback <= 0 {
+			return fmt.Errorf("warmup.lookback must be positive")
+		}
+		if c.Warmup.MaxBuckets < 1 || c.Warmup.MaxAccessKeys < 1 {
+			return fmt.Errorf("warmup.max_buckets and warmup.max_access_keys must be positive")
+		}
+		if c.Warmup.KeysPerBucket < 1 || c.Warmup.KeysPerBucket > 1000 {
+			return fmt.Errorf("warmup.keys_per_bucket must be between 1 and 1000")
+		}
+		if c.Warmup.CachedKeysPerBucket < 0 || c.Warmup.CachedKeysPerBucket > c.Warmup.KeysPerBucket {
+			return fmt.Errorf("warmup.cached_keys_per_bucket must be between 0 and warmup.keys_per_bucket")
+		}
+		if c.Warmup.Timeout <= 0 {
+			return fmt.Errorf("warmup.timeout must be positive")
+		}
+	}
+
+	// Validate object cache configuration
+	if c.ObjectCache.Enabled {
+		if c.ObjectCache.TTL <= 0 || c.ObjectCache.NegativeTTL <= 0 {
+			return fmt.Errorf("object_cache.ttl and object_cache.negative_ttl must be positive")
+		}
+	}
+
+	// Validate lifecycle configuration
+	if c.Lifecycle.Enabled {
+		if c.Lifecycle.Interval <= 0 || c.Lifecycle.BatchSize <= 0 {
+			return fmt.Errorf("lifecycle.interval and lifecycle.batch_size must be positive")
+		}
+	}
+	if c.Lifecycle.RuleCacheTTL <= 0 {
+		return fmt.Errorf("lifecycle.rule_cache_ttl must be positive")
+	}
 
 	// Validate logging configuration
 	validLevels := map[string]bool{
@@ -555,10 +2439,41 @@ func (c *Config) Validate() error {
 	if !validLevels[strings.ToLower(c.Logging.Level)] {
 		return fmt.Errorf("logging.level must be one of: trace, debug, info, warn, error, fatal, panic")
 	}
+	if c.Logging.Format != "json" && c.Logging.Format != "console" {
+		return fmt.Errorf("logging.format must be json or console")
+	}
+	switch c.Logging.Output {
+	case "stdout", "stderr":
+	case "file":
+		if c.Logging.File == "" {
+			return fmt.Errorf("logging.file is required with logging.output: file")
+		}
+	default:
+		return fmt.Errorf("logging.output must be stdout, stderr or file")
+	}
+	if c.Logging.MaxSizeMB < 0 || c.Logging.MaxAge < 0 || c.Logging.MaxBackups < 0 {
+		return fmt.Errorf("logging.max_size_mb, logging.max_age and logging.max_backups must not be negative")
+	}
+	if sampling := c.Logging.Sampling; sampling.Enabled {
+		if sampling.Period <= 0 {
+			return fmt.Errorf("logging.sampling.period must be positive")
+		}
+		for _, ls := range []LogLevelSamplingConfig{sampling.Trace, sampling.Debug, sampling.Info} {
+			if ls.Burst < 0 || ls.Every < 0 {
+				return fmt.Errorf("logging.sampling: burst and every must not be negative")
+			}
+		}
+	}
 
 	return nil
 }
 
+// File returns the path of the configuration file the configuration was
+// read from, or "" if it comes from defaults and environment variables only.
+func (c *Config) File() string {
+	return c.file
+}
+
 // MustLoad loads configuration or panics on error.
 // Useful for main function initialization.
 func MustLoad(configPath string) *Config {
@@ -568,3 +2483,101 @@ func MustLoad(configPath string) *Config {
 	}
 	return cfg
 }
+
+// Secrets returns all configured secret values (passwords and keys).
+// Used to register them with the log scrubber.
+func (c *Config) Secrets() []string {
+	return []string{
+		c.Database.Password,
+		c.Redis.Password,
+		c.Storage.S3.SecretAccessKey,
+		c.Auth.EncryptionKey,
+		c.Auth.SSEMasterKey,
+		c.Encryption.MasterKey,
+		c.Manifest.Token,
+		c.AdminAPI.Token,
+		c.Quarantine.Token,
+		c.Auth.LDAP.BindPassword,
+		c.Auth.OIDC.ClientSecret,
+		c.Cluster.Secret,
+		c.Audit.Webhook.Secret,
+		c.Keys.Vault.Token,
+		c.Keys.KMS.SecretAccessKey,
+	}
+}
+
+// Settings returns the effective configuration as nested maps keyed like the
+// configuration file, with durations in their string form. With
+// redactSecrets, the values of Secrets and anything else the log scrubber
+// recognizes, such as passwords in URLs, are replaced by redact.Placeholder.
+func (c *Config) Settings(redactSecrets bool) map[string]any {
+	value := func(s string) string { return s }
+	if redactSecrets {
+		secrets := make(map[string]bool)
+		for _, secret := range c.Secrets() {
+			if secret != "" {
+				secrets[secret] = true
+			}
+		}
+		scrubber := redact.NewScrubber()
+		scrubber.AddSecrets(c.Secrets()...)
+		value = func(s string) string {
+			if secrets[s] {
+				return redact.Placeholder
+			}
+			return scrubber.String(s)
+		}
+	}
+	return settings(reflect.ValueOf(*c), value).(map[string]any)
+}
+
+// settings converts a configuration value for Settings, passing strings
+// through value.
+func settings(v reflect.Value, value func(string) string) any {
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		return time.Duration(v.Int()).String()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		m := make(map[string]any)
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			name, opts, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+			if !field.IsExported() || name == "-" {
+				continue
+			}
+			if opts == "squash" {
+				for k, fv := range settings(v.Field(i), value).(map[string]any) {
+					m[k] = fv
+				}
+				continue
+			}
+			if name == "" {
+				name = strings.ToLower(field.Name)
+			}
+			m[name] = settings(v.Field(i), value)
+		}
+		return m
+	case reflect.Map:
+		m := make(map[string]any, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			m[fmt.Sprint(iter.Key().Interface())] = settings(iter.Value(), value)
+		}
+		return m
+	case reflect.Slice, reflect.Array:
+		list := make([]any, v.Len())
+		for i := range list {
+			list[i] = settings(v.Index(i), value)
+		}
+		return list
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return settings(v.Elem(), value)
+	case reflect.String:
+		return value(v.String())
+	}
+	return v.Interface()
+}
@@ -49,6 +49,10 @@ type AccessKey struct {
 
 	// LastUsedAt is the timestamp when the key was last used for authentication.
 	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+
+	// Policy optionally restricts the key to a subset of its owner's access.
+	// If nil, the key has full access to its owner's buckets.
+	Policy *AccessKeyPolicy `json:"policy,omitempty"`
 }
 
 // NewAccessKey creates a new AccessKey with default values.
@@ -0,0 +1,192 @@
+// Package domain contains the core business entities for Alexander Storage.
+package domain
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// AuditResult is the outcome of an audited action.
+type AuditResult string
+
+const (
+	// AuditResultSuccess means the action was carried out.
+	AuditResultSuccess AuditResult = "success"
+
+	// AuditResultDenied means authentication or authorization rejected the action.
+	AuditResultDenied AuditResult = "denied"
+
+	// AuditResultFailure means the action was attempted but failed.
+	AuditResultFailure AuditResult = "failure"
+)
+
+// AuditResultForStatus maps an HTTP response status to an audit result.
+func AuditResultForStatus(status int) AuditResult {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return AuditResultDenied
+	case status >= 400:
+		return AuditResultFailure
+	default:
+		return AuditResultSuccess
+	}
+}
+
+// AuditSeverity ranks audit events for routing to sinks.
+type AuditSeverity string
+
+const (
+	// AuditSeverityInfo is a routine successful action.
+	AuditSeverityInfo AuditSeverity = "info"
+
+	// AuditSeverityNotice is a successful security or legal action, such as
+	// creating an access key or placing a legal hold.
+	AuditSeverityNotice AuditSeverity = "notice"
+
+	// AuditSeverityWarning is a denied or failed action.
+	AuditSeverityWarning AuditSeverity = "warning"
+)
+
+// auditSeverityRank orders the severities from least to most severe.
+var auditSeverityRank = map[AuditSeverity]int{
+	AuditSeverityInfo:    1,
+	AuditSeverityNotice:  2,
+	AuditSeverityWarning: 3,
+}
+
+// ParseAuditSeverity parses a severity name.
+func ParseAuditSeverity(value string) (AuditSeverity, error) {
+	severity := AuditSeverity(value)
+	if _, ok := auditSeverityRank[severity]; !ok {
+		return "", fmt.Errorf("invalid audit severity %q: must be info, notice or warning", value)
+	}
+	return severity, nil
+}
+
+// AtLeast reports whether s is as severe as min or more.
+func (s AuditSeverity) AtLeast(min AuditSeverity) bool {
+	return auditSeverityRank[s] >= auditSeverityRank[min]
+}
+
+// AuditCategory groups audit events by the policy they fall under, e.g.
+// for retention.
+type AuditCategory string
+
+const (
+	// AuditCategoryOperational covers data and configuration changes.
+	AuditCategoryOperational AuditCategory = "operational"
+
+	// AuditCategorySecurity covers identities, credentials, access control,
+	// encryption and the audit log itself, and every denied request.
+	AuditCategorySecurity AuditCategory = "security"
+
+	// AuditCategoryLegal covers object lock, legal holds and data residency.
+	AuditCategoryLegal AuditCategory = "legal"
+)
+
+// AuditCategories lists every category.
+var AuditCategories = []AuditCategory{AuditCategoryOperational, AuditCategorySecurity, AuditCategoryLegal}
+
+// ParseAuditCategory parses a category name.
+func ParseAuditCategory(value string) (AuditCategory, error) {
+	for _, category := range AuditCategories {
+		if string(category) == value {
+			return category, nil
+		}
+	}
+	return "", fmt.Errorf("invalid audit category %q: must be operational, security or legal", value)
+}
+
+// securityOperations are the S3 operations changing access control or
+// encryption. Administrative operations are matched by prefix.
+var securityOperations = map[string]bool{
+	"PutBucketAcl":           true,
+	"PutObjectAcl":           true,
+	"PutBucketPolicy":        true,
+	"DeleteBucketPolicy":     true,
+	"PutBucketEncryption":    true,
+	"DeleteBucketEncryption": true,
+}
+
+// legalOperations are the S3 operations on object lock and legal holds.
+var legalOperations = map[string]bool{
+	"PutObjectLockConfiguration": true,
+	"PutObjectLegalHold":         true,
+	"PutObjectRetention":         true,
+}
+
+// ClassifyAudit returns the category and severity of an operation with the
+// given result.
+func ClassifyAudit(operation string, result AuditResult) (AuditCategory, AuditSeverity) {
+	category := AuditCategoryOperational
+	switch {
+	case result == AuditResultDenied,
+		securityOperations[operation],
+		strings.HasPrefix(operation, "user."),
+		strings.HasPrefix(operation, "accesskey."),
+		strings.HasPrefix(operation, "encrypt."),
+		strings.HasPrefix(operation, "backup."),
+		strings.HasPrefix(operation, "audit."):
+		category = AuditCategorySecurity
+	case legalOperations[operation],
+		operation == "bucket.set-residency":
+		category = AuditCategoryLegal
+	}
+
+	switch {
+	case result != AuditResultSuccess:
+		return category, AuditSeverityWarning
+	case category != AuditCategoryOperational:
+		return category, AuditSeverityNotice
+	default:
+		return category, AuditSeverityInfo
+	}
+}
+
+// AuditEvent is an entry in the append-only audit log. One is recorded for
+// every write through the S3 API and every administrative action, whether it
+// succeeded or not.
+type AuditEvent struct {
+	// ID increases with every event.
+	ID int64 `json:"id"`
+
+	// Time is when the action completed.
+	Time time.Time `json:"time"`
+
+	// UserID is the acting user, 0 for anonymous requests and the admin CLI.
+	UserID int64 `json:"user_id,omitempty"`
+
+	// Actor names who acted: a username, "anonymous", or "cli:<os user>"
+	// for the admin CLI.
+	Actor string `json:"actor"`
+
+	// AccessKeyID is the access key that signed the request, if any.
+	AccessKeyID string `json:"access_key_id,omitempty"`
+
+	// Operation names the action, e.g. PutObject, DeleteBucket or user.set-role.
+	Operation string `json:"operation"`
+
+	// BucketName and ObjectKey are the affected resources, if any.
+	BucketName string `json:"bucket,omitempty"`
+	ObjectKey  string `json:"key,omitempty"`
+
+	// SourceIP is the client address; empty for the admin CLI.
+	SourceIP string `json:"source_ip,omitempty"`
+
+	// RequestID correlates the event with request logs and traces.
+	RequestID string `json:"request_id,omitempty"`
+
+	// Result is the outcome, and StatusCode the HTTP status for API requests.
+	Result     AuditResult `json:"result"`
+	StatusCode int         `json:"status_code,omitempty"`
+
+	// Category and Severity are derived from the operation and result by
+	// ClassifyAudit when the event is recorded.
+	Category AuditCategory `json:"category"`
+	Severity AuditSeverity `json:"severity"`
+
+	// Detail holds operation specific context, e.g. the role that was assigned.
+	Detail string `json:"detail,omitempty"`
+}
@@ -0,0 +1,31 @@
+// Package domain contains the core business entities for Alexander Storage.
+package domain
+
+import "time"
+
+// BackfillProgress is the persisted state of a batched data migration, so
+// it resumes where it stopped after a restart.
+type BackfillProgress struct {
+	// Name identifies the backfill.
+	Name string `json:"name"`
+
+	// Cursor is the last row ID processed; the next batch starts after it.
+	Cursor int64 `json:"cursor"`
+
+	// RowsDone is the number of rows processed so far.
+	RowsDone int64 `json:"rows_done"`
+
+	// StartedAt is when the first batch ran.
+	StartedAt time.Time `json:"started_at"`
+
+	// UpdatedAt is when the last batch committed.
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// CompletedAt is set once every batch and the finalize step are done.
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// IsCompleted returns true if the backfill has finished.
+func (p *BackfillProgress) IsCompleted() bool {
+	return p.CompletedAt != nil
+}
@@ -0,0 +1,42 @@
+// Package domain contains the core business entities for Alexander Storage.
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Batch groups staged object writes, possibly to several buckets, that
+// become visible together when the batch is committed. Writes of an
+// aborted or expired batch are discarded.
+type Batch struct {
+	// ID is the batch ID clients send with each write (x-alexander-batch-id).
+	ID uuid.UUID `json:"id"`
+
+	// OwnerID is the user who opened the batch; only they may write to,
+	// commit or abort it.
+	OwnerID int64 `json:"owner_id"`
+
+	// CreatedAt is when the batch was opened.
+	CreatedAt time.Time `json:"created_at"`
+
+	// ExpiresAt is when an uncommitted batch is aborted.
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// NewBatch opens a batch for ownerID that expires after ttl.
+func NewBatch(ownerID int64, ttl time.Duration) *Batch {
+	now := time.Now().UTC()
+	return &Batch{
+		ID:        uuid.New(),
+		OwnerID:   ownerID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+}
+
+// IsExpired reports whether the batch can no longer be written or committed.
+func (b *Batch) IsExpired() bool {
+	return !time.Now().Before(b.ExpiresAt)
+}
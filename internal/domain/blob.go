@@ -36,6 +36,23 @@ const (
 	EncryptionSchemeChaCha EncryptionScheme = "chacha20-poly1305-stream"
 )
 
+// BlobGCState tracks the progress of garbage collecting an orphan blob.
+// Deletion runs pending -> storage_deleted -> (row deleted), so an
+// interrupted run can resume at the step that failed.
+type BlobGCState string
+
+const (
+	// BlobGCStateNone means the blob is not being collected.
+	BlobGCStateNone BlobGCState = ""
+
+	// BlobGCStatePending means GC has claimed the blob and is deleting its content.
+	BlobGCStatePending BlobGCState = "pending"
+
+	// BlobGCStateStorageDeleted means the content is gone from storage and only
+	// the metadata row remains to be deleted.
+	BlobGCStateStorageDeleted BlobGCState = "storage_deleted"
+)
+
 // PartReference represents a reference to a part blob in composite blobs.
 type PartReference struct {
 	// PartIndex is the 0-based index of this part in the composite.
@@ -101,6 +118,10 @@ type Blob struct {
 	// Nil for unencrypted blobs.
 	EncryptionIV *string `json:"encryption_iv,omitempty"`
 
+	// EncryptionKeyVersion is the version of the SSE master key that
+	// encrypted the blob.
+	EncryptionKeyVersion int `json:"encryption_key_version,omitempty"`
+
 	// PartReferences holds references to parts for composite blobs.
 	// Only populated when BlobType is "composite".
 	PartReferences []PartReference `json:"part_references,omitempty"`
@@ -113,6 +134,23 @@ type Blob struct {
 	// Only populated when BlobType is "delta".
 	DeltaInstructions []DeltaInstruction `json:"delta_instructions,omitempty"`
 
+	// GCState is the garbage collection progress of an orphan blob.
+	GCState BlobGCState `json:"gc_state,omitempty"`
+
+	// GCAttempts is the number of times GC has tried to delete this blob.
+	GCAttempts int `json:"gc_attempts,omitempty"`
+
+	// GCLastError is the last error GC hit while deleting this blob.
+	GCLastError *string `json:"gc_last_error,omitempty"`
+
+	// LastVerifiedAt is when the scrubber last re-hashed the content, nil
+	// if never.
+	LastVerifiedAt *time.Time `json:"last_verified_at,omitempty"`
+
+	// CorruptedAt is when the scrubber found the content missing or not
+	// matching its hash, nil while the content is intact.
+	CorruptedAt *time.Time `json:"corrupted_at,omitempty"`
+
 	// CreatedAt is the timestamp when the blob was first stored.
 	CreatedAt time.Time `json:"created_at"`
 
@@ -202,6 +240,42 @@ func ComputeStoragePath(basePath, contentHash string) string {
 	return filepath.Join(basePath, level1, level2, contentHash)
 }
 
+// BlobStats summarizes the stored blobs.
+type BlobStats struct {
+	// Blobs is the number of blobs.
+	Blobs int64
+
+	// Orphans is the number of blobs no object references.
+	Orphans int64
+
+	// StoredBytes is the total size of all blobs.
+	StoredBytes int64
+
+	// ReferencedBytes is the size of each blob times its reference count,
+	// i.e. the bytes stored without deduplication.
+	ReferencedBytes int64
+}
+
+// BlobRefCount compares the reference count stored on a blob with the
+// references held on it by object versions, upload parts, staged objects
+// and bucket snapshots.
+type BlobRefCount struct {
+	ContentHash string
+	Size        int64
+	GCState     BlobGCState
+
+	// Stored is the blob's ref_count.
+	Stored int32
+
+	// Actual is the number of references counted.
+	Actual int32
+}
+
+// Drifted returns true if the stored reference count is wrong.
+func (c *BlobRefCount) Drifted() bool {
+	return c.Stored != c.Actual
+}
+
 // IsOrphan returns true if no objects reference this blob.
 func (b *Blob) IsOrphan() bool {
 	return b.RefCount <= 0
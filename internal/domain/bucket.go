@@ -66,6 +66,18 @@ func (a BucketACL) AllowsAnonymousWrite() bool {
 // Must start and end with letter or number.
 var bucketNameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`)
 
+// residencyRegex validates residency tags: 1-32 lowercase letters, numbers and hyphens.
+var residencyRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
+// ValidateResidency checks if a residency tag is well-formed. Empty is valid
+// and means the default storage backend.
+func ValidateResidency(residency string) error {
+	if residency != "" && !residencyRegex.MatchString(residency) {
+		return ErrInvalidResidency
+	}
+	return nil
+}
+
 // Bucket represents an S3-compatible storage bucket.
 // Buckets are containers for objects and define policies like versioning.
 type Bucket struct {
@@ -75,6 +87,9 @@ type Bucket struct {
 	// OwnerID is the ID of the user who owns this bucket.
 	OwnerID int64 `json:"owner_id"`
 
+	// TenantID is the tenant the bucket belongs to, that of its owner.
+	TenantID int64 `json:"tenant_id"`
+
 	// Name is the globally unique bucket name.
 	// Constraints: 3-63 characters, lowercase, alphanumeric with hyphens/periods.
 	Name string `json:"name"`
@@ -96,12 +111,38 @@ type Bucket struct {
 
 	// CreatedAt is the timestamp when the bucket was created.
 	CreatedAt time.Time `json:"created_at"`
+
+	// Residency is the data residency tag of the bucket. Blobs of a tagged
+	// bucket may only be written to the storage backend configured for that
+	// tag. Empty means the default backend.
+	Residency string `json:"residency,omitempty"`
+
+	// Quarantine holds uploads as staged until an approver promotes them.
+	// Staged uploads are not visible to readers and expire if not promoted.
+	Quarantine bool `json:"quarantine,omitempty"`
+
+	// ObjectDefaults are the settings of uploads that do not specify them,
+	// nil if the bucket has none.
+	ObjectDefaults *ObjectDefaults `json:"object_defaults,omitempty"`
+
+	// MFADelete requires a code from the owner's MFA device to permanently
+	// delete versions or to change the versioning state.
+	MFADelete bool `json:"mfa_delete,omitempty"`
+
+	// ReadOnly rejects writes to the bucket while reads are still served,
+	// e.g. while its data is being migrated.
+	ReadOnly bool `json:"read_only,omitempty"`
+
+	// QuotaBytes is the most data the bucket may hold, counting every
+	// version of its objects. Zero means no quota.
+	QuotaBytes int64 `json:"quota_bytes,omitempty"`
 }
 
 // NewBucket creates a new Bucket with default values.
 func NewBucket(ownerID int64, name string) *Bucket {
 	return &Bucket{
 		OwnerID:    ownerID,
+		TenantID:   DefaultTenantID,
 		Name:       name,
 		Region:     "us-east-1",
 		Versioning: VersioningDisabled,
@@ -144,3 +185,32 @@ func isIPAddress(s string) bool {
 	ipRegex := regexp.MustCompile(`^\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3}$`)
 	return ipRegex.MatchString(s)
 }
+
+// BucketStats summarizes the contents of a bucket.
+type BucketStats struct {
+	// Objects is the number of current objects, not counting delete markers.
+	Objects int64 `json:"objects"`
+
+	// LogicalBytes is the total size of the current objects.
+	LogicalBytes int64 `json:"logical_bytes"`
+
+	// PhysicalBytes is the total size of the distinct blobs referenced by
+	// any version in the bucket, i.e. the bytes stored after deduplication.
+	PhysicalBytes int64 `json:"physical_bytes"`
+
+	// Versions is the number of object versions, current and noncurrent,
+	// not counting delete markers.
+	Versions int64 `json:"versions"`
+
+	// MultipartUploads is the number of multipart uploads in progress.
+	MultipartUploads int64 `json:"multipart_uploads"`
+
+	// LargestObjects lists the largest current objects, largest first.
+	LargestObjects []ObjectSize `json:"largest_objects"`
+}
+
+// ObjectSize is the key and size of an object.
+type ObjectSize struct {
+	Key  string `json:"key"`
+	Size int64  `json:"size"`
+}
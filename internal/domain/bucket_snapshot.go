@@ -0,0 +1,68 @@
+// Package domain contains the core business entities for Alexander Storage.
+package domain
+
+import (
+	"regexp"
+	"time"
+)
+
+// snapshotNameRegex matches valid bucket snapshot names.
+var snapshotNameRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,127}$`)
+
+// BucketSnapshot is a read-only, point-in-time view of a bucket: the latest
+// version of every key when the snapshot was taken. Only object metadata is
+// copied; the snapshot holds a reference on each blob it names, so its
+// content survives later overwrites and deletes in the bucket.
+type BucketSnapshot struct {
+	// ID is the unique identifier for the snapshot.
+	ID int64 `json:"id"`
+
+	// BucketID is the bucket the snapshot was taken of.
+	BucketID int64 `json:"bucket_id"`
+
+	// Name identifies the snapshot within its bucket.
+	Name string `json:"name"`
+
+	// ObjectCount is the number of objects in the snapshot.
+	ObjectCount int64 `json:"object_count"`
+
+	// TotalSize is the combined size of the objects in the snapshot.
+	TotalSize int64 `json:"total_size"`
+
+	// CreatedAt is the point in time the snapshot shows.
+	CreatedAt time.Time `json:"created_at"`
+
+	// ExpiresAt is when the snapshot is deleted automatically.
+	// Nil keeps the snapshot until it is deleted explicitly.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// NewBucketSnapshot creates a snapshot of bucketID that expires after
+// retain, or never if retain is zero.
+func NewBucketSnapshot(bucketID int64, name string, retain time.Duration) *BucketSnapshot {
+	now := time.Now().UTC()
+	snapshot := &BucketSnapshot{
+		BucketID:  bucketID,
+		Name:      name,
+		CreatedAt: now,
+	}
+	if retain > 0 {
+		expiresAt := now.Add(retain)
+		snapshot.ExpiresAt = &expiresAt
+	}
+	return snapshot
+}
+
+// IsExpired reports whether the snapshot is past its retention.
+func (s *BucketSnapshot) IsExpired() bool {
+	return s.ExpiresAt != nil && !time.Now().Before(*s.ExpiresAt)
+}
+
+// ValidateSnapshotName validates a bucket snapshot name: 1 to 128 letters,
+// digits, dots, hyphens and underscores, starting with a letter or digit.
+func ValidateSnapshotName(name string) error {
+	if !snapshotNameRegex.MatchString(name) {
+		return ErrInvalidSnapshotName
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+// Package domain contains the core business entities for Alexander Storage.
+package domain
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"strings"
+)
+
+// ChecksumAlgorithm is an S3 additional checksum algorithm. Clients send
+// and receive checksums in x-amz-checksum-* headers, base64-encoded.
+type ChecksumAlgorithm string
+
+const (
+	// ChecksumCRC32 is the IEEE CRC-32 checksum.
+	ChecksumCRC32 ChecksumAlgorithm = "CRC32"
+
+	// ChecksumCRC32C is the Castagnoli CRC-32 checksum.
+	ChecksumCRC32C ChecksumAlgorithm = "CRC32C"
+
+	// ChecksumSHA1 is the SHA-1 digest.
+	ChecksumSHA1 ChecksumAlgorithm = "SHA1"
+
+	// ChecksumSHA256 is the SHA-256 digest.
+	ChecksumSHA256 ChecksumAlgorithm = "SHA256"
+)
+
+// ChecksumAlgorithms lists the supported checksum algorithms.
+var ChecksumAlgorithms = []ChecksumAlgorithm{ChecksumCRC32, ChecksumCRC32C, ChecksumSHA1, ChecksumSHA256}
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// ParseChecksumAlgorithm parses a checksum algorithm name, ignoring case.
+func ParseChecksumAlgorithm(name string) (ChecksumAlgorithm, error) {
+	algorithm := ChecksumAlgorithm(strings.ToUpper(name))
+	for _, supported := range ChecksumAlgorithms {
+		if algorithm == supported {
+			return algorithm, nil
+		}
+	}
+	return "", fmt.Errorf("%w: unsupported checksum algorithm %q", ErrInvalidChecksum, name)
+}
+
+// NewHash returns a hash computing the checksum.
+func (a ChecksumAlgorithm) NewHash() hash.Hash {
+	switch a {
+	case ChecksumCRC32:
+		return crc32.NewIEEE()
+	case ChecksumCRC32C:
+		return crc32.New(crc32cTable)
+	case ChecksumSHA1:
+		return sha1.New()
+	case ChecksumSHA256:
+		return sha256.New()
+	default:
+		return nil
+	}
+}
+
+// Validate checks that a checksum sent by a client is the base64 encoding
+// of a checksum of this algorithm.
+func (a ChecksumAlgorithm) Validate(checksum string) error {
+	decoded, err := base64.StdEncoding.DecodeString(checksum)
+	if err != nil || len(decoded) != a.NewHash().Size() {
+		return fmt.Errorf("%w: value for x-amz-checksum-%s is not a valid %s checksum",
+			ErrInvalidChecksum, strings.ToLower(string(a)), a)
+	}
+	return nil
+}
+
+// CompositeChecksum returns the checksum of a multipart object: the
+// checksum of its parts' concatenated checksums, followed by "-" and the
+// number of parts, as S3 computes it.
+func CompositeChecksum(algorithm ChecksumAlgorithm, partChecksums []string) (string, error) {
+	h := algorithm.NewHash()
+	for _, checksum := range partChecksums {
+		decoded, err := base64.StdEncoding.DecodeString(checksum)
+		if err != nil {
+			return "", fmt.Errorf("%w: invalid part checksum %q", ErrInvalidChecksum, checksum)
+		}
+		h.Write(decoded)
+	}
+	return fmt.Sprintf("%s-%d", base64.StdEncoding.EncodeToString(h.Sum(nil)), len(partChecksums)), nil
+}
+
+// IsCompositeChecksum reports whether a stored checksum is the composite
+// checksum of a multipart object rather than of its full content.
+func IsCompositeChecksum(checksum string) bool {
+	return strings.Contains(checksum, "-")
+}
@@ -0,0 +1,21 @@
+// Package domain contains the core business entities for Alexander Storage.
+package domain
+
+import "time"
+
+// ClusterNode is a member of a multi-node cluster. Nodes announce
+// themselves by updating LastHeartbeat; a node whose heartbeat is older
+// than the heartbeat timeout is no longer a member.
+type ClusterNode struct {
+	// ID is the configured node ID, unique within the cluster.
+	ID string `json:"id"`
+
+	// Address is the host:port other nodes use for the internal blob API.
+	Address string `json:"address"`
+
+	// StartedAt is when the node process started.
+	StartedAt time.Time `json:"started_at"`
+
+	// LastHeartbeat is when the node last announced itself.
+	LastHeartbeat time.Time `json:"last_heartbeat"`
+}
@@ -26,6 +26,9 @@ var (
 	// ErrInvalidCredentials indicates authentication failed.
 	ErrInvalidCredentials = errors.New("invalid credentials")
 
+	// ErrInvalidUserRole indicates an unknown user role name.
+	ErrInvalidUserRole = errors.New("invalid user role")
+
 	// ===========================================
 	// Access Key Errors
 	// ===========================================
@@ -45,6 +48,9 @@ var (
 	// ErrInvalidSecretKey indicates the secret key format is invalid.
 	ErrInvalidSecretKey = errors.New("invalid secret key")
 
+	// ErrInvalidPolicy indicates an access key policy document is malformed or unsupported.
+	ErrInvalidPolicy = errors.New("invalid access key policy")
+
 	// ===========================================
 	// Bucket Errors
 	// ===========================================
@@ -67,6 +73,24 @@ var (
 	// ErrBucketNameIPFormat indicates the bucket name looks like an IP address.
 	ErrBucketNameIPFormat = errors.New("bucket name cannot be formatted as an IP address")
 
+	// ErrInvalidResidency indicates a bucket residency tag is malformed.
+	ErrInvalidResidency = errors.New("residency tag must be 1-32 lowercase letters, numbers, and hyphens")
+
+	// ErrInvalidLocationConstraint indicates a bucket region is not one the server allows.
+	ErrInvalidLocationConstraint = errors.New("the specified location constraint is not valid")
+
+	// ErrNoSuchTagSet indicates the bucket has no tags.
+	ErrNoSuchTagSet = errors.New("the bucket has no tag set")
+
+	// ErrInvalidTag indicates a tag set exceeds the limits or has a malformed tag.
+	ErrInvalidTag = errors.New("invalid tag")
+
+	// ErrInvalidObjectDefaults indicates a bucket's object defaults are malformed.
+	ErrInvalidObjectDefaults = errors.New("invalid object defaults")
+
+	// ErrInvalidStorageClass indicates an unknown storage class.
+	ErrInvalidStorageClass = errors.New("the storage class you specified is not valid")
+
 	// ===========================================
 	// Object Errors
 	// ===========================================
@@ -89,6 +113,29 @@ var (
 	// ErrInvalidVersionID indicates the version ID format is invalid.
 	ErrInvalidVersionID = errors.New("invalid version ID format")
 
+	// ErrObjectNotDeleted indicates a restore without a version found a key
+	// whose latest version is not a delete marker.
+	ErrObjectNotDeleted = errors.New("latest version is not a delete marker")
+
+	// ErrSequenceMismatch indicates a conditional write expected a different
+	// current sequence for the key (x-alexander-if-sequence).
+	ErrSequenceMismatch = errors.New("object sequence does not match")
+
+	// ErrPartNumberNotSatisfiable indicates a GET/HEAD partNumber exceeds the object's part count.
+	ErrPartNumberNotSatisfiable = errors.New("requested part number is not satisfiable")
+
+	// ErrInvalidChecksum indicates an unsupported checksum algorithm, a
+	// malformed checksum or conflicting checksum headers.
+	ErrInvalidChecksum = errors.New("invalid checksum")
+
+	// ErrChecksumMismatch indicates the content does not match the checksum
+	// the client sent with it.
+	ErrChecksumMismatch = errors.New("checksum does not match the content")
+
+	// ErrInvalidRename indicates a rename the server cannot perform as a
+	// metadata-only move, such as a rename onto the source key itself.
+	ErrInvalidRename = errors.New("invalid rename")
+
 	// ===========================================
 	// Blob/Storage Errors
 	// ===========================================
@@ -121,11 +168,16 @@ var (
 	// ErrInvalidPartNumber indicates the part number is outside valid range (1-10000).
 	ErrInvalidPartNumber = errors.New("part number must be between 1 and 10000")
 
-	// ErrPartTooSmall indicates the part size is below minimum (5MB, except last part).
-	ErrPartTooSmall = errors.New("part size is below minimum (5MB)")
+	// ErrPartTooSmall indicates a part other than the last is below the
+	// minimum part size.
+	ErrPartTooSmall = errors.New("part is smaller than the minimum part size")
+
+	// ErrPartTooLarge indicates the part size exceeds the maximum part size.
+	ErrPartTooLarge = errors.New("part is larger than the maximum part size")
 
-	// ErrPartTooLarge indicates the part size exceeds maximum (5GB).
-	ErrPartTooLarge = errors.New("part size exceeds maximum (5GB)")
+	// ErrTooManyParts indicates a completion request lists more parts than
+	// an upload may have.
+	ErrTooManyParts = errors.New("upload has too many parts")
 
 	// ErrPartNotFound indicates the specified part does not exist.
 	ErrPartNotFound = errors.New("part not found")
@@ -133,12 +185,20 @@ var (
 	// ErrPartETagMismatch indicates the part ETag does not match.
 	ErrPartETagMismatch = errors.New("part ETag mismatch")
 
+	// ErrPartChecksumMismatch indicates the part checksum in a completion
+	// request does not match the uploaded part.
+	ErrPartChecksumMismatch = errors.New("part checksum mismatch")
+
 	// ErrInvalidPartOrder indicates parts are not in ascending order.
 	ErrInvalidPartOrder = errors.New("parts must be in ascending order")
 
 	// ErrNoPartsProvided indicates no parts were provided for completion.
 	ErrNoPartsProvided = errors.New("no parts provided for completion")
 
+	// ErrInvalidChunkHash indicates a resumable upload chunk is not named by
+	// a hex SHA-256 hash.
+	ErrInvalidChunkHash = errors.New("chunk hash must be 64 lowercase hex characters")
+
 	// ===========================================
 	// Authentication/Authorization Errors
 	// ===========================================
@@ -171,6 +231,153 @@ var (
 
 	// ErrInvalidLifecycleRule indicates the lifecycle rule is invalid.
 	ErrInvalidLifecycleRule = errors.New("invalid lifecycle rule")
+
+	// ErrLifecycleConfigNotFound indicates the bucket has no lifecycle rules.
+	ErrLifecycleConfigNotFound = errors.New("lifecycle configuration not found")
+
+	// ===========================================
+	// Replication Errors
+	// ===========================================
+
+	// ErrReplicationConfigNotFound indicates the bucket has no replication configuration.
+	ErrReplicationConfigNotFound = errors.New("replication configuration not found")
+
+	// ErrInvalidReplicationConfig indicates the replication configuration is invalid.
+	ErrInvalidReplicationConfig = errors.New("invalid replication configuration")
+
+	// ===========================================
+	// Inventory Errors
+	// ===========================================
+
+	// ErrInventoryConfigNotFound indicates the bucket has no inventory configuration with that ID.
+	ErrInventoryConfigNotFound = errors.New("inventory configuration not found")
+
+	// ErrInvalidInventoryConfig indicates the inventory configuration is invalid.
+	ErrInvalidInventoryConfig = errors.New("invalid inventory configuration")
+
+	// ErrTooManyInventoryConfigs indicates the bucket already has the maximum number of inventory configurations.
+	ErrTooManyInventoryConfigs = errors.New("too many inventory configurations")
+
+	// ===========================================
+	// Website Errors
+	// ===========================================
+
+	// ErrWebsiteConfigNotFound indicates the bucket has no website configuration.
+	ErrWebsiteConfigNotFound = errors.New("website configuration not found")
+
+	// ErrInvalidWebsiteConfig indicates the website configuration is invalid.
+	ErrInvalidWebsiteConfig = errors.New("invalid website configuration")
+
+	// ===========================================
+	// Quarantine Errors
+	// ===========================================
+
+	// ErrStagedObjectNotFound indicates the staged upload does not exist,
+	// or was already promoted, rejected or expired.
+	ErrStagedObjectNotFound = errors.New("staged upload not found")
+
+	// ===========================================
+	// Batch Errors
+	// ===========================================
+
+	// ErrBatchNotFound indicates the batch does not exist, belongs to
+	// another user, or was already committed, aborted or expired.
+	ErrBatchNotFound = errors.New("batch not found")
+
+	// ErrBatchTooLarge indicates the batch already holds the maximum number of writes.
+	ErrBatchTooLarge = errors.New("batch holds too many writes")
+
+	// ErrBatchQuarantineBucket indicates a batch write to a quarantine bucket,
+	// whose uploads must be promoted individually.
+	ErrBatchQuarantineBucket = errors.New("batch writes to quarantine buckets are not allowed")
+
+	// ===========================================
+	// Bucket Snapshot Errors
+	// ===========================================
+
+	// ErrSnapshotNotFound indicates the bucket has no snapshot of that name.
+	ErrSnapshotNotFound = errors.New("snapshot not found")
+
+	// ErrSnapshotAlreadyExists indicates the bucket already has a snapshot of that name.
+	ErrSnapshotAlreadyExists = errors.New("snapshot already exists")
+
+	// ErrInvalidSnapshotName indicates the snapshot name is invalid.
+	ErrInvalidSnapshotName = errors.New("invalid snapshot name")
+
+	// ===========================================
+	// MFA Errors
+	// ===========================================
+
+	// ErrMFADeviceNotFound indicates the user has no MFA device.
+	ErrMFADeviceNotFound = errors.New("MFA device not found")
+
+	// ErrMFADeviceExists indicates the user already has an MFA device.
+	ErrMFADeviceExists = errors.New("MFA device already exists")
+
+	// ErrMFARequired indicates the request needs an x-amz-mfa header.
+	ErrMFARequired = errors.New("MFA authentication is required")
+
+	// ErrInvalidMFA indicates the x-amz-mfa header is malformed, names
+	// another device or carries a wrong code.
+	ErrInvalidMFA = errors.New("invalid MFA authentication")
+
+	// ===========================================
+	// Read-Only Errors
+	// ===========================================
+
+	// ErrBucketReadOnly indicates a write to a bucket in read-only mode.
+	ErrBucketReadOnly = errors.New("bucket is read-only")
+
+	// ErrServiceReadOnly indicates a write while the whole service is in
+	// read-only mode.
+	ErrServiceReadOnly = errors.New("service is read-only")
+
+	// ===========================================
+	// Bucket Quota Errors
+	// ===========================================
+
+	// ErrInvalidBucketQuota indicates a negative bucket quota.
+	ErrInvalidBucketQuota = errors.New("bucket quota must not be negative")
+
+	// ErrBucketQuotaExceeded indicates a write would take the bucket over
+	// its storage quota.
+	ErrBucketQuotaExceeded = errors.New("bucket storage quota exceeded")
+
+	// ===========================================
+	// Tenant Errors
+	// ===========================================
+
+	// ErrTenantNotFound indicates the tenant does not exist.
+	ErrTenantNotFound = errors.New("tenant not found")
+
+	// ErrTenantAlreadyExists indicates a tenant with that name exists.
+	ErrTenantAlreadyExists = errors.New("tenant already exists")
+
+	// ErrInvalidTenantName indicates a malformed tenant name.
+	ErrInvalidTenantName = errors.New("tenant name must be 3-32 lowercase letters, numbers and hyphens, starting with a letter")
+
+	// ErrInvalidTenantLimits indicates negative limits, or a request rate
+	// without a burst size.
+	ErrInvalidTenantLimits = errors.New("invalid tenant limits")
+
+	// ErrTenantNotEmpty indicates a tenant that still has users or buckets
+	// cannot be deleted.
+	ErrTenantNotEmpty = errors.New("tenant still has users or buckets")
+
+	// ErrDefaultTenant indicates the default tenant cannot be deleted.
+	ErrDefaultTenant = errors.New("the default tenant cannot be deleted")
+
+	// ErrTenantBucketQuotaExceeded indicates the tenant has reached its
+	// maximum number of buckets.
+	ErrTenantBucketQuotaExceeded = errors.New("tenant bucket quota exceeded")
+
+	// ErrTenantStorageQuotaExceeded indicates a write would take the tenant
+	// over its storage quota.
+	ErrTenantStorageQuotaExceeded = errors.New("tenant storage quota exceeded")
+
+	// ErrBucketNameNotInNamespace indicates a bucket name that does not
+	// start with the name of the owner's tenant.
+	ErrBucketNameNotInNamespace = errors.New("bucket name must start with the tenant name and a hyphen")
 )
 
 // DomainError wraps a domain error with additional context.
@@ -0,0 +1,213 @@
+// Package domain contains the core business entities for Alexander Storage.
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// inventoryIDRegex matches valid inventory configuration IDs.
+var inventoryIDRegex = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// InventoryFormat is the file format of an inventory report.
+type InventoryFormat string
+
+const (
+	// InventoryFormatCSV writes gzip-compressed CSV files without a header row.
+	InventoryFormatCSV InventoryFormat = "CSV"
+
+	// InventoryFormatParquet writes Apache Parquet files.
+	InventoryFormatParquet InventoryFormat = "Parquet"
+)
+
+// InventoryFrequency is how often an inventory report is generated.
+type InventoryFrequency string
+
+const (
+	// InventoryFrequencyDaily generates a report every day.
+	InventoryFrequencyDaily InventoryFrequency = "Daily"
+
+	// InventoryFrequencyWeekly generates a report every week.
+	InventoryFrequencyWeekly InventoryFrequency = "Weekly"
+)
+
+// Interval returns the time between reports.
+func (f InventoryFrequency) Interval() time.Duration {
+	if f == InventoryFrequencyWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// InventoryField is an optional column of an inventory report. Bucket, key,
+// and for all-versions reports the version ID, latest and delete marker
+// flags, are always included.
+type InventoryField string
+
+const (
+	// InventoryFieldSize is the object size in bytes.
+	InventoryFieldSize InventoryField = "Size"
+
+	// InventoryFieldLastModifiedDate is when the version was created.
+	InventoryFieldLastModifiedDate InventoryField = "LastModifiedDate"
+
+	// InventoryFieldETag is the entity tag.
+	InventoryFieldETag InventoryField = "ETag"
+
+	// InventoryFieldStorageClass is the storage class.
+	InventoryFieldStorageClass InventoryField = "StorageClass"
+
+	// InventoryFieldEncryptionStatus is SSE-S3 or NOT-SSE.
+	InventoryFieldEncryptionStatus InventoryField = "EncryptionStatus"
+
+	// InventoryFieldReplicationStatus is the replication status.
+	InventoryFieldReplicationStatus InventoryField = "ReplicationStatus"
+)
+
+// InventoryFields lists the optional fields in report column order.
+var InventoryFields = []InventoryField{
+	InventoryFieldSize,
+	InventoryFieldLastModifiedDate,
+	InventoryFieldETag,
+	InventoryFieldStorageClass,
+	InventoryFieldEncryptionStatus,
+	InventoryFieldReplicationStatus,
+}
+
+// InventoryConfig is one of a bucket's inventory configurations. Each
+// produces a scheduled listing of the bucket's objects, written as data
+// files and a manifest to a destination bucket.
+type InventoryConfig struct {
+	// BucketID is the ID of the bucket listed.
+	BucketID int64 `json:"bucket_id"`
+
+	// ID identifies the configuration within its bucket.
+	ID string `json:"id"`
+
+	// Enabled is false for configurations that are kept but not run.
+	Enabled bool `json:"enabled"`
+
+	// DestinationBucket is the name of the bucket reports are written to.
+	// It must belong to the owner of the source bucket.
+	DestinationBucket string `json:"destination_bucket"`
+
+	// DestinationPrefix is prepended to the keys of report files.
+	DestinationPrefix string `json:"destination_prefix,omitempty"`
+
+	// Format is the file format of the report.
+	Format InventoryFormat `json:"format"`
+
+	// Frequency is how often the report is generated.
+	Frequency InventoryFrequency `json:"frequency"`
+
+	// Prefix limits the report to keys with this prefix.
+	Prefix string `json:"prefix,omitempty"`
+
+	// AllVersions lists every version and delete marker instead of only
+	// the current version of each key.
+	AllVersions bool `json:"all_versions"`
+
+	// Fields are the optional fields included in the report.
+	Fields []InventoryField `json:"fields,omitempty"`
+
+	// LastRunAt is when the last report was generated, nil if none has been.
+	LastRunAt *time.Time `json:"last_run_at,omitempty"`
+
+	// CreatedAt is when the configuration was first stored.
+	CreatedAt time.Time `json:"created_at"`
+
+	// UpdatedAt is when the configuration last changed.
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// MaxInventoryConfigs is the maximum number of inventory configurations per bucket.
+const MaxInventoryConfigs = 1000
+
+// Validate checks the configuration.
+func (c *InventoryConfig) Validate() error {
+	if !inventoryIDRegex.MatchString(c.ID) {
+		return fmt.Errorf("%w: ID must be 1 to 64 letters, digits, dots, hyphens or underscores", ErrInvalidInventoryConfig)
+	}
+	if err := ValidateBucketName(c.DestinationBucket); err != nil {
+		return fmt.Errorf("%w: destination bucket: %v", ErrInvalidInventoryConfig, err)
+	}
+	if len(c.DestinationPrefix) > 512 || len(c.Prefix) > MaxObjectKeyLength {
+		return fmt.Errorf("%w: prefix is too long", ErrInvalidInventoryConfig)
+	}
+	if c.Format != InventoryFormatCSV && c.Format != InventoryFormatParquet {
+		return fmt.Errorf("%w: format must be CSV or Parquet", ErrInvalidInventoryConfig)
+	}
+	if c.Frequency != InventoryFrequencyDaily && c.Frequency != InventoryFrequencyWeekly {
+		return fmt.Errorf("%w: frequency must be Daily or Weekly", ErrInvalidInventoryConfig)
+	}
+
+	seen := make(map[InventoryField]bool, len(c.Fields))
+	for _, field := range c.Fields {
+		if !field.valid() {
+			return fmt.Errorf("%w: unknown field %q", ErrInvalidInventoryConfig, field)
+		}
+		if seen[field] {
+			return fmt.Errorf("%w: duplicate field %q", ErrInvalidInventoryConfig, field)
+		}
+		seen[field] = true
+	}
+	return nil
+}
+
+func (f InventoryField) valid() bool {
+	for _, field := range InventoryFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// HasField reports whether the report includes field.
+func (c *InventoryConfig) HasField(field InventoryField) bool {
+	for _, f := range c.Fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// IsDue reports whether a report should be generated at now.
+func (c *InventoryConfig) IsDue(now time.Time) bool {
+	return c.Enabled && (c.LastRunAt == nil || !now.Before(c.LastRunAt.Add(c.Frequency.Interval())))
+}
+
+// ReportPrefix returns the key prefix under which the configuration's
+// report files are written: {prefix}/{source bucket}/{ID}/.
+func (c *InventoryConfig) ReportPrefix(sourceBucket string) string {
+	prefix := strings.TrimSuffix(c.DestinationPrefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	return prefix + sourceBucket + "/" + c.ID + "/"
+}
+
+// InventoryEntry is an object version listed in an inventory report.
+type InventoryEntry struct {
+	// ID is the object row ID, used to resume the listing.
+	ID int64
+
+	Key               string
+	VersionID         string
+	IsLatest          bool
+	IsDeleteMarker    bool
+	Size              int64
+	ETag              string
+	StorageClass      StorageClass
+	ReplicationStatus ReplicationStatus
+	LastModified      time.Time
+
+	// Encrypted is true if the content is stored with SSE-S3 encryption.
+	Encrypted bool
+
+	// Sequence is the key's write counter, used to order versions.
+	Sequence int64
+}
@@ -2,6 +2,8 @@
 package domain
 
 import (
+	"fmt"
+	"strings"
 	"time"
 )
 
@@ -16,8 +18,82 @@ const (
 	LifecycleDisabled LifecycleStatus = "Disabled"
 )
 
-// LifecycleRule represents an object lifecycle management rule.
-// Currently supports expiration rules only (objects are deleted after N days).
+// Lifecycle configuration limits, as in S3.
+const (
+	// MaxLifecycleRules is the maximum number of rules of a bucket.
+	MaxLifecycleRules = 1000
+
+	// MaxLifecyclePrefixLength is the maximum length of a rule's prefix.
+	MaxLifecyclePrefixLength = 1024
+)
+
+// LifecycleFilter selects the objects a lifecycle rule applies to. An object
+// must meet every condition that is set, as with the <And> element of an S3
+// lifecycle rule filter.
+type LifecycleFilter struct {
+	// Prefix is the object key prefix filter.
+	// Empty string means all objects in the bucket.
+	Prefix string `json:"prefix"`
+
+	// Tags must all be set on an object for the rule to apply. Objects
+	// carry no tags yet, so a rule with tags applies to no object.
+	Tags []Tag `json:"tags,omitempty"`
+
+	// ObjectSizeGreaterThan, if set, limits the rule to objects larger than
+	// this many bytes.
+	ObjectSizeGreaterThan *int64 `json:"object_size_greater_than,omitempty"`
+
+	// ObjectSizeLessThan, if set, limits the rule to objects smaller than
+	// this many bytes.
+	ObjectSizeLessThan *int64 `json:"object_size_less_than,omitempty"`
+}
+
+// MatchesKey returns true if the given object key matches this filter's prefix.
+func (f *LifecycleFilter) MatchesKey(key string) bool {
+	return strings.HasPrefix(key, f.Prefix)
+}
+
+// Matches returns true if an object with the given key and size meets every
+// condition of the filter.
+func (f *LifecycleFilter) Matches(key string, size int64) bool {
+	switch {
+	case len(f.Tags) > 0:
+		return false
+	case f.ObjectSizeGreaterThan != nil && size <= *f.ObjectSizeGreaterThan:
+		return false
+	case f.ObjectSizeLessThan != nil && size >= *f.ObjectSizeLessThan:
+		return false
+	}
+	return f.MatchesKey(key)
+}
+
+// hasObjectConditions reports whether the filter looks at more than the key.
+func (f *LifecycleFilter) hasObjectConditions() bool {
+	return len(f.Tags) > 0 || f.ObjectSizeGreaterThan != nil || f.ObjectSizeLessThan != nil
+}
+
+// validate checks the filter's prefix length, tag set and size bounds.
+func (f *LifecycleFilter) validate() error {
+	if len(f.Prefix) > MaxLifecyclePrefixLength {
+		return fmt.Errorf("%w: the prefix can be at most %d bytes", ErrInvalidLifecycleRule, MaxLifecyclePrefixLength)
+	}
+	if err := ValidateBucketTags(f.Tags); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidLifecycleRule, err)
+	}
+	if f.ObjectSizeGreaterThan != nil && *f.ObjectSizeGreaterThan < 0 {
+		return fmt.Errorf("%w: ObjectSizeGreaterThan cannot be negative", ErrInvalidLifecycleRule)
+	}
+	if f.ObjectSizeLessThan != nil && *f.ObjectSizeLessThan <= 0 {
+		return fmt.Errorf("%w: ObjectSizeLessThan must be positive", ErrInvalidLifecycleRule)
+	}
+	if f.ObjectSizeGreaterThan != nil && f.ObjectSizeLessThan != nil && *f.ObjectSizeGreaterThan >= *f.ObjectSizeLessThan {
+		return fmt.Errorf("%w: ObjectSizeGreaterThan must be less than ObjectSizeLessThan", ErrInvalidLifecycleRule)
+	}
+	return nil
+}
+
+// LifecycleRule represents an object lifecycle management rule: a filter
+// selecting objects and one or more actions applied to them.
 type LifecycleRule struct {
 	// ID is the unique database identifier.
 	ID int64 `json:"id"`
@@ -29,14 +105,28 @@ type LifecycleRule struct {
 	// Must be unique within the bucket.
 	RuleID string `json:"rule_id"`
 
-	// Prefix is the object key prefix filter.
-	// Empty string means all objects in the bucket.
-	Prefix string `json:"prefix"`
+	LifecycleFilter
 
 	// ExpirationDays is the number of days after object creation
 	// when the object should be deleted. Nil means never expire.
 	ExpirationDays *int `json:"expiration_days,omitempty"`
 
+	// ExpirationDate is the midnight UTC at which matching objects are
+	// deleted, whenever they were created. Excludes ExpirationDays.
+	ExpirationDate *time.Time `json:"expiration_date,omitempty"`
+
+	// ExpiredObjectDeleteMarker removes delete markers that are the only
+	// remaining version of their key. Excludes the other expirations.
+	ExpiredObjectDeleteMarker bool `json:"expired_object_delete_marker,omitempty"`
+
+	// NoncurrentDays is the number of days after a version stops being the
+	// current one when it is permanently deleted. Nil means never.
+	NoncurrentDays *int `json:"noncurrent_days,omitempty"`
+
+	// AbortIncompleteUploadDays is the number of days after initiation
+	// when a multipart upload is aborted. Nil means the server's default.
+	AbortIncompleteUploadDays *int `json:"abort_incomplete_upload_days,omitempty"`
+
 	// Status indicates whether the rule is enabled.
 	Status LifecycleStatus `json:"status"`
 
@@ -53,27 +143,52 @@ func NewLifecycleRule(bucketID int64, ruleID string) *LifecycleRule {
 	return &LifecycleRule{
 		BucketID:  bucketID,
 		RuleID:    ruleID,
-		Prefix:    "",
 		Status:    LifecycleEnabled,
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
 }
 
-// Validate checks if the lifecycle rule is valid.
+// Validate checks if the lifecycle rule is valid: it needs an ID, a known
+// status, a valid filter and at least one action, with the combinations of
+// filters and actions S3 allows.
 func (r *LifecycleRule) Validate() error {
-	if r.RuleID == "" {
-		return ErrInvalidLifecycleRule
-	}
-	if len(r.RuleID) > 255 {
-		return ErrInvalidLifecycleRule
+	if r.RuleID == "" || len(r.RuleID) > 255 {
+		return fmt.Errorf("%w: the ID must be 1 to 255 characters", ErrInvalidLifecycleRule)
 	}
 	if r.Status != LifecycleEnabled && r.Status != LifecycleDisabled {
-		return ErrInvalidLifecycleRule
+		return fmt.Errorf("%w: the status must be Enabled or Disabled", ErrInvalidLifecycleRule)
+	}
+	if err := r.LifecycleFilter.validate(); err != nil {
+		return err
+	}
+	if !r.HasExpiration() && !r.ExpiredObjectDeleteMarker && r.NoncurrentDays == nil && r.AbortIncompleteUploadDays == nil {
+		return fmt.Errorf("%w: rule %q has no action", ErrInvalidLifecycleRule, r.RuleID)
+	}
+
+	expirations := 0
+	for _, set := range []bool{r.ExpirationDays != nil, r.ExpirationDate != nil, r.ExpiredObjectDeleteMarker} {
+		if set {
+			expirations++
+		}
+	}
+	if expirations > 1 {
+		return fmt.Errorf("%w: an expiration has only one of Days, Date and ExpiredObjectDeleteMarker", ErrInvalidLifecycleRule)
+	}
+
+	for _, days := range []*int{r.ExpirationDays, r.NoncurrentDays, r.AbortIncompleteUploadDays} {
+		if days != nil && *days < 1 {
+			return fmt.Errorf("%w: days must be positive", ErrInvalidLifecycleRule)
+		}
+	}
+	if r.ExpirationDate != nil && !r.ExpirationDate.UTC().Equal(r.ExpirationDate.UTC().Truncate(24*time.Hour)) {
+		return fmt.Errorf("%w: the expiration date must be at midnight UTC", ErrInvalidLifecycleRule)
+	}
+	if len(r.Tags) > 0 && r.ExpiredObjectDeleteMarker {
+		return fmt.Errorf("%w: ExpiredObjectDeleteMarker cannot be combined with a tag filter", ErrInvalidLifecycleRule)
 	}
-	// Expiration days must be positive if set
-	if r.ExpirationDays != nil && *r.ExpirationDays < 1 {
-		return ErrInvalidLifecycleRule
+	if r.hasObjectConditions() && r.AbortIncompleteUploadDays != nil {
+		return fmt.Errorf("%w: AbortIncompleteMultipartUpload cannot be combined with a tag or object size filter", ErrInvalidLifecycleRule)
 	}
 	return nil
 }
@@ -83,17 +198,19 @@ func (r *LifecycleRule) IsEnabled() bool {
 	return r.Status == LifecycleEnabled
 }
 
-// HasExpiration returns true if the rule has an expiration policy.
+// HasExpiration returns true if the rule expires current objects, after a
+// number of days or at a date.
 func (r *LifecycleRule) HasExpiration() bool {
-	return r.ExpirationDays != nil && *r.ExpirationDays > 0
+	return (r.ExpirationDays != nil && *r.ExpirationDays > 0) || r.ExpirationDate != nil
 }
 
-// MatchesKey returns true if the given object key matches this rule's prefix filter.
-func (r *LifecycleRule) MatchesKey(key string) bool {
-	if r.Prefix == "" {
-		return true
+// ExpiresAt returns when an object created at the given time expires under
+// this rule. Only meaningful if HasExpiration.
+func (r *LifecycleRule) ExpiresAt(createdAt time.Time) time.Time {
+	if r.ExpirationDate != nil {
+		return r.ExpirationDate.UTC()
 	}
-	return len(key) >= len(r.Prefix) && key[:len(r.Prefix)] == r.Prefix
+	return createdAt.UTC().AddDate(0, 0, *r.ExpirationDays)
 }
 
 // ShouldExpire checks if an object created at the given time should be expired.
@@ -102,8 +219,16 @@ func (r *LifecycleRule) ShouldExpire(createdAt time.Time) bool {
 		return false
 	}
 
-	expirationTime := createdAt.AddDate(0, 0, *r.ExpirationDays)
-	return time.Now().UTC().After(expirationTime)
+	return time.Now().UTC().After(r.ExpiresAt(createdAt))
+}
+
+// ObjectExpiration is when a lifecycle rule will expire an object.
+type ObjectExpiration struct {
+	// Date is when the object expires.
+	Date time.Time
+
+	// RuleID is the rule that expires the object.
+	RuleID string
 }
 
 // LifecycleConfiguration represents the complete lifecycle configuration for a bucket.
@@ -119,6 +244,28 @@ func NewLifecycleConfiguration() *LifecycleConfiguration {
 	}
 }
 
+// Validate checks every rule, and that there are at most MaxLifecycleRules
+// with unique IDs.
+func (c *LifecycleConfiguration) Validate() error {
+	if len(c.Rules) == 0 {
+		return fmt.Errorf("%w: a configuration needs at least one rule", ErrInvalidLifecycleRule)
+	}
+	if len(c.Rules) > MaxLifecycleRules {
+		return fmt.Errorf("%w: at most %d rules are allowed", ErrInvalidLifecycleRule, MaxLifecycleRules)
+	}
+	seen := make(map[string]bool, len(c.Rules))
+	for _, rule := range c.Rules {
+		if err := rule.Validate(); err != nil {
+			return err
+		}
+		if seen[rule.RuleID] {
+			return fmt.Errorf("%w: duplicate rule ID %q", ErrInvalidLifecycleRule, rule.RuleID)
+		}
+		seen[rule.RuleID] = true
+	}
+	return nil
+}
+
 // GetEnabledRules returns only the enabled rules.
 func (c *LifecycleConfiguration) GetEnabledRules() []*LifecycleRule {
 	enabled := make([]*LifecycleRule, 0)
@@ -139,3 +286,37 @@ func (c *LifecycleConfiguration) FindRule(ruleID string) *LifecycleRule {
 	}
 	return nil
 }
+
+// Expiration returns the earliest expiration of the current version of key,
+// of the given size and created at the given time, by the enabled rules;
+// nil if no rule applies.
+func (c *LifecycleConfiguration) Expiration(key string, size int64, createdAt time.Time) *ObjectExpiration {
+	var earliest *ObjectExpiration
+	for _, rule := range c.Rules {
+		if !rule.IsEnabled() || !rule.HasExpiration() || !rule.Matches(key, size) {
+			continue
+		}
+		date := rule.ExpiresAt(createdAt)
+		if earliest == nil || date.Before(earliest.Date) {
+			earliest = &ObjectExpiration{Date: date, RuleID: rule.RuleID}
+		}
+	}
+	return earliest
+}
+
+// AbortIncompleteUpload returns when the enabled rules abort a multipart
+// upload of key initiated at the given time, the earliest if several
+// apply; nil if none does.
+func (c *LifecycleConfiguration) AbortIncompleteUpload(key string, initiated time.Time) *ObjectExpiration {
+	var earliest *ObjectExpiration
+	for _, rule := range c.Rules {
+		if !rule.IsEnabled() || rule.AbortIncompleteUploadDays == nil || !rule.MatchesKey(key) {
+			continue
+		}
+		date := initiated.UTC().AddDate(0, 0, *rule.AbortIncompleteUploadDays)
+		if earliest == nil || date.Before(earliest.Date) {
+			earliest = &ObjectExpiration{Date: date, RuleID: rule.RuleID}
+		}
+	}
+	return earliest
+}
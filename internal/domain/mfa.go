@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MFADevice is a user's virtual (TOTP) MFA device. Buckets with MFA delete
+// enabled require a code from their owner's device, given in the x-amz-mfa
+// header as "<serial number> <code>".
+type MFADevice struct {
+	// UserID is the user the device belongs to. A user has at most one.
+	UserID int64 `json:"user_id"`
+
+	// SerialNumber identifies the device in x-amz-mfa headers.
+	SerialNumber string `json:"serial_number"`
+
+	// EncryptedSecret is the AES-256-GCM encrypted TOTP secret.
+	// This should never be exposed in API responses.
+	EncryptedSecret string `json:"-"`
+
+	// CreatedAt is the timestamp when the device was enrolled.
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewMFADevice creates the MFA device of a user.
+func NewMFADevice(user *User, encryptedSecret string) *MFADevice {
+	return &MFADevice{
+		UserID:          user.ID,
+		SerialNumber:    MFASerialNumber(user),
+		EncryptedSecret: encryptedSecret,
+		CreatedAt:       time.Now().UTC(),
+	}
+}
+
+// MFASerialNumber returns the serial number of a user's MFA device, in the
+// form of an AWS virtual MFA device ARN.
+func MFASerialNumber(user *User) string {
+	return fmt.Sprintf("arn:aws:iam::%d:mfa/%s", user.ID, user.Username)
+}
+
+// ParseMFAHeader splits an x-amz-mfa header into the device serial number
+// and the code.
+func ParseMFAHeader(header string) (serialNumber, code string, err error) {
+	fields := strings.Fields(header)
+	if len(fields) != 2 {
+		return "", "", fmt.Errorf("%w: expected \"<serial number> <code>\"", ErrInvalidMFA)
+	}
+	return fields[0], fields[1], nil
+}
@@ -21,6 +21,18 @@ const (
 	MultipartStatusAborted MultipartStatus = "Aborted"
 )
 
+// Multipart upload limits (S3-compatible).
+const (
+	// MaxPartNumber is the highest allowed part number.
+	MaxPartNumber = 10000
+
+	// MinPartSize is the minimum size of every part except the last (5 MiB).
+	MinPartSize int64 = 5 * 1024 * 1024
+
+	// MaxPartSize is the maximum size of a single part (5 GiB).
+	MaxPartSize int64 = 5 * 1024 * 1024 * 1024
+)
+
 // MultipartUpload represents an in-progress multipart upload.
 // Multipart uploads allow uploading large objects in parts.
 type MultipartUpload struct {
@@ -42,6 +54,14 @@ type MultipartUpload struct {
 	// StorageClass is the storage tier for the final object.
 	StorageClass StorageClass `json:"storage_class"`
 
+	// ACL is the canned ACL of the final object.
+	ACL ObjectACL `json:"acl"`
+
+	// ChecksumAlgorithm is the algorithm of the part checksums and the
+	// composite checksum of the final object. Empty for uploads without
+	// additional checksums.
+	ChecksumAlgorithm ChecksumAlgorithm `json:"checksum_algorithm,omitempty"`
+
 	// Metadata contains user-defined metadata for the final object.
 	Metadata map[string]string `json:"metadata,omitempty"`
 
@@ -66,6 +86,7 @@ func NewMultipartUpload(bucketID int64, key string, initiatorID int64) *Multipar
 		InitiatorID:  initiatorID,
 		Status:       MultipartStatusInProgress,
 		StorageClass: StorageClassStandard,
+		ACL:          ObjectACLPrivate,
 		Metadata:     make(map[string]string),
 		InitiatedAt:  now,
 		ExpiresAt:    now.Add(7 * 24 * time.Hour), // 7 days
@@ -103,6 +124,10 @@ type UploadPart struct {
 	// ETag is the entity tag for this part (typically MD5).
 	ETag string `json:"etag"`
 
+	// Checksum is the base64-encoded checksum of the part, computed with
+	// the upload's ChecksumAlgorithm. Empty if the upload has none.
+	Checksum string `json:"checksum,omitempty"`
+
 	// CreatedAt is when this part was uploaded.
 	CreatedAt time.Time `json:"created_at"`
 }
@@ -121,17 +146,32 @@ func NewUploadPart(uploadID uuid.UUID, partNumber int, contentHash, etag string,
 
 // ValidatePartNumber checks if the part number is valid (1-10000).
 func ValidatePartNumber(partNumber int) error {
-	if partNumber < 1 || partNumber > 10000 {
+	if partNumber < 1 || partNumber > MaxPartNumber {
 		return ErrInvalidPartNumber
 	}
 	return nil
 }
 
+// ValidateChunkHash checks that a resumable upload chunk hash is a
+// lowercase hex SHA-256 hash, as content hashes are stored.
+func ValidateChunkHash(hash string) error {
+	if len(hash) != 64 {
+		return ErrInvalidChunkHash
+	}
+	for _, c := range hash {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return ErrInvalidChunkHash
+		}
+	}
+	return nil
+}
+
 // PartInfo is a summary of part information returned in list operations.
 type PartInfo struct {
 	PartNumber   int       `json:"part_number"`
 	Size         int64     `json:"size"`
 	ETag         string    `json:"etag"`
+	Checksum     string    `json:"checksum,omitempty"`
 	LastModified time.Time `json:"last_modified"`
 }
 
@@ -139,6 +179,7 @@ type PartInfo struct {
 type CompletedPart struct {
 	PartNumber int    `json:"part_number"`
 	ETag       string `json:"etag"`
+	Checksum   string `json:"checksum,omitempty"` // Optional - checked against the part's checksum
 }
 
 // MultipartUploadInfo is a summary returned in ListMultipartUploads.
@@ -150,3 +191,15 @@ type MultipartUploadInfo struct {
 	Owner        *OwnerInfo   `json:"owner,omitempty"`
 	Initiator    *OwnerInfo   `json:"initiator,omitempty"`
 }
+
+// MultipartStats summarizes the multipart uploads in progress in all buckets.
+type MultipartStats struct {
+	// Uploads is the number of uploads in progress.
+	Uploads int64 `json:"uploads"`
+
+	// Parts is the number of parts uploaded so far.
+	Parts int64 `json:"parts"`
+
+	// Bytes is the total size of the uploaded parts.
+	Bytes int64 `json:"bytes"`
+}
@@ -2,6 +2,7 @@
 package domain
 
 import (
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
@@ -24,6 +25,52 @@ const (
 	StorageClassDeepArchive StorageClass = "DEEP_ARCHIVE"
 )
 
+// ObjectACL represents the canned ACL of an object version.
+type ObjectACL string
+
+const (
+	// ObjectACLPrivate means the object is readable as its bucket allows (default).
+	ObjectACLPrivate ObjectACL = "private"
+
+	// ObjectACLPublicRead means anyone can read the object, even if its
+	// bucket is private.
+	ObjectACLPublicRead ObjectACL = "public-read"
+)
+
+// IsValidObjectACL checks if the given ACL string is a supported object ACL.
+func IsValidObjectACL(acl string) bool {
+	switch ObjectACL(acl) {
+	case ObjectACLPrivate, ObjectACLPublicRead:
+		return true
+	default:
+		return false
+	}
+}
+
+// AllowsAnonymousRead returns true if the ACL allows unauthenticated read access.
+func (a ObjectACL) AllowsAnonymousRead() bool {
+	return a == ObjectACLPublicRead
+}
+
+// MaxObjectKeyLength is the maximum length of an object key in bytes.
+const MaxObjectKeyLength = 1024
+
+// ContentHeaders are the standard HTTP headers stored with an object and
+// returned when it is read. They are kept in Object.Metadata under these
+// canonical names, apart from user metadata, whose keys are lower case.
+var ContentHeaders = []string{
+	"Cache-Control",
+	"Content-Disposition",
+	"Content-Encoding",
+	"Content-Language",
+	"Expires",
+}
+
+// IsContentHeader reports whether a metadata key holds one of ContentHeaders.
+func IsContentHeader(key string) bool {
+	return slices.Contains(ContentHeaders, key)
+}
+
 // Object represents an S3-compatible object stored in a bucket.
 // Objects support versioning - each version has a unique version ID.
 type Object struct {
@@ -68,9 +115,36 @@ type Object struct {
 	// StorageClass is the storage tier for this object.
 	StorageClass StorageClass `json:"storage_class"`
 
-	// Metadata contains user-defined metadata (x-amz-meta-* headers).
+	// ACL is the canned ACL of this version. Empty for delete markers.
+	ACL ObjectACL `json:"acl,omitempty"`
+
+	// ChecksumAlgorithm is the algorithm of Checksum, empty if the object
+	// was stored without an additional checksum.
+	ChecksumAlgorithm ChecksumAlgorithm `json:"checksum_algorithm,omitempty"`
+
+	// Checksum is the base64-encoded checksum of the content, or for
+	// multipart objects the composite checksum of the parts with a
+	// "-{partCount}" suffix.
+	Checksum string `json:"checksum,omitempty"`
+
+	// Metadata contains user-defined metadata (x-amz-meta-* headers) and
+	// the object's ContentHeaders.
 	Metadata map[string]string `json:"metadata,omitempty"`
 
+	// PartSizes holds the size of each original part, in part order,
+	// for objects created by a multipart upload. Empty for single-part objects.
+	// Used to serve GET/HEAD requests with a partNumber parameter.
+	PartSizes []int64 `json:"part_sizes,omitempty"`
+
+	// Sequence is the per-key write counter assigned when this version was
+	// created. It increases by one with every write or delete of the key, and
+	// is returned to clients in the x-alexander-sequence header.
+	Sequence int64 `json:"sequence"`
+
+	// ReplicationStatus is the replication state of this version, empty if
+	// the bucket does not replicate it.
+	ReplicationStatus ReplicationStatus `json:"replication_status,omitempty"`
+
 	// CreatedAt is the timestamp when this version was created.
 	CreatedAt time.Time `json:"created_at"`
 
@@ -92,6 +166,7 @@ func NewObject(bucketID int64, key, contentHash, contentType, etag string, size
 		ContentType:    contentType,
 		ETag:           etag,
 		StorageClass:   StorageClassStandard,
+		ACL:            ObjectACLPrivate,
 		Metadata:       make(map[string]string),
 		CreatedAt:      time.Now().UTC(),
 	}
@@ -125,10 +200,58 @@ func (o *Object) IsDeleted() bool {
 // GetVersionIDString returns the version ID as a string.
 // Returns "null" for null version (suspended versioning).
 func (o *Object) GetVersionIDString() string {
-	if o.VersionID == uuid.Nil {
+	return VersionIDString(o.VersionID)
+}
+
+// VersionIDString returns a version ID as clients see it, "null" for the
+// null version.
+func VersionIDString(versionID uuid.UUID) string {
+	if versionID == uuid.Nil {
 		return "null"
 	}
-	return o.VersionID.String()
+	return versionID.String()
+}
+
+// ParseVersionID parses a version ID as given by a client, where "null"
+// names the null version.
+func ParseVersionID(s string) (uuid.UUID, error) {
+	if s == "null" {
+		return uuid.Nil, nil
+	}
+	versionID, err := uuid.Parse(s)
+	if err != nil {
+		return uuid.Nil, ErrInvalidVersionID
+	}
+	return versionID, nil
+}
+
+// PartsCount returns the number of parts the object was uploaded in.
+// Returns 0 for objects not created by a multipart upload.
+func (o *Object) PartsCount() int {
+	return len(o.PartSizes)
+}
+
+// PartRange returns the byte offset and length of the given 1-based part.
+// Single-part objects have exactly one part spanning the whole object.
+func (o *Object) PartRange(partNumber int) (offset, length int64, err error) {
+	if err := ValidatePartNumber(partNumber); err != nil {
+		return 0, 0, err
+	}
+
+	if len(o.PartSizes) == 0 {
+		if partNumber != 1 {
+			return 0, 0, ErrPartNumberNotSatisfiable
+		}
+		return 0, o.Size, nil
+	}
+
+	if partNumber > len(o.PartSizes) {
+		return 0, 0, ErrPartNumberNotSatisfiable
+	}
+	for _, size := range o.PartSizes[:partNumber-1] {
+		offset += size
+	}
+	return offset, o.PartSizes[partNumber-1], nil
 }
 
 // ObjectInfo is a summary of object metadata returned in list operations.
@@ -161,3 +284,19 @@ type ObjectVersion struct {
 	StorageClass   StorageClass `json:"storage_class"`
 	Owner          *OwnerInfo   `json:"owner,omitempty"`
 }
+
+// ObjectStats summarizes the objects of all buckets.
+type ObjectStats struct {
+	// Objects is the number of current objects, not counting delete markers.
+	Objects int64 `json:"objects"`
+
+	// LogicalBytes is the total size of the current objects.
+	LogicalBytes int64 `json:"logical_bytes"`
+
+	// Versions is the number of object versions, current and noncurrent,
+	// not counting delete markers.
+	Versions int64 `json:"versions"`
+
+	// DeleteMarkers is the number of delete markers.
+	DeleteMarkers int64 `json:"delete_markers"`
+}
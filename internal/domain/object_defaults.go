@@ -0,0 +1,132 @@
+// Package domain contains the core business entities for Alexander Storage.
+package domain
+
+import (
+	"fmt"
+	"mime"
+	"path"
+	"sort"
+	"strings"
+)
+
+// IsValidStorageClass checks if the given storage class is one objects can be stored with.
+func IsValidStorageClass(class string) bool {
+	switch StorageClass(class) {
+	case StorageClassStandard, StorageClassReducedRedundancy, StorageClassGlacier, StorageClassDeepArchive:
+		return true
+	default:
+		return false
+	}
+}
+
+// MaxContentTypeMappings is the maximum number of extensions a bucket maps
+// to content types.
+const MaxContentTypeMappings = 100
+
+// ObjectDefaults are the settings a bucket gives uploads that do not specify
+// them. They apply when an object is written with PutObject or a multipart
+// upload is initiated; objects already stored keep their settings.
+type ObjectDefaults struct {
+	// CacheControl is the Cache-Control header of uploads sent without one.
+	CacheControl string `json:"cache_control,omitempty"`
+
+	// StorageClass is the storage class of uploads sent without
+	// x-amz-storage-class.
+	StorageClass StorageClass `json:"storage_class,omitempty"`
+
+	// ContentTypes maps lower-case key extensions, such as ".log", to the
+	// Content-Type of uploads sent without one. They take precedence over
+	// the server's own guess from the extension.
+	ContentTypes map[string]string `json:"content_types,omitempty"`
+}
+
+// IsEmpty reports whether the defaults change nothing.
+func (d *ObjectDefaults) IsEmpty() bool {
+	return d == nil || (d.CacheControl == "" && d.StorageClass == "" && len(d.ContentTypes) == 0)
+}
+
+// Normalize lower-cases the extensions of ContentTypes and adds their
+// leading dot where it is missing.
+func (d *ObjectDefaults) Normalize() {
+	if len(d.ContentTypes) == 0 {
+		d.ContentTypes = nil
+		return
+	}
+	normalized := make(map[string]string, len(d.ContentTypes))
+	for ext, contentType := range d.ContentTypes {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext != "" && !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		normalized[ext] = strings.TrimSpace(contentType)
+	}
+	d.ContentTypes = normalized
+}
+
+// Validate checks the defaults: a known storage class, a Cache-Control value
+// that can be sent as a header, and at most MaxContentTypeMappings
+// extensions mapped to well-formed media types.
+func (d *ObjectDefaults) Validate() error {
+	if d.StorageClass != "" && !IsValidStorageClass(string(d.StorageClass)) {
+		return fmt.Errorf("%w: unknown storage class %q", ErrInvalidObjectDefaults, d.StorageClass)
+	}
+	if strings.ContainsFunc(d.CacheControl, func(r rune) bool { return r < ' ' || r == 0x7f }) {
+		return fmt.Errorf("%w: Cache-Control cannot contain control characters", ErrInvalidObjectDefaults)
+	}
+	if len(d.ContentTypes) > MaxContentTypeMappings {
+		return fmt.Errorf("%w: at most %d content types can be mapped", ErrInvalidObjectDefaults, MaxContentTypeMappings)
+	}
+	for ext, contentType := range d.ContentTypes {
+		if len(ext) < 2 || ext[0] != '.' || strings.ContainsAny(ext[1:], "./ ") {
+			return fmt.Errorf("%w: %q is not a file extension", ErrInvalidObjectDefaults, ext)
+		}
+		if _, _, err := mime.ParseMediaType(contentType); err != nil {
+			return fmt.Errorf("%w: %q is not a valid content type for %s", ErrInvalidObjectDefaults, contentType, ext)
+		}
+	}
+	return nil
+}
+
+// ContentTypeFor returns the Content-Type the defaults map the key's
+// extension to, or "" if they map none. It is safe to call on nil defaults.
+func (d *ObjectDefaults) ContentTypeFor(key string) string {
+	if d == nil {
+		return ""
+	}
+	return d.ContentTypes[strings.ToLower(path.Ext(key))]
+}
+
+// ParseContentTypeMappings parses extension-to-content-type mappings written
+// as "ext=type" pairs separated by commas or newlines, such as
+// ".log=text/plain, .md=text/markdown". Blank entries are ignored.
+func ParseContentTypeMappings(s string) (map[string]string, error) {
+	mappings := make(map[string]string)
+	for _, entry := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '\n' || r == '\r' }) {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		ext, contentType, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(ext) == "" || strings.TrimSpace(contentType) == "" {
+			return nil, fmt.Errorf("%w: %q is not of the form ext=type", ErrInvalidObjectDefaults, entry)
+		}
+		mappings[strings.TrimSpace(ext)] = strings.TrimSpace(contentType)
+	}
+	return mappings, nil
+}
+
+// FormatContentTypeMappings writes mappings in the form
+// ParseContentTypeMappings reads, one per line in extension order.
+func FormatContentTypeMappings(mappings map[string]string) string {
+	exts := make([]string, 0, len(mappings))
+	for ext := range mappings {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+
+	var b strings.Builder
+	for _, ext := range exts {
+		fmt.Fprintf(&b, "%s=%s\n", ext, mappings[ext])
+	}
+	return b.String()
+}
@@ -0,0 +1,109 @@
+// Package domain contains the core business entities for Alexander Storage.
+package domain
+
+import (
+	"time"
+)
+
+// ObjectEventType identifies the kind of object change, using S3 event names.
+type ObjectEventType string
+
+const (
+	// ObjectEventCreatedPut is recorded when an object is uploaded with PutObject.
+	ObjectEventCreatedPut ObjectEventType = "s3:ObjectCreated:Put"
+
+	// ObjectEventCreatedCopy is recorded when an object is created by CopyObject.
+	ObjectEventCreatedCopy ObjectEventType = "s3:ObjectCreated:Copy"
+
+	// ObjectEventCreatedMultipart is recorded when a multipart upload completes.
+	ObjectEventCreatedMultipart ObjectEventType = "s3:ObjectCreated:CompleteMultipartUpload"
+
+	// ObjectEventRemovedDelete is recorded when an object version is permanently deleted.
+	ObjectEventRemovedDelete ObjectEventType = "s3:ObjectRemoved:Delete"
+
+	// ObjectEventRemovedDeleteMarker is recorded when a delete marker hides an object.
+	ObjectEventRemovedDeleteMarker ObjectEventType = "s3:ObjectRemoved:DeleteMarkerCreated"
+
+	// ObjectEventLifecycleExpirationDelete is recorded when a lifecycle rule
+	// permanently deletes an object version.
+	ObjectEventLifecycleExpirationDelete ObjectEventType = "s3:LifecycleExpiration:Delete"
+
+	// ObjectEventLifecycleExpirationDeleteMarker is recorded when a lifecycle
+	// rule expires the current version of a versioned object with a delete marker.
+	ObjectEventLifecycleExpirationDeleteMarker ObjectEventType = "s3:LifecycleExpiration:DeleteMarkerCreated"
+
+	// ObjectEventBlobGarbageCollected is recorded when the garbage collector
+	// deletes an unreferenced blob. Blobs are shared between buckets, so the
+	// event has no bucket; its key is the content hash.
+	ObjectEventBlobGarbageCollected ObjectEventType = "alexander:BlobGarbageCollected"
+)
+
+// ObjectEvent is an object change recorded in the event outbox. Events are
+// written in the same transaction as the change, so the outbox never reports
+// a change that was rolled back.
+type ObjectEvent struct {
+	// ID is the outbox sequence number; it increases with every event.
+	ID int64 `json:"id"`
+
+	// Type is the kind of change.
+	Type ObjectEventType `json:"type"`
+
+	// BucketName is the bucket containing the object, empty for blob events.
+	BucketName string `json:"bucket"`
+
+	// Key is the object key, or the content hash for blob events.
+	Key string `json:"key"`
+
+	// VersionID is the affected version, empty for unversioned objects.
+	VersionID string `json:"version_id,omitempty"`
+
+	// Size is the object size in bytes (0 for removals).
+	Size int64 `json:"size"`
+
+	// ETag is the object ETag (empty for removals).
+	ETag string `json:"etag,omitempty"`
+
+	// CreatedAt is when the change happened.
+	CreatedAt time.Time `json:"time"`
+}
+
+// NewObjectEvent creates an event describing a change to obj in bucket.
+// Version IDs are only reported for buckets that have had versioning enabled.
+func NewObjectEvent(eventType ObjectEventType, bucket *Bucket, obj *Object) *ObjectEvent {
+	event := &ObjectEvent{
+		Type:       eventType,
+		BucketName: bucket.Name,
+		Key:        obj.Key,
+		CreatedAt:  time.Now().UTC(),
+	}
+	if bucket.Versioning != VersioningDisabled {
+		event.VersionID = obj.GetVersionIDString()
+	}
+	if !eventType.IsRemoval() {
+		event.Size = obj.Size
+		event.ETag = obj.ETag
+	}
+	return event
+}
+
+// NewBlobEvent creates an event describing a change to a blob, reporting
+// its size so that freed storage can be accounted for.
+func NewBlobEvent(eventType ObjectEventType, blob *Blob) *ObjectEvent {
+	return &ObjectEvent{
+		Type:      eventType,
+		Key:       blob.ContentHash,
+		Size:      blob.Size,
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+// IsRemoval reports whether the event removes an object version or hides
+// it behind a delete marker.
+func (t ObjectEventType) IsRemoval() bool {
+	switch t {
+	case ObjectEventRemovedDelete, ObjectEventRemovedDeleteMarker,
+		ObjectEventLifecycleExpirationDelete, ObjectEventLifecycleExpirationDeleteMarker:
+		return true
+	}
+	return false
+}
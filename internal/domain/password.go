@@ -0,0 +1,138 @@
+package domain
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode"
+)
+
+// MinPasswordLength is the shortest password any policy allows.
+const MinPasswordLength = 8
+
+// Character classes used to generate passwords.
+const (
+	passwordLower  = "abcdefghijklmnopqrstuvwxyz"
+	passwordUpper  = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	passwordDigit  = "0123456789"
+	passwordSymbol = "!@#$%^&*-_=+"
+)
+
+// PasswordPolicy is the set of rules local user passwords must satisfy.
+type PasswordPolicy struct {
+	// MinLength is the minimum length in characters. Values below
+	// MinPasswordLength are raised to it.
+	MinLength int
+
+	// RequireUpper, RequireLower, RequireDigit and RequireSymbol require at
+	// least one character of the class.
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+
+	// Banned lists passwords that are rejected regardless of the other
+	// rules, such as common or breached passwords. Matching ignores case.
+	Banned []string
+}
+
+// DefaultPasswordPolicy returns the policy of earlier releases: at least
+// MinPasswordLength characters and nothing else.
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{MinLength: MinPasswordLength}
+}
+
+// minLength returns MinLength raised to MinPasswordLength.
+func (p PasswordPolicy) minLength() int {
+	return max(p.MinLength, MinPasswordLength)
+}
+
+// Validate returns an error describing the first rule the password breaks.
+func (p PasswordPolicy) Validate(password string) error {
+	if len([]rune(password)) < p.minLength() {
+		return fmt.Errorf("must be at least %d characters", p.minLength())
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			symbol = true
+		}
+	}
+	switch {
+	case p.RequireUpper && !upper:
+		return errors.New("must contain an upper case letter")
+	case p.RequireLower && !lower:
+		return errors.New("must contain a lower case letter")
+	case p.RequireDigit && !digit:
+		return errors.New("must contain a digit")
+	case p.RequireSymbol && !symbol:
+		return errors.New("must contain a symbol")
+	}
+
+	for _, banned := range p.Banned {
+		if strings.EqualFold(password, banned) {
+			return errors.New("is too common")
+		}
+	}
+	return nil
+}
+
+// Generate returns a random password of at least length characters that
+// satisfies the policy.
+func (p PasswordPolicy) Generate(length int) (string, error) {
+	length = max(length, p.minLength())
+
+	// One character of every class, so required classes are always present
+	classes := []string{passwordLower, passwordUpper, passwordDigit, passwordSymbol}
+	password := make([]byte, 0, length)
+	for _, class := range classes {
+		c, err := randomChar(class)
+		if err != nil {
+			return "", err
+		}
+		password = append(password, c)
+	}
+
+	all := strings.Join(classes, "")
+	for len(password) < length {
+		c, err := randomChar(all)
+		if err != nil {
+			return "", err
+		}
+		password = append(password, c)
+	}
+
+	// Shuffle so the class characters are not always first
+	for i := len(password) - 1; i > 0; i-- {
+		j, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return "", err
+		}
+		password[i], password[j.Int64()] = password[j.Int64()], password[i]
+	}
+
+	if err := p.Validate(string(password)); err != nil {
+		// Only a banned list entry can reject a generated password
+		return p.Generate(length)
+	}
+	return string(password), nil
+}
+
+// randomChar returns a uniformly random character of charset.
+func randomChar(charset string) (byte, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(len(charset))))
+	if err != nil {
+		return 0, err
+	}
+	return charset[n.Int64()], nil
+}
@@ -0,0 +1,275 @@
+// Package domain contains the core business entities for Alexander Storage.
+package domain
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// PolicyEffect is the effect of a policy statement.
+type PolicyEffect string
+
+const (
+	// PolicyEffectAllow grants the matched actions.
+	PolicyEffectAllow PolicyEffect = "Allow"
+
+	// PolicyEffectDeny refuses the matched actions, overriding any Allow.
+	PolicyEffectDeny PolicyEffect = "Deny"
+)
+
+// Supported policy condition operators and keys.
+const (
+	// PolicyConditionIPAddress matches when the source IP is in one of the ranges.
+	PolicyConditionIPAddress = "IpAddress"
+
+	// PolicyConditionNotIPAddress matches when the source IP is in none of the ranges.
+	PolicyConditionNotIPAddress = "NotIpAddress"
+
+	// PolicyKeySourceIP is the condition key for the client IP address.
+	PolicyKeySourceIP = "aws:SourceIp"
+
+	// PolicyResourcePrefix is the ARN prefix of S3 resources.
+	PolicyResourcePrefix = "arn:aws:s3:::"
+)
+
+// AccessKeyPolicy scopes what an access key may do. It uses the IAM policy
+// document syntax for the subset Alexander supports: Allow/Deny statements
+// over S3 actions, bucket and object resources, and source IP conditions.
+//
+// A key without a policy has full access to its owner's buckets. A key with a
+// policy may only perform actions an Allow statement grants and no Deny
+// statement refuses.
+type AccessKeyPolicy struct {
+	// Version is the policy language version (informational).
+	Version string `json:"Version,omitempty"`
+
+	// Statement is the list of policy statements.
+	Statement []PolicyStatement `json:"Statement"`
+}
+
+// PolicyStatement is a single Allow or Deny rule.
+type PolicyStatement struct {
+	// Sid is an optional statement identifier.
+	Sid string `json:"Sid,omitempty"`
+
+	// Effect is Allow or Deny.
+	Effect PolicyEffect `json:"Effect"`
+
+	// Action lists the S3 actions, e.g. "s3:GetObject", "s3:List*" or "s3:*".
+	Action PolicyValues `json:"Action"`
+
+	// Resource lists bucket and object ARNs, e.g. "arn:aws:s3:::photos" or
+	// "arn:aws:s3:::photos/2024/*". The "arn:aws:s3:::" prefix is optional.
+	Resource PolicyValues `json:"Resource"`
+
+	// Condition maps an operator to condition keys and their values, e.g.
+	// {"IpAddress": {"aws:SourceIp": ["10.0.0.0/8"]}}.
+	Condition map[string]map[string]PolicyValues `json:"Condition,omitempty"`
+}
+
+// PolicyValues is a list of strings that, like in IAM documents, may also be
+// written as a single string.
+type PolicyValues []string
+
+// UnmarshalJSON accepts either a string or an array of strings.
+func (v *PolicyValues) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte(`"`)) {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*v = PolicyValues{s}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*v = list
+	return nil
+}
+
+// ParseAccessKeyPolicy parses and validates a JSON policy document.
+// Unknown fields are rejected so that unsupported IAM features (NotAction,
+// Principal, ...) are not silently ignored.
+func ParseAccessKeyPolicy(data []byte) (*AccessKeyPolicy, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	var policy AccessKeyPolicy
+	if err := dec.Decode(&policy); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPolicy, err)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// Validate checks that the policy only uses supported features.
+func (p *AccessKeyPolicy) Validate() error {
+	if len(p.Statement) == 0 {
+		return fmt.Errorf("%w: at least one statement is required", ErrInvalidPolicy)
+	}
+
+	for i, st := range p.Statement {
+		if st.Effect != PolicyEffectAllow && st.Effect != PolicyEffectDeny {
+			return fmt.Errorf("%w: statement %d: effect must be Allow or Deny", ErrInvalidPolicy, i)
+		}
+		if len(st.Action) == 0 {
+			return fmt.Errorf("%w: statement %d: at least one action is required", ErrInvalidPolicy, i)
+		}
+		for _, action := range st.Action {
+			if action != "*" && !strings.HasPrefix(strings.ToLower(action), "s3:") {
+				return fmt.Errorf("%w: statement %d: unsupported action %q", ErrInvalidPolicy, i, action)
+			}
+		}
+		if len(st.Resource) == 0 {
+			return fmt.Errorf("%w: statement %d: at least one resource is required", ErrInvalidPolicy, i)
+		}
+		for _, resource := range st.Resource {
+			if resource == "" || (strings.HasPrefix(resource, "arn:") && !strings.HasPrefix(resource, PolicyResourcePrefix)) {
+				return fmt.Errorf("%w: statement %d: unsupported resource %q", ErrInvalidPolicy, i, resource)
+			}
+		}
+		for op, keys := range st.Condition {
+			if op != PolicyConditionIPAddress && op != PolicyConditionNotIPAddress {
+				return fmt.Errorf("%w: statement %d: unsupported condition operator %q", ErrInvalidPolicy, i, op)
+			}
+			for key, values := range keys {
+				if !strings.EqualFold(key, PolicyKeySourceIP) {
+					return fmt.Errorf("%w: statement %d: unsupported condition key %q", ErrInvalidPolicy, i, key)
+				}
+				for _, value := range values {
+					if parseIPRange(value) == nil {
+						return fmt.Errorf("%w: statement %d: invalid IP range %q", ErrInvalidPolicy, i, value)
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// IsAllowed reports whether the policy permits action on resource for a
+// request from sourceIP. resource is an ARN ("arn:aws:s3:::bucket/key");
+// sourceIP may be nil when unknown, in which case IpAddress conditions fail.
+// An explicit Deny overrides any Allow, and the default is deny.
+func (p *AccessKeyPolicy) IsAllowed(action, resource string, sourceIP net.IP) bool {
+	allowed := false
+	for i := range p.Statement {
+		st := &p.Statement[i]
+		if !st.matches(action, resource, sourceIP) {
+			continue
+		}
+		if st.Effect == PolicyEffectDeny {
+			return false
+		}
+		allowed = true
+	}
+	return allowed
+}
+
+// matches reports whether the statement applies to the request.
+func (st *PolicyStatement) matches(action, resource string, sourceIP net.IP) bool {
+	actionMatched := false
+	for _, pattern := range st.Action {
+		// Action names are case-insensitive in IAM
+		if wildcardMatch(strings.ToLower(pattern), strings.ToLower(action)) {
+			actionMatched = true
+			break
+		}
+	}
+	if !actionMatched {
+		return false
+	}
+
+	resourceMatched := false
+	for _, pattern := range st.Resource {
+		if pattern != "*" && !strings.HasPrefix(pattern, PolicyResourcePrefix) {
+			pattern = PolicyResourcePrefix + pattern
+		}
+		if wildcardMatch(pattern, resource) {
+			resourceMatched = true
+			break
+		}
+	}
+	if !resourceMatched {
+		return false
+	}
+
+	for op, keys := range st.Condition {
+		for _, values := range keys {
+			inRange := ipInRanges(sourceIP, values)
+			if op == PolicyConditionIPAddress && !inRange {
+				return false
+			}
+			if op == PolicyConditionNotIPAddress && inRange {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// ipInRanges reports whether ip is in any of the given IPs or CIDR ranges.
+func ipInRanges(ip net.IP, ranges []string) bool {
+	if ip == nil {
+		return false
+	}
+	for _, value := range ranges {
+		if ipNet := parseIPRange(value); ipNet != nil && ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseIPRange parses a CIDR range or a single IP address.
+func parseIPRange(value string) *net.IPNet {
+	if _, ipNet, err := net.ParseCIDR(value); err == nil {
+		return ipNet
+	}
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return nil
+	}
+	bits := 128
+	if ip4 := ip.To4(); ip4 != nil {
+		ip, bits = ip4, 32
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+}
+
+// wildcardMatch matches s against a pattern where '*' matches any sequence
+// of characters and '?' matches any single character.
+func wildcardMatch(pattern, s string) bool {
+	p, i := 0, 0
+	star, mark := -1, 0
+	for i < len(s) {
+		switch {
+		case p < len(pattern) && (pattern[p] == '?' || pattern[p] == s[i]):
+			p++
+			i++
+		case p < len(pattern) && pattern[p] == '*':
+			star, mark = p, i
+			p++
+		case star >= 0:
+			p = star + 1
+			mark++
+			i = mark
+		default:
+			return false
+		}
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
@@ -0,0 +1,187 @@
+// Package domain contains the core business entities for Alexander Storage.
+package domain
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ReplicationStatus is the replication state of an object version, reported
+// in the x-amz-replication-status header.
+type ReplicationStatus string
+
+const (
+	// ReplicationStatusNone means the version is not replicated.
+	ReplicationStatusNone ReplicationStatus = ""
+
+	// ReplicationStatusPending means the version is queued for replication.
+	ReplicationStatusPending ReplicationStatus = "PENDING"
+
+	// ReplicationStatusCompleted means the version was copied to the destination.
+	ReplicationStatusCompleted ReplicationStatus = "COMPLETED"
+
+	// ReplicationStatusFailed means replication gave up after repeated errors.
+	ReplicationStatusFailed ReplicationStatus = "FAILED"
+)
+
+// ReplicationRule selects the keys a bucket replicates.
+type ReplicationRule struct {
+	// ID is the user-defined identifier of the rule, unique within the bucket.
+	ID string `json:"id"`
+
+	// Enabled is false for rules with status Disabled.
+	Enabled bool `json:"enabled"`
+
+	// Priority decides between rules whose prefixes match the same key;
+	// the highest wins.
+	Priority int `json:"priority"`
+
+	// Prefix is the key prefix filter. Empty matches all keys.
+	Prefix string `json:"prefix"`
+
+	// DeleteMarkers replicates delete markers created under the rule.
+	DeleteMarkers bool `json:"delete_markers"`
+}
+
+// ReplicationConfig is a bucket's replication configuration. New object
+// versions matching a rule are copied asynchronously to a bucket on a
+// remote S3-compatible endpoint.
+type ReplicationConfig struct {
+	// BucketID is the ID of the source bucket.
+	BucketID int64 `json:"bucket_id"`
+
+	// Endpoint is the base URL of the destination, e.g. https://s3.example.com.
+	Endpoint string `json:"endpoint"`
+
+	// Region is the signing region of the destination.
+	Region string `json:"region"`
+
+	// DestinationBucket is the name of the bucket versions are copied to.
+	DestinationBucket string `json:"destination_bucket"`
+
+	// AccessKeyID is the access key used at the destination.
+	AccessKeyID string `json:"access_key_id"`
+
+	// EncryptedSecretKey is the destination secret key, encrypted with the
+	// server's encryption key.
+	EncryptedSecretKey string `json:"-"`
+
+	// Rules select the keys to replicate.
+	Rules []ReplicationRule `json:"rules"`
+
+	// CreatedAt is when replication was first configured.
+	CreatedAt time.Time `json:"created_at"`
+
+	// UpdatedAt is when the configuration last changed.
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// MaxReplicationRules is the maximum number of rules in a configuration.
+const MaxReplicationRules = 1000
+
+// Validate checks the configuration, except for the secret key.
+func (c *ReplicationConfig) Validate() error {
+	endpoint, err := url.Parse(c.Endpoint)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return fmt.Errorf("%w: endpoint must be an http or https URL", ErrInvalidReplicationConfig)
+	}
+	if err := ValidateBucketName(c.DestinationBucket); err != nil {
+		return fmt.Errorf("%w: destination bucket: %v", ErrInvalidReplicationConfig, err)
+	}
+	if c.AccessKeyID == "" {
+		return fmt.Errorf("%w: access key is required", ErrInvalidReplicationConfig)
+	}
+	if len(c.Rules) == 0 || len(c.Rules) > MaxReplicationRules {
+		return fmt.Errorf("%w: between 1 and %d rules are required", ErrInvalidReplicationConfig, MaxReplicationRules)
+	}
+
+	ids := make(map[string]bool, len(c.Rules))
+	for _, rule := range c.Rules {
+		if rule.ID == "" || len(rule.ID) > 255 {
+			return fmt.Errorf("%w: rule ID must be 1 to 255 characters", ErrInvalidReplicationConfig)
+		}
+		if ids[rule.ID] {
+			return fmt.Errorf("%w: duplicate rule ID %q", ErrInvalidReplicationConfig, rule.ID)
+		}
+		ids[rule.ID] = true
+	}
+	return nil
+}
+
+// RuleFor returns the enabled rule that applies to key, or nil if the key
+// is not replicated. Among matching rules the one with the highest
+// priority, then the longest prefix, wins.
+func (c *ReplicationConfig) RuleFor(key string) *ReplicationRule {
+	var best *ReplicationRule
+	for i := range c.Rules {
+		rule := &c.Rules[i]
+		if !rule.Enabled || !strings.HasPrefix(key, rule.Prefix) {
+			continue
+		}
+		if best == nil || rule.Priority > best.Priority ||
+			(rule.Priority == best.Priority && len(rule.Prefix) > len(best.Prefix)) {
+			best = rule
+		}
+	}
+	return best
+}
+
+// ReplicationOperation is the change a replication task applies to the destination.
+type ReplicationOperation string
+
+const (
+	// ReplicationOperationPut copies an object version.
+	ReplicationOperationPut ReplicationOperation = "put"
+
+	// ReplicationOperationDelete deletes the key, which creates a delete
+	// marker if the destination bucket is versioned.
+	ReplicationOperationDelete ReplicationOperation = "delete"
+)
+
+// ReplicationTask is a queued change to push to a bucket's replication
+// destination. Tasks are queued in the same transaction as the change, and
+// tasks for the same key are applied in queue order.
+type ReplicationTask struct {
+	// ID is the queue position.
+	ID int64 `json:"id"`
+
+	// BucketID is the source bucket.
+	BucketID int64 `json:"bucket_id"`
+
+	// ObjectID is the version to copy, or the delete marker whose status
+	// tracks a delete. 0 for deletes of unversioned objects.
+	ObjectID int64 `json:"object_id"`
+
+	// Key is the object key.
+	Key string `json:"key"`
+
+	// Operation is the change to apply.
+	Operation ReplicationOperation `json:"operation"`
+
+	// Attempts is the number of failed attempts so far.
+	Attempts int `json:"attempts"`
+
+	// NextAttemptAt is when the task is next due.
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+
+	// LastError is the error of the last failed attempt.
+	LastError string `json:"last_error,omitempty"`
+
+	// CreatedAt is when the change happened.
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewReplicationTask creates a task that is due immediately.
+func NewReplicationTask(bucketID int64, obj *Object, operation ReplicationOperation) *ReplicationTask {
+	now := time.Now().UTC()
+	return &ReplicationTask{
+		BucketID:      bucketID,
+		ObjectID:      obj.ID,
+		Key:           obj.Key,
+		Operation:     operation,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+}
@@ -18,6 +18,12 @@ const (
 
 	// MaxSessionDuration is the maximum allowed session duration.
 	MaxSessionDuration = 7 * 24 * time.Hour
+
+	// DefaultSessionIdleTimeout is how long a session stays valid without requests.
+	DefaultSessionIdleTimeout = 30 * time.Minute
+
+	// DefaultRememberDuration is how long a remember-me token can resume sessions.
+	DefaultRememberDuration = 30 * 24 * time.Hour
 )
 
 // Session represents an authenticated web dashboard session.
@@ -44,6 +50,18 @@ type Session struct {
 
 	// UserAgent is the client user agent string.
 	UserAgent string `json:"user_agent,omitempty"`
+
+	// CSRFToken must accompany every state-changing request of the session.
+	CSRFToken string `json:"-"`
+
+	// LastSeenAt is when the session was last used; the session expires
+	// after the idle timeout without requests.
+	LastSeenAt time.Time `json:"last_seen_at"`
+
+	// RememberToken resumes the session with a new Token after it expired,
+	// until RememberExpiresAt. Empty unless the user chose "remember me".
+	RememberToken     string     `json:"-"`
+	RememberExpiresAt *time.Time `json:"remember_expires_at,omitempty"`
 }
 
 // NewSession creates a new session for the given user.
@@ -52,16 +70,22 @@ func NewSession(userID int64, ipAddress, userAgent string) (*Session, error) {
 	if err != nil {
 		return nil, err
 	}
+	csrfToken, err := GenerateSessionToken()
+	if err != nil {
+		return nil, err
+	}
 
 	now := time.Now().UTC()
 	return &Session{
-		ID:        uuid.New(),
-		UserID:    userID,
-		Token:     token,
-		ExpiresAt: now.Add(DefaultSessionDuration),
-		CreatedAt: now,
-		IPAddress: ipAddress,
-		UserAgent: userAgent,
+		ID:         uuid.New(),
+		UserID:     userID,
+		Token:      token,
+		ExpiresAt:  now.Add(DefaultSessionDuration),
+		CreatedAt:  now,
+		IPAddress:  ipAddress,
+		UserAgent:  userAgent,
+		CSRFToken:  csrfToken,
+		LastSeenAt: now,
 	}, nil
 }
 
@@ -79,6 +103,17 @@ func (s *Session) IsExpired() bool {
 	return time.Now().UTC().After(s.ExpiresAt)
 }
 
+// IsIdle returns true if the session has not been used within timeout.
+// A zero timeout disables the check.
+func (s *Session) IsIdle(timeout time.Duration) bool {
+	return timeout > 0 && time.Now().UTC().After(s.LastSeenAt.Add(timeout))
+}
+
+// IsResumable returns true if the remember-me token can still resume the session.
+func (s *Session) IsResumable() bool {
+	return s.RememberExpiresAt != nil && time.Now().UTC().Before(*s.RememberExpiresAt)
+}
+
 // IsValid returns true if the session is still valid.
 func (s *Session) IsValid() bool {
 	return !s.IsExpired()
@@ -96,22 +131,26 @@ func (s *Session) TimeUntilExpiry() time.Duration {
 
 // SessionInfo contains minimal session information for display.
 type SessionInfo struct {
-	ID        uuid.UUID `json:"id"`
-	IPAddress string    `json:"ip_address"`
-	UserAgent string    `json:"user_agent"`
-	CreatedAt time.Time `json:"created_at"`
-	ExpiresAt time.Time `json:"expires_at"`
-	IsCurrent bool      `json:"is_current"`
+	ID         uuid.UUID `json:"id"`
+	IPAddress  string    `json:"ip_address"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	Remembered bool      `json:"remembered"`
+	IsCurrent  bool      `json:"is_current"`
 }
 
 // ToInfo converts a session to session info.
 func (s *Session) ToInfo(currentToken string) *SessionInfo {
 	return &SessionInfo{
-		ID:        s.ID,
-		IPAddress: s.IPAddress,
-		UserAgent: s.UserAgent,
-		CreatedAt: s.CreatedAt,
-		ExpiresAt: s.ExpiresAt,
-		IsCurrent: s.Token == currentToken,
+		ID:         s.ID,
+		IPAddress:  s.IPAddress,
+		UserAgent:  s.UserAgent,
+		CreatedAt:  s.CreatedAt,
+		ExpiresAt:  s.ExpiresAt,
+		LastSeenAt: s.LastSeenAt,
+		Remembered: s.RememberExpiresAt != nil,
+		IsCurrent:  s.Token == currentToken,
 	}
 }
@@ -0,0 +1,102 @@
+// Package domain contains the core business entities for Alexander Storage.
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StagedObject is an upload to a quarantine bucket that is waiting for an
+// approver, or a write waiting for its batch to be committed. It is
+// invisible to readers until promoted, when it becomes a regular object
+// version. Unpromoted uploads expire.
+type StagedObject struct {
+	// ID is the staging ID returned to the uploader (x-alexander-staging-id).
+	ID uuid.UUID `json:"id"`
+
+	// BucketID is the ID of the bucket the upload was written to.
+	BucketID int64 `json:"bucket_id"`
+
+	// Key is the object key the upload is promoted to.
+	Key string `json:"key"`
+
+	// ContentHash is the SHA-256 hash of the uploaded content.
+	// The staged upload holds one reference on this blob.
+	ContentHash string `json:"content_hash"`
+
+	// Size is the size of the upload in bytes.
+	Size int64 `json:"size"`
+
+	// ContentType is the MIME type of the upload.
+	ContentType string `json:"content_type"`
+
+	// ETag is the entity tag the promoted version will have.
+	ETag string `json:"etag"`
+
+	// StorageClass is the storage tier of the promoted version.
+	StorageClass StorageClass `json:"storage_class"`
+
+	// ACL is the canned ACL of the promoted version.
+	ACL ObjectACL `json:"acl"`
+
+	// ChecksumAlgorithm and Checksum are the additional checksum of the
+	// promoted version, if the upload had one.
+	ChecksumAlgorithm ChecksumAlgorithm `json:"checksum_algorithm,omitempty"`
+	Checksum          string            `json:"checksum,omitempty"`
+
+	// Metadata contains user-defined metadata (x-amz-meta-* headers).
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// PartSizes holds the part sizes of a multipart upload.
+	PartSizes []int64 `json:"part_sizes,omitempty"`
+
+	// BatchID is the batch the write belongs to; nil for quarantined uploads.
+	BatchID *uuid.UUID `json:"batch_id,omitempty"`
+
+	// EventType is the object event recorded when the upload is promoted.
+	EventType ObjectEventType `json:"event_type"`
+
+	// CreatedAt is when the upload was staged.
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewStagedObject stages obj, which must not be a delete marker.
+func NewStagedObject(obj *Object, eventType ObjectEventType) *StagedObject {
+	staged := &StagedObject{
+		ID:                uuid.New(),
+		BucketID:          obj.BucketID,
+		Key:               obj.Key,
+		Size:              obj.Size,
+		ContentType:       obj.ContentType,
+		ETag:              obj.ETag,
+		StorageClass:      obj.StorageClass,
+		ACL:               obj.ACL,
+		ChecksumAlgorithm: obj.ChecksumAlgorithm,
+		Checksum:          obj.Checksum,
+		Metadata:          obj.Metadata,
+		PartSizes:         obj.PartSizes,
+		EventType:         eventType,
+		CreatedAt:         obj.CreatedAt,
+	}
+	if obj.ContentHash != nil {
+		staged.ContentHash = *obj.ContentHash
+	}
+	return staged
+}
+
+// Object returns the object version the upload becomes when promoted.
+func (s *StagedObject) Object() *Object {
+	obj := NewObject(s.BucketID, s.Key, s.ContentHash, s.ContentType, s.ETag, s.Size)
+	obj.StorageClass = s.StorageClass
+	if s.ACL != "" {
+		obj.ACL = s.ACL
+	}
+	obj.ChecksumAlgorithm = s.ChecksumAlgorithm
+	obj.Checksum = s.Checksum
+	obj.PartSizes = s.PartSizes
+	if s.Metadata != nil {
+		obj.Metadata = s.Metadata
+	}
+	return obj
+}
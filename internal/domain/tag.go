@@ -0,0 +1,53 @@
+// Package domain contains the core business entities for Alexander Storage.
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Bucket tag limits, as in S3.
+const (
+	// MaxBucketTags is the maximum number of tags on a bucket.
+	MaxBucketTags = 50
+
+	// MaxTagKeyLength is the maximum length of a tag key, in characters.
+	MaxTagKeyLength = 128
+
+	// MaxTagValueLength is the maximum length of a tag value, in characters.
+	MaxTagValueLength = 256
+)
+
+// Tag is a key-value pair attached to a resource, such as a cost center.
+type Tag struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// ValidateBucketTags checks a bucket's tag set: at most MaxBucketTags tags
+// with unique, non-empty keys within the length limits. Keys starting with
+// "aws:" are reserved.
+func ValidateBucketTags(tags []Tag) error {
+	if len(tags) > MaxBucketTags {
+		return fmt.Errorf("%w: at most %d tags are allowed", ErrInvalidTag, MaxBucketTags)
+	}
+
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		switch {
+		case tag.Key == "":
+			return fmt.Errorf("%w: tag keys cannot be empty", ErrInvalidTag)
+		case utf8.RuneCountInString(tag.Key) > MaxTagKeyLength:
+			return fmt.Errorf("%w: tag keys can be at most %d characters", ErrInvalidTag, MaxTagKeyLength)
+		case utf8.RuneCountInString(tag.Value) > MaxTagValueLength:
+			return fmt.Errorf("%w: tag values can be at most %d characters", ErrInvalidTag, MaxTagValueLength)
+		case strings.HasPrefix(strings.ToLower(tag.Key), "aws:"):
+			return fmt.Errorf("%w: tag keys starting with aws: are reserved", ErrInvalidTag)
+		case seen[tag.Key]:
+			return fmt.Errorf("%w: duplicate tag key %q", ErrInvalidTag, tag.Key)
+		}
+		seen[tag.Key] = true
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"regexp"
+	"time"
+)
+
+const (
+	// DefaultTenantID is the tenant of users and buckets that were not
+	// created in another one, including all those that existed before
+	// tenants were introduced.
+	DefaultTenantID int64 = 1
+
+	// DefaultTenantName is the name of the default tenant.
+	DefaultTenantName = "default"
+)
+
+// tenantNameRegex validates tenant names: 3-32 lowercase letters, numbers
+// and hyphens, starting with a letter and not ending with a hyphen, so that
+// "<tenant>-" is a valid bucket name prefix.
+var tenantNameRegex = regexp.MustCompile(`^[a-z][a-z0-9-]{1,30}[a-z0-9]$`)
+
+// Tenant groups users, with their buckets and access keys, under common
+// quotas and rate limits. Users only ever own buckets of their own tenant.
+type Tenant struct {
+	// ID is the unique identifier for the tenant (auto-generated).
+	ID int64 `json:"id"`
+
+	// Name is the unique tenant name.
+	Name string `json:"name"`
+
+	// Limits are the quotas and rate limits of the tenant.
+	Limits TenantLimits `json:"limits"`
+
+	// CreatedAt is the timestamp when the tenant was created.
+	CreatedAt time.Time `json:"created_at"`
+
+	// UpdatedAt is the timestamp when the tenant was last updated.
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TenantLimits are the quotas and rate limits of a tenant. Zero means
+// unlimited.
+type TenantLimits struct {
+	// MaxBuckets is the maximum number of buckets.
+	MaxBuckets int `json:"max_buckets"`
+
+	// MaxStorageBytes is the maximum total size of all object versions in
+	// the tenant's buckets.
+	MaxStorageBytes int64 `json:"max_storage_bytes"`
+
+	// RequestsPerSecond is the rate at which the tenant's access keys may
+	// make requests, together.
+	RequestsPerSecond float64 `json:"requests_per_second"`
+
+	// BurstSize is the number of requests that may be made at once above
+	// RequestsPerSecond.
+	BurstSize int `json:"burst_size"`
+}
+
+// Validate checks that no limit is negative and that a request rate comes
+// with a burst size.
+func (l TenantLimits) Validate() error {
+	if l.MaxBuckets < 0 || l.MaxStorageBytes < 0 || l.RequestsPerSecond < 0 || l.BurstSize < 0 {
+		return ErrInvalidTenantLimits
+	}
+	if l.RequestsPerSecond > 0 && l.BurstSize == 0 {
+		return ErrInvalidTenantLimits
+	}
+	return nil
+}
+
+// NewTenant creates a new Tenant without limits.
+func NewTenant(name string) *Tenant {
+	now := time.Now().UTC()
+	return &Tenant{
+		Name:      name,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// ValidateTenantName checks if a tenant name is well-formed.
+func ValidateTenantName(name string) error {
+	if !tenantNameRegex.MatchString(name) {
+		return ErrInvalidTenantName
+	}
+	return nil
+}
+
+// IsDefault returns true for the default tenant.
+func (t *Tenant) IsDefault() bool {
+	return t.ID == DefaultTenantID
+}
+
+// BucketPrefix returns the prefix of bucket names in the tenant's namespace.
+func (t *Tenant) BucketPrefix() string {
+	return t.Name + "-"
+}
+
+// TenantUsage is what a tenant currently uses of its quotas.
+type TenantUsage struct {
+	// Users is the number of users in the tenant.
+	Users int64 `json:"users"`
+
+	// Buckets is the number of buckets in the tenant.
+	Buckets int64 `json:"buckets"`
+
+	// StorageBytes is the total size of all object versions in the tenant's
+	// buckets, not counting delete markers.
+	StorageBytes int64 `json:"storage_bytes"`
+}
@@ -0,0 +1,50 @@
+// Package domain contains the core business entities for Alexander Storage.
+package domain
+
+import "time"
+
+// AnonymousAccessKeyID is the access key recorded for unauthenticated requests
+// (public buckets). It cannot collide with a generated access key ID.
+const AnonymousAccessKeyID = "anonymous"
+
+// UsageRecord holds the usage of one bucket by one access key during one hour.
+// Storage snapshots are not attributable to an access key and are recorded
+// with an empty AccessKeyID.
+type UsageRecord struct {
+	// Hour is the start of the hour (UTC) the counters belong to.
+	Hour time.Time `json:"hour"`
+
+	// BucketName is the name of the bucket.
+	BucketName string `json:"bucket_name"`
+
+	// AccessKeyID is the access key that made the requests.
+	AccessKeyID string `json:"access_key_id"`
+
+	// BytesIn is the number of request body bytes received (uploads).
+	BytesIn int64 `json:"bytes_in"`
+
+	// BytesOut is the number of response body bytes sent (downloads).
+	BytesOut int64 `json:"bytes_out"`
+
+	// Requests is the number of requests made.
+	Requests int64 `json:"requests"`
+
+	// BytesStored is the logical size of all object versions in the bucket
+	// at the time of the hourly snapshot.
+	BytesStored int64 `json:"bytes_stored"`
+}
+
+// UsageHour truncates t to the start of its hour in UTC.
+func UsageHour(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Hour)
+}
+
+// StorageSample is the logical bytes stored in all buckets at an hourly
+// storage snapshot.
+type StorageSample struct {
+	// Hour is the start of the hour (UTC) of the snapshot.
+	Hour time.Time `json:"hour"`
+
+	// BytesStored is the logical size of all object versions.
+	BytesStored int64 `json:"bytes_stored"`
+}
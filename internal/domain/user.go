@@ -4,15 +4,79 @@
 package domain
 
 import (
+	"fmt"
 	"time"
 )
 
+// UserRole is a user's role in the dashboard and admin APIs.
+// Each role includes the permissions of the roles below it.
+type UserRole string
+
+const (
+	// RoleNone grants no dashboard or admin API access (S3 API only).
+	RoleNone UserRole = ""
+
+	// RoleReadOnly may view buckets, settings and usage.
+	RoleReadOnly UserRole = "read-only"
+
+	// RoleOperator may additionally change bucket settings such as ACLs
+	// and lifecycle rules.
+	RoleOperator UserRole = "operator"
+
+	// RoleAdmin may additionally manage users and their roles.
+	RoleAdmin UserRole = "admin"
+)
+
+// roleRanks orders the roles from least to most privileged.
+var roleRanks = map[UserRole]int{
+	RoleNone:     0,
+	RoleReadOnly: 1,
+	RoleOperator: 2,
+	RoleAdmin:    3,
+}
+
+// ParseUserRole parses a role name. "none" and "" both mean RoleNone.
+func ParseUserRole(s string) (UserRole, error) {
+	if s == "none" {
+		return RoleNone, nil
+	}
+	role := UserRole(s)
+	if !role.IsValid() {
+		return RoleNone, fmt.Errorf("%w: %q (must be none, read-only, operator or admin)", ErrInvalidUserRole, s)
+	}
+	return role, nil
+}
+
+// IsValid returns true if r is a known role.
+func (r UserRole) IsValid() bool {
+	_, ok := roleRanks[r]
+	return ok
+}
+
+// Includes returns true if r grants at least the permissions of required.
+func (r UserRole) Includes(required UserRole) bool {
+	rank, ok := roleRanks[r]
+	return ok && rank >= roleRanks[required]
+}
+
+// String returns the role name, "none" for RoleNone.
+func (r UserRole) String() string {
+	if r == RoleNone {
+		return "none"
+	}
+	return string(r)
+}
+
 // User represents a registered user in the system.
 // Users own buckets and can have multiple access keys for API authentication.
 type User struct {
 	// ID is the unique identifier for the user (auto-generated).
 	ID int64 `json:"id"`
 
+	// TenantID is the tenant the user, their buckets and access keys
+	// belong to.
+	TenantID int64 `json:"tenant_id"`
+
 	// Username is the unique username for login and display.
 	// Constraints: 3-255 characters.
 	Username string `json:"username"`
@@ -28,9 +92,13 @@ type User struct {
 	// Inactive users cannot authenticate or perform any operations.
 	IsActive bool `json:"is_active"`
 
-	// IsAdmin indicates whether the user has administrative privileges.
-	// Admins can manage other users and perform system-wide operations.
-	IsAdmin bool `json:"is_admin"`
+	// Role controls access to the dashboard and admin APIs.
+	// Users without a role can only use the S3 API.
+	Role UserRole `json:"role"`
+
+	// MustChangePassword forces the user to choose a new password at the
+	// next dashboard login, for example after an administrator reset it.
+	MustChangePassword bool `json:"must_change_password"`
 
 	// CreatedAt is the timestamp when the user was created.
 	CreatedAt time.Time `json:"created_at"`
@@ -43,16 +111,22 @@ type User struct {
 func NewUser(username, email, passwordHash string) *User {
 	now := time.Now().UTC()
 	return &User{
+		TenantID:     DefaultTenantID,
 		Username:     username,
 		Email:        email,
 		PasswordHash: passwordHash,
 		IsActive:     true,
-		IsAdmin:      false,
+		Role:         RoleNone,
 		CreatedAt:    now,
 		UpdatedAt:    now,
 	}
 }
 
+// IsAdmin returns true if the user has the admin role.
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
+
 // CanAuthenticate returns true if the user is allowed to authenticate.
 func (u *User) CanAuthenticate() bool {
 	return u.IsActive
@@ -0,0 +1,188 @@
+// Package domain contains the core business entities for Alexander Storage.
+package domain
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// MaxWebsiteRoutingRules is the maximum number of routing rules in a configuration.
+const MaxWebsiteRoutingRules = 50
+
+// WebsiteRedirectAll redirects every request of a website to another host.
+type WebsiteRedirectAll struct {
+	// HostName is the host requests are redirected to.
+	HostName string `json:"host_name"`
+
+	// Protocol is http or https. Empty keeps the protocol of the request.
+	Protocol string `json:"protocol,omitempty"`
+}
+
+// WebsiteCondition selects the requests a routing rule redirects. A rule
+// without an error code applies before the object is looked up; a rule
+// with one applies when the lookup fails with that status.
+type WebsiteCondition struct {
+	// KeyPrefixEquals matches keys with this prefix. Empty matches all keys.
+	KeyPrefixEquals string `json:"key_prefix_equals,omitempty"`
+
+	// HTTPErrorCodeReturnedEquals matches lookups failing with this status.
+	HTTPErrorCodeReturnedEquals int `json:"http_error_code_returned_equals,omitempty"`
+}
+
+// WebsiteRedirect is where a routing rule sends a request.
+type WebsiteRedirect struct {
+	// HostName replaces the host of the request.
+	HostName string `json:"host_name,omitempty"`
+
+	// Protocol is http or https. Empty keeps the protocol of the request.
+	Protocol string `json:"protocol,omitempty"`
+
+	// ReplaceKeyPrefixWith replaces the prefix matched by the condition.
+	ReplaceKeyPrefixWith string `json:"replace_key_prefix_with,omitempty"`
+
+	// ReplaceKeyWith replaces the whole key.
+	ReplaceKeyWith string `json:"replace_key_with,omitempty"`
+
+	// HTTPRedirectCode is the 3xx status of the redirect. 0 means 301.
+	HTTPRedirectCode int `json:"http_redirect_code,omitempty"`
+}
+
+// WebsiteRoutingRule redirects the requests matching its condition.
+type WebsiteRoutingRule struct {
+	Condition WebsiteCondition `json:"condition"`
+	Redirect  WebsiteRedirect  `json:"redirect"`
+}
+
+// WebsiteConfig is a bucket's static website configuration. Requests to the
+// website endpoint of a public-read bucket are answered with its objects,
+// resolving directory keys to their index document.
+type WebsiteConfig struct {
+	// BucketID is the ID of the bucket.
+	BucketID int64 `json:"-"`
+
+	// IndexDocument is the suffix appended to keys ending in "/", e.g.
+	// index.html. Required unless RedirectAllRequestsTo is set.
+	IndexDocument string `json:"index_document,omitempty"`
+
+	// ErrorDocument is the key of the object served with 4xx errors.
+	ErrorDocument string `json:"error_document,omitempty"`
+
+	// RedirectAllRequestsTo, if set, redirects every request and excludes
+	// all other settings.
+	RedirectAllRequestsTo *WebsiteRedirectAll `json:"redirect_all_requests_to,omitempty"`
+
+	// RoutingRules are checked in order; the first match wins.
+	RoutingRules []WebsiteRoutingRule `json:"routing_rules,omitempty"`
+
+	// CreatedAt is when the website was first configured.
+	CreatedAt time.Time `json:"-"`
+
+	// UpdatedAt is when the configuration last changed.
+	UpdatedAt time.Time `json:"-"`
+}
+
+// Validate checks the configuration.
+func (c *WebsiteConfig) Validate() error {
+	if c.RedirectAllRequestsTo != nil {
+		if c.IndexDocument != "" || c.ErrorDocument != "" || len(c.RoutingRules) > 0 {
+			return fmt.Errorf("%w: RedirectAllRequestsTo cannot be combined with other settings", ErrInvalidWebsiteConfig)
+		}
+		if c.RedirectAllRequestsTo.HostName == "" {
+			return fmt.Errorf("%w: RedirectAllRequestsTo requires a HostName", ErrInvalidWebsiteConfig)
+		}
+		return validateWebsiteProtocol(c.RedirectAllRequestsTo.Protocol)
+	}
+
+	if c.IndexDocument == "" || strings.Contains(c.IndexDocument, "/") {
+		return fmt.Errorf("%w: IndexDocument suffix is required and cannot contain a slash", ErrInvalidWebsiteConfig)
+	}
+	if len(c.RoutingRules) > MaxWebsiteRoutingRules {
+		return fmt.Errorf("%w: at most %d routing rules are allowed", ErrInvalidWebsiteConfig, MaxWebsiteRoutingRules)
+	}
+	for _, rule := range c.RoutingRules {
+		if code := rule.Condition.HTTPErrorCodeReturnedEquals; code != 0 && (code < 400 || code > 599) {
+			return fmt.Errorf("%w: HttpErrorCodeReturnedEquals must be a 4xx or 5xx status", ErrInvalidWebsiteConfig)
+		}
+		redirect := rule.Redirect
+		if redirect.ReplaceKeyPrefixWith != "" && redirect.ReplaceKeyWith != "" {
+			return fmt.Errorf("%w: ReplaceKeyPrefixWith and ReplaceKeyWith are exclusive", ErrInvalidWebsiteConfig)
+		}
+		if code := redirect.HTTPRedirectCode; code != 0 && (code < 300 || code > 399) {
+			return fmt.Errorf("%w: HttpRedirectCode must be a 3xx status", ErrInvalidWebsiteConfig)
+		}
+		if err := validateWebsiteProtocol(redirect.Protocol); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateWebsiteProtocol(protocol string) error {
+	if protocol != "" && protocol != "http" && protocol != "https" {
+		return fmt.Errorf("%w: Protocol must be http or https", ErrInvalidWebsiteConfig)
+	}
+	return nil
+}
+
+// IndexKey returns the key served for key: the index document of the
+// "directory" for the root and keys ending in "/", otherwise key itself.
+func (c *WebsiteConfig) IndexKey(key string) string {
+	if key == "" || strings.HasSuffix(key, "/") {
+		return key + c.IndexDocument
+	}
+	return key
+}
+
+// RuleFor returns the first routing rule matching key, or nil. With status
+// 0 only rules without an error code condition match; otherwise only rules
+// for that status do.
+func (c *WebsiteConfig) RuleFor(key string, status int) *WebsiteRoutingRule {
+	for i := range c.RoutingRules {
+		rule := &c.RoutingRules[i]
+		if rule.Condition.HTTPErrorCodeReturnedEquals == status && strings.HasPrefix(key, rule.Condition.KeyPrefixEquals) {
+			return rule
+		}
+	}
+	return nil
+}
+
+// Location returns the URL a request for key is redirected to. scheme and
+// host are those of the request, kept unless the rule replaces them.
+func (r *WebsiteRoutingRule) Location(key, scheme, host string) string {
+	switch {
+	case r.Redirect.ReplaceKeyWith != "":
+		key = r.Redirect.ReplaceKeyWith
+	case r.Redirect.ReplaceKeyPrefixWith != "" || r.Condition.KeyPrefixEquals != "":
+		key = r.Redirect.ReplaceKeyPrefixWith + strings.TrimPrefix(key, r.Condition.KeyPrefixEquals)
+	}
+	if r.Redirect.Protocol != "" {
+		scheme = r.Redirect.Protocol
+	}
+	if r.Redirect.HostName != "" {
+		host = r.Redirect.HostName
+	}
+	return websiteURL(scheme, host, key)
+}
+
+// StatusCode returns the status of the redirect.
+func (r *WebsiteRoutingRule) StatusCode() int {
+	if r.Redirect.HTTPRedirectCode != 0 {
+		return r.Redirect.HTTPRedirectCode
+	}
+	return 301
+}
+
+// Location returns the URL a request for key is redirected to.
+func (r *WebsiteRedirectAll) Location(key, scheme string) string {
+	if r.Protocol != "" {
+		scheme = r.Protocol
+	}
+	return websiteURL(scheme, r.HostName, key)
+}
+
+func websiteURL(scheme, host, key string) string {
+	u := url.URL{Scheme: scheme, Host: host, Path: "/" + key}
+	return u.String()
+}
@@ -0,0 +1,878 @@
+// Package handler provides HTTP handlers for Alexander Storage API.
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/service"
+)
+
+// AdminPathPrefix is the path prefix of the admin REST API. The API is
+// served under the same paths on the S3 listener and on its own listener.
+const AdminPathPrefix = "/_alexander/admin/"
+
+// maxAdminBodySize bounds the JSON request bodies of the admin API.
+const maxAdminBodySize = 1 << 20
+
+// BlobStatsProvider reports the number and size of stored blobs.
+type BlobStatsProvider interface {
+	GetStats(ctx context.Context) (*domain.BlobStats, error)
+}
+
+// AdminHandler serves the admin REST API, through which provisioning tools
+// manage tenants, users, access keys and buckets, trigger garbage collection and read
+// storage statistics. Like the manifest API it uses a dedicated bearer token
+// instead of SigV4; on its own listener, verified client certificates are
+// accepted as well.
+type AdminHandler struct {
+	userService      *service.UserService
+	iamService       *service.IAMService
+	bucketService    *service.BucketService
+	gc               *service.GarbageCollector
+	events           *EventHandler
+	readOnly         *service.ReadOnlyService
+	tenants          *service.TenantService
+	blobStats        BlobStatsProvider
+	token            string
+	allowClientCerts bool
+	mux              *http.ServeMux
+	logger           zerolog.Logger
+}
+
+// AdminHandlerConfig contains the dependencies of the admin API.
+type AdminHandlerConfig struct {
+	UserService   *service.UserService
+	IAMService    *service.IAMService
+	BucketService *service.BucketService
+	GC            *service.GarbageCollector // Optional; nil when garbage collection is disabled
+	Events        *EventHandler             // Optional; nil when change events are disabled
+	ReadOnly      *service.ReadOnlyService
+	Tenants       *service.TenantService
+	BlobStats     BlobStatsProvider
+
+	// Token is the bearer token clients must present.
+	Token string
+
+	// AllowClientCerts accepts requests over TLS connections with a client
+	// certificate, which the listener must have verified, without a token.
+	AllowClientCerts bool
+
+	Logger zerolog.Logger
+}
+
+// NewAdminHandler creates a new AdminHandler.
+func NewAdminHandler(config AdminHandlerConfig) *AdminHandler {
+	h := &AdminHandler{
+		userService:      config.UserService,
+		iamService:       config.IAMService,
+		bucketService:    config.BucketService,
+		gc:               config.GC,
+		events:           config.Events,
+		readOnly:         config.ReadOnly,
+		tenants:          config.Tenants,
+		blobStats:        config.BlobStats,
+		token:            config.Token,
+		allowClientCerts: config.AllowClientCerts,
+		logger:           config.Logger.With().Str("handler", "admin").Logger(),
+	}
+
+	p := strings.TrimSuffix(AdminPathPrefix, "/")
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+p+"/tenants", h.listTenants)
+	mux.HandleFunc("POST "+p+"/tenants", h.createTenant)
+	mux.HandleFunc("GET "+p+"/tenants/{name}", h.getTenant)
+	mux.HandleFunc("PATCH "+p+"/tenants/{name}", h.updateTenant)
+	mux.HandleFunc("DELETE "+p+"/tenants/{name}", h.deleteTenant)
+	mux.HandleFunc("GET "+p+"/users", h.listUsers)
+	mux.HandleFunc("POST "+p+"/users", h.createUser)
+	mux.HandleFunc("GET "+p+"/users/{id}", h.getUser)
+	mux.HandleFunc("PATCH "+p+"/users/{id}", h.updateUser)
+	mux.HandleFunc("DELETE "+p+"/users/{id}", h.deleteUser)
+	mux.HandleFunc("GET "+p+"/users/{id}/keys", h.listAccessKeys)
+	mux.HandleFunc("POST "+p+"/users/{id}/keys", h.createAccessKey)
+	mux.HandleFunc("GET "+p+"/keys/{accessKeyID}", h.getAccessKey)
+	mux.HandleFunc("PATCH "+p+"/keys/{accessKeyID}", h.updateAccessKey)
+	mux.HandleFunc("DELETE "+p+"/keys/{accessKeyID}", h.deleteAccessKey)
+	mux.HandleFunc("GET "+p+"/buckets", h.listBuckets)
+	mux.HandleFunc("POST "+p+"/buckets", h.createBucket)
+	mux.HandleFunc("GET "+p+"/buckets/{name}", h.getBucket)
+	mux.HandleFunc("PATCH "+p+"/buckets/{name}", h.updateBucket)
+	mux.HandleFunc("DELETE "+p+"/buckets/{name}", h.deleteBucket)
+	mux.HandleFunc("GET "+p+"/gc", h.gcStatus)
+	mux.HandleFunc("POST "+p+"/gc", h.runGC)
+	mux.HandleFunc("GET "+p+"/stats", h.stats)
+	mux.HandleFunc("GET "+p+"/events", h.watchEvents)
+	mux.HandleFunc("GET "+p+"/read-only", h.getReadOnly)
+	mux.HandleFunc("PUT "+p+"/read-only", h.setReadOnly)
+	h.mux = mux
+
+	return h
+}
+
+// ServeHTTP authenticates the request and dispatches it. Unknown paths
+// return 404 and known paths with the wrong method return 405.
+func (h *AdminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.authorized(r) {
+		h.logger.Warn().Str("remote_addr", r.RemoteAddr).Msg("rejected admin request with invalid token")
+		w.Header().Set("WWW-Authenticate", `Bearer realm="alexander-admin"`)
+		writeJSONError(w, http.StatusUnauthorized, "invalid or missing bearer token")
+		return
+	}
+	h.mux.ServeHTTP(w, r)
+}
+
+// authorized checks the bearer token or, if allowed, the client certificate.
+func (h *AdminHandler) authorized(r *http.Request) bool {
+	if h.allowClientCerts && r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+		return true
+	}
+	return bearerAuthorized(r, h.token)
+}
+
+// =============================================================================
+// Tenants
+// =============================================================================
+
+// createTenantRequest is the JSON body of POST /tenants.
+type createTenantRequest struct {
+	Name   string              `json:"name"`
+	Limits domain.TenantLimits `json:"limits"`
+}
+
+// updateTenantRequest is the JSON body of PATCH /tenants/{name}. Omitted
+// limits are left unchanged; zero removes a limit.
+type updateTenantRequest struct {
+	MaxBuckets        *int     `json:"max_buckets"`
+	MaxStorageBytes   *int64   `json:"max_storage_bytes"`
+	RequestsPerSecond *float64 `json:"requests_per_second"`
+	BurstSize         *int     `json:"burst_size"`
+}
+
+// tenantResponse is the JSON body of GET /tenants/{name}.
+type tenantResponse struct {
+	*domain.Tenant
+	Usage *domain.TenantUsage `json:"usage"`
+}
+
+// tenantListResponse is the JSON body of GET /tenants.
+type tenantListResponse struct {
+	Tenants []*domain.Tenant `json:"tenants"`
+}
+
+func (h *AdminHandler) listTenants(w http.ResponseWriter, r *http.Request) {
+	tenants, err := h.tenants.List(r.Context())
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	if tenants == nil {
+		tenants = []*domain.Tenant{}
+	}
+	writeJSON(w, http.StatusOK, tenantListResponse{Tenants: tenants})
+}
+
+func (h *AdminHandler) createTenant(w http.ResponseWriter, r *http.Request) {
+	var req createTenantRequest
+	if !decodeAdminRequest(w, r, &req) {
+		return
+	}
+	tenant, err := h.tenants.Create(r.Context(), service.CreateTenantInput{Name: req.Name, Limits: req.Limits})
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, tenant)
+}
+
+func (h *AdminHandler) getTenant(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	tenant, err := h.tenants.Get(r.Context(), name)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	usage, err := h.tenants.Usage(r.Context(), name)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, tenantResponse{Tenant: tenant, Usage: usage})
+}
+
+func (h *AdminHandler) updateTenant(w http.ResponseWriter, r *http.Request) {
+	var req updateTenantRequest
+	if !decodeAdminRequest(w, r, &req) {
+		return
+	}
+	if _, err := h.tenants.UpdateLimits(r.Context(), service.UpdateTenantLimitsInput{
+		Name:              r.PathValue("name"),
+		MaxBuckets:        req.MaxBuckets,
+		MaxStorageBytes:   req.MaxStorageBytes,
+		RequestsPerSecond: req.RequestsPerSecond,
+		BurstSize:         req.BurstSize,
+	}); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.getTenant(w, r)
+}
+
+func (h *AdminHandler) deleteTenant(w http.ResponseWriter, r *http.Request) {
+	if err := h.tenants.Delete(r.Context(), r.PathValue("name")); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// =============================================================================
+// Users
+// =============================================================================
+
+// createUserRequest is the JSON body of POST /users.
+type createUserRequest struct {
+	Username           string          `json:"username"`
+	Email              string          `json:"email"`
+	Password           string          `json:"password"`
+	Role               domain.UserRole `json:"role"`
+	MustChangePassword bool            `json:"must_change_password"`
+	Tenant             string          `json:"tenant"`
+}
+
+// updateUserRequest is the JSON body of PATCH /users/{id}. Omitted fields
+// are left unchanged. Changing the tenant moves the user's buckets with them.
+type updateUserRequest struct {
+	Role     *string `json:"role"`
+	IsActive *bool   `json:"is_active"`
+	Tenant   *string `json:"tenant"`
+}
+
+// userListResponse is the JSON body of GET /users.
+type userListResponse struct {
+	Users      []*domain.User `json:"users"`
+	TotalCount int64          `json:"total_count"`
+}
+
+func (h *AdminHandler) listUsers(w http.ResponseWriter, r *http.Request) {
+	limit, offset, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+	input := service.ListUsersInput{Limit: limit, Offset: offset}
+	if name := r.URL.Query().Get("tenant"); name != "" {
+		tenant, err := h.tenants.Get(r.Context(), name)
+		if err != nil {
+			h.writeError(w, err)
+			return
+		}
+		input.TenantID = tenant.ID
+	}
+	output, err := h.userService.List(r.Context(), input)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, userListResponse{Users: output.Users, TotalCount: output.TotalCount})
+}
+
+func (h *AdminHandler) createUser(w http.ResponseWriter, r *http.Request) {
+	var req createUserRequest
+	if !decodeAdminRequest(w, r, &req) {
+		return
+	}
+	if !req.Role.IsValid() {
+		writeJSONError(w, http.StatusBadRequest, domain.ErrInvalidUserRole.Error())
+		return
+	}
+	tenantID := domain.DefaultTenantID
+	if req.Tenant != "" {
+		tenant, err := h.tenants.Get(r.Context(), req.Tenant)
+		if err != nil {
+			h.writeError(w, err)
+			return
+		}
+		tenantID = tenant.ID
+	}
+	output, err := h.userService.Create(r.Context(), service.CreateUserInput{
+		Username: req.Username,
+		Email:    req.Email,
+		Password: req.Password,
+		Role:     req.Role,
+		TenantID: tenantID,
+
+		MustChangePassword: req.MustChangePassword,
+	})
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, output.User)
+}
+
+func (h *AdminHandler) getUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := userIDParam(w, r)
+	if !ok {
+		return
+	}
+	user, err := h.userService.GetByID(r.Context(), id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, user)
+}
+
+func (h *AdminHandler) updateUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := userIDParam(w, r)
+	if !ok {
+		return
+	}
+	var req updateUserRequest
+	if !decodeAdminRequest(w, r, &req) {
+		return
+	}
+
+	if req.Role != nil {
+		role, err := domain.ParseUserRole(*req.Role)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := h.userService.SetRole(r.Context(), id, role); err != nil {
+			h.writeError(w, err)
+			return
+		}
+	}
+	if req.IsActive != nil {
+		if err := h.userService.SetActive(r.Context(), id, *req.IsActive); err != nil {
+			h.writeError(w, err)
+			return
+		}
+	}
+	if req.Tenant != nil {
+		if err := h.tenants.MoveUser(r.Context(), id, *req.Tenant); err != nil {
+			h.writeError(w, err)
+			return
+		}
+	}
+
+	h.getUser(w, r)
+}
+
+func (h *AdminHandler) deleteUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := userIDParam(w, r)
+	if !ok {
+		return
+	}
+	if err := h.userService.Delete(r.Context(), id); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// =============================================================================
+// Access Keys
+// =============================================================================
+
+// createAccessKeyRequest is the JSON body of POST /users/{id}/keys.
+type createAccessKeyRequest struct {
+	Description string                  `json:"description"`
+	ExpiresAt   *time.Time              `json:"expires_at"`
+	Policy      *domain.AccessKeyPolicy `json:"policy"`
+}
+
+// createAccessKeyResponse is the JSON body of a created access key. The
+// secret key is only ever returned here.
+type createAccessKeyResponse struct {
+	*domain.AccessKey
+	SecretKey string `json:"secret_key"`
+}
+
+// updateAccessKeyRequest is the JSON body of PATCH /keys/{accessKeyID}.
+type updateAccessKeyRequest struct {
+	Status domain.AccessKeyStatus `json:"status"`
+}
+
+func (h *AdminHandler) listAccessKeys(w http.ResponseWriter, r *http.Request) {
+	id, ok := userIDParam(w, r)
+	if !ok {
+		return
+	}
+	if _, err := h.userService.GetByID(r.Context(), id); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	keys, err := h.iamService.ListAccessKeys(r.Context(), service.ListAccessKeysInput{
+		UserID:     id,
+		ActiveOnly: r.URL.Query().Get("active") == "true",
+	})
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"access_keys": keys})
+}
+
+func (h *AdminHandler) createAccessKey(w http.ResponseWriter, r *http.Request) {
+	id, ok := userIDParam(w, r)
+	if !ok {
+		return
+	}
+	var req createAccessKeyRequest
+	if !decodeAdminRequest(w, r, &req) {
+		return
+	}
+	output, err := h.iamService.CreateAccessKey(r.Context(), service.CreateAccessKeyInput{
+		UserID:      id,
+		Description: req.Description,
+		ExpiresAt:   req.ExpiresAt,
+		Policy:      req.Policy,
+	})
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, createAccessKeyResponse{AccessKey: output.AccessKey, SecretKey: output.SecretKey})
+}
+
+func (h *AdminHandler) getAccessKey(w http.ResponseWriter, r *http.Request) {
+	key, err := h.iamService.GetAccessKey(r.Context(), r.PathValue("accessKeyID"))
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, key)
+}
+
+func (h *AdminHandler) updateAccessKey(w http.ResponseWriter, r *http.Request) {
+	var req updateAccessKeyRequest
+	if !decodeAdminRequest(w, r, &req) {
+		return
+	}
+
+	accessKeyID := r.PathValue("accessKeyID")
+	var err error
+	switch req.Status {
+	case domain.AccessKeyStatusActive:
+		err = h.iamService.ActivateAccessKey(r.Context(), accessKeyID)
+	case domain.AccessKeyStatusInactive:
+		err = h.iamService.DeactivateAccessKey(r.Context(), accessKeyID)
+	default:
+		writeJSONError(w, http.StatusBadRequest, "status must be Active or Inactive")
+		return
+	}
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.getAccessKey(w, r)
+}
+
+func (h *AdminHandler) deleteAccessKey(w http.ResponseWriter, r *http.Request) {
+	if err := h.iamService.DeleteAccessKey(r.Context(), r.PathValue("accessKeyID")); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// =============================================================================
+// Buckets
+// =============================================================================
+
+// createBucketRequest is the JSON body of POST /buckets.
+type createBucketRequest struct {
+	Name      string `json:"name"`
+	OwnerID   int64  `json:"owner_id"`
+	Region    string `json:"region"`
+	Residency string `json:"residency"`
+}
+
+// updateBucketRequest is the JSON body of PATCH /buckets/{name}. Omitted
+// fields are left unchanged.
+type updateBucketRequest struct {
+	Versioning *domain.VersioningStatus `json:"versioning"`
+	Quarantine *bool                    `json:"quarantine"`
+	ReadOnly   *bool                    `json:"read_only"`
+
+	// QuotaBytes is the most data the bucket may hold; zero removes the
+	// quota.
+	QuotaBytes *int64 `json:"quota_bytes"`
+
+	// ObjectDefaults replaces the bucket's object defaults; an empty object
+	// removes them.
+	ObjectDefaults *domain.ObjectDefaults `json:"object_defaults"`
+}
+
+func (h *AdminHandler) listBuckets(w http.ResponseWriter, r *http.Request) {
+	var ownerID int64
+	if v := r.URL.Query().Get("owner_id"); v != "" {
+		var err error
+		ownerID, err = strconv.ParseInt(v, 10, 64)
+		if err != nil || ownerID < 1 {
+			writeJSONError(w, http.StatusBadRequest, "owner_id must be a positive integer")
+			return
+		}
+	}
+	output, err := h.bucketService.ListBuckets(r.Context(), service.ListBucketsInput{OwnerID: ownerID})
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"buckets": output.Buckets})
+}
+
+func (h *AdminHandler) createBucket(w http.ResponseWriter, r *http.Request) {
+	var req createBucketRequest
+	if !decodeAdminRequest(w, r, &req) {
+		return
+	}
+	if _, err := h.userService.GetByID(r.Context(), req.OwnerID); err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			writeJSONError(w, http.StatusBadRequest, "owner_id must name an existing user")
+			return
+		}
+		h.writeError(w, err)
+		return
+	}
+	output, err := h.bucketService.CreateBucket(r.Context(), service.CreateBucketInput{
+		OwnerID:   req.OwnerID,
+		Name:      req.Name,
+		Region:    req.Region,
+		Residency: req.Residency,
+	})
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, output.Bucket)
+}
+
+func (h *AdminHandler) getBucket(w http.ResponseWriter, r *http.Request) {
+	output, err := h.bucketService.GetBucket(r.Context(), service.GetBucketInput{Name: r.PathValue("name")})
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, output.Bucket)
+}
+
+func (h *AdminHandler) updateBucket(w http.ResponseWriter, r *http.Request) {
+	var req updateBucketRequest
+	if !decodeAdminRequest(w, r, &req) {
+		return
+	}
+
+	name := r.PathValue("name")
+	if req.Versioning != nil {
+		if err := h.bucketService.PutBucketVersioning(r.Context(), service.PutBucketVersioningInput{
+			Name:   name,
+			Status: *req.Versioning,
+		}); err != nil {
+			h.writeError(w, err)
+			return
+		}
+	}
+	if req.Quarantine != nil {
+		if err := h.bucketService.SetBucketQuarantine(r.Context(), service.SetBucketQuarantineInput{
+			Name:    name,
+			Enabled: *req.Quarantine,
+		}); err != nil {
+			h.writeError(w, err)
+			return
+		}
+	}
+	if req.ReadOnly != nil {
+		if err := h.bucketService.SetBucketReadOnly(r.Context(), service.SetBucketReadOnlyInput{
+			Name:    name,
+			Enabled: *req.ReadOnly,
+		}); err != nil {
+			h.writeError(w, err)
+			return
+		}
+	}
+	if req.QuotaBytes != nil {
+		if err := h.bucketService.SetBucketQuota(r.Context(), service.SetBucketQuotaInput{
+			Name:  name,
+			Bytes: *req.QuotaBytes,
+		}); err != nil {
+			h.writeError(w, err)
+			return
+		}
+	}
+	if req.ObjectDefaults != nil {
+		if err := h.bucketService.SetBucketObjectDefaults(r.Context(), service.SetBucketObjectDefaultsInput{
+			Name:     name,
+			Defaults: req.ObjectDefaults,
+		}); err != nil {
+			h.writeError(w, err)
+			return
+		}
+	}
+
+	h.getBucket(w, r)
+}
+
+func (h *AdminHandler) deleteBucket(w http.ResponseWriter, r *http.Request) {
+	if err := h.bucketService.DeleteBucket(r.Context(), service.DeleteBucketInput{Name: r.PathValue("name")}); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// =============================================================================
+// Garbage Collection and Statistics
+// =============================================================================
+
+// gcStatusResponse is the JSON body of GET /gc.
+type gcStatusResponse struct {
+	OrphanBlobs      int    `json:"orphan_blobs"`
+	OrphanBytes      int64  `json:"orphan_bytes"`
+	HasMoreOrphans   bool   `json:"has_more_orphans"`
+	GracePeriod      string `json:"grace_period"`
+	Interval         string `json:"interval"`
+	CollectorEnabled bool   `json:"collector_enabled"`
+}
+
+// gcRunResponse is the JSON body of POST /gc.
+type gcRunResponse struct {
+	BlobsDeleted         int     `json:"blobs_deleted"`
+	BytesFreed           int64   `json:"bytes_freed"`
+	Errors               int     `json:"errors"`
+	Resumed              int     `json:"resumed"`
+	Skipped              int     `json:"skipped"`
+	OrphanBlobsRemaining int     `json:"orphan_blobs_remaining"`
+	DurationSeconds      float64 `json:"duration_seconds"`
+}
+
+// statsResponse is the JSON body of GET /stats.
+type statsResponse struct {
+	Blobs           int64   `json:"blobs"`
+	OrphanBlobs     int64   `json:"orphan_blobs"`
+	StoredBytes     int64   `json:"stored_bytes"`
+	ReferencedBytes int64   `json:"referenced_bytes"`
+	DedupRatio      float64 `json:"dedup_ratio"`
+	Buckets         int     `json:"buckets"`
+	Users           int64   `json:"users"`
+}
+
+func (h *AdminHandler) gcStatus(w http.ResponseWriter, r *http.Request) {
+	if h.gc == nil {
+		writeJSONError(w, http.StatusConflict, "garbage collection is disabled")
+		return
+	}
+	stats, err := h.gc.GetStats(r.Context())
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, gcStatusResponse{
+		OrphanBlobs:      stats.OrphanBlobCount,
+		OrphanBytes:      stats.OrphanBlobSize,
+		HasMoreOrphans:   stats.HasMoreOrphans,
+		GracePeriod:      stats.GracePeriod.String(),
+		Interval:         stats.NextRunIn.String(),
+		CollectorEnabled: true,
+	})
+}
+
+// watchEvents streams the change events of every bucket, including
+// lifecycle expirations, and the blobs deleted by garbage collection.
+func (h *AdminHandler) watchEvents(w http.ResponseWriter, r *http.Request) {
+	if h.events == nil {
+		writeJSONError(w, http.StatusConflict, "change events are disabled")
+		return
+	}
+	h.events.WatchAll(w, r)
+}
+
+// runGC runs a garbage collection pass and waits for it to finish.
+func (h *AdminHandler) runGC(w http.ResponseWriter, r *http.Request) {
+	if h.gc == nil {
+		writeJSONError(w, http.StatusConflict, "garbage collection is disabled")
+		return
+	}
+	result := h.gc.RunOnce(r.Context())
+	h.logger.Info().
+		Int("blobs_deleted", result.BlobsDeleted).
+		Int64("bytes_freed", result.BytesFreed).
+		Msg("garbage collection triggered through the admin API")
+	writeJSON(w, http.StatusOK, gcRunResponse{
+		BlobsDeleted:         result.BlobsDeleted,
+		BytesFreed:           result.BytesFreed,
+		Errors:               result.Errors,
+		Resumed:              result.Resumed,
+		Skipped:              result.Skipped,
+		OrphanBlobsRemaining: result.OrphanBlobsRemaining,
+		DurationSeconds:      result.Duration.Seconds(),
+	})
+}
+
+func (h *AdminHandler) stats(w http.ResponseWriter, r *http.Request) {
+	blobs, err := h.blobStats.GetStats(r.Context())
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	buckets, err := h.bucketService.ListBuckets(r.Context(), service.ListBucketsInput{})
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	users, err := h.userService.List(r.Context(), service.ListUsersInput{Limit: 1})
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	resp := statsResponse{
+		Blobs:           blobs.Blobs,
+		OrphanBlobs:     blobs.Orphans,
+		StoredBytes:     blobs.StoredBytes,
+		ReferencedBytes: blobs.ReferencedBytes,
+		DedupRatio:      1,
+		Buckets:         len(buckets.Buckets),
+		Users:           users.TotalCount,
+	}
+	if blobs.StoredBytes > 0 {
+		resp.DedupRatio = float64(blobs.ReferencedBytes) / float64(blobs.StoredBytes)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// =============================================================================
+// Read-Only Mode
+// =============================================================================
+
+// readOnlyBody is the JSON body of GET and PUT /read-only.
+type readOnlyBody struct {
+	Enabled *bool `json:"enabled"`
+}
+
+func (h *AdminHandler) getReadOnly(w http.ResponseWriter, r *http.Request) {
+	enabled := h.readOnly.Enabled(r.Context())
+	writeJSON(w, http.StatusOK, readOnlyBody{Enabled: &enabled})
+}
+
+// setReadOnly turns service-wide read-only mode on or off. Other servers
+// sharing the database follow within a few seconds.
+func (h *AdminHandler) setReadOnly(w http.ResponseWriter, r *http.Request) {
+	var req readOnlyBody
+	if !decodeAdminRequest(w, r, &req) {
+		return
+	}
+	if req.Enabled == nil {
+		writeJSONError(w, http.StatusBadRequest, "enabled is required")
+		return
+	}
+	if err := h.readOnly.SetEnabled(r.Context(), *req.Enabled); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.logger.Info().Bool("read_only", *req.Enabled).Msg("read-only mode changed through the admin API")
+	writeJSON(w, http.StatusOK, req)
+}
+
+// =============================================================================
+// Helpers
+// =============================================================================
+
+// writeError maps service errors to JSON error responses.
+func (h *AdminHandler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound), errors.Is(err, domain.ErrUserNotFound):
+		writeJSONError(w, http.StatusNotFound, "user not found")
+	case errors.Is(err, service.ErrAccessKeyNotFound):
+		writeJSONError(w, http.StatusNotFound, "access key not found")
+	case errors.Is(err, domain.ErrBucketNotFound):
+		writeJSONError(w, http.StatusNotFound, "bucket not found")
+	case errors.Is(err, domain.ErrTenantNotFound):
+		writeJSONError(w, http.StatusNotFound, "tenant not found")
+	case errors.Is(err, service.ErrUserAlreadyExists),
+		errors.Is(err, service.ErrMaxAccessKeysReached),
+		errors.Is(err, service.ErrUserInactive),
+		errors.Is(err, domain.ErrBucketAlreadyExists),
+		errors.Is(err, domain.ErrBucketNotEmpty),
+		errors.Is(err, domain.ErrTenantAlreadyExists),
+		errors.Is(err, domain.ErrTenantNotEmpty),
+		errors.Is(err, domain.ErrDefaultTenant),
+		errors.Is(err, domain.ErrTenantBucketQuotaExceeded):
+		writeJSONError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrInvalidUsername),
+		errors.Is(err, service.ErrInvalidPassword),
+		errors.Is(err, service.ErrInvalidEmail),
+		errors.Is(err, service.ErrInvalidVersioningStatus),
+		errors.Is(err, domain.ErrInvalidUserRole),
+		errors.Is(err, domain.ErrInvalidPolicy),
+		errors.Is(err, domain.ErrBucketNameLength),
+		errors.Is(err, domain.ErrBucketNameFormat),
+		errors.Is(err, domain.ErrBucketNameIPFormat),
+		errors.Is(err, domain.ErrInvalidResidency),
+		errors.Is(err, domain.ErrInvalidLocationConstraint),
+		errors.Is(err, domain.ErrInvalidObjectDefaults),
+		errors.Is(err, domain.ErrInvalidTenantName),
+		errors.Is(err, domain.ErrInvalidTenantLimits),
+		errors.Is(err, domain.ErrInvalidBucketQuota),
+		errors.Is(err, domain.ErrBucketNameNotInNamespace):
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, domain.ErrBucketReadOnly), errors.Is(err, domain.ErrServiceReadOnly):
+		writeJSONError(w, http.StatusServiceUnavailable, err.Error())
+	default:
+		h.logger.Error().Err(err).Msg("admin request failed")
+		writeJSONError(w, http.StatusInternalServerError, "internal error")
+	}
+}
+
+// decodeAdminRequest decodes a JSON request body, writing a 400 response
+// and returning false if it is malformed.
+func decodeAdminRequest(w http.ResponseWriter, r *http.Request, v any) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAdminBodySize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// userIDParam parses the {id} path parameter.
+func userIDParam(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id < 1 {
+		writeJSONError(w, http.StatusBadRequest, "user ID must be a positive integer")
+		return 0, false
+	}
+	return id, true
+}
+
+// pageParams parses the limit and offset query parameters.
+func pageParams(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	query := r.URL.Query()
+	var limit, offset int
+	var err error
+	if v := query.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 {
+			writeJSONError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return 0, 0, false
+		}
+	}
+	if v := query.Get("offset"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil || offset < 0 {
+			writeJSONError(w, http.StatusBadRequest, "offset must not be negative")
+			return 0, 0, false
+		}
+	}
+	return limit, offset, true
+}
@@ -0,0 +1,284 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/pkg/crypto"
+	"github.com/prn-tf/alexander-storage/internal/repository/sqlite"
+	"github.com/prn-tf/alexander-storage/internal/service"
+)
+
+const testAdminToken = "admin-token-0123456789abcdefghijklmnop"
+
+// newAdminTestHandler creates an admin API backed by SQLite.
+func newAdminTestHandler(t *testing.T, allowClientCerts bool) *AdminHandler {
+	t.Helper()
+	ctx := context.Background()
+
+	db, err := sqlite.NewDB(ctx, sqlite.DefaultConfig(filepath.Join(t.TempDir(), "alexander.db")), zerolog.Nop())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, db.Migrate(ctx))
+
+	encryptor, err := crypto.NewEncryptor(bytes.Repeat([]byte{7}, crypto.KeySize))
+	require.NoError(t, err)
+
+	userRepo := sqlite.NewUserRepository(db)
+	readOnly := service.NewReadOnlyService(sqlite.NewReadOnlyRepository(db), zerolog.Nop())
+	bucketService := service.NewBucketService(sqlite.NewBucketRepository(db), nil, zerolog.Nop(), service.DefaultBucketConfig())
+	bucketService.SetReadOnlyService(readOnly)
+	tenants := service.NewTenantService(sqlite.NewTenantRepository(db), userRepo, sqlite.NewBucketRepository(db), service.TenantConfig{NamespaceBuckets: true}, zerolog.Nop())
+	bucketService.SetTenantService(tenants)
+	return NewAdminHandler(AdminHandlerConfig{
+		UserService:      service.NewUserService(userRepo, domain.DefaultPasswordPolicy(), zerolog.Nop()),
+		IAMService:       service.NewIAMService(sqlite.NewAccessKeyRepository(db), userRepo, encryptor, zerolog.Nop(), service.DefaultIAMConfig()),
+		BucketService:    bucketService,
+		ReadOnly:         readOnly,
+		Tenants:          tenants,
+		BlobStats:        sqlite.NewBlobRepository(db),
+		Token:            testAdminToken,
+		AllowClientCerts: allowClientCerts,
+		Logger:           zerolog.Nop(),
+	})
+}
+
+// adminRequest sends an authenticated request and decodes the JSON response.
+func adminRequest(t *testing.T, h http.Handler, method, path, body string, out any) int {
+	t.Helper()
+	req := httptest.NewRequest(method, strings.TrimSuffix(AdminPathPrefix, "/")+path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+testAdminToken)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if out != nil && rec.Code < 300 {
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), out), rec.Body.String())
+	}
+	return rec.Code
+}
+
+func TestAdminHandler_ProvisionsUserKeyAndBucket(t *testing.T) {
+	h := newAdminTestHandler(t, false)
+
+	var user struct {
+		ID       int64  `json:"id"`
+		Username string `json:"username"`
+		Role     string `json:"role"`
+	}
+	code := adminRequest(t, h, http.MethodPost, "/users", `{"username":"terraform","email":"tf@example.com","password":"correct-horse","role":"operator"}`, &user)
+	require.Equal(t, http.StatusCreated, code)
+	assert.Equal(t, "terraform", user.Username)
+	assert.Equal(t, "operator", user.Role)
+
+	code = adminRequest(t, h, http.MethodPost, "/users", `{"username":"terraform","email":"tf2@example.com","password":"correct-horse"}`, nil)
+	assert.Equal(t, http.StatusConflict, code)
+
+	var key struct {
+		AccessKeyID string `json:"access_key_id"`
+		SecretKey   string `json:"secret_key"`
+		Status      string `json:"status"`
+	}
+	code = adminRequest(t, h, http.MethodPost, "/users/"+strconv.FormatInt(user.ID, 10)+"/keys", `{"description":"ci"}`, &key)
+	require.Equal(t, http.StatusCreated, code)
+	assert.NotEmpty(t, key.AccessKeyID)
+	assert.NotEmpty(t, key.SecretKey)
+
+	var updated map[string]any
+	code = adminRequest(t, h, http.MethodPatch, "/keys/"+key.AccessKeyID, `{"status":"Inactive"}`, &updated)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "Inactive", updated["status"])
+	assert.NotContains(t, updated, "secret_key", "the secret is only returned at creation")
+
+	var bucket struct {
+		Name       string `json:"name"`
+		OwnerID    int64  `json:"owner_id"`
+		Versioning string `json:"versioning"`
+	}
+	code = adminRequest(t, h, http.MethodPost, "/buckets", `{"name":"tf-state","owner_id":`+strconv.FormatInt(user.ID, 10)+`}`, &bucket)
+	require.Equal(t, http.StatusCreated, code)
+	assert.Equal(t, user.ID, bucket.OwnerID)
+
+	code = adminRequest(t, h, http.MethodPatch, "/buckets/tf-state", `{"versioning":"Enabled"}`, &bucket)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "Enabled", bucket.Versioning)
+
+	var stats statsResponse
+	require.Equal(t, http.StatusOK, adminRequest(t, h, http.MethodGet, "/stats", "", &stats))
+	assert.Equal(t, 1, stats.Buckets)
+	assert.Equal(t, int64(1), stats.Users)
+
+	assert.Equal(t, http.StatusNoContent, adminRequest(t, h, http.MethodDelete, "/buckets/tf-state", "", nil))
+	assert.Equal(t, http.StatusNotFound, adminRequest(t, h, http.MethodGet, "/buckets/tf-state", "", nil))
+	assert.Equal(t, http.StatusNoContent, adminRequest(t, h, http.MethodDelete, "/keys/"+key.AccessKeyID, "", nil))
+	assert.Equal(t, http.StatusNoContent, adminRequest(t, h, http.MethodDelete, "/users/"+strconv.FormatInt(user.ID, 10), "", nil))
+}
+
+func TestAdminHandler_RejectsInvalidRequests(t *testing.T) {
+	h := newAdminTestHandler(t, false)
+
+	assert.Equal(t, http.StatusBadRequest, adminRequest(t, h, http.MethodPost, "/users", `{"username":"x","unknown":true}`, nil))
+	assert.Equal(t, http.StatusBadRequest, adminRequest(t, h, http.MethodPost, "/users", `{"username":"valid","email":"v@example.com","password":"correct-horse","role":"root"}`, nil))
+	assert.Equal(t, http.StatusBadRequest, adminRequest(t, h, http.MethodGet, "/users/abc", "", nil))
+	assert.Equal(t, http.StatusNotFound, adminRequest(t, h, http.MethodGet, "/users/42", "", nil))
+	assert.Equal(t, http.StatusBadRequest, adminRequest(t, h, http.MethodPost, "/buckets", `{"name":"orphan","owner_id":42}`, nil))
+	assert.Equal(t, http.StatusConflict, adminRequest(t, h, http.MethodPost, "/gc", "", nil), "garbage collection is disabled")
+	assert.Equal(t, http.StatusMethodNotAllowed, adminRequest(t, h, http.MethodPut, "/stats", "", nil))
+	assert.Equal(t, http.StatusNotFound, adminRequest(t, h, http.MethodGet, "/nothing", "", nil))
+}
+
+func TestAdminHandler_ReadOnlyMode(t *testing.T) {
+	h := newAdminTestHandler(t, false)
+
+	var user struct {
+		ID int64 `json:"id"`
+	}
+	require.Equal(t, http.StatusCreated, adminRequest(t, h, http.MethodPost, "/users", `{"username":"migrator","email":"m@example.com","password":"correct-horse"}`, &user))
+	createBucket := `{"name":"photos","owner_id":` + strconv.FormatInt(user.ID, 10) + `}`
+
+	var mode struct {
+		Enabled bool `json:"enabled"`
+	}
+	require.Equal(t, http.StatusOK, adminRequest(t, h, http.MethodPut, "/read-only", `{"enabled":true}`, &mode))
+	assert.True(t, mode.Enabled)
+	require.Equal(t, http.StatusOK, adminRequest(t, h, http.MethodGet, "/read-only", "", &mode))
+	assert.True(t, mode.Enabled)
+	assert.Equal(t, http.StatusServiceUnavailable, adminRequest(t, h, http.MethodPost, "/buckets", createBucket, nil))
+
+	require.Equal(t, http.StatusOK, adminRequest(t, h, http.MethodPut, "/read-only", `{"enabled":false}`, &mode))
+	assert.False(t, mode.Enabled)
+	require.Equal(t, http.StatusCreated, adminRequest(t, h, http.MethodPost, "/buckets", createBucket, nil))
+
+	var bucket struct {
+		ReadOnly bool `json:"read_only"`
+	}
+	require.Equal(t, http.StatusOK, adminRequest(t, h, http.MethodPatch, "/buckets/photos", `{"read_only":true}`, &bucket))
+	assert.True(t, bucket.ReadOnly)
+	assert.Equal(t, http.StatusServiceUnavailable, adminRequest(t, h, http.MethodDelete, "/buckets/photos", "", nil))
+
+	var updated map[string]any
+	require.Equal(t, http.StatusOK, adminRequest(t, h, http.MethodPatch, "/buckets/photos", `{"read_only":false}`, &updated))
+	assert.NotContains(t, updated, "read_only")
+	assert.Equal(t, http.StatusNoContent, adminRequest(t, h, http.MethodDelete, "/buckets/photos", "", nil))
+
+	assert.Equal(t, http.StatusBadRequest, adminRequest(t, h, http.MethodPut, "/read-only", `{}`, nil))
+}
+
+func TestAdminHandler_BucketQuota(t *testing.T) {
+	h := newAdminTestHandler(t, false)
+
+	var user struct {
+		ID int64 `json:"id"`
+	}
+	require.Equal(t, http.StatusCreated, adminRequest(t, h, http.MethodPost, "/users", `{"username":"wile","email":"wile@example.com","password":"correct-horse"}`, &user))
+	require.Equal(t, http.StatusCreated, adminRequest(t, h, http.MethodPost, "/buckets", `{"name":"photos","owner_id":`+strconv.FormatInt(user.ID, 10)+`}`, nil))
+
+	var bucket struct {
+		QuotaBytes int64 `json:"quota_bytes"`
+	}
+	require.Equal(t, http.StatusOK, adminRequest(t, h, http.MethodPatch, "/buckets/photos", `{"quota_bytes":1048576}`, &bucket))
+	assert.Equal(t, int64(1048576), bucket.QuotaBytes)
+	require.Equal(t, http.StatusOK, adminRequest(t, h, http.MethodGet, "/buckets/photos", "", &bucket))
+	assert.Equal(t, int64(1048576), bucket.QuotaBytes)
+
+	assert.Equal(t, http.StatusBadRequest, adminRequest(t, h, http.MethodPatch, "/buckets/photos", `{"quota_bytes":-1}`, nil))
+	assert.Equal(t, http.StatusNotFound, adminRequest(t, h, http.MethodPatch, "/buckets/nope", `{"quota_bytes":1}`, nil))
+
+	var updated map[string]any
+	require.Equal(t, http.StatusOK, adminRequest(t, h, http.MethodPatch, "/buckets/photos", `{"quota_bytes":0}`, &updated))
+	assert.NotContains(t, updated, "quota_bytes")
+}
+
+func TestAdminHandler_Tenants(t *testing.T) {
+	h := newAdminTestHandler(t, false)
+
+	var tenant struct {
+		ID     int64               `json:"id"`
+		Name   string              `json:"name"`
+		Limits domain.TenantLimits `json:"limits"`
+		Usage  domain.TenantUsage  `json:"usage"`
+	}
+	require.Equal(t, http.StatusCreated, adminRequest(t, h, http.MethodPost, "/tenants", `{"name":"acme","limits":{"max_buckets":1}}`, &tenant))
+	assert.Equal(t, 1, tenant.Limits.MaxBuckets)
+	assert.Equal(t, http.StatusConflict, adminRequest(t, h, http.MethodPost, "/tenants", `{"name":"acme"}`, nil))
+	assert.Equal(t, http.StatusBadRequest, adminRequest(t, h, http.MethodPost, "/tenants", `{"name":"Acme Corp"}`, nil))
+	assert.Equal(t, http.StatusBadRequest, adminRequest(t, h, http.MethodPost, "/tenants", `{"name":"initech","limits":{"requests_per_second":10}}`, nil),
+		"a request rate needs a burst size")
+
+	var user struct {
+		ID       int64 `json:"id"`
+		TenantID int64 `json:"tenant_id"`
+	}
+	require.Equal(t, http.StatusCreated, adminRequest(t, h, http.MethodPost, "/users", `{"username":"wile","email":"wile@example.com","password":"correct-horse","tenant":"acme"}`, &user))
+	assert.Equal(t, tenant.ID, user.TenantID)
+	assert.Equal(t, http.StatusNotFound, adminRequest(t, h, http.MethodPost, "/users", `{"username":"roadrunner","email":"rr@example.com","password":"correct-horse","tenant":"nope"}`, nil))
+	owner := strconv.FormatInt(user.ID, 10)
+
+	// Buckets are namespaced by tenant and count towards its quota
+	assert.Equal(t, http.StatusBadRequest, adminRequest(t, h, http.MethodPost, "/buckets", `{"name":"anvils","owner_id":`+owner+`}`, nil))
+	require.Equal(t, http.StatusCreated, adminRequest(t, h, http.MethodPost, "/buckets", `{"name":"acme-anvils","owner_id":`+owner+`}`, nil))
+	assert.Equal(t, http.StatusConflict, adminRequest(t, h, http.MethodPost, "/buckets", `{"name":"acme-rockets","owner_id":`+owner+`}`, nil))
+
+	require.Equal(t, http.StatusOK, adminRequest(t, h, http.MethodPatch, "/tenants/acme", `{"max_buckets":0,"max_storage_bytes":1024}`, &tenant))
+	assert.Equal(t, 0, tenant.Limits.MaxBuckets)
+	assert.Equal(t, int64(1024), tenant.Limits.MaxStorageBytes)
+	assert.Equal(t, domain.TenantUsage{Users: 1, Buckets: 1}, tenant.Usage)
+
+	var users userListResponse
+	require.Equal(t, http.StatusOK, adminRequest(t, h, http.MethodGet, "/users?tenant=acme", "", &users))
+	assert.Equal(t, int64(1), users.TotalCount)
+	require.Equal(t, http.StatusOK, adminRequest(t, h, http.MethodGet, "/users?tenant=default", "", &users))
+	assert.Equal(t, int64(0), users.TotalCount)
+
+	assert.Equal(t, http.StatusConflict, adminRequest(t, h, http.MethodDelete, "/tenants/acme", "", nil), "the tenant still has a user")
+	assert.Equal(t, http.StatusConflict, adminRequest(t, h, http.MethodDelete, "/tenants/default", "", nil))
+
+	// Moving the user takes their bucket along
+	require.Equal(t, http.StatusOK, adminRequest(t, h, http.MethodPatch, "/users/"+owner, `{"tenant":"default"}`, &user))
+	assert.Equal(t, domain.DefaultTenantID, user.TenantID)
+	var bucket struct {
+		TenantID int64 `json:"tenant_id"`
+	}
+	require.Equal(t, http.StatusOK, adminRequest(t, h, http.MethodGet, "/buckets/acme-anvils", "", &bucket))
+	assert.Equal(t, domain.DefaultTenantID, bucket.TenantID)
+
+	assert.Equal(t, http.StatusNoContent, adminRequest(t, h, http.MethodDelete, "/tenants/acme", "", nil))
+	var list tenantListResponse
+	require.Equal(t, http.StatusOK, adminRequest(t, h, http.MethodGet, "/tenants", "", &list))
+	require.Len(t, list.Tenants, 1)
+	assert.Equal(t, domain.DefaultTenantName, list.Tenants[0].Name)
+}
+
+func TestAdminHandler_Authentication(t *testing.T) {
+	h := newAdminTestHandler(t, true)
+	path := AdminPathPrefix + "stats"
+
+	for _, header := range []string{"", "Bearer wrong", "Basic " + testAdminToken} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, header)
+	}
+
+	// A client certificate verified by the listener replaces the token
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{}}}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
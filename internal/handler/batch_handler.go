@@ -0,0 +1,179 @@
+// Package handler provides HTTP handlers for Alexander Storage API.
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/auth"
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/service"
+)
+
+const (
+	// BatchPath is the path of the batch commit API. Requests are signed
+	// with SigV4 like S3 requests; a batch belongs to the user who opened it.
+	BatchPath = "/_alexander/batches"
+
+	// BatchIDHeader names the batch a PutObject, CopyObject or
+	// CompleteMultipartUpload request is staged in. The write is answered
+	// with a staging ID and becomes visible when the batch is committed.
+	BatchIDHeader = "x-alexander-batch-id"
+)
+
+// BatchHandler serves the batch commit API, through which a client opens a
+// batch, stages writes to any of its buckets in it and then commits them
+// atomically or aborts them.
+type BatchHandler struct {
+	batchService *service.BatchService
+	logger       zerolog.Logger
+}
+
+// NewBatchHandler creates a new BatchHandler.
+func NewBatchHandler(batchService *service.BatchService, logger zerolog.Logger) *BatchHandler {
+	return &BatchHandler{
+		batchService: batchService,
+		logger:       logger.With().Str("handler", "batch").Logger(),
+	}
+}
+
+// batchResponse is the JSON body describing an open batch.
+type batchResponse struct {
+	ID        string              `json:"id"`
+	ExpiresAt time.Time           `json:"expires_at"`
+	Writes    []stagedUploadEntry `json:"writes,omitempty"`
+}
+
+// committedEntry is an object version published by a commit.
+type committedEntry struct {
+	Bucket    string `json:"bucket"`
+	Key       string `json:"key"`
+	VersionID string `json:"version_id,omitempty"`
+	Sequence  int64  `json:"sequence"`
+	ETag      string `json:"etag"`
+}
+
+// HandleBatch handles the batch API:
+//
+//	POST /_alexander/batches                    opens a batch
+//	GET  /_alexander/batches?id=X               lists the writes staged in a batch
+//	POST /_alexander/batches?id=X&action=commit publishes all writes at once
+//	POST /_alexander/batches?id=X&action=abort  discards all writes
+func (h *BatchHandler) HandleBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.Header().Set("Allow", "GET, POST")
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	authCtx := auth.GetAuthContext(r.Context())
+	if authCtx == nil {
+		writeJSONError(w, http.StatusForbidden, "access denied")
+		return
+	}
+
+	query := r.URL.Query()
+	id := query.Get("id")
+
+	switch {
+	case r.Method == http.MethodGet && id == "":
+		writeJSONError(w, http.StatusBadRequest, "id is required")
+	case r.Method == http.MethodGet:
+		h.get(w, r, id, authCtx.UserID)
+	case id == "":
+		h.create(w, r, authCtx.UserID)
+	case query.Get("action") == "commit":
+		h.commit(w, r, id, authCtx.UserID)
+	case query.Get("action") == "abort":
+		h.abort(w, r, id, authCtx.UserID)
+	default:
+		writeJSONError(w, http.StatusBadRequest, "action must be commit or abort")
+	}
+}
+
+// create opens a batch.
+func (h *BatchHandler) create(w http.ResponseWriter, r *http.Request, ownerID int64) {
+	batch, err := h.batchService.Create(r.Context(), ownerID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, batchResponse{ID: batch.ID.String(), ExpiresAt: batch.ExpiresAt})
+}
+
+// get writes a batch and its staged writes.
+func (h *BatchHandler) get(w http.ResponseWriter, r *http.Request, id string, ownerID int64) {
+	batch, writes, err := h.batchService.Get(r.Context(), id, ownerID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	resp := batchResponse{
+		ID:        batch.ID.String(),
+		ExpiresAt: batch.ExpiresAt,
+		Writes:    make([]stagedUploadEntry, len(writes)),
+	}
+	for i, s := range writes {
+		resp.Writes[i] = stagedUploadEntry{
+			ID:          s.ID.String(),
+			Bucket:      s.BucketName,
+			Key:         s.Key,
+			Size:        s.Size,
+			ContentType: s.ContentType,
+			ETag:        s.ETag,
+			ContentHash: s.ContentHash,
+			Metadata:    s.Metadata,
+			CreatedAt:   s.CreatedAt,
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// commit publishes the writes of a batch.
+func (h *BatchHandler) commit(w http.ResponseWriter, r *http.Request, id string, ownerID int64) {
+	committed, err := h.batchService.Commit(r.Context(), id, ownerID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	entries := make([]committedEntry, len(committed))
+	for i, c := range committed {
+		entries[i] = committedEntry{
+			Bucket:   c.BucketName,
+			Key:      c.Key,
+			Sequence: c.Sequence,
+			ETag:     c.ETag,
+		}
+		if v := c.GetVersionIDString(); v != "null" {
+			entries[i].VersionID = v
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"id": id, "objects": entries})
+}
+
+// abort discards the writes of a batch.
+func (h *BatchHandler) abort(w http.ResponseWriter, r *http.Request, id string, ownerID int64) {
+	if err := h.batchService.Abort(r.Context(), id, ownerID); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeError maps a batch service error to a JSON error response.
+func (h *BatchHandler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrBatchNotFound):
+		writeJSONError(w, http.StatusNotFound, "batch not found")
+	case errors.Is(err, domain.ErrBucketReadOnly), errors.Is(err, domain.ErrServiceReadOnly):
+		writeJSONError(w, http.StatusServiceUnavailable, err.Error())
+	default:
+		h.logger.Error().Err(err).Msg("batch request failed")
+		writeJSONError(w, http.StatusInternalServerError, "internal error")
+	}
+}
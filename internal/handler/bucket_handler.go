@@ -3,9 +3,10 @@ package handler
 
 import (
 	"encoding/xml"
-	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/rs/zerolog"
@@ -35,10 +36,12 @@ func NewBucketHandler(bucketService *service.BucketService, logger zerolog.Logge
 
 // ListAllMyBucketsResult is the response for ListBuckets.
 type ListAllMyBucketsResult struct {
-	XMLName xml.Name `xml:"ListAllMyBucketsResult"`
-	Xmlns   string   `xml:"xmlns,attr"`
-	Owner   Owner    `xml:"Owner"`
-	Buckets Buckets  `xml:"Buckets"`
+	XMLName           xml.Name `xml:"ListAllMyBucketsResult"`
+	Xmlns             string   `xml:"xmlns,attr"`
+	Owner             Owner    `xml:"Owner"`
+	Buckets           Buckets  `xml:"Buckets"`
+	ContinuationToken string   `xml:"ContinuationToken,omitempty"`
+	Prefix            string   `xml:"Prefix,omitempty"`
 }
 
 // Buckets is a container for bucket list.
@@ -50,14 +53,29 @@ type Buckets struct {
 type BucketInfo struct {
 	Name         string `xml:"Name"`
 	CreationDate string `xml:"CreationDate"`
+	BucketRegion string `xml:"BucketRegion,omitempty"`
 }
 
+// ResidencyHeader optionally sets the data residency tag on CreateBucket.
+const ResidencyHeader = "x-alexander-residency"
+
+// ReadOnlyHeader is set to "true" on HeadBucket responses while writes to the
+// bucket are refused because it or the whole service is read-only.
+const ReadOnlyHeader = "x-alexander-read-only"
+
 // CreateBucketConfiguration is the request body for CreateBucket.
 type CreateBucketConfiguration struct {
 	XMLName            xml.Name `xml:"CreateBucketConfiguration"`
 	LocationConstraint string   `xml:"LocationConstraint"`
 }
 
+// LocationConstraint is the response for GetBucketLocation.
+type LocationConstraint struct {
+	XMLName xml.Name `xml:"LocationConstraint"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	Region  string   `xml:",chardata"`
+}
+
 // VersioningConfiguration is the request/response for bucket versioning.
 type VersioningConfiguration struct {
 	XMLName   xml.Name `xml:"VersioningConfiguration"`
@@ -66,6 +84,68 @@ type VersioningConfiguration struct {
 	MFADelete string   `xml:"MfaDelete,omitempty"`
 }
 
+// ACLHeader sets the canned ACL of a bucket or object on writes and on
+// PutBucketAcl and PutObjectAcl.
+const ACLHeader = "x-amz-acl"
+
+// MaxBucketsLimit is the largest max-buckets a ListBuckets request may ask for.
+const MaxBucketsLimit = 10000
+
+// Grantee types and the group URI used in access control policies.
+const (
+	granteeCanonicalUser = "CanonicalUser"
+	granteeGroup         = "Group"
+	allUsersURI          = "http://acs.amazonaws.com/groups/global/AllUsers"
+)
+
+// AccessControlPolicy is the request/response for bucket and object ACLs. Only the
+// grants of the canned ACLs are supported: FULL_CONTROL for the owner, and
+// READ or READ and WRITE for all users.
+type AccessControlPolicy struct {
+	XMLName           xml.Name          `xml:"AccessControlPolicy"`
+	Xmlns             string            `xml:"xmlns,attr,omitempty"`
+	Owner             Owner             `xml:"Owner"`
+	AccessControlList AccessControlList `xml:"AccessControlList"`
+}
+
+// AccessControlList is a container for grants.
+type AccessControlList struct {
+	Grant []Grant `xml:"Grant"`
+}
+
+// Grant gives a grantee a permission.
+type Grant struct {
+	Grantee    Grantee `xml:"Grantee"`
+	Permission string  `xml:"Permission"`
+}
+
+// Grantee is a user, identified by ID, or a group, identified by URI.
+type Grantee struct {
+	XMLNSXSI    string `xml:"xmlns:xsi,attr,omitempty"`
+	Type        string `xml:"xsi:type,attr,omitempty"`
+	ID          string `xml:"ID,omitempty"`
+	DisplayName string `xml:"DisplayName,omitempty"`
+	URI         string `xml:"URI,omitempty"`
+}
+
+// Tagging is the request/response for bucket tags.
+type Tagging struct {
+	XMLName xml.Name `xml:"Tagging"`
+	Xmlns   string   `xml:"xmlns,attr,omitempty"`
+	TagSet  TagSet   `xml:"TagSet"`
+}
+
+// TagSet is a container for tags.
+type TagSet struct {
+	Tag []Tag `xml:"Tag"`
+}
+
+// Tag is a key-value pair in a tag set.
+type Tag struct {
+	Key   string `xml:"Key"`
+	Value string `xml:"Value"`
+}
+
 // =============================================================================
 // Handler Methods
 // =============================================================================
@@ -110,11 +190,18 @@ func (h *BucketHandler) CreateBucket(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if hasGrantHeaders(r) {
+		writeError(w, errGrantHeadersNotImplemented)
+		return
+	}
+
 	// Create bucket
 	output, err := h.bucketService.CreateBucket(ctx, service.CreateBucketInput{
-		OwnerID: userCtx.UserID,
-		Name:    bucketName,
-		Region:  region,
+		OwnerID:   userCtx.UserID,
+		Name:      bucketName,
+		Region:    region,
+		Residency: r.Header.Get(ResidencyHeader),
+		ACL:       domain.BucketACL(r.Header.Get(ACLHeader)),
 	})
 
 	if err != nil {
@@ -173,9 +260,28 @@ func (h *BucketHandler) ListBuckets(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Parse the paging extensions; without max-buckets every bucket is listed
+	query := r.URL.Query()
+	maxBuckets := 0
+	if value := query.Get("max-buckets"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > MaxBucketsLimit {
+			writeError(w, S3Error{
+				Code:           "InvalidArgument",
+				Message:        fmt.Sprintf("max-buckets must be an integer between 1 and %d.", MaxBucketsLimit),
+				HTTPStatusCode: http.StatusBadRequest,
+			})
+			return
+		}
+		maxBuckets = n
+	}
+
 	// List buckets
 	output, err := h.bucketService.ListBuckets(ctx, service.ListBucketsInput{
-		OwnerID: userCtx.UserID,
+		OwnerID:           userCtx.UserID,
+		Prefix:            query.Get("prefix"),
+		ContinuationToken: query.Get("continuation-token"),
+		MaxBuckets:        maxBuckets,
 	})
 
 	if err != nil {
@@ -189,18 +295,21 @@ func (h *BucketHandler) ListBuckets(w http.ResponseWriter, r *http.Request) {
 		buckets[i] = BucketInfo{
 			Name:         b.Name,
 			CreationDate: formatS3Time(b.CreatedAt),
+			BucketRegion: b.Region,
 		}
 	}
 
 	response := ListAllMyBucketsResult{
 		Xmlns: "http://s3.amazonaws.com/doc/2006-03-01/",
 		Owner: Owner{
-			ID:          userCtx.Username,
+			ID:          strconv.FormatInt(userCtx.UserID, 10),
 			DisplayName: userCtx.Username,
 		},
 		Buckets: Buckets{
 			Bucket: buckets,
 		},
+		ContinuationToken: output.NextContinuationToken,
+		Prefix:            query.Get("prefix"),
 	}
 
 	writeXML(w, http.StatusOK, response)
@@ -243,9 +352,51 @@ func (h *BucketHandler) HeadBucket(w http.ResponseWriter, r *http.Request) {
 
 	// Success - return 200 with headers
 	w.Header().Set("x-amz-bucket-region", output.Region)
+	if output.ReadOnly {
+		w.Header().Set(ReadOnlyHeader, "true")
+	}
 	w.WriteHeader(http.StatusOK)
 }
 
+// GetBucketLocation handles GET /{bucket}?location requests.
+func (h *BucketHandler) GetBucketLocation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get authenticated user from context
+	userCtx, ok := auth.GetUserContext(ctx)
+	if !ok {
+		h.logger.Error().Msg("no user context found")
+		writeError(w, ErrAccessDenied)
+		return
+	}
+
+	// Extract bucket name from path
+	bucketName := extractBucketName(r)
+	if bucketName == "" {
+		writeError(w, ErrInvalidBucketName)
+		return
+	}
+
+	output, err := h.bucketService.GetBucket(ctx, service.GetBucketInput{
+		Name:    bucketName,
+		OwnerID: userCtx.UserID,
+	})
+	if err != nil {
+		h.handleError(w, err, bucketName)
+		return
+	}
+
+	// Like S3, buckets in us-east-1 report an empty location constraint
+	response := LocationConstraint{
+		Xmlns: "http://s3.amazonaws.com/doc/2006-03-01/",
+	}
+	if output.Bucket.Region != auth.DefaultRegion {
+		response.Region = output.Bucket.Region
+	}
+
+	writeXML(w, http.StatusOK, response)
+}
+
 // GetBucketVersioning handles GET /{bucket}?versioning requests.
 func (h *BucketHandler) GetBucketVersioning(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -288,6 +439,9 @@ func (h *BucketHandler) GetBucketVersioning(w http.ResponseWriter, r *http.Reque
 		response.Status = "Suspended"
 	}
 	// If Disabled, Status element is omitted (empty response body with just the root element)
+	if output.MFADelete {
+		response.MFADelete = "Enabled"
+	}
 
 	writeXML(w, http.StatusOK, response)
 }
@@ -338,13 +492,114 @@ func (h *BucketHandler) PutBucketVersioning(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	// MfaDelete is optional; without it the setting is left unchanged
+	var mfaDelete *bool
+	switch config.MFADelete {
+	case "":
+	case "Enabled", "Disabled":
+		enabled := config.MFADelete == "Enabled"
+		mfaDelete = &enabled
+	default:
+		writeError(w, ErrIllegalVersioningConfigurationException)
+		return
+	}
+
 	// Update versioning
 	err = h.bucketService.PutBucketVersioning(ctx, service.PutBucketVersioningInput{
+		Name:      bucketName,
+		OwnerID:   userCtx.UserID,
+		Status:    status,
+		MFADelete: mfaDelete,
+		MFA:       r.Header.Get(MFAHeader),
+	})
+
+	if err != nil {
+		h.handleError(w, err, bucketName)
+		return
+	}
+
+	// Success - return 200
+	w.WriteHeader(http.StatusOK)
+}
+
+// GetBucketACL handles GET /{bucket}?acl requests.
+func (h *BucketHandler) GetBucketACL(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get authenticated user from context
+	userCtx, ok := auth.GetUserContext(ctx)
+	if !ok {
+		h.logger.Error().Msg("no user context found")
+		writeError(w, ErrAccessDenied)
+		return
+	}
+
+	// Extract bucket name from path
+	bucketName := extractBucketName(r)
+	if bucketName == "" {
+		writeError(w, ErrInvalidBucketName)
+		return
+	}
+
+	output, err := h.bucketService.GetBucketACL(ctx, service.GetBucketACLInput{
 		Name:    bucketName,
 		OwnerID: userCtx.UserID,
-		Status:  status,
 	})
+	if err != nil {
+		h.handleError(w, err, bucketName)
+		return
+	}
+
+	owner := Owner{
+		ID:          strconv.FormatInt(output.OwnerID, 10),
+		DisplayName: userCtx.Username,
+	}
+	response := newAccessControlPolicy(owner, output.ACL.AllowsAnonymousRead(), output.ACL.AllowsAnonymousWrite())
+
+	writeXML(w, http.StatusOK, response)
+}
+
+// PutBucketACL handles PUT /{bucket}?acl requests. The ACL is given either
+// as a canned ACL in the x-amz-acl header or as an access control policy
+// in the body that matches one.
+func (h *BucketHandler) PutBucketACL(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get authenticated user from context
+	userCtx, ok := auth.GetUserContext(ctx)
+	if !ok {
+		h.logger.Error().Msg("no user context found")
+		writeError(w, ErrAccessDenied)
+		return
+	}
 
+	// Extract bucket name from path
+	bucketName := extractBucketName(r)
+	if bucketName == "" {
+		writeError(w, ErrInvalidBucketName)
+		return
+	}
+
+	// Parse request body
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1024*10)) // 10KB limit
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to read request body")
+		writeError(w, ErrInternalError)
+		return
+	}
+	defer r.Body.Close()
+
+	acl, s3Err, ok := requestedACL(r, body, strconv.FormatInt(userCtx.UserID, 10))
+	if !ok {
+		writeError(w, s3Err)
+		return
+	}
+
+	err = h.bucketService.PutBucketACL(ctx, service.PutBucketACLInput{
+		Name:    bucketName,
+		OwnerID: userCtx.UserID,
+		ACL:     domain.BucketACL(acl),
+	})
 	if err != nil {
 		h.handleError(w, err, bucketName)
 		return
@@ -354,10 +609,257 @@ func (h *BucketHandler) PutBucketVersioning(w http.ResponseWriter, r *http.Reque
 	w.WriteHeader(http.StatusOK)
 }
 
+// GetBucketTagging handles GET /{bucket}?tagging requests.
+func (h *BucketHandler) GetBucketTagging(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get authenticated user from context
+	userCtx, ok := auth.GetUserContext(ctx)
+	if !ok {
+		h.logger.Error().Msg("no user context found")
+		writeError(w, ErrAccessDenied)
+		return
+	}
+
+	// Extract bucket name from path
+	bucketName := extractBucketName(r)
+	if bucketName == "" {
+		writeError(w, ErrInvalidBucketName)
+		return
+	}
+
+	tags, err := h.bucketService.GetBucketTagging(ctx, service.BucketTaggingInput{
+		Name:    bucketName,
+		OwnerID: userCtx.UserID,
+	})
+	if err != nil {
+		h.handleError(w, err, bucketName)
+		return
+	}
+
+	response := Tagging{Xmlns: "http://s3.amazonaws.com/doc/2006-03-01/"}
+	for _, tag := range tags {
+		response.TagSet.Tag = append(response.TagSet.Tag, Tag(tag))
+	}
+
+	writeXML(w, http.StatusOK, response)
+}
+
+// PutBucketTagging handles PUT /{bucket}?tagging requests. The tag set
+// replaces the bucket's current tags.
+func (h *BucketHandler) PutBucketTagging(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get authenticated user from context
+	userCtx, ok := auth.GetUserContext(ctx)
+	if !ok {
+		h.logger.Error().Msg("no user context found")
+		writeError(w, ErrAccessDenied)
+		return
+	}
+
+	// Extract bucket name from path
+	bucketName := extractBucketName(r)
+	if bucketName == "" {
+		writeError(w, ErrInvalidBucketName)
+		return
+	}
+
+	// Parse request body
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1024*64)) // 64KB limit
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to read request body")
+		writeError(w, ErrInternalError)
+		return
+	}
+	defer r.Body.Close()
+
+	var request Tagging
+	if err := xml.Unmarshal(body, &request); err != nil {
+		writeError(w, ErrMalformedXML)
+		return
+	}
+
+	tags := make([]domain.Tag, len(request.TagSet.Tag))
+	for i, tag := range request.TagSet.Tag {
+		tags[i] = domain.Tag(tag)
+	}
+	err = h.bucketService.PutBucketTagging(ctx, service.PutBucketTaggingInput{
+		Name:    bucketName,
+		OwnerID: userCtx.UserID,
+		Tags:    tags,
+	})
+	if err != nil {
+		h.handleError(w, err, bucketName)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteBucketTagging handles DELETE /{bucket}?tagging requests.
+func (h *BucketHandler) DeleteBucketTagging(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get authenticated user from context
+	userCtx, ok := auth.GetUserContext(ctx)
+	if !ok {
+		h.logger.Error().Msg("no user context found")
+		writeError(w, ErrAccessDenied)
+		return
+	}
+
+	// Extract bucket name from path
+	bucketName := extractBucketName(r)
+	if bucketName == "" {
+		writeError(w, ErrInvalidBucketName)
+		return
+	}
+
+	err := h.bucketService.DeleteBucketTagging(ctx, service.BucketTaggingInput{
+		Name:    bucketName,
+		OwnerID: userCtx.UserID,
+	})
+	if err != nil {
+		h.handleError(w, err, bucketName)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 // =============================================================================
 // Helper Methods
 // =============================================================================
 
+// errGrantHeadersNotImplemented is returned for x-amz-grant-* headers, which
+// grant permissions beyond the canned ACLs.
+var errGrantHeadersNotImplemented = S3Error{
+	Code:           "NotImplemented",
+	Message:        "Explicit grant headers are not supported; use the x-amz-acl header with a canned ACL.",
+	HTTPStatusCode: http.StatusNotImplemented,
+}
+
+// errUnsupportedGrants is returned for access control policies that do not
+// match a canned ACL.
+var errUnsupportedGrants = S3Error{
+	Code:           "InvalidArgument",
+	Message:        "Only the grants of the private, public-read and public-read-write canned ACLs are supported.",
+	HTTPStatusCode: http.StatusBadRequest,
+}
+
+// hasGrantHeaders reports whether the request sets any x-amz-grant-* header.
+func hasGrantHeaders(r *http.Request) bool {
+	for name := range r.Header {
+		if strings.HasPrefix(strings.ToLower(name), "x-amz-grant-") {
+			return true
+		}
+	}
+	return false
+}
+
+// newGrantee returns a grantee of the given xsi:type.
+func newGrantee(granteeType, id, displayName, uri string) Grantee {
+	return Grantee{
+		XMLNSXSI:    "http://www.w3.org/2001/XMLSchema-instance",
+		Type:        granteeType,
+		ID:          id,
+		DisplayName: displayName,
+		URI:         uri,
+	}
+}
+
+// newAccessControlPolicy returns the access control policy of a canned ACL:
+// FULL_CONTROL for the owner, and READ and WRITE for all users as allowed.
+func newAccessControlPolicy(owner Owner, read, write bool) AccessControlPolicy {
+	policy := AccessControlPolicy{
+		Xmlns: "http://s3.amazonaws.com/doc/2006-03-01/",
+		Owner: owner,
+	}
+	policy.AccessControlList.Grant = append(policy.AccessControlList.Grant, Grant{
+		Grantee:    newGrantee(granteeCanonicalUser, owner.ID, owner.DisplayName, ""),
+		Permission: "FULL_CONTROL",
+	})
+	if read {
+		policy.AccessControlList.Grant = append(policy.AccessControlList.Grant, Grant{
+			Grantee:    newGrantee(granteeGroup, "", "", allUsersURI),
+			Permission: "READ",
+		})
+	}
+	if write {
+		policy.AccessControlList.Grant = append(policy.AccessControlList.Grant, Grant{
+			Grantee:    newGrantee(granteeGroup, "", "", allUsersURI),
+			Permission: "WRITE",
+		})
+	}
+	return policy
+}
+
+// requestedACL returns the canned ACL set by a PutBucketAcl or PutObjectAcl
+// request, given either in the x-amz-acl header or as an access control
+// policy in the body that matches one. The caller validates the ACL.
+func requestedACL(r *http.Request, body []byte, ownerID string) (string, S3Error, bool) {
+	if hasGrantHeaders(r) {
+		return "", errGrantHeadersNotImplemented, false
+	}
+
+	acl := r.Header.Get(ACLHeader)
+	switch {
+	case acl != "" && len(body) > 0:
+		return "", S3Error{
+			Code:           "UnexpectedContent",
+			Message:        "This request does not support content when the x-amz-acl header is set.",
+			HTTPStatusCode: http.StatusBadRequest,
+		}, false
+	case acl == "" && len(body) == 0:
+		return "", S3Error{
+			Code:           "MissingSecurityHeader",
+			Message:        "Your request was missing a required header: x-amz-acl.",
+			HTTPStatusCode: http.StatusBadRequest,
+		}, false
+	case len(body) > 0:
+		var policy AccessControlPolicy
+		if err := xml.Unmarshal(body, &policy); err != nil {
+			return "", ErrMalformedXML, false
+		}
+		canned, ok := cannedACL(policy, ownerID)
+		if !ok {
+			return "", errUnsupportedGrants, false
+		}
+		acl = string(canned)
+	}
+	return acl, S3Error{}, true
+}
+
+// cannedACL returns the canned ACL whose grants an access control policy
+// lists. Grants of FULL_CONTROL to the owner are implied and may be omitted.
+func cannedACL(policy AccessControlPolicy, ownerID string) (domain.BucketACL, bool) {
+	var read, write bool
+	for _, grant := range policy.AccessControlList.Grant {
+		switch {
+		case grant.Grantee.URI == allUsersURI && grant.Permission == "READ":
+			read = true
+		case grant.Grantee.URI == allUsersURI && grant.Permission == "WRITE":
+			write = true
+		case grant.Grantee.URI == "" && grant.Grantee.ID == ownerID && grant.Permission == "FULL_CONTROL":
+		default:
+			return "", false
+		}
+	}
+
+	switch {
+	case read && write:
+		return domain.ACLPublicReadWrite, true
+	case read:
+		return domain.ACLPublicRead, true
+	case write:
+		// Anonymous write without read has no canned ACL
+		return "", false
+	default:
+		return domain.ACLPrivate, true
+	}
+}
+
 // extractBucketName extracts the bucket name from the request path.
 // Supports both path-style (/{bucket}) and virtual-hosted style (bucket.host.com).
 func extractBucketName(r *http.Request) string {
@@ -373,26 +875,8 @@ func extractBucketName(r *http.Request) string {
 
 // handleError maps service errors to S3 error responses.
 func (h *BucketHandler) handleError(w http.ResponseWriter, err error, resource string) {
-	s3Err := ErrInternalError
-	s3Err.Resource = resource
-
-	switch {
-	case errors.Is(err, domain.ErrBucketNotFound):
-		s3Err = ErrNoSuchBucket
-	case errors.Is(err, domain.ErrBucketAlreadyExists):
-		s3Err = ErrBucketAlreadyExists
-	case errors.Is(err, domain.ErrBucketNotEmpty):
-		s3Err = ErrBucketNotEmpty
-	case errors.Is(err, domain.ErrBucketNameLength),
-		errors.Is(err, domain.ErrBucketNameFormat),
-		errors.Is(err, domain.ErrBucketNameIPFormat):
-		s3Err = ErrInvalidBucketName
-		s3Err.Message = err.Error()
-	case errors.Is(err, service.ErrBucketAccessDenied):
-		s3Err = ErrAccessDenied
-	case errors.Is(err, service.ErrInvalidVersioningStatus):
-		s3Err = ErrIllegalVersioningConfigurationException
-	default:
+	s3Err, ok := s3ErrorFor(err)
+	if !ok {
 		h.logger.Error().Err(err).Str("resource", resource).Msg("unhandled error")
 	}
 
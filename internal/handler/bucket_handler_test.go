@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+)
+
+func TestCannedACL(t *testing.T) {
+	owner := Grant{Grantee: newGrantee(granteeCanonicalUser, "1", "alice", ""), Permission: "FULL_CONTROL"}
+	allUsers := func(permission string) Grant {
+		return Grant{Grantee: newGrantee(granteeGroup, "", "", allUsersURI), Permission: permission}
+	}
+
+	tests := []struct {
+		name   string
+		grants []Grant
+		want   domain.BucketACL
+		ok     bool
+	}{
+		{"owner only", []Grant{owner}, domain.ACLPrivate, true},
+		{"no grants", nil, domain.ACLPrivate, true},
+		{"public read", []Grant{owner, allUsers("READ")}, domain.ACLPublicRead, true},
+		{"public read write", []Grant{owner, allUsers("READ"), allUsers("WRITE")}, domain.ACLPublicReadWrite, true},
+		{"write only", []Grant{owner, allUsers("WRITE")}, "", false},
+		{"other user", []Grant{owner, {Grantee: newGrantee(granteeCanonicalUser, "2", "", ""), Permission: "READ"}}, "", false},
+		{"read ACP", []Grant{owner, allUsers("READ_ACP")}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Round trip through XML, as the grants arrive in a request body
+			body, err := xml.Marshal(AccessControlPolicy{AccessControlList: AccessControlList{Grant: tt.grants}})
+			require.NoError(t, err)
+			var policy AccessControlPolicy
+			require.NoError(t, xml.Unmarshal(body, &policy))
+
+			acl, ok := cannedACL(policy, "1")
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, acl)
+		})
+	}
+}
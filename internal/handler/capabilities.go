@@ -0,0 +1,210 @@
+// Package handler provides HTTP handlers for Alexander Storage API.
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/service"
+)
+
+// CapabilitiesPath is the path of the capability discovery endpoint.
+// The leading underscore cannot occur in a valid bucket name, so it never
+// shadows an S3 request.
+const CapabilitiesPath = "/_alexander/capabilities"
+
+// supportedOperations lists the S3 operations routed by Router.
+var supportedOperations = []string{
+	"ListBuckets",
+	"CreateBucket",
+	"HeadBucket",
+	"DeleteBucket",
+	"GetBucketVersioning",
+	"PutBucketVersioning",
+	"GetBucketAcl",
+	"PutBucketAcl",
+	"GetBucketTagging",
+	"PutBucketTagging",
+	"DeleteBucketTagging",
+	"ListObjects",
+	"ListObjectsV2",
+	"ListObjectVersions",
+	"PutObject",
+	"GetObject",
+	"HeadObject",
+	"DeleteObject",
+	"CopyObject",
+	"RenameObject",
+	"GetObjectAcl",
+	"PutObjectAcl",
+	"CreateMultipartUpload",
+	"UploadPart",
+	"CompleteMultipartUpload",
+	"AbortMultipartUpload",
+	"ListParts",
+	"ListMultipartUploads",
+}
+
+// replicationOperations lists the bucket replication operations, which are
+// only served when replication is enabled.
+var replicationOperations = []string{
+	"GetBucketReplication",
+	"PutBucketReplication",
+	"DeleteBucketReplication",
+}
+
+// inventoryOperations lists the bucket inventory operations, which are only
+// served when inventory reports are enabled.
+var inventoryOperations = []string{
+	"GetBucketInventoryConfiguration",
+	"ListBucketInventoryConfigurations",
+	"PutBucketInventoryConfiguration",
+	"DeleteBucketInventoryConfiguration",
+}
+
+// websiteOperations lists the bucket website operations, which are only
+// served when website hosting is enabled.
+var websiteOperations = []string{
+	"GetBucketWebsite",
+	"PutBucketWebsite",
+	"DeleteBucketWebsite",
+}
+
+// notImplementedOperations lists operations that are recognised but answered
+// with NotImplemented, so clients can skip them instead of probing.
+var notImplementedOperations = []string{
+	"GetBucketAccelerateConfiguration",
+	"PutBucketAccelerateConfiguration",
+}
+
+// supportedExtensions lists behaviour beyond the core operations that clients may rely on.
+var supportedExtensions = []string{
+	"versioning",
+	"get-object-part-number",
+	"range-requests",
+	"content-addressable-deduplication",
+	"object-sequence",
+	"list-from-primary",
+	"resumable-uploads",
+}
+
+// Capabilities describes the operations, limits and extensions supported by this server.
+type Capabilities struct {
+	Version        string           `json:"version"`
+	Operations     []string         `json:"operations"`
+	NotImplemented []string         `json:"not_implemented"`
+	Limits         CapabilityLimits `json:"limits"`
+	Extensions     []string         `json:"extensions"`
+}
+
+// CapabilityLimits describes request size and pagination limits.
+type CapabilityLimits struct {
+	MaxKeys            int   `json:"max_keys"`
+	MaxUploads         int   `json:"max_uploads"`
+	MaxParts           int   `json:"max_parts"`
+	MaxPartNumber      int   `json:"max_part_number"`
+	MaxPartsPerUpload  int   `json:"max_parts_per_upload"`
+	MinPartSize        int64 `json:"min_part_size"`
+	MaxPartSize        int64 `json:"max_part_size"`
+	MaxObjectKeyLength int   `json:"max_object_key_length"`
+	MaxBodySize        int64 `json:"max_body_size,omitempty"`
+}
+
+// CapabilitiesConfig contains capabilities handler configuration.
+type CapabilitiesConfig struct {
+	// Version is the server version reported to clients.
+	Version string
+
+	// MaxBodySize is the configured maximum request body size (0 = unlimited).
+	MaxBodySize int64
+
+	// Replication reports whether bucket replication is enabled.
+	Replication bool
+
+	// Inventory reports whether bucket inventory reports are enabled.
+	Inventory bool
+
+	// Website reports whether bucket website hosting is enabled.
+	Website bool
+
+	// Restore reports whether archived objects can be restored, which
+	// requires the tiering controller.
+	Restore bool
+
+	// Multipart is the configured multipart upload limits (zero = defaults).
+	Multipart service.MultipartLimits
+}
+
+// CapabilitiesHandler serves the capability discovery endpoint.
+type CapabilitiesHandler struct {
+	capabilities Capabilities
+}
+
+// NewCapabilitiesHandler creates a new CapabilitiesHandler.
+func NewCapabilitiesHandler(config CapabilitiesConfig) *CapabilitiesHandler {
+	operations := supportedOperations
+	notImplemented := notImplementedOperations
+	if config.Replication {
+		operations = append(slices.Clone(operations), replicationOperations...)
+	} else {
+		notImplemented = append(slices.Clone(notImplemented), replicationOperations...)
+	}
+	if config.Inventory {
+		operations = append(slices.Clone(operations), inventoryOperations...)
+	} else {
+		notImplemented = append(slices.Clone(notImplemented), inventoryOperations...)
+	}
+	if config.Website {
+		operations = append(slices.Clone(operations), websiteOperations...)
+	} else {
+		notImplemented = append(slices.Clone(notImplemented), websiteOperations...)
+	}
+	if config.Restore {
+		operations = append(slices.Clone(operations), "RestoreObject")
+	} else {
+		notImplemented = append(slices.Clone(notImplemented), "RestoreObject")
+	}
+
+	multipart := config.Multipart
+	if multipart == (service.MultipartLimits{}) {
+		multipart = service.DefaultMultipartLimits()
+	}
+
+	return &CapabilitiesHandler{
+		capabilities: Capabilities{
+			Version:        config.Version,
+			Operations:     operations,
+			NotImplemented: notImplemented,
+			Limits: CapabilityLimits{
+				MaxKeys:            maxListKeys,
+				MaxUploads:         maxListKeys,
+				MaxParts:           maxListKeys,
+				MaxPartNumber:      domain.MaxPartNumber,
+				MaxPartsPerUpload:  multipart.MaxParts,
+				MinPartSize:        multipart.MinPartSize,
+				MaxPartSize:        multipart.MaxPartSize,
+				MaxObjectKeyLength: domain.MaxObjectKeyLength,
+				MaxBodySize:        config.MaxBodySize,
+			},
+			Extensions: supportedExtensions,
+		},
+	}
+}
+
+// HandleCapabilities handles GET /_alexander/capabilities requests.
+func (h *CapabilitiesHandler) HandleCapabilities(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "max-age=300")
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodGet {
+		json.NewEncoder(w).Encode(h.capabilities)
+	}
+}
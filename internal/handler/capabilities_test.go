@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/service"
+)
+
+// newCapabilitiesRouter returns a router that serves capability discovery
+// and lets every request through authentication.
+func newCapabilitiesRouter(config CapabilitiesConfig) http.Handler {
+	return NewRouter(RouterConfig{
+		Capabilities:   NewCapabilitiesHandler(config),
+		AuthMiddleware: func(next http.Handler) http.Handler { return next },
+	}).Handler()
+}
+
+func TestCapabilitiesHandler(t *testing.T) {
+	h := newCapabilitiesRouter(CapabilitiesConfig{
+		Version:     "1.2.3",
+		MaxBodySize: 1 << 20,
+		Replication: true,
+		Multipart:   service.MultipartLimits{MinPartSize: 1 << 10, MaxPartSize: 1 << 30, MaxParts: 100},
+	})
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, CapabilitiesPath, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var caps Capabilities
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&caps))
+	assert.Equal(t, "1.2.3", caps.Version)
+	assert.Contains(t, caps.Operations, "PutBucketReplication", "enabled features are listed as operations")
+	assert.Contains(t, caps.NotImplemented, "PutBucketWebsite", "disabled features are listed as not implemented")
+	assert.Contains(t, caps.NotImplemented, "PutBucketAccelerateConfiguration")
+	assert.Contains(t, caps.NotImplemented, "RestoreObject", "restores need the tiering controller")
+	assert.Equal(t, CapabilityLimits{
+		MaxKeys:            1000,
+		MaxUploads:         1000,
+		MaxParts:           1000,
+		MaxPartNumber:      10000,
+		MaxPartsPerUpload:  100,
+		MinPartSize:        1 << 10,
+		MaxPartSize:        1 << 30,
+		MaxObjectKeyLength: 1024,
+		MaxBodySize:        1 << 20,
+	}, caps.Limits)
+	assert.Contains(t, caps.Extensions, "get-object-part-number")
+
+	// Without configured multipart limits the S3 defaults are reported
+	w = httptest.NewRecorder()
+	newCapabilitiesRouter(CapabilitiesConfig{}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, CapabilitiesPath, nil))
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&caps))
+	assert.Equal(t, int64(5<<20), caps.Limits.MinPartSize)
+	assert.Equal(t, 10000, caps.Limits.MaxPartsPerUpload)
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, CapabilitiesPath, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, "GET, HEAD", w.Header().Get("Allow"))
+}
+
+func TestRouter_AccelerateIsNotImplemented(t *testing.T) {
+	h := newCapabilitiesRouter(CapabilitiesConfig{})
+	for _, method := range []string{http.MethodGet, http.MethodPut} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(method, "/photos?accelerate", nil))
+		assert.Equal(t, http.StatusNotImplemented, w.Code, method)
+		assert.Contains(t, w.Body.String(), "<Code>NotImplemented</Code>", method)
+		assert.Contains(t, w.Body.String(), "<Resource>/photos</Resource>", method)
+	}
+}
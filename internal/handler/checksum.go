@@ -0,0 +1,145 @@
+// Package handler provides HTTP handlers for Alexander Storage API.
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+)
+
+const (
+	// ChecksumAlgorithmHeader names the additional checksum algorithm of an
+	// upload, e.g. CRC32C.
+	ChecksumAlgorithmHeader = "x-amz-checksum-algorithm"
+
+	// SDKChecksumAlgorithmHeader is sent by AWS SDKs with the checksum of an
+	// upload, naming its algorithm.
+	SDKChecksumAlgorithmHeader = "x-amz-sdk-checksum-algorithm"
+
+	// ChecksumModeHeader set to ENABLED asks GetObject and HeadObject to
+	// return the object's checksum.
+	ChecksumModeHeader = "x-amz-checksum-mode"
+
+	// ChecksumTypeHeader reports whether a returned checksum covers the
+	// full object or is the composite checksum of its parts.
+	ChecksumTypeHeader = "x-amz-checksum-type"
+)
+
+// Checksums holds the checksum elements of S3 XML documents, of which at
+// most one is set.
+type Checksums struct {
+	ChecksumCRC32  string `xml:"ChecksumCRC32,omitempty"`
+	ChecksumCRC32C string `xml:"ChecksumCRC32C,omitempty"`
+	ChecksumSHA1   string `xml:"ChecksumSHA1,omitempty"`
+	ChecksumSHA256 string `xml:"ChecksumSHA256,omitempty"`
+}
+
+// newChecksums returns the checksum elements for a checksum.
+func newChecksums(algorithm domain.ChecksumAlgorithm, checksum string) Checksums {
+	var c Checksums
+	switch algorithm {
+	case domain.ChecksumCRC32:
+		c.ChecksumCRC32 = checksum
+	case domain.ChecksumCRC32C:
+		c.ChecksumCRC32C = checksum
+	case domain.ChecksumSHA1:
+		c.ChecksumSHA1 = checksum
+	case domain.ChecksumSHA256:
+		c.ChecksumSHA256 = checksum
+	}
+	return c
+}
+
+// value returns the checksum that is set, if any.
+func (c Checksums) value() string {
+	for _, checksum := range []string{c.ChecksumCRC32, c.ChecksumCRC32C, c.ChecksumSHA1, c.ChecksumSHA256} {
+		if checksum != "" {
+			return checksum
+		}
+	}
+	return ""
+}
+
+// checksumHeader returns the name of the header carrying a checksum of the
+// algorithm, e.g. x-amz-checksum-crc32c.
+func checksumHeader(algorithm domain.ChecksumAlgorithm) string {
+	return "x-amz-checksum-" + strings.ToLower(string(algorithm))
+}
+
+// parseChecksumHeaders parses the checksum headers of an upload. A
+// checksum is sent in the x-amz-checksum-* header of its algorithm;
+// x-amz-checksum-algorithm or x-amz-sdk-checksum-algorithm alone asks for a
+// checksum to be computed. It returns an empty algorithm if no checksum was
+// requested.
+func parseChecksumHeaders(r *http.Request) (domain.ChecksumAlgorithm, string, S3Error, bool) {
+	var algorithm domain.ChecksumAlgorithm
+	var checksum string
+	for _, candidate := range domain.ChecksumAlgorithms {
+		value := r.Header.Get(checksumHeader(candidate))
+		if value == "" {
+			continue
+		}
+		if algorithm != "" {
+			return "", "", S3Error{
+				Code:           "InvalidRequest",
+				Message:        "Expecting a single x-amz-checksum- header. Multiple checksum Types are not allowed.",
+				HTTPStatusCode: http.StatusBadRequest,
+			}, false
+		}
+		algorithm, checksum = candidate, value
+	}
+
+	for _, header := range []string{ChecksumAlgorithmHeader, SDKChecksumAlgorithmHeader} {
+		name := r.Header.Get(header)
+		if name == "" {
+			continue
+		}
+		named, err := domain.ParseChecksumAlgorithm(name)
+		if err != nil {
+			return "", "", S3Error{
+				Code:           "InvalidRequest",
+				Message:        "Value for " + header + " header is invalid.",
+				HTTPStatusCode: http.StatusBadRequest,
+			}, false
+		}
+		if algorithm != "" && named != algorithm {
+			return "", "", S3Error{
+				Code:           "InvalidRequest",
+				Message:        "Value for " + header + " header does not match the checksum header.",
+				HTTPStatusCode: http.StatusBadRequest,
+			}, false
+		}
+		algorithm = named
+	}
+
+	return algorithm, checksum, S3Error{}, true
+}
+
+// checksumModeEnabled reports whether a GetObject or HeadObject request
+// asks for the object's checksum.
+func checksumModeEnabled(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get(ChecksumModeHeader), "ENABLED")
+}
+
+// setChecksumHeader sets the x-amz-checksum-* header of a checksum, if any.
+func setChecksumHeader(w http.ResponseWriter, algorithm domain.ChecksumAlgorithm, checksum string) {
+	if algorithm != "" && checksum != "" {
+		w.Header().Set(checksumHeader(algorithm), checksum)
+	}
+}
+
+// setObjectChecksumHeaders sets the checksum headers of a GetObject or
+// HeadObject response. Checksums cover whole objects, so none is returned
+// for range and part requests.
+func setObjectChecksumHeaders(w http.ResponseWriter, r *http.Request, algorithm domain.ChecksumAlgorithm, checksum, contentRange string) {
+	if !checksumModeEnabled(r) || checksum == "" || contentRange != "" {
+		return
+	}
+	setChecksumHeader(w, algorithm, checksum)
+	if domain.IsCompositeChecksum(checksum) {
+		w.Header().Set(ChecksumTypeHeader, "COMPOSITE")
+	} else {
+		w.Header().Set(ChecksumTypeHeader, "FULL_OBJECT")
+	}
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+)
+
+func TestParseChecksumHeaders(t *testing.T) {
+	tests := []struct {
+		name          string
+		headers       map[string]string
+		wantAlgorithm domain.ChecksumAlgorithm
+		wantChecksum  string
+		wantOK        bool
+	}{
+		{"none", nil, "", "", true},
+		{"checksum", map[string]string{"x-amz-checksum-crc32c": "yZRlqg=="}, domain.ChecksumCRC32C, "yZRlqg==", true},
+		{"sdk algorithm", map[string]string{"x-amz-checksum-crc32": "NhCmhg==", SDKChecksumAlgorithmHeader: "crc32"}, domain.ChecksumCRC32, "NhCmhg==", true},
+		{"algorithm only", map[string]string{ChecksumAlgorithmHeader: "SHA256"}, domain.ChecksumSHA256, "", true},
+		{"unknown algorithm", map[string]string{ChecksumAlgorithmHeader: "MD5"}, "", "", false},
+		{"conflicting algorithm", map[string]string{"x-amz-checksum-sha1": "x", SDKChecksumAlgorithmHeader: "CRC32"}, "", "", false},
+		{"two checksums", map[string]string{"x-amz-checksum-crc32": "a", "x-amz-checksum-sha1": "b"}, "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("PUT", "/photos/cat.jpg", nil)
+			for name, value := range tt.headers {
+				r.Header.Set(name, value)
+			}
+			algorithm, checksum, s3Err, ok := parseChecksumHeaders(r)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantAlgorithm, algorithm)
+			assert.Equal(t, tt.wantChecksum, checksum)
+			if !ok {
+				assert.Equal(t, "InvalidRequest", s3Err.Code)
+			}
+		})
+	}
+}
+
+func TestSetObjectChecksumHeaders(t *testing.T) {
+	r := httptest.NewRequest("GET", "/photos/big.iso", nil)
+	w := httptest.NewRecorder()
+	setObjectChecksumHeaders(w, r, domain.ChecksumSHA256, "abc=-2", "")
+	assert.Empty(t, w.Header().Get("x-amz-checksum-sha256"), "checksums are returned on request")
+
+	r.Header.Set(ChecksumModeHeader, "ENABLED")
+	setObjectChecksumHeaders(w, r, domain.ChecksumSHA256, "abc=-2", "")
+	assert.Equal(t, "abc=-2", w.Header().Get("x-amz-checksum-sha256"))
+	assert.Equal(t, "COMPOSITE", w.Header().Get(ChecksumTypeHeader))
+
+	w = httptest.NewRecorder()
+	setObjectChecksumHeaders(w, r, domain.ChecksumCRC32, "NhCmhg==", "bytes 0-1/4")
+	assert.Empty(t, w.Header().Get("x-amz-checksum-crc32"), "ranges have no checksum")
+}
@@ -5,8 +5,13 @@ import (
 	"encoding/xml"
 	"net/http"
 	"time"
+
+	"github.com/prn-tf/alexander-storage/internal/middleware"
 )
 
+// maxListKeys is the default and maximum number of entries returned by a single list request.
+const maxListKeys = 1000
+
 // Common S3 XML response types
 
 // Owner represents the owner of a bucket or object.
@@ -24,13 +29,19 @@ func writeXML(w http.ResponseWriter, statusCode int, v interface{}) {
 	enc.Encode(v)
 }
 
-// writeError writes an S3-compatible error response.
+// writeError writes an S3-compatible error response. The request and host
+// IDs default to those the tracing middleware set on the response, so SDKs
+// can report them.
 func writeError(w http.ResponseWriter, err S3Error) {
+	if err.RequestID == "" {
+		err.RequestID = w.Header().Get(middleware.HeaderAmzRequestID)
+	}
 	writeXML(w, err.HTTPStatusCode, ErrorResponse{
 		Code:      err.Code,
 		Message:   err.Message,
 		Resource:  err.Resource,
 		RequestID: err.RequestID,
+		HostID:    w.Header().Get(middleware.HeaderAmzID2),
 	})
 }
 
@@ -41,6 +52,7 @@ type ErrorResponse struct {
 	Message   string   `xml:"Message"`
 	Resource  string   `xml:"Resource,omitempty"`
 	RequestID string   `xml:"RequestId,omitempty"`
+	HostID    string   `xml:"HostId,omitempty"`
 }
 
 // S3Error represents an S3-compatible error.
@@ -102,11 +114,29 @@ var (
 		HTTPStatusCode: http.StatusBadRequest,
 	}
 
+	ErrNotImplemented = S3Error{
+		Code:           "NotImplemented",
+		Message:        "A header or query you provided implies functionality that is not implemented.",
+		HTTPStatusCode: http.StatusNotImplemented,
+	}
+
+	ErrInvalidLocationConstraint = S3Error{
+		Code:           "InvalidLocationConstraint",
+		Message:        "The specified location constraint is not valid.",
+		HTTPStatusCode: http.StatusBadRequest,
+	}
+
 	ErrIllegalVersioningConfigurationException = S3Error{
 		Code:           "IllegalVersioningConfigurationException",
 		Message:        "The versioning configuration specified in the request is invalid.",
 		HTTPStatusCode: http.StatusBadRequest,
 	}
+
+	ErrResidencyViolation = S3Error{
+		Code:           "AccessDenied",
+		Message:        "The bucket's data residency does not permit storing its data on this server.",
+		HTTPStatusCode: http.StatusForbidden,
+	}
 )
 
 // formatS3Time formats a time in S3's expected format.
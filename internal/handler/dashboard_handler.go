@@ -2,13 +2,23 @@
 package handler
 
 import (
+	"context"
+	"crypto/subtle"
 	"embed"
+	"errors"
+	"fmt"
 	"html/template"
+	"io/fs"
+	"net"
 	"net/http"
+	"path"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 
 	"github.com/prn-tf/alexander-storage/internal/domain"
@@ -25,7 +35,12 @@ type DashboardHandler struct {
 	userService      *service.UserService
 	bucketService    *service.BucketService
 	lifecycleService *service.LifecycleService
-	templates        *template.Template
+	objectService    *service.ObjectService
+	iamService       *service.IAMService
+	statsService     *service.StatsService
+	oidc             *service.OIDCAuthenticator
+	audit            *service.AuditService
+	templates        map[string]*template.Template
 	logger           zerolog.Logger
 }
 
@@ -35,13 +50,17 @@ type DashboardConfig struct {
 	UserService      *service.UserService
 	BucketService    *service.BucketService
 	LifecycleService *service.LifecycleService
+	ObjectService    *service.ObjectService
+	IAMService       *service.IAMService
+	StatsService     *service.StatsService      // Optional; enables the statistics page
+	OIDC             *service.OIDCAuthenticator // Optional; enables single sign-on
+	Audit            *service.AuditService      // Optional; records changes in the audit log
 	Logger           zerolog.Logger
 }
 
 // NewDashboardHandler creates a new dashboard handler.
 func NewDashboardHandler(cfg DashboardConfig) (*DashboardHandler, error) {
-	// Parse templates
-	tmpl, err := template.ParseFS(templateFS, "templates/*.html")
+	tmpl, err := parseTemplates()
 	if err != nil {
 		return nil, err
 	}
@@ -51,11 +70,47 @@ func NewDashboardHandler(cfg DashboardConfig) (*DashboardHandler, error) {
 		userService:      cfg.UserService,
 		bucketService:    cfg.BucketService,
 		lifecycleService: cfg.LifecycleService,
+		objectService:    cfg.ObjectService,
+		iamService:       cfg.IAMService,
+		statsService:     cfg.StatsService,
+		oidc:             cfg.OIDC,
+		audit:            cfg.Audit,
 		templates:        tmpl,
 		logger:           cfg.Logger.With().Str("handler", "dashboard").Logger(),
 	}, nil
 }
 
+// statsHistoryDays is how many days of storage usage the statistics page shows.
+const statsHistoryDays = 30
+
+// templateFuncs are the functions available to the templates.
+var templateFuncs = template.FuncMap{
+	"bytes": formatBytes,
+}
+
+// parseTemplates parses each page together with the base layout. Pages are
+// parsed separately because each defines its own "content" template.
+func parseTemplates() (map[string]*template.Template, error) {
+	pages, err := fs.Glob(templateFS, "templates/*.html")
+	if err != nil {
+		return nil, err
+	}
+
+	templates := make(map[string]*template.Template, len(pages))
+	for _, page := range pages {
+		name := path.Base(page)
+		if name == "base.html" {
+			continue
+		}
+		tmpl, err := template.New(name).Funcs(templateFuncs).ParseFS(templateFS, "templates/base.html", page)
+		if err != nil {
+			return nil, err
+		}
+		templates[name] = tmpl
+	}
+	return templates, nil
+}
+
 // =============================================================================
 // Template Data Structs
 // =============================================================================
@@ -64,6 +119,7 @@ func NewDashboardHandler(cfg DashboardConfig) (*DashboardHandler, error) {
 type PageData struct {
 	Title     string
 	Username  string
+	Role      domain.UserRole
 	Error     string
 	Success   string
 	CSRFToken string
@@ -72,12 +128,44 @@ type PageData struct {
 // LoginPageData contains login page data.
 type LoginPageData struct {
 	PageData
+	SSOEnabled bool
+
+	// PasswordChange asks Username for a new password because the current
+	// one must be changed before login.
+	PasswordChange bool
+	Username       string
+	RememberMe     bool
 }
 
 // DashboardPageData contains main dashboard page data.
 type DashboardPageData struct {
 	PageData
-	Buckets []*domain.Bucket
+	BucketListData
+	Regions []string
+
+	// NewBucket holds the values of a create bucket form that failed.
+	NewBucket NewBucketForm
+}
+
+// BucketListData contains a page of the bucket list, filtered by Prefix.
+// NextContinuationToken is set if there are more pages.
+type BucketListData struct {
+	Buckets               []*domain.Bucket
+	Prefix                string
+	NextContinuationToken string
+}
+
+// NewBucketForm contains the values of the create bucket form.
+type NewBucketForm struct {
+	Name       string
+	Region     string
+	Versioning bool
+}
+
+// BucketNameCheck is the feedback on a bucket name typed into the create form.
+type BucketNameCheck struct {
+	Valid   bool
+	Message string
 }
 
 // BucketDetailPageData contains bucket detail page data.
@@ -85,12 +173,68 @@ type BucketDetailPageData struct {
 	PageData
 	Bucket         *domain.Bucket
 	LifecycleRules []*domain.LifecycleRule
+	Defaults       domain.ObjectDefaults // The bucket's object defaults, zero if it has none
+	ContentTypes   string                // Defaults.ContentTypes as edited on the form
+	StorageClasses []domain.StorageClass
+}
+
+// ObjectVersionsPageData contains the version list page data.
+type ObjectVersionsPageData struct {
+	PageData
+	Bucket              *domain.Bucket
+	Prefix              string
+	Versions            []ObjectVersionRow
+	NextKeyMarker       string
+	NextVersionIDMarker string
+}
+
+// ObjectVersionRow is a version or delete marker in the version list.
+type ObjectVersionRow struct {
+	Key          string
+	VersionID    string
+	IsLatest     bool
+	DeleteMarker bool
+	Size         int64
+	LastModified time.Time
 }
 
 // UsersPageData contains users management page data.
 type UsersPageData struct {
 	PageData
-	Users []*domain.User
+	Users  []*domain.User
+	UserID int64
+	Roles  []domain.UserRole
+}
+
+// KeysPageData contains the access keys page data.
+type KeysPageData struct {
+	PageData
+	Keys []*domain.AccessKey
+
+	// Created is the key just created, whose secret is shown once.
+	Created *service.CreateAccessKeyOutput
+}
+
+// SessionsPageData contains the sessions page data.
+type SessionsPageData struct {
+	PageData
+	Sessions []*domain.SessionInfo
+}
+
+// StatsPageData contains the statistics page data.
+type StatsPageData struct {
+	PageData
+	Stats   *service.SystemStats
+	History []StorageBar
+}
+
+// StorageBar is a day in the storage usage chart.
+type StorageBar struct {
+	Day   time.Time
+	Bytes int64
+
+	// Percent is the height of the bar relative to the largest day.
+	Percent int
 }
 
 // =============================================================================
@@ -103,20 +247,49 @@ func (h *DashboardHandler) RegisterRoutes(r chi.Router) {
 	r.Get("/dashboard/login", h.handleLoginPage)
 	r.Post("/dashboard/login", h.handleLogin)
 	r.Post("/dashboard/logout", h.handleLogout)
+	r.Get("/dashboard/oidc/login", h.handleOIDCLogin)
+	r.Get("/dashboard/oidc/callback", h.handleOIDCCallback)
+
+	// Viewing (read-only and above)
+	r.Group(func(r chi.Router) {
+		r.Use(h.requireRole(domain.RoleReadOnly), h.requireCSRF)
+		r.Get("/dashboard", h.handleDashboard)
+		r.Get("/dashboard/buckets", h.handleBucketList)
+		r.Get("/dashboard/buckets/{name}", h.handleBucketDetail)
+		r.Get("/dashboard/buckets/{name}/versions", h.handleObjectVersions)
+
+		// Every dashboard user manages their own access keys
+		r.Get("/dashboard/keys", h.handleKeyList)
+		r.Post("/dashboard/keys", h.handleCreateKey)
+		r.Delete("/dashboard/keys/{accessKeyID}", h.handleDeleteKey)
+
+		// ... and their own sessions
+		r.Get("/dashboard/sessions", h.handleSessionList)
+		r.Delete("/dashboard/sessions/{id}", h.handleRevokeSession)
+	})
 
-	// Bucket management
-	r.Get("/dashboard/buckets", h.handleBucketList)
-	r.Get("/dashboard/buckets/{name}", h.handleBucketDetail)
-	r.Post("/dashboard/buckets/{name}/acl", h.handleUpdateBucketACL)
-
-	// Lifecycle management
-	r.Post("/dashboard/buckets/{name}/lifecycle", h.handleCreateLifecycleRule)
-	r.Delete("/dashboard/buckets/{name}/lifecycle/{ruleId}", h.handleDeleteLifecycleRule)
+	// Bucket settings and lifecycle management (operator and above)
+	r.Group(func(r chi.Router) {
+		r.Use(h.requireRole(domain.RoleOperator), h.requireCSRF)
+		r.Post("/dashboard/buckets", h.handleCreateBucket)
+		r.Get("/dashboard/check-bucket-name", h.handleCheckBucketName)
+		r.Delete("/dashboard/buckets/{name}", h.handleDeleteBucket)
+		r.Post("/dashboard/buckets/{name}/acl", h.handleUpdateBucketACL)
+		r.Post("/dashboard/buckets/{name}/defaults", h.handleUpdateObjectDefaults)
+		r.Post("/dashboard/buckets/{name}/lifecycle", h.handleCreateLifecycleRule)
+		r.Delete("/dashboard/buckets/{name}/lifecycle/{ruleId}", h.handleDeleteLifecycleRule)
+		r.Post("/dashboard/buckets/{name}/restore", h.handleRestoreObject)
+	})
 
-	// Users management (admin only)
-	r.Get("/dashboard/users", h.handleUserList)
-	r.Post("/dashboard/users", h.handleCreateUser)
-	r.Delete("/dashboard/users/{id}", h.handleDeleteUser)
+	// Users management and statistics (admin only)
+	r.Group(func(r chi.Router) {
+		r.Use(h.requireRole(domain.RoleAdmin), h.requireCSRF)
+		r.Get("/dashboard/stats", h.handleStats)
+		r.Get("/dashboard/users", h.handleUserList)
+		r.Post("/dashboard/users", h.handleCreateUser)
+		r.Post("/dashboard/users/{id}/role", h.handleSetUserRole)
+		r.Delete("/dashboard/users/{id}", h.handleDeleteUser)
+	})
 }
 
 // =============================================================================
@@ -128,6 +301,7 @@ func (h *DashboardHandler) handleLoginPage(w http.ResponseWriter, r *http.Reques
 		PageData: PageData{
 			Title: "Login - Alexander Storage",
 		},
+		SSOEnabled: h.oidc != nil,
 	}
 	h.render(w, "login.html", data)
 }
@@ -140,6 +314,7 @@ func (h *DashboardHandler) handleLogin(w http.ResponseWriter, r *http.Request) {
 
 	username := r.FormValue("username")
 	password := r.FormValue("password")
+	rememberMe := r.FormValue("remember_me") != ""
 
 	if username == "" || password == "" {
 		h.renderLoginError(w, "Username and password are required")
@@ -147,49 +322,224 @@ func (h *DashboardHandler) handleLogin(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Authenticate user
-	output, err := h.sessionService.Login(r.Context(), service.LoginInput{
-		Username:  username,
-		Password:  password,
-		IPAddress: r.RemoteAddr,
-		UserAgent: r.UserAgent(),
-	})
+	input := service.LoginInput{
+		Username:   username,
+		Password:   password,
+		IPAddress:  r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+		RememberMe: rememberMe,
+	}
+	output, err := h.sessionService.Login(r.Context(), input)
+	if errors.Is(err, service.ErrPasswordChangeRequired) {
+		// The password is correct, so it may be used to set a new one
+		input.Password, err = h.changeExpiredPassword(r, username, password)
+		if err == nil {
+			output, err = h.sessionService.Login(r.Context(), input)
+		}
+	}
 	if err != nil {
 		h.logger.Debug().Err(err).Str("username", username).Msg("Login failed")
+		var changeErr *passwordChangeError
+		if errors.As(err, &changeErr) {
+			h.renderPasswordChange(w, username, rememberMe, changeErr.message)
+			return
+		}
 		h.renderLoginError(w, "Invalid username or password")
 		return
 	}
 
-	// Set session cookie
-	http.SetCookie(w, &http.Cookie{
-		Name:     "session",
-		Value:    output.Session.Token,
-		Path:     "/dashboard",
-		HttpOnly: true,
-		Secure:   r.TLS != nil,
-		SameSite: http.SameSiteStrictMode,
-		MaxAge:   int(24 * time.Hour / time.Second),
-	})
+	setSessionCookies(w, r, output.Session)
 
 	// Redirect to dashboard
 	w.Header().Set("HX-Redirect", "/dashboard")
 	w.WriteHeader(http.StatusOK)
 }
 
-func (h *DashboardHandler) handleLogout(w http.ResponseWriter, r *http.Request) {
-	cookie, err := r.Cookie("session")
-	if err == nil {
-		_ = h.sessionService.Logout(r.Context(), cookie.Value)
+// passwordChangeError asks the user to choose a new password at login.
+type passwordChangeError struct {
+	message string
+}
+
+func (e *passwordChangeError) Error() string {
+	return "password change required: " + e.message
+}
+
+// changeExpiredPassword sets the new password submitted with a login whose
+// password must be changed and returns it.
+func (h *DashboardHandler) changeExpiredPassword(r *http.Request, username, password string) (string, error) {
+	newPassword := r.FormValue("new_password")
+	switch {
+	case newPassword == "":
+		return "", &passwordChangeError{"Your password has expired. Choose a new password."}
+	case newPassword != r.FormValue("confirm_password"):
+		return "", &passwordChangeError{"The new passwords do not match"}
 	}
 
-	// Clear session cookie
+	user, err := h.userService.GetByUsername(r.Context(), username)
+	if err != nil {
+		return "", err
+	}
+	err = h.userService.UpdatePassword(r.Context(), service.UpdatePasswordInput{
+		UserID:      user.ID,
+		OldPassword: password,
+		NewPassword: newPassword,
+	})
+	if errors.Is(err, service.ErrInvalidPassword) {
+		return "", &passwordChangeError{err.Error()}
+	}
+	if err != nil {
+		return "", err
+	}
+	return newPassword, nil
+}
+
+// oidcLoginCookie carries the state, nonce and PKCE verifier of a single
+// sign-on login from the redirect to the callback.
+const oidcLoginCookie = "oidc_login"
+
+func (h *DashboardHandler) handleOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	if h.oidc == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	login, err := h.oidc.BeginLogin(r.Context())
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to start single sign-on")
+		h.renderLoginError(w, "Single sign-on is currently unavailable")
+		return
+	}
+
+	// SameSite=Lax so the cookie comes back on the provider's redirect
 	http.SetCookie(w, &http.Cookie{
-		Name:     "session",
+		Name:     oidcLoginCookie,
+		Value:    strings.Join([]string{login.State, login.Nonce, login.CodeVerifier}, "."),
+		Path:     "/dashboard/oidc",
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   int(10 * time.Minute / time.Second),
+	})
+	http.Redirect(w, r, login.URL, http.StatusFound)
+}
+
+func (h *DashboardHandler) handleOIDCCallback(w http.ResponseWriter, r *http.Request) {
+	if h.oidc == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	// The login cookie is single use
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcLoginCookie,
 		Value:    "",
-		Path:     "/dashboard",
+		Path:     "/dashboard/oidc",
 		HttpOnly: true,
 		MaxAge:   -1,
 	})
 
+	query := r.URL.Query()
+	if providerErr := query.Get("error"); providerErr != "" {
+		h.logger.Debug().Str("error", providerErr).Str("description", query.Get("error_description")).Msg("Single sign-on denied by provider")
+		h.renderLoginError(w, "Single sign-on was cancelled or denied")
+		return
+	}
+
+	cookie, err := r.Cookie(oidcLoginCookie)
+	if err != nil {
+		h.renderLoginError(w, "Single sign-on session expired, please try again")
+		return
+	}
+	parts := strings.Split(cookie.Value, ".")
+	if len(parts) != 3 || subtle.ConstantTimeCompare([]byte(parts[0]), []byte(query.Get("state"))) != 1 {
+		h.logger.Warn().Msg("Single sign-on callback with mismatched state")
+		h.renderLoginError(w, "Single sign-on session expired, please try again")
+		return
+	}
+
+	identity, err := h.oidc.CompleteLogin(r.Context(), query.Get("code"), parts[2], parts[1])
+	if err != nil {
+		h.logger.Debug().Err(err).Msg("Single sign-on failed")
+		h.renderLoginError(w, "Single sign-on failed")
+		return
+	}
+
+	output, err := h.sessionService.LoginSSO(r.Context(), service.SSOLoginInput{
+		Identity:  identity,
+		IPAddress: r.RemoteAddr,
+		UserAgent: r.UserAgent(),
+	})
+	if err != nil {
+		h.logger.Debug().Err(err).Str("email", identity.Email).Msg("Single sign-on login rejected")
+		h.renderLoginError(w, "Your account is not allowed to use the dashboard")
+		return
+	}
+
+	setSessionCookies(w, r, output.Session)
+
+	// A strict session cookie is not sent on redirects that started at the
+	// provider, so navigate from a page of our own instead
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	fmt.Fprint(w, `<!DOCTYPE html><meta http-equiv="refresh" content="0;url=/dashboard"><a href="/dashboard">Continue</a>`)
+}
+
+// Dashboard cookie names. The remember cookie carries the remember-me token
+// that resumes a session after the session cookie expired.
+const (
+	sessionCookie  = "session"
+	rememberCookie = "remember"
+)
+
+// setSessionCookies sets the dashboard session cookie and, if the user chose
+// "remember me", the remember cookie. Both last as long as their tokens.
+func setSessionCookies(w http.ResponseWriter, r *http.Request, session *domain.Session) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    session.Token,
+		Path:     "/dashboard",
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+		MaxAge:   int(time.Until(session.ExpiresAt) / time.Second),
+	})
+	if session.RememberExpiresAt != nil {
+		http.SetCookie(w, &http.Cookie{
+			Name:     rememberCookie,
+			Value:    session.RememberToken,
+			Path:     "/dashboard",
+			HttpOnly: true,
+			Secure:   r.TLS != nil,
+			SameSite: http.SameSiteStrictMode,
+			MaxAge:   int(time.Until(*session.RememberExpiresAt) / time.Second),
+		})
+	}
+}
+
+func (h *DashboardHandler) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(sessionCookie); err == nil {
+		session, _, err := h.sessionService.ValidateSession(r.Context(), cookie.Value)
+		if err == nil {
+			// Another site must not be able to log the user out
+			if !validCSRFToken(r, session.CSRFToken) {
+				http.Error(w, "Invalid CSRF token", http.StatusForbidden)
+				return
+			}
+			_ = h.sessionService.Logout(r.Context(), cookie.Value)
+		}
+	}
+
+	// Clear session cookies
+	for _, name := range []string{sessionCookie, rememberCookie} {
+		http.SetCookie(w, &http.Cookie{
+			Name:     name,
+			Value:    "",
+			Path:     "/dashboard",
+			HttpOnly: true,
+			MaxAge:   -1,
+		})
+	}
+
 	w.Header().Set("HX-Redirect", "/dashboard/login")
 	w.WriteHeader(http.StatusOK)
 }
@@ -199,19 +549,17 @@ func (h *DashboardHandler) handleLogout(w http.ResponseWriter, r *http.Request)
 // =============================================================================
 
 func (h *DashboardHandler) handleDashboard(w http.ResponseWriter, r *http.Request) {
-	session, err := h.getSession(r)
-	if err != nil {
-		http.Redirect(w, r, "/dashboard/login", http.StatusFound)
-		return
-	}
+	h.renderDashboard(w, r, sessionFromContext(r.Context()), NewBucketForm{}, "")
+}
 
+// renderDashboard renders the bucket list, with the values of a create
+// bucket form that failed and its error.
+func (h *DashboardHandler) renderDashboard(w http.ResponseWriter, r *http.Request, session *sessionInfo, form NewBucketForm, message string) {
 	// Get buckets
-	buckets, err := h.bucketService.ListBuckets(r.Context(), service.ListBucketsInput{
-		OwnerID: session.UserID,
-	})
+	buckets, err := h.listBuckets(r, session)
 	if err != nil {
 		h.logger.Error().Err(err).Msg("Failed to list buckets")
-		h.renderError(w, r, "Failed to load buckets", session.Username)
+		h.renderError(w, r, "Failed to load buckets", session)
 		return
 	}
 
@@ -219,47 +567,68 @@ func (h *DashboardHandler) handleDashboard(w http.ResponseWriter, r *http.Reques
 		PageData: PageData{
 			Title:     "Dashboard - Alexander Storage",
 			Username:  session.Username,
-			CSRFToken: middleware.TokenFromContext(r.Context()),
+			Role:      session.Role,
+			Error:     message,
+			CSRFToken: session.CSRFToken,
 		},
-		Buckets: buckets.Buckets,
+		BucketListData: *buckets,
+		Regions:        h.bucketService.Regions(),
+		NewBucket:      form,
 	}
 	h.render(w, "dashboard.html", data)
 }
 
 func (h *DashboardHandler) handleBucketList(w http.ResponseWriter, r *http.Request) {
-	session, err := h.getSession(r)
-	if err != nil {
-		w.WriteHeader(http.StatusUnauthorized)
-		return
-	}
+	session := sessionFromContext(r.Context())
 
-	buckets, err := h.bucketService.ListBuckets(r.Context(), service.ListBucketsInput{
-		OwnerID: session.UserID,
-	})
+	buckets, err := h.listBuckets(r, session)
 	if err != nil {
 		h.logger.Error().Err(err).Msg("Failed to list buckets")
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
-	h.render(w, "bucket_list.html", buckets.Buckets)
+	h.render(w, "bucket_list.html", buckets)
 }
 
-func (h *DashboardHandler) handleBucketDetail(w http.ResponseWriter, r *http.Request) {
-	session, err := h.getSession(r)
+// bucketPageSize is the number of buckets on a page of the bucket list.
+const bucketPageSize = 100
+
+// listBuckets lists the page of the user's buckets selected by the prefix
+// and continuation-token query parameters.
+func (h *DashboardHandler) listBuckets(r *http.Request, session *sessionInfo) (*BucketListData, error) {
+	query := r.URL.Query()
+	prefix := query.Get("prefix")
+	output, err := h.bucketService.ListBuckets(r.Context(), service.ListBucketsInput{
+		OwnerID:           session.UserID,
+		Prefix:            prefix,
+		ContinuationToken: query.Get("continuation-token"),
+		MaxBuckets:        bucketPageSize,
+	})
 	if err != nil {
-		http.Redirect(w, r, "/dashboard/login", http.StatusFound)
-		return
+		return nil, err
 	}
 
-	bucketName := chi.URLParam(r, "name")
+	return &BucketListData{
+		Buckets:               output.Buckets,
+		Prefix:                prefix,
+		NextContinuationToken: output.NextContinuationToken,
+	}, nil
+}
+
+func (h *DashboardHandler) handleBucketDetail(w http.ResponseWriter, r *http.Request) {
+	h.renderBucketDetail(w, r, sessionFromContext(r.Context()), chi.URLParam(r, "name"), "")
+}
+
+// renderBucketDetail renders a bucket's settings page, with an error.
+func (h *DashboardHandler) renderBucketDetail(w http.ResponseWriter, r *http.Request, session *sessionInfo, bucketName, message string) {
 	bucket, err := h.bucketService.GetBucket(r.Context(), service.GetBucketInput{
 		Name:    bucketName,
 		OwnerID: session.UserID,
 	})
 	if err != nil {
 		h.logger.Error().Err(err).Str("bucket", bucketName).Msg("Failed to get bucket")
-		h.renderError(w, r, "Bucket not found", session.Username)
+		h.renderError(w, r, "Bucket not found", session)
 		return
 	}
 
@@ -274,21 +643,166 @@ func (h *DashboardHandler) handleBucketDetail(w http.ResponseWriter, r *http.Req
 		PageData: PageData{
 			Title:     bucketName + " - Alexander Storage",
 			Username:  session.Username,
-			CSRFToken: middleware.TokenFromContext(r.Context()),
+			Role:      session.Role,
+			Error:     message,
+			CSRFToken: session.CSRFToken,
 		},
 		Bucket:         bucket.Bucket,
 		LifecycleRules: rules,
+		StorageClasses: []domain.StorageClass{
+			domain.StorageClassStandard,
+			domain.StorageClassReducedRedundancy,
+			domain.StorageClassGlacier,
+			domain.StorageClassDeepArchive,
+		},
+	}
+	if bucket.Bucket.ObjectDefaults != nil {
+		data.Defaults = *bucket.Bucket.ObjectDefaults
+		data.ContentTypes = domain.FormatContentTypeMappings(data.Defaults.ContentTypes)
 	}
 	h.render(w, "bucket_detail.html", data)
 }
 
-func (h *DashboardHandler) handleUpdateBucketACL(w http.ResponseWriter, r *http.Request) {
-	session, err := h.getSession(r)
+func (h *DashboardHandler) handleCreateBucket(w http.ResponseWriter, r *http.Request) {
+	session := sessionFromContext(r.Context())
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	form := NewBucketForm{
+		Name:       strings.TrimSpace(r.FormValue("name")),
+		Region:     r.FormValue("region"),
+		Versioning: r.FormValue("versioning") != "",
+	}
+	_, err := h.bucketService.CreateBucket(r.Context(), service.CreateBucketInput{
+		OwnerID:          session.UserID,
+		Name:             form.Name,
+		Region:           form.Region,
+		EnableVersioning: form.Versioning,
+	})
+	h.recordAudit(r, session, domain.AuditEvent{
+		Operation:  "bucket.create",
+		BucketName: form.Name,
+		Detail:     "region=" + form.Region,
+	}, err)
 	if err != nil {
-		w.WriteHeader(http.StatusUnauthorized)
+		message := bucketNameError(err)
+		if message == "" {
+			h.logger.Error().Err(err).Str("bucket", form.Name).Msg("Failed to create bucket")
+			message = "Failed to create bucket"
+		}
+		h.renderDashboard(w, r, session, form, message)
 		return
 	}
 
+	w.Header().Set("HX-Redirect", "/dashboard/buckets/"+form.Name)
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleCheckBucketName gives feedback on a bucket name while it is typed
+// into the create form.
+func (h *DashboardHandler) handleCheckBucketName(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimSpace(r.URL.Query().Get("name"))
+	check := BucketNameCheck{Valid: true, Message: "Name is available"}
+
+	err := domain.ValidateBucketName(name)
+	if err == nil {
+		var head *service.HeadBucketOutput
+		head, err = h.bucketService.HeadBucket(r.Context(), service.HeadBucketInput{Name: name})
+		if err == nil && head.Exists {
+			err = domain.ErrBucketAlreadyExists
+		}
+	}
+	if err != nil {
+		check = BucketNameCheck{Message: bucketNameError(err)}
+		if check.Message == "" {
+			h.logger.Error().Err(err).Str("bucket", name).Msg("Failed to check bucket name")
+			check.Message = "Could not check the name"
+		}
+	}
+	if name == "" {
+		check = BucketNameCheck{}
+	}
+
+	h.render(w, "bucket_name_check.html", check)
+}
+
+// bucketNameError returns the message shown for a bucket that cannot be
+// created with the requested name or region, or "" for other errors.
+func bucketNameError(err error) string {
+	switch {
+	case errors.Is(err, domain.ErrBucketNameLength),
+		errors.Is(err, domain.ErrBucketNameFormat),
+		errors.Is(err, domain.ErrBucketNameIPFormat):
+		message := err.Error()
+		return strings.ToUpper(message[:1]) + message[1:]
+	case errors.Is(err, domain.ErrBucketAlreadyExists):
+		return "A bucket with this name already exists"
+	case errors.Is(err, domain.ErrInvalidLocationConstraint):
+		return "Buckets cannot be created in this region"
+	}
+	return ""
+}
+
+func (h *DashboardHandler) handleDeleteBucket(w http.ResponseWriter, r *http.Request) {
+	session := sessionFromContext(r.Context())
+	bucketName := chi.URLParam(r, "name")
+	force := r.FormValue("force") != ""
+
+	// Verify bucket ownership
+	_, err := h.bucketService.GetBucket(r.Context(), service.GetBucketInput{
+		Name:    bucketName,
+		OwnerID: session.UserID,
+	})
+	if err != nil {
+		http.Error(w, "Bucket not found", http.StatusNotFound)
+		return
+	}
+
+	detail := ""
+	if force {
+		var emptied *service.EmptyBucketOutput
+		emptied, err = h.objectService.EmptyBucket(r.Context(), service.EmptyBucketInput{
+			BucketName: bucketName,
+			OwnerID:    session.UserID,
+		})
+		if emptied != nil {
+			detail = fmt.Sprintf("force, %d versions and %d delete markers deleted", emptied.VersionsDeleted, emptied.DeleteMarkersDeleted)
+		}
+	}
+	if err == nil {
+		err = h.bucketService.DeleteBucket(r.Context(), service.DeleteBucketInput{
+			Name:    bucketName,
+			OwnerID: session.UserID,
+		})
+	}
+	h.recordAudit(r, session, domain.AuditEvent{
+		Operation:  "bucket.delete",
+		BucketName: bucketName,
+		Detail:     detail,
+	}, err)
+	if err != nil {
+		message := "Failed to delete bucket"
+		if errors.Is(err, domain.ErrBucketNotEmpty) {
+			message = "The bucket is not empty. Delete its objects first, or choose to delete them with the bucket."
+		} else if errors.Is(err, domain.ErrMFARequired) {
+			message = "The bucket has MFA delete enabled. Delete its versions with an MFA code first."
+		} else {
+			h.logger.Error().Err(err).Str("bucket", bucketName).Msg("Failed to delete bucket")
+		}
+		h.renderBucketDetail(w, r, session, bucketName, message)
+		return
+	}
+
+	w.Header().Set("HX-Redirect", "/dashboard")
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *DashboardHandler) handleUpdateBucketACL(w http.ResponseWriter, r *http.Request) {
+	session := sessionFromContext(r.Context())
+
 	if err := r.ParseForm(); err != nil {
 		http.Error(w, "Invalid form data", http.StatusBadRequest)
 		return
@@ -297,39 +811,84 @@ func (h *DashboardHandler) handleUpdateBucketACL(w http.ResponseWriter, r *http.
 	bucketName := chi.URLParam(r, "name")
 	acl := domain.BucketACL(r.FormValue("acl"))
 
-	// Validate ACL
-	if acl != domain.ACLPrivate && acl != domain.ACLPublicRead && acl != domain.ACLPublicReadWrite {
-		http.Error(w, "Invalid ACL", http.StatusBadRequest)
-		return
-	}
-
-	// Get bucket first
-	bucket, err := h.bucketService.GetBucket(r.Context(), service.GetBucketInput{
+	err := h.bucketService.PutBucketACL(r.Context(), service.PutBucketACLInput{
 		Name:    bucketName,
 		OwnerID: session.UserID,
+		ACL:     acl,
 	})
-	if err != nil {
+	h.recordAudit(r, session, domain.AuditEvent{
+		Operation:  "bucket.acl",
+		BucketName: bucketName,
+		Detail:     "acl=" + string(acl),
+	}, err)
+	switch {
+	case errors.Is(err, service.ErrInvalidBucketACL):
+		http.Error(w, "Invalid ACL", http.StatusBadRequest)
+		return
+	case errors.Is(err, domain.ErrBucketNotFound), errors.Is(err, service.ErrBucketAccessDenied):
 		http.Error(w, "Bucket not found", http.StatusNotFound)
 		return
+	case err != nil:
+		h.logger.Error().Err(err).Str("bucket", bucketName).Msg("Failed to update bucket ACL")
+		http.Error(w, "Failed to update ACL", http.StatusInternalServerError)
+		return
 	}
 
-	// Update ACL via repository (we need to add this to the service)
-	_ = bucket // ACL update would be done here
-
 	w.Header().Set("HX-Trigger", "bucketUpdated")
 	_, _ = w.Write([]byte("ACL updated successfully"))
 }
 
+func (h *DashboardHandler) handleUpdateObjectDefaults(w http.ResponseWriter, r *http.Request) {
+	session := sessionFromContext(r.Context())
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	bucketName := chi.URLParam(r, "name")
+	defaults := &domain.ObjectDefaults{
+		CacheControl: strings.TrimSpace(r.FormValue("cache_control")),
+		StorageClass: domain.StorageClass(r.FormValue("storage_class")),
+	}
+	var err error
+	defaults.ContentTypes, err = domain.ParseContentTypeMappings(r.FormValue("content_types"))
+	if err == nil {
+		err = h.bucketService.SetBucketObjectDefaults(r.Context(), service.SetBucketObjectDefaultsInput{
+			Name:     bucketName,
+			OwnerID:  session.UserID,
+			Defaults: defaults,
+		})
+	}
+	h.recordAudit(r, session, domain.AuditEvent{
+		Operation:  "bucket.object-defaults",
+		BucketName: bucketName,
+		Detail: fmt.Sprintf("cache_control=%q storage_class=%s content_types=%d",
+			defaults.CacheControl, defaults.StorageClass, len(defaults.ContentTypes)),
+	}, err)
+	switch {
+	case errors.Is(err, domain.ErrInvalidObjectDefaults):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, domain.ErrBucketNotFound), errors.Is(err, service.ErrBucketAccessDenied):
+		http.Error(w, "Bucket not found", http.StatusNotFound)
+		return
+	case err != nil:
+		h.logger.Error().Err(err).Str("bucket", bucketName).Msg("Failed to update object defaults")
+		http.Error(w, "Failed to update object defaults", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("HX-Trigger", "bucketUpdated")
+	_, _ = w.Write([]byte("Object defaults updated successfully"))
+}
+
 // =============================================================================
 // Lifecycle Handlers
 // =============================================================================
 
 func (h *DashboardHandler) handleCreateLifecycleRule(w http.ResponseWriter, r *http.Request) {
-	session, err := h.getSession(r)
-	if err != nil {
-		w.WriteHeader(http.StatusUnauthorized)
-		return
-	}
+	session := sessionFromContext(r.Context())
 
 	if err := r.ParseForm(); err != nil {
 		http.Error(w, "Invalid form data", http.StatusBadRequest)
@@ -339,7 +898,7 @@ func (h *DashboardHandler) handleCreateLifecycleRule(w http.ResponseWriter, r *h
 	bucketName := chi.URLParam(r, "name")
 
 	// Verify bucket ownership
-	_, err = h.bucketService.GetBucket(r.Context(), service.GetBucketInput{
+	_, err := h.bucketService.GetBucket(r.Context(), service.GetBucketInput{
 		Name:    bucketName,
 		OwnerID: session.UserID,
 	})
@@ -357,6 +916,11 @@ func (h *DashboardHandler) handleCreateLifecycleRule(w http.ResponseWriter, r *h
 		ExpirationDays: expirationDays,
 		Status:         "Enabled",
 	})
+	h.recordAudit(r, session, domain.AuditEvent{
+		Operation:  "lifecycle.create-rule",
+		BucketName: bucketName,
+		Detail:     "rule_id=" + r.FormValue("rule_id"),
+	}, err)
 	if err != nil {
 		h.logger.Error().Err(err).Msg("Failed to create lifecycle rule")
 		http.Error(w, err.Error(), http.StatusBadRequest)
@@ -368,16 +932,16 @@ func (h *DashboardHandler) handleCreateLifecycleRule(w http.ResponseWriter, r *h
 }
 
 func (h *DashboardHandler) handleDeleteLifecycleRule(w http.ResponseWriter, r *http.Request) {
-	_, err := h.getSession(r)
-	if err != nil {
-		w.WriteHeader(http.StatusUnauthorized)
-		return
-	}
-
+	session := sessionFromContext(r.Context())
 	bucketName := chi.URLParam(r, "name")
 	ruleID := chi.URLParam(r, "ruleId")
 
-	err = h.lifecycleService.DeleteRuleByName(r.Context(), bucketName, ruleID)
+	err := h.lifecycleService.DeleteRuleByName(r.Context(), bucketName, ruleID)
+	h.recordAudit(r, session, domain.AuditEvent{
+		Operation:  "lifecycle.delete-rule",
+		BucketName: bucketName,
+		Detail:     "rule_id=" + ruleID,
+	}, err)
 	if err != nil {
 		h.logger.Error().Err(err).Msg("Failed to delete lifecycle rule")
 		http.Error(w, err.Error(), http.StatusBadRequest)
@@ -389,20 +953,256 @@ func (h *DashboardHandler) handleDeleteLifecycleRule(w http.ResponseWriter, r *h
 }
 
 // =============================================================================
-// User Management Handlers
+// Object Version Handlers
 // =============================================================================
 
-func (h *DashboardHandler) handleUserList(w http.ResponseWriter, r *http.Request) {
-	session, err := h.getSession(r)
+func (h *DashboardHandler) handleObjectVersions(w http.ResponseWriter, r *http.Request) {
+	session := sessionFromContext(r.Context())
+
+	bucketName := chi.URLParam(r, "name")
+	bucket, err := h.bucketService.GetBucket(r.Context(), service.GetBucketInput{
+		Name:    bucketName,
+		OwnerID: session.UserID,
+	})
 	if err != nil {
-		http.Redirect(w, r, "/dashboard/login", http.StatusFound)
+		h.logger.Error().Err(err).Str("bucket", bucketName).Msg("Failed to get bucket")
+		h.renderError(w, r, "Bucket not found", session)
 		return
 	}
 
-	output, err := h.userService.List(r.Context(), service.ListUsersInput{Limit: 100})
+	query := r.URL.Query()
+	prefix := query.Get("prefix")
+	output, err := h.objectService.ListObjectVersions(r.Context(), service.ListObjectVersionsInput{
+		BucketName:      bucketName,
+		Prefix:          prefix,
+		KeyMarker:       query.Get("key-marker"),
+		VersionIDMarker: query.Get("version-id-marker"),
+		MaxKeys:         100,
+		OwnerID:         session.UserID,
+	})
+	if err != nil {
+		h.logger.Error().Err(err).Str("bucket", bucketName).Msg("Failed to list object versions")
+		h.renderError(w, r, "Failed to load versions", session)
+		return
+	}
+
+	rows := make([]ObjectVersionRow, 0, len(output.Versions)+len(output.DeleteMarkers))
+	for _, v := range output.Versions {
+		rows = append(rows, ObjectVersionRow{
+			Key:          v.Key,
+			VersionID:    v.VersionID,
+			IsLatest:     v.IsLatest,
+			Size:         v.Size,
+			LastModified: v.LastModified,
+		})
+	}
+	for _, m := range output.DeleteMarkers {
+		rows = append(rows, ObjectVersionRow{
+			Key:          m.Key,
+			VersionID:    m.VersionID,
+			IsLatest:     m.IsLatest,
+			DeleteMarker: true,
+			LastModified: m.LastModified,
+		})
+	}
+	// Each key's versions are shown newest first, with the latest on top
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].Key != rows[j].Key {
+			return rows[i].Key < rows[j].Key
+		}
+		if rows[i].IsLatest != rows[j].IsLatest {
+			return rows[i].IsLatest
+		}
+		return rows[i].LastModified.After(rows[j].LastModified)
+	})
+
+	data := ObjectVersionsPageData{
+		PageData: PageData{
+			Title:     bucketName + " versions - Alexander Storage",
+			Username:  session.Username,
+			Role:      session.Role,
+			CSRFToken: session.CSRFToken,
+		},
+		Bucket:              bucket.Bucket,
+		Prefix:              prefix,
+		Versions:            rows,
+		NextKeyMarker:       output.NextKeyMarker,
+		NextVersionIDMarker: output.NextVersionIDMarker,
+	}
+	h.render(w, "object_versions.html", data)
+}
+
+func (h *DashboardHandler) handleRestoreObject(w http.ResponseWriter, r *http.Request) {
+	session := sessionFromContext(r.Context())
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	bucketName := chi.URLParam(r, "name")
+
+	// Verify bucket ownership
+	_, err := h.bucketService.GetBucket(r.Context(), service.GetBucketInput{
+		Name:    bucketName,
+		OwnerID: session.UserID,
+	})
+	if err != nil {
+		http.Error(w, "Bucket not found", http.StatusNotFound)
+		return
+	}
+
+	key := r.FormValue("key")
+	versionID := r.FormValue("version_id")
+	restored, err := h.objectService.RestoreObject(r.Context(), service.RestoreObjectInput{
+		BucketName: bucketName,
+		Key:        key,
+		VersionID:  versionID,
+		OwnerID:    session.UserID,
+	})
+	detail := ""
+	if versionID != "" {
+		detail = "version_id=" + versionID
+	}
+	h.recordAudit(r, session, domain.AuditEvent{
+		Operation:  "object.restore",
+		BucketName: bucketName,
+		ObjectKey:  key,
+		Detail:     detail,
+	}, err)
+	if err != nil {
+		h.logger.Error().Err(err).Str("bucket", bucketName).Str("key", key).Msg("Failed to restore object")
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, domain.ErrObjectNotFound), errors.Is(err, domain.ErrVersionNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, domain.ErrObjectNotDeleted), errors.Is(err, domain.ErrObjectDeleted), errors.Is(err, domain.ErrInvalidVersionID):
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("HX-Trigger", "versionsUpdated")
+	if restored.DeleteMarkerVersionID != "" {
+		_, _ = w.Write([]byte("Delete marker removed"))
+		return
+	}
+	_, _ = w.Write([]byte("Version restored"))
+}
+
+// =============================================================================
+// Access Key Handlers
+// =============================================================================
+
+func (h *DashboardHandler) handleKeyList(w http.ResponseWriter, r *http.Request) {
+	h.renderKeys(w, r, sessionFromContext(r.Context()), nil, "")
+}
+
+func (h *DashboardHandler) handleCreateKey(w http.ResponseWriter, r *http.Request) {
+	session := sessionFromContext(r.Context())
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	input := service.CreateAccessKeyInput{
+		UserID:      session.UserID,
+		Description: r.FormValue("description"),
+	}
+	if days := r.FormValue("expires_days"); days != "" {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 1 {
+			h.renderKeys(w, r, session, nil, "Expiration must be a positive number of days")
+			return
+		}
+		expiresAt := time.Now().UTC().AddDate(0, 0, n)
+		input.ExpiresAt = &expiresAt
+	}
+
+	output, err := h.iamService.CreateAccessKey(r.Context(), input)
+	detail := "self-service"
+	if err == nil {
+		detail = "access_key_id=" + output.AccessKeyID + " " + detail
+	}
+	h.recordAudit(r, session, domain.AuditEvent{Operation: "accesskey.create", Detail: detail}, err)
+	if err != nil {
+		h.logger.Error().Err(err).Int64("user_id", session.UserID).Msg("Failed to create access key")
+		message := "Failed to create access key"
+		if errors.Is(err, service.ErrMaxAccessKeysReached) {
+			message = "You already have the maximum number of active access keys"
+		}
+		h.renderKeys(w, r, session, nil, message)
+		return
+	}
+
+	h.renderKeys(w, r, session, output, "")
+}
+
+func (h *DashboardHandler) handleDeleteKey(w http.ResponseWriter, r *http.Request) {
+	session := sessionFromContext(r.Context())
+	accessKeyID := chi.URLParam(r, "accessKeyID")
+
+	err := h.iamService.DeleteUserAccessKey(r.Context(), session.UserID, accessKeyID)
+	h.recordAudit(r, session, domain.AuditEvent{
+		Operation: "accesskey.delete",
+		Detail:    "access_key_id=" + accessKeyID,
+	}, err)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrAccessKeyNotFound) {
+			status = http.StatusNotFound
+		} else {
+			h.logger.Error().Err(err).Str("access_key_id", accessKeyID).Msg("Failed to delete access key")
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("HX-Trigger", "keyDeleted")
+	_, _ = w.Write([]byte("Access key deleted"))
+}
+
+// renderKeys renders the user's access keys, with the secret of a key just
+// created or an error.
+func (h *DashboardHandler) renderKeys(w http.ResponseWriter, r *http.Request, session *sessionInfo, created *service.CreateAccessKeyOutput, message string) {
+	keys, err := h.iamService.ListAccessKeys(r.Context(), service.ListAccessKeysInput{UserID: session.UserID})
+	if err != nil {
+		h.logger.Error().Err(err).Int64("user_id", session.UserID).Msg("Failed to list access keys")
+		h.renderError(w, r, "Failed to load access keys", session)
+		return
+	}
+
+	data := KeysPageData{
+		PageData: PageData{
+			Title:     "Access Keys - Alexander Storage",
+			Username:  session.Username,
+			Role:      session.Role,
+			Error:     message,
+			CSRFToken: session.CSRFToken,
+		},
+		Keys:    keys,
+		Created: created,
+	}
+	h.render(w, "keys.html", data)
+}
+
+// =============================================================================
+// User Management Handlers
+// =============================================================================
+
+func (h *DashboardHandler) handleUserList(w http.ResponseWriter, r *http.Request) {
+	session := sessionFromContext(r.Context())
+
+	input := service.ListUsersInput{Limit: 100}
+	if !session.managesAllTenants() {
+		input.TenantID = session.TenantID
+	}
+	output, err := h.userService.List(r.Context(), input)
 	if err != nil {
 		h.logger.Error().Err(err).Msg("Failed to list users")
-		h.renderError(w, r, "Failed to load users", session.Username)
+		h.renderError(w, r, "Failed to load users", session)
 		return
 	}
 
@@ -410,30 +1210,42 @@ func (h *DashboardHandler) handleUserList(w http.ResponseWriter, r *http.Request
 		PageData: PageData{
 			Title:     "Users - Alexander Storage",
 			Username:  session.Username,
-			CSRFToken: middleware.TokenFromContext(r.Context()),
+			Role:      session.Role,
+			CSRFToken: session.CSRFToken,
 		},
-		Users: output.Users,
+		Users:  output.Users,
+		UserID: session.UserID,
+		Roles:  []domain.UserRole{domain.RoleNone, domain.RoleReadOnly, domain.RoleOperator, domain.RoleAdmin},
 	}
 	h.render(w, "users.html", data)
 }
 
 func (h *DashboardHandler) handleCreateUser(w http.ResponseWriter, r *http.Request) {
-	_, err := h.getSession(r)
-	if err != nil {
-		w.WriteHeader(http.StatusUnauthorized)
-		return
-	}
+	session := sessionFromContext(r.Context())
 
 	if err := r.ParseForm(); err != nil {
 		http.Error(w, "Invalid form data", http.StatusBadRequest)
 		return
 	}
 
+	role, err := domain.ParseUserRole(r.FormValue("role"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// New users join the admin's tenant
 	_, err = h.userService.Create(r.Context(), service.CreateUserInput{
 		Username: r.FormValue("username"),
 		Email:    r.FormValue("email"),
 		Password: r.FormValue("password"),
+		Role:     role,
+		TenantID: session.TenantID,
 	})
+	h.recordAudit(r, session, domain.AuditEvent{
+		Operation: "user.create",
+		Detail:    fmt.Sprintf("username=%s role=%s", r.FormValue("username"), role),
+	}, err)
 	if err != nil {
 		h.logger.Error().Err(err).Msg("Failed to create user")
 		http.Error(w, err.Error(), http.StatusBadRequest)
@@ -445,19 +1257,27 @@ func (h *DashboardHandler) handleCreateUser(w http.ResponseWriter, r *http.Reque
 }
 
 func (h *DashboardHandler) handleDeleteUser(w http.ResponseWriter, r *http.Request) {
-	_, err := h.getSession(r)
-	if err != nil {
-		w.WriteHeader(http.StatusUnauthorized)
-		return
-	}
+	session := sessionFromContext(r.Context())
 
 	userID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
 		http.Error(w, "Invalid user ID", http.StatusBadRequest)
 		return
 	}
+	if userID == session.UserID {
+		http.Error(w, "You cannot delete your own user", http.StatusBadRequest)
+		return
+	}
+	if !h.managesUser(r, session, userID) {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
 
 	err = h.userService.Delete(r.Context(), userID)
+	h.recordAudit(r, session, domain.AuditEvent{
+		Operation: "user.delete",
+		Detail:    fmt.Sprintf("user_id=%d", userID),
+	}, err)
 	if err != nil {
 		h.logger.Error().Err(err).Msg("Failed to delete user")
 		http.Error(w, err.Error(), http.StatusBadRequest)
@@ -468,46 +1288,363 @@ func (h *DashboardHandler) handleDeleteUser(w http.ResponseWriter, r *http.Reque
 	w.WriteHeader(http.StatusOK)
 }
 
+func (h *DashboardHandler) handleSetUserRole(w http.ResponseWriter, r *http.Request) {
+	session := sessionFromContext(r.Context())
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+	// Admins cannot lock themselves out; another admin has to demote them
+	if userID == session.UserID {
+		http.Error(w, "You cannot change your own role", http.StatusBadRequest)
+		return
+	}
+	if !h.managesUser(r, session, userID) {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	role, err := domain.ParseUserRole(r.FormValue("role"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = h.userService.SetRole(r.Context(), userID, role)
+	h.recordAudit(r, session, domain.AuditEvent{
+		Operation: "user.set-role",
+		Detail:    fmt.Sprintf("user_id=%d role=%s", userID, role),
+	}, err)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to set user role")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.logger.Info().
+		Str("admin", session.Username).
+		Int64("user_id", userID).
+		Stringer("role", role).
+		Msg("User role changed from dashboard")
+
+	w.Header().Set("HX-Trigger", "userUpdated")
+	_, _ = w.Write([]byte("Role updated"))
+}
+
+// =============================================================================
+// Session Handlers
+// =============================================================================
+
+func (h *DashboardHandler) handleSessionList(w http.ResponseWriter, r *http.Request) {
+	session := sessionFromContext(r.Context())
+
+	sessions, err := h.sessionService.GetUserSessions(r.Context(), session.UserID)
+	if err != nil {
+		h.logger.Error().Err(err).Int64("user_id", session.UserID).Msg("Failed to list sessions")
+		h.renderError(w, r, "Failed to load sessions", session)
+		return
+	}
+
+	infos := make([]*domain.SessionInfo, 0, len(sessions))
+	for _, s := range sessions {
+		infos = append(infos, s.ToInfo(session.Token))
+	}
+
+	data := SessionsPageData{
+		PageData: PageData{
+			Title:     "Sessions - Alexander Storage",
+			Username:  session.Username,
+			Role:      session.Role,
+			CSRFToken: session.CSRFToken,
+		},
+		Sessions: infos,
+	}
+	h.render(w, "sessions.html", data)
+}
+
+func (h *DashboardHandler) handleRevokeSession(w http.ResponseWriter, r *http.Request) {
+	session := sessionFromContext(r.Context())
+
+	sessionID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.sessionService.RevokeSession(r.Context(), session.UserID, sessionID)
+	h.recordAudit(r, session, domain.AuditEvent{
+		Operation: "user.session-revoke",
+		Detail:    "session_id=" + sessionID.String(),
+	}, err)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrSessionNotFound) {
+			status = http.StatusNotFound
+		} else {
+			h.logger.Error().Err(err).Str("session_id", sessionID.String()).Msg("Failed to revoke session")
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("HX-Trigger", "sessionRevoked")
+	_, _ = w.Write([]byte("Session revoked"))
+}
+
+// =============================================================================
+// Statistics Handlers
+// =============================================================================
+
+func (h *DashboardHandler) handleStats(w http.ResponseWriter, r *http.Request) {
+	session := sessionFromContext(r.Context())
+	if h.statsService == nil {
+		h.renderError(w, r, "Statistics are not available", session)
+		return
+	}
+	// Statistics cover all tenants
+	if !session.managesAllTenants() {
+		h.renderError(w, r, "Statistics are only available to administrators of the default tenant", session)
+		return
+	}
+
+	stats, err := h.statsService.GetSystemStats(r.Context(), service.SystemStatsInput{HistoryDays: statsHistoryDays})
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get system statistics")
+		h.renderError(w, r, "Failed to load statistics", session)
+		return
+	}
+
+	var largest int64
+	for _, sample := range stats.StorageHistory {
+		largest = max(largest, sample.BytesStored)
+	}
+	history := make([]StorageBar, 0, len(stats.StorageHistory))
+	for _, sample := range stats.StorageHistory {
+		bar := StorageBar{Day: sample.Hour, Bytes: sample.BytesStored}
+		if largest > 0 {
+			bar.Percent = int(sample.BytesStored * 100 / largest)
+		}
+		history = append(history, bar)
+	}
+
+	data := StatsPageData{
+		PageData: PageData{
+			Title:     "Statistics - Alexander Storage",
+			Username:  session.Username,
+			Role:      session.Role,
+			CSRFToken: session.CSRFToken,
+		},
+		Stats:   stats,
+		History: history,
+	}
+	h.render(w, "stats.html", data)
+}
+
 // =============================================================================
 // Helper Methods
 // =============================================================================
 
 type sessionInfo struct {
-	UserID   int64
-	Username string
+	Token     string
+	CSRFToken string
+	UserID    int64
+	Username  string
+	Role      domain.UserRole
+	TenantID  int64
 }
 
-func (h *DashboardHandler) getSession(r *http.Request) (*sessionInfo, error) {
-	cookie, err := r.Cookie("session")
-	if err != nil {
-		return nil, err
+// managesAllTenants returns true if the session's role applies to all
+// tenants. Roles of users in the default tenant do; those of users in other
+// tenants only apply to the users of their own tenant.
+func (s *sessionInfo) managesAllTenants() bool {
+	return s.TenantID == domain.DefaultTenantID
+}
+
+// managesUser returns true if the session's role applies to the user.
+// Users of other tenants are treated as missing.
+func (h *DashboardHandler) managesUser(r *http.Request, session *sessionInfo, userID int64) bool {
+	if session.managesAllTenants() {
+		return true
+	}
+	user, err := h.userService.GetByID(r.Context(), userID)
+	return err == nil && user.TenantID == session.TenantID
+}
+
+type sessionContextKey struct{}
+
+// sessionFromContext returns the session stored by requireRole.
+func sessionFromContext(ctx context.Context) *sessionInfo {
+	session, _ := ctx.Value(sessionContextKey{}).(*sessionInfo)
+	return session
+}
+
+// requireRole rejects requests without a valid session whose user has at
+// least the given role, and stores the session in the request context.
+// Page loads without a session are redirected to the login page; htmx and
+// form requests get 401 so the page can handle it.
+func (h *DashboardHandler) requireRole(role domain.UserRole) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			session, err := h.getSession(w, r)
+			if err != nil {
+				if r.Method == http.MethodGet && r.Header.Get("HX-Request") == "" {
+					http.Redirect(w, r, "/dashboard/login", http.StatusFound)
+					return
+				}
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			if !session.Role.Includes(role) {
+				h.logger.Warn().
+					Str("username", session.Username).
+					Stringer("role", session.Role).
+					Stringer("required", role).
+					Str("path", r.URL.Path).
+					Msg("Dashboard request denied by role")
+				if r.Method != http.MethodGet {
+					h.recordAudit(r, session, domain.AuditEvent{
+						Operation: r.Method + " " + r.URL.Path,
+						Result:    domain.AuditResultDenied,
+						Detail:    fmt.Sprintf("role %s, requires %s", session.Role, role),
+					}, nil)
+				}
+				if r.Method == http.MethodGet && r.Header.Get("HX-Request") == "" {
+					w.WriteHeader(http.StatusForbidden)
+					h.renderError(w, r, "You do not have permission to view this page", session)
+					return
+				}
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), sessionContextKey{}, session)))
+		})
+	}
+}
+
+// requireCSRF rejects state-changing requests whose CSRF token, sent in the
+// X-CSRF-Token header or the csrf_token form field, does not match the
+// session's. It must run after requireRole.
+func (h *DashboardHandler) requireCSRF(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		session := sessionFromContext(r.Context())
+		if !validCSRFToken(r, session.CSRFToken) {
+			h.logger.Warn().
+				Str("username", session.Username).
+				Str("path", r.URL.Path).
+				Msg("Dashboard request with invalid CSRF token")
+			http.Error(w, "Invalid CSRF token", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// validCSRFToken reports whether the request carries the session's CSRF token.
+// Sessions created before CSRF tokens were introduced have none and never match.
+func validCSRFToken(r *http.Request, expected string) bool {
+	token := r.Header.Get("X-CSRF-Token")
+	if token == "" {
+		token = r.PostFormValue("csrf_token")
+	}
+	return expected != "" && subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1
+}
+
+// recordAudit queues a dashboard action for the audit log, if it is enabled.
+// actionErr is the outcome of the action; an event that already carries a
+// result keeps it.
+func (h *DashboardHandler) recordAudit(r *http.Request, session *sessionInfo, event domain.AuditEvent, actionErr error) {
+	if h.audit == nil {
+		return
+	}
+
+	event.UserID = session.UserID
+	event.Actor = session.Username
+	event.SourceIP = r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		event.SourceIP = host
+	}
+	event.RequestID = middleware.GetRequestID(r.Context())
+	if event.Result == "" {
+		event.Result = domain.AuditResultSuccess
+		if actionErr != nil {
+			event.Result = domain.AuditResultFailure
+			event.Detail += ": " + actionErr.Error()
+		}
 	}
 
-	session, user, err := h.sessionService.ValidateSession(r.Context(), cookie.Value)
+	h.audit.Record(&event)
+}
+
+// getSession validates the session cookie. If the session has expired or
+// gone idle, a remember cookie resumes it and both cookies are replaced.
+func (h *DashboardHandler) getSession(w http.ResponseWriter, r *http.Request) (*sessionInfo, error) {
+	var session *domain.Session
+	var user *domain.User
+	cookie, err := r.Cookie(sessionCookie)
+	if err == nil {
+		session, user, err = h.sessionService.ValidateSession(r.Context(), cookie.Value)
+	}
 	if err != nil {
-		return nil, err
+		remember, cookieErr := r.Cookie(rememberCookie)
+		if cookieErr != nil {
+			return nil, err
+		}
+		output, resumeErr := h.sessionService.ResumeSession(r.Context(), remember.Value, r.RemoteAddr, r.UserAgent())
+		if resumeErr != nil {
+			return nil, resumeErr
+		}
+		session, user = output.Session, output.User
+		setSessionCookies(w, r, session)
 	}
 
 	return &sessionInfo{
-		UserID:   session.UserID,
-		Username: user.Username,
+		Token:     session.Token,
+		CSRFToken: session.CSRFToken,
+		UserID:    session.UserID,
+		Username:  user.Username,
+		Role:      user.Role,
+		TenantID:  user.TenantID,
 	}, nil
 }
 
 func (h *DashboardHandler) render(w http.ResponseWriter, name string, data interface{}) {
+	tmpl, ok := h.templates[name]
+	if !ok {
+		h.logger.Error().Str("template", name).Msg("Unknown template")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	if err := h.templates.ExecuteTemplate(w, name, data); err != nil {
+	if err := tmpl.ExecuteTemplate(w, name, data); err != nil {
 		h.logger.Error().Err(err).Str("template", name).Msg("Failed to render template")
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 	}
 }
 
-func (h *DashboardHandler) renderError(w http.ResponseWriter, r *http.Request, message, username string) {
+func (h *DashboardHandler) renderError(w http.ResponseWriter, r *http.Request, message string, session *sessionInfo) {
 	data := PageData{
 		Title:     "Error - Alexander Storage",
-		Username:  username,
+		Username:  session.Username,
+		Role:      session.Role,
 		Error:     message,
-		CSRFToken: middleware.TokenFromContext(r.Context()),
+		CSRFToken: session.CSRFToken,
 	}
 	h.render(w, "error.html", data)
 }
@@ -518,6 +1655,34 @@ func (h *DashboardHandler) renderLoginError(w http.ResponseWriter, message strin
 			Title: "Login - Alexander Storage",
 			Error: message,
 		},
+		SSOEnabled: h.oidc != nil,
+	}
+	h.render(w, "login.html", data)
+}
+
+func (h *DashboardHandler) renderPasswordChange(w http.ResponseWriter, username string, rememberMe bool, message string) {
+	data := LoginPageData{
+		PageData: PageData{
+			Title: "Change Password - Alexander Storage",
+			Error: message,
+		},
+		PasswordChange: true,
+		Username:       username,
+		RememberMe:     rememberMe,
 	}
 	h.render(w, "login.html", data)
 }
+
+// formatBytes formats a size with a binary unit, e.g. "1.50 GB".
+func formatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.2f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,482 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/lock"
+	"github.com/prn-tf/alexander-storage/internal/pkg/crypto"
+	"github.com/prn-tf/alexander-storage/internal/repository/sqlite"
+	"github.com/prn-tf/alexander-storage/internal/service"
+	"github.com/prn-tf/alexander-storage/internal/storage/filesystem"
+)
+
+type dashboardFixture struct {
+	router      chi.Router
+	userService *service.UserService
+	sessions    *service.SessionService
+	buckets     *service.BucketService
+	objects     *service.ObjectService
+	tenants     *service.TenantService
+	users       map[domain.UserRole]*domain.User
+}
+
+// newDashboardFixture creates a dashboard backed by SQLite with one user per role.
+func newDashboardFixture(t *testing.T) *dashboardFixture {
+	t.Helper()
+	ctx := context.Background()
+
+	dir := t.TempDir()
+	db, err := sqlite.NewDB(ctx, sqlite.DefaultConfig(filepath.Join(dir, "alexander.db")), zerolog.Nop())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, db.Migrate(ctx))
+
+	encryptor, err := crypto.NewEncryptor(bytes.Repeat([]byte{7}, crypto.KeySize))
+	require.NoError(t, err)
+
+	store, err := filesystem.NewStorage(filesystem.Config{
+		DataDir: filepath.Join(dir, "blobs"),
+		TempDir: filepath.Join(dir, "tmp"),
+	}, zerolog.Nop())
+	require.NoError(t, err)
+
+	userRepo := sqlite.NewUserRepository(db)
+	bucketRepo := sqlite.NewBucketRepository(db)
+	f := &dashboardFixture{
+		router:      chi.NewRouter(),
+		userService: service.NewUserService(userRepo, domain.DefaultPasswordPolicy(), zerolog.Nop()),
+		sessions:    service.NewSessionService(sqlite.NewSessionRepository(db), userRepo, zerolog.Nop(), service.DefaultSessionServiceConfig()),
+		buckets:     service.NewBucketService(bucketRepo, nil, zerolog.Nop(), service.DefaultBucketConfig()),
+		objects: service.NewObjectService(
+			sqlite.NewObjectRepository(db),
+			sqlite.NewBlobRepository(db),
+			bucketRepo,
+			sqlite.NewEventRepository(db),
+			sqlite.NewReplicationRepository(db),
+			sqlite.NewLifecycleRepository(db),
+			sqlite.NewStagedObjectRepository(db),
+			sqlite.NewBatchRepository(db),
+			nil,
+			sqlite.NewTxManager(db),
+			store,
+			lock.NewMemoryLocker(),
+			nil,
+			zerolog.Nop(),
+		),
+		tenants: service.NewTenantService(sqlite.NewTenantRepository(db), userRepo, sqlite.NewBucketRepository(db), service.TenantConfig{}, zerolog.Nop()),
+		users:   make(map[domain.UserRole]*domain.User),
+	}
+
+	h, err := NewDashboardHandler(DashboardConfig{
+		SessionService: f.sessions,
+		UserService:    f.userService,
+		BucketService:  f.buckets,
+		LifecycleService: service.NewLifecycleService(
+			sqlite.NewLifecycleRepository(db),
+			sqlite.NewObjectRepository(db),
+			bucketRepo,
+			sqlite.NewBlobRepository(db),
+			nil,
+			sqlite.NewTxManager(db),
+			lock.NewNoOpLocker(),
+			nil,
+			zerolog.Nop(),
+			service.DefaultLifecycleConfig(),
+		),
+		ObjectService: f.objects,
+		IAMService:    service.NewIAMService(sqlite.NewAccessKeyRepository(db), userRepo, encryptor, zerolog.Nop(), service.DefaultIAMConfig()),
+		StatsService: service.NewStatsService(
+			sqlite.NewBlobRepository(db),
+			sqlite.NewObjectRepository(db),
+			sqlite.NewMultipartRepository(db),
+			sqlite.NewUsageRepository(db),
+			nil, nil, zerolog.Nop(),
+		),
+		Logger: zerolog.Nop(),
+	})
+	require.NoError(t, err)
+	h.RegisterRoutes(f.router)
+
+	for _, role := range []domain.UserRole{domain.RoleNone, domain.RoleReadOnly, domain.RoleOperator, domain.RoleAdmin} {
+		output, err := f.userService.Create(ctx, service.CreateUserInput{
+			Username: "user-" + role.String(),
+			Email:    role.String() + "@example.com",
+			Password: "password-" + role.String(),
+			Role:     role,
+		})
+		require.NoError(t, err)
+		f.users[role] = output.User
+	}
+	return f
+}
+
+// login starts a session for the user with the given role.
+func (f *dashboardFixture) login(t *testing.T, role domain.UserRole) string {
+	t.Helper()
+	output, err := f.sessions.Login(context.Background(), service.LoginInput{
+		Username: "user-" + role.String(),
+		Password: "password-" + role.String(),
+	})
+	require.NoError(t, err)
+	return output.Session.Token
+}
+
+// do sends a request with the session token and its CSRF token, if any.
+func (f *dashboardFixture) do(t *testing.T, token, method, target string, form url.Values) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(form.Encode()))
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	if token != "" {
+		req.AddCookie(&http.Cookie{Name: "session", Value: token})
+		if session, _, err := f.sessions.ValidateSession(context.Background(), token); err == nil {
+			req.Header.Set("X-CSRF-Token", session.CSRFToken)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	f.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestDashboard_UsersWithoutRoleCannotLogIn(t *testing.T) {
+	f := newDashboardFixture(t)
+
+	_, err := f.sessions.Login(context.Background(), service.LoginInput{Username: "user-none", Password: "password-none"})
+	require.ErrorIs(t, err, service.ErrNoDashboardRole)
+
+	// Without a session, pages redirect to the login page and actions are unauthorized
+	rec := f.do(t, "", http.MethodGet, "/dashboard", nil)
+	require.Equal(t, http.StatusFound, rec.Code)
+	require.Equal(t, "/dashboard/login", rec.Header().Get("Location"))
+	rec = f.do(t, "", http.MethodDelete, "/dashboard/users/1", nil)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestDashboard_RoleChecksPerRoute(t *testing.T) {
+	f := newDashboardFixture(t)
+
+	tests := []struct {
+		method  string
+		target  string
+		form    url.Values
+		minimum domain.UserRole
+	}{
+		{http.MethodGet, "/dashboard", nil, domain.RoleReadOnly},
+		{http.MethodGet, "/dashboard/buckets", nil, domain.RoleReadOnly},
+		{http.MethodGet, "/dashboard/buckets/missing/versions", nil, domain.RoleReadOnly},
+		{http.MethodPost, "/dashboard/buckets", url.Values{"name": {"x"}}, domain.RoleOperator},
+		{http.MethodGet, "/dashboard/check-bucket-name?name=photos", nil, domain.RoleOperator},
+		{http.MethodDelete, "/dashboard/buckets/missing", nil, domain.RoleOperator},
+		{http.MethodPost, "/dashboard/buckets/missing/acl", url.Values{"acl": {"private"}}, domain.RoleOperator},
+		{http.MethodPost, "/dashboard/buckets/missing/defaults", url.Values{"cache_control": {"no-cache"}}, domain.RoleOperator},
+		{http.MethodPost, "/dashboard/buckets/missing/restore", url.Values{"key": {"notes.txt"}}, domain.RoleOperator},
+		{http.MethodGet, "/dashboard/keys", nil, domain.RoleReadOnly},
+		{http.MethodGet, "/dashboard/sessions", nil, domain.RoleReadOnly},
+		{http.MethodGet, "/dashboard/users", nil, domain.RoleAdmin},
+		{http.MethodGet, "/dashboard/stats", nil, domain.RoleAdmin},
+	}
+	for _, tt := range tests {
+		for _, role := range []domain.UserRole{domain.RoleReadOnly, domain.RoleOperator, domain.RoleAdmin} {
+			rec := f.do(t, f.login(t, role), tt.method, tt.target, tt.form)
+			if role.Includes(tt.minimum) {
+				require.NotEqual(t, http.StatusForbidden, rec.Code, "%s %s as %s", tt.method, tt.target, role)
+			} else {
+				require.Equal(t, http.StatusForbidden, rec.Code, "%s %s as %s", tt.method, tt.target, role)
+			}
+		}
+	}
+}
+
+func TestDashboard_PagesRenderTheirOwnContent(t *testing.T) {
+	f := newDashboardFixture(t)
+	token := f.login(t, domain.RoleAdmin)
+
+	rec := f.do(t, token, http.MethodGet, "/dashboard", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NotContains(t, rec.Body.String(), "Create New User")
+
+	rec = f.do(t, token, http.MethodGet, "/dashboard/buckets/missing/versions", nil)
+	require.Contains(t, rec.Body.String(), "Bucket not found")
+
+	rec = f.do(t, token, http.MethodGet, "/dashboard/users", nil)
+	require.Contains(t, rec.Body.String(), "Create New User")
+
+	rec = f.do(t, token, http.MethodGet, "/dashboard/stats", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), "Deduplication ratio")
+	require.Contains(t, rec.Body.String(), "Garbage collection is disabled")
+}
+
+func TestDashboard_AdminManagesRoles(t *testing.T) {
+	f := newDashboardFixture(t)
+	ctx := context.Background()
+	operator := f.users[domain.RoleOperator]
+	admin := f.users[domain.RoleAdmin]
+	operatorToken := f.login(t, domain.RoleOperator)
+	adminToken := f.login(t, domain.RoleAdmin)
+	readOnlyToken := f.login(t, domain.RoleReadOnly)
+
+	// Operators cannot change roles or delete users
+	rec := f.do(t, operatorToken, http.MethodPost, "/dashboard/users/"+strconv.FormatInt(operator.ID, 10)+"/role", url.Values{"role": {"admin"}})
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	rec = f.do(t, operatorToken, http.MethodDelete, "/dashboard/users/"+strconv.FormatInt(admin.ID, 10), nil)
+	require.Equal(t, http.StatusForbidden, rec.Code)
+
+	// Admins can
+	rec = f.do(t, adminToken, http.MethodPost, "/dashboard/users/"+strconv.FormatInt(operator.ID, 10)+"/role", url.Values{"role": {"read-only"}})
+	require.Equal(t, http.StatusOK, rec.Code)
+	user, err := f.userService.GetByID(ctx, operator.ID)
+	require.NoError(t, err)
+	require.Equal(t, domain.RoleReadOnly, user.Role)
+
+	rec = f.do(t, adminToken, http.MethodPost, "/dashboard/users/"+strconv.FormatInt(operator.ID, 10)+"/role", url.Values{"role": {"superuser"}})
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// But not lock themselves out
+	rec = f.do(t, adminToken, http.MethodPost, "/dashboard/users/"+strconv.FormatInt(admin.ID, 10)+"/role", url.Values{"role": {"none"}})
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	rec = f.do(t, adminToken, http.MethodDelete, "/dashboard/users/"+strconv.FormatInt(admin.ID, 10), nil)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	user, err = f.userService.GetByID(ctx, admin.ID)
+	require.NoError(t, err)
+	require.Equal(t, domain.RoleAdmin, user.Role)
+
+	// Sessions of demoted users lose access immediately
+	rec = f.do(t, adminToken, http.MethodPost, "/dashboard/users/"+strconv.FormatInt(f.users[domain.RoleReadOnly].ID, 10)+"/role", url.Values{"role": {"none"}})
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec = f.do(t, readOnlyToken, http.MethodGet, "/dashboard", nil)
+	require.Equal(t, http.StatusFound, rec.Code)
+	_, err = f.sessions.Login(ctx, service.LoginInput{Username: "user-read-only", Password: "password-read-only"})
+	require.ErrorIs(t, err, service.ErrNoDashboardRole)
+}
+
+func TestDashboard_TenantAdminsOnlyManageTheirTenant(t *testing.T) {
+	f := newDashboardFixture(t)
+	ctx := context.Background()
+	tenant, err := f.tenants.Create(ctx, service.CreateTenantInput{Name: "acme"})
+	require.NoError(t, err)
+	_, err = f.userService.Create(ctx, service.CreateUserInput{
+		Username: "acme-admin",
+		Email:    "admin@acme.example.com",
+		Password: "password-acme",
+		Role:     domain.RoleAdmin,
+		TenantID: tenant.ID,
+	})
+	require.NoError(t, err)
+	login, err := f.sessions.Login(ctx, service.LoginInput{Username: "acme-admin", Password: "password-acme"})
+	require.NoError(t, err)
+	token := login.Session.Token
+
+	// Users of the default tenant are out of reach
+	operator := f.users[domain.RoleOperator]
+	rec := f.do(t, token, http.MethodPost, "/dashboard/users/"+strconv.FormatInt(operator.ID, 10)+"/role", url.Values{"role": {"none"}})
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	rec = f.do(t, token, http.MethodDelete, "/dashboard/users/"+strconv.FormatInt(operator.ID, 10), nil)
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	user, err := f.userService.GetByID(ctx, operator.ID)
+	require.NoError(t, err)
+	require.Equal(t, domain.RoleOperator, user.Role)
+
+	rec = f.do(t, token, http.MethodGet, "/dashboard/users", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), "acme-admin")
+	require.NotContains(t, rec.Body.String(), "user-operator")
+	rec = f.do(t, token, http.MethodGet, "/dashboard/stats", nil)
+	require.NotContains(t, rec.Body.String(), "Deduplication ratio")
+
+	// New users join the admin's tenant and can be managed
+	rec = f.do(t, token, http.MethodPost, "/dashboard/users", url.Values{
+		"username": {"wile"}, "email": {"wile@acme.example.com"}, "password": {"correct-horse"}, "role": {"operator"},
+	})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	created, err := f.userService.GetByUsername(ctx, "wile")
+	require.NoError(t, err)
+	require.Equal(t, tenant.ID, created.TenantID)
+	rec = f.do(t, token, http.MethodPost, "/dashboard/users/"+strconv.FormatInt(created.ID, 10)+"/role", url.Values{"role": {"read-only"}})
+	require.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestDashboard_CSRFAndSessionRevocation(t *testing.T) {
+	f := newDashboardFixture(t)
+	token := f.login(t, domain.RoleReadOnly)
+
+	// State-changing requests need the session's CSRF token
+	for _, csrfToken := range []string{"", "forged"} {
+		req := httptest.NewRequest(http.MethodPost, "/dashboard/keys", strings.NewReader(url.Values{"csrf_token": {csrfToken}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(&http.Cookie{Name: "session", Value: token})
+		rec := httptest.NewRecorder()
+		f.router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusForbidden, rec.Code, "csrf token %q", csrfToken)
+	}
+	rec := f.do(t, token, http.MethodPost, "/dashboard/keys", url.Values{})
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), "Access key created")
+
+	// Logging in with "remember me" sets the remember cookie
+	rec = f.do(t, "", http.MethodPost, "/dashboard/login", url.Values{
+		"username":    {"user-read-only"},
+		"password":    {"password-read-only"},
+		"remember_me": {"1"},
+	})
+	require.Equal(t, "/dashboard", rec.Header().Get("HX-Redirect"))
+	var other string
+	var remembered bool
+	for _, cookie := range rec.Result().Cookies() {
+		switch cookie.Name {
+		case "session":
+			other = cookie.Value
+		case "remember":
+			remembered = cookie.MaxAge > 0
+		}
+	}
+	require.NotEmpty(t, other)
+	require.True(t, remembered)
+
+	rec = f.do(t, token, http.MethodGet, "/dashboard/sessions", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), "This browser")
+	require.Contains(t, rec.Body.String(), "(remembered)")
+
+	session, _, err := f.sessions.ValidateSession(context.Background(), other)
+	require.NoError(t, err)
+	rec = f.do(t, f.login(t, domain.RoleAdmin), http.MethodDelete, "/dashboard/sessions/"+session.ID.String(), nil)
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	rec = f.do(t, token, http.MethodDelete, "/dashboard/sessions/"+session.ID.String(), nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec = f.do(t, other, http.MethodGet, "/dashboard", nil)
+	require.Equal(t, http.StatusFound, rec.Code)
+}
+
+func TestDashboard_CreateAndDeleteBuckets(t *testing.T) {
+	f := newDashboardFixture(t)
+	ctx := context.Background()
+	operator := f.users[domain.RoleOperator]
+	token := f.login(t, domain.RoleOperator)
+
+	// Invalid names are reported on the form, which keeps its values
+	rec := f.do(t, token, http.MethodPost, "/dashboard/buckets", url.Values{"name": {"Photos"}, "versioning": {"1"}})
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), "Bucket name must contain only lowercase letters")
+	require.Contains(t, rec.Body.String(), `value="Photos"`)
+	rec = f.do(t, token, http.MethodPost, "/dashboard/buckets", url.Values{"name": {"photos"}, "region": {"mars-north-1"}})
+	require.Contains(t, rec.Body.String(), "Buckets cannot be created in this region")
+
+	rec = f.do(t, token, http.MethodPost, "/dashboard/buckets", url.Values{"name": {"photos"}, "versioning": {"1"}})
+	require.Equal(t, "/dashboard/buckets/photos", rec.Header().Get("HX-Redirect"))
+	bucket, err := f.buckets.GetBucket(ctx, service.GetBucketInput{Name: "photos", OwnerID: operator.ID})
+	require.NoError(t, err)
+	require.Equal(t, domain.VersioningEnabled, bucket.Bucket.Versioning)
+
+	rec = f.do(t, token, http.MethodPost, "/dashboard/buckets/photos/acl", url.Values{"acl": {"public-read"}})
+	require.Equal(t, "bucketUpdated", rec.Header().Get("HX-Trigger"))
+	bucket, err = f.buckets.GetBucket(ctx, service.GetBucketInput{Name: "photos"})
+	require.NoError(t, err)
+	require.Equal(t, domain.ACLPublicRead, bucket.Bucket.ACL)
+	rec = f.do(t, token, http.MethodPost, "/dashboard/buckets/photos/acl", url.Values{"acl": {"authenticated-read"}})
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = f.do(t, token, http.MethodPost, "/dashboard/buckets", url.Values{"name": {"photos"}})
+	require.Contains(t, rec.Body.String(), "A bucket with this name already exists")
+	rec = f.do(t, token, http.MethodGet, "/dashboard/check-bucket-name?name=photos", nil)
+	require.Contains(t, rec.Body.String(), "A bucket with this name already exists")
+	rec = f.do(t, token, http.MethodGet, "/dashboard/check-bucket-name?name=videos", nil)
+	require.Contains(t, rec.Body.String(), "Name is available")
+
+	rec = f.do(t, token, http.MethodGet, "/dashboard?prefix=pho", nil)
+	require.Contains(t, rec.Body.String(), `href="/dashboard/buckets/photos"`)
+	rec = f.do(t, token, http.MethodGet, "/dashboard?prefix=vid", nil)
+	require.Contains(t, rec.Body.String(), "No buckets match the prefix")
+	rec = f.do(t, token, http.MethodGet, "/dashboard/buckets", nil)
+	require.Contains(t, rec.Body.String(), `href="/dashboard/buckets/photos"`)
+
+	// Two versions and a delete marker
+	for _, body := range []string{"v1", "v2"} {
+		_, err := f.objects.PutObject(ctx, service.PutObjectInput{
+			BucketName: "photos",
+			Key:        "cat.jpg",
+			Body:       strings.NewReader(body),
+			Size:       int64(len(body)),
+			OwnerID:    operator.ID,
+		})
+		require.NoError(t, err)
+	}
+	_, err = f.objects.DeleteObject(ctx, service.DeleteObjectInput{BucketName: "photos", Key: "cat.jpg", OwnerID: operator.ID})
+	require.NoError(t, err)
+
+	// Other users' buckets cannot be deleted
+	rec = f.do(t, f.login(t, domain.RoleAdmin), http.MethodDelete, "/dashboard/buckets/photos?force=1", nil)
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = f.do(t, token, http.MethodDelete, "/dashboard/buckets/photos", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), "The bucket is not empty")
+
+	rec = f.do(t, token, http.MethodDelete, "/dashboard/buckets/photos?force=1", nil)
+	require.Equal(t, "/dashboard", rec.Header().Get("HX-Redirect"), rec.Body.String())
+	_, err = f.buckets.GetBucket(ctx, service.GetBucketInput{Name: "photos"})
+	require.ErrorIs(t, err, domain.ErrBucketNotFound)
+}
+
+func TestDashboard_BucketObjectDefaults(t *testing.T) {
+	f := newDashboardFixture(t)
+	ctx := context.Background()
+	operator := f.users[domain.RoleOperator]
+	token := f.login(t, domain.RoleOperator)
+
+	_, err := f.buckets.CreateBucket(ctx, service.CreateBucketInput{OwnerID: operator.ID, Name: "logs"})
+	require.NoError(t, err)
+
+	rec := f.do(t, token, http.MethodPost, "/dashboard/buckets/logs/defaults", url.Values{
+		"cache_control": {"max-age=60"},
+		"storage_class": {"REDUCED_REDUNDANCY"},
+		"content_types": {".log=text/plain\r\nmd=text/markdown\r\n"},
+	})
+	require.Equal(t, "bucketUpdated", rec.Header().Get("HX-Trigger"), rec.Body.String())
+
+	rec = f.do(t, token, http.MethodGet, "/dashboard/buckets/logs", nil)
+	require.Contains(t, rec.Body.String(), `value="max-age=60"`)
+	require.Contains(t, rec.Body.String(), `<option value="REDUCED_REDUNDANCY" selected>`)
+	require.Contains(t, rec.Body.String(), ".log=text/plain\n.md=text/markdown\n</textarea>")
+
+	_, err = f.objects.PutObject(ctx, service.PutObjectInput{
+		BucketName: "logs",
+		Key:        "app.LOG",
+		Body:       strings.NewReader("started"),
+		Size:       7,
+		OwnerID:    operator.ID,
+	})
+	require.NoError(t, err)
+	head, err := f.objects.HeadObject(ctx, service.HeadObjectInput{BucketName: "logs", Key: "app.LOG", OwnerID: operator.ID})
+	require.NoError(t, err)
+	require.Equal(t, "text/plain", head.ContentType)
+	require.Equal(t, domain.StorageClassReducedRedundancy, head.StorageClass)
+	require.Equal(t, "max-age=60", head.Metadata["Cache-Control"])
+
+	rec = f.do(t, token, http.MethodPost, "/dashboard/buckets/logs/defaults", url.Values{"content_types": {"log"}})
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Contains(t, rec.Body.String(), "not of the form ext=type")
+
+	// An empty form removes the defaults
+	rec = f.do(t, token, http.MethodPost, "/dashboard/buckets/logs/defaults", url.Values{})
+	require.Equal(t, http.StatusOK, rec.Code)
+	bucket, err := f.buckets.GetBucket(ctx, service.GetBucketInput{Name: "logs"})
+	require.NoError(t, err)
+	require.Nil(t, bucket.Bucket.ObjectDefaults)
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+)
+
+// encodingTypeURL is the only encoding-type S3 list requests accept.
+const encodingTypeURL = "url"
+
+// errInvalidEncodingType is returned for list requests with an
+// encoding-type other than url.
+var errInvalidEncodingType = S3Error{
+	Code:           "InvalidArgument",
+	Message:        "Invalid Encoding Method specified in Request",
+	HTTPStatusCode: http.StatusBadRequest,
+}
+
+// listEncoding reads the encoding-type of a list request. It returns the
+// value to echo in the response's EncodingType element, "" when the request
+// sets none, and a function encoding the key names of the response to
+// match. It writes an error and returns false if the encoding-type is not
+// one S3 supports.
+func listEncoding(w http.ResponseWriter, r *http.Request) (string, func(string) string, bool) {
+	encodingType := r.URL.Query().Get("encoding-type")
+	switch {
+	case encodingType == "":
+		return "", func(s string) string { return s }, true
+	case strings.EqualFold(encodingType, encodingTypeURL):
+		return encodingTypeURL, s3URLEncode, true
+	default:
+		writeError(w, errInvalidEncodingType)
+		return "", nil, false
+	}
+}
+
+// s3URLEncode encodes a key name the way S3 does for encoding-type=url:
+// letters, digits and "-_.*/" are kept, spaces become "+" and every other
+// byte of the UTF-8 name is percent-encoded in upper-case hex. SDKs decode
+// the names with the equivalent of url.QueryUnescape.
+func s3URLEncode(s string) string {
+	const hex = "0123456789ABCDEF"
+
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+			b.WriteByte(c)
+		case c == '-', c == '_', c == '.', c == '*', c == '/':
+			b.WriteByte(c)
+		case c == ' ':
+			b.WriteByte('+')
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hex[c>>4])
+			b.WriteByte(hex[c&0x0f])
+		}
+	}
+	return b.String()
+}
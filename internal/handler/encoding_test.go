@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestS3URLEncode(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain", "photos/2024/cat.jpg", "photos/2024/cat.jpg"},
+		{"kept punctuation", "a-b_c.d*e/f", "a-b_c.d*e/f"},
+		{"space", "my photos/cat 1.jpg", "my+photos/cat+1.jpg"},
+		{"reserved", "a+b=c&d?e#f%g~h", "a%2Bb%3Dc%26d%3Fe%23f%25g%7Eh"},
+		{"unicode", "café/日本.txt", "caf%C3%A9/%E6%97%A5%E6%9C%AC.txt"},
+		{"control", "a\x01b\nc", "a%01b%0Ac"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := s3URLEncode(tt.in)
+			assert.Equal(t, tt.want, got)
+
+			decoded, err := url.QueryUnescape(got)
+			require.NoError(t, err)
+			assert.Equal(t, tt.in, decoded, "SDKs decode names as query values")
+		})
+	}
+}
+
+func TestListEncoding(t *testing.T) {
+	tests := []struct {
+		query    string
+		wantType string
+		wantKey  string
+		wantOK   bool
+	}{
+		{"", "", "a b/ü", true},
+		{"encoding-type=url", "url", "a+b/%C3%BC", true},
+		{"encoding-type=URL", "url", "a+b/%C3%BC", true},
+		{"encoding-type=base64", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			encodingType, encode, ok := listEncoding(w, httptest.NewRequest("GET", "/photos?"+tt.query, nil))
+			require.Equal(t, tt.wantOK, ok)
+			if !ok {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Contains(t, w.Body.String(), "<Code>InvalidArgument</Code>")
+				return
+			}
+			assert.Equal(t, tt.wantType, encodingType)
+			assert.Equal(t, tt.wantKey, encode("a b/ü"))
+		})
+	}
+}
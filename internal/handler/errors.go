@@ -0,0 +1,310 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/service"
+	"github.com/prn-tf/alexander-storage/internal/tiering"
+)
+
+// errorMapping maps a domain or service error to the S3 error returned for it.
+type errorMapping struct {
+	err   error
+	s3Err S3Error
+
+	// detailed replaces the message with the error's own, for validation
+	// errors that say what was wrong.
+	detailed bool
+}
+
+// errNoSuchKey is returned for missing objects.
+var errNoSuchKey = S3Error{
+	Code:           "NoSuchKey",
+	Message:        "The specified key does not exist.",
+	HTTPStatusCode: http.StatusNotFound,
+}
+
+// errorMappings are checked in order with errors.Is; the first match wins.
+var errorMappings = []errorMapping{
+	// Access
+	{err: service.ErrBucketAccessDenied, s3Err: ErrAccessDenied},
+	{err: domain.ErrAccessDenied, s3Err: ErrAccessDenied},
+	{err: service.ErrResidencyViolation, s3Err: ErrResidencyViolation},
+	{err: domain.ErrMFARequired, s3Err: S3Error{
+		Code:           "AccessDenied",
+		Message:        "Mfa Authentication must be used for this request",
+		HTTPStatusCode: http.StatusForbidden,
+	}},
+	{err: domain.ErrInvalidMFA, s3Err: ErrAccessDenied, detailed: true},
+
+	// Buckets
+	{err: domain.ErrBucketNotFound, s3Err: ErrNoSuchBucket},
+	{err: domain.ErrBucketAlreadyExists, s3Err: ErrBucketAlreadyExists},
+	{err: domain.ErrBucketNotEmpty, s3Err: ErrBucketNotEmpty},
+	{err: domain.ErrBucketNameLength, s3Err: ErrInvalidBucketName, detailed: true},
+	{err: domain.ErrBucketNameFormat, s3Err: ErrInvalidBucketName, detailed: true},
+	{err: domain.ErrBucketNameIPFormat, s3Err: ErrInvalidBucketName, detailed: true},
+	{err: service.ErrInvalidVersioningStatus, s3Err: ErrIllegalVersioningConfigurationException},
+	{err: domain.ErrInvalidLocationConstraint, s3Err: ErrInvalidLocationConstraint},
+	{err: domain.ErrInvalidResidency, s3Err: errInvalidArgument, detailed: true},
+	{err: service.ErrInvalidBucketACL, s3Err: errInvalidArgument, detailed: true},
+	{err: service.ErrInvalidObjectACL, s3Err: errInvalidArgument, detailed: true},
+	{err: domain.ErrNoSuchTagSet, s3Err: S3Error{
+		Code:           "NoSuchTagSet",
+		Message:        "The TagSet does not exist.",
+		HTTPStatusCode: http.StatusNotFound,
+	}},
+	{err: domain.ErrInvalidTag, s3Err: S3Error{
+		Code:           "InvalidTag",
+		Message:        "The tag provided was not a valid tag.",
+		HTTPStatusCode: http.StatusBadRequest,
+	}, detailed: true},
+	{err: domain.ErrInvalidObjectDefaults, s3Err: errInvalidArgument, detailed: true},
+
+	// Read-only mode; a code of its own tells clients to retry once it ends
+	{err: domain.ErrBucketReadOnly, s3Err: S3Error{
+		Code:           "ReadOnlyMode",
+		Message:        "The bucket is read-only for maintenance. Please retry later.",
+		HTTPStatusCode: http.StatusServiceUnavailable,
+	}},
+	{err: domain.ErrServiceReadOnly, s3Err: S3Error{
+		Code:           "ReadOnlyMode",
+		Message:        "The service is read-only for maintenance. Please retry later.",
+		HTTPStatusCode: http.StatusServiceUnavailable,
+	}},
+
+	// Tenant quotas
+	{err: domain.ErrTenantBucketQuotaExceeded, s3Err: S3Error{
+		Code:           "TooManyBuckets",
+		Message:        "You have attempted to create more buckets than allowed.",
+		HTTPStatusCode: http.StatusBadRequest,
+	}},
+	{err: domain.ErrTenantStorageQuotaExceeded, s3Err: S3Error{
+		Code:           "QuotaExceeded",
+		Message:        "The storage quota of your tenant has been exceeded.",
+		HTTPStatusCode: http.StatusForbidden,
+	}},
+
+	// Bucket quotas
+	{err: domain.ErrBucketQuotaExceeded, s3Err: S3Error{
+		Code:           "QuotaExceeded",
+		Message:        "The storage quota of the bucket has been exceeded.",
+		HTTPStatusCode: http.StatusForbidden,
+	}},
+	{err: domain.ErrBucketNameNotInNamespace, s3Err: ErrInvalidBucketName, detailed: true},
+
+	// Objects
+	{err: domain.ErrObjectNotFound, s3Err: errNoSuchKey},
+	{err: domain.ErrObjectDeleted, s3Err: errNoSuchKey},
+	{err: domain.ErrVersionNotFound, s3Err: S3Error{
+		Code:           "NoSuchVersion",
+		Message:        "The specified version does not exist.",
+		HTTPStatusCode: http.StatusNotFound,
+	}},
+	{err: domain.ErrObjectKeyEmpty, s3Err: S3Error{
+		Code:           "InvalidArgument",
+		Message:        "Object key cannot be empty.",
+		HTTPStatusCode: http.StatusBadRequest,
+	}},
+	{err: domain.ErrObjectKeyTooLong, s3Err: S3Error{
+		Code:           "KeyTooLongError",
+		Message:        "Your key is too long.",
+		HTTPStatusCode: http.StatusBadRequest,
+	}},
+	{err: domain.ErrInvalidVersionID, s3Err: S3Error{
+		Code:           "InvalidArgument",
+		Message:        "Invalid version id specified.",
+		HTTPStatusCode: http.StatusBadRequest,
+	}},
+	{err: domain.ErrSequenceMismatch, s3Err: S3Error{
+		Code:           "PreconditionFailed",
+		Message:        "At least one of the pre-conditions you specified did not hold.",
+		HTTPStatusCode: http.StatusPreconditionFailed,
+	}},
+	{err: service.ErrObjectBusy, s3Err: S3Error{
+		Code:           "OperationAborted",
+		Message:        "A conflicting operation is currently in progress against this resource. Please try again.",
+		HTTPStatusCode: http.StatusConflict,
+	}},
+	{err: service.ErrUploadBusy, s3Err: S3Error{
+		Code:           "OperationAborted",
+		Message:        "A conflicting operation is currently in progress against this resource. Please try again.",
+		HTTPStatusCode: http.StatusConflict,
+	}},
+	{err: domain.ErrPartNumberNotSatisfiable, s3Err: S3Error{
+		Code:           "InvalidPartNumber",
+		Message:        "The requested partnumber is not satisfiable.",
+		HTTPStatusCode: http.StatusRequestedRangeNotSatisfiable,
+	}},
+	{err: domain.ErrInvalidChecksum, s3Err: S3Error{
+		Code:           "InvalidRequest",
+		HTTPStatusCode: http.StatusBadRequest,
+	}, detailed: true},
+	{err: domain.ErrInvalidRename, s3Err: S3Error{
+		Code:           "InvalidRequest",
+		HTTPStatusCode: http.StatusBadRequest,
+	}, detailed: true},
+	{err: domain.ErrInvalidStorageClass, s3Err: S3Error{
+		Code:           "InvalidStorageClass",
+		Message:        "The storage class you specified is not valid.",
+		HTTPStatusCode: http.StatusBadRequest,
+	}},
+	{err: domain.ErrChecksumMismatch, s3Err: S3Error{
+		Code:           "BadDigest",
+		Message:        "The checksum you specified did not match the calculated checksum.",
+		HTTPStatusCode: http.StatusBadRequest,
+	}},
+	{err: service.ErrRestoreNotSupported, s3Err: ErrNotImplemented},
+	{err: tiering.ErrNotArchived, s3Err: S3Error{
+		Code:           "InvalidObjectState",
+		Message:        "The operation is not valid for the object's storage class.",
+		HTTPStatusCode: http.StatusForbidden,
+	}},
+	{err: tiering.ErrRestoreInProgress, s3Err: S3Error{
+		Code:           "RestoreAlreadyInProgress",
+		Message:        "Object restore is already in progress.",
+		HTTPStatusCode: http.StatusConflict,
+	}},
+	{err: tiering.ErrInvalidRestoreDays, s3Err: errInvalidArgument, detailed: true},
+
+	// Multipart uploads
+	{err: domain.ErrMultipartUploadNotFound, s3Err: S3Error{
+		Code:           "NoSuchUpload",
+		Message:        "The specified multipart upload does not exist.",
+		HTTPStatusCode: http.StatusNotFound,
+	}},
+	{err: domain.ErrMultipartUploadExpired, s3Err: S3Error{
+		Code:           "NoSuchUpload",
+		Message:        "The specified multipart upload has expired.",
+		HTTPStatusCode: http.StatusNotFound,
+	}},
+	{err: domain.ErrMultipartUploadCompleted, s3Err: S3Error{
+		Code:           "NoSuchUpload",
+		Message:        "The specified multipart upload is already completed.",
+		HTTPStatusCode: http.StatusNotFound,
+	}},
+	{err: domain.ErrMultipartUploadAborted, s3Err: S3Error{
+		Code:           "NoSuchUpload",
+		Message:        "The specified multipart upload has been aborted.",
+		HTTPStatusCode: http.StatusNotFound,
+	}},
+	{err: domain.ErrInvalidPartNumber, s3Err: S3Error{
+		Code:           "InvalidArgument",
+		Message:        "Part number must be an integer between 1 and 10000.",
+		HTTPStatusCode: http.StatusBadRequest,
+	}},
+	{err: domain.ErrPartTooSmall, s3Err: S3Error{
+		Code:           "EntityTooSmall",
+		HTTPStatusCode: http.StatusBadRequest,
+	}, detailed: true},
+	{err: domain.ErrPartTooLarge, s3Err: S3Error{
+		Code:           "EntityTooLarge",
+		HTTPStatusCode: http.StatusBadRequest,
+	}, detailed: true},
+	{err: domain.ErrTooManyParts, s3Err: errInvalidArgument, detailed: true},
+	{err: domain.ErrInvalidChunkHash, s3Err: errInvalidArgument, detailed: true},
+	{err: domain.ErrPartNotFound, s3Err: S3Error{
+		Code:           "InvalidPart",
+		Message:        "One or more of the specified parts could not be found.",
+		HTTPStatusCode: http.StatusBadRequest,
+	}},
+	{err: domain.ErrPartETagMismatch, s3Err: S3Error{
+		Code:           "InvalidPart",
+		Message:        "One or more of the specified parts had invalid ETags.",
+		HTTPStatusCode: http.StatusBadRequest,
+	}},
+	{err: domain.ErrPartChecksumMismatch, s3Err: S3Error{
+		Code:           "InvalidPart",
+		Message:        "One or more of the specified parts had invalid checksums.",
+		HTTPStatusCode: http.StatusBadRequest,
+	}},
+	{err: domain.ErrInvalidPartOrder, s3Err: S3Error{
+		Code:           "InvalidPartOrder",
+		Message:        "Parts must be specified in ascending order by part number.",
+		HTTPStatusCode: http.StatusBadRequest,
+	}},
+	{err: domain.ErrNoPartsProvided, s3Err: S3Error{
+		Code:           "MalformedXML",
+		Message:        "The XML you provided did not have the required number of parts.",
+		HTTPStatusCode: http.StatusBadRequest,
+	}},
+
+	// Batches
+	{err: domain.ErrBatchNotFound, s3Err: S3Error{
+		Code:           "NoSuchBatch",
+		Message:        "The specified batch does not exist or has expired.",
+		HTTPStatusCode: http.StatusNotFound,
+	}},
+	{err: domain.ErrBatchTooLarge, s3Err: S3Error{
+		Code:           "InvalidRequest",
+		Message:        "The specified batch holds the maximum number of writes.",
+		HTTPStatusCode: http.StatusBadRequest,
+	}},
+	{err: domain.ErrBatchQuarantineBucket, s3Err: S3Error{
+		Code:           "InvalidRequest",
+		Message:        "Writes to a quarantine bucket cannot be part of a batch.",
+		HTTPStatusCode: http.StatusBadRequest,
+	}},
+
+	// Bucket subresources
+	{err: domain.ErrReplicationConfigNotFound, s3Err: S3Error{
+		Code:           "ReplicationConfigurationNotFoundError",
+		Message:        "The replication configuration was not found.",
+		HTTPStatusCode: http.StatusNotFound,
+	}},
+	{err: service.ErrReplicationRequiresVersioning, s3Err: S3Error{
+		Code:           "InvalidRequest",
+		Message:        "Versioning must be 'Enabled' on the bucket to apply a replication configuration.",
+		HTTPStatusCode: http.StatusBadRequest,
+	}},
+	{err: service.ErrReplicationSecretRequired, s3Err: S3Error{
+		Code:           "InvalidArgument",
+		Message:        "The destination SecretAccessKey is required.",
+		HTTPStatusCode: http.StatusBadRequest,
+	}},
+	{err: domain.ErrInvalidReplicationConfig, s3Err: errInvalidArgument, detailed: true},
+	{err: domain.ErrInventoryConfigNotFound, s3Err: S3Error{
+		Code:           "NoSuchConfiguration",
+		Message:        "The specified configuration does not exist.",
+		HTTPStatusCode: http.StatusNotFound,
+	}},
+	{err: domain.ErrTooManyInventoryConfigs, s3Err: S3Error{
+		Code:           "TooManyConfigurations",
+		Message:        "You are attempting to create a new configuration but have already reached the limit.",
+		HTTPStatusCode: http.StatusBadRequest,
+	}},
+	{err: service.ErrInventoryDestinationNotOwned, s3Err: S3Error{
+		Code:           "InvalidArgument",
+		Message:        "The destination bucket must belong to the owner of the source bucket.",
+		HTTPStatusCode: http.StatusBadRequest,
+	}},
+	{err: domain.ErrInvalidInventoryConfig, s3Err: errInvalidArgument, detailed: true},
+	{err: domain.ErrWebsiteConfigNotFound, s3Err: errNoSuchWebsiteConfiguration},
+	{err: domain.ErrInvalidWebsiteConfig, s3Err: errInvalidArgument, detailed: true},
+	{err: domain.ErrLifecycleConfigNotFound, s3Err: errNoSuchLifecycleConfiguration},
+	{err: domain.ErrInvalidLifecycleRule, s3Err: errInvalidArgument, detailed: true},
+}
+
+// errInvalidArgument is the base of detailed validation errors.
+var errInvalidArgument = S3Error{
+	Code:           "InvalidArgument",
+	HTTPStatusCode: http.StatusBadRequest,
+}
+
+// s3ErrorFor returns the S3 error for a domain or service error. It
+// returns ErrInternalError and false for errors without a mapping, which
+// callers should log.
+func s3ErrorFor(err error) (S3Error, bool) {
+	for _, mapping := range errorMappings {
+		if errors.Is(err, mapping.err) {
+			s3Err := mapping.s3Err
+			if mapping.detailed {
+				s3Err.Message = err.Error()
+			}
+			return s3Err, true
+		}
+	}
+	return ErrInternalError, false
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/middleware"
+	"github.com/prn-tf/alexander-storage/internal/service"
+)
+
+func TestS3ErrorFor(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		code    string
+		status  int
+		message string
+		mapped  bool
+	}{
+		{"wrapped", fmt.Errorf("get object: %w", domain.ErrObjectNotFound), "NoSuchKey", http.StatusNotFound, "The specified key does not exist.", true},
+		{"service", service.ErrBucketAccessDenied, "AccessDenied", http.StatusForbidden, "Access Denied", true},
+		{"detailed", fmt.Errorf("%w: IndexDocument is required", domain.ErrInvalidWebsiteConfig), "InvalidArgument", http.StatusBadRequest,
+			"invalid website configuration: IndexDocument is required", true},
+		{"read-only", fmt.Errorf("put object: %w", domain.ErrBucketReadOnly), "ReadOnlyMode", http.StatusServiceUnavailable,
+			"The bucket is read-only for maintenance. Please retry later.", true},
+		{"tenant quota", fmt.Errorf("put object: %w", domain.ErrTenantStorageQuotaExceeded), "QuotaExceeded", http.StatusForbidden,
+			"The storage quota of your tenant has been exceeded.", true},
+		{"bucket quota", fmt.Errorf("put object: %w", domain.ErrBucketQuotaExceeded), "QuotaExceeded", http.StatusForbidden,
+			"The storage quota of the bucket has been exceeded.", true},
+		{"namespace", fmt.Errorf("%w: %q", domain.ErrBucketNameNotInNamespace, "acme-"), "InvalidBucketName", http.StatusBadRequest,
+			`bucket name must start with the tenant name and a hyphen: "acme-"`, true},
+		{"unmapped", errors.New("disk on fire"), "InternalError", http.StatusInternalServerError, ErrInternalError.Message, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s3Err, mapped := s3ErrorFor(tt.err)
+			assert.Equal(t, tt.mapped, mapped)
+			assert.Equal(t, tt.code, s3Err.Code)
+			assert.Equal(t, tt.status, s3Err.HTTPStatusCode)
+			assert.Equal(t, tt.message, s3Err.Message)
+		})
+	}
+}
+
+func TestWriteError_RequestID(t *testing.T) {
+	w := httptest.NewRecorder()
+	w.Header().Set(middleware.HeaderAmzRequestID, "req-1")
+	w.Header().Set(middleware.HeaderAmzID2, "host-1")
+
+	s3Err := ErrNoSuchBucket
+	s3Err.Resource = "/photos"
+	writeError(w, s3Err)
+
+	require.Equal(t, http.StatusNotFound, w.Code)
+	var response ErrorResponse
+	require.NoError(t, xml.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "NoSuchBucket", response.Code)
+	assert.Equal(t, "/photos", response.Resource)
+	assert.Equal(t, "req-1", response.RequestID)
+	assert.Equal(t, "host-1", response.HostID)
+}
@@ -0,0 +1,194 @@
+// Package handler provides HTTP handlers for Alexander Storage API.
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/auth"
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/service"
+)
+
+// EventHandler streams object change events to watch clients.
+type EventHandler struct {
+	eventService      *service.EventService
+	pollInterval      time.Duration
+	heartbeatInterval time.Duration
+	logger            zerolog.Logger
+}
+
+// NewEventHandler creates a new EventHandler.
+func NewEventHandler(eventService *service.EventService, pollInterval, heartbeatInterval time.Duration, logger zerolog.Logger) *EventHandler {
+	return &EventHandler{
+		eventService:      eventService,
+		pollInterval:      pollInterval,
+		heartbeatInterval: heartbeatInterval,
+		logger:            logger.With().Str("handler", "event").Logger(),
+	}
+}
+
+// Watch handles GET /{bucket}?watch[&prefix=X][&after=ID] requests.
+// Events are sent as Server-Sent Events until the client disconnects; the
+// event ID is the resume cursor, taken from Last-Event-ID or ?after=.
+func (h *EventHandler) Watch(w http.ResponseWriter, r *http.Request, bucketName string) {
+	ctx := r.Context()
+
+	// Get authenticated user from context
+	userCtx, ok := auth.GetUserContext(ctx)
+	if !ok {
+		h.logger.Error().Msg("no user context found")
+		writeError(w, ErrAccessDenied)
+		return
+	}
+
+	input := service.WatchInput{
+		BucketName: bucketName,
+		Prefix:     r.URL.Query().Get("prefix"),
+		OwnerID:    userCtx.UserID,
+	}
+	if !parseEventCursor(r, &input) {
+		writeError(w, S3Error{
+			Code:           "InvalidArgument",
+			Message:        "The event cursor must be a non-negative integer.",
+			HTTPStatusCode: http.StatusBadRequest,
+			Resource:       "/" + bucketName,
+		})
+		return
+	}
+
+	watch, err := h.eventService.OpenWatch(ctx, input)
+	if err != nil {
+		h.handleError(w, err, bucketName)
+		return
+	}
+
+	h.stream(w, r, watch, input)
+}
+
+// WatchAll streams the events of every bucket and of the garbage collector
+// like Watch. It serves GET /_alexander/admin/events, whose caller the admin
+// API has already authenticated.
+func (h *EventHandler) WatchAll(w http.ResponseWriter, r *http.Request) {
+	input := service.WatchInput{Prefix: r.URL.Query().Get("prefix")}
+	if !parseEventCursor(r, &input) {
+		writeJSONError(w, http.StatusBadRequest, "the event cursor must be a non-negative integer")
+		return
+	}
+
+	watch, err := h.eventService.OpenWatch(r.Context(), input)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to open admin watch")
+		writeJSONError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	h.stream(w, r, watch, input)
+}
+
+// parseEventCursor sets the resume cursor of input from Last-Event-ID or
+// ?after=. It returns false if the cursor is not a non-negative integer.
+func parseEventCursor(r *http.Request, input *service.WatchInput) bool {
+	cursor := r.Header.Get("Last-Event-ID")
+	if cursor == "" {
+		cursor = r.URL.Query().Get("after")
+	}
+	if cursor == "" {
+		return true
+	}
+	afterID, err := strconv.ParseInt(cursor, 10, 64)
+	if err != nil || afterID < 0 {
+		return false
+	}
+	input.AfterID = afterID
+	input.Resume = true
+	return true
+}
+
+// stream sends the events of an open watch as Server-Sent Events until the
+// client disconnects.
+func (h *EventHandler) stream(w http.ResponseWriter, r *http.Request, watch *service.Watch, input service.WatchInput) {
+	ctx := r.Context()
+	// The stream outlives the server's write timeout
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.Debug().Err(err).Msg("could not clear write deadline for watch stream")
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		h.logger.Error().Err(err).Msg("watch stream does not support flushing")
+		return
+	}
+
+	h.logger.Debug().
+		Str("bucket", input.BucketName).
+		Str("prefix", input.Prefix).
+		Int64("cursor", watch.Cursor()).
+		Msg("watch opened")
+
+	poll := time.NewTicker(h.pollInterval)
+	defer poll.Stop()
+	heartbeat := time.NewTicker(h.heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case <-poll.C:
+			events, err := watch.Poll(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					h.logger.Error().Err(err).Str("bucket", input.BucketName).Msg("failed to poll events")
+				}
+				return
+			}
+			if len(events) == 0 {
+				continue
+			}
+			for _, event := range events {
+				if err := writeEvent(w, event); err != nil {
+					return
+				}
+			}
+			heartbeat.Reset(h.heartbeatInterval)
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeEvent writes one Server-Sent Event.
+func writeEvent(w http.ResponseWriter, event *domain.ObjectEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
+
+// handleError converts service errors to S3 errors.
+func (h *EventHandler) handleError(w http.ResponseWriter, err error, bucketName string) {
+	s3Err, ok := s3ErrorFor(err)
+	if !ok {
+		h.logger.Error().Err(err).Str("bucket", bucketName).Msg("unhandled error")
+	}
+
+	s3Err.Resource = "/" + bucketName
+	writeError(w, s3Err)
+}
@@ -2,8 +2,13 @@
 package handler
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"sync"
 	"time"
@@ -17,6 +22,9 @@ import (
 type HealthChecker struct {
 	dbChecker      DatabaseChecker
 	storageBackend storage.Backend
+	redisChecker   RedisChecker
+	gc             GCReporter
+	thresholds     HealthThresholds
 	logger         zerolog.Logger
 
 	// Cached status for efficiency
@@ -31,24 +39,67 @@ type DatabaseChecker interface {
 	Ping(ctx context.Context) error
 }
 
+// RedisChecker interface for Redis health checks.
+type RedisChecker interface {
+	Health(ctx context.Context) error
+}
+
+// GCReporter reports when garbage collection last ran.
+type GCReporter interface {
+	LastRun() time.Time
+}
+
+// HealthThresholds are the limits past which a dependency is reported
+// degraded. A degraded server still reports ready.
+type HealthThresholds struct {
+	DatabaseLatency time.Duration
+	StorageLatency  time.Duration
+	RedisLatency    time.Duration
+
+	// GCLag is how long garbage collection may go without a run.
+	GCLag time.Duration
+}
+
 // HealthCheckerConfig contains health checker configuration.
 type HealthCheckerConfig struct {
 	DatabaseChecker DatabaseChecker
 	StorageBackend  storage.Backend
+	RedisChecker    RedisChecker // Optional; nil omits the redis component
+	GC              GCReporter   // Optional; nil omits the gc component
+	Thresholds      HealthThresholds
 	Logger          zerolog.Logger
 	CacheTTL        time.Duration
 }
 
-// NewHealthChecker creates a new health checker.
+// NewHealthChecker creates a new health checker. Zero thresholds get
+// defaults: 100ms for the database and Redis, 500ms for storage and 3h for
+// garbage collection.
 func NewHealthChecker(config HealthCheckerConfig) *HealthChecker {
 	cacheTTL := config.CacheTTL
 	if cacheTTL == 0 {
 		cacheTTL = 5 * time.Second
 	}
 
+	thresholds := config.Thresholds
+	if thresholds.DatabaseLatency == 0 {
+		thresholds.DatabaseLatency = 100 * time.Millisecond
+	}
+	if thresholds.StorageLatency == 0 {
+		thresholds.StorageLatency = 500 * time.Millisecond
+	}
+	if thresholds.RedisLatency == 0 {
+		thresholds.RedisLatency = 100 * time.Millisecond
+	}
+	if thresholds.GCLag == 0 {
+		thresholds.GCLag = 3 * time.Hour
+	}
+
 	return &HealthChecker{
 		dbChecker:      config.DatabaseChecker,
 		storageBackend: config.StorageBackend,
+		redisChecker:   config.RedisChecker,
+		gc:             config.GC,
+		thresholds:     thresholds,
 		logger:         config.Logger.With().Str("handler", "health").Logger(),
 		cacheTTL:       cacheTTL,
 	}
@@ -60,7 +111,7 @@ type HealthStatus struct {
 	Timestamp  time.Time                   `json:"timestamp"`
 	Version    string                      `json:"version,omitempty"`
 	Uptime     string                      `json:"uptime,omitempty"`
-	Components map[string]*ComponentStatus `json:"components"`
+	Components map[string]*ComponentStatus `json:"components,omitempty"`
 }
 
 // ComponentStatus represents the health of a single component.
@@ -80,7 +131,7 @@ const (
 
 var startTime = time.Now()
 
-// HandleLiveness handles liveness probe requests (Kubernetes /healthz).
+// HandleLiveness handles liveness probe requests (Kubernetes /livez).
 // Returns 200 if the server is running (always succeeds if handler is called).
 func (h *HealthChecker) HandleLiveness(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -90,41 +141,38 @@ func (h *HealthChecker) HandleLiveness(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// HandleReadiness handles readiness probe requests (Kubernetes /readyz).
-// Returns 200 if the server is ready to accept traffic.
+// HandleReadiness handles readiness probe requests (Kubernetes /readyz and
+// /healthz). Returns 200 unless the database or storage is unhealthy. The
+// body holds the overall status, with every component's status if the
+// verbose query parameter is present.
 func (h *HealthChecker) HandleReadiness(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
-
-	status := h.checkComponents(ctx)
-
-	w.Header().Set("Content-Type", "application/json")
-
-	if status.Status == StatusHealthy || status.Status == StatusDegraded {
-		w.WriteHeader(http.StatusOK)
-	} else {
-		w.WriteHeader(http.StatusServiceUnavailable)
+	status := h.status(r.Context())
+	if _, verbose := r.URL.Query()["verbose"]; !verbose {
+		status = &HealthStatus{Status: status.Status, Timestamp: status.Timestamp}
 	}
-
-	json.NewEncoder(w).Encode(status)
+	h.writeHealthResponse(w, status)
 }
 
 // HandleHealth handles detailed health check requests.
 // This is the main health endpoint with full component status.
 func (h *HealthChecker) HandleHealth(w http.ResponseWriter, r *http.Request) {
+	h.writeHealthResponse(w, h.status(r.Context()))
+}
+
+// status returns the status of all components, checked at most once per
+// cache TTL so that probes do not load the dependencies.
+func (h *HealthChecker) status(ctx context.Context) *HealthStatus {
 	// Check for cached status
 	h.mu.RLock()
 	if h.cachedStatus != nil && time.Now().Before(h.cacheExpiry) {
 		status := h.cachedStatus
 		h.mu.RUnlock()
-
-		h.writeHealthResponse(w, status)
-		return
+		return status
 	}
 	h.mu.RUnlock()
 
 	// Perform health check
-	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	status := h.checkComponents(ctx)
@@ -136,7 +184,7 @@ func (h *HealthChecker) HandleHealth(w http.ResponseWriter, r *http.Request) {
 	h.cacheExpiry = time.Now().Add(h.cacheTTL)
 	h.mu.Unlock()
 
-	h.writeHealthResponse(w, status)
+	return status
 }
 
 func (h *HealthChecker) writeHealthResponse(w http.ResponseWriter, status *HealthStatus) {
@@ -154,7 +202,9 @@ func (h *HealthChecker) writeHealthResponse(w http.ResponseWriter, status *Healt
 	json.NewEncoder(w).Encode(status)
 }
 
-// checkComponents checks all components and returns health status.
+// checkComponents checks all components and returns health status. The
+// server is unhealthy if the database or storage is; any other failing
+// component only degrades it.
 func (h *HealthChecker) checkComponents(ctx context.Context) *HealthStatus {
 	status := &HealthStatus{
 		Status:     StatusHealthy,
@@ -170,13 +220,21 @@ func (h *HealthChecker) checkComponents(ctx context.Context) *HealthStatus {
 	storageStatus := h.checkStorage(ctx)
 	status.Components["storage"] = storageStatus
 
+	if h.redisChecker != nil {
+		status.Components["redis"] = h.checkRedis(ctx)
+	}
+	if h.gc != nil {
+		status.Components["gc"] = h.checkGC()
+	}
+
 	// Determine overall status
-	for _, comp := range status.Components {
-		if comp.Status == StatusUnhealthy {
+	for name, comp := range status.Components {
+		critical := name == "database" || name == "storage"
+		if comp.Status == StatusUnhealthy && critical {
 			status.Status = StatusUnhealthy
 			break
 		}
-		if comp.Status == StatusDegraded {
+		if comp.Status != StatusHealthy {
 			status.Status = StatusDegraded
 		}
 	}
@@ -206,18 +264,14 @@ func (h *HealthChecker) checkDatabase(ctx context.Context) *ComponentStatus {
 		}
 	}
 
-	status := StatusHealthy
-	if latency > 100*time.Millisecond {
-		status = StatusDegraded
-	}
-
 	return &ComponentStatus{
-		Status:  status,
+		Status:  latencyStatus(latency, h.thresholds.DatabaseLatency),
 		Latency: latency.String(),
 	}
 }
 
-// checkStorage checks storage backend accessibility.
+// checkStorage checks that the storage backend is accessible, and that a
+// canary blob can be written, read back and deleted.
 func (h *HealthChecker) checkStorage(ctx context.Context) *ComponentStatus {
 	if h.storageBackend == nil {
 		return &ComponentStatus{
@@ -227,10 +281,10 @@ func (h *HealthChecker) checkStorage(ctx context.Context) *ComponentStatus {
 	}
 
 	start := time.Now()
-
-	// Try to check if storage is accessible
-	// We'll use a simple operation that doesn't require a specific blob
 	err := h.storageBackend.HealthCheck(ctx)
+	if err == nil {
+		err = h.writeCanary(ctx)
+	}
 	latency := time.Since(start)
 
 	if err != nil {
@@ -242,17 +296,99 @@ func (h *HealthChecker) checkStorage(ctx context.Context) *ComponentStatus {
 		}
 	}
 
-	status := StatusHealthy
-	if latency > 500*time.Millisecond {
-		status = StatusDegraded
+	return &ComponentStatus{
+		Status:  latencyStatus(latency, h.thresholds.StorageLatency),
+		Latency: latency.String(),
+	}
+}
+
+// writeCanary stores, reads back and deletes a blob. The content is unique
+// to the check, so the blob is never shared with an object.
+func (h *HealthChecker) writeCanary(ctx context.Context) error {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("canary: %w", err)
+	}
+	content := []byte(fmt.Sprintf("alexander health canary %d %x", time.Now().UnixNano(), nonce))
+
+	contentHash, err := h.storageBackend.Store(ctx, bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return fmt.Errorf("canary write: %w", err)
+	}
+	defer func() {
+		if err := h.storageBackend.Delete(context.WithoutCancel(ctx), contentHash); err != nil {
+			h.logger.Warn().Err(err).Str("content_hash", contentHash).Msg("Failed to delete storage canary")
+		}
+	}()
+
+	reader, err := h.storageBackend.Retrieve(ctx, contentHash)
+	if err != nil {
+		return fmt.Errorf("canary read: %w", err)
+	}
+	defer reader.Close()
+	read, err := io.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("canary read: %w", err)
+	}
+	if !bytes.Equal(read, content) {
+		return errors.New("canary read: content does not match what was written")
+	}
+	return nil
+}
+
+// checkRedis checks Redis connectivity.
+func (h *HealthChecker) checkRedis(ctx context.Context) *ComponentStatus {
+	start := time.Now()
+	err := h.redisChecker.Health(ctx)
+	latency := time.Since(start)
+
+	if err != nil {
+		h.logger.Warn().Err(err).Msg("Redis health check failed")
+		return &ComponentStatus{
+			Status:  StatusUnhealthy,
+			Latency: latency.String(),
+			Error:   err.Error(),
+		}
 	}
 
 	return &ComponentStatus{
-		Status:  status,
+		Status:  latencyStatus(latency, h.thresholds.RedisLatency),
 		Latency: latency.String(),
 	}
 }
 
+// gcDetails is the detail of the gc component.
+type gcDetails struct {
+	LastRun *time.Time `json:"last_run"`
+	Lag     string     `json:"lag"`
+}
+
+// checkGC checks that garbage collection has run recently. Before the first
+// run, the lag counts from when the server started.
+func (h *HealthChecker) checkGC() *ComponentStatus {
+	details := gcDetails{}
+	since := startTime
+	if lastRun := h.gc.LastRun(); !lastRun.IsZero() {
+		details.LastRun = &lastRun
+		since = lastRun
+	}
+	lag := time.Since(since).Round(time.Second)
+	details.Lag = lag.String()
+
+	return &ComponentStatus{
+		Status:  latencyStatus(lag, h.thresholds.GCLag),
+		Details: details,
+	}
+}
+
+// latencyStatus returns degraded if d exceeds threshold, otherwise healthy.
+func latencyStatus(d, threshold time.Duration) string {
+	if d > threshold {
+		return StatusDegraded
+	}
+	return StatusHealthy
+}
+
 // SimpleHealth returns a simple JSON health response.
 // Used as a lightweight endpoint.
 func SimpleHealth(w http.ResponseWriter, r *http.Request) {
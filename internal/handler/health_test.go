@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/storage/filesystem"
+)
+
+type fakeDatabase struct{ err error }
+
+func (d fakeDatabase) Ping(context.Context) error { return d.err }
+
+type fakeGC struct{ lastRun time.Time }
+
+func (g fakeGC) LastRun() time.Time { return g.lastRun }
+
+func TestHealthChecker_Readiness(t *testing.T) {
+	dir := t.TempDir()
+	store, err := filesystem.NewStorage(filesystem.Config{
+		DataDir: filepath.Join(dir, "blobs"),
+		TempDir: filepath.Join(dir, "tmp"),
+	}, zerolog.Nop())
+	require.NoError(t, err)
+
+	probe := func(db DatabaseChecker, gc GCReporter, path string) (int, HealthStatus) {
+		checker := NewHealthChecker(HealthCheckerConfig{
+			DatabaseChecker: db,
+			StorageBackend:  store,
+			GC:              gc,
+			Thresholds:      HealthThresholds{GCLag: time.Hour},
+			Logger:          zerolog.Nop(),
+		})
+		w := httptest.NewRecorder()
+		checker.HandleReadiness(w, httptest.NewRequest(http.MethodGet, path, nil))
+		var status HealthStatus
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+		return w.Code, status
+	}
+
+	code, status := probe(fakeDatabase{}, fakeGC{lastRun: time.Now()}, "/readyz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, StatusHealthy, status.Status)
+	assert.Empty(t, status.Components, "components are listed only with verbose")
+
+	// The storage canary leaves no blob behind
+	var blobs int
+	require.NoError(t, filepath.WalkDir(filepath.Join(dir, "blobs"), func(_ string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			blobs++
+		}
+		return err
+	}))
+	assert.Zero(t, blobs)
+
+	// Lagging garbage collection degrades the server, which stays ready
+	code, status = probe(fakeDatabase{}, fakeGC{lastRun: time.Now().Add(-2 * time.Hour)}, "/healthz?verbose")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, StatusDegraded, status.Status)
+	require.Contains(t, status.Components, "gc")
+	assert.Equal(t, StatusDegraded, status.Components["gc"].Status)
+	assert.Equal(t, StatusHealthy, status.Components["storage"].Status)
+
+	// A failing database makes it unready
+	code, status = probe(fakeDatabase{err: errors.New("connection refused")}, nil, "/readyz?verbose")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, StatusUnhealthy, status.Status)
+	assert.Equal(t, "connection refused", status.Components["database"].Error)
+	assert.NotContains(t, status.Components, "gc")
+}
@@ -0,0 +1,270 @@
+// Package handler provides HTTP handlers for Alexander Storage API.
+package handler
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/auth"
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/service"
+)
+
+// InventoryHandler handles bucket inventory configuration requests.
+type InventoryHandler struct {
+	inventoryService *service.InventoryService
+	logger           zerolog.Logger
+}
+
+// NewInventoryHandler creates a new InventoryHandler.
+func NewInventoryHandler(inventoryService *service.InventoryService, logger zerolog.Logger) *InventoryHandler {
+	return &InventoryHandler{
+		inventoryService: inventoryService,
+		logger:           logger.With().Str("handler", "inventory").Logger(),
+	}
+}
+
+// =============================================================================
+// XML Request/Response Types
+// =============================================================================
+
+// InventoryConfiguration is the request/response for a bucket inventory configuration.
+type InventoryConfiguration struct {
+	XMLName                xml.Name               `xml:"InventoryConfiguration"`
+	Xmlns                  string                 `xml:"xmlns,attr,omitempty"`
+	ID                     string                 `xml:"Id"`
+	IsEnabled              bool                   `xml:"IsEnabled"`
+	Filter                 *InventoryFilter       `xml:"Filter,omitempty"`
+	Destination            InventoryDestination   `xml:"Destination"`
+	Schedule               InventorySchedule      `xml:"Schedule"`
+	IncludedObjectVersions string                 `xml:"IncludedObjectVersions"`
+	OptionalFields         *InventoryOptionalList `xml:"OptionalFields,omitempty"`
+}
+
+// InventoryFilter selects the keys listed.
+type InventoryFilter struct {
+	Prefix string `xml:"Prefix"`
+}
+
+// InventoryDestination is where reports are written.
+type InventoryDestination struct {
+	S3BucketDestination InventoryS3BucketDestination `xml:"S3BucketDestination"`
+}
+
+// InventoryS3BucketDestination is the bucket reports are written to.
+type InventoryS3BucketDestination struct {
+	Format    string `xml:"Format"`
+	AccountID string `xml:"AccountId,omitempty"`
+	Bucket    string `xml:"Bucket"`
+	Prefix    string `xml:"Prefix,omitempty"`
+}
+
+// InventorySchedule is how often reports are generated.
+type InventorySchedule struct {
+	Frequency string `xml:"Frequency"`
+}
+
+// InventoryOptionalList lists the optional fields of a report.
+type InventoryOptionalList struct {
+	Fields []string `xml:"Field"`
+}
+
+// ListInventoryConfigurationsResult is the response for listing inventory configurations.
+type ListInventoryConfigurationsResult struct {
+	XMLName        xml.Name                 `xml:"ListInventoryConfigurationsResult"`
+	Xmlns          string                   `xml:"xmlns,attr"`
+	Configurations []InventoryConfiguration `xml:"InventoryConfiguration"`
+	IsTruncated    bool                     `xml:"IsTruncated"`
+}
+
+// =============================================================================
+// Handler Methods
+// =============================================================================
+
+// GetBucketInventoryConfiguration handles GET /{bucket}?inventory&id={id} requests.
+func (h *InventoryHandler) GetBucketInventoryConfiguration(w http.ResponseWriter, r *http.Request, bucketName string) {
+	ctx := r.Context()
+
+	userCtx, ok := auth.GetUserContext(ctx)
+	if !ok {
+		h.logger.Error().Msg("no user context found")
+		writeError(w, ErrAccessDenied)
+		return
+	}
+
+	config, err := h.inventoryService.GetInventoryConfiguration(ctx, bucketName, r.URL.Query().Get("id"), userCtx.UserID)
+	if err != nil {
+		h.handleError(w, err, bucketName)
+		return
+	}
+
+	response := toInventoryConfiguration(config)
+	response.Xmlns = "http://s3.amazonaws.com/doc/2006-03-01/"
+	writeXML(w, http.StatusOK, response)
+}
+
+// ListBucketInventoryConfigurations handles GET /{bucket}?inventory requests.
+// A bucket holds at most domain.MaxInventoryConfigs, so the listing is
+// never truncated.
+func (h *InventoryHandler) ListBucketInventoryConfigurations(w http.ResponseWriter, r *http.Request, bucketName string) {
+	ctx := r.Context()
+
+	userCtx, ok := auth.GetUserContext(ctx)
+	if !ok {
+		h.logger.Error().Msg("no user context found")
+		writeError(w, ErrAccessDenied)
+		return
+	}
+
+	configs, err := h.inventoryService.ListInventoryConfigurations(ctx, bucketName, userCtx.UserID)
+	if err != nil {
+		h.handleError(w, err, bucketName)
+		return
+	}
+
+	response := ListInventoryConfigurationsResult{
+		Xmlns: "http://s3.amazonaws.com/doc/2006-03-01/",
+	}
+	for _, config := range configs {
+		response.Configurations = append(response.Configurations, toInventoryConfiguration(config))
+	}
+
+	writeXML(w, http.StatusOK, response)
+}
+
+// PutBucketInventoryConfiguration handles PUT /{bucket}?inventory&id={id} requests.
+func (h *InventoryHandler) PutBucketInventoryConfiguration(w http.ResponseWriter, r *http.Request, bucketName string) {
+	ctx := r.Context()
+
+	userCtx, ok := auth.GetUserContext(ctx)
+	if !ok {
+		h.logger.Error().Msg("no user context found")
+		writeError(w, ErrAccessDenied)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1024*1024)) // 1MB limit
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to read request body")
+		writeError(w, ErrInternalError)
+		return
+	}
+	defer r.Body.Close()
+
+	var request InventoryConfiguration
+	if err := xml.Unmarshal(body, &request); err != nil {
+		writeError(w, ErrMalformedXML)
+		return
+	}
+
+	config, err := parseInventoryConfiguration(&request, r.URL.Query().Get("id"))
+	if err != nil {
+		h.handleError(w, err, bucketName)
+		return
+	}
+
+	if err := h.inventoryService.PutInventoryConfiguration(ctx, bucketName, userCtx.UserID, config); err != nil {
+		h.handleError(w, err, bucketName)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// DeleteBucketInventoryConfiguration handles DELETE /{bucket}?inventory&id={id} requests.
+func (h *InventoryHandler) DeleteBucketInventoryConfiguration(w http.ResponseWriter, r *http.Request, bucketName string) {
+	ctx := r.Context()
+
+	userCtx, ok := auth.GetUserContext(ctx)
+	if !ok {
+		h.logger.Error().Msg("no user context found")
+		writeError(w, ErrAccessDenied)
+		return
+	}
+
+	err := h.inventoryService.DeleteInventoryConfiguration(ctx, bucketName, r.URL.Query().Get("id"), userCtx.UserID)
+	if err != nil {
+		h.handleError(w, err, bucketName)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// toInventoryConfiguration converts a domain configuration to its XML form.
+func toInventoryConfiguration(config *domain.InventoryConfig) InventoryConfiguration {
+	response := InventoryConfiguration{
+		ID:        config.ID,
+		IsEnabled: config.Enabled,
+		Destination: InventoryDestination{S3BucketDestination: InventoryS3BucketDestination{
+			Format: string(config.Format),
+			Bucket: domain.PolicyResourcePrefix + config.DestinationBucket,
+			Prefix: config.DestinationPrefix,
+		}},
+		Schedule:               InventorySchedule{Frequency: string(config.Frequency)},
+		IncludedObjectVersions: "Current",
+	}
+	if config.Prefix != "" {
+		response.Filter = &InventoryFilter{Prefix: config.Prefix}
+	}
+	if config.AllVersions {
+		response.IncludedObjectVersions = "All"
+	}
+	if len(config.Fields) > 0 {
+		response.OptionalFields = &InventoryOptionalList{}
+		for _, field := range config.Fields {
+			response.OptionalFields.Fields = append(response.OptionalFields.Fields, string(field))
+		}
+	}
+	return response
+}
+
+// parseInventoryConfiguration converts a request to a domain configuration.
+// The ID in the body must match the id query parameter.
+func parseInventoryConfiguration(request *InventoryConfiguration, id string) (*domain.InventoryConfig, error) {
+	if request.ID != id {
+		return nil, fmt.Errorf("%w: Id must match the id parameter", domain.ErrInvalidInventoryConfig)
+	}
+
+	destination := request.Destination.S3BucketDestination
+	config := &domain.InventoryConfig{
+		ID:                request.ID,
+		Enabled:           request.IsEnabled,
+		DestinationBucket: strings.TrimPrefix(destination.Bucket, domain.PolicyResourcePrefix),
+		DestinationPrefix: destination.Prefix,
+		Format:            domain.InventoryFormat(destination.Format),
+		Frequency:         domain.InventoryFrequency(request.Schedule.Frequency),
+	}
+	if request.Filter != nil {
+		config.Prefix = request.Filter.Prefix
+	}
+	switch request.IncludedObjectVersions {
+	case "All":
+		config.AllVersions = true
+	case "Current":
+	default:
+		return nil, fmt.Errorf("%w: IncludedObjectVersions must be All or Current", domain.ErrInvalidInventoryConfig)
+	}
+	if request.OptionalFields != nil {
+		for _, field := range request.OptionalFields.Fields {
+			config.Fields = append(config.Fields, domain.InventoryField(field))
+		}
+	}
+	return config, nil
+}
+
+// handleError converts service errors to S3 errors.
+func (h *InventoryHandler) handleError(w http.ResponseWriter, err error, bucketName string) {
+	s3Err, ok := s3ErrorFor(err)
+	if !ok {
+		h.logger.Error().Err(err).Str("bucket", bucketName).Msg("unhandled error")
+	}
+
+	s3Err.Resource = "/" + bucketName
+	writeError(w, s3Err)
+}
@@ -0,0 +1,173 @@
+// Package handler provides HTTP handlers for Alexander Storage API.
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/auth"
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/middleware"
+	"github.com/prn-tf/alexander-storage/internal/service"
+)
+
+// KeysPath is the path of the self-service access key API. Requests are
+// signed with SigV4 like S3 requests and manage the keys of the signing
+// user. Keys restricted by a policy cannot manage keys, so they cannot
+// create keys with more access than their own.
+const KeysPath = "/_alexander/keys"
+
+// KeysHandler lets users create, list and delete their own access keys.
+type KeysHandler struct {
+	iamService *service.IAMService
+	audit      *service.AuditService
+	logger     zerolog.Logger
+}
+
+// NewKeysHandler creates a new KeysHandler. audit is optional.
+func NewKeysHandler(iamService *service.IAMService, audit *service.AuditService, logger zerolog.Logger) *KeysHandler {
+	return &KeysHandler{
+		iamService: iamService,
+		audit:      audit,
+		logger:     logger.With().Str("handler", "keys").Logger(),
+	}
+}
+
+// HandleKeys handles the access key API:
+//
+//	GET    /_alexander/keys                   lists the user's access keys
+//	POST   /_alexander/keys                   creates an access key
+//	DELETE /_alexander/keys?access_key_id=K   deletes an access key
+//
+// POST takes an optional JSON body with description, expires_at and policy
+// and returns the secret key, which is never shown again.
+func (h *KeysHandler) HandleKeys(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	authCtx := auth.GetAuthContext(r.Context())
+	if authCtx == nil {
+		writeJSONError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	signingKey, err := h.iamService.GetAccessKey(r.Context(), authCtx.AccessKeyID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	if signingKey.Policy != nil {
+		h.logger.Warn().Str("access_key_id", authCtx.AccessKeyID).Msg("rejected access key request signed with a restricted key")
+		writeJSONError(w, http.StatusForbidden, "access denied")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.list(w, r, authCtx)
+	case http.MethodPost:
+		h.create(w, r, authCtx)
+	default:
+		h.delete(w, r, authCtx)
+	}
+}
+
+// list returns the user's access keys.
+func (h *KeysHandler) list(w http.ResponseWriter, r *http.Request, authCtx *auth.AuthContext) {
+	keys, err := h.iamService.ListAccessKeys(r.Context(), service.ListAccessKeysInput{UserID: authCtx.UserID})
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"access_keys": keys})
+}
+
+// create creates an access key for the user.
+func (h *KeysHandler) create(w http.ResponseWriter, r *http.Request, authCtx *auth.AuthContext) {
+	var req createAccessKeyRequest
+	if r.ContentLength != 0 && !decodeAdminRequest(w, r, &req) {
+		return
+	}
+
+	output, err := h.iamService.CreateAccessKey(r.Context(), service.CreateAccessKeyInput{
+		UserID:      authCtx.UserID,
+		Description: req.Description,
+		ExpiresAt:   req.ExpiresAt,
+		Policy:      req.Policy,
+	})
+	detail := fmt.Sprintf("scoped=%t", req.Policy != nil)
+	if err == nil {
+		detail = "access_key_id=" + output.AccessKeyID + " " + detail
+	}
+	h.recordAudit(r, authCtx, "accesskey.create", detail, err)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, createAccessKeyResponse{AccessKey: output.AccessKey, SecretKey: output.SecretKey})
+}
+
+// delete deletes one of the user's access keys. The key signing the request
+// cannot be deleted, so a user cannot lock themselves out by mistake.
+func (h *KeysHandler) delete(w http.ResponseWriter, r *http.Request, authCtx *auth.AuthContext) {
+	accessKeyID := r.URL.Query().Get("access_key_id")
+	if accessKeyID == "" {
+		writeJSONError(w, http.StatusBadRequest, "access_key_id is required")
+		return
+	}
+	if accessKeyID == authCtx.AccessKeyID {
+		writeJSONError(w, http.StatusConflict, "cannot delete the access key that signed the request")
+		return
+	}
+
+	err := h.iamService.DeleteUserAccessKey(r.Context(), authCtx.UserID, accessKeyID)
+	h.recordAudit(r, authCtx, "accesskey.delete", "access_key_id="+accessKeyID, err)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// recordAudit queues an access key change for the audit log, if enabled.
+func (h *KeysHandler) recordAudit(r *http.Request, authCtx *auth.AuthContext, operation, detail string, actionErr error) {
+	if h.audit == nil {
+		return
+	}
+
+	event := &domain.AuditEvent{
+		UserID:      authCtx.UserID,
+		Actor:       authCtx.Username,
+		AccessKeyID: authCtx.AccessKeyID,
+		Operation:   operation,
+		Detail:      detail,
+		SourceIP:    auth.SourceIP(r).String(),
+		RequestID:   middleware.GetRequestID(r.Context()),
+		Result:      domain.AuditResultSuccess,
+	}
+	if actionErr != nil {
+		event.Result = domain.AuditResultFailure
+		event.Detail += ": " + actionErr.Error()
+	}
+	h.audit.Record(event)
+}
+
+// writeError maps service errors to JSON error responses.
+func (h *KeysHandler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrAccessKeyNotFound):
+		writeJSONError(w, http.StatusNotFound, "access key not found")
+	case errors.Is(err, service.ErrMaxAccessKeysReached), errors.Is(err, service.ErrUserInactive):
+		writeJSONError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, domain.ErrInvalidPolicy):
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+	default:
+		h.logger.Error().Err(err).Msg("access key request failed")
+		writeJSONError(w, http.StatusInternalServerError, "internal error")
+	}
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/auth"
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/pkg/crypto"
+	"github.com/prn-tf/alexander-storage/internal/repository/sqlite"
+	"github.com/prn-tf/alexander-storage/internal/service"
+)
+
+func TestKeysHandler_SelfService(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqlite.NewDB(ctx, sqlite.DefaultConfig(filepath.Join(t.TempDir(), "alexander.db")), zerolog.Nop())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, db.Migrate(ctx))
+
+	encryptor, err := crypto.NewEncryptor(bytes.Repeat([]byte{7}, crypto.KeySize))
+	require.NoError(t, err)
+	userRepo := sqlite.NewUserRepository(db)
+	iam := service.NewIAMService(sqlite.NewAccessKeyRepository(db), userRepo, encryptor, zerolog.Nop(), service.IAMConfig{MaxAccessKeysPerUser: 3})
+	h := NewKeysHandler(iam, nil, zerolog.Nop())
+
+	alice := domain.NewUser("alice", "alice@example.com", "hash")
+	bob := domain.NewUser("bob", "bob@example.com", "hash")
+	require.NoError(t, userRepo.Create(ctx, alice))
+	require.NoError(t, userRepo.Create(ctx, bob))
+
+	aliceKey, err := iam.CreateAccessKey(ctx, service.CreateAccessKeyInput{UserID: alice.ID})
+	require.NoError(t, err)
+	bobKey, err := iam.CreateAccessKey(ctx, service.CreateAccessKeyInput{UserID: bob.ID})
+	require.NoError(t, err)
+	scopedKey, err := iam.CreateAccessKey(ctx, service.CreateAccessKeyInput{UserID: alice.ID, Policy: &domain.AccessKeyPolicy{
+		Version:   "2012-10-17",
+		Statement: []domain.PolicyStatement{{Effect: "Allow", Action: []string{"s3:*"}, Resource: []string{"*"}}},
+	}})
+	require.NoError(t, err)
+
+	do := func(accessKeyID, method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req = req.WithContext(context.WithValue(req.Context(), auth.AuthContextKey, &auth.AuthContext{
+			UserID:      alice.ID,
+			Username:    alice.Username,
+			AccessKeyID: accessKeyID,
+		}))
+		rec := httptest.NewRecorder()
+		h.HandleKeys(rec, req)
+		return rec
+	}
+
+	// Keys restricted by a policy cannot manage keys
+	rec := do(scopedKey.AccessKeyID, http.MethodPost, KeysPath, "")
+	require.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = do(aliceKey.AccessKeyID, http.MethodPost, KeysPath, `{"description":"ci"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var created createAccessKeyResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	assert.NotEmpty(t, created.SecretKey)
+	assert.Equal(t, "ci", created.Description)
+
+	// The limit counts the user's active keys
+	rec = do(aliceKey.AccessKeyID, http.MethodPost, KeysPath, "")
+	require.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = do(aliceKey.AccessKeyID, http.MethodGet, KeysPath, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var listed struct {
+		AccessKeys []*domain.AccessKey `json:"access_keys"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &listed))
+	assert.Len(t, listed.AccessKeys, 3)
+	assert.NotContains(t, rec.Body.String(), bobKey.AccessKeyID)
+
+	// Other users' keys and the signing key cannot be deleted
+	rec = do(aliceKey.AccessKeyID, http.MethodDelete, KeysPath+"?access_key_id="+bobKey.AccessKeyID, "")
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	rec = do(aliceKey.AccessKeyID, http.MethodDelete, KeysPath+"?access_key_id="+aliceKey.AccessKeyID, "")
+	require.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = do(aliceKey.AccessKeyID, http.MethodDelete, KeysPath+"?access_key_id="+created.AccessKeyID, "")
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	_, err = iam.GetAccessKey(ctx, created.AccessKeyID)
+	assert.Error(t, err)
+	_, err = iam.GetAccessKey(ctx, bobKey.AccessKeyID)
+	assert.NoError(t, err)
+}
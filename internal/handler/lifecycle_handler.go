@@ -0,0 +1,371 @@
+package handler
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/auth"
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/service"
+)
+
+// lifecycleDateFormat is the format of expiration dates in responses.
+const lifecycleDateFormat = "2006-01-02T15:04:05.000Z"
+
+// LifecycleHandler handles bucket lifecycle configuration requests.
+type LifecycleHandler struct {
+	lifecycleService *service.LifecycleService
+	logger           zerolog.Logger
+}
+
+// NewLifecycleHandler creates a new LifecycleHandler.
+func NewLifecycleHandler(lifecycleService *service.LifecycleService, logger zerolog.Logger) *LifecycleHandler {
+	return &LifecycleHandler{
+		lifecycleService: lifecycleService,
+		logger:           logger.With().Str("handler", "lifecycle").Logger(),
+	}
+}
+
+// =============================================================================
+// XML Request/Response Types
+// =============================================================================
+
+// LifecycleConfiguration is the request/response for a bucket lifecycle configuration.
+type LifecycleConfiguration struct {
+	XMLName xml.Name        `xml:"LifecycleConfiguration"`
+	Xmlns   string          `xml:"xmlns,attr,omitempty"`
+	Rules   []LifecycleRule `xml:"Rule"`
+}
+
+// LifecycleRule is a filter and the actions applied to the objects it selects.
+type LifecycleRule struct {
+	ID     string           `xml:"ID,omitempty"`
+	Filter *LifecycleFilter `xml:"Filter,omitempty"`
+
+	// Prefix is the filter of rules written before <Filter> existed.
+	Prefix *string `xml:"Prefix,omitempty"`
+
+	Status                         string                                   `xml:"Status"`
+	Expiration                     *LifecycleExpiration                     `xml:"Expiration,omitempty"`
+	NoncurrentVersionExpiration    *LifecycleNoncurrentVersionExpiration    `xml:"NoncurrentVersionExpiration,omitempty"`
+	AbortIncompleteMultipartUpload *LifecycleAbortIncompleteMultipartUpload `xml:"AbortIncompleteMultipartUpload,omitempty"`
+
+	// Transitions are not supported and only read to reject them.
+	Transitions                  []struct{} `xml:"Transition"`
+	NoncurrentVersionTransitions []struct{} `xml:"NoncurrentVersionTransition"`
+}
+
+// LifecycleFilter selects objects by one condition, or by several in And.
+type LifecycleFilter struct {
+	Prefix                *string             `xml:"Prefix,omitempty"`
+	Tag                   *Tag                `xml:"Tag,omitempty"`
+	ObjectSizeGreaterThan *int64              `xml:"ObjectSizeGreaterThan,omitempty"`
+	ObjectSizeLessThan    *int64              `xml:"ObjectSizeLessThan,omitempty"`
+	And                   *LifecycleFilterAnd `xml:"And,omitempty"`
+}
+
+// LifecycleFilterAnd selects the objects meeting all of its conditions.
+type LifecycleFilterAnd struct {
+	Prefix                string `xml:"Prefix,omitempty"`
+	Tags                  []Tag  `xml:"Tag"`
+	ObjectSizeGreaterThan *int64 `xml:"ObjectSizeGreaterThan,omitempty"`
+	ObjectSizeLessThan    *int64 `xml:"ObjectSizeLessThan,omitempty"`
+}
+
+// LifecycleExpiration expires current versions, or removes delete markers
+// left without versions.
+type LifecycleExpiration struct {
+	Date                      string `xml:"Date,omitempty"`
+	Days                      *int   `xml:"Days,omitempty"`
+	ExpiredObjectDeleteMarker *bool  `xml:"ExpiredObjectDeleteMarker,omitempty"`
+}
+
+// LifecycleNoncurrentVersionExpiration permanently deletes noncurrent versions.
+type LifecycleNoncurrentVersionExpiration struct {
+	NoncurrentDays          *int `xml:"NoncurrentDays,omitempty"`
+	NewerNoncurrentVersions *int `xml:"NewerNoncurrentVersions,omitempty"`
+}
+
+// LifecycleAbortIncompleteMultipartUpload aborts multipart uploads that are
+// not completed in time.
+type LifecycleAbortIncompleteMultipartUpload struct {
+	DaysAfterInitiation *int `xml:"DaysAfterInitiation,omitempty"`
+}
+
+// =============================================================================
+// Handler Methods
+// =============================================================================
+
+// GetBucketLifecycleConfiguration handles GET /{bucket}?lifecycle requests.
+func (h *LifecycleHandler) GetBucketLifecycleConfiguration(w http.ResponseWriter, r *http.Request, bucketName string) {
+	ctx := r.Context()
+
+	userCtx, ok := auth.GetUserContext(ctx)
+	if !ok {
+		h.logger.Error().Msg("no user context found")
+		writeError(w, ErrAccessDenied)
+		return
+	}
+
+	config, err := h.lifecycleService.GetBucketLifecycle(ctx, bucketName, userCtx.UserID)
+	if err != nil {
+		h.handleError(w, err, bucketName)
+		return
+	}
+
+	response := toLifecycleConfiguration(config)
+	response.Xmlns = "http://s3.amazonaws.com/doc/2006-03-01/"
+	writeXML(w, http.StatusOK, response)
+}
+
+// PutBucketLifecycleConfiguration handles PUT /{bucket}?lifecycle requests.
+// The configuration replaces every rule of the bucket.
+func (h *LifecycleHandler) PutBucketLifecycleConfiguration(w http.ResponseWriter, r *http.Request, bucketName string) {
+	ctx := r.Context()
+
+	userCtx, ok := auth.GetUserContext(ctx)
+	if !ok {
+		h.logger.Error().Msg("no user context found")
+		writeError(w, ErrAccessDenied)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1024*1024)) // 1MB limit
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to read request body")
+		writeError(w, ErrInternalError)
+		return
+	}
+	defer r.Body.Close()
+
+	var request LifecycleConfiguration
+	if err := xml.Unmarshal(body, &request); err != nil {
+		writeError(w, ErrMalformedXML)
+		return
+	}
+
+	for _, rule := range request.Rules {
+		if len(rule.Transitions) > 0 || len(rule.NoncurrentVersionTransitions) > 0 {
+			s3Err := ErrNotImplemented
+			s3Err.Message = "Lifecycle transitions are not supported."
+			s3Err.Resource = "/" + bucketName
+			writeError(w, s3Err)
+			return
+		}
+	}
+
+	config, err := parseLifecycleConfiguration(&request)
+	if err == nil {
+		err = h.lifecycleService.PutBucketLifecycle(ctx, bucketName, userCtx.UserID, config)
+	}
+	if err != nil {
+		h.handleError(w, err, bucketName)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// DeleteBucketLifecycle handles DELETE /{bucket}?lifecycle requests.
+func (h *LifecycleHandler) DeleteBucketLifecycle(w http.ResponseWriter, r *http.Request, bucketName string) {
+	ctx := r.Context()
+
+	userCtx, ok := auth.GetUserContext(ctx)
+	if !ok {
+		h.logger.Error().Msg("no user context found")
+		writeError(w, ErrAccessDenied)
+		return
+	}
+
+	if err := h.lifecycleService.DeleteBucketLifecycle(ctx, bucketName, userCtx.UserID); err != nil {
+		h.handleError(w, err, bucketName)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// toLifecycleConfiguration converts domain rules to their XML form. A
+// filter with more than a prefix is written as an And.
+func toLifecycleConfiguration(config *domain.LifecycleConfiguration) LifecycleConfiguration {
+	response := LifecycleConfiguration{}
+	for _, rule := range config.Rules {
+		xmlRule := LifecycleRule{
+			ID:     rule.RuleID,
+			Status: string(rule.Status),
+			Filter: &LifecycleFilter{},
+		}
+
+		conditions := len(rule.Tags)
+		if rule.ObjectSizeGreaterThan != nil {
+			conditions++
+		}
+		if rule.ObjectSizeLessThan != nil {
+			conditions++
+		}
+		if conditions == 0 || (conditions == 1 && rule.Prefix == "") {
+			prefix := rule.Prefix
+			switch {
+			case len(rule.Tags) == 1:
+				xmlRule.Filter.Tag = &Tag{Key: rule.Tags[0].Key, Value: rule.Tags[0].Value}
+			case rule.ObjectSizeGreaterThan != nil:
+				xmlRule.Filter.ObjectSizeGreaterThan = rule.ObjectSizeGreaterThan
+			case rule.ObjectSizeLessThan != nil:
+				xmlRule.Filter.ObjectSizeLessThan = rule.ObjectSizeLessThan
+			default:
+				xmlRule.Filter.Prefix = &prefix
+			}
+		} else {
+			and := &LifecycleFilterAnd{
+				Prefix:                rule.Prefix,
+				ObjectSizeGreaterThan: rule.ObjectSizeGreaterThan,
+				ObjectSizeLessThan:    rule.ObjectSizeLessThan,
+			}
+			for _, tag := range rule.Tags {
+				and.Tags = append(and.Tags, Tag{Key: tag.Key, Value: tag.Value})
+			}
+			xmlRule.Filter.And = and
+		}
+
+		if rule.HasExpiration() || rule.ExpiredObjectDeleteMarker {
+			expiration := &LifecycleExpiration{Days: rule.ExpirationDays}
+			if rule.ExpirationDate != nil {
+				expiration.Date = rule.ExpirationDate.UTC().Format(lifecycleDateFormat)
+			}
+			if rule.ExpiredObjectDeleteMarker {
+				marker := true
+				expiration.ExpiredObjectDeleteMarker = &marker
+			}
+			xmlRule.Expiration = expiration
+		}
+		if rule.NoncurrentDays != nil {
+			xmlRule.NoncurrentVersionExpiration = &LifecycleNoncurrentVersionExpiration{NoncurrentDays: rule.NoncurrentDays}
+		}
+		if rule.AbortIncompleteUploadDays != nil {
+			xmlRule.AbortIncompleteMultipartUpload = &LifecycleAbortIncompleteMultipartUpload{DaysAfterInitiation: rule.AbortIncompleteUploadDays}
+		}
+
+		response.Rules = append(response.Rules, xmlRule)
+	}
+	return response
+}
+
+// parseLifecycleConfiguration converts a request to domain rules. Errors
+// wrap domain.ErrInvalidLifecycleRule; the rules themselves are validated
+// by the service.
+func parseLifecycleConfiguration(request *LifecycleConfiguration) (*domain.LifecycleConfiguration, error) {
+	config := domain.NewLifecycleConfiguration()
+	for _, xmlRule := range request.Rules {
+		rule := &domain.LifecycleRule{
+			RuleID: xmlRule.ID,
+			Status: domain.LifecycleStatus(xmlRule.Status),
+		}
+
+		filter, err := parseLifecycleFilter(&xmlRule)
+		if err != nil {
+			return nil, err
+		}
+		rule.LifecycleFilter = filter
+
+		if expiration := xmlRule.Expiration; expiration != nil {
+			rule.ExpirationDays = expiration.Days
+			if expiration.Date != "" {
+				date, err := time.Parse(time.RFC3339, expiration.Date)
+				if err != nil {
+					return nil, fmt.Errorf("%w: invalid expiration date %q", domain.ErrInvalidLifecycleRule, expiration.Date)
+				}
+				rule.ExpirationDate = &date
+			}
+			rule.ExpiredObjectDeleteMarker = expiration.ExpiredObjectDeleteMarker != nil && *expiration.ExpiredObjectDeleteMarker
+			if expiration.Days == nil && expiration.Date == "" && expiration.ExpiredObjectDeleteMarker == nil {
+				return nil, fmt.Errorf("%w: Expiration needs Days, Date or ExpiredObjectDeleteMarker", domain.ErrInvalidLifecycleRule)
+			}
+		}
+		if noncurrent := xmlRule.NoncurrentVersionExpiration; noncurrent != nil {
+			if noncurrent.NewerNoncurrentVersions != nil {
+				return nil, fmt.Errorf("%w: NewerNoncurrentVersions is not supported", domain.ErrInvalidLifecycleRule)
+			}
+			if noncurrent.NoncurrentDays == nil {
+				return nil, fmt.Errorf("%w: NoncurrentVersionExpiration needs NoncurrentDays", domain.ErrInvalidLifecycleRule)
+			}
+			rule.NoncurrentDays = noncurrent.NoncurrentDays
+		}
+		if abort := xmlRule.AbortIncompleteMultipartUpload; abort != nil {
+			if abort.DaysAfterInitiation == nil {
+				return nil, fmt.Errorf("%w: AbortIncompleteMultipartUpload needs DaysAfterInitiation", domain.ErrInvalidLifecycleRule)
+			}
+			rule.AbortIncompleteUploadDays = abort.DaysAfterInitiation
+		}
+
+		config.Rules = append(config.Rules, rule)
+	}
+	return config, nil
+}
+
+// parseLifecycleFilter returns the filter of a rule, from its Filter or its
+// legacy Prefix. A Filter holds one condition, or several in And.
+func parseLifecycleFilter(xmlRule *LifecycleRule) (domain.LifecycleFilter, error) {
+	var filter domain.LifecycleFilter
+	if xmlRule.Filter != nil && xmlRule.Prefix != nil {
+		return filter, fmt.Errorf("%w: a rule has either a Filter or a Prefix", domain.ErrInvalidLifecycleRule)
+	}
+	if xmlRule.Prefix != nil {
+		filter.Prefix = *xmlRule.Prefix
+		return filter, nil
+	}
+	if xmlRule.Filter == nil {
+		return filter, nil
+	}
+
+	f := xmlRule.Filter
+	conditions := 0
+	for _, set := range []bool{f.Prefix != nil, f.Tag != nil, f.ObjectSizeGreaterThan != nil, f.ObjectSizeLessThan != nil, f.And != nil} {
+		if set {
+			conditions++
+		}
+	}
+	if conditions > 1 {
+		return filter, fmt.Errorf("%w: a Filter has one condition; combine several with And", domain.ErrInvalidLifecycleRule)
+	}
+
+	switch {
+	case f.Prefix != nil:
+		filter.Prefix = *f.Prefix
+	case f.Tag != nil:
+		filter.Tags = []domain.Tag{{Key: f.Tag.Key, Value: f.Tag.Value}}
+	case f.ObjectSizeGreaterThan != nil:
+		filter.ObjectSizeGreaterThan = f.ObjectSizeGreaterThan
+	case f.ObjectSizeLessThan != nil:
+		filter.ObjectSizeLessThan = f.ObjectSizeLessThan
+	case f.And != nil:
+		filter.Prefix = f.And.Prefix
+		filter.ObjectSizeGreaterThan = f.And.ObjectSizeGreaterThan
+		filter.ObjectSizeLessThan = f.And.ObjectSizeLessThan
+		for _, tag := range f.And.Tags {
+			filter.Tags = append(filter.Tags, domain.Tag{Key: tag.Key, Value: tag.Value})
+		}
+	}
+	return filter, nil
+}
+
+// handleError converts service errors to S3 errors.
+func (h *LifecycleHandler) handleError(w http.ResponseWriter, err error, bucketName string) {
+	s3Err, ok := s3ErrorFor(err)
+	if !ok {
+		h.logger.Error().Err(err).Str("bucket", bucketName).Msg("unhandled error")
+	}
+
+	s3Err.Resource = "/" + bucketName
+	writeError(w, s3Err)
+}
+
+var errNoSuchLifecycleConfiguration = S3Error{
+	Code:           "NoSuchLifecycleConfiguration",
+	Message:        "The lifecycle configuration does not exist.",
+	HTTPStatusCode: http.StatusNotFound,
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+)
+
+func TestParseLifecycleConfiguration(t *testing.T) {
+	body := `<LifecycleConfiguration>
+		<Rule>
+			<ID>large-logs</ID>
+			<Filter>
+				<And>
+					<Prefix>logs/</Prefix>
+					<Tag><Key>class</Key><Value>temp</Value></Tag>
+					<ObjectSizeGreaterThan>1024</ObjectSizeGreaterThan>
+				</And>
+			</Filter>
+			<Status>Enabled</Status>
+			<Expiration><Days>30</Days></Expiration>
+			<NoncurrentVersionExpiration><NoncurrentDays>7</NoncurrentDays></NoncurrentVersionExpiration>
+		</Rule>
+		<Rule>
+			<ID>legacy</ID>
+			<Prefix>tmp/</Prefix>
+			<Status>Disabled</Status>
+			<Expiration><Date>2027-01-01T00:00:00.000Z</Date></Expiration>
+			<AbortIncompleteMultipartUpload><DaysAfterInitiation>3</DaysAfterInitiation></AbortIncompleteMultipartUpload>
+		</Rule>
+		<Rule>
+			<ID>markers</ID>
+			<Filter><Prefix></Prefix></Filter>
+			<Status>Enabled</Status>
+			<Expiration><ExpiredObjectDeleteMarker>true</ExpiredObjectDeleteMarker></Expiration>
+		</Rule>
+	</LifecycleConfiguration>`
+
+	var request LifecycleConfiguration
+	require.NoError(t, xml.Unmarshal([]byte(body), &request))
+	config, err := parseLifecycleConfiguration(&request)
+	require.NoError(t, err)
+	require.Len(t, config.Rules, 3)
+
+	large := config.Rules[0]
+	assert.Equal(t, "logs/", large.Prefix)
+	assert.Equal(t, []domain.Tag{{Key: "class", Value: "temp"}}, large.Tags)
+	assert.Equal(t, int64(1024), *large.ObjectSizeGreaterThan)
+	assert.Nil(t, large.ObjectSizeLessThan)
+	assert.Equal(t, 30, *large.ExpirationDays)
+	assert.Equal(t, 7, *large.NoncurrentDays)
+
+	legacy := config.Rules[1]
+	assert.Equal(t, "tmp/", legacy.Prefix)
+	assert.Equal(t, domain.LifecycleDisabled, legacy.Status)
+	assert.True(t, legacy.ExpirationDate.Equal(time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, 3, *legacy.AbortIncompleteUploadDays)
+
+	assert.True(t, config.Rules[2].ExpiredObjectDeleteMarker)
+
+	// The rules survive a round trip through the response form
+	data, err := xml.Marshal(toLifecycleConfiguration(config))
+	require.NoError(t, err)
+	var response LifecycleConfiguration
+	require.NoError(t, xml.Unmarshal(data, &response))
+	again, err := parseLifecycleConfiguration(&response)
+	require.NoError(t, err)
+	assert.Equal(t, config, again)
+}
+
+func TestParseLifecycleConfiguration_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		rule string
+	}{
+		{"filter and prefix", `<Filter><Prefix>a/</Prefix></Filter><Prefix>b/</Prefix>`},
+		{"two filter conditions", `<Filter><Prefix>a/</Prefix><ObjectSizeLessThan>10</ObjectSizeLessThan></Filter>`},
+		{"empty expiration", `<Expiration></Expiration>`},
+		{"bad date", `<Expiration><Date>next year</Date></Expiration>`},
+		{"newer noncurrent versions", `<NoncurrentVersionExpiration><NoncurrentDays>1</NoncurrentDays><NewerNoncurrentVersions>2</NewerNoncurrentVersions></NoncurrentVersionExpiration>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `<LifecycleConfiguration><Rule><ID>r</ID><Status>Enabled</Status>` + tt.rule + `</Rule></LifecycleConfiguration>`
+			var request LifecycleConfiguration
+			require.NoError(t, xml.Unmarshal([]byte(body), &request))
+			_, err := parseLifecycleConfiguration(&request)
+			require.ErrorIs(t, err, domain.ErrInvalidLifecycleRule)
+		})
+	}
+}
@@ -0,0 +1,138 @@
+// Package handler provides HTTP handlers for Alexander Storage API.
+package handler
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/service"
+)
+
+// ManifestPath is the path of the blob manifest API.
+const ManifestPath = "/_alexander/manifest"
+
+// ManifestHandler serves the blob manifest API for external scanning tools.
+// It uses a dedicated bearer token instead of SigV4 so scanners do not need
+// an S3 access key.
+type ManifestHandler struct {
+	manifestService *service.ManifestService
+	token           string
+	logger          zerolog.Logger
+}
+
+// NewManifestHandler creates a new ManifestHandler.
+func NewManifestHandler(manifestService *service.ManifestService, token string, logger zerolog.Logger) *ManifestHandler {
+	return &ManifestHandler{
+		manifestService: manifestService,
+		token:           token,
+		logger:          logger.With().Str("handler", "manifest").Logger(),
+	}
+}
+
+// manifestResponse is the JSON body of a manifest page.
+type manifestResponse struct {
+	Snapshot    time.Time       `json:"snapshot"`
+	Blobs       []manifestEntry `json:"blobs"`
+	IsTruncated bool            `json:"is_truncated"`
+	NextCursor  string          `json:"next_cursor,omitempty"`
+}
+
+// manifestEntry is a single blob in a manifest page.
+type manifestEntry struct {
+	Hash        string    `json:"hash"`
+	Size        int64     `json:"size"`
+	Path        string    `json:"path"`
+	RefCount    int32     `json:"ref_count"`
+	IsEncrypted bool      `json:"is_encrypted"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// HandleManifest handles GET /_alexander/manifest?cursor=X&limit=N requests.
+func (h *ManifestHandler) HandleManifest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	if !bearerAuthorized(r, h.token) {
+		h.logger.Warn().Str("remote_addr", r.RemoteAddr).Msg("rejected manifest request with invalid token")
+		w.Header().Set("WWW-Authenticate", `Bearer realm="alexander-manifest"`)
+		writeJSONError(w, http.StatusUnauthorized, "invalid or missing bearer token")
+		return
+	}
+
+	query := r.URL.Query()
+	limit := 0
+	if v := query.Get("limit"); v != "" {
+		var err error
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			writeJSONError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+	}
+
+	output, err := h.manifestService.ListManifest(r.Context(), service.ListManifestInput{
+		Cursor: query.Get("cursor"),
+		Limit:  limit,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidManifestCursor) {
+			writeJSONError(w, http.StatusBadRequest, "invalid cursor")
+			return
+		}
+		h.logger.Error().Err(err).Msg("failed to list manifest")
+		writeJSONError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	resp := manifestResponse{
+		Snapshot:    output.Snapshot,
+		Blobs:       make([]manifestEntry, len(output.Entries)),
+		IsTruncated: output.IsTruncated,
+		NextCursor:  output.NextCursor,
+	}
+	for i, e := range output.Entries {
+		resp.Blobs[i] = manifestEntry{
+			Hash:        e.ContentHash,
+			Size:        e.Size,
+			Path:        e.StoragePath,
+			RefCount:    e.RefCount,
+			IsEncrypted: e.IsEncrypted,
+			CreatedAt:   e.CreatedAt,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// bearerAuthorized checks the bearer token of a request in constant time.
+// An empty expected token rejects every request.
+func bearerAuthorized(r *http.Request, expected string) bool {
+	if expected == "" {
+		return false
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1
+}
+
+// writeJSONError writes a JSON error response for the non-S3 APIs.
+func writeJSONError(w http.ResponseWriter, statusCode int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
@@ -3,9 +3,10 @@ package handler
 
 import (
 	"encoding/xml"
-	"errors"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/rs/zerolog"
 
@@ -14,17 +15,38 @@ import (
 	"github.com/prn-tf/alexander-storage/internal/service"
 )
 
+const (
+	// ChunkSHA256Header turns a PUT to a multipart upload into a resumable
+	// upload chunk: the body is staged as a part named by its hex SHA-256
+	// hash instead of a part number. A chunk the upload already has is not
+	// read again, so a client sending Expect: 100-continue skips the body.
+	ChunkSHA256Header = "x-alexander-chunk-sha256"
+
+	// CommitChunksHeader turns a POST to a multipart upload into the commit
+	// of a resumable upload: the body lists the hex SHA-256 hashes of the
+	// chunks in object order, separated by whitespace.
+	CommitChunksHeader = "x-alexander-commit-chunks"
+)
+
+// maxCommitChunksBody bounds the body of a resumable upload commit: a hash
+// and a line break for every part an upload may have.
+const maxCommitChunksBody = domain.MaxPartNumber * 65
+
 // MultipartHandler handles multipart upload HTTP requests.
 type MultipartHandler struct {
-	multipartService *service.MultipartService
-	logger           zerolog.Logger
+	multipartService  *service.MultipartService
+	detectContentType bool
+	logger            zerolog.Logger
 }
 
-// NewMultipartHandler creates a new MultipartHandler.
-func NewMultipartHandler(multipartService *service.MultipartService, logger zerolog.Logger) *MultipartHandler {
+// NewMultipartHandler creates a new MultipartHandler. With
+// detectContentType, uploads initiated without a Content-Type get one from
+// their key's extension.
+func NewMultipartHandler(multipartService *service.MultipartService, detectContentType bool, logger zerolog.Logger) *MultipartHandler {
 	return &MultipartHandler{
-		multipartService: multipartService,
-		logger:           logger.With().Str("handler", "multipart").Logger(),
+		multipartService:  multipartService,
+		detectContentType: detectContentType,
+		logger:            logger.With().Str("handler", "multipart").Logger(),
 	}
 }
 
@@ -49,6 +71,7 @@ type CompleteMultipartUploadResult struct {
 	Bucket   string   `xml:"Bucket"`
 	Key      string   `xml:"Key"`
 	ETag     string   `xml:"ETag"`
+	Checksums
 }
 
 // CompleteMultipartUploadRequest is the request body for CompleteMultipartUpload.
@@ -61,6 +84,7 @@ type CompleteMultipartUploadRequest struct {
 type CompletedPartRequest struct {
 	PartNumber int    `xml:"PartNumber"`
 	ETag       string `xml:"ETag"`
+	Checksums
 }
 
 // ListMultipartUploadsResult is the response for ListMultipartUploads.
@@ -74,6 +98,7 @@ type ListMultipartUploadsResult struct {
 	NextUploadIdMarker string          `xml:"NextUploadIdMarker,omitempty"`
 	Prefix             string          `xml:"Prefix,omitempty"`
 	Delimiter          string          `xml:"Delimiter,omitempty"`
+	EncodingType       string          `xml:"EncodingType,omitempty"`
 	MaxUploads         int             `xml:"MaxUploads"`
 	IsTruncated        bool            `xml:"IsTruncated"`
 	Uploads            []UploadElement `xml:"Upload,omitempty"`
@@ -101,6 +126,7 @@ type ListPartsResult struct {
 	IsTruncated          bool          `xml:"IsTruncated"`
 	Parts                []PartElement `xml:"Part,omitempty"`
 	StorageClass         string        `xml:"StorageClass"`
+	ChecksumAlgorithm    string        `xml:"ChecksumAlgorithm,omitempty"`
 }
 
 // PartElement represents a part in list parts response.
@@ -109,6 +135,7 @@ type PartElement struct {
 	LastModified string `xml:"LastModified"`
 	ETag         string `xml:"ETag"`
 	Size         int64  `xml:"Size"`
+	Checksums
 }
 
 // =============================================================================
@@ -127,27 +154,30 @@ func (h *MultipartHandler) InitiateMultipartUpload(w http.ResponseWriter, r *htt
 		return
 	}
 
-	// Get content type and metadata
-	contentType := r.Header.Get("Content-Type")
-	metadata := parseMetadata(r)
-	if contentType != "" {
-		metadata["Content-Type"] = contentType
+	acl, s3Err, ok := parseObjectACLHeader(r)
+	if !ok {
+		writeError(w, s3Err)
+		return
 	}
 
-	// Get storage class
-	storageClass := domain.StorageClass(r.Header.Get("x-amz-storage-class"))
-	if storageClass == "" {
-		storageClass = domain.StorageClassStandard
+	checksumAlgorithm, _, s3Err, ok := parseChecksumHeaders(r)
+	if !ok {
+		writeError(w, s3Err)
+		return
 	}
 
 	// Initiate upload
 	output, err := h.multipartService.InitiateMultipartUpload(ctx, service.InitiateMultipartUploadInput{
 		BucketName:   bucketName,
 		Key:          objectKey,
-		ContentType:  contentType,
-		Metadata:     metadata,
-		StorageClass: storageClass,
+		ContentType:  r.Header.Get("Content-Type"),
+		Metadata:     parseMetadata(r),
+		StorageClass: domain.StorageClass(r.Header.Get("x-amz-storage-class")),
+		ACL:          acl,
 		OwnerID:      userCtx.UserID,
+
+		DetectContentType: h.detectContentType,
+		ChecksumAlgorithm: checksumAlgorithm,
 	})
 
 	if err != nil {
@@ -155,6 +185,14 @@ func (h *MultipartHandler) InitiateMultipartUpload(w http.ResponseWriter, r *htt
 		return
 	}
 
+	if output.ChecksumAlgorithm != "" {
+		w.Header().Set(ChecksumAlgorithmHeader, string(output.ChecksumAlgorithm))
+	}
+	if output.AbortDate != nil {
+		w.Header().Set("x-amz-abort-date", output.AbortDate.UTC().Format(http.TimeFormat))
+		w.Header().Set("x-amz-abort-rule-id", output.AbortRuleID)
+	}
+
 	// Return XML response
 	response := InitiateMultipartUploadResult{
 		Xmlns:    "http://s3.amazonaws.com/doc/2006-03-01/",
@@ -214,6 +252,12 @@ func (h *MultipartHandler) UploadPart(w http.ResponseWriter, r *http.Request, bu
 		return
 	}
 
+	checksumAlgorithm, checksum, s3Err, ok := parseChecksumHeaders(r)
+	if !ok {
+		writeError(w, s3Err)
+		return
+	}
+
 	// Upload part
 	output, err := h.multipartService.UploadPart(ctx, service.UploadPartInput{
 		BucketName: bucketName,
@@ -223,6 +267,9 @@ func (h *MultipartHandler) UploadPart(w http.ResponseWriter, r *http.Request, bu
 		Body:       r.Body,
 		Size:       contentLength,
 		OwnerID:    userCtx.UserID,
+
+		ChecksumAlgorithm: checksumAlgorithm,
+		Checksum:          checksum,
 	})
 
 	if err != nil {
@@ -232,6 +279,7 @@ func (h *MultipartHandler) UploadPart(w http.ResponseWriter, r *http.Request, bu
 
 	// Set ETag header
 	w.Header().Set("ETag", output.ETag)
+	setChecksumHeader(w, output.ChecksumAlgorithm, output.Checksum)
 	w.WriteHeader(http.StatusOK)
 }
 
@@ -275,6 +323,7 @@ func (h *MultipartHandler) CompleteMultipartUpload(w http.ResponseWriter, r *htt
 		parts[i] = domain.CompletedPart{
 			PartNumber: p.PartNumber,
 			ETag:       p.ETag,
+			Checksum:   p.Checksums.value(),
 		}
 	}
 
@@ -285,6 +334,7 @@ func (h *MultipartHandler) CompleteMultipartUpload(w http.ResponseWriter, r *htt
 		UploadID:   uploadID,
 		Parts:      parts,
 		OwnerID:    userCtx.UserID,
+		BatchID:    r.Header.Get(BatchIDHeader),
 	})
 
 	if err != nil {
@@ -292,10 +342,17 @@ func (h *MultipartHandler) CompleteMultipartUpload(w http.ResponseWriter, r *htt
 		return
 	}
 
+	h.writeCompleteResult(w, output)
+}
+
+// writeCompleteResult writes the response to a completed upload.
+func (h *MultipartHandler) writeCompleteResult(w http.ResponseWriter, output *service.CompleteMultipartUploadOutput) {
 	// Set version ID header if applicable
 	if output.VersionID != "" && output.VersionID != "null" {
 		w.Header().Set("x-amz-version-id", output.VersionID)
 	}
+	setSequenceHeader(w, output.Sequence)
+	setStagingIDHeader(w, output.StagingID)
 
 	// Return XML response
 	response := CompleteMultipartUploadResult{
@@ -304,11 +361,95 @@ func (h *MultipartHandler) CompleteMultipartUpload(w http.ResponseWriter, r *htt
 		Bucket:   output.Bucket,
 		Key:      output.Key,
 		ETag:     output.ETag,
+
+		Checksums: newChecksums(output.ChecksumAlgorithm, output.Checksum),
 	}
 
 	writeXML(w, http.StatusOK, response)
 }
 
+// StageChunk handles PUT /{bucket}/{key}?uploadId=X requests with an
+// x-alexander-chunk-sha256 header.
+func (h *MultipartHandler) StageChunk(w http.ResponseWriter, r *http.Request, bucketName, objectKey string) {
+	ctx := r.Context()
+
+	userCtx, ok := auth.GetUserContext(ctx)
+	if !ok {
+		h.logger.Error().Msg("no user context found")
+		writeError(w, ErrAccessDenied)
+		return
+	}
+
+	contentLength := r.ContentLength
+	if contentLength < 0 {
+		writeError(w, S3Error{
+			Code:           "MissingContentLength",
+			Message:        "You must provide the Content-Length HTTP header.",
+			HTTPStatusCode: http.StatusLengthRequired,
+		})
+		return
+	}
+
+	output, err := h.multipartService.StageChunk(ctx, service.StageChunkInput{
+		BucketName:  bucketName,
+		Key:         objectKey,
+		UploadID:    r.URL.Query().Get("uploadId"),
+		ContentHash: r.Header.Get(ChunkSHA256Header),
+		Body:        r.Body,
+		Size:        contentLength,
+		OwnerID:     userCtx.UserID,
+	})
+	if err != nil {
+		h.handleMultipartError(w, err, bucketName, objectKey)
+		return
+	}
+
+	w.Header().Set("ETag", output.ETag)
+	w.WriteHeader(http.StatusOK)
+}
+
+// CommitChunks handles POST /{bucket}/{key}?uploadId=X requests with an
+// x-alexander-commit-chunks header.
+func (h *MultipartHandler) CommitChunks(w http.ResponseWriter, r *http.Request, bucketName, objectKey string) {
+	ctx := r.Context()
+
+	userCtx, ok := auth.GetUserContext(ctx)
+	if !ok {
+		h.logger.Error().Msg("no user context found")
+		writeError(w, ErrAccessDenied)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxCommitChunksBody+1))
+	if err != nil {
+		writeError(w, ErrInternalError)
+		return
+	}
+	if len(body) > maxCommitChunksBody {
+		writeError(w, S3Error{
+			Code:           "InvalidArgument",
+			Message:        "The chunk list is too long.",
+			HTTPStatusCode: http.StatusBadRequest,
+		})
+		return
+	}
+
+	output, err := h.multipartService.CommitChunks(ctx, service.CommitChunksInput{
+		BucketName: bucketName,
+		Key:        objectKey,
+		UploadID:   r.URL.Query().Get("uploadId"),
+		Chunks:     strings.Fields(string(body)),
+		OwnerID:    userCtx.UserID,
+		BatchID:    r.Header.Get(BatchIDHeader),
+	})
+	if err != nil {
+		h.handleMultipartError(w, err, bucketName, objectKey)
+		return
+	}
+
+	h.writeCompleteResult(w, output)
+}
+
 // AbortMultipartUpload handles DELETE /{bucket}/{key}?uploadId=X requests.
 func (h *MultipartHandler) AbortMultipartUpload(w http.ResponseWriter, r *http.Request, bucketName, objectKey string) {
 	ctx := r.Context()
@@ -365,7 +506,11 @@ func (h *MultipartHandler) ListMultipartUploads(w http.ResponseWriter, r *http.R
 	// Parse parameters
 	maxUploads, _ := strconv.Atoi(query.Get("max-uploads"))
 	if maxUploads <= 0 {
-		maxUploads = 1000
+		maxUploads = maxListKeys
+	}
+	encodingType, encode, ok := listEncoding(w, r)
+	if !ok {
+		return
 	}
 
 	// List uploads
@@ -388,7 +533,7 @@ func (h *MultipartHandler) ListMultipartUploads(w http.ResponseWriter, r *http.R
 	uploads := make([]UploadElement, len(output.Uploads))
 	for i, u := range output.Uploads {
 		uploads[i] = UploadElement{
-			Key:          u.Key,
+			Key:          encode(u.Key),
 			UploadId:     u.UploadID,
 			Initiated:    formatS3Time(u.Initiated),
 			StorageClass: string(u.StorageClass),
@@ -397,18 +542,19 @@ func (h *MultipartHandler) ListMultipartUploads(w http.ResponseWriter, r *http.R
 
 	commonPrefixes := make([]CommonPrefix, len(output.CommonPrefixes))
 	for i, prefix := range output.CommonPrefixes {
-		commonPrefixes[i] = CommonPrefix{Prefix: prefix}
+		commonPrefixes[i] = CommonPrefix{Prefix: encode(prefix)}
 	}
 
 	response := ListMultipartUploadsResult{
 		Xmlns:              "http://s3.amazonaws.com/doc/2006-03-01/",
 		Bucket:             output.Bucket,
-		KeyMarker:          output.KeyMarker,
+		KeyMarker:          encode(output.KeyMarker),
 		UploadIdMarker:     output.UploadIDMarker,
-		NextKeyMarker:      output.NextKeyMarker,
+		NextKeyMarker:      encode(output.NextKeyMarker),
 		NextUploadIdMarker: output.NextUploadIDMarker,
-		Prefix:             output.Prefix,
-		Delimiter:          output.Delimiter,
+		Prefix:             encode(output.Prefix),
+		Delimiter:          encode(output.Delimiter),
+		EncodingType:       encodingType,
 		MaxUploads:         output.MaxUploads,
 		IsTruncated:        output.IsTruncated,
 		Uploads:            uploads,
@@ -447,7 +593,7 @@ func (h *MultipartHandler) ListParts(w http.ResponseWriter, r *http.Request, buc
 	partNumberMarker, _ := strconv.Atoi(query.Get("part-number-marker"))
 	maxParts, _ := strconv.Atoi(query.Get("max-parts"))
 	if maxParts <= 0 {
-		maxParts = 1000
+		maxParts = maxListKeys
 	}
 
 	// List parts
@@ -473,6 +619,7 @@ func (h *MultipartHandler) ListParts(w http.ResponseWriter, r *http.Request, buc
 			LastModified: formatS3Time(p.LastModified),
 			ETag:         p.ETag,
 			Size:         p.Size,
+			Checksums:    newChecksums(output.ChecksumAlgorithm, p.Checksum),
 		}
 	}
 
@@ -487,6 +634,7 @@ func (h *MultipartHandler) ListParts(w http.ResponseWriter, r *http.Request, buc
 		IsTruncated:          output.IsTruncated,
 		Parts:                parts,
 		StorageClass:         string(output.StorageClass),
+		ChecksumAlgorithm:    string(output.ChecksumAlgorithm),
 	}
 
 	writeXML(w, http.StatusOK, response)
@@ -498,100 +646,14 @@ func (h *MultipartHandler) ListParts(w http.ResponseWriter, r *http.Request, buc
 
 // handleMultipartError maps service errors to S3 error responses.
 func (h *MultipartHandler) handleMultipartError(w http.ResponseWriter, err error, bucket, key string) {
-	var s3Err S3Error
-	resource := "/" + bucket
-	if key != "" {
-		resource += "/" + key
-	}
-
-	switch {
-	case errors.Is(err, domain.ErrBucketNotFound):
-		s3Err = ErrNoSuchBucket
-	case errors.Is(err, domain.ErrMultipartUploadNotFound):
-		s3Err = S3Error{
-			Code:           "NoSuchUpload",
-			Message:        "The specified multipart upload does not exist.",
-			HTTPStatusCode: http.StatusNotFound,
-		}
-	case errors.Is(err, domain.ErrMultipartUploadExpired):
-		s3Err = S3Error{
-			Code:           "NoSuchUpload",
-			Message:        "The specified multipart upload has expired.",
-			HTTPStatusCode: http.StatusNotFound,
-		}
-	case errors.Is(err, domain.ErrMultipartUploadCompleted):
-		s3Err = S3Error{
-			Code:           "NoSuchUpload",
-			Message:        "The specified multipart upload is already completed.",
-			HTTPStatusCode: http.StatusNotFound,
-		}
-	case errors.Is(err, domain.ErrMultipartUploadAborted):
-		s3Err = S3Error{
-			Code:           "NoSuchUpload",
-			Message:        "The specified multipart upload has been aborted.",
-			HTTPStatusCode: http.StatusNotFound,
-		}
-	case errors.Is(err, domain.ErrInvalidPartNumber):
-		s3Err = S3Error{
-			Code:           "InvalidArgument",
-			Message:        "Part number must be between 1 and 10000.",
-			HTTPStatusCode: http.StatusBadRequest,
-		}
-	case errors.Is(err, domain.ErrPartTooSmall):
-		s3Err = S3Error{
-			Code:           "EntityTooSmall",
-			Message:        "Your proposed upload is smaller than the minimum allowed object size.",
-			HTTPStatusCode: http.StatusBadRequest,
-		}
-	case errors.Is(err, domain.ErrPartTooLarge):
-		s3Err = S3Error{
-			Code:           "EntityTooLarge",
-			Message:        "Your proposed upload exceeds the maximum allowed object size.",
-			HTTPStatusCode: http.StatusBadRequest,
-		}
-	case errors.Is(err, domain.ErrPartNotFound):
-		s3Err = S3Error{
-			Code:           "InvalidPart",
-			Message:        "One or more of the specified parts could not be found.",
-			HTTPStatusCode: http.StatusBadRequest,
-		}
-	case errors.Is(err, domain.ErrPartETagMismatch):
-		s3Err = S3Error{
-			Code:           "InvalidPart",
-			Message:        "One or more of the specified parts had invalid ETags.",
-			HTTPStatusCode: http.StatusBadRequest,
-		}
-	case errors.Is(err, domain.ErrInvalidPartOrder):
-		s3Err = S3Error{
-			Code:           "InvalidPartOrder",
-			Message:        "Parts must be specified in ascending order by part number.",
-			HTTPStatusCode: http.StatusBadRequest,
-		}
-	case errors.Is(err, domain.ErrNoPartsProvided):
-		s3Err = S3Error{
-			Code:           "MalformedXML",
-			Message:        "The XML you provided did not have the required number of parts.",
-			HTTPStatusCode: http.StatusBadRequest,
-		}
-	case errors.Is(err, domain.ErrObjectKeyEmpty):
-		s3Err = S3Error{
-			Code:           "InvalidArgument",
-			Message:        "Object key cannot be empty.",
-			HTTPStatusCode: http.StatusBadRequest,
-		}
-	case errors.Is(err, domain.ErrObjectKeyTooLong):
-		s3Err = S3Error{
-			Code:           "KeyTooLongError",
-			Message:        "Your key is too long.",
-			HTTPStatusCode: http.StatusBadRequest,
-		}
-	case errors.Is(err, service.ErrBucketAccessDenied):
-		s3Err = ErrAccessDenied
-	default:
+	s3Err, ok := s3ErrorFor(err)
+	if !ok {
 		h.logger.Error().Err(err).Str("bucket", bucket).Str("key", key).Msg("unhandled error")
-		s3Err = ErrInternalError
 	}
 
-	s3Err.Resource = resource
+	s3Err.Resource = "/" + bucket
+	if key != "" {
+		s3Err.Resource += "/" + key
+	}
 	writeError(w, s3Err)
 }
@@ -3,7 +3,6 @@ package handler
 
 import (
 	"encoding/xml"
-	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -16,19 +15,41 @@ import (
 	"github.com/prn-tf/alexander-storage/internal/auth"
 	"github.com/prn-tf/alexander-storage/internal/domain"
 	"github.com/prn-tf/alexander-storage/internal/service"
+	"github.com/prn-tf/alexander-storage/internal/tiering"
+)
+
+const (
+	// SequenceHeader carries the per-key write sequence of the object version
+	// written or read. It increases with every write or delete of the key.
+	SequenceHeader = "x-alexander-sequence"
+
+	// IfSequenceHeader makes PUT and DELETE conditional on the key's current
+	// sequence (0 for a key that was never written). It acts as a fencing token.
+	IfSequenceHeader = "x-alexander-if-sequence"
+
+	// RenameSourceHeader names the key a RenameObject request moves.
+	RenameSourceHeader = "x-amz-rename-source"
+
+	// MFAHeader carries the serial number of the bucket owner's MFA device
+	// and a current code, separated by a space. Buckets with MFA delete
+	// require it to delete versions and to change the versioning state.
+	MFAHeader = "x-amz-mfa"
 )
 
 // ObjectHandler handles object-related HTTP requests.
 type ObjectHandler struct {
-	objectService *service.ObjectService
-	logger        zerolog.Logger
+	objectService     *service.ObjectService
+	detectContentType bool
+	logger            zerolog.Logger
 }
 
-// NewObjectHandler creates a new ObjectHandler.
-func NewObjectHandler(objectService *service.ObjectService, logger zerolog.Logger) *ObjectHandler {
+// NewObjectHandler creates a new ObjectHandler. With detectContentType,
+// uploads sent without a Content-Type get one from their key's extension.
+func NewObjectHandler(objectService *service.ObjectService, detectContentType bool, logger zerolog.Logger) *ObjectHandler {
 	return &ObjectHandler{
-		objectService: objectService,
-		logger:        logger.With().Str("handler", "object").Logger(),
+		objectService:     objectService,
+		detectContentType: detectContentType,
+		logger:            logger.With().Str("handler", "object").Logger(),
 	}
 }
 
@@ -45,6 +66,7 @@ type ListBucketResult struct {
 	Marker         string         `xml:"Marker,omitempty"`
 	MaxKeys        int            `xml:"MaxKeys"`
 	Delimiter      string         `xml:"Delimiter,omitempty"`
+	EncodingType   string         `xml:"EncodingType,omitempty"`
 	IsTruncated    bool           `xml:"IsTruncated"`
 	Contents       []S3Object     `xml:"Contents,omitempty"`
 	CommonPrefixes []CommonPrefix `xml:"CommonPrefixes,omitempty"`
@@ -62,6 +84,7 @@ type ListBucketResultV2 struct {
 	NextContinuationToken string         `xml:"NextContinuationToken,omitempty"`
 	MaxKeys               int            `xml:"MaxKeys"`
 	Delimiter             string         `xml:"Delimiter,omitempty"`
+	EncodingType          string         `xml:"EncodingType,omitempty"`
 	IsTruncated           bool           `xml:"IsTruncated"`
 	Contents              []S3Object     `xml:"Contents,omitempty"`
 	CommonPrefixes        []CommonPrefix `xml:"CommonPrefixes,omitempty"`
@@ -82,6 +105,12 @@ type CommonPrefix struct {
 	Prefix string `xml:"Prefix"`
 }
 
+// RestoreRequest is the request body of RestoreObject.
+type RestoreRequest struct {
+	XMLName xml.Name `xml:"RestoreRequest"`
+	Days    int      `xml:"Days"`
+}
+
 // CopyObjectResult is the response for CopyObject.
 type CopyObjectResult struct {
 	XMLName      xml.Name `xml:"CopyObjectResult"`
@@ -111,6 +140,7 @@ type ListVersionsResult struct {
 	NextVersionIdMarker string            `xml:"NextVersionIdMarker,omitempty"`
 	MaxKeys             int               `xml:"MaxKeys"`
 	Delimiter           string            `xml:"Delimiter,omitempty"`
+	EncodingType        string            `xml:"EncodingType,omitempty"`
 	IsTruncated         bool              `xml:"IsTruncated"`
 	Versions            []S3ObjectVersion `xml:"Version,omitempty"`
 	DeleteMarkers       []S3DeleteMarker  `xml:"DeleteMarker,omitempty"`
@@ -163,21 +193,44 @@ func (h *ObjectHandler) PutObject(w http.ResponseWriter, r *http.Request, bucket
 		return
 	}
 
-	// Get content type
-	contentType := r.Header.Get("Content-Type")
-
 	// Parse metadata from x-amz-meta-* headers
 	metadata := parseMetadata(r)
 
+	ifSequence, s3Err, ok := parseIfSequenceHeader(r)
+	if !ok {
+		writeError(w, s3Err)
+		return
+	}
+
+	acl, s3Err, ok := parseObjectACLHeader(r)
+	if !ok {
+		writeError(w, s3Err)
+		return
+	}
+
+	checksumAlgorithm, checksum, s3Err, ok := parseChecksumHeaders(r)
+	if !ok {
+		writeError(w, s3Err)
+		return
+	}
+
 	// Store object
 	output, err := h.objectService.PutObject(ctx, service.PutObjectInput{
 		BucketName:  bucketName,
 		Key:         objectKey,
 		Body:        r.Body,
 		Size:        contentLength,
-		ContentType: contentType,
+		ContentType: r.Header.Get("Content-Type"),
 		Metadata:    metadata,
 		OwnerID:     userCtx.UserID,
+		IfSequence:  ifSequence,
+		BatchID:     r.Header.Get(BatchIDHeader),
+		ACL:         acl,
+
+		StorageClass:      domain.StorageClass(r.Header.Get("x-amz-storage-class")),
+		DetectContentType: h.detectContentType,
+		ChecksumAlgorithm: checksumAlgorithm,
+		Checksum:          checksum,
 	})
 
 	if err != nil {
@@ -190,6 +243,10 @@ func (h *ObjectHandler) PutObject(w http.ResponseWriter, r *http.Request, bucket
 	if output.VersionID != "" && output.VersionID != "null" {
 		w.Header().Set("x-amz-version-id", output.VersionID)
 	}
+	setSequenceHeader(w, output.Sequence)
+	setExpirationHeader(w, output.Expiration)
+	setStagingIDHeader(w, output.StagingID)
+	setChecksumHeader(w, output.ChecksumAlgorithm, output.Checksum)
 	w.WriteHeader(http.StatusOK)
 }
 
@@ -197,17 +254,22 @@ func (h *ObjectHandler) PutObject(w http.ResponseWriter, r *http.Request, bucket
 func (h *ObjectHandler) GetObject(w http.ResponseWriter, r *http.Request, bucketName, objectKey string) {
 	ctx := r.Context()
 
-	// Get authenticated user from context
-	userCtx, ok := auth.GetUserContext(ctx)
-	if !ok {
-		h.logger.Error().Msg("no user context found")
-		writeError(w, ErrAccessDenied)
-		return
+	// Anonymous reads were admitted by the bucket or object ACL
+	var ownerID int64
+	if userCtx, ok := auth.GetUserContext(ctx); ok {
+		ownerID = userCtx.UserID
 	}
 
 	// Parse version ID
 	versionID := r.URL.Query().Get("versionId")
 
+	// Parse part number
+	partNumber, s3Err, ok := parsePartNumberParam(r)
+	if !ok {
+		writeError(w, s3Err)
+		return
+	}
+
 	// Parse range header
 	var byteRange *service.ByteRange
 	rangeHeader := r.Header.Get("Range")
@@ -229,8 +291,9 @@ func (h *ObjectHandler) GetObject(w http.ResponseWriter, r *http.Request, bucket
 		BucketName: bucketName,
 		Key:        objectKey,
 		VersionID:  versionID,
-		OwnerID:    userCtx.UserID,
+		OwnerID:    ownerID,
 		Range:      byteRange,
+		PartNumber: partNumber,
 	})
 
 	if err != nil {
@@ -249,10 +312,20 @@ func (h *ObjectHandler) GetObject(w http.ResponseWriter, r *http.Request, bucket
 		w.Header().Set("x-amz-version-id", output.VersionID)
 	}
 
-	// Set metadata headers
-	for key, value := range output.Metadata {
-		w.Header().Set("x-amz-meta-"+key, value)
+	if output.PartsCount > 0 {
+		w.Header().Set("x-amz-mp-parts-count", strconv.Itoa(output.PartsCount))
 	}
+	setSequenceHeader(w, output.Sequence)
+
+	if output.ReplicationStatus != domain.ReplicationStatusNone {
+		w.Header().Set("x-amz-replication-status", string(output.ReplicationStatus))
+	}
+	setExpirationHeader(w, output.Expiration)
+	setRestoreHeader(w, output.Restore)
+	setObjectChecksumHeaders(w, r, output.ChecksumAlgorithm, output.Checksum, output.ContentRange)
+
+	setMetadataHeaders(w, output.Metadata)
+	setResponseOverrides(w, r)
 
 	// Handle range response
 	if output.ContentRange != "" {
@@ -270,23 +343,29 @@ func (h *ObjectHandler) GetObject(w http.ResponseWriter, r *http.Request, bucket
 func (h *ObjectHandler) HeadObject(w http.ResponseWriter, r *http.Request, bucketName, objectKey string) {
 	ctx := r.Context()
 
-	// Get authenticated user from context
-	userCtx, ok := auth.GetUserContext(ctx)
-	if !ok {
-		h.logger.Error().Msg("no user context found")
-		writeError(w, ErrAccessDenied)
-		return
+	// Anonymous reads were admitted by the bucket or object ACL
+	var ownerID int64
+	if userCtx, ok := auth.GetUserContext(ctx); ok {
+		ownerID = userCtx.UserID
 	}
 
 	// Parse version ID
 	versionID := r.URL.Query().Get("versionId")
 
+	// Parse part number
+	partNumber, s3Err, ok := parsePartNumberParam(r)
+	if !ok {
+		writeError(w, s3Err)
+		return
+	}
+
 	// Get object metadata
 	output, err := h.objectService.HeadObject(ctx, service.HeadObjectInput{
 		BucketName: bucketName,
 		Key:        objectKey,
 		VersionID:  versionID,
-		OwnerID:    userCtx.UserID,
+		OwnerID:    ownerID,
+		PartNumber: partNumber,
 	})
 
 	if err != nil {
@@ -305,12 +384,27 @@ func (h *ObjectHandler) HeadObject(w http.ResponseWriter, r *http.Request, bucke
 		w.Header().Set("x-amz-version-id", output.VersionID)
 	}
 
-	// Set metadata headers
-	for key, value := range output.Metadata {
-		w.Header().Set("x-amz-meta-"+key, value)
+	if output.PartsCount > 0 {
+		w.Header().Set("x-amz-mp-parts-count", strconv.Itoa(output.PartsCount))
 	}
+	setSequenceHeader(w, output.Sequence)
 
-	w.WriteHeader(http.StatusOK)
+	if output.ReplicationStatus != domain.ReplicationStatusNone {
+		w.Header().Set("x-amz-replication-status", string(output.ReplicationStatus))
+	}
+	setExpirationHeader(w, output.Expiration)
+	setRestoreHeader(w, output.Restore)
+	setObjectChecksumHeaders(w, r, output.ChecksumAlgorithm, output.Checksum, output.ContentRange)
+
+	setMetadataHeaders(w, output.Metadata)
+	setResponseOverrides(w, r)
+
+	if output.ContentRange != "" {
+		w.Header().Set("Content-Range", output.ContentRange)
+		w.WriteHeader(http.StatusPartialContent)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
 }
 
 // DeleteObject handles DELETE /{bucket}/{key} requests.
@@ -328,12 +422,20 @@ func (h *ObjectHandler) DeleteObject(w http.ResponseWriter, r *http.Request, buc
 	// Parse version ID
 	versionID := r.URL.Query().Get("versionId")
 
+	ifSequence, s3Err, ok := parseIfSequenceHeader(r)
+	if !ok {
+		writeError(w, s3Err)
+		return
+	}
+
 	// Delete object
 	output, err := h.objectService.DeleteObject(ctx, service.DeleteObjectInput{
 		BucketName: bucketName,
 		Key:        objectKey,
 		VersionID:  versionID,
 		OwnerID:    userCtx.UserID,
+		IfSequence: ifSequence,
+		MFA:        r.Header.Get(MFAHeader),
 	})
 
 	if err != nil {
@@ -348,10 +450,94 @@ func (h *ObjectHandler) DeleteObject(w http.ResponseWriter, r *http.Request, buc
 	if output.VersionID != "" {
 		w.Header().Set("x-amz-version-id", output.VersionID)
 	}
+	setSequenceHeader(w, output.Sequence)
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// GetObjectACL handles GET /{bucket}/{key}?acl requests.
+func (h *ObjectHandler) GetObjectACL(w http.ResponseWriter, r *http.Request, bucketName, objectKey string) {
+	ctx := r.Context()
+
+	// Get authenticated user from context
+	userCtx, ok := auth.GetUserContext(ctx)
+	if !ok {
+		h.logger.Error().Msg("no user context found")
+		writeError(w, ErrAccessDenied)
+		return
+	}
+
+	output, err := h.objectService.GetObjectACL(ctx, service.GetObjectACLInput{
+		BucketName: bucketName,
+		Key:        objectKey,
+		VersionID:  r.URL.Query().Get("versionId"),
+		OwnerID:    userCtx.UserID,
+	})
+	if err != nil {
+		h.handleObjectError(w, err, bucketName, objectKey)
+		return
+	}
+
+	owner := Owner{
+		ID:          strconv.FormatInt(output.OwnerID, 10),
+		DisplayName: userCtx.Username,
+	}
+	response := newAccessControlPolicy(owner, output.ACL.AllowsAnonymousRead(), false)
+
+	if output.VersionID != "" {
+		w.Header().Set("x-amz-version-id", output.VersionID)
+	}
+	writeXML(w, http.StatusOK, response)
+}
+
+// PutObjectACL handles PUT /{bucket}/{key}?acl requests. Like PutBucketACL,
+// it accepts a canned ACL in the x-amz-acl header or a matching access
+// control policy in the body.
+func (h *ObjectHandler) PutObjectACL(w http.ResponseWriter, r *http.Request, bucketName, objectKey string) {
+	ctx := r.Context()
+
+	// Get authenticated user from context
+	userCtx, ok := auth.GetUserContext(ctx)
+	if !ok {
+		h.logger.Error().Msg("no user context found")
+		writeError(w, ErrAccessDenied)
+		return
+	}
+
+	// Parse request body
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1024*10)) // 10KB limit
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to read request body")
+		writeError(w, ErrInternalError)
+		return
+	}
+	defer r.Body.Close()
+
+	acl, s3Err, ok := requestedACL(r, body, strconv.FormatInt(userCtx.UserID, 10))
+	if !ok {
+		writeError(w, s3Err)
+		return
+	}
+
+	versionID := r.URL.Query().Get("versionId")
+	err = h.objectService.PutObjectACL(ctx, service.PutObjectACLInput{
+		BucketName: bucketName,
+		Key:        objectKey,
+		VersionID:  versionID,
+		OwnerID:    userCtx.UserID,
+		ACL:        domain.ObjectACL(acl),
+	})
+	if err != nil {
+		h.handleObjectError(w, err, bucketName, objectKey)
+		return
+	}
+
+	if versionID != "" {
+		w.Header().Set("x-amz-version-id", versionID)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
 // ListObjects handles GET /{bucket} requests (v1).
 func (h *ObjectHandler) ListObjects(w http.ResponseWriter, r *http.Request, bucketName string) {
 	ctx := r.Context()
@@ -375,7 +561,11 @@ func (h *ObjectHandler) ListObjects(w http.ResponseWriter, r *http.Request, buck
 	// Parse parameters
 	maxKeys, _ := strconv.Atoi(query.Get("max-keys"))
 	if maxKeys <= 0 {
-		maxKeys = 1000
+		maxKeys = maxListKeys
+	}
+	encodingType, encode, ok := listEncoding(w, r)
+	if !ok {
+		return
 	}
 
 	// List objects
@@ -397,7 +587,7 @@ func (h *ObjectHandler) ListObjects(w http.ResponseWriter, r *http.Request, buck
 	contents := make([]S3Object, len(output.Contents))
 	for i, obj := range output.Contents {
 		contents[i] = S3Object{
-			Key:          obj.Key,
+			Key:          encode(obj.Key),
 			LastModified: formatS3Time(obj.LastModified),
 			ETag:         obj.ETag,
 			Size:         obj.Size,
@@ -407,20 +597,21 @@ func (h *ObjectHandler) ListObjects(w http.ResponseWriter, r *http.Request, buck
 
 	commonPrefixes := make([]CommonPrefix, len(output.CommonPrefixes))
 	for i, prefix := range output.CommonPrefixes {
-		commonPrefixes[i] = CommonPrefix{Prefix: prefix}
+		commonPrefixes[i] = CommonPrefix{Prefix: encode(prefix)}
 	}
 
 	response := ListBucketResult{
 		Xmlns:          "http://s3.amazonaws.com/doc/2006-03-01/",
 		Name:           bucketName,
-		Prefix:         output.Prefix,
-		Marker:         query.Get("marker"),
+		Prefix:         encode(output.Prefix),
+		Marker:         encode(query.Get("marker")),
 		MaxKeys:        output.MaxKeys,
-		Delimiter:      output.Delimiter,
+		Delimiter:      encode(output.Delimiter),
+		EncodingType:   encodingType,
 		IsTruncated:    output.IsTruncated,
 		Contents:       contents,
 		CommonPrefixes: commonPrefixes,
-		NextMarker:     output.NextMarker,
+		NextMarker:     encode(output.NextMarker),
 	}
 
 	writeXML(w, http.StatusOK, response)
@@ -443,7 +634,11 @@ func (h *ObjectHandler) ListObjectsV2(w http.ResponseWriter, r *http.Request, bu
 	// Parse parameters
 	maxKeys, _ := strconv.Atoi(query.Get("max-keys"))
 	if maxKeys <= 0 {
-		maxKeys = 1000
+		maxKeys = maxListKeys
+	}
+	encodingType, encode, ok := listEncoding(w, r)
+	if !ok {
+		return
 	}
 
 	// List objects
@@ -466,7 +661,7 @@ func (h *ObjectHandler) ListObjectsV2(w http.ResponseWriter, r *http.Request, bu
 	contents := make([]S3Object, len(output.Contents))
 	for i, obj := range output.Contents {
 		contents[i] = S3Object{
-			Key:          obj.Key,
+			Key:          encode(obj.Key),
 			LastModified: formatS3Time(obj.LastModified),
 			ETag:         obj.ETag,
 			Size:         obj.Size,
@@ -476,18 +671,19 @@ func (h *ObjectHandler) ListObjectsV2(w http.ResponseWriter, r *http.Request, bu
 
 	commonPrefixes := make([]CommonPrefix, len(output.CommonPrefixes))
 	for i, prefix := range output.CommonPrefixes {
-		commonPrefixes[i] = CommonPrefix{Prefix: prefix}
+		commonPrefixes[i] = CommonPrefix{Prefix: encode(prefix)}
 	}
 
 	response := ListBucketResultV2{
 		Xmlns:                 "http://s3.amazonaws.com/doc/2006-03-01/",
 		Name:                  bucketName,
-		Prefix:                output.Prefix,
-		StartAfter:            query.Get("start-after"),
+		Prefix:                encode(output.Prefix),
+		StartAfter:            encode(query.Get("start-after")),
 		ContinuationToken:     query.Get("continuation-token"),
 		NextContinuationToken: output.NextContinuationToken,
 		MaxKeys:               output.MaxKeys,
-		Delimiter:             output.Delimiter,
+		Delimiter:             encode(output.Delimiter),
+		EncodingType:          encodingType,
 		IsTruncated:           output.IsTruncated,
 		Contents:              contents,
 		CommonPrefixes:        commonPrefixes,
@@ -514,7 +710,11 @@ func (h *ObjectHandler) ListObjectVersions(w http.ResponseWriter, r *http.Reques
 	// Parse parameters
 	maxKeys, _ := strconv.Atoi(query.Get("max-keys"))
 	if maxKeys <= 0 {
-		maxKeys = 1000
+		maxKeys = maxListKeys
+	}
+	encodingType, encode, ok := listEncoding(w, r)
+	if !ok {
+		return
 	}
 
 	// List versions
@@ -537,7 +737,7 @@ func (h *ObjectHandler) ListObjectVersions(w http.ResponseWriter, r *http.Reques
 	versions := make([]S3ObjectVersion, len(output.Versions))
 	for i, ver := range output.Versions {
 		versions[i] = S3ObjectVersion{
-			Key:          ver.Key,
+			Key:          encode(ver.Key),
 			VersionId:    ver.VersionID,
 			IsLatest:     ver.IsLatest,
 			LastModified: formatS3Time(ver.LastModified),
@@ -550,7 +750,7 @@ func (h *ObjectHandler) ListObjectVersions(w http.ResponseWriter, r *http.Reques
 	deleteMarkers := make([]S3DeleteMarker, len(output.DeleteMarkers))
 	for i, dm := range output.DeleteMarkers {
 		deleteMarkers[i] = S3DeleteMarker{
-			Key:          dm.Key,
+			Key:          encode(dm.Key),
 			VersionId:    dm.VersionID,
 			IsLatest:     dm.IsLatest,
 			LastModified: formatS3Time(dm.LastModified),
@@ -559,19 +759,20 @@ func (h *ObjectHandler) ListObjectVersions(w http.ResponseWriter, r *http.Reques
 
 	commonPrefixes := make([]CommonPrefix, len(output.CommonPrefixes))
 	for i, prefix := range output.CommonPrefixes {
-		commonPrefixes[i] = CommonPrefix{Prefix: prefix}
+		commonPrefixes[i] = CommonPrefix{Prefix: encode(prefix)}
 	}
 
 	response := ListVersionsResult{
 		Xmlns:               "http://s3.amazonaws.com/doc/2006-03-01/",
 		Name:                bucketName,
-		Prefix:              output.Prefix,
-		KeyMarker:           output.KeyMarker,
+		Prefix:              encode(output.Prefix),
+		KeyMarker:           encode(output.KeyMarker),
 		VersionIdMarker:     output.VersionIDMarker,
-		NextKeyMarker:       output.NextKeyMarker,
+		NextKeyMarker:       encode(output.NextKeyMarker),
 		NextVersionIdMarker: output.NextVersionIDMarker,
 		MaxKeys:             output.MaxKeys,
-		Delimiter:           output.Delimiter,
+		Delimiter:           encode(output.Delimiter),
+		EncodingType:        encodingType,
 		IsTruncated:         output.IsTruncated,
 		Versions:            versions,
 		DeleteMarkers:       deleteMarkers,
@@ -635,24 +836,47 @@ func (h *ObjectHandler) CopyObject(w http.ResponseWriter, r *http.Request, destB
 	// Get content type override
 	contentType := r.Header.Get("Content-Type")
 
+	acl, s3Err, ok := parseObjectACLHeader(r)
+	if !ok {
+		writeError(w, s3Err)
+		return
+	}
+
 	// Parse new metadata
 	var metadata map[string]string
 	if metadataDirective == "REPLACE" {
 		metadata = parseMetadata(r)
 	}
 
-	// Copy object
-	output, err := h.objectService.CopyObject(ctx, service.CopyObjectInput{
-		SourceBucket:      sourceBucket,
-		SourceKey:         sourceKey,
-		SourceVersionID:   sourceVersionID,
-		DestBucket:        destBucket,
-		DestKey:           destKey,
-		ContentType:       contentType,
-		Metadata:          metadata,
-		MetadataDirective: metadataDirective,
-		OwnerID:           userCtx.UserID,
-	})
+	var output *service.CopyObjectOutput
+	var err error
+	if metadataDirective == "REPLACE" && sourceBucket == destBucket && sourceKey == destKey &&
+		sourceVersionID == "" && r.Header.Get(BatchIDHeader) == "" {
+		// Copying the latest version onto itself only changes its metadata,
+		// which is updated in place instead of creating a version
+		output, err = h.objectService.UpdateObjectMetadata(ctx, service.UpdateObjectMetadataInput{
+			BucketName:  destBucket,
+			Key:         destKey,
+			ContentType: contentType,
+			Metadata:    metadata,
+			OwnerID:     userCtx.UserID,
+			ACL:         acl,
+		})
+	} else {
+		output, err = h.objectService.CopyObject(ctx, service.CopyObjectInput{
+			SourceBucket:      sourceBucket,
+			SourceKey:         sourceKey,
+			SourceVersionID:   sourceVersionID,
+			DestBucket:        destBucket,
+			DestKey:           destKey,
+			ContentType:       contentType,
+			Metadata:          metadata,
+			MetadataDirective: metadataDirective,
+			OwnerID:           userCtx.UserID,
+			BatchID:           r.Header.Get(BatchIDHeader),
+			ACL:               acl,
+		})
+	}
 
 	if err != nil {
 		h.handleObjectError(w, err, destBucket, destKey)
@@ -663,6 +887,8 @@ func (h *ObjectHandler) CopyObject(w http.ResponseWriter, r *http.Request, destB
 	if output.VersionID != "" && output.VersionID != "null" {
 		w.Header().Set("x-amz-version-id", output.VersionID)
 	}
+	setSequenceHeader(w, output.Sequence)
+	setStagingIDHeader(w, output.StagingID)
 
 	// Return XML response
 	response := CopyObjectResult{
@@ -674,11 +900,109 @@ func (h *ObjectHandler) CopyObject(w http.ResponseWriter, r *http.Request, destB
 	writeXML(w, http.StatusOK, response)
 }
 
+// RenameObject handles PUT /{bucket}/{key}?renameObject requests, which move
+// the object named by the x-amz-rename-source header to key in the same bucket.
+func (h *ObjectHandler) RenameObject(w http.ResponseWriter, r *http.Request, bucketName, key string) {
+	ctx := r.Context()
+
+	// Get authenticated user from context
+	userCtx, ok := auth.GetUserContext(ctx)
+	if !ok {
+		h.logger.Error().Msg("no user context found")
+		writeError(w, ErrAccessDenied)
+		return
+	}
+
+	// The source is a key in the bucket, URL-encoded, with or without a leading slash
+	source, err := url.PathUnescape(r.Header.Get(RenameSourceHeader))
+	source = strings.TrimPrefix(source, "/")
+	if err != nil || source == "" {
+		writeError(w, S3Error{
+			Code:           "InvalidArgument",
+			Message:        "Missing or invalid " + RenameSourceHeader + " header.",
+			HTTPStatusCode: http.StatusBadRequest,
+		})
+		return
+	}
+
+	output, err := h.objectService.RenameObject(ctx, service.RenameObjectInput{
+		BucketName: bucketName,
+		SourceKey:  source,
+		DestKey:    key,
+		OwnerID:    userCtx.UserID,
+	})
+	if err != nil {
+		h.handleObjectError(w, err, bucketName, key)
+		return
+	}
+
+	if output.VersionID != "" && output.VersionID != "null" {
+		w.Header().Set("x-amz-version-id", output.VersionID)
+	}
+	setSequenceHeader(w, output.Sequence)
+	w.WriteHeader(http.StatusOK)
+}
+
+// RestoreObject handles POST /{bucket}/{key}?restore requests, which copy an
+// archived object back to the hot tier for the number of days in the
+// RestoreRequest. A newly queued restore answers 202 Accepted; restoring an
+// object that is already restored moves its expiry and answers 200 OK.
+func (h *ObjectHandler) RestoreObject(w http.ResponseWriter, r *http.Request, bucketName, objectKey string) {
+	ctx := r.Context()
+
+	// Get authenticated user from context
+	userCtx, ok := auth.GetUserContext(ctx)
+	if !ok {
+		h.logger.Error().Msg("no user context found")
+		writeError(w, ErrAccessDenied)
+		return
+	}
+
+	// Parse request body
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1024*10)) // 10KB limit
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to read request body")
+		writeError(w, ErrInternalError)
+		return
+	}
+	defer r.Body.Close()
+
+	var req RestoreRequest
+	if err := xml.Unmarshal(body, &req); err != nil {
+		writeError(w, ErrMalformedXML)
+		return
+	}
+
+	versionID := r.URL.Query().Get("versionId")
+	output, err := h.objectService.RestoreArchivedObject(ctx, service.RestoreArchivedObjectInput{
+		BucketName: bucketName,
+		Key:        objectKey,
+		VersionID:  versionID,
+		OwnerID:    userCtx.UserID,
+		Days:       req.Days,
+	})
+	if err != nil {
+		h.handleObjectError(w, err, bucketName, objectKey)
+		return
+	}
+
+	if versionID != "" {
+		w.Header().Set("x-amz-version-id", versionID)
+	}
+	if output.AlreadyRestored {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
 // =============================================================================
 // Helper Methods
 // =============================================================================
 
-// parseMetadata extracts x-amz-meta-* headers into a map.
+// parseMetadata extracts x-amz-meta-* headers into a map, along with the
+// content headers (Cache-Control, Content-Disposition, ...) stored with the
+// object under their canonical names.
 func parseMetadata(r *http.Request) map[string]string {
 	metadata := make(map[string]string)
 	for key, values := range r.Header {
@@ -688,9 +1012,29 @@ func parseMetadata(r *http.Request) map[string]string {
 			metadata[metaKey] = values[0]
 		}
 	}
+	for _, name := range domain.ContentHeaders {
+		if value := r.Header.Get(name); value != "" {
+			metadata[name] = value
+		}
+	}
 	return metadata
 }
 
+// setMetadataHeaders writes stored object metadata as response headers:
+// content headers as themselves and user metadata as x-amz-meta-*.
+func setMetadataHeaders(w http.ResponseWriter, metadata map[string]string) {
+	for key, value := range metadata {
+		switch {
+		case domain.IsContentHeader(key):
+			w.Header().Set(key, value)
+		case key == "Content-Type":
+			// Kept by multipart uploads; served as the object's content type
+		default:
+			w.Header().Set("x-amz-meta-"+key, value)
+		}
+	}
+}
+
 // parseRangeHeader parses a Range header into start/end bytes.
 func parseRangeHeader(rangeHeader string) (*service.ByteRange, error) {
 	// Format: bytes=start-end
@@ -727,54 +1071,128 @@ func parseRangeHeader(rangeHeader string) (*service.ByteRange, error) {
 	return &service.ByteRange{Start: start, End: end}, nil
 }
 
-// handleObjectError maps service errors to S3 error responses.
-func (h *ObjectHandler) handleObjectError(w http.ResponseWriter, err error, bucket, key string) {
-	var s3Err S3Error
-	resource := "/" + bucket
-	if key != "" {
-		resource += "/" + key
+// parsePartNumberParam parses the optional partNumber query parameter of GET and HEAD.
+// It returns 0 if the parameter is absent. A partNumber cannot be combined with a Range header.
+func parsePartNumberParam(r *http.Request) (int, S3Error, bool) {
+	partNumberStr := r.URL.Query().Get("partNumber")
+	if partNumberStr == "" {
+		return 0, S3Error{}, true
 	}
 
-	switch {
-	case errors.Is(err, domain.ErrBucketNotFound):
-		s3Err = ErrNoSuchBucket
-	case errors.Is(err, domain.ErrObjectNotFound):
-		s3Err = S3Error{
-			Code:           "NoSuchKey",
-			Message:        "The specified key does not exist.",
-			HTTPStatusCode: http.StatusNotFound,
-		}
-	case errors.Is(err, domain.ErrObjectDeleted):
-		s3Err = S3Error{
-			Code:           "NoSuchKey",
-			Message:        "The specified key does not exist.",
-			HTTPStatusCode: http.StatusNotFound,
-		}
-	case errors.Is(err, domain.ErrObjectKeyEmpty):
-		s3Err = S3Error{
+	partNumber, err := strconv.Atoi(partNumberStr)
+	if err != nil || domain.ValidatePartNumber(partNumber) != nil {
+		return 0, S3Error{
 			Code:           "InvalidArgument",
-			Message:        "Object key cannot be empty.",
+			Message:        "Part number must be an integer between 1 and 10000.",
 			HTTPStatusCode: http.StatusBadRequest,
-		}
-	case errors.Is(err, domain.ErrObjectKeyTooLong):
-		s3Err = S3Error{
-			Code:           "KeyTooLongError",
-			Message:        "Your key is too long.",
+		}, false
+	}
+
+	if r.Header.Get("Range") != "" {
+		return 0, S3Error{
+			Code:           "InvalidRequest",
+			Message:        "Cannot specify both Range header and partNumber query parameter.",
 			HTTPStatusCode: http.StatusBadRequest,
-		}
-	case errors.Is(err, domain.ErrInvalidVersionID):
-		s3Err = S3Error{
+		}, false
+	}
+
+	return partNumber, S3Error{}, true
+}
+
+// parseIfSequenceHeader parses the optional x-alexander-if-sequence header of PUT and DELETE.
+// It returns nil if the header is absent.
+func parseIfSequenceHeader(r *http.Request) (*int64, S3Error, bool) {
+	value := r.Header.Get(IfSequenceHeader)
+	if value == "" {
+		return nil, S3Error{}, true
+	}
+
+	sequence, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || sequence < 0 {
+		return nil, S3Error{
 			Code:           "InvalidArgument",
-			Message:        "Invalid version id specified.",
+			Message:        "The " + IfSequenceHeader + " header must be a non-negative integer.",
 			HTTPStatusCode: http.StatusBadRequest,
+		}, false
+	}
+
+	return &sequence, S3Error{}, true
+}
+
+// parseObjectACLHeader parses the optional x-amz-acl header of object writes.
+// It returns an empty ACL if the header is absent, and fails for explicit
+// grant headers, which are not supported.
+func parseObjectACLHeader(r *http.Request) (domain.ObjectACL, S3Error, bool) {
+	if hasGrantHeaders(r) {
+		return "", errGrantHeadersNotImplemented, false
+	}
+	return domain.ObjectACL(r.Header.Get(ACLHeader)), S3Error{}, true
+}
+
+// setSequenceHeader sets the x-alexander-sequence response header.
+// Objects written before sequences were tracked have none.
+func setSequenceHeader(w http.ResponseWriter, sequence int64) {
+	if sequence > 0 {
+		w.Header().Set(SequenceHeader, strconv.FormatInt(sequence, 10))
+	}
+}
+
+// setStagingIDHeader reports the staging ID of a write held back by
+// quarantine or a batch.
+func setStagingIDHeader(w http.ResponseWriter, stagingID string) {
+	if stagingID != "" {
+		w.Header().Set(StagingIDHeader, stagingID)
+	}
+}
+
+// responseOverrides maps the response-* query parameters of GetObject and
+// HeadObject to the response headers they replace.
+var responseOverrides = []struct{ param, header string }{
+	{"response-content-type", "Content-Type"},
+	{"response-content-language", "Content-Language"},
+	{"response-expires", "Expires"},
+	{"response-cache-control", "Cache-Control"},
+	{"response-content-disposition", "Content-Disposition"},
+	{"response-content-encoding", "Content-Encoding"},
+}
+
+// setResponseOverrides sets the headers requested by response-* query
+// parameters, e.g. a download filename in a presigned URL.
+func setResponseOverrides(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	for _, override := range responseOverrides {
+		if value := query.Get(override.param); value != "" {
+			w.Header().Set(override.header, value)
 		}
-	case errors.Is(err, service.ErrBucketAccessDenied):
-		s3Err = ErrAccessDenied
-	default:
+	}
+}
+
+// setExpirationHeader reports when a lifecycle rule expires the object.
+func setExpirationHeader(w http.ResponseWriter, expiration *domain.ObjectExpiration) {
+	if expiration != nil {
+		w.Header().Set("x-amz-expiration", fmt.Sprintf(`expiry-date="%s", rule-id="%s"`,
+			expiration.Date.UTC().Format(http.TimeFormat), expiration.RuleID))
+	}
+}
+
+// setRestoreHeader reports the restore of an archived object in the
+// x-amz-restore header. Objects that are not restored have none.
+func setRestoreHeader(w http.ResponseWriter, restore *tiering.RestoreStatus) {
+	if restore != nil {
+		w.Header().Set("x-amz-restore", restore.Header())
+	}
+}
+
+// handleObjectError maps service errors to S3 error responses.
+func (h *ObjectHandler) handleObjectError(w http.ResponseWriter, err error, bucket, key string) {
+	s3Err, ok := s3ErrorFor(err)
+	if !ok {
 		h.logger.Error().Err(err).Str("bucket", bucket).Str("key", key).Msg("unhandled error")
-		s3Err = ErrInternalError
 	}
 
-	s3Err.Resource = resource
+	s3Err.Resource = "/" + bucket
+	if key != "" {
+		s3Err.Resource += "/" + key
+	}
 	writeError(w, s3Err)
 }
@@ -0,0 +1,259 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/auth"
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/lock"
+	"github.com/prn-tf/alexander-storage/internal/repository/sqlite"
+	"github.com/prn-tf/alexander-storage/internal/service"
+	"github.com/prn-tf/alexander-storage/internal/storage/filesystem"
+	"github.com/prn-tf/alexander-storage/internal/tiering"
+)
+
+// newObjectHandlerFixture returns an object handler backed by SQLite and
+// the filesystem, with a bucket "photos", a three-part object "big.bin"
+// made of the parts "aaaa", "bbbbbb" and "cc", and a single-part object
+// "small.txt".
+func newObjectHandlerFixture(t *testing.T) *ObjectHandler {
+	t.Helper()
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	db, err := sqlite.NewDB(ctx, sqlite.DefaultConfig(filepath.Join(dir, "alexander.db")), zerolog.Nop())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, db.Migrate(ctx))
+
+	store, err := filesystem.NewStorage(filesystem.Config{
+		DataDir: filepath.Join(dir, "blobs"),
+		TempDir: filepath.Join(dir, "tmp"),
+	}, zerolog.Nop())
+	require.NoError(t, err)
+
+	user := domain.NewUser("owner", "owner@example.com", "hash")
+	require.NoError(t, sqlite.NewUserRepository(db).Create(ctx, user))
+	bucketRepo := sqlite.NewBucketRepository(db)
+	_, err = service.NewBucketService(bucketRepo, nil, zerolog.Nop(), service.DefaultBucketConfig()).
+		CreateBucket(ctx, service.CreateBucketInput{OwnerID: user.ID, Name: "photos"})
+	require.NoError(t, err)
+
+	txManager := sqlite.NewTxManager(db)
+	objects := service.NewObjectService(sqlite.NewObjectRepository(db), sqlite.NewBlobRepository(db), bucketRepo,
+		nil, nil, nil, nil, nil, nil, txManager, store, lock.NewMemoryLocker(), nil, zerolog.Nop())
+	multipart := service.NewMultipartService(sqlite.NewMultipartRepository(db), sqlite.NewObjectRepository(db),
+		sqlite.NewBlobRepository(db), bucketRepo, nil, nil, nil, nil, nil, txManager, store, lock.NewMemoryLocker(), nil, zerolog.Nop())
+	multipart.SetLimits(service.MultipartLimits{MinPartSize: 1, MaxPartSize: domain.MaxPartSize, MaxParts: domain.MaxPartNumber})
+
+	upload, err := multipart.InitiateMultipartUpload(ctx, service.InitiateMultipartUploadInput{BucketName: "photos", Key: "big.bin", OwnerID: user.ID})
+	require.NoError(t, err)
+	var parts []domain.CompletedPart
+	for i, body := range []string{"aaaa", "bbbbbb", "cc"} {
+		output, err := multipart.UploadPart(ctx, service.UploadPartInput{
+			BucketName: "photos", Key: "big.bin", UploadID: upload.UploadID, PartNumber: i + 1,
+			Body: strings.NewReader(body), Size: int64(len(body)), OwnerID: user.ID,
+		})
+		require.NoError(t, err)
+		parts = append(parts, domain.CompletedPart{PartNumber: i + 1, ETag: output.ETag})
+	}
+	_, err = multipart.CompleteMultipartUpload(ctx, service.CompleteMultipartUploadInput{
+		BucketName: "photos", Key: "big.bin", UploadID: upload.UploadID, Parts: parts, OwnerID: user.ID,
+	})
+	require.NoError(t, err)
+
+	_, err = objects.PutObject(ctx, service.PutObjectInput{
+		BucketName: "photos", Key: "small.txt", Body: bytes.NewReader([]byte("hello")), Size: 5, OwnerID: user.ID,
+	})
+	require.NoError(t, err)
+
+	return NewObjectHandler(objects, false, zerolog.Nop())
+}
+
+func TestObjectHandler_PartNumber(t *testing.T) {
+	h := newObjectHandlerFixture(t)
+
+	tests := []struct {
+		name         string
+		key          string
+		partNumber   string
+		status       int
+		body         string
+		contentRange string
+		partsCount   string
+		errorCode    string
+	}{
+		{name: "first part", key: "big.bin", partNumber: "1", status: http.StatusPartialContent, body: "aaaa", contentRange: "bytes 0-3/12", partsCount: "3"},
+		{name: "middle part", key: "big.bin", partNumber: "2", status: http.StatusPartialContent, body: "bbbbbb", contentRange: "bytes 4-9/12", partsCount: "3"},
+		{name: "last part", key: "big.bin", partNumber: "3", status: http.StatusPartialContent, body: "cc", contentRange: "bytes 10-11/12", partsCount: "3"},
+		{name: "past the last part", key: "big.bin", partNumber: "4", status: http.StatusRequestedRangeNotSatisfiable, errorCode: "InvalidPartNumber"},
+		{name: "part zero", key: "big.bin", partNumber: "0", status: http.StatusBadRequest, errorCode: "InvalidArgument"},
+		{name: "not a number", key: "big.bin", partNumber: "one", status: http.StatusBadRequest, errorCode: "InvalidArgument"},
+		{name: "single-part object", key: "small.txt", partNumber: "1", status: http.StatusOK, body: "hello"},
+		{name: "second part of a single-part object", key: "small.txt", partNumber: "2", status: http.StatusRequestedRangeNotSatisfiable, errorCode: "InvalidPartNumber"},
+	}
+
+	for _, tt := range tests {
+		for _, method := range []string{http.MethodGet, http.MethodHead} {
+			t.Run(tt.name+"/"+method, func(t *testing.T) {
+				r := httptest.NewRequest(method, "/photos/"+tt.key+"?partNumber="+tt.partNumber, nil)
+				w := httptest.NewRecorder()
+				if method == http.MethodGet {
+					h.GetObject(w, r, "photos", tt.key)
+				} else {
+					h.HeadObject(w, r, "photos", tt.key)
+				}
+
+				require.Equal(t, tt.status, w.Code, w.Body.String())
+				if tt.errorCode != "" {
+					if method == http.MethodGet {
+						assert.Contains(t, w.Body.String(), "<Code>"+tt.errorCode+"</Code>")
+					}
+					return
+				}
+				assert.Equal(t, tt.contentRange, w.Header().Get("Content-Range"))
+				assert.Equal(t, tt.partsCount, w.Header().Get("x-amz-mp-parts-count"))
+				assert.Equal(t, len(tt.body), int(w.Result().ContentLength))
+				if method == http.MethodGet {
+					body, err := io.ReadAll(w.Body)
+					require.NoError(t, err)
+					assert.Equal(t, tt.body, string(body))
+				}
+			})
+		}
+	}
+
+	// A part cannot be combined with a byte range
+	r := httptest.NewRequest(http.MethodGet, "/photos/big.bin?partNumber=1", nil)
+	r.Header.Set("Range", "bytes=0-1")
+	w := httptest.NewRecorder()
+	h.GetObject(w, r, "photos", "big.bin")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "<Code>InvalidRequest</Code>")
+}
+
+func TestSetResponseOverrides(t *testing.T) {
+	r := httptest.NewRequest("GET", "/photos/cat.jpg?response-content-type=image%2Fpng"+
+		"&response-content-disposition=attachment%3B%20filename%3D%22cat.png%22"+
+		"&response-cache-control=no-cache&response-expires=&x-id=GetObject", nil)
+	w := httptest.NewRecorder()
+	w.Header().Set("Content-Type", "image/jpeg")
+	w.Header().Set("Expires", "Thu, 01 Jan 2026 00:00:00 GMT")
+
+	setResponseOverrides(w, r)
+
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="cat.png"`, w.Header().Get("Content-Disposition"))
+	assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+	assert.Equal(t, "Thu, 01 Jan 2026 00:00:00 GMT", w.Header().Get("Expires"), "empty overrides are ignored")
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+}
+
+// fakeRestorer restores blobs in memory. Restores stay ongoing until
+// complete is called. While hot is set, blobs not restored yet are in the
+// hot tier.
+type fakeRestorer struct {
+	restores map[string]*tiering.RestoreStatus
+	hot      bool
+}
+
+func (f *fakeRestorer) Restore(ctx context.Context, contentHash string, days int) (tiering.RestoreStatus, error) {
+	if restore, ok := f.restores[contentHash]; ok {
+		if restore.Ongoing {
+			return *restore, tiering.ErrRestoreInProgress
+		}
+		restore.Days = days
+		return *restore, nil
+	}
+	if f.hot {
+		return tiering.RestoreStatus{}, tiering.ErrNotArchived
+	}
+	f.restores[contentHash] = &tiering.RestoreStatus{ContentHash: contentHash, ArchiveTier: tiering.TierCold, Days: days, Ongoing: true}
+	return *f.restores[contentHash], nil
+}
+
+func (f *fakeRestorer) GetRestoreStatus(contentHash string) (*tiering.RestoreStatus, bool) {
+	restore, ok := f.restores[contentHash]
+	return restore, ok
+}
+
+func (f *fakeRestorer) complete(expiresAt time.Time) {
+	for _, restore := range f.restores {
+		restore.Ongoing = false
+		restore.ExpiresAt = expiresAt
+	}
+}
+
+func TestObjectHandler_RestoreObject(t *testing.T) {
+	h := newObjectHandlerFixture(t)
+
+	restore := func(key, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/photos/"+key+"?restore", strings.NewReader(body))
+		// The fixture's owner is the first user
+		r = r.WithContext(context.WithValue(r.Context(), auth.AuthContextKey, &auth.AuthContext{UserID: 1, Username: "owner"}))
+		w := httptest.NewRecorder()
+		h.RestoreObject(w, r, "photos", key)
+		return w
+	}
+	head := func(key string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.HeadObject(w, httptest.NewRequest(http.MethodHead, "/photos/"+key, nil), "photos", key)
+		return w
+	}
+	const oneDay = "<RestoreRequest><Days>1</Days></RestoreRequest>"
+
+	// Without the tiering controller restores are not implemented
+	w := restore("small.txt", oneDay)
+	assert.Equal(t, http.StatusNotImplemented, w.Code)
+	assert.Contains(t, w.Body.String(), "<Code>NotImplemented</Code>")
+
+	restorer := &fakeRestorer{restores: map[string]*tiering.RestoreStatus{}}
+	h.objectService.SetRestorer(restorer)
+
+	w = restore("small.txt", "<RestoreRequest>")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "<Code>MalformedXML</Code>")
+	w = restore("small.txt", "<RestoreRequest><Days>0</Days></RestoreRequest>")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "<Code>InvalidArgument</Code>")
+	w = restore("missing.txt", oneDay)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	restorer.hot = true
+	w = restore("small.txt", oneDay)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "<Code>InvalidObjectState</Code>")
+	restorer.hot = false
+
+	// A new restore is accepted and reported as ongoing
+	w = restore("small.txt", oneDay)
+	assert.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	assert.Equal(t, `ongoing-request="true"`, head("small.txt").Header().Get("x-amz-restore"))
+	w = restore("small.txt", oneDay)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "<Code>RestoreAlreadyInProgress</Code>")
+
+	// Once the copy is done, HEAD and GET report the expiry and a new
+	// request only moves it
+	restorer.complete(time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC))
+	const restored = `ongoing-request="false", expiry-date="Sat, 17 Oct 2026 00:00:00 GMT"`
+	assert.Equal(t, restored, head("small.txt").Header().Get("x-amz-restore"))
+	get := httptest.NewRecorder()
+	h.GetObject(get, httptest.NewRequest(http.MethodGet, "/photos/small.txt", nil), "photos", "small.txt")
+	assert.Equal(t, restored, get.Header().Get("x-amz-restore"))
+	w = restore("small.txt", "<RestoreRequest><Days>5</Days></RestoreRequest>")
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	assert.Empty(t, head("big.bin").Header().Get("x-amz-restore"))
+}
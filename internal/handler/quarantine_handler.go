@@ -0,0 +1,206 @@
+// Package handler provides HTTP handlers for Alexander Storage API.
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/service"
+)
+
+const (
+	// QuarantinePath is the path of the upload quarantine API.
+	QuarantinePath = "/_alexander/quarantine"
+
+	// StagingIDHeader carries the staging ID of an upload to a quarantine
+	// bucket, which is held back until promoted through the quarantine API.
+	StagingIDHeader = "x-alexander-staging-id"
+)
+
+// QuarantineHandler serves the upload quarantine API, through which an
+// approver or scanner lists staged uploads, reads their content and promotes
+// or rejects them. Like the manifest API it uses a dedicated bearer token.
+type QuarantineHandler struct {
+	quarantineService *service.QuarantineService
+	token             string
+	logger            zerolog.Logger
+}
+
+// NewQuarantineHandler creates a new QuarantineHandler.
+func NewQuarantineHandler(quarantineService *service.QuarantineService, token string, logger zerolog.Logger) *QuarantineHandler {
+	return &QuarantineHandler{
+		quarantineService: quarantineService,
+		token:             token,
+		logger:            logger.With().Str("handler", "quarantine").Logger(),
+	}
+}
+
+// stagedUploadEntry is a staged upload in the JSON responses.
+type stagedUploadEntry struct {
+	ID          string            `json:"id"`
+	Bucket      string            `json:"bucket"`
+	Key         string            `json:"key"`
+	Size        int64             `json:"size"`
+	ContentType string            `json:"content_type"`
+	ETag        string            `json:"etag"`
+	ContentHash string            `json:"content_hash"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+}
+
+// promoteResponse is the JSON body of a successful promotion.
+type promoteResponse struct {
+	ID        string `json:"id"`
+	Key       string `json:"key"`
+	VersionID string `json:"version_id,omitempty"`
+	Sequence  int64  `json:"sequence"`
+}
+
+// HandleQuarantine handles the quarantine API:
+//
+//	GET  /_alexander/quarantine?bucket=B&limit=N      lists staged uploads
+//	GET  /_alexander/quarantine?id=X                  streams a staged upload's content
+//	POST /_alexander/quarantine?id=X&action=promote   makes the upload visible
+//	POST /_alexander/quarantine?id=X&action=reject    discards the upload
+func (h *QuarantineHandler) HandleQuarantine(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.Header().Set("Allow", "GET, POST")
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	if !bearerAuthorized(r, h.token) {
+		h.logger.Warn().Str("remote_addr", r.RemoteAddr).Msg("rejected quarantine request with invalid token")
+		w.Header().Set("WWW-Authenticate", `Bearer realm="alexander-quarantine"`)
+		writeJSONError(w, http.StatusUnauthorized, "invalid or missing bearer token")
+		return
+	}
+
+	query := r.URL.Query()
+	id := query.Get("id")
+
+	switch {
+	case r.Method == http.MethodGet && id == "":
+		h.list(w, r)
+	case r.Method == http.MethodGet:
+		h.content(w, r, id)
+	case id == "":
+		writeJSONError(w, http.StatusBadRequest, "id is required")
+	case query.Get("action") == "promote":
+		h.promote(w, r, id)
+	case query.Get("action") == "reject":
+		h.reject(w, r, id)
+	default:
+		writeJSONError(w, http.StatusBadRequest, "action must be promote or reject")
+	}
+}
+
+// list writes the staged uploads of one or all buckets.
+func (h *QuarantineHandler) list(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit := 1000
+	if v := query.Get("limit"); v != "" {
+		var err error
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > 1000 {
+			writeJSONError(w, http.StatusBadRequest, "limit must be between 1 and 1000")
+			return
+		}
+	}
+
+	staged, err := h.quarantineService.ListStaged(r.Context(), query.Get("bucket"), limit)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	entries := make([]stagedUploadEntry, len(staged))
+	for i, s := range staged {
+		entries[i] = stagedUploadEntry{
+			ID:          s.ID.String(),
+			Bucket:      s.BucketName,
+			Key:         s.Key,
+			Size:        s.Size,
+			ContentType: s.ContentType,
+			ETag:        s.ETag,
+			ContentHash: s.ContentHash,
+			Metadata:    s.Metadata,
+			CreatedAt:   s.CreatedAt,
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"uploads": entries})
+}
+
+// content streams the content of a staged upload.
+func (h *QuarantineHandler) content(w http.ResponseWriter, r *http.Request, id string) {
+	staged, reader, err := h.quarantineService.GetStaged(r.Context(), id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	defer reader.Close()
+
+	w.Header().Set("Content-Type", staged.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(staged.Size, 10))
+	w.Header().Set("ETag", staged.ETag)
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, reader); err != nil {
+		h.logger.Debug().Err(err).Str("staging_id", id).Msg("staged upload download interrupted")
+	}
+}
+
+// promote makes a staged upload visible.
+func (h *QuarantineHandler) promote(w http.ResponseWriter, r *http.Request, id string) {
+	obj, err := h.quarantineService.Promote(r.Context(), id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	resp := promoteResponse{ID: id, Key: obj.Key, Sequence: obj.Sequence}
+	if v := obj.GetVersionIDString(); v != "null" {
+		resp.VersionID = v
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// reject discards a staged upload.
+func (h *QuarantineHandler) reject(w http.ResponseWriter, r *http.Request, id string) {
+	if err := h.quarantineService.Reject(r.Context(), id); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeError maps a quarantine service error to a JSON error response.
+func (h *QuarantineHandler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrStagedObjectNotFound):
+		writeJSONError(w, http.StatusNotFound, "staged upload not found")
+	case errors.Is(err, domain.ErrBucketNotFound):
+		writeJSONError(w, http.StatusNotFound, "bucket not found")
+	case errors.Is(err, domain.ErrBucketReadOnly), errors.Is(err, domain.ErrServiceReadOnly):
+		writeJSONError(w, http.StatusServiceUnavailable, err.Error())
+	default:
+		h.logger.Error().Err(err).Msg("quarantine request failed")
+		writeJSONError(w, http.StatusInternalServerError, "internal error")
+	}
+}
+
+// writeJSON writes a JSON response for the non-S3 APIs.
+func writeJSON(w http.ResponseWriter, statusCode int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(body)
+}
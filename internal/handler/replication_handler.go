@@ -0,0 +1,251 @@
+// Package handler provides HTTP handlers for Alexander Storage API.
+package handler
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/auth"
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/service"
+)
+
+// ReplicationHandler handles bucket replication configuration requests.
+type ReplicationHandler struct {
+	replicationService *service.ReplicationService
+	logger             zerolog.Logger
+}
+
+// NewReplicationHandler creates a new ReplicationHandler.
+func NewReplicationHandler(replicationService *service.ReplicationService, logger zerolog.Logger) *ReplicationHandler {
+	return &ReplicationHandler{
+		replicationService: replicationService,
+		logger:             logger.With().Str("handler", "replication").Logger(),
+	}
+}
+
+// =============================================================================
+// XML Request/Response Types
+// =============================================================================
+
+// ReplicationConfiguration is the request/response for bucket replication.
+// The destination's endpoint and credentials are extension elements of
+// Destination, since there is no IAM role to assume; SecretAccessKey is
+// never returned.
+type ReplicationConfiguration struct {
+	XMLName xml.Name          `xml:"ReplicationConfiguration"`
+	Xmlns   string            `xml:"xmlns,attr,omitempty"`
+	Role    string            `xml:"Role,omitempty"`
+	Rules   []ReplicationRule `xml:"Rule"`
+}
+
+// ReplicationRule is a rule of a ReplicationConfiguration.
+type ReplicationRule struct {
+	ID                      string                   `xml:"ID"`
+	Status                  string                   `xml:"Status"`
+	Priority                int                      `xml:"Priority,omitempty"`
+	Prefix                  *string                  `xml:"Prefix"`
+	Filter                  *ReplicationFilter       `xml:"Filter"`
+	DeleteMarkerReplication *DeleteMarkerReplication `xml:"DeleteMarkerReplication"`
+	Destination             ReplicationDestination   `xml:"Destination"`
+}
+
+// ReplicationFilter selects the keys of a rule.
+type ReplicationFilter struct {
+	Prefix string `xml:"Prefix"`
+}
+
+// DeleteMarkerReplication enables replication of delete markers.
+type DeleteMarkerReplication struct {
+	Status string `xml:"Status"`
+}
+
+// ReplicationDestination is the bucket versions are copied to.
+type ReplicationDestination struct {
+	Bucket          string `xml:"Bucket"`
+	Endpoint        string `xml:"Endpoint"`
+	Region          string `xml:"Region,omitempty"`
+	AccessKeyID     string `xml:"AccessKeyId"`
+	SecretAccessKey string `xml:"SecretAccessKey,omitempty"`
+}
+
+// =============================================================================
+// Handler Methods
+// =============================================================================
+
+// GetBucketReplication handles GET /{bucket}?replication requests.
+func (h *ReplicationHandler) GetBucketReplication(w http.ResponseWriter, r *http.Request, bucketName string) {
+	ctx := r.Context()
+
+	userCtx, ok := auth.GetUserContext(ctx)
+	if !ok {
+		h.logger.Error().Msg("no user context found")
+		writeError(w, ErrAccessDenied)
+		return
+	}
+
+	config, err := h.replicationService.GetBucketReplication(ctx, bucketName, userCtx.UserID)
+	if err != nil {
+		h.handleError(w, err, bucketName)
+		return
+	}
+
+	response := ReplicationConfiguration{
+		Xmlns: "http://s3.amazonaws.com/doc/2006-03-01/",
+	}
+	for _, rule := range config.Rules {
+		xmlRule := ReplicationRule{
+			ID:                      rule.ID,
+			Status:                  "Disabled",
+			Priority:                rule.Priority,
+			Filter:                  &ReplicationFilter{Prefix: rule.Prefix},
+			DeleteMarkerReplication: &DeleteMarkerReplication{Status: "Disabled"},
+			Destination: ReplicationDestination{
+				Bucket:      "arn:aws:s3:::" + config.DestinationBucket,
+				Endpoint:    config.Endpoint,
+				Region:      config.Region,
+				AccessKeyID: config.AccessKeyID,
+			},
+		}
+		if rule.Enabled {
+			xmlRule.Status = "Enabled"
+		}
+		if rule.DeleteMarkers {
+			xmlRule.DeleteMarkerReplication.Status = "Enabled"
+		}
+		response.Rules = append(response.Rules, xmlRule)
+	}
+
+	writeXML(w, http.StatusOK, response)
+}
+
+// PutBucketReplication handles PUT /{bucket}?replication requests.
+// All rules must share one destination.
+func (h *ReplicationHandler) PutBucketReplication(w http.ResponseWriter, r *http.Request, bucketName string) {
+	ctx := r.Context()
+
+	userCtx, ok := auth.GetUserContext(ctx)
+	if !ok {
+		h.logger.Error().Msg("no user context found")
+		writeError(w, ErrAccessDenied)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1024*1024)) // 1MB limit
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to read request body")
+		writeError(w, ErrInternalError)
+		return
+	}
+	defer r.Body.Close()
+
+	var request ReplicationConfiguration
+	if err := xml.Unmarshal(body, &request); err != nil || len(request.Rules) == 0 {
+		writeError(w, ErrMalformedXML)
+		return
+	}
+
+	config, secretKey, err := parseReplicationConfiguration(&request)
+	if err != nil {
+		h.handleError(w, err, bucketName)
+		return
+	}
+
+	err = h.replicationService.PutBucketReplication(ctx, service.PutBucketReplicationInput{
+		BucketName: bucketName,
+		OwnerID:    userCtx.UserID,
+		Config:     config,
+		SecretKey:  secretKey,
+	})
+	if err != nil {
+		h.handleError(w, err, bucketName)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// DeleteBucketReplication handles DELETE /{bucket}?replication requests.
+func (h *ReplicationHandler) DeleteBucketReplication(w http.ResponseWriter, r *http.Request, bucketName string) {
+	ctx := r.Context()
+
+	userCtx, ok := auth.GetUserContext(ctx)
+	if !ok {
+		h.logger.Error().Msg("no user context found")
+		writeError(w, ErrAccessDenied)
+		return
+	}
+
+	err := h.replicationService.DeleteBucketReplication(ctx, bucketName, userCtx.UserID)
+	if err != nil && !errors.Is(err, domain.ErrReplicationConfigNotFound) {
+		h.handleError(w, err, bucketName)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseReplicationConfiguration converts a request to a domain
+// configuration and the destination secret key.
+func parseReplicationConfiguration(request *ReplicationConfiguration) (*domain.ReplicationConfig, string, error) {
+	destination := request.Rules[0].Destination
+	config := &domain.ReplicationConfig{
+		Endpoint:          destination.Endpoint,
+		Region:            destination.Region,
+		DestinationBucket: strings.TrimPrefix(destination.Bucket, "arn:aws:s3:::"),
+		AccessKeyID:       destination.AccessKeyID,
+	}
+	secretKey := destination.SecretAccessKey
+
+	for _, xmlRule := range request.Rules {
+		dest := xmlRule.Destination
+		if dest.Bucket != destination.Bucket || dest.Endpoint != destination.Endpoint ||
+			dest.Region != destination.Region || dest.AccessKeyID != destination.AccessKeyID {
+			return nil, "", fmt.Errorf("%w: all rules must have the same destination", domain.ErrInvalidReplicationConfig)
+		}
+		if dest.SecretAccessKey != "" {
+			secretKey = dest.SecretAccessKey
+		}
+
+		rule := domain.ReplicationRule{
+			ID:       xmlRule.ID,
+			Priority: xmlRule.Priority,
+		}
+		switch xmlRule.Status {
+		case "Enabled":
+			rule.Enabled = true
+		case "Disabled":
+		default:
+			return nil, "", fmt.Errorf("%w: rule status must be Enabled or Disabled", domain.ErrInvalidReplicationConfig)
+		}
+		switch {
+		case xmlRule.Filter != nil:
+			rule.Prefix = xmlRule.Filter.Prefix
+		case xmlRule.Prefix != nil:
+			rule.Prefix = *xmlRule.Prefix
+		}
+		if xmlRule.DeleteMarkerReplication != nil {
+			rule.DeleteMarkers = xmlRule.DeleteMarkerReplication.Status == "Enabled"
+		}
+		config.Rules = append(config.Rules, rule)
+	}
+
+	return config, secretKey, nil
+}
+
+// handleError converts service errors to S3 errors.
+func (h *ReplicationHandler) handleError(w http.ResponseWriter, err error, bucketName string) {
+	s3Err, ok := s3ErrorFor(err)
+	if !ok {
+		h.logger.Error().Err(err).Str("bucket", bucketName).Msg("unhandled error")
+	}
+
+	s3Err.Resource = "/" + bucketName
+	writeError(w, s3Err)
+}
@@ -3,6 +3,7 @@ package handler
 
 import (
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/rs/zerolog"
@@ -10,16 +11,45 @@ import (
 	"github.com/prn-tf/alexander-storage/internal/auth"
 	"github.com/prn-tf/alexander-storage/internal/metrics"
 	"github.com/prn-tf/alexander-storage/internal/middleware"
+	"github.com/prn-tf/alexander-storage/internal/repository"
 )
 
+// ListFromPrimaryHeader set to "true" makes the request's listings read from
+// the primary database even when a read replica is configured, so they
+// include every write acknowledged before the request.
+const ListFromPrimaryHeader = "x-alexander-list-from-primary"
+
 // Router handles HTTP routing for the S3-compatible API.
 type Router struct {
 	bucketHandler     *BucketHandler
 	objectHandler     *ObjectHandler
 	multipartHandler  *MultipartHandler
+	eventHandler      *EventHandler
+	replication       *ReplicationHandler
+	inventory         *InventoryHandler
+	website           *WebsiteHandler
+	websiteHosts      bool
+	lifecycle         *LifecycleHandler
 	healthChecker     *HealthChecker
+	capabilities      *CapabilitiesHandler
+	manifestHandler   *ManifestHandler
+	manifestLimiter   *middleware.RateLimiter
+	quarantine        *QuarantineHandler
+	batch             *BatchHandler
+	snapshot          *SnapshotHandler
+	keys              *KeysHandler
+	usageHandler      *UsageHandler
+	adminHandler      *AdminHandler
+	metering          *middleware.Metering
+	audit             *middleware.Audit
+	accessLog         *middleware.AccessLog
 	authMiddleware    func(http.Handler) http.Handler
+	bodyLimit         *middleware.BodyLimit
 	rateLimiter       *middleware.RateLimiter
+	tenantLimiter     *middleware.TenantRateLimiter
+	connLimiter       *middleware.ConnLimiter
+	loadShedder       *middleware.LoadShedder
+	recovery          *middleware.Recovery
 	tracing           *middleware.Tracing
 	metricsMiddleware *middleware.MetricsMiddleware
 	metrics           *metrics.Metrics
@@ -28,15 +58,38 @@ type Router struct {
 
 // RouterConfig contains configuration for the router.
 type RouterConfig struct {
-	BucketHandler    *BucketHandler
-	ObjectHandler    *ObjectHandler
-	MultipartHandler *MultipartHandler
-	HealthChecker    *HealthChecker
-	AuthMiddleware   func(http.Handler) http.Handler
-	RateLimiter      *middleware.RateLimiter
-	Tracing          *middleware.Tracing
-	Metrics          *metrics.Metrics
-	Logger           zerolog.Logger
+	BucketHandler      *BucketHandler
+	ObjectHandler      *ObjectHandler
+	MultipartHandler   *MultipartHandler
+	EventHandler       *EventHandler       // Optional; nil disables the watch API
+	ReplicationHandler *ReplicationHandler // Optional; nil disables bucket replication
+	InventoryHandler   *InventoryHandler   // Optional; nil disables bucket inventory
+	WebsiteHandler     *WebsiteHandler     // Optional; nil disables bucket websites
+	WebsiteHosts       bool                // Serve website endpoints on the S3 port, by host suffix
+	LifecycleHandler   *LifecycleHandler   // Optional; nil disables the lifecycle configuration API
+	HealthChecker      *HealthChecker
+	Capabilities       *CapabilitiesHandler
+	ManifestHandler    *ManifestHandler
+	ManifestLimiter    *middleware.RateLimiter
+	QuarantineHandler  *QuarantineHandler // Optional; nil disables the quarantine API
+	BatchHandler       *BatchHandler      // Optional; nil disables the batch API
+	SnapshotHandler    *SnapshotHandler   // Optional; nil disables bucket snapshots
+	KeysHandler        *KeysHandler       // Optional; nil disables self-service access keys
+	UsageHandler       *UsageHandler
+	AdminHandler       *AdminHandler // Optional; nil when the admin API is disabled or has its own port
+	Metering           *middleware.Metering
+	Audit              *middleware.Audit     // Optional; nil disables the audit log
+	AccessLog          *middleware.AccessLog // Optional; nil disables the access log
+	AuthMiddleware     func(http.Handler) http.Handler
+	BodyLimit          *middleware.BodyLimit // Optional; nil leaves request bodies unlimited
+	RateLimiter        *middleware.RateLimiter
+	TenantRateLimiter  *middleware.TenantRateLimiter // Optional; nil disables per-tenant rate limits
+	ConnLimiter        *middleware.ConnLimiter       // Optional; nil disables per-connection limits
+	LoadShedder        *middleware.LoadShedder       // Optional; nil serves any number of requests at once
+	Recovery           *middleware.Recovery          // Optional; nil leaves panics to net/http
+	Tracing            *middleware.Tracing
+	Metrics            *metrics.Metrics
+	Logger             zerolog.Logger
 }
 
 // NewRouter creates a new Router.
@@ -50,9 +103,32 @@ func NewRouter(config RouterConfig) *Router {
 		bucketHandler:     config.BucketHandler,
 		objectHandler:     config.ObjectHandler,
 		multipartHandler:  config.MultipartHandler,
+		eventHandler:      config.EventHandler,
+		replication:       config.ReplicationHandler,
+		inventory:         config.InventoryHandler,
+		website:           config.WebsiteHandler,
+		websiteHosts:      config.WebsiteHosts,
+		lifecycle:         config.LifecycleHandler,
 		healthChecker:     config.HealthChecker,
+		capabilities:      config.Capabilities,
+		manifestHandler:   config.ManifestHandler,
+		manifestLimiter:   config.ManifestLimiter,
+		quarantine:        config.QuarantineHandler,
+		batch:             config.BatchHandler,
+		snapshot:          config.SnapshotHandler,
+		keys:              config.KeysHandler,
+		usageHandler:      config.UsageHandler,
+		adminHandler:      config.AdminHandler,
+		metering:          config.Metering,
+		audit:             config.Audit,
+		accessLog:         config.AccessLog,
 		authMiddleware:    config.AuthMiddleware,
+		bodyLimit:         config.BodyLimit,
 		rateLimiter:       config.RateLimiter,
+		tenantLimiter:     config.TenantRateLimiter,
+		connLimiter:       config.ConnLimiter,
+		loadShedder:       config.LoadShedder,
+		recovery:          config.Recovery,
 		tracing:           config.Tracing,
 		metricsMiddleware: metricsMiddleware,
 		metrics:           config.Metrics,
@@ -67,31 +143,131 @@ func (rt *Router) Handler() http.Handler {
 	// Health check endpoints (no auth, no rate limiting)
 	if rt.healthChecker != nil {
 		mux.HandleFunc("/health", rt.healthChecker.HandleHealth)
-		mux.HandleFunc("/healthz", rt.healthChecker.HandleLiveness)
+		mux.HandleFunc("/livez", rt.healthChecker.HandleLiveness)
 		mux.HandleFunc("/readyz", rt.healthChecker.HandleReadiness)
+		mux.HandleFunc("/healthz", rt.healthChecker.HandleReadiness)
 	} else {
 		mux.HandleFunc("/health", rt.handleHealth)
 	}
 
-	// Main S3 API handler
-	mux.HandleFunc("/", rt.handleS3Request)
+	// Capability discovery (no auth, so clients can adapt before signing requests)
+	if rt.capabilities != nil {
+		mux.HandleFunc(CapabilitiesPath, rt.capabilities.HandleCapabilities)
+	}
+
+	// Blob manifest API (bearer token auth, own rate limit)
+	if rt.manifestHandler != nil {
+		var manifest http.Handler = http.HandlerFunc(rt.manifestHandler.HandleManifest)
+		if rt.manifestLimiter != nil {
+			manifest = rt.manifestLimiter.Middleware(manifest)
+		}
+		mux.Handle(ManifestPath, manifest)
+	}
+
+	// Upload quarantine API (bearer token auth)
+	if rt.quarantine != nil {
+		mux.HandleFunc(QuarantinePath, rt.quarantine.HandleQuarantine)
+	}
+
+	// Batch commit API (SigV4 auth)
+	if rt.batch != nil {
+		mux.HandleFunc(BatchPath, rt.batch.HandleBatch)
+	}
+
+	// Bucket snapshot API (SigV4 auth)
+	if rt.snapshot != nil {
+		mux.HandleFunc(SnapshotPath, rt.snapshot.HandleSnapshot)
+	}
+
+	// Self-service access key API (SigV4 auth)
+	if rt.keys != nil {
+		mux.HandleFunc(KeysPath, rt.keys.HandleKeys)
+	}
+
+	// Usage report admin API (SigV4 auth, admin users only)
+	if rt.usageHandler != nil {
+		mux.HandleFunc(UsagePath, rt.usageHandler.HandleUsage)
+	}
+
+	// Admin REST API (bearer token auth)
+	if rt.adminHandler != nil {
+		mux.Handle(AdminPathPrefix, rt.adminHandler)
+	}
+
+	// Main S3 API handler, metered per bucket and access key
+	var s3Handler http.Handler = http.HandlerFunc(rt.handleS3Request)
+	if rt.metering != nil {
+		s3Handler = rt.metering.Middleware(s3Handler)
+	}
+	mux.Handle("/", s3Handler)
 
 	// Build middleware chain (innermost to outermost)
 	var handler http.Handler = mux
 
+	// Per-tenant rate limiting (inside auth, where the tenant is known)
+	if rt.tenantLimiter != nil {
+		handler = rt.tenantLimiter.Middleware(handler)
+	}
+
+	// Audit actor capture (inside auth, where the user is known)
+	if rt.audit != nil {
+		handler = rt.audit.Actor(handler)
+	}
+
+	// Access log key capture (inside auth, where the access key is known)
+	if rt.accessLog != nil {
+		handler = rt.accessLog.Actor(handler)
+	}
+
 	// Auth middleware (innermost - after tracing, before rate limiting)
 	handler = rt.authMiddleware(handler)
 
+	// Website endpoints sharing the S3 port are anonymous
+	if rt.website != nil && rt.websiteHosts {
+		handler = rt.website.Middleware(handler)
+	}
+
+	// Request body limits (before auth reads the body for signing)
+	if rt.bodyLimit != nil {
+		handler = rt.bodyLimit.Middleware(handler)
+	}
+
+	// Audit log (outside auth, so rejected writes are recorded too)
+	if rt.audit != nil {
+		handler = rt.audit.Middleware(handler)
+	}
+
 	// Rate limiting middleware
 	if rt.rateLimiter != nil {
 		handler = rt.rateLimiter.Middleware(handler)
 	}
 
+	// Per-connection request limit
+	if rt.connLimiter != nil {
+		handler = rt.connLimiter.Middleware(handler)
+	}
+
+	// Load shedding (before authentication and the body are processed)
+	if rt.loadShedder != nil {
+		handler = rt.loadShedder.Middleware(handler)
+	}
+
 	// Metrics middleware (track in-flight requests)
 	if rt.metricsMiddleware != nil {
 		handler = rt.metricsMiddleware.Middleware(handler)
 	}
 
+	// Panic recovery (inside the access log and tracing, which record the
+	// InternalError it responds with)
+	if rt.recovery != nil {
+		handler = rt.recovery.Middleware(handler)
+	}
+
+	// Access log (inside tracing, where the request ID is known)
+	if rt.accessLog != nil {
+		handler = rt.accessLog.Middleware(handler)
+	}
+
 	// Tracing middleware (outermost - first to execute)
 	if rt.tracing != nil {
 		handler = rt.tracing.Middleware(handler)
@@ -109,6 +285,13 @@ func (rt *Router) handleHealth(w http.ResponseWriter, r *http.Request) {
 
 // handleS3Request routes S3 API requests to appropriate handlers.
 func (rt *Router) handleS3Request(w http.ResponseWriter, r *http.Request) {
+	// Listings may be served by a read replica unless the client asks for the primary
+	readPreference := repository.ReadReplica
+	if fromPrimary, _ := strconv.ParseBool(r.Header.Get(ListFromPrimaryHeader)); fromPrimary {
+		readPreference = repository.ReadPrimary
+	}
+	r = r.WithContext(repository.WithReadPreference(r.Context(), readPreference))
+
 	path := r.URL.Path
 	query := r.URL.Query()
 
@@ -135,9 +318,11 @@ func (rt *Router) handleS3Request(w http.ResponseWriter, r *http.Request) {
 	if len(parts) > 1 {
 		objectKey = parts[1]
 	}
+	r = r.WithContext(middleware.WithBucket(r.Context(), bucketName))
 
 	// Object operations (when key is present)
 	if objectKey != "" {
+		r = r.WithContext(middleware.WithObjectKey(r.Context(), objectKey))
 		rt.handleObjectRequest(w, r, bucketName, objectKey)
 		return
 	}
@@ -165,6 +350,20 @@ func (rt *Router) handleBucketRequest(w http.ResponseWriter, r *http.Request, bu
 		return
 	}
 
+	// Check for location sub-resource (GetBucketLocation)
+	if _, ok := query["location"]; ok {
+		if r.Method == http.MethodGet {
+			rt.bucketHandler.GetBucketLocation(w, r)
+			return
+		}
+		writeError(w, S3Error{
+			Code:           "MethodNotAllowed",
+			Message:        "The specified method is not allowed against this resource.",
+			HTTPStatusCode: http.StatusMethodNotAllowed,
+		})
+		return
+	}
+
 	// Check for versions sub-resource (ListObjectVersions)
 	if _, ok := query["versions"]; ok {
 		if r.Method == http.MethodGet {
@@ -193,7 +392,175 @@ func (rt *Router) handleBucketRequest(w http.ResponseWriter, r *http.Request, bu
 		return
 	}
 
-	// TODO: Add more sub-resources (lifecycle, policy, acl, etc.)
+	// Watch sub-resource streams object change events
+	if _, ok := query["watch"]; ok {
+		if rt.eventHandler == nil {
+			s3Err := ErrNotImplemented
+			s3Err.Resource = "/" + bucketName
+			writeError(w, s3Err)
+			return
+		}
+		if r.Method == http.MethodGet {
+			rt.eventHandler.Watch(w, r, bucketName)
+			return
+		}
+		writeError(w, S3Error{
+			Code:           "MethodNotAllowed",
+			Message:        "The specified method is not allowed against this resource.",
+			HTTPStatusCode: http.StatusMethodNotAllowed,
+		})
+		return
+	}
+
+	// Inventory sub-resource configures scheduled object listings; without
+	// an id, GET lists the configurations
+	if _, ok := query["inventory"]; ok {
+		if rt.inventory == nil {
+			s3Err := ErrNotImplemented
+			s3Err.Resource = "/" + bucketName
+			writeError(w, s3Err)
+			return
+		}
+		_, hasID := query["id"]
+		switch {
+		case r.Method == http.MethodGet && !hasID:
+			rt.inventory.ListBucketInventoryConfigurations(w, r, bucketName)
+		case r.Method == http.MethodGet:
+			rt.inventory.GetBucketInventoryConfiguration(w, r, bucketName)
+		case r.Method == http.MethodPut:
+			rt.inventory.PutBucketInventoryConfiguration(w, r, bucketName)
+		case r.Method == http.MethodDelete:
+			rt.inventory.DeleteBucketInventoryConfiguration(w, r, bucketName)
+		default:
+			writeError(w, S3Error{
+				Code:           "MethodNotAllowed",
+				Message:        "The specified method is not allowed against this resource.",
+				HTTPStatusCode: http.StatusMethodNotAllowed,
+			})
+		}
+		return
+	}
+
+	// Website sub-resource configures static website hosting
+	if _, ok := query["website"]; ok {
+		if rt.website == nil {
+			s3Err := ErrNotImplemented
+			s3Err.Resource = "/" + bucketName
+			writeError(w, s3Err)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			rt.website.GetBucketWebsite(w, r, bucketName)
+		case http.MethodPut:
+			rt.website.PutBucketWebsite(w, r, bucketName)
+		case http.MethodDelete:
+			rt.website.DeleteBucketWebsite(w, r, bucketName)
+		default:
+			writeError(w, S3Error{
+				Code:           "MethodNotAllowed",
+				Message:        "The specified method is not allowed against this resource.",
+				HTTPStatusCode: http.StatusMethodNotAllowed,
+			})
+		}
+		return
+	}
+
+	// Replication sub-resource configures copying to a remote endpoint
+	if _, ok := query["replication"]; ok {
+		if rt.replication == nil {
+			s3Err := ErrNotImplemented
+			s3Err.Resource = "/" + bucketName
+			writeError(w, s3Err)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			rt.replication.GetBucketReplication(w, r, bucketName)
+		case http.MethodPut:
+			rt.replication.PutBucketReplication(w, r, bucketName)
+		case http.MethodDelete:
+			rt.replication.DeleteBucketReplication(w, r, bucketName)
+		default:
+			writeError(w, S3Error{
+				Code:           "MethodNotAllowed",
+				Message:        "The specified method is not allowed against this resource.",
+				HTTPStatusCode: http.StatusMethodNotAllowed,
+			})
+		}
+		return
+	}
+
+	// Transfer acceleration is not supported; answer explicitly instead of listing objects
+	if _, ok := query["accelerate"]; ok {
+		s3Err := ErrNotImplemented
+		s3Err.Resource = "/" + bucketName
+		writeError(w, s3Err)
+		return
+	}
+
+	// Check for acl sub-resource (canned ACLs only)
+	if _, ok := query["acl"]; ok {
+		switch r.Method {
+		case http.MethodGet:
+			rt.bucketHandler.GetBucketACL(w, r)
+		case http.MethodPut:
+			rt.bucketHandler.PutBucketACL(w, r)
+		default:
+			writeError(w, S3Error{
+				Code:           "MethodNotAllowed",
+				Message:        "The specified method is not allowed against this resource.",
+				HTTPStatusCode: http.StatusMethodNotAllowed,
+			})
+		}
+		return
+	}
+
+	// Tagging sub-resource holds the bucket's tag set
+	if _, ok := query["tagging"]; ok {
+		switch r.Method {
+		case http.MethodGet:
+			rt.bucketHandler.GetBucketTagging(w, r)
+		case http.MethodPut:
+			rt.bucketHandler.PutBucketTagging(w, r)
+		case http.MethodDelete:
+			rt.bucketHandler.DeleteBucketTagging(w, r)
+		default:
+			writeError(w, S3Error{
+				Code:           "MethodNotAllowed",
+				Message:        "The specified method is not allowed against this resource.",
+				HTTPStatusCode: http.StatusMethodNotAllowed,
+			})
+		}
+		return
+	}
+
+	// Lifecycle sub-resource holds the bucket's expiration rules
+	if _, ok := query["lifecycle"]; ok {
+		if rt.lifecycle == nil {
+			s3Err := ErrNotImplemented
+			s3Err.Resource = "/" + bucketName
+			writeError(w, s3Err)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			rt.lifecycle.GetBucketLifecycleConfiguration(w, r, bucketName)
+		case http.MethodPut:
+			rt.lifecycle.PutBucketLifecycleConfiguration(w, r, bucketName)
+		case http.MethodDelete:
+			rt.lifecycle.DeleteBucketLifecycle(w, r, bucketName)
+		default:
+			writeError(w, S3Error{
+				Code:           "MethodNotAllowed",
+				Message:        "The specified method is not allowed against this resource.",
+				HTTPStatusCode: http.StatusMethodNotAllowed,
+			})
+		}
+		return
+	}
+
+	// TODO: Add more sub-resources (policy, etc.)
 
 	// Basic bucket operations
 	switch r.Method {
@@ -234,10 +601,20 @@ func (rt *Router) handleObjectRequest(w http.ResponseWriter, r *http.Request, bu
 	if uploadID != "" {
 		switch r.Method {
 		case http.MethodPut:
+			if r.Header.Get(ChunkSHA256Header) != "" {
+				// Resumable upload chunk: PUT /{bucket}/{key}?uploadId=X
+				rt.multipartHandler.StageChunk(w, r, bucketName, objectKey)
+				return
+			}
 			// UploadPart: PUT /{bucket}/{key}?partNumber=N&uploadId=X
 			rt.multipartHandler.UploadPart(w, r, bucketName, objectKey)
 			return
 		case http.MethodPost:
+			if r.Header.Get(CommitChunksHeader) != "" {
+				// Resumable upload commit: POST /{bucket}/{key}?uploadId=X
+				rt.multipartHandler.CommitChunks(w, r, bucketName, objectKey)
+				return
+			}
 			// CompleteMultipartUpload: POST /{bucket}/{key}?uploadId=X
 			rt.multipartHandler.CompleteMultipartUpload(w, r, bucketName, objectKey)
 			return
@@ -252,6 +629,35 @@ func (rt *Router) handleObjectRequest(w http.ResponseWriter, r *http.Request, bu
 		}
 	}
 
+	// Object ACL operations
+	if _, ok := query["acl"]; ok {
+		switch r.Method {
+		case http.MethodGet:
+			rt.objectHandler.GetObjectACL(w, r, bucketName, objectKey)
+		case http.MethodPut:
+			rt.objectHandler.PutObjectACL(w, r, bucketName, objectKey)
+		default:
+			writeError(w, S3Error{
+				Code:           "MethodNotAllowed",
+				Message:        "The specified method is not allowed against this resource.",
+				HTTPStatusCode: http.StatusMethodNotAllowed,
+			})
+		}
+		return
+	}
+
+	// RestoreObject: POST /{bucket}/{key}?restore
+	if _, ok := query["restore"]; ok && r.Method == http.MethodPost {
+		rt.objectHandler.RestoreObject(w, r, bucketName, objectKey)
+		return
+	}
+
+	// RenameObject: PUT /{bucket}/{key}?renameObject
+	if _, ok := query["renameObject"]; ok && r.Method == http.MethodPut {
+		rt.objectHandler.RenameObject(w, r, bucketName, objectKey)
+		return
+	}
+
 	// Standard object operations
 	switch r.Method {
 	case http.MethodGet:
@@ -0,0 +1,279 @@
+// Package handler provides HTTP handlers for Alexander Storage API.
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/auth"
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/service"
+)
+
+// SnapshotPath is the path of the bucket snapshot API. Requests are signed
+// with SigV4 like S3 requests; only the bucket owner can use its snapshots.
+const SnapshotPath = "/_alexander/snapshots"
+
+// SnapshotHandler serves the bucket snapshot API, through which a client
+// takes, lists and deletes snapshots and reads the objects they hold.
+type SnapshotHandler struct {
+	snapshotService *service.SnapshotService
+	logger          zerolog.Logger
+}
+
+// NewSnapshotHandler creates a new SnapshotHandler.
+func NewSnapshotHandler(snapshotService *service.SnapshotService, logger zerolog.Logger) *SnapshotHandler {
+	return &SnapshotHandler{
+		snapshotService: snapshotService,
+		logger:          logger.With().Str("handler", "snapshot").Logger(),
+	}
+}
+
+// snapshotResponse is the JSON body describing a snapshot.
+type snapshotResponse struct {
+	Bucket      string     `json:"bucket"`
+	Name        string     `json:"name"`
+	ObjectCount int64      `json:"object_count"`
+	TotalSize   int64      `json:"total_size"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+// snapshotObjectsResponse is the JSON body of a page of snapshot objects.
+type snapshotObjectsResponse struct {
+	Bucket                string                `json:"bucket"`
+	Snapshot              string                `json:"snapshot"`
+	Prefix                string                `json:"prefix,omitempty"`
+	Objects               []snapshotObjectEntry `json:"objects"`
+	IsTruncated           bool                  `json:"is_truncated"`
+	NextContinuationToken string                `json:"next_continuation_token,omitempty"`
+}
+
+// snapshotObjectEntry is a single object in a snapshot listing.
+type snapshotObjectEntry struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	ETag         string    `json:"etag"`
+	LastModified time.Time `json:"last_modified"`
+	StorageClass string    `json:"storage_class"`
+}
+
+// HandleSnapshot handles the snapshot API:
+//
+//	POST   /_alexander/snapshots?bucket=B&name=N[&retain=D]  takes a snapshot
+//	GET    /_alexander/snapshots?bucket=B                    lists snapshots
+//	GET    /_alexander/snapshots?bucket=B&name=N             lists snapshot objects
+//	GET    /_alexander/snapshots?bucket=B&name=N&key=K       reads a snapshot object
+//	DELETE /_alexander/snapshots?bucket=B&name=N             deletes a snapshot
+//
+// retain is a duration such as 720h after which the snapshot is deleted.
+// Listings take prefix, max-keys and continuation-token; reads honor Range.
+func (h *SnapshotHandler) HandleSnapshot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	authCtx := auth.GetAuthContext(r.Context())
+	if authCtx == nil {
+		writeJSONError(w, http.StatusForbidden, "access denied")
+		return
+	}
+
+	query := r.URL.Query()
+	bucket := query.Get("bucket")
+	name := query.Get("name")
+	if bucket == "" {
+		writeJSONError(w, http.StatusBadRequest, "bucket is required")
+		return
+	}
+	if name == "" && r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodPost:
+		h.create(w, r, bucket, name, authCtx.UserID)
+	case r.Method == http.MethodDelete:
+		h.delete(w, r, bucket, name, authCtx.UserID)
+	case name == "":
+		h.list(w, r, bucket, authCtx.UserID)
+	case query.Has("key"):
+		h.getObject(w, r, bucket, name, authCtx.UserID)
+	default:
+		h.listObjects(w, r, bucket, name, authCtx.UserID)
+	}
+}
+
+// create takes a snapshot.
+func (h *SnapshotHandler) create(w http.ResponseWriter, r *http.Request, bucket, name string, ownerID int64) {
+	var retain time.Duration
+	if v := r.URL.Query().Get("retain"); v != "" {
+		var err error
+		retain, err = time.ParseDuration(v)
+		if err != nil || retain <= 0 {
+			writeJSONError(w, http.StatusBadRequest, "retain must be a positive duration")
+			return
+		}
+	}
+
+	snapshot, err := h.snapshotService.Create(r.Context(), bucket, name, ownerID, retain)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, newSnapshotResponse(bucket, snapshot))
+}
+
+// list writes the snapshots of a bucket.
+func (h *SnapshotHandler) list(w http.ResponseWriter, r *http.Request, bucket string, ownerID int64) {
+	snapshots, err := h.snapshotService.List(r.Context(), bucket, ownerID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	entries := make([]snapshotResponse, len(snapshots))
+	for i, snapshot := range snapshots {
+		entries[i] = newSnapshotResponse(bucket, snapshot)
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"bucket": bucket, "snapshots": entries})
+}
+
+// delete deletes a snapshot.
+func (h *SnapshotHandler) delete(w http.ResponseWriter, r *http.Request, bucket, name string, ownerID int64) {
+	if err := h.snapshotService.Delete(r.Context(), bucket, name, ownerID); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listObjects writes a page of the objects of a snapshot.
+func (h *SnapshotHandler) listObjects(w http.ResponseWriter, r *http.Request, bucket, name string, ownerID int64) {
+	query := r.URL.Query()
+	maxKeys := 1000
+	if v := query.Get("max-keys"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeJSONError(w, http.StatusBadRequest, "max-keys must be a positive integer")
+			return
+		}
+		maxKeys = n
+	}
+
+	output, err := h.snapshotService.ListObjects(r.Context(), name, service.ListObjectsInput{
+		BucketName:        bucket,
+		Prefix:            query.Get("prefix"),
+		MaxKeys:           maxKeys,
+		ContinuationToken: query.Get("continuation-token"),
+		OwnerID:           ownerID,
+	})
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	resp := snapshotObjectsResponse{
+		Bucket:                bucket,
+		Snapshot:              name,
+		Prefix:                output.Prefix,
+		Objects:               make([]snapshotObjectEntry, len(output.Contents)),
+		IsTruncated:           output.IsTruncated,
+		NextContinuationToken: output.NextContinuationToken,
+	}
+	for i, obj := range output.Contents {
+		resp.Objects[i] = snapshotObjectEntry{
+			Key:          obj.Key,
+			Size:         obj.Size,
+			ETag:         obj.ETag,
+			LastModified: obj.LastModified,
+			StorageClass: string(obj.StorageClass),
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// getObject streams the content of a snapshot object.
+func (h *SnapshotHandler) getObject(w http.ResponseWriter, r *http.Request, bucket, name string, ownerID int64) {
+	var byteRange *service.ByteRange
+	if rangeHeader := r.Header.Get("Range"); rangeHeader != "" {
+		var err error
+		byteRange, err = parseRangeHeader(rangeHeader)
+		if err != nil {
+			writeJSONError(w, http.StatusRequestedRangeNotSatisfiable, "the requested range is not satisfiable")
+			return
+		}
+	}
+
+	output, err := h.snapshotService.GetObject(r.Context(), name, service.GetObjectInput{
+		BucketName: bucket,
+		Key:        r.URL.Query().Get("key"),
+		OwnerID:    ownerID,
+		Range:      byteRange,
+	})
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	defer output.Body.Close()
+
+	w.Header().Set("Content-Type", output.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(output.ContentLength, 10))
+	w.Header().Set("ETag", output.ETag)
+	w.Header().Set("Last-Modified", output.LastModified.UTC().Format(http.TimeFormat))
+	if output.VersionID != "" && output.VersionID != "null" {
+		w.Header().Set("x-amz-version-id", output.VersionID)
+	}
+	setSequenceHeader(w, output.Sequence)
+	setMetadataHeaders(w, output.Metadata)
+
+	if output.ContentRange != "" {
+		w.Header().Set("Content-Range", output.ContentRange)
+		w.WriteHeader(http.StatusPartialContent)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	io.Copy(w, output.Body)
+}
+
+// writeError maps a snapshot service error to a JSON error response.
+func (h *SnapshotHandler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrBucketNotFound):
+		writeJSONError(w, http.StatusNotFound, "bucket not found")
+	case errors.Is(err, service.ErrBucketAccessDenied):
+		writeJSONError(w, http.StatusForbidden, "access denied")
+	case errors.Is(err, domain.ErrSnapshotNotFound):
+		writeJSONError(w, http.StatusNotFound, "snapshot not found")
+	case errors.Is(err, domain.ErrSnapshotAlreadyExists):
+		writeJSONError(w, http.StatusConflict, "snapshot already exists")
+	case errors.Is(err, domain.ErrInvalidSnapshotName):
+		writeJSONError(w, http.StatusBadRequest, "invalid snapshot name")
+	case errors.Is(err, domain.ErrObjectNotFound):
+		writeJSONError(w, http.StatusNotFound, "object not found")
+	default:
+		h.logger.Error().Err(err).Msg("snapshot request failed")
+		writeJSONError(w, http.StatusInternalServerError, "internal error")
+	}
+}
+
+// newSnapshotResponse converts a snapshot to its JSON body.
+func newSnapshotResponse(bucket string, snapshot *domain.BucketSnapshot) snapshotResponse {
+	return snapshotResponse{
+		Bucket:      bucket,
+		Name:        snapshot.Name,
+		ObjectCount: snapshot.ObjectCount,
+		TotalSize:   snapshot.TotalSize,
+		CreatedAt:   snapshot.CreatedAt,
+		ExpiresAt:   snapshot.ExpiresAt,
+	}
+}
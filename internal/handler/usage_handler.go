@@ -0,0 +1,122 @@
+// Package handler provides HTTP handlers for Alexander Storage API.
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/auth"
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/service"
+)
+
+// UsagePath is the path of the usage report admin API. Requests are signed
+// with SigV4 like S3 requests and must come from a user with at least the
+// read-only role. Users outside of the default tenant only see the buckets
+// of their own tenant.
+const UsagePath = "/admin/usage"
+
+// UsageHandler serves usage reports for billing.
+type UsageHandler struct {
+	meteringService *service.MeteringService
+	userService     *service.UserService
+	bucketService   *service.BucketService
+	logger          zerolog.Logger
+}
+
+// NewUsageHandler creates a new UsageHandler.
+func NewUsageHandler(
+	meteringService *service.MeteringService,
+	userService *service.UserService,
+	bucketService *service.BucketService,
+	logger zerolog.Logger,
+) *UsageHandler {
+	return &UsageHandler{
+		meteringService: meteringService,
+		userService:     userService,
+		bucketService:   bucketService,
+		logger:          logger.With().Str("handler", "usage").Logger(),
+	}
+}
+
+// HandleUsage handles GET /admin/usage?month=YYYY-MM[&bucket=X][&access_key=Y] requests.
+func (h *UsageHandler) HandleUsage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	authCtx := auth.GetAuthContext(r.Context())
+	if authCtx == nil {
+		writeJSONError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	user, err := h.userService.GetByID(r.Context(), authCtx.UserID)
+	if err != nil || !user.Role.Includes(domain.RoleReadOnly) {
+		h.logger.Warn().Str("access_key_id", authCtx.AccessKeyID).Msg("rejected usage request from user without a dashboard role")
+		writeJSONError(w, http.StatusForbidden, "access denied")
+		return
+	}
+
+	query := r.URL.Query()
+	from, to, err := service.ParseUsageMonth(query.Get("month"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	output, err := h.meteringService.Report(r.Context(), service.UsageReportInput{
+		From:        from,
+		To:          to,
+		BucketName:  query.Get("bucket"),
+		AccessKeyID: query.Get("access_key"),
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidUsagePeriod) {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.logger.Error().Err(err).Msg("failed to build usage report")
+		writeJSONError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if user.TenantID != domain.DefaultTenantID {
+		if output.Buckets, err = h.tenantBuckets(r, user.TenantID, output.Buckets); err != nil {
+			h.logger.Error().Err(err).Msg("failed to list tenant buckets")
+			writeJSONError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(output)
+}
+
+// tenantBuckets returns the usage of those buckets that currently belong to
+// the tenant.
+func (h *UsageHandler) tenantBuckets(r *http.Request, tenantID int64, usage []service.BucketUsage) ([]service.BucketUsage, error) {
+	buckets, err := h.bucketService.ListBuckets(r.Context(), service.ListBucketsInput{})
+	if err != nil {
+		return nil, err
+	}
+	inTenant := make(map[string]bool)
+	for _, bucket := range buckets.Buckets {
+		if bucket.TenantID == tenantID {
+			inTenant[bucket.Name] = true
+		}
+	}
+
+	filtered := make([]service.BucketUsage, 0, len(usage))
+	for _, u := range usage {
+		if inTenant[u.BucketName] {
+			filtered = append(filtered, u)
+		}
+	}
+	return filtered, nil
+}
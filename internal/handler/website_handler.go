@@ -0,0 +1,368 @@
+// Package handler provides HTTP handlers for Alexander Storage API.
+package handler
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/auth"
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/service"
+)
+
+// WebsiteHandler handles bucket website configuration requests and serves
+// website endpoints, where the bucket is named by the Host header.
+type WebsiteHandler struct {
+	websiteService *service.WebsiteService
+	hostSuffix     string
+	logger         zerolog.Logger
+}
+
+// NewWebsiteHandler creates a new WebsiteHandler. With a host suffix such
+// as "web.example.com", website endpoints serve bucket "site" at
+// site.web.example.com; without one, the whole host names the bucket.
+func NewWebsiteHandler(websiteService *service.WebsiteService, hostSuffix string, logger zerolog.Logger) *WebsiteHandler {
+	return &WebsiteHandler{
+		websiteService: websiteService,
+		hostSuffix:     strings.ToLower(strings.Trim(hostSuffix, ".")),
+		logger:         logger.With().Str("handler", "website").Logger(),
+	}
+}
+
+// =============================================================================
+// XML Request/Response Types
+// =============================================================================
+
+// WebsiteConfiguration is the request/response for a bucket website configuration.
+type WebsiteConfiguration struct {
+	XMLName               xml.Name                      `xml:"WebsiteConfiguration"`
+	Xmlns                 string                        `xml:"xmlns,attr,omitempty"`
+	ErrorDocument         *WebsiteErrorDocument         `xml:"ErrorDocument,omitempty"`
+	IndexDocument         *WebsiteIndexDocument         `xml:"IndexDocument,omitempty"`
+	RedirectAllRequestsTo *WebsiteRedirectAllRequestsTo `xml:"RedirectAllRequestsTo,omitempty"`
+	RoutingRules          *WebsiteRoutingRules          `xml:"RoutingRules,omitempty"`
+}
+
+// WebsiteErrorDocument is the object served with 4xx errors.
+type WebsiteErrorDocument struct {
+	Key string `xml:"Key"`
+}
+
+// WebsiteIndexDocument is the suffix appended to directory requests.
+type WebsiteIndexDocument struct {
+	Suffix string `xml:"Suffix"`
+}
+
+// WebsiteRedirectAllRequestsTo redirects every request to another host.
+type WebsiteRedirectAllRequestsTo struct {
+	HostName string `xml:"HostName"`
+	Protocol string `xml:"Protocol,omitempty"`
+}
+
+// WebsiteRoutingRules lists the routing rules.
+type WebsiteRoutingRules struct {
+	Rules []WebsiteRoutingRule `xml:"RoutingRule"`
+}
+
+// WebsiteRoutingRule redirects the requests matching its condition.
+type WebsiteRoutingRule struct {
+	Condition *WebsiteCondition `xml:"Condition,omitempty"`
+	Redirect  WebsiteRedirect   `xml:"Redirect"`
+}
+
+// WebsiteCondition selects the requests a routing rule applies to.
+type WebsiteCondition struct {
+	KeyPrefixEquals             string `xml:"KeyPrefixEquals,omitempty"`
+	HTTPErrorCodeReturnedEquals int    `xml:"HttpErrorCodeReturnedEquals,omitempty"`
+}
+
+// WebsiteRedirect is where a routing rule sends a request.
+type WebsiteRedirect struct {
+	HostName             string `xml:"HostName,omitempty"`
+	HTTPRedirectCode     int    `xml:"HttpRedirectCode,omitempty"`
+	Protocol             string `xml:"Protocol,omitempty"`
+	ReplaceKeyPrefixWith string `xml:"ReplaceKeyPrefixWith,omitempty"`
+	ReplaceKeyWith       string `xml:"ReplaceKeyWith,omitempty"`
+}
+
+// =============================================================================
+// Handler Methods
+// =============================================================================
+
+// GetBucketWebsite handles GET /{bucket}?website requests.
+func (h *WebsiteHandler) GetBucketWebsite(w http.ResponseWriter, r *http.Request, bucketName string) {
+	ctx := r.Context()
+
+	userCtx, ok := auth.GetUserContext(ctx)
+	if !ok {
+		h.logger.Error().Msg("no user context found")
+		writeError(w, ErrAccessDenied)
+		return
+	}
+
+	config, err := h.websiteService.GetBucketWebsite(ctx, bucketName, userCtx.UserID)
+	if err != nil {
+		h.handleError(w, err, bucketName)
+		return
+	}
+
+	response := toWebsiteConfiguration(config)
+	response.Xmlns = "http://s3.amazonaws.com/doc/2006-03-01/"
+	writeXML(w, http.StatusOK, response)
+}
+
+// PutBucketWebsite handles PUT /{bucket}?website requests.
+func (h *WebsiteHandler) PutBucketWebsite(w http.ResponseWriter, r *http.Request, bucketName string) {
+	ctx := r.Context()
+
+	userCtx, ok := auth.GetUserContext(ctx)
+	if !ok {
+		h.logger.Error().Msg("no user context found")
+		writeError(w, ErrAccessDenied)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1024*1024)) // 1MB limit
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to read request body")
+		writeError(w, ErrInternalError)
+		return
+	}
+	defer r.Body.Close()
+
+	var request WebsiteConfiguration
+	if err := xml.Unmarshal(body, &request); err != nil {
+		writeError(w, ErrMalformedXML)
+		return
+	}
+
+	if err := h.websiteService.PutBucketWebsite(ctx, bucketName, userCtx.UserID, parseWebsiteConfiguration(&request)); err != nil {
+		h.handleError(w, err, bucketName)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// DeleteBucketWebsite handles DELETE /{bucket}?website requests.
+func (h *WebsiteHandler) DeleteBucketWebsite(w http.ResponseWriter, r *http.Request, bucketName string) {
+	ctx := r.Context()
+
+	userCtx, ok := auth.GetUserContext(ctx)
+	if !ok {
+		h.logger.Error().Msg("no user context found")
+		writeError(w, ErrAccessDenied)
+		return
+	}
+
+	if err := h.websiteService.DeleteBucketWebsite(ctx, bucketName, userCtx.UserID); err != nil {
+		h.handleError(w, err, bucketName)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// toWebsiteConfiguration converts a domain configuration to its XML form.
+func toWebsiteConfiguration(config *domain.WebsiteConfig) WebsiteConfiguration {
+	response := WebsiteConfiguration{}
+	if config.IndexDocument != "" {
+		response.IndexDocument = &WebsiteIndexDocument{Suffix: config.IndexDocument}
+	}
+	if config.ErrorDocument != "" {
+		response.ErrorDocument = &WebsiteErrorDocument{Key: config.ErrorDocument}
+	}
+	if redirect := config.RedirectAllRequestsTo; redirect != nil {
+		response.RedirectAllRequestsTo = &WebsiteRedirectAllRequestsTo{
+			HostName: redirect.HostName,
+			Protocol: redirect.Protocol,
+		}
+	}
+	if len(config.RoutingRules) > 0 {
+		response.RoutingRules = &WebsiteRoutingRules{}
+		for _, rule := range config.RoutingRules {
+			xmlRule := WebsiteRoutingRule{Redirect: WebsiteRedirect{
+				HostName:             rule.Redirect.HostName,
+				HTTPRedirectCode:     rule.Redirect.HTTPRedirectCode,
+				Protocol:             rule.Redirect.Protocol,
+				ReplaceKeyPrefixWith: rule.Redirect.ReplaceKeyPrefixWith,
+				ReplaceKeyWith:       rule.Redirect.ReplaceKeyWith,
+			}}
+			if rule.Condition != (domain.WebsiteCondition{}) {
+				condition := WebsiteCondition(rule.Condition)
+				xmlRule.Condition = &condition
+			}
+			response.RoutingRules.Rules = append(response.RoutingRules.Rules, xmlRule)
+		}
+	}
+	return response
+}
+
+// parseWebsiteConfiguration converts a request to a domain configuration.
+func parseWebsiteConfiguration(request *WebsiteConfiguration) *domain.WebsiteConfig {
+	config := &domain.WebsiteConfig{}
+	if request.IndexDocument != nil {
+		config.IndexDocument = request.IndexDocument.Suffix
+	}
+	if request.ErrorDocument != nil {
+		config.ErrorDocument = request.ErrorDocument.Key
+	}
+	if redirect := request.RedirectAllRequestsTo; redirect != nil {
+		config.RedirectAllRequestsTo = &domain.WebsiteRedirectAll{
+			HostName: redirect.HostName,
+			Protocol: redirect.Protocol,
+		}
+	}
+	if request.RoutingRules != nil {
+		for _, rule := range request.RoutingRules.Rules {
+			domainRule := domain.WebsiteRoutingRule{Redirect: domain.WebsiteRedirect{
+				HostName:             rule.Redirect.HostName,
+				Protocol:             rule.Redirect.Protocol,
+				ReplaceKeyPrefixWith: rule.Redirect.ReplaceKeyPrefixWith,
+				ReplaceKeyWith:       rule.Redirect.ReplaceKeyWith,
+				HTTPRedirectCode:     rule.Redirect.HTTPRedirectCode,
+			}}
+			if rule.Condition != nil {
+				domainRule.Condition = domain.WebsiteCondition(*rule.Condition)
+			}
+			config.RoutingRules = append(config.RoutingRules, domainRule)
+		}
+	}
+	return config
+}
+
+// handleError converts service errors to S3 errors.
+func (h *WebsiteHandler) handleError(w http.ResponseWriter, err error, bucketName string) {
+	s3Err, ok := s3ErrorFor(err)
+	if !ok {
+		h.logger.Error().Err(err).Str("bucket", bucketName).Msg("unhandled error")
+	}
+
+	s3Err.Resource = "/" + bucketName
+	writeError(w, s3Err)
+}
+
+var errNoSuchWebsiteConfiguration = S3Error{
+	Code:           "NoSuchWebsiteConfiguration",
+	Message:        "The specified bucket does not have a website configuration.",
+	HTTPStatusCode: http.StatusNotFound,
+}
+
+// =============================================================================
+// Website Endpoint
+// =============================================================================
+
+// ServeHTTP serves a website endpoint. Only GET and HEAD are allowed, and
+// errors are answered with HTML pages for browsers.
+func (h *WebsiteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bucketName := h.bucketName(r.Host)
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeWebsiteError(w, r, S3Error{
+			Code:           "MethodNotAllowed",
+			Message:        "The specified method is not allowed against this resource.",
+			HTTPStatusCode: http.StatusMethodNotAllowed,
+		})
+		return
+	}
+	if bucketName == "" {
+		writeWebsiteError(w, r, ErrNoSuchBucket)
+		return
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	response, err := h.websiteService.Serve(r.Context(), service.WebsiteRequest{
+		BucketName: bucketName,
+		Key:        strings.TrimPrefix(r.URL.Path, "/"),
+		Scheme:     scheme,
+		Host:       r.Host,
+	})
+	if err != nil {
+		h.handleWebsiteError(w, r, err, bucketName)
+		return
+	}
+
+	if response.Object == nil {
+		w.Header().Set("Location", response.Location)
+		w.WriteHeader(response.StatusCode)
+		return
+	}
+	defer response.Object.Body.Close()
+
+	object := response.Object
+	w.Header().Set("Content-Type", object.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(object.ContentLength, 10))
+	w.Header().Set("ETag", object.ETag)
+	w.Header().Set("Last-Modified", object.LastModified.UTC().Format(http.TimeFormat))
+	w.WriteHeader(response.StatusCode)
+	if r.Method == http.MethodGet {
+		io.Copy(w, object.Body)
+	}
+}
+
+// Middleware serves requests for hosts under the website host suffix and
+// passes all others to next, so websites can share the S3 port.
+func (h *WebsiteHandler) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.hostSuffix != "" && h.bucketName(r.Host) != "" {
+			h.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// bucketName returns the bucket a website host names, or "" if the host
+// is not under the host suffix.
+func (h *WebsiteHandler) bucketName(host string) string {
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if h.hostSuffix == "" {
+		return host
+	}
+	bucketName, ok := strings.CutSuffix(host, "."+h.hostSuffix)
+	if !ok {
+		return ""
+	}
+	return bucketName
+}
+
+// handleWebsiteError converts service errors to website error pages.
+func (h *WebsiteHandler) handleWebsiteError(w http.ResponseWriter, r *http.Request, err error, bucketName string) {
+	s3Err, ok := s3ErrorFor(err)
+	if !ok {
+		h.logger.Error().Err(err).Str("bucket", bucketName).Msg("unhandled error")
+	}
+
+	s3Err.Resource = "/" + bucketName + r.URL.Path
+	writeWebsiteError(w, r, s3Err)
+}
+
+// writeWebsiteError writes an error as an HTML page.
+func writeWebsiteError(w http.ResponseWriter, r *http.Request, err S3Error) {
+	status := fmt.Sprintf("%d %s", err.HTTPStatusCode, http.StatusText(err.HTTPStatusCode))
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(err.HTTPStatusCode)
+	if r.Method == http.MethodHead {
+		return
+	}
+	fmt.Fprintf(w, "<html>\n<head><title>%s</title></head>\n<body>\n<h1>%s</h1>\n<ul>\n<li>Code: %s</li>\n<li>Message: %s</li>\n",
+		status, status, html.EscapeString(err.Code), html.EscapeString(err.Message))
+	if err.Resource != "" {
+		fmt.Fprintf(w, "<li>Resource: %s</li>\n", html.EscapeString(err.Resource))
+	}
+	fmt.Fprint(w, "</ul>\n</body>\n</html>\n")
+}
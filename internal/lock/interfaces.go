@@ -5,6 +5,7 @@ package lock
 
 import (
 	"context"
+	"strconv"
 	"time"
 )
 
@@ -31,6 +32,26 @@ type Locker interface {
 
 	// IsHeld checks if the lock is currently held.
 	IsHeld(ctx context.Context, key string) (bool, error)
+
+	// AcquireLease attempts to acquire a lock owned by the returned lease.
+	// Returns nil if the lock is held by another process.
+	AcquireLease(ctx context.Context, key string, ttl time.Duration) (*Lease, error)
+
+	// ReleaseLease releases a lock if the lease still owns it.
+	// Returns false if the lease expired, even if the lock is now held by another owner.
+	ReleaseLease(ctx context.Context, lease *Lease) (bool, error)
+
+	// ExtendLease extends the TTL of a lock if the lease still owns it.
+	// Returns false if the lease expired, even if the lock is now held by another owner.
+	ExtendLease(ctx context.Context, lease *Lease, ttl time.Duration) (bool, error)
+}
+
+// Lease is an acquired lock together with the token proving its ownership.
+// Unlike Release and Extend, which act on whoever holds a key, lease
+// operations fail once the lock expired and was acquired by someone else.
+type Lease struct {
+	Key   string
+	Token string
 }
 
 // Lock is a convenience wrapper for a specific lock instance.
@@ -98,10 +119,10 @@ var Keys = lockKeys{}
 
 type lockKeys struct{}
 
-// ObjectUpload returns a lock key for object upload operations.
-// Prevents concurrent uploads to the same object.
-func (lockKeys) ObjectUpload(bucketID int64, key string) string {
-	return "lock:object:upload:" + formatBucketKey(bucketID, key)
+// ObjectWrite returns a lock key for writes to an object key.
+// Prevents concurrent puts, copies and deletes from interleaving on the same key.
+func (lockKeys) ObjectWrite(bucketID int64, key string) string {
+	return "lock:object:write:" + formatBucketKey(bucketID, key)
 }
 
 // MultipartUpload returns a lock key for multipart upload operations.
@@ -119,7 +140,37 @@ func (lockKeys) MultipartGC() string {
 	return "lock:gc:multipart"
 }
 
+// ObjectPurge returns a lock key for purging soft-deleted object versions.
+func (lockKeys) ObjectPurge() string {
+	return "lock:gc:objects"
+}
+
+// BlobScrub returns a lock key for blob integrity scrubbing.
+func (lockKeys) BlobScrub() string {
+	return "lock:scrub:blob"
+}
+
+// QuarantineExpiry returns a lock key for expiring unpromoted staged uploads.
+func (lockKeys) QuarantineExpiry() string {
+	return "lock:quarantine:expiry"
+}
+
+// BatchExpiry returns a lock key for aborting expired batches.
+func (lockKeys) BatchExpiry() string {
+	return "lock:batch:expiry"
+}
+
+// SnapshotExpiry returns a lock key for deleting expired bucket snapshots.
+func (lockKeys) SnapshotExpiry() string {
+	return "lock:snapshot:expiry"
+}
+
 // formatBucketKey formats a bucket ID and key into a string.
 func formatBucketKey(bucketID int64, key string) string {
-	return string(rune(bucketID)) + ":" + key
+	return strconv.FormatInt(bucketID, 10) + ":" + key
+}
+
+// Inventory returns a lock key for generating scheduled bucket inventory reports.
+func (lockKeys) Inventory() string {
+	return "lock:inventory"
 }
@@ -0,0 +1,69 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrLeaseLost is the cause of the context returned by KeepAlive once the
+// lease was taken over or could not be renewed in time.
+var ErrLeaseLost = errors.New("lock lease lost")
+
+// KeepAlive renews lease every third of ttl until stop is called, so work
+// guarded by the lock can outlast its TTL.
+//
+// The returned context is canceled with ErrLeaseLost as its cause when the
+// lease expired and another owner may have acquired the lock, or when
+// renewals keep failing until the lease is about to expire. Work guarded by
+// the lock must use it and stop when it is canceled. stop waits for the
+// renewals to end and returns the cause if the lease was lost.
+func KeepAlive(ctx context.Context, locker Locker, lease *Lease, ttl time.Duration) (_ context.Context, stop func() error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	interval := ttl / 3
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		renewed := time.Now()
+		for {
+			select {
+			case <-ticker.C:
+				extended, err := locker.ExtendLease(ctx, lease, ttl)
+				switch {
+				case extended:
+					renewed = time.Now()
+				case ctx.Err() != nil:
+					return
+				case err == nil:
+					cancel(ErrLeaseLost)
+					return
+				case time.Since(renewed) > ttl/2:
+					// The lease expires before the attempt after next
+					cancel(fmt.Errorf("%w: %v", ErrLeaseLost, err))
+					return
+				}
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ctx, func() error {
+		close(done)
+		<-stopped
+		cause := context.Cause(ctx)
+		cancel(nil)
+		if errors.Is(cause, ErrLeaseLost) {
+			return cause
+		}
+		return nil
+	}
+}
@@ -0,0 +1,89 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeepAlive_RenewsLease(t *testing.T) {
+	locker := NewMemoryLocker()
+	ctx := context.Background()
+	ttl := 60 * time.Millisecond
+
+	lease, err := locker.AcquireLease(ctx, "test-lock", ttl)
+	require.NoError(t, err)
+	require.NotNil(t, lease)
+
+	runCtx, stop := KeepAlive(ctx, locker, lease, ttl)
+	time.Sleep(4 * ttl)
+
+	// The lock outlived its TTL
+	require.NoError(t, runCtx.Err())
+	held, err := locker.IsHeld(ctx, "test-lock")
+	require.NoError(t, err)
+	assert.True(t, held)
+
+	require.NoError(t, stop())
+	assert.Error(t, runCtx.Err())
+}
+
+func TestKeepAlive_CancelsWhenLeaseLost(t *testing.T) {
+	locker := NewMemoryLocker()
+	ctx := context.Background()
+	ttl := 60 * time.Millisecond
+
+	lease, err := locker.AcquireLease(ctx, "test-lock", ttl)
+	require.NoError(t, err)
+	require.NotNil(t, lease)
+
+	runCtx, stop := KeepAlive(ctx, locker, lease, ttl)
+
+	// Another owner takes over the lock
+	_, err = locker.Release(ctx, "test-lock")
+	require.NoError(t, err)
+	other, err := locker.AcquireLease(ctx, "test-lock", time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, other)
+
+	select {
+	case <-runCtx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("context not canceled after the lease was lost")
+	}
+	assert.True(t, errors.Is(context.Cause(runCtx), ErrLeaseLost))
+	assert.ErrorIs(t, stop(), ErrLeaseLost)
+}
+
+// failingLocker fails every renewal.
+type failingLocker struct {
+	*MemoryLocker
+}
+
+func (failingLocker) ExtendLease(ctx context.Context, lease *Lease, ttl time.Duration) (bool, error) {
+	return false, errors.New("connection refused")
+}
+
+func TestKeepAlive_CancelsWhenRenewalsFail(t *testing.T) {
+	locker := failingLocker{NewMemoryLocker()}
+	ctx := context.Background()
+	ttl := 300 * time.Millisecond
+
+	lease, err := locker.AcquireLease(ctx, "test-lock", ttl)
+	require.NoError(t, err)
+	require.NotNil(t, lease)
+
+	start := time.Now()
+	runCtx, stop := KeepAlive(ctx, locker, lease, ttl)
+	<-runCtx.Done()
+
+	// One failed renewal is retried; the run stops before the lease expires
+	assert.Less(t, time.Since(start), ttl)
+	err = stop()
+	assert.ErrorIs(t, err, ErrLeaseLost)
+	assert.ErrorContains(t, err, "connection refused")
+}
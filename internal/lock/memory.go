@@ -2,6 +2,7 @@ package lock
 
 import (
 	"context"
+	"crypto/rand"
 	"sync"
 	"time"
 )
@@ -57,7 +58,7 @@ func (m *MemoryLocker) cleanup() {
 
 // generateToken creates a unique token for lock ownership.
 func generateToken() string {
-	return time.Now().Format(time.RFC3339Nano)
+	return rand.Text()
 }
 
 // Acquire attempts to acquire a lock.
@@ -178,5 +179,61 @@ func (m *MemoryLocker) IsHeld(ctx context.Context, key string) (bool, error) {
 	return true, nil
 }
 
+// AcquireLease attempts to acquire a lock owned by the returned lease.
+func (m *MemoryLocker) AcquireLease(ctx context.Context, key string, ttl time.Duration) (*Lease, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if entry, exists := m.locks[key]; exists && now.Before(entry.expiresAt) {
+		return nil, nil
+	}
+
+	token := generateToken()
+	m.locks[key] = &lockEntry{
+		expiresAt: now.Add(ttl),
+		token:     token,
+	}
+	return &Lease{Key: key, Token: token}, nil
+}
+
+// ReleaseLease releases a lock if the lease still owns it.
+func (m *MemoryLocker) ReleaseLease(ctx context.Context, lease *Lease) (bool, error) {
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, exists := m.locks[lease.Key]
+	if !exists || entry.token != lease.Token {
+		return false, nil
+	}
+	delete(m.locks, lease.Key)
+	return time.Now().Before(entry.expiresAt), nil
+}
+
+// ExtendLease extends the TTL of a lock if the lease still owns it.
+func (m *MemoryLocker) ExtendLease(ctx context.Context, lease *Lease, ttl time.Duration) (bool, error) {
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, exists := m.locks[lease.Key]
+	if !exists || entry.token != lease.Token || time.Now().After(entry.expiresAt) {
+		return false, nil
+	}
+	entry.expiresAt = time.Now().Add(ttl)
+	return true, nil
+}
+
 // Ensure MemoryLocker implements Locker.
 var _ Locker = (*MemoryLocker)(nil)
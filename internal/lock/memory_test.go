@@ -233,6 +233,44 @@ func TestMemoryLocker_MultipleLocks(t *testing.T) {
 	assert.True(t, held3)
 }
 
+func TestMemoryLocker_LeaseOwnership(t *testing.T) {
+	locker := NewMemoryLocker()
+
+	ctx := context.Background()
+	key := "test-lock"
+
+	lease, err := locker.AcquireLease(ctx, key, 50*time.Millisecond)
+	require.NoError(t, err)
+	require.NotNil(t, lease)
+
+	other, err := locker.AcquireLease(ctx, key, 5*time.Second)
+	require.NoError(t, err)
+	assert.Nil(t, other)
+
+	// The lease expires and another owner takes the lock
+	time.Sleep(100 * time.Millisecond)
+	other, err = locker.AcquireLease(ctx, key, 5*time.Second)
+	require.NoError(t, err)
+	require.NotNil(t, other)
+	assert.NotEqual(t, lease.Token, other.Token)
+
+	// The expired lease can neither extend nor release the new owner's lock
+	extended, err := locker.ExtendLease(ctx, lease, 5*time.Second)
+	require.NoError(t, err)
+	assert.False(t, extended)
+	released, err := locker.ReleaseLease(ctx, lease)
+	require.NoError(t, err)
+	assert.False(t, released)
+
+	held, err := locker.IsHeld(ctx, key)
+	require.NoError(t, err)
+	assert.True(t, held)
+
+	released, err = locker.ReleaseLease(ctx, other)
+	require.NoError(t, err)
+	assert.True(t, released)
+}
+
 func TestNoOpLocker(t *testing.T) {
 	locker := NewNoOpLocker()
 
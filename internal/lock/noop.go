@@ -39,5 +39,23 @@ func (n *NoOpLocker) IsHeld(ctx context.Context, key string) (bool, error) {
 	return false, ctx.Err()
 }
 
+// AcquireLease always returns a lease (lock acquired).
+func (n *NoOpLocker) AcquireLease(ctx context.Context, key string, ttl time.Duration) (*Lease, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return &Lease{Key: key}, nil
+}
+
+// ReleaseLease always returns true (lock released).
+func (n *NoOpLocker) ReleaseLease(ctx context.Context, lease *Lease) (bool, error) {
+	return true, ctx.Err()
+}
+
+// ExtendLease always returns true (lock extended).
+func (n *NoOpLocker) ExtendLease(ctx context.Context, lease *Lease, ttl time.Duration) (bool, error) {
+	return true, ctx.Err()
+}
+
 // Ensure NoOpLocker implements Locker.
 var _ Locker = (*NoOpLocker)(nil)
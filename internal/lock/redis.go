@@ -47,5 +47,24 @@ func (l *RedisLocker) IsHeld(ctx context.Context, key string) (bool, error) {
 	return l.distributedLock.IsHeld(ctx, key)
 }
 
+// AcquireLease attempts to acquire a lock owned by the returned lease.
+func (l *RedisLocker) AcquireLease(ctx context.Context, key string, ttl time.Duration) (*Lease, error) {
+	token, err := l.distributedLock.AcquireToken(ctx, key, ttl)
+	if err != nil || token == "" {
+		return nil, err
+	}
+	return &Lease{Key: key, Token: token}, nil
+}
+
+// ReleaseLease releases a lock if the lease still owns it.
+func (l *RedisLocker) ReleaseLease(ctx context.Context, lease *Lease) (bool, error) {
+	return l.distributedLock.ReleaseToken(ctx, lease.Key, lease.Token)
+}
+
+// ExtendLease extends the TTL of a lock if the lease still owns it.
+func (l *RedisLocker) ExtendLease(ctx context.Context, lease *Lease, ttl time.Duration) (bool, error) {
+	return l.distributedLock.ExtendToken(ctx, lease.Key, lease.Token, ttl)
+}
+
 // Ensure RedisLocker implements Locker
 var _ Locker = (*RedisLocker)(nil)
@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DBPoolStats is a snapshot of a database connection pool.
+type DBPoolStats struct {
+	MaxOpen int // maximum number of open connections
+	InUse   int // connections running a query or transaction
+	Idle    int // open connections waiting to be used
+
+	// WaitCount and WaitDuration are the number of times a query waited
+	// for a connection because all were in use, and the total time waited,
+	// since the pool was opened.
+	WaitCount    int64
+	WaitDuration time.Duration
+}
+
+// RegisterDBPool exports the statistics of a database connection pool,
+// labeled with its name, such as "primary" or "replica". stats is called on
+// every scrape.
+func (m *Metrics) RegisterDBPool(pool string, stats func() DBPoolStats) {
+	labels := prometheus.Labels{"pool": pool}
+	prometheus.MustRegister(&dbPoolCollector{
+		stats: stats,
+		connections: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "db", "connections"),
+			"Number of database connections by state.",
+			[]string{"state"}, labels,
+		),
+		maxConnections: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "db", "max_connections"),
+			"Maximum number of open database connections.",
+			nil, labels,
+		),
+		waits: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "db", "connection_waits_total"),
+			"Total number of times a query waited for a database connection.",
+			nil, labels,
+		),
+		waitSeconds: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "db", "connection_wait_seconds_total"),
+			"Total time spent waiting for a database connection in seconds.",
+			nil, labels,
+		),
+	})
+}
+
+// dbPoolCollector reads a connection pool's statistics when scraped.
+type dbPoolCollector struct {
+	stats          func() DBPoolStats
+	connections    *prometheus.Desc
+	maxConnections *prometheus.Desc
+	waits          *prometheus.Desc
+	waitSeconds    *prometheus.Desc
+}
+
+// Describe implements prometheus.Collector.
+func (c *dbPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.connections
+	ch <- c.maxConnections
+	ch <- c.waits
+	ch <- c.waitSeconds
+}
+
+// Collect implements prometheus.Collector.
+func (c *dbPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.stats()
+	ch <- prometheus.MustNewConstMetric(c.connections, prometheus.GaugeValue, float64(stats.InUse), "in_use")
+	ch <- prometheus.MustNewConstMetric(c.connections, prometheus.GaugeValue, float64(stats.Idle), "idle")
+	ch <- prometheus.MustNewConstMetric(c.maxConnections, prometheus.GaugeValue, float64(stats.MaxOpen))
+	ch <- prometheus.MustNewConstMetric(c.waits, prometheus.CounterValue, float64(stats.WaitCount))
+	ch <- prometheus.MustNewConstMetric(c.waitSeconds, prometheus.CounterValue, stats.WaitDuration.Seconds())
+}
@@ -3,6 +3,7 @@ package metrics
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
@@ -16,6 +17,7 @@ type Metrics struct {
 	HTTPRequestDuration  *prometheus.HistogramVec
 	HTTPRequestsInFlight prometheus.Gauge
 	HTTPResponseSize     *prometheus.HistogramVec
+	HTTPPanicsTotal      *prometheus.CounterVec
 
 	// Storage Metrics
 	StorageOperationsTotal   *prometheus.CounterVec
@@ -23,6 +25,7 @@ type Metrics struct {
 	StorageBytesTotal        *prometheus.CounterVec
 	BlobsTotal               prometheus.Gauge
 	BlobsSize                prometheus.Gauge
+	DedupRatio               prometheus.Gauge
 
 	// Object Metrics
 	ObjectsTotal   *prometheus.GaugeVec
@@ -32,10 +35,12 @@ type Metrics struct {
 	MultipartTotal prometheus.Gauge
 
 	// Database Metrics
-	DBConnectionsTotal    *prometheus.GaugeVec
 	DBQueryDuration       *prometheus.HistogramVec
 	DBTransactionsTotal   *prometheus.CounterVec
 	DBTransactionDuration *prometheus.HistogramVec
+	DBReplicaLag          prometheus.Gauge
+	DBReplicaUp           prometheus.Gauge
+	DBListReadsTotal      *prometheus.CounterVec
 
 	// Cache Metrics
 	CacheHitsTotal   *prometheus.CounterVec
@@ -53,9 +58,58 @@ type Metrics struct {
 	GCDuration     prometheus.Histogram
 	GCOrphanBlobs  prometheus.Gauge
 	GCLastRunTime  prometheus.Gauge
+	GCErrorsTotal  prometheus.Counter
+
+	// Storage Verification Metrics
+	GCVerifyOrphanFiles  prometheus.Gauge
+	GCVerifyMissingBlobs prometheus.Gauge
+	GCVerifyLastRunTime  prometheus.Gauge
+
+	// Reference Count Reconciliation Metrics
+	GCReconcileDriftedBlobs prometheus.Gauge
+	GCReconcileDriftedRefs  prometheus.Gauge
+	GCReconcileFixedTotal   prometheus.Counter
+	GCReconcileLastRunTime  prometheus.Gauge
+
+	// Multipart Reaper Metrics
+	MultipartReapedUploads prometheus.Counter
+	MultipartReapedParts   prometheus.Counter
+	MultipartReapErrors    prometheus.Counter
+	MultipartReapLastRun   prometheus.Gauge
+
+	// Object Purge Metrics
+	ObjectPurgedTotal  prometheus.Counter
+	ObjectPurgeLastRun prometheus.Gauge
+
+	// Lifecycle Metrics
+	LifecycleRunsTotal    prometheus.Counter
+	LifecycleObjectsTotal prometheus.Counter
+	LifecycleBytesTotal   prometheus.Counter
+	LifecycleErrorsTotal  prometheus.Counter
+	LifecycleDuration     prometheus.Histogram
+	LifecycleLastRunTime  prometheus.Gauge
+
+	// Integrity Scrub Metrics
+	ScrubBlobsTotal   *prometheus.CounterVec
+	ScrubBytesTotal   prometheus.Counter
+	ScrubCorruptBlobs prometheus.Gauge
+	ScrubLastRunTime  prometheus.Gauge
+
+	// Replication Metrics
+	ReplicationTasksTotal   *prometheus.CounterVec
+	ReplicationPendingTasks prometheus.Gauge
+	ReplicationLag          prometheus.Gauge
+
+	// Cluster Metrics
+	ClusterNodes          prometheus.Gauge
+	ClusterRebalanceTotal *prometheus.CounterVec
 
 	// Rate Limiting Metrics
 	RateLimitedRequests *prometheus.CounterVec
+
+	// Load Shedding Metrics
+	LoadShedQueued   *prometheus.GaugeVec
+	LoadShedRejected *prometheus.CounterVec
 }
 
 // namespace for all Alexander metrics
@@ -102,6 +156,15 @@ func New() *Metrics {
 			},
 			[]string{"method", "path"},
 		),
+		HTTPPanicsTotal: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: "http",
+				Name:      "panics_total",
+				Help:      "Total number of HTTP requests whose handler panicked.",
+			},
+			[]string{"method", "path"},
+		),
 
 		// Storage Metrics
 		StorageOperationsTotal: promauto.NewCounterVec(
@@ -148,6 +211,14 @@ func New() *Metrics {
 				Help:      "Total size of all blobs in bytes.",
 			},
 		),
+		DedupRatio: promauto.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: "storage",
+				Name:      "dedup_ratio",
+				Help:      "Ratio of referenced bytes to stored blob bytes.",
+			},
+		),
 
 		// Object Metrics
 		ObjectsTotal: promauto.NewGaugeVec(
@@ -194,15 +265,6 @@ func New() *Metrics {
 		),
 
 		// Database Metrics
-		DBConnectionsTotal: promauto.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Namespace: namespace,
-				Subsystem: "db",
-				Name:      "connections",
-				Help:      "Number of database connections by state.",
-			},
-			[]string{"state"},
-		),
 		DBQueryDuration: promauto.NewHistogramVec(
 			prometheus.HistogramOpts{
 				Namespace: namespace,
@@ -232,6 +294,31 @@ func New() *Metrics {
 			},
 			[]string{"status"},
 		),
+		DBReplicaLag: promauto.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: "db",
+				Name:      "replica_lag_seconds",
+				Help:      "Replication lag of the read replica in seconds.",
+			},
+		),
+		DBReplicaUp: promauto.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: "db",
+				Name:      "replica_up",
+				Help:      "Whether the last read replica lag check succeeded (1) or not (0).",
+			},
+		),
+		DBListReadsTotal: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: "db",
+				Name:      "list_reads_total",
+				Help:      "Total number of listings by serving database and routing reason.",
+			},
+			[]string{"target", "reason"},
+		),
 
 		// Cache Metrics
 		CacheHitsTotal: promauto.NewCounterVec(
@@ -331,6 +418,258 @@ func New() *Metrics {
 				Help:      "Timestamp of the last garbage collection run.",
 			},
 		),
+		GCErrorsTotal: promauto.NewCounter(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: "gc",
+				Name:      "errors_total",
+				Help:      "Total number of blobs garbage collection failed to delete.",
+			},
+		),
+
+		// Storage Verification Metrics
+		GCVerifyOrphanFiles: promauto.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: "gc_verify",
+				Name:      "orphan_files",
+				Help:      "Stored files without a blob record found by the last storage verification.",
+			},
+		),
+		GCVerifyMissingBlobs: promauto.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: "gc_verify",
+				Name:      "missing_blobs",
+				Help:      "Blob records without stored content found by the last storage verification.",
+			},
+		),
+		GCVerifyLastRunTime: promauto.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: "gc_verify",
+				Name:      "last_run_timestamp_seconds",
+				Help:      "Timestamp of the last storage verification run.",
+			},
+		),
+
+		// Reference Count Reconciliation Metrics
+		GCReconcileDriftedBlobs: promauto.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: "gc_reconcile",
+				Name:      "drifted_blobs",
+				Help:      "Blobs whose stored reference count was wrong in the last reconciliation.",
+			},
+		),
+		GCReconcileDriftedRefs: promauto.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: "gc_reconcile",
+				Name:      "drifted_refs",
+				Help:      "Sum of the differences between stored and counted references in the last reconciliation.",
+			},
+		),
+		GCReconcileFixedTotal: promauto.NewCounter(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: "gc_reconcile",
+				Name:      "fixed_total",
+				Help:      "Total number of blob reference counts corrected by reconciliation.",
+			},
+		),
+		GCReconcileLastRunTime: promauto.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: "gc_reconcile",
+				Name:      "last_run_timestamp_seconds",
+				Help:      "Timestamp of the last reference count reconciliation run.",
+			},
+		),
+
+		// Multipart Reaper Metrics
+		MultipartReapedUploads: promauto.NewCounter(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: "multipart_reaper",
+				Name:      "uploads_aborted_total",
+				Help:      "Total number of expired multipart uploads aborted.",
+			},
+		),
+		MultipartReapedParts: promauto.NewCounter(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: "multipart_reaper",
+				Name:      "parts_released_total",
+				Help:      "Total number of part blob references released from expired uploads.",
+			},
+		),
+		MultipartReapErrors: promauto.NewCounter(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: "multipart_reaper",
+				Name:      "errors_total",
+				Help:      "Total number of expired multipart uploads the reaper failed to abort.",
+			},
+		),
+		MultipartReapLastRun: promauto.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: "multipart_reaper",
+				Name:      "last_run_timestamp_seconds",
+				Help:      "Timestamp of the last multipart reaper run.",
+			},
+		),
+
+		// Object Purge Metrics
+		ObjectPurgedTotal: promauto.NewCounter(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: "object_purge",
+				Name:      "versions_purged_total",
+				Help:      "Total number of soft-deleted object versions removed.",
+			},
+		),
+		ObjectPurgeLastRun: promauto.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: "object_purge",
+				Name:      "last_run_timestamp_seconds",
+				Help:      "Timestamp of the last object purge run.",
+			},
+		),
+
+		// Lifecycle Metrics
+		LifecycleRunsTotal: promauto.NewCounter(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: "lifecycle",
+				Name:      "runs_total",
+				Help:      "Total number of lifecycle evaluation runs.",
+			},
+		),
+		LifecycleObjectsTotal: promauto.NewCounter(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: "lifecycle",
+				Name:      "objects_expired_total",
+				Help:      "Total number of objects expired by lifecycle rules.",
+			},
+		),
+		LifecycleBytesTotal: promauto.NewCounter(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: "lifecycle",
+				Name:      "bytes_expired_total",
+				Help:      "Total bytes of objects expired by lifecycle rules.",
+			},
+		),
+		LifecycleErrorsTotal: promauto.NewCounter(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: "lifecycle",
+				Name:      "errors_total",
+				Help:      "Total number of errors during lifecycle evaluation.",
+			},
+		),
+		LifecycleDuration: promauto.NewHistogram(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Subsystem: "lifecycle",
+				Name:      "duration_seconds",
+				Help:      "Lifecycle evaluation run duration in seconds.",
+				Buckets:   []float64{.1, .5, 1, 5, 10, 30, 60, 120},
+			},
+		),
+		LifecycleLastRunTime: promauto.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: "lifecycle",
+				Name:      "last_run_timestamp_seconds",
+				Help:      "Timestamp of the last lifecycle evaluation run.",
+			},
+		),
+
+		// Integrity Scrub Metrics
+		ScrubBlobsTotal: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: "scrub",
+				Name:      "blobs_total",
+				Help:      "Total number of blobs verified by the integrity scrubber by result.",
+			},
+			[]string{"result"},
+		),
+		ScrubBytesTotal: promauto.NewCounter(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: "scrub",
+				Name:      "bytes_total",
+				Help:      "Total bytes re-hashed by the integrity scrubber.",
+			},
+		),
+		ScrubCorruptBlobs: promauto.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: "scrub",
+				Name:      "corrupt_blobs",
+				Help:      "Current number of blobs flagged as corrupt.",
+			},
+		),
+		ScrubLastRunTime: promauto.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: "scrub",
+				Name:      "last_run_timestamp_seconds",
+				Help:      "Timestamp of the last integrity scrub run.",
+			},
+		),
+
+		// Replication Metrics
+		ReplicationTasksTotal: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: "replication",
+				Name:      "tasks_total",
+				Help:      "Total number of replication attempts by operation and result.",
+			},
+			[]string{"operation", "result"},
+		),
+		ReplicationPendingTasks: promauto.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: "replication",
+				Name:      "pending_tasks",
+				Help:      "Number of changes waiting to be replicated.",
+			},
+		),
+		ReplicationLag: promauto.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: "replication",
+				Name:      "lag_seconds",
+				Help:      "Age of the oldest change waiting to be replicated in seconds.",
+			},
+		),
+
+		// Cluster Metrics
+		ClusterNodes: promauto.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: "cluster",
+				Name:      "nodes",
+				Help:      "Number of live cluster nodes.",
+			},
+		),
+		ClusterRebalanceTotal: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: "cluster",
+				Name:      "rebalanced_blobs_total",
+				Help:      "Total number of blobs moved by the rebalancer by action and result.",
+			},
+			[]string{"action", "result"},
+		),
 
 		// Rate Limiting Metrics
 		RateLimitedRequests: promauto.NewCounterVec(
@@ -342,6 +681,26 @@ func New() *Metrics {
 			},
 			[]string{"limit_type"},
 		),
+
+		// Load Shedding Metrics
+		LoadShedQueued: promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: "loadshed",
+				Name:      "queued_requests",
+				Help:      "Current number of requests waiting for a slot by pool (read, write).",
+			},
+			[]string{"pool"},
+		),
+		LoadShedRejected: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: "loadshed",
+				Name:      "rejected_total",
+				Help:      "Total number of requests rejected with SlowDown by pool and reason (queue_full, timeout, canceled).",
+			},
+			[]string{"pool", "reason"},
+		),
 	}
 
 	return m
@@ -359,6 +718,11 @@ func (m *Metrics) RecordHTTPRequest(method, path, status string, duration float6
 	m.HTTPResponseSize.WithLabelValues(method, path).Observe(float64(size))
 }
 
+// RecordPanic records a request whose handler panicked.
+func (m *Metrics) RecordPanic(method, path string) {
+	m.HTTPPanicsTotal.WithLabelValues(method, path).Inc()
+}
+
 // RecordStorageOperation records storage operation metrics.
 func (m *Metrics) RecordStorageOperation(operation, status string, duration float64, bytes int64) {
 	m.StorageOperationsTotal.WithLabelValues(operation, status).Inc()
@@ -376,6 +740,37 @@ func (m *Metrics) RecordAuthAttempt(method string, success bool, reason string)
 	}
 }
 
+// RecordReplicaLag records a read replica lag check.
+func (m *Metrics) RecordReplicaLag(lag time.Duration, available bool) {
+	m.DBReplicaLag.Set(lag.Seconds())
+	if available {
+		m.DBReplicaUp.Set(1)
+	} else {
+		m.DBReplicaUp.Set(0)
+	}
+}
+
+// RecordListRead records which database served a listing.
+func (m *Metrics) RecordListRead(target, reason string) {
+	m.DBListReadsTotal.WithLabelValues(target, reason).Inc()
+}
+
+// RecordReplication records the result of a replication attempt.
+func (m *Metrics) RecordReplication(operation, result string) {
+	m.ReplicationTasksTotal.WithLabelValues(operation, result).Inc()
+}
+
+// RecordReplicationBacklog records the replication queue length and lag.
+func (m *Metrics) RecordReplicationBacklog(pending int64, lag time.Duration) {
+	m.ReplicationPendingTasks.Set(float64(pending))
+	m.ReplicationLag.Set(lag.Seconds())
+}
+
+// RecordClusterRebalance records a blob moved by the cluster rebalancer.
+func (m *Metrics) RecordClusterRebalance(action, result string) {
+	m.ClusterRebalanceTotal.WithLabelValues(action, result).Inc()
+}
+
 // RecordCacheAccess records a cache access.
 func (m *Metrics) RecordCacheAccess(cache string, hit bool) {
 	if hit {
@@ -385,15 +780,93 @@ func (m *Metrics) RecordCacheAccess(cache string, hit bool) {
 	}
 }
 
-// RecordGCRun records a garbage collection run.
-func (m *Metrics) RecordGCRun(duration float64, blobsDeleted int, bytesFreed int64) {
+// RecordGCRun records a completed garbage collection run. Deleted blobs,
+// freed bytes and errors are recorded while the run progresses.
+func (m *Metrics) RecordGCRun(duration float64) {
 	m.GCRunsTotal.Inc()
 	m.GCDuration.Observe(duration)
+	m.GCLastRunTime.SetToCurrentTime()
+}
+
+// RecordGCProgress adds the blobs deleted, errors and bytes freed since the
+// last report of a garbage collection run.
+func (m *Metrics) RecordGCProgress(blobsDeleted, errors int, bytesFreed int64) {
 	m.GCBlobsDeleted.Add(float64(blobsDeleted))
 	m.GCBytesFreed.Add(float64(bytesFreed))
+	m.GCErrorsTotal.Add(float64(errors))
+}
+
+// RecordGCVerify records the findings of a completed storage verification.
+func (m *Metrics) RecordGCVerify(orphanFiles, missingBlobs int) {
+	m.GCVerifyOrphanFiles.Set(float64(orphanFiles))
+	m.GCVerifyMissingBlobs.Set(float64(missingBlobs))
+	m.GCVerifyLastRunTime.SetToCurrentTime()
+}
+
+// RecordGCReconcile records the drift found and fixed by a completed
+// reference count reconciliation.
+func (m *Metrics) RecordGCReconcile(driftedBlobs int, driftedRefs int64, fixed int) {
+	m.GCReconcileDriftedBlobs.Set(float64(driftedBlobs))
+	m.GCReconcileDriftedRefs.Set(float64(driftedRefs))
+	m.GCReconcileFixedTotal.Add(float64(fixed))
+	m.GCReconcileLastRunTime.SetToCurrentTime()
+}
+
+// RecordMultipartReap records a completed multipart reaper run.
+func (m *Metrics) RecordMultipartReap(uploads, parts, errors int) {
+	m.MultipartReapedUploads.Add(float64(uploads))
+	m.MultipartReapedParts.Add(float64(parts))
+	m.MultipartReapErrors.Add(float64(errors))
+	m.MultipartReapLastRun.SetToCurrentTime()
+}
+
+// RecordObjectPurge records a completed object purge run.
+func (m *Metrics) RecordObjectPurge(purged int64) {
+	m.ObjectPurgedTotal.Add(float64(purged))
+	m.ObjectPurgeLastRun.SetToCurrentTime()
+}
+
+// RecordLifecycleRun records a lifecycle evaluation run.
+func (m *Metrics) RecordLifecycleRun(duration float64, objectsExpired, errors int, bytesFreed int64) {
+	m.LifecycleRunsTotal.Inc()
+	m.LifecycleDuration.Observe(duration)
+	m.LifecycleObjectsTotal.Add(float64(objectsExpired))
+	m.LifecycleBytesTotal.Add(float64(bytesFreed))
+	m.LifecycleErrorsTotal.Add(float64(errors))
+	m.LifecycleLastRunTime.SetToCurrentTime()
+}
+
+// RecordStorageUsage records the number and size of stored blobs. The dedup
+// ratio is the referenced bytes over the stored bytes.
+func (m *Metrics) RecordStorageUsage(blobs, orphans, storedBytes, referencedBytes int64) {
+	m.BlobsTotal.Set(float64(blobs))
+	m.BlobsSize.Set(float64(storedBytes))
+	m.GCOrphanBlobs.Set(float64(orphans))
+	if storedBytes > 0 {
+		m.DedupRatio.Set(float64(referencedBytes) / float64(storedBytes))
+	} else {
+		m.DedupRatio.Set(1)
+	}
+}
+
+// RecordScrubBlob records a blob verified by the integrity scrubber.
+func (m *Metrics) RecordScrubBlob(result string, bytes int64) {
+	m.ScrubBlobsTotal.WithLabelValues(result).Inc()
+	m.ScrubBytesTotal.Add(float64(bytes))
 }
 
 // RecordRateLimited records a rate limited request.
 func (m *Metrics) RecordRateLimited(limitType string) {
 	m.RateLimitedRequests.WithLabelValues(limitType).Inc()
 }
+
+// RecordLoadShedQueue records requests entering (delta 1) or leaving
+// (delta -1) the queue of a pool.
+func (m *Metrics) RecordLoadShedQueue(pool string, delta int) {
+	m.LoadShedQueued.WithLabelValues(pool).Add(float64(delta))
+}
+
+// RecordLoadShedRejected records a request rejected by the load shedder.
+func (m *Metrics) RecordLoadShedRejected(pool, reason string) {
+	m.LoadShedRejected.WithLabelValues(pool, reason).Inc()
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessLog_LogsRequest(t *testing.T) {
+	var buf bytes.Buffer
+	accessLog := NewAccessLog(zerolog.New(&buf))
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Write([]byte("stored"))
+	})
+	tracing := NewTracing(nil, zerolog.Nop())
+	handler := tracing.Middleware(accessLog.Middleware(fakeAuth(accessLog.Actor(inner))))
+
+	req := httptest.NewRequest(http.MethodPut, "/photos/2024/cat.jpg", strings.NewReader("meow"))
+	req.RemoteAddr = "192.0.2.10:54321"
+	req.Header.Set("X-Test-User", "alice")
+	req.Header.Set(HeaderRequestID, "req-1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, "req-1", line["request_id"])
+	assert.Equal(t, "192.0.2.10", line["remote_ip"])
+	assert.Equal(t, "AKIATEST", line["access_key_id"])
+	assert.Equal(t, "PUT", line["method"])
+	assert.Equal(t, "PutObject", line["operation"])
+	assert.Equal(t, "photos", line["bucket"])
+	assert.Equal(t, "2024/cat.jpg", line["key"])
+	assert.Equal(t, float64(http.StatusOK), line["status"])
+	assert.Equal(t, float64(4), line["bytes_in"])
+	assert.Equal(t, float64(6), line["bytes_out"])
+	assert.Contains(t, line, "duration")
+}
+
+func TestAccessLog_LogsRejectedRequest(t *testing.T) {
+	var buf bytes.Buffer
+	accessLog := NewAccessLog(zerolog.New(&buf))
+	handler := accessLog.Middleware(fakeAuth(accessLog.Actor(http.NotFoundHandler())))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/photos", nil))
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, float64(http.StatusForbidden), line["status"])
+	assert.Equal(t, "", line["access_key_id"])
+	assert.Equal(t, "ListObjects", line["operation"])
+}
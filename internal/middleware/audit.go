@@ -0,0 +1,239 @@
+// Package middleware provides HTTP middleware for Alexander Storage.
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prn-tf/alexander-storage/internal/auth"
+	"github.com/prn-tf/alexander-storage/internal/domain"
+)
+
+// AuditRecorder receives the audit events of completed requests.
+type AuditRecorder interface {
+	// Record queues an event for the audit log. It must not block on I/O.
+	Record(event *domain.AuditEvent)
+}
+
+// Audit records every request that can change state (anything but GET, HEAD
+// and OPTIONS) in the audit log, including requests rejected by
+// authentication.
+//
+// Middleware must wrap the auth middleware so rejected requests are seen;
+// Actor must wrap the handlers inside it, where the authenticated user is
+// known.
+type Audit struct {
+	recorder AuditRecorder
+}
+
+// NewAudit creates a new Audit middleware.
+func NewAudit(recorder AuditRecorder) *Audit {
+	return &Audit{recorder: recorder}
+}
+
+type auditContextKey struct{}
+
+// auditActor is filled in by Actor once the request is authenticated.
+type auditActor struct {
+	userID      int64
+	username    string
+	accessKeyID string
+}
+
+// Middleware returns the middleware that records the audit events.
+func (a *Audit) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		actor := &auditActor{}
+		wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(wrapped, r.WithContext(context.WithValue(r.Context(), auditContextKey{}, actor)))
+
+		var bucket, key string
+		if !isInternalPath(r.URL.Path) {
+			bucket, key = splitS3Path(r.URL.Path)
+		}
+		event := &domain.AuditEvent{
+			Time:        time.Now().UTC(),
+			UserID:      actor.userID,
+			Actor:       actor.username,
+			AccessKeyID: actor.accessKeyID,
+			Operation:   S3Operation(r),
+			BucketName:  bucket,
+			ObjectKey:   key,
+			SourceIP:    remoteIP(r),
+			RequestID:   GetRequestID(r.Context()),
+			Result:      domain.AuditResultForStatus(wrapped.statusCode),
+			StatusCode:  wrapped.statusCode,
+		}
+		if event.Actor == "" {
+			event.Actor = "anonymous"
+		}
+		a.recorder.Record(event)
+	})
+}
+
+// Actor returns the middleware that captures the authenticated user for the
+// audit event of the request.
+func (a *Audit) Actor(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if actor, ok := r.Context().Value(auditContextKey{}).(*auditActor); ok {
+			if authCtx := auth.GetAuthContext(r.Context()); authCtx != nil {
+				actor.userID = authCtx.UserID
+				actor.username = authCtx.Username
+				actor.accessKeyID = authCtx.AccessKeyID
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isInternalPath returns true for the paths of Alexander's own APIs, which
+// share the listener with the S3 API.
+func isInternalPath(path string) bool {
+	return strings.HasPrefix(path, "/_alexander/") || strings.HasPrefix(path, "/admin/")
+}
+
+// splitS3Path returns the bucket and key of a path-style request.
+func splitS3Path(path string) (string, string) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	return bucket, key
+}
+
+// remoteIP returns the address of the directly connected client. Forwarding
+// headers are ignored because clients can forge them.
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// bucketSubresources name the S3 operation of PUT and DELETE requests on a
+// bucket sub-resource, e.g. PUT /bucket?lifecycle is PutBucketLifecycle.
+var bucketSubresources = []struct{ query, name string }{
+	{"acl", "Acl"},
+	{"cors", "Cors"},
+	{"encryption", "Encryption"},
+	{"lifecycle", "Lifecycle"},
+	{"policy", "Policy"},
+	{"tagging", "Tagging"},
+	{"versioning", "Versioning"},
+	{"website", "Website"},
+}
+
+// S3Operation returns the S3 API operation name of a request, e.g. PutObject
+// or DeleteBucket. Requests outside the S3 API are named by method and path.
+func S3Operation(r *http.Request) string {
+	query := r.URL.Query()
+	has := func(name string) bool {
+		_, ok := query[name]
+		return ok
+	}
+
+	if isInternalPath(r.URL.Path) {
+		return r.Method + " " + r.URL.Path
+	}
+
+	bucket, key := splitS3Path(r.URL.Path)
+	switch {
+	case bucket == "":
+		if r.Method == http.MethodGet {
+			return "ListBuckets"
+		}
+	case key == "":
+		for _, sub := range bucketSubresources {
+			if has(sub.query) {
+				switch r.Method {
+				case http.MethodGet:
+					return "GetBucket" + sub.name
+				case http.MethodPut:
+					return "PutBucket" + sub.name
+				case http.MethodDelete:
+					return "DeleteBucket" + sub.name
+				}
+			}
+		}
+		switch r.Method {
+		case http.MethodGet:
+			if has("versions") {
+				return "ListObjectVersions"
+			}
+			if has("uploads") {
+				return "ListMultipartUploads"
+			}
+			return "ListObjects"
+		case http.MethodHead:
+			return "HeadBucket"
+		case http.MethodPut:
+			return "CreateBucket"
+		case http.MethodDelete:
+			return "DeleteBucket"
+		case http.MethodPost:
+			if has("delete") {
+				return "DeleteObjects"
+			}
+		}
+	default:
+		switch r.Method {
+		case http.MethodGet:
+			if has("uploadId") {
+				return "ListParts"
+			}
+			if has("tagging") {
+				return "GetObjectTagging"
+			}
+			return "GetObject"
+		case http.MethodHead:
+			return "HeadObject"
+		case http.MethodPut:
+			copySource := r.Header.Get("x-amz-copy-source") != ""
+			switch {
+			case has("uploadId") && copySource:
+				return "UploadPartCopy"
+			case has("uploadId") && r.Header.Get("x-alexander-chunk-sha256") != "":
+				return "StageChunk"
+			case has("uploadId"):
+				return "UploadPart"
+			case has("tagging"):
+				return "PutObjectTagging"
+			case has("acl"):
+				return "PutObjectAcl"
+			case has("renameObject"):
+				return "RenameObject"
+			case copySource:
+				return "CopyObject"
+			}
+			return "PutObject"
+		case http.MethodPost:
+			switch {
+			case has("uploads"):
+				return "CreateMultipartUpload"
+			case has("uploadId") && r.Header.Get("x-alexander-commit-chunks") != "":
+				return "CommitChunks"
+			case has("uploadId"):
+				return "CompleteMultipartUpload"
+			case has("restore"):
+				return "RestoreObject"
+			}
+		case http.MethodDelete:
+			switch {
+			case has("uploadId"):
+				return "AbortMultipartUpload"
+			case has("tagging"):
+				return "DeleteObjectTagging"
+			}
+			return "DeleteObject"
+		}
+	}
+
+	return r.Method + " " + r.URL.Path
+}
@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/auth"
+	"github.com/prn-tf/alexander-storage/internal/domain"
+)
+
+type recordedEvents struct {
+	mu     sync.Mutex
+	events []*domain.AuditEvent
+}
+
+func (r *recordedEvents) Record(event *domain.AuditEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+// fakeAuth authenticates requests carrying an X-Test-User header and rejects
+// all others, like the SigV4 middleware.
+func fakeAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Test-User") == "" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		ctx := context.WithValue(r.Context(), auth.AuthContextKey, &auth.AuthContext{
+			UserID:      7,
+			Username:    r.Header.Get("X-Test-User"),
+			AccessKeyID: "AKIATEST",
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func newAuditTestHandler(recorder AuditRecorder, status int) http.Handler {
+	audit := NewAudit(recorder)
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	})
+	return audit.Middleware(fakeAuth(audit.Actor(inner)))
+}
+
+func TestAudit_RecordsAuthenticatedWrite(t *testing.T) {
+	recorder := &recordedEvents{}
+	handler := newAuditTestHandler(recorder, http.StatusOK)
+
+	req := httptest.NewRequest(http.MethodPut, "/photos/2024/cat.jpg", nil)
+	req.RemoteAddr = "192.0.2.10:54321"
+	req.Header.Set("X-Test-User", "alice")
+	req.Header.Set("X-Forwarded-For", "203.0.113.1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	require.Len(t, recorder.events, 1)
+	event := recorder.events[0]
+	assert.Equal(t, int64(7), event.UserID)
+	assert.Equal(t, "alice", event.Actor)
+	assert.Equal(t, "AKIATEST", event.AccessKeyID)
+	assert.Equal(t, "PutObject", event.Operation)
+	assert.Equal(t, "photos", event.BucketName)
+	assert.Equal(t, "2024/cat.jpg", event.ObjectKey)
+	assert.Equal(t, "192.0.2.10", event.SourceIP)
+	assert.Equal(t, domain.AuditResultSuccess, event.Result)
+	assert.Equal(t, http.StatusOK, event.StatusCode)
+	assert.False(t, event.Time.IsZero())
+}
+
+func TestAudit_RecordsDeniedWrite(t *testing.T) {
+	recorder := &recordedEvents{}
+	handler := newAuditTestHandler(recorder, http.StatusOK)
+
+	req := httptest.NewRequest(http.MethodDelete, "/photos", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	require.Len(t, recorder.events, 1)
+	event := recorder.events[0]
+	assert.Equal(t, "anonymous", event.Actor)
+	assert.Equal(t, "DeleteBucket", event.Operation)
+	assert.Equal(t, domain.AuditResultDenied, event.Result)
+	assert.Equal(t, http.StatusForbidden, event.StatusCode)
+}
+
+func TestAudit_RecordsFailedWrite(t *testing.T) {
+	recorder := &recordedEvents{}
+	handler := newAuditTestHandler(recorder, http.StatusNotFound)
+
+	req := httptest.NewRequest(http.MethodDelete, "/photos/missing.jpg", nil)
+	req.Header.Set("X-Test-User", "alice")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	require.Len(t, recorder.events, 1)
+	assert.Equal(t, domain.AuditResultFailure, recorder.events[0].Result)
+}
+
+func TestAudit_SkipsReads(t *testing.T) {
+	recorder := &recordedEvents{}
+	handler := newAuditTestHandler(recorder, http.StatusOK)
+
+	for _, method := range []string{http.MethodGet, http.MethodHead, http.MethodOptions} {
+		req := httptest.NewRequest(method, "/photos/cat.jpg", nil)
+		req.Header.Set("X-Test-User", "alice")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	assert.Empty(t, recorder.events)
+}
+
+func TestS3Operation(t *testing.T) {
+	tests := []struct {
+		method string
+		target string
+		copy   bool
+		want   string
+	}{
+		{http.MethodGet, "/", false, "ListBuckets"},
+		{http.MethodPut, "/bucket", false, "CreateBucket"},
+		{http.MethodDelete, "/bucket", false, "DeleteBucket"},
+		{http.MethodPut, "/bucket?versioning", false, "PutBucketVersioning"},
+		{http.MethodDelete, "/bucket?lifecycle", false, "DeleteBucketLifecycle"},
+		{http.MethodPost, "/bucket?delete", false, "DeleteObjects"},
+		{http.MethodGet, "/bucket?versions", false, "ListObjectVersions"},
+		{http.MethodPut, "/bucket/key", false, "PutObject"},
+		{http.MethodPut, "/bucket/key", true, "CopyObject"},
+		{http.MethodPut, "/bucket/key?tagging", false, "PutObjectTagging"},
+		{http.MethodPut, "/bucket/key?renameObject", false, "RenameObject"},
+		{http.MethodPost, "/bucket/key?uploads", false, "CreateMultipartUpload"},
+		{http.MethodPut, "/bucket/key?partNumber=1&uploadId=u", false, "UploadPart"},
+		{http.MethodPut, "/bucket/key?partNumber=1&uploadId=u", true, "UploadPartCopy"},
+		{http.MethodPost, "/bucket/key?uploadId=u", false, "CompleteMultipartUpload"},
+		{http.MethodDelete, "/bucket/key?uploadId=u", false, "AbortMultipartUpload"},
+		{http.MethodDelete, "/bucket/a/b/c", false, "DeleteObject"},
+		{http.MethodPost, "/_alexander/v1/manifest", false, "POST /_alexander/v1/manifest"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.target, nil)
+		if tt.copy {
+			req.Header.Set("x-amz-copy-source", "/src/key")
+		}
+		assert.Equal(t, tt.want, S3Operation(req), "%s %s", tt.method, tt.target)
+	}
+}
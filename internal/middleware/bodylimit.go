@@ -0,0 +1,165 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// BodyLimit rejects request bodies larger than the configured limits with
+// the S3 EntityTooLarge error. Object uploads and all other requests have
+// separate limits, so configuration and admin requests cannot be used to
+// stream unbounded data.
+type BodyLimit struct {
+	maxObjectSize int64
+	maxAPISize    int64
+	logger        zerolog.Logger
+}
+
+// BodyLimitConfig holds request body limit configuration.
+type BodyLimitConfig struct {
+	// MaxObjectSize limits the body of object uploads (PutObject and
+	// UploadPart). Zero means unlimited.
+	MaxObjectSize int64
+
+	// MaxAPISize limits the body of all other requests. Zero means
+	// unlimited.
+	MaxAPISize int64
+}
+
+// NewBodyLimit creates a new request body limit middleware.
+func NewBodyLimit(config BodyLimitConfig, logger zerolog.Logger) *BodyLimit {
+	return &BodyLimit{
+		maxObjectSize: config.MaxObjectSize,
+		maxAPISize:    config.MaxAPISize,
+		logger:        logger.With().Str("component", "bodylimit").Logger(),
+	}
+}
+
+// Middleware returns the body limit middleware. Bodies of known length are
+// checked before the handler runs; bodies of unknown length are cut off at
+// the limit and the handler's response is replaced with EntityTooLarge.
+func (bl *BodyLimit) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit := bl.maxAPISize
+		if isObjectUpload(r) {
+			limit = bl.maxObjectSize
+		}
+		if limit <= 0 || r.ContentLength == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// aws-chunked uploads declare the size of the data without the
+		// chunk signatures
+		size := r.ContentLength
+		if decoded, err := strconv.ParseInt(r.Header.Get("X-Amz-Decoded-Content-Length"), 10, 64); err == nil {
+			size = decoded
+		}
+		if size > limit {
+			bl.reject(w, r, limit)
+			return
+		}
+		if r.ContentLength > 0 {
+			// The server never reads past Content-Length
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body := &limitedBody{ReadCloser: http.MaxBytesReader(w, r.Body, limit)}
+		r.Body = body
+		lw := &limitedResponseWriter{ResponseWriter: w, body: body, reject: func() { bl.reject(w, r, limit) }}
+		next.ServeHTTP(lw, r)
+	})
+}
+
+// reject writes the EntityTooLarge error.
+func (bl *BodyLimit) reject(w http.ResponseWriter, r *http.Request, limit int64) {
+	bl.logger.Warn().
+		Str("remote_addr", r.RemoteAddr).
+		Str("method", r.Method).
+		Str("path", r.URL.Path).
+		Int64("content_length", r.ContentLength).
+		Int64("limit", limit).
+		Msg("Request body too large")
+
+	w.Header().Set("Content-Type", "application/xml")
+	w.Header().Set("Connection", "close")
+	w.WriteHeader(http.StatusBadRequest)
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
+<Error>
+    <Code>EntityTooLarge</Code>
+    <Message>Your proposed upload exceeds the maximum allowed size.</Message>
+    <MaxSizeAllowed>%d</MaxSizeAllowed>
+</Error>`, limit)
+}
+
+// isObjectUpload reports whether a request uploads object data: a PUT to
+// an object key that is not a server-side copy.
+func isObjectUpload(r *http.Request) bool {
+	if r.Method != http.MethodPut || r.Header.Get("x-amz-copy-source") != "" {
+		return false
+	}
+	_, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	return key != ""
+}
+
+// limitedBody records whether the body exceeded its limit.
+type limitedBody struct {
+	io.ReadCloser
+	exceeded bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		b.exceeded = true
+	}
+	return n, err
+}
+
+// limitedResponseWriter replaces the handler's response with the reject
+// response once the body exceeded its limit.
+type limitedResponseWriter struct {
+	http.ResponseWriter
+	body        *limitedBody
+	reject      func()
+	wroteHeader bool
+	rejected    bool
+}
+
+func (w *limitedResponseWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	if w.body.exceeded {
+		w.rejected = true
+		clear(w.Header())
+		w.reject()
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *limitedResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.rejected {
+		// Discard the handler's response
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *limitedResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
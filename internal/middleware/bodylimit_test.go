@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBodyLimit(t *testing.T) {
+	bl := NewBodyLimit(BodyLimitConfig{MaxObjectSize: 10, MaxAPISize: 4}, zerolog.Nop())
+	handler := bl.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			w.Header().Set("X-Handler", "failed")
+			http.Error(w, "read failed", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		body    string
+		chunked bool
+		header  map[string]string
+		want    int
+	}{
+		{name: "object within limit", method: http.MethodPut, path: "/bucket/key", body: "0123456789", want: http.StatusOK},
+		{name: "object over limit", method: http.MethodPut, path: "/bucket/key", body: "0123456789x", want: http.StatusBadRequest},
+		{name: "chunked object over limit", method: http.MethodPut, path: "/bucket/key", body: "0123456789x", chunked: true, want: http.StatusBadRequest},
+		{name: "chunked object within limit", method: http.MethodPut, path: "/bucket/key", body: "0123", chunked: true, want: http.StatusOK},
+		{name: "aws-chunked uses decoded length", method: http.MethodPut, path: "/bucket/key", body: "0123456789abcdef", header: map[string]string{"X-Amz-Decoded-Content-Length": "10"}, want: http.StatusOK},
+		{name: "bucket request uses API limit", method: http.MethodPut, path: "/bucket", body: "01234", want: http.StatusBadRequest},
+		{name: "multipart completion uses API limit", method: http.MethodPost, path: "/bucket/key", body: "01234", chunked: true, want: http.StatusBadRequest},
+		{name: "copy uses API limit", method: http.MethodPut, path: "/bucket/key", body: "01234", header: map[string]string{"X-Amz-Copy-Source": "/other/key"}, want: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.want, rec.Code)
+			if tt.want == http.StatusBadRequest {
+				assert.Contains(t, rec.Body.String(), "<Code>EntityTooLarge</Code>")
+				assert.Empty(t, rec.Header().Get("X-Handler"), "handler response is replaced")
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/metrics"
+)
+
+// ConnLimiter limits the number of open connections and the number of
+// requests served concurrently on each connection. HTTP/1.1 connections
+// carry one request at a time, so the per-connection limit matters for
+// HTTP/2, where one client can multiplex many uploads over a connection.
+type ConnLimiter struct {
+	maxConns       int
+	maxConnStreams int
+	metrics        *metrics.Metrics
+	logger         zerolog.Logger
+}
+
+// ConnLimiterConfig holds connection limiter configuration.
+type ConnLimiterConfig struct {
+	// MaxConnections is the maximum number of open connections. Further
+	// connections wait in the listen backlog. Zero means unlimited.
+	MaxConnections int
+
+	// MaxRequestsPerConnection is the maximum number of requests served at
+	// once on one connection. Further requests are rejected with SlowDown.
+	// Zero means unlimited.
+	MaxRequestsPerConnection int
+}
+
+// NewConnLimiter creates a new connection limiter.
+func NewConnLimiter(config ConnLimiterConfig, m *metrics.Metrics, logger zerolog.Logger) *ConnLimiter {
+	return &ConnLimiter{
+		maxConns:       config.MaxConnections,
+		maxConnStreams: config.MaxRequestsPerConnection,
+		metrics:        m,
+		logger:         logger.With().Str("component", "connlimiter").Logger(),
+	}
+}
+
+// Listener wraps a listener so at most MaxConnections are open at once.
+func (cl *ConnLimiter) Listener(ln net.Listener) net.Listener {
+	if cl.maxConns <= 0 {
+		return ln
+	}
+	return &limitListener{Listener: ln, sem: make(chan struct{}, cl.maxConns), done: make(chan struct{})}
+}
+
+type connSemaphoreKey struct{}
+
+// ConnContext gives each connection its request semaphore. It is set as
+// http.Server.ConnContext.
+func (cl *ConnLimiter) ConnContext(ctx context.Context, _ net.Conn) context.Context {
+	if cl.maxConnStreams <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, connSemaphoreKey{}, make(chan struct{}, cl.maxConnStreams))
+}
+
+// Middleware returns the per-connection request limiting middleware.
+func (cl *ConnLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sem, ok := r.Context().Value(connSemaphoreKey{}).(chan struct{})
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		select {
+		case sem <- struct{}{}:
+			defer func() { <-sem }()
+			next.ServeHTTP(w, r)
+		default:
+			cl.logger.Warn().
+				Str("remote_addr", r.RemoteAddr).
+				Str("path", r.URL.Path).
+				Msg("Per-connection request limit exceeded")
+
+			if cl.metrics != nil {
+				cl.metrics.RecordRateLimited("connection")
+			}
+
+			w.Header().Set("Content-Type", "application/xml")
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Error>
+    <Code>SlowDown</Code>
+    <Message>Too many concurrent requests on this connection.</Message>
+</Error>`))
+		}
+	})
+}
+
+// limitListener accepts a connection only while fewer than cap(sem) are open.
+type limitListener struct {
+	net.Listener
+	sem       chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Accept waits for a free slot, then accepts a connection.
+func (l *limitListener) Accept() (net.Conn, error) {
+	select {
+	case l.sem <- struct{}{}:
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		<-l.sem
+		return nil, err
+	}
+	return &limitConn{Conn: conn, release: func() { <-l.sem }}, nil
+}
+
+// Close closes the listener and wakes an Accept waiting for a slot.
+func (l *limitListener) Close() error {
+	err := l.Listener.Close()
+	l.closeOnce.Do(func() { close(l.done) })
+	return err
+}
+
+// limitConn frees its listener slot when closed.
+type limitConn struct {
+	net.Conn
+	releaseOnce sync.Once
+	release     func()
+}
+
+// Close closes the connection and frees its slot.
+func (c *limitConn) Close() error {
+	err := c.Conn.Close()
+	c.releaseOnce.Do(c.release)
+	return err
+}
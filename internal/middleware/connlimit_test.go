@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnLimiter_LimitsRequestsPerConnection(t *testing.T) {
+	cl := NewConnLimiter(ConnLimiterConfig{MaxRequestsPerConnection: 1}, nil, zerolog.Nop())
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := cl.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/bucket/slow" {
+			close(started)
+			<-release
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// Requests of one connection share its context
+	ctx := cl.ConnContext(context.Background(), nil)
+
+	first := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(first, httptest.NewRequest(http.MethodGet, "/bucket/slow", nil).WithContext(ctx))
+		close(done)
+	}()
+	<-started
+
+	second := httptest.NewRecorder()
+	handler.ServeHTTP(second, httptest.NewRequest(http.MethodGet, "/bucket/b", nil).WithContext(ctx))
+	assert.Equal(t, http.StatusServiceUnavailable, second.Code)
+	assert.Equal(t, "1", second.Header().Get("Retry-After"))
+	assert.Contains(t, second.Body.String(), "SlowDown")
+
+	// Another connection has its own limit
+	other := httptest.NewRecorder()
+	handler.ServeHTTP(other, httptest.NewRequest(http.MethodGet, "/bucket/c", nil).WithContext(cl.ConnContext(context.Background(), nil)))
+	assert.Equal(t, http.StatusOK, other.Code)
+
+	close(release)
+	<-done
+	assert.Equal(t, http.StatusOK, first.Code)
+
+	// The slot is free again once the request completes
+	third := httptest.NewRecorder()
+	handler.ServeHTTP(third, httptest.NewRequest(http.MethodGet, "/bucket/d", nil).WithContext(ctx))
+	assert.Equal(t, http.StatusOK, third.Code)
+}
+
+func TestConnLimiter_LimitsOpenConnections(t *testing.T) {
+	cl := NewConnLimiter(ConnLimiterConfig{MaxConnections: 1}, nil, zerolog.Nop())
+
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	ln := cl.Listener(inner)
+	defer ln.Close()
+
+	accepted := make(chan net.Conn)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				close(accepted)
+				return
+			}
+			accepted <- conn
+		}
+	}()
+
+	for i := 0; i < 2; i++ {
+		c, err := net.Dial("tcp", inner.Addr().String())
+		require.NoError(t, err)
+		defer c.Close()
+	}
+
+	first := <-accepted
+	select {
+	case <-accepted:
+		t.Fatal("second connection accepted while the first is open")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Closing the first connection frees its slot
+	require.NoError(t, first.Close())
+	select {
+	case conn := <-accepted:
+		require.NotNil(t, conn)
+		conn.Close()
+	case <-time.After(5 * time.Second):
+		t.Fatal("second connection not accepted after the first closed")
+	}
+
+	// Close wakes an Accept waiting for a slot
+	ln.Close()
+	select {
+	case _, ok := <-accepted:
+		assert.False(t, ok)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Accept did not return after Close")
+	}
+}
@@ -0,0 +1,167 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/metrics"
+)
+
+// LoadShedder limits the number of requests served at once, so an
+// overloaded server answers SlowDown instead of taking on work until it runs
+// out of memory. Reads (GET and HEAD) and all other requests have separate
+// pools, so a flood of uploads cannot starve downloads and vice versa.
+// Requests over the limit wait in a bounded queue for a slot.
+type LoadShedder struct {
+	reads   *requestPool
+	writes  *requestPool
+	skip    func(r *http.Request) bool
+	metrics *metrics.Metrics
+	logger  zerolog.Logger
+}
+
+// LoadShedderConfig holds load shedder configuration.
+type LoadShedderConfig struct {
+	// MaxReads is the maximum number of GET and HEAD requests served at
+	// once. Zero means unlimited.
+	MaxReads int
+
+	// MaxWrites is the maximum number of other requests served at once.
+	// Zero means unlimited.
+	MaxWrites int
+
+	// MaxQueued is the number of requests per pool that wait for a slot.
+	// Further requests are rejected right away.
+	MaxQueued int
+
+	// QueueTimeout is how long a request waits for a slot before it is
+	// rejected.
+	QueueTimeout time.Duration
+
+	// Skip, if set, exempts requests from the limits, such as health checks
+	// and long-lived streams that would hold a slot indefinitely.
+	Skip func(r *http.Request) bool
+}
+
+// NewLoadShedder creates a new load shedder.
+func NewLoadShedder(config LoadShedderConfig, m *metrics.Metrics, logger zerolog.Logger) *LoadShedder {
+	return &LoadShedder{
+		reads:   newRequestPool("read", config.MaxReads, config),
+		writes:  newRequestPool("write", config.MaxWrites, config),
+		skip:    config.Skip,
+		metrics: m,
+		logger:  logger.With().Str("component", "loadshedder").Logger(),
+	}
+}
+
+// Middleware returns the load shedding middleware.
+func (ls *LoadShedder) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pool := ls.writes
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			pool = ls.reads
+		}
+		if pool == nil || (ls.skip != nil && ls.skip(r)) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if reason := ls.acquire(r.Context(), pool); reason != "" {
+			ls.reject(w, r, pool, reason)
+			return
+		}
+		defer pool.release()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// acquire takes a slot of the pool, waiting in its queue if there is none.
+// It returns why the request is rejected, or "" once it has a slot.
+func (ls *LoadShedder) acquire(ctx context.Context, pool *requestPool) string {
+	select {
+	case pool.slots <- struct{}{}:
+		return ""
+	default:
+	}
+
+	if pool.queued.Add(1) > pool.maxQueued || pool.timeout <= 0 {
+		pool.queued.Add(-1)
+		return "queue_full"
+	}
+	if ls.metrics != nil {
+		ls.metrics.RecordLoadShedQueue(pool.name, 1)
+	}
+	defer func() {
+		pool.queued.Add(-1)
+		if ls.metrics != nil {
+			ls.metrics.RecordLoadShedQueue(pool.name, -1)
+		}
+	}()
+
+	timer := time.NewTimer(pool.timeout)
+	defer timer.Stop()
+	select {
+	case pool.slots <- struct{}{}:
+		return ""
+	case <-timer.C:
+		return "timeout"
+	case <-ctx.Done():
+		return "canceled"
+	}
+}
+
+// reject writes the SlowDown error, as S3 does when it is overloaded.
+func (ls *LoadShedder) reject(w http.ResponseWriter, r *http.Request, pool *requestPool, reason string) {
+	ls.logger.Warn().
+		Ctx(r.Context()).
+		Str("pool", pool.name).
+		Str("reason", reason).
+		Str("method", r.Method).
+		Str("path", r.URL.Path).
+		Msg("Request shed under load")
+
+	if ls.metrics != nil {
+		ls.metrics.RecordLoadShedRejected(pool.name, reason)
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	w.Header().Set("Retry-After", "1")
+	w.WriteHeader(http.StatusServiceUnavailable)
+	w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Error>
+    <Code>SlowDown</Code>
+    <Message>Please reduce your request rate.</Message>
+</Error>`))
+}
+
+// requestPool is the slots and the queue of one kind of request.
+type requestPool struct {
+	name      string
+	slots     chan struct{}
+	queued    atomic.Int64
+	maxQueued int64
+	timeout   time.Duration
+}
+
+// newRequestPool returns a pool of size slots, or nil if size is not
+// positive.
+func newRequestPool(name string, size int, config LoadShedderConfig) *requestPool {
+	if size <= 0 {
+		return nil
+	}
+	return &requestPool{
+		name:      name,
+		slots:     make(chan struct{}, size),
+		maxQueued: int64(config.MaxQueued),
+		timeout:   config.QueueTimeout,
+	}
+}
+
+// release frees the slot taken by acquire.
+func (p *requestPool) release() {
+	<-p.slots
+}
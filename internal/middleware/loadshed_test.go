@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+// blockingHandler holds each request until release is closed, and signals
+// started when it begins serving one.
+func blockingHandler(started chan<- struct{}, release <-chan struct{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func serve(handler http.Handler, method, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+	return rec
+}
+
+func TestLoadShedder_RejectsWhenQueueFull(t *testing.T) {
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	ls := NewLoadShedder(LoadShedderConfig{MaxReads: 1, MaxQueued: 0, QueueTimeout: time.Second}, nil, zerolog.Nop())
+	handler := ls.Middleware(blockingHandler(started, release))
+
+	go serve(handler, http.MethodGet, "/bucket/a")
+	<-started
+
+	rec := serve(handler, http.MethodGet, "/bucket/b")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+	assert.Contains(t, rec.Body.String(), "<Code>SlowDown</Code>")
+	close(release)
+}
+
+func TestLoadShedder_QueuedRequestTimesOut(t *testing.T) {
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	ls := NewLoadShedder(LoadShedderConfig{MaxWrites: 1, MaxQueued: 1, QueueTimeout: 20 * time.Millisecond}, nil, zerolog.Nop())
+	handler := ls.Middleware(blockingHandler(started, release))
+
+	go serve(handler, http.MethodPut, "/bucket/a")
+	<-started
+
+	rec := serve(handler, http.MethodPut, "/bucket/b")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	close(release)
+}
+
+func TestLoadShedder_QueuedRequestGetsFreedSlot(t *testing.T) {
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	ls := NewLoadShedder(LoadShedderConfig{MaxWrites: 1, MaxQueued: 1, QueueTimeout: 5 * time.Second}, nil, zerolog.Nop())
+	handler := ls.Middleware(blockingHandler(started, release))
+
+	go serve(handler, http.MethodPut, "/bucket/a")
+	<-started
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- serve(handler, http.MethodPut, "/bucket/b") }()
+	close(release)
+
+	assert.Equal(t, http.StatusOK, (<-done).Code)
+}
+
+func TestLoadShedder_SeparatePools(t *testing.T) {
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	ls := NewLoadShedder(LoadShedderConfig{MaxReads: 1, MaxWrites: 1}, nil, zerolog.Nop())
+	handler := ls.Middleware(blockingHandler(started, release))
+
+	go serve(handler, http.MethodPut, "/bucket/a")
+	<-started
+
+	// A busy write pool does not hold up reads
+	go serve(handler, http.MethodGet, "/bucket/a")
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("read was not served while the write pool was full")
+	}
+	close(release)
+}
+
+func TestLoadShedder_SkipsExemptRequests(t *testing.T) {
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	ls := NewLoadShedder(LoadShedderConfig{
+		MaxReads: 1,
+		Skip:     func(r *http.Request) bool { return r.URL.Query().Has("watch") },
+	}, nil, zerolog.Nop())
+	handler := ls.Middleware(blockingHandler(started, release))
+
+	go serve(handler, http.MethodGet, "/bucket?watch")
+	<-started
+
+	// The open stream does not hold the only read slot
+	go serve(handler, http.MethodGet, "/bucket/a")
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("request was not served while a watch stream was open")
+	}
+	close(release)
+}
@@ -0,0 +1,100 @@
+// Package middleware provides HTTP middleware for Alexander Storage.
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/prn-tf/alexander-storage/internal/auth"
+)
+
+// UsageRecorder receives the usage of completed requests.
+type UsageRecorder interface {
+	// Record adds one request and its transferred bytes to the usage counters.
+	Record(accessKeyID, bucketName string, bytesIn, bytesOut int64)
+}
+
+// Metering records per-bucket, per-access-key request and transfer usage.
+// It must wrap only the S3 API handler, inside the auth middleware, so the
+// access key is known. Requests that do not address a bucket (ListBuckets)
+// are not metered.
+type Metering struct {
+	recorder UsageRecorder
+}
+
+// NewMetering creates a new Metering middleware.
+func NewMetering(recorder UsageRecorder) *Metering {
+	return &Metering{recorder: recorder}
+}
+
+// Middleware returns the metering middleware.
+func (m *Metering) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bucketName := meteredBucket(r.URL.Path)
+		if bucketName == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Anonymous requests (public buckets) are recorded with an empty access key
+		var accessKeyID string
+		if authCtx := auth.GetAuthContext(r.Context()); authCtx != nil {
+			accessKeyID = authCtx.AccessKeyID
+		}
+
+		body := &countingReader{ReadCloser: r.Body}
+		if r.Body != nil && r.Body != http.NoBody {
+			r.Body = body
+		}
+		wrapped := &countingResponseWriter{ResponseWriter: w}
+
+		next.ServeHTTP(wrapped, r)
+
+		m.recorder.Record(accessKeyID, bucketName, body.n, wrapped.n)
+	})
+}
+
+// meteredBucket returns the bucket addressed by a path-style request, or ""
+// for requests on the service root.
+func meteredBucket(path string) string {
+	bucket, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	return bucket
+}
+
+// countingReader counts the bytes read from a request body.
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// countingResponseWriter counts the bytes written to a response body.
+type countingResponseWriter struct {
+	http.ResponseWriter
+	n int64
+}
+
+func (w *countingResponseWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.n += int64(n)
+	return n, err
+}
+
+// Flush implements http.Flusher for streaming responses.
+func (w *countingResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to
+// clear the write deadline for long-lived streams.
+func (w *countingResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
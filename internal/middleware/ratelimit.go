@@ -13,7 +13,8 @@ import (
 
 // RateLimiter implements token bucket rate limiting.
 type RateLimiter struct {
-	// Configuration
+	// Configuration, changed by SetLimits
+	mu                sync.RWMutex
 	requestsPerSecond float64
 	burstSize         int
 	enabled           bool
@@ -74,17 +75,32 @@ func NewRateLimiter(config RateLimiterConfig, m *metrics.Metrics, logger zerolog
 		stopCleanup:       make(chan struct{}),
 	}
 
-	if config.Enabled {
-		go rl.cleanupLoop()
-	}
+	go rl.cleanupLoop()
 
 	return rl
 }
 
+// SetLimits changes the limits of a running rate limiter. Clients keep
+// their tokens, capped at the new burst size on their next request.
+func (rl *RateLimiter) SetLimits(requestsPerSecond float64, burstSize int, enabled bool) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.requestsPerSecond = requestsPerSecond
+	rl.burstSize = burstSize
+	rl.enabled = enabled
+}
+
+// limits returns the current refill rate, burst size and whether limiting is on.
+func (rl *RateLimiter) limits() (float64, int, bool) {
+	rl.mu.RLock()
+	defer rl.mu.RUnlock()
+	return rl.requestsPerSecond, rl.burstSize, rl.enabled
+}
+
 // Middleware returns the rate limiting middleware.
 func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if !rl.enabled {
+		if _, _, enabled := rl.limits(); !enabled {
 			next.ServeHTTP(w, r)
 			return
 		}
@@ -103,14 +119,7 @@ func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
 				rl.metrics.RecordRateLimited("request")
 			}
 
-			w.Header().Set("Content-Type", "application/xml")
-			w.Header().Set("Retry-After", "1")
-			w.WriteHeader(http.StatusTooManyRequests)
-			w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
-<Error>
-    <Code>SlowDown</Code>
-    <Message>Please reduce your request rate.</Message>
-</Error>`))
+			writeSlowDown(w)
 			return
 		}
 
@@ -118,6 +127,18 @@ func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
 	})
 }
 
+// writeSlowDown writes the S3 error for rate-limited requests.
+func writeSlowDown(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.Header().Set("Retry-After", "1")
+	w.WriteHeader(http.StatusTooManyRequests)
+	w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Error>
+    <Code>SlowDown</Code>
+    <Message>Please reduce your request rate.</Message>
+</Error>`))
+}
+
 // getClientID extracts the client identifier from the request.
 func (rl *RateLimiter) getClientID(r *http.Request) string {
 	// Try to get X-Forwarded-For header first (for proxied requests)
@@ -131,8 +152,13 @@ func (rl *RateLimiter) getClientID(r *http.Request) string {
 
 // allow checks if a request is allowed under the rate limit.
 func (rl *RateLimiter) allow(clientID string) bool {
-	b := rl.getBucket(clientID)
+	requestsPerSecond, burstSize, _ := rl.limits()
+	return rl.getBucket(clientID, burstSize).take(requestsPerSecond, burstSize)
+}
 
+// take refills the bucket at requestsPerSecond up to burstSize and takes a
+// token from it, if there is one.
+func (b *bucket) take(requestsPerSecond float64, burstSize int) bool {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
@@ -140,11 +166,11 @@ func (rl *RateLimiter) allow(clientID string) bool {
 
 	// Refill tokens based on time elapsed
 	elapsed := now.Sub(b.lastRefill).Seconds()
-	b.tokens += elapsed * rl.requestsPerSecond
+	b.tokens += elapsed * requestsPerSecond
 
 	// Cap at burst size
-	if b.tokens > float64(rl.burstSize) {
-		b.tokens = float64(rl.burstSize)
+	if b.tokens > float64(burstSize) {
+		b.tokens = float64(burstSize)
 	}
 
 	b.lastRefill = now
@@ -159,14 +185,14 @@ func (rl *RateLimiter) allow(clientID string) bool {
 }
 
 // getBucket gets or creates a bucket for the client.
-func (rl *RateLimiter) getBucket(clientID string) *bucket {
+func (rl *RateLimiter) getBucket(clientID string, burstSize int) *bucket {
 	if b, ok := rl.buckets.Load(clientID); ok {
 		return b.(*bucket)
 	}
 
 	// Create new bucket with full tokens
 	b := &bucket{
-		tokens:     float64(rl.burstSize),
+		tokens:     float64(burstSize),
 		lastRefill: time.Now(),
 	}
 
@@ -214,9 +240,7 @@ func (rl *RateLimiter) cleanup() {
 
 // Stop stops the rate limiter's background cleanup.
 func (rl *RateLimiter) Stop() {
-	if rl.enabled {
-		close(rl.stopCleanup)
-	}
+	close(rl.stopCleanup)
 }
 
 // BandwidthLimiter limits bandwidth per client.
@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"encoding/xml"
+	"fmt"
+	"maps"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/metrics"
+)
+
+// Recovery turns a panic in a request handler into the S3 InternalError
+// response. The panic is logged with its stack trace and counted, and the
+// connection stays usable for the client's next request.
+type Recovery struct {
+	metrics *metrics.Metrics
+	logger  zerolog.Logger
+}
+
+// NewRecovery creates a new panic recovery middleware.
+func NewRecovery(m *metrics.Metrics, logger zerolog.Logger) *Recovery {
+	return &Recovery{
+		metrics: m,
+		logger:  logger.With().Str("component", "recovery").Logger(),
+	}
+}
+
+// Middleware returns the recovery middleware. Place it inside the tracing
+// and access log middleware so the failed request is traced and logged.
+func (rc *Recovery) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &responseWriter{ResponseWriter: w}
+		header := w.Header().Clone()
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				// Deliberate aborts are not failures
+				panic(v)
+			}
+
+			rc.logger.Error().
+				Ctx(r.Context()).
+				Str("method", r.Method).
+				Str("path", r.URL.Path).
+				Str("panic", fmt.Sprint(v)).
+				Str("stack", string(debug.Stack())).
+				Msg("Request handler panicked")
+			if rc.metrics != nil {
+				rc.metrics.RecordPanic(r.Method, normalizePath(r.URL.Path))
+			}
+
+			if rw.statusCode != 0 || rw.bytesWritten > 0 {
+				// Part of the response is sent; only closing the connection
+				// tells the client it is incomplete
+				panic(http.ErrAbortHandler)
+			}
+			rc.writeInternalError(w, header)
+		}()
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// internalError is the body of the InternalError response.
+type internalError struct {
+	XMLName   xml.Name `xml:"Error"`
+	Code      string   `xml:"Code"`
+	Message   string   `xml:"Message"`
+	RequestID string   `xml:"RequestId,omitempty"`
+	HostID    string   `xml:"HostId,omitempty"`
+}
+
+// writeInternalError replaces the headers the handler set before it
+// panicked with those of the InternalError response, and writes it.
+func (rc *Recovery) writeInternalError(w http.ResponseWriter, header http.Header) {
+	clear(w.Header())
+	maps.Copy(w.Header(), header)
+
+	body, _ := xml.Marshal(internalError{
+		Code:      "InternalError",
+		Message:   "We encountered an internal error. Please try again.",
+		RequestID: header.Get(HeaderAmzRequestID),
+		HostID:    header.Get(HeaderAmzID2),
+	})
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(http.StatusInternalServerError)
+	w.Write([]byte(xml.Header))
+	w.Write(body)
+}
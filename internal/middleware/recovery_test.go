@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecovery_RespondsWithInternalError(t *testing.T) {
+	handler := NewTracing(nil, zerolog.Nop()).Middleware(NewRecovery(nil, zerolog.Nop()).Middleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("ETag", `"abc"`)
+			var m map[string]int
+			m["boom"]++
+		})))
+
+	req := httptest.NewRequest(http.MethodGet, "/bucket/key", nil)
+	req.Header.Set(HeaderRequestID, "req-<1>")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, "application/xml", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "req-<1>", rec.Header().Get(HeaderAmzRequestID))
+	assert.Empty(t, rec.Header().Get("ETag"), "headers of the handler are dropped")
+	assert.Contains(t, rec.Body.String(), "<Code>InternalError</Code>")
+	assert.Contains(t, rec.Body.String(), "<RequestId>req-&lt;1&gt;</RequestId>")
+}
+
+func TestRecovery_AbortsPartialResponse(t *testing.T) {
+	server := httptest.NewServer(NewRecovery(nil, zerolog.Nop()).Middleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Length", "10")
+			w.Write([]byte("01234"))
+			w.(http.Flusher).Flush()
+			panic("halfway")
+		})))
+	t.Cleanup(server.Close)
+
+	resp, err := http.Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	// The connection is closed before the declared length
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	_, err = io.ReadAll(resp.Body)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/auth"
+	"github.com/prn-tf/alexander-storage/internal/metrics"
+)
+
+// TenantLimitProvider returns the request rate and burst size shared by the
+// access keys of a tenant. A zero rate means the tenant is not limited.
+type TenantLimitProvider interface {
+	RateLimit(ctx context.Context, tenantID int64) (requestsPerSecond float64, burstSize int)
+}
+
+// TenantRateLimiter limits the requests of all access keys of a tenant
+// together, with a token bucket per tenant. It must run inside the auth
+// middleware; anonymous requests are not limited by it.
+type TenantRateLimiter struct {
+	limits  TenantLimitProvider
+	buckets sync.Map // map[int64]*bucket
+	metrics *metrics.Metrics
+	logger  zerolog.Logger
+}
+
+// NewTenantRateLimiter creates a new tenant rate limiter.
+func NewTenantRateLimiter(limits TenantLimitProvider, m *metrics.Metrics, logger zerolog.Logger) *TenantRateLimiter {
+	return &TenantRateLimiter{
+		limits:  limits,
+		metrics: m,
+		logger:  logger.With().Str("component", "tenant_ratelimiter").Logger(),
+	}
+}
+
+// Middleware returns the tenant rate limiting middleware.
+func (tl *TenantRateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authCtx := auth.GetAuthContext(r.Context())
+		if authCtx == nil || authCtx.TenantID == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		requestsPerSecond, burstSize := tl.limits.RateLimit(r.Context(), authCtx.TenantID)
+		if requestsPerSecond <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !tl.getBucket(authCtx.TenantID, burstSize).take(requestsPerSecond, burstSize) {
+			tl.logger.Warn().
+				Int64("tenant_id", authCtx.TenantID).
+				Str("access_key_id", authCtx.AccessKeyID).
+				Str("path", r.URL.Path).
+				Msg("Tenant rate limit exceeded")
+
+			if tl.metrics != nil {
+				tl.metrics.RecordRateLimited("tenant")
+			}
+
+			writeSlowDown(w)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// getBucket gets or creates the bucket of a tenant. There are few tenants,
+// so their buckets are never cleaned up.
+func (tl *TenantRateLimiter) getBucket(tenantID int64, burstSize int) *bucket {
+	if b, ok := tl.buckets.Load(tenantID); ok {
+		return b.(*bucket)
+	}
+
+	b := &bucket{
+		tokens:     float64(burstSize),
+		lastRefill: time.Now(),
+	}
+
+	actual, _ := tl.buckets.LoadOrStore(tenantID, b)
+	return actual.(*bucket)
+}
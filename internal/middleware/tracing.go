@@ -3,13 +3,16 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 
+	"github.com/prn-tf/alexander-storage/internal/auth"
 	"github.com/prn-tf/alexander-storage/internal/metrics"
+	"github.com/prn-tf/alexander-storage/internal/pkg/telemetry"
 )
 
 // Context keys for tracing.
@@ -27,6 +30,12 @@ const (
 
 	// RequestStartKey is the context key for request start time.
 	RequestStartKey contextKey = "request_start"
+
+	// BucketKey is the context key for the bucket a request addresses.
+	BucketKey contextKey = "bucket"
+
+	// ObjectKeyKey is the context key for the object key a request addresses.
+	ObjectKeyKey contextKey = "object_key"
 )
 
 // Header names for tracing.
@@ -36,6 +45,7 @@ const (
 	HeaderSpanID       = "X-Span-ID"
 	HeaderAmzRequestID = "x-amz-request-id"
 	HeaderAmzID2       = "x-amz-id-2"
+	HeaderTraceParent  = "traceparent"
 )
 
 // Tracing provides request tracing and correlation ID middleware.
@@ -63,17 +73,33 @@ func (t *Tracing) Middleware(next http.Handler) http.Handler {
 			requestID = generateID()
 		}
 
-		// Extract or generate trace ID
+		// Normalize path for metrics and span names (avoid high cardinality)
+		metricPath := normalizePath(r.URL.Path)
+
+		// Continue the caller's trace, if any, with a server span
+		ctx := r.Context()
+		if sc, ok := telemetry.ParseTraceParent(r.Header.Get(HeaderTraceParent)); ok {
+			ctx = telemetry.ContextWithSpanContext(ctx, sc)
+		}
+		ctx, span := telemetry.StartKind(ctx, r.Method+" "+metricPath, telemetry.KindServer)
+		defer span.End()
+
+		// Extract or generate trace ID, preferring the OpenTelemetry trace
+		// so that logs can be correlated with exported spans
 		traceID := r.Header.Get(HeaderTraceID)
-		if traceID == "" {
+		if sc := telemetry.SpanContextFromContext(ctx); sc.IsValid() {
+			traceID = sc.TraceID.String()
+		} else if traceID == "" {
 			traceID = generateID()
 		}
 
 		// Generate new span ID for this request
 		spanID := generateShortID()
+		if span != nil {
+			spanID = span.SpanContext().SpanID.String()
+		}
 
 		// Add to context
-		ctx := r.Context()
 		ctx = context.WithValue(ctx, RequestIDKey, requestID)
 		ctx = context.WithValue(ctx, TraceIDKey, traceID)
 		ctx = context.WithValue(ctx, SpanIDKey, spanID)
@@ -104,8 +130,15 @@ func (t *Tracing) Middleware(next http.Handler) http.Handler {
 		// Calculate duration
 		duration := time.Since(start)
 
-		// Normalize path for metrics (avoid high cardinality)
-		metricPath := normalizePath(r.URL.Path)
+		span.SetAttribute("http.request.method", r.Method)
+		span.SetAttribute("url.path", r.URL.Path)
+		span.SetAttribute("http.response.status_code", wrapped.statusCode)
+		span.SetAttribute("client.address", r.RemoteAddr)
+		span.SetAttribute("user_agent.original", r.UserAgent())
+		span.SetAttribute("request_id", requestID)
+		if wrapped.statusCode >= 500 {
+			span.SetError(errors.New(http.StatusText(wrapped.statusCode)))
+		}
 
 		// Record metrics
 		if t.metrics != nil {
@@ -164,6 +197,12 @@ func (rw *responseWriter) Flush() {
 	}
 }
 
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to
+// clear the write deadline for long-lived streams.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 // generateID generates a unique request ID.
 func generateID() string {
 	return uuid.New().String()
@@ -256,6 +295,57 @@ func GetRequestStart(ctx context.Context) time.Time {
 	return time.Time{}
 }
 
+// WithBucket returns a context carrying the bucket a request addresses.
+func WithBucket(ctx context.Context, bucket string) context.Context {
+	return context.WithValue(ctx, BucketKey, bucket)
+}
+
+// GetBucket extracts the bucket a request addresses from context.
+func GetBucket(ctx context.Context) string {
+	if v := ctx.Value(BucketKey); v != nil {
+		return v.(string)
+	}
+	return ""
+}
+
+// WithObjectKey returns a context carrying the object key a request addresses.
+func WithObjectKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, ObjectKeyKey, key)
+}
+
+// GetObjectKey extracts the object key a request addresses from context.
+func GetObjectKey(ctx context.Context) string {
+	if v := ctx.Value(ObjectKeyKey); v != nil {
+		return v.(string)
+	}
+	return ""
+}
+
+// RequestLogHook adds the request ID, access key, bucket and key of the request
+// an event is logged for, so service logs can be correlated with a client
+// request. Events are tied to a request with Event.Ctx; others are left
+// unchanged.
+type RequestLogHook struct{}
+
+// Run implements zerolog.Hook.
+func (RequestLogHook) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
+	ctx := e.GetCtx()
+	requestID := GetRequestID(ctx)
+	if requestID == "" {
+		return
+	}
+	e.Str("request_id", requestID)
+	if authCtx := auth.GetAuthContext(ctx); authCtx != nil && authCtx.AccessKeyID != "" {
+		e.Str("access_key_id", authCtx.AccessKeyID)
+	}
+	if bucket := GetBucket(ctx); bucket != "" {
+		e.Str("request_bucket", bucket)
+	}
+	if key := GetObjectKey(ctx); key != "" {
+		e.Str("request_key", key)
+	}
+}
+
 // LoggerWithTrace returns a logger with trace context fields.
 func LoggerWithTrace(ctx context.Context, logger zerolog.Logger) zerolog.Logger {
 	return logger.With().
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestLogHook(t *testing.T) {
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf).Hook(RequestLogHook{})
+
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := WithObjectKey(WithBucket(r.Context(), "photos"), "cat.jpg")
+		logger.Info().Ctx(ctx).Msg("object stored")
+	})
+	handler := NewTracing(nil, zerolog.Nop()).Middleware(fakeAuth(inner))
+
+	req := httptest.NewRequest(http.MethodPut, "/photos/cat.jpg", nil)
+	req.Header.Set("X-Test-User", "alice")
+	req.Header.Set(HeaderRequestID, "req-1")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, "req-1", w.Header().Get(HeaderAmzRequestID))
+	assert.NotEmpty(t, w.Header().Get(HeaderAmzID2))
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, "req-1", line["request_id"])
+	assert.Equal(t, "AKIATEST", line["access_key_id"])
+	assert.Equal(t, "photos", line["request_bucket"])
+	assert.Equal(t, "cat.jpg", line["request_key"])
+
+	// Events outside a request are unchanged
+	buf.Reset()
+	logger.Info().Ctx(context.Background()).Msg("background")
+	line = nil
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.NotContains(t, line, "request_id")
+}
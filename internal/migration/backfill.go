@@ -0,0 +1,240 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// Backfill is a data migration that rewrites rows in small batches while the
+// server keeps serving, instead of a single UPDATE that locks a large table
+// for its whole duration. Its progress is persisted after every batch, so it
+// resumes where it stopped after a restart.
+type Backfill struct {
+	// Name identifies the backfill's progress. A shipped backfill must never
+	// be renamed, or it starts over.
+	Name string
+
+	// Batch processes up to limit rows with an ID greater than after, in ID
+	// order, and returns the highest ID it looked at and the number of rows.
+	// It runs in the same transaction as the progress update, so a batch is
+	// either fully applied and recorded or not at all. Returning zero rows
+	// ends the backfill.
+	Batch func(ctx context.Context, after int64, limit int) (last int64, rows int, err error)
+
+	// Finalize, if set, runs once after the last batch and outside of a
+	// transaction, e.g. to validate a constraint added NOT VALID.
+	Finalize func(ctx context.Context) error
+}
+
+// BackfillConfig contains backfill runner configuration.
+type BackfillConfig struct {
+	// BatchSize is the number of rows per batch. Default: 1000.
+	BatchSize int
+
+	// Pause is the delay between batches, leaving room for regular
+	// traffic and replication. Default: 100ms.
+	Pause time.Duration
+}
+
+// BackfillRunner runs backfills.
+type BackfillRunner struct {
+	repo      repository.BackfillRepository
+	txManager repository.TxManager
+	logger    zerolog.Logger
+	config    BackfillConfig
+
+	// Control
+	mu       sync.Mutex
+	running  bool
+	cancel   context.CancelFunc
+	doneChan chan struct{}
+}
+
+// NewBackfillRunner creates a new backfill runner.
+func NewBackfillRunner(
+	repo repository.BackfillRepository,
+	txManager repository.TxManager,
+	logger zerolog.Logger,
+	config BackfillConfig,
+) *BackfillRunner {
+	if config.BatchSize <= 0 {
+		config.BatchSize = 1000
+	}
+	if config.Pause <= 0 {
+		config.Pause = 100 * time.Millisecond
+	}
+
+	return &BackfillRunner{
+		repo:      repo,
+		txManager: txManager,
+		logger:    logger.With().Str("component", "backfill").Logger(),
+		config:    config,
+	}
+}
+
+// Start runs the backfills in order in the background. A failed backfill is
+// logged and stops the run; it resumes on the next start.
+func (r *BackfillRunner) Start(backfills []Backfill) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.running || len(backfills) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r.running = true
+	r.cancel = cancel
+	r.doneChan = make(chan struct{})
+
+	go func() {
+		defer close(r.doneChan)
+		if err := r.RunAll(ctx, backfills); err != nil && !errors.Is(err, context.Canceled) {
+			r.logger.Error().Err(err).Msg("Backfill failed; it resumes on the next start")
+		}
+	}()
+}
+
+// Stop interrupts a background run after the current batch.
+func (r *BackfillRunner) Stop() {
+	r.mu.Lock()
+	if !r.running {
+		r.mu.Unlock()
+		return
+	}
+	r.running = false
+	r.mu.Unlock()
+
+	r.cancel()
+	<-r.doneChan
+}
+
+// RunAll runs the backfills in order, stopping at the first error.
+func (r *BackfillRunner) RunAll(ctx context.Context, backfills []Backfill) error {
+	for _, backfill := range backfills {
+		if err := r.Run(ctx, backfill); err != nil {
+			return fmt.Errorf("backfill %s: %w", backfill.Name, err)
+		}
+	}
+	return nil
+}
+
+// Run runs a backfill to completion, resuming from its saved progress. It
+// returns immediately if the backfill has already completed.
+func (r *BackfillRunner) Run(ctx context.Context, backfill Backfill) error {
+	logger := r.logger.With().Str("backfill", backfill.Name).Logger()
+	lastLog := time.Now()
+	started := false
+
+	for {
+		var progress *domain.BackfillProgress
+		var rows int
+		err := r.txManager.WithTx(ctx, func(ctx context.Context) error {
+			var err error
+			progress, err = r.repo.Acquire(ctx, backfill.Name)
+			if err != nil || progress.IsCompleted() {
+				return err
+			}
+
+			var last int64
+			last, rows, err = backfill.Batch(ctx, progress.Cursor, r.config.BatchSize)
+			if err != nil || rows == 0 {
+				return err
+			}
+
+			progress.Cursor = last
+			progress.RowsDone += int64(rows)
+			progress.UpdatedAt = time.Now().UTC()
+			return r.repo.Update(ctx, progress)
+		})
+		if err != nil {
+			return err
+		}
+		if progress.IsCompleted() {
+			if started {
+				logger.Info().Msg("Backfill completed by another runner")
+			}
+			return nil
+		}
+		if !started {
+			logger.Info().Int64("cursor", progress.Cursor).Msg("Running backfill")
+			started = true
+		}
+
+		if rows == 0 {
+			return r.complete(ctx, backfill, logger)
+		}
+
+		if time.Since(lastLog) >= 10*time.Second {
+			logger.Info().
+				Int64("cursor", progress.Cursor).
+				Int64("rows_done", progress.RowsDone).
+				Msg("Backfill progress")
+			lastLog = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(r.config.Pause):
+		}
+	}
+}
+
+// complete runs the finalize step and marks the backfill completed.
+func (r *BackfillRunner) complete(ctx context.Context, backfill Backfill, logger zerolog.Logger) error {
+	if backfill.Finalize != nil {
+		if err := backfill.Finalize(ctx); err != nil {
+			return fmt.Errorf("finalize: %w", err)
+		}
+	}
+
+	var rowsDone int64
+	err := r.txManager.WithTx(ctx, func(ctx context.Context) error {
+		progress, err := r.repo.Acquire(ctx, backfill.Name)
+		if err != nil || progress.IsCompleted() {
+			return err
+		}
+		now := time.Now().UTC()
+		progress.UpdatedAt = now
+		progress.CompletedAt = &now
+		rowsDone = progress.RowsDone
+		return r.repo.Update(ctx, progress)
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Info().Int64("rows_done", rowsDone).Msg("Backfill completed")
+	return nil
+}
+
+// Status returns the progress of the given backfills, in the same order.
+// Backfills that have not started yet have zero progress.
+func (r *BackfillRunner) Status(ctx context.Context, backfills []Backfill) ([]*domain.BackfillProgress, error) {
+	saved, err := r.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]*domain.BackfillProgress, len(saved))
+	for _, progress := range saved {
+		byName[progress.Name] = progress
+	}
+
+	result := make([]*domain.BackfillProgress, 0, len(backfills))
+	for _, backfill := range backfills {
+		progress, ok := byName[backfill.Name]
+		if !ok {
+			progress = &domain.BackfillProgress{Name: backfill.Name}
+		}
+		result = append(result, progress)
+	}
+	return result, nil
+}
@@ -0,0 +1,151 @@
+package migration_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/migration"
+	"github.com/prn-tf/alexander-storage/internal/repository/sqlite"
+)
+
+// rowTable is a table of row IDs and values that a test backfill doubles.
+type rowTable struct {
+	ids    []int64
+	values map[int64]int
+
+	// failAfter makes Batch fail once it is called with this cursor.
+	failAfter int64
+	finalized int
+}
+
+func newRowTable(n int) *rowTable {
+	t := &rowTable{values: make(map[int64]int), failAfter: -1}
+	for i := 1; i <= n; i++ {
+		t.ids = append(t.ids, int64(i))
+		t.values[int64(i)] = i
+	}
+	return t
+}
+
+func (t *rowTable) backfill() migration.Backfill {
+	return migration.Backfill{
+		Name: "double_values",
+		Batch: func(ctx context.Context, after int64, limit int) (int64, int, error) {
+			if after == t.failAfter {
+				t.failAfter = -1
+				return 0, 0, errors.New("database unavailable")
+			}
+			var last int64
+			rows := 0
+			for _, id := range t.ids {
+				if id > after && rows < limit {
+					t.values[id] *= 2
+					last = id
+					rows++
+				}
+			}
+			return last, rows, nil
+		},
+		Finalize: func(ctx context.Context) error {
+			t.finalized++
+			return nil
+		},
+	}
+}
+
+// newTestRunner returns a runner backed by SQLite that processes three rows
+// per batch and waits pause between batches.
+func newTestRunner(t *testing.T, pause time.Duration) *migration.BackfillRunner {
+	t.Helper()
+	ctx := context.Background()
+
+	db, err := sqlite.NewDB(ctx, sqlite.DefaultConfig(filepath.Join(t.TempDir(), "alexander.db")), zerolog.Nop())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, db.Migrate(ctx))
+
+	return migration.NewBackfillRunner(sqlite.NewBackfillRepository(db), sqlite.NewTxManager(db), zerolog.Nop(), migration.BackfillConfig{
+		BatchSize: 3,
+		Pause:     pause,
+	})
+}
+
+func TestBackfillRunner_RunsToCompletion(t *testing.T) {
+	ctx := context.Background()
+	runner := newTestRunner(t, time.Millisecond)
+	table := newRowTable(10)
+
+	require.NoError(t, runner.Run(ctx, table.backfill()))
+
+	for id, value := range table.values {
+		assert.Equal(t, int(id)*2, value, "row %d", id)
+	}
+	assert.Equal(t, 1, table.finalized)
+
+	status, err := runner.Status(ctx, []migration.Backfill{table.backfill()})
+	require.NoError(t, err)
+	require.Len(t, status, 1)
+	assert.True(t, status[0].IsCompleted())
+	assert.Equal(t, int64(10), status[0].RowsDone)
+	assert.Equal(t, int64(10), status[0].Cursor)
+
+	// A completed backfill does not run again
+	require.NoError(t, runner.Run(ctx, table.backfill()))
+	assert.Equal(t, 20, table.values[10])
+	assert.Equal(t, 1, table.finalized)
+}
+
+func TestBackfillRunner_ResumesAfterFailure(t *testing.T) {
+	ctx := context.Background()
+	runner := newTestRunner(t, time.Millisecond)
+	table := newRowTable(10)
+	table.failAfter = 6
+
+	err := runner.Run(ctx, table.backfill())
+	require.Error(t, err)
+	assert.Equal(t, 0, table.finalized)
+
+	status, err := runner.Status(ctx, []migration.Backfill{table.backfill()})
+	require.NoError(t, err)
+	assert.False(t, status[0].IsCompleted())
+	assert.Equal(t, int64(6), status[0].Cursor)
+
+	// The second run continues after row 6 without processing rows twice
+	require.NoError(t, runner.Run(ctx, table.backfill()))
+	for id, value := range table.values {
+		assert.Equal(t, int(id)*2, value, "row %d", id)
+	}
+	assert.Equal(t, 1, table.finalized)
+}
+
+func TestBackfillRunner_StatusOfPendingBackfill(t *testing.T) {
+	runner := newTestRunner(t, time.Millisecond)
+
+	status, err := runner.Status(context.Background(), []migration.Backfill{{Name: "not_started"}})
+	require.NoError(t, err)
+	require.Len(t, status, 1)
+	assert.Equal(t, "not_started", status[0].Name)
+	assert.False(t, status[0].IsCompleted())
+	assert.Zero(t, status[0].RowsDone)
+}
+
+func TestBackfillRunner_StopInterruptsRun(t *testing.T) {
+	runner := newTestRunner(t, time.Hour)
+	table := newRowTable(10)
+
+	runner.Start([]migration.Backfill{table.backfill()})
+	time.Sleep(50 * time.Millisecond)
+	runner.Stop()
+
+	status, err := runner.Status(context.Background(), []migration.Backfill{table.backfill()})
+	require.NoError(t, err)
+	assert.False(t, status[0].IsCompleted())
+	assert.Equal(t, int64(3), status[0].RowsDone)
+}
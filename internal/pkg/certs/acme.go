@@ -0,0 +1,343 @@
+package certs
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"golang.org/x/crypto/acme"
+)
+
+// acmeALPNProto is the ALPN protocol of TLS-ALPN-01 challenges (RFC 8737).
+const acmeALPNProto = "acme-tls/1"
+
+// acmeRetryInterval is the wait after a failed issuance.
+const acmeRetryInterval = time.Hour
+
+// ACMEOptions configures certificates obtained from an ACME certificate
+// authority. Domains are validated with the TLS-ALPN-01 challenge, so the
+// listener must be reachable on port 443 under every domain.
+type ACMEOptions struct {
+	// Domains are the names on the certificate.
+	Domains []string
+
+	// Email is the contact address of the ACME account.
+	Email string
+
+	// CacheDir keeps the account key and the certificate across restarts.
+	CacheDir string
+
+	// DirectoryURL is the ACME directory. Empty uses Let's Encrypt.
+	DirectoryURL string
+
+	// RenewBefore renews the certificate this long before it expires.
+	RenewBefore time.Duration
+}
+
+// acmeManager obtains and renews a certificate for the configured domains.
+type acmeManager struct {
+	opts   ACMEOptions
+	client *acme.Client
+	logger zerolog.Logger
+
+	mu         sync.RWMutex
+	cert       *tls.Certificate
+	challenges map[string]*tls.Certificate // By domain
+}
+
+// newACMEManager loads or creates the account key and loads the cached
+// certificate, if any.
+func newACMEManager(opts ACMEOptions, logger zerolog.Logger) (*acmeManager, error) {
+	if len(opts.Domains) == 0 || opts.CacheDir == "" {
+		return nil, errors.New("certs: ACME needs domains and a cache directory")
+	}
+	if opts.DirectoryURL == "" {
+		opts.DirectoryURL = acme.LetsEncryptURL
+	}
+	if opts.RenewBefore <= 0 {
+		opts.RenewBefore = 30 * 24 * time.Hour
+	}
+	if err := os.MkdirAll(opts.CacheDir, 0o700); err != nil {
+		return nil, fmt.Errorf("certs: failed to create ACME cache directory: %w", err)
+	}
+
+	key, err := loadOrCreateKey(filepath.Join(opts.CacheDir, "acme_account.key"))
+	if err != nil {
+		return nil, err
+	}
+
+	m := &acmeManager{
+		opts:       opts,
+		client:     &acme.Client{Key: key, DirectoryURL: opts.DirectoryURL},
+		logger:     logger.With().Strs("domains", opts.Domains).Logger(),
+		challenges: make(map[string]*tls.Certificate),
+	}
+
+	data, err := os.ReadFile(m.certPath())
+	if err == nil {
+		cert, err := tls.X509KeyPair(data, data)
+		if err == nil && m.covers(&cert) {
+			m.cert = &cert
+		} else {
+			m.logger.Warn().Msg("ignoring cached ACME certificate that does not match the configured domains")
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("certs: failed to read cached certificate: %w", err)
+	}
+
+	return m, nil
+}
+
+// run obtains a certificate when there is none or it is due for renewal,
+// then sleeps until the next renewal.
+func (m *acmeManager) run(ctx context.Context) {
+	for {
+		wait := m.renewIn()
+		if wait <= 0 {
+			if err := m.obtain(ctx); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				m.logger.Error().Err(err).Dur("retry_in", acmeRetryInterval).Msg("failed to obtain ACME certificate")
+				wait = acmeRetryInterval
+			} else {
+				continue
+			}
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// renewIn returns the time until the certificate is due for renewal.
+func (m *acmeManager) renewIn() time.Duration {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.cert == nil {
+		return 0
+	}
+	return time.Until(m.cert.Leaf.NotAfter.Add(-m.opts.RenewBefore))
+}
+
+// obtain orders, validates and stores a new certificate.
+func (m *acmeManager) obtain(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	account := &acme.Account{}
+	if m.opts.Email != "" {
+		account.Contact = []string{"mailto:" + m.opts.Email}
+	}
+	if _, err := m.client.Register(ctx, account, acme.AcceptTOS); err != nil && !errors.Is(err, acme.ErrAccountAlreadyExists) {
+		return fmt.Errorf("failed to register ACME account: %w", err)
+	}
+
+	order, err := m.client.AuthorizeOrder(ctx, acme.DomainIDs(m.opts.Domains...))
+	if err != nil {
+		return fmt.Errorf("failed to create order: %w", err)
+	}
+	for _, url := range order.AuthzURLs {
+		if err := m.authorize(ctx, url); err != nil {
+			return err
+		}
+	}
+	if order, err = m.client.WaitOrder(ctx, order.URI); err != nil {
+		return fmt.Errorf("order not ready: %w", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: m.opts.Domains[0]},
+		DNSNames: m.opts.Domains,
+	}, key)
+	if err != nil {
+		return err
+	}
+	chain, _, err := m.client.CreateOrderCert(ctx, order.FinalizeURL, csr, true)
+	if err != nil {
+		return fmt.Errorf("failed to finalize order: %w", err)
+	}
+
+	leaf, err := x509.ParseCertificate(chain[0])
+	if err != nil {
+		return err
+	}
+	cert := &tls.Certificate{Certificate: chain, PrivateKey: key, Leaf: leaf}
+	if err := m.save(cert, key); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.cert = cert
+	m.mu.Unlock()
+
+	m.logger.Info().Time("expires_at", leaf.NotAfter).Msg("obtained ACME certificate")
+	return nil
+}
+
+// authorize completes the TLS-ALPN-01 challenge of an authorization.
+func (m *acmeManager) authorize(ctx context.Context, url string) error {
+	authz, err := m.client.GetAuthorization(ctx, url)
+	if err != nil {
+		return fmt.Errorf("failed to get authorization: %w", err)
+	}
+	if authz.Status == acme.StatusValid {
+		return nil
+	}
+
+	domain := authz.Identifier.Value
+	var challenge *acme.Challenge
+	for _, c := range authz.Challenges {
+		if c.Type == "tls-alpn-01" {
+			challenge = c
+			break
+		}
+	}
+	if challenge == nil {
+		return fmt.Errorf("no tls-alpn-01 challenge offered for %s", domain)
+	}
+
+	cert, err := m.client.TLSALPN01ChallengeCert(challenge.Token, domain)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.challenges[domain] = &cert
+	m.mu.Unlock()
+	defer func() {
+		m.mu.Lock()
+		delete(m.challenges, domain)
+		m.mu.Unlock()
+	}()
+
+	if _, err := m.client.Accept(ctx, challenge); err != nil {
+		return fmt.Errorf("failed to accept challenge for %s: %w", domain, err)
+	}
+	if _, err := m.client.WaitAuthorization(ctx, authz.URI); err != nil {
+		return fmt.Errorf("failed to validate %s: %w", domain, err)
+	}
+	return nil
+}
+
+// getCertificate answers challenge handshakes with the challenge
+// certificate and all others with the current certificate.
+func (m *acmeManager) getCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if slices.Contains(hello.SupportedProtos, acmeALPNProto) {
+		if cert, ok := m.challenges[hello.ServerName]; ok {
+			return cert, nil
+		}
+		return nil, fmt.Errorf("certs: no pending challenge for %q", hello.ServerName)
+	}
+	if m.cert == nil {
+		return nil, errors.New("certs: ACME certificate not obtained yet")
+	}
+	return m.cert, nil
+}
+
+// covers reports whether a certificate names every configured domain.
+func (m *acmeManager) covers(cert *tls.Certificate) bool {
+	if cert.Leaf == nil {
+		return false
+	}
+	for _, domain := range m.opts.Domains {
+		if cert.Leaf.VerifyHostname(domain) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// certPath is the cache file of the certificate and its key.
+func (m *acmeManager) certPath() string {
+	return filepath.Join(m.opts.CacheDir, "acme_cert.pem")
+}
+
+// save writes the key and certificate chain to the cache file.
+func (m *acmeManager) save(cert *tls.Certificate, key crypto.Signer) error {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	for _, c := range cert.Certificate {
+		data = append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c})...)
+	}
+	return writeFile(m.certPath(), data)
+}
+
+// loadOrCreateKey reads an ECDSA key from a PEM file, creating it if it
+// does not exist.
+func loadOrCreateKey(path string) (crypto.Signer, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("certs: invalid key file %s", path)
+		}
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("certs: invalid key file %s: %w", path, err)
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("certs: invalid key file %s", path)
+		}
+		return signer, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("certs: failed to read key file: %w", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// writeFile writes a private file through a temporary file, so readers
+// never see a partial file.
+func writeFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("certs: failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("certs: failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+// Package certs provides the TLS configuration of the HTTPS listener:
+// certificates loaded from files and reloaded on demand, or obtained and
+// renewed from an ACME certificate authority such as Let's Encrypt, and
+// optional client certificate verification (mTLS).
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/rs/zerolog"
+)
+
+// Options configures a Manager. Either CertFile and KeyFile or ACME must
+// be set.
+type Options struct {
+	// CertFile and KeyFile are PEM files holding the certificate chain and
+	// its private key.
+	CertFile string
+	KeyFile  string
+
+	// ACME obtains certificates from an ACME certificate authority instead.
+	ACME *ACMEOptions
+
+	// MinVersion is the minimum TLS version, e.g. tls.VersionTLS12.
+	MinVersion uint16
+
+	// ClientCAFile is a PEM bundle of CAs that sign client certificates.
+	// If set, clients are verified according to ClientAuth.
+	ClientCAFile string
+	ClientAuth   tls.ClientAuthType
+
+	Logger zerolog.Logger
+}
+
+// Manager serves the certificates of the HTTPS listener.
+type Manager struct {
+	opts   Options
+	acme   *acmeManager
+	logger zerolog.Logger
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	clientCA *x509.CertPool
+}
+
+// New creates a Manager and loads the configured certificate files. With
+// ACME, certificates are obtained by Run.
+func New(opts Options) (*Manager, error) {
+	if opts.ACME == nil && (opts.CertFile == "" || opts.KeyFile == "") {
+		return nil, errors.New("certs: a certificate and key file or ACME is required")
+	}
+	if opts.MinVersion == 0 {
+		opts.MinVersion = tls.VersionTLS12
+	}
+
+	m := &Manager{
+		opts:   opts,
+		logger: opts.Logger.With().Str("component", "certs").Logger(),
+	}
+	if opts.ACME != nil {
+		acme, err := newACMEManager(*opts.ACME, m.logger)
+		if err != nil {
+			return nil, err
+		}
+		m.acme = acme
+	}
+	if err := m.Reload(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// TLSConfig returns the configuration for the HTTPS listener. Certificates
+// and client CAs are looked up per connection, so reloads apply to new
+// connections without restarting the listener.
+func (m *Manager) TLSConfig() *tls.Config {
+	config := &tls.Config{
+		MinVersion:     m.opts.MinVersion,
+		GetCertificate: m.getCertificate,
+	}
+	if m.acme != nil {
+		// net/http adds the HTTP protocols it serves
+		config.NextProtos = []string{acmeALPNProto}
+	}
+	if m.opts.ClientCAFile != "" {
+		// Clients are verified against the current CA pool here rather than
+		// through ClientCAs, so a reload applies to new connections
+		config.ClientAuth = tls.RequestClientCert
+		if m.opts.ClientAuth == tls.RequireAndVerifyClientCert {
+			config.ClientAuth = tls.RequireAnyClientCert
+		}
+		config.VerifyPeerCertificate = m.verifyClient
+	}
+	return config
+}
+
+// Reload reads the certificate, key and client CA files again. On error,
+// the certificates already loaded stay in use.
+func (m *Manager) Reload() error {
+	var cert *tls.Certificate
+	if m.acme == nil {
+		pair, err := tls.LoadX509KeyPair(m.opts.CertFile, m.opts.KeyFile)
+		if err != nil {
+			return fmt.Errorf("certs: failed to load certificate: %w", err)
+		}
+		cert = &pair
+	}
+
+	var clientCA *x509.CertPool
+	if m.opts.ClientCAFile != "" {
+		data, err := os.ReadFile(m.opts.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("certs: failed to read client CA file: %w", err)
+		}
+		clientCA = x509.NewCertPool()
+		if !clientCA.AppendCertsFromPEM(data) {
+			return fmt.Errorf("certs: no certificates found in %s", m.opts.ClientCAFile)
+		}
+	}
+
+	m.mu.Lock()
+	if cert != nil {
+		m.cert = cert
+	}
+	m.clientCA = clientCA
+	m.mu.Unlock()
+	return nil
+}
+
+// Run obtains and renews ACME certificates until ctx is done. Without
+// ACME it returns immediately.
+func (m *Manager) Run(ctx context.Context) {
+	if m.acme != nil {
+		m.acme.run(ctx)
+	}
+}
+
+// verifyClient verifies a client certificate chain against the client CAs.
+// Clients without a certificate are rejected by ClientAuth if required.
+func (m *Manager) verifyClient(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+	if len(rawCerts) == 0 {
+		return nil
+	}
+
+	chain := make([]*x509.Certificate, len(rawCerts))
+	for i, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return fmt.Errorf("certs: invalid client certificate: %w", err)
+		}
+		chain[i] = cert
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+	m.mu.RLock()
+	roots := m.clientCA
+	m.mu.RUnlock()
+
+	_, err := chain[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil {
+		return fmt.Errorf("certs: client certificate not trusted: %w", err)
+	}
+	return nil
+}
+
+// getCertificate returns the certificate for a TLS handshake.
+func (m *Manager) getCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	if m.acme != nil {
+		return m.acme.getCertificate(hello)
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.cert, nil
+}
+
+// ParseVersion parses a minimum TLS version such as "1.2".
+func ParseVersion(version string) (uint16, error) {
+	switch version {
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("certs: unsupported TLS version %q (use 1.2 or 1.3)", version)
+	}
+}
+
+// ParseClientAuth parses how client certificates are verified: "require"
+// rejects clients without a valid certificate, "verify_if_given" only
+// rejects invalid ones.
+func ParseClientAuth(mode string) (tls.ClientAuthType, error) {
+	switch mode {
+	case "", "require":
+		return tls.RequireAndVerifyClientCert, nil
+	case "verify_if_given":
+		return tls.VerifyClientCertIfGiven, nil
+	default:
+		return 0, fmt.Errorf("certs: unsupported client auth mode %q (use require or verify_if_given)", mode)
+	}
+}
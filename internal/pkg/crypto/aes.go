@@ -10,6 +10,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strconv"
+	"strings"
 )
 
 const (
@@ -38,29 +40,47 @@ var (
 	ErrDecryptionFailed = errors.New("decryption failed: authentication error")
 )
 
-// Encryptor provides AES-256-GCM encryption and decryption.
+// Encryptor provides AES-256-GCM encryption and decryption with a
+// versioned key. Ciphertext of key versions above 1 is tagged with its
+// version ("v2:..."), so rotated keys can still decrypt older secrets.
+// Version 1 ciphertext is untagged, as it was before keys had versions.
 type Encryptor struct {
-	gcm cipher.AEAD
+	gcms    map[int]cipher.AEAD
+	current int
 }
 
 // NewEncryptor creates a new Encryptor with the given master key.
 // The key must be exactly 32 bytes (256 bits).
 func NewEncryptor(masterKey []byte) (*Encryptor, error) {
-	if len(masterKey) != KeySize {
-		return nil, ErrInvalidKeySize
-	}
+	return NewVersionedEncryptor(map[int][]byte{1: masterKey}, 1)
+}
 
-	block, err := aes.NewCipher(masterKey)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
+// NewVersionedEncryptor creates an Encryptor that encrypts with the
+// current key version and decrypts with any of the given versions.
+func NewVersionedEncryptor(keys map[int][]byte, current int) (*Encryptor, error) {
+	if _, ok := keys[current]; !ok {
+		return nil, fmt.Errorf("current key version %d is missing", current)
 	}
 
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	gcms := make(map[int]cipher.AEAD, len(keys))
+	for version, key := range keys {
+		if len(key) != KeySize {
+			return nil, ErrInvalidKeySize
+		}
+
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create AES cipher: %w", err)
+		}
+
+		gcm, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create GCM: %w", err)
+		}
+		gcms[version] = gcm
 	}
 
-	return &Encryptor{gcm: gcm}, nil
+	return &Encryptor{gcms: gcms, current: current}, nil
 }
 
 // NewEncryptorFromHex creates a new Encryptor from a hex-encoded master key.
@@ -73,8 +93,30 @@ func NewEncryptorFromHex(hexKey string) (*Encryptor, error) {
 	return NewEncryptor(key)
 }
 
-// Encrypt encrypts the plaintext and returns base64-encoded ciphertext.
-// The ciphertext format is: base64(nonce || ciphertext || tag)
+// CurrentVersion returns the key version used to encrypt.
+func (e *Encryptor) CurrentVersion() int {
+	return e.current
+}
+
+// KeyVersion returns the key version that encrypted a ciphertext.
+func (e *Encryptor) KeyVersion(encoded string) int {
+	version, _ := splitVersion(encoded)
+	return version
+}
+
+// splitVersion splits the version tag off a ciphertext.
+func splitVersion(encoded string) (int, string) {
+	if tag, rest, ok := strings.Cut(encoded, ":"); ok && strings.HasPrefix(tag, "v") {
+		if version, err := strconv.Atoi(tag[1:]); err == nil {
+			return version, rest
+		}
+	}
+	return 1, encoded
+}
+
+// Encrypt encrypts the plaintext with the current key version and returns
+// base64-encoded ciphertext.
+// The ciphertext format is: [v<version>:]base64(nonce || ciphertext || tag)
 func (e *Encryptor) Encrypt(plaintext []byte) (string, error) {
 	// Generate random nonce
 	nonce := make([]byte, NonceSize)
@@ -83,15 +125,25 @@ func (e *Encryptor) Encrypt(plaintext []byte) (string, error) {
 	}
 
 	// Encrypt with GCM (includes authentication tag)
-	ciphertext := e.gcm.Seal(nonce, nonce, plaintext, nil)
+	ciphertext := e.gcms[e.current].Seal(nonce, nonce, plaintext, nil)
 
-	// Return base64-encoded result
-	return base64.StdEncoding.EncodeToString(ciphertext), nil
+	// Return base64-encoded result, tagged with the key version
+	encoded := base64.StdEncoding.EncodeToString(ciphertext)
+	if e.current != 1 {
+		encoded = fmt.Sprintf("v%d:%s", e.current, encoded)
+	}
+	return encoded, nil
 }
 
 // Decrypt decrypts base64-encoded ciphertext and returns plaintext.
-// Expects format: base64(nonce || ciphertext || tag)
+// Expects format: [v<version>:]base64(nonce || ciphertext || tag)
 func (e *Encryptor) Decrypt(encoded string) ([]byte, error) {
+	version, encoded := splitVersion(encoded)
+	gcm, ok := e.gcms[version]
+	if !ok {
+		return nil, fmt.Errorf("%w: key version %d is not available", ErrDecryptionFailed, version)
+	}
+
 	// Decode from base64
 	ciphertext, err := base64.StdEncoding.DecodeString(encoded)
 	if err != nil {
@@ -99,7 +151,7 @@ func (e *Encryptor) Decrypt(encoded string) ([]byte, error) {
 	}
 
 	// Validate minimum length (nonce + at least 1 byte + tag)
-	minLength := NonceSize + 1 + e.gcm.Overhead()
+	minLength := NonceSize + 1 + gcm.Overhead()
 	if len(ciphertext) < minLength {
 		return nil, ErrInvalidCiphertext
 	}
@@ -109,7 +161,7 @@ func (e *Encryptor) Decrypt(encoded string) ([]byte, error) {
 	encryptedData := ciphertext[NonceSize:]
 
 	// Decrypt and verify
-	plaintext, err := e.gcm.Open(nil, nonce, encryptedData, nil)
+	plaintext, err := gcm.Open(nil, nonce, encryptedData, nil)
 	if err != nil {
 		return nil, ErrDecryptionFailed
 	}
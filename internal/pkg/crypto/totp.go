@@ -0,0 +1,102 @@
+package crypto
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TOTP parameters (RFC 6238), the defaults of authenticator apps.
+const (
+	// TOTPSecretSize is the size of a generated secret in bytes.
+	TOTPSecretSize = 20
+
+	// TOTPDigits is the number of digits in a code.
+	TOTPDigits = 6
+
+	// TOTPPeriod is how long a code is valid.
+	TOTPPeriod = 30 * time.Second
+
+	// totpSkew is the number of periods before and after the current one
+	// whose codes are also accepted, to allow for clock drift.
+	totpSkew = 1
+)
+
+// totpEncoding encodes secrets as authenticator apps expect them.
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret generates a random TOTP secret, base32 encoded.
+func GenerateTOTPSecret() (string, error) {
+	secret := make([]byte, TOTPSecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate TOTP secret: %w", err)
+	}
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+// TOTPCode returns the code of a base32 encoded secret at time t.
+func TOTPCode(secret string, t time.Time) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return "", fmt.Errorf("invalid TOTP secret: %w", err)
+	}
+	return totpCode(key, uint64(t.Unix())/uint64(TOTPPeriod/time.Second)), nil
+}
+
+// ValidateTOTP reports whether code is the code of secret at time t or in
+// the periods next to it.
+func ValidateTOTP(secret, code string, t time.Time) bool {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil || len(code) != TOTPDigits {
+		return false
+	}
+
+	counter := uint64(t.Unix()) / uint64(TOTPPeriod/time.Second)
+	valid := false
+	for i := -totpSkew; i <= totpSkew; i++ {
+		// Compare every candidate so the time taken does not depend on which matched
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, counter+uint64(i))), []byte(code)) == 1 {
+			valid = true
+		}
+	}
+	return valid
+}
+
+// TOTPURI returns the otpauth:// URI authenticator apps import a secret
+// from, usually as a QR code.
+func TOTPURI(issuer, account, secret string) string {
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", issuer)
+	params.Set("digits", fmt.Sprint(TOTPDigits))
+	params.Set("period", fmt.Sprint(int(TOTPPeriod/time.Second)))
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// totpCode computes the HOTP value (RFC 4226) of key for counter.
+func totpCode(key []byte, counter uint64) string {
+	var message [8]byte
+	binary.BigEndian.PutUint64(message[:], counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(message[:])
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulus := uint32(1)
+	for i := 0; i < TOTPDigits; i++ {
+		modulus *= 10
+	}
+	return fmt.Sprintf("%0*d", TOTPDigits, value%modulus)
+}
@@ -0,0 +1,235 @@
+// Package keys resolves the encryption keys of Alexander Storage from a
+// key provider: static configuration, environment variables, files,
+// HashiCorp Vault or AWS KMS.
+//
+// Every key is a keyring of numbered versions so keys can be rotated. The
+// highest version is current and encrypts new data; older versions stay
+// available to decrypt data written before the rotation until it has been
+// re-encrypted. Outside static configuration a keyring is written as
+// entries separated by newlines or commas, each "<version>:<key>", or just
+// "<key>" for a keyring with a single version 1 key:
+//
+//	2:8f0c...e1,1:3a9d...7b
+package keys
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Names of the keys used by the server.
+const (
+	// EncryptionKey encrypts access key secrets and replication credentials.
+	EncryptionKey = "encryption_key"
+
+	// SSEMasterKey derives the per-blob keys of SSE-S3 encrypted blobs.
+	SSEMasterKey = "sse_master_key"
+)
+
+// Size is the size of every key in bytes (256 bits).
+const Size = 32
+
+// ErrKeyNotFound is returned when a provider has no key of a name.
+var ErrKeyNotFound = errors.New("keys: key not found")
+
+// Key is one version of a key.
+type Key struct {
+	Version  int
+	Material []byte
+}
+
+// Keyring holds the versions of a key, newest first.
+type Keyring []Key
+
+// Current returns the newest version, which encrypts new data.
+func (k Keyring) Current() Key {
+	return k[0]
+}
+
+// Version returns the material of a version.
+func (k Keyring) Version(version int) ([]byte, bool) {
+	for _, key := range k {
+		if key.Version == version {
+			return key.Material, true
+		}
+	}
+	return nil, false
+}
+
+// Materials returns the material of every version by version number.
+func (k Keyring) Materials() map[int][]byte {
+	materials := make(map[int][]byte, len(k))
+	for _, key := range k {
+		materials[key.Version] = key.Material
+	}
+	return materials
+}
+
+// NewKeyring returns a keyring of the given keys. Versions must be
+// positive and unique and every key must be Size bytes.
+func NewKeyring(keys ...Key) (Keyring, error) {
+	if len(keys) == 0 {
+		return nil, ErrKeyNotFound
+	}
+	keyring := make(Keyring, len(keys))
+	copy(keyring, keys)
+	sort.Slice(keyring, func(i, j int) bool { return keyring[i].Version > keyring[j].Version })
+
+	for i, key := range keyring {
+		if key.Version < 1 {
+			return nil, fmt.Errorf("keys: invalid version %d", key.Version)
+		}
+		if i > 0 && keyring[i-1].Version == key.Version {
+			return nil, fmt.Errorf("keys: duplicate version %d", key.Version)
+		}
+		if len(key.Material) != Size {
+			return nil, fmt.Errorf("keys: version %d must be %d bytes, got %d", key.Version, Size, len(key.Material))
+		}
+	}
+	return keyring, nil
+}
+
+// Provider resolves keys by name.
+type Provider interface {
+	// Keyring returns every version of the named key, or ErrKeyNotFound.
+	Keyring(ctx context.Context, name string) (Keyring, error)
+}
+
+// ParseKeyring parses a keyring in text form. Keys are 64 hex characters,
+// standard base64 or, for compatibility with auth.encryption_key, 32 raw
+// characters.
+func ParseKeyring(text string) (Keyring, error) {
+	entries, err := parseEntries(text)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]Key, len(entries))
+	for i, entry := range entries {
+		keys[i] = Key{Version: entry.version, Material: decodeMaterial(entry.value)}
+	}
+	return NewKeyring(keys...)
+}
+
+// entry is one version of a keyring in text form, before decoding.
+type entry struct {
+	version int
+	value   string
+}
+
+// parseEntries splits a keyring in text form into its entries.
+func parseEntries(text string) ([]entry, error) {
+	var entries []entry
+	for _, field := range strings.FieldsFunc(text, func(r rune) bool { return r == '\n' || r == ',' }) {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		version := 1
+		if prefix, value, ok := strings.Cut(field, ":"); ok {
+			v, err := strconv.Atoi(prefix)
+			if err != nil {
+				return nil, fmt.Errorf("keys: invalid version %q", prefix)
+			}
+			version, field = v, value
+		}
+		entries = append(entries, entry{version: version, value: field})
+	}
+	if len(entries) == 0 {
+		return nil, ErrKeyNotFound
+	}
+	return entries, nil
+}
+
+// decodeMaterial decodes a key in hex or base64, or returns it as is.
+func decodeMaterial(value string) []byte {
+	if len(value) == 2*Size {
+		if material, err := hex.DecodeString(value); err == nil {
+			return material
+		}
+	}
+	if material, err := base64.StdEncoding.DecodeString(value); err == nil && len(material) == Size {
+		return material
+	}
+	return []byte(value)
+}
+
+// staticProvider serves fixed keyrings.
+type staticProvider map[string]Keyring
+
+// NewStaticProvider returns a provider of fixed keyrings, such as keys
+// read from the configuration file.
+func NewStaticProvider(keyrings map[string]Keyring) Provider {
+	return staticProvider(keyrings)
+}
+
+// Keyring implements Provider.
+func (p staticProvider) Keyring(_ context.Context, name string) (Keyring, error) {
+	keyring, ok := p[name]
+	if !ok || len(keyring) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, name)
+	}
+	return keyring, nil
+}
+
+// envProvider reads keyrings from environment variables.
+type envProvider struct {
+	prefix string
+}
+
+// NewEnvProvider returns a provider that reads the keyring of a key from
+// the environment variable named prefix plus the upper-cased key name, for
+// example ALEXANDER_KEY_SSE_MASTER_KEY.
+func NewEnvProvider(prefix string) Provider {
+	return envProvider{prefix: prefix}
+}
+
+// Keyring implements Provider.
+func (p envProvider) Keyring(_ context.Context, name string) (Keyring, error) {
+	variable := p.prefix + strings.ToUpper(name)
+	text, ok := os.LookupEnv(variable)
+	if !ok || strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("%w: %s is not set", ErrKeyNotFound, variable)
+	}
+	keyring, err := ParseKeyring(text)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", variable, err)
+	}
+	return keyring, nil
+}
+
+// fileProvider reads keyrings from files.
+type fileProvider struct {
+	dir string
+}
+
+// NewFileProvider returns a provider that reads the keyring of a key from
+// the file of the same name in dir, such as a mounted Kubernetes or Docker
+// secret.
+func NewFileProvider(dir string) Provider {
+	return fileProvider{dir: dir}
+}
+
+// Keyring implements Provider.
+func (p fileProvider) Keyring(_ context.Context, name string) (Keyring, error) {
+	path := filepath.Join(p.dir, name)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s does not exist", ErrKeyNotFound, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("keys: %w", err)
+	}
+	keyring, err := ParseKeyring(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return keyring, nil
+}
@@ -0,0 +1,125 @@
+package keys
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	key1 = bytes.Repeat([]byte{1}, Size)
+	key2 = bytes.Repeat([]byte{2}, Size)
+)
+
+func TestParseKeyring(t *testing.T) {
+	keyring, err := ParseKeyring("1:" + hex.EncodeToString(key1) + ",\n 2:" + base64.StdEncoding.EncodeToString(key2) + "\n")
+	require.NoError(t, err)
+	assert.Equal(t, 2, keyring.Current().Version)
+	assert.Equal(t, key2, keyring.Current().Material)
+	material, ok := keyring.Version(1)
+	require.True(t, ok)
+	assert.Equal(t, key1, material)
+
+	// A bare key is version 1; 32 characters are used as is
+	keyring, err = ParseKeyring(strings.Repeat("k", Size))
+	require.NoError(t, err)
+	assert.Equal(t, Key{Version: 1, Material: []byte(strings.Repeat("k", Size))}, keyring.Current())
+
+	for _, text := range []string{"", "1:short", "x:" + hex.EncodeToString(key1), "0:" + hex.EncodeToString(key1),
+		"1:" + hex.EncodeToString(key1) + ",1:" + hex.EncodeToString(key2)} {
+		_, err := ParseKeyring(text)
+		assert.Error(t, err, text)
+	}
+}
+
+func TestEnvAndFileProviders(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("TEST_KEY_SSE_MASTER_KEY", "2:"+hex.EncodeToString(key2))
+
+	keyring, err := NewEnvProvider("TEST_KEY_").Keyring(ctx, SSEMasterKey)
+	require.NoError(t, err)
+	assert.Equal(t, 2, keyring.Current().Version)
+
+	_, err = NewEnvProvider("TEST_KEY_").Keyring(ctx, EncryptionKey)
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, EncryptionKey), []byte(hex.EncodeToString(key1)+"\n"), 0600))
+	keyring, err = NewFileProvider(dir).Keyring(ctx, EncryptionKey)
+	require.NoError(t, err)
+	assert.Equal(t, Key{Version: 1, Material: key1}, keyring.Current())
+
+	_, err = NewFileProvider(dir).Keyring(ctx, SSEMasterKey)
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+}
+
+func TestVaultProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "s.token" {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(map[string][]string{"errors": {"permission denied"}})
+			return
+		}
+		require.Equal(t, "/v1/kv/data/alexander", r.URL.Path)
+		json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"data": map[string]string{
+			EncryptionKey: "1:" + hex.EncodeToString(key1) + "\n2:" + hex.EncodeToString(key2),
+		}}})
+	}))
+	defer server.Close()
+
+	config := VaultConfig{Address: server.URL, Token: "s.token", Mount: "kv", Path: "alexander"}
+	keyring, err := NewVaultProvider(config).Keyring(context.Background(), EncryptionKey)
+	require.NoError(t, err)
+	assert.Equal(t, map[int][]byte{1: key1, 2: key2}, keyring.Materials())
+
+	_, err = NewVaultProvider(config).Keyring(context.Background(), SSEMasterKey)
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	config.Token = "wrong"
+	_, err = NewVaultProvider(config).Keyring(context.Background(), EncryptionKey)
+	assert.ErrorContains(t, err, "permission denied")
+}
+
+func TestKMSProvider(t *testing.T) {
+	// The fake KMS "decrypts" a ciphertext blob by reversing it
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "TrentService.Decrypt", r.Header.Get("X-Amz-Target"))
+		require.Contains(t, r.Header.Get("Authorization"), "Credential=AKIATEST/")
+		var input struct{ CiphertextBlob []byte }
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&input))
+		plaintext := make([]byte, len(input.CiphertextBlob))
+		for i, b := range input.CiphertextBlob {
+			plaintext[len(plaintext)-1-i] = b
+		}
+		json.NewEncoder(w).Encode(map[string][]byte{"Plaintext": plaintext})
+	}))
+	defer server.Close()
+
+	ciphertext := append(bytes.Repeat([]byte{9}, Size-1), 7)
+	provider := NewKMSProvider(KMSConfig{
+		Region:          "eu-west-1",
+		Endpoint:        server.URL,
+		AccessKeyID:     "AKIATEST",
+		SecretAccessKey: "secret",
+		Keyrings:        map[string]string{SSEMasterKey: "3:" + base64.StdEncoding.EncodeToString(ciphertext)},
+	})
+
+	keyring, err := provider.Keyring(context.Background(), SSEMasterKey)
+	require.NoError(t, err)
+	assert.Equal(t, 3, keyring.Current().Version)
+	assert.Equal(t, append([]byte{7}, bytes.Repeat([]byte{9}, Size-1)...), keyring.Current().Material)
+
+	_, err = provider.Keyring(context.Background(), EncryptionKey)
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+}
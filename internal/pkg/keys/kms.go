@@ -0,0 +1,144 @@
+package keys
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+)
+
+// KMSConfig configures an AWS KMS provider.
+type KMSConfig struct {
+	// Region is the AWS region of the KMS keys.
+	Region string
+
+	// Endpoint overrides the regional KMS endpoint, for VPC endpoints and
+	// KMS-compatible services.
+	Endpoint string
+
+	// AccessKeyID and SecretAccessKey are static credentials. If unset,
+	// the default AWS credential chain is used: environment, shared
+	// configuration and instance or task roles.
+	AccessKeyID     string
+	SecretAccessKey string
+
+	// Keyrings maps key names to keyrings in text form whose keys are
+	// base64 ciphertext blobs of data keys, as returned by the KMS
+	// GenerateDataKey operation.
+	Keyrings map[string]string
+
+	// HTTPClient is used for all requests. Default: 10 second timeout.
+	HTTPClient *http.Client
+}
+
+// kmsProvider decrypts data keys with AWS KMS.
+type kmsProvider struct {
+	config     KMSConfig
+	httpClient *http.Client
+	signer     *v4.Signer
+}
+
+// NewKMSProvider returns a provider that decrypts data keys with AWS KMS,
+// so the configuration holds only ciphertext that is useless without
+// access to the KMS key.
+func NewKMSProvider(config KMSConfig) Provider {
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &kmsProvider{config: config, httpClient: httpClient, signer: v4.NewSigner()}
+}
+
+// Keyring implements Provider.
+func (p *kmsProvider) Keyring(ctx context.Context, name string) (Keyring, error) {
+	entries, err := parseEntries(p.config.Keyrings[name])
+	if err != nil {
+		return nil, fmt.Errorf("KMS %s: %w", name, err)
+	}
+
+	creds, err := p.credentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]Key, len(entries))
+	for i, entry := range entries {
+		material, err := p.decrypt(ctx, creds, entry.value)
+		if err != nil {
+			return nil, fmt.Errorf("KMS %s version %d: %w", name, entry.version, err)
+		}
+		keys[i] = Key{Version: entry.version, Material: material}
+	}
+	return NewKeyring(keys...)
+}
+
+// credentials returns the static credentials or those of the default chain.
+func (p *kmsProvider) credentials(ctx context.Context) (aws.Credentials, error) {
+	if p.config.AccessKeyID != "" {
+		return credentials.NewStaticCredentialsProvider(p.config.AccessKeyID, p.config.SecretAccessKey, "").Retrieve(ctx)
+	}
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(p.config.Region))
+	if err != nil {
+		return aws.Credentials{}, fmt.Errorf("keys: loading AWS configuration: %w", err)
+	}
+	creds, err := cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return aws.Credentials{}, fmt.Errorf("keys: retrieving AWS credentials: %w", err)
+	}
+	return creds, nil
+}
+
+// decrypt calls the KMS Decrypt operation on a base64 ciphertext blob.
+func (p *kmsProvider) decrypt(ctx context.Context, creds aws.Credentials, ciphertext string) ([]byte, error) {
+	body, err := json.Marshal(map[string]string{"CiphertextBlob": ciphertext})
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := p.config.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://kms.%s.amazonaws.com", p.config.Region)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(endpoint, "/")+"/", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "TrentService.Decrypt")
+
+	hash := sha256.Sum256(body)
+	if err := p.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(hash[:]), "kms", p.config.Region, time.Now()); err != nil {
+		return nil, fmt.Errorf("signing request: %w", err)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Plaintext string `json:"Plaintext"`
+		Type      string `json:"__type"`
+		Message   string `json:"message"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&result); err != nil && resp.StatusCode == http.StatusOK {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("KMS returned %s: %s %s", resp.Status, result.Type, result.Message)
+	}
+	return base64.StdEncoding.DecodeString(result.Plaintext)
+}
@@ -0,0 +1,113 @@
+package keys
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// maxResponseSize bounds Vault and KMS responses.
+const maxResponseSize = 1 << 20
+
+// VaultConfig configures a HashiCorp Vault provider.
+type VaultConfig struct {
+	// Address is the Vault server URL, such as https://vault:8200.
+	Address string
+
+	// Token authenticates to Vault. TokenFile, if set, is read instead,
+	// which suits tokens renewed by a Vault agent.
+	Token     string
+	TokenFile string
+
+	// Namespace is the Vault Enterprise namespace, if any.
+	Namespace string
+
+	// Mount is the mount path of the KV version 2 secrets engine.
+	Mount string
+
+	// Path is the secret holding one field per key name, each a keyring
+	// in text form.
+	Path string
+
+	// HTTPClient is used for all requests. Default: 10 second timeout.
+	HTTPClient *http.Client
+}
+
+// vaultProvider reads keyrings from a Vault KV version 2 secret.
+type vaultProvider struct {
+	config     VaultConfig
+	httpClient *http.Client
+}
+
+// NewVaultProvider returns a provider that reads keyrings from the fields
+// of a Vault KV version 2 secret.
+func NewVaultProvider(config VaultConfig) Provider {
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &vaultProvider{config: config, httpClient: httpClient}
+}
+
+// vaultSecret is the response to a KV version 2 read.
+type vaultSecret struct {
+	Data struct {
+		Data map[string]string `json:"data"`
+	} `json:"data"`
+	Errors []string `json:"errors"`
+}
+
+// Keyring implements Provider.
+func (p *vaultProvider) Keyring(ctx context.Context, name string) (Keyring, error) {
+	token := p.config.Token
+	if p.config.TokenFile != "" {
+		data, err := os.ReadFile(p.config.TokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("keys: reading Vault token: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+
+	url := fmt.Sprintf("%s/v1/%s/data/%s", strings.TrimSuffix(p.config.Address, "/"),
+		strings.Trim(p.config.Mount, "/"), strings.Trim(p.config.Path, "/"))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("keys: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if p.config.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.config.Namespace)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("keys: Vault request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var secret vaultSecret
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&secret); err != nil && resp.StatusCode == http.StatusOK {
+		return nil, fmt.Errorf("keys: decoding Vault response: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: Vault secret %s/%s does not exist", ErrKeyNotFound, p.config.Mount, p.config.Path)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("keys: Vault returned %s: %s", resp.Status, strings.Join(secret.Errors, "; "))
+	}
+
+	text, ok := secret.Data.Data[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: Vault secret %s/%s has no field %s", ErrKeyNotFound, p.config.Mount, p.config.Path, name)
+	}
+	keyring, err := ParseKeyring(text)
+	if err != nil {
+		return nil, fmt.Errorf("Vault field %s: %w", name, err)
+	}
+	return keyring, nil
+}
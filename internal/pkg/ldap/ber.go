@@ -0,0 +1,209 @@
+package ldap
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// BER identifier octets used by the LDAPv3 messages this client speaks (RFC 4511).
+const (
+	tagBoolean     byte = 0x01
+	tagInteger     byte = 0x02
+	tagOctetString byte = 0x04
+	tagEnumerated  byte = 0x0a
+	tagSequence    byte = 0x30
+	tagSet         byte = 0x31
+
+	tagBindRequest           byte = 0x60
+	tagBindResponse          byte = 0x61
+	tagUnbindRequest         byte = 0x42
+	tagSearchRequest         byte = 0x63
+	tagSearchResultEntry     byte = 0x64
+	tagSearchResultDone      byte = 0x65
+	tagSearchResultReference byte = 0x73
+	tagExtendedRequest       byte = 0x77
+	tagExtendedResponse      byte = 0x78
+
+	tagSimpleAuth     byte = 0x80
+	tagExtendedName   byte = 0x80
+	tagFilterAnd      byte = 0xa0
+	tagFilterOr       byte = 0xa1
+	tagFilterNot      byte = 0xa2
+	tagFilterEquality byte = 0xa3
+	tagFilterPresent  byte = 0x87
+	constructedBit    byte = 0x20
+)
+
+const (
+	// maxMessageSize bounds the size of a single server response.
+	maxMessageSize = 16 << 20
+
+	// maxElementChildren bounds the number of elements in a constructed element.
+	maxElementChildren = 1 << 16
+)
+
+var errMalformed = errors.New("ldap: malformed BER element")
+
+// element is a decoded BER TLV.
+type element struct {
+	tag   byte
+	value []byte
+}
+
+// encode returns the BER encoding of tag and value.
+func encode(tag byte, value []byte) []byte {
+	n := len(value)
+	var length []byte
+	switch {
+	case n < 0x80:
+		length = []byte{byte(n)}
+	default:
+		for v := n; v > 0; v >>= 8 {
+			length = append([]byte{byte(v)}, length...)
+		}
+		length = append([]byte{0x80 | byte(len(length))}, length...)
+	}
+
+	out := make([]byte, 0, 1+len(length)+n)
+	out = append(out, tag)
+	out = append(out, length...)
+	return append(out, value...)
+}
+
+// encodeConstructed returns the BER encoding of a constructed element.
+func encodeConstructed(tag byte, children ...[]byte) []byte {
+	var value []byte
+	for _, child := range children {
+		value = append(value, child...)
+	}
+	return encode(tag, value)
+}
+
+func encodeString(tag byte, s string) []byte {
+	return encode(tag, []byte(s))
+}
+
+func encodeInt(tag byte, v int64) []byte {
+	var value []byte
+	for {
+		value = append([]byte{byte(v)}, value...)
+		if (v >= -128 && v < 128) || len(value) == 8 {
+			break
+		}
+		v >>= 8
+	}
+	return encode(tag, value)
+}
+
+func encodeBool(b bool) []byte {
+	if b {
+		return encode(tagBoolean, []byte{0xff})
+	}
+	return encode(tagBoolean, []byte{0x00})
+}
+
+// readElement reads one BER element from r.
+func readElement(r *bufio.Reader) (*element, error) {
+	tag, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	first, err := r.ReadByte()
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	length := int(first)
+	if first&0x80 != 0 {
+		octets := int(first & 0x7f)
+		if octets == 0 || octets > 4 {
+			return nil, errMalformed
+		}
+		length = 0
+		for i := 0; i < octets; i++ {
+			b, err := r.ReadByte()
+			if err != nil {
+				return nil, unexpectedEOF(err)
+			}
+			length = length<<8 | int(b)
+		}
+	}
+	if length > maxMessageSize {
+		return nil, fmt.Errorf("ldap: message of %d bytes exceeds limit", length)
+	}
+
+	value := make([]byte, length)
+	if _, err := io.ReadFull(r, value); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	return &element{tag: tag, value: value}, nil
+}
+
+// children decodes the elements contained in a constructed element.
+func (e *element) children() ([]*element, error) {
+	if e.tag&constructedBit == 0 {
+		return nil, errMalformed
+	}
+
+	var out []*element
+	data := e.value
+	for len(data) > 0 {
+		if len(out) == maxElementChildren {
+			return nil, errMalformed
+		}
+		child, rest, err := decodeElement(data)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, child)
+		data = rest
+	}
+	return out, nil
+}
+
+// decodeElement decodes the first element in data and returns the remainder.
+func decodeElement(data []byte) (*element, []byte, error) {
+	if len(data) < 2 {
+		return nil, nil, errMalformed
+	}
+	tag, first := data[0], data[1]
+	data = data[2:]
+
+	length := int(first)
+	if first&0x80 != 0 {
+		octets := int(first & 0x7f)
+		if octets == 0 || octets > 4 || len(data) < octets {
+			return nil, nil, errMalformed
+		}
+		length = 0
+		for _, b := range data[:octets] {
+			length = length<<8 | int(b)
+		}
+		data = data[octets:]
+	}
+	if length < 0 || length > len(data) {
+		return nil, nil, errMalformed
+	}
+	return &element{tag: tag, value: data[:length]}, data[length:], nil
+}
+
+// int decodes an INTEGER or ENUMERATED value.
+func (e *element) int() (int64, error) {
+	if len(e.value) == 0 || len(e.value) > 8 {
+		return 0, errMalformed
+	}
+	v := int64(int8(e.value[0]))
+	for _, b := range e.value[1:] {
+		v = v<<8 | int64(b)
+	}
+	return v, nil
+}
+
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
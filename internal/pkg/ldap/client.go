@@ -0,0 +1,384 @@
+// Package ldap implements the subset of LDAPv3 (RFC 4511) needed to
+// authenticate users against an LDAP or Active Directory server: simple bind,
+// StartTLS and subtree search.
+package ldap
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// LDAP result codes handled by this package.
+const (
+	ResultSuccess            = 0
+	ResultSizeLimitExceeded  = 4
+	ResultInvalidCredentials = 49
+)
+
+// startTLSOID is the extended operation name for StartTLS (RFC 4511 section 4.14).
+const startTLSOID = "1.3.6.1.4.1.1466.20037"
+
+// ErrInvalidCredentials is matched (via errors.Is) by a bind rejected with
+// the invalidCredentials result code, and by binds with an empty password.
+var ErrInvalidCredentials = errors.New("ldap: invalid credentials")
+
+// Error is an LDAP operation result other than success.
+type Error struct {
+	Code    int64
+	Message string
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("ldap: result code %d", e.Code)
+	}
+	return fmt.Sprintf("ldap: result code %d: %s", e.Code, e.Message)
+}
+
+// Is reports whether the result is an invalid credentials failure.
+func (e *Error) Is(target error) bool {
+	return target == ErrInvalidCredentials && e.Code == ResultInvalidCredentials
+}
+
+// Options configures a connection.
+type Options struct {
+	// TLSConfig is used for ldaps:// URLs and StartTLS. If ServerName is
+	// empty, the host from the URL is used.
+	TLSConfig *tls.Config
+
+	// StartTLS upgrades an ldap:// connection to TLS before any bind.
+	StartTLS bool
+
+	// Timeout bounds dialing and each operation when the context has no deadline.
+	Timeout time.Duration
+}
+
+// Conn is a connection to an LDAP server. Operations are synchronous; a Conn
+// must not be used concurrently.
+type Conn struct {
+	conn    net.Conn
+	reader  *bufio.Reader
+	timeout time.Duration
+	lastID  int64
+}
+
+// Dial connects to an ldap:// or ldaps:// URL.
+func Dial(ctx context.Context, rawURL string, opts Options) (*Conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("ldap: invalid URL: %w", err)
+	}
+
+	var defaultPort string
+	switch u.Scheme {
+	case "ldap":
+		defaultPort = "389"
+	case "ldaps":
+		defaultPort = "636"
+		if opts.StartTLS {
+			return nil, errors.New("ldap: StartTLS cannot be used with ldaps://")
+		}
+	default:
+		return nil, fmt.Errorf("ldap: unsupported URL scheme %q", u.Scheme)
+	}
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), defaultPort)
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if opts.TLSConfig != nil {
+		tlsConfig = opts.TLSConfig.Clone()
+	}
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = u.Hostname()
+	}
+
+	dialer := &net.Dialer{Timeout: opts.Timeout}
+	netConn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("ldap: dial %s: %w", addr, err)
+	}
+
+	c := &Conn{conn: netConn, reader: bufio.NewReader(netConn), timeout: opts.Timeout}
+	if u.Scheme == "ldaps" {
+		err = c.upgradeTLS(ctx, tlsConfig)
+	} else if opts.StartTLS {
+		err = c.startTLS(ctx, tlsConfig)
+	}
+	if err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Bind authenticates the connection with a simple bind. An empty password is
+// rejected: servers treat it as an unauthenticated bind that always succeeds.
+func (c *Conn) Bind(ctx context.Context, dn, password string) error {
+	if password == "" {
+		return ErrInvalidCredentials
+	}
+
+	op := encodeConstructed(tagBindRequest,
+		encodeInt(tagInteger, 3),
+		encodeString(tagOctetString, dn),
+		encodeString(tagSimpleAuth, password),
+	)
+	resp, err := c.roundTrip(ctx, op)
+	if err != nil {
+		return err
+	}
+	if resp.tag != tagBindResponse {
+		return fmt.Errorf("ldap: unexpected response tag 0x%02x to bind", resp.tag)
+	}
+	return parseResult(resp)
+}
+
+// SearchRequest describes a subtree search.
+type SearchRequest struct {
+	BaseDN     string
+	Filter     string
+	Attributes []string
+
+	// SizeLimit caps the number of entries returned (0 = server default).
+	SizeLimit int
+}
+
+// Entry is a search result entry.
+type Entry struct {
+	DN         string
+	Attributes map[string][]string
+}
+
+// Values returns the values of an attribute, matching its name case-insensitively.
+func (e *Entry) Values(name string) []string {
+	for attr, values := range e.Attributes {
+		if strings.EqualFold(attr, name) {
+			return values
+		}
+	}
+	return nil
+}
+
+// Search performs a subtree search. Hitting the size limit is not an error;
+// the entries received up to the limit are returned.
+func (c *Conn) Search(ctx context.Context, req SearchRequest) ([]*Entry, error) {
+	filter, err := compileFilter(req.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := make([][]byte, 0, len(req.Attributes))
+	for _, attr := range req.Attributes {
+		attrs = append(attrs, encodeString(tagOctetString, attr))
+	}
+
+	var timeLimit int64
+	if deadline, ok := c.deadline(ctx); ok {
+		timeLimit = int64(time.Until(deadline).Seconds()) + 1
+	}
+
+	op := encodeConstructed(tagSearchRequest,
+		encodeString(tagOctetString, req.BaseDN),
+		encodeInt(tagEnumerated, 2), // wholeSubtree
+		encodeInt(tagEnumerated, 0), // neverDerefAliases
+		encodeInt(tagInteger, int64(req.SizeLimit)),
+		encodeInt(tagInteger, timeLimit),
+		encodeBool(false),
+		filter,
+		encodeConstructed(tagSequence, attrs...),
+	)
+	if err := c.send(ctx, op); err != nil {
+		return nil, err
+	}
+
+	var entries []*Entry
+	for {
+		resp, err := c.receive()
+		if err != nil {
+			return nil, err
+		}
+
+		switch resp.tag {
+		case tagSearchResultEntry:
+			entry, err := parseEntry(resp)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, entry)
+		case tagSearchResultReference:
+			// Referrals to other servers are not followed
+		case tagSearchResultDone:
+			err := parseResult(resp)
+			var ldapErr *Error
+			if errors.As(err, &ldapErr) && ldapErr.Code == ResultSizeLimitExceeded {
+				err = nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			return entries, nil
+		default:
+			return nil, fmt.Errorf("ldap: unexpected response tag 0x%02x to search", resp.tag)
+		}
+	}
+}
+
+// Close sends an unbind request and closes the connection.
+func (c *Conn) Close() error {
+	_ = c.send(context.Background(), encode(tagUnbindRequest, nil))
+	return c.conn.Close()
+}
+
+func (c *Conn) startTLS(ctx context.Context, tlsConfig *tls.Config) error {
+	op := encodeConstructed(tagExtendedRequest, encodeString(tagExtendedName, startTLSOID))
+	resp, err := c.roundTrip(ctx, op)
+	if err != nil {
+		return err
+	}
+	if resp.tag != tagExtendedResponse {
+		return fmt.Errorf("ldap: unexpected response tag 0x%02x to StartTLS", resp.tag)
+	}
+	if err := parseResult(resp); err != nil {
+		return fmt.Errorf("ldap: StartTLS refused: %w", err)
+	}
+	return c.upgradeTLS(ctx, tlsConfig)
+}
+
+func (c *Conn) upgradeTLS(ctx context.Context, tlsConfig *tls.Config) error {
+	tlsConn := tls.Client(c.conn, tlsConfig)
+	c.setDeadline(ctx)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return fmt.Errorf("ldap: TLS handshake: %w", err)
+	}
+	c.conn = tlsConn
+	c.reader = bufio.NewReader(tlsConn)
+	return nil
+}
+
+// roundTrip sends a request and returns the protocol op of its response.
+func (c *Conn) roundTrip(ctx context.Context, op []byte) (*element, error) {
+	if err := c.send(ctx, op); err != nil {
+		return nil, err
+	}
+	return c.receive()
+}
+
+func (c *Conn) send(ctx context.Context, op []byte) error {
+	c.lastID++
+	msg := encodeConstructed(tagSequence, encodeInt(tagInteger, c.lastID), op)
+	c.setDeadline(ctx)
+	if _, err := c.conn.Write(msg); err != nil {
+		return fmt.Errorf("ldap: write: %w", err)
+	}
+	return nil
+}
+
+// receive reads the next message for the last request and returns its protocol op.
+func (c *Conn) receive() (*element, error) {
+	for {
+		msg, err := readElement(c.reader)
+		if err != nil {
+			return nil, fmt.Errorf("ldap: read: %w", err)
+		}
+		if msg.tag != tagSequence {
+			return nil, errMalformed
+		}
+		parts, err := msg.children()
+		if err != nil {
+			return nil, err
+		}
+		if len(parts) < 2 {
+			return nil, errMalformed
+		}
+		id, err := parts[0].int()
+		if err != nil {
+			return nil, err
+		}
+
+		switch id {
+		case c.lastID:
+			return parts[1], nil
+		case 0:
+			// Unsolicited notification, e.g. notice of disconnection
+			return nil, fmt.Errorf("ldap: server closed the connection: %w", parseResult(parts[1]))
+		}
+	}
+}
+
+func (c *Conn) deadline(ctx context.Context) (time.Time, bool) {
+	if deadline, ok := ctx.Deadline(); ok {
+		return deadline, true
+	}
+	if c.timeout > 0 {
+		return time.Now().Add(c.timeout), true
+	}
+	return time.Time{}, false
+}
+
+func (c *Conn) setDeadline(ctx context.Context) {
+	deadline, _ := c.deadline(ctx)
+	_ = c.conn.SetDeadline(deadline)
+}
+
+// parseResult converts an LDAPResult into an error.
+func parseResult(op *element) error {
+	fields, err := op.children()
+	if err != nil {
+		return err
+	}
+	if len(fields) < 3 {
+		return errMalformed
+	}
+	code, err := fields[0].int()
+	if err != nil {
+		return err
+	}
+	if code == ResultSuccess {
+		return nil
+	}
+	return &Error{Code: code, Message: string(fields[2].value)}
+}
+
+func parseEntry(op *element) (*Entry, error) {
+	fields, err := op.children()
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) < 2 {
+		return nil, errMalformed
+	}
+
+	entry := &Entry{DN: string(fields[0].value), Attributes: make(map[string][]string)}
+	attrs, err := fields[1].children()
+	if err != nil {
+		return nil, err
+	}
+	for _, attr := range attrs {
+		parts, err := attr.children()
+		if err != nil {
+			return nil, err
+		}
+		if len(parts) < 2 {
+			return nil, errMalformed
+		}
+		vals, err := parts[1].children()
+		if err != nil {
+			return nil, err
+		}
+		name := string(parts[0].value)
+		for _, val := range vals {
+			entry.Attributes[name] = append(entry.Attributes[name], string(val.value))
+		}
+	}
+	return entry, nil
+}
@@ -0,0 +1,137 @@
+package ldap
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testServiceDN = "cn=svc,dc=example,dc=com"
+	testUserDN    = "uid=jdoe,ou=people,dc=example,dc=com"
+)
+
+// serveTestDirectory answers binds and searches for a single user entry.
+func serveTestDirectory(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	wantFilter, err := compileFilter("(&(objectClass=person)(uid=jdoe))")
+	require.NoError(t, err)
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveTestConn(conn, wantFilter)
+		}
+	}()
+	return "ldap://" + ln.Addr().String()
+}
+
+func serveTestConn(conn net.Conn, wantFilter []byte) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	result := func(id int64, tag byte, code int64) []byte {
+		return encodeConstructed(tagSequence, encodeInt(tagInteger, id), encodeConstructed(tag,
+			encodeInt(tagEnumerated, code), encodeString(tagOctetString, ""), encodeString(tagOctetString, ""),
+		))
+	}
+
+	for {
+		msg, err := readElement(reader)
+		if err != nil {
+			return
+		}
+		parts, _ := msg.children()
+		id, _ := parts[0].int()
+		op := parts[1]
+
+		switch op.tag {
+		case tagBindRequest:
+			fields, _ := op.children()
+			dn, password := string(fields[1].value), string(fields[2].value)
+			code := int64(ResultInvalidCredentials)
+			if (dn == testServiceDN && password == "svc-pass") || (dn == testUserDN && password == "secret") {
+				code = ResultSuccess
+			}
+			conn.Write(result(id, tagBindResponse, code))
+		case tagSearchRequest:
+			fields, _ := op.children()
+			filter := encode(fields[6].tag, fields[6].value)
+			if bytes.Equal(filter, wantFilter) {
+				entry := encodeConstructed(tagSearchResultEntry,
+					encodeString(tagOctetString, testUserDN),
+					encodeConstructed(tagSequence,
+						encodeConstructed(tagSequence, encodeString(tagOctetString, "mail"),
+							encodeConstructed(tagSet, encodeString(tagOctetString, "jdoe@example.com"))),
+						encodeConstructed(tagSequence, encodeString(tagOctetString, "memberOf"),
+							encodeConstructed(tagSet,
+								encodeString(tagOctetString, "cn=staff,dc=example,dc=com"),
+								encodeString(tagOctetString, "cn=admins,dc=example,dc=com"))),
+					),
+				)
+				conn.Write(encodeConstructed(tagSequence, encodeInt(tagInteger, id), entry))
+			}
+			conn.Write(result(id, tagSearchResultDone, ResultSuccess))
+		case tagUnbindRequest:
+			return
+		}
+	}
+}
+
+func TestSearchThenBind(t *testing.T) {
+	ctx := context.Background()
+	conn, err := Dial(ctx, serveTestDirectory(t), Options{Timeout: 5 * time.Second})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.NoError(t, conn.Bind(ctx, testServiceDN, "svc-pass"))
+
+	entries, err := conn.Search(ctx, SearchRequest{
+		BaseDN:     "dc=example,dc=com",
+		Filter:     "(&(objectClass=person)(uid=" + EscapeFilter("jdoe") + "))",
+		Attributes: []string{"mail", "memberOf"},
+		SizeLimit:  2,
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, testUserDN, entries[0].DN)
+	require.Equal(t, []string{"jdoe@example.com"}, entries[0].Values("MAIL"))
+	require.Len(t, entries[0].Values("memberof"), 2)
+
+	// Escaped input cannot widen the filter
+	entries, err = conn.Search(ctx, SearchRequest{
+		BaseDN: "dc=example,dc=com",
+		Filter: "(&(objectClass=person)(uid=" + EscapeFilter("*)(uid=jdoe") + "))",
+	})
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	require.NoError(t, conn.Bind(ctx, testUserDN, "secret"))
+	require.ErrorIs(t, conn.Bind(ctx, testUserDN, "wrong"), ErrInvalidCredentials)
+	require.ErrorIs(t, conn.Bind(ctx, testUserDN, ""), ErrInvalidCredentials)
+}
+
+func TestCompileFilter(t *testing.T) {
+	encoded, err := compileFilter(`(|(cn=a\2ab)(!(mail=*)))`)
+	require.NoError(t, err)
+	require.Equal(t, encodeConstructed(tagFilterOr,
+		encodeConstructed(tagFilterEquality, encodeString(tagOctetString, "cn"), encodeString(tagOctetString, "a*b")),
+		encode(tagFilterNot, encodeString(tagFilterPresent, "mail")),
+	), encoded)
+
+	for _, filter := range []string{"uid=x", "(uid=x", "(uid=a*)", "(uid>=1)", "(&)", `(uid=\4)`, "(uid=x))"} {
+		_, err := compileFilter(filter)
+		require.Error(t, err, filter)
+	}
+}
@@ -0,0 +1,154 @@
+package ldap
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// EscapeFilter escapes a value for use in a search filter (RFC 4515), so
+// user input cannot change the structure of the filter.
+func EscapeFilter(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; c {
+		case '\\', '*', '(', ')', 0:
+			fmt.Fprintf(&b, "\\%02x", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// compileFilter encodes a string search filter. It supports the '&', '|' and
+// '!' operators with equality ("attr=value") and presence ("attr=*") items,
+// which covers the user lookups this client is used for.
+func compileFilter(filter string) ([]byte, error) {
+	p := &filterParser{input: filter}
+	encoded, err := p.filter()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.input) {
+		return nil, p.errorf("unexpected trailing characters")
+	}
+	return encoded, nil
+}
+
+type filterParser struct {
+	input string
+	pos   int
+}
+
+func (p *filterParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("ldap: invalid filter %q at offset %d: %s", p.input, p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *filterParser) filter() ([]byte, error) {
+	if p.pos >= len(p.input) || p.input[p.pos] != '(' {
+		return nil, p.errorf("expected '('")
+	}
+	p.pos++
+	if p.pos >= len(p.input) {
+		return nil, p.errorf("unterminated filter")
+	}
+
+	var encoded []byte
+	var err error
+	switch p.input[p.pos] {
+	case '&':
+		p.pos++
+		encoded, err = p.list(tagFilterAnd)
+	case '|':
+		p.pos++
+		encoded, err = p.list(tagFilterOr)
+	case '!':
+		p.pos++
+		var inner []byte
+		inner, err = p.filter()
+		encoded = encode(tagFilterNot, inner)
+	default:
+		encoded, err = p.item()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if p.pos >= len(p.input) || p.input[p.pos] != ')' {
+		return nil, p.errorf("expected ')'")
+	}
+	p.pos++
+	return encoded, nil
+}
+
+func (p *filterParser) list(tag byte) ([]byte, error) {
+	var children [][]byte
+	for p.pos < len(p.input) && p.input[p.pos] == '(' {
+		child, err := p.filter()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+	}
+	if len(children) == 0 {
+		return nil, p.errorf("empty filter list")
+	}
+	return encodeConstructed(tag, children...), nil
+}
+
+func (p *filterParser) item() ([]byte, error) {
+	end := strings.IndexByte(p.input[p.pos:], ')')
+	if end < 0 {
+		return nil, p.errorf("unterminated filter")
+	}
+	item := p.input[p.pos : p.pos+end]
+
+	attr, value, ok := strings.Cut(item, "=")
+	if !ok || attr == "" {
+		return nil, p.errorf("expected attribute=value")
+	}
+	if strings.ContainsAny(attr, "~<>:") {
+		return nil, p.errorf("only equality and presence filters are supported")
+	}
+	p.pos += end
+
+	if value == "*" {
+		return encodeString(tagFilterPresent, attr), nil
+	}
+	if strings.Contains(value, "*") {
+		return nil, p.errorf("substring filters are not supported")
+	}
+	unescaped, err := unescapeFilterValue(value)
+	if err != nil {
+		return nil, p.errorf("%v", err)
+	}
+	return encodeConstructed(tagFilterEquality,
+		encodeString(tagOctetString, attr),
+		encodeString(tagOctetString, unescaped),
+	), nil
+}
+
+func unescapeFilterValue(value string) (string, error) {
+	if !strings.Contains(value, `\`) {
+		return value, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' {
+			b.WriteByte(value[i])
+			continue
+		}
+		if i+3 > len(value) {
+			return "", fmt.Errorf("truncated escape sequence")
+		}
+		decoded, err := hex.DecodeString(value[i+1 : i+3])
+		if err != nil {
+			return "", fmt.Errorf("invalid escape sequence %q", value[i:i+3])
+		}
+		b.Write(decoded)
+		i += 2
+	}
+	return b.String(), nil
+}
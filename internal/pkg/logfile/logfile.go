@@ -0,0 +1,169 @@
+// Package logfile appends log lines to a file that is rotated by size or
+// age. When a write would take the file past its maximum size, or the file
+// has been written to for longer than its maximum age, the file is renamed
+// to <path>.1, older backups shift to <path>.2 and so on, and backups beyond
+// the configured count are removed.
+//
+// Files rotated by an external tool such as logrotate are picked up with
+// Reopen.
+package logfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Config configures a File.
+type Config struct {
+	// Path is the file to write.
+	Path string
+
+	// MaxSize is the size in bytes at which the file is rotated; 0 disables
+	// rotation.
+	MaxSize int64
+
+	// MaxAge is how long the file is written to before it is rotated,
+	// counted from when it was opened or last rotated; 0 disables rotation
+	// by age.
+	MaxAge time.Duration
+
+	// MaxBackups is the number of rotated files kept; 0 discards the
+	// content of a rotated file.
+	MaxBackups int
+}
+
+// File is an io.WriteCloser appending to a rotated log file. It is safe for
+// concurrent use; every Write is appended whole to a single file.
+type File struct {
+	config Config
+
+	mu      sync.Mutex
+	file    *os.File
+	size    int64
+	started time.Time // When writing to file started
+	now     func() time.Time
+}
+
+// Open opens the log file for appending, creating it and its directory if
+// needed.
+func Open(config Config) (*File, error) {
+	if config.Path == "" {
+		return nil, errors.New("logfile: a path is required")
+	}
+	if config.MaxSize < 0 || config.MaxAge < 0 || config.MaxBackups < 0 {
+		return nil, errors.New("logfile: max size, max age and max backups must not be negative")
+	}
+	if err := os.MkdirAll(filepath.Dir(config.Path), 0750); err != nil {
+		return nil, fmt.Errorf("logfile: failed to create directory: %w", err)
+	}
+
+	f := &File{config: config, now: time.Now}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Write appends p to the file, rotating it first if p would take it past
+// the maximum size or the file is past the maximum age.
+func (f *File) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	tooLarge := f.config.MaxSize > 0 && f.size+int64(len(p)) > f.config.MaxSize
+	tooOld := f.config.MaxAge > 0 && f.now().Sub(f.started) >= f.config.MaxAge
+	if f.size > 0 && (tooLarge || tooOld) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Reopen closes and reopens the file, e.g. after it was moved away by
+// logrotate.
+func (f *File) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file != nil {
+		if err := f.file.Close(); err != nil {
+			return fmt.Errorf("logfile: failed to close file: %w", err)
+		}
+	}
+	return f.open()
+}
+
+// Close closes the file. Later writes fail.
+func (f *File) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// open opens the file for appending and records its size.
+func (f *File) open() error {
+	file, err := os.OpenFile(f.config.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0640)
+	if err != nil {
+		f.file = nil
+		return fmt.Errorf("logfile: failed to open file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		f.file = nil
+		return fmt.Errorf("logfile: failed to stat file: %w", err)
+	}
+	f.file = file
+	f.size = info.Size()
+	f.started = f.now()
+	return nil
+}
+
+// rotate shifts the backups, moves the current file to the first backup and
+// opens a new file.
+func (f *File) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("logfile: failed to close file: %w", err)
+	}
+
+	path := f.config.Path
+	if f.config.MaxBackups == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("logfile: failed to remove file: %w", err)
+		}
+		return f.open()
+	}
+
+	_ = os.Remove(backupPath(path, f.config.MaxBackups))
+	for i := f.config.MaxBackups - 1; i >= 1; i-- {
+		if err := os.Rename(backupPath(path, i), backupPath(path, i+1)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("logfile: failed to rotate backup: %w", err)
+		}
+	}
+	if err := os.Rename(path, backupPath(path, 1)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("logfile: failed to rotate file: %w", err)
+	}
+	return f.open()
+}
+
+// backupPath returns the path of the nth backup.
+func backupPath(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
+}
@@ -0,0 +1,106 @@
+package logfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestFile_RotatesBySize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "access.log")
+	f, err := Open(Config{Path: path, MaxSize: 10, MaxBackups: 2})
+	require.NoError(t, err)
+	defer f.Close()
+
+	for _, line := range []string{"one\n", "two\n", "three\n", "four\n", "five\n", "six\n"} {
+		_, err := f.Write([]byte(line))
+		require.NoError(t, err)
+	}
+
+	// Lines are never split across files, and the oldest backup is dropped
+	assert.Equal(t, "six\n", readFile(t, path))
+	assert.Equal(t, "four\nfive\n", readFile(t, path+".1"))
+	assert.Equal(t, "three\n", readFile(t, path+".2"))
+	assert.NoFileExists(t, path+".3")
+}
+
+func TestFile_RotatesByAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "server.log")
+	f, err := Open(Config{Path: path, MaxAge: time.Hour, MaxBackups: 1})
+	require.NoError(t, err)
+	defer f.Close()
+
+	now := time.Now()
+	f.now = func() time.Time { return now }
+	require.NoError(t, f.Reopen())
+
+	_, err = f.Write([]byte("one\n"))
+	require.NoError(t, err)
+	now = now.Add(59 * time.Minute)
+	_, err = f.Write([]byte("two\n"))
+	require.NoError(t, err)
+
+	// The age counts from the rotation, not from the last write
+	now = now.Add(time.Minute)
+	_, err = f.Write([]byte("three\n"))
+	require.NoError(t, err)
+	now = now.Add(59 * time.Minute)
+	_, err = f.Write([]byte("four\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "three\nfour\n", readFile(t, path))
+	assert.Equal(t, "one\ntwo\n", readFile(t, path+".1"))
+}
+
+func TestFile_AppendsToExistingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	require.NoError(t, os.WriteFile(path, []byte("old\n"), 0640))
+
+	f, err := Open(Config{Path: path, MaxSize: 8})
+	require.NoError(t, err)
+	defer f.Close()
+
+	_, err = f.Write([]byte("new\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "old\nnew\n", readFile(t, path))
+
+	// Without backups, a rotated file's content is discarded
+	_, err = f.Write([]byte("next\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "next\n", readFile(t, path))
+	assert.NoFileExists(t, path+".1")
+}
+
+func TestFile_Reopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "access.log")
+	f, err := Open(Config{Path: path})
+	require.NoError(t, err)
+
+	_, err = f.Write([]byte("before\n"))
+	require.NoError(t, err)
+
+	// Rotated by an external tool
+	require.NoError(t, f.Close())
+	require.NoError(t, os.Rename(path, filepath.Join(dir, "access.log.old")))
+	require.NoError(t, f.Reopen())
+
+	_, err = f.Write([]byte("after\n"))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	assert.Equal(t, "after\n", readFile(t, path))
+	_, err = f.Write([]byte("closed\n"))
+	assert.ErrorIs(t, err, os.ErrClosed)
+}
@@ -0,0 +1,361 @@
+// Package oidc implements the parts of OpenID Connect needed for a relying
+// party using the authorization code flow: provider discovery, the
+// authorization redirect with PKCE, the code exchange and ID token
+// verification against the provider's JWK set.
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInvalidToken is returned when an ID token fails verification.
+var ErrInvalidToken = errors.New("oidc: invalid ID token")
+
+// clockSkew is the tolerance applied to token time claims.
+const clockSkew = time.Minute
+
+// maxResponseSize bounds discovery, JWKS and token responses.
+const maxResponseSize = 1 << 20
+
+// Config configures a relying party.
+type Config struct {
+	// Issuer is the provider's issuer URL; discovery is fetched from
+	// {Issuer}/.well-known/openid-configuration.
+	Issuer string
+
+	// ClientID and ClientSecret identify this application to the provider.
+	ClientID     string
+	ClientSecret string
+
+	// RedirectURL is the callback URL registered with the provider.
+	RedirectURL string
+
+	// Scopes are requested in addition to "openid".
+	Scopes []string
+
+	// HTTPClient is used for all provider requests. Default: 10 second timeout.
+	HTTPClient *http.Client
+}
+
+// Claims are the verified claims of an ID token.
+type Claims struct {
+	Issuer            string `json:"iss"`
+	Subject           string `json:"sub"`
+	Nonce             string `json:"nonce"`
+	Email             string `json:"email"`
+	EmailVerified     *bool  `json:"email_verified"`
+	Name              string `json:"name"`
+	PreferredUsername string `json:"preferred_username"`
+
+	// Raw holds every claim, for deployments that map a custom claim.
+	Raw map[string]interface{} `json:"-"`
+}
+
+// String returns a string claim from Raw, or "" if it is absent or not a string.
+func (c *Claims) String(name string) string {
+	value, _ := c.Raw[name].(string)
+	return value
+}
+
+// Client is an OpenID Connect relying party.
+type Client struct {
+	config     Config
+	httpClient *http.Client
+
+	authorizationEndpoint string
+	tokenEndpoint         string
+	jwksURI               string
+
+	keysMu      sync.Mutex
+	keys        map[string]crypto.PublicKey
+	keysFetched time.Time
+
+	// now is replaceable in tests.
+	now func() time.Time
+}
+
+// discoveryDocument is the subset of provider metadata used by the client.
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// NewClient fetches the provider's discovery document and returns a client.
+func NewClient(ctx context.Context, config Config) (*Client, error) {
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	issuer := strings.TrimSuffix(config.Issuer, "/")
+
+	var doc discoveryDocument
+	if err := getJSON(ctx, httpClient, issuer+"/.well-known/openid-configuration", &doc); err != nil {
+		return nil, fmt.Errorf("oidc: discovery failed: %w", err)
+	}
+	if strings.TrimSuffix(doc.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("oidc: discovery issuer %q does not match %q", doc.Issuer, config.Issuer)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JWKSURI == "" {
+		return nil, errors.New("oidc: discovery document is missing endpoints")
+	}
+
+	config.Issuer = doc.Issuer
+	return &Client{
+		config:                config,
+		httpClient:            httpClient,
+		authorizationEndpoint: doc.AuthorizationEndpoint,
+		tokenEndpoint:         doc.TokenEndpoint,
+		jwksURI:               doc.JWKSURI,
+		now:                   time.Now,
+	}, nil
+}
+
+// AuthCodeURL returns the provider URL that starts a login. state and nonce
+// must be random per login; codeVerifier is the PKCE verifier (RFC 7636).
+func (c *Client) AuthCodeURL(state, nonce, codeVerifier string) string {
+	challenge := sha256.Sum256([]byte(codeVerifier))
+
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {c.config.ClientID},
+		"redirect_uri":          {c.config.RedirectURL},
+		"scope":                 {strings.Join(append([]string{"openid"}, c.config.Scopes...), " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(c.authorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return c.authorizationEndpoint + separator + params.Encode()
+}
+
+// Exchange redeems an authorization code and returns the verified ID token
+// claims. nonce must be the value passed to AuthCodeURL.
+func (c *Client) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Claims, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {c.config.RedirectURL},
+		"code_verifier": {codeVerifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.tokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(c.config.ClientID), url.QueryEscape(c.config.ClientSecret))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("oidc: reading token response: %w", err)
+	}
+
+	var token struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return nil, fmt.Errorf("oidc: token endpoint returned %s", resp.Status)
+	}
+	if resp.StatusCode != http.StatusOK || token.Error != "" {
+		return nil, fmt.Errorf("oidc: token endpoint returned %s: %s %s", resp.Status, token.Error, token.ErrorDescription)
+	}
+	if token.IDToken == "" {
+		return nil, errors.New("oidc: token response has no id_token")
+	}
+
+	return c.Verify(ctx, token.IDToken, nonce)
+}
+
+// Verify checks an ID token's signature, issuer, audience, expiry and nonce.
+func (c *Client) Verify(ctx context.Context, rawToken, nonce string) (*Claims, error) {
+	header, payload, signingInput, signature, err := parseJWT(rawToken)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := c.key(ctx, header.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignature(header.Algorithm, key, signingInput, signature); err != nil {
+		return nil, err
+	}
+
+	var registered struct {
+		Audience  audience `json:"aud"`
+		AZP       string   `json:"azp"`
+		ExpiresAt *float64 `json:"exp"`
+		IssuedAt  *float64 `json:"iat"`
+	}
+	claims := &Claims{}
+	if err := json.Unmarshal(payload, &registered); err != nil {
+		return nil, fmt.Errorf("%w: malformed claims", ErrInvalidToken)
+	}
+	if err := json.Unmarshal(payload, claims); err != nil {
+		return nil, fmt.Errorf("%w: malformed claims", ErrInvalidToken)
+	}
+	if err := json.Unmarshal(payload, &claims.Raw); err != nil {
+		return nil, fmt.Errorf("%w: malformed claims", ErrInvalidToken)
+	}
+
+	now := c.now()
+	switch {
+	case claims.Issuer != c.config.Issuer:
+		return nil, fmt.Errorf("%w: unexpected issuer %q", ErrInvalidToken, claims.Issuer)
+	case !registered.Audience.contains(c.config.ClientID):
+		return nil, fmt.Errorf("%w: token is not for this client", ErrInvalidToken)
+	case len(registered.Audience) > 1 && registered.AZP != c.config.ClientID:
+		return nil, fmt.Errorf("%w: token is not for this client", ErrInvalidToken)
+	case registered.ExpiresAt == nil || now.After(unixTime(*registered.ExpiresAt).Add(clockSkew)):
+		return nil, fmt.Errorf("%w: token expired", ErrInvalidToken)
+	case registered.IssuedAt != nil && unixTime(*registered.IssuedAt).After(now.Add(clockSkew)):
+		return nil, fmt.Errorf("%w: token issued in the future", ErrInvalidToken)
+	case claims.Subject == "":
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidToken)
+	case subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1:
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidToken)
+	}
+
+	return claims, nil
+}
+
+// key returns the provider key with the given ID. The JWK set is refetched
+// when the ID is unknown, at most once a minute, to follow key rotation.
+func (c *Client) key(ctx context.Context, keyID string) (crypto.PublicKey, error) {
+	c.keysMu.Lock()
+	defer c.keysMu.Unlock()
+
+	if key, ok := c.lookupKey(keyID); ok {
+		return key, nil
+	}
+	if !c.keysFetched.IsZero() && c.now().Sub(c.keysFetched) < time.Minute {
+		return nil, fmt.Errorf("%w: unknown signing key %q", ErrInvalidToken, keyID)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := getJSON(ctx, c.httpClient, c.jwksURI, &set); err != nil {
+		return nil, fmt.Errorf("oidc: fetching JWK set failed: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for i := range set.Keys {
+		jwk := &set.Keys[i]
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			continue // Skip keys of unsupported types
+		}
+		keys[jwk.KeyID] = key
+	}
+	c.keys = keys
+	c.keysFetched = c.now()
+
+	if key, ok := c.lookupKey(keyID); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w: unknown signing key %q", ErrInvalidToken, keyID)
+}
+
+// lookupKey finds a cached key. A token without a key ID is accepted only if
+// the provider publishes a single key.
+func (c *Client) lookupKey(keyID string) (crypto.PublicKey, bool) {
+	if keyID == "" && len(c.keys) == 1 {
+		for _, key := range c.keys {
+			return key, true
+		}
+	}
+	key, ok := c.keys[keyID]
+	return key, ok
+}
+
+// RandomString returns a URL-safe random string for state, nonce and PKCE verifiers.
+func RandomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// audience is the "aud" claim, which may be a string or an array.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*a = multiple
+	return nil
+}
+
+func (a audience) contains(clientID string) bool {
+	for _, aud := range a {
+		if aud == clientID {
+			return true
+		}
+	}
+	return false
+}
+
+func unixTime(seconds float64) time.Time {
+	return time.Unix(int64(seconds), 0)
+}
+
+// getJSON fetches a URL and decodes its JSON body.
+func getJSON(ctx context.Context, client *http.Client, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s", url, resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(v)
+}
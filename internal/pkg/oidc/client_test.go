@@ -0,0 +1,156 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testProvider is a minimal OpenID provider that issues one ID token.
+type testProvider struct {
+	server  *httptest.Server
+	key     *rsa.PrivateKey
+	idToken string
+	form    url.Values
+}
+
+func newTestProvider(t *testing.T) *testProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	p := &testProvider{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 p.server.URL,
+			"authorization_endpoint": p.server.URL + "/authorize",
+			"token_endpoint":         p.server.URL + "/token",
+			"jwks_uri":               p.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "k1",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		p.form = r.PostForm
+		if id, secret, _ := r.BasicAuth(); id != "dashboard" || secret != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "at", "id_token": p.idToken})
+	})
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+	return p
+}
+
+func (p *testProvider) sign(t *testing.T, alg string, claims map[string]interface{}) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": "k1"})
+	payload, _ := json.Marshal(claims)
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(input))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, p.key, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func (p *testProvider) claims() map[string]interface{} {
+	return map[string]interface{}{
+		"iss":                p.server.URL,
+		"sub":                "user-1",
+		"aud":                "dashboard",
+		"exp":                time.Now().Add(time.Hour).Unix(),
+		"iat":                time.Now().Unix(),
+		"nonce":              "n0nce",
+		"email":              "jdoe@example.com",
+		"email_verified":     true,
+		"preferred_username": "jdoe",
+	}
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	ctx := context.Background()
+	p := newTestProvider(t)
+
+	client, err := NewClient(ctx, Config{
+		Issuer:       p.server.URL + "/",
+		ClientID:     "dashboard",
+		ClientSecret: "s3cret",
+		RedirectURL:  "https://s3.example.com/dashboard/oidc/callback",
+		Scopes:       []string{"email"},
+	})
+	require.NoError(t, err)
+
+	authURL, err := url.Parse(client.AuthCodeURL("st4te", "n0nce", "verifier"))
+	require.NoError(t, err)
+	require.Equal(t, "/authorize", authURL.Path)
+	query := authURL.Query()
+	require.Equal(t, "openid email", query.Get("scope"))
+	require.Equal(t, "st4te", query.Get("state"))
+	require.Equal(t, "S256", query.Get("code_challenge_method"))
+
+	p.idToken = p.sign(t, "RS256", p.claims())
+	claims, err := client.Exchange(ctx, "c0de", "verifier", "n0nce")
+	require.NoError(t, err)
+	require.Equal(t, "user-1", claims.Subject)
+	require.Equal(t, "jdoe@example.com", claims.String("email"))
+	require.Equal(t, "jdoe", claims.PreferredUsername)
+	require.Equal(t, "verifier", p.form.Get("code_verifier"))
+	require.Equal(t, "c0de", p.form.Get("code"))
+
+	// A replayed token from another login has the wrong nonce
+	_, err = client.Exchange(ctx, "c0de", "verifier", "other")
+	require.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestVerifyRejectsInvalidTokens(t *testing.T) {
+	ctx := context.Background()
+	p := newTestProvider(t)
+	client, err := NewClient(ctx, Config{Issuer: p.server.URL, ClientID: "dashboard", ClientSecret: "s3cret"})
+	require.NoError(t, err)
+
+	tamper := func(change func(map[string]interface{})) string {
+		claims := p.claims()
+		change(claims)
+		return p.sign(t, "RS256", claims)
+	}
+
+	for name, token := range map[string]string{
+		"expired":      tamper(func(c map[string]interface{}) { c["exp"] = time.Now().Add(-time.Hour).Unix() }),
+		"audience":     tamper(func(c map[string]interface{}) { c["aud"] = []string{"other"} }),
+		"issuer":       tamper(func(c map[string]interface{}) { c["iss"] = "https://evil.example.com" }),
+		"no subject":   tamper(func(c map[string]interface{}) { delete(c, "sub") }),
+		"alg mismatch": p.sign(t, "ES256", p.claims()),
+		"unsigned": base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." +
+			base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"x"}`)) + ".",
+		"malformed": "a.b",
+	} {
+		_, err := client.Verify(ctx, token, "n0nce")
+		require.ErrorIs(t, err, ErrInvalidToken, name)
+	}
+
+	_, err = client.Verify(ctx, p.sign(t, "RS256", p.claims()), "n0nce")
+	require.NoError(t, err)
+}
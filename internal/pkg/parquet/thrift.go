@@ -0,0 +1,102 @@
+package parquet
+
+import "encoding/binary"
+
+// Thrift compact protocol type codes used by the Parquet metadata structs.
+const (
+	compactStop   byte = 0x00
+	compactI32    byte = 0x05
+	compactI64    byte = 0x06
+	compactBinary byte = 0x08
+	compactList   byte = 0x09
+	compactStruct byte = 0x0c
+)
+
+// encoder writes Thrift structs in the compact protocol. Fields must be
+// written in ascending ID order within each struct.
+type encoder struct {
+	buf    []byte
+	lastID int16
+	stack  []int16
+}
+
+func (e *encoder) fieldHeader(id int16, typ byte) {
+	if delta := id - e.lastID; delta > 0 && delta <= 15 {
+		e.buf = append(e.buf, byte(delta)<<4|typ)
+	} else {
+		e.buf = append(e.buf, typ)
+		e.uvarint(uint64(uint16((id << 1) ^ (id >> 15))))
+	}
+	e.lastID = id
+}
+
+func (e *encoder) uvarint(v uint64) {
+	e.buf = binary.AppendUvarint(e.buf, v)
+}
+
+func (e *encoder) zigzag32(v int32) {
+	e.uvarint(uint64(uint32((v << 1) ^ (v >> 31))))
+}
+
+func (e *encoder) zigzag64(v int64) {
+	e.uvarint(uint64((v << 1) ^ (v >> 63)))
+}
+
+func (e *encoder) i32(id int16, v int32) {
+	e.fieldHeader(id, compactI32)
+	e.zigzag32(v)
+}
+
+func (e *encoder) i64(id int16, v int64) {
+	e.fieldHeader(id, compactI64)
+	e.zigzag64(v)
+}
+
+func (e *encoder) string(id int16, s string) {
+	e.fieldHeader(id, compactBinary)
+	e.stringElem(s)
+}
+
+// beginStruct starts a struct-typed field, ended by endStruct.
+func (e *encoder) beginStruct(id int16) {
+	e.fieldHeader(id, compactStruct)
+	e.beginElem()
+}
+
+// beginElem starts a struct element of a list, ended by endStruct.
+func (e *encoder) beginElem() {
+	e.stack = append(e.stack, e.lastID)
+	e.lastID = 0
+}
+
+func (e *encoder) endStruct() {
+	e.buf = append(e.buf, compactStop)
+	e.lastID = e.stack[len(e.stack)-1]
+	e.stack = e.stack[:len(e.stack)-1]
+}
+
+// list starts a list field of n elements of type elem, which the caller
+// writes next with the *Elem methods.
+func (e *encoder) list(id int16, elem byte, n int) {
+	e.fieldHeader(id, compactList)
+	if n < 15 {
+		e.buf = append(e.buf, byte(n)<<4|elem)
+		return
+	}
+	e.buf = append(e.buf, 0xf0|elem)
+	e.uvarint(uint64(n))
+}
+
+func (e *encoder) i32Elem(v int32) {
+	e.zigzag32(v)
+}
+
+func (e *encoder) stringElem(s string) {
+	e.uvarint(uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+// end finishes the top-level struct and returns its encoding.
+func (e *encoder) end() []byte {
+	return append(e.buf, compactStop)
+}
@@ -0,0 +1,389 @@
+// Package parquet writes flat tables as Apache Parquet files. It covers
+// what report generation needs: required or optional columns of strings,
+// 64-bit integers, booleans and millisecond timestamps, stored with PLAIN
+// encoding and no compression, one data page per column chunk.
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Type is the type of a column's values.
+type Type int
+
+const (
+	// String columns hold Go strings, stored as UTF-8 byte arrays.
+	String Type = iota
+
+	// Int64 columns hold int64 values.
+	Int64
+
+	// Boolean columns hold bool values.
+	Boolean
+
+	// Timestamp columns hold time.Time values, stored as milliseconds since
+	// the Unix epoch in UTC.
+	Timestamp
+)
+
+// Column describes a column of the file.
+type Column struct {
+	Name string
+	Type Type
+
+	// Optional columns accept nil values.
+	Optional bool
+}
+
+// Parquet enum values, from parquet.thrift.
+const (
+	typeBoolean   int32 = 0
+	typeInt64     int32 = 2
+	typeByteArray int32 = 6
+
+	convertedUTF8            int32 = 0
+	convertedTimestampMillis int32 = 9
+
+	repetitionRequired int32 = 0
+	repetitionOptional int32 = 1
+
+	encodingPlain int32 = 0
+	encodingRLE   int32 = 3
+
+	codecUncompressed int32 = 0
+	pageTypeData      int32 = 0
+)
+
+var magic = []byte("PAR1")
+
+// DefaultRowGroupSize is the number of rows buffered before a row group is
+// written.
+const DefaultRowGroupSize = 100000
+
+// ErrClosed is returned when writing to a closed Writer.
+var ErrClosed = errors.New("parquet: writer is closed")
+
+// Writer writes rows to a Parquet file. Rows are buffered in memory and
+// written a row group at a time; the file is complete once Close returns.
+type Writer struct {
+	w       io.Writer
+	columns []Column
+
+	// RowGroupSize is the number of rows per row group.
+	RowGroupSize int
+
+	chunks    []columnBuffer
+	rows      int
+	offset    int64
+	numRows   int64
+	rowGroups [][]byte // encoded RowGroup structs
+	started   bool
+	closed    bool
+}
+
+// columnBuffer holds the values of a column in the current row group.
+type columnBuffer struct {
+	values  bytes.Buffer // PLAIN-encoded non-null values, except booleans
+	bools   []bool
+	defined []bool // definition level of each row, for optional columns
+}
+
+// NewWriter returns a Writer of the given columns to w.
+func NewWriter(w io.Writer, columns []Column) *Writer {
+	return &Writer{
+		w:            w,
+		columns:      columns,
+		RowGroupSize: DefaultRowGroupSize,
+		chunks:       make([]columnBuffer, len(columns)),
+	}
+}
+
+// Write buffers a row, with one value per column in column order.
+func (w *Writer) Write(row []any) error {
+	if w.closed {
+		return ErrClosed
+	}
+	if len(row) != len(w.columns) {
+		return fmt.Errorf("parquet: row has %d values, want %d", len(row), len(w.columns))
+	}
+
+	for i, value := range row {
+		column := w.columns[i]
+		chunk := &w.chunks[i]
+		if value == nil {
+			if !column.Optional {
+				return fmt.Errorf("parquet: column %s is required", column.Name)
+			}
+			chunk.defined = append(chunk.defined, false)
+			continue
+		}
+		if column.Optional {
+			chunk.defined = append(chunk.defined, true)
+		}
+		if err := chunk.add(column, value); err != nil {
+			return err
+		}
+	}
+
+	w.rows++
+	if w.rows >= w.RowGroupSize {
+		return w.flush()
+	}
+	return nil
+}
+
+// add appends a PLAIN-encoded value.
+func (c *columnBuffer) add(column Column, value any) error {
+	var ok bool
+	switch column.Type {
+	case String:
+		var s string
+		if s, ok = value.(string); ok {
+			_ = binary.Write(&c.values, binary.LittleEndian, uint32(len(s)))
+			c.values.WriteString(s)
+		}
+	case Int64:
+		var n int64
+		if n, ok = value.(int64); ok {
+			_ = binary.Write(&c.values, binary.LittleEndian, n)
+		}
+	case Boolean:
+		var b bool
+		if b, ok = value.(bool); ok {
+			c.bools = append(c.bools, b)
+		}
+	case Timestamp:
+		var t time.Time
+		if t, ok = value.(time.Time); ok {
+			_ = binary.Write(&c.values, binary.LittleEndian, t.UnixMilli())
+		}
+	}
+	if !ok {
+		return fmt.Errorf("parquet: column %s cannot hold %T", column.Name, value)
+	}
+	return nil
+}
+
+// Close writes any buffered rows and the file footer. It does not close
+// the underlying writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	if err := w.flush(); err != nil {
+		return err
+	}
+	w.closed = true
+	if err := w.start(); err != nil {
+		return err
+	}
+
+	footer := w.fileMetaData()
+	if err := w.write(footer); err != nil {
+		return err
+	}
+	var length [4]byte
+	binary.LittleEndian.PutUint32(length[:], uint32(len(footer)))
+	if err := w.write(length[:]); err != nil {
+		return err
+	}
+	return w.write(magic)
+}
+
+// start writes the leading magic number once.
+func (w *Writer) start() error {
+	if w.started {
+		return nil
+	}
+	w.started = true
+	return w.write(magic)
+}
+
+func (w *Writer) write(p []byte) error {
+	n, err := w.w.Write(p)
+	w.offset += int64(n)
+	return err
+}
+
+// flush writes the buffered rows as a row group.
+func (w *Writer) flush() error {
+	if w.rows == 0 {
+		return nil
+	}
+	if err := w.start(); err != nil {
+		return err
+	}
+
+	var group encoder
+	group.list(1, compactStruct, len(w.columns))
+	var totalSize int64
+	for i, column := range w.columns {
+		offset := w.offset
+		page := w.chunks[i].page(column)
+		header := pageHeader(len(page), w.rows)
+		if err := w.write(header); err != nil {
+			return err
+		}
+		if err := w.write(page); err != nil {
+			return err
+		}
+		size := int64(len(header) + len(page))
+		totalSize += size
+
+		group.beginElem()
+		group.i64(2, offset)
+		group.beginStruct(3)
+		group.i32(1, physicalType(column.Type))
+		group.list(2, compactI32, 2)
+		group.i32Elem(encodingPlain)
+		group.i32Elem(encodingRLE)
+		group.list(3, compactBinary, 1)
+		group.stringElem(column.Name)
+		group.i32(4, codecUncompressed)
+		group.i64(5, int64(w.rows))
+		group.i64(6, size)
+		group.i64(7, size)
+		group.i64(9, offset)
+		group.endStruct()
+		group.endStruct()
+	}
+	group.i64(2, totalSize)
+	group.i64(3, int64(w.rows))
+	w.rowGroups = append(w.rowGroups, group.end())
+
+	w.numRows += int64(w.rows)
+	w.rows = 0
+	w.chunks = make([]columnBuffer, len(w.columns))
+	return nil
+}
+
+// page returns the data page body: definition levels for optional
+// columns, then the values.
+func (c *columnBuffer) page(column Column) []byte {
+	var page bytes.Buffer
+	if column.Optional {
+		levels := encodeLevels(c.defined)
+		_ = binary.Write(&page, binary.LittleEndian, uint32(len(levels)))
+		page.Write(levels)
+	}
+	if column.Type == Boolean {
+		page.Write(packBits(c.bools))
+	} else {
+		page.Write(c.values.Bytes())
+	}
+	return page.Bytes()
+}
+
+// encodeLevels encodes definition levels of bit width 1 as a single
+// bit-packed run of the RLE/bit-packing hybrid encoding.
+func encodeLevels(defined []bool) []byte {
+	groups := (len(defined) + 7) / 8
+	out := binary.AppendUvarint(nil, uint64(groups)<<1|1)
+	return append(out, packBits(defined)...)
+}
+
+// packBits packs booleans LSB first, padding the last byte with zeros.
+func packBits(bits []bool) []byte {
+	out := make([]byte, (len(bits)+7)/8)
+	for i, bit := range bits {
+		if bit {
+			out[i/8] |= 1 << (i % 8)
+		}
+	}
+	return out
+}
+
+// pageHeader encodes the header of a data page of size bytes and rows values.
+func pageHeader(size, rows int) []byte {
+	var e encoder
+	e.i32(1, pageTypeData)
+	e.i32(2, int32(size))
+	e.i32(3, int32(size))
+	e.beginStruct(5)
+	e.i32(1, int32(rows))
+	e.i32(2, encodingPlain)
+	e.i32(3, encodingRLE)
+	e.i32(4, encodingRLE)
+	e.endStruct()
+	return e.end()
+}
+
+// fileMetaData encodes the footer.
+func (w *Writer) fileMetaData() []byte {
+	var e encoder
+	e.i32(1, 1)
+
+	e.list(2, compactStruct, len(w.columns)+1)
+	e.beginElem()
+	e.string(4, "schema")
+	e.i32(5, int32(len(w.columns)))
+	e.endStruct()
+	for _, column := range w.columns {
+		e.beginElem()
+		e.i32(1, physicalType(column.Type))
+		repetition := repetitionRequired
+		if column.Optional {
+			repetition = repetitionOptional
+		}
+		e.i32(3, repetition)
+		e.string(4, column.Name)
+		switch column.Type {
+		case String:
+			e.i32(6, convertedUTF8)
+		case Timestamp:
+			e.i32(6, convertedTimestampMillis)
+		}
+		e.endStruct()
+	}
+
+	e.i64(3, w.numRows)
+	e.list(4, compactStruct, len(w.rowGroups))
+	for _, group := range w.rowGroups {
+		// Each was encoded as a complete struct when it was flushed
+		e.buf = append(e.buf, group...)
+	}
+	e.string(6, "alexander-storage")
+	return e.end()
+}
+
+func physicalType(t Type) int32 {
+	switch t {
+	case Int64, Timestamp:
+		return typeInt64
+	case Boolean:
+		return typeBoolean
+	default:
+		return typeByteArray
+	}
+}
+
+// Schema returns the file's schema in the Parquet message notation, e.g.
+// for describing the file in a manifest.
+func Schema(name string, columns []Column) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "message %s { ", name)
+	for _, column := range columns {
+		repetition := "required"
+		if column.Optional {
+			repetition = "optional"
+		}
+		switch column.Type {
+		case String:
+			fmt.Fprintf(&b, "%s binary %s (UTF8); ", repetition, column.Name)
+		case Int64:
+			fmt.Fprintf(&b, "%s int64 %s; ", repetition, column.Name)
+		case Boolean:
+			fmt.Fprintf(&b, "%s boolean %s; ", repetition, column.Name)
+		case Timestamp:
+			fmt.Fprintf(&b, "%s int64 %s (TIMESTAMP_MILLIS); ", repetition, column.Name)
+		}
+	}
+	b.WriteString("}")
+	return b.String()
+}
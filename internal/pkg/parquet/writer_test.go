@@ -0,0 +1,188 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// decoder reads Thrift compact structs into maps of field ID to value, so
+// tests can check what a reader would see.
+type decoder struct {
+	t   *testing.T
+	buf []byte
+}
+
+func (d *decoder) uvarint() uint64 {
+	v, n := binary.Uvarint(d.buf)
+	require.Positive(d.t, n)
+	d.buf = d.buf[n:]
+	return v
+}
+
+func (d *decoder) zigzag() int64 {
+	v := d.uvarint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (d *decoder) value(typ byte) any {
+	switch typ {
+	case compactI32, compactI64:
+		return d.zigzag()
+	case compactBinary:
+		n := d.uvarint()
+		s := string(d.buf[:n])
+		d.buf = d.buf[n:]
+		return s
+	case compactList:
+		header := d.buf[0]
+		d.buf = d.buf[1:]
+		n := uint64(header >> 4)
+		if n == 15 {
+			n = d.uvarint()
+		}
+		list := make([]any, n)
+		for i := range list {
+			list[i] = d.value(header & 0x0f)
+		}
+		return list
+	case compactStruct:
+		return d.structure()
+	}
+	d.t.Fatalf("unexpected type %#x", typ)
+	return nil
+}
+
+func (d *decoder) structure() map[int16]any {
+	fields := map[int16]any{}
+	var id int16
+	for {
+		header := d.buf[0]
+		d.buf = d.buf[1:]
+		if header == compactStop {
+			return fields
+		}
+		if delta := int16(header >> 4); delta != 0 {
+			id += delta
+		} else {
+			id = int16(d.zigzag())
+		}
+		fields[id] = d.value(header & 0x0f)
+	}
+}
+
+func decode(t *testing.T, buf []byte) (map[int16]any, []byte) {
+	d := &decoder{t: t, buf: buf}
+	return d.structure(), d.buf
+}
+
+func TestWriter(t *testing.T) {
+	columns := []Column{
+		{Name: "key", Type: String},
+		{Name: "size", Type: Int64, Optional: true},
+		{Name: "is_latest", Type: Boolean},
+		{Name: "last_modified", Type: Timestamp},
+	}
+	modified := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	rows := [][]any{
+		{"a", int64(10), true, modified},
+		{"b", nil, false, modified},
+		{"c", int64(30), true, modified.Add(time.Second)},
+	}
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf, columns)
+	w.RowGroupSize = 2
+	for _, row := range rows {
+		require.NoError(t, w.Write(row))
+	}
+	require.NoError(t, w.Close())
+	require.ErrorIs(t, w.Write(rows[0]), ErrClosed)
+
+	file := buf.Bytes()
+	require.Equal(t, magic, file[:4])
+	require.Equal(t, magic, file[len(file)-4:])
+	length := binary.LittleEndian.Uint32(file[len(file)-8:])
+	meta, rest := decode(t, file[len(file)-8-int(length):len(file)-8])
+	require.Empty(t, rest)
+
+	require.Equal(t, int64(3), meta[3])
+	schema := meta[2].([]any)
+	require.Len(t, schema, 5)
+	require.Equal(t, int64(4), schema[0].(map[int16]any)[5])
+	for i, column := range columns {
+		element := schema[i+1].(map[int16]any)
+		require.Equal(t, column.Name, element[4])
+		require.Equal(t, int64(physicalType(column.Type)), element[1])
+	}
+
+	// Read every column back from its pages
+	keys := []string{}
+	sizes := []any{}
+	latest := []bool{}
+	modifiedAt := []time.Time{}
+	groups := meta[4].([]any)
+	require.Len(t, groups, 2)
+	for _, g := range groups {
+		group := g.(map[int16]any)
+		numRows := int(group[3].(int64))
+		for i, c := range group[1].([]any) {
+			chunk := c.(map[int16]any)
+			header, page := decode(t, file[chunk[2].(int64):])
+			require.Equal(t, int64(numRows), header[5].(map[int16]any)[1])
+			page = page[:header[2].(int64)]
+
+			defined := make([]bool, numRows)
+			for r := range defined {
+				defined[r] = true
+			}
+			if columns[i].Optional {
+				n := binary.LittleEndian.Uint32(page)
+				levels := page[4 : 4+n]
+				page = page[4+n:]
+				runs, m := binary.Uvarint(levels)
+				require.Equal(t, uint64(1), runs&1)
+				for r := range defined {
+					defined[r] = levels[m+r/8]&(1<<(r%8)) != 0
+				}
+			}
+
+			for r := 0; r < numRows; r++ {
+				switch columns[i].Type {
+				case String:
+					n := binary.LittleEndian.Uint32(page)
+					keys = append(keys, string(page[4:4+n]))
+					page = page[4+n:]
+				case Int64:
+					if !defined[r] {
+						sizes = append(sizes, nil)
+						continue
+					}
+					sizes = append(sizes, int64(binary.LittleEndian.Uint64(page)))
+					page = page[8:]
+				case Boolean:
+					latest = append(latest, page[r/8]&(1<<(r%8)) != 0)
+				case Timestamp:
+					ms := int64(binary.LittleEndian.Uint64(page))
+					modifiedAt = append(modifiedAt, time.UnixMilli(ms).UTC())
+					page = page[8:]
+				}
+			}
+		}
+	}
+
+	require.Equal(t, []string{"a", "b", "c"}, keys)
+	require.Equal(t, []any{int64(10), nil, int64(30)}, sizes)
+	require.Equal(t, []bool{true, false, true}, latest)
+	require.Equal(t, []time.Time{modified, modified, modified.Add(time.Second)}, modifiedAt)
+}
+
+func TestWriterRejectsBadRows(t *testing.T) {
+	w := NewWriter(&bytes.Buffer{}, []Column{{Name: "key", Type: String}})
+	require.Error(t, w.Write([]any{nil}))
+	require.Error(t, w.Write([]any{int64(1)}))
+	require.Error(t, w.Write([]any{"a", "b"}))
+}
@@ -0,0 +1,186 @@
+// Package syslog sends RFC 5424 messages to a syslog server over UDP, TCP or
+// a Unix socket. Unlike the standard library's log/syslog it also builds on
+// Windows, and it reconnects after connection failures.
+package syslog
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Severity is a syslog message severity.
+type Severity int
+
+// Severities, from RFC 5424 section 6.2.1.
+const (
+	SeverityEmergency Severity = iota
+	SeverityAlert
+	SeverityCritical
+	SeverityError
+	SeverityWarning
+	SeverityNotice
+	SeverityInfo
+	SeverityDebug
+)
+
+// Facility is a syslog facility.
+type Facility int
+
+// Commonly used facilities.
+const (
+	FacilityUser     Facility = 1
+	FacilityDaemon   Facility = 3
+	FacilityAuth     Facility = 4
+	FacilityAuthPriv Facility = 10
+	FacilityLocal0   Facility = 16
+	FacilityLocal7   Facility = 23
+)
+
+// ParseFacility parses a facility name: user, daemon, auth, authpriv or local0-local7.
+func ParseFacility(name string) (Facility, error) {
+	name = strings.ToLower(name)
+	switch name {
+	case "user":
+		return FacilityUser, nil
+	case "daemon":
+		return FacilityDaemon, nil
+	case "auth":
+		return FacilityAuth, nil
+	case "authpriv":
+		return FacilityAuthPriv, nil
+	}
+	if len(name) == 6 && strings.HasPrefix(name, "local") && name[5] >= '0' && name[5] <= '7' {
+		return FacilityLocal0 + Facility(name[5]-'0'), nil
+	}
+	return 0, fmt.Errorf("syslog: unknown facility %q", name)
+}
+
+// Config configures a Writer.
+type Config struct {
+	// Network is "udp", "tcp", "unix" or "unixgram".
+	Network string
+
+	// Address is host:port for udp and tcp, or a socket path such as /dev/log.
+	Address string
+
+	// Facility is the facility of every message. Default: FacilityAuthPriv.
+	Facility Facility
+
+	// AppName is the APP-NAME field. Default: the executable name.
+	AppName string
+
+	// Timeout bounds connecting and each write. Default: 5 seconds.
+	Timeout time.Duration
+}
+
+// Writer sends messages to a syslog server. It is safe for concurrent use.
+type Writer struct {
+	config   Config
+	hostname string
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// New creates a Writer. The connection is opened on the first message.
+func New(config Config) (*Writer, error) {
+	switch config.Network {
+	case "udp", "tcp", "unix", "unixgram":
+	default:
+		return nil, fmt.Errorf("syslog: unsupported network %q", config.Network)
+	}
+	if config.Address == "" {
+		return nil, fmt.Errorf("syslog: address is required")
+	}
+	if config.Facility == 0 {
+		config.Facility = FacilityAuthPriv
+	}
+	if config.AppName == "" {
+		config.AppName = "alexander"
+		if exe, err := os.Executable(); err == nil {
+			config.AppName = strings.TrimSuffix(baseName(exe), ".exe")
+		}
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 5 * time.Second
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+
+	return &Writer{config: config, hostname: hostname}, nil
+}
+
+// Write sends a message with the given severity. If the connection broke it
+// is reopened once before giving up.
+func (w *Writer) Write(severity Severity, msgID, message string) error {
+	frame := w.format(severity, msgID, message, time.Now())
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if w.conn == nil {
+			w.conn, err = net.DialTimeout(w.config.Network, w.config.Address, w.config.Timeout)
+			if err != nil {
+				return fmt.Errorf("syslog: %w", err)
+			}
+		}
+
+		_ = w.conn.SetWriteDeadline(time.Now().Add(w.config.Timeout))
+		if _, err = w.conn.Write(frame); err == nil {
+			return nil
+		}
+		w.conn.Close()
+		w.conn = nil
+	}
+	return fmt.Errorf("syslog: %w", err)
+}
+
+// Close closes the connection.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+// format builds an RFC 5424 message. Stream transports use octet counting
+// framing (RFC 6587), datagram transports send one message per packet.
+func (w *Writer) format(severity Severity, msgID, message string, now time.Time) []byte {
+	if msgID == "" {
+		msgID = "-"
+	}
+	msg := fmt.Sprintf("<%d>1 %s %s %s %d %s - %s",
+		int(w.config.Facility)*8+int(severity),
+		now.UTC().Format(time.RFC3339Nano),
+		w.hostname,
+		w.config.AppName,
+		os.Getpid(),
+		msgID,
+		message,
+	)
+	if w.config.Network == "tcp" || w.config.Network == "unix" {
+		return []byte(fmt.Sprintf("%d %s", len(msg), msg))
+	}
+	return []byte(msg)
+}
+
+// baseName returns the last element of a slash or backslash separated path.
+func baseName(path string) string {
+	if i := strings.LastIndexAny(path, `/\`); i >= 0 {
+		return path[i+1:]
+	}
+	return path
+}
@@ -0,0 +1,114 @@
+package syslog
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var rfc5424 = regexp.MustCompile(`^<(\d+)>1 \S+ \S+ test \d+ (\S+) - (.*)$`)
+
+func TestWriter_UDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	w, err := New(Config{Network: "udp", Address: conn.LocalAddr().String(), Facility: FacilityLocal0, AppName: "test"})
+	require.NoError(t, err)
+	defer w.Close()
+
+	require.NoError(t, w.Write(SeverityWarning, "PutObject", `{"actor":"alice"}`))
+
+	buf := make([]byte, 4096)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+
+	m := rfc5424.FindStringSubmatch(string(buf[:n]))
+	require.NotNil(t, m, "not an RFC 5424 message: %q", buf[:n])
+	assert.Equal(t, strconv.Itoa(16*8+4), m[1])
+	assert.Equal(t, "PutObject", m[2])
+	assert.Equal(t, `{"actor":"alice"}`, m[3])
+}
+
+func TestWriter_TCPOctetCounting(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	received := make(chan string, 2)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		for {
+			length, err := reader.ReadString(' ')
+			if err != nil {
+				return
+			}
+			n, _ := strconv.Atoi(strings.TrimSuffix(length, " "))
+			msg := make([]byte, n)
+			if _, err := io.ReadFull(reader, msg); err != nil {
+				return
+			}
+			received <- string(msg)
+		}
+	}()
+
+	w, err := New(Config{Network: "tcp", Address: ln.Addr().String(), AppName: "test"})
+	require.NoError(t, err)
+	defer w.Close()
+
+	require.NoError(t, w.Write(SeverityNotice, "", "first"))
+	require.NoError(t, w.Write(SeverityNotice, "user.create", "second"))
+
+	for _, want := range []struct{ msgID, message string }{{"-", "first"}, {"user.create", "second"}} {
+		select {
+		case msg := <-received:
+			m := rfc5424.FindStringSubmatch(msg)
+			require.NotNil(t, m, "not an RFC 5424 message: %q", msg)
+			assert.Equal(t, strconv.Itoa(int(FacilityAuthPriv)*8+int(SeverityNotice)), m[1])
+			assert.Equal(t, want.msgID, m[2])
+			assert.Equal(t, want.message, m[3])
+		case <-time.After(5 * time.Second):
+			t.Fatal("message not received")
+		}
+	}
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	_, err := New(Config{Network: "http", Address: "localhost:514"})
+	assert.Error(t, err)
+
+	_, err = New(Config{Network: "udp"})
+	assert.Error(t, err)
+}
+
+func TestParseFacility(t *testing.T) {
+	for name, want := range map[string]Facility{
+		"user":     FacilityUser,
+		"AUTHPRIV": FacilityAuthPriv,
+		"local0":   FacilityLocal0,
+		"local7":   FacilityLocal7,
+	} {
+		got, err := ParseFacility(name)
+		require.NoError(t, err, name)
+		assert.Equal(t, want, got, name)
+	}
+
+	for _, name := range []string{"", "local8", "local10", "kern"} {
+		_, err := ParseFacility(name)
+		assert.Error(t, err, name)
+	}
+}
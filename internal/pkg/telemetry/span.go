@@ -0,0 +1,227 @@
+package telemetry
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// TraceID identifies a trace.
+type TraceID [16]byte
+
+// SpanID identifies a span within a trace.
+type SpanID [8]byte
+
+// String returns the trace ID in lowercase hex.
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+
+// String returns the span ID in lowercase hex.
+func (id SpanID) String() string { return hex.EncodeToString(id[:]) }
+
+// SpanContext is the part of a span propagated to child spans and to other
+// services.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+// IsValid reports whether the trace and span IDs are set.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != TraceID{} && sc.SpanID != SpanID{}
+}
+
+// TraceParent formats the span context as a W3C traceparent header.
+func (sc SpanContext) TraceParent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s", sc.TraceID, sc.SpanID, flags)
+}
+
+// ParseTraceParent parses a W3C traceparent header. It returns false for
+// malformed headers and all-zero IDs.
+func ParseTraceParent(header string) (SpanContext, bool) {
+	// version-traceid-spanid-flags: 2+1+32+1+16+1+2 characters
+	if len(header) < 55 || header[2] != '-' || header[35] != '-' || header[52] != '-' {
+		return SpanContext{}, false
+	}
+	version, err := hex.DecodeString(header[0:2])
+	if err != nil || version[0] == 0xff || (version[0] == 0 && len(header) != 55) {
+		return SpanContext{}, false
+	}
+
+	var sc SpanContext
+	if _, err := hex.Decode(sc.TraceID[:], []byte(header[3:35])); err != nil {
+		return SpanContext{}, false
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(header[36:52])); err != nil {
+		return SpanContext{}, false
+	}
+	flags, err := hex.DecodeString(header[53:55])
+	if err != nil {
+		return SpanContext{}, false
+	}
+	sc.Sampled = flags[0]&0x01 != 0
+	return sc, sc.IsValid()
+}
+
+// Kind is the role of a span in a request.
+type Kind int
+
+// Span kinds, numbered as in OTLP.
+const (
+	KindInternal Kind = 1
+	KindServer   Kind = 2
+	KindClient   Kind = 3
+)
+
+// Span records one operation of a trace. A nil *Span is valid and records
+// nothing, so callers need not check whether tracing is enabled.
+type Span struct {
+	provider *Provider
+	name     string
+	kind     Kind
+	sc       SpanContext
+	parent   SpanID
+	start    time.Time
+
+	mu     sync.Mutex
+	end    time.Time
+	attrs  []attribute
+	errMsg string
+	ended  bool
+}
+
+// attribute is a key-value pair of a span.
+type attribute struct {
+	key   string
+	value any
+}
+
+// SpanContext returns the span's context, or the zero SpanContext for a
+// nil span.
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.sc
+}
+
+// SetAttribute sets an attribute. Values are strings, bools, integers or
+// floats; other values are formatted as strings.
+func (s *Span) SetAttribute(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.attrs {
+		if s.attrs[i].key == key {
+			s.attrs[i].value = value
+			return
+		}
+	}
+	s.attrs = append(s.attrs, attribute{key: key, value: value})
+}
+
+// SetError marks the span as failed. A nil error is ignored.
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	s.errMsg = err.Error()
+	s.mu.Unlock()
+}
+
+// End completes the span and queues it for export. Only the first call
+// has an effect.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.mu.Unlock()
+	s.provider.enqueue(s)
+}
+
+type spanKey struct{}
+
+// ContextWithSpanContext returns a context carrying a span context, e.g. one
+// received from another service, as the parent of spans started from it.
+func ContextWithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanKey{}, sc)
+}
+
+// SpanContextFromContext returns the span context of the current span, or
+// the zero SpanContext.
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	sc, _ := ctx.Value(spanKey{}).(SpanContext)
+	return sc
+}
+
+// Start starts an internal span as a child of the span in ctx.
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+	return StartKind(ctx, name, KindInternal)
+}
+
+// StartKind starts a span of the given kind as a child of the span in ctx.
+// Without a provider, or if the parent was not sampled, it returns a nil
+// span. A new trace is sampled according to the provider's ratio; an
+// unsampled one is still propagated, so its child spans are not sampled
+// either.
+func StartKind(ctx context.Context, name string, kind Kind) (context.Context, *Span) {
+	p := global.Load()
+	if p == nil {
+		return ctx, nil
+	}
+
+	parent := SpanContextFromContext(ctx)
+	sc := SpanContext{SpanID: newSpanID()}
+	if parent.IsValid() {
+		if !parent.Sampled {
+			return ctx, nil
+		}
+		sc.TraceID = parent.TraceID
+		sc.Sampled = true
+	} else {
+		sc.TraceID = newTraceID()
+		sc.Sampled = p.sample(sc.TraceID)
+		if !sc.Sampled {
+			return ContextWithSpanContext(ctx, sc), nil
+		}
+	}
+
+	span := &Span{
+		provider: p,
+		name:     name,
+		kind:     kind,
+		sc:       sc,
+		parent:   parent.SpanID,
+		start:    time.Now(),
+	}
+	return ContextWithSpanContext(ctx, sc), span
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	rand.Read(id[:])
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	rand.Read(id[:])
+	return id
+}
@@ -0,0 +1,215 @@
+// Package telemetry records distributed traces and exports them to an
+// OpenTelemetry collector with the OTLP/HTTP protocol (JSON encoding).
+// Incoming W3C traceparent headers are continued, so spans join the trace
+// of the calling service.
+//
+// Spans are started with Start from a context; a process-wide Provider set
+// with SetProvider samples and exports them. Without a provider, Start
+// returns nil spans, whose methods do nothing.
+package telemetry
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Config configures a Provider.
+type Config struct {
+	// Endpoint is the base URL of the OTLP/HTTP receiver, e.g.
+	// http://otel-collector:4318. Spans are posted to Endpoint/v1/traces.
+	Endpoint string
+
+	// Headers are sent with every export request, e.g. for authentication.
+	Headers map[string]string
+
+	// ServiceName and ServiceVersion describe this process in exported spans.
+	ServiceName    string
+	ServiceVersion string
+
+	// SampleRatio is the fraction of new traces that are recorded, from 0
+	// to 1. Traces continued from a caller follow the caller's decision.
+	SampleRatio float64
+
+	// BatchSize is the maximum number of spans per export request.
+	BatchSize int
+
+	// ExportInterval is how often queued spans are exported.
+	ExportInterval time.Duration
+
+	// ExportTimeout bounds each export request.
+	ExportTimeout time.Duration
+
+	Logger zerolog.Logger
+}
+
+// Provider samples spans and exports them in batches.
+type Provider struct {
+	config    Config
+	url       string
+	client    *http.Client
+	threshold uint64
+	logger    zerolog.Logger
+
+	queue    chan *Span
+	flush    chan chan struct{}
+	dropped  atomic.Int64
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// global is the provider used by Start.
+var global atomic.Pointer[Provider]
+
+// SetProvider sets the provider used by Start. A nil provider disables
+// tracing.
+func SetProvider(p *Provider) {
+	global.Store(p)
+}
+
+// NewProvider creates a provider and starts exporting spans. Call Shutdown
+// to export the remaining spans.
+func NewProvider(config Config) (*Provider, error) {
+	if config.Endpoint == "" {
+		return nil, errors.New("telemetry: an OTLP endpoint is required")
+	}
+	if config.SampleRatio < 0 || config.SampleRatio > 1 {
+		return nil, errors.New("telemetry: sample ratio must be between 0 and 1")
+	}
+	if config.ServiceName == "" {
+		config.ServiceName = "alexander-storage"
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 512
+	}
+	if config.ExportInterval <= 0 {
+		config.ExportInterval = 5 * time.Second
+	}
+	if config.ExportTimeout <= 0 {
+		config.ExportTimeout = 10 * time.Second
+	}
+
+	p := &Provider{
+		config: config,
+		url:    strings.TrimSuffix(config.Endpoint, "/") + "/v1/traces",
+		client: &http.Client{Timeout: config.ExportTimeout},
+		logger: config.Logger.With().Str("component", "telemetry").Logger(),
+		queue:  make(chan *Span, 4*config.BatchSize),
+		flush:  make(chan chan struct{}),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	// Sample trace IDs below the ratio of the 63-bit ID space
+	p.threshold = uint64(config.SampleRatio * (1 << 63))
+
+	go p.run()
+	return p, nil
+}
+
+// Flush exports the queued spans and waits until they are sent.
+func (p *Provider) Flush(ctx context.Context) error {
+	flushed := make(chan struct{})
+	select {
+	case p.flush <- flushed:
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Shutdown exports the queued spans and stops the provider. Spans ended
+// afterwards are dropped.
+func (p *Provider) Shutdown(ctx context.Context) error {
+	p.stopOnce.Do(func() { close(p.stop) })
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// sample decides whether a new trace is recorded.
+func (p *Provider) sample(id TraceID) bool {
+	return binary.BigEndian.Uint64(id[8:])>>1 < p.threshold
+}
+
+// enqueue queues an ended span for export, dropping it if the queue is full.
+func (p *Provider) enqueue(s *Span) {
+	select {
+	case <-p.stop:
+		return
+	default:
+	}
+	select {
+	case p.queue <- s:
+	default:
+		p.dropped.Add(1)
+	}
+}
+
+// run exports batches of spans until the provider is shut down.
+func (p *Provider) run() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.config.ExportInterval)
+	defer ticker.Stop()
+
+	batch := make([]*Span, 0, p.config.BatchSize)
+	export := func() {
+		if len(batch) > 0 {
+			p.export(batch)
+			batch = batch[:0]
+		}
+		if dropped := p.dropped.Swap(0); dropped > 0 {
+			p.logger.Warn().Int64("dropped", dropped).Msg("Span queue full, spans dropped")
+		}
+	}
+	drain := func() {
+		for {
+			select {
+			case s := <-p.queue:
+				batch = append(batch, s)
+				if len(batch) == p.config.BatchSize {
+					export()
+				}
+			default:
+				export()
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case s := <-p.queue:
+			batch = append(batch, s)
+			if len(batch) == p.config.BatchSize {
+				export()
+			}
+		case <-ticker.C:
+			export()
+		case flushed := <-p.flush:
+			drain()
+			close(flushed)
+		case <-p.stop:
+			drain()
+			return
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	sc, ok := ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	require.True(t, ok)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+	assert.Equal(t, "00f067aa0ba902b7", sc.SpanID.String())
+	assert.True(t, sc.Sampled)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", sc.TraceParent())
+
+	// Future versions may append fields
+	_, ok = ParseTraceParent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra")
+	assert.True(t, ok)
+
+	for _, header := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473g-00f067aa0ba902b7-01",
+	} {
+		_, ok := ParseTraceParent(header)
+		assert.False(t, ok, header)
+	}
+}
+
+// collector records the spans posted to it.
+type collector struct {
+	mu    sync.Mutex
+	spans []otlpSpan
+	auth  string
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req otlpRequest
+	if r.URL.Path != "/v1/traces" || json.NewDecoder(r.Body).Decode(&req) != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.auth = r.Header.Get("Authorization")
+	for _, rs := range req.ResourceSpans {
+		for _, ss := range rs.ScopeSpans {
+			c.spans = append(c.spans, ss.Spans...)
+		}
+	}
+}
+
+func TestProvider_ExportsSpansOfContinuedTrace(t *testing.T) {
+	c := &collector{}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	p, err := NewProvider(Config{
+		Endpoint:       srv.URL,
+		Headers:        map[string]string{"Authorization": "Bearer token"},
+		SampleRatio:    0, // Only continued, sampled traces are recorded
+		ExportInterval: time.Hour,
+		Logger:         zerolog.Nop(),
+	})
+	require.NoError(t, err)
+	SetProvider(p)
+	defer SetProvider(nil)
+
+	// A new trace is not sampled, and neither are its children
+	ctx, span := StartKind(context.Background(), "unsampled", KindServer)
+	assert.Nil(t, span)
+	assert.True(t, SpanContextFromContext(ctx).IsValid())
+	_, child := Start(ctx, "child")
+	assert.Nil(t, child)
+
+	// A sampled caller's trace is continued
+	remote, _ := ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx, server := StartKind(ContextWithSpanContext(context.Background(), remote), "GET /{bucket}/{key}", KindServer)
+	require.NotNil(t, server)
+	server.SetAttribute("http.response.status_code", 404)
+	_, internal := Start(ctx, "object.get_object")
+	internal.SetError(errors.New("object not found"))
+	internal.End()
+	server.End()
+	server.End()
+
+	require.NoError(t, p.Flush(context.Background()))
+	require.NoError(t, p.Shutdown(context.Background()))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	assert.Equal(t, "Bearer token", c.auth)
+	require.Len(t, c.spans, 2)
+
+	got, parent := c.spans[0], c.spans[1]
+	assert.Equal(t, "object.get_object", got.Name)
+	assert.Equal(t, KindInternal, got.Kind)
+	assert.Equal(t, 2, got.Status.Code)
+	assert.Equal(t, parent.SpanID, got.ParentSpanID)
+
+	assert.Equal(t, "GET /{bucket}/{key}", parent.Name)
+	assert.Equal(t, KindServer, parent.Kind)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", parent.TraceID)
+	assert.Equal(t, "00f067aa0ba902b7", parent.ParentSpanID)
+	require.Len(t, parent.Attributes, 1)
+	assert.Equal(t, "404", *parent.Attributes[0].Value.IntValue)
+}
+
+func TestStart_WithoutProvider(t *testing.T) {
+	ctx, span := Start(context.Background(), "noop")
+	assert.Nil(t, span)
+	assert.False(t, SpanContextFromContext(ctx).IsValid())
+
+	// Nil spans are safe to use
+	span.SetAttribute("key", "value")
+	span.SetError(errors.New("ignored"))
+	span.End()
+}
@@ -0,0 +1,105 @@
+package zstd
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// bitWriter writes a bitstream that is read backwards, as FSE and Huffman
+// coded sections are: bits fill each byte from its least significant bit,
+// and a single 1 bit after the last value marks where reading starts.
+type bitWriter struct {
+	out   []byte
+	acc   uint64
+	nbits uint
+}
+
+// addBits appends the low n bits of value, n <= 32.
+func (w *bitWriter) addBits(value uint64, n uint) {
+	w.acc |= (value & (1<<n - 1)) << w.nbits
+	w.nbits += n
+	for w.nbits >= 8 {
+		w.out = append(w.out, byte(w.acc))
+		w.acc >>= 8
+		w.nbits -= 8
+	}
+}
+
+// close appends the end marker and returns the stream.
+func (w *bitWriter) close() []byte {
+	w.addBits(1, 1)
+	if w.nbits > 0 {
+		w.out = append(w.out, byte(w.acc))
+	}
+	return w.out
+}
+
+// backwardReader reads a bitstream written by bitWriter, last value first.
+// Reading past the start of the stream yields zero bits and leaves pos
+// negative, which callers check to detect corrupt or overread streams.
+type backwardReader struct {
+	data []byte
+	pos  int // number of unread bits
+}
+
+func newBackwardReader(data []byte) (*backwardReader, error) {
+	if len(data) == 0 || data[len(data)-1] == 0 {
+		return nil, ErrCorrupt
+	}
+	return &backwardReader{data: data, pos: len(data)*8 - 9 + bits.Len8(data[len(data)-1])}, nil
+}
+
+// peek returns the next n bits without consuming them, n <= 32.
+func (r *backwardReader) peek(n uint) uint64 {
+	start := r.pos - int(n)
+	if start >= 0 {
+		return extractBits(r.data, start, n)
+	}
+	if r.pos <= 0 {
+		return 0
+	}
+	return extractBits(r.data, 0, uint(r.pos)) << uint(-start)
+}
+
+// read consumes and returns the next n bits, n <= 32.
+func (r *backwardReader) read(n uint) uint64 {
+	if n == 0 {
+		return 0
+	}
+	v := r.peek(n)
+	r.pos -= int(n)
+	return v
+}
+
+// forwardReader reads a bitstream from the least significant bit of its
+// first byte, as FSE table descriptions are stored.
+type forwardReader struct {
+	data []byte
+	pos  int // number of bits read
+}
+
+func (r *forwardReader) peek(n uint) uint64 {
+	return extractBits(r.data, r.pos, n)
+}
+
+func (r *forwardReader) read(n uint) uint64 {
+	v := r.peek(n)
+	r.pos += int(n)
+	return v
+}
+
+// extractBits returns the n bits of data starting at bit start, counting
+// from the least significant bit of the first byte. Bits past the end of
+// data are zero. n must be at most 56.
+func extractBits(data []byte, start int, n uint) uint64 {
+	i := start >> 3
+	var v uint64
+	if i+8 <= len(data) {
+		v = binary.LittleEndian.Uint64(data[i:])
+	} else {
+		for j := len(data) - 1; j >= i; j-- {
+			v = v<<8 | uint64(data[j])
+		}
+	}
+	return v >> (start & 7) & (1<<n - 1)
+}
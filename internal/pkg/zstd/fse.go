@@ -0,0 +1,227 @@
+package zstd
+
+import "math/bits"
+
+// fseEntry is a decoding table state: the symbol it emits and how to
+// reach the next state.
+type fseEntry struct {
+	symbol uint8
+	nbBits uint8
+	base   uint16
+}
+
+// fseTable is an FSE decoding table.
+type fseTable struct {
+	entries     []fseEntry
+	accuracyLog uint8
+}
+
+// spreadSymbols assigns the table positions to symbols in the order the
+// format prescribes. Symbols with a "less than one" probability take the
+// last positions.
+func spreadSymbols(norm []int16, accuracyLog uint8) []uint8 {
+	size := 1 << accuracyLog
+	symbols := make([]uint8, size)
+	high := size - 1
+	for s, n := range norm {
+		if n == -1 {
+			symbols[high] = uint8(s)
+			high--
+		}
+	}
+	mask := size - 1
+	step := size>>1 + size>>3 + 3
+	pos := 0
+	for s, n := range norm {
+		for i := 0; i < int(n); i++ {
+			symbols[pos] = uint8(s)
+			pos = (pos + step) & mask
+			for pos > high {
+				pos = (pos + step) & mask
+			}
+		}
+	}
+	return symbols
+}
+
+// newFSETable builds the decoding table of a normalized distribution.
+func newFSETable(norm []int16, accuracyLog uint8) *fseTable {
+	size := 1 << accuracyLog
+	symbols := spreadSymbols(norm, accuracyLog)
+	next := make([]int, len(norm))
+	for s, n := range norm {
+		if n == -1 {
+			next[s] = 1
+		} else {
+			next[s] = int(n)
+		}
+	}
+	t := &fseTable{entries: make([]fseEntry, size), accuracyLog: accuracyLog}
+	for u, s := range symbols {
+		state := next[s]
+		next[s]++
+		nbBits := int(accuracyLog) - (bits.Len(uint(state)) - 1)
+		t.entries[u] = fseEntry{
+			symbol: s,
+			nbBits: uint8(nbBits),
+			base:   uint16(state<<nbBits - size),
+		}
+	}
+	return t
+}
+
+// rleFSETable returns a table that always emits symbol and reads no bits.
+func rleFSETable(symbol uint8) *fseTable {
+	return &fseTable{entries: []fseEntry{{symbol: symbol}}}
+}
+
+// readFSETable parses a table description from the start of data and
+// returns the table and the number of bytes it took.
+func readFSETable(data []byte, maxSymbol int, maxLog uint8) (*fseTable, int, error) {
+	if len(data) < 2 {
+		return nil, 0, ErrCorrupt
+	}
+	r := &forwardReader{data: data}
+	accuracyLog := uint8(r.read(4)) + 5
+	if accuracyLog > maxLog {
+		return nil, 0, ErrCorrupt
+	}
+	remaining := 1<<accuracyLog + 1
+	threshold := 1 << accuracyLog
+	nbBits := uint(accuracyLog) + 1
+	norm := make([]int16, 0, maxSymbol+1)
+	previous0 := false
+	for remaining > 1 && len(norm) <= maxSymbol {
+		if previous0 {
+			n0 := len(norm)
+			for r.peek(16) == 0xFFFF {
+				n0 += 24
+				r.pos += 16
+			}
+			for r.peek(2) == 3 {
+				n0 += 3
+				r.pos += 2
+			}
+			n0 += int(r.read(2))
+			if n0 > maxSymbol+1 {
+				return nil, 0, ErrCorrupt
+			}
+			for len(norm) < n0 {
+				norm = append(norm, 0)
+			}
+			if len(norm) > maxSymbol {
+				break
+			}
+		}
+		limit := uint64(2*threshold - 1 - remaining)
+		var count int
+		if low := r.peek(nbBits - 1); low < limit {
+			count = int(low)
+			r.pos += int(nbBits) - 1
+		} else {
+			v := r.read(nbBits)
+			if v >= uint64(threshold) {
+				v -= limit
+			}
+			count = int(v)
+		}
+		count--
+		if count < 0 {
+			remaining += count
+		} else {
+			remaining -= count
+		}
+		norm = append(norm, int16(count))
+		previous0 = count == 0
+		for remaining < threshold {
+			nbBits--
+			threshold >>= 1
+		}
+		if r.pos > len(data)*8 {
+			return nil, 0, ErrCorrupt
+		}
+	}
+	if remaining != 1 || r.pos > len(data)*8 {
+		return nil, 0, ErrCorrupt
+	}
+	return newFSETable(norm, accuracyLog), (r.pos + 7) / 8, nil
+}
+
+// fseSymbolTransform holds how to encode one symbol from any state.
+type fseSymbolTransform struct {
+	deltaNbBits    uint32
+	deltaFindState int32
+}
+
+// fseEncTable is an FSE encoding table.
+type fseEncTable struct {
+	stateTable  []uint16
+	symbols     []fseSymbolTransform
+	accuracyLog uint8
+}
+
+// newFSEEncTable builds the encoding table of a normalized distribution,
+// the mirror image of newFSETable.
+func newFSEEncTable(norm []int16, accuracyLog uint8) *fseEncTable {
+	size := 1 << accuracyLog
+	symbols := spreadSymbols(norm, accuracyLog)
+	cumul := make([]int, len(norm)+1)
+	for s, n := range norm {
+		if n == -1 {
+			n = 1
+		}
+		cumul[s+1] = cumul[s] + int(n)
+	}
+	t := &fseEncTable{
+		stateTable:  make([]uint16, size),
+		symbols:     make([]fseSymbolTransform, len(norm)),
+		accuracyLog: accuracyLog,
+	}
+	next := append([]int(nil), cumul...)
+	for u, s := range symbols {
+		t.stateTable[next[s]] = uint16(size + u)
+		next[s]++
+	}
+	for s, n := range norm {
+		switch n {
+		case 0:
+			t.symbols[s].deltaNbBits = uint32(int(accuracyLog+1)<<16 - size)
+		case -1, 1:
+			t.symbols[s].deltaNbBits = uint32(int(accuracyLog)<<16 - size)
+			t.symbols[s].deltaFindState = int32(cumul[s] - 1)
+		default:
+			maxBitsOut := int(accuracyLog) - (bits.Len(uint(n-1)) - 1)
+			minStatePlus := int(n) << maxBitsOut
+			t.symbols[s].deltaNbBits = uint32(maxBitsOut<<16 - minStatePlus)
+			t.symbols[s].deltaFindState = int32(cumul[s] - int(n))
+		}
+	}
+	return t
+}
+
+// fseEncoder is the state of one FSE stream being encoded.
+type fseEncoder struct {
+	table *fseEncTable
+	state uint32
+}
+
+// init sets the state for the last symbol of the stream, which is the
+// first one encoded.
+func (e *fseEncoder) init(table *fseEncTable, symbol uint8) {
+	e.table = table
+	tt := table.symbols[symbol]
+	nbBitsOut := (tt.deltaNbBits + 1<<15) >> 16
+	value := nbBitsOut<<16 - tt.deltaNbBits
+	e.state = uint32(table.stateTable[int32(value>>nbBitsOut)+tt.deltaFindState])
+}
+
+func (e *fseEncoder) encode(w *bitWriter, symbol uint8) {
+	tt := e.table.symbols[symbol]
+	nbBitsOut := (e.state + tt.deltaNbBits) >> 16
+	w.addBits(uint64(e.state), uint(nbBitsOut))
+	e.state = uint32(e.table.stateTable[int32(e.state>>nbBitsOut)+tt.deltaFindState])
+}
+
+func (e *fseEncoder) flush(w *bitWriter) {
+	w.addBits(uint64(e.state), uint(e.table.accuracyLog))
+}
@@ -0,0 +1,202 @@
+package zstd
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+const maxHuffmanBits = 11
+
+// huffEntry is a decoding table entry: the symbol a code prefix stands
+// for and the length of its code.
+type huffEntry struct {
+	symbol uint8
+	nbBits uint8
+}
+
+// huffTable is a Huffman decoding table indexed by the next maxBits bits
+// of a stream.
+type huffTable struct {
+	entries []huffEntry
+	maxBits uint8
+}
+
+// readHuffmanTable parses a Huffman tree description from the start of
+// data and returns the table and the number of bytes it took.
+func readHuffmanTable(data []byte) (*huffTable, int, error) {
+	if len(data) == 0 {
+		return nil, 0, ErrCorrupt
+	}
+	var weights []uint8
+	header := int(data[0])
+	size := 1
+	if header < 128 {
+		// FSE-compressed weights
+		size += header
+		if len(data) < size {
+			return nil, 0, ErrCorrupt
+		}
+		var err error
+		weights, err = decodeHuffmanWeights(data[1:size])
+		if err != nil {
+			return nil, 0, err
+		}
+	} else {
+		// Direct representation, four bits per weight
+		n := header - 127
+		size += (n + 1) / 2
+		if len(data) < size {
+			return nil, 0, ErrCorrupt
+		}
+		weights = make([]uint8, n)
+		for i := range weights {
+			b := data[1+i/2]
+			if i%2 == 0 {
+				weights[i] = b >> 4
+			} else {
+				weights[i] = b & 0xf
+			}
+		}
+	}
+	t, err := newHuffTable(weights)
+	if err != nil {
+		return nil, 0, err
+	}
+	return t, size, nil
+}
+
+// decodeHuffmanWeights decodes weights stored with two interleaved FSE
+// states sharing one table.
+func decodeHuffmanWeights(data []byte) ([]uint8, error) {
+	table, n, err := readFSETable(data, 255, 6)
+	if err != nil {
+		return nil, err
+	}
+	r, err := newBackwardReader(data[n:])
+	if err != nil {
+		return nil, err
+	}
+	log := uint(table.accuracyLog)
+	states := [2]uint64{r.read(log), r.read(log)}
+	var weights []uint8
+	for i := 0; ; i ^= 1 {
+		if len(weights) >= 255 {
+			return nil, ErrCorrupt
+		}
+		e := table.entries[states[i]]
+		weights = append(weights, e.symbol)
+		states[i] = uint64(e.base) + r.read(uint(e.nbBits))
+		if r.pos < 0 {
+			weights = append(weights, table.entries[states[i^1]].symbol)
+			break
+		}
+	}
+	return weights, nil
+}
+
+// newHuffTable builds the decoding table for the given weights. The
+// weight of the last symbol is implied by the others.
+func newHuffTable(weights []uint8) (*huffTable, error) {
+	var total uint32
+	for _, w := range weights {
+		if w > maxHuffmanBits {
+			return nil, ErrCorrupt
+		}
+		if w > 0 {
+			total += 1 << (w - 1)
+		}
+	}
+	if total == 0 {
+		return nil, ErrCorrupt
+	}
+	maxBits := bits.Len32(total)
+	if maxBits > maxHuffmanBits {
+		return nil, ErrCorrupt
+	}
+	rest := uint32(1)<<maxBits - total
+	if rest&(rest-1) != 0 {
+		return nil, ErrCorrupt
+	}
+	weights = append(weights, uint8(bits.Len32(rest)))
+	if len(weights) > 256 {
+		return nil, ErrCorrupt
+	}
+
+	// Longer codes come first, and symbols keep their order within a
+	// code length
+	var rankStart [maxHuffmanBits + 2]int
+	for _, w := range weights {
+		if w > 0 {
+			rankStart[w] += 1 << (w - 1)
+		}
+	}
+	next := 0
+	for w := 1; w <= maxBits; w++ {
+		next, rankStart[w] = next+rankStart[w], next
+	}
+	t := &huffTable{entries: make([]huffEntry, 1<<maxBits), maxBits: uint8(maxBits)}
+	for s, w := range weights {
+		if w == 0 {
+			continue
+		}
+		length := 1 << (w - 1)
+		e := huffEntry{symbol: uint8(s), nbBits: uint8(maxBits + 1 - int(w))}
+		for i := rankStart[w]; i < rankStart[w]+length; i++ {
+			t.entries[i] = e
+		}
+		rankStart[w] += length
+	}
+	return t, nil
+}
+
+// decodeStreams decodes size literals from one or four Huffman streams.
+func (t *huffTable) decodeStreams(dst, data []byte, size int, four bool) ([]byte, error) {
+	if !four {
+		return t.decodeStream(dst, data, size)
+	}
+	if len(data) < 6 {
+		return nil, ErrCorrupt
+	}
+	var sizes [4]int
+	sizes[0] = int(binary.LittleEndian.Uint16(data))
+	sizes[1] = int(binary.LittleEndian.Uint16(data[2:]))
+	sizes[2] = int(binary.LittleEndian.Uint16(data[4:]))
+	data = data[6:]
+	sizes[3] = len(data) - sizes[0] - sizes[1] - sizes[2]
+	if sizes[3] < 0 {
+		return nil, ErrCorrupt
+	}
+	segment := (size + 3) / 4
+	if 3*segment > size {
+		return nil, ErrCorrupt
+	}
+	var err error
+	for i, n := range sizes {
+		count := segment
+		if i == 3 {
+			count = size - 3*segment
+		}
+		if dst, err = t.decodeStream(dst, data[:n], count); err != nil {
+			return nil, err
+		}
+		data = data[n:]
+	}
+	return dst, nil
+}
+
+func (t *huffTable) decodeStream(dst, data []byte, count int) ([]byte, error) {
+	r, err := newBackwardReader(data)
+	if err != nil {
+		return nil, err
+	}
+	maxBits := uint(t.maxBits)
+	for i := 0; i < count; i++ {
+		e := t.entries[r.peek(maxBits)]
+		r.pos -= int(e.nbBits)
+		dst = append(dst, e.symbol)
+	}
+	if r.pos != 0 {
+		return nil, ErrCorrupt
+	}
+	return dst, nil
+}
@@ -0,0 +1,529 @@
+package zstd
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// Predefined sequence decoding tables.
+var (
+	llDefaultTable = newFSETable(llDefaultNorm, llDefaultLog)
+	mlDefaultTable = newFSETable(mlDefaultNorm, mlDefaultLog)
+	ofDefaultTable = newFSETable(ofDefaultNorm, ofDefaultLog)
+)
+
+// Reader decompresses a stream of Zstandard frames. Concatenated frames
+// decode as one stream, and skippable frames are ignored.
+type Reader struct {
+	r   *bufio.Reader
+	err error
+
+	// hist holds the decoded data of the current frame: the window that
+	// matches may refer to, followed by the bytes not yet read.
+	hist []byte
+	off  int
+
+	inFrame     bool
+	lastBlock   bool
+	frames      int
+	windowSize  int
+	checksum    bool
+	contentSize int64 // -1 when the header leaves it out
+	produced    int64
+	hash        *xxh64
+
+	// State carried from block to block within a frame
+	block     []byte
+	literals  []byte
+	huffman   *huffTable
+	llTable   *fseTable
+	mlTable   *fseTable
+	ofTable   *fseTable
+	repeats   [3]int
+	sequences []sequence
+}
+
+// sequence is a run of literals followed by a match.
+type sequence struct {
+	litLen   int
+	matchLen int
+	offset   int
+}
+
+// NewReader returns a Reader that decompresses r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r), hash: newXXH64()}
+}
+
+// Read reads decompressed data.
+func (z *Reader) Read(p []byte) (int, error) {
+	for z.off == len(z.hist) {
+		if z.err != nil {
+			return 0, z.err
+		}
+		z.err = z.next()
+	}
+	n := copy(p, z.hist[z.off:])
+	z.off += n
+	return n, nil
+}
+
+// next decodes the next block, starting a new frame when the last one is
+// finished.
+func (z *Reader) next() error {
+	if !z.inFrame {
+		return z.readFrameHeader()
+	}
+	if z.lastBlock {
+		return z.finishFrame()
+	}
+	return z.readBlock()
+}
+
+func (z *Reader) readFrameHeader() error {
+	var magic [4]byte
+	for {
+		n, err := io.ReadFull(z.r, magic[:])
+		if n == 0 && err == io.EOF {
+			if z.frames == 0 {
+				return io.ErrUnexpectedEOF
+			}
+			return io.EOF
+		}
+		if err != nil {
+			return unexpected(err)
+		}
+		m := binary.LittleEndian.Uint32(magic[:])
+		if m == frameMagic {
+			break
+		}
+		if m&skippableMagicMask != skippableMagic {
+			return ErrCorrupt
+		}
+		if err := z.skipFrame(); err != nil {
+			return err
+		}
+	}
+
+	descriptor, err := z.r.ReadByte()
+	if err != nil {
+		return unexpected(err)
+	}
+	if descriptor&0x08 != 0 {
+		return ErrCorrupt
+	}
+	singleSegment := descriptor&0x20 != 0
+	z.checksum = descriptor&0x04 != 0
+	if !singleSegment {
+		b, err := z.r.ReadByte()
+		if err != nil {
+			return unexpected(err)
+		}
+		base := 1 << (10 + b>>3)
+		z.windowSize = base + base/8*int(b&7)
+	}
+	if n := [4]int{0, 1, 2, 4}[descriptor&3]; n > 0 {
+		var id [4]byte
+		if _, err := io.ReadFull(z.r, id[:n]); err != nil {
+			return unexpected(err)
+		}
+		if binary.LittleEndian.Uint32(id[:]) != 0 {
+			return ErrUnsupported
+		}
+	}
+	z.contentSize = -1
+	fcsSize := [4]int{0, 2, 4, 8}[descriptor>>6]
+	if fcsSize == 0 && singleSegment {
+		fcsSize = 1
+	}
+	if fcsSize > 0 {
+		var fcs [8]byte
+		if _, err := io.ReadFull(z.r, fcs[:fcsSize]); err != nil {
+			return unexpected(err)
+		}
+		z.contentSize = int64(binary.LittleEndian.Uint64(fcs[:]))
+		if fcsSize == 2 {
+			z.contentSize += 256
+		}
+		if z.contentSize < 0 {
+			return ErrUnsupported
+		}
+	}
+	if singleSegment {
+		z.windowSize = int(min(z.contentSize, maxWindowSize+1))
+	}
+	if z.windowSize > maxWindowSize {
+		return ErrUnsupported
+	}
+
+	z.frames++
+	z.inFrame = true
+	z.lastBlock = false
+	z.produced = 0
+	z.hash.reset()
+	z.hist = z.hist[:0]
+	z.off = 0
+	z.huffman = nil
+	z.llTable, z.mlTable, z.ofTable = nil, nil, nil
+	z.repeats = [3]int{1, 4, 8}
+	return nil
+}
+
+func (z *Reader) skipFrame() error {
+	var size [4]byte
+	if _, err := io.ReadFull(z.r, size[:]); err != nil {
+		return unexpected(err)
+	}
+	n := int64(binary.LittleEndian.Uint32(size[:]))
+	if skipped, err := io.CopyN(io.Discard, z.r, n); skipped != n {
+		return unexpected(err)
+	}
+	return nil
+}
+
+func (z *Reader) finishFrame() error {
+	z.inFrame = false
+	if z.contentSize >= 0 && z.produced != z.contentSize {
+		return ErrCorrupt
+	}
+	if z.checksum {
+		var sum [4]byte
+		if _, err := io.ReadFull(z.r, sum[:]); err != nil {
+			return unexpected(err)
+		}
+		if binary.LittleEndian.Uint32(sum[:]) != uint32(z.hash.Sum64()) {
+			return ErrChecksum
+		}
+	}
+	return nil
+}
+
+func (z *Reader) readBlock() error {
+	var header [3]byte
+	if _, err := io.ReadFull(z.r, header[:]); err != nil {
+		return unexpected(err)
+	}
+	h := uint32(header[0]) | uint32(header[1])<<8 | uint32(header[2])<<16
+	z.lastBlock = h&1 != 0
+	size := int(h >> 3)
+	maxSize := min(z.windowSize, maxBlockSize)
+
+	// Drop history the window no longer covers; everything before off has
+	// been read
+	if z.off > 2*z.windowSize && z.off > maxBlockSize {
+		keep := z.hist[z.off-z.windowSize:]
+		z.hist = z.hist[:copy(z.hist, keep)]
+		z.off = len(z.hist)
+	}
+	start := len(z.hist)
+
+	switch (h >> 1) & 3 {
+	case blockRaw:
+		if size > maxSize {
+			return ErrCorrupt
+		}
+		z.hist = grow(z.hist, size)
+		if _, err := io.ReadFull(z.r, z.hist[start:]); err != nil {
+			return unexpected(err)
+		}
+	case blockRLE:
+		if size > maxSize {
+			return ErrCorrupt
+		}
+		b, err := z.r.ReadByte()
+		if err != nil {
+			return unexpected(err)
+		}
+		z.hist = grow(z.hist, size)
+		for i := start; i < len(z.hist); i++ {
+			z.hist[i] = b
+		}
+	case blockCompressed:
+		if size > maxSize {
+			return ErrCorrupt
+		}
+		z.block = grow(z.block[:0], size)
+		if _, err := io.ReadFull(z.r, z.block); err != nil {
+			return unexpected(err)
+		}
+		if err := z.decompressBlock(z.block, maxSize); err != nil {
+			return err
+		}
+	default:
+		return ErrCorrupt
+	}
+
+	out := z.hist[start:]
+	z.produced += int64(len(out))
+	if z.checksum {
+		z.hash.Write(out)
+	}
+	return nil
+}
+
+func (z *Reader) decompressBlock(data []byte, maxSize int) error {
+	n, err := z.readLiterals(data)
+	if err != nil {
+		return err
+	}
+	if len(z.literals) > maxSize {
+		return ErrCorrupt
+	}
+	if err := z.readSequences(data[n:]); err != nil {
+		return err
+	}
+	return z.execute(maxSize)
+}
+
+// readLiterals decodes the literals section into z.literals and returns
+// its size.
+func (z *Reader) readLiterals(data []byte) (int, error) {
+	if len(data) == 0 {
+		return 0, ErrCorrupt
+	}
+	typ := data[0] & 3
+	format := data[0] >> 2 & 3
+	z.literals = z.literals[:0]
+
+	if typ == literalsRaw || typ == literalsRLE {
+		var size, n int
+		switch format {
+		case 0, 2:
+			size, n = int(data[0]>>3), 1
+		case 1:
+			if len(data) < 2 {
+				return 0, ErrCorrupt
+			}
+			size, n = int(data[0]>>4)|int(data[1])<<4, 2
+		case 3:
+			if len(data) < 3 {
+				return 0, ErrCorrupt
+			}
+			size, n = int(data[0]>>4)|int(data[1])<<4|int(data[2])<<12, 3
+		}
+		if typ == literalsRLE {
+			if len(data) < n+1 {
+				return 0, ErrCorrupt
+			}
+			for i := 0; i < size; i++ {
+				z.literals = append(z.literals, data[n])
+			}
+			return n + 1, nil
+		}
+		if len(data) < n+size {
+			return 0, ErrCorrupt
+		}
+		z.literals = append(z.literals, data[n:n+size]...)
+		return n + size, nil
+	}
+
+	n, fieldBits := [4]int{3, 3, 4, 5}[format], [4]uint{10, 10, 14, 18}[format]
+	if len(data) < n {
+		return 0, ErrCorrupt
+	}
+	var h uint64
+	for i := n - 1; i >= 0; i-- {
+		h = h<<8 | uint64(data[i])
+	}
+	mask := uint64(1)<<fieldBits - 1
+	size := int(h >> 4 & mask)
+	compressed := int(h >> (4 + fieldBits) & mask)
+	if len(data) < n+compressed {
+		return 0, ErrCorrupt
+	}
+	streams := data[n : n+compressed]
+	if typ == literalsCompressed {
+		table, used, err := readHuffmanTable(streams)
+		if err != nil {
+			return 0, err
+		}
+		z.huffman = table
+		streams = streams[used:]
+	} else if z.huffman == nil {
+		return 0, ErrCorrupt
+	}
+	literals, err := z.huffman.decodeStreams(z.literals, streams, size, format != 0)
+	if err != nil {
+		return 0, err
+	}
+	z.literals = literals
+	return n + compressed, nil
+}
+
+// readSequences decodes the sequences section into z.sequences.
+func (z *Reader) readSequences(data []byte) error {
+	z.sequences = z.sequences[:0]
+	if len(data) == 0 {
+		return ErrCorrupt
+	}
+	var count int
+	switch b := int(data[0]); {
+	case b == 0:
+		return nil
+	case b < 128:
+		count, data = b, data[1:]
+	case b < 255:
+		if len(data) < 2 {
+			return ErrCorrupt
+		}
+		count, data = (b-128)<<8|int(data[1]), data[2:]
+	default:
+		if len(data) < 3 {
+			return ErrCorrupt
+		}
+		count, data = int(data[1])|int(data[2])<<8+0x7F00, data[3:]
+	}
+	if len(data) == 0 {
+		return ErrCorrupt
+	}
+	modes := data[0]
+	if modes&3 != 0 {
+		return ErrCorrupt
+	}
+	data = data[1:]
+
+	var err error
+	if z.llTable, data, err = selectTable(data, modes>>6, z.llTable, llDefaultTable, llMaxSymbol, llMaxLog); err != nil {
+		return err
+	}
+	if z.ofTable, data, err = selectTable(data, modes>>4&3, z.ofTable, ofDefaultTable, ofMaxSymbol, ofMaxLog); err != nil {
+		return err
+	}
+	if z.mlTable, data, err = selectTable(data, modes>>2&3, z.mlTable, mlDefaultTable, mlMaxSymbol, mlMaxLog); err != nil {
+		return err
+	}
+
+	r, err := newBackwardReader(data)
+	if err != nil {
+		return err
+	}
+	llState := r.read(uint(z.llTable.accuracyLog))
+	ofState := r.read(uint(z.ofTable.accuracyLog))
+	mlState := r.read(uint(z.mlTable.accuracyLog))
+	for i := 0; i < count; i++ {
+		ll := z.llTable.entries[llState]
+		ml := z.mlTable.entries[mlState]
+		of := z.ofTable.entries[ofState]
+		if ll.symbol > llMaxSymbol || ml.symbol > mlMaxSymbol || of.symbol > ofMaxSymbol {
+			return ErrCorrupt
+		}
+
+		offsetValue := 1<<of.symbol + int(r.read(uint(of.symbol)))
+		seq := sequence{
+			matchLen: int(mlBase[ml.symbol]) + int(r.read(uint(mlBits[ml.symbol]))),
+			litLen:   int(llBase[ll.symbol]) + int(r.read(uint(llBits[ll.symbol]))),
+		}
+		if offsetValue > 3 {
+			seq.offset = offsetValue - 3
+			z.repeats = [3]int{seq.offset, z.repeats[0], z.repeats[1]}
+		} else {
+			index := offsetValue - 1
+			if seq.litLen == 0 {
+				index++
+			}
+			switch index {
+			case 0:
+				seq.offset = z.repeats[0]
+			case 3:
+				seq.offset = z.repeats[0] - 1
+			default:
+				seq.offset = z.repeats[index]
+			}
+			if seq.offset <= 0 {
+				return ErrCorrupt
+			}
+			switch index {
+			case 0:
+			case 1:
+				z.repeats = [3]int{seq.offset, z.repeats[0], z.repeats[2]}
+			default:
+				z.repeats = [3]int{seq.offset, z.repeats[0], z.repeats[1]}
+			}
+		}
+		z.sequences = append(z.sequences, seq)
+
+		if i < count-1 {
+			llState = uint64(ll.base) + r.read(uint(ll.nbBits))
+			mlState = uint64(ml.base) + r.read(uint(ml.nbBits))
+			ofState = uint64(of.base) + r.read(uint(of.nbBits))
+		}
+		if r.pos < 0 {
+			return ErrCorrupt
+		}
+	}
+	if r.pos != 0 {
+		return ErrCorrupt
+	}
+	return nil
+}
+
+// selectTable returns the decoding table a sequence mode asks for and
+// the data that follows its description.
+func selectTable(data []byte, mode byte, previous, predefined *fseTable, maxSymbol int, maxLog uint8) (*fseTable, []byte, error) {
+	switch mode {
+	case modePredefined:
+		return predefined, data, nil
+	case modeRLE:
+		if len(data) == 0 || int(data[0]) > maxSymbol {
+			return nil, nil, ErrCorrupt
+		}
+		return rleFSETable(data[0]), data[1:], nil
+	case modeFSE:
+		table, n, err := readFSETable(data, maxSymbol, maxLog)
+		if err != nil {
+			return nil, nil, err
+		}
+		return table, data[n:], nil
+	default:
+		if previous == nil {
+			return nil, nil, ErrCorrupt
+		}
+		return previous, data, nil
+	}
+}
+
+// execute appends the block's output to the history.
+func (z *Reader) execute(maxSize int) error {
+	start := len(z.hist)
+	literals := z.literals
+	for _, seq := range z.sequences {
+		if seq.litLen > len(literals) {
+			return ErrCorrupt
+		}
+		z.hist = append(z.hist, literals[:seq.litLen]...)
+		literals = literals[seq.litLen:]
+		if seq.offset > len(z.hist) || seq.offset > z.windowSize || len(z.hist)-start+seq.matchLen > maxSize {
+			return ErrCorrupt
+		}
+		// Copy byte by byte: the match may overlap the bytes it produces
+		from := len(z.hist) - seq.offset
+		for i := 0; i < seq.matchLen; i++ {
+			z.hist = append(z.hist, z.hist[from+i])
+		}
+	}
+	z.hist = append(z.hist, literals...)
+	if len(z.hist)-start > maxSize {
+		return ErrCorrupt
+	}
+	return nil
+}
+
+// grow extends b by n bytes.
+func grow(b []byte, n int) []byte {
+	size := len(b) + n
+	if size > cap(b) {
+		b = append(b[:cap(b)], make([]byte, size-cap(b))...)
+	}
+	return b[:size]
+}
+
+// unexpected turns an end of input inside a frame into
+// io.ErrUnexpectedEOF.
+func unexpected(err error) error {
+	if err == nil || errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,287 @@
+package zstd
+
+import (
+	"encoding/binary"
+	"io"
+	"math/bits"
+)
+
+const (
+	windowLog  = 20
+	windowSize = 1 << windowLog
+
+	hashLog = 16
+)
+
+// Predefined sequence encoding tables.
+var (
+	llDefaultEncTable = newFSEEncTable(llDefaultNorm, llDefaultLog)
+	mlDefaultEncTable = newFSEEncTable(mlDefaultNorm, mlDefaultLog)
+	ofDefaultEncTable = newFSEEncTable(ofDefaultNorm, ofDefaultLog)
+)
+
+// Codes of the short literal and match lengths; longer ones are computed.
+var llCodes, mlCodes [128]uint8
+
+func init() {
+	for code, base := range llBase {
+		for n := int(base); n < len(llCodes) && n < int(base)+1<<llBits[code]; n++ {
+			llCodes[n] = uint8(code)
+		}
+	}
+	for code, base := range mlBase {
+		for n := int(base) - minMatch; n < len(mlCodes) && n < int(base)-minMatch+1<<mlBits[code]; n++ {
+			mlCodes[n] = uint8(code)
+		}
+	}
+}
+
+func litLenCode(n int) uint8 {
+	if n < len(llCodes) {
+		return llCodes[n]
+	}
+	return uint8(bits.Len(uint(n)) - 1 + 19)
+}
+
+// matchLenCode returns the code of a match length less minMatch.
+func matchLenCode(n int) uint8 {
+	if n < len(mlCodes) {
+		return mlCodes[n]
+	}
+	return uint8(bits.Len(uint(n)) - 1 + 36)
+}
+
+// Writer compresses data into a single Zstandard frame with a content
+// checksum. Data is written out in blocks of 128 KiB, so Close must be
+// called to write the last block and end the frame.
+type Writer struct {
+	w       io.Writer
+	err     error
+	started bool
+	closed  bool
+
+	// hist holds the last window of input followed by the pending block,
+	// which starts at start
+	hist  []byte
+	start int
+	base  int64 // stream position of hist[0]
+	table [1 << hashLog]int64
+	hash  *xxh64
+
+	literals  []byte
+	sequences []sequence
+	out       []byte
+}
+
+// NewWriter returns a Writer that compresses to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w, hash: newXXH64()}
+}
+
+// Write compresses p.
+func (z *Writer) Write(p []byte) (int, error) {
+	if z.closed {
+		return 0, ErrClosed
+	}
+	if z.err != nil {
+		return 0, z.err
+	}
+	written := 0
+	for len(p) > 0 {
+		if len(z.hist)-z.start == maxBlockSize {
+			if z.err = z.flushBlock(false); z.err != nil {
+				return written, z.err
+			}
+		}
+		n := min(len(p), maxBlockSize-(len(z.hist)-z.start))
+		z.hist = append(z.hist, p[:n]...)
+		p = p[n:]
+		written += n
+	}
+	return written, nil
+}
+
+// Close writes the last block and the checksum. It does not close the
+// underlying writer.
+func (z *Writer) Close() error {
+	if z.closed {
+		return z.err
+	}
+	z.closed = true
+	if z.err != nil {
+		return z.err
+	}
+	if z.err = z.flushBlock(true); z.err != nil {
+		return z.err
+	}
+	var sum [4]byte
+	binary.LittleEndian.PutUint32(sum[:], uint32(z.hash.Sum64()))
+	_, z.err = z.w.Write(sum[:])
+	return z.err
+}
+
+// flushBlock writes the pending block.
+func (z *Writer) flushBlock(last bool) error {
+	z.out = z.out[:0]
+	if !z.started {
+		z.started = true
+		// No content size, checksum on, and the window descriptor
+		z.out = binary.LittleEndian.AppendUint32(z.out, frameMagic)
+		z.out = append(z.out, 0x04, (windowLog-10)<<3)
+	}
+
+	src := z.hist[z.start:]
+	z.hash.Write(src)
+	headerAt := len(z.out)
+	z.out = append(z.out, 0, 0, 0)
+	typ, size := blockRaw, len(src)
+	switch {
+	case len(src) > 0 && isRun(src):
+		typ = blockRLE
+		z.out = append(z.out, src[0])
+	default:
+		compressed := z.compressBlock()
+		if n := len(z.out) - headerAt - 3; compressed && n < len(src) {
+			typ, size = blockCompressed, n
+		} else {
+			z.out = append(z.out[:headerAt+3], src...)
+		}
+	}
+	header := uint32(size)<<3 | uint32(typ)<<1
+	if last {
+		header |= 1
+	}
+	z.out[headerAt] = byte(header)
+	z.out[headerAt+1] = byte(header >> 8)
+	z.out[headerAt+2] = byte(header >> 16)
+	if _, err := z.w.Write(z.out); err != nil {
+		return err
+	}
+
+	z.start = len(z.hist)
+	if z.start >= 2*windowSize {
+		// Keep one window of history
+		drop := z.start - windowSize
+		z.hist = z.hist[:copy(z.hist, z.hist[drop:])]
+		z.base += int64(drop)
+		z.start = len(z.hist)
+	}
+	return nil
+}
+
+func isRun(b []byte) bool {
+	for _, c := range b[1:] {
+		if c != b[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// compressBlock appends the compressed form of the pending block to
+// z.out. It reports false if the block has no matches worth encoding.
+func (z *Writer) compressBlock() bool {
+	z.findSequences()
+	if len(z.sequences) == 0 {
+		return false
+	}
+
+	// Literals section, stored raw
+	n := len(z.literals)
+	switch {
+	case n < 32:
+		z.out = append(z.out, byte(n<<3))
+	case n < 4096:
+		z.out = append(z.out, byte(1<<2|n<<4), byte(n>>4))
+	default:
+		z.out = append(z.out, byte(3<<2|n<<4), byte(n>>4), byte(n>>12))
+	}
+	z.out = append(z.out, z.literals...)
+
+	// Sequences section, with the predefined tables
+	count := len(z.sequences)
+	switch {
+	case count < 128:
+		z.out = append(z.out, byte(count))
+	case count < 0x7F00:
+		z.out = append(z.out, byte(count>>8+128), byte(count))
+	default:
+		z.out = append(z.out, 255, byte(count-0x7F00), byte((count-0x7F00)>>8))
+	}
+	z.out = append(z.out, modePredefined<<6|modePredefined<<4|modePredefined<<2)
+	z.out = append(z.out, z.encodeSequences()...)
+	return true
+}
+
+// findSequences splits the pending block into literals and matches,
+// greedily taking the first match a hash lookup finds.
+func (z *Writer) findSequences() {
+	z.literals = z.literals[:0]
+	z.sequences = z.sequences[:0]
+	hist := z.hist
+	end := len(hist)
+	anchor := z.start
+	for i := z.start; i+4 <= end; {
+		cur := binary.LittleEndian.Uint32(hist[i:])
+		h := cur * 2654435761 >> (32 - hashLog)
+		cand := int(z.table[h] - 1 - z.base)
+		z.table[h] = z.base + int64(i) + 1
+		if cand < 0 || i-cand > windowSize || binary.LittleEndian.Uint32(hist[cand:]) != cur {
+			i++
+			continue
+		}
+		length := 4
+		for i+length < end && hist[cand+length] == hist[i+length] {
+			length++
+		}
+		for i > anchor && cand > 0 && hist[i-1] == hist[cand-1] {
+			i--
+			cand--
+			length++
+		}
+		z.literals = append(z.literals, hist[anchor:i]...)
+		z.sequences = append(z.sequences, sequence{litLen: i - anchor, matchLen: length, offset: i - cand})
+		i += length
+		anchor = i
+	}
+	z.literals = append(z.literals, hist[anchor:end]...)
+}
+
+// encodeSequences returns the sequences' bitstream. Sequences are written
+// last first, so that the reader decodes them in order.
+func (z *Writer) encodeSequences() []byte {
+	type codes struct{ ll, ml, of uint8 }
+	seqs := z.sequences
+	last := len(seqs) - 1
+	code := func(s sequence) codes {
+		return codes{
+			ll: litLenCode(s.litLen),
+			ml: matchLenCode(s.matchLen - minMatch),
+			of: uint8(bits.Len(uint(s.offset+3)) - 1),
+		}
+	}
+	extra := func(w *bitWriter, s sequence, c codes) {
+		w.addBits(uint64(s.litLen)-uint64(llBase[c.ll]), uint(llBits[c.ll]))
+		w.addBits(uint64(s.matchLen)-uint64(mlBase[c.ml]), uint(mlBits[c.ml]))
+		w.addBits(uint64(s.offset+3), uint(c.of))
+	}
+
+	w := &bitWriter{out: make([]byte, 0, len(seqs)*4)}
+	var ll, ml, of fseEncoder
+	c := code(seqs[last])
+	ml.init(mlDefaultEncTable, c.ml)
+	of.init(ofDefaultEncTable, c.of)
+	ll.init(llDefaultEncTable, c.ll)
+	extra(w, seqs[last], c)
+	for i := last - 1; i >= 0; i-- {
+		c = code(seqs[i])
+		of.encode(w, c.of)
+		ml.encode(w, c.ml)
+		ll.encode(w, c.ll)
+		extra(w, seqs[i], c)
+	}
+	ml.flush(w)
+	of.flush(w)
+	ll.flush(w)
+	return w.close()
+}
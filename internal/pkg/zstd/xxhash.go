@@ -0,0 +1,108 @@
+package zstd
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+const (
+	prime64x1 uint64 = 11400714785074694791
+	prime64x2 uint64 = 14029467366897019727
+	prime64x3 uint64 = 1609587929392839161
+	prime64x4 uint64 = 9650029242287828579
+	prime64x5 uint64 = 2870177450012600261
+)
+
+// xxh64 computes the XXH64 hash with seed 0, whose low 32 bits are the
+// content checksum of a frame.
+type xxh64 struct {
+	v     [4]uint64
+	buf   [32]byte
+	n     int
+	total uint64
+}
+
+func newXXH64() *xxh64 {
+	h := &xxh64{}
+	h.reset()
+	return h
+}
+
+func (h *xxh64) reset() {
+	p1, p2 := prime64x1, prime64x2
+	h.v = [4]uint64{p1 + p2, p2, 0, -p1}
+	h.n = 0
+	h.total = 0
+}
+
+func xxhRound(acc, lane uint64) uint64 {
+	acc += lane * prime64x2
+	return bits.RotateLeft64(acc, 31) * prime64x1
+}
+
+func xxhMerge(acc, v uint64) uint64 {
+	acc ^= xxhRound(0, v)
+	return acc*prime64x1 + prime64x4
+}
+
+func (h *xxh64) stripe(b []byte) {
+	for i := range h.v {
+		h.v[i] = xxhRound(h.v[i], binary.LittleEndian.Uint64(b[i*8:]))
+	}
+}
+
+func (h *xxh64) Write(p []byte) (int, error) {
+	n := len(p)
+	h.total += uint64(n)
+	if h.n > 0 {
+		c := copy(h.buf[h.n:], p)
+		h.n += c
+		p = p[c:]
+		if h.n < len(h.buf) {
+			return n, nil
+		}
+		h.stripe(h.buf[:])
+		h.n = 0
+	}
+	for ; len(p) >= 32; p = p[32:] {
+		h.stripe(p)
+	}
+	h.n = copy(h.buf[:], p)
+	return n, nil
+}
+
+func (h *xxh64) Sum64() uint64 {
+	var acc uint64
+	if h.total >= 32 {
+		acc = bits.RotateLeft64(h.v[0], 1) + bits.RotateLeft64(h.v[1], 7) +
+			bits.RotateLeft64(h.v[2], 12) + bits.RotateLeft64(h.v[3], 18)
+		for _, v := range h.v {
+			acc = xxhMerge(acc, v)
+		}
+	} else {
+		acc = prime64x5
+	}
+	acc += h.total
+
+	p := h.buf[:h.n]
+	for ; len(p) >= 8; p = p[8:] {
+		acc ^= xxhRound(0, binary.LittleEndian.Uint64(p))
+		acc = bits.RotateLeft64(acc, 27)*prime64x1 + prime64x4
+	}
+	if len(p) >= 4 {
+		acc ^= uint64(binary.LittleEndian.Uint32(p)) * prime64x1
+		acc = bits.RotateLeft64(acc, 23)*prime64x2 + prime64x3
+		p = p[4:]
+	}
+	for _, b := range p {
+		acc ^= uint64(b) * prime64x5
+		acc = bits.RotateLeft64(acc, 11) * prime64x1
+	}
+
+	acc ^= acc >> 33
+	acc *= prime64x2
+	acc ^= acc >> 29
+	acc *= prime64x3
+	acc ^= acc >> 32
+	return acc
+}
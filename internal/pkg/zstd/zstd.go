@@ -0,0 +1,120 @@
+// Package zstd reads and writes Zstandard (RFC 8878) frames. It covers what
+// backup archives need: the writer emits frames with a 1 MiB window, greedy
+// matching, raw literals and the predefined sequence tables, and the reader
+// decodes any frame that does not use a dictionary.
+package zstd
+
+import "errors"
+
+var (
+	// ErrCorrupt is returned when the input is not valid Zstandard data.
+	ErrCorrupt = errors.New("zstd: corrupt input")
+
+	// ErrChecksum is returned when a frame's content checksum does not
+	// match the decoded data.
+	ErrChecksum = errors.New("zstd: checksum mismatch")
+
+	// ErrUnsupported is returned for frames that need a dictionary or a
+	// window larger than the reader allows.
+	ErrUnsupported = errors.New("zstd: unsupported frame")
+
+	// ErrClosed is returned when writing to a closed Writer.
+	ErrClosed = errors.New("zstd: writer is closed")
+)
+
+const (
+	frameMagic         = 0xFD2FB528
+	skippableMagicMask = 0xFFFFFFF0
+	skippableMagic     = 0x184D2A50
+
+	maxBlockSize  = 128 << 10
+	maxWindowSize = 1 << 27
+	minMatch      = 3
+)
+
+// Block types.
+const (
+	blockRaw = iota
+	blockRLE
+	blockCompressed
+	blockReserved
+)
+
+// Literals section types.
+const (
+	literalsRaw = iota
+	literalsRLE
+	literalsCompressed
+	literalsTreeless
+)
+
+// Sequence table modes.
+const (
+	modePredefined = iota
+	modeRLE
+	modeFSE
+	modeRepeat
+)
+
+// Baselines and extra bits of the literal length codes.
+var (
+	llBase = [36]uint32{
+		0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15,
+		16, 18, 20, 22, 24, 28, 32, 40, 48, 64, 128, 256, 512, 1024, 2048, 4096,
+		8192, 16384, 32768, 65536,
+	}
+	llBits = [36]uint8{
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		1, 1, 1, 1, 2, 2, 3, 3, 4, 6, 7, 8, 9, 10, 11, 12,
+		13, 14, 15, 16,
+	}
+)
+
+// Baselines and extra bits of the match length codes.
+var (
+	mlBase = [53]uint32{
+		3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18,
+		19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31, 32, 33, 34,
+		35, 37, 39, 41, 43, 47, 51, 59, 67, 83, 99, 131, 259, 515, 1027, 2051,
+		4099, 8195, 16387, 32771, 65539,
+	}
+	mlBits = [53]uint8{
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		1, 1, 1, 1, 2, 2, 3, 3, 4, 4, 5, 7, 8, 9, 10, 11,
+		12, 13, 14, 15, 16,
+	}
+)
+
+// Normalized distributions of the predefined sequence tables.
+var (
+	llDefaultNorm = []int16{
+		4, 3, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 1, 1, 1,
+		2, 2, 2, 2, 2, 2, 2, 2, 2, 3, 2, 1, 1, 1, 1, 1,
+		-1, -1, -1, -1,
+	}
+	mlDefaultNorm = []int16{
+		1, 4, 3, 2, 2, 2, 2, 2, 2, 1, 1, 1, 1, 1, 1, 1,
+		1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
+		1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, -1, -1,
+		-1, -1, -1, -1, -1,
+	}
+	ofDefaultNorm = []int16{
+		1, 1, 1, 1, 1, 1, 2, 2, 2, 1, 1, 1, 1, 1, 1, 1,
+		1, 1, 1, 1, 1, 1, 1, 1, -1, -1, -1, -1, -1,
+	}
+)
+
+const (
+	llDefaultLog = 6
+	mlDefaultLog = 6
+	ofDefaultLog = 5
+
+	llMaxLog = 9
+	mlMaxLog = 9
+	ofMaxLog = 8
+
+	llMaxSymbol = 35
+	mlMaxSymbol = 52
+	ofMaxSymbol = 31
+)
@@ -0,0 +1,149 @@
+package zstd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func compress(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	_, err := w.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func decompress(data []byte) ([]byte, error) {
+	return io.ReadAll(NewReader(bytes.NewReader(data)))
+}
+
+func TestRoundTrip(t *testing.T) {
+	random := make([]byte, 300<<10)
+	rand.New(rand.NewSource(1)).Read(random)
+	var text strings.Builder
+	for i := 0; text.Len() < 3<<20; i++ {
+		fmt.Fprintf(&text, "object %d: photos/cat-%d.jpg\n", i, i%97)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"one byte", []byte("x")},
+		{"short", []byte("hello, hello, hello")},
+		{"run", bytes.Repeat([]byte{0xAB}, 200<<10)},
+		{"random", random},
+		// Longer than the window, so matches reach back across blocks
+		{"text", []byte(text.String())},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compressed := compress(t, tt.data)
+			got, err := decompress(compressed)
+			require.NoError(t, err)
+			require.Equal(t, len(tt.data), len(got))
+			require.True(t, bytes.Equal(tt.data, got))
+		})
+	}
+}
+
+func TestWriter_Compresses(t *testing.T) {
+	data := bytes.Repeat([]byte("bucket=photos key=cat.jpg size=1024\n"), 10000)
+	compressed := compress(t, data)
+	require.Less(t, len(compressed), len(data)/20)
+
+	// Small writes give the same frame as one large one
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	for chunk := data; len(chunk) > 0; chunk = chunk[min(len(chunk), 1000):] {
+		_, err := w.Write(chunk[:min(len(chunk), 1000)])
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	require.Equal(t, compressed, buf.Bytes())
+
+	_, err := w.Write([]byte("more"))
+	require.ErrorIs(t, err, ErrClosed)
+}
+
+// fixture is the text of fixtureText compressed by the reference zstd
+// tool at level 19: Huffman-coded literals and FSE-coded sequences.
+const fixture = "" +
+	"KLUv/WSoFX0KAPabOheQKZUxoHK6j5DK3V5o74qIiAg27S+VXUAALwAuAO2gWNDj" +
+	"1+yohRoRD37Njlr0zKOCYZfT06sYbUgk/HZLblIKHHDAQAAB4ICAAgESMDgwQOAA" +
+	"wQBJCA4KDDBQgEHUomd4VDDscnp6FSM2JBJ+uyU3KQkfFg38rIaf1ZxjQiG/3ZKb" +
+	"FGdcOPT0KmaXE7dbcpOSflg08LMaflZDjgmF/HZLblKWceHQ06uYXY46KBb0+DU7" +
+	"aok0Ih78ml1jQiG/3ZKblJFx4dDTq5hdjuigWNDj1+yoJWhEPPg1O2rRc48Khl1O" +
+	"T69ivCGR8IGOqBHA1/5v8WtsBhGcECiIvhvxV9Iwq1RheV7BlEPFpNBVqnCet2DK" +
+	"UHFS6JJqOM8rWGWomJR0k4VZoZtU4TxfwZSh0qTQJbVwnldwylAxaeiSKlzPK5gy" +
+	"rJgUbhp/AFCrVWRR9A=="
+
+func fixtureText() []byte {
+	var b strings.Builder
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&b, "object %03d: photos/cat-%d.jpg\n", i, i%7)
+	}
+	return []byte(b.String())
+}
+
+func TestReader_ReferenceFrame(t *testing.T) {
+	data, err := base64.StdEncoding.DecodeString(fixture)
+	require.NoError(t, err)
+
+	got, err := decompress(data)
+	require.NoError(t, err)
+	require.Equal(t, string(fixtureText()), string(got))
+
+	// Concatenated frames and skippable frames decode as one stream
+	skippable := []byte{0x50, 0x2A, 0x4D, 0x18, 3, 0, 0, 0, 'a', 'b', 'c'}
+	stream := append(append(append([]byte{}, data...), skippable...), compress(t, []byte("tail"))...)
+	got, err = decompress(stream)
+	require.NoError(t, err)
+	require.Equal(t, string(fixtureText())+"tail", string(got))
+}
+
+func TestReader_Errors(t *testing.T) {
+	valid := compress(t, fixtureText())
+
+	_, err := decompress(nil)
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	_, err = decompress([]byte("not zstd data"))
+	require.ErrorIs(t, err, ErrCorrupt)
+
+	_, err = decompress(valid[:len(valid)/2])
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	corrupt := append([]byte{}, valid...)
+	corrupt[len(corrupt)-1] ^= 1
+	_, err = decompress(corrupt)
+	require.ErrorIs(t, err, ErrChecksum)
+
+	// A frame that needs a dictionary
+	_, err = decompress([]byte{0x28, 0xB5, 0x2F, 0xFD, 0x01, 0x50, 0x07})
+	require.ErrorIs(t, err, ErrUnsupported)
+}
+
+func TestXXH64(t *testing.T) {
+	// Reference values from the xxHash test suite
+	for input, want := range map[string]uint64{
+		"":    0xEF46DB3751D8E999,
+		"a":   0xD24EC4F1A98C6E5B,
+		"abc": 0x44BC2CF5AD770999,
+		"Nobody inspects the spammish repetition": 0xFBCEA83C8A378BF1,
+	} {
+		h := newXXH64()
+		h.Write([]byte(input))
+		require.Equal(t, want, h.Sum64(), input)
+	}
+}
@@ -3,6 +3,7 @@ package repository
 
 import (
 	"context"
+	"strconv"
 	"time"
 )
 
@@ -80,6 +81,16 @@ type DistributedLock interface {
 
 	// IsHeld checks if the lock is currently held.
 	IsHeld(ctx context.Context, key string) (bool, error)
+
+	// AcquireToken attempts to acquire a lock owned by the returned token.
+	// Returns an empty token if the lock is held by another process.
+	AcquireToken(ctx context.Context, key string, ttl time.Duration) (string, error)
+
+	// ReleaseToken releases a lock if it is still owned by token.
+	ReleaseToken(ctx context.Context, key, token string) (bool, error)
+
+	// ExtendToken extends the TTL of a lock if it is still owned by token.
+	ExtendToken(ctx context.Context, key, token string, ttl time.Duration) (bool, error)
 }
 
 // Lock is a convenience wrapper for a specific lock instance.
@@ -168,7 +179,7 @@ func (LockKey) MultipartGC() string {
 
 // formatBucketKey formats a bucket ID and key into a string.
 func formatBucketKey(bucketID int64, key string) string {
-	return string(rune(bucketID)) + ":" + key
+	return strconv.FormatInt(bucketID, 10) + ":" + key
 }
 
 // =============================================================================
@@ -198,6 +209,12 @@ func (CacheKey) ObjectMeta(bucketID int64, key string) string {
 	return "cache:object:" + formatBucketKey(bucketID, key)
 }
 
+// ObjectGeneration returns a cache key for the token that changes with every
+// write to an object key, invalidating its cached metadata.
+func (CacheKey) ObjectGeneration(bucketID int64, key string) string {
+	return "cache:object:gen:" + formatBucketKey(bucketID, key)
+}
+
 // UserByID returns a cache key for user metadata.
 func (CacheKey) UserByID(id int64) string {
 	return "cache:user:id:" + string(rune(id))
@@ -13,12 +13,28 @@ import (
 
 // Repositories holds all repository instances.
 type Repositories struct {
-	User      UserRepository
-	AccessKey AccessKeyRepository
-	Bucket    BucketRepository
-	Object    ObjectRepository
-	Blob      BlobRepository
-	Multipart MultipartUploadRepository
+	User        UserRepository
+	AccessKey   AccessKeyRepository
+	Bucket      BucketRepository
+	Object      ObjectRepository
+	Blob        BlobRepository
+	Multipart   MultipartUploadRepository
+	Usage       UsageRepository
+	Event       EventRepository
+	Audit       AuditRepository
+	Backfill    BackfillRepository
+	Replication ReplicationRepository
+	Lifecycle   LifecycleRepository
+	Staged      StagedObjectRepository
+	Batch       BatchRepository
+	Snapshot    BucketSnapshotRepository
+	Inventory   InventoryRepository
+	Website     WebsiteRepository
+	MFADevice   MFADeviceRepository
+	ReadOnly    ReadOnlyRepository
+	Tenant      TenantRepository
+	ClusterNode ClusterNodeRepository // PostgreSQL only; nil with SQLite
+	TxManager   TxManager
 }
 
 // DatabaseHealth is an interface for database health checks.
@@ -38,6 +38,9 @@ type UserRepository interface {
 	// List returns all users with pagination.
 	List(ctx context.Context, opts ListOptions) (*ListResult[domain.User], error)
 
+	// ListByTenant returns the users of a tenant with pagination.
+	ListByTenant(ctx context.Context, tenantID int64, opts ListOptions) (*ListResult[domain.User], error)
+
 	// ExistsByUsername checks if a user with the given username exists.
 	ExistsByUsername(ctx context.Context, username string) (bool, error)
 
@@ -70,6 +73,10 @@ type AccessKeyRepository interface {
 	// Update updates an existing access key.
 	Update(ctx context.Context, key *domain.AccessKey) error
 
+	// UpdateSecret replaces the encrypted secret of an access key, after
+	// re-encrypting it with a new version of the encryption key.
+	UpdateSecret(ctx context.Context, id int64, encryptedSecret string) error
+
 	// UpdateLastUsed updates the last_used_at timestamp.
 	UpdateLastUsed(ctx context.Context, id int64) error
 
@@ -101,6 +108,10 @@ type BucketRepository interface {
 	// List returns all buckets for a user (or all if userID is 0).
 	List(ctx context.Context, userID int64) ([]*domain.Bucket, error)
 
+	// ListPage returns a page of the buckets of a user (or of all users if
+	// userID is 0) in name order.
+	ListPage(ctx context.Context, userID int64, opts BucketListOptions) (*BucketListResult, error)
+
 	// Update updates an existing bucket.
 	Update(ctx context.Context, bucket *domain.Bucket) error
 
@@ -110,6 +121,16 @@ type BucketRepository interface {
 	// UpdateACL updates the ACL of a bucket.
 	UpdateACL(ctx context.Context, id int64, acl domain.BucketACL) error
 
+	// GetTags returns the tag set of a bucket, or domain.ErrNoSuchTagSet if
+	// it has none.
+	GetTags(ctx context.Context, id int64) ([]domain.Tag, error)
+
+	// PutTags replaces the tag set of a bucket.
+	PutTags(ctx context.Context, id int64, tags []domain.Tag) error
+
+	// DeleteTags removes the tag set of a bucket.
+	DeleteTags(ctx context.Context, id int64) error
+
 	// Delete deletes a bucket by ID.
 	Delete(ctx context.Context, id int64) error
 
@@ -119,14 +140,39 @@ type BucketRepository interface {
 	// ExistsByName checks if a bucket with the given name exists.
 	ExistsByName(ctx context.Context, name string) (bool, error)
 
-	// IsEmpty checks if a bucket contains any objects.
+	// IsEmpty checks if a bucket contains any objects, staged uploads or snapshots.
 	IsEmpty(ctx context.Context, id int64) (bool, error)
 
+	// StorageBytes returns the total size of the object versions in a
+	// bucket, which is what counts against its quota.
+	StorageBytes(ctx context.Context, id int64) (int64, error)
+
 	// GetACLByName retrieves only the ACL for a bucket by name.
 	// This is optimized for anonymous access checks.
 	GetACLByName(ctx context.Context, name string) (domain.BucketACL, error)
 }
 
+// BucketListOptions contains options for listing buckets.
+type BucketListOptions struct {
+	// Prefix filters buckets by name prefix.
+	Prefix string
+
+	// StartAfter lists buckets after this name (for pagination).
+	StartAfter string
+
+	// MaxBuckets is the maximum number of buckets to return. Zero is unlimited.
+	MaxBuckets int
+}
+
+// BucketListResult contains the result of a list buckets operation.
+type BucketListResult struct {
+	// Buckets is the list of buckets.
+	Buckets []*domain.Bucket
+
+	// IsTruncated indicates if there are more results.
+	IsTruncated bool
+}
+
 // =============================================================================
 // Blob Repository (Content-Addressable Storage Metadata)
 // =============================================================================
@@ -155,14 +201,42 @@ type BlobRepository interface {
 	// Exists checks if a blob with the given hash exists.
 	Exists(ctx context.Context, contentHash string) (bool, error)
 
-	// Delete deletes a blob by its content hash.
-	// Should only be called when ref_count is 0.
+	// Delete deletes the metadata of an unreferenced blob whose content GC has
+	// already deleted (gc_state storage_deleted). Returns ErrBlobNotFound otherwise.
 	Delete(ctx context.Context, contentHash string) error
 
-	// ListOrphans returns blobs with ref_count = 0 that are older than the grace period.
-	// Used by garbage collection.
+	// ListOrphans returns blobs with ref_count = 0 that are older than the grace period,
+	// least-attempted first. Used by garbage collection.
 	ListOrphans(ctx context.Context, gracePeriod time.Duration, limit int) ([]*domain.Blob, error)
 
+	// ListOrphansAfter returns orphan blobs older than the grace period whose
+	// content hash sorts after the given one, in content hash order. GC walks
+	// all orphans with it, resuming from its saved cursor.
+	ListOrphansAfter(ctx context.Context, gracePeriod time.Duration, after string, limit int) ([]*domain.Blob, error)
+
+	// ListAfter returns blobs, including their GC state, whose content hash
+	// sorts after the given one, in content hash order. Used to cross-check
+	// the blob table against storage.
+	ListAfter(ctx context.Context, after string, limit int) ([]*domain.Blob, error)
+
+	// GetGCCursor returns the content hash GC last finished at, or "" if it
+	// starts from the beginning.
+	GetGCCursor(ctx context.Context) (string, error)
+
+	// SaveGCCursor saves the content hash GC resumes after.
+	SaveGCCursor(ctx context.Context, after string) error
+
+	// MarkGCPending claims an orphan blob for deletion and counts the attempt.
+	// Returns false if the blob is referenced again or already past this step.
+	MarkGCPending(ctx context.Context, contentHash string) (bool, error)
+
+	// MarkGCStorageDeleted records that a pending blob's content was deleted.
+	// Returns false if the blob is no longer pending (e.g. it was re-referenced).
+	MarkGCStorageDeleted(ctx context.Context, contentHash string) (bool, error)
+
+	// RecordGCError stores the last GC error for a blob.
+	RecordGCError(ctx context.Context, contentHash string, message string) error
+
 	// DeleteOrphans deletes orphan blobs older than the grace period.
 	// Returns the list of deleted blobs (for physical file cleanup).
 	DeleteOrphans(ctx context.Context, gracePeriod time.Duration, limit int) ([]*domain.Blob, error)
@@ -177,8 +251,9 @@ type BlobRepository interface {
 	// GetEncryptionStatus returns the encryption status and IV for a blob.
 	GetEncryptionStatus(ctx context.Context, contentHash string) (isEncrypted bool, encryptionIV string, err error)
 
-	// UpdateEncrypted marks a blob as encrypted with the given IV (SSE-S3 migration).
-	UpdateEncrypted(ctx context.Context, contentHash string, encryptionIV string) error
+	// UpdateEncrypted marks a blob as encrypted with the given IV and
+	// version of the SSE master key (SSE-S3 migration and key rotation).
+	UpdateEncrypted(ctx context.Context, contentHash string, encryptionIV string, keyVersion int) error
 
 	// ListUnencrypted returns unencrypted blobs for migration.
 	// Used by the encrypt-blobs CLI command.
@@ -188,9 +263,48 @@ type BlobRepository interface {
 	// Used by the encrypt rotate CLI command.
 	ListEncrypted(ctx context.Context, limit int, offset int) ([]*domain.Blob, error)
 
+	// ListEncryptedBelowKeyVersion returns encrypted blobs whose SSE master
+	// key version is below keyVersion and whose content hash sorts after
+	// after, in content hash order. Used by the keys reencrypt CLI command.
+	ListEncryptedBelowKeyVersion(ctx context.Context, keyVersion int, after string, limit int) ([]*domain.Blob, error)
+
 	// ListAll returns all blobs up to the limit.
 	// Used for encryption status reporting.
 	ListAll(ctx context.Context, limit int) ([]*domain.Blob, error)
+
+	// ListCreatedBefore returns blobs created before the given time, ordered by
+	// content hash and starting after afterHash (empty for the first page).
+	// Used for snapshot-consistent paging of the blob manifest.
+	ListCreatedBefore(ctx context.Context, before time.Time, afterHash string, limit int) ([]*domain.Blob, error)
+
+	// ListUnverified returns referenced, unencrypted blobs never verified or
+	// last verified before the given time, least recently verified first.
+	// Used by the integrity scrubber.
+	ListUnverified(ctx context.Context, verifiedBefore time.Time, limit int) ([]*domain.Blob, error)
+
+	// RecordVerification stores the outcome of re-hashing a blob. A corrupt
+	// blob keeps the time it was first found corrupt; an intact one is
+	// cleared.
+	RecordVerification(ctx context.Context, contentHash string, verifiedAt time.Time, corrupt bool) error
+
+	// ListCorrupt returns blobs whose content was found corrupt, oldest
+	// finding first.
+	ListCorrupt(ctx context.Context, limit int) ([]*domain.Blob, error)
+
+	// CountCorrupt returns the number of blobs whose content was found corrupt.
+	CountCorrupt(ctx context.Context) (int64, error)
+
+	// GetStats returns the number and total size of all blobs.
+	GetStats(ctx context.Context) (*domain.BlobStats, error)
+
+	// CountRefsAfter returns the stored and counted references of blobs
+	// whose content hash sorts after the given one, in content hash order.
+	// Used by reference count reconciliation.
+	CountRefsAfter(ctx context.Context, after string, limit int) ([]*domain.BlobRefCount, error)
+
+	// SetRefCount replaces the reference count of a blob GC is not
+	// collecting, if it still equals expected. Returns false otherwise.
+	SetRefCount(ctx context.Context, contentHash string, expected, refCount int32) (bool, error)
 }
 
 // =============================================================================
@@ -217,9 +331,19 @@ type ObjectRepository interface {
 	// ListVersions returns all versions of objects in a bucket.
 	ListVersions(ctx context.Context, bucketID int64, opts ObjectListOptions) (*ObjectVersionListResult, error)
 
-	// ListExpiredObjects returns latest objects older than cutoff, with optional prefix.
-	// Used by lifecycle service for expiration processing.
-	ListExpiredObjects(ctx context.Context, bucketID int64, prefix string, olderThan time.Time, limit int) ([]*domain.Object, error)
+	// ListExpiredObjects returns latest objects created before cutoff that match
+	// the filter's prefix and size bounds. Used by lifecycle service for
+	// expiration processing.
+	ListExpiredObjects(ctx context.Context, bucketID int64, filter domain.LifecycleFilter, olderThan time.Time, limit int) ([]*domain.Object, error)
+
+	// ListNoncurrentVersions returns versions, delete markers included, that
+	// match the filter's prefix and size bounds and were superseded by a newer
+	// version of their key before the cutoff.
+	ListNoncurrentVersions(ctx context.Context, bucketID int64, filter domain.LifecycleFilter, noncurrentBefore time.Time, limit int) ([]*domain.Object, error)
+
+	// ListExpiredDeleteMarkers returns latest delete markers under prefix that
+	// are the only remaining version of their key.
+	ListExpiredDeleteMarkers(ctx context.Context, bucketID int64, prefix string, limit int) ([]*domain.Object, error)
 
 	// Update updates an existing object.
 	Update(ctx context.Context, obj *domain.Object) error
@@ -228,15 +352,40 @@ type ObjectRepository interface {
 	// Used when creating a new version.
 	MarkNotLatest(ctx context.Context, bucketID int64, key string) error
 
-	// Delete hard-deletes an object by ID.
+	// MarkLatest marks an object as the latest version of its key.
+	// Used when the version above it is removed.
+	MarkLatest(ctx context.Context, id int64) error
+
+	// NextSequence increments and returns the write sequence for a key.
+	// The first write to a key gets sequence 1. Within a transaction the
+	// counter row stays locked until commit, which orders concurrent writers.
+	NextSequence(ctx context.Context, bucketID int64, key string) (int64, error)
+
+	// Delete soft-deletes an object by ID. A soft-deleted version holds no
+	// blob reference, so the caller releases its reference in the same
+	// transaction; PurgeDeleted removes the row later.
 	Delete(ctx context.Context, id int64) error
 
-	// DeleteAllVersions deletes all versions of an object.
-	DeleteAllVersions(ctx context.Context, bucketID int64, key string) error
+	// DeleteAllVersions soft-deletes all versions of an object and returns
+	// the content hashes of those that had content, one per version, for
+	// the caller to release in the same transaction.
+	DeleteAllVersions(ctx context.Context, bucketID int64, key string) ([]string, error)
+
+	// PurgeDeleted removes up to limit versions soft-deleted before the
+	// cutoff and returns how many were removed.
+	PurgeDeleted(ctx context.Context, before time.Time, limit int) (int64, error)
 
 	// CountByBucket returns the number of objects in a bucket.
 	CountByBucket(ctx context.Context, bucketID int64) (int64, error)
 
+	// GetBucketStats returns the object, version and size totals of a bucket
+	// and its largest current objects, at most largest of them.
+	// MultipartUploads is left to MultipartUploadRepository.CountInProgress.
+	GetBucketStats(ctx context.Context, bucketID int64, largest int) (*domain.BucketStats, error)
+
+	// GetStats returns the object, version and size totals of all buckets.
+	GetStats(ctx context.Context) (*domain.ObjectStats, error)
+
 	// GetContentHashForVersion retrieves the content hash for a specific version.
 	// Used for ref_count management.
 	GetContentHashForVersion(ctx context.Context, bucketID int64, key string, versionID uuid.UUID) (*string, error)
@@ -253,6 +402,10 @@ type ObjectListOptions struct {
 	// StartAfter lists objects after this key (for pagination).
 	StartAfter string
 
+	// VersionIDMarker continues ListVersions within the StartAfter key,
+	// after this version. Empty skips every version of that key.
+	VersionIDMarker string
+
 	// ContinuationToken for pagination (opaque token from previous response).
 	ContinuationToken string
 
@@ -299,6 +452,28 @@ type ObjectVersionListResult struct {
 	NextVersionIDMarker string
 }
 
+// NewObjectVersionListResult builds a page from versions listed in order,
+// fetching one more than maxKeys to detect truncation. The markers of a
+// truncated page point at its last version.
+func NewObjectVersionListResult(listed []*domain.ObjectVersion, maxKeys int) *ObjectVersionListResult {
+	result := &ObjectVersionListResult{}
+	if len(listed) > maxKeys {
+		listed = listed[:maxKeys]
+		last := listed[len(listed)-1]
+		result.IsTruncated = true
+		result.NextKeyMarker = last.Key
+		result.NextVersionIDMarker = last.VersionID
+	}
+	for _, ver := range listed {
+		if ver.IsDeleteMarker {
+			result.DeleteMarkers = append(result.DeleteMarkers, ver)
+		} else {
+			result.Versions = append(result.Versions, ver)
+		}
+	}
+	return result
+}
+
 // =============================================================================
 // Multipart Upload Repository
 // =============================================================================
@@ -320,8 +495,16 @@ type MultipartUploadRepository interface {
 	// Delete deletes a multipart upload.
 	Delete(ctx context.Context, uploadID uuid.UUID) error
 
-	// DeleteExpired deletes expired multipart uploads.
-	DeleteExpired(ctx context.Context) (int64, error)
+	// ListExpired returns in-progress uploads that expired before the given
+	// time, earliest expiry first. Used by the multipart reaper.
+	ListExpired(ctx context.Context, before time.Time, limit int) ([]*domain.MultipartUpload, error)
+
+	// CountInProgress returns the number of multipart uploads in progress in a bucket.
+	CountInProgress(ctx context.Context, bucketID int64) (int64, error)
+
+	// GetStats returns the number and size of the multipart uploads in
+	// progress in all buckets.
+	GetStats(ctx context.Context) (*domain.MultipartStats, error)
 
 	// --- Part operations ---
 
@@ -339,6 +522,14 @@ type MultipartUploadRepository interface {
 
 	// GetPartsForCompletion returns parts in order for completing the upload.
 	GetPartsForCompletion(ctx context.Context, uploadID uuid.UUID, partNumbers []int) ([]*domain.UploadPart, error)
+
+	// GetPartsByContentHash returns the parts of an upload with any of the
+	// given content hashes, in part number order.
+	GetPartsByContentHash(ctx context.Context, uploadID uuid.UUID, contentHashes []string) ([]*domain.UploadPart, error)
+
+	// LastPartNumber returns the highest part number of an upload, or 0 if
+	// it has no parts.
+	LastPartNumber(ctx context.Context, uploadID uuid.UUID) (int, error)
 }
 
 // MultipartListOptions contains options for listing multipart uploads.
@@ -456,6 +647,52 @@ type TxOptions struct {
 	ReadOnly bool
 }
 
+// =============================================================================
+// Read Routing
+// =============================================================================
+
+// ReadPreference selects the database that serves listing reads when a read
+// replica is configured.
+type ReadPreference int
+
+const (
+	// ReadPrimary reads from the primary. It is the default, so background
+	// jobs and read-modify-write paths never see replication lag.
+	ReadPrimary ReadPreference = iota
+
+	// ReadReplica allows reading from a replica whose lag is within limits.
+	// Listings may then miss writes from the last moments.
+	ReadReplica
+)
+
+type readPreferenceKey struct{}
+
+// WithReadPreference returns a context carrying the read preference.
+func WithReadPreference(ctx context.Context, pref ReadPreference) context.Context {
+	return context.WithValue(ctx, readPreferenceKey{}, pref)
+}
+
+// ReadPreferenceFromContext returns the read preference of ctx, ReadPrimary if unset.
+func ReadPreferenceFromContext(ctx context.Context) ReadPreference {
+	pref, _ := ctx.Value(readPreferenceKey{}).(ReadPreference)
+	return pref
+}
+
+type objectCacheKey struct{}
+
+// WithObjectCache returns a context whose GetByKey lookups may be served
+// from the object metadata cache, if one is configured. Only read paths set
+// it, so read-modify-write paths always see the database.
+func WithObjectCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, objectCacheKey{}, true)
+}
+
+// ObjectCacheFromContext reports whether ctx allows cached object lookups.
+func ObjectCacheFromContext(ctx context.Context) bool {
+	allowed, _ := ctx.Value(objectCacheKey{}).(bool)
+	return allowed
+}
+
 // =============================================================================
 // Session Repository (Dashboard Authentication)
 // =============================================================================
@@ -468,17 +705,26 @@ type SessionRepository interface {
 	// GetByToken retrieves a session by its token.
 	GetByToken(ctx context.Context, token string) (*domain.Session, error)
 
+	// GetByRememberToken retrieves a session by its remember-me token.
+	GetByRememberToken(ctx context.Context, token string) (*domain.Session, error)
+
 	// GetByUserID returns all sessions for a user.
 	GetByUserID(ctx context.Context, userID int64) ([]*domain.Session, error)
 
+	// Update saves the tokens, expiry and last activity of a session.
+	Update(ctx context.Context, session *domain.Session) error
+
 	// Delete deletes a session by token.
 	Delete(ctx context.Context, token string) error
 
+	// DeleteByID deletes a session by ID.
+	DeleteByID(ctx context.Context, id uuid.UUID) error
+
 	// DeleteByUserID deletes all sessions for a user.
 	DeleteByUserID(ctx context.Context, userID int64) error
 
-	// DeleteExpired deletes all expired sessions.
-	// Returns the number of deleted sessions.
+	// DeleteExpired deletes all expired sessions that cannot be resumed
+	// with a remember-me token. Returns the number of deleted sessions.
 	DeleteExpired(ctx context.Context) (int64, error)
 
 	// Refresh extends a session's expiration time.
@@ -525,3 +771,438 @@ type LifecycleRepository interface {
 	// Used by the lifecycle service scheduler.
 	ListAllEnabled(ctx context.Context) ([]*domain.LifecycleRule, error)
 }
+
+// =============================================================================
+// Usage Repository (Metering)
+// =============================================================================
+
+// UsageRepository defines the interface for hourly usage data access.
+type UsageRepository interface {
+	// AddTraffic adds the traffic counters (bytes in/out, requests) of each record
+	// to its hourly row, creating the row if needed.
+	AddTraffic(ctx context.Context, records []*domain.UsageRecord) error
+
+	// RecordStorage snapshots the logical bytes stored in every bucket for the given hour.
+	RecordStorage(ctx context.Context, hour time.Time) error
+
+	// List returns hourly usage rows with from <= hour < to, ordered by hour.
+	List(ctx context.Context, from, to time.Time, filter UsageFilter) ([]*domain.UsageRecord, error)
+
+	// ListStorage returns the total bytes stored in all buckets at each
+	// storage snapshot with from <= hour < to, ordered by hour.
+	ListStorage(ctx context.Context, from, to time.Time) ([]*domain.StorageSample, error)
+}
+
+// UsageFilter narrows a usage listing. Empty fields match everything.
+type UsageFilter struct {
+	// BucketName restricts rows to a single bucket.
+	BucketName string
+
+	// AccessKeyID restricts rows to a single access key.
+	AccessKeyID string
+}
+
+// =============================================================================
+// Event Repository (Object Change Outbox)
+// =============================================================================
+
+// EventRepository defines the interface for the object change event outbox.
+// Append joins the transaction bound to ctx, so events commit or roll back
+// together with the change they describe.
+type EventRepository interface {
+	// Append records an event and sets its ID.
+	Append(ctx context.Context, event *domain.ObjectEvent) error
+
+	// List returns events matching the filter, ordered by ID.
+	List(ctx context.Context, filter EventFilter) ([]*domain.ObjectEvent, error)
+
+	// LatestID returns the ID of the newest event, or 0 if there are none.
+	LatestID(ctx context.Context) (int64, error)
+
+	// DeleteBefore removes events created before the given time.
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// EventFilter selects events from the outbox.
+type EventFilter struct {
+	// BucketName restricts events to a single bucket.
+	BucketName string
+
+	// Prefix restricts events to keys starting with it.
+	Prefix string
+
+	// AfterID returns only events with a greater ID.
+	AfterID int64
+
+	// CreatedBefore, if set, returns only events created before it.
+	CreatedBefore time.Time
+
+	// Limit caps the number of events returned.
+	Limit int
+}
+
+// =============================================================================
+// Audit Repository
+// =============================================================================
+
+// AuditRepository defines the interface for the append-only audit log.
+// There are deliberately no methods to change events; they are only removed
+// by retention pruning.
+type AuditRepository interface {
+	// Append records events and sets their IDs.
+	Append(ctx context.Context, events []*domain.AuditEvent) error
+
+	// List returns events matching the filter, ordered by ID.
+	List(ctx context.Context, filter AuditFilter) ([]*domain.AuditEvent, error)
+
+	// Prune deletes up to limit of the oldest events of a category recorded
+	// before the given time, and returns the number deleted.
+	Prune(ctx context.Context, category domain.AuditCategory, before time.Time, limit int) (int64, error)
+}
+
+// AuditFilter selects events from the audit log. Empty fields match everything.
+type AuditFilter struct {
+	// Since and Until restrict events to Since <= time < Until.
+	Since time.Time
+	Until time.Time
+
+	// UserID restricts events to a single user.
+	UserID int64
+
+	// AccessKeyID restricts events to a single access key.
+	AccessKeyID string
+
+	// BucketName restricts events to a single bucket.
+	BucketName string
+
+	// Operation restricts events to a single operation.
+	Operation string
+
+	// Result restricts events to a single outcome.
+	Result domain.AuditResult
+
+	// Category restricts events to a single category.
+	Category domain.AuditCategory
+
+	// Severity restricts events to a single severity.
+	Severity domain.AuditSeverity
+
+	// AfterID returns only events with a greater ID, for paging.
+	AfterID int64
+
+	// Limit caps the number of events returned.
+	Limit int
+}
+
+// =============================================================================
+// Backfill Repository
+// =============================================================================
+
+// BackfillRepository persists the progress of batched data migrations.
+type BackfillRepository interface {
+	// Acquire returns the progress of the named backfill, creating it if it
+	// does not exist. Within a transaction the row stays locked until the
+	// transaction ends, so concurrent runners process batches in turn.
+	Acquire(ctx context.Context, name string) (*domain.BackfillProgress, error)
+
+	// Update saves the progress.
+	Update(ctx context.Context, progress *domain.BackfillProgress) error
+
+	// List returns the progress of every backfill that has started, by name.
+	List(ctx context.Context) ([]*domain.BackfillProgress, error)
+}
+
+// =============================================================================
+// Cluster Node Repository
+// =============================================================================
+
+// ClusterNodeRepository stores cluster membership.
+type ClusterNodeRepository interface {
+	// Heartbeat creates or updates a node's row and sets LastHeartbeat to
+	// the current time of the database, which is the clock all nodes share.
+	Heartbeat(ctx context.Context, node *domain.ClusterNode) error
+
+	// ListLive returns the nodes that sent a heartbeat within timeout, by ID.
+	ListLive(ctx context.Context, timeout time.Duration) ([]*domain.ClusterNode, error)
+
+	// Delete removes a node, e.g. when it leaves the cluster on shutdown.
+	Delete(ctx context.Context, id string) error
+}
+
+// =============================================================================
+// Replication Repository
+// =============================================================================
+
+// ReplicationRepository stores bucket replication configurations and the
+// queue of changes to replicate. Enqueue joins the transaction bound to ctx,
+// so a task commits or rolls back together with the change it describes.
+type ReplicationRepository interface {
+	// PutConfig creates or replaces the configuration of a bucket.
+	PutConfig(ctx context.Context, config *domain.ReplicationConfig) error
+
+	// GetConfig returns the configuration of a bucket, or
+	// domain.ErrReplicationConfigNotFound.
+	GetConfig(ctx context.Context, bucketID int64) (*domain.ReplicationConfig, error)
+
+	// DeleteConfig removes the configuration of a bucket and its queued tasks.
+	DeleteConfig(ctx context.Context, bucketID int64) error
+
+	// Enqueue queues a task and sets its ID.
+	Enqueue(ctx context.Context, task *domain.ReplicationTask) error
+
+	// ClaimDue returns up to limit tasks due at now, in ID order, and
+	// postpones them to leaseUntil so no other worker picks them up
+	// meanwhile. A task is only returned if no earlier task exists for its
+	// key, so changes to a key are replicated in order.
+	ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*domain.ReplicationTask, error)
+
+	// Complete removes a finished task and sets the replication status of
+	// its object version, if it has one.
+	Complete(ctx context.Context, task *domain.ReplicationTask, status domain.ReplicationStatus) error
+
+	// Retry saves the attempt count, next attempt time and error of a failed task.
+	Retry(ctx context.Context, task *domain.ReplicationTask) error
+
+	// Backlog returns the number of queued tasks and the creation time of
+	// the oldest, or nil if the queue is empty.
+	Backlog(ctx context.Context) (int64, *time.Time, error)
+}
+
+// =============================================================================
+// MFA Device Repository
+// =============================================================================
+
+// MFADeviceRepository stores the MFA devices of users.
+type MFADeviceRepository interface {
+	// Create enrolls a device, or returns domain.ErrMFADeviceExists if the
+	// user already has one.
+	Create(ctx context.Context, device *domain.MFADevice) error
+
+	// GetByUserID returns the device of a user, or domain.ErrMFADeviceNotFound.
+	GetByUserID(ctx context.Context, userID int64) (*domain.MFADevice, error)
+
+	// Delete removes the device of a user, or returns domain.ErrMFADeviceNotFound.
+	Delete(ctx context.Context, userID int64) error
+}
+
+// =============================================================================
+// Read-Only Repository
+// =============================================================================
+
+// ReadOnlyRepository stores whether the whole service is in read-only mode.
+// Buckets store their own read-only flag.
+type ReadOnlyRepository interface {
+	// Get reports whether read-only mode is on. It is off until first set.
+	Get(ctx context.Context) (bool, error)
+
+	// Set turns read-only mode on or off.
+	Set(ctx context.Context, enabled bool) error
+}
+
+// =============================================================================
+// Tenant Repository
+// =============================================================================
+
+// TenantRepository stores tenants. Users and buckets store the tenant they
+// belong to.
+type TenantRepository interface {
+	// Create creates a new tenant, or returns domain.ErrTenantAlreadyExists.
+	Create(ctx context.Context, tenant *domain.Tenant) error
+
+	// GetByID returns a tenant, or domain.ErrTenantNotFound.
+	GetByID(ctx context.Context, id int64) (*domain.Tenant, error)
+
+	// GetByName returns a tenant, or domain.ErrTenantNotFound.
+	GetByName(ctx context.Context, name string) (*domain.Tenant, error)
+
+	// List returns all tenants in name order.
+	List(ctx context.Context) ([]*domain.Tenant, error)
+
+	// UpdateLimits replaces the limits of a tenant.
+	UpdateLimits(ctx context.Context, id int64, limits domain.TenantLimits) error
+
+	// Delete deletes a tenant, or returns domain.ErrTenantNotFound.
+	Delete(ctx context.Context, id int64) error
+
+	// Usage returns the number of users and buckets of a tenant and the
+	// size of the object versions in its buckets.
+	Usage(ctx context.Context, id int64) (*domain.TenantUsage, error)
+
+	// MoveUser moves a user and the buckets they own to another tenant.
+	MoveUser(ctx context.Context, userID, tenantID int64) error
+}
+
+// =============================================================================
+// Website Repository
+// =============================================================================
+
+// WebsiteRepository stores bucket static website configurations.
+type WebsiteRepository interface {
+	// PutConfig creates or replaces the configuration of a bucket.
+	PutConfig(ctx context.Context, config *domain.WebsiteConfig) error
+
+	// GetConfig returns the configuration of a bucket, or
+	// domain.ErrWebsiteConfigNotFound.
+	GetConfig(ctx context.Context, bucketID int64) (*domain.WebsiteConfig, error)
+
+	// DeleteConfig removes the configuration of a bucket, or returns
+	// domain.ErrWebsiteConfigNotFound.
+	DeleteConfig(ctx context.Context, bucketID int64) error
+}
+
+// =============================================================================
+// Inventory Repository
+// =============================================================================
+
+// InventoryRepository stores bucket inventory configurations and lists the
+// objects a report includes.
+type InventoryRepository interface {
+	// Put creates or replaces an inventory configuration.
+	Put(ctx context.Context, config *domain.InventoryConfig) error
+
+	// Get returns a configuration of a bucket, or domain.ErrInventoryConfigNotFound.
+	Get(ctx context.Context, bucketID int64, id string) (*domain.InventoryConfig, error)
+
+	// ListByBucket returns the configurations of a bucket, by ID.
+	ListByBucket(ctx context.Context, bucketID int64) ([]*domain.InventoryConfig, error)
+
+	// ListEnabled returns the enabled configurations of all buckets.
+	ListEnabled(ctx context.Context) ([]*domain.InventoryConfig, error)
+
+	// Delete removes a configuration, or returns domain.ErrInventoryConfigNotFound.
+	Delete(ctx context.Context, bucketID int64, id string) error
+
+	// MarkRun records when a report was generated.
+	MarkRun(ctx context.Context, bucketID int64, id string, at time.Time) error
+
+	// ListEntries returns up to opts.Limit object versions of a bucket in
+	// listing order: by key, then newest version first.
+	ListEntries(ctx context.Context, bucketID int64, opts InventoryListOptions) ([]*domain.InventoryEntry, error)
+}
+
+// InventoryListOptions contains options for listing inventory entries.
+type InventoryListOptions struct {
+	// Prefix filters entries by key prefix.
+	Prefix string
+
+	// AllVersions lists every version and delete marker instead of only
+	// the latest version of each key.
+	AllVersions bool
+
+	// After continues the listing after this entry. Nil starts at the beginning.
+	After *domain.InventoryEntry
+
+	// Limit is the maximum number of entries to return.
+	Limit int
+}
+
+// =============================================================================
+// Staged Object Repository (Upload Quarantine)
+// =============================================================================
+
+// StagedObjectRepository stores uploads to quarantine buckets that wait for
+// promotion. Create and Delete join the transaction bound to ctx, so a
+// staged row commits together with its blob reference or its promotion.
+type StagedObjectRepository interface {
+	// Create stages an upload.
+	Create(ctx context.Context, staged *domain.StagedObject) error
+
+	// GetByID returns a staged upload, or domain.ErrStagedObjectNotFound.
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.StagedObject, error)
+
+	// List returns up to limit staged uploads of a bucket (or of all
+	// buckets if bucketID is 0), oldest first. Batch writes are not listed.
+	List(ctx context.Context, bucketID int64, limit int) ([]*domain.StagedObject, error)
+
+	// Delete removes a staged upload, or returns
+	// domain.ErrStagedObjectNotFound if it no longer exists.
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// ListExpired returns up to limit uploads staged before the cutoff,
+	// oldest first. Batch writes expire with their batch and are not listed.
+	ListExpired(ctx context.Context, before time.Time, limit int) ([]*domain.StagedObject, error)
+
+	// ListByBatch returns the writes staged in a batch in the order they were
+	// staged, so a commit publishes later writes to a key after earlier ones.
+	ListByBatch(ctx context.Context, batchID uuid.UUID) ([]*domain.StagedObject, error)
+
+	// CountByBatch returns the number of writes staged in a batch.
+	CountByBatch(ctx context.Context, batchID uuid.UUID) (int, error)
+}
+
+// =============================================================================
+// Batch Repository
+// =============================================================================
+
+// BatchRepository stores open batches of staged writes. All methods join the
+// transaction bound to ctx, so a batch is committed or aborted together
+// with its staged writes.
+type BatchRepository interface {
+	// Create opens a batch.
+	Create(ctx context.Context, batch *domain.Batch) error
+
+	// GetByID returns a batch, or domain.ErrBatchNotFound.
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Batch, error)
+
+	// Delete removes a batch, or returns domain.ErrBatchNotFound if it no
+	// longer exists. Its staged writes must be removed in the same transaction.
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// ListExpired returns up to limit batches that expired before the cutoff.
+	ListExpired(ctx context.Context, before time.Time, limit int) ([]*domain.Batch, error)
+}
+
+// =============================================================================
+// Bucket Snapshot Repository
+// =============================================================================
+
+// BucketSnapshotRepository stores bucket snapshots and the object metadata
+// they copy. All methods join the transaction bound to ctx.
+type BucketSnapshotRepository interface {
+	// Create records the latest version of every key in the snapshot's
+	// bucket, delete markers excluded, and takes a reference on each blob
+	// they name. It sets the snapshot's ID, ObjectCount and TotalSize, or
+	// returns domain.ErrSnapshotAlreadyExists.
+	Create(ctx context.Context, snapshot *domain.BucketSnapshot) error
+
+	// GetByName returns a snapshot of a bucket, or domain.ErrSnapshotNotFound.
+	GetByName(ctx context.Context, bucketID int64, name string) (*domain.BucketSnapshot, error)
+
+	// ListByBucket returns the snapshots of a bucket, oldest first.
+	ListByBucket(ctx context.Context, bucketID int64) ([]*domain.BucketSnapshot, error)
+
+	// Delete removes a snapshot and releases the blob references it holds,
+	// or returns domain.ErrSnapshotNotFound if it no longer exists.
+	Delete(ctx context.Context, id int64) error
+
+	// GetObject returns the object version a snapshot holds for a key,
+	// or domain.ErrObjectNotFound.
+	GetObject(ctx context.Context, snapshotID int64, key string) (*domain.Object, error)
+
+	// ListObjects returns the objects of a snapshot in key order, with the
+	// same pagination and prefix filtering as ObjectRepository.List.
+	ListObjects(ctx context.Context, snapshotID int64, opts ObjectListOptions) (*ObjectListResult, error)
+
+	// ListExpired returns up to limit snapshots that expired before the cutoff.
+	ListExpired(ctx context.Context, before time.Time, limit int) ([]*domain.BucketSnapshot, error)
+}
+
+// =============================================================================
+// Snapshotter (Backup and Restore)
+// =============================================================================
+
+// Snapshotter copies the whole metadata database for backup and restore.
+// The copy is opaque and only meaningful to the driver that wrote it.
+type Snapshotter interface {
+	// SchemaVersion returns the migration version of the database.
+	SchemaVersion(ctx context.Context) (int64, error)
+
+	// Snapshot writes a transactionally consistent copy of every table into
+	// the empty directory dir.
+	Snapshot(ctx context.Context, dir string) error
+
+	// Restore replaces the contents of every table with a copy written by
+	// Snapshot, in a single transaction. The database must be migrated to
+	// the schema version the copy was taken at.
+	Restore(ctx context.Context, dir string) error
+}
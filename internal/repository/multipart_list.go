@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"strings"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+)
+
+// MultipartListCursor is the position after which FetchMultipartUploads
+// continues.
+type MultipartListCursor struct {
+	// KeyMarker and UploadIDMarker select the uploads after the given upload
+	// in (key, initiated, upload ID) order. Without UploadIDMarker every
+	// upload of KeyMarker is skipped.
+	KeyMarker      string
+	UploadIDMarker string
+
+	// SkipPrefix, if set, skips the uploads whose key starts with it: the
+	// keys of a common prefix already returned.
+	SkipPrefix string
+}
+
+// FetchMultipartUploads returns up to limit in-progress uploads whose key
+// starts with prefix, after cursor, in (key, initiated, upload ID) order.
+type FetchMultipartUploads func(prefix string, cursor MultipartListCursor, limit int) ([]*domain.MultipartUploadInfo, error)
+
+// ListMultipartUploads implements MultipartRepository.List on top of a
+// driver's fetch. With a delimiter the keys that contain it after the prefix
+// are rolled up into common prefixes, which count towards MaxUploads like
+// uploads. Each common prefix ends a fetch, and the next one skips its keys,
+// so a prefix holding many uploads is not read row by row.
+//
+// When the result is truncated, NextKeyMarker and NextUploadIDMarker are the
+// last upload returned, or the last common prefix with an empty upload ID
+// marker. A key marker that is a common prefix of the request continues
+// after all of its keys.
+func ListMultipartUploads(opts MultipartListOptions, fetch FetchMultipartUploads) (*MultipartListResult, error) {
+	maxUploads := opts.MaxUploads
+	if maxUploads <= 0 {
+		maxUploads = 1000
+	}
+
+	cursor := MultipartListCursor{KeyMarker: opts.KeyMarker}
+	switch {
+	case opts.KeyMarker == "":
+		// The upload ID marker is ignored without a key marker
+	case opts.UploadIDMarker != "":
+		cursor.UploadIDMarker = opts.UploadIDMarker
+	case commonPrefix(opts, opts.KeyMarker) == opts.KeyMarker:
+		cursor.SkipPrefix = opts.KeyMarker
+	}
+
+	result := &MultipartListResult{}
+	entries := 0
+	for {
+		limit := maxUploads - entries + 1
+		uploads, err := fetch(opts.Prefix, cursor, limit)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, upload := range uploads {
+			if entries == maxUploads {
+				result.IsTruncated = true
+				result.NextKeyMarker = cursor.KeyMarker
+				result.NextUploadIDMarker = cursor.UploadIDMarker
+				return result, nil
+			}
+			entries++
+
+			if prefix := commonPrefix(opts, upload.Key); prefix != "" {
+				result.CommonPrefixes = append(result.CommonPrefixes, prefix)
+				cursor = MultipartListCursor{KeyMarker: prefix, SkipPrefix: prefix}
+				break
+			}
+			result.Uploads = append(result.Uploads, upload)
+			cursor = MultipartListCursor{KeyMarker: upload.Key, UploadIDMarker: upload.UploadID}
+		}
+
+		// Only a common prefix ends a fetch early; otherwise every upload
+		// left has been read
+		if cursor.SkipPrefix == "" || len(uploads) == 0 {
+			return result, nil
+		}
+	}
+}
+
+// commonPrefix returns the common prefix key is rolled up into, or "" if
+// the listing has no delimiter or key does not contain it after the prefix.
+func commonPrefix(opts MultipartListOptions, key string) string {
+	if opts.Delimiter == "" || !strings.HasPrefix(key, opts.Prefix) {
+		return ""
+	}
+	i := strings.Index(key[len(opts.Prefix):], opts.Delimiter)
+	if i < 0 {
+		return ""
+	}
+	return key[:len(opts.Prefix)+i+len(opts.Delimiter)]
+}
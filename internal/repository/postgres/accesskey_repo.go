@@ -25,12 +25,12 @@ func NewAccessKeyRepository(db *DB) repository.AccessKeyRepository {
 // Create creates a new access key.
 func (r *accessKeyRepository) Create(ctx context.Context, key *domain.AccessKey) error {
 	query := `
-		INSERT INTO access_keys (user_id, access_key_id, encrypted_secret, description, status, created_at, expires_at, last_used_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO access_keys (user_id, access_key_id, encrypted_secret, description, status, created_at, expires_at, last_used_at, policy)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		RETURNING id
 	`
 
-	err := r.db.Pool.QueryRow(ctx, query,
+	err := r.db.conn(ctx).QueryRow(ctx, query,
 		key.UserID,
 		key.AccessKeyID,
 		key.EncryptedSecret,
@@ -39,6 +39,7 @@ func (r *accessKeyRepository) Create(ctx context.Context, key *domain.AccessKey)
 		key.CreatedAt,
 		key.ExpiresAt,
 		key.LastUsedAt,
+		key.Policy,
 	).Scan(&key.ID)
 
 	if err != nil {
@@ -54,13 +55,13 @@ func (r *accessKeyRepository) Create(ctx context.Context, key *domain.AccessKey)
 // GetByID retrieves an access key by ID.
 func (r *accessKeyRepository) GetByID(ctx context.Context, id int64) (*domain.AccessKey, error) {
 	query := `
-		SELECT id, user_id, access_key_id, encrypted_secret, description, status, created_at, expires_at, last_used_at
+		SELECT id, user_id, access_key_id, encrypted_secret, description, status, created_at, expires_at, last_used_at, policy
 		FROM access_keys
 		WHERE id = $1
 	`
 
 	key := &domain.AccessKey{}
-	err := r.db.Pool.QueryRow(ctx, query, id).Scan(
+	err := r.db.conn(ctx).QueryRow(ctx, query, id).Scan(
 		&key.ID,
 		&key.UserID,
 		&key.AccessKeyID,
@@ -70,6 +71,7 @@ func (r *accessKeyRepository) GetByID(ctx context.Context, id int64) (*domain.Ac
 		&key.CreatedAt,
 		&key.ExpiresAt,
 		&key.LastUsedAt,
+		&key.Policy,
 	)
 
 	if err != nil {
@@ -85,13 +87,13 @@ func (r *accessKeyRepository) GetByID(ctx context.Context, id int64) (*domain.Ac
 // GetByAccessKeyID retrieves an access key by access key ID (20-char identifier).
 func (r *accessKeyRepository) GetByAccessKeyID(ctx context.Context, accessKeyID string) (*domain.AccessKey, error) {
 	query := `
-		SELECT id, user_id, access_key_id, encrypted_secret, description, status, created_at, expires_at, last_used_at
+		SELECT id, user_id, access_key_id, encrypted_secret, description, status, created_at, expires_at, last_used_at, policy
 		FROM access_keys
 		WHERE access_key_id = $1
 	`
 
 	key := &domain.AccessKey{}
-	err := r.db.Pool.QueryRow(ctx, query, accessKeyID).Scan(
+	err := r.db.conn(ctx).QueryRow(ctx, query, accessKeyID).Scan(
 		&key.ID,
 		&key.UserID,
 		&key.AccessKeyID,
@@ -101,6 +103,7 @@ func (r *accessKeyRepository) GetByAccessKeyID(ctx context.Context, accessKeyID
 		&key.CreatedAt,
 		&key.ExpiresAt,
 		&key.LastUsedAt,
+		&key.Policy,
 	)
 
 	if err != nil {
@@ -116,7 +119,7 @@ func (r *accessKeyRepository) GetByAccessKeyID(ctx context.Context, accessKeyID
 // GetActiveByAccessKeyID retrieves an active, non-expired access key.
 func (r *accessKeyRepository) GetActiveByAccessKeyID(ctx context.Context, accessKeyID string) (*domain.AccessKey, error) {
 	query := `
-		SELECT id, user_id, access_key_id, encrypted_secret, description, status, created_at, expires_at, last_used_at
+		SELECT id, user_id, access_key_id, encrypted_secret, description, status, created_at, expires_at, last_used_at, policy
 		FROM access_keys
 		WHERE access_key_id = $1 
 			AND status = $2 
@@ -124,7 +127,7 @@ func (r *accessKeyRepository) GetActiveByAccessKeyID(ctx context.Context, access
 	`
 
 	key := &domain.AccessKey{}
-	err := r.db.Pool.QueryRow(ctx, query, accessKeyID, domain.AccessKeyStatusActive, time.Now().UTC()).Scan(
+	err := r.db.conn(ctx).QueryRow(ctx, query, accessKeyID, domain.AccessKeyStatusActive, time.Now().UTC()).Scan(
 		&key.ID,
 		&key.UserID,
 		&key.AccessKeyID,
@@ -134,6 +137,7 @@ func (r *accessKeyRepository) GetActiveByAccessKeyID(ctx context.Context, access
 		&key.CreatedAt,
 		&key.ExpiresAt,
 		&key.LastUsedAt,
+		&key.Policy,
 	)
 
 	if err != nil {
@@ -149,13 +153,13 @@ func (r *accessKeyRepository) GetActiveByAccessKeyID(ctx context.Context, access
 // ListByUserID retrieves all access keys for a user.
 func (r *accessKeyRepository) ListByUserID(ctx context.Context, userID int64) ([]*domain.AccessKey, error) {
 	query := `
-		SELECT id, user_id, access_key_id, encrypted_secret, description, status, created_at, expires_at, last_used_at
+		SELECT id, user_id, access_key_id, encrypted_secret, description, status, created_at, expires_at, last_used_at, policy
 		FROM access_keys
 		WHERE user_id = $1
 		ORDER BY created_at DESC
 	`
 
-	rows, err := r.db.Pool.Query(ctx, query, userID)
+	rows, err := r.db.conn(ctx).Query(ctx, query, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list access keys: %w", err)
 	}
@@ -174,6 +178,7 @@ func (r *accessKeyRepository) ListByUserID(ctx context.Context, userID int64) ([
 			&key.CreatedAt,
 			&key.ExpiresAt,
 			&key.LastUsedAt,
+			&key.Policy,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan access key: %w", err)
@@ -192,15 +197,16 @@ func (r *accessKeyRepository) ListByUserID(ctx context.Context, userID int64) ([
 func (r *accessKeyRepository) Update(ctx context.Context, key *domain.AccessKey) error {
 	query := `
 		UPDATE access_keys
-		SET description = $2, status = $3, expires_at = $4
+		SET description = $2, status = $3, expires_at = $4, policy = $5
 		WHERE id = $1
 	`
 
-	result, err := r.db.Pool.Exec(ctx, query,
+	result, err := r.db.conn(ctx).Exec(ctx, query,
 		key.ID,
 		key.Description,
 		key.Status,
 		key.ExpiresAt,
+		key.Policy,
 	)
 
 	if err != nil {
@@ -214,12 +220,28 @@ func (r *accessKeyRepository) Update(ctx context.Context, key *domain.AccessKey)
 	return nil
 }
 
+// UpdateSecret replaces the encrypted secret of an access key.
+func (r *accessKeyRepository) UpdateSecret(ctx context.Context, id int64, encryptedSecret string) error {
+	query := `UPDATE access_keys SET encrypted_secret = $2 WHERE id = $1`
+
+	result, err := r.db.conn(ctx).Exec(ctx, query, id, encryptedSecret)
+	if err != nil {
+		return fmt.Errorf("failed to update access key secret: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrAccessKeyNotFound
+	}
+
+	return nil
+}
+
 // UpdateLastUsed updates the last used timestamp for an access key.
 func (r *accessKeyRepository) UpdateLastUsed(ctx context.Context, id int64) error {
 	query := `UPDATE access_keys SET last_used_at = $2 WHERE id = $1`
 
 	now := time.Now().UTC()
-	result, err := r.db.Pool.Exec(ctx, query, id, now)
+	result, err := r.db.conn(ctx).Exec(ctx, query, id, now)
 	if err != nil {
 		return fmt.Errorf("failed to update last used: %w", err)
 	}
@@ -235,7 +257,7 @@ func (r *accessKeyRepository) UpdateLastUsed(ctx context.Context, id int64) erro
 func (r *accessKeyRepository) Delete(ctx context.Context, id int64) error {
 	query := `DELETE FROM access_keys WHERE id = $1`
 
-	result, err := r.db.Pool.Exec(ctx, query, id)
+	result, err := r.db.conn(ctx).Exec(ctx, query, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete access key: %w", err)
 	}
@@ -251,7 +273,7 @@ func (r *accessKeyRepository) Delete(ctx context.Context, id int64) error {
 func (r *accessKeyRepository) DeleteByAccessKeyID(ctx context.Context, accessKeyID string) error {
 	query := `DELETE FROM access_keys WHERE access_key_id = $1`
 
-	result, err := r.db.Pool.Exec(ctx, query, accessKeyID)
+	result, err := r.db.conn(ctx).Exec(ctx, query, accessKeyID)
 	if err != nil {
 		return fmt.Errorf("failed to delete access key: %w", err)
 	}
@@ -267,7 +289,7 @@ func (r *accessKeyRepository) DeleteByAccessKeyID(ctx context.Context, accessKey
 func (r *accessKeyRepository) DeleteExpired(ctx context.Context) (int64, error) {
 	query := `DELETE FROM access_keys WHERE expires_at IS NOT NULL AND expires_at < $1`
 
-	result, err := r.db.Pool.Exec(ctx, query, time.Now().UTC())
+	result, err := r.db.conn(ctx).Exec(ctx, query, time.Now().UTC())
 	if err != nil {
 		return 0, fmt.Errorf("failed to delete expired access keys: %w", err)
 	}
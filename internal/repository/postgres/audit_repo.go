@@ -0,0 +1,219 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// auditRepository implements repository.AuditRepository.
+type auditRepository struct {
+	db *DB
+}
+
+// NewAuditRepository creates a new PostgreSQL audit repository.
+func NewAuditRepository(db *DB) repository.AuditRepository {
+	return &auditRepository{db: db}
+}
+
+// Append records events and sets their IDs. All events are inserted with
+// one statement that unnests a column array each, so its text, and with it
+// the cached prepared statement, is the same for any number of events.
+func (r *auditRepository) Append(ctx context.Context, events []*domain.AuditEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	// IDs are taken from the sequence first, since the order of the rows
+	// RETURNING reports is not guaranteed. Sorted, they keep the events in order.
+	rows, err := r.db.conn(ctx).Query(ctx,
+		`SELECT nextval(pg_get_serial_sequence('audit_log', 'id')) FROM generate_series(1, $1)`, len(events))
+	if err != nil {
+		return fmt.Errorf("failed to allocate audit event ids: %w", err)
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		return fmt.Errorf("failed to allocate audit event ids: %w", err)
+	}
+	slices.Sort(ids)
+
+	n := len(events)
+	var (
+		times        = make([]time.Time, n)
+		userIDs      = make([]int64, n)
+		actors       = make([]string, n)
+		accessKeyIDs = make([]string, n)
+		operations   = make([]string, n)
+		bucketNames  = make([]string, n)
+		objectKeys   = make([]string, n)
+		sourceIPs    = make([]string, n)
+		requestIDs   = make([]string, n)
+		results      = make([]string, n)
+		statusCodes  = make([]int32, n)
+		details      = make([]string, n)
+		categories   = make([]string, n)
+		severities   = make([]string, n)
+	)
+	for i, event := range events {
+		times[i] = event.Time.UTC()
+		userIDs[i] = event.UserID
+		actors[i] = event.Actor
+		accessKeyIDs[i] = event.AccessKeyID
+		operations[i] = event.Operation
+		bucketNames[i] = event.BucketName
+		objectKeys[i] = event.ObjectKey
+		sourceIPs[i] = event.SourceIP
+		requestIDs[i] = event.RequestID
+		results[i] = string(event.Result)
+		statusCodes[i] = int32(event.StatusCode)
+		details[i] = event.Detail
+		categories[i] = string(event.Category)
+		severities[i] = string(event.Severity)
+	}
+
+	query := `
+		INSERT INTO audit_log (id, time, user_id, actor, access_key_id, operation, bucket_name, object_key,
+			source_ip, request_id, result, status_code, detail, category, severity)
+		SELECT * FROM unnest($1::bigint[], $2::timestamptz[], $3::bigint[], $4::text[], $5::text[], $6::text[],
+			$7::text[], $8::text[], $9::text[], $10::text[], $11::text[], $12::integer[], $13::text[], $14::text[], $15::text[])
+	`
+	_, err = r.db.conn(ctx).Exec(ctx, query,
+		ids, times, userIDs, actors, accessKeyIDs, operations, bucketNames, objectKeys,
+		sourceIPs, requestIDs, results, statusCodes, details, categories, severities)
+	if err != nil {
+		return fmt.Errorf("failed to append audit events: %w", err)
+	}
+
+	for i, event := range events {
+		event.ID = ids[i]
+	}
+	return nil
+}
+
+// List returns events matching the filter, ordered by ID.
+func (r *auditRepository) List(ctx context.Context, filter repository.AuditFilter) ([]*domain.AuditEvent, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 1000
+	}
+
+	var since, until *time.Time
+	if !filter.Since.IsZero() {
+		t := filter.Since.UTC()
+		since = &t
+	}
+	if !filter.Until.IsZero() {
+		t := filter.Until.UTC()
+		until = &t
+	}
+
+	query := `
+		SELECT id, time, user_id, actor, access_key_id, operation, bucket_name, object_key,
+			source_ip, request_id, result, status_code, detail, category, severity
+		FROM audit_log
+		WHERE id > $1
+			AND ($2::timestamptz IS NULL OR time >= $2)
+			AND ($3::timestamptz IS NULL OR time < $3)
+			AND ($4 = 0 OR user_id = $4)
+			AND ($5 = '' OR access_key_id = $5)
+			AND ($6 = '' OR bucket_name = $6)
+			AND ($7 = '' OR operation = $7)
+			AND ($8 = '' OR result = $8)
+			AND ($9 = '' OR category = $9)
+			AND ($10 = '' OR severity = $10)
+		ORDER BY id
+		LIMIT $11
+	`
+
+	rows, err := r.db.conn(ctx).Query(ctx, query,
+		filter.AfterID,
+		since,
+		until,
+		filter.UserID,
+		filter.AccessKeyID,
+		filter.BucketName,
+		filter.Operation,
+		string(filter.Result),
+		string(filter.Category),
+		string(filter.Severity),
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*domain.AuditEvent
+	for rows.Next() {
+		event := &domain.AuditEvent{}
+		var result, category, severity string
+		err := rows.Scan(
+			&event.ID,
+			&event.Time,
+			&event.UserID,
+			&event.Actor,
+			&event.AccessKeyID,
+			&event.Operation,
+			&event.BucketName,
+			&event.ObjectKey,
+			&event.SourceIP,
+			&event.RequestID,
+			&result,
+			&event.StatusCode,
+			&event.Detail,
+			&category,
+			&severity,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan audit event: %w", err)
+		}
+		event.Time = event.Time.UTC()
+		event.Result = domain.AuditResult(result)
+		event.Category = domain.AuditCategory(category)
+		event.Severity = domain.AuditSeverity(severity)
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating audit events: %w", err)
+	}
+
+	return events, nil
+}
+
+// Prune deletes up to limit of the oldest events of a category recorded
+// before the given time. Setting alexander.audit_prune lifts the
+// append-only trigger for the duration of the transaction.
+func (r *auditRepository) Prune(ctx context.Context, category domain.AuditCategory, before time.Time, limit int) (int64, error) {
+	var deleted int64
+	err := r.db.WithTx(ctx, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `SELECT set_config('alexander.audit_prune', 'on', true)`); err != nil {
+			return err
+		}
+
+		tag, err := tx.Exec(ctx, `
+			DELETE FROM audit_log
+			WHERE id IN (
+				SELECT id FROM audit_log
+				WHERE category = $1 AND time < $2
+				ORDER BY id
+				LIMIT $3
+			)
+		`, string(category), before.UTC(), limit)
+		if err != nil {
+			return err
+		}
+		deleted = tag.RowsAffected()
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune audit events: %w", err)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,97 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// backfillRepository implements repository.BackfillRepository.
+type backfillRepository struct {
+	db *DB
+}
+
+// NewBackfillRepository creates a new PostgreSQL backfill repository.
+func NewBackfillRepository(db *DB) repository.BackfillRepository {
+	return &backfillRepository{db: db}
+}
+
+// Acquire returns the named backfill's progress, creating it if needed, and
+// locks the row for the rest of the transaction.
+func (r *backfillRepository) Acquire(ctx context.Context, name string) (*domain.BackfillProgress, error) {
+	_, err := r.db.conn(ctx).Exec(ctx,
+		`INSERT INTO backfill_progress (name) VALUES ($1) ON CONFLICT (name) DO NOTHING`, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create backfill progress: %w", err)
+	}
+
+	progress := &domain.BackfillProgress{}
+	err = r.db.conn(ctx).QueryRow(ctx, `
+		SELECT name, cursor, rows_done, started_at, updated_at, completed_at
+		FROM backfill_progress
+		WHERE name = $1
+		FOR UPDATE
+	`, name).Scan(
+		&progress.Name,
+		&progress.Cursor,
+		&progress.RowsDone,
+		&progress.StartedAt,
+		&progress.UpdatedAt,
+		&progress.CompletedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get backfill progress: %w", err)
+	}
+
+	return progress, nil
+}
+
+// Update saves the progress.
+func (r *backfillRepository) Update(ctx context.Context, progress *domain.BackfillProgress) error {
+	_, err := r.db.conn(ctx).Exec(ctx, `
+		UPDATE backfill_progress
+		SET cursor = $2, rows_done = $3, updated_at = $4, completed_at = $5
+		WHERE name = $1
+	`, progress.Name, progress.Cursor, progress.RowsDone, progress.UpdatedAt.UTC(), progress.CompletedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update backfill progress: %w", err)
+	}
+	return nil
+}
+
+// List returns the progress of every backfill, by name.
+func (r *backfillRepository) List(ctx context.Context) ([]*domain.BackfillProgress, error) {
+	rows, err := r.db.conn(ctx).Query(ctx, `
+		SELECT name, cursor, rows_done, started_at, updated_at, completed_at
+		FROM backfill_progress
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backfill progress: %w", err)
+	}
+	defer rows.Close()
+
+	var result []*domain.BackfillProgress
+	for rows.Next() {
+		progress := &domain.BackfillProgress{}
+		if err := rows.Scan(
+			&progress.Name,
+			&progress.Cursor,
+			&progress.RowsDone,
+			&progress.StartedAt,
+			&progress.UpdatedAt,
+			&progress.CompletedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan backfill progress: %w", err)
+		}
+		result = append(result, progress)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating backfill progress: %w", err)
+	}
+
+	return result, nil
+}
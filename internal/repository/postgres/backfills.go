@@ -0,0 +1,174 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/prn-tf/alexander-storage/internal/migration"
+)
+
+// Backfills returns the batched data migrations of the PostgreSQL schema, in
+// the order they must run; see migrations/postgres/README.md. New backfills
+// are appended, and shipped ones are never removed or renamed.
+func Backfills(db *DB) []migration.Backfill {
+	return []migration.Backfill{
+		objectsMetadataNotNull(db),
+		tenantForeignKeys(db),
+		objectSequence(db),
+	}
+}
+
+// objectsMetadataNotNull rewrites NULL object metadata, written by releases
+// that stored a nil map as NULL, to '{}' and then enforces it with a CHECK
+// constraint. The constraint is added NOT VALID, which does not scan the
+// table, and validated separately, which scans it without blocking writes.
+func objectsMetadataNotNull(db *DB) migration.Backfill {
+	return migration.Backfill{
+		Name: "objects_metadata_not_null",
+		Batch: func(ctx context.Context, after int64, limit int) (int64, int, error) {
+			query := `
+				WITH batch AS (
+					SELECT id, metadata FROM objects
+					WHERE id > $1
+					ORDER BY id
+					LIMIT $2
+				), updated AS (
+					UPDATE objects SET metadata = '{}'
+					WHERE id IN (SELECT id FROM batch WHERE metadata IS NULL)
+				)
+				SELECT COALESCE(MAX(id), 0), COUNT(*) FROM batch
+			`
+
+			var last int64
+			var rows int
+			if err := db.conn(ctx).QueryRow(ctx, query, after, limit).Scan(&last, &rows); err != nil {
+				return 0, 0, fmt.Errorf("failed to backfill object metadata: %w", err)
+			}
+			return last, rows, nil
+		},
+		Finalize: func(ctx context.Context) error {
+			err := db.execDDL(ctx, `
+				DO $$
+				BEGIN
+					IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'objects_metadata_not_null') THEN
+						ALTER TABLE objects
+						ADD CONSTRAINT objects_metadata_not_null CHECK (metadata IS NOT NULL) NOT VALID;
+					END IF;
+				END
+				$$
+			`)
+			if err != nil {
+				return fmt.Errorf("failed to add objects_metadata_not_null: %w", err)
+			}
+
+			_, err = db.conn(ctx).Exec(ctx, `ALTER TABLE objects VALIDATE CONSTRAINT objects_metadata_not_null`)
+			if err != nil {
+				return fmt.Errorf("failed to validate objects_metadata_not_null: %w", err)
+			}
+			return nil
+		},
+	}
+}
+
+// tenantForeignKeys references tenants from the tenant_id columns of users
+// and buckets. Migration 000043 added the columns with the default tenant as
+// their default, so there are no rows to convert; the constraints are added
+// NOT VALID and validated without blocking writes.
+func tenantForeignKeys(db *DB) migration.Backfill {
+	return migration.Backfill{
+		Name: "tenant_foreign_keys",
+		Batch: func(ctx context.Context, after int64, limit int) (int64, int, error) {
+			return after, 0, nil
+		},
+		Finalize: func(ctx context.Context) error {
+			for _, table := range []string{"users", "buckets"} {
+				constraint := "fk_" + table + "_tenant"
+				err := db.execDDL(ctx, fmt.Sprintf(`
+					DO $$
+					BEGIN
+						IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = '%[2]s') THEN
+							ALTER TABLE %[1]s
+							ADD CONSTRAINT %[2]s FOREIGN KEY (tenant_id) REFERENCES tenants(id) NOT VALID;
+						END IF;
+					END
+					$$
+				`, table, constraint))
+				if err != nil {
+					return fmt.Errorf("failed to add %s: %w", constraint, err)
+				}
+
+				_, err = db.conn(ctx).Exec(ctx, fmt.Sprintf(`ALTER TABLE %s VALIDATE CONSTRAINT %s`, table, constraint))
+				if err != nil {
+					return fmt.Errorf("failed to validate %s: %w", constraint, err)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// objectSequence numbers the versions written before migration 000011 added
+// write sequences, which start at 0. Each key's versions are numbered from 1
+// in creation order, all at once when the batch reaches the key's first
+// version, and the key's counter is set to the last of them. New writes to a
+// key that is not numbered yet start after its unnumbered versions, so both
+// agree; the counter is written first, as writers do, so a concurrent first
+// write to the key waits for the batch instead of deadlocking with it.
+func objectSequence(db *DB) migration.Backfill {
+	return migration.Backfill{
+		Name: "object_sequence",
+		Batch: func(ctx context.Context, after int64, limit int) (int64, int, error) {
+			var last int64
+			var rows int
+			err := db.conn(ctx).QueryRow(ctx, `
+				SELECT COALESCE(MAX(id), 0), COUNT(*) FROM (
+					SELECT id FROM objects
+					WHERE id > $1
+					ORDER BY id
+					LIMIT $2
+				) AS batch
+			`, after, limit).Scan(&last, &rows)
+			if err != nil {
+				return 0, 0, fmt.Errorf("failed to read object batch: %w", err)
+			}
+			if rows == 0 {
+				return after, 0, nil
+			}
+
+			// The keys of the batch with versions still to number
+			const keys = `
+				SELECT DISTINCT bucket_id, key FROM objects
+				WHERE id > $1 AND id <= $2 AND sequence = 0
+			`
+
+			_, err = db.conn(ctx).Exec(ctx, `
+				INSERT INTO object_sequences (bucket_id, key, sequence)
+				SELECT o.bucket_id, o.key, COUNT(*)
+				FROM objects o
+				JOIN (`+keys+`) AS keys ON o.bucket_id = keys.bucket_id AND o.key = keys.key
+				WHERE o.sequence = 0
+				GROUP BY o.bucket_id, o.key
+				ON CONFLICT (bucket_id, key) DO NOTHING
+			`, after, last)
+			if err != nil {
+				return 0, 0, fmt.Errorf("failed to backfill object sequence counters: %w", err)
+			}
+
+			_, err = db.conn(ctx).Exec(ctx, `
+				UPDATE objects SET sequence = numbered.sequence
+				FROM (
+					SELECT o.id, o.bucket_id,
+						ROW_NUMBER() OVER (PARTITION BY o.bucket_id, o.key ORDER BY o.id) AS sequence
+					FROM objects o
+					JOIN (`+keys+`) AS keys ON o.bucket_id = keys.bucket_id AND o.key = keys.key
+					WHERE o.sequence = 0
+				) AS numbered
+				WHERE objects.id = numbered.id AND objects.bucket_id = numbered.bucket_id
+			`, after, last)
+			if err != nil {
+				return 0, 0, fmt.Errorf("failed to backfill object sequences: %w", err)
+			}
+			return last, rows, nil
+		},
+	}
+}
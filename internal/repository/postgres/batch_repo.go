@@ -0,0 +1,109 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// batchRepository implements repository.BatchRepository.
+type batchRepository struct {
+	db *DB
+}
+
+// NewBatchRepository creates a new PostgreSQL batch repository.
+func NewBatchRepository(db *DB) repository.BatchRepository {
+	return &batchRepository{db: db}
+}
+
+// Create opens a batch.
+func (r *batchRepository) Create(ctx context.Context, batch *domain.Batch) error {
+	query := `INSERT INTO batches (id, owner_id, created_at, expires_at) VALUES ($1, $2, $3, $4)`
+
+	_, err := r.db.conn(ctx).Exec(ctx, query, batch.ID, batch.OwnerID, batch.CreatedAt, batch.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to create batch: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID returns a batch.
+func (r *batchRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Batch, error) {
+	query := `SELECT id, owner_id, created_at, expires_at FROM batches WHERE id = $1`
+
+	batch, err := scanBatch(r.db.conn(ctx).QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrBatchNotFound
+		}
+		return nil, fmt.Errorf("failed to get batch: %w", err)
+	}
+
+	return batch, nil
+}
+
+// Delete removes a batch.
+func (r *batchRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.conn(ctx).Exec(ctx, `DELETE FROM batches WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete batch: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrBatchNotFound
+	}
+
+	return nil
+}
+
+// ListExpired returns up to limit batches that expired before the cutoff.
+func (r *batchRepository) ListExpired(ctx context.Context, before time.Time, limit int) ([]*domain.Batch, error) {
+	query := `
+		SELECT id, owner_id, created_at, expires_at
+		FROM batches
+		WHERE expires_at < $1
+		ORDER BY expires_at ASC, id ASC
+		LIMIT $2
+	`
+
+	rows, err := r.db.conn(ctx).Query(ctx, query, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired batches: %w", err)
+	}
+	defer rows.Close()
+
+	var batches []*domain.Batch
+	for rows.Next() {
+		batch, err := scanBatch(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan batch: %w", err)
+		}
+		batches = append(batches, batch)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating batches: %w", err)
+	}
+
+	return batches, nil
+}
+
+// scanBatch scans the columns of one batches row.
+func scanBatch(row pgx.Row) (*domain.Batch, error) {
+	batch := &domain.Batch{}
+	if err := row.Scan(&batch.ID, &batch.OwnerID, &batch.CreatedAt, &batch.ExpiresAt); err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+// Ensure batchRepository implements repository.BatchRepository
+var _ repository.BatchRepository = (*batchRepository)(nil)
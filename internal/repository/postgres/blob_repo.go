@@ -32,12 +32,12 @@ func (r *blobRepository) UpsertWithRefIncrement(ctx context.Context, contentHash
 		INSERT INTO blobs (content_hash, size, storage_path, ref_count, is_encrypted, created_at)
 		VALUES ($1, $2, $3, 1, true, $4)
 		ON CONFLICT (content_hash) DO UPDATE
-		SET ref_count = blobs.ref_count + 1
+		SET ref_count = blobs.ref_count + 1, gc_state = ''
 		RETURNING (xmax = 0) AS is_new
 	`
 
 	var isNew bool
-	err := r.db.Pool.QueryRow(ctx, query, contentHash, size, storagePath, time.Now().UTC()).Scan(&isNew)
+	err := r.db.conn(ctx).QueryRow(ctx, query, contentHash, size, storagePath, time.Now().UTC()).Scan(&isNew)
 	if err != nil {
 		return false, fmt.Errorf("failed to upsert blob: %w", err)
 	}
@@ -52,12 +52,12 @@ func (r *blobRepository) UpsertEncrypted(ctx context.Context, contentHash string
 		INSERT INTO blobs (content_hash, size, storage_path, ref_count, is_encrypted, encryption_iv, created_at)
 		VALUES ($1, $2, $3, 1, true, $4, $5)
 		ON CONFLICT (content_hash) DO UPDATE
-		SET ref_count = blobs.ref_count + 1
+		SET ref_count = blobs.ref_count + 1, gc_state = ''
 		RETURNING (xmax = 0) AS is_new
 	`
 
 	var isNew bool
-	err := r.db.Pool.QueryRow(ctx, query, contentHash, size, storagePath, encryptionIV, time.Now().UTC()).Scan(&isNew)
+	err := r.db.conn(ctx).QueryRow(ctx, query, contentHash, size, storagePath, encryptionIV, time.Now().UTC()).Scan(&isNew)
 	if err != nil {
 		return false, fmt.Errorf("failed to upsert encrypted blob: %w", err)
 	}
@@ -74,7 +74,7 @@ func (r *blobRepository) GetByHash(ctx context.Context, contentHash string) (*do
 	`
 
 	blob := &domain.Blob{}
-	err := r.db.Pool.QueryRow(ctx, query, contentHash).Scan(
+	err := r.db.conn(ctx).QueryRow(ctx, query, contentHash).Scan(
 		&blob.ContentHash,
 		&blob.Size,
 		&blob.StoragePath,
@@ -98,11 +98,11 @@ func (r *blobRepository) GetByHash(ctx context.Context, contentHash string) (*do
 func (r *blobRepository) IncrementRef(ctx context.Context, contentHash string) error {
 	query := `
 		UPDATE blobs
-		SET ref_count = ref_count + 1
+		SET ref_count = ref_count + 1, gc_state = ''
 		WHERE content_hash = $1
 	`
 
-	result, err := r.db.Pool.Exec(ctx, query, contentHash)
+	result, err := r.db.conn(ctx).Exec(ctx, query, contentHash)
 	if err != nil {
 		return fmt.Errorf("failed to increment ref count: %w", err)
 	}
@@ -125,7 +125,7 @@ func (r *blobRepository) DecrementRef(ctx context.Context, contentHash string) (
 	`
 
 	var newRefCount int32
-	err := r.db.Pool.QueryRow(ctx, query, contentHash).Scan(&newRefCount)
+	err := r.db.conn(ctx).QueryRow(ctx, query, contentHash).Scan(&newRefCount)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return 0, domain.ErrBlobNotFound
@@ -139,7 +139,7 @@ func (r *blobRepository) DecrementRef(ctx context.Context, contentHash string) (
 // GetRefCount returns the current reference count for a blob.
 func (r *blobRepository) GetRefCount(ctx context.Context, contentHash string) (int32, error) {
 	var refCount int32
-	err := r.db.Pool.QueryRow(ctx, `SELECT ref_count FROM blobs WHERE content_hash = $1`, contentHash).Scan(&refCount)
+	err := r.db.conn(ctx).QueryRow(ctx, `SELECT ref_count FROM blobs WHERE content_hash = $1`, contentHash).Scan(&refCount)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return 0, domain.ErrBlobNotFound
@@ -152,7 +152,7 @@ func (r *blobRepository) GetRefCount(ctx context.Context, contentHash string) (i
 // Exists checks if a blob with the given hash exists.
 func (r *blobRepository) Exists(ctx context.Context, contentHash string) (bool, error) {
 	var exists bool
-	err := r.db.Pool.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM blobs WHERE content_hash = $1)`, contentHash).Scan(&exists)
+	err := r.db.conn(ctx).QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM blobs WHERE content_hash = $1)`, contentHash).Scan(&exists)
 	if err != nil {
 		return false, fmt.Errorf("failed to check blob existence: %w", err)
 	}
@@ -161,9 +161,9 @@ func (r *blobRepository) Exists(ctx context.Context, contentHash string) (bool,
 
 // Delete deletes a blob by its content hash.
 func (r *blobRepository) Delete(ctx context.Context, contentHash string) error {
-	query := `DELETE FROM blobs WHERE content_hash = $1 AND ref_count <= 0`
+	query := `DELETE FROM blobs WHERE content_hash = $1 AND ref_count <= 0 AND gc_state = 'storage_deleted'`
 
-	result, err := r.db.Pool.Exec(ctx, query, contentHash)
+	result, err := r.db.conn(ctx).Exec(ctx, query, contentHash)
 	if err != nil {
 		return fmt.Errorf("failed to delete blob: %w", err)
 	}
@@ -176,19 +176,49 @@ func (r *blobRepository) Delete(ctx context.Context, contentHash string) error {
 }
 
 // ListOrphans returns blobs with ref_count = 0 that are older than the grace period.
+// Blobs with the fewest GC attempts come first so repeatedly failing deletions
+// do not starve the rest.
 func (r *blobRepository) ListOrphans(ctx context.Context, gracePeriod time.Duration, limit int) ([]*domain.Blob, error) {
 	query := `
-		SELECT content_hash, size, storage_path, ref_count, is_encrypted, created_at, last_accessed
+		SELECT content_hash, size, storage_path, ref_count, is_encrypted, gc_state, gc_attempts, gc_last_error, created_at, last_accessed
 		FROM blobs
 		WHERE ref_count <= 0 AND created_at < $1
-		ORDER BY created_at ASC
+		ORDER BY gc_attempts ASC, created_at ASC
 		LIMIT $2
 	`
+	return r.listWithGCState(ctx, query, time.Now().UTC().Add(-gracePeriod), limit)
+}
 
-	cutoff := time.Now().UTC().Add(-gracePeriod)
-	rows, err := r.db.Pool.Query(ctx, query, cutoff, limit)
+// ListOrphansAfter returns orphan blobs after a content hash, in content hash order.
+func (r *blobRepository) ListOrphansAfter(ctx context.Context, gracePeriod time.Duration, after string, limit int) ([]*domain.Blob, error) {
+	query := `
+		SELECT content_hash, size, storage_path, ref_count, is_encrypted, gc_state, gc_attempts, gc_last_error, created_at, last_accessed
+		FROM blobs
+		WHERE ref_count <= 0 AND created_at < $1 AND content_hash > $2
+		ORDER BY content_hash ASC
+		LIMIT $3
+	`
+	return r.listWithGCState(ctx, query, time.Now().UTC().Add(-gracePeriod), after, limit)
+}
+
+// ListAfter returns blobs whose content hash sorts after the given one, in
+// content hash order.
+func (r *blobRepository) ListAfter(ctx context.Context, after string, limit int) ([]*domain.Blob, error) {
+	query := `
+		SELECT content_hash, size, storage_path, ref_count, is_encrypted, gc_state, gc_attempts, gc_last_error, created_at, last_accessed
+		FROM blobs
+		WHERE content_hash > $1
+		ORDER BY content_hash ASC
+		LIMIT $2
+	`
+	return r.listWithGCState(ctx, query, after, limit)
+}
+
+// listWithGCState runs a query selecting blobs along with their GC state.
+func (r *blobRepository) listWithGCState(ctx context.Context, query string, args ...interface{}) ([]*domain.Blob, error) {
+	rows, err := r.db.conn(ctx).Query(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list orphan blobs: %w", err)
+		return nil, fmt.Errorf("failed to list blobs: %w", err)
 	}
 	defer rows.Close()
 
@@ -201,6 +231,9 @@ func (r *blobRepository) ListOrphans(ctx context.Context, gracePeriod time.Durat
 			&blob.StoragePath,
 			&blob.RefCount,
 			&blob.IsEncrypted,
+			&blob.GCState,
+			&blob.GCAttempts,
+			&blob.GCLastError,
 			&blob.CreatedAt,
 			&blob.LastAccessed,
 		)
@@ -217,6 +250,74 @@ func (r *blobRepository) ListOrphans(ctx context.Context, gracePeriod time.Durat
 	return blobs, nil
 }
 
+// GetGCCursor returns the content hash GC resumes after.
+func (r *blobRepository) GetGCCursor(ctx context.Context) (string, error) {
+	var after string
+	err := r.db.conn(ctx).QueryRow(ctx, `SELECT after_hash FROM gc_cursor WHERE id = 1`).Scan(&after)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get gc cursor: %w", err)
+	}
+	return after, nil
+}
+
+// SaveGCCursor saves the content hash GC resumes after.
+func (r *blobRepository) SaveGCCursor(ctx context.Context, after string) error {
+	_, err := r.db.conn(ctx).Exec(ctx, `
+		INSERT INTO gc_cursor (id, after_hash, updated_at) VALUES (1, $1, NOW())
+		ON CONFLICT (id) DO UPDATE SET after_hash = EXCLUDED.after_hash, updated_at = EXCLUDED.updated_at
+	`, after)
+	if err != nil {
+		return fmt.Errorf("failed to save gc cursor: %w", err)
+	}
+	return nil
+}
+
+// MarkGCPending claims an orphan blob for deletion.
+func (r *blobRepository) MarkGCPending(ctx context.Context, contentHash string) (bool, error) {
+	query := `
+		UPDATE blobs
+		SET gc_state = 'pending', gc_attempts = gc_attempts + 1, gc_last_error = NULL
+		WHERE content_hash = $1 AND ref_count <= 0 AND gc_state IN ('', 'pending')
+	`
+
+	result, err := r.db.conn(ctx).Exec(ctx, query, contentHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark blob gc pending: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+// MarkGCStorageDeleted records that a pending blob's content was deleted.
+func (r *blobRepository) MarkGCStorageDeleted(ctx context.Context, contentHash string) (bool, error) {
+	query := `
+		UPDATE blobs
+		SET gc_state = 'storage_deleted'
+		WHERE content_hash = $1 AND ref_count <= 0 AND gc_state = 'pending'
+	`
+
+	result, err := r.db.conn(ctx).Exec(ctx, query, contentHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark blob storage deleted: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+// RecordGCError stores the last GC error for a blob.
+func (r *blobRepository) RecordGCError(ctx context.Context, contentHash string, message string) error {
+	query := `UPDATE blobs SET gc_last_error = $2 WHERE content_hash = $1`
+
+	if _, err := r.db.conn(ctx).Exec(ctx, query, contentHash, message); err != nil {
+		return fmt.Errorf("failed to record blob gc error: %w", err)
+	}
+
+	return nil
+}
+
 // DeleteOrphans deletes orphan blobs older than the grace period.
 func (r *blobRepository) DeleteOrphans(ctx context.Context, gracePeriod time.Duration, limit int) ([]*domain.Blob, error) {
 	// First get the blobs to be deleted
@@ -236,7 +337,7 @@ func (r *blobRepository) DeleteOrphans(ctx context.Context, gracePeriod time.Dur
 	`
 
 	cutoff := time.Now().UTC().Add(-gracePeriod)
-	_, err = r.db.Pool.Exec(ctx, query, cutoff)
+	_, err = r.db.conn(ctx).Exec(ctx, query, cutoff)
 	if err != nil {
 		return nil, fmt.Errorf("failed to delete orphan blobs: %w", err)
 	}
@@ -248,7 +349,7 @@ func (r *blobRepository) DeleteOrphans(ctx context.Context, gracePeriod time.Dur
 func (r *blobRepository) UpdateLastAccessed(ctx context.Context, contentHash string) error {
 	query := `UPDATE blobs SET last_accessed = $2 WHERE content_hash = $1`
 
-	result, err := r.db.Pool.Exec(ctx, query, contentHash, time.Now().UTC())
+	result, err := r.db.conn(ctx).Exec(ctx, query, contentHash, time.Now().UTC())
 	if err != nil {
 		return fmt.Errorf("failed to update last accessed: %w", err)
 	}
@@ -260,11 +361,12 @@ func (r *blobRepository) UpdateLastAccessed(ctx context.Context, contentHash str
 	return nil
 }
 
-// UpdateEncrypted marks a blob as encrypted with the given IV (SSE-S3 migration).
-func (r *blobRepository) UpdateEncrypted(ctx context.Context, contentHash string, encryptionIV string) error {
-	query := `UPDATE blobs SET is_encrypted = true, encryption_iv = $2 WHERE content_hash = $1`
+// UpdateEncrypted marks a blob as encrypted with the given IV and key
+// version (SSE-S3 migration and key rotation).
+func (r *blobRepository) UpdateEncrypted(ctx context.Context, contentHash string, encryptionIV string, keyVersion int) error {
+	query := `UPDATE blobs SET is_encrypted = true, encryption_iv = $2, encryption_key_version = $3 WHERE content_hash = $1`
 
-	result, err := r.db.Pool.Exec(ctx, query, contentHash, encryptionIV)
+	result, err := r.db.conn(ctx).Exec(ctx, query, contentHash, encryptionIV, keyVersion)
 	if err != nil {
 		return fmt.Errorf("failed to update encrypted flag: %w", err)
 	}
@@ -286,7 +388,7 @@ func (r *blobRepository) ListUnencrypted(ctx context.Context, limit int) ([]*dom
 		LIMIT $1
 	`
 
-	rows, err := r.db.Pool.Query(ctx, query, limit)
+	rows, err := r.db.conn(ctx).Query(ctx, query, limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list unencrypted blobs: %w", err)
 	}
@@ -320,7 +422,7 @@ func (r *blobRepository) ListUnencrypted(ctx context.Context, limit int) ([]*dom
 // IsEncrypted checks if a blob is stored encrypted.
 func (r *blobRepository) IsEncrypted(ctx context.Context, contentHash string) (bool, error) {
 	var isEncrypted bool
-	err := r.db.Pool.QueryRow(ctx, `SELECT is_encrypted FROM blobs WHERE content_hash = $1`, contentHash).Scan(&isEncrypted)
+	err := r.db.conn(ctx).QueryRow(ctx, `SELECT is_encrypted FROM blobs WHERE content_hash = $1`, contentHash).Scan(&isEncrypted)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return false, domain.ErrBlobNotFound
@@ -334,7 +436,7 @@ func (r *blobRepository) IsEncrypted(ctx context.Context, contentHash string) (b
 func (r *blobRepository) GetEncryptionStatus(ctx context.Context, contentHash string) (isEncrypted bool, encryptionIV string, err error) {
 	var iv *string
 
-	err = r.db.Pool.QueryRow(ctx,
+	err = r.db.conn(ctx).QueryRow(ctx,
 		`SELECT is_encrypted, encryption_iv FROM blobs WHERE content_hash = $1`,
 		contentHash,
 	).Scan(&isEncrypted, &iv)
@@ -354,14 +456,31 @@ func (r *blobRepository) GetEncryptionStatus(ctx context.Context, contentHash st
 // ListEncrypted returns encrypted blobs for key rotation.
 func (r *blobRepository) ListEncrypted(ctx context.Context, limit int, offset int) ([]*domain.Blob, error) {
 	query := `
-		SELECT content_hash, size, storage_path, ref_count, is_encrypted, encryption_iv, created_at, last_accessed
+		SELECT content_hash, size, storage_path, ref_count, is_encrypted, encryption_iv, encryption_key_version, created_at, last_accessed
 		FROM blobs
 		WHERE is_encrypted = true
 		ORDER BY created_at ASC
 		LIMIT $1 OFFSET $2
 	`
+	return r.listEncrypted(ctx, query, limit, offset)
+}
+
+// ListEncryptedBelowKeyVersion returns encrypted blobs whose SSE master key
+// version is below keyVersion and whose content hash sorts after after.
+func (r *blobRepository) ListEncryptedBelowKeyVersion(ctx context.Context, keyVersion int, after string, limit int) ([]*domain.Blob, error) {
+	query := `
+		SELECT content_hash, size, storage_path, ref_count, is_encrypted, encryption_iv, encryption_key_version, created_at, last_accessed
+		FROM blobs
+		WHERE is_encrypted = true AND encryption_key_version < $1 AND content_hash > $2
+		ORDER BY content_hash
+		LIMIT $3
+	`
+	return r.listEncrypted(ctx, query, keyVersion, after, limit)
+}
 
-	rows, err := r.db.Pool.Query(ctx, query, limit, offset)
+// listEncrypted runs a query for encrypted blobs and their key versions.
+func (r *blobRepository) listEncrypted(ctx context.Context, query string, args ...any) ([]*domain.Blob, error) {
+	rows, err := r.db.conn(ctx).Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list encrypted blobs: %w", err)
 	}
@@ -378,6 +497,7 @@ func (r *blobRepository) ListEncrypted(ctx context.Context, limit int, offset in
 			&blob.RefCount,
 			&blob.IsEncrypted,
 			&iv,
+			&blob.EncryptionKeyVersion,
 			&blob.CreatedAt,
 			&blob.LastAccessed,
 		)
@@ -404,7 +524,7 @@ func (r *blobRepository) ListAll(ctx context.Context, limit int) ([]*domain.Blob
 		LIMIT $1
 	`
 
-	rows, err := r.db.Pool.Query(ctx, query, limit)
+	rows, err := r.db.conn(ctx).Query(ctx, query, limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list blobs: %w", err)
 	}
@@ -442,3 +562,201 @@ func (r *blobRepository) ListAll(ctx context.Context, limit int) ([]*domain.Blob
 
 // Ensure blobRepository implements repository.BlobRepository
 var _ repository.BlobRepository = (*blobRepository)(nil)
+
+// ListCreatedBefore returns blobs created before the given time, ordered by content hash.
+func (r *blobRepository) ListCreatedBefore(ctx context.Context, before time.Time, afterHash string, limit int) ([]*domain.Blob, error) {
+	query := `
+		SELECT content_hash, size, storage_path, ref_count, is_encrypted, encryption_iv, created_at, last_accessed
+		FROM blobs
+		WHERE created_at < $1 AND content_hash > $2
+		ORDER BY content_hash ASC
+		LIMIT $3
+	`
+
+	rows, err := r.db.conn(ctx).Query(ctx, query, before, afterHash, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list blobs: %w", err)
+	}
+	defer rows.Close()
+
+	var blobs []*domain.Blob
+	for rows.Next() {
+		blob := &domain.Blob{}
+		err := rows.Scan(
+			&blob.ContentHash,
+			&blob.Size,
+			&blob.StoragePath,
+			&blob.RefCount,
+			&blob.IsEncrypted,
+			&blob.EncryptionIV,
+			&blob.CreatedAt,
+			&blob.LastAccessed,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan blob: %w", err)
+		}
+		blobs = append(blobs, blob)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating blobs: %w", err)
+	}
+
+	return blobs, nil
+}
+
+// ListUnverified returns referenced, unencrypted blobs never verified or
+// last verified before the given time, least recently verified first.
+func (r *blobRepository) ListUnverified(ctx context.Context, verifiedBefore time.Time, limit int) ([]*domain.Blob, error) {
+	query := `
+		SELECT content_hash, size, storage_path, ref_count, last_verified_at, corrupted_at, created_at, last_accessed
+		FROM blobs
+		WHERE ref_count > 0 AND gc_state = '' AND encryption_iv IS NULL
+			AND (last_verified_at IS NULL OR last_verified_at < $1)
+		ORDER BY last_verified_at ASC NULLS FIRST, content_hash ASC
+		LIMIT $2
+	`
+	return r.listVerification(ctx, query, verifiedBefore, limit)
+}
+
+// RecordVerification stores the outcome of re-hashing a blob.
+func (r *blobRepository) RecordVerification(ctx context.Context, contentHash string, verifiedAt time.Time, corrupt bool) error {
+	query := `
+		UPDATE blobs
+		SET last_verified_at = $2,
+			corrupted_at = CASE WHEN $3 THEN COALESCE(corrupted_at, $2) ELSE NULL END
+		WHERE content_hash = $1
+	`
+	_, err := r.db.conn(ctx).Exec(ctx, query, contentHash, verifiedAt, corrupt)
+	if err != nil {
+		return fmt.Errorf("failed to record blob verification: %w", err)
+	}
+	return nil
+}
+
+// ListCorrupt returns blobs whose content was found corrupt, oldest finding first.
+func (r *blobRepository) ListCorrupt(ctx context.Context, limit int) ([]*domain.Blob, error) {
+	query := `
+		SELECT content_hash, size, storage_path, ref_count, last_verified_at, corrupted_at, created_at, last_accessed
+		FROM blobs
+		WHERE corrupted_at IS NOT NULL
+		ORDER BY corrupted_at ASC, content_hash ASC
+		LIMIT $1
+	`
+	return r.listVerification(ctx, query, limit)
+}
+
+// CountCorrupt returns the number of blobs whose content was found corrupt.
+func (r *blobRepository) CountCorrupt(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.db.conn(ctx).QueryRow(ctx, `SELECT COUNT(*) FROM blobs WHERE corrupted_at IS NOT NULL`).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count corrupt blobs: %w", err)
+	}
+	return count, nil
+}
+
+// GetStats returns the number and total size of all blobs.
+func (r *blobRepository) GetStats(ctx context.Context) (*domain.BlobStats, error) {
+	query := `
+		SELECT COUNT(*),
+		       COUNT(*) FILTER (WHERE ref_count <= 0),
+		       COALESCE(SUM(size), 0),
+		       COALESCE(SUM(size * GREATEST(ref_count, 0)), 0)
+		FROM blobs
+	`
+	var stats domain.BlobStats
+	err := r.db.conn(ctx).QueryRow(ctx, query).Scan(&stats.Blobs, &stats.Orphans, &stats.StoredBytes, &stats.ReferencedBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blob stats: %w", err)
+	}
+	return &stats, nil
+}
+
+// CountRefsAfter returns the stored and counted references of blobs whose
+// content hash sorts after the given one, in content hash order.
+func (r *blobRepository) CountRefsAfter(ctx context.Context, after string, limit int) ([]*domain.BlobRefCount, error) {
+	// The references each blob should have: one per live object version,
+	// upload part, staged object and snapshot object
+	query := `
+		SELECT b.content_hash, b.size, b.gc_state, b.ref_count,
+		       (SELECT COUNT(*) FROM objects o WHERE o.content_hash = b.content_hash AND o.deleted_at IS NULL)
+		     + (SELECT COUNT(*) FROM upload_parts p WHERE p.content_hash = b.content_hash)
+		     + (SELECT COUNT(*) FROM staged_objects s WHERE s.content_hash = b.content_hash)
+		     + (SELECT COUNT(*) FROM bucket_snapshot_objects so WHERE so.content_hash = b.content_hash)
+		FROM blobs b
+		WHERE b.content_hash > $1
+		ORDER BY b.content_hash ASC
+		LIMIT $2
+	`
+
+	rows, err := r.db.conn(ctx).Query(ctx, query, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count blob references: %w", err)
+	}
+	defer rows.Close()
+
+	var counts []*domain.BlobRefCount
+	for rows.Next() {
+		count := &domain.BlobRefCount{}
+		if err := rows.Scan(&count.ContentHash, &count.Size, &count.GCState, &count.Stored, &count.Actual); err != nil {
+			return nil, fmt.Errorf("failed to scan blob references: %w", err)
+		}
+		counts = append(counts, count)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating blob references: %w", err)
+	}
+	return counts, nil
+}
+
+// SetRefCount replaces the reference count of a blob GC is not collecting,
+// if it still equals expected.
+func (r *blobRepository) SetRefCount(ctx context.Context, contentHash string, expected, refCount int32) (bool, error) {
+	query := `
+		UPDATE blobs
+		SET ref_count = $3
+		WHERE content_hash = $1 AND ref_count = $2 AND gc_state = ''
+	`
+
+	result, err := r.db.conn(ctx).Exec(ctx, query, contentHash, expected, refCount)
+	if err != nil {
+		return false, fmt.Errorf("failed to set blob ref_count: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+// listVerification runs a query selecting blobs with their verification state.
+func (r *blobRepository) listVerification(ctx context.Context, query string, args ...interface{}) ([]*domain.Blob, error) {
+	rows, err := r.db.conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list blobs: %w", err)
+	}
+	defer rows.Close()
+
+	var blobs []*domain.Blob
+	for rows.Next() {
+		blob := &domain.Blob{}
+		err := rows.Scan(
+			&blob.ContentHash,
+			&blob.Size,
+			&blob.StoragePath,
+			&blob.RefCount,
+			&blob.LastVerifiedAt,
+			&blob.CorruptedAt,
+			&blob.CreatedAt,
+			&blob.LastAccessed,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan blob: %w", err)
+		}
+		blobs = append(blobs, blob)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating blobs: %w", err)
+	}
+
+	return blobs, nil
+}
@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 
@@ -24,19 +25,31 @@ func NewBucketRepository(db *DB) repository.BucketRepository {
 // Create creates a new bucket.
 func (r *bucketRepository) Create(ctx context.Context, bucket *domain.Bucket) error {
 	query := `
-		INSERT INTO buckets (owner_id, name, region, versioning, acl, object_lock, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO buckets (owner_id, tenant_id, name, region, versioning, acl, object_lock, created_at, residency, quarantine, object_defaults, mfa_delete, read_only, quota_bytes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 		RETURNING id
 	`
 
-	err := r.db.Pool.QueryRow(ctx, query,
+	objectDefaults, err := encodeObjectDefaults(bucket.ObjectDefaults)
+	if err != nil {
+		return err
+	}
+
+	err = r.db.conn(ctx).QueryRow(ctx, query,
 		bucket.OwnerID,
+		bucket.TenantID,
 		bucket.Name,
 		bucket.Region,
 		bucket.Versioning,
 		bucket.ACL,
 		bucket.ObjectLock,
 		bucket.CreatedAt,
+		bucket.Residency,
+		bucket.Quarantine,
+		objectDefaults,
+		bucket.MFADelete,
+		bucket.ReadOnly,
+		bucket.QuotaBytes,
 	).Scan(&bucket.ID)
 
 	if err != nil {
@@ -52,21 +65,29 @@ func (r *bucketRepository) Create(ctx context.Context, bucket *domain.Bucket) er
 // GetByID retrieves a bucket by ID.
 func (r *bucketRepository) GetByID(ctx context.Context, id int64) (*domain.Bucket, error) {
 	query := `
-		SELECT id, owner_id, name, region, versioning, acl, object_lock, created_at
+		SELECT id, owner_id, tenant_id, name, region, versioning, acl, object_lock, created_at, residency, quarantine, object_defaults, mfa_delete, read_only, quota_bytes
 		FROM buckets
 		WHERE id = $1
 	`
 
 	bucket := &domain.Bucket{}
-	err := r.db.Pool.QueryRow(ctx, query, id).Scan(
+	var objectDefaults []byte
+	err := r.db.conn(ctx).QueryRow(ctx, query, id).Scan(
 		&bucket.ID,
 		&bucket.OwnerID,
+		&bucket.TenantID,
 		&bucket.Name,
 		&bucket.Region,
 		&bucket.Versioning,
 		&bucket.ACL,
 		&bucket.ObjectLock,
 		&bucket.CreatedAt,
+		&bucket.Residency,
+		&bucket.Quarantine,
+		&objectDefaults,
+		&bucket.MFADelete,
+		&bucket.ReadOnly,
+		&bucket.QuotaBytes,
 	)
 
 	if err != nil {
@@ -76,27 +97,38 @@ func (r *bucketRepository) GetByID(ctx context.Context, id int64) (*domain.Bucke
 		return nil, fmt.Errorf("failed to get bucket by ID: %w", err)
 	}
 
+	if bucket.ObjectDefaults, err = decodeObjectDefaults(objectDefaults); err != nil {
+		return nil, err
+	}
 	return bucket, nil
 }
 
 // GetByName retrieves a bucket by name.
 func (r *bucketRepository) GetByName(ctx context.Context, name string) (*domain.Bucket, error) {
 	query := `
-		SELECT id, owner_id, name, region, versioning, acl, object_lock, created_at
+		SELECT id, owner_id, tenant_id, name, region, versioning, acl, object_lock, created_at, residency, quarantine, object_defaults, mfa_delete, read_only, quota_bytes
 		FROM buckets
 		WHERE name = $1
 	`
 
 	bucket := &domain.Bucket{}
-	err := r.db.Pool.QueryRow(ctx, query, name).Scan(
+	var objectDefaults []byte
+	err := r.db.conn(ctx).QueryRow(ctx, query, name).Scan(
 		&bucket.ID,
 		&bucket.OwnerID,
+		&bucket.TenantID,
 		&bucket.Name,
 		&bucket.Region,
 		&bucket.Versioning,
 		&bucket.ACL,
 		&bucket.ObjectLock,
 		&bucket.CreatedAt,
+		&bucket.Residency,
+		&bucket.Quarantine,
+		&objectDefaults,
+		&bucket.MFADelete,
+		&bucket.ReadOnly,
+		&bucket.QuotaBytes,
 	)
 
 	if err != nil {
@@ -106,6 +138,9 @@ func (r *bucketRepository) GetByName(ctx context.Context, name string) (*domain.
 		return nil, fmt.Errorf("failed to get bucket by name: %w", err)
 	}
 
+	if bucket.ObjectDefaults, err = decodeObjectDefaults(objectDefaults); err != nil {
+		return nil, err
+	}
 	return bucket, nil
 }
 
@@ -117,42 +152,98 @@ func (r *bucketRepository) List(ctx context.Context, userID int64) ([]*domain.Bu
 
 	if userID > 0 {
 		query = `
-			SELECT id, owner_id, name, region, versioning, acl, object_lock, created_at
+			SELECT id, owner_id, tenant_id, name, region, versioning, acl, object_lock, created_at, residency, quarantine, object_defaults, mfa_delete, read_only, quota_bytes
 			FROM buckets
 			WHERE owner_id = $1
 			ORDER BY name ASC
 		`
-		rows, err = r.db.Pool.Query(ctx, query, userID)
+		rows, err = r.db.listConn(ctx).Query(ctx, query, userID)
 	} else {
 		query = `
-			SELECT id, owner_id, name, region, versioning, acl, object_lock, created_at
+			SELECT id, owner_id, tenant_id, name, region, versioning, acl, object_lock, created_at, residency, quarantine, object_defaults, mfa_delete, read_only, quota_bytes
 			FROM buckets
 			ORDER BY name ASC
 		`
-		rows, err = r.db.Pool.Query(ctx, query)
+		rows, err = r.db.listConn(ctx).Query(ctx, query)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to list buckets: %w", err)
 	}
+	defer rows.Close()
+
+	return scanBuckets(rows)
+}
+
+// ListPage returns a page of the buckets of a user (or of all users if
+// userID is 0) in name order.
+func (r *bucketRepository) ListPage(ctx context.Context, userID int64, opts repository.BucketListOptions) (*repository.BucketListResult, error) {
+	// One more than requested tells whether the page is truncated; a NULL
+	// limit is unlimited
+	var limit *int
+	if opts.MaxBuckets > 0 {
+		n := opts.MaxBuckets + 1
+		limit = &n
+	}
+
+	query := `
+		SELECT id, owner_id, tenant_id, name, region, versioning, acl, object_lock, created_at, residency, quarantine, object_defaults, mfa_delete, read_only, quota_bytes
+		FROM buckets
+		WHERE ($1::bigint = 0 OR owner_id = $1)
+			AND ($2 = '' OR name LIKE $2 || '%')
+			AND name > $3
+		ORDER BY name ASC
+		LIMIT $4
+	`
 
+	rows, err := r.db.listConn(ctx).Query(ctx, query, userID, opts.Prefix, opts.StartAfter, limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list buckets: %w", err)
 	}
 	defer rows.Close()
 
+	buckets, err := scanBuckets(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &repository.BucketListResult{Buckets: buckets}
+	if opts.MaxBuckets > 0 && len(buckets) > opts.MaxBuckets {
+		result.IsTruncated = true
+		result.Buckets = buckets[:opts.MaxBuckets]
+	}
+	return result, nil
+}
+
+// scanBuckets scans the rows of a bucket listing.
+func scanBuckets(rows pgx.Rows) ([]*domain.Bucket, error) {
 	var buckets []*domain.Bucket
 	for rows.Next() {
 		bucket := &domain.Bucket{}
+		var objectDefaults []byte
 		err := rows.Scan(
 			&bucket.ID,
 			&bucket.OwnerID,
+			&bucket.TenantID,
 			&bucket.Name,
 			&bucket.Region,
 			&bucket.Versioning,
 			&bucket.ACL,
 			&bucket.ObjectLock,
 			&bucket.CreatedAt,
+			&bucket.Residency,
+			&bucket.Quarantine,
+			&objectDefaults,
+			&bucket.MFADelete,
+			&bucket.ReadOnly,
+			&bucket.QuotaBytes,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan bucket: %w", err)
 		}
+		if bucket.ObjectDefaults, err = decodeObjectDefaults(objectDefaults); err != nil {
+			return nil, err
+		}
 		buckets = append(buckets, bucket)
 	}
 
@@ -167,14 +258,25 @@ func (r *bucketRepository) List(ctx context.Context, userID int64) ([]*domain.Bu
 func (r *bucketRepository) Update(ctx context.Context, bucket *domain.Bucket) error {
 	query := `
 		UPDATE buckets
-		SET versioning = $2, object_lock = $3
+		SET versioning = $2, object_lock = $3, residency = $4, quarantine = $5, object_defaults = $6, mfa_delete = $7, read_only = $8, quota_bytes = $9
 		WHERE id = $1
 	`
 
-	result, err := r.db.Pool.Exec(ctx, query,
+	objectDefaults, err := encodeObjectDefaults(bucket.ObjectDefaults)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.conn(ctx).Exec(ctx, query,
 		bucket.ID,
 		bucket.Versioning,
 		bucket.ObjectLock,
+		bucket.Residency,
+		bucket.Quarantine,
+		objectDefaults,
+		bucket.MFADelete,
+		bucket.ReadOnly,
+		bucket.QuotaBytes,
 	)
 
 	if err != nil {
@@ -192,7 +294,7 @@ func (r *bucketRepository) Update(ctx context.Context, bucket *domain.Bucket) er
 func (r *bucketRepository) UpdateVersioning(ctx context.Context, id int64, status domain.VersioningStatus) error {
 	query := `UPDATE buckets SET versioning = $2 WHERE id = $1`
 
-	result, err := r.db.Pool.Exec(ctx, query, id, status)
+	result, err := r.db.conn(ctx).Exec(ctx, query, id, status)
 	if err != nil {
 		return fmt.Errorf("failed to update versioning status: %w", err)
 	}
@@ -208,7 +310,7 @@ func (r *bucketRepository) UpdateVersioning(ctx context.Context, id int64, statu
 func (r *bucketRepository) UpdateACL(ctx context.Context, id int64, acl domain.BucketACL) error {
 	query := `UPDATE buckets SET acl = $2 WHERE id = $1`
 
-	result, err := r.db.Pool.Exec(ctx, query, id, acl)
+	result, err := r.db.conn(ctx).Exec(ctx, query, id, acl)
 	if err != nil {
 		return fmt.Errorf("failed to update ACL: %w", err)
 	}
@@ -220,11 +322,60 @@ func (r *bucketRepository) UpdateACL(ctx context.Context, id int64, acl domain.B
 	return nil
 }
 
+// GetTags returns the tag set of a bucket.
+func (r *bucketRepository) GetTags(ctx context.Context, id int64) ([]domain.Tag, error) {
+	var data []byte
+	err := r.db.conn(ctx).QueryRow(ctx, `SELECT tags FROM buckets WHERE id = $1`, id).Scan(&data)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrBucketNotFound
+		}
+		return nil, fmt.Errorf("failed to get bucket tags: %w", err)
+	}
+	if data == nil {
+		return nil, domain.ErrNoSuchTagSet
+	}
+
+	var tags []domain.Tag
+	if err := json.Unmarshal(data, &tags); err != nil {
+		return nil, fmt.Errorf("failed to decode bucket tags: %w", err)
+	}
+	return tags, nil
+}
+
+// PutTags replaces the tag set of a bucket.
+func (r *bucketRepository) PutTags(ctx context.Context, id int64, tags []domain.Tag) error {
+	data, err := json.Marshal(tags)
+	if err != nil {
+		return fmt.Errorf("failed to encode bucket tags: %w", err)
+	}
+	return r.setTags(ctx, id, data)
+}
+
+// DeleteTags removes the tag set of a bucket.
+func (r *bucketRepository) DeleteTags(ctx context.Context, id int64) error {
+	return r.setTags(ctx, id, nil)
+}
+
+// setTags sets the tags column of a bucket; nil clears it.
+func (r *bucketRepository) setTags(ctx context.Context, id int64, tags []byte) error {
+	result, err := r.db.conn(ctx).Exec(ctx, `UPDATE buckets SET tags = $2 WHERE id = $1`, id, tags)
+	if err != nil {
+		return fmt.Errorf("failed to update bucket tags: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrBucketNotFound
+	}
+
+	return nil
+}
+
 // Delete deletes a bucket by ID.
 func (r *bucketRepository) Delete(ctx context.Context, id int64) error {
 	query := `DELETE FROM buckets WHERE id = $1`
 
-	result, err := r.db.Pool.Exec(ctx, query, id)
+	result, err := r.db.conn(ctx).Exec(ctx, query, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete bucket: %w", err)
 	}
@@ -240,7 +391,7 @@ func (r *bucketRepository) Delete(ctx context.Context, id int64) error {
 func (r *bucketRepository) DeleteByName(ctx context.Context, name string) error {
 	query := `DELETE FROM buckets WHERE name = $1`
 
-	result, err := r.db.Pool.Exec(ctx, query, name)
+	result, err := r.db.conn(ctx).Exec(ctx, query, name)
 	if err != nil {
 		return fmt.Errorf("failed to delete bucket: %w", err)
 	}
@@ -255,28 +406,44 @@ func (r *bucketRepository) DeleteByName(ctx context.Context, name string) error
 // ExistsByName checks if a bucket with the given name exists.
 func (r *bucketRepository) ExistsByName(ctx context.Context, name string) (bool, error) {
 	var exists bool
-	err := r.db.Pool.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM buckets WHERE name = $1)`, name).Scan(&exists)
+	err := r.db.conn(ctx).QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM buckets WHERE name = $1)`, name).Scan(&exists)
 	if err != nil {
 		return false, fmt.Errorf("failed to check bucket existence: %w", err)
 	}
 	return exists, nil
 }
 
-// IsEmpty checks if a bucket contains any objects.
+// IsEmpty checks if a bucket contains any objects, staged uploads or snapshots.
 func (r *bucketRepository) IsEmpty(ctx context.Context, id int64) (bool, error) {
-	var count int64
-	err := r.db.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM objects WHERE bucket_id = $1 LIMIT 1`, id).Scan(&count)
+	var notEmpty bool
+	err := r.db.conn(ctx).QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM objects WHERE bucket_id = $1 AND deleted_at IS NULL) OR EXISTS (SELECT 1 FROM staged_objects WHERE bucket_id = $1)
+			OR EXISTS (SELECT 1 FROM bucket_snapshots WHERE bucket_id = $1)
+	`, id).Scan(&notEmpty)
 	if err != nil {
 		return false, fmt.Errorf("failed to check if bucket is empty: %w", err)
 	}
-	return count == 0, nil
+	return !notEmpty, nil
+}
+
+// StorageBytes returns the total size of the object versions in a bucket.
+func (r *bucketRepository) StorageBytes(ctx context.Context, id int64) (int64, error) {
+	var size int64
+	err := r.db.conn(ctx).QueryRow(ctx, `
+		SELECT COALESCE(SUM(size), 0)::BIGINT FROM objects
+		WHERE bucket_id = $1 AND NOT is_delete_marker AND deleted_at IS NULL
+	`, id).Scan(&size)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get bucket storage: %w", err)
+	}
+	return size, nil
 }
 
 // GetACLByName retrieves only the ACL for a bucket by name.
 // This is optimized for anonymous access checks.
 func (r *bucketRepository) GetACLByName(ctx context.Context, name string) (domain.BucketACL, error) {
 	var acl domain.BucketACL
-	err := r.db.Pool.QueryRow(ctx, `SELECT acl FROM buckets WHERE name = $1`, name).Scan(&acl)
+	err := r.db.conn(ctx).QueryRow(ctx, `SELECT acl FROM buckets WHERE name = $1`, name).Scan(&acl)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return "", domain.ErrBucketNotFound
@@ -288,3 +455,28 @@ func (r *bucketRepository) GetACLByName(ctx context.Context, name string) (domai
 
 // Ensure bucketRepository implements repository.BucketRepository
 var _ repository.BucketRepository = (*bucketRepository)(nil)
+
+// encodeObjectDefaults returns the object_defaults column of a bucket; nil
+// clears it.
+func encodeObjectDefaults(defaults *domain.ObjectDefaults) ([]byte, error) {
+	if defaults.IsEmpty() {
+		return nil, nil
+	}
+	data, err := json.Marshal(defaults)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode bucket object defaults: %w", err)
+	}
+	return data, nil
+}
+
+// decodeObjectDefaults decodes the object_defaults column of a bucket.
+func decodeObjectDefaults(data []byte) (*domain.ObjectDefaults, error) {
+	if data == nil {
+		return nil, nil
+	}
+	defaults := &domain.ObjectDefaults{}
+	if err := json.Unmarshal(data, defaults); err != nil {
+		return nil, fmt.Errorf("failed to decode bucket object defaults: %w", err)
+	}
+	return defaults, nil
+}
@@ -0,0 +1,288 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// bucketSnapshotColumns lists the bucket_snapshots columns read by scanBucketSnapshot.
+const bucketSnapshotColumns = `id, bucket_id, name, object_count, total_size, created_at, expires_at`
+
+// bucketSnapshotRepository implements repository.BucketSnapshotRepository.
+type bucketSnapshotRepository struct {
+	db *DB
+}
+
+// NewBucketSnapshotRepository creates a new PostgreSQL bucket snapshot repository.
+func NewBucketSnapshotRepository(db *DB) repository.BucketSnapshotRepository {
+	return &bucketSnapshotRepository{db: db}
+}
+
+// Create records a snapshot of the latest versions in a bucket.
+func (r *bucketSnapshotRepository) Create(ctx context.Context, snapshot *domain.BucketSnapshot) error {
+	conn := r.db.conn(ctx)
+
+	var id int64
+	err := conn.QueryRow(ctx,
+		`INSERT INTO bucket_snapshots (bucket_id, name, created_at, expires_at) VALUES ($1, $2, $3, $4) RETURNING id`,
+		snapshot.BucketID, snapshot.Name, snapshot.CreatedAt, snapshot.ExpiresAt,
+	).Scan(&id)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return domain.ErrSnapshotAlreadyExists
+		}
+		return fmt.Errorf("failed to create snapshot: %w", err)
+	}
+
+	_, err = conn.Exec(ctx, `
+		INSERT INTO bucket_snapshot_objects (snapshot_id, key, version_id, content_hash, size, content_type,
+			etag, storage_class, metadata, part_sizes, sequence, created_at)
+		SELECT $1, key, version_id, content_hash, size, content_type,
+			etag, storage_class, metadata, part_sizes, sequence, created_at
+		FROM objects
+		WHERE bucket_id = $2 AND is_latest = TRUE AND is_delete_marker = FALSE AND deleted_at IS NULL
+	`, id, snapshot.BucketID)
+	if err != nil {
+		return fmt.Errorf("failed to copy snapshot objects: %w", err)
+	}
+
+	// A blob named by several keys gets one reference per key
+	_, err = conn.Exec(ctx, `
+		UPDATE blobs
+		SET ref_count = blobs.ref_count + refs.count, gc_state = ''
+		FROM (
+			SELECT content_hash, COUNT(*) AS count
+			FROM bucket_snapshot_objects
+			WHERE snapshot_id = $1 AND content_hash IS NOT NULL
+			GROUP BY content_hash
+		) refs
+		WHERE blobs.content_hash = refs.content_hash
+	`, id)
+	if err != nil {
+		return fmt.Errorf("failed to increment snapshot blob refs: %w", err)
+	}
+
+	err = conn.QueryRow(ctx, `
+		UPDATE bucket_snapshots
+		SET object_count = totals.count, total_size = totals.size
+		FROM (
+			SELECT COUNT(*) AS count, COALESCE(SUM(size), 0) AS size
+			FROM bucket_snapshot_objects
+			WHERE snapshot_id = $1
+		) totals
+		WHERE id = $1
+		RETURNING object_count, total_size
+	`, id).Scan(&snapshot.ObjectCount, &snapshot.TotalSize)
+	if err != nil {
+		return fmt.Errorf("failed to update snapshot: %w", err)
+	}
+
+	snapshot.ID = id
+	return nil
+}
+
+// GetByName returns a snapshot of a bucket.
+func (r *bucketSnapshotRepository) GetByName(ctx context.Context, bucketID int64, name string) (*domain.BucketSnapshot, error) {
+	query := `SELECT ` + bucketSnapshotColumns + ` FROM bucket_snapshots WHERE bucket_id = $1 AND name = $2`
+
+	snapshot, err := scanBucketSnapshot(r.db.conn(ctx).QueryRow(ctx, query, bucketID, name))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrSnapshotNotFound
+		}
+		return nil, fmt.Errorf("failed to get snapshot: %w", err)
+	}
+
+	return snapshot, nil
+}
+
+// ListByBucket returns the snapshots of a bucket.
+func (r *bucketSnapshotRepository) ListByBucket(ctx context.Context, bucketID int64) ([]*domain.BucketSnapshot, error) {
+	query := `SELECT ` + bucketSnapshotColumns + ` FROM bucket_snapshots WHERE bucket_id = $1 ORDER BY id ASC`
+	return r.list(ctx, query, bucketID)
+}
+
+// Delete removes a snapshot and releases its blob references.
+func (r *bucketSnapshotRepository) Delete(ctx context.Context, id int64) error {
+	conn := r.db.conn(ctx)
+
+	_, err := conn.Exec(ctx, `
+		UPDATE blobs
+		SET ref_count = blobs.ref_count - refs.count
+		FROM (
+			SELECT content_hash, COUNT(*) AS count
+			FROM bucket_snapshot_objects
+			WHERE snapshot_id = $1 AND content_hash IS NOT NULL
+			GROUP BY content_hash
+		) refs
+		WHERE blobs.content_hash = refs.content_hash
+	`, id)
+	if err != nil {
+		return fmt.Errorf("failed to release snapshot blob refs: %w", err)
+	}
+
+	if _, err := conn.Exec(ctx, `DELETE FROM bucket_snapshot_objects WHERE snapshot_id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete snapshot objects: %w", err)
+	}
+
+	result, err := conn.Exec(ctx, `DELETE FROM bucket_snapshots WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete snapshot: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrSnapshotNotFound
+	}
+
+	return nil
+}
+
+// GetObject returns the object version a snapshot holds for a key.
+func (r *bucketSnapshotRepository) GetObject(ctx context.Context, snapshotID int64, key string) (*domain.Object, error) {
+	query := `
+		SELECT s.bucket_id, o.key, o.version_id, o.content_hash, o.size, o.content_type, COALESCE(o.etag, ''),
+			o.storage_class, o.metadata, o.part_sizes, o.sequence, o.created_at
+		FROM bucket_snapshot_objects o
+		JOIN bucket_snapshots s ON s.id = o.snapshot_id
+		WHERE o.snapshot_id = $1 AND o.key = $2
+	`
+
+	obj := &domain.Object{}
+	err := r.db.conn(ctx).QueryRow(ctx, query, snapshotID, key).Scan(
+		&obj.BucketID,
+		&obj.Key,
+		&obj.VersionID,
+		&obj.ContentHash,
+		&obj.Size,
+		&obj.ContentType,
+		&obj.ETag,
+		&obj.StorageClass,
+		&obj.Metadata,
+		&obj.PartSizes,
+		&obj.Sequence,
+		&obj.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrObjectNotFound
+		}
+		return nil, fmt.Errorf("failed to get snapshot object: %w", err)
+	}
+
+	return obj, nil
+}
+
+// ListObjects returns the objects of a snapshot with pagination and optional prefix filtering.
+func (r *bucketSnapshotRepository) ListObjects(ctx context.Context, snapshotID int64, opts repository.ObjectListOptions) (*repository.ObjectListResult, error) {
+	maxKeys := opts.MaxKeys
+	if maxKeys <= 0 {
+		maxKeys = 1000
+	}
+
+	query := `
+		SELECT key, version_id::text, size, COALESCE(etag, ''), created_at, storage_class
+		FROM bucket_snapshot_objects
+		WHERE snapshot_id = $1
+			AND ($2 = '' OR key LIKE $2 || '%')
+			AND ($3 = '' OR key > $3)
+		ORDER BY key ASC
+		LIMIT $4
+	`
+
+	rows, err := r.db.listConn(ctx).Query(ctx, query, snapshotID, opts.Prefix, opts.StartAfter, maxKeys+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshot objects: %w", err)
+	}
+	defer rows.Close()
+
+	var objects []*domain.ObjectInfo
+	for rows.Next() {
+		obj := &domain.ObjectInfo{}
+		if err := rows.Scan(&obj.Key, &obj.VersionID, &obj.Size, &obj.ETag, &obj.LastModified, &obj.StorageClass); err != nil {
+			return nil, fmt.Errorf("failed to scan snapshot object: %w", err)
+		}
+		objects = append(objects, obj)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating snapshot objects: %w", err)
+	}
+
+	result := &repository.ObjectListResult{
+		KeyCount: len(objects),
+	}
+
+	if len(objects) > maxKeys {
+		result.IsTruncated = true
+		result.NextContinuationToken = objects[maxKeys-1].Key
+		result.Objects = objects[:maxKeys]
+	} else {
+		result.Objects = objects
+	}
+
+	return result, nil
+}
+
+// ListExpired returns up to limit snapshots that expired before the cutoff.
+func (r *bucketSnapshotRepository) ListExpired(ctx context.Context, before time.Time, limit int) ([]*domain.BucketSnapshot, error) {
+	query := `
+		SELECT ` + bucketSnapshotColumns + `
+		FROM bucket_snapshots
+		WHERE expires_at < $1
+		ORDER BY expires_at ASC, id ASC
+		LIMIT $2
+	`
+	return r.list(ctx, query, before, limit)
+}
+
+// list runs a bucket_snapshots query and scans every row.
+func (r *bucketSnapshotRepository) list(ctx context.Context, query string, args ...interface{}) ([]*domain.BucketSnapshot, error) {
+	rows, err := r.db.conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	var snapshots []*domain.BucketSnapshot
+	for rows.Next() {
+		snapshot, err := scanBucketSnapshot(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan snapshot: %w", err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating snapshots: %w", err)
+	}
+
+	return snapshots, nil
+}
+
+// scanBucketSnapshot scans the bucketSnapshotColumns of one row.
+func scanBucketSnapshot(row pgx.Row) (*domain.BucketSnapshot, error) {
+	snapshot := &domain.BucketSnapshot{}
+	err := row.Scan(
+		&snapshot.ID,
+		&snapshot.BucketID,
+		&snapshot.Name,
+		&snapshot.ObjectCount,
+		&snapshot.TotalSize,
+		&snapshot.CreatedAt,
+		&snapshot.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// Ensure bucketSnapshotRepository implements repository.BucketSnapshotRepository
+var _ repository.BucketSnapshotRepository = (*bucketSnapshotRepository)(nil)
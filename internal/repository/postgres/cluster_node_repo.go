@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// clusterNodeRepository implements repository.ClusterNodeRepository.
+type clusterNodeRepository struct {
+	db *DB
+}
+
+// NewClusterNodeRepository creates a new PostgreSQL cluster node repository.
+func NewClusterNodeRepository(db *DB) repository.ClusterNodeRepository {
+	return &clusterNodeRepository{db: db}
+}
+
+// Heartbeat creates or updates a node's row. The heartbeat time comes from
+// the database clock, so clock skew between nodes does not matter.
+func (r *clusterNodeRepository) Heartbeat(ctx context.Context, node *domain.ClusterNode) error {
+	query := `
+		INSERT INTO cluster_nodes (id, address, started_at, last_heartbeat)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (id) DO UPDATE SET
+			address = EXCLUDED.address,
+			started_at = EXCLUDED.started_at,
+			last_heartbeat = EXCLUDED.last_heartbeat
+		RETURNING last_heartbeat
+	`
+
+	err := r.db.conn(ctx).QueryRow(ctx, query, node.ID, node.Address, node.StartedAt).Scan(&node.LastHeartbeat)
+	if err != nil {
+		return fmt.Errorf("failed to record cluster heartbeat: %w", err)
+	}
+	return nil
+}
+
+// ListLive returns the nodes that sent a heartbeat within timeout, by ID.
+func (r *clusterNodeRepository) ListLive(ctx context.Context, timeout time.Duration) ([]*domain.ClusterNode, error) {
+	query := `
+		SELECT id, address, started_at, last_heartbeat
+		FROM cluster_nodes
+		WHERE last_heartbeat >= NOW() - make_interval(secs => $1)
+		ORDER BY id
+	`
+
+	rows, err := r.db.conn(ctx).Query(ctx, query, timeout.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cluster nodes: %w", err)
+	}
+	defer rows.Close()
+
+	var nodes []*domain.ClusterNode
+	for rows.Next() {
+		node := &domain.ClusterNode{}
+		if err := rows.Scan(&node.ID, &node.Address, &node.StartedAt, &node.LastHeartbeat); err != nil {
+			return nil, fmt.Errorf("failed to scan cluster node: %w", err)
+		}
+		nodes = append(nodes, node)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating cluster nodes: %w", err)
+	}
+
+	return nodes, nil
+}
+
+// Delete removes a node.
+func (r *clusterNodeRepository) Delete(ctx context.Context, id string) error {
+	if _, err := r.db.conn(ctx).Exec(ctx, `DELETE FROM cluster_nodes WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete cluster node: %w", err)
+	}
+	return nil
+}
@@ -11,19 +11,23 @@ import (
 	"github.com/rs/zerolog"
 
 	"github.com/prn-tf/alexander-storage/internal/config"
+	"github.com/prn-tf/alexander-storage/internal/pkg/redact"
+	"github.com/prn-tf/alexander-storage/internal/pkg/telemetry"
+	"github.com/prn-tf/alexander-storage/internal/repository"
 )
 
 // DB wraps a pgx connection pool with additional functionality.
 type DB struct {
-	Pool   *pgxpool.Pool
-	logger zerolog.Logger
+	Pool    *pgxpool.Pool
+	replica *replica // nil without a read replica
+	logger  zerolog.Logger
 }
 
 // NewDB creates a new database connection pool.
 func NewDB(ctx context.Context, cfg config.DatabaseConfig, logger zerolog.Logger) (*DB, error) {
 	poolConfig, err := pgxpool.ParseConfig(cfg.DSN())
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse database config: %w", err)
+		return nil, fmt.Errorf("failed to parse database config: %w", redact.Error(err))
 	}
 
 	// Configure pool settings
@@ -35,20 +39,19 @@ func NewDB(ctx context.Context, cfg config.DatabaseConfig, logger zerolog.Logger
 	// Configure connection settings
 	poolConfig.ConnConfig.ConnectTimeout = 10 * time.Second
 
-	// Add query tracer for debugging (optional)
-	if logger.GetLevel() <= zerolog.DebugLevel {
-		poolConfig.ConnConfig.Tracer = &queryTracer{logger: logger}
-	}
+	// Add query tracer for spans, debug and slow query logging
+	poolConfig.ConnConfig.Tracer = newQueryTracer(logger, cfg.SlowQueryThreshold)
+	configureStatementCache(poolConfig.ConnConfig, cfg.StatementCacheSize)
 
 	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create connection pool: %w", err)
+		return nil, fmt.Errorf("failed to create connection pool: %w", redact.Error(err))
 	}
 
 	// Verify connection
 	if err := pool.Ping(ctx); err != nil {
 		pool.Close()
-		return nil, fmt.Errorf("failed to ping database: %w", err)
+		return nil, fmt.Errorf("failed to ping database: %w", redact.Error(err))
 	}
 
 	logger.Info().
@@ -58,14 +61,47 @@ func NewDB(ctx context.Context, cfg config.DatabaseConfig, logger zerolog.Logger
 		Int("max_conns", cfg.MaxOpenConns).
 		Msg("connected to PostgreSQL")
 
-	return &DB{
+	db := &DB{
 		Pool:   pool,
 		logger: logger,
-	}, nil
+	}
+
+	if cfg.Replica.Enabled {
+		db.replica, err = newReplica(ctx, cfg, logger)
+		if err != nil {
+			pool.Close()
+			return nil, err
+		}
+		logger.Info().
+			Str("host", cfg.Replica.Host).
+			Int("port", cfg.Replica.Port).
+			Dur("max_lag", cfg.Replica.MaxLag).
+			Msg("listing from PostgreSQL read replica")
+	}
+
+	return db, nil
+}
+
+// configureStatementCache makes each connection prepare a statement the
+// first time it runs it and reuse the prepared statement, and its plan, for
+// up to size distinct statements. With size 0 statements are never prepared
+// by name, which connection poolers in transaction mode (PgBouncer) need.
+// The default size of 512 is what pgx does unconfigured.
+func configureStatementCache(connConfig *pgx.ConnConfig, size int) {
+	if size <= 0 {
+		connConfig.DefaultQueryExecMode = pgx.QueryExecModeDescribeExec
+		connConfig.StatementCacheCapacity = 0
+		return
+	}
+	connConfig.DefaultQueryExecMode = pgx.QueryExecModeCacheStatement
+	connConfig.StatementCacheCapacity = size
 }
 
 // Close closes the database connection pool.
 func (db *DB) Close() error {
+	if db.replica != nil {
+		db.replica.close()
+	}
 	db.Pool.Close()
 	db.logger.Info().Msg("database connection pool closed")
 	return nil
@@ -94,7 +130,12 @@ func (db *DB) BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error) {
 // WithTx executes a function within a transaction.
 // If the function returns an error, the transaction is rolled back.
 // Otherwise, the transaction is committed.
+// If ctx already carries a transaction (see TxManager), fn runs in it.
 func (db *DB) WithTx(ctx context.Context, opts pgx.TxOptions, fn func(tx pgx.Tx) error) error {
+	if tx, ok := txFromContext(ctx); ok {
+		return fn(tx)
+	}
+
 	tx, err := db.Pool.BeginTx(ctx, opts)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
@@ -121,9 +162,21 @@ func (db *DB) WithTx(ctx context.Context, opts pgx.TxOptions, fn func(tx pgx.Tx)
 	return nil
 }
 
-// queryTracer implements pgx.QueryTracer for debug logging.
+// queryTracer implements pgx.QueryTracer. It records a span for every query,
+// logs queries that take at least slowQuery and, at debug level, logs every
+// query.
 type queryTracer struct {
-	logger zerolog.Logger
+	logger    zerolog.Logger
+	debug     bool
+	slowQuery time.Duration // 0 disables slow query logging
+}
+
+func newQueryTracer(logger zerolog.Logger, slowQuery time.Duration) *queryTracer {
+	return &queryTracer{
+		logger:    logger,
+		debug:     logger.GetLevel() <= zerolog.DebugLevel,
+		slowQuery: slowQuery,
+	}
 }
 
 type traceQueryCtxKey struct{}
@@ -132,13 +185,22 @@ type traceQueryData struct {
 	sql       string
 	args      []any
 	startTime time.Time
+	span      *telemetry.Span
 }
 
 func (t *queryTracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	ctx, span := telemetry.StartKind(ctx, "db.query", telemetry.KindClient)
+	if span == nil && !t.debug && t.slowQuery == 0 {
+		return ctx
+	}
+	span.SetAttribute("db.system", "postgresql")
+	span.SetAttribute("db.statement", data.SQL)
+
 	return context.WithValue(ctx, traceQueryCtxKey{}, &traceQueryData{
 		sql:       data.SQL,
 		args:      data.Args,
 		startTime: time.Now(),
+		span:      span,
 	})
 }
 
@@ -148,7 +210,18 @@ func (t *queryTracer) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pg
 		return
 	}
 
+	queryData.span.SetError(data.Err)
+	queryData.span.End()
+
+	// A query that returns rows ends when its rows are closed, so the
+	// duration includes reading them
 	duration := time.Since(queryData.startTime)
+	if t.slowQuery > 0 && duration >= t.slowQuery {
+		logSlowQuery(ctx, t.logger, queryData.sql, duration, data.Err)
+	}
+	if !t.debug {
+		return
+	}
 
 	event := t.logger.Debug().
 		Str("sql", queryData.sql).
@@ -162,6 +235,21 @@ func (t *queryTracer) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pg
 	event.Msg("query executed")
 }
 
+// logSlowQuery logs a query that took at least the slow query threshold.
+// The event carries ctx, so the request log hook adds the request ID,
+// bucket and key of the request that ran the query.
+func logSlowQuery(ctx context.Context, logger zerolog.Logger, sql string, duration time.Duration, err error) {
+	event := logger.Warn().
+		Ctx(ctx).
+		Str("statement", repository.StatementName()).
+		Str("sql", sql).
+		Dur("duration", duration)
+	if err != nil {
+		event.Err(redact.Error(err))
+	}
+	event.Msg("slow query")
+}
+
 // Querier is an interface that both pgxpool.Pool and pgx.Tx implement.
 // This allows repositories to work with both.
 type Querier interface {
@@ -0,0 +1,122 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// eventRepository implements repository.EventRepository.
+type eventRepository struct {
+	db *DB
+}
+
+// NewEventRepository creates a new PostgreSQL event repository.
+func NewEventRepository(db *DB) repository.EventRepository {
+	return &eventRepository{db: db}
+}
+
+// Append records an event and sets its ID.
+func (r *eventRepository) Append(ctx context.Context, event *domain.ObjectEvent) error {
+	query := `
+		INSERT INTO object_events (event_type, bucket_name, object_key, version_id, size, etag, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`
+
+	err := r.db.conn(ctx).QueryRow(ctx, query,
+		string(event.Type),
+		event.BucketName,
+		event.Key,
+		event.VersionID,
+		event.Size,
+		event.ETag,
+		event.CreatedAt.UTC(),
+	).Scan(&event.ID)
+	if err != nil {
+		return fmt.Errorf("failed to append event: %w", err)
+	}
+
+	return nil
+}
+
+// List returns events matching the filter, ordered by ID.
+func (r *eventRepository) List(ctx context.Context, filter repository.EventFilter) ([]*domain.ObjectEvent, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 1000
+	}
+
+	var createdBefore *time.Time
+	if !filter.CreatedBefore.IsZero() {
+		t := filter.CreatedBefore.UTC()
+		createdBefore = &t
+	}
+
+	query := `
+		SELECT id, event_type, bucket_name, object_key, version_id, size, etag, created_at
+		FROM object_events
+		WHERE id > $1
+			AND ($2 = '' OR bucket_name = $2)
+			AND ($3 = '' OR left(object_key, length($3)) = $3)
+			AND ($4::timestamptz IS NULL OR created_at < $4)
+		ORDER BY id
+		LIMIT $5
+	`
+
+	rows, err := r.db.conn(ctx).Query(ctx, query, filter.AfterID, filter.BucketName, filter.Prefix, createdBefore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*domain.ObjectEvent
+	for rows.Next() {
+		event := &domain.ObjectEvent{}
+		var eventType string
+		err := rows.Scan(
+			&event.ID,
+			&eventType,
+			&event.BucketName,
+			&event.Key,
+			&event.VersionID,
+			&event.Size,
+			&event.ETag,
+			&event.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		event.Type = domain.ObjectEventType(eventType)
+		event.CreatedAt = event.CreatedAt.UTC()
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating events: %w", err)
+	}
+
+	return events, nil
+}
+
+// LatestID returns the ID of the newest event, or 0 if there are none.
+func (r *eventRepository) LatestID(ctx context.Context) (int64, error) {
+	var id int64
+	err := r.db.conn(ctx).QueryRow(ctx, `SELECT COALESCE(MAX(id), 0) FROM object_events`).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get latest event id: %w", err)
+	}
+	return id, nil
+}
+
+// DeleteBefore removes events created before the given time.
+func (r *eventRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.conn(ctx).Exec(ctx, `DELETE FROM object_events WHERE created_at < $1`, before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete events: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
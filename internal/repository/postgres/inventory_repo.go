@@ -0,0 +1,237 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// inventoryConfigColumns lists the bucket_inventory_configs columns read by scanInventoryConfig.
+const inventoryConfigColumns = `bucket_id, config_id, enabled, destination_bucket, destination_prefix,
+	format, frequency, prefix, all_versions, fields, last_run_at, created_at, updated_at`
+
+// inventoryRepository implements repository.InventoryRepository.
+type inventoryRepository struct {
+	db *DB
+}
+
+// NewInventoryRepository creates a new PostgreSQL inventory repository.
+func NewInventoryRepository(db *DB) repository.InventoryRepository {
+	return &inventoryRepository{db: db}
+}
+
+// Put creates or replaces an inventory configuration. The time of the last
+// report is kept.
+func (r *inventoryRepository) Put(ctx context.Context, config *domain.InventoryConfig) error {
+	fields := config.Fields
+	if fields == nil {
+		fields = []domain.InventoryField{}
+	}
+
+	query := `
+		INSERT INTO bucket_inventory_configs (bucket_id, config_id, enabled, destination_bucket,
+			destination_prefix, format, frequency, prefix, all_versions, fields, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (bucket_id, config_id) DO UPDATE SET
+			enabled = EXCLUDED.enabled,
+			destination_bucket = EXCLUDED.destination_bucket,
+			destination_prefix = EXCLUDED.destination_prefix,
+			format = EXCLUDED.format,
+			frequency = EXCLUDED.frequency,
+			prefix = EXCLUDED.prefix,
+			all_versions = EXCLUDED.all_versions,
+			fields = EXCLUDED.fields,
+			updated_at = EXCLUDED.updated_at
+		RETURNING created_at
+	`
+
+	err := r.db.conn(ctx).QueryRow(ctx, query,
+		config.BucketID,
+		config.ID,
+		config.Enabled,
+		config.DestinationBucket,
+		config.DestinationPrefix,
+		string(config.Format),
+		string(config.Frequency),
+		config.Prefix,
+		config.AllVersions,
+		fields,
+		config.CreatedAt,
+		config.UpdatedAt,
+	).Scan(&config.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to put inventory config: %w", err)
+	}
+
+	return nil
+}
+
+// Get returns a configuration of a bucket.
+func (r *inventoryRepository) Get(ctx context.Context, bucketID int64, id string) (*domain.InventoryConfig, error) {
+	query := `SELECT ` + inventoryConfigColumns + ` FROM bucket_inventory_configs WHERE bucket_id = $1 AND config_id = $2`
+
+	config, err := scanInventoryConfig(r.db.conn(ctx).QueryRow(ctx, query, bucketID, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrInventoryConfigNotFound
+		}
+		return nil, fmt.Errorf("failed to get inventory config: %w", err)
+	}
+	return config, nil
+}
+
+// ListByBucket returns the configurations of a bucket, by ID.
+func (r *inventoryRepository) ListByBucket(ctx context.Context, bucketID int64) ([]*domain.InventoryConfig, error) {
+	query := `SELECT ` + inventoryConfigColumns + ` FROM bucket_inventory_configs WHERE bucket_id = $1 ORDER BY config_id ASC`
+	return r.list(ctx, query, bucketID)
+}
+
+// ListEnabled returns the enabled configurations of all buckets.
+func (r *inventoryRepository) ListEnabled(ctx context.Context) ([]*domain.InventoryConfig, error) {
+	query := `SELECT ` + inventoryConfigColumns + ` FROM bucket_inventory_configs WHERE enabled ORDER BY bucket_id ASC, config_id ASC`
+	return r.list(ctx, query)
+}
+
+func (r *inventoryRepository) list(ctx context.Context, query string, args ...any) ([]*domain.InventoryConfig, error) {
+	rows, err := r.db.conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list inventory configs: %w", err)
+	}
+	defer rows.Close()
+
+	var configs []*domain.InventoryConfig
+	for rows.Next() {
+		config, err := scanInventoryConfig(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan inventory config: %w", err)
+		}
+		configs = append(configs, config)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating inventory configs: %w", err)
+	}
+	return configs, nil
+}
+
+// Delete removes a configuration.
+func (r *inventoryRepository) Delete(ctx context.Context, bucketID int64, id string) error {
+	result, err := r.db.conn(ctx).Exec(ctx,
+		`DELETE FROM bucket_inventory_configs WHERE bucket_id = $1 AND config_id = $2`, bucketID, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete inventory config: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrInventoryConfigNotFound
+	}
+	return nil
+}
+
+// MarkRun records when a report was generated.
+func (r *inventoryRepository) MarkRun(ctx context.Context, bucketID int64, id string, at time.Time) error {
+	_, err := r.db.conn(ctx).Exec(ctx,
+		`UPDATE bucket_inventory_configs SET last_run_at = $3 WHERE bucket_id = $1 AND config_id = $2`,
+		bucketID, id, at)
+	if err != nil {
+		return fmt.Errorf("failed to mark inventory run: %w", err)
+	}
+	return nil
+}
+
+// ListEntries returns object versions of a bucket in listing order. Pages
+// may be read from the replica, since a report is a listing like any other.
+func (r *inventoryRepository) ListEntries(ctx context.Context, bucketID int64, opts repository.InventoryListOptions) ([]*domain.InventoryEntry, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 1000
+	}
+	after := opts.After
+	if after == nil {
+		after = &domain.InventoryEntry{}
+	}
+
+	query := `
+		SELECT o.id, o.key, o.version_id, o.is_latest, o.is_delete_marker, o.size,
+			COALESCE(o.etag, ''), o.storage_class, o.replication_status,
+			COALESCE(b.is_encrypted, FALSE), o.sequence, o.created_at
+		FROM objects o
+		LEFT JOIN blobs b ON b.content_hash = o.content_hash
+		WHERE o.bucket_id = $1 AND o.deleted_at IS NULL
+			AND ($2 OR o.is_latest)
+			AND ($3 = '' OR o.key LIKE $3 || '%')
+			AND (NOT $4 OR o.key > $5 OR (o.key = $5 AND (o.sequence, o.id) < ($6, $7)))
+		ORDER BY o.key ASC, o.sequence DESC, o.id DESC
+		LIMIT $8
+	`
+
+	rows, err := r.db.listConn(ctx).Query(ctx, query, bucketID, opts.AllVersions, opts.Prefix,
+		opts.After != nil, after.Key, after.Sequence, after.ID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list inventory entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*domain.InventoryEntry
+	for rows.Next() {
+		entry := &domain.InventoryEntry{}
+		var versionID uuid.UUID
+		err := rows.Scan(
+			&entry.ID,
+			&entry.Key,
+			&versionID,
+			&entry.IsLatest,
+			&entry.IsDeleteMarker,
+			&entry.Size,
+			&entry.ETag,
+			&entry.StorageClass,
+			&entry.ReplicationStatus,
+			&entry.Encrypted,
+			&entry.Sequence,
+			&entry.LastModified,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan inventory entry: %w", err)
+		}
+		entry.VersionID = versionID.String()
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating inventory entries: %w", err)
+	}
+	return entries, nil
+}
+
+func scanInventoryConfig(row pgx.Row) (*domain.InventoryConfig, error) {
+	config := &domain.InventoryConfig{}
+	var format, frequency string
+	err := row.Scan(
+		&config.BucketID,
+		&config.ID,
+		&config.Enabled,
+		&config.DestinationBucket,
+		&config.DestinationPrefix,
+		&format,
+		&frequency,
+		&config.Prefix,
+		&config.AllVersions,
+		&config.Fields,
+		&config.LastRunAt,
+		&config.CreatedAt,
+		&config.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	config.Format = domain.InventoryFormat(format)
+	config.Frequency = domain.InventoryFrequency(frequency)
+	return config, nil
+}
+
+// Ensure inventoryRepository implements repository.InventoryRepository
+var _ repository.InventoryRepository = (*inventoryRepository)(nil)
@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 
@@ -11,6 +12,11 @@ import (
 	"github.com/prn-tf/alexander-storage/internal/repository"
 )
 
+// lifecycleRuleColumns are the columns scanned by scanLifecycleRule.
+const lifecycleRuleColumns = `id, bucket_id, rule_id, prefix, filter_tags, object_size_greater_than, object_size_less_than,
+	expiration_days, expiration_date, expired_object_delete_marker, noncurrent_days, abort_incomplete_upload_days,
+	status, created_at, updated_at`
+
 // lifecycleRepository implements repository.LifecycleRepository.
 type lifecycleRepository struct {
 	db *DB
@@ -23,17 +29,31 @@ func NewLifecycleRepository(db *DB) repository.LifecycleRepository {
 
 // Create creates a new lifecycle rule.
 func (r *lifecycleRepository) Create(ctx context.Context, rule *domain.LifecycleRule) error {
+	tags, err := encodeLifecycleTags(rule.Tags)
+	if err != nil {
+		return err
+	}
+
 	query := `
-		INSERT INTO lifecycle_rules (bucket_id, rule_id, prefix, expiration_days, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO lifecycle_rules (bucket_id, rule_id, prefix, filter_tags, object_size_greater_than, object_size_less_than,
+			expiration_days, expiration_date, expired_object_delete_marker, noncurrent_days, abort_incomplete_upload_days,
+			status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 		RETURNING id
 	`
 
-	err := r.db.Pool.QueryRow(ctx, query,
+	err = r.db.conn(ctx).QueryRow(ctx, query,
 		rule.BucketID,
 		rule.RuleID,
 		rule.Prefix,
+		tags,
+		rule.ObjectSizeGreaterThan,
+		rule.ObjectSizeLessThan,
 		rule.ExpirationDays,
+		rule.ExpirationDate,
+		rule.ExpiredObjectDeleteMarker,
+		rule.NoncurrentDays,
+		rule.AbortIncompleteUploadDays,
 		rule.Status,
 		rule.CreatedAt,
 		rule.UpdatedAt,
@@ -51,24 +71,9 @@ func (r *lifecycleRepository) Create(ctx context.Context, rule *domain.Lifecycle
 
 // GetByID retrieves a lifecycle rule by ID.
 func (r *lifecycleRepository) GetByID(ctx context.Context, id int64) (*domain.LifecycleRule, error) {
-	query := `
-		SELECT id, bucket_id, rule_id, prefix, expiration_days, status, created_at, updated_at
-		FROM lifecycle_rules
-		WHERE id = $1
-	`
-
-	rule := &domain.LifecycleRule{}
-	err := r.db.Pool.QueryRow(ctx, query, id).Scan(
-		&rule.ID,
-		&rule.BucketID,
-		&rule.RuleID,
-		&rule.Prefix,
-		&rule.ExpirationDays,
-		&rule.Status,
-		&rule.CreatedAt,
-		&rule.UpdatedAt,
-	)
+	query := `SELECT ` + lifecycleRuleColumns + ` FROM lifecycle_rules WHERE id = $1`
 
+	rule, err := scanLifecycleRule(r.db.conn(ctx).QueryRow(ctx, query, id))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, repository.ErrNotFound
@@ -81,24 +86,9 @@ func (r *lifecycleRepository) GetByID(ctx context.Context, id int64) (*domain.Li
 
 // GetByBucketAndRuleID retrieves a rule by bucket ID and rule ID.
 func (r *lifecycleRepository) GetByBucketAndRuleID(ctx context.Context, bucketID int64, ruleID string) (*domain.LifecycleRule, error) {
-	query := `
-		SELECT id, bucket_id, rule_id, prefix, expiration_days, status, created_at, updated_at
-		FROM lifecycle_rules
-		WHERE bucket_id = $1 AND rule_id = $2
-	`
-
-	rule := &domain.LifecycleRule{}
-	err := r.db.Pool.QueryRow(ctx, query, bucketID, ruleID).Scan(
-		&rule.ID,
-		&rule.BucketID,
-		&rule.RuleID,
-		&rule.Prefix,
-		&rule.ExpirationDays,
-		&rule.Status,
-		&rule.CreatedAt,
-		&rule.UpdatedAt,
-	)
+	query := `SELECT ` + lifecycleRuleColumns + ` FROM lifecycle_rules WHERE bucket_id = $1 AND rule_id = $2`
 
+	rule, err := scanLifecycleRule(r.db.conn(ctx).QueryRow(ctx, query, bucketID, ruleID))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, repository.ErrNotFound
@@ -112,97 +102,61 @@ func (r *lifecycleRepository) GetByBucketAndRuleID(ctx context.Context, bucketID
 // ListByBucket returns all lifecycle rules for a bucket.
 func (r *lifecycleRepository) ListByBucket(ctx context.Context, bucketID int64) ([]*domain.LifecycleRule, error) {
 	query := `
-		SELECT id, bucket_id, rule_id, prefix, expiration_days, status, created_at, updated_at
+		SELECT ` + lifecycleRuleColumns + `
 		FROM lifecycle_rules
 		WHERE bucket_id = $1
 		ORDER BY rule_id ASC
 	`
 
-	rows, err := r.db.Pool.Query(ctx, query, bucketID)
+	rules, err := r.list(ctx, query, bucketID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list lifecycle rules: %w", err)
 	}
-	defer rows.Close()
-
-	var rules []*domain.LifecycleRule
-	for rows.Next() {
-		rule := &domain.LifecycleRule{}
-		err := rows.Scan(
-			&rule.ID,
-			&rule.BucketID,
-			&rule.RuleID,
-			&rule.Prefix,
-			&rule.ExpirationDays,
-			&rule.Status,
-			&rule.CreatedAt,
-			&rule.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan lifecycle rule: %w", err)
-		}
-		rules = append(rules, rule)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating lifecycle rules: %w", err)
-	}
-
 	return rules, nil
 }
 
 // ListEnabledByBucket returns only enabled rules for a bucket.
 func (r *lifecycleRepository) ListEnabledByBucket(ctx context.Context, bucketID int64) ([]*domain.LifecycleRule, error) {
 	query := `
-		SELECT id, bucket_id, rule_id, prefix, expiration_days, status, created_at, updated_at
+		SELECT ` + lifecycleRuleColumns + `
 		FROM lifecycle_rules
 		WHERE bucket_id = $1 AND status = 'Enabled'
 		ORDER BY rule_id ASC
 	`
 
-	rows, err := r.db.Pool.Query(ctx, query, bucketID)
+	rules, err := r.list(ctx, query, bucketID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list enabled lifecycle rules: %w", err)
 	}
-	defer rows.Close()
-
-	var rules []*domain.LifecycleRule
-	for rows.Next() {
-		rule := &domain.LifecycleRule{}
-		err := rows.Scan(
-			&rule.ID,
-			&rule.BucketID,
-			&rule.RuleID,
-			&rule.Prefix,
-			&rule.ExpirationDays,
-			&rule.Status,
-			&rule.CreatedAt,
-			&rule.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan lifecycle rule: %w", err)
-		}
-		rules = append(rules, rule)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating lifecycle rules: %w", err)
-	}
-
 	return rules, nil
 }
 
 // Update updates an existing lifecycle rule.
 func (r *lifecycleRepository) Update(ctx context.Context, rule *domain.LifecycleRule) error {
+	tags, err := encodeLifecycleTags(rule.Tags)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE lifecycle_rules
-		SET prefix = $2, expiration_days = $3, status = $4, updated_at = NOW()
+		SET prefix = $2, filter_tags = $3, object_size_greater_than = $4, object_size_less_than = $5,
+			expiration_days = $6, expiration_date = $7, expired_object_delete_marker = $8, noncurrent_days = $9,
+			abort_incomplete_upload_days = $10, status = $11, updated_at = NOW()
 		WHERE id = $1
 	`
 
-	result, err := r.db.Pool.Exec(ctx, query,
+	result, err := r.db.conn(ctx).Exec(ctx, query,
 		rule.ID,
 		rule.Prefix,
+		tags,
+		rule.ObjectSizeGreaterThan,
+		rule.ObjectSizeLessThan,
 		rule.ExpirationDays,
+		rule.ExpirationDate,
+		rule.ExpiredObjectDeleteMarker,
+		rule.NoncurrentDays,
+		rule.AbortIncompleteUploadDays,
 		rule.Status,
 	)
 
@@ -221,7 +175,7 @@ func (r *lifecycleRepository) Update(ctx context.Context, rule *domain.Lifecycle
 func (r *lifecycleRepository) Delete(ctx context.Context, id int64) error {
 	query := `DELETE FROM lifecycle_rules WHERE id = $1`
 
-	result, err := r.db.Pool.Exec(ctx, query, id)
+	result, err := r.db.conn(ctx).Exec(ctx, query, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete lifecycle rule: %w", err)
 	}
@@ -237,7 +191,7 @@ func (r *lifecycleRepository) Delete(ctx context.Context, id int64) error {
 func (r *lifecycleRepository) DeleteByBucketAndRuleID(ctx context.Context, bucketID int64, ruleID string) error {
 	query := `DELETE FROM lifecycle_rules WHERE bucket_id = $1 AND rule_id = $2`
 
-	result, err := r.db.Pool.Exec(ctx, query, bucketID, ruleID)
+	result, err := r.db.conn(ctx).Exec(ctx, query, bucketID, ruleID)
 	if err != nil {
 		return fmt.Errorf("failed to delete lifecycle rule: %w", err)
 	}
@@ -253,7 +207,7 @@ func (r *lifecycleRepository) DeleteByBucketAndRuleID(ctx context.Context, bucke
 func (r *lifecycleRepository) DeleteByBucket(ctx context.Context, bucketID int64) error {
 	query := `DELETE FROM lifecycle_rules WHERE bucket_id = $1`
 
-	_, err := r.db.Pool.Exec(ctx, query, bucketID)
+	_, err := r.db.conn(ctx).Exec(ctx, query, bucketID)
 	if err != nil {
 		return fmt.Errorf("failed to delete lifecycle rules by bucket: %w", err)
 	}
@@ -264,31 +218,30 @@ func (r *lifecycleRepository) DeleteByBucket(ctx context.Context, bucketID int64
 // ListAllEnabled returns all enabled lifecycle rules across all buckets.
 func (r *lifecycleRepository) ListAllEnabled(ctx context.Context) ([]*domain.LifecycleRule, error) {
 	query := `
-		SELECT id, bucket_id, rule_id, prefix, expiration_days, status, created_at, updated_at
+		SELECT ` + lifecycleRuleColumns + `
 		FROM lifecycle_rules
 		WHERE status = 'Enabled'
 		ORDER BY bucket_id ASC, rule_id ASC
 	`
 
-	rows, err := r.db.Pool.Query(ctx, query)
+	rules, err := r.list(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list all enabled lifecycle rules: %w", err)
 	}
+	return rules, nil
+}
+
+// list returns the rules a query selecting lifecycleRuleColumns returns.
+func (r *lifecycleRepository) list(ctx context.Context, query string, args ...any) ([]*domain.LifecycleRule, error) {
+	rows, err := r.db.conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
 
 	var rules []*domain.LifecycleRule
 	for rows.Next() {
-		rule := &domain.LifecycleRule{}
-		err := rows.Scan(
-			&rule.ID,
-			&rule.BucketID,
-			&rule.RuleID,
-			&rule.Prefix,
-			&rule.ExpirationDays,
-			&rule.Status,
-			&rule.CreatedAt,
-			&rule.UpdatedAt,
-		)
+		rule, err := scanLifecycleRule(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan lifecycle rule: %w", err)
 		}
@@ -302,5 +255,52 @@ func (r *lifecycleRepository) ListAllEnabled(ctx context.Context) ([]*domain.Lif
 	return rules, nil
 }
 
+func scanLifecycleRule(row pgx.Row) (*domain.LifecycleRule, error) {
+	rule := &domain.LifecycleRule{}
+	var tags []byte
+
+	err := row.Scan(
+		&rule.ID,
+		&rule.BucketID,
+		&rule.RuleID,
+		&rule.Prefix,
+		&tags,
+		&rule.ObjectSizeGreaterThan,
+		&rule.ObjectSizeLessThan,
+		&rule.ExpirationDays,
+		&rule.ExpirationDate,
+		&rule.ExpiredObjectDeleteMarker,
+		&rule.NoncurrentDays,
+		&rule.AbortIncompleteUploadDays,
+		&rule.Status,
+		&rule.CreatedAt,
+		&rule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if tags != nil {
+		if err := json.Unmarshal(tags, &rule.Tags); err != nil {
+			return nil, fmt.Errorf("failed to decode lifecycle rule tags: %w", err)
+		}
+	}
+
+	return rule, nil
+}
+
+// encodeLifecycleTags encodes the filter_tags column of a rule, NULL if it
+// filters on no tags.
+func encodeLifecycleTags(tags []domain.Tag) ([]byte, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(tags)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode lifecycle rule tags: %w", err)
+	}
+	return data, nil
+}
+
 // Ensure lifecycleRepository implements repository.LifecycleRepository
 var _ repository.LifecycleRepository = (*lifecycleRepository)(nil)
@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// mfaDeviceRepository implements repository.MFADeviceRepository.
+type mfaDeviceRepository struct {
+	db *DB
+}
+
+// NewMFADeviceRepository creates a new PostgreSQL MFA device repository.
+func NewMFADeviceRepository(db *DB) repository.MFADeviceRepository {
+	return &mfaDeviceRepository{db: db}
+}
+
+// Create enrolls a device.
+func (r *mfaDeviceRepository) Create(ctx context.Context, device *domain.MFADevice) error {
+	query := `
+		INSERT INTO mfa_devices (user_id, serial_number, encrypted_secret, created_at)
+		VALUES ($1, $2, $3, $4)
+	`
+
+	_, err := r.db.conn(ctx).Exec(ctx, query,
+		device.UserID,
+		device.SerialNumber,
+		device.EncryptedSecret,
+		device.CreatedAt,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return domain.ErrMFADeviceExists
+		}
+		return fmt.Errorf("failed to create MFA device: %w", err)
+	}
+
+	return nil
+}
+
+// GetByUserID returns the device of a user.
+func (r *mfaDeviceRepository) GetByUserID(ctx context.Context, userID int64) (*domain.MFADevice, error) {
+	query := `
+		SELECT user_id, serial_number, encrypted_secret, created_at
+		FROM mfa_devices
+		WHERE user_id = $1
+	`
+
+	device := &domain.MFADevice{}
+	err := r.db.conn(ctx).QueryRow(ctx, query, userID).Scan(
+		&device.UserID,
+		&device.SerialNumber,
+		&device.EncryptedSecret,
+		&device.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrMFADeviceNotFound
+		}
+		return nil, fmt.Errorf("failed to get MFA device: %w", err)
+	}
+
+	return device, nil
+}
+
+// Delete removes the device of a user.
+func (r *mfaDeviceRepository) Delete(ctx context.Context, userID int64) error {
+	result, err := r.db.conn(ctx).Exec(ctx, `DELETE FROM mfa_devices WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete MFA device: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrMFADeviceNotFound
+	}
+	return nil
+}
+
+// Ensure mfaDeviceRepository implements repository.MFADeviceRepository
+var _ repository.MFADeviceRepository = (*mfaDeviceRepository)(nil)
@@ -26,17 +26,19 @@ func NewMultipartRepository(db *DB) repository.MultipartUploadRepository {
 // Create creates a new multipart upload.
 func (r *multipartRepository) Create(ctx context.Context, upload *domain.MultipartUpload) error {
 	query := `
-		INSERT INTO multipart_uploads (id, bucket_id, key, initiator_id, status, storage_class, metadata, initiated_at, expires_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO multipart_uploads (id, bucket_id, key, initiator_id, status, storage_class, acl, checksum_algorithm, metadata, initiated_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 	`
 
-	_, err := r.db.Pool.Exec(ctx, query,
+	_, err := r.db.conn(ctx).Exec(ctx, query,
 		upload.ID,
 		upload.BucketID,
 		upload.Key,
 		upload.InitiatorID,
 		upload.Status,
 		upload.StorageClass,
+		upload.ACL,
+		upload.ChecksumAlgorithm,
 		upload.Metadata,
 		upload.InitiatedAt,
 		upload.ExpiresAt,
@@ -52,19 +54,21 @@ func (r *multipartRepository) Create(ctx context.Context, upload *domain.Multipa
 // GetByID retrieves a multipart upload by ID.
 func (r *multipartRepository) GetByID(ctx context.Context, uploadID uuid.UUID) (*domain.MultipartUpload, error) {
 	query := `
-		SELECT id, bucket_id, key, initiator_id, status, storage_class, metadata, initiated_at, expires_at, completed_at
+		SELECT id, bucket_id, key, initiator_id, status, storage_class, acl, checksum_algorithm, metadata, initiated_at, expires_at, completed_at
 		FROM multipart_uploads
 		WHERE id = $1
 	`
 
 	upload := &domain.MultipartUpload{}
-	err := r.db.Pool.QueryRow(ctx, query, uploadID).Scan(
+	err := r.db.conn(ctx).QueryRow(ctx, query, uploadID).Scan(
 		&upload.ID,
 		&upload.BucketID,
 		&upload.Key,
 		&upload.InitiatorID,
 		&upload.Status,
 		&upload.StorageClass,
+		&upload.ACL,
+		&upload.ChecksumAlgorithm,
 		&upload.Metadata,
 		&upload.InitiatedAt,
 		&upload.ExpiresAt,
@@ -83,22 +87,38 @@ func (r *multipartRepository) GetByID(ctx context.Context, uploadID uuid.UUID) (
 
 // List returns multipart uploads for a bucket.
 func (r *multipartRepository) List(ctx context.Context, bucketID int64, opts repository.MultipartListOptions) (*repository.MultipartListResult, error) {
-	maxUploads := opts.MaxUploads
-	if maxUploads <= 0 {
-		maxUploads = 1000
-	}
+	// All pages of a listing read the same database
+	conn := r.db.listConn(ctx)
+	return repository.ListMultipartUploads(opts, func(prefix string, cursor repository.MultipartListCursor, limit int) ([]*domain.MultipartUploadInfo, error) {
+		return r.fetchUploads(ctx, conn, bucketID, prefix, cursor, limit)
+	})
+}
 
+// fetchUploads returns the in-progress uploads of a page of List. Prefixes
+// are compared with starts_with rather than LIKE, which treats % and _ in
+// keys as wildcards.
+func (r *multipartRepository) fetchUploads(ctx context.Context, conn querier, bucketID int64, prefix string, cursor repository.MultipartListCursor, limit int) ([]*domain.MultipartUploadInfo, error) {
 	query := `
 		SELECT id, key, initiated_at, storage_class
 		FROM multipart_uploads
 		WHERE bucket_id = $1 AND status = $2
-			AND ($3 = '' OR key LIKE $3 || '%')
-			AND ($4 = '' OR key > $4)
-		ORDER BY key ASC, initiated_at ASC
-		LIMIT $5
+			AND starts_with(key, $3)
+			AND ($4 = '' OR NOT starts_with(key, $4))
+			AND (key > $5 OR (key = $5 AND (initiated_at, id) > (
+				SELECT initiated_at, id FROM multipart_uploads WHERE id = $6
+			)))
+		ORDER BY key ASC, initiated_at ASC, id ASC
+		LIMIT $7
 	`
 
-	rows, err := r.db.Pool.Query(ctx, query, bucketID, domain.MultipartStatusInProgress, opts.Prefix, opts.KeyMarker, maxUploads+1)
+	// An upload ID marker that is not a valid ID matches no upload
+	var uploadIDMarker *uuid.UUID
+	if id, err := uuid.Parse(cursor.UploadIDMarker); err == nil {
+		uploadIDMarker = &id
+	}
+
+	rows, err := conn.Query(ctx, query, bucketID, domain.MultipartStatusInProgress,
+		prefix, cursor.SkipPrefix, cursor.KeyMarker, uploadIDMarker, limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list uploads: %w", err)
 	}
@@ -125,18 +145,7 @@ func (r *multipartRepository) List(ctx context.Context, bucketID int64, opts rep
 		return nil, fmt.Errorf("error iterating uploads: %w", err)
 	}
 
-	result := &repository.MultipartListResult{}
-
-	if len(uploads) > maxUploads {
-		result.IsTruncated = true
-		result.NextKeyMarker = uploads[maxUploads-1].Key
-		result.NextUploadIDMarker = uploads[maxUploads-1].UploadID
-		result.Uploads = uploads[:maxUploads]
-	} else {
-		result.Uploads = uploads
-	}
-
-	return result, nil
+	return uploads, nil
 }
 
 // UpdateStatus updates the status of a multipart upload.
@@ -146,10 +155,10 @@ func (r *multipartRepository) UpdateStatus(ctx context.Context, uploadID uuid.UU
 
 	if status == domain.MultipartStatusCompleted {
 		query = `UPDATE multipart_uploads SET status = $2, completed_at = $3 WHERE id = $1`
-		_, err = r.db.Pool.Exec(ctx, query, uploadID, status, time.Now().UTC())
+		_, err = r.db.conn(ctx).Exec(ctx, query, uploadID, status, time.Now().UTC())
 	} else {
 		query = `UPDATE multipart_uploads SET status = $2 WHERE id = $1`
-		_, err = r.db.Pool.Exec(ctx, query, uploadID, status)
+		_, err = r.db.conn(ctx).Exec(ctx, query, uploadID, status)
 	}
 
 	if err != nil {
@@ -182,48 +191,87 @@ func (r *multipartRepository) Delete(ctx context.Context, uploadID uuid.UUID) er
 	})
 }
 
-// DeleteExpired deletes expired multipart uploads.
-func (r *multipartRepository) DeleteExpired(ctx context.Context) (int64, error) {
-	// First delete parts for expired uploads
-	_, err := r.db.Pool.Exec(ctx, `
-		DELETE FROM upload_parts 
-		WHERE upload_id IN (
-			SELECT id FROM multipart_uploads 
-			WHERE status = $1 AND expires_at < $2
-		)
-	`, domain.MultipartStatusInProgress, time.Now().UTC())
+// ListExpired returns in-progress uploads that expired before the given
+// time, earliest expiry first.
+func (r *multipartRepository) ListExpired(ctx context.Context, before time.Time, limit int) ([]*domain.MultipartUpload, error) {
+	query := `
+		SELECT id, bucket_id, key, initiator_id, status, initiated_at, expires_at
+		FROM multipart_uploads
+		WHERE status = $1 AND expires_at < $2
+		ORDER BY expires_at ASC, id ASC
+		LIMIT $3
+	`
+
+	rows, err := r.db.conn(ctx).Query(ctx, query, domain.MultipartStatusInProgress, before.UTC(), limit)
 	if err != nil {
-		return 0, fmt.Errorf("failed to delete expired parts: %w", err)
+		return nil, fmt.Errorf("failed to list expired uploads: %w", err)
 	}
+	defer rows.Close()
 
-	// Then delete expired uploads
-	result, err := r.db.Pool.Exec(ctx, `
-		DELETE FROM multipart_uploads 
-		WHERE status = $1 AND expires_at < $2
-	`, domain.MultipartStatusInProgress, time.Now().UTC())
+	var uploads []*domain.MultipartUpload
+	for rows.Next() {
+		upload := &domain.MultipartUpload{}
+		if err := rows.Scan(&upload.ID, &upload.BucketID, &upload.Key, &upload.InitiatorID, &upload.Status, &upload.InitiatedAt, &upload.ExpiresAt); err != nil {
+			return nil, fmt.Errorf("failed to scan upload: %w", err)
+		}
+		uploads = append(uploads, upload)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating uploads: %w", err)
+	}
+
+	return uploads, nil
+}
+
+// CountInProgress returns the number of multipart uploads in progress in a bucket.
+func (r *multipartRepository) CountInProgress(ctx context.Context, bucketID int64) (int64, error) {
+	var count int64
+	err := r.db.conn(ctx).QueryRow(ctx,
+		`SELECT COUNT(*) FROM multipart_uploads WHERE bucket_id = $1 AND status = $2`,
+		bucketID, domain.MultipartStatusInProgress,
+	).Scan(&count)
 	if err != nil {
-		return 0, fmt.Errorf("failed to delete expired uploads: %w", err)
+		return 0, fmt.Errorf("failed to count uploads: %w", err)
 	}
+	return count, nil
+}
 
-	return result.RowsAffected(), nil
+// GetStats returns the number and size of the uploads in progress.
+func (r *multipartRepository) GetStats(ctx context.Context) (*domain.MultipartStats, error) {
+	stats := &domain.MultipartStats{}
+	err := r.db.conn(ctx).QueryRow(ctx, `
+		SELECT (SELECT COUNT(*) FROM multipart_uploads WHERE status = $1),
+		       COUNT(p.id), COALESCE(SUM(p.size), 0)
+		FROM upload_parts p
+		JOIN multipart_uploads u ON u.id = p.upload_id
+		WHERE u.status = $1
+	`, domain.MultipartStatusInProgress,
+	).Scan(&stats.Uploads, &stats.Parts, &stats.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get multipart stats: %w", err)
+	}
+	return stats, nil
 }
 
 // CreatePart creates a new upload part.
 func (r *multipartRepository) CreatePart(ctx context.Context, part *domain.UploadPart) error {
 	query := `
-		INSERT INTO upload_parts (upload_id, part_number, content_hash, size, etag, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO upload_parts (upload_id, part_number, content_hash, size, etag, checksum, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 		ON CONFLICT (upload_id, part_number) DO UPDATE
-		SET content_hash = EXCLUDED.content_hash, size = EXCLUDED.size, etag = EXCLUDED.etag, created_at = EXCLUDED.created_at
+		SET content_hash = EXCLUDED.content_hash, size = EXCLUDED.size, etag = EXCLUDED.etag,
+			checksum = EXCLUDED.checksum, created_at = EXCLUDED.created_at
 		RETURNING id
 	`
 
-	err := r.db.Pool.QueryRow(ctx, query,
+	err := r.db.conn(ctx).QueryRow(ctx, query,
 		part.UploadID,
 		part.PartNumber,
 		part.ContentHash,
 		part.Size,
 		part.ETag,
+		part.Checksum,
 		part.CreatedAt,
 	).Scan(&part.ID)
 
@@ -237,19 +285,20 @@ func (r *multipartRepository) CreatePart(ctx context.Context, part *domain.Uploa
 // GetPart retrieves a specific part.
 func (r *multipartRepository) GetPart(ctx context.Context, uploadID uuid.UUID, partNumber int) (*domain.UploadPart, error) {
 	query := `
-		SELECT id, upload_id, part_number, content_hash, size, etag, created_at
+		SELECT id, upload_id, part_number, content_hash, size, etag, checksum, created_at
 		FROM upload_parts
 		WHERE upload_id = $1 AND part_number = $2
 	`
 
 	part := &domain.UploadPart{}
-	err := r.db.Pool.QueryRow(ctx, query, uploadID, partNumber).Scan(
+	err := r.db.conn(ctx).QueryRow(ctx, query, uploadID, partNumber).Scan(
 		&part.ID,
 		&part.UploadID,
 		&part.PartNumber,
 		&part.ContentHash,
 		&part.Size,
 		&part.ETag,
+		&part.Checksum,
 		&part.CreatedAt,
 	)
 
@@ -271,14 +320,14 @@ func (r *multipartRepository) ListParts(ctx context.Context, uploadID uuid.UUID,
 	}
 
 	query := `
-		SELECT part_number, size, etag, created_at
+		SELECT part_number, size, etag, checksum, created_at
 		FROM upload_parts
 		WHERE upload_id = $1 AND part_number > $2
 		ORDER BY part_number ASC
 		LIMIT $3
 	`
 
-	rows, err := r.db.Pool.Query(ctx, query, uploadID, opts.PartNumberMarker, maxParts+1)
+	rows, err := r.db.listConn(ctx).Query(ctx, query, uploadID, opts.PartNumberMarker, maxParts+1)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list parts: %w", err)
 	}
@@ -291,6 +340,7 @@ func (r *multipartRepository) ListParts(ctx context.Context, uploadID uuid.UUID,
 			&part.PartNumber,
 			&part.Size,
 			&part.ETag,
+			&part.Checksum,
 			&part.LastModified,
 		)
 		if err != nil {
@@ -318,7 +368,7 @@ func (r *multipartRepository) ListParts(ctx context.Context, uploadID uuid.UUID,
 
 // DeleteParts deletes all parts for an upload.
 func (r *multipartRepository) DeleteParts(ctx context.Context, uploadID uuid.UUID) error {
-	_, err := r.db.Pool.Exec(ctx, `DELETE FROM upload_parts WHERE upload_id = $1`, uploadID)
+	_, err := r.db.conn(ctx).Exec(ctx, `DELETE FROM upload_parts WHERE upload_id = $1`, uploadID)
 	if err != nil {
 		return fmt.Errorf("failed to delete parts: %w", err)
 	}
@@ -328,16 +378,54 @@ func (r *multipartRepository) DeleteParts(ctx context.Context, uploadID uuid.UUI
 // GetPartsForCompletion returns parts in order for completing the upload.
 func (r *multipartRepository) GetPartsForCompletion(ctx context.Context, uploadID uuid.UUID, partNumbers []int) ([]*domain.UploadPart, error) {
 	query := `
-		SELECT id, upload_id, part_number, content_hash, size, etag, created_at
+		SELECT id, upload_id, part_number, content_hash, size, etag, checksum, created_at
 		FROM upload_parts
 		WHERE upload_id = $1 AND part_number = ANY($2)
 		ORDER BY part_number ASC
 	`
 
-	rows, err := r.db.Pool.Query(ctx, query, uploadID, partNumbers)
+	parts, err := r.queryParts(ctx, query, uploadID, partNumbers)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get parts for completion: %w", err)
 	}
+	return parts, nil
+}
+
+// GetPartsByContentHash returns the parts of an upload with any of the
+// given content hashes, in part number order.
+func (r *multipartRepository) GetPartsByContentHash(ctx context.Context, uploadID uuid.UUID, contentHashes []string) ([]*domain.UploadPart, error) {
+	query := `
+		SELECT id, upload_id, part_number, content_hash, size, etag, checksum, created_at
+		FROM upload_parts
+		WHERE upload_id = $1 AND content_hash = ANY($2)
+		ORDER BY part_number ASC
+	`
+
+	parts, err := r.queryParts(ctx, query, uploadID, contentHashes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get parts by content hash: %w", err)
+	}
+	return parts, nil
+}
+
+// LastPartNumber returns the highest part number of an upload, or 0 if it
+// has no parts.
+func (r *multipartRepository) LastPartNumber(ctx context.Context, uploadID uuid.UUID) (int, error) {
+	query := `SELECT COALESCE(MAX(part_number), 0) FROM upload_parts WHERE upload_id = $1`
+
+	var partNumber int
+	if err := r.db.conn(ctx).QueryRow(ctx, query, uploadID).Scan(&partNumber); err != nil {
+		return 0, fmt.Errorf("failed to get last part number: %w", err)
+	}
+	return partNumber, nil
+}
+
+// queryParts runs a query selecting upload part rows.
+func (r *multipartRepository) queryParts(ctx context.Context, query string, args ...any) ([]*domain.UploadPart, error) {
+	rows, err := r.db.conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
 
 	var parts []*domain.UploadPart
@@ -350,6 +438,7 @@ func (r *multipartRepository) GetPartsForCompletion(ctx context.Context, uploadI
 			&part.ContentHash,
 			&part.Size,
 			&part.ETag,
+			&part.Checksum,
 			&part.CreatedAt,
 		)
 		if err != nil {
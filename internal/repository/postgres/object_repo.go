@@ -27,12 +27,12 @@ func NewObjectRepository(db *DB) repository.ObjectRepository {
 func (r *objectRepository) Create(ctx context.Context, obj *domain.Object) error {
 	query := `
 		INSERT INTO objects (bucket_id, key, version_id, is_latest, is_delete_marker, 
-			content_hash, size, content_type, etag, storage_class, metadata, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+			content_hash, size, content_type, etag, storage_class, metadata, part_sizes, sequence, replication_status, acl, checksum_algorithm, checksum, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, COALESCE($11::jsonb, '{}'), $12, $13, $14, $15, $16, $17, $18)
 		RETURNING id
 	`
 
-	err := r.db.Pool.QueryRow(ctx, query,
+	err := r.db.conn(ctx).QueryRow(ctx, query,
 		obj.BucketID,
 		obj.Key,
 		obj.VersionID,
@@ -44,6 +44,12 @@ func (r *objectRepository) Create(ctx context.Context, obj *domain.Object) error
 		obj.ETag,
 		obj.StorageClass,
 		obj.Metadata,
+		obj.PartSizes,
+		obj.Sequence,
+		obj.ReplicationStatus,
+		obj.ACL,
+		obj.ChecksumAlgorithm,
+		obj.Checksum,
 		obj.CreatedAt,
 	).Scan(&obj.ID)
 
@@ -58,13 +64,13 @@ func (r *objectRepository) Create(ctx context.Context, obj *domain.Object) error
 func (r *objectRepository) GetByID(ctx context.Context, id int64) (*domain.Object, error) {
 	query := `
 		SELECT id, bucket_id, key, version_id, is_latest, is_delete_marker, 
-			content_hash, size, content_type, etag, storage_class, metadata, created_at, deleted_at
+			content_hash, size, content_type, etag, storage_class, metadata, part_sizes, sequence, replication_status, acl, checksum_algorithm, checksum, created_at, deleted_at
 		FROM objects
 		WHERE id = $1
 	`
 
 	obj := &domain.Object{}
-	err := r.db.Pool.QueryRow(ctx, query, id).Scan(
+	err := r.db.conn(ctx).QueryRow(ctx, query, id).Scan(
 		&obj.ID,
 		&obj.BucketID,
 		&obj.Key,
@@ -77,6 +83,12 @@ func (r *objectRepository) GetByID(ctx context.Context, id int64) (*domain.Objec
 		&obj.ETag,
 		&obj.StorageClass,
 		&obj.Metadata,
+		&obj.PartSizes,
+		&obj.Sequence,
+		&obj.ReplicationStatus,
+		&obj.ACL,
+		&obj.ChecksumAlgorithm,
+		&obj.Checksum,
 		&obj.CreatedAt,
 		&obj.DeletedAt,
 	)
@@ -95,13 +107,13 @@ func (r *objectRepository) GetByID(ctx context.Context, id int64) (*domain.Objec
 func (r *objectRepository) GetByKey(ctx context.Context, bucketID int64, key string) (*domain.Object, error) {
 	query := `
 		SELECT id, bucket_id, key, version_id, is_latest, is_delete_marker, 
-			content_hash, size, content_type, etag, storage_class, metadata, created_at, deleted_at
+			content_hash, size, content_type, etag, storage_class, metadata, part_sizes, sequence, replication_status, acl, checksum_algorithm, checksum, created_at, deleted_at
 		FROM objects
 		WHERE bucket_id = $1 AND key = $2 AND is_latest = TRUE AND deleted_at IS NULL
 	`
 
 	obj := &domain.Object{}
-	err := r.db.Pool.QueryRow(ctx, query, bucketID, key).Scan(
+	err := r.db.conn(ctx).QueryRow(ctx, query, bucketID, key).Scan(
 		&obj.ID,
 		&obj.BucketID,
 		&obj.Key,
@@ -114,6 +126,12 @@ func (r *objectRepository) GetByKey(ctx context.Context, bucketID int64, key str
 		&obj.ETag,
 		&obj.StorageClass,
 		&obj.Metadata,
+		&obj.PartSizes,
+		&obj.Sequence,
+		&obj.ReplicationStatus,
+		&obj.ACL,
+		&obj.ChecksumAlgorithm,
+		&obj.Checksum,
 		&obj.CreatedAt,
 		&obj.DeletedAt,
 	)
@@ -132,13 +150,13 @@ func (r *objectRepository) GetByKey(ctx context.Context, bucketID int64, key str
 func (r *objectRepository) GetByKeyAndVersion(ctx context.Context, bucketID int64, key string, versionID uuid.UUID) (*domain.Object, error) {
 	query := `
 		SELECT id, bucket_id, key, version_id, is_latest, is_delete_marker, 
-			content_hash, size, content_type, etag, storage_class, metadata, created_at, deleted_at
+			content_hash, size, content_type, etag, storage_class, metadata, part_sizes, sequence, replication_status, acl, checksum_algorithm, checksum, created_at, deleted_at
 		FROM objects
-		WHERE bucket_id = $1 AND key = $2 AND version_id = $3
+		WHERE bucket_id = $1 AND key = $2 AND version_id = $3 AND deleted_at IS NULL
 	`
 
 	obj := &domain.Object{}
-	err := r.db.Pool.QueryRow(ctx, query, bucketID, key, versionID).Scan(
+	err := r.db.conn(ctx).QueryRow(ctx, query, bucketID, key, versionID).Scan(
 		&obj.ID,
 		&obj.BucketID,
 		&obj.Key,
@@ -151,6 +169,12 @@ func (r *objectRepository) GetByKeyAndVersion(ctx context.Context, bucketID int6
 		&obj.ETag,
 		&obj.StorageClass,
 		&obj.Metadata,
+		&obj.PartSizes,
+		&obj.Sequence,
+		&obj.ReplicationStatus,
+		&obj.ACL,
+		&obj.ChecksumAlgorithm,
+		&obj.Checksum,
 		&obj.CreatedAt,
 		&obj.DeletedAt,
 	)
@@ -175,14 +199,14 @@ func (r *objectRepository) List(ctx context.Context, bucketID int64, opts reposi
 	query := `
 		SELECT key, version_id, is_latest, size, etag, created_at, storage_class
 		FROM objects
-		WHERE bucket_id = $1 AND is_latest = TRUE AND deleted_at IS NULL
+		WHERE bucket_id = $1 AND is_latest = TRUE AND is_delete_marker = FALSE AND deleted_at IS NULL
 			AND ($2 = '' OR key LIKE $2 || '%')
 			AND ($3 = '' OR key > $3)
 		ORDER BY key ASC
 		LIMIT $4
 	`
 
-	rows, err := r.db.Pool.Query(ctx, query, bucketID, opts.Prefix, opts.StartAfter, maxKeys+1)
+	rows, err := r.db.listConn(ctx).Query(ctx, query, bucketID, opts.Prefix, opts.StartAfter, maxKeys+1)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list objects: %w", err)
 	}
@@ -239,19 +263,21 @@ func (r *objectRepository) ListVersions(ctx context.Context, bucketID int64, opt
 		FROM objects
 		WHERE bucket_id = $1 AND deleted_at IS NULL
 			AND ($2 = '' OR key LIKE $2 || '%')
-			AND ($3 = '' OR key > $3)
-		ORDER BY key ASC, created_at DESC
-		LIMIT $4
+			AND ($3 = '' OR key > $3 OR (key = $3 AND (sequence, id) < (
+				SELECT sequence, id FROM objects
+				WHERE bucket_id = $1 AND key = $3 AND version_id::text = $4 AND deleted_at IS NULL
+			)))
+		ORDER BY key ASC, sequence DESC, id DESC
+		LIMIT $5
 	`
 
-	rows, err := r.db.Pool.Query(ctx, query, bucketID, opts.Prefix, opts.StartAfter, maxKeys+1)
+	rows, err := r.db.listConn(ctx).Query(ctx, query, bucketID, opts.Prefix, opts.StartAfter, versionIDMarker(opts.VersionIDMarker), maxKeys+1)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list versions: %w", err)
 	}
 	defer rows.Close()
 
-	var versions []*domain.ObjectVersion
-	var deleteMarkers []*domain.ObjectVersion
+	var listed []*domain.ObjectVersion
 
 	for rows.Next() {
 		ver := &domain.ObjectVersion{}
@@ -270,46 +296,43 @@ func (r *objectRepository) ListVersions(ctx context.Context, bucketID int64, opt
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan version: %w", err)
 		}
-		ver.VersionID = versionID.String()
+		ver.VersionID = domain.VersionIDString(versionID)
 		ver.IsDeleteMarker = isDeleteMarker
-
-		if isDeleteMarker {
-			deleteMarkers = append(deleteMarkers, ver)
-		} else {
-			versions = append(versions, ver)
-		}
+		listed = append(listed, ver)
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating versions: %w", err)
 	}
 
-	result := &repository.ObjectVersionListResult{
-		Versions:      versions,
-		DeleteMarkers: deleteMarkers,
-	}
+	return repository.NewObjectVersionListResult(listed, maxKeys), nil
+}
 
-	totalCount := len(versions) + len(deleteMarkers)
-	if totalCount > maxKeys {
-		result.IsTruncated = true
+// versionIDMarker returns the stored form of a version ID marker, which
+// names the null version "null".
+func versionIDMarker(marker string) string {
+	if versionID, err := domain.ParseVersionID(marker); err == nil {
+		return versionID.String()
 	}
-
-	return result, nil
+	return marker
 }
 
 // Update updates an existing object.
 func (r *objectRepository) Update(ctx context.Context, obj *domain.Object) error {
 	query := `
 		UPDATE objects
-		SET content_type = $2, metadata = $3, storage_class = $4
-		WHERE id = $1
+		SET content_type = $2, metadata = COALESCE($3::jsonb, '{}'), storage_class = $4, acl = $5
+		WHERE id = $1 AND bucket_id = $6
 	`
 
-	result, err := r.db.Pool.Exec(ctx, query,
+	// bucket_id limits the update to one partition of a partitioned table
+	result, err := r.db.conn(ctx).Exec(ctx, query,
 		obj.ID,
 		obj.ContentType,
 		obj.Metadata,
 		obj.StorageClass,
+		obj.ACL,
+		obj.BucketID,
 	)
 
 	if err != nil {
@@ -331,7 +354,7 @@ func (r *objectRepository) MarkNotLatest(ctx context.Context, bucketID int64, ke
 		WHERE bucket_id = $1 AND key = $2 AND is_latest = TRUE
 	`
 
-	_, err := r.db.Pool.Exec(ctx, query, bucketID, key)
+	_, err := r.db.conn(ctx).Exec(ctx, query, bucketID, key)
 	if err != nil {
 		return fmt.Errorf("failed to mark as not latest: %w", err)
 	}
@@ -339,11 +362,60 @@ func (r *objectRepository) MarkNotLatest(ctx context.Context, bucketID int64, ke
 	return nil
 }
 
-// Delete hard-deletes an object by ID.
+// MarkLatest marks an object as the latest version of its key.
+func (r *objectRepository) MarkLatest(ctx context.Context, id int64) error {
+	query := `UPDATE objects SET is_latest = TRUE WHERE id = $1 AND deleted_at IS NULL`
+
+	result, err := r.db.conn(ctx).Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark as latest: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrObjectNotFound
+	}
+
+	return nil
+}
+
+// NextSequence increments and returns the write sequence for a key. The
+// first write to a key numbers itself after the versions the object_sequence
+// backfill has not numbered yet, which it numbers from 1.
+func (r *objectRepository) NextSequence(ctx context.Context, bucketID int64, key string) (int64, error) {
+	query := `
+		UPDATE object_sequences SET sequence = sequence + 1
+		WHERE bucket_id = $1 AND key = $2
+		RETURNING sequence
+	`
+
+	var sequence int64
+	err := r.db.conn(ctx).QueryRow(ctx, query, bucketID, key).Scan(&sequence)
+	if err == nil {
+		return sequence, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return 0, fmt.Errorf("failed to get next sequence: %w", err)
+	}
+
+	query = `
+		INSERT INTO object_sequences (bucket_id, key, sequence)
+		SELECT $1::BIGINT, $2::VARCHAR, COUNT(*) + 1 FROM objects
+		WHERE bucket_id = $1 AND key = $2 AND sequence = 0
+		ON CONFLICT (bucket_id, key) DO UPDATE SET sequence = object_sequences.sequence + 1
+		RETURNING sequence
+	`
+	if err := r.db.conn(ctx).QueryRow(ctx, query, bucketID, key).Scan(&sequence); err != nil {
+		return 0, fmt.Errorf("failed to get next sequence: %w", err)
+	}
+
+	return sequence, nil
+}
+
+// Delete soft-deletes an object by ID.
 func (r *objectRepository) Delete(ctx context.Context, id int64) error {
 	query := `UPDATE objects SET deleted_at = $2 WHERE id = $1`
 
-	result, err := r.db.Pool.Exec(ctx, query, id, time.Now().UTC())
+	result, err := r.db.conn(ctx).Exec(ctx, query, id, time.Now().UTC())
 	if err != nil {
 		return fmt.Errorf("failed to delete object: %w", err)
 	}
@@ -355,32 +427,139 @@ func (r *objectRepository) Delete(ctx context.Context, id int64) error {
 	return nil
 }
 
-// DeleteAllVersions deletes all versions of an object.
-func (r *objectRepository) DeleteAllVersions(ctx context.Context, bucketID int64, key string) error {
-	query := `UPDATE objects SET deleted_at = $3 WHERE bucket_id = $1 AND key = $2`
+// DeleteAllVersions soft-deletes all versions of an object.
+func (r *objectRepository) DeleteAllVersions(ctx context.Context, bucketID int64, key string) ([]string, error) {
+	query := `
+		UPDATE objects SET deleted_at = $3
+		WHERE bucket_id = $1 AND key = $2 AND deleted_at IS NULL
+		RETURNING content_hash
+	`
 
-	_, err := r.db.Pool.Exec(ctx, query, bucketID, key, time.Now().UTC())
+	rows, err := r.db.conn(ctx).Query(ctx, query, bucketID, key, time.Now().UTC())
 	if err != nil {
-		return fmt.Errorf("failed to delete all versions: %w", err)
+		return nil, fmt.Errorf("failed to delete all versions: %w", err)
 	}
+	defer rows.Close()
 
-	return nil
+	var hashes []string
+	for rows.Next() {
+		var contentHash *string
+		if err := rows.Scan(&contentHash); err != nil {
+			return nil, fmt.Errorf("failed to scan content hash: %w", err)
+		}
+		if contentHash != nil {
+			hashes = append(hashes, *contentHash)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to delete all versions: %w", err)
+	}
+
+	return hashes, nil
+}
+
+// PurgeDeleted removes versions soft-deleted before the cutoff, oldest first.
+func (r *objectRepository) PurgeDeleted(ctx context.Context, before time.Time, limit int) (int64, error) {
+	query := `
+		DELETE FROM objects WHERE id IN (
+			SELECT id FROM objects
+			WHERE deleted_at IS NOT NULL AND deleted_at < $1
+			ORDER BY deleted_at
+			LIMIT $2
+		)
+	`
+
+	result, err := r.db.conn(ctx).Exec(ctx, query, before.UTC(), limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted objects: %w", err)
+	}
+
+	return result.RowsAffected(), nil
 }
 
 // CountByBucket returns the number of objects in a bucket.
 func (r *objectRepository) CountByBucket(ctx context.Context, bucketID int64) (int64, error) {
 	var count int64
-	err := r.db.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM objects WHERE bucket_id = $1 AND is_latest = TRUE AND deleted_at IS NULL`, bucketID).Scan(&count)
+	err := r.db.conn(ctx).QueryRow(ctx, `SELECT COUNT(*) FROM objects WHERE bucket_id = $1 AND is_latest = TRUE AND deleted_at IS NULL`, bucketID).Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("failed to count objects: %w", err)
 	}
 	return count, nil
 }
 
+// GetBucketStats returns the object, version and size totals of a bucket.
+func (r *objectRepository) GetBucketStats(ctx context.Context, bucketID int64, largest int) (*domain.BucketStats, error) {
+	stats := &domain.BucketStats{LargestObjects: []domain.ObjectSize{}}
+	err := r.db.conn(ctx).QueryRow(ctx, `
+		SELECT COUNT(*) FILTER (WHERE is_latest AND NOT is_delete_marker),
+		       COALESCE(SUM(size) FILTER (WHERE is_latest AND NOT is_delete_marker), 0),
+		       COUNT(*) FILTER (WHERE NOT is_delete_marker)
+		FROM objects
+		WHERE bucket_id = $1 AND deleted_at IS NULL
+	`, bucketID).Scan(&stats.Objects, &stats.LogicalBytes, &stats.Versions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bucket stats: %w", err)
+	}
+
+	err = r.db.conn(ctx).QueryRow(ctx, `
+		SELECT COALESCE(SUM(size), 0) FROM blobs
+		WHERE content_hash IN (
+			SELECT content_hash FROM objects
+			WHERE bucket_id = $1 AND deleted_at IS NULL AND content_hash IS NOT NULL
+		)
+	`, bucketID).Scan(&stats.PhysicalBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bucket physical size: %w", err)
+	}
+
+	if largest <= 0 {
+		return stats, nil
+	}
+	rows, err := r.db.conn(ctx).Query(ctx, `
+		SELECT key, size FROM objects
+		WHERE bucket_id = $1 AND is_latest AND NOT is_delete_marker AND deleted_at IS NULL
+		ORDER BY size DESC, key
+		LIMIT $2
+	`, bucketID, largest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list largest objects: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var obj domain.ObjectSize
+		if err := rows.Scan(&obj.Key, &obj.Size); err != nil {
+			return nil, fmt.Errorf("failed to scan object: %w", err)
+		}
+		stats.LargestObjects = append(stats.LargestObjects, obj)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list largest objects: %w", err)
+	}
+	return stats, nil
+}
+
+// GetStats returns the object, version and size totals of all buckets.
+func (r *objectRepository) GetStats(ctx context.Context) (*domain.ObjectStats, error) {
+	stats := &domain.ObjectStats{}
+	err := r.db.conn(ctx).QueryRow(ctx, `
+		SELECT COUNT(*) FILTER (WHERE is_latest AND NOT is_delete_marker),
+		       COALESCE(SUM(size) FILTER (WHERE is_latest AND NOT is_delete_marker), 0),
+		       COUNT(*) FILTER (WHERE NOT is_delete_marker),
+		       COUNT(*) FILTER (WHERE is_delete_marker)
+		FROM objects
+		WHERE deleted_at IS NULL
+	`).Scan(&stats.Objects, &stats.LogicalBytes, &stats.Versions, &stats.DeleteMarkers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object stats: %w", err)
+	}
+	return stats, nil
+}
+
 // GetContentHashForVersion retrieves the content hash for a specific version.
 func (r *objectRepository) GetContentHashForVersion(ctx context.Context, bucketID int64, key string, versionID uuid.UUID) (*string, error) {
 	var contentHash *string
-	err := r.db.Pool.QueryRow(ctx, `SELECT content_hash FROM objects WHERE bucket_id = $1 AND key = $2 AND version_id = $3`, bucketID, key, versionID).Scan(&contentHash)
+	err := r.db.conn(ctx).QueryRow(ctx, `SELECT content_hash FROM objects WHERE bucket_id = $1 AND key = $2 AND version_id = $3 AND deleted_at IS NULL`, bucketID, key, versionID).Scan(&contentHash)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, domain.ErrObjectNotFound
@@ -390,27 +569,96 @@ func (r *objectRepository) GetContentHashForVersion(ctx context.Context, bucketI
 	return contentHash, nil
 }
 
-// ListExpiredObjects returns latest objects older than cutoff, with optional prefix.
+// objectSummaryColumns are the columns scanned by listObjects.
+const objectSummaryColumns = `o.id, o.bucket_id, o.key, o.version_id, o.is_latest, o.is_delete_marker,
+	o.content_hash, o.size, o.content_type, o.etag, o.storage_class, o.metadata, o.created_at, o.deleted_at`
+
+// ListExpiredObjects returns latest objects created before cutoff that match
+// the filter's prefix and size bounds.
 // Used by lifecycle service for expiration processing.
-func (r *objectRepository) ListExpiredObjects(ctx context.Context, bucketID int64, prefix string, olderThan time.Time, limit int) ([]*domain.Object, error) {
+func (r *objectRepository) ListExpiredObjects(ctx context.Context, bucketID int64, filter domain.LifecycleFilter, olderThan time.Time, limit int) ([]*domain.Object, error) {
 	query := `
-		SELECT id, bucket_id, key, version_id, is_latest, is_delete_marker, 
-			content_hash, size, content_type, etag, storage_class, metadata, created_at, deleted_at
-		FROM objects
-		WHERE bucket_id = $1 
-			AND is_latest = TRUE 
-			AND is_delete_marker = FALSE
-			AND deleted_at IS NULL
-			AND created_at < $2
-			AND ($3 = '' OR key LIKE $3 || '%')
-		ORDER BY created_at ASC
-		LIMIT $4
+		SELECT ` + objectSummaryColumns + `
+		FROM objects o
+		WHERE o.bucket_id = $1
+			AND o.is_latest = TRUE
+			AND o.is_delete_marker = FALSE
+			AND o.deleted_at IS NULL
+			AND o.created_at < $2
+			AND ($3 = '' OR starts_with(o.key, $3))
+			AND ($4::BIGINT IS NULL OR o.size > $4)
+			AND ($5::BIGINT IS NULL OR o.size < $5)
+		ORDER BY o.created_at ASC
+		LIMIT $6
 	`
 
-	rows, err := r.db.Pool.Query(ctx, query, bucketID, olderThan, prefix, limit)
+	objects, err := r.listObjects(ctx, query, bucketID, olderThan, filter.Prefix, filter.ObjectSizeGreaterThan, filter.ObjectSizeLessThan, limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list expired objects: %w", err)
 	}
+	return objects, nil
+}
+
+// ListNoncurrentVersions returns versions superseded before the cutoff that
+// match the filter's prefix and size bounds. A version becomes noncurrent
+// when the next version of its key is created.
+func (r *objectRepository) ListNoncurrentVersions(ctx context.Context, bucketID int64, filter domain.LifecycleFilter, noncurrentBefore time.Time, limit int) ([]*domain.Object, error) {
+	query := `
+		SELECT ` + objectSummaryColumns + `
+		FROM objects o
+		WHERE o.bucket_id = $1
+			AND o.is_latest = FALSE
+			AND o.deleted_at IS NULL
+			AND ($2 = '' OR starts_with(o.key, $2))
+			AND ($3::BIGINT IS NULL OR o.size > $3)
+			AND ($4::BIGINT IS NULL OR o.size < $4)
+			AND (
+				SELECT MIN(n.created_at) FROM objects n
+				WHERE n.bucket_id = o.bucket_id AND n.key = o.key AND n.id > o.id
+			) < $5
+		ORDER BY o.created_at ASC
+		LIMIT $6
+	`
+
+	objects, err := r.listObjects(ctx, query, bucketID, filter.Prefix, filter.ObjectSizeGreaterThan, filter.ObjectSizeLessThan, noncurrentBefore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list noncurrent versions: %w", err)
+	}
+	return objects, nil
+}
+
+// ListExpiredDeleteMarkers returns latest delete markers under prefix that
+// are the only remaining version of their key.
+func (r *objectRepository) ListExpiredDeleteMarkers(ctx context.Context, bucketID int64, prefix string, limit int) ([]*domain.Object, error) {
+	query := `
+		SELECT ` + objectSummaryColumns + `
+		FROM objects o
+		WHERE o.bucket_id = $1
+			AND o.is_latest = TRUE
+			AND o.is_delete_marker = TRUE
+			AND o.deleted_at IS NULL
+			AND ($2 = '' OR starts_with(o.key, $2))
+			AND NOT EXISTS (
+				SELECT 1 FROM objects n
+				WHERE n.bucket_id = o.bucket_id AND n.key = o.key AND n.id <> o.id AND n.deleted_at IS NULL
+			)
+		ORDER BY o.created_at ASC
+		LIMIT $3
+	`
+
+	objects, err := r.listObjects(ctx, query, bucketID, prefix, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired delete markers: %w", err)
+	}
+	return objects, nil
+}
+
+// listObjects returns the objects a query selecting objectSummaryColumns returns.
+func (r *objectRepository) listObjects(ctx context.Context, query string, args ...any) ([]*domain.Object, error) {
+	rows, err := r.db.conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
 
 	var objects []*domain.Object
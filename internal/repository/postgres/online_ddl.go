@@ -0,0 +1,30 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ddlLockTimeout bounds how long online DDL waits for its table lock.
+const ddlLockTimeout = 5 * time.Second
+
+// execDDL runs schema changes in one transaction with a lock timeout.
+// ALTER TABLE needs a brief exclusive lock; without a timeout it would queue
+// behind a long-running query and block every query that arrives after it.
+// On a timeout the statements fail and can simply be retried.
+func (db *DB) execDDL(ctx context.Context, statements ...string) error {
+	return db.WithTx(ctx, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, fmt.Sprintf("SET LOCAL lock_timeout = '%dms'", ddlLockTimeout.Milliseconds())); err != nil {
+			return err
+		}
+		for _, statement := range statements {
+			if _, err := tx.Exec(ctx, statement); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,279 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// MaxObjectPartitions bounds the number of hash partitions of objects.
+	MaxObjectPartitions = 256
+
+	// partitionLockTimeout bounds how long PartitionObjects waits for its
+	// exclusive lock on objects.
+	partitionLockTimeout = 30 * time.Second
+)
+
+// partitionAutovacuum makes autovacuum visit a partition after 2% of its
+// rows changed instead of the default 20%. Every overwrite of a key leaves
+// a dead tuple behind (MarkNotLatest updates the previous version), which in
+// a large partition would otherwise accumulate for a long time.
+const partitionAutovacuum = `autovacuum_vacuum_scale_factor = 0.02, autovacuum_analyze_scale_factor = 0.01`
+
+// ObjectPartitions returns the number of hash partitions of the objects
+// table, or 0 if it is not partitioned.
+func (db *DB) ObjectPartitions(ctx context.Context) (int, error) {
+	var partitions int
+	err := db.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM pg_inherits WHERE inhparent = 'objects'::regclass`).Scan(&partitions)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count object partitions: %w", err)
+	}
+	return partitions, nil
+}
+
+// PartitionObjects converts the objects table into a table hash-partitioned
+// by bucket_id, so that ListVersions, MarkNotLatest and the other queries of
+// a bucket only scan and lock its partition, and vacuum works through one
+// partition at a time.
+//
+// Rows, indexes, constraints and the ID sequence are carried over in a
+// single transaction, which holds an exclusive lock on objects while it
+// copies every row: stop the servers first. The primary key becomes
+// (id, bucket_id), since a partitioned table's unique indexes must include
+// the partition key. Backfills rewrite objects in place and must have
+// completed.
+func (db *DB) PartitionObjects(ctx context.Context, partitions int) error {
+	if partitions < 2 || partitions > MaxObjectPartitions {
+		return fmt.Errorf("partitions must be between 2 and %d", MaxObjectPartitions)
+	}
+
+	current, err := db.ObjectPartitions(ctx)
+	if err != nil {
+		return err
+	}
+	if current > 0 {
+		return fmt.Errorf("objects is already partitioned into %d partitions", current)
+	}
+	if err := db.checkBackfillsCompleted(ctx); err != nil {
+		return err
+	}
+
+	err = db.WithTx(ctx, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, fmt.Sprintf("SET LOCAL lock_timeout = '%dms'", partitionLockTimeout.Milliseconds())); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `LOCK TABLE objects IN ACCESS EXCLUSIVE MODE`); err != nil {
+			return fmt.Errorf("failed to lock objects: %w", err)
+		}
+
+		indexes, foreignKeys, err := objectsSchema(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		for _, statement := range partitionStatements(partitions, indexes, foreignKeys) {
+			if _, err := tx.Exec(ctx, statement); err != nil {
+				return fmt.Errorf("failed to partition objects: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	db.logger.Info().Int("partitions", partitions).Msg("partitioned objects table by bucket")
+	return nil
+}
+
+// partitionStatements returns the statements replacing objects with a
+// table of the given number of hash partitions, given the statements
+// recreating its secondary indexes and foreign keys.
+func partitionStatements(partitions int, indexes, foreignKeys []string) []string {
+	// LIKE copies the columns with their defaults, including the ID
+	// sequence, and the check constraints; indexes and foreign keys are
+	// recreated below under their original names
+	statements := []string{
+		`CREATE TABLE objects_partitioned (LIKE objects INCLUDING DEFAULTS INCLUDING CONSTRAINTS INCLUDING STORAGE INCLUDING COMMENTS)
+			PARTITION BY HASH (bucket_id)`,
+	}
+	for i := 0; i < partitions; i++ {
+		statements = append(statements, fmt.Sprintf(
+			`CREATE TABLE objects_p%d PARTITION OF objects_partitioned FOR VALUES WITH (MODULUS %d, REMAINDER %d) WITH (%s)`,
+			i, partitions, i, partitionAutovacuum))
+	}
+	statements = append(statements,
+		`INSERT INTO objects_partitioned SELECT * FROM objects`,
+		`ALTER SEQUENCE objects_id_seq OWNED BY objects_partitioned.id`,
+		`DROP TABLE objects`,
+		`ALTER TABLE objects_partitioned RENAME TO objects`,
+		`ALTER TABLE objects ADD CONSTRAINT objects_pkey PRIMARY KEY (id, bucket_id)`,
+	)
+	statements = append(statements, indexes...)
+	statements = append(statements, foreignKeys...)
+	return append(statements, `ANALYZE objects`)
+}
+
+// objectsSchema returns the statements recreating the secondary indexes
+// and the foreign keys of objects.
+func objectsSchema(ctx context.Context, tx pgx.Tx) (indexes, foreignKeys []string, err error) {
+	rows, err := tx.Query(ctx, `
+		SELECT pg_get_indexdef(i.indexrelid)
+		FROM pg_index i
+		WHERE i.indrelid = 'objects'::regclass AND NOT i.indisprimary
+		ORDER BY i.indexrelid
+	`)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list object indexes: %w", err)
+	}
+	indexes, err = pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list object indexes: %w", err)
+	}
+
+	rows, err = tx.Query(ctx, `
+		SELECT format('ALTER TABLE objects ADD CONSTRAINT %I %s', conname, pg_get_constraintdef(oid))
+		FROM pg_constraint
+		WHERE conrelid = 'objects'::regclass AND contype = 'f'
+		ORDER BY conname
+	`)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list object foreign keys: %w", err)
+	}
+	foreignKeys, err = pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list object foreign keys: %w", err)
+	}
+	return indexes, foreignKeys, nil
+}
+
+// checkBackfillsCompleted returns an error if a backfill of the driver has
+// not completed.
+func (db *DB) checkBackfillsCompleted(ctx context.Context) error {
+	progress, err := NewBackfillRepository(db).List(ctx)
+	if err != nil {
+		return err
+	}
+	completed := make(map[string]bool, len(progress))
+	for _, p := range progress {
+		completed[p.Name] = p.IsCompleted()
+	}
+	for _, backfill := range Backfills(db) {
+		if !completed[backfill.Name] {
+			return fmt.Errorf("backfill %s has not completed; run \"alexander-admin backfill run\" first", backfill.Name)
+		}
+	}
+	return nil
+}
+
+// TableMaintenance reports the maintenance of one table.
+type TableMaintenance struct {
+	Table string `json:"table"`
+
+	// The tuple counts are the statistics collector's estimates, before and
+	// after the table was vacuumed.
+	LiveTuples       int64 `json:"live_tuples"`
+	DeadTuplesBefore int64 `json:"dead_tuples_before"`
+	DeadTuplesAfter  int64 `json:"dead_tuples_after"`
+
+	Reindexed bool          `json:"reindexed"`
+	Duration  time.Duration `json:"duration"`
+}
+
+// MaintainObjects vacuums and analyzes the objects table, one partition at
+// a time if it is partitioned, which removes the dead tuples that replaced
+// and deleted versions leave behind and refreshes the planner statistics.
+// With reindex, the indexes are rebuilt concurrently too, which shrinks
+// indexes bloated by those dead tuples without blocking writes.
+func (db *DB) MaintainObjects(ctx context.Context, reindex bool) ([]TableMaintenance, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT c.relname
+		FROM pg_class c
+		WHERE c.oid = 'objects'::regclass AND c.relkind = 'r'
+		UNION ALL
+		SELECT c.relname
+		FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		WHERE i.inhparent = 'objects'::regclass
+		ORDER BY 1
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list object tables: %w", err)
+	}
+	tables, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to list object tables: %w", err)
+	}
+
+	results := make([]TableMaintenance, 0, len(tables))
+	for _, table := range tables {
+		result, err := db.maintainTable(ctx, table, reindex)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, *result)
+	}
+	if len(tables) > 1 {
+		// The parent's statistics cover all partitions; autovacuum never
+		// analyzes a partitioned table by itself
+		if _, err := db.Pool.Exec(ctx, `ANALYZE objects`); err != nil {
+			return results, fmt.Errorf("failed to analyze objects: %w", err)
+		}
+	}
+	return results, nil
+}
+
+// maintainTable vacuums, analyzes and optionally reindexes one table.
+// VACUUM and REINDEX CONCURRENTLY cannot run in a transaction, so each
+// statement runs on its own.
+func (db *DB) maintainTable(ctx context.Context, table string, reindex bool) (*TableMaintenance, error) {
+	start := time.Now()
+	result := &TableMaintenance{Table: table, Reindexed: reindex}
+	identifier := pgx.Identifier{table}.Sanitize()
+
+	var err error
+	if result.DeadTuplesBefore, _, err = db.tupleCounts(ctx, table); err != nil {
+		return nil, err
+	}
+	if _, err := db.Pool.Exec(ctx, `VACUUM (ANALYZE) `+identifier); err != nil {
+		return nil, fmt.Errorf("failed to vacuum %s: %w", table, err)
+	}
+	if reindex {
+		if _, err := db.Pool.Exec(ctx, `REINDEX TABLE CONCURRENTLY `+identifier); err != nil {
+			return nil, fmt.Errorf("failed to reindex %s: %w", table, err)
+		}
+	}
+	if result.DeadTuplesAfter, result.LiveTuples, err = db.tupleCounts(ctx, table); err != nil {
+		return nil, err
+	}
+	result.Duration = time.Since(start)
+
+	db.logger.Info().
+		Str("table", table).
+		Int64("dead_tuples_before", result.DeadTuplesBefore).
+		Int64("dead_tuples_after", result.DeadTuplesAfter).
+		Bool("reindexed", reindex).
+		Dur("duration", result.Duration).
+		Msg("maintained table")
+	return result, nil
+}
+
+// tupleCounts returns the estimated dead and live tuples of a table.
+func (db *DB) tupleCounts(ctx context.Context, table string) (dead, live int64, err error) {
+	err = db.Pool.QueryRow(ctx, `
+		SELECT n_dead_tup, n_live_tup
+		FROM pg_stat_user_tables
+		WHERE schemaname = current_schema() AND relname = $1
+	`, table).Scan(&dead, &live)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read table statistics: %w", err)
+	}
+	return dead, live, nil
+}
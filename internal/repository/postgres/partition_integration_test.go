@@ -0,0 +1,154 @@
+//go:build integration
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/migration"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// newTestDB connects to the database of ALEXANDER_TEST_POSTGRES_DSN and
+// migrates a schema of its own, dropped when the test ends. The test is
+// skipped without the variable.
+func newTestDB(t *testing.T) *DB {
+	t.Helper()
+	dsn := os.Getenv("ALEXANDER_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("ALEXANDER_TEST_POSTGRES_DSN is not set")
+	}
+	ctx := context.Background()
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+
+	admin, err := pgx.Connect(ctx, dsn)
+	require.NoError(t, err)
+	_, err = admin.Exec(ctx, "CREATE SCHEMA "+schema)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, _ = admin.Exec(context.Background(), "DROP SCHEMA "+schema+" CASCADE")
+		admin.Close(context.Background())
+	})
+
+	poolConfig, err := pgxpool.ParseConfig(dsn)
+	require.NoError(t, err)
+	// Extensions are installed database-wide, usually in public
+	poolConfig.ConnConfig.RuntimeParams["search_path"] = schema + ", public"
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	files, err := filepath.Glob(filepath.Join("..", "..", "..", "migrations", "postgres", "*.up.sql"))
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+	sort.Strings(files)
+	for _, file := range files {
+		statements, err := os.ReadFile(file)
+		require.NoError(t, err)
+		_, err = pool.Exec(ctx, string(statements))
+		require.NoError(t, err, file)
+	}
+
+	db := &DB{Pool: pool, logger: zerolog.Nop()}
+	runner := migration.NewBackfillRunner(NewBackfillRepository(db), NewTxManager(db), zerolog.Nop(), migration.BackfillConfig{Pause: time.Millisecond})
+	require.NoError(t, runner.RunAll(ctx, Backfills(db)))
+	return db
+}
+
+func TestPartitionObjects(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	user := domain.NewUser("owner", "owner@example.com", "hash")
+	require.NoError(t, NewUserRepository(db).Create(ctx, user))
+	bucketRepo := NewBucketRepository(db)
+	photos := domain.NewBucket(user.ID, "photos")
+	require.NoError(t, bucketRepo.Create(ctx, photos))
+	logs := domain.NewBucket(user.ID, "logs")
+	require.NoError(t, bucketRepo.Create(ctx, logs))
+
+	hash := strings.Repeat("ab", 32)
+	_, err := NewBlobRepository(db).UpsertWithRefIncrement(ctx, hash, 4, "ab/ab/"+hash)
+	require.NoError(t, err)
+
+	objectRepo := NewObjectRepository(db)
+	put := func(bucket *domain.Bucket, key string) *domain.Object {
+		t.Helper()
+		require.NoError(t, objectRepo.MarkNotLatest(ctx, bucket.ID, key))
+		obj := domain.NewObject(bucket.ID, key, hash, "image/jpeg", `"etag"`, 4)
+		require.NoError(t, objectRepo.Create(ctx, obj))
+		return obj
+	}
+	put(photos, "cat.jpg")
+	second := put(photos, "cat.jpg")
+	put(logs, "today.log")
+
+	partitions, err := db.ObjectPartitions(ctx)
+	require.NoError(t, err)
+	require.Zero(t, partitions)
+
+	require.NoError(t, db.PartitionObjects(ctx, 4))
+	partitions, err = db.ObjectPartitions(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 4, partitions)
+	require.ErrorContains(t, db.PartitionObjects(ctx, 4), "already partitioned into 4 partitions")
+
+	// The rows, indexes and ID sequence were carried over
+	latest, err := objectRepo.GetByKey(ctx, photos.ID, "cat.jpg")
+	require.NoError(t, err)
+	require.Equal(t, second.ID, latest.ID)
+	versions, err := objectRepo.ListVersions(ctx, photos.ID, repository.ObjectListOptions{MaxKeys: 10})
+	require.NoError(t, err)
+	require.Len(t, versions.Versions, 2)
+
+	latest.ContentType = "image/png"
+	require.NoError(t, objectRepo.Update(ctx, latest))
+	latest, err = objectRepo.GetByKey(ctx, photos.ID, "cat.jpg")
+	require.NoError(t, err)
+	require.Equal(t, "image/png", latest.ContentType)
+
+	third := put(photos, "cat.jpg")
+	require.Greater(t, third.ID, second.ID)
+
+	var indexes int
+	require.NoError(t, db.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM pg_index WHERE indrelid = 'objects'::regclass`).Scan(&indexes))
+	require.Greater(t, indexes, 1, "secondary indexes were recreated")
+
+	// The bucket's foreign key still holds
+	orphan := domain.NewObject(photos.ID+logs.ID+100, "orphan", hash, "image/jpeg", `"etag"`, 4)
+	require.Error(t, objectRepo.Create(ctx, orphan))
+
+	// Maintenance visits each partition rather than the parent
+	results, err := db.MaintainObjects(ctx, true)
+	require.NoError(t, err)
+	tables := make([]string, len(results))
+	for i, result := range results {
+		tables[i] = result.Table
+		assert.True(t, result.Reindexed)
+	}
+	require.Equal(t, []string{"objects_p0", "objects_p1", "objects_p2", "objects_p3"}, tables)
+
+	// Snapshots copy the partitions through the parent
+	err = db.WithTx(ctx, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		snapshot, err := snapshotTables(ctx, tx)
+		require.NoError(t, err)
+		assert.Contains(t, snapshot, "objects")
+		assert.NotContains(t, snapshot, "objects_p0")
+		return nil
+	})
+	require.NoError(t, err)
+}
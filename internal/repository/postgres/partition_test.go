@@ -0,0 +1,59 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartitionStatements(t *testing.T) {
+	indexes := []string{"CREATE INDEX idx_objects_bucket_key ON public.objects USING btree (bucket_id, key)"}
+	foreignKeys := []string{"ALTER TABLE objects ADD CONSTRAINT objects_bucket_id_fkey FOREIGN KEY (bucket_id) REFERENCES buckets(id)"}
+	statements := partitionStatements(4, indexes, foreignKeys)
+
+	require.True(t, strings.HasPrefix(statements[0], "CREATE TABLE objects_partitioned (LIKE objects"))
+	assert.Contains(t, statements[0], "PARTITION BY HASH (bucket_id)")
+	for i := 0; i < 4; i++ {
+		assert.Equal(t, fmt.Sprintf(
+			"CREATE TABLE objects_p%d PARTITION OF objects_partitioned FOR VALUES WITH (MODULUS 4, REMAINDER %d) WITH (%s)",
+			i, i, partitionAutovacuum), statements[1+i])
+	}
+
+	// The rows are copied before the old table is dropped, and the indexes
+	// and foreign keys are recreated once the new table has its name
+	position := func(statement string) int {
+		for i, s := range statements {
+			if s == statement {
+				return i
+			}
+		}
+		t.Fatalf("missing statement %q", statement)
+		return -1
+	}
+	insert := position("INSERT INTO objects_partitioned SELECT * FROM objects")
+	sequence := position("ALTER SEQUENCE objects_id_seq OWNED BY objects_partitioned.id")
+	drop := position("DROP TABLE objects")
+	rename := position("ALTER TABLE objects_partitioned RENAME TO objects")
+	primaryKey := position("ALTER TABLE objects ADD CONSTRAINT objects_pkey PRIMARY KEY (id, bucket_id)")
+	assert.Less(t, insert, sequence)
+	assert.Less(t, sequence, drop, "dropping objects would drop the sequence it owns")
+	assert.Less(t, drop, rename)
+	assert.Less(t, rename, primaryKey)
+	assert.Less(t, primaryKey, position(indexes[0]))
+	assert.Less(t, position(indexes[0]), position(foreignKeys[0]))
+	assert.Equal(t, "ANALYZE objects", statements[len(statements)-1])
+}
+
+func TestPartitionObjects_Count(t *testing.T) {
+	// The count is checked before the database is queried
+	db := &DB{logger: zerolog.Nop()}
+	for _, partitions := range []int{-1, 0, 1, MaxObjectPartitions + 1} {
+		err := db.PartitionObjects(context.Background(), partitions)
+		assert.ErrorContains(t, err, "partitions must be between 2", partitions)
+	}
+}
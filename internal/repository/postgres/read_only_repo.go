@@ -0,0 +1,49 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// readOnlyRepository implements repository.ReadOnlyRepository.
+type readOnlyRepository struct {
+	db *DB
+}
+
+// NewReadOnlyRepository creates a new PostgreSQL read-only mode repository.
+func NewReadOnlyRepository(db *DB) repository.ReadOnlyRepository {
+	return &readOnlyRepository{db: db}
+}
+
+// Get reports whether read-only mode is on.
+func (r *readOnlyRepository) Get(ctx context.Context) (bool, error) {
+	var enabled bool
+	err := r.db.conn(ctx).QueryRow(ctx, `SELECT enabled FROM read_only_mode WHERE id = 1`).Scan(&enabled)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get read-only mode: %w", err)
+	}
+	return enabled, nil
+}
+
+// Set turns read-only mode on or off.
+func (r *readOnlyRepository) Set(ctx context.Context, enabled bool) error {
+	_, err := r.db.conn(ctx).Exec(ctx, `
+		INSERT INTO read_only_mode (id, enabled, updated_at) VALUES (1, $1, NOW())
+		ON CONFLICT (id) DO UPDATE SET enabled = EXCLUDED.enabled, updated_at = EXCLUDED.updated_at
+	`, enabled)
+	if err != nil {
+		return fmt.Errorf("failed to set read-only mode: %w", err)
+	}
+	return nil
+}
+
+// Ensure readOnlyRepository implements repository.ReadOnlyRepository
+var _ repository.ReadOnlyRepository = (*readOnlyRepository)(nil)
@@ -0,0 +1,215 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/config"
+	"github.com/prn-tf/alexander-storage/internal/pkg/redact"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// ReplicaObserver receives read replica health and routing decisions, e.g.
+// to export them as metrics.
+type ReplicaObserver interface {
+	// RecordReplicaLag is called after every lag check. available is false
+	// if the replica could not be reached.
+	RecordReplicaLag(lag time.Duration, available bool)
+
+	// RecordListRead is called for every listing with the database that
+	// served it ("replica" or "primary") and why.
+	RecordListRead(target, reason string)
+}
+
+// Reasons reported to ReplicaObserver.RecordListRead.
+const (
+	listReasonReplica     = "replica"     // served by the replica
+	listReasonRequested   = "requested"   // the caller asked for the primary
+	listReasonConfigured  = "configured"  // list_from_primary is set
+	listReasonLagging     = "lagging"     // replica lag exceeds max_lag
+	listReasonUnavailable = "unavailable" // the last lag check failed
+)
+
+// replicaLagQuery returns the replica's replay lag in seconds. A replica
+// that has replayed everything it received is not lagging, even if the
+// primary has been idle since the last replayed transaction.
+const replicaLagQuery = `
+	SELECT CASE
+		WHEN NOT pg_is_in_recovery() THEN 0
+		WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+		ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+	END
+`
+
+// replica is a read replica connection pool with a background lag check.
+type replica struct {
+	pool            *pgxpool.Pool
+	maxLag          time.Duration
+	interval        time.Duration
+	listFromPrimary bool
+	logger          zerolog.Logger
+
+	// lag is the last measured lag in nanoseconds, or -1 if the replica
+	// could not be reached.
+	lag      atomic.Int64
+	observer atomic.Pointer[ReplicaObserver]
+
+	stopChan chan struct{}
+	doneChan chan struct{}
+	stopOnce sync.Once
+}
+
+// newReplica connects to the read replica and starts checking its lag. An
+// unreachable replica is not an error: listings use the primary until it
+// becomes reachable.
+func newReplica(ctx context.Context, cfg config.DatabaseConfig, logger zerolog.Logger) (*replica, error) {
+	poolConfig, err := pgxpool.ParseConfig(cfg.ReplicaDSN())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse replica config: %w", redact.Error(err))
+	}
+	poolConfig.MaxConns = int32(cfg.MaxOpenConns)
+	poolConfig.MaxConnLifetime = cfg.ConnMaxLifetime
+	poolConfig.MaxConnIdleTime = cfg.ConnMaxIdleTime
+	poolConfig.ConnConfig.ConnectTimeout = 10 * time.Second
+	poolConfig.ConnConfig.Tracer = newQueryTracer(logger, cfg.SlowQueryThreshold)
+	configureStatementCache(poolConfig.ConnConfig, cfg.StatementCacheSize)
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create replica connection pool: %w", redact.Error(err))
+	}
+
+	r := &replica{
+		pool:            pool,
+		maxLag:          cfg.Replica.MaxLag,
+		interval:        cfg.Replica.CheckInterval,
+		listFromPrimary: cfg.Replica.ListFromPrimary,
+		logger:          logger.With().Str("component", "replica").Str("host", cfg.Replica.Host).Logger(),
+		stopChan:        make(chan struct{}),
+		doneChan:        make(chan struct{}),
+	}
+	r.lag.Store(-1)
+	r.check(ctx)
+
+	go r.run()
+	return r, nil
+}
+
+// run checks the lag until close is called.
+func (r *replica) run() {
+	defer close(r.doneChan)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stopChan:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), r.interval)
+			r.check(ctx)
+			cancel()
+		}
+	}
+}
+
+// check measures the lag and logs when the replica becomes unavailable or
+// starts or stops lagging.
+func (r *replica) check(ctx context.Context) {
+	var seconds float64
+	lag := int64(-1)
+	err := r.pool.QueryRow(ctx, replicaLagQuery).Scan(&seconds)
+	if err == nil {
+		lag = int64(seconds * float64(time.Second))
+	}
+
+	previous := r.lag.Swap(lag)
+	switch {
+	case err != nil && previous != -1:
+		r.logger.Warn().Err(redact.Error(err)).Msg("Read replica unavailable; listing from primary")
+	case err == nil && previous == -1:
+		r.logger.Info().Dur("lag", time.Duration(lag)).Msg("Read replica available")
+	case err == nil && lag > int64(r.maxLag) && previous <= int64(r.maxLag):
+		r.logger.Warn().Dur("lag", time.Duration(lag)).Msg("Read replica lagging; listing from primary")
+	case err == nil && lag <= int64(r.maxLag) && previous > int64(r.maxLag):
+		r.logger.Info().Dur("lag", time.Duration(lag)).Msg("Read replica caught up")
+	}
+
+	if observer := r.observer.Load(); observer != nil {
+		(*observer).RecordReplicaLag(time.Duration(max(lag, 0)), err == nil)
+	}
+}
+
+// route decides whether a listing with the given context may use the replica.
+func (r *replica) route(ctx context.Context) (useReplica bool, reason string) {
+	lag := r.lag.Load()
+	switch {
+	case repository.ReadPreferenceFromContext(ctx) != repository.ReadReplica:
+		return false, listReasonRequested
+	case r.listFromPrimary:
+		return false, listReasonConfigured
+	case lag < 0:
+		return false, listReasonUnavailable
+	case lag > int64(r.maxLag):
+		return false, listReasonLagging
+	}
+	return true, listReasonReplica
+}
+
+// close stops the lag check and closes the pool.
+func (r *replica) close() {
+	r.stopOnce.Do(func() {
+		close(r.stopChan)
+		<-r.doneChan
+		r.pool.Close()
+	})
+}
+
+// SetReplicaObserver registers an observer of the read replica. It does
+// nothing if no replica is configured.
+func (db *DB) SetReplicaObserver(observer ReplicaObserver) {
+	if db.replica != nil {
+		db.replica.observer.Store(&observer)
+	}
+}
+
+// ReplicaPool returns the read replica's connection pool, or nil if no
+// replica is configured.
+func (db *DB) ReplicaPool() *pgxpool.Pool {
+	if db.replica == nil {
+		return nil
+	}
+	return db.replica.pool
+}
+
+// listConn returns the connection for a listing: the transaction bound to
+// ctx, the read replica if ctx allows it and the replica is within its lag
+// limit, or else the primary.
+func (db *DB) listConn(ctx context.Context) querier {
+	if tx, ok := txFromContext(ctx); ok {
+		return tx
+	}
+	if db.replica == nil {
+		return db.Pool
+	}
+
+	useReplica, reason := db.replica.route(ctx)
+	if observer := db.replica.observer.Load(); observer != nil {
+		target := "primary"
+		if useReplica {
+			target = "replica"
+		}
+		(*observer).RecordListRead(target, reason)
+	}
+	if useReplica {
+		return db.replica.pool
+	}
+	return db.Pool
+}
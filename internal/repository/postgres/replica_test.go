@@ -0,0 +1,44 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+func TestReplicaRoute(t *testing.T) {
+	replicaCtx := repository.WithReadPreference(context.Background(), repository.ReadReplica)
+	primaryCtx := repository.WithReadPreference(context.Background(), repository.ReadPrimary)
+
+	tests := []struct {
+		name            string
+		ctx             context.Context
+		lag             time.Duration
+		listFromPrimary bool
+		wantReplica     bool
+		wantReason      string
+	}{
+		{"within max lag", replicaCtx, time.Second, false, true, listReasonReplica},
+		{"at max lag", replicaCtx, 5 * time.Second, false, true, listReasonReplica},
+		{"lagging", replicaCtx, 6 * time.Second, false, false, listReasonLagging},
+		{"unavailable", replicaCtx, -1, false, false, listReasonUnavailable},
+		{"primary requested", primaryCtx, 0, false, false, listReasonRequested},
+		{"no preference", context.Background(), 0, false, false, listReasonRequested},
+		{"list from primary configured", replicaCtx, 0, true, false, listReasonConfigured},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &replica{maxLag: 5 * time.Second, listFromPrimary: tt.listFromPrimary}
+			r.lag.Store(int64(tt.lag))
+
+			useReplica, reason := r.route(tt.ctx)
+			assert.Equal(t, tt.wantReplica, useReplica)
+			assert.Equal(t, tt.wantReason, reason)
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// replicationRepository implements repository.ReplicationRepository.
+type replicationRepository struct {
+	db *DB
+}
+
+// NewReplicationRepository creates a new PostgreSQL replication repository.
+func NewReplicationRepository(db *DB) repository.ReplicationRepository {
+	return &replicationRepository{db: db}
+}
+
+// PutConfig creates or replaces the configuration of a bucket.
+func (r *replicationRepository) PutConfig(ctx context.Context, config *domain.ReplicationConfig) error {
+	query := `
+		INSERT INTO bucket_replication (bucket_id, endpoint, region, destination_bucket,
+			access_key_id, encrypted_secret_key, rules, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (bucket_id) DO UPDATE SET
+			endpoint = EXCLUDED.endpoint,
+			region = EXCLUDED.region,
+			destination_bucket = EXCLUDED.destination_bucket,
+			access_key_id = EXCLUDED.access_key_id,
+			encrypted_secret_key = EXCLUDED.encrypted_secret_key,
+			rules = EXCLUDED.rules,
+			updated_at = EXCLUDED.updated_at
+		RETURNING created_at
+	`
+
+	err := r.db.conn(ctx).QueryRow(ctx, query,
+		config.BucketID,
+		config.Endpoint,
+		config.Region,
+		config.DestinationBucket,
+		config.AccessKeyID,
+		config.EncryptedSecretKey,
+		config.Rules,
+		config.CreatedAt,
+		config.UpdatedAt,
+	).Scan(&config.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to put replication config: %w", err)
+	}
+
+	return nil
+}
+
+// GetConfig returns the configuration of a bucket.
+func (r *replicationRepository) GetConfig(ctx context.Context, bucketID int64) (*domain.ReplicationConfig, error) {
+	query := `
+		SELECT bucket_id, endpoint, region, destination_bucket, access_key_id,
+			encrypted_secret_key, rules, created_at, updated_at
+		FROM bucket_replication
+		WHERE bucket_id = $1
+	`
+
+	config := &domain.ReplicationConfig{}
+	err := r.db.conn(ctx).QueryRow(ctx, query, bucketID).Scan(
+		&config.BucketID,
+		&config.Endpoint,
+		&config.Region,
+		&config.DestinationBucket,
+		&config.AccessKeyID,
+		&config.EncryptedSecretKey,
+		&config.Rules,
+		&config.CreatedAt,
+		&config.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrReplicationConfigNotFound
+		}
+		return nil, fmt.Errorf("failed to get replication config: %w", err)
+	}
+
+	return config, nil
+}
+
+// DeleteConfig removes the configuration of a bucket and its queued tasks.
+// Versions still waiting for replication are marked failed.
+func (r *replicationRepository) DeleteConfig(ctx context.Context, bucketID int64) error {
+	return r.db.WithTx(ctx, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		result, err := tx.Exec(ctx, `DELETE FROM bucket_replication WHERE bucket_id = $1`, bucketID)
+		if err != nil {
+			return fmt.Errorf("failed to delete replication config: %w", err)
+		}
+		if result.RowsAffected() == 0 {
+			return domain.ErrReplicationConfigNotFound
+		}
+
+		if _, err := tx.Exec(ctx, `DELETE FROM replication_tasks WHERE bucket_id = $1`, bucketID); err != nil {
+			return fmt.Errorf("failed to delete replication tasks: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE objects SET replication_status = $2
+			WHERE bucket_id = $1 AND replication_status = $3
+		`, bucketID, domain.ReplicationStatusFailed, domain.ReplicationStatusPending)
+		if err != nil {
+			return fmt.Errorf("failed to update replication status: %w", err)
+		}
+		return nil
+	})
+}
+
+// Enqueue queues a task and sets its ID.
+func (r *replicationRepository) Enqueue(ctx context.Context, task *domain.ReplicationTask) error {
+	query := `
+		INSERT INTO replication_tasks (bucket_id, object_id, key, operation, next_attempt_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+
+	err := r.db.conn(ctx).QueryRow(ctx, query,
+		task.BucketID,
+		task.ObjectID,
+		task.Key,
+		string(task.Operation),
+		task.NextAttemptAt,
+		task.CreatedAt,
+	).Scan(&task.ID)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue replication task: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimDue returns due tasks that have no earlier task for their key and
+// postpones them to leaseUntil. SKIP LOCKED lets concurrent workers claim
+// disjoint batches.
+func (r *replicationRepository) ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*domain.ReplicationTask, error) {
+	query := `
+		WITH due AS (
+			SELECT t.id FROM replication_tasks t
+			WHERE t.next_attempt_at <= $1
+				AND NOT EXISTS (
+					SELECT 1 FROM replication_tasks e
+					WHERE e.bucket_id = t.bucket_id AND e.key = t.key AND e.id < t.id
+				)
+			ORDER BY t.id
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE replication_tasks SET next_attempt_at = $2
+		FROM due
+		WHERE replication_tasks.id = due.id
+		RETURNING replication_tasks.id, bucket_id, object_id, key, operation,
+			attempts, next_attempt_at, last_error, created_at
+	`
+
+	rows, err := r.db.conn(ctx).Query(ctx, query, now, leaseUntil, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim replication tasks: %w", err)
+	}
+	defer rows.Close()
+
+	var tasks []*domain.ReplicationTask
+	for rows.Next() {
+		task := &domain.ReplicationTask{}
+		var operation string
+		err := rows.Scan(
+			&task.ID,
+			&task.BucketID,
+			&task.ObjectID,
+			&task.Key,
+			&operation,
+			&task.Attempts,
+			&task.NextAttemptAt,
+			&task.LastError,
+			&task.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan replication task: %w", err)
+		}
+		task.Operation = domain.ReplicationOperation(operation)
+		tasks = append(tasks, task)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating replication tasks: %w", err)
+	}
+
+	// UPDATE ... RETURNING does not keep the order of the CTE
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+	return tasks, nil
+}
+
+// Complete removes a finished task and sets the replication status of its object version.
+func (r *replicationRepository) Complete(ctx context.Context, task *domain.ReplicationTask, status domain.ReplicationStatus) error {
+	return r.db.WithTx(ctx, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `DELETE FROM replication_tasks WHERE id = $1`, task.ID); err != nil {
+			return fmt.Errorf("failed to delete replication task: %w", err)
+		}
+		if task.ObjectID == 0 {
+			return nil
+		}
+		_, err := tx.Exec(ctx, `UPDATE objects SET replication_status = $2 WHERE id = $1 AND bucket_id = $3`, task.ObjectID, status, task.BucketID)
+		if err != nil {
+			return fmt.Errorf("failed to update replication status: %w", err)
+		}
+		return nil
+	})
+}
+
+// Retry saves the state of a failed task.
+func (r *replicationRepository) Retry(ctx context.Context, task *domain.ReplicationTask) error {
+	_, err := r.db.conn(ctx).Exec(ctx, `
+		UPDATE replication_tasks SET attempts = $2, next_attempt_at = $3, last_error = $4
+		WHERE id = $1
+	`, task.ID, task.Attempts, task.NextAttemptAt, task.LastError)
+	if err != nil {
+		return fmt.Errorf("failed to update replication task: %w", err)
+	}
+	return nil
+}
+
+// Backlog returns the number of queued tasks and the creation time of the oldest.
+func (r *replicationRepository) Backlog(ctx context.Context) (int64, *time.Time, error) {
+	var count int64
+	var oldest *time.Time
+	err := r.db.conn(ctx).QueryRow(ctx, `SELECT COUNT(*), MIN(created_at) FROM replication_tasks`).Scan(&count, &oldest)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to get replication backlog: %w", err)
+	}
+	return count, oldest, nil
+}
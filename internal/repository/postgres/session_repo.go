@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 
 	"github.com/prn-tf/alexander-storage/internal/domain"
@@ -25,11 +26,12 @@ func NewSessionRepository(db *DB) repository.SessionRepository {
 // Create creates a new session.
 func (r *sessionRepository) Create(ctx context.Context, session *domain.Session) error {
 	query := `
-		INSERT INTO sessions (id, user_id, token, expires_at, created_at, ip_address, user_agent)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO sessions (id, user_id, token, expires_at, created_at, ip_address, user_agent,
+			csrf_token, last_seen_at, remember_token, remember_expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 	`
 
-	_, err := r.db.Pool.Exec(ctx, query,
+	_, err := r.db.conn(ctx).Exec(ctx, query,
 		session.ID,
 		session.UserID,
 		session.Token,
@@ -37,6 +39,10 @@ func (r *sessionRepository) Create(ctx context.Context, session *domain.Session)
 		session.CreatedAt,
 		session.IPAddress,
 		session.UserAgent,
+		session.CSRFToken,
+		session.LastSeenAt,
+		nullRememberToken(session),
+		session.RememberExpiresAt,
 	)
 
 	if err != nil {
@@ -51,25 +57,9 @@ func (r *sessionRepository) Create(ctx context.Context, session *domain.Session)
 
 // GetByToken retrieves a session by its token.
 func (r *sessionRepository) GetByToken(ctx context.Context, token string) (*domain.Session, error) {
-	query := `
-		SELECT id, user_id, token, expires_at, created_at, ip_address, user_agent
-		FROM sessions
-		WHERE token = $1
-	`
-
-	session := &domain.Session{}
-	var ipAddress, userAgent *string
-
-	err := r.db.Pool.QueryRow(ctx, query, token).Scan(
-		&session.ID,
-		&session.UserID,
-		&session.Token,
-		&session.ExpiresAt,
-		&session.CreatedAt,
-		&ipAddress,
-		&userAgent,
-	)
+	query := `SELECT ` + sessionColumns + ` FROM sessions WHERE token = $1`
 
+	session, err := scanSession(r.db.conn(ctx).QueryRow(ctx, query, token))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, repository.ErrNotFound
@@ -77,11 +67,19 @@ func (r *sessionRepository) GetByToken(ctx context.Context, token string) (*doma
 		return nil, fmt.Errorf("failed to get session by token: %w", err)
 	}
 
-	if ipAddress != nil {
-		session.IPAddress = *ipAddress
-	}
-	if userAgent != nil {
-		session.UserAgent = *userAgent
+	return session, nil
+}
+
+// GetByRememberToken retrieves a session by its remember-me token.
+func (r *sessionRepository) GetByRememberToken(ctx context.Context, token string) (*domain.Session, error) {
+	query := `SELECT ` + sessionColumns + ` FROM sessions WHERE remember_token = $1`
+
+	session, err := scanSession(r.db.conn(ctx).QueryRow(ctx, query, token))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get session by remember token: %w", err)
 	}
 
 	return session, nil
@@ -89,14 +87,9 @@ func (r *sessionRepository) GetByToken(ctx context.Context, token string) (*doma
 
 // GetByUserID returns all sessions for a user.
 func (r *sessionRepository) GetByUserID(ctx context.Context, userID int64) ([]*domain.Session, error) {
-	query := `
-		SELECT id, user_id, token, expires_at, created_at, ip_address, user_agent
-		FROM sessions
-		WHERE user_id = $1
-		ORDER BY created_at DESC
-	`
+	query := `SELECT ` + sessionColumns + ` FROM sessions WHERE user_id = $1 ORDER BY created_at DESC`
 
-	rows, err := r.db.Pool.Query(ctx, query, userID)
+	rows, err := r.db.conn(ctx).Query(ctx, query, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get sessions by user ID: %w", err)
 	}
@@ -104,29 +97,10 @@ func (r *sessionRepository) GetByUserID(ctx context.Context, userID int64) ([]*d
 
 	var sessions []*domain.Session
 	for rows.Next() {
-		session := &domain.Session{}
-		var ipAddress, userAgent *string
-
-		err := rows.Scan(
-			&session.ID,
-			&session.UserID,
-			&session.Token,
-			&session.ExpiresAt,
-			&session.CreatedAt,
-			&ipAddress,
-			&userAgent,
-		)
+		session, err := scanSession(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan session: %w", err)
 		}
-
-		if ipAddress != nil {
-			session.IPAddress = *ipAddress
-		}
-		if userAgent != nil {
-			session.UserAgent = *userAgent
-		}
-
 		sessions = append(sessions, session)
 	}
 
@@ -137,11 +111,52 @@ func (r *sessionRepository) GetByUserID(ctx context.Context, userID int64) ([]*d
 	return sessions, nil
 }
 
+// Update saves the tokens, expiry and last activity of a session.
+func (r *sessionRepository) Update(ctx context.Context, session *domain.Session) error {
+	query := `
+		UPDATE sessions
+		SET token = $2, expires_at = $3, last_seen_at = $4, remember_token = $5, remember_expires_at = $6
+		WHERE id = $1
+	`
+
+	result, err := r.db.conn(ctx).Exec(ctx, query,
+		session.ID,
+		session.Token,
+		session.ExpiresAt,
+		session.LastSeenAt,
+		nullRememberToken(session),
+		session.RememberExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
 // Delete deletes a session by token.
 func (r *sessionRepository) Delete(ctx context.Context, token string) error {
 	query := `DELETE FROM sessions WHERE token = $1`
 
-	result, err := r.db.Pool.Exec(ctx, query, token)
+	result, err := r.db.conn(ctx).Exec(ctx, query, token)
+	if err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+// DeleteByID deletes a session by ID.
+func (r *sessionRepository) DeleteByID(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.conn(ctx).Exec(ctx, `DELETE FROM sessions WHERE id = $1`, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete session: %w", err)
 	}
@@ -157,7 +172,7 @@ func (r *sessionRepository) Delete(ctx context.Context, token string) error {
 func (r *sessionRepository) DeleteByUserID(ctx context.Context, userID int64) error {
 	query := `DELETE FROM sessions WHERE user_id = $1`
 
-	_, err := r.db.Pool.Exec(ctx, query, userID)
+	_, err := r.db.conn(ctx).Exec(ctx, query, userID)
 	if err != nil {
 		return fmt.Errorf("failed to delete sessions by user ID: %w", err)
 	}
@@ -165,11 +180,14 @@ func (r *sessionRepository) DeleteByUserID(ctx context.Context, userID int64) er
 	return nil
 }
 
-// DeleteExpired deletes all expired sessions.
+// DeleteExpired deletes all expired sessions that cannot be resumed.
 func (r *sessionRepository) DeleteExpired(ctx context.Context) (int64, error) {
-	query := `DELETE FROM sessions WHERE expires_at < $1`
+	query := `
+		DELETE FROM sessions
+		WHERE expires_at < $1 AND (remember_expires_at IS NULL OR remember_expires_at < $1)
+	`
 
-	result, err := r.db.Pool.Exec(ctx, query, time.Now().UTC())
+	result, err := r.db.conn(ctx).Exec(ctx, query, time.Now().UTC())
 	if err != nil {
 		return 0, fmt.Errorf("failed to delete expired sessions: %w", err)
 	}
@@ -181,7 +199,7 @@ func (r *sessionRepository) DeleteExpired(ctx context.Context) (int64, error) {
 func (r *sessionRepository) Refresh(ctx context.Context, token string, newExpiresAt time.Time) error {
 	query := `UPDATE sessions SET expires_at = $2 WHERE token = $1`
 
-	result, err := r.db.Pool.Exec(ctx, query, token, newExpiresAt)
+	result, err := r.db.conn(ctx).Exec(ctx, query, token, newExpiresAt)
 	if err != nil {
 		return fmt.Errorf("failed to refresh session: %w", err)
 	}
@@ -196,7 +214,7 @@ func (r *sessionRepository) Refresh(ctx context.Context, token string, newExpire
 // CountByUserID returns the number of active sessions for a user.
 func (r *sessionRepository) CountByUserID(ctx context.Context, userID int64) (int64, error) {
 	var count int64
-	err := r.db.Pool.QueryRow(ctx,
+	err := r.db.conn(ctx).QueryRow(ctx,
 		`SELECT COUNT(*) FROM sessions WHERE user_id = $1 AND expires_at > $2`,
 		userID, time.Now().UTC(),
 	).Scan(&count)
@@ -206,5 +224,60 @@ func (r *sessionRepository) CountByUserID(ctx context.Context, userID int64) (in
 	return count, nil
 }
 
+// sessionColumns lists the sessions columns read by scanSession.
+const sessionColumns = `id, user_id, token, expires_at, created_at, ip_address, user_agent,
+	csrf_token, last_seen_at, remember_token, remember_expires_at`
+
+// scanSession scans the sessionColumns of one row.
+func scanSession(row pgx.Row) (*domain.Session, error) {
+	session := &domain.Session{}
+	var ipAddress, userAgent, rememberToken *string
+	var lastSeenAt *time.Time
+
+	err := row.Scan(
+		&session.ID,
+		&session.UserID,
+		&session.Token,
+		&session.ExpiresAt,
+		&session.CreatedAt,
+		&ipAddress,
+		&userAgent,
+		&session.CSRFToken,
+		&lastSeenAt,
+		&rememberToken,
+		&session.RememberExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if ipAddress != nil {
+		session.IPAddress = *ipAddress
+	}
+	if userAgent != nil {
+		session.UserAgent = *userAgent
+	}
+	if rememberToken != nil {
+		session.RememberToken = *rememberToken
+	}
+
+	// Sessions created before idle tracking count from their creation
+	session.LastSeenAt = session.CreatedAt
+	if lastSeenAt != nil {
+		session.LastSeenAt = *lastSeenAt
+	}
+
+	return session, nil
+}
+
+// nullRememberToken returns the remember-me token, or nil when there is none,
+// so that the unique index only covers sessions that have one.
+func nullRememberToken(session *domain.Session) *string {
+	if session.RememberToken == "" {
+		return nil
+	}
+	return &session.RememberToken
+}
+
 // Ensure sessionRepository implements repository.SessionRepository
 var _ repository.SessionRepository = (*sessionRepository)(nil)
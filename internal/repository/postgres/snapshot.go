@@ -0,0 +1,291 @@
+package postgres
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// snapshotTableList names the file listing a snapshot's tables in restore order.
+const snapshotTableList = "tables"
+
+// SchemaVersion returns the migration version recorded by golang-migrate.
+// A dirty version means a migration failed halfway and is reported as an error.
+func (db *DB) SchemaVersion(ctx context.Context) (int64, error) {
+	var version int64
+	var dirty bool
+	err := db.Pool.QueryRow(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get schema version: %w", err)
+	}
+	if dirty {
+		return 0, fmt.Errorf("schema version %d is dirty; fix the failed migration first", version)
+	}
+	return version, nil
+}
+
+// Snapshot copies every table into dir in PostgreSQL's COPY text format,
+// one file per table. All tables are read in one repeatable-read
+// transaction, so the copy is consistent without blocking writers.
+func (db *DB) Snapshot(ctx context.Context, dir string) error {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tables, err := snapshotTables(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	for _, table := range tables {
+		if err := copyTableTo(ctx, tx, table, filepath.Join(dir, table+".copy")); err != nil {
+			return fmt.Errorf("failed to snapshot table %s: %w", table, err)
+		}
+	}
+
+	list := strings.Join(tables, "\n") + "\n"
+	if err := os.WriteFile(filepath.Join(dir, snapshotTableList), []byte(list), 0600); err != nil {
+		return fmt.Errorf("failed to write table list: %w", err)
+	}
+	return nil
+}
+
+// Restore replaces the contents of every table with those of a snapshot in
+// one transaction: the tables are truncated, refilled in an order that
+// satisfies foreign keys, and their sequences moved past the restored IDs.
+// User triggers are disabled during the copy so restored rows are not
+// rewritten, which requires owning the tables like the migrations do.
+func (db *DB) Restore(ctx context.Context, dir string) error {
+	tables, err := readTableList(filepath.Join(dir, snapshotTableList))
+	if err != nil {
+		return fmt.Errorf("invalid snapshot: %w", err)
+	}
+
+	err = db.WithTx(ctx, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		current, err := snapshotTables(ctx, tx)
+		if err != nil {
+			return err
+		}
+		if !sameTables(current, tables) {
+			return fmt.Errorf("snapshot tables do not match the database schema")
+		}
+
+		quoted := make([]string, len(tables))
+		for i, table := range tables {
+			quoted[i] = pgx.Identifier{table}.Sanitize()
+		}
+		if _, err := tx.Exec(ctx, `TRUNCATE `+strings.Join(quoted, ", ")); err != nil {
+			return fmt.Errorf("failed to truncate tables: %w", err)
+		}
+
+		for i, table := range tables {
+			if _, err := tx.Exec(ctx, `ALTER TABLE `+quoted[i]+` DISABLE TRIGGER USER`); err != nil {
+				return fmt.Errorf("failed to disable triggers on %s: %w", table, err)
+			}
+			if err := copyTableFrom(ctx, tx, table, filepath.Join(dir, table+".copy")); err != nil {
+				return fmt.Errorf("failed to restore table %s: %w", table, err)
+			}
+			if _, err := tx.Exec(ctx, `ALTER TABLE `+quoted[i]+` ENABLE TRIGGER USER`); err != nil {
+				return fmt.Errorf("failed to enable triggers on %s: %w", table, err)
+			}
+		}
+
+		return resetSequences(ctx, tx)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to restore snapshot: %w", err)
+	}
+
+	db.logger.Info().Int("tables", len(tables)).Msg("restored database from snapshot")
+	return nil
+}
+
+// snapshotTables returns the tables of the current schema, except the
+// migration history, ordered so that every table follows the tables its
+// foreign keys reference.
+func snapshotTables(ctx context.Context, tx pgx.Tx) ([]string, error) {
+	// Partitions are copied through their parent table
+	rows, err := tx.Query(ctx, `
+		SELECT c.relname FROM pg_class c
+		WHERE c.relnamespace = current_schema()::regnamespace
+			AND c.relkind IN ('r', 'p') AND NOT c.relispartition
+			AND c.relname <> 'schema_migrations'
+		ORDER BY c.relname
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	tables, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+
+	rows, err = tx.Query(ctx, `
+		SELECT child.relname, parent.relname
+		FROM pg_constraint c
+		JOIN pg_class child ON child.oid = c.conrelid
+		JOIN pg_class parent ON parent.oid = c.confrelid
+		WHERE c.contype = 'f'
+			AND child.relnamespace = current_schema()::regnamespace
+			AND c.conrelid <> c.confrelid
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list foreign keys: %w", err)
+	}
+	references := make(map[string][]string)
+	var child, parent string
+	_, err = pgx.ForEachRow(rows, []any{&child, &parent}, func() error {
+		references[child] = append(references[child], parent)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list foreign keys: %w", err)
+	}
+
+	return dependencyOrder(tables, references), nil
+}
+
+// dependencyOrder sorts tables so each follows the tables it references.
+// Tables are visited in name order, which keeps the result stable.
+func dependencyOrder(tables []string, references map[string][]string) []string {
+	ordered := make([]string, 0, len(tables))
+	visited := make(map[string]bool, len(tables))
+
+	var visit func(table string)
+	visit = func(table string) {
+		if visited[table] {
+			return
+		}
+		visited[table] = true
+		parents := append([]string(nil), references[table]...)
+		sort.Strings(parents)
+		for _, parent := range parents {
+			visit(parent)
+		}
+		ordered = append(ordered, table)
+	}
+
+	for _, table := range tables {
+		visit(table)
+	}
+	return ordered
+}
+
+// copyTableTo writes the rows of a table to a file.
+func copyTableTo(ctx context.Context, tx pgx.Tx, table, path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	// COPY of a partitioned table must read it through a query
+	query := `COPY (SELECT * FROM ` + pgx.Identifier{table}.Sanitize() + `) TO STDOUT`
+	if _, err := tx.Conn().PgConn().CopyTo(ctx, w, query); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// copyTableFrom loads the rows of a table from a file.
+func copyTableFrom(ctx context.Context, tx pgx.Tx, table, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = tx.Conn().PgConn().CopyFrom(ctx, bufio.NewReader(f), `COPY `+pgx.Identifier{table}.Sanitize()+` FROM STDIN`)
+	return err
+}
+
+// resetSequences moves every serial and identity sequence of the current
+// schema past the largest value in its column.
+func resetSequences(ctx context.Context, tx pgx.Tx) error {
+	rows, err := tx.Query(ctx, `
+		SELECT table_name, column_name, seq
+		FROM (
+			SELECT table_name::text, column_name::text,
+				pg_get_serial_sequence(quote_ident(table_name), column_name) AS seq
+			FROM information_schema.columns
+			WHERE table_schema = current_schema()
+		) columns
+		WHERE seq IS NOT NULL
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to list sequences: %w", err)
+	}
+
+	type sequence struct{ table, column, name string }
+	var sequences []sequence
+	var s sequence
+	_, err = pgx.ForEachRow(rows, []any{&s.table, &s.column, &s.name}, func() error {
+		sequences = append(sequences, s)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list sequences: %w", err)
+	}
+
+	for _, s := range sequences {
+		query := fmt.Sprintf(`SELECT setval($1, COALESCE((SELECT MAX(%s) FROM %s), 0) + 1, false)`,
+			pgx.Identifier{s.column}.Sanitize(), pgx.Identifier{s.table}.Sanitize())
+		if _, err := tx.Exec(ctx, query, s.name); err != nil {
+			return fmt.Errorf("failed to reset sequence %s: %w", s.name, err)
+		}
+	}
+	return nil
+}
+
+// readTableList reads the table list of a snapshot.
+func readTableList(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	tables := strings.Fields(string(data))
+	if len(tables) == 0 {
+		return nil, fmt.Errorf("snapshot lists no tables")
+	}
+	return tables, nil
+}
+
+// sameTables reports whether two table lists name the same tables.
+func sameTables(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	names := make(map[string]bool, len(a))
+	for _, table := range a {
+		names[table] = true
+	}
+	for _, table := range b {
+		if !names[table] {
+			return false
+		}
+	}
+	return true
+}
+
+// Ensure DB implements repository.Snapshotter
+var _ repository.Snapshotter = (*DB)(nil)
@@ -0,0 +1,189 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// stagedObjectColumns lists the staged_objects columns read by scanStagedObject.
+const stagedObjectColumns = `id, bucket_id, key, content_hash, size, content_type, etag,
+	storage_class, acl, checksum_algorithm, checksum, metadata, part_sizes, batch_id, event_type, created_at`
+
+// stagedObjectRepository implements repository.StagedObjectRepository.
+type stagedObjectRepository struct {
+	db *DB
+}
+
+// NewStagedObjectRepository creates a new PostgreSQL staged object repository.
+func NewStagedObjectRepository(db *DB) repository.StagedObjectRepository {
+	return &stagedObjectRepository{db: db}
+}
+
+// Create stages an upload.
+func (r *stagedObjectRepository) Create(ctx context.Context, staged *domain.StagedObject) error {
+	query := `
+		INSERT INTO staged_objects (id, bucket_id, key, content_hash, size, content_type, etag,
+			storage_class, acl, checksum_algorithm, checksum, metadata, part_sizes, batch_id, event_type, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, COALESCE($12::jsonb, '{}'), $13, $14, $15, $16)
+	`
+
+	_, err := r.db.conn(ctx).Exec(ctx, query,
+		staged.ID,
+		staged.BucketID,
+		staged.Key,
+		staged.ContentHash,
+		staged.Size,
+		staged.ContentType,
+		staged.ETag,
+		staged.StorageClass,
+		staged.ACL,
+		staged.ChecksumAlgorithm,
+		staged.Checksum,
+		staged.Metadata,
+		staged.PartSizes,
+		staged.BatchID,
+		staged.EventType,
+		staged.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to stage object: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID returns a staged upload.
+func (r *stagedObjectRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.StagedObject, error) {
+	query := `SELECT ` + stagedObjectColumns + ` FROM staged_objects WHERE id = $1`
+
+	staged, err := scanStagedObject(r.db.conn(ctx).QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrStagedObjectNotFound
+		}
+		return nil, fmt.Errorf("failed to get staged object: %w", err)
+	}
+
+	return staged, nil
+}
+
+// List returns up to limit staged uploads of a bucket, or of all buckets if bucketID is 0.
+func (r *stagedObjectRepository) List(ctx context.Context, bucketID int64, limit int) ([]*domain.StagedObject, error) {
+	query := `
+		SELECT ` + stagedObjectColumns + `
+		FROM staged_objects
+		WHERE ($1::bigint = 0 OR bucket_id = $1) AND batch_id IS NULL
+		ORDER BY created_at ASC, id ASC
+		LIMIT $2
+	`
+	return r.list(ctx, query, bucketID, limit)
+}
+
+// Delete removes a staged upload.
+func (r *stagedObjectRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.conn(ctx).Exec(ctx, `DELETE FROM staged_objects WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete staged object: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrStagedObjectNotFound
+	}
+
+	return nil
+}
+
+// ListExpired returns up to limit uploads staged before the cutoff.
+func (r *stagedObjectRepository) ListExpired(ctx context.Context, before time.Time, limit int) ([]*domain.StagedObject, error) {
+	query := `
+		SELECT ` + stagedObjectColumns + `
+		FROM staged_objects
+		WHERE created_at < $1 AND batch_id IS NULL
+		ORDER BY created_at ASC, id ASC
+		LIMIT $2
+	`
+	return r.list(ctx, query, before, limit)
+}
+
+// ListByBatch returns the writes staged in a batch.
+func (r *stagedObjectRepository) ListByBatch(ctx context.Context, batchID uuid.UUID) ([]*domain.StagedObject, error) {
+	query := `
+		SELECT ` + stagedObjectColumns + `
+		FROM staged_objects
+		WHERE batch_id = $1
+		ORDER BY created_at ASC, id ASC
+	`
+	return r.list(ctx, query, batchID)
+}
+
+// CountByBatch returns the number of writes staged in a batch.
+func (r *stagedObjectRepository) CountByBatch(ctx context.Context, batchID uuid.UUID) (int, error) {
+	var count int
+	err := r.db.conn(ctx).QueryRow(ctx, `SELECT COUNT(*) FROM staged_objects WHERE batch_id = $1`, batchID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count staged objects: %w", err)
+	}
+	return count, nil
+}
+
+// list runs a staged_objects query and scans every row.
+func (r *stagedObjectRepository) list(ctx context.Context, query string, args ...any) ([]*domain.StagedObject, error) {
+	rows, err := r.db.conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list staged objects: %w", err)
+	}
+	defer rows.Close()
+
+	var staged []*domain.StagedObject
+	for rows.Next() {
+		s, err := scanStagedObject(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan staged object: %w", err)
+		}
+		staged = append(staged, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating staged objects: %w", err)
+	}
+
+	return staged, nil
+}
+
+// scanStagedObject scans the stagedObjectColumns of one row.
+func scanStagedObject(row pgx.Row) (*domain.StagedObject, error) {
+	staged := &domain.StagedObject{}
+	err := row.Scan(
+		&staged.ID,
+		&staged.BucketID,
+		&staged.Key,
+		&staged.ContentHash,
+		&staged.Size,
+		&staged.ContentType,
+		&staged.ETag,
+		&staged.StorageClass,
+		&staged.ACL,
+		&staged.ChecksumAlgorithm,
+		&staged.Checksum,
+		&staged.Metadata,
+		&staged.PartSizes,
+		&staged.BatchID,
+		&staged.EventType,
+		&staged.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return staged, nil
+}
+
+// Ensure stagedObjectRepository implements repository.StagedObjectRepository
+var _ repository.StagedObjectRepository = (*stagedObjectRepository)(nil)
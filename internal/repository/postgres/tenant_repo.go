@@ -0,0 +1,180 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// tenantRepository implements repository.TenantRepository.
+type tenantRepository struct {
+	db *DB
+}
+
+// NewTenantRepository creates a new PostgreSQL tenant repository.
+func NewTenantRepository(db *DB) repository.TenantRepository {
+	return &tenantRepository{db: db}
+}
+
+// tenantColumns are the columns scanned by scanTenant.
+const tenantColumns = `id, name, max_buckets, max_storage_bytes, requests_per_second, burst_size, created_at, updated_at`
+
+// Create creates a new tenant.
+func (r *tenantRepository) Create(ctx context.Context, tenant *domain.Tenant) error {
+	query := `
+		INSERT INTO tenants (name, max_buckets, max_storage_bytes, requests_per_second, burst_size, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`
+
+	err := r.db.conn(ctx).QueryRow(ctx, query,
+		tenant.Name,
+		tenant.Limits.MaxBuckets,
+		tenant.Limits.MaxStorageBytes,
+		tenant.Limits.RequestsPerSecond,
+		tenant.Limits.BurstSize,
+		tenant.CreatedAt,
+		tenant.UpdatedAt,
+	).Scan(&tenant.ID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return domain.ErrTenantAlreadyExists
+		}
+		return fmt.Errorf("failed to create tenant: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID returns a tenant.
+func (r *tenantRepository) GetByID(ctx context.Context, id int64) (*domain.Tenant, error) {
+	return scanTenant(r.db.conn(ctx).QueryRow(ctx, `SELECT `+tenantColumns+` FROM tenants WHERE id = $1`, id))
+}
+
+// GetByName returns a tenant.
+func (r *tenantRepository) GetByName(ctx context.Context, name string) (*domain.Tenant, error) {
+	return scanTenant(r.db.conn(ctx).QueryRow(ctx, `SELECT `+tenantColumns+` FROM tenants WHERE name = $1`, name))
+}
+
+// List returns all tenants in name order.
+func (r *tenantRepository) List(ctx context.Context) ([]*domain.Tenant, error) {
+	rows, err := r.db.conn(ctx).Query(ctx, `SELECT `+tenantColumns+` FROM tenants ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tenants: %w", err)
+	}
+	defer rows.Close()
+
+	var tenants []*domain.Tenant
+	for rows.Next() {
+		tenant, err := scanTenant(rows)
+		if err != nil {
+			return nil, err
+		}
+		tenants = append(tenants, tenant)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tenants: %w", err)
+	}
+	return tenants, nil
+}
+
+// UpdateLimits replaces the limits of a tenant.
+func (r *tenantRepository) UpdateLimits(ctx context.Context, id int64, limits domain.TenantLimits) error {
+	query := `
+		UPDATE tenants
+		SET max_buckets = $2, max_storage_bytes = $3, requests_per_second = $4, burst_size = $5, updated_at = NOW()
+		WHERE id = $1
+	`
+
+	result, err := r.db.conn(ctx).Exec(ctx, query,
+		id,
+		limits.MaxBuckets,
+		limits.MaxStorageBytes,
+		limits.RequestsPerSecond,
+		limits.BurstSize,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update tenant limits: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrTenantNotFound
+	}
+	return nil
+}
+
+// Delete deletes a tenant.
+func (r *tenantRepository) Delete(ctx context.Context, id int64) error {
+	result, err := r.db.conn(ctx).Exec(ctx, `DELETE FROM tenants WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete tenant: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrTenantNotFound
+	}
+	return nil
+}
+
+// Usage returns what a tenant currently uses of its quotas.
+func (r *tenantRepository) Usage(ctx context.Context, id int64) (*domain.TenantUsage, error) {
+	usage := &domain.TenantUsage{}
+	err := r.db.conn(ctx).QueryRow(ctx, `
+		SELECT (SELECT COUNT(*) FROM users WHERE tenant_id = $1),
+		       (SELECT COUNT(*) FROM buckets WHERE tenant_id = $1),
+		       (SELECT COALESCE(SUM(o.size), 0)::BIGINT
+		        FROM objects o
+		        JOIN buckets b ON b.id = o.bucket_id
+		        WHERE b.tenant_id = $1 AND NOT o.is_delete_marker AND o.deleted_at IS NULL)
+	`, id).Scan(&usage.Users, &usage.Buckets, &usage.StorageBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant usage: %w", err)
+	}
+	return usage, nil
+}
+
+// MoveUser moves a user and the buckets they own to another tenant.
+func (r *tenantRepository) MoveUser(ctx context.Context, userID, tenantID int64) error {
+	return r.db.WithTx(ctx, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		result, err := tx.Exec(ctx, `UPDATE users SET tenant_id = $2 WHERE id = $1`, userID, tenantID)
+		if err != nil {
+			return fmt.Errorf("failed to move user: %w", err)
+		}
+		if result.RowsAffected() == 0 {
+			return domain.ErrUserNotFound
+		}
+
+		if _, err := tx.Exec(ctx, `UPDATE buckets SET tenant_id = $2 WHERE owner_id = $1`, userID, tenantID); err != nil {
+			return fmt.Errorf("failed to move buckets: %w", err)
+		}
+		return nil
+	})
+}
+
+// scanTenant scans the tenantColumns of a row.
+func scanTenant(row pgx.Row) (*domain.Tenant, error) {
+	tenant := &domain.Tenant{}
+	err := row.Scan(
+		&tenant.ID,
+		&tenant.Name,
+		&tenant.Limits.MaxBuckets,
+		&tenant.Limits.MaxStorageBytes,
+		&tenant.Limits.RequestsPerSecond,
+		&tenant.Limits.BurstSize,
+		&tenant.CreatedAt,
+		&tenant.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrTenantNotFound
+		}
+		return nil, fmt.Errorf("failed to scan tenant: %w", err)
+	}
+	return tenant, nil
+}
+
+// Ensure tenantRepository implements repository.TenantRepository
+var _ repository.TenantRepository = (*tenantRepository)(nil)
@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// querier is the subset of pgxpool.Pool and pgx.Tx used by repositories.
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// txKey is the context key for the active transaction.
+type txKey struct{}
+
+// txFromContext returns the transaction stored in ctx, if any.
+func txFromContext(ctx context.Context) (pgx.Tx, bool) {
+	tx, ok := ctx.Value(txKey{}).(pgx.Tx)
+	return tx, ok
+}
+
+// conn returns the transaction bound to ctx, or the pool if there is none.
+// Repositories use it so their queries take part in a TxManager transaction.
+func (db *DB) conn(ctx context.Context) querier {
+	if tx, ok := txFromContext(ctx); ok {
+		return tx
+	}
+	return db.Pool
+}
+
+// txManager implements repository.TxManager for PostgreSQL.
+type txManager struct {
+	db *DB
+}
+
+// NewTxManager creates a new PostgreSQL transaction manager.
+func NewTxManager(db *DB) repository.TxManager {
+	return &txManager{db: db}
+}
+
+// WithTx executes fn within a transaction.
+func (m *txManager) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return m.WithTxOptions(ctx, repository.TxOptions{}, fn)
+}
+
+// WithTxOptions executes fn within a transaction with the given options.
+// If ctx already carries a transaction, fn joins it instead of starting a new one.
+func (m *txManager) WithTxOptions(ctx context.Context, opts repository.TxOptions, fn func(ctx context.Context) error) error {
+	if _, ok := txFromContext(ctx); ok {
+		return fn(ctx)
+	}
+
+	return m.db.WithTx(ctx, toPgxTxOptions(opts), func(tx pgx.Tx) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// toPgxTxOptions converts repository transaction options to pgx options.
+func toPgxTxOptions(opts repository.TxOptions) pgx.TxOptions {
+	var pgxOpts pgx.TxOptions
+
+	switch strings.ToLower(opts.IsolationLevel) {
+	case "serializable":
+		pgxOpts.IsoLevel = pgx.Serializable
+	case "repeatable read":
+		pgxOpts.IsoLevel = pgx.RepeatableRead
+	case "read committed":
+		pgxOpts.IsoLevel = pgx.ReadCommitted
+	case "read uncommitted":
+		pgxOpts.IsoLevel = pgx.ReadUncommitted
+	}
+
+	if opts.ReadOnly {
+		pgxOpts.AccessMode = pgx.ReadOnly
+	}
+
+	return pgxOpts
+}
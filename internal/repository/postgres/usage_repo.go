@@ -0,0 +1,145 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// usageRepository implements repository.UsageRepository.
+type usageRepository struct {
+	db *DB
+}
+
+// NewUsageRepository creates a new PostgreSQL usage repository.
+func NewUsageRepository(db *DB) repository.UsageRepository {
+	return &usageRepository{db: db}
+}
+
+// AddTraffic adds traffic counters to the hourly usage rows.
+func (r *usageRepository) AddTraffic(ctx context.Context, records []*domain.UsageRecord) error {
+	query := `
+		INSERT INTO usage_hourly (hour, bucket_name, access_key_id, bytes_in, bytes_out, requests)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (hour, bucket_name, access_key_id) DO UPDATE SET
+			bytes_in = usage_hourly.bytes_in + EXCLUDED.bytes_in,
+			bytes_out = usage_hourly.bytes_out + EXCLUDED.bytes_out,
+			requests = usage_hourly.requests + EXCLUDED.requests
+	`
+
+	for _, rec := range records {
+		_, err := r.db.conn(ctx).Exec(ctx, query,
+			domain.UsageHour(rec.Hour),
+			rec.BucketName,
+			rec.AccessKeyID,
+			rec.BytesIn,
+			rec.BytesOut,
+			rec.Requests,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to add usage traffic: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// RecordStorage snapshots the logical bytes stored in every bucket.
+func (r *usageRepository) RecordStorage(ctx context.Context, hour time.Time) error {
+	query := `
+		INSERT INTO usage_hourly (hour, bucket_name, access_key_id, bytes_stored)
+		SELECT $1, b.name, '', COALESCE(SUM(o.size), 0)
+		FROM buckets b
+		LEFT JOIN objects o ON o.bucket_id = b.id AND o.is_delete_marker = FALSE
+		GROUP BY b.name
+		ON CONFLICT (hour, bucket_name, access_key_id) DO UPDATE SET
+			bytes_stored = EXCLUDED.bytes_stored
+	`
+
+	if _, err := r.db.conn(ctx).Exec(ctx, query, domain.UsageHour(hour)); err != nil {
+		return fmt.Errorf("failed to record storage usage: %w", err)
+	}
+
+	return nil
+}
+
+// List returns hourly usage rows in [from, to).
+func (r *usageRepository) List(ctx context.Context, from, to time.Time, filter repository.UsageFilter) ([]*domain.UsageRecord, error) {
+	query := `
+		SELECT hour, bucket_name, access_key_id, bytes_in, bytes_out, requests, bytes_stored
+		FROM usage_hourly
+		WHERE hour >= $1 AND hour < $2
+			AND ($3 = '' OR bucket_name = $3)
+			AND ($4 = '' OR access_key_id = $4)
+		ORDER BY hour, bucket_name, access_key_id
+	`
+
+	rows, err := r.db.conn(ctx).Query(ctx, query, from.UTC(), to.UTC(), filter.BucketName, filter.AccessKeyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list usage: %w", err)
+	}
+	defer rows.Close()
+
+	var records []*domain.UsageRecord
+	for rows.Next() {
+		rec := &domain.UsageRecord{}
+		err := rows.Scan(
+			&rec.Hour,
+			&rec.BucketName,
+			&rec.AccessKeyID,
+			&rec.BytesIn,
+			&rec.BytesOut,
+			&rec.Requests,
+			&rec.BytesStored,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan usage: %w", err)
+		}
+		rec.Hour = rec.Hour.UTC()
+		records = append(records, rec)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating usage: %w", err)
+	}
+
+	return records, nil
+}
+
+// ListStorage returns the total bytes stored at each snapshot in [from, to).
+// Hours without a snapshot, or with nothing stored, are left out.
+func (r *usageRepository) ListStorage(ctx context.Context, from, to time.Time) ([]*domain.StorageSample, error) {
+	query := `
+		SELECT hour, SUM(bytes_stored)::BIGINT
+		FROM usage_hourly
+		WHERE hour >= $1 AND hour < $2 AND access_key_id = ''
+		GROUP BY hour
+		HAVING SUM(bytes_stored) > 0
+		ORDER BY hour
+	`
+
+	rows, err := r.db.conn(ctx).Query(ctx, query, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list storage usage: %w", err)
+	}
+	defer rows.Close()
+
+	var samples []*domain.StorageSample
+	for rows.Next() {
+		sample := &domain.StorageSample{}
+		if err := rows.Scan(&sample.Hour, &sample.BytesStored); err != nil {
+			return nil, fmt.Errorf("failed to scan storage usage: %w", err)
+		}
+		sample.Hour = sample.Hour.UTC()
+		samples = append(samples, sample)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating storage usage: %w", err)
+	}
+
+	return samples, nil
+}
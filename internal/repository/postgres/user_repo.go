@@ -25,17 +25,20 @@ func NewUserRepository(db *DB) repository.UserRepository {
 // Create creates a new user.
 func (r *userRepository) Create(ctx context.Context, user *domain.User) error {
 	query := `
-		INSERT INTO users (username, email, password_hash, is_active, is_admin, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO users (tenant_id, username, email, password_hash, is_active, is_admin, role, must_change_password, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		RETURNING id
 	`
 
-	err := r.db.Pool.QueryRow(ctx, query,
+	err := r.db.conn(ctx).QueryRow(ctx, query,
+		user.TenantID,
 		user.Username,
 		user.Email,
 		user.PasswordHash,
 		user.IsActive,
-		user.IsAdmin,
+		user.IsAdmin(),
+		string(user.Role),
+		user.MustChangePassword,
 		user.CreatedAt,
 		user.UpdatedAt,
 	).Scan(&user.ID)
@@ -53,19 +56,21 @@ func (r *userRepository) Create(ctx context.Context, user *domain.User) error {
 // GetByID retrieves a user by ID.
 func (r *userRepository) GetByID(ctx context.Context, id int64) (*domain.User, error) {
 	query := `
-		SELECT id, username, email, password_hash, is_active, is_admin, created_at, updated_at
+		SELECT id, tenant_id, username, email, password_hash, is_active, role, must_change_password, created_at, updated_at
 		FROM users
 		WHERE id = $1
 	`
 
 	user := &domain.User{}
-	err := r.db.Pool.QueryRow(ctx, query, id).Scan(
+	err := r.db.conn(ctx).QueryRow(ctx, query, id).Scan(
 		&user.ID,
+		&user.TenantID,
 		&user.Username,
 		&user.Email,
 		&user.PasswordHash,
 		&user.IsActive,
-		&user.IsAdmin,
+		&user.Role,
+		&user.MustChangePassword,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -83,19 +88,21 @@ func (r *userRepository) GetByID(ctx context.Context, id int64) (*domain.User, e
 // GetByUsername retrieves a user by username.
 func (r *userRepository) GetByUsername(ctx context.Context, username string) (*domain.User, error) {
 	query := `
-		SELECT id, username, email, password_hash, is_active, is_admin, created_at, updated_at
+		SELECT id, tenant_id, username, email, password_hash, is_active, role, must_change_password, created_at, updated_at
 		FROM users
 		WHERE username = $1
 	`
 
 	user := &domain.User{}
-	err := r.db.Pool.QueryRow(ctx, query, username).Scan(
+	err := r.db.conn(ctx).QueryRow(ctx, query, username).Scan(
 		&user.ID,
+		&user.TenantID,
 		&user.Username,
 		&user.Email,
 		&user.PasswordHash,
 		&user.IsActive,
-		&user.IsAdmin,
+		&user.Role,
+		&user.MustChangePassword,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -113,19 +120,21 @@ func (r *userRepository) GetByUsername(ctx context.Context, username string) (*d
 // GetByEmail retrieves a user by email.
 func (r *userRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
 	query := `
-		SELECT id, username, email, password_hash, is_active, is_admin, created_at, updated_at
+		SELECT id, tenant_id, username, email, password_hash, is_active, role, must_change_password, created_at, updated_at
 		FROM users
 		WHERE email = $1
 	`
 
 	user := &domain.User{}
-	err := r.db.Pool.QueryRow(ctx, query, email).Scan(
+	err := r.db.conn(ctx).QueryRow(ctx, query, email).Scan(
 		&user.ID,
+		&user.TenantID,
 		&user.Username,
 		&user.Email,
 		&user.PasswordHash,
 		&user.IsActive,
-		&user.IsAdmin,
+		&user.Role,
+		&user.MustChangePassword,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -144,19 +153,21 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*domain.
 func (r *userRepository) Update(ctx context.Context, user *domain.User) error {
 	query := `
 		UPDATE users
-		SET username = $2, email = $3, password_hash = $4, is_active = $5, is_admin = $6, updated_at = $7
+		SET username = $2, email = $3, password_hash = $4, is_active = $5, is_admin = $6, role = $7, must_change_password = $8, updated_at = $9
 		WHERE id = $1
 	`
 
 	user.UpdatedAt = time.Now().UTC()
 
-	result, err := r.db.Pool.Exec(ctx, query,
+	result, err := r.db.conn(ctx).Exec(ctx, query,
 		user.ID,
 		user.Username,
 		user.Email,
 		user.PasswordHash,
 		user.IsActive,
-		user.IsAdmin,
+		user.IsAdmin(),
+		string(user.Role),
+		user.MustChangePassword,
 		user.UpdatedAt,
 	)
 
@@ -178,7 +189,7 @@ func (r *userRepository) Update(ctx context.Context, user *domain.User) error {
 func (r *userRepository) Delete(ctx context.Context, id int64) error {
 	query := `DELETE FROM users WHERE id = $1`
 
-	result, err := r.db.Pool.Exec(ctx, query, id)
+	result, err := r.db.conn(ctx).Exec(ctx, query, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete user: %w", err)
 	}
@@ -192,20 +203,31 @@ func (r *userRepository) Delete(ctx context.Context, id int64) error {
 
 // List returns all users with pagination.
 func (r *userRepository) List(ctx context.Context, opts repository.ListOptions) (*repository.ListResult[domain.User], error) {
-	countQuery := `SELECT COUNT(*) FROM users`
+	return r.list(ctx, 0, opts)
+}
+
+// ListByTenant returns the users of a tenant with pagination.
+func (r *userRepository) ListByTenant(ctx context.Context, tenantID int64, opts repository.ListOptions) (*repository.ListResult[domain.User], error) {
+	return r.list(ctx, tenantID, opts)
+}
+
+// list returns the users of a tenant, or of all tenants if tenantID is 0.
+func (r *userRepository) list(ctx context.Context, tenantID int64, opts repository.ListOptions) (*repository.ListResult[domain.User], error) {
+	countQuery := `SELECT COUNT(*) FROM users WHERE $1::BIGINT = 0 OR tenant_id = $1`
 	var total int64
-	if err := r.db.Pool.QueryRow(ctx, countQuery).Scan(&total); err != nil {
+	if err := r.db.conn(ctx).QueryRow(ctx, countQuery, tenantID).Scan(&total); err != nil {
 		return nil, fmt.Errorf("failed to count users: %w", err)
 	}
 
 	query := `
-		SELECT id, username, email, password_hash, is_active, is_admin, created_at, updated_at
+		SELECT id, tenant_id, username, email, password_hash, is_active, role, must_change_password, created_at, updated_at
 		FROM users
+		WHERE $1::BIGINT = 0 OR tenant_id = $1
 		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2
+		LIMIT $2 OFFSET $3
 	`
 
-	rows, err := r.db.Pool.Query(ctx, query, opts.Limit, opts.Offset)
+	rows, err := r.db.conn(ctx).Query(ctx, query, tenantID, opts.Limit, opts.Offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list users: %w", err)
 	}
@@ -216,11 +238,13 @@ func (r *userRepository) List(ctx context.Context, opts repository.ListOptions)
 		user := &domain.User{}
 		err := rows.Scan(
 			&user.ID,
+			&user.TenantID,
 			&user.Username,
 			&user.Email,
 			&user.PasswordHash,
 			&user.IsActive,
-			&user.IsAdmin,
+			&user.Role,
+			&user.MustChangePassword,
 			&user.CreatedAt,
 			&user.UpdatedAt,
 		)
@@ -245,7 +269,7 @@ func (r *userRepository) List(ctx context.Context, opts repository.ListOptions)
 // ExistsByUsername checks if a user with the given username exists.
 func (r *userRepository) ExistsByUsername(ctx context.Context, username string) (bool, error) {
 	var exists bool
-	err := r.db.Pool.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM users WHERE username = $1)`, username).Scan(&exists)
+	err := r.db.conn(ctx).QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM users WHERE username = $1)`, username).Scan(&exists)
 	if err != nil {
 		return false, fmt.Errorf("failed to check username existence: %w", err)
 	}
@@ -255,7 +279,7 @@ func (r *userRepository) ExistsByUsername(ctx context.Context, username string)
 // ExistsByEmail checks if a user with the given email exists.
 func (r *userRepository) ExistsByEmail(ctx context.Context, email string) (bool, error) {
 	var exists bool
-	err := r.db.Pool.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM users WHERE email = $1)`, email).Scan(&exists)
+	err := r.db.conn(ctx).QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM users WHERE email = $1)`, email).Scan(&exists)
 	if err != nil {
 		return false, fmt.Errorf("failed to check email existence: %w", err)
 	}
@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// websiteRepository implements repository.WebsiteRepository.
+type websiteRepository struct {
+	db *DB
+}
+
+// NewWebsiteRepository creates a new PostgreSQL website repository.
+func NewWebsiteRepository(db *DB) repository.WebsiteRepository {
+	return &websiteRepository{db: db}
+}
+
+// PutConfig creates or replaces the configuration of a bucket.
+func (r *websiteRepository) PutConfig(ctx context.Context, config *domain.WebsiteConfig) error {
+	query := `
+		INSERT INTO bucket_websites (bucket_id, config, created_at, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (bucket_id) DO UPDATE SET
+			config = EXCLUDED.config,
+			updated_at = EXCLUDED.updated_at
+		RETURNING created_at
+	`
+
+	err := r.db.conn(ctx).QueryRow(ctx, query,
+		config.BucketID,
+		config,
+		config.CreatedAt,
+		config.UpdatedAt,
+	).Scan(&config.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to put website config: %w", err)
+	}
+
+	return nil
+}
+
+// GetConfig returns the configuration of a bucket.
+func (r *websiteRepository) GetConfig(ctx context.Context, bucketID int64) (*domain.WebsiteConfig, error) {
+	query := `
+		SELECT config, created_at, updated_at
+		FROM bucket_websites
+		WHERE bucket_id = $1
+	`
+
+	config := &domain.WebsiteConfig{}
+	err := r.db.conn(ctx).QueryRow(ctx, query, bucketID).Scan(config, &config.CreatedAt, &config.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrWebsiteConfigNotFound
+		}
+		return nil, fmt.Errorf("failed to get website config: %w", err)
+	}
+	config.BucketID = bucketID
+
+	return config, nil
+}
+
+// DeleteConfig removes the configuration of a bucket.
+func (r *websiteRepository) DeleteConfig(ctx context.Context, bucketID int64) error {
+	result, err := r.db.conn(ctx).Exec(ctx, `DELETE FROM bucket_websites WHERE bucket_id = $1`, bucketID)
+	if err != nil {
+		return fmt.Errorf("failed to delete website config: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrWebsiteConfigNotFound
+	}
+	return nil
+}
+
+// Ensure websiteRepository implements repository.WebsiteRepository
+var _ repository.WebsiteRepository = (*websiteRepository)(nil)
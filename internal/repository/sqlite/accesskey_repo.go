@@ -3,6 +3,7 @@ package sqlite
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -23,10 +24,15 @@ func NewAccessKeyRepository(db *DB) repository.AccessKeyRepository {
 // Create creates a new access key.
 func (r *accessKeyRepository) Create(ctx context.Context, key *domain.AccessKey) error {
 	query := `
-		INSERT INTO access_keys (user_id, access_key_id, encrypted_secret, description, status, created_at, expires_at, last_used_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO access_keys (user_id, access_key_id, encrypted_secret, description, status, created_at, expires_at, last_used_at, policy)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
+	policy, err := encodePolicy(key.Policy)
+	if err != nil {
+		return err
+	}
+
 	var expiresAt, lastUsedAt sql.NullString
 	if key.ExpiresAt != nil {
 		expiresAt = sql.NullString{String: key.ExpiresAt.Format(time.RFC3339), Valid: true}
@@ -44,6 +50,7 @@ func (r *accessKeyRepository) Create(ctx context.Context, key *domain.AccessKey)
 		key.CreatedAt.Format(time.RFC3339),
 		expiresAt,
 		lastUsedAt,
+		policy,
 	)
 
 	if err != nil {
@@ -65,7 +72,7 @@ func (r *accessKeyRepository) Create(ctx context.Context, key *domain.AccessKey)
 // GetByID retrieves an access key by ID.
 func (r *accessKeyRepository) GetByID(ctx context.Context, id int64) (*domain.AccessKey, error) {
 	query := `
-		SELECT id, user_id, access_key_id, encrypted_secret, description, status, created_at, expires_at, last_used_at
+		SELECT id, user_id, access_key_id, encrypted_secret, description, status, created_at, expires_at, last_used_at, policy
 		FROM access_keys
 		WHERE id = ?
 	`
@@ -75,7 +82,7 @@ func (r *accessKeyRepository) GetByID(ctx context.Context, id int64) (*domain.Ac
 // GetByAccessKeyID retrieves an access key by access key ID (20-char identifier).
 func (r *accessKeyRepository) GetByAccessKeyID(ctx context.Context, accessKeyID string) (*domain.AccessKey, error) {
 	query := `
-		SELECT id, user_id, access_key_id, encrypted_secret, description, status, created_at, expires_at, last_used_at
+		SELECT id, user_id, access_key_id, encrypted_secret, description, status, created_at, expires_at, last_used_at, policy
 		FROM access_keys
 		WHERE access_key_id = ?
 	`
@@ -85,7 +92,7 @@ func (r *accessKeyRepository) GetByAccessKeyID(ctx context.Context, accessKeyID
 // GetActiveByAccessKeyID retrieves an active, non-expired access key.
 func (r *accessKeyRepository) GetActiveByAccessKeyID(ctx context.Context, accessKeyID string) (*domain.AccessKey, error) {
 	query := `
-		SELECT id, user_id, access_key_id, encrypted_secret, description, status, created_at, expires_at, last_used_at
+		SELECT id, user_id, access_key_id, encrypted_secret, description, status, created_at, expires_at, last_used_at, policy
 		FROM access_keys
 		WHERE access_key_id = ? 
 			AND status = ? 
@@ -99,7 +106,7 @@ func (r *accessKeyRepository) scanAccessKey(row *sql.Row) (*domain.AccessKey, er
 	key := &domain.AccessKey{}
 	var createdAt string
 	var expiresAt, lastUsedAt sql.NullString
-	var description sql.NullString
+	var description, policy sql.NullString
 
 	err := row.Scan(
 		&key.ID,
@@ -111,6 +118,7 @@ func (r *accessKeyRepository) scanAccessKey(row *sql.Row) (*domain.AccessKey, er
 		&createdAt,
 		&expiresAt,
 		&lastUsedAt,
+		&policy,
 	)
 
 	if err != nil {
@@ -132,6 +140,9 @@ func (r *accessKeyRepository) scanAccessKey(row *sql.Row) (*domain.AccessKey, er
 		t, _ := time.Parse(time.RFC3339, lastUsedAt.String)
 		key.LastUsedAt = &t
 	}
+	if key.Policy, err = decodePolicy(policy); err != nil {
+		return nil, err
+	}
 
 	return key, nil
 }
@@ -139,7 +150,7 @@ func (r *accessKeyRepository) scanAccessKey(row *sql.Row) (*domain.AccessKey, er
 // ListByUserID retrieves all access keys for a user.
 func (r *accessKeyRepository) ListByUserID(ctx context.Context, userID int64) ([]*domain.AccessKey, error) {
 	query := `
-		SELECT id, user_id, access_key_id, encrypted_secret, description, status, created_at, expires_at, last_used_at
+		SELECT id, user_id, access_key_id, encrypted_secret, description, status, created_at, expires_at, last_used_at, policy
 		FROM access_keys
 		WHERE user_id = ?
 		ORDER BY created_at DESC
@@ -155,7 +166,7 @@ func (r *accessKeyRepository) ListByUserID(ctx context.Context, userID int64) ([
 	for rows.Next() {
 		key := &domain.AccessKey{}
 		var createdAt string
-		var expiresAt, lastUsedAt, description sql.NullString
+		var expiresAt, lastUsedAt, description, policy sql.NullString
 
 		err := rows.Scan(
 			&key.ID,
@@ -167,6 +178,7 @@ func (r *accessKeyRepository) ListByUserID(ctx context.Context, userID int64) ([
 			&createdAt,
 			&expiresAt,
 			&lastUsedAt,
+			&policy,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan access key: %w", err)
@@ -184,6 +196,9 @@ func (r *accessKeyRepository) ListByUserID(ctx context.Context, userID int64) ([
 			t, _ := time.Parse(time.RFC3339, lastUsedAt.String)
 			key.LastUsedAt = &t
 		}
+		if key.Policy, err = decodePolicy(policy); err != nil {
+			return nil, err
+		}
 
 		keys = append(keys, key)
 	}
@@ -199,10 +214,15 @@ func (r *accessKeyRepository) ListByUserID(ctx context.Context, userID int64) ([
 func (r *accessKeyRepository) Update(ctx context.Context, key *domain.AccessKey) error {
 	query := `
 		UPDATE access_keys
-		SET description = ?, status = ?, expires_at = ?
+		SET description = ?, status = ?, expires_at = ?, policy = ?
 		WHERE id = ?
 	`
 
+	policy, err := encodePolicy(key.Policy)
+	if err != nil {
+		return err
+	}
+
 	var expiresAt sql.NullString
 	if key.ExpiresAt != nil {
 		expiresAt = sql.NullString{String: key.ExpiresAt.Format(time.RFC3339), Valid: true}
@@ -212,6 +232,7 @@ func (r *accessKeyRepository) Update(ctx context.Context, key *domain.AccessKey)
 		key.Description,
 		key.Status,
 		expiresAt,
+		policy,
 		key.ID,
 	)
 	if err != nil {
@@ -226,6 +247,21 @@ func (r *accessKeyRepository) Update(ctx context.Context, key *domain.AccessKey)
 	return nil
 }
 
+// UpdateSecret replaces the encrypted secret of an access key.
+func (r *accessKeyRepository) UpdateSecret(ctx context.Context, id int64, encryptedSecret string) error {
+	query := `UPDATE access_keys SET encrypted_secret = ? WHERE id = ?`
+	result, err := r.db.ExecContext(ctx, query, encryptedSecret, id)
+	if err != nil {
+		return fmt.Errorf("failed to update access key secret: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return domain.ErrAccessKeyNotFound
+	}
+	return nil
+}
+
 // UpdateLastUsed updates the last_used_at timestamp.
 func (r *accessKeyRepository) UpdateLastUsed(ctx context.Context, id int64) error {
 	query := `UPDATE access_keys SET last_used_at = ? WHERE id = ?`
@@ -282,5 +318,30 @@ func (r *accessKeyRepository) DeleteExpired(ctx context.Context) (int64, error)
 	return result.RowsAffected()
 }
 
+// encodePolicy serializes an access key policy to JSON; nil stays NULL.
+func encodePolicy(policy *domain.AccessKeyPolicy) (sql.NullString, error) {
+	if policy == nil {
+		return sql.NullString{}, nil
+	}
+	data, err := json.Marshal(policy)
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("failed to encode access key policy: %w", err)
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
+
+// decodePolicy parses a stored access key policy. A policy that cannot be
+// read is an error rather than nil, which would grant full access.
+func decodePolicy(value sql.NullString) (*domain.AccessKeyPolicy, error) {
+	if !value.Valid {
+		return nil, nil
+	}
+	var policy domain.AccessKeyPolicy
+	if err := json.Unmarshal([]byte(value.String), &policy); err != nil {
+		return nil, fmt.Errorf("failed to decode access key policy: %w", err)
+	}
+	return &policy, nil
+}
+
 // Ensure accessKeyRepository implements repository.AccessKeyRepository.
 var _ repository.AccessKeyRepository = (*accessKeyRepository)(nil)
@@ -0,0 +1,204 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// auditRepository implements repository.AuditRepository for SQLite.
+type auditRepository struct {
+	db *DB
+}
+
+// NewAuditRepository creates a new SQLite audit repository.
+func NewAuditRepository(db *DB) repository.AuditRepository {
+	return &auditRepository{db: db}
+}
+
+// auditColumns are the columns written by Append, in order.
+const auditColumns = `time, user_id, actor, access_key_id, operation, bucket_name, object_key,
+	source_ip, request_id, result, status_code, detail, category, severity`
+
+// auditBatchSize is the number of events per INSERT, which keeps a statement
+// well under SQLite's limit of 32766 parameters.
+const auditBatchSize = 500
+
+// Append records events and sets their IDs. Events are inserted with one
+// multi-row INSERT per batch instead of one statement each.
+func (r *auditRepository) Append(ctx context.Context, events []*domain.AuditEvent) error {
+	for start := 0; start < len(events); start += auditBatchSize {
+		if err := r.appendBatch(ctx, events[start:min(start+auditBatchSize, len(events))]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *auditRepository) appendBatch(ctx context.Context, events []*domain.AuditEvent) error {
+	values := strings.TrimSuffix(strings.Repeat("(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?), ", len(events)), ", ")
+	args := make([]interface{}, 0, len(events)*14)
+	for _, event := range events {
+		args = append(args,
+			event.Time.UTC().Format(time.RFC3339),
+			event.UserID,
+			event.Actor,
+			event.AccessKeyID,
+			event.Operation,
+			event.BucketName,
+			event.ObjectKey,
+			event.SourceIP,
+			event.RequestID,
+			string(event.Result),
+			event.StatusCode,
+			event.Detail,
+			string(event.Category),
+			string(event.Severity),
+		)
+	}
+
+	result, err := r.db.ExecContext(ctx, `INSERT INTO audit_log (`+auditColumns+`) VALUES `+values, args...)
+	if err != nil {
+		return fmt.Errorf("failed to append audit events: %w", err)
+	}
+
+	// AUTOINCREMENT assigns the rows of one statement consecutive IDs in
+	// order, ending at the last inserted ID
+	last, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get audit event id: %w", err)
+	}
+	for i, event := range events {
+		event.ID = last - int64(len(events)-1-i)
+	}
+	return nil
+}
+
+// List returns events matching the filter, ordered by ID.
+func (r *auditRepository) List(ctx context.Context, filter repository.AuditFilter) ([]*domain.AuditEvent, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 1000
+	}
+
+	var since, until string
+	if !filter.Since.IsZero() {
+		since = filter.Since.UTC().Format(time.RFC3339)
+	}
+	if !filter.Until.IsZero() {
+		until = filter.Until.UTC().Format(time.RFC3339)
+	}
+
+	query := `
+		SELECT id, time, user_id, actor, access_key_id, operation, bucket_name, object_key,
+			source_ip, request_id, result, status_code, detail, category, severity
+		FROM audit_log
+		WHERE id > ?
+			AND (? = '' OR time >= ?)
+			AND (? = '' OR time < ?)
+			AND (? = 0 OR user_id = ?)
+			AND (? = '' OR access_key_id = ?)
+			AND (? = '' OR bucket_name = ?)
+			AND (? = '' OR operation = ?)
+			AND (? = '' OR result = ?)
+			AND (? = '' OR category = ?)
+			AND (? = '' OR severity = ?)
+		ORDER BY id
+		LIMIT ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query,
+		filter.AfterID,
+		since, since,
+		until, until,
+		filter.UserID, filter.UserID,
+		filter.AccessKeyID, filter.AccessKeyID,
+		filter.BucketName, filter.BucketName,
+		filter.Operation, filter.Operation,
+		string(filter.Result), string(filter.Result),
+		string(filter.Category), string(filter.Category),
+		string(filter.Severity), string(filter.Severity),
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*domain.AuditEvent
+	for rows.Next() {
+		event := &domain.AuditEvent{}
+		var eventTime, result, category, severity string
+		err := rows.Scan(
+			&event.ID,
+			&eventTime,
+			&event.UserID,
+			&event.Actor,
+			&event.AccessKeyID,
+			&event.Operation,
+			&event.BucketName,
+			&event.ObjectKey,
+			&event.SourceIP,
+			&event.RequestID,
+			&result,
+			&event.StatusCode,
+			&event.Detail,
+			&category,
+			&severity,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan audit event: %w", err)
+		}
+		event.Time, _ = time.Parse(time.RFC3339, eventTime)
+		event.Result = domain.AuditResult(result)
+		event.Category = domain.AuditCategory(category)
+		event.Severity = domain.AuditSeverity(severity)
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating audit events: %w", err)
+	}
+
+	return events, nil
+}
+
+// Prune deletes up to limit of the oldest events of a category recorded
+// before the given time. The row in audit_log_prune lifts the append-only
+// trigger for the duration of the transaction.
+func (r *auditRepository) Prune(ctx context.Context, category domain.AuditCategory, before time.Time, limit int) (int64, error) {
+	var deleted int64
+	err := r.db.WithTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO audit_log_prune (active) VALUES (1)`); err != nil {
+			return err
+		}
+
+		result, err := tx.ExecContext(ctx, `
+			DELETE FROM audit_log
+			WHERE id IN (
+				SELECT id FROM audit_log
+				WHERE category = ? AND time < ?
+				ORDER BY id
+				LIMIT ?
+			)
+		`, string(category), before.UTC().Format(time.RFC3339), limit)
+		if err != nil {
+			return err
+		}
+		if deleted, err = result.RowsAffected(); err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `DELETE FROM audit_log_prune`)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune audit events: %w", err)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,81 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+func newAuditEvents(n int) []*domain.AuditEvent {
+	events := make([]*domain.AuditEvent, n)
+	for i := range events {
+		events[i] = &domain.AuditEvent{
+			Time:      time.Now(),
+			Actor:     "cli",
+			Operation: fmt.Sprintf("op-%d", i),
+			Result:    domain.AuditResultSuccess,
+			Category:  domain.AuditCategoryOperational,
+			Severity:  domain.AuditSeverityInfo,
+		}
+	}
+	return events
+}
+
+func TestAuditRepository_AppendBatches(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t, filepath.Join(t.TempDir(), "alexander.db"))
+	require.NoError(t, db.Migrate(ctx))
+	repo := NewAuditRepository(db)
+
+	// More events than fit in one statement, after an existing event
+	require.NoError(t, repo.Append(ctx, newAuditEvents(1)))
+	events := newAuditEvents(auditBatchSize + 7)
+	require.NoError(t, repo.Append(ctx, events))
+
+	listed, err := repo.List(ctx, repository.AuditFilter{Limit: 2 * auditBatchSize})
+	require.NoError(t, err)
+	require.Len(t, listed, len(events)+1)
+	for i, event := range events {
+		require.Equal(t, listed[i+1].ID, event.ID)
+		require.Equal(t, event.Operation, listed[i+1].Operation)
+	}
+}
+
+// BenchmarkAuditRepository_Append compares appending events one statement
+// at a time with appending them in multi-row batches, in one transaction
+// like the audit service's flush. Compare the ns/event metric.
+func BenchmarkAuditRepository_Append(b *testing.B) {
+	for _, batch := range []int{1, 10, 100} {
+		b.Run(fmt.Sprintf("batch=%d", batch), func(b *testing.B) {
+			ctx := context.Background()
+			db, err := NewDB(ctx, DefaultConfig(filepath.Join(b.TempDir(), "alexander.db")), nopLogger)
+			require.NoError(b, err)
+			defer db.Close()
+			require.NoError(b, db.Migrate(ctx))
+			repo := NewAuditRepository(db)
+			tx := NewTxManager(db)
+
+			const events = 100
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				err := tx.WithTx(ctx, func(ctx context.Context) error {
+					for appended := 0; appended < events; appended += batch {
+						if err := repo.Append(ctx, newAuditEvents(batch)); err != nil {
+							return err
+						}
+					}
+					return nil
+				})
+				require.NoError(b, err)
+			}
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*events), "ns/event")
+		})
+	}
+}
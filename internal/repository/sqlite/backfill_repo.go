@@ -0,0 +1,116 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// backfillRepository implements repository.BackfillRepository for SQLite.
+type backfillRepository struct {
+	db *DB
+}
+
+// NewBackfillRepository creates a new SQLite backfill repository.
+func NewBackfillRepository(db *DB) repository.BackfillRepository {
+	return &backfillRepository{db: db}
+}
+
+// Acquire returns the named backfill's progress, creating it if needed.
+// SQLite allows a single writer, so a transaction needs no row lock.
+func (r *backfillRepository) Acquire(ctx context.Context, name string) (*domain.BackfillProgress, error) {
+	now := time.Now().UTC().Format(time.RFC3339)
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO backfill_progress (name, started_at, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT (name) DO NOTHING
+	`, name, now, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create backfill progress: %w", err)
+	}
+
+	row := r.db.QueryRowContext(ctx, `
+		SELECT name, cursor, rows_done, started_at, updated_at, completed_at
+		FROM backfill_progress
+		WHERE name = ?
+	`, name)
+	progress, err := scanBackfillProgress(row)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get backfill progress: %w", err)
+	}
+	return progress, nil
+}
+
+// Update saves the progress.
+func (r *backfillRepository) Update(ctx context.Context, progress *domain.BackfillProgress) error {
+	var completedAt sql.NullString
+	if progress.CompletedAt != nil {
+		completedAt = sql.NullString{String: progress.CompletedAt.UTC().Format(time.RFC3339), Valid: true}
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE backfill_progress
+		SET cursor = ?, rows_done = ?, updated_at = ?, completed_at = ?
+		WHERE name = ?
+	`, progress.Cursor, progress.RowsDone, progress.UpdatedAt.UTC().Format(time.RFC3339), completedAt, progress.Name)
+	if err != nil {
+		return fmt.Errorf("failed to update backfill progress: %w", err)
+	}
+	return nil
+}
+
+// List returns the progress of every backfill, by name.
+func (r *backfillRepository) List(ctx context.Context) ([]*domain.BackfillProgress, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT name, cursor, rows_done, started_at, updated_at, completed_at
+		FROM backfill_progress
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backfill progress: %w", err)
+	}
+	defer rows.Close()
+
+	var result []*domain.BackfillProgress
+	for rows.Next() {
+		progress, err := scanBackfillProgress(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan backfill progress: %w", err)
+		}
+		result = append(result, progress)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating backfill progress: %w", err)
+	}
+
+	return result, nil
+}
+
+// scanBackfillProgress scans a backfill_progress row.
+func scanBackfillProgress(row interface{ Scan(dest ...any) error }) (*domain.BackfillProgress, error) {
+	progress := &domain.BackfillProgress{}
+	var startedAt, updatedAt string
+	var completedAt sql.NullString
+	if err := row.Scan(
+		&progress.Name,
+		&progress.Cursor,
+		&progress.RowsDone,
+		&startedAt,
+		&updatedAt,
+		&completedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	progress.StartedAt, _ = time.Parse(time.RFC3339, startedAt)
+	progress.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
+	if completedAt.Valid {
+		t, _ := time.Parse(time.RFC3339, completedAt.String)
+		progress.CompletedAt = &t
+	}
+	return progress, nil
+}
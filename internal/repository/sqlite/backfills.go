@@ -0,0 +1,82 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/prn-tf/alexander-storage/internal/migration"
+)
+
+// Backfills returns the batched data migrations of the SQLite schema, in the
+// order they must run. New backfills are appended, and shipped ones are never
+// removed or renamed.
+func Backfills(db *DB) []migration.Backfill {
+	return []migration.Backfill{
+		objectSequence(db),
+	}
+}
+
+// objectSequence numbers the versions written before migration 000010 added
+// write sequences, which start at 0. Each key's versions are numbered from 1
+// in creation order, all at once when the batch reaches the key's first
+// version, and the key's counter is set to the last of them. New writes to a
+// key that is not numbered yet start after its unnumbered versions, so both
+// agree.
+func objectSequence(db *DB) migration.Backfill {
+	return migration.Backfill{
+		Name: "object_sequence",
+		Batch: func(ctx context.Context, after int64, limit int) (int64, int, error) {
+			var last int64
+			var rows int
+			err := db.QueryRowContext(ctx, `
+				SELECT COALESCE(MAX(id), 0), COUNT(*) FROM (
+					SELECT id FROM objects
+					WHERE id > ?
+					ORDER BY id
+					LIMIT ?
+				)
+			`, after, limit).Scan(&last, &rows)
+			if err != nil {
+				return 0, 0, fmt.Errorf("failed to read object batch: %w", err)
+			}
+			if rows == 0 {
+				return after, 0, nil
+			}
+
+			// The keys of the batch with versions still to number
+			const keys = `
+				SELECT DISTINCT bucket_id, key FROM objects
+				WHERE id > ?1 AND id <= ?2 AND sequence = 0
+			`
+
+			_, err = db.ExecContext(ctx, `
+				INSERT INTO object_sequences (bucket_id, key, sequence)
+				SELECT o.bucket_id, o.key, COUNT(*)
+				FROM objects o
+				JOIN (`+keys+`) AS keys ON o.bucket_id = keys.bucket_id AND o.key = keys.key
+				WHERE o.sequence = 0
+				GROUP BY o.bucket_id, o.key
+				ON CONFLICT (bucket_id, key) DO NOTHING
+			`, after, last)
+			if err != nil {
+				return 0, 0, fmt.Errorf("failed to backfill object sequence counters: %w", err)
+			}
+
+			_, err = db.ExecContext(ctx, `
+				UPDATE objects SET sequence = numbered.sequence
+				FROM (
+					SELECT o.id,
+						ROW_NUMBER() OVER (PARTITION BY o.bucket_id, o.key ORDER BY o.id) AS sequence
+					FROM objects o
+					JOIN (`+keys+`) AS keys ON o.bucket_id = keys.bucket_id AND o.key = keys.key
+					WHERE o.sequence = 0
+				) AS numbered
+				WHERE objects.id = numbered.id
+			`, after, last)
+			if err != nil {
+				return 0, 0, fmt.Errorf("failed to backfill object sequences: %w", err)
+			}
+			return last, rows, nil
+		},
+	}
+}
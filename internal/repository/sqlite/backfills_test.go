@@ -0,0 +1,79 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/migration"
+)
+
+func TestBackfills_ObjectSequence(t *testing.T) {
+	ctx := context.Background()
+	db, err := NewDB(ctx, DefaultConfig(filepath.Join(t.TempDir(), "alexander.db")), zerolog.Nop())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, db.Migrate(ctx))
+
+	user := domain.NewUser("owner", "owner@example.com", "hash")
+	require.NoError(t, NewUserRepository(db).Create(ctx, user))
+	bucket := domain.NewBucket(user.ID, "photos")
+	require.NoError(t, NewBucketRepository(db).Create(ctx, bucket))
+
+	// Versions written before sequences existed, interleaved so a.txt spans
+	// several batches
+	repo := NewObjectRepository(db)
+	create := func(key string, sequence int64) {
+		t.Helper()
+		require.NoError(t, repo.MarkNotLatest(ctx, bucket.ID, key))
+		obj := domain.NewObject(bucket.ID, key, "hash", "text/plain", "etag", 1)
+		obj.Sequence = sequence
+		require.NoError(t, repo.Create(ctx, obj))
+	}
+	for _, key := range []string{"a.txt", "b.txt", "a.txt", "a.txt"} {
+		create(key, 0)
+	}
+	sequences := func(key string) []int64 {
+		t.Helper()
+		rows, err := db.QueryContext(ctx, `SELECT sequence FROM objects WHERE bucket_id = ? AND key = ? ORDER BY id`, bucket.ID, key)
+		require.NoError(t, err)
+		defer rows.Close()
+		var result []int64
+		for rows.Next() {
+			var sequence int64
+			require.NoError(t, rows.Scan(&sequence))
+			result = append(result, sequence)
+		}
+		require.NoError(t, rows.Err())
+		return result
+	}
+	require.Equal(t, []int64{0, 0, 0}, sequences("a.txt"))
+
+	// A write before the backfill comes after the key's existing versions
+	sequence, err := repo.NextSequence(ctx, bucket.ID, "b.txt")
+	require.NoError(t, err)
+	require.EqualValues(t, 2, sequence)
+	create("b.txt", sequence)
+	sequence, err = repo.NextSequence(ctx, bucket.ID, "new.txt")
+	require.NoError(t, err)
+	require.EqualValues(t, 1, sequence)
+
+	runner := migration.NewBackfillRunner(NewBackfillRepository(db), NewTxManager(db), zerolog.Nop(), migration.BackfillConfig{
+		BatchSize: 2,
+		Pause:     time.Millisecond,
+	})
+	require.NoError(t, runner.RunAll(ctx, Backfills(db)))
+
+	require.Equal(t, []int64{1, 2, 3}, sequences("a.txt"))
+	require.Equal(t, []int64{1, 2}, sequences("b.txt"))
+	for key, want := range map[string]int64{"a.txt": 4, "b.txt": 3, "new.txt": 2} {
+		sequence, err := repo.NextSequence(ctx, bucket.ID, key)
+		require.NoError(t, err)
+		require.Equal(t, want, sequence, key)
+	}
+}
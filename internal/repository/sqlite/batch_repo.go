@@ -0,0 +1,127 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// batchRepository implements repository.BatchRepository for SQLite.
+type batchRepository struct {
+	db *DB
+}
+
+// NewBatchRepository creates a new SQLite batch repository.
+func NewBatchRepository(db *DB) repository.BatchRepository {
+	return &batchRepository{db: db}
+}
+
+// Create opens a batch.
+func (r *batchRepository) Create(ctx context.Context, batch *domain.Batch) error {
+	query := `INSERT INTO batches (id, owner_id, created_at, expires_at) VALUES (?, ?, ?, ?)`
+
+	_, err := r.db.ExecContext(ctx, query,
+		batch.ID.String(),
+		batch.OwnerID,
+		batch.CreatedAt.UTC().Format(time.RFC3339),
+		batch.ExpiresAt.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create batch: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID returns a batch.
+func (r *batchRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Batch, error) {
+	query := `SELECT id, owner_id, created_at, expires_at FROM batches WHERE id = ?`
+
+	batch, err := scanBatch(r.db.QueryRowContext(ctx, query, id.String()))
+	if err != nil {
+		if isNoRows(err) {
+			return nil, domain.ErrBatchNotFound
+		}
+		return nil, fmt.Errorf("failed to get batch: %w", err)
+	}
+
+	return batch, nil
+}
+
+// Delete removes a batch.
+func (r *batchRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM batches WHERE id = ?`, id.String())
+	if err != nil {
+		return fmt.Errorf("failed to delete batch: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return domain.ErrBatchNotFound
+	}
+
+	return nil
+}
+
+// ListExpired returns up to limit batches that expired before the cutoff.
+func (r *batchRepository) ListExpired(ctx context.Context, before time.Time, limit int) ([]*domain.Batch, error) {
+	query := `
+		SELECT id, owner_id, created_at, expires_at
+		FROM batches
+		WHERE expires_at < ?
+		ORDER BY expires_at ASC, id ASC
+		LIMIT ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, before.UTC().Format(time.RFC3339), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired batches: %w", err)
+	}
+	defer rows.Close()
+
+	var batches []*domain.Batch
+	for rows.Next() {
+		batch, err := scanBatch(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan batch: %w", err)
+		}
+		batches = append(batches, batch)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating batches: %w", err)
+	}
+
+	return batches, nil
+}
+
+// scanBatch scans the columns of one batches row.
+func scanBatch(row interface{ Scan(dest ...any) error }) (*domain.Batch, error) {
+	batch := &domain.Batch{}
+	var id, createdAt, expiresAt string
+
+	if err := row.Scan(&id, &batch.OwnerID, &createdAt, &expiresAt); err != nil {
+		return nil, err
+	}
+
+	var err error
+	batch.ID, err = uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid batch ID %q: %w", id, err)
+	}
+	batch.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	batch.ExpiresAt, _ = time.Parse(time.RFC3339, expiresAt)
+
+	return batch, nil
+}
+
+// Ensure batchRepository implements repository.BatchRepository
+var _ repository.BatchRepository = (*batchRepository)(nil)
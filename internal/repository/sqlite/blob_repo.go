@@ -2,6 +2,7 @@ package sqlite
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"time"
 
@@ -51,7 +52,7 @@ func (r *blobRepository) UpsertWithRefIncrement(ctx context.Context, contentHash
 	// Existing blob - increment ref_count
 	query := `
 		UPDATE blobs
-		SET ref_count = ref_count + 1, last_accessed = ?
+		SET ref_count = ref_count + 1, gc_state = '', last_accessed = ?
 		WHERE content_hash = ?
 	`
 	_, err = r.db.ExecContext(ctx, query, time.Now().UTC().Format(time.RFC3339), contentHash)
@@ -91,7 +92,7 @@ func (r *blobRepository) UpsertEncrypted(ctx context.Context, contentHash string
 	// Existing blob - increment ref_count
 	query := `
 		UPDATE blobs
-		SET ref_count = ref_count + 1, last_accessed = ?
+		SET ref_count = ref_count + 1, gc_state = '', last_accessed = ?
 		WHERE content_hash = ?
 	`
 	_, err = r.db.ExecContext(ctx, query, time.Now().UTC().Format(time.RFC3339), contentHash)
@@ -170,7 +171,7 @@ func (r *blobRepository) GetEncryptionStatus(ctx context.Context, contentHash st
 func (r *blobRepository) IncrementRef(ctx context.Context, contentHash string) error {
 	query := `
 		UPDATE blobs
-		SET ref_count = ref_count + 1
+		SET ref_count = ref_count + 1, gc_state = ''
 		WHERE content_hash = ?
 	`
 
@@ -251,7 +252,7 @@ func (r *blobRepository) Exists(ctx context.Context, contentHash string) (bool,
 
 // Delete deletes a blob by its content hash.
 func (r *blobRepository) Delete(ctx context.Context, contentHash string) error {
-	query := `DELETE FROM blobs WHERE content_hash = ? AND ref_count <= 0`
+	query := `DELETE FROM blobs WHERE content_hash = ? AND ref_count <= 0 AND gc_state = 'storage_deleted'`
 
 	result, err := r.db.ExecContext(ctx, query, contentHash)
 	if err != nil {
@@ -267,20 +268,53 @@ func (r *blobRepository) Delete(ctx context.Context, contentHash string) error {
 }
 
 // ListOrphans returns blobs with ref_count = 0 that are older than the grace period.
+// Blobs with the fewest GC attempts come first so repeatedly failing deletions
+// do not starve the rest.
 func (r *blobRepository) ListOrphans(ctx context.Context, gracePeriod time.Duration, limit int) ([]*domain.Blob, error) {
 	cutoff := time.Now().UTC().Add(-gracePeriod).Format(time.RFC3339)
 
 	query := `
-		SELECT content_hash, size, storage_path, ref_count, is_encrypted, encryption_iv, created_at, last_accessed
+		SELECT content_hash, size, storage_path, ref_count, is_encrypted, encryption_iv, gc_state, gc_attempts, gc_last_error, created_at, last_accessed
 		FROM blobs
 		WHERE ref_count <= 0 AND created_at < ?
-		ORDER BY created_at ASC
+		ORDER BY gc_attempts ASC, created_at ASC
+		LIMIT ?
+	`
+	return r.listWithGCState(ctx, query, cutoff, limit)
+}
+
+// ListOrphansAfter returns orphan blobs after a content hash, in content hash order.
+func (r *blobRepository) ListOrphansAfter(ctx context.Context, gracePeriod time.Duration, after string, limit int) ([]*domain.Blob, error) {
+	cutoff := time.Now().UTC().Add(-gracePeriod).Format(time.RFC3339)
+
+	query := `
+		SELECT content_hash, size, storage_path, ref_count, is_encrypted, encryption_iv, gc_state, gc_attempts, gc_last_error, created_at, last_accessed
+		FROM blobs
+		WHERE ref_count <= 0 AND created_at < ? AND content_hash > ?
+		ORDER BY content_hash ASC
+		LIMIT ?
+	`
+	return r.listWithGCState(ctx, query, cutoff, after, limit)
+}
+
+// ListAfter returns blobs whose content hash sorts after the given one, in
+// content hash order.
+func (r *blobRepository) ListAfter(ctx context.Context, after string, limit int) ([]*domain.Blob, error) {
+	query := `
+		SELECT content_hash, size, storage_path, ref_count, is_encrypted, encryption_iv, gc_state, gc_attempts, gc_last_error, created_at, last_accessed
+		FROM blobs
+		WHERE content_hash > ?
+		ORDER BY content_hash ASC
 		LIMIT ?
 	`
+	return r.listWithGCState(ctx, query, after, limit)
+}
 
-	rows, err := r.db.QueryContext(ctx, query, cutoff, limit)
+// listWithGCState runs a query selecting blobs along with their GC state.
+func (r *blobRepository) listWithGCState(ctx context.Context, query string, args ...interface{}) ([]*domain.Blob, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list orphan blobs: %w", err)
+		return nil, fmt.Errorf("failed to list blobs: %w", err)
 	}
 	defer rows.Close()
 
@@ -298,6 +332,9 @@ func (r *blobRepository) ListOrphans(ctx context.Context, gracePeriod time.Durat
 			&blob.RefCount,
 			&isEncrypted,
 			&encryptionIV,
+			&blob.GCState,
+			&blob.GCAttempts,
+			&blob.GCLastError,
 			&createdAt,
 			&lastAccessed,
 		)
@@ -322,6 +359,76 @@ func (r *blobRepository) ListOrphans(ctx context.Context, gracePeriod time.Durat
 	return blobs, nil
 }
 
+// GetGCCursor returns the content hash GC resumes after.
+func (r *blobRepository) GetGCCursor(ctx context.Context) (string, error) {
+	var after string
+	err := r.db.QueryRowContext(ctx, `SELECT after_hash FROM gc_cursor WHERE id = 1`).Scan(&after)
+	if err != nil {
+		if isNoRows(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get gc cursor: %w", err)
+	}
+	return after, nil
+}
+
+// SaveGCCursor saves the content hash GC resumes after.
+func (r *blobRepository) SaveGCCursor(ctx context.Context, after string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO gc_cursor (id, after_hash, updated_at) VALUES (1, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET after_hash = excluded.after_hash, updated_at = excluded.updated_at
+	`, after, time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("failed to save gc cursor: %w", err)
+	}
+	return nil
+}
+
+// MarkGCPending claims an orphan blob for deletion.
+func (r *blobRepository) MarkGCPending(ctx context.Context, contentHash string) (bool, error) {
+	query := `
+		UPDATE blobs
+		SET gc_state = 'pending', gc_attempts = gc_attempts + 1, gc_last_error = NULL
+		WHERE content_hash = ? AND ref_count <= 0 AND gc_state IN ('', 'pending')
+	`
+
+	result, err := r.db.ExecContext(ctx, query, contentHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark blob gc pending: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected > 0, nil
+}
+
+// MarkGCStorageDeleted records that a pending blob's content was deleted.
+func (r *blobRepository) MarkGCStorageDeleted(ctx context.Context, contentHash string) (bool, error) {
+	query := `
+		UPDATE blobs
+		SET gc_state = 'storage_deleted'
+		WHERE content_hash = ? AND ref_count <= 0 AND gc_state = 'pending'
+	`
+
+	result, err := r.db.ExecContext(ctx, query, contentHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark blob storage deleted: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected > 0, nil
+}
+
+// RecordGCError stores the last GC error for a blob.
+func (r *blobRepository) RecordGCError(ctx context.Context, contentHash string, message string) error {
+	query := `UPDATE blobs SET gc_last_error = ? WHERE content_hash = ?`
+
+	if _, err := r.db.ExecContext(ctx, query, message, contentHash); err != nil {
+		return fmt.Errorf("failed to record blob gc error: %w", err)
+	}
+
+	return nil
+}
+
 // DeleteOrphans deletes orphan blobs older than the grace period.
 func (r *blobRepository) DeleteOrphans(ctx context.Context, gracePeriod time.Duration, limit int) ([]*domain.Blob, error) {
 	// First get the blobs to be deleted
@@ -355,11 +462,11 @@ func (r *blobRepository) UpdateLastAccessed(ctx context.Context, contentHash str
 	return nil
 }
 
-// UpdateEncrypted marks a blob as encrypted with the given IV.
-// Used during lazy migration of existing unencrypted blobs.
-func (r *blobRepository) UpdateEncrypted(ctx context.Context, contentHash string, encryptionIV string) error {
-	query := `UPDATE blobs SET is_encrypted = 1, encryption_iv = ? WHERE content_hash = ?`
-	result, err := r.db.ExecContext(ctx, query, encryptionIV, contentHash)
+// UpdateEncrypted marks a blob as encrypted with the given IV and key version.
+// Used during lazy migration of existing unencrypted blobs and key rotation.
+func (r *blobRepository) UpdateEncrypted(ctx context.Context, contentHash string, encryptionIV string, keyVersion int) error {
+	query := `UPDATE blobs SET is_encrypted = 1, encryption_iv = ?, encryption_key_version = ? WHERE content_hash = ?`
+	result, err := r.db.ExecContext(ctx, query, encryptionIV, keyVersion, contentHash)
 	if err != nil {
 		return fmt.Errorf("failed to update blob encryption status: %w", err)
 	}
@@ -426,17 +533,34 @@ func (r *blobRepository) ListUnencrypted(ctx context.Context, limit int) ([]*dom
 	return blobs, nil
 }
 
-// UpdateEncrypted marks a blob as encrypted with the given IV.
+// ListEncrypted returns encrypted blobs for key rotation.
 func (r *blobRepository) ListEncrypted(ctx context.Context, limit int, offset int) ([]*domain.Blob, error) {
 	query := `
-		SELECT content_hash, size, storage_path, ref_count, is_encrypted, encryption_iv, created_at, last_accessed
+		SELECT content_hash, size, storage_path, ref_count, is_encrypted, encryption_iv, encryption_key_version, created_at, last_accessed
 		FROM blobs
 		WHERE is_encrypted = 1
 		ORDER BY created_at ASC
 		LIMIT ? OFFSET ?
 	`
+	return r.listEncrypted(ctx, query, limit, offset)
+}
+
+// ListEncryptedBelowKeyVersion returns encrypted blobs whose SSE master key
+// version is below keyVersion and whose content hash sorts after after.
+func (r *blobRepository) ListEncryptedBelowKeyVersion(ctx context.Context, keyVersion int, after string, limit int) ([]*domain.Blob, error) {
+	query := `
+		SELECT content_hash, size, storage_path, ref_count, is_encrypted, encryption_iv, encryption_key_version, created_at, last_accessed
+		FROM blobs
+		WHERE is_encrypted = 1 AND encryption_key_version < ? AND content_hash > ?
+		ORDER BY content_hash
+		LIMIT ?
+	`
+	return r.listEncrypted(ctx, query, keyVersion, after, limit)
+}
 
-	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+// listEncrypted runs a query for encrypted blobs and their key versions.
+func (r *blobRepository) listEncrypted(ctx context.Context, query string, args ...any) ([]*domain.Blob, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list encrypted blobs: %w", err)
 	}
@@ -456,6 +580,7 @@ func (r *blobRepository) ListEncrypted(ctx context.Context, limit int, offset in
 			&blob.RefCount,
 			&isEncrypted,
 			&encryptionIV,
+			&blob.EncryptionKeyVersion,
 			&createdAt,
 			&lastAccessed,
 		)
@@ -535,3 +660,228 @@ func (r *blobRepository) ListAll(ctx context.Context, limit int) ([]*domain.Blob
 
 // Ensure blobRepository implements repository.BlobRepository.
 var _ repository.BlobRepository = (*blobRepository)(nil)
+
+// ListCreatedBefore returns blobs created before the given time, ordered by content hash.
+func (r *blobRepository) ListCreatedBefore(ctx context.Context, before time.Time, afterHash string, limit int) ([]*domain.Blob, error) {
+	query := `
+		SELECT content_hash, size, storage_path, ref_count, is_encrypted, encryption_iv, created_at, last_accessed
+		FROM blobs
+		WHERE created_at < ? AND content_hash > ?
+		ORDER BY content_hash ASC
+		LIMIT ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, before.UTC().Format(time.RFC3339), afterHash, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list blobs: %w", err)
+	}
+	defer rows.Close()
+
+	var blobs []*domain.Blob
+	for rows.Next() {
+		blob := &domain.Blob{}
+		var isEncrypted int
+		var encryptionIV *string
+		var createdAt, lastAccessed string
+
+		err := rows.Scan(
+			&blob.ContentHash,
+			&blob.Size,
+			&blob.StoragePath,
+			&blob.RefCount,
+			&isEncrypted,
+			&encryptionIV,
+			&createdAt,
+			&lastAccessed,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan blob: %w", err)
+		}
+
+		blob.IsEncrypted = isEncrypted == 1
+		blob.EncryptionIV = encryptionIV
+		blob.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+		blob.LastAccessed, _ = time.Parse(time.RFC3339, lastAccessed)
+
+		blobs = append(blobs, blob)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating blobs: %w", err)
+	}
+
+	return blobs, nil
+}
+
+// ListUnverified returns referenced, unencrypted blobs never verified or
+// last verified before the given time, least recently verified first.
+func (r *blobRepository) ListUnverified(ctx context.Context, verifiedBefore time.Time, limit int) ([]*domain.Blob, error) {
+	query := `
+		SELECT content_hash, size, storage_path, ref_count, last_verified_at, corrupted_at, created_at, last_accessed
+		FROM blobs
+		WHERE ref_count > 0 AND gc_state = '' AND encryption_iv IS NULL
+			AND (last_verified_at IS NULL OR last_verified_at < ?)
+		ORDER BY last_verified_at ASC, content_hash ASC
+		LIMIT ?
+	`
+	return r.listVerification(ctx, query, verifiedBefore.UTC().Format(time.RFC3339), limit)
+}
+
+// RecordVerification stores the outcome of re-hashing a blob.
+func (r *blobRepository) RecordVerification(ctx context.Context, contentHash string, verifiedAt time.Time, corrupt bool) error {
+	query := `
+		UPDATE blobs
+		SET last_verified_at = ?,
+			corrupted_at = CASE WHEN ? THEN COALESCE(corrupted_at, ?) ELSE NULL END
+		WHERE content_hash = ?
+	`
+	at := verifiedAt.UTC().Format(time.RFC3339)
+	_, err := r.db.ExecContext(ctx, query, at, corrupt, at, contentHash)
+	if err != nil {
+		return fmt.Errorf("failed to record blob verification: %w", err)
+	}
+	return nil
+}
+
+// ListCorrupt returns blobs whose content was found corrupt, oldest finding first.
+func (r *blobRepository) ListCorrupt(ctx context.Context, limit int) ([]*domain.Blob, error) {
+	query := `
+		SELECT content_hash, size, storage_path, ref_count, last_verified_at, corrupted_at, created_at, last_accessed
+		FROM blobs
+		WHERE corrupted_at IS NOT NULL
+		ORDER BY corrupted_at ASC, content_hash ASC
+		LIMIT ?
+	`
+	return r.listVerification(ctx, query, limit)
+}
+
+// CountCorrupt returns the number of blobs whose content was found corrupt.
+func (r *blobRepository) CountCorrupt(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM blobs WHERE corrupted_at IS NOT NULL`).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count corrupt blobs: %w", err)
+	}
+	return count, nil
+}
+
+// GetStats returns the number and total size of all blobs.
+func (r *blobRepository) GetStats(ctx context.Context) (*domain.BlobStats, error) {
+	query := `
+		SELECT COUNT(*),
+		       COALESCE(SUM(CASE WHEN ref_count <= 0 THEN 1 ELSE 0 END), 0),
+		       COALESCE(SUM(size), 0),
+		       COALESCE(SUM(size * MAX(ref_count, 0)), 0)
+		FROM blobs
+	`
+	var stats domain.BlobStats
+	err := r.db.QueryRowContext(ctx, query).Scan(&stats.Blobs, &stats.Orphans, &stats.StoredBytes, &stats.ReferencedBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blob stats: %w", err)
+	}
+	return &stats, nil
+}
+
+// CountRefsAfter returns the stored and counted references of blobs whose
+// content hash sorts after the given one, in content hash order.
+func (r *blobRepository) CountRefsAfter(ctx context.Context, after string, limit int) ([]*domain.BlobRefCount, error) {
+	// The references each blob should have: one per live object version,
+	// upload part, staged object and snapshot object
+	query := `
+		SELECT b.content_hash, b.size, b.gc_state, b.ref_count,
+		       (SELECT COUNT(*) FROM objects o WHERE o.content_hash = b.content_hash AND o.deleted_at IS NULL)
+		     + (SELECT COUNT(*) FROM upload_parts p WHERE p.content_hash = b.content_hash)
+		     + (SELECT COUNT(*) FROM staged_objects s WHERE s.content_hash = b.content_hash)
+		     + (SELECT COUNT(*) FROM bucket_snapshot_objects so WHERE so.content_hash = b.content_hash)
+		FROM blobs b
+		WHERE b.content_hash > ?
+		ORDER BY b.content_hash ASC
+		LIMIT ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count blob references: %w", err)
+	}
+	defer rows.Close()
+
+	var counts []*domain.BlobRefCount
+	for rows.Next() {
+		count := &domain.BlobRefCount{}
+		if err := rows.Scan(&count.ContentHash, &count.Size, &count.GCState, &count.Stored, &count.Actual); err != nil {
+			return nil, fmt.Errorf("failed to scan blob references: %w", err)
+		}
+		counts = append(counts, count)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating blob references: %w", err)
+	}
+	return counts, nil
+}
+
+// SetRefCount replaces the reference count of a blob GC is not collecting,
+// if it still equals expected.
+func (r *blobRepository) SetRefCount(ctx context.Context, contentHash string, expected, refCount int32) (bool, error) {
+	query := `
+		UPDATE blobs
+		SET ref_count = ?
+		WHERE content_hash = ? AND ref_count = ? AND gc_state = ''
+	`
+
+	result, err := r.db.ExecContext(ctx, query, refCount, contentHash, expected)
+	if err != nil {
+		return false, fmt.Errorf("failed to set blob ref_count: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected > 0, nil
+}
+
+// listVerification runs a query selecting blobs with their verification state.
+func (r *blobRepository) listVerification(ctx context.Context, query string, args ...interface{}) ([]*domain.Blob, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list blobs: %w", err)
+	}
+	defer rows.Close()
+
+	var blobs []*domain.Blob
+	for rows.Next() {
+		blob := &domain.Blob{}
+		var lastVerifiedAt, corruptedAt sql.NullString
+		var createdAt, lastAccessed string
+
+		err := rows.Scan(
+			&blob.ContentHash,
+			&blob.Size,
+			&blob.StoragePath,
+			&blob.RefCount,
+			&lastVerifiedAt,
+			&corruptedAt,
+			&createdAt,
+			&lastAccessed,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan blob: %w", err)
+		}
+
+		blob.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+		blob.LastAccessed, _ = time.Parse(time.RFC3339, lastAccessed)
+		if lastVerifiedAt.Valid {
+			t, _ := time.Parse(time.RFC3339, lastVerifiedAt.String)
+			blob.LastVerifiedAt = &t
+		}
+		if corruptedAt.Valid {
+			t, _ := time.Parse(time.RFC3339, corruptedAt.String)
+			blob.CorruptedAt = &t
+		}
+
+		blobs = append(blobs, blob)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating blobs: %w", err)
+	}
+
+	return blobs, nil
+}
@@ -2,6 +2,8 @@ package sqlite
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -22,18 +24,30 @@ func NewBucketRepository(db *DB) repository.BucketRepository {
 // Create creates a new bucket.
 func (r *bucketRepository) Create(ctx context.Context, bucket *domain.Bucket) error {
 	query := `
-		INSERT INTO buckets (owner_id, name, region, versioning, acl, object_lock, created_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO buckets (owner_id, tenant_id, name, region, versioning, acl, object_lock, created_at, residency, quarantine, object_defaults, mfa_delete, read_only, quota_bytes)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
+	objectDefaults, err := encodeObjectDefaults(bucket.ObjectDefaults)
+	if err != nil {
+		return err
+	}
+
 	result, err := r.db.ExecContext(ctx, query,
 		bucket.OwnerID,
+		bucket.TenantID,
 		bucket.Name,
 		bucket.Region,
 		bucket.Versioning,
 		bucket.ACL,
 		boolToInt(bucket.ObjectLock),
 		bucket.CreatedAt.Format(time.RFC3339),
+		bucket.Residency,
+		boolToInt(bucket.Quarantine),
+		objectDefaults,
+		boolToInt(bucket.MFADelete),
+		boolToInt(bucket.ReadOnly),
+		bucket.QuotaBytes,
 	)
 
 	if err != nil {
@@ -55,24 +69,32 @@ func (r *bucketRepository) Create(ctx context.Context, bucket *domain.Bucket) er
 // GetByID retrieves a bucket by ID.
 func (r *bucketRepository) GetByID(ctx context.Context, id int64) (*domain.Bucket, error) {
 	query := `
-		SELECT id, owner_id, name, region, versioning, acl, object_lock, created_at
+		SELECT id, owner_id, tenant_id, name, region, versioning, acl, object_lock, created_at, residency, quarantine, object_defaults, mfa_delete, read_only, quota_bytes
 		FROM buckets
 		WHERE id = ?
 	`
 
 	bucket := &domain.Bucket{}
-	var objectLock int
+	var objectLock, quarantine, mfaDelete, readOnly int
 	var createdAt string
+	var objectDefaults sql.NullString
 
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&bucket.ID,
 		&bucket.OwnerID,
+		&bucket.TenantID,
 		&bucket.Name,
 		&bucket.Region,
 		&bucket.Versioning,
 		&bucket.ACL,
 		&objectLock,
 		&createdAt,
+		&bucket.Residency,
+		&quarantine,
+		&objectDefaults,
+		&mfaDelete,
+		&readOnly,
+		&bucket.QuotaBytes,
 	)
 
 	if err != nil {
@@ -83,7 +105,13 @@ func (r *bucketRepository) GetByID(ctx context.Context, id int64) (*domain.Bucke
 	}
 
 	bucket.ObjectLock = objectLock != 0
+	bucket.Quarantine = quarantine != 0
+	bucket.MFADelete = mfaDelete != 0
+	bucket.ReadOnly = readOnly != 0
 	bucket.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	if bucket.ObjectDefaults, err = decodeObjectDefaults(objectDefaults); err != nil {
+		return nil, err
+	}
 
 	return bucket, nil
 }
@@ -91,24 +119,32 @@ func (r *bucketRepository) GetByID(ctx context.Context, id int64) (*domain.Bucke
 // GetByName retrieves a bucket by name.
 func (r *bucketRepository) GetByName(ctx context.Context, name string) (*domain.Bucket, error) {
 	query := `
-		SELECT id, owner_id, name, region, versioning, acl, object_lock, created_at
+		SELECT id, owner_id, tenant_id, name, region, versioning, acl, object_lock, created_at, residency, quarantine, object_defaults, mfa_delete, read_only, quota_bytes
 		FROM buckets
 		WHERE name = ?
 	`
 
 	bucket := &domain.Bucket{}
-	var objectLock int
+	var objectLock, quarantine, mfaDelete, readOnly int
 	var createdAt string
+	var objectDefaults sql.NullString
 
 	err := r.db.QueryRowContext(ctx, query, name).Scan(
 		&bucket.ID,
 		&bucket.OwnerID,
+		&bucket.TenantID,
 		&bucket.Name,
 		&bucket.Region,
 		&bucket.Versioning,
 		&bucket.ACL,
 		&objectLock,
 		&createdAt,
+		&bucket.Residency,
+		&quarantine,
+		&objectDefaults,
+		&mfaDelete,
+		&readOnly,
+		&bucket.QuotaBytes,
 	)
 
 	if err != nil {
@@ -119,7 +155,13 @@ func (r *bucketRepository) GetByName(ctx context.Context, name string) (*domain.
 	}
 
 	bucket.ObjectLock = objectLock != 0
+	bucket.Quarantine = quarantine != 0
+	bucket.MFADelete = mfaDelete != 0
+	bucket.ReadOnly = readOnly != 0
 	bucket.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	if bucket.ObjectDefaults, err = decodeObjectDefaults(objectDefaults); err != nil {
+		return nil, err
+	}
 
 	return bucket, nil
 }
@@ -131,7 +173,7 @@ func (r *bucketRepository) List(ctx context.Context, userID int64) ([]*domain.Bu
 
 	if userID > 0 {
 		query = `
-			SELECT id, owner_id, name, region, versioning, acl, object_lock, created_at
+			SELECT id, owner_id, tenant_id, name, region, versioning, acl, object_lock, created_at, residency, quarantine, object_defaults, mfa_delete, read_only, quota_bytes
 			FROM buckets
 			WHERE owner_id = ?
 			ORDER BY name ASC
@@ -139,7 +181,7 @@ func (r *bucketRepository) List(ctx context.Context, userID int64) ([]*domain.Bu
 		args = []interface{}{userID}
 	} else {
 		query = `
-			SELECT id, owner_id, name, region, versioning, acl, object_lock, created_at
+			SELECT id, owner_id, tenant_id, name, region, versioning, acl, object_lock, created_at, residency, quarantine, object_defaults, mfa_delete, read_only, quota_bytes
 			FROM buckets
 			ORDER BY name ASC
 		`
@@ -151,28 +193,85 @@ func (r *bucketRepository) List(ctx context.Context, userID int64) ([]*domain.Bu
 	}
 	defer rows.Close()
 
+	return scanBuckets(rows)
+}
+
+// ListPage returns a page of the buckets of a user (or of all users if
+// userID is 0) in name order.
+func (r *bucketRepository) ListPage(ctx context.Context, userID int64, opts repository.BucketListOptions) (*repository.BucketListResult, error) {
+	// One more than requested tells whether the page is truncated
+	limit := -1
+	if opts.MaxBuckets > 0 {
+		limit = opts.MaxBuckets + 1
+	}
+
+	query := `
+		SELECT id, owner_id, tenant_id, name, region, versioning, acl, object_lock, created_at, residency, quarantine, object_defaults, mfa_delete, read_only, quota_bytes
+		FROM buckets
+		WHERE (? = 0 OR owner_id = ?)
+			AND (? = '' OR name LIKE ? || '%')
+			AND name > ?
+		ORDER BY name ASC
+		LIMIT ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, userID, opts.Prefix, opts.Prefix, opts.StartAfter, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list buckets: %w", err)
+	}
+	defer rows.Close()
+
+	buckets, err := scanBuckets(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &repository.BucketListResult{Buckets: buckets}
+	if opts.MaxBuckets > 0 && len(buckets) > opts.MaxBuckets {
+		result.IsTruncated = true
+		result.Buckets = buckets[:opts.MaxBuckets]
+	}
+	return result, nil
+}
+
+// scanBuckets scans the rows of a bucket listing.
+func scanBuckets(rows *sql.Rows) ([]*domain.Bucket, error) {
 	var buckets []*domain.Bucket
 	for rows.Next() {
 		bucket := &domain.Bucket{}
-		var objectLock int
+		var objectLock, quarantine, mfaDelete, readOnly int
 		var createdAt string
+		var objectDefaults sql.NullString
 
 		err := rows.Scan(
 			&bucket.ID,
 			&bucket.OwnerID,
+			&bucket.TenantID,
 			&bucket.Name,
 			&bucket.Region,
 			&bucket.Versioning,
 			&bucket.ACL,
 			&objectLock,
 			&createdAt,
+			&bucket.Residency,
+			&quarantine,
+			&objectDefaults,
+			&mfaDelete,
+			&readOnly,
+			&bucket.QuotaBytes,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan bucket: %w", err)
 		}
 
 		bucket.ObjectLock = objectLock != 0
+		bucket.Quarantine = quarantine != 0
+		bucket.MFADelete = mfaDelete != 0
+		bucket.ReadOnly = readOnly != 0
 		bucket.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+		if bucket.ObjectDefaults, err = decodeObjectDefaults(objectDefaults); err != nil {
+			return nil, err
+		}
 
 		buckets = append(buckets, bucket)
 	}
@@ -188,13 +287,24 @@ func (r *bucketRepository) List(ctx context.Context, userID int64) ([]*domain.Bu
 func (r *bucketRepository) Update(ctx context.Context, bucket *domain.Bucket) error {
 	query := `
 		UPDATE buckets
-		SET versioning = ?, object_lock = ?
+		SET versioning = ?, object_lock = ?, residency = ?, quarantine = ?, object_defaults = ?, mfa_delete = ?, read_only = ?, quota_bytes = ?
 		WHERE id = ?
 	`
 
+	objectDefaults, err := encodeObjectDefaults(bucket.ObjectDefaults)
+	if err != nil {
+		return err
+	}
+
 	result, err := r.db.ExecContext(ctx, query,
 		bucket.Versioning,
 		boolToInt(bucket.ObjectLock),
+		bucket.Residency,
+		boolToInt(bucket.Quarantine),
+		objectDefaults,
+		boolToInt(bucket.MFADelete),
+		boolToInt(bucket.ReadOnly),
+		bucket.QuotaBytes,
 		bucket.ID,
 	)
 
@@ -244,6 +354,56 @@ func (r *bucketRepository) UpdateACL(ctx context.Context, id int64, acl domain.B
 	return nil
 }
 
+// GetTags returns the tag set of a bucket.
+func (r *bucketRepository) GetTags(ctx context.Context, id int64) ([]domain.Tag, error) {
+	var data sql.NullString
+	err := r.db.QueryRowContext(ctx, `SELECT tags FROM buckets WHERE id = ?`, id).Scan(&data)
+	if err != nil {
+		if isNoRows(err) {
+			return nil, domain.ErrBucketNotFound
+		}
+		return nil, fmt.Errorf("failed to get bucket tags: %w", err)
+	}
+	if !data.Valid {
+		return nil, domain.ErrNoSuchTagSet
+	}
+
+	var tags []domain.Tag
+	if err := json.Unmarshal([]byte(data.String), &tags); err != nil {
+		return nil, fmt.Errorf("failed to decode bucket tags: %w", err)
+	}
+	return tags, nil
+}
+
+// PutTags replaces the tag set of a bucket.
+func (r *bucketRepository) PutTags(ctx context.Context, id int64, tags []domain.Tag) error {
+	data, err := json.Marshal(tags)
+	if err != nil {
+		return fmt.Errorf("failed to encode bucket tags: %w", err)
+	}
+	return r.setTags(ctx, id, string(data))
+}
+
+// DeleteTags removes the tag set of a bucket.
+func (r *bucketRepository) DeleteTags(ctx context.Context, id int64) error {
+	return r.setTags(ctx, id, nil)
+}
+
+// setTags sets the tags column of a bucket; nil clears it.
+func (r *bucketRepository) setTags(ctx context.Context, id int64, tags interface{}) error {
+	result, err := r.db.ExecContext(ctx, `UPDATE buckets SET tags = ? WHERE id = ?`, tags, id)
+	if err != nil {
+		return fmt.Errorf("failed to update bucket tags: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return domain.ErrBucketNotFound
+	}
+
+	return nil
+}
+
 // Delete deletes a bucket by ID.
 func (r *bucketRepository) Delete(ctx context.Context, id int64) error {
 	query := `DELETE FROM buckets WHERE id = ?`
@@ -288,16 +448,32 @@ func (r *bucketRepository) ExistsByName(ctx context.Context, name string) (bool,
 	return count > 0, nil
 }
 
-// IsEmpty checks if a bucket contains any objects.
+// IsEmpty checks if a bucket contains any objects, staged uploads or snapshots.
 func (r *bucketRepository) IsEmpty(ctx context.Context, id int64) (bool, error) {
 	var count int
-	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM objects WHERE bucket_id = ? LIMIT 1`, id).Scan(&count)
+	err := r.db.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM objects WHERE bucket_id = ? AND deleted_at IS NULL) + EXISTS (SELECT 1 FROM staged_objects WHERE bucket_id = ?)
+			+ EXISTS (SELECT 1 FROM bucket_snapshots WHERE bucket_id = ?)
+	`, id, id, id).Scan(&count)
 	if err != nil {
 		return false, fmt.Errorf("failed to check if bucket is empty: %w", err)
 	}
 	return count == 0, nil
 }
 
+// StorageBytes returns the total size of the object versions in a bucket.
+func (r *bucketRepository) StorageBytes(ctx context.Context, id int64) (int64, error) {
+	var size int64
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(size), 0) FROM objects
+		WHERE bucket_id = ? AND is_delete_marker = 0 AND deleted_at IS NULL
+	`, id).Scan(&size)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get bucket storage: %w", err)
+	}
+	return size, nil
+}
+
 // GetACLByName retrieves only the ACL for a bucket by name.
 func (r *bucketRepository) GetACLByName(ctx context.Context, name string) (domain.BucketACL, error) {
 	var acl domain.BucketACL
@@ -313,3 +489,28 @@ func (r *bucketRepository) GetACLByName(ctx context.Context, name string) (domai
 
 // Ensure bucketRepository implements repository.BucketRepository.
 var _ repository.BucketRepository = (*bucketRepository)(nil)
+
+// encodeObjectDefaults returns the object_defaults column of a bucket; nil
+// clears it.
+func encodeObjectDefaults(defaults *domain.ObjectDefaults) (interface{}, error) {
+	if defaults.IsEmpty() {
+		return nil, nil
+	}
+	data, err := json.Marshal(defaults)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode bucket object defaults: %w", err)
+	}
+	return string(data), nil
+}
+
+// decodeObjectDefaults decodes the object_defaults column of a bucket.
+func decodeObjectDefaults(data sql.NullString) (*domain.ObjectDefaults, error) {
+	if !data.Valid {
+		return nil, nil
+	}
+	defaults := &domain.ObjectDefaults{}
+	if err := json.Unmarshal([]byte(data.String), defaults); err != nil {
+		return nil, fmt.Errorf("failed to decode bucket object defaults: %w", err)
+	}
+	return defaults, nil
+}
@@ -0,0 +1,334 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// bucketSnapshotColumns lists the bucket_snapshots columns read by scanBucketSnapshot.
+const bucketSnapshotColumns = `id, bucket_id, name, object_count, total_size, created_at, expires_at`
+
+// bucketSnapshotRepository implements repository.BucketSnapshotRepository for SQLite.
+type bucketSnapshotRepository struct {
+	db *DB
+}
+
+// NewBucketSnapshotRepository creates a new SQLite bucket snapshot repository.
+func NewBucketSnapshotRepository(db *DB) repository.BucketSnapshotRepository {
+	return &bucketSnapshotRepository{db: db}
+}
+
+// Create records a snapshot of the latest versions in a bucket.
+func (r *bucketSnapshotRepository) Create(ctx context.Context, snapshot *domain.BucketSnapshot) error {
+	var expiresAt sql.NullString
+	if snapshot.ExpiresAt != nil {
+		expiresAt = sql.NullString{String: snapshot.ExpiresAt.UTC().Format(time.RFC3339), Valid: true}
+	}
+
+	result, err := r.db.ExecContext(ctx,
+		`INSERT INTO bucket_snapshots (bucket_id, name, created_at, expires_at) VALUES (?, ?, ?, ?)`,
+		snapshot.BucketID,
+		snapshot.Name,
+		snapshot.CreatedAt.UTC().Format(time.RFC3339),
+		expiresAt,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return domain.ErrSnapshotAlreadyExists
+		}
+		return fmt.Errorf("failed to create snapshot: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get snapshot ID: %w", err)
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO bucket_snapshot_objects (snapshot_id, key, version_id, content_hash, size, content_type,
+			etag, storage_class, metadata, part_sizes, sequence, created_at)
+		SELECT ?, key, version_id, content_hash, size, content_type,
+			etag, storage_class, metadata, part_sizes, sequence, created_at
+		FROM objects
+		WHERE bucket_id = ? AND is_latest = 1 AND is_delete_marker = 0 AND deleted_at IS NULL
+	`, id, snapshot.BucketID)
+	if err != nil {
+		return fmt.Errorf("failed to copy snapshot objects: %w", err)
+	}
+
+	// A blob named by several keys gets one reference per key
+	_, err = r.db.ExecContext(ctx, `
+		UPDATE blobs
+		SET ref_count = ref_count + (
+				SELECT COUNT(*) FROM bucket_snapshot_objects o
+				WHERE o.snapshot_id = ? AND o.content_hash = blobs.content_hash
+			),
+			gc_state = ''
+		WHERE content_hash IN (SELECT content_hash FROM bucket_snapshot_objects WHERE snapshot_id = ?)
+	`, id, id)
+	if err != nil {
+		return fmt.Errorf("failed to increment snapshot blob refs: %w", err)
+	}
+
+	err = r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*), COALESCE(SUM(size), 0) FROM bucket_snapshot_objects WHERE snapshot_id = ?`, id,
+	).Scan(&snapshot.ObjectCount, &snapshot.TotalSize)
+	if err != nil {
+		return fmt.Errorf("failed to count snapshot objects: %w", err)
+	}
+
+	_, err = r.db.ExecContext(ctx,
+		`UPDATE bucket_snapshots SET object_count = ?, total_size = ? WHERE id = ?`,
+		snapshot.ObjectCount, snapshot.TotalSize, id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update snapshot: %w", err)
+	}
+
+	snapshot.ID = id
+	return nil
+}
+
+// GetByName returns a snapshot of a bucket.
+func (r *bucketSnapshotRepository) GetByName(ctx context.Context, bucketID int64, name string) (*domain.BucketSnapshot, error) {
+	query := `SELECT ` + bucketSnapshotColumns + ` FROM bucket_snapshots WHERE bucket_id = ? AND name = ?`
+
+	snapshot, err := scanBucketSnapshot(r.db.QueryRowContext(ctx, query, bucketID, name))
+	if err != nil {
+		if isNoRows(err) {
+			return nil, domain.ErrSnapshotNotFound
+		}
+		return nil, fmt.Errorf("failed to get snapshot: %w", err)
+	}
+
+	return snapshot, nil
+}
+
+// ListByBucket returns the snapshots of a bucket.
+func (r *bucketSnapshotRepository) ListByBucket(ctx context.Context, bucketID int64) ([]*domain.BucketSnapshot, error) {
+	query := `SELECT ` + bucketSnapshotColumns + ` FROM bucket_snapshots WHERE bucket_id = ? ORDER BY id ASC`
+	return r.list(ctx, query, bucketID)
+}
+
+// Delete removes a snapshot and releases its blob references.
+func (r *bucketSnapshotRepository) Delete(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE blobs
+		SET ref_count = ref_count - (
+			SELECT COUNT(*) FROM bucket_snapshot_objects o
+			WHERE o.snapshot_id = ? AND o.content_hash = blobs.content_hash
+		)
+		WHERE content_hash IN (SELECT content_hash FROM bucket_snapshot_objects WHERE snapshot_id = ?)
+	`, id, id)
+	if err != nil {
+		return fmt.Errorf("failed to release snapshot blob refs: %w", err)
+	}
+
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM bucket_snapshot_objects WHERE snapshot_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete snapshot objects: %w", err)
+	}
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM bucket_snapshots WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete snapshot: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return domain.ErrSnapshotNotFound
+	}
+
+	return nil
+}
+
+// GetObject returns the object version a snapshot holds for a key.
+func (r *bucketSnapshotRepository) GetObject(ctx context.Context, snapshotID int64, key string) (*domain.Object, error) {
+	query := `
+		SELECT s.bucket_id, o.key, o.version_id, o.content_hash, o.size, o.content_type, o.etag,
+			o.storage_class, o.metadata, o.part_sizes, o.sequence, o.created_at
+		FROM bucket_snapshot_objects o
+		JOIN bucket_snapshots s ON s.id = o.snapshot_id
+		WHERE o.snapshot_id = ? AND o.key = ?
+	`
+
+	obj := &domain.Object{}
+	var versionIDStr, metadataJSON, createdAt string
+	var contentHash, etag, partSizesJSON sql.NullString
+
+	err := r.db.QueryRowContext(ctx, query, snapshotID, key).Scan(
+		&obj.BucketID,
+		&obj.Key,
+		&versionIDStr,
+		&contentHash,
+		&obj.Size,
+		&obj.ContentType,
+		&etag,
+		&obj.StorageClass,
+		&metadataJSON,
+		&partSizesJSON,
+		&obj.Sequence,
+		&createdAt,
+	)
+	if err != nil {
+		if isNoRows(err) {
+			return nil, domain.ErrObjectNotFound
+		}
+		return nil, fmt.Errorf("failed to get snapshot object: %w", err)
+	}
+
+	obj.VersionID, err = uuid.Parse(versionIDStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version ID %q: %w", versionIDStr, err)
+	}
+	if contentHash.Valid {
+		obj.ContentHash = &contentHash.String
+	}
+	if etag.Valid {
+		obj.ETag = etag.String
+	}
+	if metadataJSON != "" {
+		json.Unmarshal([]byte(metadataJSON), &obj.Metadata)
+	}
+	if partSizesJSON.Valid && partSizesJSON.String != "" {
+		json.Unmarshal([]byte(partSizesJSON.String), &obj.PartSizes)
+	}
+	obj.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+
+	return obj, nil
+}
+
+// ListObjects returns the objects of a snapshot with pagination and optional prefix filtering.
+func (r *bucketSnapshotRepository) ListObjects(ctx context.Context, snapshotID int64, opts repository.ObjectListOptions) (*repository.ObjectListResult, error) {
+	maxKeys := opts.MaxKeys
+	if maxKeys <= 0 {
+		maxKeys = 1000
+	}
+
+	query := `
+		SELECT key, version_id, size, etag, created_at, storage_class
+		FROM bucket_snapshot_objects
+		WHERE snapshot_id = ?
+			AND (? = '' OR key LIKE ? || '%')
+			AND (? = '' OR key > ?)
+		ORDER BY key ASC
+		LIMIT ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, snapshotID, opts.Prefix, opts.Prefix, opts.StartAfter, opts.StartAfter, maxKeys+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshot objects: %w", err)
+	}
+	defer rows.Close()
+
+	var objects []*domain.ObjectInfo
+	for rows.Next() {
+		obj := &domain.ObjectInfo{}
+		var etag sql.NullString
+		var createdAt string
+
+		if err := rows.Scan(&obj.Key, &obj.VersionID, &obj.Size, &etag, &createdAt, &obj.StorageClass); err != nil {
+			return nil, fmt.Errorf("failed to scan snapshot object: %w", err)
+		}
+		if etag.Valid {
+			obj.ETag = etag.String
+		}
+		obj.LastModified, _ = time.Parse(time.RFC3339, createdAt)
+		objects = append(objects, obj)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating snapshot objects: %w", err)
+	}
+
+	result := &repository.ObjectListResult{
+		KeyCount: len(objects),
+	}
+
+	if len(objects) > maxKeys {
+		result.IsTruncated = true
+		result.NextContinuationToken = objects[maxKeys-1].Key
+		result.Objects = objects[:maxKeys]
+	} else {
+		result.Objects = objects
+	}
+
+	return result, nil
+}
+
+// ListExpired returns up to limit snapshots that expired before the cutoff.
+func (r *bucketSnapshotRepository) ListExpired(ctx context.Context, before time.Time, limit int) ([]*domain.BucketSnapshot, error) {
+	query := `
+		SELECT ` + bucketSnapshotColumns + `
+		FROM bucket_snapshots
+		WHERE expires_at IS NOT NULL AND expires_at < ?
+		ORDER BY expires_at ASC, id ASC
+		LIMIT ?
+	`
+	return r.list(ctx, query, before.UTC().Format(time.RFC3339), limit)
+}
+
+// list runs a bucket_snapshots query and scans every row.
+func (r *bucketSnapshotRepository) list(ctx context.Context, query string, args ...interface{}) ([]*domain.BucketSnapshot, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	var snapshots []*domain.BucketSnapshot
+	for rows.Next() {
+		snapshot, err := scanBucketSnapshot(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan snapshot: %w", err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating snapshots: %w", err)
+	}
+
+	return snapshots, nil
+}
+
+// scanBucketSnapshot scans the bucketSnapshotColumns of one row.
+func scanBucketSnapshot(row interface{ Scan(dest ...any) error }) (*domain.BucketSnapshot, error) {
+	snapshot := &domain.BucketSnapshot{}
+	var createdAt string
+	var expiresAt sql.NullString
+
+	err := row.Scan(
+		&snapshot.ID,
+		&snapshot.BucketID,
+		&snapshot.Name,
+		&snapshot.ObjectCount,
+		&snapshot.TotalSize,
+		&createdAt,
+		&expiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	if expiresAt.Valid {
+		t, _ := time.Parse(time.RFC3339, expiresAt.String)
+		snapshot.ExpiresAt = &t
+	}
+
+	return snapshot, nil
+}
+
+// Ensure bucketSnapshotRepository implements repository.BucketSnapshotRepository
+var _ repository.BucketSnapshotRepository = (*bucketSnapshotRepository)(nil)
@@ -8,11 +8,20 @@ import (
 	"database/sql"
 	"embed"
 	"fmt"
+	"io/fs"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	_ "modernc.org/sqlite"
 
 	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/pkg/telemetry"
+	"github.com/prn-tf/alexander-storage/internal/repository"
 )
 
 //go:embed migrations/*.sql
@@ -44,6 +53,10 @@ type Config struct {
 
 	// SynchronousMode sets the synchronous mode (NORMAL, FULL, OFF).
 	SynchronousMode string
+
+	// SlowQueryThreshold is the duration from which a query is logged as
+	// slow. 0 disables slow query logging.
+	SlowQueryThreshold time.Duration
 }
 
 // DefaultConfig returns a default SQLite configuration.
@@ -62,27 +75,17 @@ func DefaultConfig(dbPath string) Config {
 
 // DB wraps a sql.DB connection for SQLite.
 type DB struct {
-	db     *sql.DB
-	logger zerolog.Logger
-	path   string
+	db        *sql.DB
+	logger    zerolog.Logger
+	path      string
+	slowQuery time.Duration
 }
 
 // NewDB creates a new SQLite database connection.
 func NewDB(ctx context.Context, cfg Config, logger zerolog.Logger) (*DB, error) {
 	// Build connection string with pragmas
 	// Note: Directory creation should be handled by caller
-
-	// Add pragmas to connection string
-	connStr := fmt.Sprintf(
-		"%s?_journal_mode=%s&_busy_timeout=%d&_cache_size=%d&_synchronous=%s&_foreign_keys=ON",
-		cfg.Path,
-		cfg.JournalMode,
-		cfg.BusyTimeout,
-		cfg.CacheSize,
-		cfg.SynchronousMode,
-	)
-
-	db, err := sql.Open("sqlite", connStr)
+	db, err := sql.Open("sqlite", connectionString(cfg))
 	if err != nil {
 		return nil, fmt.Errorf("failed to open SQLite database: %w", err)
 	}
@@ -105,12 +108,33 @@ func NewDB(ctx context.Context, cfg Config, logger zerolog.Logger) (*DB, error)
 		Msg("connected to SQLite database")
 
 	return &DB{
-		db:     db,
-		logger: logger,
-		path:   cfg.Path,
+		db:        db,
+		logger:    logger,
+		path:      cfg.Path,
+		slowQuery: cfg.SlowQueryThreshold,
 	}, nil
 }
 
+// connectionString returns the data source name for cfg. The driver runs
+// each _pragma parameter on every new connection and ignores parameters
+// such as _journal_mode, so settings must be passed as pragmas.
+func connectionString(cfg Config) string {
+	params := url.Values{}
+	if cfg.JournalMode != "" {
+		params.Add("_pragma", "journal_mode("+cfg.JournalMode+")")
+	}
+	if cfg.BusyTimeout > 0 {
+		params.Add("_pragma", "busy_timeout("+strconv.Itoa(cfg.BusyTimeout)+")")
+	}
+	if cfg.CacheSize != 0 {
+		params.Add("_pragma", "cache_size("+strconv.Itoa(cfg.CacheSize)+")")
+	}
+	if cfg.SynchronousMode != "" {
+		params.Add("_pragma", "synchronous("+cfg.SynchronousMode+")")
+	}
+	return cfg.Path + "?" + params.Encode()
+}
+
 // Close closes the database connection.
 func (db *DB) Close() error {
 	db.logger.Info().Msg("closing SQLite connection")
@@ -140,8 +164,18 @@ func (db *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
 // WithTx executes a function within a transaction.
 // If the function returns an error, the transaction is rolled back.
 // Otherwise, the transaction is committed.
+// If ctx already carries a transaction (see TxManager), fn runs in it.
 func (db *DB) WithTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
-	tx, err := db.db.BeginTx(ctx, nil)
+	return db.withTxOptions(ctx, nil, fn)
+}
+
+// withTxOptions is WithTx with explicit transaction options.
+func (db *DB) withTxOptions(ctx context.Context, opts *sql.TxOptions, fn func(tx *sql.Tx) error) error {
+	if tx, ok := txFromContext(ctx); ok {
+		return fn(tx)
+	}
+
+	tx, err := db.db.BeginTx(ctx, opts)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -168,18 +202,71 @@ func (db *DB) WithTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
 }
 
 // ExecContext executes a query without returning rows.
+// It runs in the transaction bound to ctx, if any.
 func (db *DB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
-	return db.db.ExecContext(ctx, query, args...)
+	ctx, span := startQuerySpan(ctx, query)
+	defer span.End()
+	start := time.Now()
+	result, err := db.conn(ctx).ExecContext(ctx, query, args...)
+	db.checkSlowQuery(ctx, query, start, err)
+	span.SetError(err)
+	return result, err
 }
 
 // QueryContext executes a query that returns rows.
+// It runs in the transaction bound to ctx, if any.
 func (db *DB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
-	return db.db.QueryContext(ctx, query, args...)
+	ctx, span := startQuerySpan(ctx, query)
+	defer span.End()
+	start := time.Now()
+	rows, err := db.conn(ctx).QueryContext(ctx, query, args...)
+	db.checkSlowQuery(ctx, query, start, err)
+	span.SetError(err)
+	return rows, err
 }
 
 // QueryRowContext executes a query that returns a single row.
+// It runs in the transaction bound to ctx, if any.
 func (db *DB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
-	return db.db.QueryRowContext(ctx, query, args...)
+	ctx, span := startQuerySpan(ctx, query)
+	defer span.End()
+	start := time.Now()
+	row := db.conn(ctx).QueryRowContext(ctx, query, args...)
+	db.checkSlowQuery(ctx, query, start, row.Err())
+	return row
+}
+
+// startQuerySpan starts a span for a query. The span covers executing the
+// query, not reading its rows.
+func startQuerySpan(ctx context.Context, query string) (context.Context, *telemetry.Span) {
+	ctx, span := telemetry.StartKind(ctx, "db.query", telemetry.KindClient)
+	span.SetAttribute("db.system", "sqlite")
+	span.SetAttribute("db.statement", query)
+	return ctx, span
+}
+
+// checkSlowQuery logs a query started at start if it took at least the
+// slow query threshold. The duration covers executing the query, including
+// waiting for the connection and for locks, but not reading its rows. The
+// event carries ctx, so the request log hook adds the request that ran the
+// query.
+func (db *DB) checkSlowQuery(ctx context.Context, query string, start time.Time, err error) {
+	if db.slowQuery <= 0 {
+		return
+	}
+	duration := time.Since(start)
+	if duration < db.slowQuery {
+		return
+	}
+	event := db.logger.Warn().
+		Ctx(ctx).
+		Str("statement", repository.StatementName()).
+		Str("sql", query).
+		Dur("duration", duration)
+	if err != nil {
+		event.Err(err)
+	}
+	event.Msg("slow query")
 }
 
 // Migrate runs database migrations.
@@ -204,27 +291,73 @@ func (db *DB) Migrate(ctx context.Context) error {
 
 	db.logger.Info().Int("current_version", currentVersion).Msg("checking migrations")
 
-	// For now, we apply all migrations in a single file
-	// In a production system, you'd iterate through migration files
-	if currentVersion < 1 {
-		// Read and execute the init migration
-		migration, err := migrationsFS.ReadFile("migrations/000001_init.up.sql")
-		if err != nil {
-			// If embedded migrations not found, try to continue (migrations may be applied externally)
-			db.logger.Warn().Msg("embedded migrations not found, skipping auto-migration")
-			return nil
+	migrations, err := listMigrations()
+	if err != nil {
+		// If embedded migrations not found, try to continue (migrations may be applied externally)
+		db.logger.Warn().Err(err).Msg("embedded migrations not found, skipping auto-migration")
+		return nil
+	}
+
+	for _, m := range migrations {
+		if m.version <= currentVersion {
+			continue
 		}
 
-		if _, err := db.db.ExecContext(ctx, string(migration)); err != nil {
-			return fmt.Errorf("failed to apply migration 1: %w", err)
+		script, err := migrationsFS.ReadFile(m.path)
+		if err != nil {
+			return fmt.Errorf("failed to read migration %d: %w", m.version, err)
 		}
 
-		if _, err := db.db.ExecContext(ctx, `INSERT INTO schema_migrations (version) VALUES (1)`); err != nil {
-			return fmt.Errorf("failed to record migration: %w", err)
+		err = db.WithTx(ctx, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, string(script)); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version) VALUES (?)`, m.version)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to apply migration %d: %w", m.version, err)
 		}
 
-		db.logger.Info().Int("version", 1).Msg("applied migration")
+		db.logger.Info().Int("version", m.version).Msg("applied migration")
 	}
 
 	return nil
 }
+
+// migrationFile is an embedded up-migration file.
+type migrationFile struct {
+	version int
+	path    string
+}
+
+// listMigrations returns the embedded up-migrations sorted by version.
+// Files are named NNNNNN_description.up.sql.
+func listMigrations() ([]migrationFile, error) {
+	paths, err := fs.Glob(migrationsFS, "migrations/*.up.sql")
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no migration files embedded")
+	}
+
+	migrations := make([]migrationFile, 0, len(paths))
+	for _, p := range paths {
+		name := path.Base(p)
+		prefix, _, ok := strings.Cut(name, "_")
+		if !ok {
+			return nil, fmt.Errorf("invalid migration file name: %s", name)
+		}
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", name, err)
+		}
+		migrations = append(migrations, migrationFile{version: version, path: p})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].version < migrations[j].version
+	})
+	return migrations, nil
+}
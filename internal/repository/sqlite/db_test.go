@@ -0,0 +1,38 @@
+package sqlite
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+)
+
+func TestDB_LogsSlowQueries(t *testing.T) {
+	ctx := context.Background()
+	cfg := DefaultConfig(filepath.Join(t.TempDir(), "alexander.db"))
+	cfg.SlowQueryThreshold = time.Nanosecond
+
+	var buf bytes.Buffer
+	db, err := NewDB(ctx, cfg, zerolog.New(&buf))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, db.Migrate(ctx))
+
+	buf.Reset()
+	require.NoError(t, NewUserRepository(db).Create(ctx, domain.NewUser("owner", "owner@example.com", "hash")))
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	require.Equal(t, "slow query", line["message"])
+	require.Equal(t, "warn", line["level"])
+	require.Equal(t, "sqlite.userRepository.Create", line["statement"])
+	require.Contains(t, line["sql"], "INSERT INTO users")
+	require.Contains(t, line, "duration")
+}
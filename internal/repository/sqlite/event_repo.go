@@ -0,0 +1,135 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// eventRepository implements repository.EventRepository for SQLite.
+type eventRepository struct {
+	db *DB
+}
+
+// NewEventRepository creates a new SQLite event repository.
+func NewEventRepository(db *DB) repository.EventRepository {
+	return &eventRepository{db: db}
+}
+
+// Append records an event and sets its ID.
+func (r *eventRepository) Append(ctx context.Context, event *domain.ObjectEvent) error {
+	query := `
+		INSERT INTO object_events (event_type, bucket_name, object_key, version_id, size, etag, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		string(event.Type),
+		event.BucketName,
+		event.Key,
+		event.VersionID,
+		event.Size,
+		event.ETag,
+		event.CreatedAt.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to append event: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get event id: %w", err)
+	}
+	event.ID = id
+
+	return nil
+}
+
+// List returns events matching the filter, ordered by ID.
+func (r *eventRepository) List(ctx context.Context, filter repository.EventFilter) ([]*domain.ObjectEvent, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 1000
+	}
+
+	createdBefore := ""
+	if !filter.CreatedBefore.IsZero() {
+		createdBefore = filter.CreatedBefore.UTC().Format(time.RFC3339)
+	}
+
+	query := `
+		SELECT id, event_type, bucket_name, object_key, version_id, size, etag, created_at
+		FROM object_events
+		WHERE id > ?
+			AND (? = '' OR bucket_name = ?)
+			AND (? = '' OR substr(object_key, 1, length(?)) = ?)
+			AND (? = '' OR created_at < ?)
+		ORDER BY id
+		LIMIT ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query,
+		filter.AfterID,
+		filter.BucketName, filter.BucketName,
+		filter.Prefix, filter.Prefix, filter.Prefix,
+		createdBefore, createdBefore,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*domain.ObjectEvent
+	for rows.Next() {
+		event := &domain.ObjectEvent{}
+		var eventType, createdAt string
+		err := rows.Scan(
+			&event.ID,
+			&eventType,
+			&event.BucketName,
+			&event.Key,
+			&event.VersionID,
+			&event.Size,
+			&event.ETag,
+			&createdAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		event.Type = domain.ObjectEventType(eventType)
+		event.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating events: %w", err)
+	}
+
+	return events, nil
+}
+
+// LatestID returns the ID of the newest event, or 0 if there are none.
+func (r *eventRepository) LatestID(ctx context.Context) (int64, error) {
+	var id int64
+	err := r.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(id), 0) FROM object_events`).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get latest event id: %w", err)
+	}
+	return id, nil
+}
+
+// DeleteBefore removes events created before the given time.
+func (r *eventRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx,
+		`DELETE FROM object_events WHERE created_at < ?`,
+		before.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete events: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,259 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// inventoryConfigColumns lists the bucket_inventory_configs columns read by scanInventoryConfig.
+const inventoryConfigColumns = `bucket_id, config_id, enabled, destination_bucket, destination_prefix,
+	format, frequency, prefix, all_versions, fields, last_run_at, created_at, updated_at`
+
+// inventoryRepository implements repository.InventoryRepository for SQLite.
+type inventoryRepository struct {
+	db *DB
+}
+
+// NewInventoryRepository creates a new SQLite inventory repository.
+func NewInventoryRepository(db *DB) repository.InventoryRepository {
+	return &inventoryRepository{db: db}
+}
+
+// Put creates or replaces an inventory configuration. The time of the last
+// report is kept.
+func (r *inventoryRepository) Put(ctx context.Context, config *domain.InventoryConfig) error {
+	fields, err := json.Marshal(config.Fields)
+	if err != nil {
+		return fmt.Errorf("failed to encode inventory fields: %w", err)
+	}
+
+	query := `
+		INSERT INTO bucket_inventory_configs (bucket_id, config_id, enabled, destination_bucket,
+			destination_prefix, format, frequency, prefix, all_versions, fields, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (bucket_id, config_id) DO UPDATE SET
+			enabled = excluded.enabled,
+			destination_bucket = excluded.destination_bucket,
+			destination_prefix = excluded.destination_prefix,
+			format = excluded.format,
+			frequency = excluded.frequency,
+			prefix = excluded.prefix,
+			all_versions = excluded.all_versions,
+			fields = excluded.fields,
+			updated_at = excluded.updated_at
+		RETURNING created_at
+	`
+
+	var createdAt string
+	err = r.db.QueryRowContext(ctx, query,
+		config.BucketID,
+		config.ID,
+		boolToInt(config.Enabled),
+		config.DestinationBucket,
+		config.DestinationPrefix,
+		string(config.Format),
+		string(config.Frequency),
+		config.Prefix,
+		boolToInt(config.AllVersions),
+		string(fields),
+		config.CreatedAt.UTC().Format(time.RFC3339),
+		config.UpdatedAt.UTC().Format(time.RFC3339),
+	).Scan(&createdAt)
+	if err != nil {
+		return fmt.Errorf("failed to put inventory config: %w", err)
+	}
+	config.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+
+	return nil
+}
+
+// Get returns a configuration of a bucket.
+func (r *inventoryRepository) Get(ctx context.Context, bucketID int64, id string) (*domain.InventoryConfig, error) {
+	query := `SELECT ` + inventoryConfigColumns + ` FROM bucket_inventory_configs WHERE bucket_id = ? AND config_id = ?`
+
+	config, err := scanInventoryConfig(r.db.QueryRowContext(ctx, query, bucketID, id))
+	if err != nil {
+		if isNoRows(err) {
+			return nil, domain.ErrInventoryConfigNotFound
+		}
+		return nil, fmt.Errorf("failed to get inventory config: %w", err)
+	}
+	return config, nil
+}
+
+// ListByBucket returns the configurations of a bucket, by ID.
+func (r *inventoryRepository) ListByBucket(ctx context.Context, bucketID int64) ([]*domain.InventoryConfig, error) {
+	query := `SELECT ` + inventoryConfigColumns + ` FROM bucket_inventory_configs WHERE bucket_id = ? ORDER BY config_id ASC`
+	return r.list(ctx, query, bucketID)
+}
+
+// ListEnabled returns the enabled configurations of all buckets.
+func (r *inventoryRepository) ListEnabled(ctx context.Context) ([]*domain.InventoryConfig, error) {
+	query := `SELECT ` + inventoryConfigColumns + ` FROM bucket_inventory_configs WHERE enabled = 1 ORDER BY bucket_id ASC, config_id ASC`
+	return r.list(ctx, query)
+}
+
+func (r *inventoryRepository) list(ctx context.Context, query string, args ...any) ([]*domain.InventoryConfig, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list inventory configs: %w", err)
+	}
+	defer rows.Close()
+
+	var configs []*domain.InventoryConfig
+	for rows.Next() {
+		config, err := scanInventoryConfig(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan inventory config: %w", err)
+		}
+		configs = append(configs, config)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating inventory configs: %w", err)
+	}
+	return configs, nil
+}
+
+// Delete removes a configuration.
+func (r *inventoryRepository) Delete(ctx context.Context, bucketID int64, id string) error {
+	result, err := r.db.ExecContext(ctx,
+		`DELETE FROM bucket_inventory_configs WHERE bucket_id = ? AND config_id = ?`, bucketID, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete inventory config: %w", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return domain.ErrInventoryConfigNotFound
+	}
+	return nil
+}
+
+// MarkRun records when a report was generated.
+func (r *inventoryRepository) MarkRun(ctx context.Context, bucketID int64, id string, at time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE bucket_inventory_configs SET last_run_at = ? WHERE bucket_id = ? AND config_id = ?`,
+		at.UTC().Format(time.RFC3339), bucketID, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark inventory run: %w", err)
+	}
+	return nil
+}
+
+// ListEntries returns object versions of a bucket in listing order.
+func (r *inventoryRepository) ListEntries(ctx context.Context, bucketID int64, opts repository.InventoryListOptions) ([]*domain.InventoryEntry, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 1000
+	}
+	after := opts.After
+	if after == nil {
+		after = &domain.InventoryEntry{}
+	}
+
+	query := `
+		SELECT o.id, o.key, o.version_id, o.is_latest, o.is_delete_marker, o.size, o.etag,
+			o.storage_class, o.replication_status, COALESCE(b.is_encrypted, 0),
+			o.sequence, o.created_at
+		FROM objects o
+		LEFT JOIN blobs b ON b.content_hash = o.content_hash
+		WHERE o.bucket_id = ? AND o.deleted_at IS NULL
+			AND (? = 1 OR o.is_latest = 1)
+			AND (? = '' OR o.key LIKE ? || '%')
+			AND (? = 0 OR o.key > ? OR (o.key = ? AND (o.sequence, o.id) < (?, ?)))
+		ORDER BY o.key ASC, o.sequence DESC, o.id DESC
+		LIMIT ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, bucketID,
+		boolToInt(opts.AllVersions),
+		opts.Prefix, opts.Prefix,
+		boolToInt(opts.After != nil), after.Key, after.Key, after.Sequence, after.ID,
+		limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list inventory entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*domain.InventoryEntry
+	for rows.Next() {
+		entry := &domain.InventoryEntry{}
+		var isLatest, isDeleteMarker, encrypted int
+		var etag sql.NullString
+		var createdAt string
+		err := rows.Scan(
+			&entry.ID,
+			&entry.Key,
+			&entry.VersionID,
+			&isLatest,
+			&isDeleteMarker,
+			&entry.Size,
+			&etag,
+			&entry.StorageClass,
+			&entry.ReplicationStatus,
+			&encrypted,
+			&entry.Sequence,
+			&createdAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan inventory entry: %w", err)
+		}
+		entry.IsLatest = isLatest != 0
+		entry.IsDeleteMarker = isDeleteMarker != 0
+		entry.Encrypted = encrypted != 0
+		entry.ETag = etag.String
+		entry.LastModified, _ = time.Parse(time.RFC3339, createdAt)
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating inventory entries: %w", err)
+	}
+	return entries, nil
+}
+
+func scanInventoryConfig(row interface{ Scan(dest ...any) error }) (*domain.InventoryConfig, error) {
+	config := &domain.InventoryConfig{}
+	var enabled, allVersions int
+	var format, frequency, fields, createdAt, updatedAt string
+	var lastRunAt sql.NullString
+	err := row.Scan(
+		&config.BucketID,
+		&config.ID,
+		&enabled,
+		&config.DestinationBucket,
+		&config.DestinationPrefix,
+		&format,
+		&frequency,
+		&config.Prefix,
+		&allVersions,
+		&fields,
+		&lastRunAt,
+		&createdAt,
+		&updatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	config.Enabled = enabled != 0
+	config.AllVersions = allVersions != 0
+	config.Format = domain.InventoryFormat(format)
+	config.Frequency = domain.InventoryFrequency(frequency)
+	if err := json.Unmarshal([]byte(fields), &config.Fields); err != nil {
+		return nil, fmt.Errorf("failed to decode inventory fields: %w", err)
+	}
+	if lastRunAt.Valid {
+		t, _ := time.Parse(time.RFC3339, lastRunAt.String)
+		config.LastRunAt = &t
+	}
+	config.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	config.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
+	return config, nil
+}
+
+// Ensure inventoryRepository implements repository.InventoryRepository
+var _ repository.InventoryRepository = (*inventoryRepository)(nil)
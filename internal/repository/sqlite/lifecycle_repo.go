@@ -2,6 +2,8 @@ package sqlite
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -9,6 +11,11 @@ import (
 	"github.com/prn-tf/alexander-storage/internal/repository"
 )
 
+// lifecycleRuleColumns are the columns scanned by scanLifecycleRule.
+const lifecycleRuleColumns = `id, bucket_id, rule_id, prefix, filter_tags, object_size_greater_than, object_size_less_than,
+	expiration_days, expiration_date, expired_object_delete_marker, noncurrent_days, abort_incomplete_upload_days,
+	status, created_at, updated_at`
+
 // lifecycleRepository implements repository.LifecycleRepository for SQLite.
 type lifecycleRepository struct {
 	db *DB
@@ -21,16 +28,30 @@ func NewLifecycleRepository(db *DB) repository.LifecycleRepository {
 
 // Create creates a new lifecycle rule.
 func (r *lifecycleRepository) Create(ctx context.Context, rule *domain.LifecycleRule) error {
+	tags, err := encodeLifecycleTags(rule.Tags)
+	if err != nil {
+		return err
+	}
+
 	query := `
-		INSERT INTO lifecycle_rules (bucket_id, rule_id, prefix, expiration_days, status, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO lifecycle_rules (bucket_id, rule_id, prefix, filter_tags, object_size_greater_than, object_size_less_than,
+			expiration_days, expiration_date, expired_object_delete_marker, noncurrent_days, abort_incomplete_upload_days,
+			status, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	result, err := r.db.ExecContext(ctx, query,
 		rule.BucketID,
 		rule.RuleID,
 		rule.Prefix,
+		tags,
+		rule.ObjectSizeGreaterThan,
+		rule.ObjectSizeLessThan,
 		rule.ExpirationDays,
+		formatLifecycleDate(rule.ExpirationDate),
+		boolToInt(rule.ExpiredObjectDeleteMarker),
+		rule.NoncurrentDays,
+		rule.AbortIncompleteUploadDays,
 		rule.Status,
 		rule.CreatedAt.Format(time.RFC3339),
 		rule.UpdatedAt.Format(time.RFC3339),
@@ -54,26 +75,9 @@ func (r *lifecycleRepository) Create(ctx context.Context, rule *domain.Lifecycle
 
 // GetByID retrieves a lifecycle rule by ID.
 func (r *lifecycleRepository) GetByID(ctx context.Context, id int64) (*domain.LifecycleRule, error) {
-	query := `
-		SELECT id, bucket_id, rule_id, prefix, expiration_days, status, created_at, updated_at
-		FROM lifecycle_rules
-		WHERE id = ?
-	`
-
-	rule := &domain.LifecycleRule{}
-	var createdAt, updatedAt string
-
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&rule.ID,
-		&rule.BucketID,
-		&rule.RuleID,
-		&rule.Prefix,
-		&rule.ExpirationDays,
-		&rule.Status,
-		&createdAt,
-		&updatedAt,
-	)
+	query := `SELECT ` + lifecycleRuleColumns + ` FROM lifecycle_rules WHERE id = ?`
 
+	rule, err := scanLifecycleRule(r.db.QueryRowContext(ctx, query, id))
 	if err != nil {
 		if isNoRows(err) {
 			return nil, repository.ErrNotFound
@@ -81,34 +85,14 @@ func (r *lifecycleRepository) GetByID(ctx context.Context, id int64) (*domain.Li
 		return nil, fmt.Errorf("failed to get lifecycle rule: %w", err)
 	}
 
-	rule.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
-	rule.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
-
 	return rule, nil
 }
 
 // GetByBucketAndRuleID retrieves a rule by bucket ID and rule ID.
 func (r *lifecycleRepository) GetByBucketAndRuleID(ctx context.Context, bucketID int64, ruleID string) (*domain.LifecycleRule, error) {
-	query := `
-		SELECT id, bucket_id, rule_id, prefix, expiration_days, status, created_at, updated_at
-		FROM lifecycle_rules
-		WHERE bucket_id = ? AND rule_id = ?
-	`
-
-	rule := &domain.LifecycleRule{}
-	var createdAt, updatedAt string
-
-	err := r.db.QueryRowContext(ctx, query, bucketID, ruleID).Scan(
-		&rule.ID,
-		&rule.BucketID,
-		&rule.RuleID,
-		&rule.Prefix,
-		&rule.ExpirationDays,
-		&rule.Status,
-		&createdAt,
-		&updatedAt,
-	)
+	query := `SELECT ` + lifecycleRuleColumns + ` FROM lifecycle_rules WHERE bucket_id = ? AND rule_id = ?`
 
+	rule, err := scanLifecycleRule(r.db.QueryRowContext(ctx, query, bucketID, ruleID))
 	if err != nil {
 		if isNoRows(err) {
 			return nil, repository.ErrNotFound
@@ -116,117 +100,66 @@ func (r *lifecycleRepository) GetByBucketAndRuleID(ctx context.Context, bucketID
 		return nil, fmt.Errorf("failed to get lifecycle rule: %w", err)
 	}
 
-	rule.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
-	rule.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
-
 	return rule, nil
 }
 
 // ListByBucket returns all lifecycle rules for a bucket.
 func (r *lifecycleRepository) ListByBucket(ctx context.Context, bucketID int64) ([]*domain.LifecycleRule, error) {
 	query := `
-		SELECT id, bucket_id, rule_id, prefix, expiration_days, status, created_at, updated_at
+		SELECT ` + lifecycleRuleColumns + `
 		FROM lifecycle_rules
 		WHERE bucket_id = ?
 		ORDER BY rule_id ASC
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, bucketID)
+	rules, err := r.list(ctx, query, bucketID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list lifecycle rules: %w", err)
 	}
-	defer rows.Close()
-
-	var rules []*domain.LifecycleRule
-	for rows.Next() {
-		rule := &domain.LifecycleRule{}
-		var createdAt, updatedAt string
-
-		err := rows.Scan(
-			&rule.ID,
-			&rule.BucketID,
-			&rule.RuleID,
-			&rule.Prefix,
-			&rule.ExpirationDays,
-			&rule.Status,
-			&createdAt,
-			&updatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan lifecycle rule: %w", err)
-		}
-
-		rule.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
-		rule.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
-
-		rules = append(rules, rule)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating lifecycle rules: %w", err)
-	}
-
 	return rules, nil
 }
 
 // ListEnabledByBucket returns only enabled rules for a bucket.
 func (r *lifecycleRepository) ListEnabledByBucket(ctx context.Context, bucketID int64) ([]*domain.LifecycleRule, error) {
 	query := `
-		SELECT id, bucket_id, rule_id, prefix, expiration_days, status, created_at, updated_at
+		SELECT ` + lifecycleRuleColumns + `
 		FROM lifecycle_rules
 		WHERE bucket_id = ? AND status = 'Enabled'
 		ORDER BY rule_id ASC
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, bucketID)
+	rules, err := r.list(ctx, query, bucketID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list enabled lifecycle rules: %w", err)
 	}
-	defer rows.Close()
-
-	var rules []*domain.LifecycleRule
-	for rows.Next() {
-		rule := &domain.LifecycleRule{}
-		var createdAt, updatedAt string
-
-		err := rows.Scan(
-			&rule.ID,
-			&rule.BucketID,
-			&rule.RuleID,
-			&rule.Prefix,
-			&rule.ExpirationDays,
-			&rule.Status,
-			&createdAt,
-			&updatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan lifecycle rule: %w", err)
-		}
-
-		rule.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
-		rule.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
-
-		rules = append(rules, rule)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating lifecycle rules: %w", err)
-	}
-
 	return rules, nil
 }
 
 // Update updates an existing lifecycle rule.
 func (r *lifecycleRepository) Update(ctx context.Context, rule *domain.LifecycleRule) error {
+	tags, err := encodeLifecycleTags(rule.Tags)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE lifecycle_rules
-		SET prefix = ?, expiration_days = ?, status = ?, updated_at = ?
+		SET prefix = ?, filter_tags = ?, object_size_greater_than = ?, object_size_less_than = ?,
+			expiration_days = ?, expiration_date = ?, expired_object_delete_marker = ?, noncurrent_days = ?,
+			abort_incomplete_upload_days = ?, status = ?, updated_at = ?
 		WHERE id = ?
 	`
 
 	result, err := r.db.ExecContext(ctx, query,
 		rule.Prefix,
+		tags,
+		rule.ObjectSizeGreaterThan,
+		rule.ObjectSizeLessThan,
 		rule.ExpirationDays,
+		formatLifecycleDate(rule.ExpirationDate),
+		boolToInt(rule.ExpiredObjectDeleteMarker),
+		rule.NoncurrentDays,
+		rule.AbortIncompleteUploadDays,
 		rule.Status,
 		time.Now().UTC().Format(time.RFC3339),
 		rule.ID,
@@ -293,40 +226,33 @@ func (r *lifecycleRepository) DeleteByBucket(ctx context.Context, bucketID int64
 // ListAllEnabled returns all enabled lifecycle rules across all buckets.
 func (r *lifecycleRepository) ListAllEnabled(ctx context.Context) ([]*domain.LifecycleRule, error) {
 	query := `
-		SELECT id, bucket_id, rule_id, prefix, expiration_days, status, created_at, updated_at
+		SELECT ` + lifecycleRuleColumns + `
 		FROM lifecycle_rules
 		WHERE status = 'Enabled'
 		ORDER BY bucket_id ASC, rule_id ASC
 	`
 
-	rows, err := r.db.QueryContext(ctx, query)
+	rules, err := r.list(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list all enabled lifecycle rules: %w", err)
 	}
+	return rules, nil
+}
+
+// list returns the rules a query selecting lifecycleRuleColumns returns.
+func (r *lifecycleRepository) list(ctx context.Context, query string, args ...interface{}) ([]*domain.LifecycleRule, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
 
 	var rules []*domain.LifecycleRule
 	for rows.Next() {
-		rule := &domain.LifecycleRule{}
-		var createdAt, updatedAt string
-
-		err := rows.Scan(
-			&rule.ID,
-			&rule.BucketID,
-			&rule.RuleID,
-			&rule.Prefix,
-			&rule.ExpirationDays,
-			&rule.Status,
-			&createdAt,
-			&updatedAt,
-		)
+		rule, err := scanLifecycleRule(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan lifecycle rule: %w", err)
 		}
-
-		rule.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
-		rule.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
-
 		rules = append(rules, rule)
 	}
 
@@ -337,5 +263,69 @@ func (r *lifecycleRepository) ListAllEnabled(ctx context.Context) ([]*domain.Lif
 	return rules, nil
 }
 
+func scanLifecycleRule(row interface{ Scan(dest ...any) error }) (*domain.LifecycleRule, error) {
+	rule := &domain.LifecycleRule{}
+	var tags, expirationDate sql.NullString
+	var expiredObjectDeleteMarker int
+	var createdAt, updatedAt string
+
+	err := row.Scan(
+		&rule.ID,
+		&rule.BucketID,
+		&rule.RuleID,
+		&rule.Prefix,
+		&tags,
+		&rule.ObjectSizeGreaterThan,
+		&rule.ObjectSizeLessThan,
+		&rule.ExpirationDays,
+		&expirationDate,
+		&expiredObjectDeleteMarker,
+		&rule.NoncurrentDays,
+		&rule.AbortIncompleteUploadDays,
+		&rule.Status,
+		&createdAt,
+		&updatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if tags.Valid {
+		if err := json.Unmarshal([]byte(tags.String), &rule.Tags); err != nil {
+			return nil, fmt.Errorf("failed to decode lifecycle rule tags: %w", err)
+		}
+	}
+	if expirationDate.Valid {
+		date, _ := time.Parse(time.RFC3339, expirationDate.String)
+		rule.ExpirationDate = &date
+	}
+	rule.ExpiredObjectDeleteMarker = expiredObjectDeleteMarker != 0
+	rule.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	rule.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
+
+	return rule, nil
+}
+
+// encodeLifecycleTags encodes the filter_tags column of a rule, NULL if it
+// filters on no tags.
+func encodeLifecycleTags(tags []domain.Tag) (interface{}, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(tags)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode lifecycle rule tags: %w", err)
+	}
+	return string(data), nil
+}
+
+// formatLifecycleDate formats the expiration_date column of a rule.
+func formatLifecycleDate(date *time.Time) interface{} {
+	if date == nil {
+		return nil
+	}
+	return date.UTC().Format(time.RFC3339)
+}
+
 // Ensure lifecycleRepository implements repository.LifecycleRepository.
 var _ repository.LifecycleRepository = (*lifecycleRepository)(nil)
@@ -0,0 +1,208 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	sqlitedriver "modernc.org/sqlite"
+)
+
+// Checkpoint copies the write-ahead log into the database file and
+// truncates it. Without periodic checkpoints a busy database's WAL grows
+// until the server restarts. A checkpoint blocked by active readers or
+// writers is reported and retried on the next call.
+func (db *DB) Checkpoint(ctx context.Context) error {
+	var busy, logFrames, checkpointed int
+	err := db.db.QueryRowContext(ctx, `PRAGMA wal_checkpoint(TRUNCATE)`).Scan(&busy, &logFrames, &checkpointed)
+	if err != nil {
+		return fmt.Errorf("failed to checkpoint WAL: %w", err)
+	}
+	if busy != 0 {
+		db.logger.Debug().
+			Int("log_frames", logFrames).
+			Int("checkpointed_frames", checkpointed).
+			Msg("WAL checkpoint blocked by active connections")
+	}
+	return nil
+}
+
+// Optimize lets SQLite refresh the query planner statistics of tables whose
+// contents changed enough to warrant it.
+func (db *DB) Optimize(ctx context.Context) error {
+	if _, err := db.db.ExecContext(ctx, `PRAGMA optimize`); err != nil {
+		return fmt.Errorf("failed to optimize database: %w", err)
+	}
+	return nil
+}
+
+// Backup copies the database into a new file at path with SQLite's online
+// backup API. The copy is read in a single transaction, so it is consistent
+// and, in WAL mode, does not block writers, including other processes such
+// as a running server.
+func (db *DB) Backup(ctx context.Context, path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("backup destination %s already exists", path)
+	}
+
+	conn, err := db.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	err = conn.Raw(func(driverConn any) error {
+		backuper, ok := driverConn.(interface {
+			NewBackup(dstURI string) (*sqlitedriver.Backup, error)
+		})
+		if !ok {
+			return errors.New("driver does not support online backup")
+		}
+		backup, err := backuper.NewBackup(path)
+		if err != nil {
+			return err
+		}
+		// Copying every page in one step keeps a single read transaction;
+		// smaller steps restart whenever another process writes
+		if _, err := backup.Step(-1); err != nil {
+			backup.Finish()
+			return err
+		}
+		return backup.Finish()
+	})
+	if err != nil {
+		os.Remove(path)
+		return fmt.Errorf("failed to back up database: %w", err)
+	}
+
+	db.logger.Info().Str("output", path).Msg("backed up database")
+	return nil
+}
+
+// MaintenanceConfig contains database maintenance settings.
+type MaintenanceConfig struct {
+	// CheckpointInterval is how often the WAL is checkpointed and
+	// truncated. Zero disables checkpoints.
+	CheckpointInterval time.Duration
+
+	// OptimizeInterval is how often PRAGMA optimize runs. Zero disables it.
+	OptimizeInterval time.Duration
+}
+
+// Maintenance runs periodic WAL checkpoints and PRAGMA optimize.
+type Maintenance struct {
+	db     *DB
+	config MaintenanceConfig
+	logger zerolog.Logger
+
+	mu       sync.Mutex
+	running  bool
+	stopChan chan struct{}
+	doneChan chan struct{}
+}
+
+// NewMaintenance creates the maintenance loop of db.
+func NewMaintenance(db *DB, config MaintenanceConfig, logger zerolog.Logger) *Maintenance {
+	return &Maintenance{
+		db:       db,
+		config:   config,
+		logger:   logger.With().Str("component", "sqlite-maintenance").Logger(),
+		stopChan: make(chan struct{}),
+		doneChan: make(chan struct{}),
+	}
+}
+
+// Start starts the maintenance loop. Each task first runs after one interval.
+func (m *Maintenance) Start() {
+	m.mu.Lock()
+	if m.running {
+		m.mu.Unlock()
+		return
+	}
+	m.running = true
+	m.mu.Unlock()
+
+	m.logger.Info().
+		Dur("checkpoint_interval", m.config.CheckpointInterval).
+		Dur("optimize_interval", m.config.OptimizeInterval).
+		Msg("Starting SQLite maintenance")
+
+	go m.runLoop()
+}
+
+// Stop stops the maintenance loop and runs a final PRAGMA optimize, as
+// SQLite recommends before closing a long-lived connection.
+func (m *Maintenance) Stop() {
+	m.mu.Lock()
+	if !m.running {
+		m.mu.Unlock()
+		return
+	}
+	m.running = false
+	m.mu.Unlock()
+
+	close(m.stopChan)
+	<-m.doneChan
+
+	if m.config.OptimizeInterval > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := m.db.Optimize(ctx); err != nil {
+			m.logger.Warn().Err(err).Msg("Final optimize failed")
+		}
+	}
+
+	m.logger.Info().Msg("SQLite maintenance stopped")
+}
+
+// runLoop runs the tasks until Stop is called.
+func (m *Maintenance) runLoop() {
+	defer close(m.doneChan)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-m.stopChan
+		cancel()
+	}()
+
+	checkpoints := newTicker(m.config.CheckpointInterval)
+	defer checkpoints.Stop()
+	optimizations := newTicker(m.config.OptimizeInterval)
+	defer optimizations.Stop()
+
+	for {
+		select {
+		case <-m.stopChan:
+			return
+		case <-checkpoints.C:
+			if err := m.db.Checkpoint(ctx); err != nil && ctx.Err() == nil {
+				m.logger.Error().Err(err).Msg("WAL checkpoint failed")
+			}
+		case <-optimizations.C:
+			start := time.Now()
+			if err := m.db.Optimize(ctx); err != nil {
+				if ctx.Err() == nil {
+					m.logger.Error().Err(err).Msg("Optimize failed")
+				}
+				continue
+			}
+			m.logger.Debug().Dur("duration", time.Since(start)).Msg("Optimized database")
+		}
+	}
+}
+
+// newTicker returns a ticker for interval, or one that never fires if the
+// interval is not positive.
+func newTicker(interval time.Duration) *time.Ticker {
+	if interval <= 0 {
+		t := time.NewTicker(time.Hour)
+		t.Stop()
+		return t
+	}
+	return time.NewTicker(interval)
+}
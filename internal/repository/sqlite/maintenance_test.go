@@ -0,0 +1,72 @@
+package sqlite
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+)
+
+var nopLogger = zerolog.Nop()
+
+func newTestDB(t *testing.T, path string) *DB {
+	t.Helper()
+	db, err := NewDB(context.Background(), DefaultConfig(path), nopLogger)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestDB_UsesConfiguredPragmas(t *testing.T) {
+	db := newTestDB(t, filepath.Join(t.TempDir(), "alexander.db"))
+
+	var journalMode string
+	var busyTimeout int
+	require.NoError(t, db.db.QueryRow(`PRAGMA journal_mode`).Scan(&journalMode))
+	require.NoError(t, db.db.QueryRow(`PRAGMA busy_timeout`).Scan(&busyTimeout))
+	require.Equal(t, "wal", journalMode)
+	require.Equal(t, 5000, busyTimeout)
+}
+
+func TestDB_CheckpointOptimizeAndBackup(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "alexander.db")
+	db := newTestDB(t, path)
+	require.NoError(t, db.Migrate(ctx))
+
+	users := NewUserRepository(db)
+	require.NoError(t, users.Create(ctx, domain.NewUser("owner", "owner@example.com", "hash")))
+
+	wal, err := os.Stat(path + "-wal")
+	require.NoError(t, err)
+	require.NotZero(t, wal.Size())
+
+	require.NoError(t, db.Checkpoint(ctx))
+	wal, err = os.Stat(path + "-wal")
+	require.NoError(t, err)
+	require.Zero(t, wal.Size())
+
+	require.NoError(t, db.Optimize(ctx))
+
+	backupPath := filepath.Join(dir, "snapshot.db")
+	require.NoError(t, db.Backup(ctx, backupPath))
+	require.Error(t, db.Backup(ctx, backupPath), "an existing file is not overwritten")
+
+	// The copy is a complete database at the same schema version
+	backup := newTestDB(t, backupPath)
+	version, err := backup.SchemaVersion(ctx)
+	require.NoError(t, err)
+	want, err := db.SchemaVersion(ctx)
+	require.NoError(t, err)
+	require.Equal(t, want, version)
+
+	user, err := NewUserRepository(backup).GetByUsername(ctx, "owner")
+	require.NoError(t, err)
+	require.Equal(t, "owner@example.com", user.Email)
+}
@@ -0,0 +1,85 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// mfaDeviceRepository implements repository.MFADeviceRepository for SQLite.
+type mfaDeviceRepository struct {
+	db *DB
+}
+
+// NewMFADeviceRepository creates a new SQLite MFA device repository.
+func NewMFADeviceRepository(db *DB) repository.MFADeviceRepository {
+	return &mfaDeviceRepository{db: db}
+}
+
+// Create enrolls a device.
+func (r *mfaDeviceRepository) Create(ctx context.Context, device *domain.MFADevice) error {
+	query := `
+		INSERT INTO mfa_devices (user_id, serial_number, encrypted_secret, created_at)
+		VALUES (?, ?, ?, ?)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		device.UserID,
+		device.SerialNumber,
+		device.EncryptedSecret,
+		device.CreatedAt.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return domain.ErrMFADeviceExists
+		}
+		return fmt.Errorf("failed to create MFA device: %w", err)
+	}
+
+	return nil
+}
+
+// GetByUserID returns the device of a user.
+func (r *mfaDeviceRepository) GetByUserID(ctx context.Context, userID int64) (*domain.MFADevice, error) {
+	query := `
+		SELECT user_id, serial_number, encrypted_secret, created_at
+		FROM mfa_devices
+		WHERE user_id = ?
+	`
+
+	device := &domain.MFADevice{}
+	var createdAt string
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(
+		&device.UserID,
+		&device.SerialNumber,
+		&device.EncryptedSecret,
+		&createdAt,
+	)
+	if err != nil {
+		if isNoRows(err) {
+			return nil, domain.ErrMFADeviceNotFound
+		}
+		return nil, fmt.Errorf("failed to get MFA device: %w", err)
+	}
+	device.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+
+	return device, nil
+}
+
+// Delete removes the device of a user.
+func (r *mfaDeviceRepository) Delete(ctx context.Context, userID int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM mfa_devices WHERE user_id = ?`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete MFA device: %w", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return domain.ErrMFADeviceNotFound
+	}
+	return nil
+}
+
+// Ensure mfaDeviceRepository implements repository.MFADeviceRepository
+var _ repository.MFADeviceRepository = (*mfaDeviceRepository)(nil)
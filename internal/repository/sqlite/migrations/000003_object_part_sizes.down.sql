@@ -0,0 +1,6 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000003_object_part_sizes
+-- Description: Rollback - Remove part sizes from objects
+
+-- Requires SQLite 3.35+ for DROP COLUMN
+ALTER TABLE objects DROP COLUMN part_sizes;
@@ -0,0 +1,6 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000003_object_part_sizes
+-- Description: Record original part sizes of multipart objects for partNumber GET/HEAD
+
+-- JSON array of part sizes in part order; NULL for single-part objects
+ALTER TABLE objects ADD COLUMN part_sizes TEXT;
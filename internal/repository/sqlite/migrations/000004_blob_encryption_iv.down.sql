@@ -0,0 +1,6 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000004_blob_encryption_iv
+-- Description: Rollback - Remove encryption IV from blobs
+
+-- Requires SQLite 3.35+ for DROP COLUMN
+ALTER TABLE blobs DROP COLUMN encryption_iv;
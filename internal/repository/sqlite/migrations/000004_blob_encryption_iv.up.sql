@@ -0,0 +1,6 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000004_blob_encryption_iv
+-- Description: Add the encryption IV column used by the blob repository
+
+-- Base64 IV/nonce for encrypted blobs; NULL for unencrypted blobs
+ALTER TABLE blobs ADD COLUMN encryption_iv TEXT;
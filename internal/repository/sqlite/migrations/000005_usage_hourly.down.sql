@@ -0,0 +1,5 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000005_usage_hourly
+-- Description: Rollback - Remove hourly usage accounting
+
+DROP TABLE IF EXISTS usage_hourly;
@@ -0,0 +1,18 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000005_usage_hourly
+-- Description: Hourly usage accounting per bucket and access key for billing
+
+CREATE TABLE IF NOT EXISTS usage_hourly (
+    hour            TEXT NOT NULL,                      -- RFC3339, start of hour (UTC)
+    bucket_name     TEXT NOT NULL,
+    access_key_id   TEXT NOT NULL DEFAULT '',           -- Empty for storage snapshot rows
+    bytes_in        INTEGER NOT NULL DEFAULT 0,
+    bytes_out       INTEGER NOT NULL DEFAULT 0,
+    requests        INTEGER NOT NULL DEFAULT 0,
+    bytes_stored    INTEGER NOT NULL DEFAULT 0,
+
+    PRIMARY KEY (hour, bucket_name, access_key_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_usage_hourly_bucket ON usage_hourly (bucket_name, hour);
+CREATE INDEX IF NOT EXISTS idx_usage_hourly_access_key ON usage_hourly (access_key_id, hour);
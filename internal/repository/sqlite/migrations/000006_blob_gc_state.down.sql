@@ -0,0 +1,9 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000006_blob_gc_state
+-- Description: Rollback - Remove blob garbage collection state
+
+-- Requires SQLite 3.35+ for DROP COLUMN
+DROP INDEX IF EXISTS idx_blobs_gc_state;
+ALTER TABLE blobs DROP COLUMN gc_last_error;
+ALTER TABLE blobs DROP COLUMN gc_attempts;
+ALTER TABLE blobs DROP COLUMN gc_state;
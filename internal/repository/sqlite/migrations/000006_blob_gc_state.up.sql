@@ -0,0 +1,10 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000006_blob_gc_state
+-- Description: Track per-blob garbage collection progress so interrupted deletions resume
+
+-- '' (live), 'pending' (claimed, deleting content), 'storage_deleted' (content gone, row remains)
+ALTER TABLE blobs ADD COLUMN gc_state TEXT NOT NULL DEFAULT '' CHECK (gc_state IN ('', 'pending', 'storage_deleted'));
+ALTER TABLE blobs ADD COLUMN gc_attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE blobs ADD COLUMN gc_last_error TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_blobs_gc_state ON blobs (gc_state) WHERE gc_state <> '';
@@ -0,0 +1,6 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000007_access_key_policy
+-- Description: Rollback - Remove access key policies
+
+-- Requires SQLite 3.35+ for DROP COLUMN
+ALTER TABLE access_keys DROP COLUMN policy;
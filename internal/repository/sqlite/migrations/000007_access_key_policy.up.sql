@@ -0,0 +1,6 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000007_access_key_policy
+-- Description: Store an optional scoping policy (JSON) with each access key
+
+-- NULL means the key has full access to its owner's buckets
+ALTER TABLE access_keys ADD COLUMN policy TEXT;
@@ -0,0 +1,6 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000008_bucket_residency
+-- Description: Rollback - Remove bucket residency tags
+
+-- Requires SQLite 3.35+ for DROP COLUMN
+ALTER TABLE buckets DROP COLUMN residency;
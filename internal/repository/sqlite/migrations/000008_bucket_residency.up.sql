@@ -0,0 +1,6 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000008_bucket_residency
+-- Description: Data residency tag per bucket, restricting which storage backend holds its blobs
+
+-- '' stores blobs on the default backend
+ALTER TABLE buckets ADD COLUMN residency TEXT NOT NULL DEFAULT '';
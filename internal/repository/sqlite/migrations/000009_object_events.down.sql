@@ -0,0 +1,5 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000009_object_events
+-- Description: Rollback - Remove the object event outbox
+
+DROP TABLE IF EXISTS object_events;
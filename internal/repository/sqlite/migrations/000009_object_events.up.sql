@@ -0,0 +1,17 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000009_object_events
+-- Description: Outbox of object change events for watch clients
+
+CREATE TABLE IF NOT EXISTS object_events (
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,  -- Watch cursor, never reused
+    event_type      TEXT NOT NULL,                      -- e.g. s3:ObjectCreated:Put
+    bucket_name     TEXT NOT NULL,
+    object_key      TEXT NOT NULL,
+    version_id      TEXT NOT NULL DEFAULT '',
+    size            INTEGER NOT NULL DEFAULT 0,
+    etag            TEXT NOT NULL DEFAULT '',
+    created_at      TEXT NOT NULL                       -- RFC3339
+);
+
+CREATE INDEX IF NOT EXISTS idx_object_events_bucket ON object_events (bucket_name, id);
+CREATE INDEX IF NOT EXISTS idx_object_events_created ON object_events (created_at);
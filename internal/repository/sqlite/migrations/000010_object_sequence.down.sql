@@ -0,0 +1,8 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000010_object_sequence
+-- Description: Rollback - Remove per-key write sequence numbers
+
+DROP TABLE IF EXISTS object_sequences;
+
+-- Requires SQLite 3.35+ for DROP COLUMN
+ALTER TABLE objects DROP COLUMN sequence;
@@ -0,0 +1,17 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000010_object_sequence
+-- Description: Per-key write sequence numbers (x-alexander-sequence)
+
+-- Sequence of the write that created this version (or delete marker).
+-- Existing versions keep 0 until the object_sequence backfill numbers them.
+ALTER TABLE objects ADD COLUMN sequence INTEGER NOT NULL DEFAULT 0;
+
+-- Last sequence issued per key; kept separately so it survives deletes
+CREATE TABLE IF NOT EXISTS object_sequences (
+    bucket_id       INTEGER NOT NULL,
+    key             TEXT NOT NULL,
+    sequence        INTEGER NOT NULL,
+
+    PRIMARY KEY (bucket_id, key),
+    FOREIGN KEY (bucket_id) REFERENCES buckets(id) ON DELETE CASCADE
+);
@@ -0,0 +1,6 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000011_user_roles
+-- Description: Rollback - Remove user roles
+
+-- Requires SQLite 3.35+ for DROP COLUMN
+ALTER TABLE users DROP COLUMN role;
@@ -0,0 +1,10 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000011_user_roles
+-- Description: Dashboard and admin API roles for users
+
+-- '' (S3 API only), 'read-only', 'operator' or 'admin'.
+-- is_admin is kept in sync with role = 'admin' so this migration can be rolled back.
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT ''
+    CHECK (role IN ('', 'read-only', 'operator', 'admin'));
+
+UPDATE users SET role = 'admin' WHERE is_admin = 1;
@@ -0,0 +1,7 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000012_audit_log
+-- Description: Rollback - Remove the audit log
+
+DROP TRIGGER IF EXISTS audit_log_no_delete;
+DROP TRIGGER IF EXISTS audit_log_no_update;
+DROP TABLE IF EXISTS audit_log;
@@ -0,0 +1,36 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000012_audit_log
+-- Description: Append-only audit log of writes and administrative actions
+
+CREATE TABLE IF NOT EXISTS audit_log (
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    time            TEXT NOT NULL,                      -- RFC3339
+    user_id         INTEGER NOT NULL DEFAULT 0,         -- No foreign key: events outlive users
+    actor           TEXT NOT NULL,
+    access_key_id   TEXT NOT NULL DEFAULT '',
+    operation       TEXT NOT NULL,
+    bucket_name     TEXT NOT NULL DEFAULT '',
+    object_key      TEXT NOT NULL DEFAULT '',
+    source_ip       TEXT NOT NULL DEFAULT '',
+    request_id      TEXT NOT NULL DEFAULT '',
+    result          TEXT NOT NULL CHECK (result IN ('success', 'denied', 'failure')),
+    status_code     INTEGER NOT NULL DEFAULT 0,
+    detail          TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_time ON audit_log (time);
+CREATE INDEX IF NOT EXISTS idx_audit_log_user ON audit_log (user_id, id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_bucket ON audit_log (bucket_name, id);
+
+-- The audit log is immutable
+CREATE TRIGGER IF NOT EXISTS audit_log_no_update
+BEFORE UPDATE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS audit_log_no_delete
+BEFORE DELETE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
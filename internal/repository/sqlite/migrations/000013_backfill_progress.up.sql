@@ -0,0 +1,12 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000013_backfill_progress
+-- Description: Progress of batched data migrations
+
+CREATE TABLE IF NOT EXISTS backfill_progress (
+    name            TEXT PRIMARY KEY,
+    cursor          INTEGER NOT NULL DEFAULT 0,
+    rows_done       INTEGER NOT NULL DEFAULT 0,
+    started_at      TEXT NOT NULL,
+    updated_at      TEXT NOT NULL,
+    completed_at    TEXT
+);
@@ -0,0 +1,9 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000014_bucket_replication
+-- Description: Rollback - Remove bucket replication
+
+DROP TABLE IF EXISTS replication_tasks;
+DROP TABLE IF EXISTS bucket_replication;
+
+-- Requires SQLite 3.35+ for DROP COLUMN
+ALTER TABLE objects DROP COLUMN replication_status;
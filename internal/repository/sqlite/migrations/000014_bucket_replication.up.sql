@@ -0,0 +1,39 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000014_bucket_replication
+-- Description: Bucket replication to remote S3-compatible endpoints
+
+-- Replication state of each version (x-amz-replication-status); empty if not replicated
+ALTER TABLE objects ADD COLUMN replication_status TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS bucket_replication (
+    bucket_id               INTEGER PRIMARY KEY,
+    endpoint                TEXT NOT NULL,
+    region                  TEXT NOT NULL DEFAULT '',
+    destination_bucket      TEXT NOT NULL,
+    access_key_id           TEXT NOT NULL,
+    encrypted_secret_key    TEXT NOT NULL,
+    rules                   TEXT NOT NULL,              -- JSON array of rules
+    created_at              TEXT NOT NULL,              -- RFC3339
+    updated_at              TEXT NOT NULL,
+
+    FOREIGN KEY (bucket_id) REFERENCES buckets(id) ON DELETE CASCADE
+);
+
+-- Queue of changes to push to replication destinations; tasks of one key
+-- are applied in ID order
+CREATE TABLE IF NOT EXISTS replication_tasks (
+    id                  INTEGER PRIMARY KEY AUTOINCREMENT,
+    bucket_id           INTEGER NOT NULL,
+    object_id           INTEGER NOT NULL DEFAULT 0,
+    key                 TEXT NOT NULL,
+    operation           TEXT NOT NULL,                  -- put, delete
+    attempts            INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at     TEXT NOT NULL,                  -- RFC3339
+    last_error          TEXT NOT NULL DEFAULT '',
+    created_at          TEXT NOT NULL,
+
+    FOREIGN KEY (bucket_id) REFERENCES buckets(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_replication_tasks_due ON replication_tasks (next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_replication_tasks_key ON replication_tasks (bucket_id, key, id);
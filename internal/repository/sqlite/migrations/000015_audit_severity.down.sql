@@ -0,0 +1,17 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000015_audit_severity
+-- Description: Rollback - Remove audit event categories, severities and pruning
+
+DROP TRIGGER IF EXISTS audit_log_no_delete;
+CREATE TRIGGER IF NOT EXISTS audit_log_no_delete
+BEFORE DELETE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
+
+DROP TABLE IF EXISTS audit_log_prune;
+DROP INDEX IF EXISTS idx_audit_log_category_time;
+
+-- Requires SQLite 3.35+ for DROP COLUMN
+ALTER TABLE audit_log DROP COLUMN severity;
+ALTER TABLE audit_log DROP COLUMN category;
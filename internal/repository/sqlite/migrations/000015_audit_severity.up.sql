@@ -0,0 +1,25 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000015_audit_severity
+-- Description: Audit event categories and severities, and retention pruning
+--
+-- Events recorded before this migration keep the defaults, since the audit
+-- log cannot be updated.
+
+ALTER TABLE audit_log ADD COLUMN category TEXT NOT NULL DEFAULT 'operational';
+ALTER TABLE audit_log ADD COLUMN severity TEXT NOT NULL DEFAULT 'info';
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_category_time ON audit_log (category, time);
+
+-- Retention pruning inserts a row here for the duration of its transaction;
+-- deletes are refused at any other time
+CREATE TABLE IF NOT EXISTS audit_log_prune (
+    active INTEGER NOT NULL
+);
+
+DROP TRIGGER IF EXISTS audit_log_no_delete;
+CREATE TRIGGER IF NOT EXISTS audit_log_no_delete
+BEFORE DELETE ON audit_log
+WHEN NOT EXISTS (SELECT 1 FROM audit_log_prune)
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
@@ -0,0 +1,9 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000016_blob_verification
+-- Description: Rollback - Remove blob verification state
+
+DROP INDEX IF EXISTS idx_blobs_last_verified;
+
+-- Requires SQLite 3.35+ for DROP COLUMN
+ALTER TABLE blobs DROP COLUMN corrupted_at;
+ALTER TABLE blobs DROP COLUMN last_verified_at;
@@ -0,0 +1,9 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000016_blob_verification
+-- Description: Bitrot scrubbing: when each blob was last re-hashed and
+-- whether its content was found corrupt
+
+ALTER TABLE blobs ADD COLUMN last_verified_at TEXT;         -- RFC3339, NULL until first verified
+ALTER TABLE blobs ADD COLUMN corrupted_at TEXT;             -- RFC3339, NULL unless the content does not match its hash
+
+CREATE INDEX IF NOT EXISTS idx_blobs_last_verified ON blobs (last_verified_at, content_hash) WHERE ref_count > 0;
@@ -0,0 +1,8 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000017_upload_quarantine
+-- Description: Rollback - Remove upload quarantine
+
+DROP TABLE IF EXISTS staged_objects;
+
+-- Requires SQLite 3.35+ for DROP COLUMN
+ALTER TABLE buckets DROP COLUMN quarantine;
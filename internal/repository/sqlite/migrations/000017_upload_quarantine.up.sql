@@ -0,0 +1,28 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000017_upload_quarantine
+-- Description: Quarantine buckets: uploads are staged until an approver
+-- promotes them to a visible object version
+
+ALTER TABLE buckets ADD COLUMN quarantine INTEGER NOT NULL DEFAULT 0;
+
+-- Uploads waiting for promotion; each row holds a reference on its blob
+CREATE TABLE IF NOT EXISTS staged_objects (
+    id                  TEXT PRIMARY KEY,               -- UUID as text
+    bucket_id           INTEGER NOT NULL,
+    key                 TEXT NOT NULL,
+    content_hash        TEXT NOT NULL,
+    size                INTEGER NOT NULL DEFAULT 0,
+    content_type        TEXT NOT NULL DEFAULT 'application/octet-stream',
+    etag                TEXT NOT NULL DEFAULT '',
+    storage_class       TEXT NOT NULL DEFAULT 'STANDARD',
+    metadata            TEXT DEFAULT '{}',              -- JSON for user metadata
+    part_sizes          TEXT,                           -- JSON array of part sizes
+    event_type          TEXT NOT NULL,                  -- Event recorded on promotion
+    created_at          TEXT NOT NULL,                  -- RFC3339
+
+    FOREIGN KEY (bucket_id) REFERENCES buckets(id) ON DELETE CASCADE,
+    FOREIGN KEY (content_hash) REFERENCES blobs(content_hash) ON DELETE RESTRICT
+);
+
+CREATE INDEX IF NOT EXISTS idx_staged_objects_created ON staged_objects (created_at);
+CREATE INDEX IF NOT EXISTS idx_staged_objects_bucket ON staged_objects (bucket_id, created_at);
@@ -0,0 +1,10 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000018_batch_commit
+-- Description: Rollback - Remove batch commit
+
+DROP INDEX IF EXISTS idx_staged_objects_batch;
+
+-- Requires SQLite 3.35+ for DROP COLUMN
+ALTER TABLE staged_objects DROP COLUMN batch_id;
+
+DROP TABLE IF EXISTS batches;
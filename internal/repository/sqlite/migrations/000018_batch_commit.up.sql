@@ -0,0 +1,22 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000018_batch_commit
+-- Description: Batches of staged writes that become visible together on commit
+
+CREATE TABLE IF NOT EXISTS batches (
+    id                  TEXT PRIMARY KEY,               -- UUID as text
+    owner_id            INTEGER NOT NULL,
+    created_at          TEXT NOT NULL,                  -- RFC3339
+    expires_at          TEXT NOT NULL,                  -- RFC3339
+
+    FOREIGN KEY (owner_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_batches_expires ON batches (expires_at);
+
+-- Writes of a batch are staged like quarantined uploads. Unlike PostgreSQL
+-- there is no foreign key: SQLite serializes transactions, and a write
+-- looks up its batch in the transaction that stages it.
+ALTER TABLE staged_objects ADD COLUMN batch_id TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_staged_objects_batch ON staged_objects (batch_id, created_at)
+    WHERE batch_id IS NOT NULL;
@@ -0,0 +1,43 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000019_bucket_snapshots
+-- Description: Read-only point-in-time snapshots of a bucket's latest versions
+
+CREATE TABLE IF NOT EXISTS bucket_snapshots (
+    id                  INTEGER PRIMARY KEY AUTOINCREMENT,
+    bucket_id           INTEGER NOT NULL,
+    name                TEXT NOT NULL,
+    object_count        INTEGER NOT NULL DEFAULT 0,
+    total_size          INTEGER NOT NULL DEFAULT 0,
+    created_at          TEXT NOT NULL,                  -- RFC3339
+    expires_at          TEXT,                           -- RFC3339, NULL keeps the snapshot
+
+    FOREIGN KEY (bucket_id) REFERENCES buckets(id) ON DELETE CASCADE,
+    UNIQUE (bucket_id, name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_bucket_snapshots_expires ON bucket_snapshots (expires_at)
+    WHERE expires_at IS NOT NULL;
+
+-- Metadata of each object version in a snapshot; each row holds a
+-- reference on its blob, released when the snapshot is deleted
+CREATE TABLE IF NOT EXISTS bucket_snapshot_objects (
+    snapshot_id         INTEGER NOT NULL,
+    key                 TEXT NOT NULL,
+    version_id          TEXT NOT NULL,                  -- UUID as text
+    content_hash        TEXT,
+    size                INTEGER NOT NULL DEFAULT 0,
+    content_type        TEXT NOT NULL DEFAULT 'application/octet-stream',
+    etag                TEXT,
+    storage_class       TEXT NOT NULL DEFAULT 'STANDARD',
+    metadata            TEXT DEFAULT '{}',              -- JSON for user metadata
+    part_sizes          TEXT,                           -- JSON array of part sizes
+    sequence            INTEGER NOT NULL DEFAULT 0,
+    created_at          TEXT NOT NULL,                  -- RFC3339, of the object version
+
+    PRIMARY KEY (snapshot_id, key),
+    FOREIGN KEY (snapshot_id) REFERENCES bucket_snapshots(id) ON DELETE CASCADE,
+    FOREIGN KEY (content_hash) REFERENCES blobs(content_hash) ON DELETE RESTRICT
+);
+
+CREATE INDEX IF NOT EXISTS idx_bucket_snapshot_objects_blob ON bucket_snapshot_objects (content_hash)
+    WHERE content_hash IS NOT NULL;
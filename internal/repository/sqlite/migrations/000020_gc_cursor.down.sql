@@ -0,0 +1,6 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000020_gc_cursor
+-- Description: Rollback - Remove the garbage collection cursor
+
+DROP INDEX IF EXISTS idx_blobs_orphan_hash;
+DROP TABLE IF EXISTS gc_cursor;
@@ -0,0 +1,11 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000020_gc_cursor
+-- Description: Position of garbage collection in its walk over orphan blobs, so interrupted runs resume
+
+CREATE TABLE IF NOT EXISTS gc_cursor (
+    id              INTEGER PRIMARY KEY CHECK (id = 1),     -- Single row
+    after_hash      TEXT NOT NULL DEFAULT '',               -- Content hash the next run starts after
+    updated_at      TEXT NOT NULL                           -- RFC3339
+);
+
+CREATE INDEX IF NOT EXISTS idx_blobs_orphan_hash ON blobs (content_hash) WHERE ref_count <= 0;
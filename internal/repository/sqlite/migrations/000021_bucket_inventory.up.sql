@@ -0,0 +1,22 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000021_bucket_inventory
+-- Description: Scheduled inventory report configurations per bucket
+
+CREATE TABLE IF NOT EXISTS bucket_inventory_configs (
+    bucket_id           INTEGER NOT NULL,
+    config_id           TEXT NOT NULL,
+    enabled             INTEGER NOT NULL DEFAULT 1,
+    destination_bucket  TEXT NOT NULL,
+    destination_prefix  TEXT NOT NULL DEFAULT '',
+    format              TEXT NOT NULL,                  -- CSV or Parquet
+    frequency           TEXT NOT NULL,                  -- Daily or Weekly
+    prefix              TEXT NOT NULL DEFAULT '',
+    all_versions        INTEGER NOT NULL DEFAULT 0,
+    fields              TEXT NOT NULL DEFAULT '[]',     -- JSON array of optional fields
+    last_run_at         TEXT,                           -- RFC3339, NULL if never run
+    created_at          TEXT NOT NULL,                  -- RFC3339
+    updated_at          TEXT NOT NULL,                  -- RFC3339
+
+    PRIMARY KEY (bucket_id, config_id),
+    FOREIGN KEY (bucket_id) REFERENCES buckets(id) ON DELETE CASCADE
+);
@@ -0,0 +1,5 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000022_bucket_website
+-- Description: Rollback - Remove bucket website configurations
+
+DROP TABLE IF EXISTS bucket_websites;
@@ -0,0 +1,12 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000022_bucket_website
+-- Description: Static website configurations per bucket
+
+CREATE TABLE IF NOT EXISTS bucket_websites (
+    bucket_id           INTEGER PRIMARY KEY,
+    config              TEXT NOT NULL,                  -- JSON index/error documents, redirects and routing rules
+    created_at          TEXT NOT NULL,                  -- RFC3339
+    updated_at          TEXT NOT NULL,                  -- RFC3339
+
+    FOREIGN KEY (bucket_id) REFERENCES buckets(id) ON DELETE CASCADE
+);
@@ -0,0 +1,6 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000023_blob_encryption_key_version
+-- Description: Rollback - Remove the encryption key version from blobs
+
+-- Requires SQLite 3.35+ for DROP COLUMN
+ALTER TABLE blobs DROP COLUMN encryption_key_version;
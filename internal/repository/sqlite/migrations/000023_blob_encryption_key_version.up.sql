@@ -0,0 +1,6 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000023_blob_encryption_key_version
+-- Description: Record which version of the SSE master key encrypted a blob
+
+-- Blobs encrypted before keys had versions used version 1
+ALTER TABLE blobs ADD COLUMN encryption_key_version INTEGER NOT NULL DEFAULT 1;
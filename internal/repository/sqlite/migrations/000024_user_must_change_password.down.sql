@@ -0,0 +1,6 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000024_user_must_change_password
+-- Description: Rollback - Remove the forced password change flag from users
+
+-- Requires SQLite 3.35+ for DROP COLUMN
+ALTER TABLE users DROP COLUMN must_change_password;
@@ -0,0 +1,10 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000025_session_hardening
+-- Description: Rollback - Remove CSRF, idle and remember-me columns from sessions
+
+-- Requires SQLite 3.35+ for DROP COLUMN
+DROP INDEX IF EXISTS idx_sessions_remember_token;
+ALTER TABLE sessions DROP COLUMN remember_expires_at;
+ALTER TABLE sessions DROP COLUMN remember_token;
+ALTER TABLE sessions DROP COLUMN last_seen_at;
+ALTER TABLE sessions DROP COLUMN csrf_token;
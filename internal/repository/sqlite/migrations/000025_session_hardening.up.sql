@@ -0,0 +1,10 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000025_session_hardening
+-- Description: Per-session CSRF tokens, idle expiration and remember-me tokens
+
+ALTER TABLE sessions ADD COLUMN csrf_token TEXT NOT NULL DEFAULT '';
+ALTER TABLE sessions ADD COLUMN last_seen_at TEXT;                    -- RFC3339; NULL for sessions created before this migration
+ALTER TABLE sessions ADD COLUMN remember_token TEXT;                  -- Long-lived token that resumes the session
+ALTER TABLE sessions ADD COLUMN remember_expires_at TEXT;             -- RFC3339
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sessions_remember_token ON sessions (remember_token) WHERE remember_token IS NOT NULL;
@@ -0,0 +1,6 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000026_blob_ref_indexes
+-- Description: Rollback - Remove the blob reference indexes
+
+DROP INDEX IF EXISTS idx_staged_objects_content_hash;
+DROP INDEX IF EXISTS idx_upload_parts_content_hash;
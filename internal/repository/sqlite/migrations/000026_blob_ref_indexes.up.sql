@@ -0,0 +1,7 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000026_blob_ref_indexes
+-- Description: Indexes for counting the blob references held by upload parts
+-- and staged objects
+
+CREATE INDEX IF NOT EXISTS idx_upload_parts_content_hash ON upload_parts (content_hash);
+CREATE INDEX IF NOT EXISTS idx_staged_objects_content_hash ON staged_objects (content_hash);
@@ -0,0 +1,5 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000027_objects_deleted_index
+-- Description: Rollback - Remove the soft-deleted objects index
+
+DROP INDEX IF EXISTS idx_objects_deleted;
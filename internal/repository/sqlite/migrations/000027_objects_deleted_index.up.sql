@@ -0,0 +1,5 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000027_objects_deleted_index
+-- Description: Index for purging soft-deleted object versions
+
+CREATE INDEX IF NOT EXISTS idx_objects_deleted ON objects (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,8 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000028_object_acl
+-- Description: Rollback - Remove object ACLs
+
+-- Requires SQLite 3.35+ for DROP COLUMN
+ALTER TABLE multipart_uploads DROP COLUMN acl;
+ALTER TABLE staged_objects DROP COLUMN acl;
+ALTER TABLE objects DROP COLUMN acl;
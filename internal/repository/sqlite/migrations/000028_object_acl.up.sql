@@ -0,0 +1,8 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000028_object_acl
+-- Description: Canned ACL of each object version, carried by staged and
+-- multipart uploads to the version they become
+
+ALTER TABLE objects ADD COLUMN acl TEXT NOT NULL DEFAULT 'private';
+ALTER TABLE staged_objects ADD COLUMN acl TEXT NOT NULL DEFAULT 'private';
+ALTER TABLE multipart_uploads ADD COLUMN acl TEXT NOT NULL DEFAULT 'private';
@@ -0,0 +1,6 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000029_bucket_tags
+-- Description: Rollback - Remove bucket tags
+
+-- Requires SQLite 3.35+ for DROP COLUMN
+ALTER TABLE buckets DROP COLUMN tags;
@@ -0,0 +1,5 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000029_bucket_tags
+-- Description: Tag set of each bucket
+
+ALTER TABLE buckets ADD COLUMN tags TEXT;                -- JSON array of {key, value}; NULL if untagged
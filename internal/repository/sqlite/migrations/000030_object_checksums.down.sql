@@ -0,0 +1,11 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000030_object_checksums
+-- Description: Rollback - Remove object checksums
+
+-- Requires SQLite 3.35+ for DROP COLUMN
+ALTER TABLE upload_parts DROP COLUMN checksum;
+ALTER TABLE multipart_uploads DROP COLUMN checksum_algorithm;
+ALTER TABLE staged_objects DROP COLUMN checksum;
+ALTER TABLE staged_objects DROP COLUMN checksum_algorithm;
+ALTER TABLE objects DROP COLUMN checksum;
+ALTER TABLE objects DROP COLUMN checksum_algorithm;
@@ -0,0 +1,11 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000030_object_checksums
+-- Description: Additional checksums (x-amz-checksum-*) of object versions,
+-- carried by staged uploads, and of multipart uploads and their parts
+
+ALTER TABLE objects ADD COLUMN checksum_algorithm TEXT NOT NULL DEFAULT '';
+ALTER TABLE objects ADD COLUMN checksum TEXT NOT NULL DEFAULT '';
+ALTER TABLE staged_objects ADD COLUMN checksum_algorithm TEXT NOT NULL DEFAULT '';
+ALTER TABLE staged_objects ADD COLUMN checksum TEXT NOT NULL DEFAULT '';
+ALTER TABLE multipart_uploads ADD COLUMN checksum_algorithm TEXT NOT NULL DEFAULT '';
+ALTER TABLE upload_parts ADD COLUMN checksum TEXT NOT NULL DEFAULT '';
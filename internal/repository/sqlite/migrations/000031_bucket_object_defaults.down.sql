@@ -0,0 +1,6 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000031_bucket_object_defaults
+-- Description: Rollback - Remove bucket object defaults
+
+-- Requires SQLite 3.35+ for DROP COLUMN
+ALTER TABLE buckets DROP COLUMN object_defaults;
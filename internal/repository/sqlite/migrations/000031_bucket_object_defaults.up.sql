@@ -0,0 +1,5 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000031_bucket_object_defaults
+-- Description: Settings given to uploads that do not specify them
+
+ALTER TABLE buckets ADD COLUMN object_defaults TEXT;     -- JSON object; NULL if the bucket has none
@@ -0,0 +1,12 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000032_lifecycle_rule_filters
+-- Description: Rollback - Remove lifecycle rule filters and actions
+
+-- Requires SQLite 3.35+ for DROP COLUMN
+ALTER TABLE lifecycle_rules DROP COLUMN abort_incomplete_upload_days;
+ALTER TABLE lifecycle_rules DROP COLUMN noncurrent_days;
+ALTER TABLE lifecycle_rules DROP COLUMN expired_object_delete_marker;
+ALTER TABLE lifecycle_rules DROP COLUMN expiration_date;
+ALTER TABLE lifecycle_rules DROP COLUMN object_size_less_than;
+ALTER TABLE lifecycle_rules DROP COLUMN object_size_greater_than;
+ALTER TABLE lifecycle_rules DROP COLUMN filter_tags;
@@ -0,0 +1,11 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000032_lifecycle_rule_filters
+-- Description: Tag and object size filters and further actions for lifecycle rules
+
+ALTER TABLE lifecycle_rules ADD COLUMN filter_tags TEXT;                    -- JSON array of tags; NULL if none
+ALTER TABLE lifecycle_rules ADD COLUMN object_size_greater_than INTEGER;
+ALTER TABLE lifecycle_rules ADD COLUMN object_size_less_than INTEGER;
+ALTER TABLE lifecycle_rules ADD COLUMN expiration_date TEXT;                -- Midnight UTC, RFC 3339
+ALTER TABLE lifecycle_rules ADD COLUMN expired_object_delete_marker INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE lifecycle_rules ADD COLUMN noncurrent_days INTEGER;
+ALTER TABLE lifecycle_rules ADD COLUMN abort_incomplete_upload_days INTEGER;
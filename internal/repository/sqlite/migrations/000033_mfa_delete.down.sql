@@ -0,0 +1,8 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000033_mfa_delete
+-- Description: Rollback - Remove MFA devices and MFA delete protection
+
+-- Requires SQLite 3.35+ for DROP COLUMN
+ALTER TABLE buckets DROP COLUMN mfa_delete;
+
+DROP TABLE IF EXISTS mfa_devices;
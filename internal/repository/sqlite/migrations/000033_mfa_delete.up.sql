@@ -0,0 +1,14 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000033_mfa_delete
+-- Description: MFA devices of users and MFA delete protection of buckets
+
+CREATE TABLE IF NOT EXISTS mfa_devices (
+    user_id             INTEGER PRIMARY KEY,
+    serial_number       TEXT NOT NULL UNIQUE,
+    encrypted_secret    TEXT NOT NULL,                  -- AES-256-GCM encrypted TOTP secret
+    created_at          TEXT NOT NULL,                  -- RFC3339
+
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+ALTER TABLE buckets ADD COLUMN mfa_delete INTEGER NOT NULL DEFAULT 0;
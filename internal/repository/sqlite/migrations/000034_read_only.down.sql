@@ -0,0 +1,8 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000034_read_only
+-- Description: Rollback - Remove read-only mode
+
+-- Requires SQLite 3.35+ for DROP COLUMN
+ALTER TABLE buckets DROP COLUMN read_only;
+
+DROP TABLE IF EXISTS read_only_mode;
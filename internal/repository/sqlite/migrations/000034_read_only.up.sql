@@ -0,0 +1,11 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000034_read_only
+-- Description: Read-only mode of buckets and of the whole service, for freezing writes during migrations
+
+CREATE TABLE IF NOT EXISTS read_only_mode (
+    id              INTEGER PRIMARY KEY CHECK (id = 1),     -- Single row
+    enabled         INTEGER NOT NULL DEFAULT 0,             -- Rejects writes to every bucket
+    updated_at      TEXT NOT NULL                           -- RFC3339
+);
+
+ALTER TABLE buckets ADD COLUMN read_only INTEGER NOT NULL DEFAULT 0;
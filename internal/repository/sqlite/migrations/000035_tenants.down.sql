@@ -0,0 +1,12 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000035_tenants
+-- Description: Rollback - Remove tenants
+
+DROP INDEX IF EXISTS idx_buckets_tenant_id;
+DROP INDEX IF EXISTS idx_users_tenant_id;
+
+-- Requires SQLite 3.35+ for DROP COLUMN
+ALTER TABLE buckets DROP COLUMN tenant_id;
+ALTER TABLE users DROP COLUMN tenant_id;
+
+DROP TABLE IF EXISTS tenants;
@@ -0,0 +1,25 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000035_tenants
+-- Description: Tenants with quotas and rate limits; existing users and buckets join the default tenant
+
+CREATE TABLE IF NOT EXISTS tenants (
+    id                  INTEGER PRIMARY KEY AUTOINCREMENT,
+    name                TEXT NOT NULL,
+    max_buckets         INTEGER NOT NULL DEFAULT 0,      -- 0 = unlimited
+    max_storage_bytes   INTEGER NOT NULL DEFAULT 0,      -- 0 = unlimited
+    requests_per_second REAL NOT NULL DEFAULT 0,         -- 0 = unlimited
+    burst_size          INTEGER NOT NULL DEFAULT 0,
+    created_at          TEXT NOT NULL DEFAULT (datetime('now')),
+    updated_at          TEXT NOT NULL DEFAULT (datetime('now')),
+
+    CONSTRAINT tenants_name_unique UNIQUE (name)
+);
+
+INSERT OR IGNORE INTO tenants (id, name, created_at, updated_at)
+VALUES (1, 'default', strftime('%Y-%m-%dT%H:%M:%SZ', 'now'), strftime('%Y-%m-%dT%H:%M:%SZ', 'now'));
+
+ALTER TABLE users ADD COLUMN tenant_id INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE buckets ADD COLUMN tenant_id INTEGER NOT NULL DEFAULT 1;
+
+CREATE INDEX IF NOT EXISTS idx_users_tenant_id ON users (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_buckets_tenant_id ON buckets (tenant_id);
@@ -0,0 +1,5 @@
+-- Alexander Storage Database Schema for SQLite
+-- Migration: 000036_bucket_quotas
+-- Description: Storage quotas of buckets
+
+ALTER TABLE buckets ADD COLUMN quota_bytes INTEGER NOT NULL DEFAULT 0;    -- 0 = unlimited
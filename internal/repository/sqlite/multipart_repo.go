@@ -27,8 +27,8 @@ func NewMultipartRepository(db *DB) repository.MultipartUploadRepository {
 // Create creates a new multipart upload.
 func (r *multipartRepository) Create(ctx context.Context, upload *domain.MultipartUpload) error {
 	query := `
-		INSERT INTO multipart_uploads (id, bucket_id, key, initiator_id, status, storage_class, metadata, initiated_at, expires_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO multipart_uploads (id, bucket_id, key, initiator_id, status, storage_class, acl, checksum_algorithm, metadata, initiated_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	var metadataJSON string
@@ -46,6 +46,8 @@ func (r *multipartRepository) Create(ctx context.Context, upload *domain.Multipa
 		upload.InitiatorID,
 		upload.Status,
 		upload.StorageClass,
+		upload.ACL,
+		upload.ChecksumAlgorithm,
 		metadataJSON,
 		upload.InitiatedAt.Format(time.RFC3339),
 		upload.ExpiresAt.Format(time.RFC3339),
@@ -61,7 +63,7 @@ func (r *multipartRepository) Create(ctx context.Context, upload *domain.Multipa
 // GetByID retrieves a multipart upload by ID.
 func (r *multipartRepository) GetByID(ctx context.Context, uploadID uuid.UUID) (*domain.MultipartUpload, error) {
 	query := `
-		SELECT id, bucket_id, key, initiator_id, status, storage_class, metadata, initiated_at, expires_at, completed_at
+		SELECT id, bucket_id, key, initiator_id, status, storage_class, acl, checksum_algorithm, metadata, initiated_at, expires_at, completed_at
 		FROM multipart_uploads
 		WHERE id = ?
 	`
@@ -79,6 +81,8 @@ func (r *multipartRepository) GetByID(ctx context.Context, uploadID uuid.UUID) (
 		&upload.InitiatorID,
 		&upload.Status,
 		&upload.StorageClass,
+		&upload.ACL,
+		&upload.ChecksumAlgorithm,
 		&metadataJSON,
 		&initiatedAt,
 		&expiresAt,
@@ -108,27 +112,35 @@ func (r *multipartRepository) GetByID(ctx context.Context, uploadID uuid.UUID) (
 
 // List returns multipart uploads for a bucket.
 func (r *multipartRepository) List(ctx context.Context, bucketID int64, opts repository.MultipartListOptions) (*repository.MultipartListResult, error) {
-	maxUploads := opts.MaxUploads
-	if maxUploads <= 0 {
-		maxUploads = 1000
-	}
+	return repository.ListMultipartUploads(opts, func(prefix string, cursor repository.MultipartListCursor, limit int) ([]*domain.MultipartUploadInfo, error) {
+		return r.fetchUploads(ctx, bucketID, prefix, cursor, limit)
+	})
+}
 
+// fetchUploads returns the in-progress uploads of a page of List. Prefixes
+// are compared with substr rather than LIKE, which ignores case in SQLite
+// and treats % and _ in keys as wildcards.
+func (r *multipartRepository) fetchUploads(ctx context.Context, bucketID int64, prefix string, cursor repository.MultipartListCursor, limit int) ([]*domain.MultipartUploadInfo, error) {
 	query := `
 		SELECT id, key, initiated_at, storage_class
 		FROM multipart_uploads
 		WHERE bucket_id = ? AND status = ?
-			AND (? = '' OR key LIKE ? || '%')
-			AND (? = '' OR key > ?)
-		ORDER BY key ASC, initiated_at ASC
+			AND substr(key, 1, length(?)) = ?
+			AND (? = '' OR substr(key, 1, length(?)) <> ?)
+			AND (key > ? OR (key = ? AND (initiated_at, id) > (
+				SELECT initiated_at, id FROM multipart_uploads WHERE id = ?
+			)))
+		ORDER BY key ASC, initiated_at ASC, id ASC
 		LIMIT ?
 	`
 
 	rows, err := r.db.QueryContext(ctx, query,
 		bucketID,
 		domain.MultipartStatusInProgress,
-		opts.Prefix, opts.Prefix,
-		opts.KeyMarker, opts.KeyMarker,
-		maxUploads+1,
+		prefix, prefix,
+		cursor.SkipPrefix, cursor.SkipPrefix, cursor.SkipPrefix,
+		cursor.KeyMarker, cursor.KeyMarker, cursor.UploadIDMarker,
+		limit,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list uploads: %w", err)
@@ -159,18 +171,7 @@ func (r *multipartRepository) List(ctx context.Context, bucketID int64, opts rep
 		return nil, fmt.Errorf("error iterating uploads: %w", err)
 	}
 
-	result := &repository.MultipartListResult{}
-
-	if len(uploads) > maxUploads {
-		result.IsTruncated = true
-		result.NextKeyMarker = uploads[maxUploads-1].Key
-		result.NextUploadIDMarker = uploads[maxUploads-1].UploadID
-		result.Uploads = uploads[:maxUploads]
-	} else {
-		result.Uploads = uploads
-	}
-
-	return result, nil
+	return uploads, nil
 }
 
 // UpdateStatus updates the status of a multipart upload.
@@ -217,44 +218,87 @@ func (r *multipartRepository) Delete(ctx context.Context, uploadID uuid.UUID) er
 	})
 }
 
-// DeleteExpired deletes expired multipart uploads.
-func (r *multipartRepository) DeleteExpired(ctx context.Context) (int64, error) {
-	now := time.Now().UTC().Format(time.RFC3339)
+// ListExpired returns in-progress uploads that expired before the given
+// time, earliest expiry first.
+func (r *multipartRepository) ListExpired(ctx context.Context, before time.Time, limit int) ([]*domain.MultipartUpload, error) {
+	query := `
+		SELECT id, bucket_id, key, initiator_id, status, initiated_at, expires_at
+		FROM multipart_uploads
+		WHERE status = ? AND expires_at < ?
+		ORDER BY expires_at ASC, id ASC
+		LIMIT ?
+	`
 
-	// First delete parts for expired uploads
-	_, err := r.db.ExecContext(ctx, `
-		DELETE FROM upload_parts 
-		WHERE upload_id IN (
-			SELECT id FROM multipart_uploads 
-			WHERE status = ? AND expires_at < ?
-		)
-	`, domain.MultipartStatusInProgress, now)
+	rows, err := r.db.QueryContext(ctx, query, domain.MultipartStatusInProgress, before.UTC().Format(time.RFC3339), limit)
 	if err != nil {
-		return 0, fmt.Errorf("failed to delete expired parts: %w", err)
+		return nil, fmt.Errorf("failed to list expired uploads: %w", err)
 	}
+	defer rows.Close()
 
-	// Then delete expired uploads
-	result, err := r.db.ExecContext(ctx, `
-		DELETE FROM multipart_uploads 
-		WHERE status = ? AND expires_at < ?
-	`, domain.MultipartStatusInProgress, now)
+	var uploads []*domain.MultipartUpload
+	for rows.Next() {
+		upload := &domain.MultipartUpload{}
+		var idStr, initiatedAt, expiresAt string
+		if err := rows.Scan(&idStr, &upload.BucketID, &upload.Key, &upload.InitiatorID, &upload.Status, &initiatedAt, &expiresAt); err != nil {
+			return nil, fmt.Errorf("failed to scan upload: %w", err)
+		}
+		upload.ID, err = uuid.Parse(idStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse upload ID: %w", err)
+		}
+		upload.InitiatedAt, _ = time.Parse(time.RFC3339, initiatedAt)
+		upload.ExpiresAt, _ = time.Parse(time.RFC3339, expiresAt)
+		uploads = append(uploads, upload)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating uploads: %w", err)
+	}
+
+	return uploads, nil
+}
+
+// CountInProgress returns the number of multipart uploads in progress in a bucket.
+func (r *multipartRepository) CountInProgress(ctx context.Context, bucketID int64) (int64, error) {
+	var count int64
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM multipart_uploads WHERE bucket_id = ? AND status = ?`,
+		bucketID, domain.MultipartStatusInProgress,
+	).Scan(&count)
 	if err != nil {
-		return 0, fmt.Errorf("failed to delete expired uploads: %w", err)
+		return 0, fmt.Errorf("failed to count uploads: %w", err)
 	}
+	return count, nil
+}
 
-	return result.RowsAffected()
+// GetStats returns the number and size of the uploads in progress.
+func (r *multipartRepository) GetStats(ctx context.Context) (*domain.MultipartStats, error) {
+	stats := &domain.MultipartStats{}
+	err := r.db.QueryRowContext(ctx, `
+		SELECT (SELECT COUNT(*) FROM multipart_uploads WHERE status = ?),
+		       COUNT(p.id), COALESCE(SUM(p.size), 0)
+		FROM upload_parts p
+		JOIN multipart_uploads u ON u.id = p.upload_id
+		WHERE u.status = ?
+	`, domain.MultipartStatusInProgress, domain.MultipartStatusInProgress,
+	).Scan(&stats.Uploads, &stats.Parts, &stats.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get multipart stats: %w", err)
+	}
+	return stats, nil
 }
 
 // CreatePart creates a new upload part.
 func (r *multipartRepository) CreatePart(ctx context.Context, part *domain.UploadPart) error {
 	// SQLite uses INSERT OR REPLACE for upsert
 	query := `
-		INSERT INTO upload_parts (upload_id, part_number, content_hash, size, etag, created_at)
-		VALUES (?, ?, ?, ?, ?, ?)
+		INSERT INTO upload_parts (upload_id, part_number, content_hash, size, etag, checksum, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(upload_id, part_number) DO UPDATE SET
 			content_hash = excluded.content_hash,
 			size = excluded.size,
 			etag = excluded.etag,
+			checksum = excluded.checksum,
 			created_at = excluded.created_at
 	`
 
@@ -264,6 +308,7 @@ func (r *multipartRepository) CreatePart(ctx context.Context, part *domain.Uploa
 		part.ContentHash,
 		part.Size,
 		part.ETag,
+		part.Checksum,
 		part.CreatedAt.Format(time.RFC3339),
 	)
 
@@ -280,7 +325,7 @@ func (r *multipartRepository) CreatePart(ctx context.Context, part *domain.Uploa
 // GetPart retrieves a specific part.
 func (r *multipartRepository) GetPart(ctx context.Context, uploadID uuid.UUID, partNumber int) (*domain.UploadPart, error) {
 	query := `
-		SELECT id, upload_id, part_number, content_hash, size, etag, created_at
+		SELECT id, upload_id, part_number, content_hash, size, etag, checksum, created_at
 		FROM upload_parts
 		WHERE upload_id = ? AND part_number = ?
 	`
@@ -296,6 +341,7 @@ func (r *multipartRepository) GetPart(ctx context.Context, uploadID uuid.UUID, p
 		&part.ContentHash,
 		&part.Size,
 		&part.ETag,
+		&part.Checksum,
 		&createdAt,
 	)
 
@@ -320,7 +366,7 @@ func (r *multipartRepository) ListParts(ctx context.Context, uploadID uuid.UUID,
 	}
 
 	query := `
-		SELECT part_number, size, etag, created_at
+		SELECT part_number, size, etag, checksum, created_at
 		FROM upload_parts
 		WHERE upload_id = ? AND part_number > ?
 		ORDER BY part_number ASC
@@ -342,6 +388,7 @@ func (r *multipartRepository) ListParts(ctx context.Context, uploadID uuid.UUID,
 			&part.PartNumber,
 			&part.Size,
 			&part.ETag,
+			&part.Checksum,
 			&createdAt,
 		)
 		if err != nil {
@@ -393,16 +440,66 @@ func (r *multipartRepository) GetPartsForCompletion(ctx context.Context, uploadI
 	}
 
 	query := fmt.Sprintf(`
-		SELECT id, upload_id, part_number, content_hash, size, etag, created_at
+		SELECT id, upload_id, part_number, content_hash, size, etag, checksum, created_at
 		FROM upload_parts
 		WHERE upload_id = ? AND part_number IN (%s)
 		ORDER BY part_number ASC
 	`, strings.Join(placeholders, ","))
 
-	rows, err := r.db.QueryContext(ctx, query, args...)
+	parts, err := r.queryParts(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get parts for completion: %w", err)
 	}
+	return parts, nil
+}
+
+// GetPartsByContentHash returns the parts of an upload with any of the
+// given content hashes, in part number order.
+func (r *multipartRepository) GetPartsByContentHash(ctx context.Context, uploadID uuid.UUID, contentHashes []string) ([]*domain.UploadPart, error) {
+	if len(contentHashes) == 0 {
+		return []*domain.UploadPart{}, nil
+	}
+
+	placeholders := make([]string, len(contentHashes))
+	args := make([]interface{}, len(contentHashes)+1)
+	args[0] = uploadID.String()
+	for i, hash := range contentHashes {
+		placeholders[i] = "?"
+		args[i+1] = hash
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, upload_id, part_number, content_hash, size, etag, checksum, created_at
+		FROM upload_parts
+		WHERE upload_id = ? AND content_hash IN (%s)
+		ORDER BY part_number ASC
+	`, strings.Join(placeholders, ","))
+
+	parts, err := r.queryParts(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get parts by content hash: %w", err)
+	}
+	return parts, nil
+}
+
+// LastPartNumber returns the highest part number of an upload, or 0 if it
+// has no parts.
+func (r *multipartRepository) LastPartNumber(ctx context.Context, uploadID uuid.UUID) (int, error) {
+	query := `SELECT COALESCE(MAX(part_number), 0) FROM upload_parts WHERE upload_id = ?`
+
+	var partNumber int
+	if err := r.db.QueryRowContext(ctx, query, uploadID.String()).Scan(&partNumber); err != nil {
+		return 0, fmt.Errorf("failed to get last part number: %w", err)
+	}
+	return partNumber, nil
+}
+
+// queryParts runs a query selecting upload part rows.
+func (r *multipartRepository) queryParts(ctx context.Context, query string, args ...interface{}) ([]*domain.UploadPart, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
 
 	var parts []*domain.UploadPart
@@ -418,6 +515,7 @@ func (r *multipartRepository) GetPartsForCompletion(ctx context.Context, uploadI
 			&part.ContentHash,
 			&part.Size,
 			&part.ETag,
+			&part.Checksum,
 			&createdAt,
 		)
 		if err != nil {
@@ -0,0 +1,90 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// listAllUploads pages through a listing with opts and returns the keys
+// and upload IDs of the uploads, and the common prefixes.
+func listAllUploads(t *testing.T, repo repository.MultipartUploadRepository, bucketID int64, opts repository.MultipartListOptions) (keys, uploadIDs, prefixes []string) {
+	t.Helper()
+	for page := 0; ; page++ {
+		require.Less(t, page, 100, "listing does not terminate")
+		result, err := repo.List(context.Background(), bucketID, opts)
+		require.NoError(t, err)
+		for _, upload := range result.Uploads {
+			keys = append(keys, upload.Key)
+			uploadIDs = append(uploadIDs, upload.UploadID)
+		}
+		prefixes = append(prefixes, result.CommonPrefixes...)
+		if !result.IsTruncated {
+			return keys, uploadIDs, prefixes
+		}
+		opts.KeyMarker, opts.UploadIDMarker = result.NextKeyMarker, result.NextUploadIDMarker
+	}
+}
+
+func TestMultipartRepository_ListDelimiterAndMarkers(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t, filepath.Join(t.TempDir(), "alexander.db"))
+	require.NoError(t, db.Migrate(ctx))
+
+	user := domain.NewUser("owner", "owner@example.com", "hash")
+	require.NoError(t, NewUserRepository(db).Create(ctx, user))
+	bucket := domain.NewBucket(user.ID, "photos")
+	require.NoError(t, NewBucketRepository(db).Create(ctx, bucket))
+
+	repo := NewMultipartRepository(db)
+	initiated := time.Now().UTC()
+	var sameKey []string
+	for _, key := range []string{"a.txt", "a.txt", "logs/1", "logs/2", "photos/2024/cat.jpg", "photos/dog.jpg", "100%_done", "z.txt"} {
+		upload := domain.NewMultipartUpload(bucket.ID, key, user.ID)
+		upload.InitiatedAt = initiated
+		require.NoError(t, repo.Create(ctx, upload))
+		if key == "a.txt" {
+			sameKey = append(sameKey, upload.ID.String())
+		}
+	}
+	// Uploads of a key initiated at the same time are ordered by ID
+	sort.Strings(sameKey)
+
+	for _, maxUploads := range []int{1, 2, 3, 1000} {
+		keys, uploadIDs, prefixes := listAllUploads(t, repo, bucket.ID, repository.MultipartListOptions{Delimiter: "/", MaxUploads: maxUploads})
+		require.Equal(t, []string{"100%_done", "a.txt", "a.txt", "z.txt"}, keys, "max uploads %d", maxUploads)
+		require.Equal(t, sameKey, uploadIDs[1:3], "max uploads %d", maxUploads)
+		require.Equal(t, []string{"logs/", "photos/"}, prefixes, "max uploads %d", maxUploads)
+	}
+
+	// Below a prefix
+	keys, _, prefixes := listAllUploads(t, repo, bucket.ID, repository.MultipartListOptions{Prefix: "photos/", Delimiter: "/", MaxUploads: 1})
+	require.Equal(t, []string{"photos/dog.jpg"}, keys)
+	require.Equal(t, []string{"photos/2024/"}, prefixes)
+
+	// Without a delimiter every upload is listed
+	keys, _, prefixes = listAllUploads(t, repo, bucket.ID, repository.MultipartListOptions{Prefix: "photos/", MaxUploads: 1})
+	require.Equal(t, []string{"photos/2024/cat.jpg", "photos/dog.jpg"}, keys)
+	require.Empty(t, prefixes)
+
+	// A key marker that is a common prefix continues after its keys
+	result, err := repo.List(ctx, bucket.ID, repository.MultipartListOptions{Delimiter: "/", KeyMarker: "logs/"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"photos/"}, result.CommonPrefixes)
+	require.Len(t, result.Uploads, 1)
+	require.Equal(t, "z.txt", result.Uploads[0].Key)
+
+	// Prefixes match case and wildcard characters exactly
+	for _, prefix := range []string{"Logs/", "100_", "a%"} {
+		result, err := repo.List(ctx, bucket.ID, repository.MultipartListOptions{Prefix: prefix})
+		require.NoError(t, err)
+		require.Empty(t, result.Uploads, prefix)
+	}
+}
@@ -27,8 +27,8 @@ func NewObjectRepository(db *DB) repository.ObjectRepository {
 func (r *objectRepository) Create(ctx context.Context, obj *domain.Object) error {
 	query := `
 		INSERT INTO objects (bucket_id, key, version_id, is_latest, is_delete_marker, 
-			content_hash, size, content_type, etag, storage_class, metadata, created_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			content_hash, size, content_type, etag, storage_class, metadata, part_sizes, sequence, replication_status, acl, checksum_algorithm, checksum, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	var metadataJSON string
@@ -39,6 +39,12 @@ func (r *objectRepository) Create(ctx context.Context, obj *domain.Object) error
 		metadataJSON = "{}"
 	}
 
+	var partSizesJSON sql.NullString
+	if len(obj.PartSizes) > 0 {
+		data, _ := json.Marshal(obj.PartSizes)
+		partSizesJSON = sql.NullString{String: string(data), Valid: true}
+	}
+
 	result, err := r.db.ExecContext(ctx, query,
 		obj.BucketID,
 		obj.Key,
@@ -51,6 +57,12 @@ func (r *objectRepository) Create(ctx context.Context, obj *domain.Object) error
 		obj.ETag,
 		obj.StorageClass,
 		metadataJSON,
+		partSizesJSON,
+		obj.Sequence,
+		obj.ReplicationStatus,
+		obj.ACL,
+		obj.ChecksumAlgorithm,
+		obj.Checksum,
 		obj.CreatedAt.Format(time.RFC3339),
 	)
 
@@ -71,7 +83,7 @@ func (r *objectRepository) Create(ctx context.Context, obj *domain.Object) error
 func (r *objectRepository) GetByID(ctx context.Context, id int64) (*domain.Object, error) {
 	query := `
 		SELECT id, bucket_id, key, version_id, is_latest, is_delete_marker, 
-			content_hash, size, content_type, etag, storage_class, metadata, created_at, deleted_at
+			content_hash, size, content_type, etag, storage_class, metadata, part_sizes, sequence, replication_status, acl, checksum_algorithm, checksum, created_at, deleted_at
 		FROM objects
 		WHERE id = ?
 	`
@@ -82,7 +94,7 @@ func (r *objectRepository) GetByID(ctx context.Context, id int64) (*domain.Objec
 func (r *objectRepository) GetByKey(ctx context.Context, bucketID int64, key string) (*domain.Object, error) {
 	query := `
 		SELECT id, bucket_id, key, version_id, is_latest, is_delete_marker, 
-			content_hash, size, content_type, etag, storage_class, metadata, created_at, deleted_at
+			content_hash, size, content_type, etag, storage_class, metadata, part_sizes, sequence, replication_status, acl, checksum_algorithm, checksum, created_at, deleted_at
 		FROM objects
 		WHERE bucket_id = ? AND key = ? AND is_latest = 1 AND deleted_at IS NULL
 	`
@@ -93,9 +105,9 @@ func (r *objectRepository) GetByKey(ctx context.Context, bucketID int64, key str
 func (r *objectRepository) GetByKeyAndVersion(ctx context.Context, bucketID int64, key string, versionID uuid.UUID) (*domain.Object, error) {
 	query := `
 		SELECT id, bucket_id, key, version_id, is_latest, is_delete_marker, 
-			content_hash, size, content_type, etag, storage_class, metadata, created_at, deleted_at
+			content_hash, size, content_type, etag, storage_class, metadata, part_sizes, sequence, replication_status, acl, checksum_algorithm, checksum, created_at, deleted_at
 		FROM objects
-		WHERE bucket_id = ? AND key = ? AND version_id = ?
+		WHERE bucket_id = ? AND key = ? AND version_id = ? AND deleted_at IS NULL
 	`
 	return r.scanObject(r.db.QueryRowContext(ctx, query, bucketID, key, versionID.String()))
 }
@@ -108,6 +120,7 @@ func (r *objectRepository) scanObject(row *sql.Row) (*domain.Object, error) {
 	var contentHash sql.NullString
 	var etag sql.NullString
 	var metadataJSON string
+	var partSizesJSON sql.NullString
 	var createdAt string
 	var deletedAt sql.NullString
 
@@ -124,6 +137,12 @@ func (r *objectRepository) scanObject(row *sql.Row) (*domain.Object, error) {
 		&etag,
 		&obj.StorageClass,
 		&metadataJSON,
+		&partSizesJSON,
+		&obj.Sequence,
+		&obj.ReplicationStatus,
+		&obj.ACL,
+		&obj.ChecksumAlgorithm,
+		&obj.Checksum,
 		&createdAt,
 		&deletedAt,
 	)
@@ -147,6 +166,9 @@ func (r *objectRepository) scanObject(row *sql.Row) (*domain.Object, error) {
 	if metadataJSON != "" {
 		json.Unmarshal([]byte(metadataJSON), &obj.Metadata)
 	}
+	if partSizesJSON.Valid && partSizesJSON.String != "" {
+		json.Unmarshal([]byte(partSizesJSON.String), &obj.PartSizes)
+	}
 	obj.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
 	if deletedAt.Valid {
 		t, _ := time.Parse(time.RFC3339, deletedAt.String)
@@ -166,7 +188,7 @@ func (r *objectRepository) List(ctx context.Context, bucketID int64, opts reposi
 	query := `
 		SELECT key, version_id, is_latest, size, etag, created_at, storage_class
 		FROM objects
-		WHERE bucket_id = ? AND is_latest = 1 AND deleted_at IS NULL
+		WHERE bucket_id = ? AND is_latest = 1 AND is_delete_marker = 0 AND deleted_at IS NULL
 			AND (? = '' OR key LIKE ? || '%')
 			AND (? = '' OR key > ?)
 		ORDER BY key ASC
@@ -237,19 +259,22 @@ func (r *objectRepository) ListVersions(ctx context.Context, bucketID int64, opt
 		FROM objects
 		WHERE bucket_id = ? AND deleted_at IS NULL
 			AND (? = '' OR key LIKE ? || '%')
-			AND (? = '' OR key > ?)
-		ORDER BY key ASC, created_at DESC
+			AND (? = '' OR key > ? OR (key = ? AND (sequence, id) < (
+				SELECT sequence, id FROM objects
+				WHERE bucket_id = ? AND key = ? AND version_id = ? AND deleted_at IS NULL
+			)))
+		ORDER BY key ASC, sequence DESC, id DESC
 		LIMIT ?
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, bucketID, opts.Prefix, opts.Prefix, opts.StartAfter, opts.StartAfter, maxKeys+1)
+	rows, err := r.db.QueryContext(ctx, query, bucketID, opts.Prefix, opts.Prefix,
+		opts.StartAfter, opts.StartAfter, opts.StartAfter, bucketID, opts.StartAfter, versionIDMarker(opts.VersionIDMarker), maxKeys+1)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list versions: %w", err)
 	}
 	defer rows.Close()
 
-	var versions []*domain.ObjectVersion
-	var deleteMarkers []*domain.ObjectVersion
+	var listed []*domain.ObjectVersion
 
 	for rows.Next() {
 		ver := &domain.ObjectVersion{}
@@ -272,35 +297,32 @@ func (r *objectRepository) ListVersions(ctx context.Context, bucketID int64, opt
 			return nil, fmt.Errorf("failed to scan version: %w", err)
 		}
 		ver.VersionID = versionIDStr
+		if versionIDStr == uuid.Nil.String() {
+			ver.VersionID = "null"
+		}
 		ver.IsLatest = isLatest != 0
 		ver.IsDeleteMarker = isDeleteMarker != 0
 		if etag.Valid {
 			ver.ETag = etag.String
 		}
 		ver.LastModified, _ = time.Parse(time.RFC3339, createdAt)
-
-		if ver.IsDeleteMarker {
-			deleteMarkers = append(deleteMarkers, ver)
-		} else {
-			versions = append(versions, ver)
-		}
+		listed = append(listed, ver)
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating versions: %w", err)
 	}
 
-	result := &repository.ObjectVersionListResult{
-		Versions:      versions,
-		DeleteMarkers: deleteMarkers,
-	}
+	return repository.NewObjectVersionListResult(listed, maxKeys), nil
+}
 
-	totalCount := len(versions) + len(deleteMarkers)
-	if totalCount > maxKeys {
-		result.IsTruncated = true
+// versionIDMarker returns the stored form of a version ID marker, which
+// names the null version "null".
+func versionIDMarker(marker string) string {
+	if versionID, err := domain.ParseVersionID(marker); err == nil {
+		return versionID.String()
 	}
-
-	return result, nil
+	return marker
 }
 
 // Update updates an existing object.
@@ -315,7 +337,7 @@ func (r *objectRepository) Update(ctx context.Context, obj *domain.Object) error
 
 	query := `
 		UPDATE objects
-		SET content_type = ?, metadata = ?, storage_class = ?
+		SET content_type = ?, metadata = ?, storage_class = ?, acl = ?
 		WHERE id = ?
 	`
 
@@ -323,6 +345,7 @@ func (r *objectRepository) Update(ctx context.Context, obj *domain.Object) error
 		obj.ContentType,
 		metadataJSON,
 		obj.StorageClass,
+		obj.ACL,
 		obj.ID,
 	)
 
@@ -354,6 +377,56 @@ func (r *objectRepository) MarkNotLatest(ctx context.Context, bucketID int64, ke
 	return nil
 }
 
+// MarkLatest marks an object as the latest version of its key.
+func (r *objectRepository) MarkLatest(ctx context.Context, id int64) error {
+	query := `UPDATE objects SET is_latest = 1 WHERE id = ? AND deleted_at IS NULL`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark as latest: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return domain.ErrObjectNotFound
+	}
+
+	return nil
+}
+
+// NextSequence increments and returns the write sequence for a key. The
+// first write to a key numbers itself after the versions the object_sequence
+// backfill has not numbered yet, which it numbers from 1.
+func (r *objectRepository) NextSequence(ctx context.Context, bucketID int64, key string) (int64, error) {
+	query := `
+		UPDATE object_sequences SET sequence = sequence + 1
+		WHERE bucket_id = ? AND key = ?
+		RETURNING sequence
+	`
+
+	var sequence int64
+	err := r.db.QueryRowContext(ctx, query, bucketID, key).Scan(&sequence)
+	if err == nil {
+		return sequence, nil
+	}
+	if !isNoRows(err) {
+		return 0, fmt.Errorf("failed to get next sequence: %w", err)
+	}
+
+	query = `
+		INSERT INTO object_sequences (bucket_id, key, sequence)
+		SELECT ?1, ?2, COUNT(*) + 1 FROM objects
+		WHERE bucket_id = ?1 AND key = ?2 AND sequence = 0
+		ON CONFLICT (bucket_id, key) DO UPDATE SET sequence = object_sequences.sequence + 1
+		RETURNING sequence
+	`
+	if err := r.db.QueryRowContext(ctx, query, bucketID, key).Scan(&sequence); err != nil {
+		return 0, fmt.Errorf("failed to get next sequence: %w", err)
+	}
+
+	return sequence, nil
+}
+
 // Delete soft-deletes an object by ID.
 func (r *objectRepository) Delete(ctx context.Context, id int64) error {
 	query := `UPDATE objects SET deleted_at = ? WHERE id = ?`
@@ -371,16 +444,54 @@ func (r *objectRepository) Delete(ctx context.Context, id int64) error {
 	return nil
 }
 
-// DeleteAllVersions deletes all versions of an object.
-func (r *objectRepository) DeleteAllVersions(ctx context.Context, bucketID int64, key string) error {
-	query := `UPDATE objects SET deleted_at = ? WHERE bucket_id = ? AND key = ?`
+// DeleteAllVersions soft-deletes all versions of an object.
+func (r *objectRepository) DeleteAllVersions(ctx context.Context, bucketID int64, key string) ([]string, error) {
+	query := `
+		UPDATE objects SET deleted_at = ?
+		WHERE bucket_id = ? AND key = ? AND deleted_at IS NULL
+		RETURNING content_hash
+	`
 
-	_, err := r.db.ExecContext(ctx, query, time.Now().UTC().Format(time.RFC3339), bucketID, key)
+	rows, err := r.db.QueryContext(ctx, query, time.Now().UTC().Format(time.RFC3339), bucketID, key)
 	if err != nil {
-		return fmt.Errorf("failed to delete all versions: %w", err)
+		return nil, fmt.Errorf("failed to delete all versions: %w", err)
 	}
+	defer rows.Close()
 
-	return nil
+	var hashes []string
+	for rows.Next() {
+		var contentHash sql.NullString
+		if err := rows.Scan(&contentHash); err != nil {
+			return nil, fmt.Errorf("failed to scan content hash: %w", err)
+		}
+		if contentHash.Valid {
+			hashes = append(hashes, contentHash.String)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to delete all versions: %w", err)
+	}
+
+	return hashes, nil
+}
+
+// PurgeDeleted removes versions soft-deleted before the cutoff, oldest first.
+func (r *objectRepository) PurgeDeleted(ctx context.Context, before time.Time, limit int) (int64, error) {
+	query := `
+		DELETE FROM objects WHERE id IN (
+			SELECT id FROM objects
+			WHERE deleted_at IS NOT NULL AND deleted_at < ?
+			ORDER BY deleted_at
+			LIMIT ?
+		)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, before.UTC().Format(time.RFC3339), limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted objects: %w", err)
+	}
+
+	return result.RowsAffected()
 }
 
 // CountByBucket returns the number of objects in a bucket.
@@ -396,11 +507,80 @@ func (r *objectRepository) CountByBucket(ctx context.Context, bucketID int64) (i
 	return count, nil
 }
 
+// GetBucketStats returns the object, version and size totals of a bucket.
+func (r *objectRepository) GetBucketStats(ctx context.Context, bucketID int64, largest int) (*domain.BucketStats, error) {
+	stats := &domain.BucketStats{LargestObjects: []domain.ObjectSize{}}
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(CASE WHEN is_latest = 1 AND is_delete_marker = 0 THEN 1 ELSE 0 END), 0),
+		       COALESCE(SUM(CASE WHEN is_latest = 1 AND is_delete_marker = 0 THEN size ELSE 0 END), 0),
+		       COALESCE(SUM(CASE WHEN is_delete_marker = 0 THEN 1 ELSE 0 END), 0)
+		FROM objects
+		WHERE bucket_id = ? AND deleted_at IS NULL
+	`, bucketID).Scan(&stats.Objects, &stats.LogicalBytes, &stats.Versions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bucket stats: %w", err)
+	}
+
+	err = r.db.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(size), 0) FROM blobs
+		WHERE content_hash IN (
+			SELECT content_hash FROM objects
+			WHERE bucket_id = ? AND deleted_at IS NULL AND content_hash IS NOT NULL
+		)
+	`, bucketID).Scan(&stats.PhysicalBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bucket physical size: %w", err)
+	}
+
+	if largest <= 0 {
+		return stats, nil
+	}
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT key, size FROM objects
+		WHERE bucket_id = ? AND is_latest = 1 AND is_delete_marker = 0 AND deleted_at IS NULL
+		ORDER BY size DESC, key
+		LIMIT ?
+	`, bucketID, largest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list largest objects: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var obj domain.ObjectSize
+		if err := rows.Scan(&obj.Key, &obj.Size); err != nil {
+			return nil, fmt.Errorf("failed to scan object: %w", err)
+		}
+		stats.LargestObjects = append(stats.LargestObjects, obj)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list largest objects: %w", err)
+	}
+	return stats, nil
+}
+
+// GetStats returns the object, version and size totals of all buckets.
+func (r *objectRepository) GetStats(ctx context.Context) (*domain.ObjectStats, error) {
+	stats := &domain.ObjectStats{}
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(CASE WHEN is_latest = 1 AND is_delete_marker = 0 THEN 1 ELSE 0 END), 0),
+		       COALESCE(SUM(CASE WHEN is_latest = 1 AND is_delete_marker = 0 THEN size ELSE 0 END), 0),
+		       COALESCE(SUM(CASE WHEN is_delete_marker = 0 THEN 1 ELSE 0 END), 0),
+		       COALESCE(SUM(CASE WHEN is_delete_marker = 1 THEN 1 ELSE 0 END), 0)
+		FROM objects
+		WHERE deleted_at IS NULL
+	`).Scan(&stats.Objects, &stats.LogicalBytes, &stats.Versions, &stats.DeleteMarkers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object stats: %w", err)
+	}
+	return stats, nil
+}
+
 // GetContentHashForVersion retrieves the content hash for a specific version.
 func (r *objectRepository) GetContentHashForVersion(ctx context.Context, bucketID int64, key string, versionID uuid.UUID) (*string, error) {
 	var contentHash sql.NullString
 	err := r.db.QueryRowContext(ctx,
-		`SELECT content_hash FROM objects WHERE bucket_id = ? AND key = ? AND version_id = ?`,
+		`SELECT content_hash FROM objects WHERE bucket_id = ? AND key = ? AND version_id = ? AND deleted_at IS NULL`,
 		bucketID, key, versionID.String(),
 	).Scan(&contentHash)
 	if err != nil {
@@ -415,27 +595,109 @@ func (r *objectRepository) GetContentHashForVersion(ctx context.Context, bucketI
 	return nil, nil
 }
 
-// ListExpiredObjects returns latest objects older than cutoff, with optional prefix.
+// objectSummaryColumns are the columns scanned by listObjects.
+const objectSummaryColumns = `o.id, o.bucket_id, o.key, o.version_id, o.is_latest, o.is_delete_marker,
+	o.content_hash, o.size, o.content_type, o.etag, o.storage_class, o.metadata, o.created_at, o.deleted_at`
+
+// lifecycleFilterClause matches objects o against a prefix and optional
+// exclusive size bounds, taking them as its four arguments.
+const lifecycleFilterClause = `(? = '' OR substr(o.key, 1, length(?)) = ?)
+	AND (? IS NULL OR o.size > ?)
+	AND (? IS NULL OR o.size < ?)`
+
+// lifecycleFilterArgs returns the arguments of lifecycleFilterClause.
+func lifecycleFilterArgs(filter domain.LifecycleFilter) []interface{} {
+	return []interface{}{
+		filter.Prefix, filter.Prefix, filter.Prefix,
+		filter.ObjectSizeGreaterThan, filter.ObjectSizeGreaterThan,
+		filter.ObjectSizeLessThan, filter.ObjectSizeLessThan,
+	}
+}
+
+// ListExpiredObjects returns latest objects created before cutoff that match
+// the filter's prefix and size bounds.
 // Used by lifecycle service for expiration processing.
-func (r *objectRepository) ListExpiredObjects(ctx context.Context, bucketID int64, prefix string, olderThan time.Time, limit int) ([]*domain.Object, error) {
+func (r *objectRepository) ListExpiredObjects(ctx context.Context, bucketID int64, filter domain.LifecycleFilter, olderThan time.Time, limit int) ([]*domain.Object, error) {
 	query := `
-		SELECT id, bucket_id, key, version_id, is_latest, is_delete_marker, 
-			content_hash, size, content_type, etag, storage_class, metadata, created_at, deleted_at
-		FROM objects
-		WHERE bucket_id = ? 
-			AND is_latest = 1 
-			AND is_delete_marker = 0
-			AND deleted_at IS NULL
-			AND created_at < ?
-			AND (? = '' OR key LIKE ? || '%')
-		ORDER BY created_at ASC
+		SELECT ` + objectSummaryColumns + `
+		FROM objects o
+		WHERE o.bucket_id = ?
+			AND o.is_latest = 1
+			AND o.is_delete_marker = 0
+			AND o.deleted_at IS NULL
+			AND o.created_at < ?
+			AND ` + lifecycleFilterClause + `
+		ORDER BY o.created_at ASC
 		LIMIT ?
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, bucketID, olderThan.Format(time.RFC3339), prefix, prefix, limit)
+	args := append([]interface{}{bucketID, olderThan.UTC().Format(time.RFC3339)}, lifecycleFilterArgs(filter)...)
+	objects, err := r.listObjects(ctx, query, append(args, limit)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list expired objects: %w", err)
 	}
+	return objects, nil
+}
+
+// ListNoncurrentVersions returns versions superseded before the cutoff that
+// match the filter's prefix and size bounds. A version becomes noncurrent
+// when the next version of its key is created.
+func (r *objectRepository) ListNoncurrentVersions(ctx context.Context, bucketID int64, filter domain.LifecycleFilter, noncurrentBefore time.Time, limit int) ([]*domain.Object, error) {
+	query := `
+		SELECT ` + objectSummaryColumns + `
+		FROM objects o
+		WHERE o.bucket_id = ?
+			AND o.is_latest = 0
+			AND o.deleted_at IS NULL
+			AND ` + lifecycleFilterClause + `
+			AND (
+				SELECT MIN(n.created_at) FROM objects n
+				WHERE n.bucket_id = o.bucket_id AND n.key = o.key AND n.id > o.id
+			) < ?
+		ORDER BY o.created_at ASC
+		LIMIT ?
+	`
+
+	args := append([]interface{}{bucketID}, lifecycleFilterArgs(filter)...)
+	objects, err := r.listObjects(ctx, query, append(args, noncurrentBefore.UTC().Format(time.RFC3339), limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list noncurrent versions: %w", err)
+	}
+	return objects, nil
+}
+
+// ListExpiredDeleteMarkers returns latest delete markers under prefix that
+// are the only remaining version of their key.
+func (r *objectRepository) ListExpiredDeleteMarkers(ctx context.Context, bucketID int64, prefix string, limit int) ([]*domain.Object, error) {
+	query := `
+		SELECT ` + objectSummaryColumns + `
+		FROM objects o
+		WHERE o.bucket_id = ?
+			AND o.is_latest = 1
+			AND o.is_delete_marker = 1
+			AND o.deleted_at IS NULL
+			AND (? = '' OR substr(o.key, 1, length(?)) = ?)
+			AND NOT EXISTS (
+				SELECT 1 FROM objects n
+				WHERE n.bucket_id = o.bucket_id AND n.key = o.key AND n.id <> o.id AND n.deleted_at IS NULL
+			)
+		ORDER BY o.created_at ASC
+		LIMIT ?
+	`
+
+	objects, err := r.listObjects(ctx, query, bucketID, prefix, prefix, prefix, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired delete markers: %w", err)
+	}
+	return objects, nil
+}
+
+// listObjects returns the objects a query selecting objectSummaryColumns returns.
+func (r *objectRepository) listObjects(ctx context.Context, query string, args ...interface{}) ([]*domain.Object, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
 
 	var objects []*domain.Object
@@ -0,0 +1,45 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+func TestObjectRepository_ListSkipsDeleteMarkers(t *testing.T) {
+	ctx := context.Background()
+	db, err := NewDB(ctx, DefaultConfig(filepath.Join(t.TempDir(), "alexander.db")), zerolog.Nop())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, db.Migrate(ctx))
+
+	user := domain.NewUser("owner", "owner@example.com", "hash")
+	require.NoError(t, NewUserRepository(db).Create(ctx, user))
+	bucket := domain.NewBucket(user.ID, "photos")
+	require.NoError(t, NewBucketRepository(db).Create(ctx, bucket))
+
+	repo := NewObjectRepository(db)
+	for _, key := range []string{"cat.jpg", "dog.jpg"} {
+		require.NoError(t, repo.Create(ctx, domain.NewObject(bucket.ID, key, "hash-"+key, "image/jpeg", "etag", 10)))
+	}
+	// dog.jpg is deleted in a versioned bucket
+	require.NoError(t, repo.MarkNotLatest(ctx, bucket.ID, "dog.jpg"))
+	require.NoError(t, repo.Create(ctx, domain.NewDeleteMarker(bucket.ID, "dog.jpg")))
+
+	result, err := repo.List(ctx, bucket.ID, repository.ObjectListOptions{MaxKeys: 1000})
+	require.NoError(t, err)
+	require.Len(t, result.Objects, 1)
+	require.Equal(t, "cat.jpg", result.Objects[0].Key)
+
+	// The versions listing still has the marker and the version below it
+	versions, err := repo.ListVersions(ctx, bucket.ID, repository.ObjectListOptions{Prefix: "dog.jpg", MaxKeys: 1000})
+	require.NoError(t, err)
+	require.Len(t, versions.Versions, 1)
+	require.Len(t, versions.DeleteMarkers, 1)
+}
@@ -0,0 +1,47 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// readOnlyRepository implements repository.ReadOnlyRepository for SQLite.
+type readOnlyRepository struct {
+	db *DB
+}
+
+// NewReadOnlyRepository creates a new SQLite read-only mode repository.
+func NewReadOnlyRepository(db *DB) repository.ReadOnlyRepository {
+	return &readOnlyRepository{db: db}
+}
+
+// Get reports whether read-only mode is on.
+func (r *readOnlyRepository) Get(ctx context.Context) (bool, error) {
+	var enabled int
+	err := r.db.QueryRowContext(ctx, `SELECT enabled FROM read_only_mode WHERE id = 1`).Scan(&enabled)
+	if err != nil {
+		if isNoRows(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get read-only mode: %w", err)
+	}
+	return enabled != 0, nil
+}
+
+// Set turns read-only mode on or off.
+func (r *readOnlyRepository) Set(ctx context.Context, enabled bool) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO read_only_mode (id, enabled, updated_at) VALUES (1, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET enabled = excluded.enabled, updated_at = excluded.updated_at
+	`, boolToInt(enabled), time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("failed to set read-only mode: %w", err)
+	}
+	return nil
+}
+
+// Ensure readOnlyRepository implements repository.ReadOnlyRepository
+var _ repository.ReadOnlyRepository = (*readOnlyRepository)(nil)
@@ -0,0 +1,262 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// replicationRepository implements repository.ReplicationRepository for SQLite.
+type replicationRepository struct {
+	db *DB
+}
+
+// NewReplicationRepository creates a new SQLite replication repository.
+func NewReplicationRepository(db *DB) repository.ReplicationRepository {
+	return &replicationRepository{db: db}
+}
+
+// PutConfig creates or replaces the configuration of a bucket.
+func (r *replicationRepository) PutConfig(ctx context.Context, config *domain.ReplicationConfig) error {
+	rules, err := json.Marshal(config.Rules)
+	if err != nil {
+		return fmt.Errorf("failed to encode replication rules: %w", err)
+	}
+
+	query := `
+		INSERT INTO bucket_replication (bucket_id, endpoint, region, destination_bucket,
+			access_key_id, encrypted_secret_key, rules, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (bucket_id) DO UPDATE SET
+			endpoint = excluded.endpoint,
+			region = excluded.region,
+			destination_bucket = excluded.destination_bucket,
+			access_key_id = excluded.access_key_id,
+			encrypted_secret_key = excluded.encrypted_secret_key,
+			rules = excluded.rules,
+			updated_at = excluded.updated_at
+		RETURNING created_at
+	`
+
+	var createdAt string
+	err = r.db.QueryRowContext(ctx, query,
+		config.BucketID,
+		config.Endpoint,
+		config.Region,
+		config.DestinationBucket,
+		config.AccessKeyID,
+		config.EncryptedSecretKey,
+		string(rules),
+		config.CreatedAt.UTC().Format(time.RFC3339),
+		config.UpdatedAt.UTC().Format(time.RFC3339),
+	).Scan(&createdAt)
+	if err != nil {
+		return fmt.Errorf("failed to put replication config: %w", err)
+	}
+	config.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+
+	return nil
+}
+
+// GetConfig returns the configuration of a bucket.
+func (r *replicationRepository) GetConfig(ctx context.Context, bucketID int64) (*domain.ReplicationConfig, error) {
+	query := `
+		SELECT bucket_id, endpoint, region, destination_bucket, access_key_id,
+			encrypted_secret_key, rules, created_at, updated_at
+		FROM bucket_replication
+		WHERE bucket_id = ?
+	`
+
+	config := &domain.ReplicationConfig{}
+	var rules, createdAt, updatedAt string
+	err := r.db.QueryRowContext(ctx, query, bucketID).Scan(
+		&config.BucketID,
+		&config.Endpoint,
+		&config.Region,
+		&config.DestinationBucket,
+		&config.AccessKeyID,
+		&config.EncryptedSecretKey,
+		&rules,
+		&createdAt,
+		&updatedAt,
+	)
+	if err != nil {
+		if isNoRows(err) {
+			return nil, domain.ErrReplicationConfigNotFound
+		}
+		return nil, fmt.Errorf("failed to get replication config: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(rules), &config.Rules); err != nil {
+		return nil, fmt.Errorf("failed to decode replication rules: %w", err)
+	}
+	config.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	config.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
+
+	return config, nil
+}
+
+// DeleteConfig removes the configuration of a bucket and its queued tasks.
+// Versions still waiting for replication are marked failed.
+func (r *replicationRepository) DeleteConfig(ctx context.Context, bucketID int64) error {
+	return r.db.WithTx(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, `DELETE FROM bucket_replication WHERE bucket_id = ?`, bucketID)
+		if err != nil {
+			return fmt.Errorf("failed to delete replication config: %w", err)
+		}
+		if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+			return domain.ErrReplicationConfigNotFound
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM replication_tasks WHERE bucket_id = ?`, bucketID); err != nil {
+			return fmt.Errorf("failed to delete replication tasks: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE objects SET replication_status = ?
+			WHERE bucket_id = ? AND replication_status = ?
+		`, domain.ReplicationStatusFailed, bucketID, domain.ReplicationStatusPending)
+		if err != nil {
+			return fmt.Errorf("failed to update replication status: %w", err)
+		}
+		return nil
+	})
+}
+
+// Enqueue queues a task and sets its ID.
+func (r *replicationRepository) Enqueue(ctx context.Context, task *domain.ReplicationTask) error {
+	query := `
+		INSERT INTO replication_tasks (bucket_id, object_id, key, operation, next_attempt_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		task.BucketID,
+		task.ObjectID,
+		task.Key,
+		string(task.Operation),
+		task.NextAttemptAt.UTC().Format(time.RFC3339),
+		task.CreatedAt.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue replication task: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get replication task id: %w", err)
+	}
+	task.ID = id
+
+	return nil
+}
+
+// ClaimDue returns due tasks that have no earlier task for their key and
+// postpones them to leaseUntil.
+func (r *replicationRepository) ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*domain.ReplicationTask, error) {
+	query := `
+		UPDATE replication_tasks SET next_attempt_at = ?
+		WHERE id IN (
+			SELECT t.id FROM replication_tasks t
+			WHERE t.next_attempt_at <= ?
+				AND NOT EXISTS (
+					SELECT 1 FROM replication_tasks e
+					WHERE e.bucket_id = t.bucket_id AND e.key = t.key AND e.id < t.id
+				)
+			ORDER BY t.id
+			LIMIT ?
+		)
+		RETURNING id, bucket_id, object_id, key, operation, attempts, next_attempt_at, last_error, created_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query,
+		leaseUntil.UTC().Format(time.RFC3339),
+		now.UTC().Format(time.RFC3339),
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim replication tasks: %w", err)
+	}
+	defer rows.Close()
+
+	var tasks []*domain.ReplicationTask
+	for rows.Next() {
+		task := &domain.ReplicationTask{}
+		var operation, nextAttemptAt, createdAt string
+		err := rows.Scan(
+			&task.ID,
+			&task.BucketID,
+			&task.ObjectID,
+			&task.Key,
+			&operation,
+			&task.Attempts,
+			&nextAttemptAt,
+			&task.LastError,
+			&createdAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan replication task: %w", err)
+		}
+		task.Operation = domain.ReplicationOperation(operation)
+		task.NextAttemptAt, _ = time.Parse(time.RFC3339, nextAttemptAt)
+		task.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+		tasks = append(tasks, task)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating replication tasks: %w", err)
+	}
+
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+	return tasks, nil
+}
+
+// Complete removes a finished task and sets the replication status of its object version.
+func (r *replicationRepository) Complete(ctx context.Context, task *domain.ReplicationTask, status domain.ReplicationStatus) error {
+	return r.db.WithTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM replication_tasks WHERE id = ?`, task.ID); err != nil {
+			return fmt.Errorf("failed to delete replication task: %w", err)
+		}
+		if task.ObjectID == 0 {
+			return nil
+		}
+		_, err := tx.ExecContext(ctx, `UPDATE objects SET replication_status = ? WHERE id = ?`, status, task.ObjectID)
+		if err != nil {
+			return fmt.Errorf("failed to update replication status: %w", err)
+		}
+		return nil
+	})
+}
+
+// Retry saves the state of a failed task.
+func (r *replicationRepository) Retry(ctx context.Context, task *domain.ReplicationTask) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE replication_tasks SET attempts = ?, next_attempt_at = ?, last_error = ?
+		WHERE id = ?
+	`, task.Attempts, task.NextAttemptAt.UTC().Format(time.RFC3339), task.LastError, task.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update replication task: %w", err)
+	}
+	return nil
+}
+
+// Backlog returns the number of queued tasks and the creation time of the oldest.
+func (r *replicationRepository) Backlog(ctx context.Context) (int64, *time.Time, error) {
+	var count int64
+	var oldest sql.NullString
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*), MIN(created_at) FROM replication_tasks`).Scan(&count, &oldest)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to get replication backlog: %w", err)
+	}
+	if !oldest.Valid {
+		return count, nil, nil
+	}
+	t, _ := time.Parse(time.RFC3339, oldest.String)
+	return count, &t, nil
+}
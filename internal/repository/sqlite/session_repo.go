@@ -2,6 +2,7 @@ package sqlite
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"time"
 
@@ -24,8 +25,9 @@ func NewSessionRepository(db *DB) repository.SessionRepository {
 // Create creates a new session.
 func (r *sessionRepository) Create(ctx context.Context, session *domain.Session) error {
 	query := `
-		INSERT INTO sessions (id, user_id, token, expires_at, created_at, ip_address, user_agent)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO sessions (id, user_id, token, expires_at, created_at, ip_address, user_agent,
+			csrf_token, last_seen_at, remember_token, remember_expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	_, err := r.db.ExecContext(ctx, query,
@@ -36,6 +38,10 @@ func (r *sessionRepository) Create(ctx context.Context, session *domain.Session)
 		session.CreatedAt.Format(time.RFC3339),
 		session.IPAddress,
 		session.UserAgent,
+		session.CSRFToken,
+		session.LastSeenAt.UTC().Format(time.RFC3339),
+		nullRememberToken(session),
+		nullRememberExpiresAt(session),
 	)
 
 	if err != nil {
@@ -50,26 +56,9 @@ func (r *sessionRepository) Create(ctx context.Context, session *domain.Session)
 
 // GetByToken retrieves a session by its token.
 func (r *sessionRepository) GetByToken(ctx context.Context, token string) (*domain.Session, error) {
-	query := `
-		SELECT id, user_id, token, expires_at, created_at, ip_address, user_agent
-		FROM sessions
-		WHERE token = ?
-	`
-
-	session := &domain.Session{}
-	var id, expiresAt, createdAt string
-	var ipAddress, userAgent *string
-
-	err := r.db.QueryRowContext(ctx, query, token).Scan(
-		&id,
-		&session.UserID,
-		&session.Token,
-		&expiresAt,
-		&createdAt,
-		&ipAddress,
-		&userAgent,
-	)
+	query := `SELECT ` + sessionColumns + ` FROM sessions WHERE token = ?`
 
+	session, err := scanSession(r.db.QueryRowContext(ctx, query, token))
 	if err != nil {
 		if isNoRows(err) {
 			return nil, repository.ErrNotFound
@@ -77,15 +66,19 @@ func (r *sessionRepository) GetByToken(ctx context.Context, token string) (*doma
 		return nil, fmt.Errorf("failed to get session by token: %w", err)
 	}
 
-	session.ID = parseUUID(id)
-	session.ExpiresAt, _ = time.Parse(time.RFC3339, expiresAt)
-	session.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	return session, nil
+}
 
-	if ipAddress != nil {
-		session.IPAddress = *ipAddress
-	}
-	if userAgent != nil {
-		session.UserAgent = *userAgent
+// GetByRememberToken retrieves a session by its remember-me token.
+func (r *sessionRepository) GetByRememberToken(ctx context.Context, token string) (*domain.Session, error) {
+	query := `SELECT ` + sessionColumns + ` FROM sessions WHERE remember_token = ?`
+
+	session, err := scanSession(r.db.QueryRowContext(ctx, query, token))
+	if err != nil {
+		if isNoRows(err) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get session by remember token: %w", err)
 	}
 
 	return session, nil
@@ -93,12 +86,7 @@ func (r *sessionRepository) GetByToken(ctx context.Context, token string) (*doma
 
 // GetByUserID returns all sessions for a user.
 func (r *sessionRepository) GetByUserID(ctx context.Context, userID int64) ([]*domain.Session, error) {
-	query := `
-		SELECT id, user_id, token, expires_at, created_at, ip_address, user_agent
-		FROM sessions
-		WHERE user_id = ?
-		ORDER BY created_at DESC
-	`
+	query := `SELECT ` + sessionColumns + ` FROM sessions WHERE user_id = ? ORDER BY created_at DESC`
 
 	rows, err := r.db.QueryContext(ctx, query, userID)
 	if err != nil {
@@ -108,34 +96,10 @@ func (r *sessionRepository) GetByUserID(ctx context.Context, userID int64) ([]*d
 
 	var sessions []*domain.Session
 	for rows.Next() {
-		session := &domain.Session{}
-		var id, expiresAt, createdAt string
-		var ipAddress, userAgent *string
-
-		err := rows.Scan(
-			&id,
-			&session.UserID,
-			&session.Token,
-			&expiresAt,
-			&createdAt,
-			&ipAddress,
-			&userAgent,
-		)
+		session, err := scanSession(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan session: %w", err)
 		}
-
-		session.ID = parseUUID(id)
-		session.ExpiresAt, _ = time.Parse(time.RFC3339, expiresAt)
-		session.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
-
-		if ipAddress != nil {
-			session.IPAddress = *ipAddress
-		}
-		if userAgent != nil {
-			session.UserAgent = *userAgent
-		}
-
 		sessions = append(sessions, session)
 	}
 
@@ -146,6 +110,34 @@ func (r *sessionRepository) GetByUserID(ctx context.Context, userID int64) ([]*d
 	return sessions, nil
 }
 
+// Update saves the tokens, expiry and last activity of a session.
+func (r *sessionRepository) Update(ctx context.Context, session *domain.Session) error {
+	query := `
+		UPDATE sessions
+		SET token = ?, expires_at = ?, last_seen_at = ?, remember_token = ?, remember_expires_at = ?
+		WHERE id = ?
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		session.Token,
+		session.ExpiresAt.Format(time.RFC3339),
+		session.LastSeenAt.UTC().Format(time.RFC3339),
+		nullRememberToken(session),
+		nullRememberExpiresAt(session),
+		session.ID.String(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
 // Delete deletes a session by token.
 func (r *sessionRepository) Delete(ctx context.Context, token string) error {
 	query := `DELETE FROM sessions WHERE token = ?`
@@ -163,6 +155,21 @@ func (r *sessionRepository) Delete(ctx context.Context, token string) error {
 	return nil
 }
 
+// DeleteByID deletes a session by ID.
+func (r *sessionRepository) DeleteByID(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM sessions WHERE id = ?`, id.String())
+	if err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
 // DeleteByUserID deletes all sessions for a user.
 func (r *sessionRepository) DeleteByUserID(ctx context.Context, userID int64) error {
 	query := `DELETE FROM sessions WHERE user_id = ?`
@@ -175,11 +182,15 @@ func (r *sessionRepository) DeleteByUserID(ctx context.Context, userID int64) er
 	return nil
 }
 
-// DeleteExpired deletes all expired sessions.
+// DeleteExpired deletes all expired sessions that cannot be resumed.
 func (r *sessionRepository) DeleteExpired(ctx context.Context) (int64, error) {
-	query := `DELETE FROM sessions WHERE expires_at < ?`
+	query := `
+		DELETE FROM sessions
+		WHERE expires_at < ? AND (remember_expires_at IS NULL OR remember_expires_at < ?)
+	`
 
-	result, err := r.db.ExecContext(ctx, query, time.Now().UTC().Format(time.RFC3339))
+	now := time.Now().UTC().Format(time.RFC3339)
+	result, err := r.db.ExecContext(ctx, query, now, now)
 	if err != nil {
 		return 0, fmt.Errorf("failed to delete expired sessions: %w", err)
 	}
@@ -218,6 +229,67 @@ func (r *sessionRepository) CountByUserID(ctx context.Context, userID int64) (in
 	return count, nil
 }
 
+// sessionColumns lists the sessions columns read by scanSession.
+const sessionColumns = `id, user_id, token, expires_at, created_at, ip_address, user_agent,
+	csrf_token, last_seen_at, remember_token, remember_expires_at`
+
+// scanSession scans the sessionColumns of one row.
+func scanSession(row interface{ Scan(dest ...any) error }) (*domain.Session, error) {
+	session := &domain.Session{}
+	var id, expiresAt, createdAt string
+	var ipAddress, userAgent, lastSeenAt, rememberToken, rememberExpiresAt sql.NullString
+
+	err := row.Scan(
+		&id,
+		&session.UserID,
+		&session.Token,
+		&expiresAt,
+		&createdAt,
+		&ipAddress,
+		&userAgent,
+		&session.CSRFToken,
+		&lastSeenAt,
+		&rememberToken,
+		&rememberExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	session.ID = parseUUID(id)
+	session.ExpiresAt, _ = time.Parse(time.RFC3339, expiresAt)
+	session.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	session.IPAddress = ipAddress.String
+	session.UserAgent = userAgent.String
+	session.RememberToken = rememberToken.String
+
+	// Sessions created before idle tracking count from their creation
+	session.LastSeenAt = session.CreatedAt
+	if lastSeenAt.Valid {
+		session.LastSeenAt, _ = time.Parse(time.RFC3339, lastSeenAt.String)
+	}
+	if rememberExpiresAt.Valid {
+		t, _ := time.Parse(time.RFC3339, rememberExpiresAt.String)
+		session.RememberExpiresAt = &t
+	}
+
+	return session, nil
+}
+
+// nullRememberToken returns the remember-me token, or NULL when there is none,
+// so that the unique index only covers sessions that have one.
+func nullRememberToken(session *domain.Session) sql.NullString {
+	return sql.NullString{String: session.RememberToken, Valid: session.RememberToken != ""}
+}
+
+// nullRememberExpiresAt returns the remember-me expiry as RFC3339, or NULL.
+func nullRememberExpiresAt(session *domain.Session) sql.NullString {
+	if session.RememberExpiresAt == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: session.RememberExpiresAt.UTC().Format(time.RFC3339), Valid: true}
+}
+
 // parseUUID parses a UUID string into a uuid.UUID.
 // Returns uuid.Nil on error.
 func parseUUID(s string) uuid.UUID {
@@ -0,0 +1,181 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// snapshotFile is the name of the database copy in a snapshot directory.
+const snapshotFile = "alexander.db"
+
+// SchemaVersion returns the migration version of the database.
+func (db *DB) SchemaVersion(ctx context.Context) (int64, error) {
+	var version int64
+	err := db.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get schema version: %w", err)
+	}
+	return version, nil
+}
+
+// Snapshot writes a consistent copy of the database file into dir.
+// VACUUM INTO reads in a single transaction, so writers are not blocked.
+func (db *DB) Snapshot(ctx context.Context, dir string) error {
+	if _, err := db.db.ExecContext(ctx, `VACUUM INTO ?`, filepath.Join(dir, snapshotFile)); err != nil {
+		return fmt.Errorf("failed to snapshot database: %w", err)
+	}
+	return nil
+}
+
+// Restore replaces the contents of every table with those of a snapshot.
+//
+// The snapshot is attached rather than copied over the database file, so the
+// restore is a single transaction on an open database. Triggers are dropped
+// for the duration of the transaction, since the append-only audit log
+// would otherwise refuse to be replaced, and recreated before it commits.
+func (db *DB) Restore(ctx context.Context, dir string) error {
+	path := filepath.Join(dir, snapshotFile)
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("invalid snapshot: %w", err)
+	}
+
+	// ATTACH and DETACH cannot run inside a transaction, so pin a connection
+	conn, err := db.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `ATTACH DATABASE ? AS snapshot`, path); err != nil {
+		return fmt.Errorf("failed to attach snapshot: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), `DETACH DATABASE snapshot`)
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := restoreTables(ctx, tx); err != nil {
+		return fmt.Errorf("failed to restore snapshot: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit restore: %w", err)
+	}
+
+	db.logger.Info().Str("snapshot", path).Msg("restored database from snapshot")
+	return nil
+}
+
+// restoreTables copies every table of the attached snapshot over the main
+// database in tx.
+func restoreTables(ctx context.Context, tx *sql.Tx) error {
+	// Rows are copied in name order, which need not satisfy foreign keys
+	// until the copy is complete
+	if _, err := tx.ExecContext(ctx, `PRAGMA defer_foreign_keys = ON`); err != nil {
+		return err
+	}
+
+	triggers, err := queryPairs(ctx, tx, `SELECT name, sql FROM main.sqlite_master WHERE type = 'trigger' ORDER BY name`)
+	if err != nil {
+		return fmt.Errorf("failed to list triggers: %w", err)
+	}
+	for _, trigger := range triggers {
+		if _, err := tx.ExecContext(ctx, `DROP TRIGGER main.`+quoteIdent(trigger[0])); err != nil {
+			return fmt.Errorf("failed to drop trigger %s: %w", trigger[0], err)
+		}
+	}
+
+	tables, err := tableNames(ctx, tx, "main")
+	if err != nil {
+		return err
+	}
+	snapshotTables, err := tableNames(ctx, tx, "snapshot")
+	if err != nil {
+		return err
+	}
+	inSnapshot := make(map[string]bool, len(snapshotTables))
+	for _, table := range snapshotTables {
+		inSnapshot[table] = true
+	}
+
+	for _, table := range tables {
+		if !inSnapshot[table] {
+			return fmt.Errorf("table %s is missing from the snapshot", table)
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM main.`+quoteIdent(table)); err != nil {
+			return fmt.Errorf("failed to clear table %s: %w", table, err)
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO main.`+quoteIdent(table)+` SELECT * FROM snapshot.`+quoteIdent(table)); err != nil {
+			return fmt.Errorf("failed to copy table %s: %w", table, err)
+		}
+	}
+
+	for _, trigger := range triggers {
+		if _, err := tx.ExecContext(ctx, trigger[1]); err != nil {
+			return fmt.Errorf("failed to recreate trigger %s: %w", trigger[0], err)
+		}
+	}
+	return nil
+}
+
+// tableNames returns the data tables of a schema, including the
+// AUTOINCREMENT counters in sqlite_sequence but not the migration history.
+func tableNames(ctx context.Context, tx *sql.Tx, schema string) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT name FROM `+schema+`.sqlite_master
+		WHERE type = 'table'
+			AND (name NOT LIKE 'sqlite_%' OR name = 'sqlite_sequence')
+			AND name != 'schema_migrations'
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s tables: %w", schema, err)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan table name: %w", err)
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// queryPairs returns the rows of a query selecting two text columns.
+func queryPairs(ctx context.Context, tx *sql.Tx, query string) ([][2]string, error) {
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pairs [][2]string
+	for rows.Next() {
+		var pair [2]string
+		if err := rows.Scan(&pair[0], &pair[1]); err != nil {
+			return nil, err
+		}
+		pairs = append(pairs, pair)
+	}
+	return pairs, rows.Err()
+}
+
+// quoteIdent quotes an SQL identifier.
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// Ensure DB implements repository.Snapshotter
+var _ repository.Snapshotter = (*DB)(nil)
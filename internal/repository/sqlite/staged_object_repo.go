@@ -0,0 +1,234 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// stagedObjectColumns lists the staged_objects columns read by scanStagedObject.
+const stagedObjectColumns = `id, bucket_id, key, content_hash, size, content_type, etag,
+	storage_class, acl, checksum_algorithm, checksum, metadata, part_sizes, batch_id, event_type, created_at`
+
+// stagedObjectRepository implements repository.StagedObjectRepository for SQLite.
+type stagedObjectRepository struct {
+	db *DB
+}
+
+// NewStagedObjectRepository creates a new SQLite staged object repository.
+func NewStagedObjectRepository(db *DB) repository.StagedObjectRepository {
+	return &stagedObjectRepository{db: db}
+}
+
+// Create stages an upload.
+func (r *stagedObjectRepository) Create(ctx context.Context, staged *domain.StagedObject) error {
+	query := `
+		INSERT INTO staged_objects (id, bucket_id, key, content_hash, size, content_type, etag,
+			storage_class, acl, checksum_algorithm, checksum, metadata, part_sizes, batch_id, event_type, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	metadataJSON := "{}"
+	if staged.Metadata != nil {
+		data, _ := json.Marshal(staged.Metadata)
+		metadataJSON = string(data)
+	}
+
+	var partSizesJSON sql.NullString
+	if len(staged.PartSizes) > 0 {
+		data, _ := json.Marshal(staged.PartSizes)
+		partSizesJSON = sql.NullString{String: string(data), Valid: true}
+	}
+
+	var batchID sql.NullString
+	if staged.BatchID != nil {
+		batchID = sql.NullString{String: staged.BatchID.String(), Valid: true}
+	}
+
+	_, err := r.db.ExecContext(ctx, query,
+		staged.ID.String(),
+		staged.BucketID,
+		staged.Key,
+		staged.ContentHash,
+		staged.Size,
+		staged.ContentType,
+		staged.ETag,
+		staged.StorageClass,
+		staged.ACL,
+		staged.ChecksumAlgorithm,
+		staged.Checksum,
+		metadataJSON,
+		partSizesJSON,
+		batchID,
+		staged.EventType,
+		staged.CreatedAt.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to stage object: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID returns a staged upload.
+func (r *stagedObjectRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.StagedObject, error) {
+	query := `SELECT ` + stagedObjectColumns + ` FROM staged_objects WHERE id = ?`
+
+	staged, err := scanStagedObject(r.db.QueryRowContext(ctx, query, id.String()))
+	if err != nil {
+		if isNoRows(err) {
+			return nil, domain.ErrStagedObjectNotFound
+		}
+		return nil, fmt.Errorf("failed to get staged object: %w", err)
+	}
+
+	return staged, nil
+}
+
+// List returns up to limit staged uploads of a bucket, or of all buckets if bucketID is 0.
+func (r *stagedObjectRepository) List(ctx context.Context, bucketID int64, limit int) ([]*domain.StagedObject, error) {
+	query := `
+		SELECT ` + stagedObjectColumns + `
+		FROM staged_objects
+		WHERE (? = 0 OR bucket_id = ?) AND batch_id IS NULL
+		ORDER BY created_at ASC, id ASC
+		LIMIT ?
+	`
+	return r.list(ctx, query, bucketID, bucketID, limit)
+}
+
+// Delete removes a staged upload.
+func (r *stagedObjectRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM staged_objects WHERE id = ?`, id.String())
+	if err != nil {
+		return fmt.Errorf("failed to delete staged object: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return domain.ErrStagedObjectNotFound
+	}
+
+	return nil
+}
+
+// ListExpired returns up to limit uploads staged before the cutoff.
+func (r *stagedObjectRepository) ListExpired(ctx context.Context, before time.Time, limit int) ([]*domain.StagedObject, error) {
+	query := `
+		SELECT ` + stagedObjectColumns + `
+		FROM staged_objects
+		WHERE created_at < ? AND batch_id IS NULL
+		ORDER BY created_at ASC, id ASC
+		LIMIT ?
+	`
+	return r.list(ctx, query, before.UTC().Format(time.RFC3339), limit)
+}
+
+// ListByBatch returns the writes staged in a batch. Times are stored to the
+// second, so writes are ordered by rowid, which follows insertion.
+func (r *stagedObjectRepository) ListByBatch(ctx context.Context, batchID uuid.UUID) ([]*domain.StagedObject, error) {
+	query := `
+		SELECT ` + stagedObjectColumns + `
+		FROM staged_objects
+		WHERE batch_id = ?
+		ORDER BY rowid ASC
+	`
+	return r.list(ctx, query, batchID.String())
+}
+
+// CountByBatch returns the number of writes staged in a batch.
+func (r *stagedObjectRepository) CountByBatch(ctx context.Context, batchID uuid.UUID) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM staged_objects WHERE batch_id = ?`, batchID.String()).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count staged objects: %w", err)
+	}
+	return count, nil
+}
+
+// list runs a staged_objects query and scans every row.
+func (r *stagedObjectRepository) list(ctx context.Context, query string, args ...interface{}) ([]*domain.StagedObject, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list staged objects: %w", err)
+	}
+	defer rows.Close()
+
+	var staged []*domain.StagedObject
+	for rows.Next() {
+		s, err := scanStagedObject(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan staged object: %w", err)
+		}
+		staged = append(staged, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating staged objects: %w", err)
+	}
+
+	return staged, nil
+}
+
+// scanStagedObject scans the stagedObjectColumns of one row.
+func scanStagedObject(row interface{ Scan(dest ...any) error }) (*domain.StagedObject, error) {
+	staged := &domain.StagedObject{}
+	var id, metadataJSON, createdAt string
+	var partSizesJSON, batchID sql.NullString
+
+	err := row.Scan(
+		&id,
+		&staged.BucketID,
+		&staged.Key,
+		&staged.ContentHash,
+		&staged.Size,
+		&staged.ContentType,
+		&staged.ETag,
+		&staged.StorageClass,
+		&staged.ACL,
+		&staged.ChecksumAlgorithm,
+		&staged.Checksum,
+		&metadataJSON,
+		&partSizesJSON,
+		&batchID,
+		&staged.EventType,
+		&createdAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	staged.ID, err = uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid staging ID %q: %w", id, err)
+	}
+	if batchID.Valid {
+		id, err := uuid.Parse(batchID.String)
+		if err != nil {
+			return nil, fmt.Errorf("invalid batch ID %q: %w", batchID.String, err)
+		}
+		staged.BatchID = &id
+	}
+	if metadataJSON != "" {
+		json.Unmarshal([]byte(metadataJSON), &staged.Metadata)
+	}
+	if partSizesJSON.Valid && partSizesJSON.String != "" {
+		json.Unmarshal([]byte(partSizesJSON.String), &staged.PartSizes)
+	}
+	staged.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+
+	return staged, nil
+}
+
+// Ensure stagedObjectRepository implements repository.StagedObjectRepository
+var _ repository.StagedObjectRepository = (*stagedObjectRepository)(nil)
@@ -0,0 +1,190 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// tenantRepository implements repository.TenantRepository for SQLite.
+type tenantRepository struct {
+	db *DB
+}
+
+// NewTenantRepository creates a new SQLite tenant repository.
+func NewTenantRepository(db *DB) repository.TenantRepository {
+	return &tenantRepository{db: db}
+}
+
+// tenantColumns are the columns scanned by scanTenant.
+const tenantColumns = `id, name, max_buckets, max_storage_bytes, requests_per_second, burst_size, created_at, updated_at`
+
+// Create creates a new tenant.
+func (r *tenantRepository) Create(ctx context.Context, tenant *domain.Tenant) error {
+	query := `
+		INSERT INTO tenants (name, max_buckets, max_storage_bytes, requests_per_second, burst_size, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		tenant.Name,
+		tenant.Limits.MaxBuckets,
+		tenant.Limits.MaxStorageBytes,
+		tenant.Limits.RequestsPerSecond,
+		tenant.Limits.BurstSize,
+		tenant.CreatedAt.UTC().Format(time.RFC3339),
+		tenant.UpdatedAt.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return domain.ErrTenantAlreadyExists
+		}
+		return fmt.Errorf("failed to create tenant: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert ID: %w", err)
+	}
+	tenant.ID = id
+
+	return nil
+}
+
+// GetByID returns a tenant.
+func (r *tenantRepository) GetByID(ctx context.Context, id int64) (*domain.Tenant, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+tenantColumns+` FROM tenants WHERE id = ?`, id)
+	return scanTenant(row)
+}
+
+// GetByName returns a tenant.
+func (r *tenantRepository) GetByName(ctx context.Context, name string) (*domain.Tenant, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+tenantColumns+` FROM tenants WHERE name = ?`, name)
+	return scanTenant(row)
+}
+
+// List returns all tenants in name order.
+func (r *tenantRepository) List(ctx context.Context) ([]*domain.Tenant, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+tenantColumns+` FROM tenants ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tenants: %w", err)
+	}
+	defer rows.Close()
+
+	var tenants []*domain.Tenant
+	for rows.Next() {
+		tenant, err := scanTenant(rows)
+		if err != nil {
+			return nil, err
+		}
+		tenants = append(tenants, tenant)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tenants: %w", err)
+	}
+	return tenants, nil
+}
+
+// UpdateLimits replaces the limits of a tenant.
+func (r *tenantRepository) UpdateLimits(ctx context.Context, id int64, limits domain.TenantLimits) error {
+	query := `
+		UPDATE tenants
+		SET max_buckets = ?, max_storage_bytes = ?, requests_per_second = ?, burst_size = ?, updated_at = ?
+		WHERE id = ?
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		limits.MaxBuckets,
+		limits.MaxStorageBytes,
+		limits.RequestsPerSecond,
+		limits.BurstSize,
+		time.Now().UTC().Format(time.RFC3339),
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update tenant limits: %w", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return domain.ErrTenantNotFound
+	}
+	return nil
+}
+
+// Delete deletes a tenant.
+func (r *tenantRepository) Delete(ctx context.Context, id int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM tenants WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete tenant: %w", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return domain.ErrTenantNotFound
+	}
+	return nil
+}
+
+// Usage returns what a tenant currently uses of its quotas.
+func (r *tenantRepository) Usage(ctx context.Context, id int64) (*domain.TenantUsage, error) {
+	usage := &domain.TenantUsage{}
+	err := r.db.QueryRowContext(ctx, `
+		SELECT (SELECT COUNT(*) FROM users WHERE tenant_id = ?),
+		       (SELECT COUNT(*) FROM buckets WHERE tenant_id = ?),
+		       (SELECT COALESCE(SUM(o.size), 0)
+		        FROM objects o
+		        JOIN buckets b ON b.id = o.bucket_id
+		        WHERE b.tenant_id = ? AND o.is_delete_marker = 0 AND o.deleted_at IS NULL)
+	`, id, id, id).Scan(&usage.Users, &usage.Buckets, &usage.StorageBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant usage: %w", err)
+	}
+	return usage, nil
+}
+
+// MoveUser moves a user and the buckets they own to another tenant.
+func (r *tenantRepository) MoveUser(ctx context.Context, userID, tenantID int64) error {
+	return r.db.WithTx(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, `UPDATE users SET tenant_id = ? WHERE id = ?`, tenantID, userID)
+		if err != nil {
+			return fmt.Errorf("failed to move user: %w", err)
+		}
+		if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+			return domain.ErrUserNotFound
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE buckets SET tenant_id = ? WHERE owner_id = ?`, tenantID, userID); err != nil {
+			return fmt.Errorf("failed to move buckets: %w", err)
+		}
+		return nil
+	})
+}
+
+// scanTenant scans the tenantColumns of a row.
+func scanTenant(row interface{ Scan(dest ...any) error }) (*domain.Tenant, error) {
+	tenant := &domain.Tenant{}
+	var createdAt, updatedAt string
+	err := row.Scan(
+		&tenant.ID,
+		&tenant.Name,
+		&tenant.Limits.MaxBuckets,
+		&tenant.Limits.MaxStorageBytes,
+		&tenant.Limits.RequestsPerSecond,
+		&tenant.Limits.BurstSize,
+		&createdAt,
+		&updatedAt,
+	)
+	if err != nil {
+		if isNoRows(err) {
+			return nil, domain.ErrTenantNotFound
+		}
+		return nil, fmt.Errorf("failed to scan tenant: %w", err)
+	}
+	tenant.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	tenant.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
+	return tenant, nil
+}
+
+// Ensure tenantRepository implements repository.TenantRepository
+var _ repository.TenantRepository = (*tenantRepository)(nil)
@@ -0,0 +1,60 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// querier is the subset of sql.DB and sql.Tx used by repositories.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// txKey is the context key for the active transaction.
+type txKey struct{}
+
+// txFromContext returns the transaction stored in ctx, if any.
+func txFromContext(ctx context.Context) (*sql.Tx, bool) {
+	tx, ok := ctx.Value(txKey{}).(*sql.Tx)
+	return tx, ok
+}
+
+// conn returns the transaction bound to ctx, or the database if there is none.
+func (db *DB) conn(ctx context.Context) querier {
+	if tx, ok := txFromContext(ctx); ok {
+		return tx
+	}
+	return db.db
+}
+
+// txManager implements repository.TxManager for SQLite.
+type txManager struct {
+	db *DB
+}
+
+// NewTxManager creates a new SQLite transaction manager.
+func NewTxManager(db *DB) repository.TxManager {
+	return &txManager{db: db}
+}
+
+// WithTx executes fn within a transaction.
+func (m *txManager) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return m.WithTxOptions(ctx, repository.TxOptions{}, fn)
+}
+
+// WithTxOptions executes fn within a transaction with the given options.
+// SQLite transactions are always serializable, so IsolationLevel is ignored.
+// If ctx already carries a transaction, fn joins it instead of starting a new one.
+func (m *txManager) WithTxOptions(ctx context.Context, opts repository.TxOptions, fn func(ctx context.Context) error) error {
+	if _, ok := txFromContext(ctx); ok {
+		return fn(ctx)
+	}
+
+	return m.db.withTxOptions(ctx, &sql.TxOptions{ReadOnly: opts.ReadOnly}, func(tx *sql.Tx) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
@@ -0,0 +1,155 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// usageRepository implements repository.UsageRepository for SQLite.
+type usageRepository struct {
+	db *DB
+}
+
+// NewUsageRepository creates a new SQLite usage repository.
+func NewUsageRepository(db *DB) repository.UsageRepository {
+	return &usageRepository{db: db}
+}
+
+// AddTraffic adds traffic counters to the hourly usage rows.
+func (r *usageRepository) AddTraffic(ctx context.Context, records []*domain.UsageRecord) error {
+	query := `
+		INSERT INTO usage_hourly (hour, bucket_name, access_key_id, bytes_in, bytes_out, requests)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (hour, bucket_name, access_key_id) DO UPDATE SET
+			bytes_in = bytes_in + excluded.bytes_in,
+			bytes_out = bytes_out + excluded.bytes_out,
+			requests = requests + excluded.requests
+	`
+
+	for _, rec := range records {
+		_, err := r.db.ExecContext(ctx, query,
+			domain.UsageHour(rec.Hour).Format(time.RFC3339),
+			rec.BucketName,
+			rec.AccessKeyID,
+			rec.BytesIn,
+			rec.BytesOut,
+			rec.Requests,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to add usage traffic: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// RecordStorage snapshots the logical bytes stored in every bucket.
+func (r *usageRepository) RecordStorage(ctx context.Context, hour time.Time) error {
+	// "WHERE 1" resolves the parsing ambiguity between a join ON clause and
+	// the upsert ON CONFLICT clause.
+	query := `
+		INSERT INTO usage_hourly (hour, bucket_name, access_key_id, bytes_stored)
+		SELECT ?, b.name, '', COALESCE(SUM(o.size), 0)
+		FROM buckets b
+		LEFT JOIN objects o ON o.bucket_id = b.id AND o.is_delete_marker = 0
+		WHERE 1
+		GROUP BY b.name
+		ON CONFLICT (hour, bucket_name, access_key_id) DO UPDATE SET
+			bytes_stored = excluded.bytes_stored
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, domain.UsageHour(hour).Format(time.RFC3339)); err != nil {
+		return fmt.Errorf("failed to record storage usage: %w", err)
+	}
+
+	return nil
+}
+
+// List returns hourly usage rows in [from, to).
+func (r *usageRepository) List(ctx context.Context, from, to time.Time, filter repository.UsageFilter) ([]*domain.UsageRecord, error) {
+	query := `
+		SELECT hour, bucket_name, access_key_id, bytes_in, bytes_out, requests, bytes_stored
+		FROM usage_hourly
+		WHERE hour >= ? AND hour < ?
+			AND (? = '' OR bucket_name = ?)
+			AND (? = '' OR access_key_id = ?)
+		ORDER BY hour, bucket_name, access_key_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query,
+		from.UTC().Format(time.RFC3339),
+		to.UTC().Format(time.RFC3339),
+		filter.BucketName, filter.BucketName,
+		filter.AccessKeyID, filter.AccessKeyID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list usage: %w", err)
+	}
+	defer rows.Close()
+
+	var records []*domain.UsageRecord
+	for rows.Next() {
+		rec := &domain.UsageRecord{}
+		var hour string
+		err := rows.Scan(
+			&hour,
+			&rec.BucketName,
+			&rec.AccessKeyID,
+			&rec.BytesIn,
+			&rec.BytesOut,
+			&rec.Requests,
+			&rec.BytesStored,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan usage: %w", err)
+		}
+		rec.Hour, _ = time.Parse(time.RFC3339, hour)
+		records = append(records, rec)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating usage: %w", err)
+	}
+
+	return records, nil
+}
+
+// ListStorage returns the total bytes stored at each snapshot in [from, to).
+// Hours without a snapshot, or with nothing stored, are left out.
+func (r *usageRepository) ListStorage(ctx context.Context, from, to time.Time) ([]*domain.StorageSample, error) {
+	query := `
+		SELECT hour, SUM(bytes_stored)
+		FROM usage_hourly
+		WHERE hour >= ? AND hour < ? AND access_key_id = ''
+		GROUP BY hour
+		HAVING SUM(bytes_stored) > 0
+		ORDER BY hour
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, from.UTC().Format(time.RFC3339), to.UTC().Format(time.RFC3339))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list storage usage: %w", err)
+	}
+	defer rows.Close()
+
+	var samples []*domain.StorageSample
+	for rows.Next() {
+		sample := &domain.StorageSample{}
+		var hour string
+		if err := rows.Scan(&hour, &sample.BytesStored); err != nil {
+			return nil, fmt.Errorf("failed to scan storage usage: %w", err)
+		}
+		sample.Hour, _ = time.Parse(time.RFC3339, hour)
+		samples = append(samples, sample)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating storage usage: %w", err)
+	}
+
+	return samples, nil
+}
@@ -23,16 +23,19 @@ func NewUserRepository(db *DB) repository.UserRepository {
 // Create creates a new user.
 func (r *userRepository) Create(ctx context.Context, user *domain.User) error {
 	query := `
-		INSERT INTO users (username, email, password_hash, is_active, is_admin, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO users (tenant_id, username, email, password_hash, is_active, is_admin, role, must_change_password, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	result, err := r.db.ExecContext(ctx, query,
+		user.TenantID,
 		user.Username,
 		user.Email,
 		user.PasswordHash,
 		boolToInt(user.IsActive),
-		boolToInt(user.IsAdmin),
+		boolToInt(user.IsAdmin()),
+		user.Role,
+		boolToInt(user.MustChangePassword),
 		user.CreatedAt.Format(time.RFC3339),
 		user.UpdatedAt.Format(time.RFC3339),
 	)
@@ -56,22 +59,24 @@ func (r *userRepository) Create(ctx context.Context, user *domain.User) error {
 // GetByID retrieves a user by ID.
 func (r *userRepository) GetByID(ctx context.Context, id int64) (*domain.User, error) {
 	query := `
-		SELECT id, username, email, password_hash, is_active, is_admin, created_at, updated_at
+		SELECT id, tenant_id, username, email, password_hash, is_active, role, must_change_password, created_at, updated_at
 		FROM users
 		WHERE id = ?
 	`
 
 	user := &domain.User{}
-	var isActive, isAdmin int
+	var isActive, mustChangePassword int
 	var createdAt, updatedAt string
 
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&user.ID,
+		&user.TenantID,
 		&user.Username,
 		&user.Email,
 		&user.PasswordHash,
 		&isActive,
-		&isAdmin,
+		&user.Role,
+		&mustChangePassword,
 		&createdAt,
 		&updatedAt,
 	)
@@ -84,7 +89,7 @@ func (r *userRepository) GetByID(ctx context.Context, id int64) (*domain.User, e
 	}
 
 	user.IsActive = isActive != 0
-	user.IsAdmin = isAdmin != 0
+	user.MustChangePassword = mustChangePassword != 0
 	user.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
 	user.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
 
@@ -94,22 +99,24 @@ func (r *userRepository) GetByID(ctx context.Context, id int64) (*domain.User, e
 // GetByUsername retrieves a user by username.
 func (r *userRepository) GetByUsername(ctx context.Context, username string) (*domain.User, error) {
 	query := `
-		SELECT id, username, email, password_hash, is_active, is_admin, created_at, updated_at
+		SELECT id, tenant_id, username, email, password_hash, is_active, role, must_change_password, created_at, updated_at
 		FROM users
 		WHERE username = ?
 	`
 
 	user := &domain.User{}
-	var isActive, isAdmin int
+	var isActive, mustChangePassword int
 	var createdAt, updatedAt string
 
 	err := r.db.QueryRowContext(ctx, query, username).Scan(
 		&user.ID,
+		&user.TenantID,
 		&user.Username,
 		&user.Email,
 		&user.PasswordHash,
 		&isActive,
-		&isAdmin,
+		&user.Role,
+		&mustChangePassword,
 		&createdAt,
 		&updatedAt,
 	)
@@ -122,7 +129,7 @@ func (r *userRepository) GetByUsername(ctx context.Context, username string) (*d
 	}
 
 	user.IsActive = isActive != 0
-	user.IsAdmin = isAdmin != 0
+	user.MustChangePassword = mustChangePassword != 0
 	user.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
 	user.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
 
@@ -132,22 +139,24 @@ func (r *userRepository) GetByUsername(ctx context.Context, username string) (*d
 // GetByEmail retrieves a user by email.
 func (r *userRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
 	query := `
-		SELECT id, username, email, password_hash, is_active, is_admin, created_at, updated_at
+		SELECT id, tenant_id, username, email, password_hash, is_active, role, must_change_password, created_at, updated_at
 		FROM users
 		WHERE email = ?
 	`
 
 	user := &domain.User{}
-	var isActive, isAdmin int
+	var isActive, mustChangePassword int
 	var createdAt, updatedAt string
 
 	err := r.db.QueryRowContext(ctx, query, email).Scan(
 		&user.ID,
+		&user.TenantID,
 		&user.Username,
 		&user.Email,
 		&user.PasswordHash,
 		&isActive,
-		&isAdmin,
+		&user.Role,
+		&mustChangePassword,
 		&createdAt,
 		&updatedAt,
 	)
@@ -160,7 +169,7 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*domain.
 	}
 
 	user.IsActive = isActive != 0
-	user.IsAdmin = isAdmin != 0
+	user.MustChangePassword = mustChangePassword != 0
 	user.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
 	user.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
 
@@ -173,7 +182,7 @@ func (r *userRepository) Update(ctx context.Context, user *domain.User) error {
 
 	query := `
 		UPDATE users
-		SET username = ?, email = ?, password_hash = ?, is_active = ?, is_admin = ?, updated_at = ?
+		SET username = ?, email = ?, password_hash = ?, is_active = ?, is_admin = ?, role = ?, must_change_password = ?, updated_at = ?
 		WHERE id = ?
 	`
 
@@ -182,7 +191,9 @@ func (r *userRepository) Update(ctx context.Context, user *domain.User) error {
 		user.Email,
 		user.PasswordHash,
 		boolToInt(user.IsActive),
-		boolToInt(user.IsAdmin),
+		boolToInt(user.IsAdmin()),
+		user.Role,
+		boolToInt(user.MustChangePassword),
 		user.UpdatedAt.Format(time.RFC3339),
 		user.ID,
 	)
@@ -221,20 +232,31 @@ func (r *userRepository) Delete(ctx context.Context, id int64) error {
 
 // List returns all users with pagination.
 func (r *userRepository) List(ctx context.Context, opts repository.ListOptions) (*repository.ListResult[domain.User], error) {
-	countQuery := `SELECT COUNT(*) FROM users`
+	return r.list(ctx, 0, opts)
+}
+
+// ListByTenant returns the users of a tenant with pagination.
+func (r *userRepository) ListByTenant(ctx context.Context, tenantID int64, opts repository.ListOptions) (*repository.ListResult[domain.User], error) {
+	return r.list(ctx, tenantID, opts)
+}
+
+// list returns the users of a tenant, or of all tenants if tenantID is 0.
+func (r *userRepository) list(ctx context.Context, tenantID int64, opts repository.ListOptions) (*repository.ListResult[domain.User], error) {
+	countQuery := `SELECT COUNT(*) FROM users WHERE ? = 0 OR tenant_id = ?`
 	var total int64
-	if err := r.db.QueryRowContext(ctx, countQuery).Scan(&total); err != nil {
+	if err := r.db.QueryRowContext(ctx, countQuery, tenantID, tenantID).Scan(&total); err != nil {
 		return nil, fmt.Errorf("failed to count users: %w", err)
 	}
 
 	query := `
-		SELECT id, username, email, password_hash, is_active, is_admin, created_at, updated_at
+		SELECT id, tenant_id, username, email, password_hash, is_active, role, must_change_password, created_at, updated_at
 		FROM users
+		WHERE ? = 0 OR tenant_id = ?
 		ORDER BY created_at DESC
 		LIMIT ? OFFSET ?
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, opts.Limit, opts.Offset)
+	rows, err := r.db.QueryContext(ctx, query, tenantID, tenantID, opts.Limit, opts.Offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list users: %w", err)
 	}
@@ -243,16 +265,18 @@ func (r *userRepository) List(ctx context.Context, opts repository.ListOptions)
 	var users []*domain.User
 	for rows.Next() {
 		user := &domain.User{}
-		var isActive, isAdmin int
+		var isActive, mustChangePassword int
 		var createdAt, updatedAt string
 
 		err := rows.Scan(
 			&user.ID,
+			&user.TenantID,
 			&user.Username,
 			&user.Email,
 			&user.PasswordHash,
 			&isActive,
-			&isAdmin,
+			&user.Role,
+			&mustChangePassword,
 			&createdAt,
 			&updatedAt,
 		)
@@ -261,7 +285,7 @@ func (r *userRepository) List(ctx context.Context, opts repository.ListOptions)
 		}
 
 		user.IsActive = isActive != 0
-		user.IsAdmin = isAdmin != 0
+		user.MustChangePassword = mustChangePassword != 0
 		user.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
 		user.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
 
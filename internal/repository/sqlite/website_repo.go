@@ -0,0 +1,95 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// websiteRepository implements repository.WebsiteRepository for SQLite.
+type websiteRepository struct {
+	db *DB
+}
+
+// NewWebsiteRepository creates a new SQLite website repository.
+func NewWebsiteRepository(db *DB) repository.WebsiteRepository {
+	return &websiteRepository{db: db}
+}
+
+// PutConfig creates or replaces the configuration of a bucket.
+func (r *websiteRepository) PutConfig(ctx context.Context, config *domain.WebsiteConfig) error {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to encode website config: %w", err)
+	}
+
+	query := `
+		INSERT INTO bucket_websites (bucket_id, config, created_at, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (bucket_id) DO UPDATE SET
+			config = excluded.config,
+			updated_at = excluded.updated_at
+		RETURNING created_at
+	`
+
+	var createdAt string
+	err = r.db.QueryRowContext(ctx, query,
+		config.BucketID,
+		string(data),
+		config.CreatedAt.UTC().Format(time.RFC3339),
+		config.UpdatedAt.UTC().Format(time.RFC3339),
+	).Scan(&createdAt)
+	if err != nil {
+		return fmt.Errorf("failed to put website config: %w", err)
+	}
+	config.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+
+	return nil
+}
+
+// GetConfig returns the configuration of a bucket.
+func (r *websiteRepository) GetConfig(ctx context.Context, bucketID int64) (*domain.WebsiteConfig, error) {
+	query := `
+		SELECT config, created_at, updated_at
+		FROM bucket_websites
+		WHERE bucket_id = ?
+	`
+
+	var data, createdAt, updatedAt string
+	err := r.db.QueryRowContext(ctx, query, bucketID).Scan(&data, &createdAt, &updatedAt)
+	if err != nil {
+		if isNoRows(err) {
+			return nil, domain.ErrWebsiteConfigNotFound
+		}
+		return nil, fmt.Errorf("failed to get website config: %w", err)
+	}
+
+	config := &domain.WebsiteConfig{}
+	if err := json.Unmarshal([]byte(data), config); err != nil {
+		return nil, fmt.Errorf("failed to decode website config: %w", err)
+	}
+	config.BucketID = bucketID
+	config.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	config.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
+
+	return config, nil
+}
+
+// DeleteConfig removes the configuration of a bucket.
+func (r *websiteRepository) DeleteConfig(ctx context.Context, bucketID int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM bucket_websites WHERE bucket_id = ?`, bucketID)
+	if err != nil {
+		return fmt.Errorf("failed to delete website config: %w", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return domain.ErrWebsiteConfigNotFound
+	}
+	return nil
+}
+
+// Ensure websiteRepository implements repository.WebsiteRepository
+var _ repository.WebsiteRepository = (*websiteRepository)(nil)
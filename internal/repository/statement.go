@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// repositoryPkg is the import path of this package; the database drivers
+// live below it.
+var repositoryPkg = reflect.TypeOf(Repositories{}).PkgPath()
+
+// modulePrefix is the import path prefix of this module's packages.
+var modulePrefix = strings.TrimSuffix(repositoryPkg, "internal/repository")
+
+// StatementName names the code that runs a query on the calling goroutine,
+// for logs: the innermost repository method, such as
+// "postgres.objectRepository.GetByKey", or else the innermost caller of the
+// database drivers, such as "service.GarbageCollector.runOnce". It walks the
+// stack, so call it only for the queries that are logged.
+func StatementName() string {
+	pcs := make([]uintptr, 64)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+
+	fallback := ""
+	for {
+		frame, more := frames.Next()
+		switch {
+		case strings.Contains(frame.Function, "Repository)."):
+			return shortFuncName(frame.Function)
+		case fallback == "" &&
+			strings.HasPrefix(frame.Function, modulePrefix) &&
+			!strings.HasPrefix(frame.Function, repositoryPkg):
+			fallback = shortFuncName(frame.Function)
+		}
+		if !more {
+			return fallback
+		}
+	}
+}
+
+// shortFuncName shortens a function name like
+// "example.com/m/internal/repository/postgres.(*objectRepository).GetByKey.func1"
+// to "postgres.objectRepository.GetByKey".
+func shortFuncName(name string) string {
+	name = name[strings.LastIndex(name, "/")+1:]
+	name = strings.NewReplacer("(*", "", ")", "").Replace(name)
+
+	parts := strings.Split(name, ".")
+	for i := 1; i < len(parts); i++ {
+		if isClosureName(parts[i]) {
+			parts = parts[:i]
+			break
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// isClosureName reports whether part names a closure, like "func1" or
+// "gowrap2".
+func isClosureName(part string) bool {
+	for _, prefix := range []string{"func", "gowrap"} {
+		if rest, ok := strings.CutPrefix(part, prefix); ok && rest != "" && rest[0] >= '0' && rest[0] <= '9' {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,346 @@
+// Package service provides business logic services for Alexander Storage.
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// AuditSink receives audit events in addition to the database, e.g. to
+// stream them to a file or syslog.
+type AuditSink interface {
+	// Write delivers events that were committed to the audit log.
+	Write(events []*domain.AuditEvent) error
+
+	// Close releases the sink's resources.
+	Close() error
+}
+
+// AuditConfig contains audit log configuration.
+type AuditConfig struct {
+	// FlushInterval is how often queued events are written. Default: 1 second.
+	FlushInterval time.Duration
+
+	// BatchSize triggers an early flush once this many events are queued.
+	// Default: 500.
+	BatchSize int
+
+	// Retention is how long events of each category are kept. Categories
+	// without a positive retention are kept forever.
+	Retention map[domain.AuditCategory]time.Duration
+
+	// PruneInterval is how often expired events are deleted. Default: 1 hour.
+	PruneInterval time.Duration
+
+	// PruneBatchSize is the number of events deleted per transaction.
+	// Default: 1000.
+	PruneBatchSize int
+}
+
+// AuditService writes the audit log. API requests are queued by Record and
+// written in batches, so the audit log never adds a database round trip to
+// a request; administrative actions are written synchronously by Log.
+// Events are never dropped: if the database is unavailable they stay
+// queued and are retried on the next flush.
+type AuditService struct {
+	auditRepo repository.AuditRepository
+	txManager repository.TxManager
+	sinks     []AuditSink
+	logger    zerolog.Logger
+	config    AuditConfig
+
+	pendingMu sync.Mutex
+	pending   []*domain.AuditEvent
+
+	// flushMu serializes flushes so events are written in order.
+	flushMu sync.Mutex
+
+	// Control
+	mu        sync.Mutex
+	running   bool
+	stopChan  chan struct{}
+	doneChan  chan struct{}
+	flushChan chan struct{}
+
+	// now is replaceable in tests.
+	now func() time.Time
+}
+
+// NewAuditService creates a new audit service. Sinks are closed by Stop.
+func NewAuditService(
+	auditRepo repository.AuditRepository,
+	txManager repository.TxManager,
+	sinks []AuditSink,
+	logger zerolog.Logger,
+	config AuditConfig,
+) *AuditService {
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = time.Second
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 500
+	}
+	if config.PruneInterval <= 0 {
+		config.PruneInterval = time.Hour
+	}
+	if config.PruneBatchSize <= 0 {
+		config.PruneBatchSize = 1000
+	}
+
+	return &AuditService{
+		auditRepo: auditRepo,
+		txManager: txManager,
+		sinks:     sinks,
+		logger:    logger.With().Str("service", "audit").Logger(),
+		config:    config,
+		stopChan:  make(chan struct{}),
+		doneChan:  make(chan struct{}),
+		flushChan: make(chan struct{}, 1),
+		now:       time.Now,
+	}
+}
+
+// Record queues an event, classifying it unless the caller already did. It
+// implements middleware.AuditRecorder and never blocks on the database.
+func (s *AuditService) Record(event *domain.AuditEvent) {
+	if event.Time.IsZero() {
+		event.Time = s.now().UTC()
+	}
+	if event.Category == "" || event.Severity == "" {
+		category, severity := domain.ClassifyAudit(event.Operation, event.Result)
+		if event.Category == "" {
+			event.Category = category
+		}
+		if event.Severity == "" {
+			event.Severity = severity
+		}
+	}
+
+	s.pendingMu.Lock()
+	s.pending = append(s.pending, event)
+	full := len(s.pending) >= s.config.BatchSize
+	s.pendingMu.Unlock()
+
+	if full {
+		select {
+		case s.flushChan <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Log writes an event immediately, together with any queued events.
+func (s *AuditService) Log(ctx context.Context, event *domain.AuditEvent) error {
+	s.Record(event)
+	return s.Flush(ctx)
+}
+
+// Start starts the background flush loop.
+func (s *AuditService) Start() {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = true
+	s.mu.Unlock()
+
+	s.logger.Info().
+		Dur("flush_interval", s.config.FlushInterval).
+		Int("sinks", len(s.sinks)).
+		Msg("Starting audit log")
+
+	go s.runLoop()
+}
+
+// Stop stops the flush loop, writes any queued events and closes the sinks.
+func (s *AuditService) Stop() {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = false
+	s.mu.Unlock()
+
+	close(s.stopChan)
+	<-s.doneChan
+
+	if err := s.Flush(context.Background()); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to flush audit log on shutdown")
+	}
+	for _, sink := range s.sinks {
+		if err := sink.Close(); err != nil {
+			s.logger.Warn().Err(err).Msg("Failed to close audit sink")
+		}
+	}
+
+	s.logger.Info().Msg("Audit log stopped")
+}
+
+// runLoop is the main flush loop. It also prunes expired events.
+func (s *AuditService) runLoop() {
+	defer close(s.doneChan)
+
+	ticker := time.NewTicker(s.config.FlushInterval)
+	defer ticker.Stop()
+
+	pruneTicker := time.NewTicker(s.config.PruneInterval)
+	defer pruneTicker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.flushChan:
+		case <-pruneTicker.C:
+			if len(s.config.Retention) > 0 {
+				s.prune()
+			}
+			continue
+		case <-s.stopChan:
+			return
+		}
+		if err := s.Flush(context.Background()); err != nil {
+			s.logger.Error().Err(err).Msg("Failed to flush audit log")
+		}
+	}
+}
+
+// prune runs Prune and logs the outcome.
+func (s *AuditService) prune() {
+	deleted, err := s.Prune(context.Background())
+	if err != nil {
+		s.logger.Error().Err(err).Int64("deleted", deleted).Msg("Failed to prune audit log")
+		return
+	}
+	if deleted > 0 {
+		s.logger.Info().Int64("deleted", deleted).Msg("Pruned expired audit events")
+	}
+}
+
+// Prune deletes the events older than the retention of their category, in
+// batches, and returns the number deleted.
+func (s *AuditService) Prune(ctx context.Context) (int64, error) {
+	var total int64
+	for _, category := range domain.AuditCategories {
+		retention := s.config.Retention[category]
+		if retention <= 0 {
+			continue
+		}
+		before := s.now().Add(-retention)
+
+		for {
+			deleted, err := s.auditRepo.Prune(ctx, category, before, s.config.PruneBatchSize)
+			if err != nil {
+				return total, fmt.Errorf("%w: %v", ErrInternalError, err)
+			}
+			total += deleted
+			if deleted < int64(s.config.PruneBatchSize) {
+				break
+			}
+		}
+	}
+	return total, nil
+}
+
+// Flush writes queued events to the database in a single transaction, then
+// to the sinks. On a database failure the events are kept and retried on
+// the next flush; sink failures are logged and not retried.
+func (s *AuditService) Flush(ctx context.Context) error {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	s.pendingMu.Lock()
+	batch := s.pending
+	s.pending = nil
+	s.pendingMu.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+
+	err := s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		return s.auditRepo.Append(ctx, batch)
+	})
+	if err != nil {
+		// Requeue ahead of events recorded in the meantime
+		s.pendingMu.Lock()
+		s.pending = append(batch, s.pending...)
+		s.pendingMu.Unlock()
+		return fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	for _, sink := range s.sinks {
+		if err := sink.Write(batch); err != nil {
+			s.logger.Error().Ctx(ctx).Err(err).Int("events", len(batch)).Msg("Failed to write audit events to sink")
+		}
+	}
+
+	return nil
+}
+
+// AuditQueryInput selects events from the audit log.
+type AuditQueryInput struct {
+	// Since and Until restrict events to Since <= time < Until. Zero values
+	// are open ended.
+	Since time.Time
+	Until time.Time
+
+	UserID      int64
+	AccessKeyID string
+	BucketName  string
+	Operation   string
+	Result      domain.AuditResult
+	Category    domain.AuditCategory
+	Severity    domain.AuditSeverity
+
+	// Limit caps the number of events. Default: 1000.
+	Limit int
+}
+
+// Query returns audit events matching the input, oldest first.
+func (s *AuditService) Query(ctx context.Context, input AuditQueryInput) ([]*domain.AuditEvent, error) {
+	switch input.Result {
+	case "", domain.AuditResultSuccess, domain.AuditResultDenied, domain.AuditResultFailure:
+	default:
+		return nil, fmt.Errorf("invalid audit result %q: must be success, denied or failure", input.Result)
+	}
+	if input.Category != "" {
+		if _, err := domain.ParseAuditCategory(string(input.Category)); err != nil {
+			return nil, err
+		}
+	}
+	if input.Severity != "" {
+		if _, err := domain.ParseAuditSeverity(string(input.Severity)); err != nil {
+			return nil, err
+		}
+	}
+	if input.Limit <= 0 {
+		input.Limit = 1000
+	}
+
+	events, err := s.auditRepo.List(ctx, repository.AuditFilter{
+		Since:       input.Since,
+		Until:       input.Until,
+		UserID:      input.UserID,
+		AccessKeyID: input.AccessKeyID,
+		BucketName:  input.BucketName,
+		Operation:   input.Operation,
+		Result:      input.Result,
+		Category:    input.Category,
+		Severity:    input.Severity,
+		Limit:       input.Limit,
+	})
+	if err != nil {
+		s.logger.Error().Ctx(ctx).Err(err).Msg("failed to query audit log")
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	return events, nil
+}
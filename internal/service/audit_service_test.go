@@ -0,0 +1,189 @@
+// Package service provides business logic services for Alexander Storage.
+package service
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository/sqlite"
+)
+
+// memoryAuditSink collects the events written to it.
+type memoryAuditSink struct {
+	events []*domain.AuditEvent
+	closed bool
+}
+
+func (s *memoryAuditSink) Write(events []*domain.AuditEvent) error {
+	s.events = append(s.events, events...)
+	return nil
+}
+
+func (s *memoryAuditSink) Close() error {
+	s.closed = true
+	return nil
+}
+
+func newAuditTestService(t *testing.T, sinks ...AuditSink) (*AuditService, *sqlite.DB) {
+	t.Helper()
+	ctx := context.Background()
+
+	db, err := sqlite.NewDB(ctx, sqlite.DefaultConfig(filepath.Join(t.TempDir(), "alexander.db")), zerolog.Nop())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, db.Migrate(ctx))
+
+	return NewAuditService(sqlite.NewAuditRepository(db), sqlite.NewTxManager(db), sinks, zerolog.Nop(), AuditConfig{}), db
+}
+
+func TestAuditService_RecordFlushQuery(t *testing.T) {
+	ctx := context.Background()
+	sink := &memoryAuditSink{}
+	svc, _ := newAuditTestService(t, sink)
+
+	base := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	svc.Record(&domain.AuditEvent{Time: base, UserID: 3, Actor: "alice", AccessKeyID: "AKIA1", Operation: "PutObject",
+		BucketName: "photos", ObjectKey: "cat.jpg", SourceIP: "192.0.2.1", RequestID: "req-1",
+		Result: domain.AuditResultSuccess, StatusCode: 200})
+	svc.Record(&domain.AuditEvent{Time: base.Add(time.Hour), UserID: 4, Actor: "bob", Operation: "DeleteBucket",
+		BucketName: "photos", Result: domain.AuditResultDenied, StatusCode: 403})
+	require.NoError(t, svc.Log(ctx, &domain.AuditEvent{Time: base.Add(2 * time.Hour), Actor: "cli:root",
+		Operation: "user.set-role", Result: domain.AuditResultSuccess, Detail: "user_id=3 role=operator"}))
+
+	assert.Len(t, sink.events, 3)
+
+	all, err := svc.Query(ctx, AuditQueryInput{})
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, "alice", all[0].Actor)
+	assert.Equal(t, "cat.jpg", all[0].ObjectKey)
+	assert.Equal(t, "req-1", all[0].RequestID)
+	assert.True(t, base.Equal(all[0].Time))
+	assert.Less(t, all[0].ID, all[1].ID)
+
+	byUser, err := svc.Query(ctx, AuditQueryInput{UserID: 3})
+	require.NoError(t, err)
+	require.Len(t, byUser, 1)
+	assert.Equal(t, "PutObject", byUser[0].Operation)
+
+	denied, err := svc.Query(ctx, AuditQueryInput{Result: domain.AuditResultDenied})
+	require.NoError(t, err)
+	require.Len(t, denied, 1)
+	assert.Equal(t, "bob", denied[0].Actor)
+
+	since, err := svc.Query(ctx, AuditQueryInput{Since: base.Add(30 * time.Minute), Until: base.Add(90 * time.Minute)})
+	require.NoError(t, err)
+	require.Len(t, since, 1)
+	assert.Equal(t, "DeleteBucket", since[0].Operation)
+
+	_, err = svc.Query(ctx, AuditQueryInput{Result: "maybe"})
+	assert.Error(t, err)
+}
+
+func TestAuditService_AppendOnly(t *testing.T) {
+	ctx := context.Background()
+	svc, db := newAuditTestService(t)
+
+	require.NoError(t, svc.Log(ctx, &domain.AuditEvent{Actor: "alice", Operation: "PutObject", Result: domain.AuditResultSuccess}))
+
+	_, err := db.ExecContext(ctx, "UPDATE audit_log SET actor = 'mallory'")
+	assert.Error(t, err)
+	_, err = db.ExecContext(ctx, "DELETE FROM audit_log")
+	assert.Error(t, err)
+
+	events, err := svc.Query(ctx, AuditQueryInput{})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "alice", events[0].Actor)
+}
+
+func TestAuditService_StopFlushesAndClosesSinks(t *testing.T) {
+	sink := &memoryAuditSink{}
+	svc, _ := newAuditTestService(t, sink)
+	svc.config.FlushInterval = time.Hour
+
+	svc.Start()
+	svc.Record(&domain.AuditEvent{Actor: "alice", Operation: "PutObject", Result: domain.AuditResultSuccess})
+	svc.Stop()
+
+	assert.Len(t, sink.events, 1)
+	assert.True(t, sink.closed)
+
+	events, err := svc.Query(context.Background(), AuditQueryInput{})
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+}
+
+func TestAuditService_ClassifiesAndFiltersSinks(t *testing.T) {
+	ctx := context.Background()
+	all := &memoryAuditSink{}
+	security := &memoryAuditSink{}
+	svc, _ := newAuditTestService(t, all,
+		NewFilteredAuditSink(security, domain.AuditSeverityNotice, []domain.AuditCategory{domain.AuditCategorySecurity}))
+
+	svc.Record(&domain.AuditEvent{Actor: "alice", Operation: "PutObject", Result: domain.AuditResultSuccess})
+	svc.Record(&domain.AuditEvent{Actor: "bob", Operation: "PutObject", Result: domain.AuditResultDenied})
+	svc.Record(&domain.AuditEvent{Actor: "alice", Operation: "PutObjectLegalHold", Result: domain.AuditResultSuccess})
+	require.NoError(t, svc.Log(ctx, &domain.AuditEvent{Actor: "cli:root", Operation: "accesskey.create", Result: domain.AuditResultSuccess}))
+
+	require.Len(t, all.events, 4)
+	assert.Equal(t, domain.AuditCategoryOperational, all.events[0].Category)
+	assert.Equal(t, domain.AuditSeverityInfo, all.events[0].Severity)
+	assert.Equal(t, domain.AuditCategorySecurity, all.events[1].Category)
+	assert.Equal(t, domain.AuditSeverityWarning, all.events[1].Severity)
+	assert.Equal(t, domain.AuditCategoryLegal, all.events[2].Category)
+	assert.Equal(t, domain.AuditSeverityNotice, all.events[2].Severity)
+
+	require.Len(t, security.events, 2)
+	assert.Equal(t, "bob", security.events[0].Actor)
+	assert.Equal(t, "accesskey.create", security.events[1].Operation)
+
+	warnings, err := svc.Query(ctx, AuditQueryInput{Severity: domain.AuditSeverityWarning})
+	require.NoError(t, err)
+	require.Len(t, warnings, 1)
+	assert.Equal(t, domain.AuditCategorySecurity, warnings[0].Category)
+
+	_, err = svc.Query(ctx, AuditQueryInput{Category: "billing"})
+	assert.Error(t, err)
+}
+
+func TestAuditService_PruneByCategory(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newAuditTestService(t)
+	svc.config.PruneBatchSize = 2
+
+	now := time.Date(2024, 6, 30, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+	svc.config.Retention = map[domain.AuditCategory]time.Duration{
+		domain.AuditCategoryOperational: 7 * 24 * time.Hour,
+		domain.AuditCategorySecurity:    90 * 24 * time.Hour,
+	}
+
+	old := now.Add(-30 * 24 * time.Hour)
+	for i := 0; i < 5; i++ {
+		svc.Record(&domain.AuditEvent{Time: old, Actor: "alice", Operation: "PutObject", Result: domain.AuditResultSuccess})
+	}
+	svc.Record(&domain.AuditEvent{Time: now, Actor: "alice", Operation: "PutObject", Result: domain.AuditResultSuccess})
+	svc.Record(&domain.AuditEvent{Time: old, Actor: "cli:root", Operation: "user.create", Result: domain.AuditResultSuccess})
+	svc.Record(&domain.AuditEvent{Time: old, Actor: "alice", Operation: "PutObjectRetention", Result: domain.AuditResultSuccess})
+	require.NoError(t, svc.Flush(ctx))
+
+	deleted, err := svc.Prune(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), deleted)
+
+	// Security events are within their retention, legal ones are kept forever
+	events, err := svc.Query(ctx, AuditQueryInput{})
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	assert.True(t, now.Equal(events[0].Time))
+	assert.Equal(t, "user.create", events[1].Operation)
+	assert.Equal(t, "PutObjectRetention", events[2].Operation)
+}
@@ -0,0 +1,196 @@
+// Package service provides business logic services for Alexander Storage.
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/pkg/syslog"
+)
+
+// FileAuditSink appends audit events to a file as JSON lines.
+type FileAuditSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileAuditSink opens (or creates) the file for appending.
+func NewFileAuditSink(path string) (*FileAuditSink, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log file: %w", err)
+	}
+	return &FileAuditSink{file: file}, nil
+}
+
+// Write appends the events and syncs the file.
+func (s *FileAuditSink) Write(events []*domain.AuditEvent) error {
+	var buf []byte
+	for _, event := range events {
+		line, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		buf = append(append(buf, line...), '\n')
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.file.Write(buf); err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+// Close closes the file.
+func (s *FileAuditSink) Close() error {
+	return s.file.Close()
+}
+
+// SyslogAuditSink sends audit events to syslog, one JSON message per event,
+// at the syslog severity matching the event's.
+type SyslogAuditSink struct {
+	writer *syslog.Writer
+}
+
+// NewSyslogAuditSink creates a sink writing to the configured syslog server.
+func NewSyslogAuditSink(config syslog.Config) (*SyslogAuditSink, error) {
+	writer, err := syslog.New(config)
+	if err != nil {
+		return nil, err
+	}
+	return &SyslogAuditSink{writer: writer}, nil
+}
+
+// Write sends the events.
+func (s *SyslogAuditSink) Write(events []*domain.AuditEvent) error {
+	for _, event := range events {
+		message, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		severity := syslog.SeverityInfo
+		switch event.Severity {
+		case domain.AuditSeverityNotice:
+			severity = syslog.SeverityNotice
+		case domain.AuditSeverityWarning:
+			severity = syslog.SeverityWarning
+		}
+		if err := s.writer.Write(severity, event.Operation, string(message)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the syslog connection.
+func (s *SyslogAuditSink) Close() error {
+	return s.writer.Close()
+}
+
+// WebhookAuditSink posts audit events to an HTTP endpoint as a JSON array,
+// one request per flush. If a secret is set, the body is signed with
+// HMAC-SHA256 in the X-Alexander-Signature header as "sha256=<hex>".
+type WebhookAuditSink struct {
+	url    string
+	secret []byte
+	client *http.Client
+}
+
+// NewWebhookAuditSink creates a sink posting to url.
+func NewWebhookAuditSink(url, secret string, timeout time.Duration) *WebhookAuditSink {
+	return &WebhookAuditSink{
+		url:    url,
+		secret: []byte(secret),
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Write posts the events. Any response other than 2xx is an error.
+func (s *WebhookAuditSink) Write(events []*domain.AuditEvent) error {
+	body, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(s.secret) > 0 {
+		mac := hmac.New(sha256.New, s.secret)
+		mac.Write(body)
+		req.Header.Set("X-Alexander-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("audit webhook: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("audit webhook: unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Close releases idle connections.
+func (s *WebhookAuditSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
+
+// FilteredAuditSink passes on only the events of a minimum severity and,
+// if any are given, of the listed categories.
+type FilteredAuditSink struct {
+	sink        AuditSink
+	minSeverity domain.AuditSeverity
+	categories  map[domain.AuditCategory]bool
+}
+
+// NewFilteredAuditSink wraps sink. An empty minSeverity passes every
+// severity, and no categories pass every category.
+func NewFilteredAuditSink(sink AuditSink, minSeverity domain.AuditSeverity, categories []domain.AuditCategory) *FilteredAuditSink {
+	f := &FilteredAuditSink{sink: sink, minSeverity: minSeverity}
+	if len(categories) > 0 {
+		f.categories = make(map[domain.AuditCategory]bool, len(categories))
+		for _, category := range categories {
+			f.categories[category] = true
+		}
+	}
+	return f
+}
+
+// Write passes the matching events on, if there are any.
+func (f *FilteredAuditSink) Write(events []*domain.AuditEvent) error {
+	matching := make([]*domain.AuditEvent, 0, len(events))
+	for _, event := range events {
+		if f.minSeverity != "" && !event.Severity.AtLeast(f.minSeverity) {
+			continue
+		}
+		if f.categories != nil && !f.categories[event.Category] {
+			continue
+		}
+		matching = append(matching, event)
+	}
+	if len(matching) == 0 {
+		return nil
+	}
+	return f.sink.Write(matching)
+}
+
+// Close closes the wrapped sink.
+func (f *FilteredAuditSink) Close() error {
+	return f.sink.Close()
+}
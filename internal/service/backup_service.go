@@ -0,0 +1,423 @@
+package service
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/pkg/zstd"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+	"github.com/prn-tf/alexander-storage/internal/storage"
+)
+
+// backupFormatVersion is the version of the backup archive layout.
+const backupFormatVersion = 1
+
+// Entries of a backup archive. The archive is a zstd-compressed tar holding
+// the backup description, the blob manifest and the driver's metadata
+// snapshot under metadata/.
+const (
+	backupInfoFile     = "backup.json"
+	backupManifestFile = "blobs.jsonl"
+	backupMetadataDir  = "metadata"
+)
+
+// blobPageSize is the number of blobs listed at a time.
+const blobPageSize = 1000
+
+// BackupService creates and restores backups of the metadata database.
+//
+// Blob content is not part of a backup: it is immutable and addressed by
+// hash, so it is copied with ordinary file or object-store tooling. A
+// backup instead carries a manifest of the blobs its metadata references,
+// and a restore verifies that every referenced blob is present.
+type BackupService struct {
+	snapshotter repository.Snapshotter
+	blobRepo    repository.BlobRepository
+	storage     storage.Backend
+	driver      string
+	logger      zerolog.Logger
+}
+
+// NewBackupService creates a new BackupService. driver is the configured
+// database driver, recorded in backups so they are only restored into the
+// same kind of database.
+func NewBackupService(
+	snapshotter repository.Snapshotter,
+	blobRepo repository.BlobRepository,
+	storage storage.Backend,
+	driver string,
+	logger zerolog.Logger,
+) *BackupService {
+	return &BackupService{
+		snapshotter: snapshotter,
+		blobRepo:    blobRepo,
+		storage:     storage,
+		driver:      driver,
+		logger:      logger.With().Str("service", "backup").Logger(),
+	}
+}
+
+// BackupInfo describes a backup. It is stored in the archive as backup.json.
+type BackupInfo struct {
+	FormatVersion int       `json:"format_version"`
+	Driver        string    `json:"driver"`
+	SchemaVersion int64     `json:"schema_version"`
+	CreatedAt     time.Time `json:"created_at"`
+	Blobs         int64     `json:"blobs"`
+	BlobBytes     int64     `json:"blob_bytes"`
+}
+
+// BackupBlob is an entry of the blob manifest, one JSON object per line.
+type BackupBlob struct {
+	ContentHash string `json:"content_hash"`
+	Size        int64  `json:"size"`
+	StoragePath string `json:"storage_path"`
+	IsEncrypted bool   `json:"is_encrypted,omitempty"`
+}
+
+// BlobVerification is the result of checking that blobs are present.
+type BlobVerification struct {
+	Checked      int64    `json:"checked"`
+	MissingCount int64    `json:"missing_count"`
+	Missing      []string `json:"missing,omitempty"` // The first 100 missing content hashes
+}
+
+// maxReportedMissing bounds the missing hashes listed in a BlobVerification.
+const maxReportedMissing = 100
+
+// Create writes a backup archive of the metadata database to w.
+//
+// The manifest is listed after the snapshot is taken, so it covers every
+// blob the snapshot can reference, except blobs garbage collected in
+// between; it may also list blobs created after the snapshot.
+func (s *BackupService) Create(ctx context.Context, w io.Writer) (*BackupInfo, error) {
+	schemaVersion, err := s.snapshotter.SchemaVersion(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	dir, err := os.MkdirTemp("", "alexander-backup-")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	defer os.RemoveAll(dir)
+
+	metadataDir := filepath.Join(dir, backupMetadataDir)
+	if err := os.Mkdir(metadataDir, 0700); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	info := &BackupInfo{
+		FormatVersion: backupFormatVersion,
+		Driver:        s.driver,
+		SchemaVersion: schemaVersion,
+		CreatedAt:     time.Now().UTC(),
+	}
+	if err := s.snapshotter.Snapshot(ctx, metadataDir); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	if err := s.writeManifest(ctx, filepath.Join(dir, backupManifestFile), info); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	infoJSON, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, backupInfoFile), infoJSON, 0600); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	if err := writeArchive(w, dir); err != nil {
+		return nil, fmt.Errorf("%w: failed to write archive: %v", ErrInternalError, err)
+	}
+
+	s.logger.Info().Ctx(ctx).
+		Str("driver", info.Driver).
+		Int64("schema_version", info.SchemaVersion).
+		Int64("blobs", info.Blobs).
+		Msg("backup created")
+
+	return info, nil
+}
+
+// writeManifest writes the blob manifest and counts its blobs into info.
+func (s *BackupService) writeManifest(ctx context.Context, path string, info *BackupInfo) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	err = s.eachBlob(ctx, func(blob *domain.Blob) error {
+		info.Blobs++
+		info.BlobBytes += blob.Size
+		return enc.Encode(BackupBlob{
+			ContentHash: blob.ContentHash,
+			Size:        blob.Size,
+			StoragePath: blob.StoragePath,
+			IsEncrypted: blob.IsEncrypted,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// Restore replaces the metadata database with the contents of a backup
+// archive. The backup must have been taken with the same database driver at
+// the schema version the database is migrated to.
+//
+// Restore does not check blobs; call VerifyBlobs afterwards.
+func (s *BackupService) Restore(ctx context.Context, r io.Reader) (*BackupInfo, error) {
+	dir, err := os.MkdirTemp("", "alexander-restore-")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := extractArchive(r, dir); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+	}
+
+	infoJSON, err := os.ReadFile(filepath.Join(dir, backupInfoFile))
+	if err != nil {
+		return nil, fmt.Errorf("%w: missing %s", ErrInvalidBackup, backupInfoFile)
+	}
+	var info BackupInfo
+	if err := json.Unmarshal(infoJSON, &info); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+	}
+	if info.FormatVersion != backupFormatVersion {
+		return nil, fmt.Errorf("%w: unsupported format version %d", ErrInvalidBackup, info.FormatVersion)
+	}
+
+	if info.Driver != s.driver {
+		return nil, fmt.Errorf("%w: backup was taken from %s, database is %s", ErrIncompatibleBackup, info.Driver, s.driver)
+	}
+	schemaVersion, err := s.snapshotter.SchemaVersion(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	if info.SchemaVersion != schemaVersion {
+		return nil, fmt.Errorf("%w: backup is at schema version %d, database is at %d",
+			ErrIncompatibleBackup, info.SchemaVersion, schemaVersion)
+	}
+
+	if err := s.snapshotter.Restore(ctx, filepath.Join(dir, backupMetadataDir)); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	s.logger.Info().Ctx(ctx).
+		Str("driver", info.Driver).
+		Int64("schema_version", info.SchemaVersion).
+		Time("backup_created_at", info.CreatedAt).
+		Msg("backup restored")
+
+	return &info, nil
+}
+
+// VerifyBlobs checks that the content of every blob in the metadata
+// database is present in storage. A blob counts as present if any
+// residency's backend has it, since blobs do not record their residency.
+func (s *BackupService) VerifyBlobs(ctx context.Context) (*BlobVerification, error) {
+	contexts := []context.Context{ctx}
+	if router, ok := s.storage.(*storage.ResidencyRouter); ok {
+		for _, residency := range router.Residencies() {
+			contexts = append(contexts, storage.WithResidency(ctx, residency))
+		}
+	}
+
+	result := &BlobVerification{}
+	err := s.eachBlob(ctx, func(blob *domain.Blob) error {
+		result.Checked++
+		for _, c := range contexts {
+			exists, err := s.storage.Exists(c, blob.ContentHash)
+			if err != nil {
+				return fmt.Errorf("failed to check blob %s: %w", blob.ContentHash, err)
+			}
+			if exists {
+				return nil
+			}
+		}
+
+		result.MissingCount++
+		if len(result.Missing) < maxReportedMissing {
+			result.Missing = append(result.Missing, blob.ContentHash)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	if result.MissingCount > 0 {
+		s.logger.Error().Ctx(ctx).
+			Int64("checked", result.Checked).
+			Int64("missing", result.MissingCount).
+			Msg("blobs referenced by metadata are missing from storage")
+	}
+	return result, nil
+}
+
+// eachBlob calls fn for every blob, in content hash order.
+func (s *BackupService) eachBlob(ctx context.Context, fn func(blob *domain.Blob) error) error {
+	// Timestamps are stored with second precision, so include the current second
+	before := time.Now().Add(time.Second)
+	afterHash := ""
+	for {
+		blobs, err := s.blobRepo.ListCreatedBefore(ctx, before, afterHash, blobPageSize)
+		if err != nil {
+			return err
+		}
+		for _, blob := range blobs {
+			if err := fn(blob); err != nil {
+				return err
+			}
+		}
+		if len(blobs) < blobPageSize {
+			return nil
+		}
+		afterHash = blobs[len(blobs)-1].ContentHash
+	}
+}
+
+// zstdMagic starts every zstd frame, and so every backup archive.
+var zstdMagic = []byte{0x28, 0xB5, 0x2F, 0xFD}
+
+// writeArchive writes the files under dir to w as a zstd-compressed tar.
+func writeArchive(w io.Writer, dir string) error {
+	zw := zstd.NewWriter(w)
+	tw := tar.NewWriter(zw)
+
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || p == dir {
+			return err
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(fi, "")
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if d.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// extractArchive extracts a backup archive into dir. Only regular files and
+// directories are accepted, and no entry may leave dir. Archives written
+// before backups moved to zstd are gzip-compressed, and are still read.
+func extractArchive(r io.Reader, dir string) error {
+	br := bufio.NewReader(r)
+	var archive io.Reader
+	if magic, _ := br.Peek(len(zstdMagic)); bytes.Equal(magic, zstdMagic) {
+		archive = zstd.NewReader(br)
+	} else {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		archive = gz
+	}
+
+	tr := tar.NewReader(archive)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		name := path.Clean(header.Name)
+		if !fs.ValidPath(name) || name == "." || strings.Contains(name, `\`) {
+			return fmt.Errorf("invalid entry name %q", header.Name)
+		}
+		target := filepath.Join(dir, filepath.FromSlash(name))
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0700); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+				return err
+			}
+			if err := extractFile(tr, target); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported entry type for %q", header.Name)
+		}
+	}
+}
+
+// extractFile writes the current tar entry to a new file.
+func extractFile(r io.Reader, target string) error {
+	f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, r); err != nil {
+		return err
+	}
+	return f.Close()
+}
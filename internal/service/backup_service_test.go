@@ -0,0 +1,124 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/lock"
+	"github.com/prn-tf/alexander-storage/internal/pkg/zstd"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+	"github.com/prn-tf/alexander-storage/internal/repository/sqlite"
+)
+
+func TestBackup_CreateRestoreAndVerify(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	db, err := sqlite.NewDB(ctx, sqlite.DefaultConfig(filepath.Join(dir, "alexander.db")), zerolog.Nop())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, db.Migrate(ctx))
+	store := newTestFilesystem(t, dir)
+
+	user := domain.NewUser("owner", "owner@example.com", "hash")
+	require.NoError(t, sqlite.NewUserRepository(db).Create(ctx, user))
+	bucketRepo := sqlite.NewBucketRepository(db)
+	require.NoError(t, bucketRepo.Create(ctx, domain.NewBucket(user.ID, "photos")))
+
+	blobRepo := sqlite.NewBlobRepository(db)
+	auditRepo := sqlite.NewAuditRepository(db)
+	objects := NewObjectService(sqlite.NewObjectRepository(db), blobRepo, bucketRepo, nil, nil, nil,
+		sqlite.NewStagedObjectRepository(db), nil, nil, sqlite.NewTxManager(db), store, lock.NewMemoryLocker(), nil, zerolog.Nop())
+
+	_, err = putString(ctx, objects, "photos", "cat.jpg", "meow", nil)
+	require.NoError(t, err)
+	hash := sha256.Sum256([]byte("meow"))
+	contentHash := hex.EncodeToString(hash[:])
+	require.NoError(t, auditRepo.Append(ctx, []*domain.AuditEvent{auditEvent()}))
+
+	backups := NewBackupService(db, blobRepo, store, "sqlite", zerolog.Nop())
+	var archive bytes.Buffer
+	info, err := backups.Create(ctx, &archive)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), info.Blobs)
+	require.Equal(t, int64(4), info.BlobBytes)
+	require.True(t, bytes.HasPrefix(archive.Bytes(), zstdMagic), "archives are zstd-compressed")
+
+	// Changes after the backup are undone by the restore, the append-only
+	// audit log included
+	_, err = putString(ctx, objects, "photos", "dog.jpg", "woof", nil)
+	require.NoError(t, err)
+	require.NoError(t, bucketRepo.Create(ctx, domain.NewBucket(user.ID, "later")))
+	require.NoError(t, auditRepo.Append(ctx, []*domain.AuditEvent{auditEvent()}))
+
+	restored, err := backups.Restore(ctx, bytes.NewReader(archive.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, info.SchemaVersion, restored.SchemaVersion)
+
+	body, _ := getString(t, ctx, objects, "photos", "cat.jpg")
+	require.Equal(t, "meow", body)
+	_, err = objects.GetObject(ctx, GetObjectInput{BucketName: "photos", Key: "dog.jpg"})
+	require.ErrorIs(t, err, domain.ErrObjectNotFound)
+	_, err = bucketRepo.GetByName(ctx, "later")
+	require.ErrorIs(t, err, domain.ErrBucketNotFound)
+
+	events, err := auditRepo.List(ctx, repository.AuditFilter{})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	_, err = auditRepo.Prune(ctx, domain.AuditCategoryOperational, time.Now().Add(time.Hour), 10)
+	require.NoError(t, err, "the audit log triggers must be restored")
+
+	// IDs handed out after the restore do not collide with restored rows
+	require.NoError(t, bucketRepo.Create(ctx, domain.NewBucket(user.ID, "after")))
+
+	verification, err := backups.VerifyBlobs(ctx)
+	require.NoError(t, err)
+	require.Zero(t, verification.MissingCount)
+
+	require.NoError(t, os.Remove(store.GetPath(contentHash)))
+	verification, err = backups.VerifyBlobs(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), verification.MissingCount)
+	require.Equal(t, []string{contentHash}, verification.Missing)
+
+	// Backups only restore into the driver they were taken from
+	_, err = NewBackupService(db, blobRepo, store, "postgres", zerolog.Nop()).Restore(ctx, bytes.NewReader(archive.Bytes()))
+	require.ErrorIs(t, err, ErrIncompatibleBackup)
+
+	// Archives from before the switch to zstd are gzip-compressed
+	tarball, err := io.ReadAll(zstd.NewReader(bytes.NewReader(archive.Bytes())))
+	require.NoError(t, err)
+	var legacy bytes.Buffer
+	gz := gzip.NewWriter(&legacy)
+	_, err = gz.Write(tarball)
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	_, err = backups.Restore(ctx, &legacy)
+	require.NoError(t, err)
+
+	_, err = backups.Restore(ctx, bytes.NewReader([]byte("not a backup")))
+	require.ErrorIs(t, err, ErrInvalidBackup)
+}
+
+func auditEvent() *domain.AuditEvent {
+	return &domain.AuditEvent{
+		Time:      time.Now(),
+		Actor:     "cli",
+		Operation: "bucket.create",
+		Result:    domain.AuditResultSuccess,
+		Category:  domain.AuditCategoryOperational,
+		Severity:  domain.AuditSeverityInfo,
+	}
+}
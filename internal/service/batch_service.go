@@ -0,0 +1,447 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/lock"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// maxBatchWrites is the number of writes a batch can hold.
+const maxBatchWrites = 1000
+
+// BatchService manages batches of staged writes. Writes naming a batch are
+// staged like quarantined uploads; committing the batch publishes all of
+// them in one transaction, so readers see either none or all of them.
+// Batches that are neither committed nor aborted expire.
+type BatchService struct {
+	batchRepo  repository.BatchRepository
+	stagedRepo repository.StagedObjectRepository
+	objectRepo repository.ObjectRepository
+	blobRepo   repository.BlobRepository
+	bucketRepo repository.BucketRepository
+	eventRepo  repository.EventRepository
+	replRepo   repository.ReplicationRepository
+	readOnly   *ReadOnlyService
+	txManager  repository.TxManager
+	locker     lock.Locker
+	logger     zerolog.Logger
+	config     BatchConfig
+
+	// Control
+	mu       sync.Mutex
+	running  bool
+	stopChan chan struct{}
+	doneChan chan struct{}
+}
+
+// BatchConfig contains batch commit configuration.
+type BatchConfig struct {
+	// ExpireAfter is how long a batch stays open before it is aborted.
+	ExpireAfter time.Duration
+
+	// Interval is how often to look for expired batches.
+	Interval time.Duration
+
+	// BatchSize is the number of expired batches aborted at a time.
+	BatchSize int
+}
+
+// DefaultBatchConfig returns sensible defaults.
+func DefaultBatchConfig() BatchConfig {
+	return BatchConfig{
+		ExpireAfter: 24 * time.Hour,
+		Interval:    15 * time.Minute,
+		BatchSize:   100,
+	}
+}
+
+// NewBatchService creates a new BatchService.
+// eventRepo and replRepo may be nil, as for NewObjectService.
+func NewBatchService(
+	batchRepo repository.BatchRepository,
+	stagedRepo repository.StagedObjectRepository,
+	objectRepo repository.ObjectRepository,
+	blobRepo repository.BlobRepository,
+	bucketRepo repository.BucketRepository,
+	eventRepo repository.EventRepository,
+	replRepo repository.ReplicationRepository,
+	txManager repository.TxManager,
+	locker lock.Locker,
+	logger zerolog.Logger,
+	config BatchConfig,
+) *BatchService {
+	return &BatchService{
+		batchRepo:  batchRepo,
+		stagedRepo: stagedRepo,
+		objectRepo: objectRepo,
+		blobRepo:   blobRepo,
+		bucketRepo: bucketRepo,
+		eventRepo:  eventRepo,
+		replRepo:   replRepo,
+		txManager:  txManager,
+		locker:     locker,
+		logger:     logger.With().Str("service", "batch").Logger(),
+		config:     config,
+		stopChan:   make(chan struct{}),
+		doneChan:   make(chan struct{}),
+	}
+}
+
+// SetReadOnlyService makes commits also check the service-wide read-only
+// mode, not just the read-only flag of the buckets written to. It must be
+// called before the service handles requests.
+func (s *BatchService) SetReadOnlyService(readOnly *ReadOnlyService) {
+	s.readOnly = readOnly
+}
+
+// CommittedWrite is an object version published by a batch commit.
+type CommittedWrite struct {
+	*domain.Object
+	BucketName string
+}
+
+// Create opens a batch for ownerID.
+func (s *BatchService) Create(ctx context.Context, ownerID int64) (*domain.Batch, error) {
+	batch := domain.NewBatch(ownerID, s.config.ExpireAfter)
+	if err := s.batchRepo.Create(ctx, batch); err != nil {
+		s.logger.Error().Ctx(ctx).Err(err).Int64("owner_id", ownerID).Msg("failed to create batch")
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	s.logger.Info().Ctx(ctx).
+		Str("batch_id", batch.ID.String()).
+		Int64("owner_id", ownerID).
+		Time("expires_at", batch.ExpiresAt).
+		Msg("batch opened")
+
+	return batch, nil
+}
+
+// Get returns an open batch and the writes staged in it, oldest first.
+func (s *BatchService) Get(ctx context.Context, id string, ownerID int64) (*domain.Batch, []StagedUpload, error) {
+	batch, err := openBatch(ctx, s.batchRepo, id, ownerID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	staged, err := s.stagedRepo.ListByBatch(ctx, batch.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	buckets := make(map[int64]*domain.Bucket)
+	writes := make([]StagedUpload, len(staged))
+	for i, st := range staged {
+		bucket, err := s.bucket(ctx, buckets, st.BucketID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+		}
+		writes[i] = StagedUpload{StagedObject: st, BucketName: bucket.Name}
+	}
+	return batch, writes, nil
+}
+
+// Commit publishes every write of a batch as the latest version of its key
+// in one transaction and closes the batch. If a bucket written to is
+// read-only, nothing is published and the batch stays open.
+func (s *BatchService) Commit(ctx context.Context, id string, ownerID int64) ([]CommittedWrite, error) {
+	batch, err := openBatch(ctx, s.batchRepo, id, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Removing the batch first makes a concurrent commit or abort of the
+	// same batch fail, and so does a write racing with the commit
+	var committed []CommittedWrite
+	err = s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		committed = nil
+		if err := s.batchRepo.Delete(ctx, batch.ID); err != nil {
+			return err
+		}
+
+		writes, err := s.stagedRepo.ListByBatch(ctx, batch.ID)
+		if err != nil {
+			return err
+		}
+
+		buckets := make(map[int64]*domain.Bucket)
+		for _, staged := range writes {
+			bucket, err := s.bucket(ctx, buckets, staged.BucketID)
+			if err != nil {
+				return err
+			}
+			if err := checkWritable(ctx, s.readOnly, bucket); err != nil {
+				return err
+			}
+			if err := s.stagedRepo.Delete(ctx, staged.ID); err != nil {
+				return err
+			}
+			obj, err := publishStaged(ctx, s.objectRepo, s.blobRepo, s.replRepo, s.eventRepo, s.logger, bucket, staged)
+			if err != nil {
+				return err
+			}
+			committed = append(committed, CommittedWrite{Object: obj, BucketName: bucket.Name})
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrBatchNotFound) || errors.Is(err, domain.ErrBucketReadOnly) || errors.Is(err, domain.ErrServiceReadOnly) {
+			return nil, err
+		}
+		s.logger.Error().Ctx(ctx).Err(err).Str("batch_id", id).Msg("failed to commit batch")
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	s.logger.Info().Ctx(ctx).
+		Str("batch_id", id).
+		Int("objects", len(committed)).
+		Msg("batch committed")
+
+	return committed, nil
+}
+
+// Abort discards every write of a batch and closes the batch.
+func (s *BatchService) Abort(ctx context.Context, id string, ownerID int64) error {
+	batch, err := openBatch(ctx, s.batchRepo, id, ownerID)
+	if err != nil {
+		return err
+	}
+
+	discarded, err := s.discard(ctx, batch)
+	if err != nil {
+		if errors.Is(err, domain.ErrBatchNotFound) {
+			return err
+		}
+		s.logger.Error().Ctx(ctx).Err(err).Str("batch_id", id).Msg("failed to abort batch")
+		return fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	s.logger.Info().Ctx(ctx).
+		Str("batch_id", id).
+		Int("writes", discarded).
+		Msg("batch aborted")
+
+	return nil
+}
+
+// discard removes a batch and its staged writes, releasing their blob
+// references, and returns the number of writes discarded.
+func (s *BatchService) discard(ctx context.Context, batch *domain.Batch) (int, error) {
+	discarded := 0
+	err := s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		if err := s.batchRepo.Delete(ctx, batch.ID); err != nil {
+			return err
+		}
+
+		writes, err := s.stagedRepo.ListByBatch(ctx, batch.ID)
+		if err != nil {
+			return err
+		}
+		for _, staged := range writes {
+			if err := s.stagedRepo.Delete(ctx, staged.ID); err != nil {
+				return err
+			}
+			if err := releaseBlobRef(ctx, s.blobRepo, s.logger, staged.ContentHash); err != nil {
+				return err
+			}
+		}
+		discarded = len(writes)
+		return nil
+	})
+	return discarded, err
+}
+
+// bucket returns a bucket by ID, caching it in buckets. Staged rows cascade
+// with their bucket, so a missing bucket is an error.
+func (s *BatchService) bucket(ctx context.Context, buckets map[int64]*domain.Bucket, id int64) (*domain.Bucket, error) {
+	if bucket, ok := buckets[id]; ok {
+		return bucket, nil
+	}
+	bucket, err := s.bucketRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bucket %d: %w", id, err)
+	}
+	buckets[id] = bucket
+	return bucket, nil
+}
+
+// Start begins the expiry scheduler.
+func (s *BatchService) Start() {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = true
+	s.mu.Unlock()
+
+	s.logger.Info().
+		Dur("interval", s.config.Interval).
+		Dur("expire_after", s.config.ExpireAfter).
+		Msg("Starting batch expiry")
+
+	go s.runLoop()
+}
+
+// Stop stops the expiry scheduler.
+func (s *BatchService) Stop() {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = false
+	s.mu.Unlock()
+
+	close(s.stopChan)
+	<-s.doneChan
+
+	s.logger.Info().Msg("Batch expiry stopped")
+}
+
+// runLoop is the main expiry loop.
+func (s *BatchService) runLoop() {
+	defer close(s.doneChan)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-s.stopChan
+		cancel()
+	}()
+
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		s.ExpireOnce(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+// ExpireOnce aborts every expired batch and returns the number aborted.
+func (s *BatchService) ExpireOnce(ctx context.Context) int {
+	lockKey := lock.Keys.BatchExpiry()
+	lockTTL := s.config.Interval / 2
+	if lockTTL < 5*time.Minute {
+		lockTTL = 5 * time.Minute
+	}
+
+	acquired, err := s.locker.Acquire(ctx, lockKey, lockTTL)
+	if err != nil {
+		s.logger.Error().Ctx(ctx).Err(err).Msg("Failed to acquire batch expiry lock")
+		return 0
+	}
+	if !acquired {
+		s.logger.Debug().Ctx(ctx).Msg("Batch expiry lock held by another process, skipping run")
+		return 0
+	}
+	defer func() {
+		if _, err := s.locker.Release(context.Background(), lockKey); err != nil {
+			s.logger.Error().Ctx(ctx).Err(err).Msg("Failed to release batch expiry lock")
+		}
+	}()
+
+	// Aborted batches are gone from the next page, as for quarantine expiry
+	now := time.Now()
+	expired := 0
+	for ctx.Err() == nil {
+		page, err := s.batchRepo.ListExpired(ctx, now, s.config.BatchSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				s.logger.Error().Ctx(ctx).Err(err).Msg("Failed to list expired batches")
+			}
+			break
+		}
+
+		failed := 0
+		for _, batch := range page {
+			if _, err := s.discard(ctx, batch); err != nil && !errors.Is(err, domain.ErrBatchNotFound) {
+				s.logger.Error().Ctx(ctx).Err(err).Str("batch_id", batch.ID.String()).Msg("Failed to expire batch")
+				failed++
+				continue
+			}
+			expired++
+		}
+
+		if len(page) < s.config.BatchSize || failed > 0 {
+			break
+		}
+	}
+
+	if expired > 0 {
+		s.logger.Info().Ctx(ctx).Int("expired", expired).Msg("Aborted expired batches")
+	}
+	return expired
+}
+
+// openBatch returns the open batch with the given ID. Batches of other
+// owners are reported as not found, unless ownerID is 0.
+func openBatch(ctx context.Context, batchRepo repository.BatchRepository, id string, ownerID int64) (*domain.Batch, error) {
+	batchID, err := uuid.Parse(id)
+	if err != nil || batchRepo == nil {
+		return nil, domain.ErrBatchNotFound
+	}
+
+	batch, err := batchRepo.GetByID(ctx, batchID)
+	if err != nil {
+		if errors.Is(err, domain.ErrBatchNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	if batch.IsExpired() || (ownerID > 0 && batch.OwnerID != ownerID) {
+		return nil, domain.ErrBatchNotFound
+	}
+	return batch, nil
+}
+
+// writeBatch returns the open batch a write to bucket names, or nil if it
+// names none. Quarantine buckets take no batch writes, since committing
+// them would bypass approval.
+func writeBatch(ctx context.Context, batchRepo repository.BatchRepository, bucket *domain.Bucket, batchID string, ownerID int64) (*domain.Batch, error) {
+	if batchID == "" {
+		return nil, nil
+	}
+	if bucket.Quarantine {
+		return nil, domain.ErrBatchQuarantineBucket
+	}
+	return openBatch(ctx, batchRepo, batchID, ownerID)
+}
+
+// stageInBatch stages a write in a batch. Callers run it inside the
+// transaction that takes the write's blob reference. Looking the batch up
+// again in that transaction orders the write against a concurrent commit
+// or abort; on PostgreSQL the deferred foreign key on batch_id also fails
+// a write whose batch is removed before it commits.
+func stageInBatch(ctx context.Context, batchRepo repository.BatchRepository, stagedRepo repository.StagedObjectRepository, batch *domain.Batch, staged *domain.StagedObject) error {
+	if _, err := batchRepo.GetByID(ctx, batch.ID); err != nil {
+		return err
+	}
+
+	count, err := stagedRepo.CountByBatch(ctx, batch.ID)
+	if err != nil {
+		return err
+	}
+	if count >= maxBatchWrites {
+		return domain.ErrBatchTooLarge
+	}
+
+	staged.BatchID = &batch.ID
+	return stagedRepo.Create(ctx, staged)
+}
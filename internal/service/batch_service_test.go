@@ -0,0 +1,138 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/lock"
+	"github.com/prn-tf/alexander-storage/internal/repository/sqlite"
+)
+
+func TestBatch_CommitAbortAndExpire(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	db, err := sqlite.NewDB(ctx, sqlite.DefaultConfig(filepath.Join(dir, "alexander.db")), zerolog.Nop())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, db.Migrate(ctx))
+	store := newTestFilesystem(t, dir)
+
+	user := domain.NewUser("owner", "owner@example.com", "hash")
+	require.NoError(t, sqlite.NewUserRepository(db).Create(ctx, user))
+	bucketRepo := sqlite.NewBucketRepository(db)
+	for _, name := range []string{"data", "index"} {
+		require.NoError(t, bucketRepo.Create(ctx, domain.NewBucket(user.ID, name)))
+	}
+	inbox := domain.NewBucket(user.ID, "inbox")
+	inbox.Quarantine = true
+	require.NoError(t, bucketRepo.Create(ctx, inbox))
+
+	objectRepo := sqlite.NewObjectRepository(db)
+	blobRepo := sqlite.NewBlobRepository(db)
+	stagedRepo := sqlite.NewStagedObjectRepository(db)
+	batchRepo := sqlite.NewBatchRepository(db)
+	txManager := sqlite.NewTxManager(db)
+	objects := NewObjectService(objectRepo, blobRepo, bucketRepo, nil, nil, nil, stagedRepo, batchRepo, nil, txManager, store, lock.NewMemoryLocker(), nil, zerolog.Nop())
+	batches := NewBatchService(batchRepo, stagedRepo, objectRepo, blobRepo, bucketRepo, nil, nil, txManager, lock.NewMemoryLocker(), zerolog.Nop(), DefaultBatchConfig())
+
+	put := func(batchID, bucket, key, body string) (*PutObjectOutput, error) {
+		return objects.PutObject(ctx, PutObjectInput{
+			BucketName: bucket,
+			Key:        key,
+			Body:       bytes.NewReader([]byte(body)),
+			Size:       int64(len(body)),
+			OwnerID:    user.ID,
+			BatchID:    batchID,
+		})
+	}
+
+	// Writes to several buckets stay invisible until the batch commits
+	batch, err := batches.Create(ctx, user.ID)
+	require.NoError(t, err)
+	id := batch.ID.String()
+
+	out, err := put(id, "data", "part-0.parquet", "rows")
+	require.NoError(t, err)
+	require.NotEmpty(t, out.StagingID)
+	require.Empty(t, out.VersionID)
+	_, err = objects.CopyObject(ctx, CopyObjectInput{
+		SourceBucket: "data", SourceKey: "part-0.parquet", DestBucket: "index", DestKey: "latest", OwnerID: user.ID, BatchID: id,
+	})
+	require.ErrorIs(t, err, domain.ErrObjectNotFound, "staged writes are not readable, even by the batch")
+	_, err = put(id, "index", "manifest.json", "part-0")
+	require.NoError(t, err)
+
+	_, err = objects.GetObject(ctx, GetObjectInput{BucketName: "data", Key: "part-0.parquet"})
+	require.ErrorIs(t, err, domain.ErrObjectNotFound)
+	_, writes, err := batches.Get(ctx, id, user.ID)
+	require.NoError(t, err)
+	require.Len(t, writes, 2)
+	require.Equal(t, "data", writes[0].BucketName)
+
+	staged, err := NewQuarantineService(stagedRepo, objectRepo, blobRepo, bucketRepo, nil, nil, txManager, store, lock.NewMemoryLocker(), zerolog.Nop(), DefaultQuarantineConfig()).ListStaged(ctx, "", 10)
+	require.NoError(t, err)
+	require.Empty(t, staged, "batch writes are not quarantined uploads")
+
+	_, err = batches.Commit(ctx, id, user.ID+1)
+	require.ErrorIs(t, err, domain.ErrBatchNotFound, "batches of other users are hidden")
+
+	committed, err := batches.Commit(ctx, id, user.ID)
+	require.NoError(t, err)
+	require.Len(t, committed, 2)
+	body, sequence := getString(t, ctx, objects, "data", "part-0.parquet")
+	require.Equal(t, "rows", body)
+	require.Equal(t, int64(1), sequence)
+	body, _ = getString(t, ctx, objects, "index", "manifest.json")
+	require.Equal(t, "part-0", body)
+
+	_, err = batches.Commit(ctx, id, user.ID)
+	require.ErrorIs(t, err, domain.ErrBatchNotFound)
+	_, err = put(id, "data", "late.parquet", "late")
+	require.ErrorIs(t, err, domain.ErrBatchNotFound, "a committed batch takes no more writes")
+
+	// Quarantine buckets take no batch writes
+	batch, err = batches.Create(ctx, user.ID)
+	require.NoError(t, err)
+	_, err = put(batch.ID.String(), "inbox", "report.pdf", "clean")
+	require.ErrorIs(t, err, domain.ErrBatchQuarantineBucket)
+
+	// An aborted batch releases its blob references
+	_, err = put(batch.ID.String(), "data", "part-1.parquet", "discarded")
+	require.NoError(t, err)
+	require.NoError(t, batches.Abort(ctx, batch.ID.String(), user.ID))
+	hash := sha256.Sum256([]byte("discarded"))
+	blob, err := blobRepo.GetByHash(ctx, hex.EncodeToString(hash[:]))
+	require.NoError(t, err)
+	require.Zero(t, blob.RefCount)
+	_, err = objects.GetObject(ctx, GetObjectInput{BucketName: "data", Key: "part-1.parquet"})
+	require.ErrorIs(t, err, domain.ErrObjectNotFound)
+
+	// Batches nobody commits expire
+	batch, err = batches.Create(ctx, user.ID)
+	require.NoError(t, err)
+	_, err = put(batch.ID.String(), "data", "forgotten.parquet", "pending")
+	require.NoError(t, err)
+	require.Zero(t, batches.ExpireOnce(ctx), "open batches must not expire")
+
+	_, err = db.ExecContext(ctx, `UPDATE batches SET expires_at = ?`, time.Now().Add(-time.Minute).UTC().Format(time.RFC3339))
+	require.NoError(t, err)
+	_, err = put(batch.ID.String(), "data", "too-late.parquet", "late")
+	require.ErrorIs(t, err, domain.ErrBatchNotFound, "an expired batch takes no more writes")
+
+	require.Equal(t, 1, batches.ExpireOnce(ctx))
+	_, err = objects.GetObject(ctx, GetObjectInput{BucketName: "data", Key: "forgotten.parquet"})
+	require.ErrorIs(t, err, domain.ErrObjectNotFound)
+	count, err := stagedRepo.CountByBatch(ctx, batch.ID)
+	require.NoError(t, err)
+	require.Zero(t, count)
+}
@@ -0,0 +1,368 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// BucketDeleter deletes a bucket with everything in it, for administrators
+// removing buckets too large to empty by hand. It aborts the bucket's
+// multipart uploads, deletes every version and delete marker key by key,
+// discards quarantined uploads and snapshots, and finally drops the bucket.
+// Every blob reference is released as it goes, leaving the content to GC.
+//
+// A delete that stops early can be rerun; it continues with what is left.
+type BucketDeleter struct {
+	objects      *ObjectService
+	multipart    *MultipartService
+	snapshotRepo repository.BucketSnapshotRepository
+	logger       zerolog.Logger
+	config       BucketDeleteConfig
+}
+
+// BucketDeleteConfig contains recursive bucket deletion configuration.
+type BucketDeleteConfig struct {
+	// BatchSize is the number of keys or uploads listed at a time.
+	BatchSize int
+
+	// KeysPerSecond limits the deletion rate so it does not starve other
+	// writes to the database. Zero is unlimited.
+	KeysPerSecond int
+}
+
+// DefaultBucketDeleteConfig returns sensible defaults.
+func DefaultBucketDeleteConfig() BucketDeleteConfig {
+	return BucketDeleteConfig{
+		BatchSize:     1000,
+		KeysPerSecond: 1000,
+	}
+}
+
+// NewBucketDeleter creates a new BucketDeleter. snapshotRepo may be nil if
+// snapshots are disabled, in which case buckets with snapshots cannot be
+// deleted.
+func NewBucketDeleter(
+	objects *ObjectService,
+	multipart *MultipartService,
+	snapshotRepo repository.BucketSnapshotRepository,
+	logger zerolog.Logger,
+	config BucketDeleteConfig,
+) *BucketDeleter {
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultBucketDeleteConfig().BatchSize
+	}
+	return &BucketDeleter{
+		objects:      objects,
+		multipart:    multipart,
+		snapshotRepo: snapshotRepo,
+		logger:       logger.With().Str("service", "bucket-delete").Logger(),
+		config:       config,
+	}
+}
+
+// BucketDeleteProgress is the state of a recursive bucket deletion.
+type BucketDeleteProgress struct {
+	// Bucket is the bucket being deleted.
+	Bucket string `json:"bucket"`
+
+	// Uploads is the number of multipart uploads aborted, and Parts the
+	// number of their parts released.
+	Uploads int `json:"uploads"`
+	Parts   int `json:"parts"`
+
+	// Keys is the number of keys whose versions and delete markers were
+	// deleted, and BlobRefs the number of blob references they released.
+	Keys     int64 `json:"keys"`
+	BlobRefs int64 `json:"blob_refs"`
+
+	// Staged is the number of quarantined uploads discarded.
+	Staged int `json:"staged"`
+
+	// Snapshots is the number of snapshots deleted.
+	Snapshots int `json:"snapshots"`
+
+	// Deleted reports that the bucket itself was dropped.
+	Deleted bool `json:"deleted"`
+
+	// Duration is how long the deletion took.
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// ForceDelete deletes a bucket and everything in it. Like other writes, it is
+// refused while the bucket or the service is read-only. progress, if set, is
+// called after each batch. The returned progress reports what was deleted
+// even if ForceDelete fails part way.
+func (d *BucketDeleter) ForceDelete(ctx context.Context, bucketName string, progress func(BucketDeleteProgress)) (*BucketDeleteProgress, error) {
+	start := time.Now()
+	state := &BucketDeleteProgress{Bucket: bucketName}
+	report := func() {
+		state.Duration = time.Since(start)
+		if progress != nil {
+			progress(*state)
+		}
+	}
+
+	bucket, err := d.objects.bucketRepo.GetByName(ctx, bucketName)
+	if err != nil {
+		if errors.Is(err, domain.ErrBucketNotFound) {
+			return state, domain.ErrBucketNotFound
+		}
+		return state, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	if err := checkWritable(ctx, d.objects.readOnly, bucket); err != nil {
+		return state, err
+	}
+
+	// Uploads go first so none completes into an object after its key
+	// was deleted
+	steps := []func(context.Context, *domain.Bucket, *BucketDeleteProgress, func()) error{
+		d.abortUploads,
+		d.deleteKeys,
+		d.discardStaged,
+		d.deleteSnapshots,
+	}
+	for _, step := range steps {
+		if err := step(ctx, bucket, state, report); err != nil {
+			state.Duration = time.Since(start)
+			if ctx.Err() != nil {
+				return state, ctx.Err()
+			}
+			d.logger.Error().Ctx(ctx).Err(err).Str("bucket", bucketName).Msg("recursive bucket delete stopped")
+			return state, fmt.Errorf("%w: %v", ErrInternalError, err)
+		}
+	}
+
+	// Writes made while the bucket was being emptied, including the staged
+	// writes of open batches, keep it from being dropped
+	isEmpty, err := d.objects.bucketRepo.IsEmpty(ctx, bucket.ID)
+	if err != nil {
+		state.Duration = time.Since(start)
+		return state, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	if !isEmpty {
+		state.Duration = time.Since(start)
+		return state, domain.ErrBucketNotEmpty
+	}
+	if err := d.objects.bucketRepo.Delete(ctx, bucket.ID); err != nil {
+		state.Duration = time.Since(start)
+		return state, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	state.Deleted = true
+	state.Duration = time.Since(start)
+
+	d.logger.Info().Ctx(ctx).
+		Str("bucket", bucketName).
+		Int("uploads", state.Uploads).
+		Int64("keys", state.Keys).
+		Int64("blob_refs", state.BlobRefs).
+		Int("staged", state.Staged).
+		Int("snapshots", state.Snapshots).
+		Dur("duration", state.Duration).
+		Msg("bucket deleted recursively")
+
+	return state, nil
+}
+
+// abortUploads aborts the bucket's multipart uploads in progress.
+func (d *BucketDeleter) abortUploads(ctx context.Context, bucket *domain.Bucket, state *BucketDeleteProgress, report func()) error {
+	for {
+		listed, err := d.multipart.multipartRepo.List(ctx, bucket.ID, repository.MultipartListOptions{MaxUploads: d.config.BatchSize})
+		if err != nil {
+			return fmt.Errorf("failed to list multipart uploads: %w", err)
+		}
+
+		for _, upload := range listed.Uploads {
+			uploadID, err := uuid.Parse(upload.UploadID)
+			if err != nil {
+				return fmt.Errorf("invalid upload ID %q: %w", upload.UploadID, err)
+			}
+			parts, err := d.multipart.abortUpload(ctx, uploadID)
+			if errors.Is(err, domain.ErrMultipartUploadNotFound) {
+				// Completed or aborted since it was listed
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to abort upload %s: %w", upload.UploadID, err)
+			}
+			state.Uploads++
+			state.Parts += parts
+		}
+		report()
+
+		// Aborted uploads are gone from the next page
+		if !listed.IsTruncated || len(listed.Uploads) == 0 {
+			return nil
+		}
+	}
+}
+
+// deleteKeys deletes every version and delete marker of the bucket, one key
+// at a time, at no more than the configured rate.
+func (d *BucketDeleter) deleteKeys(ctx context.Context, bucket *domain.Bucket, state *BucketDeleteProgress, report func()) error {
+	pace := newPacer(d.config.KeysPerSecond)
+	for {
+		listed, err := d.objects.objectRepo.ListVersions(ctx, bucket.ID, repository.ObjectListOptions{MaxKeys: d.config.BatchSize})
+		if err != nil {
+			return fmt.Errorf("failed to list object versions: %w", err)
+		}
+
+		// Deleted keys are gone from the next page, so each page starts
+		// at the first key left
+		var keys []string
+		seen := make(map[string]bool)
+		for _, version := range append(listed.Versions, listed.DeleteMarkers...) {
+			if !seen[version.Key] {
+				seen[version.Key] = true
+				keys = append(keys, version.Key)
+			}
+		}
+		if len(keys) == 0 {
+			return nil
+		}
+
+		for _, key := range keys {
+			released, err := d.deleteKey(ctx, bucket, key)
+			if err != nil {
+				return fmt.Errorf("failed to delete key %q: %w", key, err)
+			}
+			state.Keys++
+			state.BlobRefs += int64(released)
+			if err := pace.wait(ctx); err != nil {
+				return err
+			}
+		}
+		report()
+	}
+}
+
+// deleteKey deletes every version of a key and releases their blob
+// references in one transaction, and returns how many it released.
+func (d *BucketDeleter) deleteKey(ctx context.Context, bucket *domain.Bucket, key string) (int, error) {
+	unlock, err := d.objects.lockObject(ctx, bucket.ID, key)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	var hashes []string
+	err = d.objects.txManager.WithTx(ctx, func(ctx context.Context) error {
+		hashes, err = d.objects.objectRepo.DeleteAllVersions(ctx, bucket.ID, key)
+		if err != nil {
+			return err
+		}
+		for _, contentHash := range hashes {
+			if err := releaseBlobRef(ctx, d.objects.blobRepo, d.logger, contentHash); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(hashes), nil
+}
+
+// discardStaged discards the bucket's quarantined uploads.
+func (d *BucketDeleter) discardStaged(ctx context.Context, bucket *domain.Bucket, state *BucketDeleteProgress, report func()) error {
+	if d.objects.stagedRepo == nil {
+		return nil
+	}
+	for {
+		staged, err := d.objects.stagedRepo.List(ctx, bucket.ID, d.config.BatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to list staged uploads: %w", err)
+		}
+		if len(staged) == 0 {
+			return nil
+		}
+
+		for _, upload := range staged {
+			err := d.objects.txManager.WithTx(ctx, func(ctx context.Context) error {
+				if err := d.objects.stagedRepo.Delete(ctx, upload.ID); err != nil {
+					return err
+				}
+				return releaseBlobRef(ctx, d.objects.blobRepo, d.logger, upload.ContentHash)
+			})
+			if errors.Is(err, domain.ErrStagedObjectNotFound) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to discard staged upload %s: %w", upload.ID, err)
+			}
+			state.Staged++
+		}
+		report()
+	}
+}
+
+// deleteSnapshots deletes the bucket's snapshots, releasing the blob
+// references they hold.
+func (d *BucketDeleter) deleteSnapshots(ctx context.Context, bucket *domain.Bucket, state *BucketDeleteProgress, report func()) error {
+	if d.snapshotRepo == nil {
+		return nil
+	}
+	snapshots, err := d.snapshotRepo.ListByBucket(ctx, bucket.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	for _, snapshot := range snapshots {
+		err := d.objects.txManager.WithTx(ctx, func(ctx context.Context) error {
+			return d.snapshotRepo.Delete(ctx, snapshot.ID)
+		})
+		if errors.Is(err, domain.ErrSnapshotNotFound) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to delete snapshot %q: %w", snapshot.Name, err)
+		}
+		state.Snapshots++
+	}
+	if len(snapshots) > 0 {
+		report()
+	}
+	return nil
+}
+
+// pacer sleeps as needed to keep an operation's average rate at or below
+// rate per second.
+type pacer struct {
+	rate  int
+	start time.Time
+	done  int
+}
+
+// newPacer returns a pacer for rate operations per second. Zero is unlimited.
+func newPacer(rate int) *pacer {
+	return &pacer{rate: rate, start: time.Now()}
+}
+
+// wait counts an operation and sleeps until the rate allows the next one.
+func (p *pacer) wait(ctx context.Context) error {
+	if p.rate <= 0 {
+		return nil
+	}
+	p.done++
+
+	due := time.Duration(float64(p.done) / float64(p.rate) * float64(time.Second))
+	if wait := due - time.Since(p.start); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
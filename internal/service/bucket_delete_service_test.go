@@ -0,0 +1,82 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/lock"
+)
+
+func TestBucketDeleter_ForceDeleteReleasesEverything(t *testing.T) {
+	ctx := context.Background()
+	multipart, store := newSQLiteMultipartService(t)
+	bucket, err := multipart.bucketRepo.GetByName(ctx, "uploads")
+	require.NoError(t, err)
+	require.NoError(t, multipart.bucketRepo.UpdateVersioning(ctx, bucket.ID, domain.VersioningEnabled))
+	objects := NewObjectService(
+		multipart.objectRepo,
+		multipart.blobRepo,
+		multipart.bucketRepo,
+		nil,
+		nil,
+		nil,
+		multipart.stagedRepo,
+		nil,
+		nil,
+		multipart.txManager,
+		store,
+		lock.NewMemoryLocker(),
+		nil,
+		zerolog.Nop(),
+	)
+
+	// Two versions of a.txt, a deleted b.txt and an upload in progress
+	contents := []string{"first", "second", "deleted", "part"}
+	for _, put := range []struct{ key, content string }{{"a.txt", "first"}, {"a.txt", "second"}, {"b.txt", "deleted"}} {
+		_, err := putString(ctx, objects, "uploads", put.key, put.content, nil)
+		require.NoError(t, err)
+	}
+	_, err = objects.DeleteObject(ctx, DeleteObjectInput{BucketName: "uploads", Key: "b.txt"})
+	require.NoError(t, err)
+	initiated, err := multipart.InitiateMultipartUpload(ctx, InitiateMultipartUploadInput{BucketName: "uploads", Key: "big.iso"})
+	require.NoError(t, err)
+	_, err = multipart.UploadPart(ctx, UploadPartInput{
+		BucketName: "uploads",
+		Key:        "big.iso",
+		UploadID:   initiated.UploadID,
+		PartNumber: 1,
+		Body:       bytes.NewReader([]byte("part")),
+		Size:       4,
+	})
+	require.NoError(t, err)
+
+	deleter := NewBucketDeleter(objects, multipart, nil, zerolog.Nop(), BucketDeleteConfig{BatchSize: 1})
+	var reports int
+	progress, err := deleter.ForceDelete(ctx, "uploads", func(BucketDeleteProgress) { reports++ })
+	require.NoError(t, err)
+	require.True(t, progress.Deleted)
+	require.Equal(t, 1, progress.Uploads)
+	require.Equal(t, 1, progress.Parts)
+	require.EqualValues(t, 2, progress.Keys)
+	require.EqualValues(t, 3, progress.BlobRefs)
+	require.Greater(t, reports, 2, "progress is reported after each batch")
+
+	_, err = multipart.bucketRepo.GetByName(ctx, "uploads")
+	require.ErrorIs(t, err, domain.ErrBucketNotFound)
+	for _, content := range contents {
+		sum := sha256.Sum256([]byte(content))
+		refs, err := multipart.blobRepo.GetRefCount(ctx, hex.EncodeToString(sum[:]))
+		require.NoError(t, err)
+		require.Zero(t, refs, content)
+	}
+
+	_, err = deleter.ForceDelete(ctx, "uploads", nil)
+	require.ErrorIs(t, err, domain.ErrBucketNotFound)
+}
@@ -17,20 +17,65 @@ import (
 // BucketService handles bucket operations.
 type BucketService struct {
 	bucketRepo repository.BucketRepository
+	mfa        *MFAService
+	readOnly   *ReadOnlyService
+	tenants    *TenantService
 	logger     zerolog.Logger
+	config     BucketConfig
+}
+
+// BucketConfig contains bucket configuration.
+type BucketConfig struct {
+	// DefaultRegion is the region of buckets created without a location
+	// constraint.
+	DefaultRegion string
+
+	// AllowedRegions are the other regions a bucket may be created in.
+	AllowedRegions []string
+}
+
+// DefaultBucketConfig returns sensible defaults.
+func DefaultBucketConfig() BucketConfig {
+	return BucketConfig{
+		DefaultRegion: auth.DefaultRegion,
+	}
 }
 
 // NewBucketService creates a new BucketService.
+// mfa may be nil, in which case owners cannot change the versioning state
+// of buckets with MFA delete enabled, or enable or disable it.
 func NewBucketService(
 	bucketRepo repository.BucketRepository,
+	mfa *MFAService,
 	logger zerolog.Logger,
+	config BucketConfig,
 ) *BucketService {
+	if config.DefaultRegion == "" {
+		config.DefaultRegion = auth.DefaultRegion
+	}
 	return &BucketService{
 		bucketRepo: bucketRepo,
+		mfa:        mfa,
 		logger:     logger.With().Str("service", "bucket").Logger(),
+		config:     config,
 	}
 }
 
+// SetReadOnlyService makes bucket creation and deletion check the
+// service-wide read-only mode, and HeadBucket report it. It must be called
+// before the service handles requests.
+func (s *BucketService) SetReadOnlyService(readOnly *ReadOnlyService) {
+	s.readOnly = readOnly
+}
+
+// SetTenantService makes bucket creation enforce the bucket quota and
+// namespace of the owner's tenant, and put new buckets in that tenant.
+// Without it, all buckets are created in the default tenant. It must be
+// called before the service handles requests.
+func (s *BucketService) SetTenantService(tenants *TenantService) {
+	s.tenants = tenants
+}
+
 // =============================================================================
 // Input/Output Structs
 // =============================================================================
@@ -40,6 +85,16 @@ type CreateBucketInput struct {
 	OwnerID int64
 	Name    string
 	Region  string
+
+	// Residency optionally pins the bucket's blobs to the storage backend
+	// configured for this tag. It cannot be changed once objects exist.
+	Residency string
+
+	// EnableVersioning creates the bucket with versioning enabled.
+	EnableVersioning bool
+
+	// ACL is the bucket's canned ACL. Buckets are private if it is empty.
+	ACL domain.BucketACL
 }
 
 // CreateBucketOutput contains the result of creating a bucket.
@@ -60,12 +115,17 @@ type GetBucketOutput struct {
 
 // ListBucketsInput contains the data needed to list buckets.
 type ListBucketsInput struct {
-	OwnerID int64
+	OwnerID           int64
+	Prefix            string // Optional - filters by name prefix
+	ContinuationToken string // Optional - from a previous truncated listing
+	MaxBuckets        int    // Optional - all buckets if zero
 }
 
 // ListBucketsOutput contains the result of listing buckets.
 type ListBucketsOutput struct {
-	Buckets []*domain.Bucket
+	Buckets               []*domain.Bucket
+	IsTruncated           bool
+	NextContinuationToken string
 }
 
 // DeleteBucketInput contains the data needed to delete a bucket.
@@ -84,6 +144,10 @@ type HeadBucketInput struct {
 type HeadBucketOutput struct {
 	Exists bool
 	Region string
+
+	// ReadOnly is true if writes to the bucket are refused, because it or
+	// the whole service is in read-only mode.
+	ReadOnly bool
 }
 
 // GetBucketVersioningInput contains the data needed to get bucket versioning.
@@ -94,7 +158,8 @@ type GetBucketVersioningInput struct {
 
 // GetBucketVersioningOutput contains the versioning status.
 type GetBucketVersioningOutput struct {
-	Status domain.VersioningStatus
+	Status    domain.VersioningStatus
+	MFADelete bool
 }
 
 // PutBucketVersioningInput contains the data needed to set bucket versioning.
@@ -102,6 +167,76 @@ type PutBucketVersioningInput struct {
 	Name    string
 	OwnerID int64
 	Status  domain.VersioningStatus
+
+	// MFADelete optionally enables or disables MFA delete; nil keeps it.
+	MFADelete *bool
+
+	// MFA is the x-amz-mfa header, required to change MFADelete and, once
+	// it is enabled, the versioning state.
+	MFA string
+}
+
+// GetBucketACLInput contains the data needed to get a bucket's ACL.
+type GetBucketACLInput struct {
+	Name    string
+	OwnerID int64
+}
+
+// GetBucketACLOutput contains a bucket's owner and canned ACL.
+type GetBucketACLOutput struct {
+	OwnerID int64
+	ACL     domain.BucketACL
+}
+
+// PutBucketACLInput contains the data needed to set a bucket's ACL.
+type PutBucketACLInput struct {
+	Name    string
+	OwnerID int64
+	ACL     domain.BucketACL
+}
+
+// BucketTaggingInput identifies the bucket whose tags are read or deleted.
+type BucketTaggingInput struct {
+	Name    string
+	OwnerID int64
+}
+
+// PutBucketTaggingInput contains the data needed to set a bucket's tags.
+type PutBucketTaggingInput struct {
+	Name    string
+	OwnerID int64
+	Tags    []domain.Tag // Replaces the tag set; empty removes it
+}
+
+// SetBucketResidencyInput contains the data needed to change a bucket's residency tag.
+type SetBucketResidencyInput struct {
+	Name      string
+	Residency string
+}
+
+// SetBucketQuarantineInput contains the data needed to turn upload quarantine on or off.
+type SetBucketQuarantineInput struct {
+	Name    string
+	Enabled bool
+}
+
+// SetBucketReadOnlyInput contains the data needed to turn read-only mode of a bucket on or off.
+type SetBucketReadOnlyInput struct {
+	Name    string
+	Enabled bool
+}
+
+// SetBucketQuotaInput contains the data needed to set the storage quota of a bucket.
+type SetBucketQuotaInput struct {
+	Name  string
+	Bytes int64 // Zero removes the quota
+}
+
+// SetBucketObjectDefaultsInput contains the data needed to set the defaults of uploads to a bucket.
+type SetBucketObjectDefaultsInput struct {
+	Name     string
+	OwnerID  int64                  // For ownership verification
+	Defaults *domain.ObjectDefaults // Replaces the defaults; nil or empty removes them
 }
 
 // =============================================================================
@@ -115,44 +250,77 @@ func (s *BucketService) CreateBucket(ctx context.Context, input CreateBucketInpu
 		return nil, err
 	}
 
+	if err := domain.ValidateResidency(input.Residency); err != nil {
+		return nil, err
+	}
+
+	acl := domain.ACLPrivate
+	if input.ACL != "" {
+		if !domain.IsValidACL(string(input.ACL)) {
+			return nil, ErrInvalidBucketACL
+		}
+		acl = input.ACL
+	}
+
+	region, err := s.resolveRegion(input.Region)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.readOnly != nil && s.readOnly.Enabled(ctx) {
+		return nil, domain.ErrServiceReadOnly
+	}
+
 	// Check if bucket already exists
 	exists, err := s.bucketRepo.ExistsByName(ctx, input.Name)
 	if err != nil {
-		s.logger.Error().Err(err).Str("bucket", input.Name).Msg("failed to check bucket existence")
+		s.logger.Error().Ctx(ctx).Err(err).Str("bucket", input.Name).Msg("failed to check bucket existence")
 		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 	if exists {
 		return nil, domain.ErrBucketAlreadyExists
 	}
 
-	// Set default region if not specified
-	region := input.Region
-	if region == "" {
-		region = "us-east-1"
+	tenantID := domain.DefaultTenantID
+	if s.tenants != nil {
+		tenantID, err = s.tenants.checkNewBucket(ctx, input.OwnerID, input.Name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	versioning := domain.VersioningDisabled
+	if input.EnableVersioning {
+		versioning = domain.VersioningEnabled
 	}
 
 	// Create bucket
 	bucket := &domain.Bucket{
 		OwnerID:    input.OwnerID,
+		TenantID:   tenantID,
 		Name:       input.Name,
 		Region:     region,
-		Versioning: domain.VersioningDisabled,
+		Versioning: versioning,
+		ACL:        acl,
 		ObjectLock: false,
 		CreatedAt:  time.Now().UTC(),
+		Residency:  input.Residency,
 	}
 
 	if err := s.bucketRepo.Create(ctx, bucket); err != nil {
 		if errors.Is(err, domain.ErrBucketAlreadyExists) {
 			return nil, domain.ErrBucketAlreadyExists
 		}
-		s.logger.Error().Err(err).Str("bucket", input.Name).Msg("failed to create bucket")
+		s.logger.Error().Ctx(ctx).Err(err).Str("bucket", input.Name).Msg("failed to create bucket")
 		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 
-	s.logger.Info().
+	s.logger.Info().Ctx(ctx).
 		Int64("owner_id", input.OwnerID).
 		Str("bucket", input.Name).
 		Str("region", region).
+		Str("residency", input.Residency).
+		Str("acl", string(acl)).
 		Msg("bucket created")
 
 	return &CreateBucketOutput{
@@ -167,7 +335,7 @@ func (s *BucketService) GetBucket(ctx context.Context, input GetBucketInput) (*G
 		if errors.Is(err, domain.ErrBucketNotFound) {
 			return nil, domain.ErrBucketNotFound
 		}
-		s.logger.Error().Err(err).Str("bucket", input.Name).Msg("failed to get bucket")
+		s.logger.Error().Ctx(ctx).Err(err).Str("bucket", input.Name).Msg("failed to get bucket")
 		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 
@@ -181,17 +349,27 @@ func (s *BucketService) GetBucket(ctx context.Context, input GetBucketInput) (*G
 	}, nil
 }
 
-// ListBuckets returns all buckets for a user.
+// ListBuckets returns the buckets of a user in name order, a page at a
+// time if MaxBuckets is set.
 func (s *BucketService) ListBuckets(ctx context.Context, input ListBucketsInput) (*ListBucketsOutput, error) {
-	buckets, err := s.bucketRepo.List(ctx, input.OwnerID)
+	result, err := s.bucketRepo.ListPage(ctx, input.OwnerID, repository.BucketListOptions{
+		Prefix:     input.Prefix,
+		StartAfter: decodeContinuationToken(input.ContinuationToken),
+		MaxBuckets: input.MaxBuckets,
+	})
 	if err != nil {
-		s.logger.Error().Err(err).Int64("owner_id", input.OwnerID).Msg("failed to list buckets")
+		s.logger.Error().Ctx(ctx).Err(err).Int64("owner_id", input.OwnerID).Msg("failed to list buckets")
 		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 
-	return &ListBucketsOutput{
-		Buckets: buckets,
-	}, nil
+	output := &ListBucketsOutput{
+		Buckets:     result.Buckets,
+		IsTruncated: result.IsTruncated,
+	}
+	if result.IsTruncated {
+		output.NextContinuationToken = encodeContinuationToken(result.Buckets[len(result.Buckets)-1].Name)
+	}
+	return output, nil
 }
 
 // DeleteBucket deletes a bucket.
@@ -202,7 +380,7 @@ func (s *BucketService) DeleteBucket(ctx context.Context, input DeleteBucketInpu
 		if errors.Is(err, domain.ErrBucketNotFound) {
 			return domain.ErrBucketNotFound
 		}
-		s.logger.Error().Err(err).Str("bucket", input.Name).Msg("failed to get bucket")
+		s.logger.Error().Ctx(ctx).Err(err).Str("bucket", input.Name).Msg("failed to get bucket")
 		return fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 
@@ -211,10 +389,14 @@ func (s *BucketService) DeleteBucket(ctx context.Context, input DeleteBucketInpu
 		return ErrBucketAccessDenied
 	}
 
+	if err := checkWritable(ctx, s.readOnly, bucket); err != nil {
+		return err
+	}
+
 	// Check if bucket is empty
 	isEmpty, err := s.bucketRepo.IsEmpty(ctx, bucket.ID)
 	if err != nil {
-		s.logger.Error().Err(err).Str("bucket", input.Name).Msg("failed to check if bucket is empty")
+		s.logger.Error().Ctx(ctx).Err(err).Str("bucket", input.Name).Msg("failed to check if bucket is empty")
 		return fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 	if !isEmpty {
@@ -223,11 +405,11 @@ func (s *BucketService) DeleteBucket(ctx context.Context, input DeleteBucketInpu
 
 	// Delete bucket
 	if err := s.bucketRepo.Delete(ctx, bucket.ID); err != nil {
-		s.logger.Error().Err(err).Str("bucket", input.Name).Msg("failed to delete bucket")
+		s.logger.Error().Ctx(ctx).Err(err).Str("bucket", input.Name).Msg("failed to delete bucket")
 		return fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 
-	s.logger.Info().
+	s.logger.Info().Ctx(ctx).
 		Int64("owner_id", input.OwnerID).
 		Str("bucket", input.Name).
 		Msg("bucket deleted")
@@ -244,7 +426,7 @@ func (s *BucketService) HeadBucket(ctx context.Context, input HeadBucketInput) (
 				Exists: false,
 			}, nil
 		}
-		s.logger.Error().Err(err).Str("bucket", input.Name).Msg("failed to check bucket")
+		s.logger.Error().Ctx(ctx).Err(err).Str("bucket", input.Name).Msg("failed to check bucket")
 		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 
@@ -254,8 +436,9 @@ func (s *BucketService) HeadBucket(ctx context.Context, input HeadBucketInput) (
 	}
 
 	return &HeadBucketOutput{
-		Exists: true,
-		Region: bucket.Region,
+		Exists:   true,
+		Region:   bucket.Region,
+		ReadOnly: checkWritable(ctx, s.readOnly, bucket) != nil,
 	}, nil
 }
 
@@ -266,7 +449,7 @@ func (s *BucketService) GetBucketVersioning(ctx context.Context, input GetBucket
 		if errors.Is(err, domain.ErrBucketNotFound) {
 			return nil, domain.ErrBucketNotFound
 		}
-		s.logger.Error().Err(err).Str("bucket", input.Name).Msg("failed to get bucket")
+		s.logger.Error().Ctx(ctx).Err(err).Str("bucket", input.Name).Msg("failed to get bucket")
 		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 
@@ -276,7 +459,8 @@ func (s *BucketService) GetBucketVersioning(ctx context.Context, input GetBucket
 	}
 
 	return &GetBucketVersioningOutput{
-		Status: bucket.Versioning,
+		Status:    bucket.Versioning,
+		MFADelete: bucket.MFADelete,
 	}, nil
 }
 
@@ -293,7 +477,7 @@ func (s *BucketService) PutBucketVersioning(ctx context.Context, input PutBucket
 		if errors.Is(err, domain.ErrBucketNotFound) {
 			return domain.ErrBucketNotFound
 		}
-		s.logger.Error().Err(err).Str("bucket", input.Name).Msg("failed to get bucket")
+		s.logger.Error().Ctx(ctx).Err(err).Str("bucket", input.Name).Msg("failed to get bucket")
 		return fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 
@@ -302,30 +486,343 @@ func (s *BucketService) PutBucketVersioning(ctx context.Context, input PutBucket
 		return ErrBucketAccessDenied
 	}
 
-	// Update versioning status
-	if err := s.bucketRepo.UpdateVersioning(ctx, bucket.ID, input.Status); err != nil {
-		s.logger.Error().Err(err).Str("bucket", input.Name).Msg("failed to update versioning")
-		return fmt.Errorf("%w: %v", ErrInternalError, err)
+	if input.MFADelete == nil {
+		// Once MFA delete is enabled, changing the versioning state needs a code
+		if input.Status != bucket.Versioning {
+			if err := checkMFADelete(ctx, s.mfa, bucket, input.OwnerID, input.MFA); err != nil {
+				return err
+			}
+		}
+
+		if err := s.bucketRepo.UpdateVersioning(ctx, bucket.ID, input.Status); err != nil {
+			s.logger.Error().Ctx(ctx).Err(err).Str("bucket", input.Name).Msg("failed to update versioning")
+			return fmt.Errorf("%w: %v", ErrInternalError, err)
+		}
+	} else {
+		// Enabling or disabling MFA delete always needs a code of the owner's device
+		if input.OwnerID > 0 {
+			if s.mfa == nil {
+				return domain.ErrMFARequired
+			}
+			if err := s.mfa.Verify(ctx, bucket.OwnerID, input.MFA); err != nil {
+				return err
+			}
+		}
+
+		bucket.Versioning = input.Status
+		bucket.MFADelete = *input.MFADelete
+		if err := s.bucketRepo.Update(ctx, bucket); err != nil {
+			s.logger.Error().Ctx(ctx).Err(err).Str("bucket", input.Name).Msg("failed to update versioning")
+			return fmt.Errorf("%w: %v", ErrInternalError, err)
+		}
 	}
 
-	s.logger.Info().
+	s.logger.Info().Ctx(ctx).
 		Str("bucket", input.Name).
 		Str("versioning", string(input.Status)).
+		Bool("mfa_delete", bucket.MFADelete).
 		Msg("bucket versioning updated")
 
 	return nil
 }
 
-// GetBucketACL retrieves the ACL for a bucket.
-func (s *BucketService) GetBucketACL(ctx context.Context, bucketName string) (domain.BucketACL, error) {
-	acl, err := s.bucketRepo.GetACLByName(ctx, bucketName)
+// SetBucketResidency changes the residency tag of an empty bucket. Existing
+// blobs are not moved, so the tag can only change while the bucket has no objects.
+func (s *BucketService) SetBucketResidency(ctx context.Context, input SetBucketResidencyInput) error {
+	if err := domain.ValidateResidency(input.Residency); err != nil {
+		return err
+	}
+
+	bucket, err := s.bucketRepo.GetByName(ctx, input.Name)
 	if err != nil {
 		if errors.Is(err, domain.ErrBucketNotFound) {
-			return "", nil // Return empty string for not found
+			return domain.ErrBucketNotFound
 		}
-		return "", fmt.Errorf("%w: %v", ErrInternalError, err)
+		return fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	empty, err := s.bucketRepo.IsEmpty(ctx, bucket.ID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	if !empty {
+		return domain.ErrBucketNotEmpty
+	}
+
+	bucket.Residency = input.Residency
+	if err := s.bucketRepo.Update(ctx, bucket); err != nil {
+		s.logger.Error().Ctx(ctx).Err(err).Str("bucket", input.Name).Msg("failed to update residency")
+		return fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	s.logger.Info().Ctx(ctx).
+		Str("bucket", input.Name).
+		Str("residency", input.Residency).
+		Msg("bucket residency updated")
+
+	return nil
+}
+
+// SetBucketQuarantine turns upload quarantine on or off for a bucket. Uploads
+// already staged stay staged until promoted, rejected or expired.
+func (s *BucketService) SetBucketQuarantine(ctx context.Context, input SetBucketQuarantineInput) error {
+	bucket, err := s.bucketRepo.GetByName(ctx, input.Name)
+	if err != nil {
+		if errors.Is(err, domain.ErrBucketNotFound) {
+			return domain.ErrBucketNotFound
+		}
+		return fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	bucket.Quarantine = input.Enabled
+	if err := s.bucketRepo.Update(ctx, bucket); err != nil {
+		s.logger.Error().Ctx(ctx).Err(err).Str("bucket", input.Name).Msg("failed to update quarantine")
+		return fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	s.logger.Info().Ctx(ctx).
+		Str("bucket", input.Name).
+		Bool("quarantine", input.Enabled).
+		Msg("bucket quarantine updated")
+
+	return nil
+}
+
+// SetBucketReadOnly turns read-only mode on or off for a bucket. Writes
+// already in progress may still complete.
+func (s *BucketService) SetBucketReadOnly(ctx context.Context, input SetBucketReadOnlyInput) error {
+	bucket, err := s.bucketRepo.GetByName(ctx, input.Name)
+	if err != nil {
+		if errors.Is(err, domain.ErrBucketNotFound) {
+			return domain.ErrBucketNotFound
+		}
+		return fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	bucket.ReadOnly = input.Enabled
+	if err := s.bucketRepo.Update(ctx, bucket); err != nil {
+		s.logger.Error().Ctx(ctx).Err(err).Str("bucket", input.Name).Msg("failed to update read-only mode")
+		return fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	s.logger.Info().Ctx(ctx).
+		Str("bucket", input.Name).
+		Bool("read_only", input.Enabled).
+		Msg("bucket read-only mode updated")
+
+	return nil
+}
+
+// SetBucketQuota sets the most data a bucket may hold. Data already over
+// a lowered quota is kept, but no more can be written until enough is
+// deleted.
+func (s *BucketService) SetBucketQuota(ctx context.Context, input SetBucketQuotaInput) error {
+	if input.Bytes < 0 {
+		return domain.ErrInvalidBucketQuota
+	}
+
+	bucket, err := s.bucketRepo.GetByName(ctx, input.Name)
+	if err != nil {
+		if errors.Is(err, domain.ErrBucketNotFound) {
+			return domain.ErrBucketNotFound
+		}
+		return fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	bucket.QuotaBytes = input.Bytes
+	if err := s.bucketRepo.Update(ctx, bucket); err != nil {
+		s.logger.Error().Ctx(ctx).Err(err).Str("bucket", input.Name).Msg("failed to update bucket quota")
+		return fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	s.logger.Info().Ctx(ctx).
+		Str("bucket", input.Name).
+		Int64("quota_bytes", input.Bytes).
+		Msg("bucket quota updated")
+
+	return nil
+}
+
+// SetBucketObjectDefaults replaces the settings given to uploads to a bucket
+// that do not specify them. Objects already stored keep their settings.
+func (s *BucketService) SetBucketObjectDefaults(ctx context.Context, input SetBucketObjectDefaultsInput) error {
+	defaults := input.Defaults
+	if defaults.IsEmpty() {
+		defaults = nil
+	} else {
+		defaults.Normalize()
+		if err := defaults.Validate(); err != nil {
+			return err
+		}
+	}
+
+	bucket, err := s.getOwnedBucket(ctx, input.Name, input.OwnerID)
+	if err != nil {
+		return err
+	}
+
+	bucket.ObjectDefaults = defaults
+	if err := s.bucketRepo.Update(ctx, bucket); err != nil {
+		if errors.Is(err, domain.ErrBucketNotFound) {
+			return err
+		}
+		s.logger.Error().Ctx(ctx).Err(err).Str("bucket", input.Name).Msg("failed to update object defaults")
+		return fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	event := s.logger.Info().Ctx(ctx).Str("bucket", input.Name)
+	if defaults != nil {
+		event = event.
+			Str("cache_control", defaults.CacheControl).
+			Str("storage_class", string(defaults.StorageClass)).
+			Int("content_types", len(defaults.ContentTypes))
+	}
+	event.Msg("bucket object defaults updated")
+
+	return nil
+}
+
+// GetBucketACL retrieves the canned ACL of a bucket.
+func (s *BucketService) GetBucketACL(ctx context.Context, input GetBucketACLInput) (*GetBucketACLOutput, error) {
+	bucket, err := s.bucketRepo.GetByName(ctx, input.Name)
+	if err != nil {
+		if errors.Is(err, domain.ErrBucketNotFound) {
+			return nil, domain.ErrBucketNotFound
+		}
+		s.logger.Error().Ctx(ctx).Err(err).Str("bucket", input.Name).Msg("failed to get bucket")
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
-	return acl, nil
+
+	// Verify ownership
+	if input.OwnerID > 0 && bucket.OwnerID != input.OwnerID {
+		return nil, ErrBucketAccessDenied
+	}
+
+	return &GetBucketACLOutput{
+		OwnerID: bucket.OwnerID,
+		ACL:     bucket.ACL,
+	}, nil
+}
+
+// PutBucketACL sets the canned ACL of a bucket.
+func (s *BucketService) PutBucketACL(ctx context.Context, input PutBucketACLInput) error {
+	if !domain.IsValidACL(string(input.ACL)) {
+		return ErrInvalidBucketACL
+	}
+
+	// Get bucket to verify it exists and check ownership
+	bucket, err := s.bucketRepo.GetByName(ctx, input.Name)
+	if err != nil {
+		if errors.Is(err, domain.ErrBucketNotFound) {
+			return domain.ErrBucketNotFound
+		}
+		s.logger.Error().Ctx(ctx).Err(err).Str("bucket", input.Name).Msg("failed to get bucket")
+		return fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	// Verify ownership
+	if input.OwnerID > 0 && bucket.OwnerID != input.OwnerID {
+		return ErrBucketAccessDenied
+	}
+
+	if err := s.bucketRepo.UpdateACL(ctx, bucket.ID, input.ACL); err != nil {
+		s.logger.Error().Ctx(ctx).Err(err).Str("bucket", input.Name).Msg("failed to update ACL")
+		return fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	s.logger.Info().Ctx(ctx).
+		Str("bucket", input.Name).
+		Str("acl", string(input.ACL)).
+		Msg("bucket ACL updated")
+
+	return nil
+}
+
+// GetBucketTagging returns the tag set of a bucket, or domain.ErrNoSuchTagSet
+// if it has none.
+func (s *BucketService) GetBucketTagging(ctx context.Context, input BucketTaggingInput) ([]domain.Tag, error) {
+	bucket, err := s.getOwnedBucket(ctx, input.Name, input.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := s.bucketRepo.GetTags(ctx, bucket.ID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNoSuchTagSet) || errors.Is(err, domain.ErrBucketNotFound) {
+			return nil, err
+		}
+		s.logger.Error().Ctx(ctx).Err(err).Str("bucket", input.Name).Msg("failed to get tags")
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	return tags, nil
+}
+
+// PutBucketTagging replaces the tag set of a bucket.
+func (s *BucketService) PutBucketTagging(ctx context.Context, input PutBucketTaggingInput) error {
+	if err := domain.ValidateBucketTags(input.Tags); err != nil {
+		return err
+	}
+	if len(input.Tags) == 0 {
+		return s.DeleteBucketTagging(ctx, BucketTaggingInput{Name: input.Name, OwnerID: input.OwnerID})
+	}
+
+	bucket, err := s.getOwnedBucket(ctx, input.Name, input.OwnerID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.bucketRepo.PutTags(ctx, bucket.ID, input.Tags); err != nil {
+		if errors.Is(err, domain.ErrBucketNotFound) {
+			return err
+		}
+		s.logger.Error().Ctx(ctx).Err(err).Str("bucket", input.Name).Msg("failed to update tags")
+		return fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	s.logger.Info().Ctx(ctx).
+		Str("bucket", input.Name).
+		Int("tags", len(input.Tags)).
+		Msg("bucket tags updated")
+
+	return nil
+}
+
+// DeleteBucketTagging removes the tag set of a bucket. Deleting a tag set
+// that does not exist succeeds.
+func (s *BucketService) DeleteBucketTagging(ctx context.Context, input BucketTaggingInput) error {
+	bucket, err := s.getOwnedBucket(ctx, input.Name, input.OwnerID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.bucketRepo.DeleteTags(ctx, bucket.ID); err != nil {
+		if errors.Is(err, domain.ErrBucketNotFound) {
+			return err
+		}
+		s.logger.Error().Ctx(ctx).Err(err).Str("bucket", input.Name).Msg("failed to delete tags")
+		return fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	s.logger.Info().Ctx(ctx).Str("bucket", input.Name).Msg("bucket tags deleted")
+
+	return nil
+}
+
+// getOwnedBucket returns a bucket, verifying its owner if ownerID is set.
+func (s *BucketService) getOwnedBucket(ctx context.Context, name string, ownerID int64) (*domain.Bucket, error) {
+	bucket, err := s.bucketRepo.GetByName(ctx, name)
+	if err != nil {
+		if errors.Is(err, domain.ErrBucketNotFound) {
+			return nil, domain.ErrBucketNotFound
+		}
+		s.logger.Error().Ctx(ctx).Err(err).Str("bucket", name).Msg("failed to get bucket")
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	if ownerID > 0 && bucket.OwnerID != ownerID {
+		return nil, ErrBucketAccessDenied
+	}
+	return bucket, nil
 }
 
 // =============================================================================
@@ -342,14 +839,43 @@ func NewBucketACLAdapter(bucketService *BucketService) *BucketACLAdapter {
 	return &BucketACLAdapter{bucketService: bucketService}
 }
 
-// GetBucketACL implements auth.BucketACLChecker.
+// GetBucketACL implements auth.BucketACLChecker. A bucket that does not
+// exist has no ACL.
 func (a *BucketACLAdapter) GetBucketACL(ctx context.Context, bucketName string) (string, error) {
-	acl, err := a.bucketService.GetBucketACL(ctx, bucketName)
+	acl, err := a.bucketService.bucketRepo.GetACLByName(ctx, bucketName)
 	if err != nil {
-		return "", err
+		if errors.Is(err, domain.ErrBucketNotFound) {
+			return "", nil
+		}
+		return "", fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 	return string(acl), nil
 }
 
 // Ensure BucketACLAdapter implements auth.BucketACLChecker
 var _ auth.BucketACLChecker = (*BucketACLAdapter)(nil)
+
+// Regions returns the regions buckets can be created in, the default first.
+func (s *BucketService) Regions() []string {
+	regions := []string{s.config.DefaultRegion}
+	for _, region := range s.config.AllowedRegions {
+		if region != s.config.DefaultRegion {
+			regions = append(regions, region)
+		}
+	}
+	return regions
+}
+
+// resolveRegion returns the region of a new bucket: the default region if
+// none is requested, or the requested one if the server allows it.
+func (s *BucketService) resolveRegion(region string) (string, error) {
+	if region == "" || region == s.config.DefaultRegion {
+		return s.config.DefaultRegion, nil
+	}
+	for _, allowed := range s.config.AllowedRegions {
+		if region == allowed {
+			return region, nil
+		}
+	}
+	return "", domain.ErrInvalidLocationConstraint
+}
@@ -2,12 +2,17 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/rs/zerolog"
 
 	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
 )
 
 // MockBucketRepository is a mock implementation of repository.BucketRepository.
@@ -15,6 +20,7 @@ type MockBucketRepository struct {
 	buckets   map[string]*domain.Bucket
 	nextID    int64
 	objects   map[int64]int64 // bucketID -> object count
+	tags      map[int64][]domain.Tag
 	createErr error
 	getErr    error
 	deleteErr error
@@ -24,6 +30,7 @@ func NewMockBucketRepository() *MockBucketRepository {
 	return &MockBucketRepository{
 		buckets: make(map[string]*domain.Bucket),
 		objects: make(map[int64]int64),
+		tags:    make(map[int64][]domain.Tag),
 		nextID:  1,
 	}
 }
@@ -73,6 +80,23 @@ func (m *MockBucketRepository) List(ctx context.Context, userID int64) ([]*domai
 	return result, nil
 }
 
+func (m *MockBucketRepository) ListPage(ctx context.Context, userID int64, opts repository.BucketListOptions) (*repository.BucketListResult, error) {
+	var buckets []*domain.Bucket
+	for _, b := range m.buckets {
+		if (userID == 0 || b.OwnerID == userID) && strings.HasPrefix(b.Name, opts.Prefix) && b.Name > opts.StartAfter {
+			buckets = append(buckets, b)
+		}
+	}
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].Name < buckets[j].Name })
+
+	result := &repository.BucketListResult{Buckets: buckets}
+	if opts.MaxBuckets > 0 && len(buckets) > opts.MaxBuckets {
+		result.IsTruncated = true
+		result.Buckets = buckets[:opts.MaxBuckets]
+	}
+	return result, nil
+}
+
 func (m *MockBucketRepository) Update(ctx context.Context, bucket *domain.Bucket) error {
 	if _, exists := m.buckets[bucket.Name]; !exists {
 		return domain.ErrBucketNotFound
@@ -128,6 +152,28 @@ func (m *MockBucketRepository) IsEmpty(ctx context.Context, id int64) (bool, err
 	return count == 0, nil
 }
 
+func (m *MockBucketRepository) GetTags(ctx context.Context, id int64) ([]domain.Tag, error) {
+	tags, ok := m.tags[id]
+	if !ok {
+		return nil, domain.ErrNoSuchTagSet
+	}
+	return tags, nil
+}
+
+func (m *MockBucketRepository) PutTags(ctx context.Context, id int64, tags []domain.Tag) error {
+	m.tags[id] = tags
+	return nil
+}
+
+func (m *MockBucketRepository) DeleteTags(ctx context.Context, id int64) error {
+	delete(m.tags, id)
+	return nil
+}
+
+func (m *MockBucketRepository) StorageBytes(ctx context.Context, id int64) (int64, error) {
+	return 0, nil
+}
+
 func (m *MockBucketRepository) GetACLByName(ctx context.Context, name string) (domain.BucketACL, error) {
 	if b, exists := m.buckets[name]; exists {
 		return b.ACL, nil
@@ -179,6 +225,24 @@ func TestBucketService_CreateBucket(t *testing.T) {
 			},
 			wantErr: nil,
 		},
+		{
+			name: "allowed region",
+			input: CreateBucketInput{
+				OwnerID: 1,
+				Name:    "eu-bucket",
+				Region:  "eu-west-1",
+			},
+			wantErr: nil,
+		},
+		{
+			name: "region not allowed",
+			input: CreateBucketInput{
+				OwnerID: 1,
+				Name:    "ap-bucket",
+				Region:  "ap-south-1",
+			},
+			wantErr: domain.ErrInvalidLocationConstraint,
+		},
 		{
 			name: "invalid name - too short",
 			input: CreateBucketInput{
@@ -220,7 +284,10 @@ func TestBucketService_CreateBucket(t *testing.T) {
 			}
 
 			logger := zerolog.Nop()
-			svc := NewBucketService(repo, logger)
+			svc := NewBucketService(repo, nil, logger, BucketConfig{
+				DefaultRegion:  "us-east-1",
+				AllowedRegions: []string{"eu-west-1"},
+			})
 
 			output, err := svc.CreateBucket(context.Background(), tt.input)
 
@@ -250,6 +317,9 @@ func TestBucketService_CreateBucket(t *testing.T) {
 			if tt.input.Region == "" && output.Bucket.Region != "us-east-1" {
 				t.Errorf("expected default region us-east-1, got %s", output.Bucket.Region)
 			}
+			if tt.input.Region != "" && output.Bucket.Region != tt.input.Region {
+				t.Errorf("expected region %s, got %s", tt.input.Region, output.Bucket.Region)
+			}
 		})
 	}
 }
@@ -325,7 +395,7 @@ func TestBucketService_DeleteBucket(t *testing.T) {
 			}
 
 			logger := zerolog.Nop()
-			svc := NewBucketService(repo, logger)
+			svc := NewBucketService(repo, nil, logger, DefaultBucketConfig())
 
 			err := svc.DeleteBucket(context.Background(), tt.input)
 
@@ -354,7 +424,7 @@ func TestBucketService_ListBuckets(t *testing.T) {
 	repo.buckets["bucket-3"] = &domain.Bucket{ID: 3, OwnerID: 2, Name: "bucket-3", CreatedAt: time.Now()}
 
 	logger := zerolog.Nop()
-	svc := NewBucketService(repo, logger)
+	svc := NewBucketService(repo, nil, logger, DefaultBucketConfig())
 
 	// List buckets for user 1
 	output, err := svc.ListBuckets(context.Background(), ListBucketsInput{OwnerID: 1})
@@ -377,6 +447,113 @@ func TestBucketService_ListBuckets(t *testing.T) {
 	}
 }
 
+func TestBucketService_ListBucketsPaged(t *testing.T) {
+	ctx := context.Background()
+	repo := newConsistencyService(t, "alpha", domain.VersioningDisabled).bucketRepo
+	for _, name := range []string{"beta-1", "beta-2", "beta-3", "gamma"} {
+		if err := repo.Create(ctx, domain.NewBucket(consistencyOwnerID, name)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	svc := NewBucketService(repo, nil, zerolog.Nop(), DefaultBucketConfig())
+
+	tests := []struct {
+		name   string
+		prefix string
+		pages  [][]string
+	}{
+		{name: "all buckets", pages: [][]string{{"alpha", "beta-1"}, {"beta-2", "beta-3"}, {"gamma"}}},
+		{name: "prefix", prefix: "beta", pages: [][]string{{"beta-1", "beta-2"}, {"beta-3"}}},
+		{name: "no match", prefix: "delta", pages: [][]string{nil}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var token string
+			for i, want := range tt.pages {
+				output, err := svc.ListBuckets(ctx, ListBucketsInput{
+					OwnerID:           consistencyOwnerID,
+					Prefix:            tt.prefix,
+					ContinuationToken: token,
+					MaxBuckets:        2,
+				})
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				var names []string
+				for _, b := range output.Buckets {
+					names = append(names, b.Name)
+				}
+				if strings.Join(names, ",") != strings.Join(want, ",") {
+					t.Errorf("page %d: expected %v, got %v", i, want, names)
+				}
+				if last := i == len(tt.pages)-1; output.IsTruncated == last {
+					t.Errorf("page %d: expected truncated %v, got %v", i, !last, output.IsTruncated)
+				}
+				token = output.NextContinuationToken
+			}
+		})
+	}
+}
+
+func TestBucketService_BucketTagging(t *testing.T) {
+	ctx := context.Background()
+	repo := newConsistencyService(t, "costs", domain.VersioningDisabled).bucketRepo
+	svc := NewBucketService(repo, nil, zerolog.Nop(), DefaultBucketConfig())
+	input := BucketTaggingInput{Name: "costs", OwnerID: consistencyOwnerID}
+
+	if _, err := svc.GetBucketTagging(ctx, input); !errors.Is(err, domain.ErrNoSuchTagSet) {
+		t.Fatalf("expected ErrNoSuchTagSet, got %v", err)
+	}
+
+	tags := []domain.Tag{{Key: "cost-center", Value: "1234"}, {Key: "team", Value: ""}}
+	if err := svc.PutBucketTagging(ctx, PutBucketTaggingInput{Name: "costs", OwnerID: consistencyOwnerID, Tags: tags}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := svc.GetBucketTagging(ctx, input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 || got[0] != tags[0] || got[1] != tags[1] {
+		t.Errorf("expected %v, got %v", tags, got)
+	}
+
+	tooMany := make([]domain.Tag, domain.MaxBucketTags+1)
+	for i := range tooMany {
+		tooMany[i] = domain.Tag{Key: fmt.Sprintf("key-%d", i)}
+	}
+	invalid := [][]domain.Tag{
+		tooMany,
+		{{Key: ""}},
+		{{Key: strings.Repeat("k", domain.MaxTagKeyLength+1)}},
+		{{Key: "k", Value: strings.Repeat("v", domain.MaxTagValueLength+1)}},
+		{{Key: "aws:createdBy"}},
+		{{Key: "team", Value: "a"}, {Key: "team", Value: "b"}},
+	}
+	for _, tags := range invalid {
+		err := svc.PutBucketTagging(ctx, PutBucketTaggingInput{Name: "costs", OwnerID: consistencyOwnerID, Tags: tags})
+		if !errors.Is(err, domain.ErrInvalidTag) {
+			t.Errorf("expected ErrInvalidTag, got %v", err)
+		}
+	}
+
+	err = svc.PutBucketTagging(ctx, PutBucketTaggingInput{Name: "costs", OwnerID: consistencyOwnerID + 1, Tags: tags})
+	if !errors.Is(err, ErrBucketAccessDenied) {
+		t.Errorf("expected ErrBucketAccessDenied, got %v", err)
+	}
+
+	// Deleting twice succeeds
+	for i := 0; i < 2; i++ {
+		if err := svc.DeleteBucketTagging(ctx, input); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if _, err := svc.GetBucketTagging(ctx, input); !errors.Is(err, domain.ErrNoSuchTagSet) {
+		t.Errorf("expected ErrNoSuchTagSet, got %v", err)
+	}
+}
+
 func TestBucketService_PutBucketVersioning(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -453,7 +630,7 @@ func TestBucketService_PutBucketVersioning(t *testing.T) {
 			}
 
 			logger := zerolog.Nop()
-			svc := NewBucketService(repo, logger)
+			svc := NewBucketService(repo, nil, logger, DefaultBucketConfig())
 
 			err := svc.PutBucketVersioning(context.Background(), tt.input)
 
@@ -472,3 +649,57 @@ func TestBucketService_PutBucketVersioning(t *testing.T) {
 		})
 	}
 }
+
+func TestBucketService_PutBucketACL(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   PutBucketACLInput
+		wantErr error
+		wantACL domain.BucketACL
+	}{
+		{
+			name:    "public read",
+			input:   PutBucketACLInput{Name: "my-bucket", OwnerID: 1, ACL: domain.ACLPublicRead},
+			wantACL: domain.ACLPublicRead,
+		},
+		{
+			name:    "invalid ACL",
+			input:   PutBucketACLInput{Name: "my-bucket", OwnerID: 1, ACL: "authenticated-read"},
+			wantErr: ErrInvalidBucketACL,
+			wantACL: domain.ACLPrivate,
+		},
+		{
+			name:    "other owner",
+			input:   PutBucketACLInput{Name: "my-bucket", OwnerID: 2, ACL: domain.ACLPublicRead},
+			wantErr: ErrBucketAccessDenied,
+			wantACL: domain.ACLPrivate,
+		},
+		{
+			name:    "bucket not found",
+			input:   PutBucketACLInput{Name: "non-existent", OwnerID: 1, ACL: domain.ACLPublicRead},
+			wantErr: domain.ErrBucketNotFound,
+			wantACL: domain.ACLPrivate,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewMockBucketRepository()
+			repo.buckets["my-bucket"] = &domain.Bucket{ID: 1, OwnerID: 1, Name: "my-bucket", ACL: domain.ACLPrivate}
+			svc := NewBucketService(repo, nil, zerolog.Nop(), DefaultBucketConfig())
+
+			err := svc.PutBucketACL(context.Background(), tt.input)
+			if err != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+
+			output, err := svc.GetBucketACL(context.Background(), GetBucketACLInput{Name: "my-bucket", OwnerID: 1})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if output.ACL != tt.wantACL {
+				t.Errorf("expected ACL %q, got %q", tt.wantACL, output.ACL)
+			}
+		})
+	}
+}
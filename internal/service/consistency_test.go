@@ -0,0 +1,721 @@
+// Package service provides business logic services for Alexander Storage.
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/lock"
+	"github.com/prn-tf/alexander-storage/internal/repository/sqlite"
+	"github.com/prn-tf/alexander-storage/internal/storage/filesystem"
+)
+
+// These tests document the consistency guarantees clients can build on,
+// against a real database and storage backend:
+//
+//   - read-after-write: once a PUT or DELETE returns, every GET, HEAD and
+//     LIST observes it
+//   - every write to a key gets the next x-alexander-sequence, with no gaps
+//     or duplicates, and the latest version carries the highest sequence
+//   - a write conditional on the current sequence succeeds for exactly one
+//     of several racing writers
+
+const consistencyOwnerID = 1
+
+// newConsistencyService returns an ObjectService backed by SQLite and the
+// filesystem, and creates the named bucket.
+func newConsistencyService(t *testing.T, bucketName string, versioning domain.VersioningStatus) *ObjectService {
+	t.Helper()
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	db, err := sqlite.NewDB(ctx, sqlite.DefaultConfig(filepath.Join(dir, "alexander.db")), zerolog.Nop())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, db.Migrate(ctx))
+
+	store, err := filesystem.NewStorage(filesystem.Config{
+		DataDir: filepath.Join(dir, "blobs"),
+		TempDir: filepath.Join(dir, "tmp"),
+	}, zerolog.Nop())
+	require.NoError(t, err)
+
+	user := domain.NewUser("owner", "owner@example.com", "hash")
+	require.NoError(t, sqlite.NewUserRepository(db).Create(ctx, user))
+	require.Equal(t, int64(consistencyOwnerID), user.ID)
+
+	bucketRepo := sqlite.NewBucketRepository(db)
+	bucket := domain.NewBucket(user.ID, bucketName)
+	bucket.Versioning = versioning
+	require.NoError(t, bucketRepo.Create(ctx, bucket))
+
+	return NewObjectService(
+		sqlite.NewObjectRepository(db),
+		sqlite.NewBlobRepository(db),
+		bucketRepo,
+		sqlite.NewEventRepository(db),
+		sqlite.NewReplicationRepository(db),
+		sqlite.NewLifecycleRepository(db),
+		sqlite.NewStagedObjectRepository(db),
+		sqlite.NewBatchRepository(db),
+		nil,
+		sqlite.NewTxManager(db),
+		store,
+		lock.NewMemoryLocker(),
+		nil,
+		zerolog.Nop(),
+	)
+}
+
+func putString(ctx context.Context, svc *ObjectService, bucket, key, body string, ifSequence *int64) (*PutObjectOutput, error) {
+	return svc.PutObject(ctx, PutObjectInput{
+		BucketName: bucket,
+		Key:        key,
+		Body:       bytes.NewReader([]byte(body)),
+		Size:       int64(len(body)),
+		OwnerID:    consistencyOwnerID,
+		IfSequence: ifSequence,
+	})
+}
+
+func getString(t *testing.T, ctx context.Context, svc *ObjectService, bucket, key string) (string, int64) {
+	t.Helper()
+	output, err := svc.GetObject(ctx, GetObjectInput{BucketName: bucket, Key: key, OwnerID: consistencyOwnerID})
+	require.NoError(t, err)
+	defer output.Body.Close()
+	data, err := io.ReadAll(output.Body)
+	require.NoError(t, err)
+	return string(data), output.Sequence
+}
+
+func listKeys(t *testing.T, ctx context.Context, svc *ObjectService, bucket, prefix string) []string {
+	t.Helper()
+	output, err := svc.ListObjects(ctx, ListObjectsInput{BucketName: bucket, Prefix: prefix, MaxKeys: 1000, OwnerID: consistencyOwnerID})
+	require.NoError(t, err)
+	keys := make([]string, 0, len(output.Contents))
+	for _, obj := range output.Contents {
+		keys = append(keys, obj.Key)
+	}
+	return keys
+}
+
+func TestConsistency_ReadAfterWrite(t *testing.T) {
+	svc := newConsistencyService(t, "raw", domain.VersioningDisabled)
+	ctx := context.Background()
+
+	const writers, rounds = 8, 10
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			key := fmt.Sprintf("writer-%d", w)
+			for i := 1; i <= rounds; i++ {
+				body := fmt.Sprintf("%s round %d", key, i)
+				put, err := putString(ctx, svc, "raw", key, body, nil)
+				if err != nil {
+					errs <- err
+					return
+				}
+
+				// The write is visible to the very next read, with its sequence
+				got, err := svc.GetObject(ctx, GetObjectInput{BucketName: "raw", Key: key, OwnerID: consistencyOwnerID})
+				if err != nil {
+					errs <- err
+					return
+				}
+				data, _ := io.ReadAll(got.Body)
+				got.Body.Close()
+				if string(data) != body || got.Sequence != put.Sequence || put.Sequence != int64(i) {
+					errs <- fmt.Errorf("%s: read %q (sequence %d) after writing %q (sequence %d)", key, data, got.Sequence, body, put.Sequence)
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	for w := 0; w < writers; w++ {
+		body, sequence := getString(t, ctx, svc, "raw", fmt.Sprintf("writer-%d", w))
+		require.Equal(t, fmt.Sprintf("writer-%d round %d", w, rounds), body)
+		require.Equal(t, int64(rounds), sequence)
+	}
+}
+
+func TestConsistency_ConcurrentWritersToOneKey(t *testing.T) {
+	svc := newConsistencyService(t, "shared", domain.VersioningEnabled)
+	ctx := context.Background()
+
+	const writers = 16
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		sequences = make(map[int64]string)
+	)
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			body := fmt.Sprintf("writer %d", w)
+			put, err := putString(ctx, svc, "shared", "key", body, nil)
+			if !assert.NoError(t, err) {
+				return
+			}
+			mu.Lock()
+			sequences[put.Sequence] = body
+			mu.Unlock()
+		}(w)
+	}
+	wg.Wait()
+
+	// Every write got its own sequence, with no gaps
+	require.Len(t, sequences, writers)
+	for sequence := int64(1); sequence <= writers; sequence++ {
+		require.Contains(t, sequences, sequence)
+	}
+
+	// The latest version is the write with the highest sequence
+	body, sequence := getString(t, ctx, svc, "shared", "key")
+	require.Equal(t, int64(writers), sequence)
+	require.Equal(t, sequences[writers], body)
+
+	// Versions list newest first, in sequence order
+	versions, err := svc.ListObjectVersions(ctx, ListObjectVersionsInput{BucketName: "shared", MaxKeys: 1000, OwnerID: consistencyOwnerID})
+	require.NoError(t, err)
+	require.Len(t, versions.Versions, writers)
+	for i, version := range versions.Versions {
+		head, err := svc.HeadObject(ctx, HeadObjectInput{BucketName: "shared", Key: "key", VersionID: version.VersionID, OwnerID: consistencyOwnerID})
+		require.NoError(t, err)
+		require.Equal(t, int64(writers-i), head.Sequence)
+	}
+}
+
+func TestConsistency_ConcurrentOverwritesKeepBlobRefs(t *testing.T) {
+	svc := newConsistencyService(t, "overwrite", domain.VersioningDisabled)
+	ctx := context.Background()
+
+	// Seed the copy source, which holds a reference of its own
+	_, err := putString(ctx, svc, "overwrite", "source", "same content", nil)
+	require.NoError(t, err)
+
+	const writers, rounds = 8, 10
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < rounds; i++ {
+				var err error
+				switch (w + i) % 3 {
+				case 0:
+					_, err = putString(ctx, svc, "overwrite", "key", "same content", nil)
+				case 1:
+					_, err = svc.CopyObject(ctx, CopyObjectInput{
+						SourceBucket: "overwrite",
+						SourceKey:    "source",
+						DestBucket:   "overwrite",
+						DestKey:      "key",
+						OwnerID:      consistencyOwnerID,
+					})
+				case 2:
+					_, err = svc.DeleteObject(ctx, DeleteObjectInput{BucketName: "overwrite", Key: "key", OwnerID: consistencyOwnerID})
+				}
+				if err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	// Each overwrite released exactly the reference of the version it replaced
+	bucket, err := svc.bucketRepo.GetByName(ctx, "overwrite")
+	require.NoError(t, err)
+	source, err := svc.objectRepo.GetByKey(ctx, bucket.ID, "source")
+	require.NoError(t, err)
+	want := int32(1)
+	if _, err := svc.objectRepo.GetByKey(ctx, bucket.ID, "key"); err == nil {
+		want = 2
+	}
+	refs, err := svc.blobRepo.GetRefCount(ctx, *source.ContentHash)
+	require.NoError(t, err)
+	assert.Equal(t, want, refs)
+}
+
+func TestConsistency_ListAfterWrite(t *testing.T) {
+	svc := newConsistencyService(t, "law", domain.VersioningDisabled)
+	ctx := context.Background()
+
+	const writers, keysPerWriter = 8, 10
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < keysPerWriter; i++ {
+				key := fmt.Sprintf("dir/%02d/%02d", w, i)
+				if _, err := putString(ctx, svc, "law", key, key, nil); err != nil {
+					errs <- err
+					return
+				}
+				output, err := svc.ListObjects(ctx, ListObjectsInput{BucketName: "law", Prefix: key, MaxKeys: 1, OwnerID: consistencyOwnerID})
+				if err != nil {
+					errs <- err
+					return
+				}
+				if len(output.Contents) != 1 || output.Contents[0].Key != key {
+					errs <- fmt.Errorf("%s missing from listing right after PUT", key)
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	keys := listKeys(t, ctx, svc, "law", "dir/")
+	require.Len(t, keys, writers*keysPerWriter)
+	require.True(t, sort.StringsAreSorted(keys))
+
+	// Deletes are just as immediate
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			prefix := fmt.Sprintf("dir/%02d/", w)
+			for i := 0; i < keysPerWriter; i++ {
+				_, err := svc.DeleteObject(ctx, DeleteObjectInput{BucketName: "law", Key: fmt.Sprintf("%s%02d", prefix, i), OwnerID: consistencyOwnerID})
+				assert.NoError(t, err)
+			}
+			output, err := svc.ListObjects(ctx, ListObjectsInput{BucketName: "law", Prefix: prefix, MaxKeys: 1000, OwnerID: consistencyOwnerID})
+			if assert.NoError(t, err) {
+				assert.Empty(t, output.Contents)
+			}
+		}(w)
+	}
+	wg.Wait()
+	require.Empty(t, listKeys(t, ctx, svc, "law", ""))
+}
+
+func TestConsistency_SequenceFencing(t *testing.T) {
+	svc := newConsistencyService(t, "fenced", domain.VersioningDisabled)
+	ctx := context.Background()
+	sequence := func(n int64) *int64 { return &n }
+
+	// Creating a key that must not exist yet
+	put, err := putString(ctx, svc, "fenced", "lease", "owner-a", sequence(0))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), put.Sequence)
+	_, err = putString(ctx, svc, "fenced", "lease", "owner-b", sequence(0))
+	require.ErrorIs(t, err, domain.ErrSequenceMismatch)
+
+	// Racing writers holding the same token: exactly one wins
+	const writers = 12
+	var wg sync.WaitGroup
+	results := make(chan error, writers)
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			_, err := putString(ctx, svc, "fenced", "lease", fmt.Sprintf("writer %d", w), sequence(1))
+			results <- err
+		}(w)
+	}
+	wg.Wait()
+	close(results)
+	wins := 0
+	for err := range results {
+		if err == nil {
+			wins++
+			continue
+		}
+		require.ErrorIs(t, err, domain.ErrSequenceMismatch)
+	}
+	require.Equal(t, 1, wins)
+
+	// Rejected writes left no trace
+	body, current := getString(t, ctx, svc, "fenced", "lease")
+	require.Equal(t, int64(2), current)
+	require.Contains(t, body, "writer ")
+
+	// Deletes are fenced too, and the sequence survives them
+	_, err = svc.DeleteObject(ctx, DeleteObjectInput{BucketName: "fenced", Key: "lease", OwnerID: consistencyOwnerID, IfSequence: sequence(1)})
+	require.ErrorIs(t, err, domain.ErrSequenceMismatch)
+	deleted, err := svc.DeleteObject(ctx, DeleteObjectInput{BucketName: "fenced", Key: "lease", OwnerID: consistencyOwnerID, IfSequence: sequence(2)})
+	require.NoError(t, err)
+	require.Equal(t, int64(3), deleted.Sequence)
+
+	_, err = svc.HeadObject(ctx, HeadObjectInput{BucketName: "fenced", Key: "lease", OwnerID: consistencyOwnerID})
+	require.ErrorIs(t, err, domain.ErrObjectNotFound)
+	_, err = putString(ctx, svc, "fenced", "lease", "stale", sequence(0))
+	require.ErrorIs(t, err, domain.ErrSequenceMismatch)
+	put, err = putString(ctx, svc, "fenced", "lease", "owner-c", sequence(3))
+	require.NoError(t, err)
+	require.Equal(t, int64(4), put.Sequence)
+}
+
+func TestConsistency_ListVersionsPages(t *testing.T) {
+	svc := newConsistencyService(t, "history", domain.VersioningEnabled)
+	ctx := context.Background()
+
+	for _, key := range []string{"a", "a", "a", "b", "b", "c"} {
+		_, err := putString(ctx, svc, "history", key, "body of "+key, nil)
+		require.NoError(t, err)
+	}
+	_, err := svc.DeleteObject(ctx, DeleteObjectInput{BucketName: "history", Key: "a", OwnerID: consistencyOwnerID})
+	require.NoError(t, err)
+
+	entries := func(out *ListObjectVersionsOutput) []string {
+		var ids []string
+		for _, v := range out.Versions {
+			ids = append(ids, v.Key+"/"+v.VersionID)
+		}
+		for _, dm := range out.DeleteMarkers {
+			ids = append(ids, dm.Key+"/"+dm.VersionID)
+		}
+		return ids
+	}
+
+	all, err := svc.ListObjectVersions(ctx, ListObjectVersionsInput{BucketName: "history", MaxKeys: 1000, OwnerID: consistencyOwnerID})
+	require.NoError(t, err)
+	require.False(t, all.IsTruncated)
+	want := entries(all)
+	require.Len(t, want, 7)
+
+	// Pages split keys between versions and pick up where the last ended
+	var got []string
+	input := ListObjectVersionsInput{BucketName: "history", MaxKeys: 2, OwnerID: consistencyOwnerID}
+	for page := 0; ; page++ {
+		require.Less(t, page, 10)
+		out, err := svc.ListObjectVersions(ctx, input)
+		require.NoError(t, err)
+		ids := entries(out)
+		require.LessOrEqual(t, len(ids), 2)
+		got = append(got, ids...)
+		if !out.IsTruncated {
+			break
+		}
+		require.NotEmpty(t, out.NextVersionIDMarker)
+		input.KeyMarker, input.VersionIDMarker = out.NextKeyMarker, out.NextVersionIDMarker
+	}
+	sort.Strings(want)
+	sort.Strings(got)
+	require.Equal(t, want, got)
+
+	// A key marker alone skips every version of that key
+	out, err := svc.ListObjectVersions(ctx, ListObjectVersionsInput{BucketName: "history", KeyMarker: "a", MaxKeys: 1000, OwnerID: consistencyOwnerID})
+	require.NoError(t, err)
+	require.Len(t, entries(out), 3)
+}
+
+func TestConsistency_RestoreObject(t *testing.T) {
+	svc := newConsistencyService(t, "history", domain.VersioningEnabled)
+	ctx := context.Background()
+
+	first, err := putString(ctx, svc, "history", "notes.txt", "v1", nil)
+	require.NoError(t, err)
+	second, err := putString(ctx, svc, "history", "notes.txt", "v2", nil)
+	require.NoError(t, err)
+	_, err = putString(ctx, svc, "history", "notes.txt.bak", "backup", nil)
+	require.NoError(t, err)
+
+	restore := func(versionID string) (*RestoreObjectOutput, error) {
+		return svc.RestoreObject(ctx, RestoreObjectInput{BucketName: "history", Key: "notes.txt", VersionID: versionID, OwnerID: consistencyOwnerID})
+	}
+
+	_, err = restore("")
+	require.ErrorIs(t, err, domain.ErrObjectNotDeleted)
+
+	// Removing the delete marker brings back the version it hid
+	deleted, err := svc.DeleteObject(ctx, DeleteObjectInput{BucketName: "history", Key: "notes.txt", OwnerID: consistencyOwnerID})
+	require.NoError(t, err)
+	require.Equal(t, []string{"notes.txt.bak"}, listKeys(t, ctx, svc, "history", ""))
+
+	restored, err := restore("")
+	require.NoError(t, err)
+	require.Equal(t, deleted.DeleteMarkerVersionID, restored.DeleteMarkerVersionID)
+	require.Equal(t, second.VersionID, restored.VersionID)
+	require.False(t, restored.Copied)
+	body, sequence := getString(t, ctx, svc, "history", "notes.txt")
+	require.Equal(t, "v2", body)
+	require.Equal(t, second.Sequence, sequence)
+	require.Equal(t, []string{"notes.txt", "notes.txt.bak"}, listKeys(t, ctx, svc, "history", ""))
+
+	// An older version is copied forward, keeping the history above it
+	restored, err = restore(first.VersionID)
+	require.NoError(t, err)
+	require.True(t, restored.Copied)
+	require.NotEqual(t, first.VersionID, restored.VersionID)
+	body, sequence = getString(t, ctx, svc, "history", "notes.txt")
+	require.Equal(t, "v1", body)
+	require.Equal(t, restored.Sequence, sequence)
+
+	versions, err := svc.ListObjectVersions(ctx, ListObjectVersionsInput{BucketName: "history", Prefix: "notes.txt", MaxKeys: 1000, OwnerID: consistencyOwnerID})
+	require.NoError(t, err)
+	require.Len(t, versions.Versions, 4)
+	require.Empty(t, versions.DeleteMarkers)
+
+	// The latest version is already restored
+	again, err := restore(restored.VersionID)
+	require.NoError(t, err)
+	require.False(t, again.Copied)
+	require.Equal(t, restored.VersionID, again.VersionID)
+
+	_, err = restore(deleted.DeleteMarkerVersionID)
+	require.ErrorIs(t, err, domain.ErrVersionNotFound)
+
+	// A restore waits for the key's write lock like any other write
+	bucket, err := svc.bucketRepo.GetByName(ctx, "history")
+	require.NoError(t, err)
+	lockKey := lock.Keys.ObjectWrite(bucket.ID, "notes.txt")
+	acquired, err := svc.locker.Acquire(ctx, lockKey, time.Minute)
+	require.NoError(t, err)
+	require.True(t, acquired)
+	waiting, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	_, err = svc.RestoreObject(waiting, RestoreObjectInput{BucketName: "history", Key: "notes.txt", VersionID: first.VersionID, OwnerID: consistencyOwnerID})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	_, err = svc.locker.Release(ctx, lockKey)
+	require.NoError(t, err)
+	_, err = restore(first.VersionID)
+	require.NoError(t, err)
+}
+
+func TestConsistency_UpdateObjectMetadata(t *testing.T) {
+	svc := newConsistencyService(t, "history", domain.VersioningEnabled)
+	ctx := context.Background()
+
+	put, err := putString(ctx, svc, "history", "notes.txt", "v1", nil)
+	require.NoError(t, err)
+	bucket, err := svc.bucketRepo.GetByName(ctx, "history")
+	require.NoError(t, err)
+	obj, err := svc.objectRepo.GetByKey(ctx, bucket.ID, "notes.txt")
+	require.NoError(t, err)
+	refs, err := svc.blobRepo.GetRefCount(ctx, *obj.ContentHash)
+	require.NoError(t, err)
+
+	updated, err := svc.UpdateObjectMetadata(ctx, UpdateObjectMetadataInput{
+		BucketName:  "history",
+		Key:         "notes.txt",
+		ContentType: "text/plain",
+		Metadata:    map[string]string{"author": "ada", "Cache-Control": "max-age=60"},
+		OwnerID:     consistencyOwnerID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, put.VersionID, updated.VersionID)
+	require.Equal(t, put.ETag, updated.ETag)
+	require.Equal(t, put.Sequence, updated.Sequence)
+
+	// The version is changed in place, keeping its data and references
+	head, err := svc.HeadObject(ctx, HeadObjectInput{BucketName: "history", Key: "notes.txt", OwnerID: consistencyOwnerID})
+	require.NoError(t, err)
+	require.Equal(t, "text/plain", head.ContentType)
+	require.Equal(t, map[string]string{"author": "ada", "Cache-Control": "max-age=60"}, head.Metadata)
+	body, sequence := getString(t, ctx, svc, "history", "notes.txt")
+	require.Equal(t, "v1", body)
+	require.Equal(t, put.Sequence, sequence)
+	after, err := svc.blobRepo.GetRefCount(ctx, *obj.ContentHash)
+	require.NoError(t, err)
+	require.Equal(t, refs, after)
+
+	versions, err := svc.ListObjectVersions(ctx, ListObjectVersionsInput{BucketName: "history", Prefix: "notes.txt", MaxKeys: 1000, OwnerID: consistencyOwnerID})
+	require.NoError(t, err)
+	require.Len(t, versions.Versions, 1)
+
+	// The update waits for the key's write lock like any other write
+	lockKey := lock.Keys.ObjectWrite(bucket.ID, "notes.txt")
+	acquired, err := svc.locker.Acquire(ctx, lockKey, time.Minute)
+	require.NoError(t, err)
+	require.True(t, acquired)
+	waiting, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	_, err = svc.UpdateObjectMetadata(waiting, UpdateObjectMetadataInput{BucketName: "history", Key: "notes.txt", OwnerID: consistencyOwnerID})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	_, err = svc.locker.Release(ctx, lockKey)
+	require.NoError(t, err)
+
+	_, err = svc.DeleteObject(ctx, DeleteObjectInput{BucketName: "history", Key: "notes.txt", OwnerID: consistencyOwnerID})
+	require.NoError(t, err)
+	_, err = svc.UpdateObjectMetadata(ctx, UpdateObjectMetadataInput{BucketName: "history", Key: "notes.txt", OwnerID: consistencyOwnerID})
+	require.ErrorIs(t, err, domain.ErrObjectNotFound)
+}
+
+func TestConsistency_RenameObject(t *testing.T) {
+	ctx := context.Background()
+	rename := func(svc *ObjectService, bucket, source, dest string) (*CopyObjectOutput, error) {
+		return svc.RenameObject(ctx, RenameObjectInput{BucketName: bucket, SourceKey: source, DestKey: dest, OwnerID: consistencyOwnerID})
+	}
+
+	t.Run("unversioned", func(t *testing.T) {
+		svc := newConsistencyService(t, "plain", domain.VersioningDisabled)
+		put, err := putString(ctx, svc, "plain", "draft.txt", "content", nil)
+		require.NoError(t, err)
+		_, err = putString(ctx, svc, "plain", "final.txt", "replaced", nil)
+		require.NoError(t, err)
+		bucket, err := svc.bucketRepo.GetByName(ctx, "plain")
+		require.NoError(t, err)
+		replaced, err := svc.objectRepo.GetByKey(ctx, bucket.ID, "final.txt")
+		require.NoError(t, err)
+
+		renamed, err := rename(svc, "plain", "draft.txt", "final.txt")
+		require.NoError(t, err)
+		require.Equal(t, put.ETag, renamed.ETag)
+		body, sequence := getString(t, ctx, svc, "plain", "final.txt")
+		require.Equal(t, "content", body)
+		require.Equal(t, renamed.Sequence, sequence)
+		require.Equal(t, []string{"final.txt"}, listKeys(t, ctx, svc, "plain", ""))
+
+		// The reference moved with the object; the replaced content lost its own
+		obj, err := svc.objectRepo.GetByKey(ctx, bucket.ID, "final.txt")
+		require.NoError(t, err)
+		refs, err := svc.blobRepo.GetRefCount(ctx, *obj.ContentHash)
+		require.NoError(t, err)
+		require.Equal(t, int32(1), refs)
+		refs, err = svc.blobRepo.GetRefCount(ctx, *replaced.ContentHash)
+		require.NoError(t, err)
+		require.Equal(t, int32(0), refs)
+
+		// The source key keeps counting writes after the rename
+		again, err := putString(ctx, svc, "plain", "draft.txt", "next", nil)
+		require.NoError(t, err)
+		require.Equal(t, put.Sequence+2, again.Sequence)
+
+		_, err = rename(svc, "plain", "missing.txt", "other.txt")
+		require.ErrorIs(t, err, domain.ErrObjectNotFound)
+		_, err = rename(svc, "plain", "final.txt", "final.txt")
+		require.ErrorIs(t, err, domain.ErrInvalidRename)
+	})
+
+	t.Run("versioned", func(t *testing.T) {
+		svc := newConsistencyService(t, "history", domain.VersioningEnabled)
+		put, err := putString(ctx, svc, "history", "notes.txt", "v1", nil)
+		require.NoError(t, err)
+
+		renamed, err := rename(svc, "history", "notes.txt", "archive/notes.txt")
+		require.NoError(t, err)
+		require.NotEqual(t, put.VersionID, renamed.VersionID)
+		body, _ := getString(t, ctx, svc, "history", "archive/notes.txt")
+		require.Equal(t, "v1", body)
+		require.Equal(t, []string{"archive/notes.txt"}, listKeys(t, ctx, svc, "history", ""))
+
+		// The source's history is kept behind a delete marker
+		versions, err := svc.ListObjectVersions(ctx, ListObjectVersionsInput{BucketName: "history", Prefix: "notes.txt", MaxKeys: 1000, OwnerID: consistencyOwnerID})
+		require.NoError(t, err)
+		require.Len(t, versions.Versions, 1)
+		require.Equal(t, put.VersionID, versions.Versions[0].VersionID)
+		require.Len(t, versions.DeleteMarkers, 1)
+
+		bucket, err := svc.bucketRepo.GetByName(ctx, "history")
+		require.NoError(t, err)
+		obj, err := svc.objectRepo.GetByKey(ctx, bucket.ID, "archive/notes.txt")
+		require.NoError(t, err)
+		refs, err := svc.blobRepo.GetRefCount(ctx, *obj.ContentHash)
+		require.NoError(t, err)
+		require.Equal(t, int32(2), refs)
+
+		_, err = rename(svc, "history", "notes.txt", "again.txt")
+		require.ErrorIs(t, err, domain.ErrObjectNotFound)
+	})
+}
+
+func TestConsistency_SuspendedVersioning(t *testing.T) {
+	svc := newConsistencyService(t, "history", domain.VersioningEnabled)
+	ctx := context.Background()
+
+	kept, err := putString(ctx, svc, "history", "notes.txt", "kept", nil)
+	require.NoError(t, err)
+	bucket, err := svc.bucketRepo.GetByName(ctx, "history")
+	require.NoError(t, err)
+	require.NoError(t, svc.bucketRepo.UpdateVersioning(ctx, bucket.ID, domain.VersioningSuspended))
+
+	versions := func() *ListObjectVersionsOutput {
+		t.Helper()
+		output, err := svc.ListObjectVersions(ctx, ListObjectVersionsInput{BucketName: "history", Prefix: "notes.txt", MaxKeys: 1000, OwnerID: consistencyOwnerID})
+		require.NoError(t, err)
+		return output
+	}
+	refs := func(versionID string) int32 {
+		t.Helper()
+		id, err := domain.ParseVersionID(versionID)
+		require.NoError(t, err)
+		obj, err := svc.objectRepo.GetByKeyAndVersion(ctx, bucket.ID, "notes.txt", id)
+		require.NoError(t, err)
+		count, err := svc.blobRepo.GetRefCount(ctx, *obj.ContentHash)
+		require.NoError(t, err)
+		return count
+	}
+
+	// Writes replace the null version and keep the versioned history
+	first, err := putString(ctx, svc, "history", "notes.txt", "first", nil)
+	require.NoError(t, err)
+	require.Equal(t, "null", first.VersionID)
+	second, err := putString(ctx, svc, "history", "notes.txt", "second", nil)
+	require.NoError(t, err)
+	require.Equal(t, "null", second.VersionID)
+
+	listed := versions()
+	require.Len(t, listed.Versions, 2)
+	require.Equal(t, "null", listed.Versions[0].VersionID)
+	require.True(t, listed.Versions[0].IsLatest)
+	require.Equal(t, kept.VersionID, listed.Versions[1].VersionID)
+	require.Equal(t, int32(1), refs(kept.VersionID))
+	require.Equal(t, int32(1), refs("null"))
+
+	output, err := svc.GetObject(ctx, GetObjectInput{BucketName: "history", Key: "notes.txt", VersionID: "null", OwnerID: consistencyOwnerID})
+	require.NoError(t, err)
+	data, err := io.ReadAll(output.Body)
+	output.Body.Close()
+	require.NoError(t, err)
+	require.Equal(t, "second", string(data))
+	head, err := svc.HeadObject(ctx, HeadObjectInput{BucketName: "history", Key: "notes.txt", VersionID: kept.VersionID, OwnerID: consistencyOwnerID})
+	require.NoError(t, err)
+	require.Equal(t, int64(len("kept")), head.ContentLength)
+
+	// A delete replaces the null version with a null delete marker
+	deleted, err := svc.DeleteObject(ctx, DeleteObjectInput{BucketName: "history", Key: "notes.txt", OwnerID: consistencyOwnerID})
+	require.NoError(t, err)
+	require.True(t, deleted.DeleteMarker)
+	require.Equal(t, "null", deleted.VersionID)
+	listed = versions()
+	require.Len(t, listed.Versions, 1)
+	require.Equal(t, kept.VersionID, listed.Versions[0].VersionID)
+	require.Len(t, listed.DeleteMarkers, 1)
+	require.Equal(t, "null", listed.DeleteMarkers[0].VersionID)
+	require.Empty(t, listKeys(t, ctx, svc, "history", ""))
+
+	// Deleting the null marker brings back the version below it
+	_, err = svc.DeleteObject(ctx, DeleteObjectInput{BucketName: "history", Key: "notes.txt", VersionID: "null", OwnerID: consistencyOwnerID})
+	require.NoError(t, err)
+	body, _ := getString(t, ctx, svc, "history", "notes.txt")
+	require.Equal(t, "kept", body)
+
+	_, err = svc.GetObject(ctx, GetObjectInput{BucketName: "history", Key: "notes.txt", VersionID: "null", OwnerID: consistencyOwnerID})
+	require.ErrorIs(t, err, domain.ErrObjectNotFound)
+}
@@ -10,7 +10,7 @@ var (
 	ErrUserAlreadyExists  = errors.New("user already exists")
 	ErrUserInactive       = errors.New("user is inactive")
 	ErrInvalidCredentials = errors.New("invalid credentials")
-	ErrInvalidPassword    = errors.New("invalid password: must be at least 8 characters")
+	ErrInvalidPassword    = errors.New("invalid password")
 	ErrInvalidUsername    = errors.New("invalid username: must be 3-255 characters")
 	ErrInvalidEmail       = errors.New("invalid email format")
 
@@ -30,17 +30,49 @@ var (
 	// Bucket errors
 	ErrBucketAccessDenied      = errors.New("access denied to bucket")
 	ErrInvalidVersioningStatus = errors.New("invalid versioning status: must be Enabled or Suspended")
+	ErrInvalidBucketACL        = errors.New("invalid ACL: must be private, public-read or public-read-write")
+	ErrResidencyViolation      = errors.New("bucket residency does not permit storing data on this server")
+
+	// Object errors
+	ErrObjectBusy       = errors.New("object is being modified by another request")
+	ErrInvalidObjectACL = errors.New("invalid object ACL: must be private or public-read")
+
+	// ErrRestoreNotSupported is returned by RestoreArchivedObject when no
+	// tiering controller runs, so no object was ever archived.
+	ErrRestoreNotSupported = errors.New("restoring archived objects requires the tiering controller")
+
+	// Multipart errors
+	ErrUploadBusy = errors.New("multipart upload is being modified by another request")
 
 	// Session errors
-	ErrSessionNotFound = errors.New("session not found")
-	ErrSessionExpired  = errors.New("session has expired")
-	ErrNotAdminUser    = errors.New("user is not an admin")
+	ErrSessionNotFound        = errors.New("session not found")
+	ErrSessionExpired         = errors.New("session has expired")
+	ErrNoDashboardRole        = errors.New("user has no dashboard role")
+	ErrInsufficientRole       = errors.New("user role does not permit this action")
+	ErrPasswordChangeRequired = errors.New("password must be changed before login")
 
 	// Lifecycle errors
 	ErrLifecycleRuleNotFound      = errors.New("lifecycle rule not found")
 	ErrLifecycleRuleAlreadyExists = errors.New("lifecycle rule already exists")
 	ErrInvalidLifecycleRule       = errors.New("invalid lifecycle rule")
 
+	// Replication errors
+	ErrReplicationRequiresVersioning = errors.New("versioning must be enabled on the bucket to configure replication")
+	ErrReplicationSecretRequired     = errors.New("a secret access key is required for the destination")
+
+	// Inventory errors
+	ErrInventoryDestinationNotOwned = errors.New("inventory destination bucket must belong to the source bucket owner")
+
+	// Manifest errors
+	ErrInvalidManifestCursor = errors.New("invalid manifest cursor")
+
+	// Backup errors
+	ErrInvalidBackup      = errors.New("invalid backup archive")
+	ErrIncompatibleBackup = errors.New("backup does not match this deployment")
+
+	// Metering errors
+	ErrInvalidUsagePeriod = errors.New("invalid usage period: month must be YYYY-MM")
+
 	// General errors
 	ErrEncryptionFailed = errors.New("encryption failed")
 	ErrDecryptionFailed = errors.New("decryption failed")
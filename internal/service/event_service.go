@@ -0,0 +1,219 @@
+// Package service provides business logic services for Alexander Storage.
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// eventPruneInterval is how often events older than the retention are removed.
+const eventPruneInterval = 10 * time.Minute
+
+// EventService serves object change events from the outbox to watch clients
+// and prunes events older than the retention period.
+type EventService struct {
+	eventRepo  repository.EventRepository
+	bucketRepo repository.BucketRepository
+	logger     zerolog.Logger
+	config     EventConfig
+
+	// Control
+	mu       sync.Mutex
+	running  bool
+	stopChan chan struct{}
+	doneChan chan struct{}
+
+	// now is replaceable in tests.
+	now func() time.Time
+}
+
+// EventConfig contains event outbox configuration.
+type EventConfig struct {
+	// Retention is how long events are kept.
+	Retention time.Duration
+
+	// SettleDelay holds back events this recent. IDs are assigned when an
+	// event is inserted, so a transaction that commits late can make a lower
+	// ID visible after a higher one has been delivered.
+	SettleDelay time.Duration
+
+	// BatchSize is the maximum number of events returned per poll.
+	BatchSize int
+}
+
+// NewEventService creates a new event service.
+func NewEventService(
+	eventRepo repository.EventRepository,
+	bucketRepo repository.BucketRepository,
+	logger zerolog.Logger,
+	config EventConfig,
+) *EventService {
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+
+	return &EventService{
+		eventRepo:  eventRepo,
+		bucketRepo: bucketRepo,
+		logger:     logger.With().Str("service", "events").Logger(),
+		config:     config,
+		stopChan:   make(chan struct{}),
+		doneChan:   make(chan struct{}),
+		now:        time.Now,
+	}
+}
+
+// Start begins pruning expired events in the background.
+func (s *EventService) Start() {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = true
+	s.mu.Unlock()
+
+	s.logger.Info().Dur("retention", s.config.Retention).Msg("Starting event outbox pruning")
+
+	go s.runLoop()
+}
+
+// Stop stops pruning and waits for the background loop to exit.
+func (s *EventService) Stop() {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = false
+	s.mu.Unlock()
+
+	close(s.stopChan)
+	<-s.doneChan
+
+	s.logger.Info().Msg("Event outbox pruning stopped")
+}
+
+// runLoop prunes the outbox periodically until stopped.
+func (s *EventService) runLoop() {
+	defer close(s.doneChan)
+
+	ticker := time.NewTicker(eventPruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := s.Prune(context.Background()); err != nil {
+				s.logger.Error().Err(err).Msg("Failed to prune events")
+			}
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+// Prune removes events older than the retention period.
+func (s *EventService) Prune(ctx context.Context) (int64, error) {
+	deleted, err := s.eventRepo.DeleteBefore(ctx, s.now().Add(-s.config.Retention))
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	if deleted > 0 {
+		s.logger.Debug().Ctx(ctx).Int64("deleted", deleted).Msg("pruned expired events")
+	}
+	return deleted, nil
+}
+
+// =============================================================================
+// Watch
+// =============================================================================
+
+// WatchInput contains the data needed to watch a bucket for changes.
+type WatchInput struct {
+	// BucketName is the bucket to watch. Administrators (OwnerID 0) may
+	// leave it empty to watch every bucket and the blob events of the
+	// garbage collector.
+	BucketName string
+	Prefix     string
+	OwnerID    int64
+
+	// AfterID resumes after this event ID when Resume is set; otherwise
+	// only changes made after the watch is opened are delivered.
+	AfterID int64
+	Resume  bool
+}
+
+// Watch is an open watch on a bucket. It is not safe for concurrent use.
+type Watch struct {
+	service *EventService
+	filter  repository.EventFilter
+}
+
+// OpenWatch validates access to the bucket and positions a watch cursor.
+func (s *EventService) OpenWatch(ctx context.Context, input WatchInput) (*Watch, error) {
+	filter := repository.EventFilter{
+		Prefix: input.Prefix,
+		Limit:  s.config.BatchSize,
+	}
+
+	if input.BucketName != "" {
+		bucket, err := s.bucketRepo.GetByName(ctx, input.BucketName)
+		if err != nil {
+			if errors.Is(err, domain.ErrBucketNotFound) {
+				return nil, domain.ErrBucketNotFound
+			}
+			return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+		}
+
+		// Check ownership
+		if input.OwnerID > 0 && bucket.OwnerID != input.OwnerID {
+			return nil, ErrBucketAccessDenied
+		}
+		filter.BucketName = bucket.Name
+	} else if input.OwnerID > 0 {
+		return nil, ErrBucketAccessDenied
+	}
+
+	filter.AfterID = input.AfterID
+	if !input.Resume {
+		latestID, err := s.eventRepo.LatestID(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+		}
+		filter.AfterID = latestID
+	}
+
+	return &Watch{service: s, filter: filter}, nil
+}
+
+// Cursor returns the ID of the last event delivered.
+func (w *Watch) Cursor() int64 {
+	return w.filter.AfterID
+}
+
+// Poll returns the events recorded since the last poll and advances the
+// cursor. It returns an empty slice when there is nothing new.
+func (w *Watch) Poll(ctx context.Context) ([]*domain.ObjectEvent, error) {
+	filter := w.filter
+	if w.service.config.SettleDelay > 0 {
+		filter.CreatedBefore = w.service.now().Add(-w.service.config.SettleDelay)
+	}
+
+	events, err := w.service.eventRepo.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	if len(events) > 0 {
+		w.filter.AfterID = events[len(events)-1].ID
+	}
+	return events, nil
+}
@@ -0,0 +1,127 @@
+// Package service provides business logic services for Alexander Storage.
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/lock"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// mockEventRepository is a mock implementation of EventRepository.
+type mockEventRepository struct {
+	mock.Mock
+}
+
+func (m *mockEventRepository) Append(ctx context.Context, event *domain.ObjectEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+func (m *mockEventRepository) List(ctx context.Context, filter repository.EventFilter) ([]*domain.ObjectEvent, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.ObjectEvent), args.Error(1)
+}
+
+func (m *mockEventRepository) LatestID(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockEventRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func TestEventService_WatchDeliversEventsAfterCursor(t *testing.T) {
+	eventRepo := new(mockEventRepository)
+	bucketRepo := new(mockBucketRepository)
+	svc := NewEventService(eventRepo, bucketRepo, zerolog.Nop(), EventConfig{SettleDelay: 2 * time.Second, BatchSize: 10})
+	now := time.Date(2024, 6, 3, 10, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	bucketRepo.On("GetByName", mock.Anything, "photos").Return(&domain.Bucket{ID: 1, Name: "photos", OwnerID: 7}, nil)
+
+	// Other users cannot watch the bucket
+	_, err := svc.OpenWatch(ctx, WatchInput{BucketName: "photos", OwnerID: 8})
+	require.ErrorIs(t, err, ErrBucketAccessDenied)
+
+	// Without a cursor the watch starts at the newest event
+	eventRepo.On("LatestID", mock.Anything).Return(int64(41), nil).Once()
+	watch, err := svc.OpenWatch(ctx, WatchInput{BucketName: "photos", Prefix: "2024/", OwnerID: 7})
+	require.NoError(t, err)
+	require.Equal(t, int64(41), watch.Cursor())
+
+	eventRepo.On("List", mock.Anything, repository.EventFilter{
+		BucketName:    "photos",
+		Prefix:        "2024/",
+		AfterID:       41,
+		CreatedBefore: now.Add(-2 * time.Second),
+		Limit:         10,
+	}).Return([]*domain.ObjectEvent{{ID: 42}, {ID: 45}}, nil).Once()
+
+	events, err := watch.Poll(ctx)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.Equal(t, int64(45), watch.Cursor())
+
+	// A resumed watch starts at the client's cursor
+	watch, err = svc.OpenWatch(ctx, WatchInput{BucketName: "photos", OwnerID: 7, AfterID: 3, Resume: true})
+	require.NoError(t, err)
+	require.Equal(t, int64(3), watch.Cursor())
+
+	// Only administrators watch every bucket, blob events included
+	_, err = svc.OpenWatch(ctx, WatchInput{OwnerID: 7})
+	require.ErrorIs(t, err, ErrBucketAccessDenied)
+	watch, err = svc.OpenWatch(ctx, WatchInput{AfterID: 45, Resume: true})
+	require.NoError(t, err)
+
+	eventRepo.On("List", mock.Anything, repository.EventFilter{
+		AfterID:       45,
+		CreatedBefore: now.Add(-2 * time.Second),
+		Limit:         10,
+	}).Return([]*domain.ObjectEvent{{ID: 46, Type: domain.ObjectEventBlobGarbageCollected}}, nil).Once()
+
+	events, err = watch.Poll(ctx)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+
+	eventRepo.AssertExpectations(t)
+}
+
+func TestObjectService_DeleteObject_RecordsEvent(t *testing.T) {
+	objectRepo := new(mockObjectRepository)
+	blobRepo := new(mockBlobRepository2)
+	bucketRepo := new(mockBucketRepository)
+	eventRepo := new(mockEventRepository)
+	svc := NewObjectService(objectRepo, blobRepo, bucketRepo, eventRepo, nil, nil, nil, nil, nil, &mockTxManager{}, new(mockStorageBackend2), lock.NewNoOpLocker(), nil, zerolog.Nop())
+
+	bucket := &domain.Bucket{ID: 1, Name: "versioned-bucket", OwnerID: 1, Versioning: domain.VersioningEnabled}
+	bucketRepo.On("GetByName", mock.Anything, "versioned-bucket").Return(bucket, nil)
+	objectRepo.On("MarkNotLatest", mock.Anything, int64(1), "a.txt").Return(nil)
+	objectRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	var recorded *domain.ObjectEvent
+	eventRepo.On("Append", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		recorded = args.Get(1).(*domain.ObjectEvent)
+	}).Return(nil)
+
+	output, err := svc.DeleteObject(context.Background(), DeleteObjectInput{BucketName: "versioned-bucket", Key: "a.txt", OwnerID: 1})
+	require.NoError(t, err)
+	require.NotNil(t, recorded)
+	require.Equal(t, domain.ObjectEventRemovedDeleteMarker, recorded.Type)
+	require.Equal(t, "versioned-bucket", recorded.BucketName)
+	require.Equal(t, "a.txt", recorded.Key)
+	require.Equal(t, output.VersionID, recorded.VersionID)
+}
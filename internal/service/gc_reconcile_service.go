@@ -0,0 +1,338 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/lock"
+	"github.com/prn-tf/alexander-storage/internal/metrics"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// GCReconciler recomputes blob reference counts from the object versions,
+// upload parts, staged objects and snapshot objects holding them, and
+// corrects the stored counts that drifted. A count that is too high keeps
+// garbage from being collected; one that is too low lets GC delete content
+// still in use.
+//
+// A run holds the GC lock, so GC does not delete blobs whose count is
+// being corrected. Blobs GC already started collecting are reported, not
+// corrected.
+type GCReconciler struct {
+	blobRepo repository.BlobRepository
+	locker   lock.Locker
+	metrics  *metrics.Metrics
+	logger   zerolog.Logger
+	config   GCReconcileConfig
+
+	// Control
+	mu       sync.Mutex
+	running  bool
+	stopChan chan struct{}
+	doneChan chan struct{}
+}
+
+// GCReconcileConfig contains reference count reconciliation configuration.
+type GCReconcileConfig struct {
+	// Interval is how often a scheduled reconciliation runs.
+	Interval time.Duration
+
+	// BatchSize is the number of blobs counted at a time.
+	BatchSize int
+
+	// DryRun reports drift without correcting it.
+	DryRun bool
+
+	// MaxFindings caps the findings listed in a result. Counts are always
+	// complete.
+	MaxFindings int
+}
+
+// DefaultGCReconcileConfig returns sensible defaults.
+func DefaultGCReconcileConfig() GCReconcileConfig {
+	return GCReconcileConfig{
+		Interval:    24 * time.Hour,
+		BatchSize:   1000,
+		MaxFindings: 1000,
+	}
+}
+
+// gcActionFixed is the action of a corrected reference count.
+const gcActionFixed = "fixed"
+
+// NewGCReconciler creates a new reference count reconciler.
+func NewGCReconciler(
+	blobRepo repository.BlobRepository,
+	locker lock.Locker,
+	m *metrics.Metrics,
+	logger zerolog.Logger,
+	config GCReconcileConfig,
+) *GCReconciler {
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultGCReconcileConfig().BatchSize
+	}
+	return &GCReconciler{
+		blobRepo: blobRepo,
+		locker:   locker,
+		metrics:  m,
+		logger:   logger.With().Str("service", "gc-reconcile").Logger(),
+		config:   config,
+		stopChan: make(chan struct{}),
+		doneChan: make(chan struct{}),
+	}
+}
+
+// Start begins the reconciliation scheduler. The first run starts after
+// one interval.
+func (r *GCReconciler) Start() {
+	r.mu.Lock()
+	if r.running {
+		r.mu.Unlock()
+		return
+	}
+	r.running = true
+	r.mu.Unlock()
+
+	r.logger.Info().
+		Dur("interval", r.config.Interval).
+		Bool("dry_run", r.config.DryRun).
+		Msg("Starting reference count reconciler")
+
+	go r.runLoop()
+}
+
+// Stop stops the reconciliation scheduler, interrupting a run in progress.
+func (r *GCReconciler) Stop() {
+	r.mu.Lock()
+	if !r.running {
+		r.mu.Unlock()
+		return
+	}
+	r.running = false
+	r.mu.Unlock()
+
+	close(r.stopChan)
+	<-r.doneChan
+
+	r.logger.Info().Msg("Reference count reconciler stopped")
+}
+
+// runLoop is the main reconciliation loop.
+func (r *GCReconciler) runLoop() {
+	defer close(r.doneChan)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-r.stopChan
+		cancel()
+	}()
+
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.RunOnce(ctx)
+		case <-r.stopChan:
+			return
+		}
+	}
+}
+
+// GCReconcileFinding is a blob whose stored reference count is wrong.
+type GCReconcileFinding struct {
+	// ContentHash identifies the blob.
+	ContentHash string `json:"content_hash"`
+
+	// Size is the blob's size.
+	Size int64 `json:"size"`
+
+	// Stored is the reference count stored on the blob.
+	Stored int32 `json:"stored"`
+
+	// Actual is the number of references counted.
+	Actual int32 `json:"actual"`
+
+	// GCState is set if GC started collecting the blob.
+	GCState domain.BlobGCState `json:"gc_state,omitempty"`
+
+	// Action is "fixed" if the count was corrected. Empty if it was only
+	// reported.
+	Action string `json:"action,omitempty"`
+
+	// Error is why the count could not be corrected.
+	Error string `json:"error,omitempty"`
+}
+
+// GCReconcileResult contains the result of a reconciliation run.
+type GCReconcileResult struct {
+	// Blobs is the number of blobs checked.
+	Blobs int `json:"blobs"`
+
+	// Drifted is the number of blobs whose stored count was wrong.
+	Drifted int `json:"drifted"`
+
+	// DriftedRefs is the sum of the differences between stored and
+	// counted references.
+	DriftedRefs int64 `json:"drifted_refs"`
+
+	// Fixed is the number of counts corrected.
+	Fixed int `json:"fixed"`
+
+	// Skipped is the number of drifted counts changed by a write while
+	// they were being corrected; the next run checks them again.
+	Skipped int `json:"skipped"`
+
+	// Errors is the number of drifted counts that could not be corrected,
+	// including referenced blobs GC started collecting, plus one if the
+	// run stopped early.
+	Errors int `json:"errors"`
+
+	// DryRun reports that drift was not corrected.
+	DryRun bool `json:"dry_run,omitempty"`
+
+	// Findings lists the drifted blobs, up to MaxFindings.
+	Findings []GCReconcileFinding `json:"findings"`
+
+	// Truncated reports that more findings exist than are listed.
+	Truncated bool `json:"truncated,omitempty"`
+
+	// Duration is how long the run took.
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// addFinding lists a finding unless the list is full.
+func (r *GCReconcileResult) addFinding(finding GCReconcileFinding, max int) {
+	if len(r.Findings) >= max {
+		r.Truncated = true
+		return
+	}
+	r.Findings = append(r.Findings, finding)
+}
+
+// RunOnce checks the reference counts of all blobs once.
+// This can be called manually or by the scheduler.
+func (r *GCReconciler) RunOnce(ctx context.Context) GCReconcileResult {
+	start := time.Now()
+	result := GCReconcileResult{DryRun: r.config.DryRun, Findings: []GCReconcileFinding{}}
+
+	lockKey := lock.Keys.BlobGC()
+	lockTTL := 5 * time.Minute
+	lease, err := r.locker.AcquireLease(ctx, lockKey, lockTTL)
+	if err != nil {
+		r.logger.Error().Err(err).Msg("Failed to acquire GC lock")
+		result.Errors++
+		result.Duration = time.Since(start)
+		return result
+	}
+	if lease == nil {
+		r.logger.Debug().Msg("GC lock held by another process, skipping reconciliation")
+		result.Duration = time.Since(start)
+		return result
+	}
+	defer func() {
+		if _, err := r.locker.ReleaseLease(context.WithoutCancel(ctx), lease); err != nil {
+			r.logger.Error().Err(err).Msg("Failed to release GC lock")
+		}
+	}()
+
+	runCtx, stopRenewal := lock.KeepAlive(ctx, r.locker, lease, lockTTL)
+	after := ""
+	for {
+		counts, err := r.blobRepo.CountRefsAfter(runCtx, after, r.config.BatchSize)
+		if err != nil {
+			if runCtx.Err() == nil {
+				r.logger.Error().Err(err).Msg("Reference count reconciliation stopped early")
+			}
+			result.Errors++
+			break
+		}
+		for _, count := range counts {
+			result.Blobs++
+			if count.Drifted() {
+				r.reconcile(runCtx, count, &result)
+			}
+		}
+		if len(counts) < r.config.BatchSize {
+			break
+		}
+		after = counts[len(counts)-1].ContentHash
+	}
+	if err := stopRenewal(); err != nil {
+		r.logger.Error().Err(err).Msg("GC lock lost, aborting reconciliation")
+		result.Errors++
+	}
+
+	result.Duration = time.Since(start)
+
+	if r.metrics != nil && ctx.Err() == nil {
+		r.metrics.RecordGCReconcile(result.Drifted, result.DriftedRefs, result.Fixed)
+	}
+
+	event := r.logger.Info()
+	if result.Drifted > result.Fixed {
+		event = r.logger.Warn()
+	}
+	event.
+		Int("blobs", result.Blobs).
+		Int("drifted", result.Drifted).
+		Int64("drifted_refs", result.DriftedRefs).
+		Int("fixed", result.Fixed).
+		Int("skipped", result.Skipped).
+		Int("errors", result.Errors).
+		Bool("dry_run", result.DryRun).
+		Dur("duration", result.Duration).
+		Msg("Reference count reconciliation completed")
+
+	return result
+}
+
+// reconcile corrects the stored count of a drifted blob.
+func (r *GCReconciler) reconcile(ctx context.Context, count *domain.BlobRefCount, result *GCReconcileResult) {
+	result.Drifted++
+	result.DriftedRefs += max(int64(count.Actual-count.Stored), int64(count.Stored-count.Actual))
+	finding := GCReconcileFinding{
+		ContentHash: count.ContentHash,
+		Size:        count.Size,
+		Stored:      count.Stored,
+		Actual:      count.Actual,
+		GCState:     count.GCState,
+	}
+	logger := r.logger.With().
+		Str("content_hash", count.ContentHash).
+		Int32("stored", count.Stored).
+		Int32("actual", count.Actual).
+		Logger()
+
+	switch {
+	case count.GCState != domain.BlobGCStateNone:
+		// GC may have deleted the content already; scrub and gc verify
+		// report it if so
+		logger.Error().Str("gc_state", string(count.GCState)).Msg("Referenced blob is being garbage collected")
+		finding.Error = "blob is being garbage collected"
+		result.Errors++
+	case r.config.DryRun:
+		logger.Warn().Msg("[DRY RUN] Would correct blob reference count")
+	default:
+		fixed, err := r.blobRepo.SetRefCount(ctx, count.ContentHash, count.Stored, count.Actual)
+		switch {
+		case err != nil:
+			logger.Warn().Err(err).Msg("Failed to correct blob reference count")
+			finding.Error = err.Error()
+			result.Errors++
+		case !fixed:
+			result.Skipped++
+		default:
+			logger.Warn().Msg("Corrected blob reference count")
+			finding.Action = gcActionFixed
+			result.Fixed++
+		}
+	}
+	result.addFinding(finding, r.config.MaxFindings)
+}
@@ -3,11 +3,15 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/rs/zerolog"
 
+	"github.com/prn-tf/alexander-storage/internal/domain"
 	"github.com/prn-tf/alexander-storage/internal/lock"
 	"github.com/prn-tf/alexander-storage/internal/metrics"
 	"github.com/prn-tf/alexander-storage/internal/repository"
@@ -16,18 +20,23 @@ import (
 
 // GarbageCollector handles cleanup of orphan blobs.
 type GarbageCollector struct {
-	blobRepo repository.BlobRepository
-	storage  storage.Backend
-	locker   lock.Locker
-	metrics  *metrics.Metrics
-	logger   zerolog.Logger
-	config   GCConfig
+	blobRepo  repository.BlobRepository
+	eventRepo repository.EventRepository
+	txManager repository.TxManager
+	storage   storage.Backend
+	locker    lock.Locker
+	metrics   *metrics.Metrics
+	logger    zerolog.Logger
+	config    GCConfig
 
 	// Control
-	mu       sync.Mutex
-	running  bool
-	stopChan chan struct{}
-	doneChan chan struct{}
+	mu           sync.Mutex
+	running      bool
+	lastRun      time.Time
+	lastResult   *GCResult
+	reconfigured chan struct{}
+	stopChan     chan struct{}
+	doneChan     chan struct{}
 }
 
 // GCConfig contains garbage collection configuration.
@@ -42,27 +51,50 @@ type GCConfig struct {
 	// This prevents race conditions during uploads.
 	GracePeriod time.Duration
 
-	// BatchSize is the maximum number of blobs to process per run.
+	// BatchSize is the maximum number of blobs to process per run. The next
+	// run continues where this one stopped.
 	BatchSize int
 
+	// Workers is the number of blobs deleted concurrently.
+	Workers int
+
+	// ProgressInterval is how often a run logs its progress and updates the
+	// GC metrics. Zero reports only when the run completes.
+	ProgressInterval time.Duration
+
 	// DryRun logs what would be deleted without actually deleting.
 	DryRun bool
+
+	// DeleteRetries is how many times a failed storage deletion is retried
+	// within a run before the blob is left for the next run.
+	DeleteRetries int
+
+	// RetryBackoff is the delay before the first retry; it doubles on each retry.
+	RetryBackoff time.Duration
 }
 
 // DefaultGCConfig returns sensible defaults.
 func DefaultGCConfig() GCConfig {
 	return GCConfig{
-		Enabled:     true,
-		Interval:    1 * time.Hour,
-		GracePeriod: 24 * time.Hour,
-		BatchSize:   1000,
-		DryRun:      false,
+		Enabled:          true,
+		Interval:         1 * time.Hour,
+		GracePeriod:      24 * time.Hour,
+		BatchSize:        1000,
+		Workers:          4,
+		ProgressInterval: 30 * time.Second,
+		DryRun:           false,
+		DeleteRetries:    3,
+		RetryBackoff:     500 * time.Millisecond,
 	}
 }
 
 // NewGarbageCollector creates a new garbage collector.
+// eventRepo may be nil, in which case deleted blobs are not recorded as
+// events and txManager may be nil as well.
 func NewGarbageCollector(
 	blobRepo repository.BlobRepository,
+	eventRepo repository.EventRepository,
+	txManager repository.TxManager,
 	storage storage.Backend,
 	locker lock.Locker,
 	m *metrics.Metrics,
@@ -70,17 +102,43 @@ func NewGarbageCollector(
 	config GCConfig,
 ) *GarbageCollector {
 	return &GarbageCollector{
-		blobRepo: blobRepo,
-		storage:  storage,
-		locker:   locker,
-		metrics:  m,
-		logger:   logger.With().Str("service", "gc").Logger(),
-		config:   config,
-		stopChan: make(chan struct{}),
-		doneChan: make(chan struct{}),
+		blobRepo:  blobRepo,
+		eventRepo: eventRepo,
+		txManager: txManager,
+		storage:   storage,
+		locker:    locker,
+		metrics:   m,
+		logger:    logger.With().Str("service", "gc").Logger(),
+		config:    config,
+
+		reconfigured: make(chan struct{}, 1),
+		stopChan:     make(chan struct{}),
+		doneChan:     make(chan struct{}),
+	}
+}
+
+// Reconfigure changes the settings of a running collector. A run in
+// progress picks up the new settings as it goes, and the schedule restarts
+// with the new interval. Enabled is ignored.
+func (gc *GarbageCollector) Reconfigure(config GCConfig) {
+	gc.mu.Lock()
+	config.Enabled = gc.config.Enabled
+	gc.config = config
+	gc.mu.Unlock()
+
+	select {
+	case gc.reconfigured <- struct{}{}:
+	default:
 	}
 }
 
+// settings returns the current configuration.
+func (gc *GarbageCollector) settings() GCConfig {
+	gc.mu.Lock()
+	defer gc.mu.Unlock()
+	return gc.config
+}
+
 // Start begins the garbage collection scheduler.
 func (gc *GarbageCollector) Start() {
 	gc.mu.Lock()
@@ -89,13 +147,15 @@ func (gc *GarbageCollector) Start() {
 		return
 	}
 	gc.running = true
+	config := gc.config
 	gc.mu.Unlock()
 
 	gc.logger.Info().
-		Dur("interval", gc.config.Interval).
-		Dur("grace_period", gc.config.GracePeriod).
-		Int("batch_size", gc.config.BatchSize).
-		Bool("dry_run", gc.config.DryRun).
+		Dur("interval", config.Interval).
+		Dur("grace_period", config.GracePeriod).
+		Int("batch_size", config.BatchSize).
+		Int("workers", config.Workers).
+		Bool("dry_run", config.DryRun).
 		Msg("Starting garbage collector")
 
 	go gc.runLoop()
@@ -124,13 +184,15 @@ func (gc *GarbageCollector) runLoop() {
 	// Run immediately on start
 	gc.runOnce()
 
-	ticker := time.NewTicker(gc.config.Interval)
+	ticker := time.NewTicker(gc.settings().Interval)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ticker.C:
 			gc.runOnce()
+		case <-gc.reconfigured:
+			ticker.Reset(gc.settings().Interval)
 		case <-gc.stopChan:
 			return
 		}
@@ -151,172 +213,443 @@ func (gc *GarbageCollector) runOnce() {
 
 // GCResult contains the result of a garbage collection run.
 type GCResult struct {
+	// StartedAt is when the run started.
+	StartedAt time.Time `json:"started_at"`
+
 	// BlobsDeleted is the number of blobs deleted.
-	BlobsDeleted int
+	BlobsDeleted int `json:"blobs_deleted"`
 
 	// BytesFreed is the total bytes freed.
-	BytesFreed int64
+	BytesFreed int64 `json:"bytes_freed"`
 
 	// Errors is the number of errors encountered.
-	Errors int
+	Errors int `json:"errors"`
 
 	// Duration is how long the run took.
-	Duration time.Duration
+	Duration time.Duration `json:"duration_ns"`
 
 	// OrphanBlobsRemaining is the approximate number of orphan blobs still pending.
-	OrphanBlobsRemaining int
+	OrphanBlobsRemaining int `json:"orphan_blobs_remaining"`
+
+	// Resumed is the number of blobs whose deletion was interrupted by an
+	// earlier run and picked up again.
+	Resumed int `json:"resumed"`
+
+	// Skipped is the number of blobs that were referenced again before deletion.
+	Skipped int `json:"skipped"`
 }
 
+// gcPageSize is the number of orphan blobs listed at a time.
+const gcPageSize = 1000
+
 // runWithContext executes garbage collection with the given context.
+//
+// Orphans are walked in content hash order from a cursor saved after each
+// page, so a run that is interrupted or reaches BatchSize is continued by
+// the next one, and blobs that keep failing are retried once per walk
+// instead of being listed again first.
 func (gc *GarbageCollector) runWithContext(ctx context.Context) GCResult {
 	start := time.Now()
-	result := GCResult{}
+	result := GCResult{StartedAt: start}
 
 	gc.logger.Debug().Msg("Starting garbage collection run")
 
 	// Acquire distributed lock to prevent concurrent GC runs
 	lockKey := lock.Keys.BlobGC()
-	lockTTL := gc.config.Interval / 2 // Lock expires before next scheduled run
+	lockTTL := gc.settings().Interval / 2 // Lock expires before next scheduled run
 	if lockTTL < 5*time.Minute {
 		lockTTL = 5 * time.Minute
 	}
 
-	acquired, err := gc.locker.Acquire(ctx, lockKey, lockTTL)
+	lease, err := gc.locker.AcquireLease(ctx, lockKey, lockTTL)
 	if err != nil {
 		gc.logger.Error().Err(err).Msg("Failed to acquire GC lock")
 		result.Errors++
 		result.Duration = time.Since(start)
 		return result
 	}
-	if !acquired {
+	if lease == nil {
+		// Another process is collecting, which keeps garbage from lagging
 		gc.logger.Debug().Msg("GC lock held by another process, skipping run")
+		gc.setLastRun(start, nil)
 		result.Duration = time.Since(start)
 		return result
 	}
 	defer func() {
-		if _, err := gc.locker.Release(ctx, lockKey); err != nil {
+		if _, err := gc.locker.ReleaseLease(context.WithoutCancel(ctx), lease); err != nil {
 			gc.logger.Error().Err(err).Msg("Failed to release GC lock")
 		}
 	}()
 
-	// Get orphan blobs
-	orphans, err := gc.blobRepo.ListOrphans(ctx, gc.config.GracePeriod, gc.config.BatchSize)
-	if err != nil {
-		gc.logger.Error().Err(err).Msg("Failed to list orphan blobs")
-		result.Errors++
-		result.Duration = time.Since(start)
-		return result
-	}
-
-	if len(orphans) == 0 {
-		gc.logger.Debug().Msg("No orphan blobs found")
-		result.Duration = time.Since(start)
-		if gc.metrics != nil {
-			gc.metrics.GCLastRunTime.SetToCurrentTime()
-		}
-		return result
-	}
-
-	gc.logger.Info().
-		Int("count", len(orphans)).
-		Msg("Found orphan blobs for cleanup")
+	// Long runs renew the lock. If a renewal fails another process may
+	// take the lock and collect the same blobs, so the run stops.
+	runCtx, stopRenewal := lock.KeepAlive(ctx, gc.locker, lease, lockTTL)
 
-	// Update metrics with orphan count
-	if gc.metrics != nil {
-		gc.metrics.GCOrphanBlobs.Set(float64(len(orphans)))
+	cursor, err := gc.blobRepo.GetGCCursor(runCtx)
+	if err != nil {
+		gc.logger.Warn().Err(err).Msg("Failed to load GC cursor, starting from the first orphan")
+	} else if cursor != "" {
+		gc.logger.Debug().Str("after", cursor).Msg("Resuming garbage collection")
 	}
 
-	// Process each orphan blob
-	for _, blob := range orphans {
-		if gc.config.DryRun {
-			gc.logger.Info().
-				Str("content_hash", blob.ContentHash).
-				Int64("size", blob.Size).
-				Msg("[DRY RUN] Would delete orphan blob")
-			result.BlobsDeleted++
-			result.BytesFreed += blob.Size
-			continue
-		}
-
-		// Delete from storage first
-		if err := gc.storage.Delete(ctx, blob.ContentHash); err != nil {
-			if !storage.IsNotFound(err) {
-				gc.logger.Error().
-					Err(err).
-					Str("content_hash", blob.ContentHash).
-					Msg("Failed to delete blob from storage")
+	progress := &gcProgress{}
+	stopReports := gc.reportProgress(progress, start)
+
+	remaining := gc.settings().BatchSize
+	wrapped := cursor == ""
+	for remaining > 0 {
+		limit := min(remaining, gcPageSize)
+		page, err := gc.blobRepo.ListOrphansAfter(runCtx, gc.settings().GracePeriod, cursor, limit)
+		if err != nil {
+			if runCtx.Err() == nil {
+				gc.logger.Error().Err(err).Msg("Failed to list orphan blobs")
 				result.Errors++
-				continue
 			}
-			// Blob already deleted from storage, continue to delete from DB
+			break
 		}
 
-		// Delete from database
-		if err := gc.blobRepo.Delete(ctx, blob.ContentHash); err != nil {
-			gc.logger.Error().
-				Err(err).
-				Str("content_hash", blob.ContentHash).
-				Msg("Failed to delete blob from database")
-			result.Errors++
-			continue
+		result.add(gc.collectPage(runCtx, page, progress))
+		remaining -= len(page)
+		if runCtx.Err() != nil {
+			// The cursor stays before this page; its unfinished blobs are
+			// listed again by the next run
+			break
 		}
 
-		gc.logger.Debug().
-			Str("content_hash", blob.ContentHash).
-			Int64("size", blob.Size).
-			Msg("Deleted orphan blob")
+		if len(page) == limit {
+			cursor = page[len(page)-1].ContentHash
+			gc.saveCursor(runCtx, cursor)
+			continue
+		}
 
-		result.BlobsDeleted++
-		result.BytesFreed += blob.Size
+		// The walk reached the last orphan and starts over
+		cursor = ""
+		gc.saveCursor(runCtx, cursor)
+		if wrapped {
+			break
+		}
+		wrapped = true
 	}
 
+	stopReports()
+	if err := stopRenewal(); err != nil {
+		gc.logger.Error().Err(err).Msg("GC lock lost, aborting run")
+		result.Errors++
+	}
 	result.Duration = time.Since(start)
 
 	// Check if there might be more orphans
-	if len(orphans) == gc.config.BatchSize {
-		// There might be more, check again
-		remaining, _ := gc.blobRepo.ListOrphans(ctx, gc.config.GracePeriod, 1)
-		result.OrphanBlobsRemaining = len(remaining)
-		if len(remaining) > 0 {
+	if remaining == 0 {
+		more, _ := gc.blobRepo.ListOrphans(ctx, gc.settings().GracePeriod, 1)
+		result.OrphanBlobsRemaining = len(more)
+		if len(more) > 0 {
 			gc.logger.Info().Msg("More orphan blobs remain for next run")
 		}
 	}
 
-	// Record metrics
-	if gc.metrics != nil {
-		gc.metrics.RecordGCRun(result.Duration.Seconds(), result.BlobsDeleted, result.BytesFreed)
-		gc.metrics.GCLastRunTime.SetToCurrentTime()
-		if result.OrphanBlobsRemaining == 0 && len(orphans) < gc.config.BatchSize {
-			gc.metrics.GCOrphanBlobs.Set(0)
-		}
+	gc.recordRun(ctx, result, progress)
+	gc.setLastRun(start, &result)
+
+	if result.BlobsDeleted == 0 && result.Errors == 0 && result.Skipped == 0 {
+		gc.logger.Debug().Msg("No orphan blobs found")
+		return result
 	}
 
 	gc.logger.Info().
 		Int("blobs_deleted", result.BlobsDeleted).
 		Int64("bytes_freed", result.BytesFreed).
 		Int("errors", result.Errors).
+		Int("resumed", result.Resumed).
+		Int("skipped", result.Skipped).
 		Dur("duration", result.Duration).
 		Msg("Garbage collection run completed")
 
 	return result
 }
 
-// CleanupExpiredMultipartUploads cleans up expired multipart uploads.
-// This is called separately from blob GC.
-func (gc *GarbageCollector) CleanupExpiredMultipartUploads(ctx context.Context, multipartRepo repository.MultipartUploadRepository) (int64, error) {
-	deleted, err := multipartRepo.DeleteExpired(ctx)
-	if err != nil {
-		gc.logger.Error().Err(err).Msg("Failed to delete expired multipart uploads")
-		return 0, err
+// add adds the counts of other to r.
+func (r *GCResult) add(other GCResult) {
+	r.BlobsDeleted += other.BlobsDeleted
+	r.BytesFreed += other.BytesFreed
+	r.Errors += other.Errors
+	r.Resumed += other.Resumed
+	r.Skipped += other.Skipped
+}
+
+// collectPage deletes the blobs of a page with up to Workers workers. Each
+// worker tallies its own results, which are merged once the page is done.
+// Blobs not yet handed to a worker when ctx is canceled are left alone.
+func (gc *GarbageCollector) collectPage(ctx context.Context, page []*domain.Blob, progress *gcProgress) GCResult {
+	var result GCResult
+	if len(page) == 0 {
+		return result
 	}
 
-	if deleted > 0 {
+	blobs := make(chan *domain.Blob)
+	tallies := make([]GCResult, max(1, min(gc.settings().Workers, len(page))))
+	var wg sync.WaitGroup
+	for i := range tallies {
+		wg.Add(1)
+		go func(tally *GCResult) {
+			defer wg.Done()
+			for blob := range blobs {
+				gc.collect(ctx, blob, tally, progress)
+			}
+		}(&tallies[i])
+	}
+
+feed:
+	for _, blob := range page {
+		select {
+		case blobs <- blob:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(blobs)
+	wg.Wait()
+
+	for _, tally := range tallies {
+		result.add(tally)
+	}
+	return result
+}
+
+// collect deletes one orphan blob and counts the outcome in tally and progress.
+func (gc *GarbageCollector) collect(ctx context.Context, blob *domain.Blob, tally *GCResult, progress *gcProgress) {
+	defer progress.processed.Add(1)
+
+	if gc.settings().DryRun {
 		gc.logger.Info().
-			Int64("count", deleted).
-			Msg("Deleted expired multipart uploads")
+			Str("content_hash", blob.ContentHash).
+			Int64("size", blob.Size).
+			Msg("[DRY RUN] Would delete orphan blob")
+		tally.BlobsDeleted++
+		tally.BytesFreed += blob.Size
+		progress.deleted.Add(1)
+		progress.bytesFreed.Add(blob.Size)
+		return
 	}
 
-	return deleted, nil
+	if blob.GCState != domain.BlobGCStateNone {
+		tally.Resumed++
+	}
+
+	deleted, err := gc.collectBlob(ctx, blob)
+	if err != nil {
+		gc.logger.Error().
+			Err(err).
+			Str("content_hash", blob.ContentHash).
+			Str("gc_state", string(blob.GCState)).
+			Int("attempts", blob.GCAttempts+1).
+			Msg("Failed to delete orphan blob, will retry on next run")
+		tally.Errors++
+		progress.errors.Add(1)
+		return
+	}
+	if !deleted {
+		tally.Skipped++
+		return
+	}
+
+	gc.logger.Debug().
+		Str("content_hash", blob.ContentHash).
+		Int64("size", blob.Size).
+		Msg("Deleted orphan blob")
+
+	tally.BlobsDeleted++
+	tally.BytesFreed += blob.Size
+	progress.deleted.Add(1)
+	progress.bytesFreed.Add(blob.Size)
+}
+
+// saveCursor saves where the next run resumes. A dry run deletes nothing,
+// so it leaves the cursor alone.
+func (gc *GarbageCollector) saveCursor(ctx context.Context, cursor string) {
+	if gc.settings().DryRun {
+		return
+	}
+	if err := gc.blobRepo.SaveGCCursor(ctx, cursor); err != nil {
+		gc.logger.Warn().Err(err).Msg("Failed to save GC cursor")
+	}
+}
+
+// gcProgress counts the work of a run across workers.
+type gcProgress struct {
+	processed  atomic.Int64
+	deleted    atomic.Int64
+	bytesFreed atomic.Int64
+	errors     atomic.Int64
+
+	// The counts already added to the metrics
+	reportedDeleted, reportedErrors, reportedBytes int64
+}
+
+// flushMetrics adds the counts since the last flush to the metrics.
+func (p *gcProgress) flushMetrics(m *metrics.Metrics, deleted, errors, bytesFreed int64) {
+	if m != nil {
+		m.RecordGCProgress(int(deleted-p.reportedDeleted), int(errors-p.reportedErrors), bytesFreed-p.reportedBytes)
+	}
+	p.reportedDeleted, p.reportedErrors, p.reportedBytes = deleted, errors, bytesFreed
+}
+
+// reportProgress logs the progress of a run and updates the metrics every
+// ProgressInterval until the returned function is called.
+func (gc *GarbageCollector) reportProgress(progress *gcProgress, start time.Time) (stop func()) {
+	if gc.settings().ProgressInterval <= 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(gc.settings().ProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				processed, deleted, errors, bytesFreed := progress.processed.Load(), progress.deleted.Load(), progress.errors.Load(), progress.bytesFreed.Load()
+				elapsed := time.Since(start)
+				gc.logger.Info().
+					Int64("processed", processed).
+					Int64("blobs_deleted", deleted).
+					Int64("bytes_freed", bytesFreed).
+					Int64("errors", errors).
+					Float64("blobs_per_second", float64(processed)/elapsed.Seconds()).
+					Dur("elapsed", elapsed).
+					Msg("Garbage collection progress")
+				progress.flushMetrics(gc.metrics, deleted, errors, bytesFreed)
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// recordRun records the results of a run and the storage usage after it.
+func (gc *GarbageCollector) recordRun(ctx context.Context, result GCResult, progress *gcProgress) {
+	if gc.metrics == nil {
+		return
+	}
+	progress.flushMetrics(gc.metrics, int64(result.BlobsDeleted), int64(result.Errors), result.BytesFreed)
+	gc.metrics.RecordGCRun(result.Duration.Seconds())
+
+	stats, err := gc.blobRepo.GetStats(ctx)
+	if err != nil {
+		gc.logger.Warn().Err(err).Msg("Failed to get storage usage")
+		return
+	}
+	gc.metrics.RecordStorageUsage(stats.Blobs, stats.Orphans, stats.StoredBytes, stats.ReferencedBytes)
+}
+
+// collectBlob deletes one orphan blob through the GC state machine:
+//
+//	pending -> storage_deleted -> metadata deleted
+//
+// Each state is persisted before the next step runs, so a blob whose
+// deletion fails part-way (e.g. a remote backend error) resumes at the failed
+// step on the next run, and metadata is never removed while content remains.
+// Returns false if the blob was referenced again and must be kept.
+func (gc *GarbageCollector) collectBlob(ctx context.Context, blob *domain.Blob) (bool, error) {
+	if blob.GCState != domain.BlobGCStateStorageDeleted {
+		claimed, err := gc.blobRepo.MarkGCPending(ctx, blob.ContentHash)
+		if err != nil {
+			return false, err
+		}
+		if !claimed {
+			return false, nil
+		}
+
+		if err := gc.deleteFromStorage(ctx, blob.ContentHash); err != nil {
+			gc.recordError(ctx, blob.ContentHash, err)
+			return false, fmt.Errorf("failed to delete blob from storage: %w", err)
+		}
+
+		marked, err := gc.blobRepo.MarkGCStorageDeleted(ctx, blob.ContentHash)
+		if err != nil {
+			gc.recordError(ctx, blob.ContentHash, err)
+			return false, err
+		}
+		if !marked {
+			// An upload referenced the blob while its content was being deleted.
+			// Its metadata is live again, so report the lost content loudly.
+			gc.logger.Error().
+				Str("content_hash", blob.ContentHash).
+				Msg("Orphan blob was referenced again during deletion; its content must be re-uploaded")
+			return false, nil
+		}
+	}
+
+	if err := gc.deleteMetadata(ctx, blob); err != nil {
+		if errors.Is(err, domain.ErrBlobNotFound) {
+			// Already removed by another run, or re-referenced
+			return false, nil
+		}
+		gc.recordError(ctx, blob.ContentHash, err)
+		return false, fmt.Errorf("failed to delete blob from database: %w", err)
+	}
+
+	return true, nil
+}
+
+// deleteMetadata removes the blob's metadata, recording the deletion in the
+// event outbox in the same transaction.
+func (gc *GarbageCollector) deleteMetadata(ctx context.Context, blob *domain.Blob) error {
+	if gc.eventRepo == nil {
+		return gc.blobRepo.Delete(ctx, blob.ContentHash)
+	}
+	return gc.txManager.WithTx(ctx, func(ctx context.Context) error {
+		if err := gc.blobRepo.Delete(ctx, blob.ContentHash); err != nil {
+			return err
+		}
+		if err := gc.eventRepo.Append(ctx, domain.NewBlobEvent(domain.ObjectEventBlobGarbageCollected, blob)); err != nil {
+			return fmt.Errorf("failed to record event: %w", err)
+		}
+		return nil
+	})
+}
+
+// deleteFromStorage deletes blob content, retrying with exponential backoff.
+// Content that is already gone counts as deleted, so re-runs are idempotent.
+func (gc *GarbageCollector) deleteFromStorage(ctx context.Context, contentHash string) error {
+	backoff := gc.settings().RetryBackoff
+
+	var err error
+	for attempt := 0; attempt <= gc.settings().DeleteRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			backoff *= 2
+		}
+
+		err = gc.storage.Delete(ctx, contentHash)
+		if err == nil || storage.IsNotFound(err) {
+			return nil
+		}
+
+		gc.logger.Warn().
+			Err(err).
+			Str("content_hash", contentHash).
+			Int("attempt", attempt+1).
+			Msg("Storage deletion failed")
+	}
+
+	return err
+}
+
+// recordError stores the last GC error on the blob for operators.
+func (gc *GarbageCollector) recordError(ctx context.Context, contentHash string, cause error) {
+	if err := gc.blobRepo.RecordGCError(ctx, contentHash, cause.Error()); err != nil {
+		gc.logger.Warn().Err(err).Str("content_hash", contentHash).Msg("Failed to record blob GC error")
+	}
 }
 
 // CleanupExpiredAccessKeys cleans up expired access keys.
@@ -336,9 +669,43 @@ func (gc *GarbageCollector) CleanupExpiredAccessKeys(ctx context.Context, access
 	return deleted, nil
 }
 
+// LastRun returns when the last completed run started, or the zero time
+// if none has completed yet. Runs skipped because another process holds
+// the GC lock count as completed.
+func (gc *GarbageCollector) LastRun() time.Time {
+	gc.mu.Lock()
+	defer gc.mu.Unlock()
+	return gc.lastRun
+}
+
+// LastResult returns the result of the last run this collector completed
+// itself, or nil if there is none. Runs skipped because another process
+// holds the GC lock leave it unchanged.
+func (gc *GarbageCollector) LastResult() *GCResult {
+	gc.mu.Lock()
+	defer gc.mu.Unlock()
+	if gc.lastResult == nil {
+		return nil
+	}
+	result := *gc.lastResult
+	return &result
+}
+
+// setLastRun records the start of a completed run and, unless the run was
+// skipped, its result.
+func (gc *GarbageCollector) setLastRun(start time.Time, result *GCResult) {
+	gc.mu.Lock()
+	gc.lastRun = start
+	if result != nil {
+		gc.lastResult = result
+	}
+	gc.mu.Unlock()
+}
+
 // GetStats returns current GC statistics.
 func (gc *GarbageCollector) GetStats(ctx context.Context) (*GCStats, error) {
-	orphans, err := gc.blobRepo.ListOrphans(ctx, gc.config.GracePeriod, gc.config.BatchSize+1)
+	config := gc.settings()
+	orphans, err := gc.blobRepo.ListOrphans(ctx, config.GracePeriod, config.BatchSize+1)
 	if err != nil {
 		return nil, err
 	}
@@ -348,17 +715,17 @@ func (gc *GarbageCollector) GetStats(ctx context.Context) (*GCStats, error) {
 		totalSize += blob.Size
 	}
 
-	hasMore := len(orphans) > gc.config.BatchSize
+	hasMore := len(orphans) > config.BatchSize
 	if hasMore {
-		orphans = orphans[:gc.config.BatchSize]
+		orphans = orphans[:config.BatchSize]
 	}
 
 	return &GCStats{
 		OrphanBlobCount: len(orphans),
 		OrphanBlobSize:  totalSize,
 		HasMoreOrphans:  hasMore,
-		GracePeriod:     gc.config.GracePeriod,
-		NextRunIn:       gc.config.Interval,
+		GracePeriod:     config.GracePeriod,
+		NextRunIn:       config.Interval,
 	}, nil
 }
 
@@ -0,0 +1,319 @@
+// Package service provides business logic services for Alexander Storage.
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/lock"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+	"github.com/prn-tf/alexander-storage/internal/storage"
+	"github.com/prn-tf/alexander-storage/internal/storage/filesystem"
+)
+
+func newTestGarbageCollector() (*GarbageCollector, *mockBlobRepository2, *mockStorageBackend2) {
+	blobRepo := new(mockBlobRepository2)
+	storageBackend := new(mockStorageBackend2)
+	config := DefaultGCConfig()
+	config.DeleteRetries = 1
+	config.RetryBackoff = time.Millisecond
+
+	gc := NewGarbageCollector(blobRepo, nil, nil, storageBackend, lock.NewNoOpLocker(), nil, zerolog.Nop(), config)
+	blobRepo.On("GetGCCursor", mock.Anything).Return("", nil).Maybe()
+	blobRepo.On("SaveGCCursor", mock.Anything, mock.Anything).Return(nil).Maybe()
+	return gc, blobRepo, storageBackend
+}
+
+func TestGarbageCollector_StorageFailureLeavesBlobPending(t *testing.T) {
+	gc, blobRepo, storageBackend := newTestGarbageCollector()
+	ctx := context.Background()
+
+	blobRepo.On("ListOrphansAfter", mock.Anything, mock.Anything, "", mock.Anything).
+		Return([]*domain.Blob{{ContentHash: "aa", Size: 10}}, nil)
+	blobRepo.On("MarkGCPending", mock.Anything, "aa").Return(true, nil)
+	storageBackend.On("Delete", mock.Anything, "aa").Return(errors.New("remote unavailable")).Twice()
+	blobRepo.On("RecordGCError", mock.Anything, "aa", "remote unavailable").Return(nil)
+
+	result := gc.RunOnce(ctx)
+
+	require.Equal(t, 1, result.Errors)
+	require.Zero(t, result.BlobsDeleted)
+	blobRepo.AssertNotCalled(t, "MarkGCStorageDeleted", mock.Anything, mock.Anything)
+	blobRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	storageBackend.AssertExpectations(t)
+}
+
+func TestGarbageCollector_ResumesAfterStorageDeleted(t *testing.T) {
+	gc, blobRepo, storageBackend := newTestGarbageCollector()
+	ctx := context.Background()
+
+	blobRepo.On("ListOrphansAfter", mock.Anything, mock.Anything, "", mock.Anything).Return([]*domain.Blob{
+		{ContentHash: "aa", Size: 10, GCState: domain.BlobGCStateStorageDeleted, GCAttempts: 1},
+		{ContentHash: "bb", Size: 20, GCState: domain.BlobGCStatePending, GCAttempts: 1},
+	}, nil)
+
+	// "aa" only needs its metadata removed
+	blobRepo.On("Delete", mock.Anything, "aa").Return(nil).Once()
+
+	// "bb" retries storage deletion; content already gone counts as deleted
+	blobRepo.On("MarkGCPending", mock.Anything, "bb").Return(true, nil)
+	storageBackend.On("Delete", mock.Anything, "bb").Return(storage.ErrBlobNotFound).Once()
+	blobRepo.On("MarkGCStorageDeleted", mock.Anything, "bb").Return(true, nil)
+	blobRepo.On("Delete", mock.Anything, "bb").Return(nil).Once()
+
+	result := gc.RunOnce(ctx)
+
+	require.Zero(t, result.Errors)
+	require.Equal(t, 2, result.Resumed)
+	require.Equal(t, 2, result.BlobsDeleted)
+	require.EqualValues(t, 30, result.BytesFreed)
+	storageBackend.AssertNotCalled(t, "Delete", mock.Anything, "aa")
+	blobRepo.AssertExpectations(t)
+}
+
+func TestGarbageCollector_SkipsReferencedBlob(t *testing.T) {
+	gc, blobRepo, storageBackend := newTestGarbageCollector()
+
+	blobRepo.On("ListOrphansAfter", mock.Anything, mock.Anything, "", mock.Anything).
+		Return([]*domain.Blob{{ContentHash: "aa", Size: 10}}, nil)
+	blobRepo.On("MarkGCPending", mock.Anything, "aa").Return(false, nil)
+
+	result := gc.RunOnce(context.Background())
+
+	require.Equal(t, 1, result.Skipped)
+	require.Zero(t, result.BlobsDeleted)
+	storageBackend.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
+
+func TestGarbageCollector_ResumesFromCursor(t *testing.T) {
+	ctx := context.Background()
+	svc := newConsistencyService(t, "photos", domain.VersioningDisabled)
+
+	for i := range 5 {
+		key := fmt.Sprintf("%d.txt", i)
+		_, err := putString(ctx, svc, "photos", key, "content "+key, nil)
+		require.NoError(t, err)
+		_, err = svc.DeleteObject(ctx, DeleteObjectInput{BucketName: "photos", Key: key})
+		require.NoError(t, err)
+	}
+
+	config := DefaultGCConfig()
+	config.GracePeriod = -time.Hour
+	config.BatchSize = 2
+	config.Workers = 3
+	gc := NewGarbageCollector(svc.blobRepo, nil, nil, svc.storage, lock.NewNoOpLocker(), nil, zerolog.Nop(), config)
+
+	// Each run continues after the blobs the previous one deleted
+	var deleted []int
+	for range 3 {
+		deleted = append(deleted, gc.RunOnce(ctx).BlobsDeleted)
+	}
+	require.Equal(t, []int{2, 2, 1}, deleted)
+
+	stats, err := svc.blobRepo.GetStats(ctx)
+	require.NoError(t, err)
+	require.Zero(t, stats.Blobs)
+	cursor, err := svc.blobRepo.GetGCCursor(ctx)
+	require.NoError(t, err)
+	require.Empty(t, cursor, "the walk starts over after the last orphan")
+}
+
+func TestGarbageCollector_RecordsDeletedBlobs(t *testing.T) {
+	ctx := context.Background()
+	svc := newConsistencyService(t, "photos", domain.VersioningDisabled)
+
+	_, err := putString(ctx, svc, "photos", "a.txt", "hello", nil)
+	require.NoError(t, err)
+	_, err = svc.DeleteObject(ctx, DeleteObjectInput{BucketName: "photos", Key: "a.txt"})
+	require.NoError(t, err)
+
+	config := DefaultGCConfig()
+	config.GracePeriod = -time.Hour
+	gc := NewGarbageCollector(svc.blobRepo, svc.eventRepo, svc.txManager, svc.storage, lock.NewNoOpLocker(), nil, zerolog.Nop(), config)
+	require.Equal(t, 1, gc.RunOnce(ctx).BlobsDeleted)
+
+	events, err := svc.eventRepo.List(ctx, repository.EventFilter{})
+	require.NoError(t, err)
+	last := events[len(events)-1]
+	sum := sha256.Sum256([]byte("hello"))
+	require.Equal(t, domain.ObjectEventBlobGarbageCollected, last.Type)
+	require.Empty(t, last.BucketName)
+	require.Equal(t, hex.EncodeToString(sum[:]), last.Key)
+	require.Equal(t, int64(5), last.Size)
+}
+
+func TestGarbageCollector_Reconfigure(t *testing.T) {
+	ctx := context.Background()
+	svc := newConsistencyService(t, "photos", domain.VersioningDisabled)
+
+	for i := range 4 {
+		key := fmt.Sprintf("%d.txt", i)
+		_, err := putString(ctx, svc, "photos", key, "content "+key, nil)
+		require.NoError(t, err)
+		_, err = svc.DeleteObject(ctx, DeleteObjectInput{BucketName: "photos", Key: key})
+		require.NoError(t, err)
+	}
+
+	config := DefaultGCConfig()
+	config.GracePeriod = -time.Hour
+	config.BatchSize = 1
+	gc := NewGarbageCollector(svc.blobRepo, nil, nil, svc.storage, lock.NewNoOpLocker(), nil, zerolog.Nop(), config)
+	require.True(t, gc.LastRun().IsZero())
+
+	require.Equal(t, 1, gc.RunOnce(ctx).BlobsDeleted)
+	require.False(t, gc.LastRun().IsZero())
+
+	// A larger batch applies to the next run; Enabled cannot change
+	config.BatchSize = 10
+	config.Enabled = false
+	gc.Reconfigure(config)
+	require.True(t, gc.settings().Enabled)
+	require.Equal(t, 3, gc.RunOnce(ctx).BlobsDeleted)
+}
+
+func TestGCVerifier_QuarantinesOrphanFilesAndRestoresMissingBlobs(t *testing.T) {
+	ctx := context.Background()
+	svc := newConsistencyService(t, "photos", domain.VersioningDisabled)
+	repairSource, err := filesystem.NewStorage(filesystem.Config{DataDir: t.TempDir(), TempDir: t.TempDir()}, zerolog.Nop())
+	require.NoError(t, err)
+
+	var hashes []string
+	for _, key := range []string{"a.jpg", "b.jpg", "c.jpg"} {
+		_, err := putString(ctx, svc, "photos", key, "content of "+key, nil)
+		require.NoError(t, err)
+		sum := sha256.Sum256([]byte("content of " + key))
+		hashes = append(hashes, hex.EncodeToString(sum[:]))
+	}
+
+	// b.jpg loses its content but a replica still has it; c.jpg has none
+	replica := []byte("content of b.jpg")
+	_, err = repairSource.Store(ctx, bytes.NewReader(replica), int64(len(replica)))
+	require.NoError(t, err)
+	require.NoError(t, svc.storage.Delete(ctx, hashes[1]))
+	require.NoError(t, svc.storage.Delete(ctx, hashes[2]))
+
+	// Content stored without a blob record, once long ago and once just now
+	old, recent := []byte("lost upload"), []byte("upload in progress")
+	orphan, err := svc.storage.Store(ctx, bytes.NewReader(old), int64(len(old)))
+	require.NoError(t, err)
+	past := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(svc.storage.GetPath(orphan), past, past))
+	_, err = svc.storage.Store(ctx, bytes.NewReader(recent), int64(len(recent)))
+	require.NoError(t, err)
+
+	config := DefaultGCVerifyConfig()
+	config.GracePeriod = time.Hour
+	config.QuarantineDir = filepath.Join(t.TempDir(), "quarantine")
+	verifier := NewGCVerifier(svc.blobRepo, svc.storage, repairSource, lock.NewNoOpLocker(), nil, zerolog.Nop(), config)
+
+	// Blob records were just created, so nothing is missing yet
+	result := verifier.RunOnce(ctx)
+	require.Equal(t, 1, result.OrphanFiles)
+	require.Equal(t, 1, result.Quarantined)
+	require.Zero(t, result.MissingBlobs)
+	require.Equal(t, 3, result.Skipped)
+	require.FileExists(t, filepath.Join(config.QuarantineDir, orphan))
+
+	verifier.config.GracePeriod = -time.Hour
+	result = verifier.RunOnce(ctx)
+	require.Zero(t, result.Errors)
+	require.Equal(t, 1, result.OrphanFiles, "the recent upload is now old enough")
+	require.Equal(t, 2, result.MissingBlobs)
+	require.Equal(t, 1, result.Repaired)
+
+	exists, err := svc.storage.Exists(ctx, hashes[1])
+	require.NoError(t, err)
+	require.True(t, exists)
+	corrupt, err := svc.blobRepo.ListCorrupt(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, corrupt, 1)
+	require.Equal(t, hashes[2], corrupt[0].ContentHash)
+}
+
+func TestGCReconciler_CorrectsDriftedRefCounts(t *testing.T) {
+	ctx := context.Background()
+	svc := newConsistencyService(t, "photos", domain.VersioningDisabled)
+
+	// Two keys share one blob; a deleted third key holds no reference
+	for _, key := range []string{"a.jpg", "b.jpg", "c.jpg"} {
+		_, err := putString(ctx, svc, "photos", key, "shared", nil)
+		require.NoError(t, err)
+	}
+	_, err := svc.DeleteObject(ctx, DeleteObjectInput{BucketName: "photos", Key: "c.jpg"})
+	require.NoError(t, err)
+	_, err = putString(ctx, svc, "photos", "d.jpg", "single", nil)
+	require.NoError(t, err)
+	shared, single := sha256.Sum256([]byte("shared")), sha256.Sum256([]byte("single"))
+	sharedHash, singleHash := hex.EncodeToString(shared[:]), hex.EncodeToString(single[:])
+
+	// One count too high, one too low
+	require.NoError(t, svc.blobRepo.IncrementRef(ctx, sharedHash))
+	_, err = svc.blobRepo.DecrementRef(ctx, singleHash)
+	require.NoError(t, err)
+
+	config := DefaultGCReconcileConfig()
+	config.BatchSize = 1
+	config.DryRun = true
+	reconciler := NewGCReconciler(svc.blobRepo, lock.NewMemoryLocker(), nil, zerolog.Nop(), config)
+
+	result := reconciler.RunOnce(ctx)
+	require.Zero(t, result.Errors)
+	require.Equal(t, 2, result.Blobs)
+	require.Equal(t, 2, result.Drifted)
+	require.EqualValues(t, 2, result.DriftedRefs)
+	require.Zero(t, result.Fixed)
+	refs, err := svc.blobRepo.GetRefCount(ctx, singleHash)
+	require.NoError(t, err)
+	require.Zero(t, refs)
+
+	reconciler.config.DryRun = false
+	result = reconciler.RunOnce(ctx)
+	require.Zero(t, result.Errors)
+	require.Equal(t, 2, result.Fixed)
+	for hash, want := range map[string]int32{sharedHash: 2, singleHash: 1} {
+		refs, err := svc.blobRepo.GetRefCount(ctx, hash)
+		require.NoError(t, err)
+		require.Equal(t, want, refs, hash)
+	}
+
+	result = reconciler.RunOnce(ctx)
+	require.Zero(t, result.Drifted)
+	require.Empty(t, result.Findings)
+}
+
+func TestBlobStats_CountsDeduplicatedBytes(t *testing.T) {
+	ctx := context.Background()
+	svc := newConsistencyService(t, "photos", domain.VersioningDisabled)
+
+	// Two keys share one blob; deleting a third leaves an orphan
+	for _, key := range []string{"a.jpg", "b.jpg"} {
+		_, err := putString(ctx, svc, "photos", key, "same content", nil)
+		require.NoError(t, err)
+	}
+	_, err := putString(ctx, svc, "photos", "c.jpg", "other", nil)
+	require.NoError(t, err)
+	_, err = svc.DeleteObject(ctx, DeleteObjectInput{BucketName: "photos", Key: "c.jpg"})
+	require.NoError(t, err)
+
+	stats, err := svc.blobRepo.GetStats(ctx)
+	require.NoError(t, err)
+	require.Equal(t, &domain.BlobStats{
+		Blobs:           2,
+		Orphans:         1,
+		StoredBytes:     int64(len("same content") + len("other")),
+		ReferencedBytes: int64(2 * len("same content")),
+	}, stats)
+}
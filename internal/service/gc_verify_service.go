@@ -0,0 +1,501 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/lock"
+	"github.com/prn-tf/alexander-storage/internal/metrics"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+	"github.com/prn-tf/alexander-storage/internal/storage"
+)
+
+// GCVerifier cross-checks storage against the blob table. It reports
+// orphan files, stored content without a blob record that GC will never
+// delete, and missing blobs, records whose content is gone. Orphan files
+// can be moved to a quarantine directory; missing blobs are restored from
+// a repair source when one is configured and flagged corrupt otherwise,
+// so they show up in the scrub reports. Blobs encrypted at rest are only
+// flagged.
+//
+// A run holds the GC lock, so no content is deleted while it walks.
+type GCVerifier struct {
+	blobRepo     repository.BlobRepository
+	storage      storage.Backend
+	repairSource storage.Backend
+	locker       lock.Locker
+	metrics      *metrics.Metrics
+	logger       zerolog.Logger
+	config       GCVerifyConfig
+
+	// Control
+	mu       sync.Mutex
+	running  bool
+	stopChan chan struct{}
+	doneChan chan struct{}
+}
+
+// GCVerifyConfig contains storage verification configuration.
+type GCVerifyConfig struct {
+	// Interval is how often a scheduled verification runs.
+	Interval time.Duration
+
+	// GracePeriod is how old files and blob records must be to be
+	// reported, since uploads store content before recording its blob.
+	GracePeriod time.Duration
+
+	// QuarantineDir receives orphan files. Empty only reports them.
+	QuarantineDir string
+
+	// MaxFindings caps the findings listed in a result. Counts are always
+	// complete.
+	MaxFindings int
+
+	// TempDir holds repair downloads until they are verified. Empty uses
+	// the system temp directory.
+	TempDir string
+}
+
+// DefaultGCVerifyConfig returns sensible defaults.
+func DefaultGCVerifyConfig() GCVerifyConfig {
+	return GCVerifyConfig{
+		Interval:    24 * time.Hour,
+		GracePeriod: 1 * time.Hour,
+		MaxFindings: 1000,
+	}
+}
+
+// Storage verification finding kinds.
+const (
+	// GCFindingOrphanFile is stored content without a blob record.
+	GCFindingOrphanFile = "orphan_file"
+
+	// GCFindingMissingBlob is a blob record without stored content.
+	GCFindingMissingBlob = "missing_blob"
+)
+
+// Actions taken on storage verification findings.
+const (
+	gcActionQuarantined = "quarantined"
+	gcActionRepaired    = "repaired"
+	gcActionFlagged     = "flagged"
+)
+
+// gcVerifyPageSize is the number of blob records read at a time.
+const gcVerifyPageSize = 1000
+
+// errGCVerifyUnsupported indicates a backend that cannot list its content.
+var errGCVerifyUnsupported = errors.New("storage backend cannot be walked")
+
+// NewGCVerifier creates a new storage verifier. repairSource may be nil,
+// in which case missing blobs are only flagged.
+func NewGCVerifier(
+	blobRepo repository.BlobRepository,
+	storage storage.Backend,
+	repairSource storage.Backend,
+	locker lock.Locker,
+	m *metrics.Metrics,
+	logger zerolog.Logger,
+	config GCVerifyConfig,
+) *GCVerifier {
+	return &GCVerifier{
+		blobRepo:     blobRepo,
+		storage:      storage,
+		repairSource: repairSource,
+		locker:       locker,
+		metrics:      m,
+		logger:       logger.With().Str("service", "gc-verify").Logger(),
+		config:       config,
+		stopChan:     make(chan struct{}),
+		doneChan:     make(chan struct{}),
+	}
+}
+
+// Start begins the verification scheduler. The first run starts after one
+// interval.
+func (v *GCVerifier) Start() {
+	v.mu.Lock()
+	if v.running {
+		v.mu.Unlock()
+		return
+	}
+	v.running = true
+	v.mu.Unlock()
+
+	v.logger.Info().
+		Dur("interval", v.config.Interval).
+		Str("quarantine_dir", v.config.QuarantineDir).
+		Bool("repair", v.repairSource != nil).
+		Msg("Starting storage verifier")
+
+	go v.runLoop()
+}
+
+// Stop stops the verification scheduler, interrupting a run in progress.
+func (v *GCVerifier) Stop() {
+	v.mu.Lock()
+	if !v.running {
+		v.mu.Unlock()
+		return
+	}
+	v.running = false
+	v.mu.Unlock()
+
+	close(v.stopChan)
+	<-v.doneChan
+
+	v.logger.Info().Msg("Storage verifier stopped")
+}
+
+// runLoop is the main verification loop.
+func (v *GCVerifier) runLoop() {
+	defer close(v.doneChan)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-v.stopChan
+		cancel()
+	}()
+
+	ticker := time.NewTicker(v.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			v.RunOnce(ctx)
+		case <-v.stopChan:
+			return
+		}
+	}
+}
+
+// GCVerifyFinding is a divergence between storage and the blob table.
+type GCVerifyFinding struct {
+	// Kind is GCFindingOrphanFile or GCFindingMissingBlob.
+	Kind string `json:"kind"`
+
+	// ContentHash identifies the content.
+	ContentHash string `json:"content_hash"`
+
+	// Size is the stored size of an orphan file or the recorded size of a
+	// missing blob.
+	Size int64 `json:"size"`
+
+	// Action is what was done about it: quarantined, repaired or flagged.
+	// Empty if it was only reported.
+	Action string `json:"action,omitempty"`
+
+	// Error is why the action failed.
+	Error string `json:"error,omitempty"`
+}
+
+// GCVerifyResult contains the result of a storage verification run.
+type GCVerifyResult struct {
+	// Files is the number of stored blobs walked.
+	Files int `json:"files"`
+
+	// Blobs is the number of blob records checked.
+	Blobs int `json:"blobs"`
+
+	// OrphanFiles is the number of stored blobs without a blob record.
+	OrphanFiles int `json:"orphan_files"`
+
+	// OrphanBytes is the stored size of the orphan files.
+	OrphanBytes int64 `json:"orphan_bytes"`
+
+	// Quarantined is the number of orphan files moved to quarantine.
+	Quarantined int `json:"quarantined"`
+
+	// MissingBlobs is the number of blob records without stored content.
+	MissingBlobs int `json:"missing_blobs"`
+
+	// Repaired is the number of missing blobs restored from the repair source.
+	Repaired int `json:"repaired"`
+
+	// Skipped is the number of files and records too recent to judge or
+	// being garbage collected.
+	Skipped int `json:"skipped"`
+
+	// Errors is the number of findings that could not be handled, plus
+	// one if the run stopped early.
+	Errors int `json:"errors"`
+
+	// Findings lists the divergences found, up to MaxFindings.
+	Findings []GCVerifyFinding `json:"findings"`
+
+	// Truncated reports that more findings exist than are listed.
+	Truncated bool `json:"truncated,omitempty"`
+
+	// Duration is how long the run took.
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// Diverged reports whether storage and the blob table disagree.
+func (r *GCVerifyResult) Diverged() bool {
+	return r.OrphanFiles > 0 || r.MissingBlobs > 0
+}
+
+// addFinding lists a finding unless the list is full.
+func (r *GCVerifyResult) addFinding(finding GCVerifyFinding, max int) {
+	if len(r.Findings) >= max {
+		r.Truncated = true
+		return
+	}
+	r.Findings = append(r.Findings, finding)
+}
+
+// RunOnce walks storage and the blob table once.
+// This can be called manually or by the scheduler.
+func (v *GCVerifier) RunOnce(ctx context.Context) GCVerifyResult {
+	start := time.Now()
+	result := GCVerifyResult{Findings: []GCVerifyFinding{}}
+
+	walker, ok := v.storage.(storage.Walker)
+	if !ok {
+		v.logger.Error().Err(errGCVerifyUnsupported).Msg("Storage verification failed")
+		result.Errors++
+		result.Duration = time.Since(start)
+		return result
+	}
+
+	lockKey := lock.Keys.BlobGC()
+	lockTTL := v.config.Interval / 2
+	if lockTTL < 5*time.Minute {
+		lockTTL = 5 * time.Minute
+	}
+
+	acquired, err := v.locker.Acquire(ctx, lockKey, lockTTL)
+	if err != nil {
+		v.logger.Error().Err(err).Msg("Failed to acquire GC lock")
+		result.Errors++
+		result.Duration = time.Since(start)
+		return result
+	}
+	if !acquired {
+		v.logger.Debug().Msg("GC lock held by another process, skipping verification")
+		result.Duration = time.Since(start)
+		return result
+	}
+	defer func() {
+		if _, err := v.locker.Release(context.Background(), lockKey); err != nil {
+			v.logger.Error().Err(err).Msg("Failed to release GC lock")
+		}
+	}()
+
+	if err := v.walk(ctx, walker, start.Add(-v.config.GracePeriod), &result); err != nil {
+		if ctx.Err() == nil {
+			v.logger.Error().Err(err).Msg("Storage verification stopped early")
+		}
+		result.Errors++
+	}
+
+	result.Duration = time.Since(start)
+
+	if v.metrics != nil && ctx.Err() == nil {
+		v.metrics.RecordGCVerify(result.OrphanFiles, result.MissingBlobs)
+	}
+
+	event := v.logger.Info()
+	if result.Diverged() {
+		event = v.logger.Error()
+	}
+	event.
+		Int("files", result.Files).
+		Int("blobs", result.Blobs).
+		Int("orphan_files", result.OrphanFiles).
+		Int64("orphan_bytes", result.OrphanBytes).
+		Int("quarantined", result.Quarantined).
+		Int("missing_blobs", result.MissingBlobs).
+		Int("repaired", result.Repaired).
+		Int("skipped", result.Skipped).
+		Int("errors", result.Errors).
+		Dur("duration", result.Duration).
+		Msg("Storage verification completed")
+
+	return result
+}
+
+// walk merges the storage walk with the blob table, both in content hash
+// order. A stored blob sorting before the next record has no record; a
+// record sorting before the next stored blob has no content.
+func (v *GCVerifier) walk(ctx context.Context, walker storage.Walker, cutoff time.Time, result *GCVerifyResult) error {
+	records := &blobCursor{repo: v.blobRepo}
+
+	err := walker.Walk(ctx, func(info storage.BlobInfo) error {
+		result.Files++
+		for {
+			blob, err := records.peek(ctx)
+			if err != nil {
+				return err
+			}
+			if blob == nil || blob.ContentHash > info.ContentHash {
+				break
+			}
+			records.next()
+			result.Blobs++
+			if blob.ContentHash < info.ContentHash {
+				v.checkMissing(ctx, blob, cutoff, result)
+			}
+		}
+		if records.matched(info.ContentHash) {
+			return nil
+		}
+		v.checkOrphan(ctx, info, cutoff, result)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Records after the last stored blob
+	for {
+		blob, err := records.peek(ctx)
+		if err != nil {
+			return err
+		}
+		if blob == nil {
+			return nil
+		}
+		records.next()
+		result.Blobs++
+		v.checkMissing(ctx, blob, cutoff, result)
+	}
+}
+
+// checkOrphan handles a stored blob without a record.
+func (v *GCVerifier) checkOrphan(ctx context.Context, info storage.BlobInfo, cutoff time.Time, result *GCVerifyResult) {
+	if info.ModTime.After(cutoff) {
+		result.Skipped++
+		return
+	}
+	logger := v.logger.With().Str("content_hash", info.ContentHash).Logger()
+
+	// The record may have been created since its page was read
+	exists, err := v.blobRepo.Exists(ctx, info.ContentHash)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Failed to look up blob record")
+		result.Errors++
+		return
+	}
+	if exists {
+		return
+	}
+
+	result.OrphanFiles++
+	result.OrphanBytes += info.Size
+	finding := GCVerifyFinding{Kind: GCFindingOrphanFile, ContentHash: info.ContentHash, Size: info.Size}
+	logger.Warn().Int64("size", info.Size).Msg("Stored blob has no blob record")
+
+	if v.config.QuarantineDir != "" {
+		if err := v.quarantine(ctx, info.ContentHash); err != nil {
+			logger.Warn().Err(err).Msg("Failed to quarantine orphan file")
+			finding.Error = err.Error()
+			result.Errors++
+		} else {
+			finding.Action = gcActionQuarantined
+			result.Quarantined++
+		}
+	}
+	result.addFinding(finding, v.config.MaxFindings)
+}
+
+// quarantine moves an orphan file to the quarantine directory.
+func (v *GCVerifier) quarantine(ctx context.Context, contentHash string) error {
+	quarantiner, ok := v.storage.(storage.Quarantiner)
+	if !ok {
+		return fmt.Errorf("storage backend %T cannot quarantine blobs", v.storage)
+	}
+	return quarantiner.Quarantine(ctx, contentHash, v.config.QuarantineDir)
+}
+
+// checkMissing handles a record without stored content.
+func (v *GCVerifier) checkMissing(ctx context.Context, blob *domain.Blob, cutoff time.Time, result *GCVerifyResult) {
+	// Content of blobs being collected is deleted before their record
+	if blob.GCState != domain.BlobGCStateNone || blob.CreatedAt.After(cutoff) {
+		result.Skipped++
+		return
+	}
+	logger := v.logger.With().Str("content_hash", blob.ContentHash).Logger()
+
+	result.MissingBlobs++
+	finding := GCVerifyFinding{Kind: GCFindingMissingBlob, ContentHash: blob.ContentHash, Size: blob.Size}
+	logger.Error().Int64("size", blob.Size).Msg("Blob record has no stored content")
+
+	// Encrypted copies do not hash to their content hash, so they cannot
+	// be checked before restoring
+	repaired := false
+	if v.repairSource != nil && !blob.IsEncrypted {
+		err := restoreBlob(ctx, v.repairSource, v.storage, blob.ContentHash, v.config.TempDir, func(r io.Reader) io.Reader { return r })
+		if err != nil {
+			logger.Warn().Err(err).Msg("Failed to restore missing blob")
+			finding.Error = err.Error()
+		} else {
+			logger.Info().Msg("Restored missing blob from repair source")
+			repaired = true
+		}
+	}
+
+	// A restored blob clears an earlier flag
+	if err := v.blobRepo.RecordVerification(ctx, blob.ContentHash, time.Now(), !repaired); err != nil {
+		logger.Warn().Err(err).Msg("Failed to record missing blob")
+		finding.Error = err.Error()
+		result.Errors++
+	}
+	switch {
+	case repaired:
+		finding.Action = gcActionRepaired
+		result.Repaired++
+	case finding.Error == "":
+		finding.Action = gcActionFlagged
+	}
+	result.addFinding(finding, v.config.MaxFindings)
+}
+
+// blobCursor pages through the blob table in content hash order.
+type blobCursor struct {
+	repo  repository.BlobRepository
+	page  []*domain.Blob
+	pos   int
+	after string
+	done  bool
+	last  string
+}
+
+// peek returns the next record without consuming it, or nil at the end.
+func (c *blobCursor) peek(ctx context.Context) (*domain.Blob, error) {
+	if c.pos == len(c.page) && !c.done {
+		page, err := c.repo.ListAfter(ctx, c.after, gcVerifyPageSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list blobs: %w", err)
+		}
+		c.page, c.pos = page, 0
+		c.done = len(page) < gcVerifyPageSize
+		if len(page) > 0 {
+			c.after = page[len(page)-1].ContentHash
+		}
+	}
+	if c.pos == len(c.page) {
+		return nil, nil
+	}
+	return c.page[c.pos], nil
+}
+
+// next consumes the record returned by peek.
+func (c *blobCursor) next() {
+	c.last = c.page[c.pos].ContentHash
+	c.pos++
+}
+
+// matched reports whether the last consumed record has the content hash.
+func (c *blobCursor) matched(contentHash string) bool {
+	return c.last == contentHash
+}
@@ -3,6 +3,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -14,16 +15,24 @@ import (
 	"github.com/prn-tf/alexander-storage/internal/repository"
 )
 
-const (
-	// MaxAccessKeysPerUser is the maximum number of access keys a user can have.
-	MaxAccessKeysPerUser = 5
-)
+// IAMConfig contains configuration for the IAMService.
+type IAMConfig struct {
+	// MaxAccessKeysPerUser is the maximum number of active access keys a
+	// user can have. Default: 5.
+	MaxAccessKeysPerUser int
+}
+
+// DefaultIAMConfig returns the default IAM configuration.
+func DefaultIAMConfig() IAMConfig {
+	return IAMConfig{MaxAccessKeysPerUser: 5}
+}
 
 // IAMService handles IAM operations (access key management).
 type IAMService struct {
 	accessKeyRepo repository.AccessKeyRepository
 	userRepo      repository.UserRepository
 	encryptor     *crypto.Encryptor
+	config        IAMConfig
 	logger        zerolog.Logger
 }
 
@@ -33,11 +42,17 @@ func NewIAMService(
 	userRepo repository.UserRepository,
 	encryptor *crypto.Encryptor,
 	logger zerolog.Logger,
+	config IAMConfig,
 ) *IAMService {
+	if config.MaxAccessKeysPerUser <= 0 {
+		config.MaxAccessKeysPerUser = DefaultIAMConfig().MaxAccessKeysPerUser
+	}
+
 	return &IAMService{
 		accessKeyRepo: accessKeyRepo,
 		userRepo:      userRepo,
 		encryptor:     encryptor,
+		config:        config,
 		logger:        logger.With().Str("service", "iam").Logger(),
 	}
 }
@@ -47,6 +62,9 @@ type CreateAccessKeyInput struct {
 	UserID      int64
 	Description string
 	ExpiresAt   *time.Time
+
+	// Policy optionally scopes the key; nil grants full access to the user's buckets.
+	Policy *domain.AccessKeyPolicy
 }
 
 // CreateAccessKeyOutput contains the result of creating an access key.
@@ -59,13 +77,19 @@ type CreateAccessKeyOutput struct {
 
 // CreateAccessKey creates a new access key for a user.
 func (s *IAMService) CreateAccessKey(ctx context.Context, input CreateAccessKeyInput) (*CreateAccessKeyOutput, error) {
+	if input.Policy != nil {
+		if err := input.Policy.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
 	// Verify user exists and is active
 	user, err := s.userRepo.GetByID(ctx, input.UserID)
 	if err != nil {
 		if err == repository.ErrNotFound {
 			return nil, ErrUserNotFound
 		}
-		s.logger.Error().Err(err).Int64("user_id", input.UserID).Msg("failed to get user")
+		s.logger.Error().Ctx(ctx).Err(err).Int64("user_id", input.UserID).Msg("failed to get user")
 		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 
@@ -76,7 +100,7 @@ func (s *IAMService) CreateAccessKey(ctx context.Context, input CreateAccessKeyI
 	// Check max access keys limit
 	existingKeys, err := s.accessKeyRepo.ListByUserID(ctx, input.UserID)
 	if err != nil {
-		s.logger.Error().Err(err).Int64("user_id", input.UserID).Msg("failed to list user access keys")
+		s.logger.Error().Ctx(ctx).Err(err).Int64("user_id", input.UserID).Msg("failed to list user access keys")
 		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 
@@ -87,21 +111,21 @@ func (s *IAMService) CreateAccessKey(ctx context.Context, input CreateAccessKeyI
 		}
 	}
 
-	if activeCount >= MaxAccessKeysPerUser {
+	if activeCount >= s.config.MaxAccessKeysPerUser {
 		return nil, ErrMaxAccessKeysReached
 	}
 
 	// Generate access key pair
 	accessKeyID, secretKey, err := crypto.GenerateAccessKeyPair()
 	if err != nil {
-		s.logger.Error().Err(err).Msg("failed to generate access key pair")
+		s.logger.Error().Ctx(ctx).Err(err).Msg("failed to generate access key pair")
 		return nil, fmt.Errorf("%w: %v", ErrEncryptionFailed, err)
 	}
 
 	// Encrypt secret key
 	encryptedSecret, err := s.encryptor.EncryptString(secretKey)
 	if err != nil {
-		s.logger.Error().Err(err).Msg("failed to encrypt secret key")
+		s.logger.Error().Ctx(ctx).Err(err).Msg("failed to encrypt secret key")
 		return nil, fmt.Errorf("%w: %v", ErrEncryptionFailed, err)
 	}
 
@@ -109,13 +133,14 @@ func (s *IAMService) CreateAccessKey(ctx context.Context, input CreateAccessKeyI
 	accessKey := domain.NewAccessKey(input.UserID, accessKeyID, encryptedSecret)
 	accessKey.Description = input.Description
 	accessKey.ExpiresAt = input.ExpiresAt
+	accessKey.Policy = input.Policy
 
 	if err := s.accessKeyRepo.Create(ctx, accessKey); err != nil {
-		s.logger.Error().Err(err).Str("access_key_id", accessKeyID).Msg("failed to create access key")
+		s.logger.Error().Ctx(ctx).Err(err).Str("access_key_id", accessKeyID).Msg("failed to create access key")
 		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 
-	s.logger.Info().
+	s.logger.Info().Ctx(ctx).
 		Int64("user_id", input.UserID).
 		Str("access_key_id", accessKeyID).
 		Msg("access key created")
@@ -134,7 +159,7 @@ func (s *IAMService) GetAccessKey(ctx context.Context, accessKeyID string) (*dom
 		if err == repository.ErrNotFound {
 			return nil, ErrAccessKeyNotFound
 		}
-		s.logger.Error().Err(err).Str("access_key_id", accessKeyID).Msg("failed to get access key")
+		s.logger.Error().Ctx(ctx).Err(err).Str("access_key_id", accessKeyID).Msg("failed to get access key")
 		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 	return key, nil
@@ -150,7 +175,7 @@ type ListAccessKeysInput struct {
 func (s *IAMService) ListAccessKeys(ctx context.Context, input ListAccessKeysInput) ([]*domain.AccessKey, error) {
 	keys, err := s.accessKeyRepo.ListByUserID(ctx, input.UserID)
 	if err != nil {
-		s.logger.Error().Err(err).Int64("user_id", input.UserID).Msg("failed to list access keys")
+		s.logger.Error().Ctx(ctx).Err(err).Int64("user_id", input.UserID).Msg("failed to list access keys")
 		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 
@@ -180,11 +205,11 @@ func (s *IAMService) DeactivateAccessKey(ctx context.Context, accessKeyID string
 	key.Status = domain.AccessKeyStatusInactive
 
 	if err := s.accessKeyRepo.Update(ctx, key); err != nil {
-		s.logger.Error().Err(err).Str("access_key_id", accessKeyID).Msg("failed to deactivate access key")
+		s.logger.Error().Ctx(ctx).Err(err).Str("access_key_id", accessKeyID).Msg("failed to deactivate access key")
 		return fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 
-	s.logger.Info().
+	s.logger.Info().Ctx(ctx).
 		Str("access_key_id", accessKeyID).
 		Int64("user_id", key.UserID).
 		Msg("access key deactivated")
@@ -205,11 +230,11 @@ func (s *IAMService) ActivateAccessKey(ctx context.Context, accessKeyID string)
 	key.Status = domain.AccessKeyStatusActive
 
 	if err := s.accessKeyRepo.Update(ctx, key); err != nil {
-		s.logger.Error().Err(err).Str("access_key_id", accessKeyID).Msg("failed to activate access key")
+		s.logger.Error().Ctx(ctx).Err(err).Str("access_key_id", accessKeyID).Msg("failed to activate access key")
 		return fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 
-	s.logger.Info().
+	s.logger.Info().Ctx(ctx).
 		Str("access_key_id", accessKeyID).
 		Int64("user_id", key.UserID).
 		Msg("access key activated")
@@ -223,24 +248,41 @@ func (s *IAMService) DeleteAccessKey(ctx context.Context, accessKeyID string) er
 		if err == repository.ErrNotFound {
 			return ErrAccessKeyNotFound
 		}
-		s.logger.Error().Err(err).Str("access_key_id", accessKeyID).Msg("failed to delete access key")
+		s.logger.Error().Ctx(ctx).Err(err).Str("access_key_id", accessKeyID).Msg("failed to delete access key")
 		return fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 
-	s.logger.Info().Str("access_key_id", accessKeyID).Msg("access key deleted")
+	s.logger.Info().Ctx(ctx).Str("access_key_id", accessKeyID).Msg("access key deleted")
 	return nil
 }
 
+// DeleteUserAccessKey permanently deletes one of a user's own access keys.
+// Keys of other users are reported as not found.
+func (s *IAMService) DeleteUserAccessKey(ctx context.Context, userID int64, accessKeyID string) error {
+	key, err := s.accessKeyRepo.GetByAccessKeyID(ctx, accessKeyID)
+	if err != nil {
+		if err == repository.ErrNotFound || errors.Is(err, domain.ErrAccessKeyNotFound) {
+			return ErrAccessKeyNotFound
+		}
+		return fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	if key.UserID != userID {
+		return ErrAccessKeyNotFound
+	}
+
+	return s.DeleteAccessKey(ctx, accessKeyID)
+}
+
 // DeleteExpiredAccessKeys deletes all expired access keys (cleanup job).
 func (s *IAMService) DeleteExpiredAccessKeys(ctx context.Context) (int64, error) {
 	count, err := s.accessKeyRepo.DeleteExpired(ctx)
 	if err != nil {
-		s.logger.Error().Err(err).Msg("failed to delete expired access keys")
+		s.logger.Error().Ctx(ctx).Err(err).Msg("failed to delete expired access keys")
 		return 0, fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 
 	if count > 0 {
-		s.logger.Info().Int64("count", count).Msg("deleted expired access keys")
+		s.logger.Info().Ctx(ctx).Int64("count", count).Msg("deleted expired access keys")
 	}
 
 	return count, nil
@@ -268,14 +310,14 @@ func (s *IAMService) VerifyAccessKey(ctx context.Context, accessKeyID string) (*
 	// Get user info for username
 	user, err := s.userRepo.GetByID(ctx, key.UserID)
 	if err != nil {
-		s.logger.Error().Err(err).Int64("user_id", key.UserID).Msg("failed to get user for access key")
+		s.logger.Error().Ctx(ctx).Err(err).Int64("user_id", key.UserID).Msg("failed to get user for access key")
 		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 
 	// Decrypt secret key
 	secretKey, err := s.encryptor.DecryptString(key.EncryptedSecret)
 	if err != nil {
-		s.logger.Error().Err(err).Str("access_key_id", accessKeyID).Msg("failed to decrypt secret key")
+		s.logger.Error().Ctx(ctx).Err(err).Str("access_key_id", accessKeyID).Msg("failed to decrypt secret key")
 		return nil, fmt.Errorf("%w: %v", ErrDecryptionFailed, err)
 	}
 
@@ -284,8 +326,10 @@ func (s *IAMService) VerifyAccessKey(ctx context.Context, accessKeyID string) (*
 		SecretKey:   secretKey,
 		UserID:      key.UserID,
 		Username:    user.Username,
+		TenantID:    user.TenantID,
 		IsActive:    key.Status == domain.AccessKeyStatusActive,
 		ExpiresAt:   key.ExpiresAt,
+		Policy:      key.Policy,
 	}, nil
 }
 
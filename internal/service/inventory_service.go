@@ -0,0 +1,737 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/md5"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/lock"
+	"github.com/prn-tf/alexander-storage/internal/pkg/parquet"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// InventoryService manages bucket inventory configurations and generates
+// their reports on schedule. A report lists the objects of a bucket in one
+// or more data files, CSV or Parquet, written to the destination bucket
+// together with a manifest naming them.
+type InventoryService struct {
+	inventoryRepo repository.InventoryRepository
+	bucketRepo    repository.BucketRepository
+	objects       *ObjectService
+	locker        lock.Locker
+	logger        zerolog.Logger
+	config        InventoryConfig
+
+	// Control
+	mu       sync.Mutex
+	running  bool
+	stopChan chan struct{}
+	doneChan chan struct{}
+
+	// now is replaceable in tests.
+	now func() time.Time
+}
+
+// InventoryConfig contains inventory scheduler configuration.
+type InventoryConfig struct {
+	// Interval is how often to look for reports that are due.
+	Interval time.Duration
+
+	// BatchSize is the number of objects listed at a time.
+	BatchSize int
+
+	// MaxFileRows is the number of objects per data file.
+	MaxFileRows int
+
+	// TempDir holds data files while they are written. Empty uses the
+	// system temporary directory.
+	TempDir string
+}
+
+// DefaultInventoryConfig returns sensible defaults.
+func DefaultInventoryConfig() InventoryConfig {
+	return InventoryConfig{
+		Interval:    time.Hour,
+		BatchSize:   1000,
+		MaxFileRows: 1000000,
+	}
+}
+
+// NewInventoryService creates a new InventoryService. Reports are stored
+// through objects, like uploads of the bucket owner.
+func NewInventoryService(
+	inventoryRepo repository.InventoryRepository,
+	bucketRepo repository.BucketRepository,
+	objects *ObjectService,
+	locker lock.Locker,
+	logger zerolog.Logger,
+	config InventoryConfig,
+) *InventoryService {
+	if config.Interval <= 0 {
+		config.Interval = time.Hour
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 1000
+	}
+	if config.MaxFileRows <= 0 {
+		config.MaxFileRows = 1000000
+	}
+
+	return &InventoryService{
+		inventoryRepo: inventoryRepo,
+		bucketRepo:    bucketRepo,
+		objects:       objects,
+		locker:        locker,
+		logger:        logger.With().Str("service", "inventory").Logger(),
+		config:        config,
+		stopChan:      make(chan struct{}),
+		doneChan:      make(chan struct{}),
+		now:           time.Now,
+	}
+}
+
+// =============================================================================
+// Configuration
+// =============================================================================
+
+// PutInventoryConfiguration creates or replaces an inventory configuration
+// of a bucket. The destination bucket must exist and have the same owner.
+func (s *InventoryService) PutInventoryConfiguration(ctx context.Context, bucketName string, ownerID int64, config *domain.InventoryConfig) error {
+	bucket, err := s.bucket(ctx, bucketName, ownerID)
+	if err != nil {
+		return err
+	}
+
+	config.BucketID = bucket.ID
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	destination, err := s.bucket(ctx, config.DestinationBucket, 0)
+	if err != nil {
+		return err
+	}
+	if destination.OwnerID != bucket.OwnerID {
+		return ErrInventoryDestinationNotOwned
+	}
+
+	existing, err := s.inventoryRepo.ListByBucket(ctx, bucket.ID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	replaced := false
+	for _, c := range existing {
+		replaced = replaced || c.ID == config.ID
+	}
+	if !replaced && len(existing) >= domain.MaxInventoryConfigs {
+		return domain.ErrTooManyInventoryConfigs
+	}
+
+	now := s.now().UTC()
+	config.CreatedAt = now
+	config.UpdatedAt = now
+	if err := s.inventoryRepo.Put(ctx, config); err != nil {
+		return fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	s.logger.Info().Ctx(ctx).
+		Str("bucket", bucket.Name).
+		Str("inventory", config.ID).
+		Str("destination_bucket", config.DestinationBucket).
+		Str("format", string(config.Format)).
+		Str("frequency", string(config.Frequency)).
+		Msg("bucket inventory configured")
+	return nil
+}
+
+// GetInventoryConfiguration returns an inventory configuration of a bucket.
+func (s *InventoryService) GetInventoryConfiguration(ctx context.Context, bucketName, id string, ownerID int64) (*domain.InventoryConfig, error) {
+	bucket, err := s.bucket(ctx, bucketName, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := s.inventoryRepo.Get(ctx, bucket.ID, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrInventoryConfigNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	return config, nil
+}
+
+// ListInventoryConfigurations returns the inventory configurations of a bucket, by ID.
+func (s *InventoryService) ListInventoryConfigurations(ctx context.Context, bucketName string, ownerID int64) ([]*domain.InventoryConfig, error) {
+	bucket, err := s.bucket(ctx, bucketName, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	configs, err := s.inventoryRepo.ListByBucket(ctx, bucket.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	return configs, nil
+}
+
+// DeleteInventoryConfiguration removes an inventory configuration of a
+// bucket. Reports already generated are kept.
+func (s *InventoryService) DeleteInventoryConfiguration(ctx context.Context, bucketName, id string, ownerID int64) error {
+	bucket, err := s.bucket(ctx, bucketName, ownerID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.inventoryRepo.Delete(ctx, bucket.ID, id); err != nil {
+		if errors.Is(err, domain.ErrInventoryConfigNotFound) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	s.logger.Info().Ctx(ctx).Str("bucket", bucket.Name).Str("inventory", id).Msg("bucket inventory deleted")
+	return nil
+}
+
+// bucket returns a bucket by name, checking that ownerID owns it unless
+// ownerID is 0.
+func (s *InventoryService) bucket(ctx context.Context, name string, ownerID int64) (*domain.Bucket, error) {
+	bucket, err := s.bucketRepo.GetByName(ctx, name)
+	if err != nil {
+		if errors.Is(err, domain.ErrBucketNotFound) {
+			return nil, domain.ErrBucketNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	if ownerID > 0 && bucket.OwnerID != ownerID {
+		return nil, ErrBucketAccessDenied
+	}
+	return bucket, nil
+}
+
+// =============================================================================
+// Scheduler
+// =============================================================================
+
+// Start begins generating reports on schedule.
+func (s *InventoryService) Start() {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = true
+	s.mu.Unlock()
+
+	s.logger.Info().
+		Dur("interval", s.config.Interval).
+		Msg("Starting inventory scheduler")
+
+	go s.runLoop()
+}
+
+// Stop stops the scheduler, abandoning a report in progress.
+func (s *InventoryService) Stop() {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = false
+	s.mu.Unlock()
+
+	close(s.stopChan)
+	<-s.doneChan
+
+	s.logger.Info().Msg("Inventory scheduler stopped")
+}
+
+func (s *InventoryService) runLoop() {
+	defer close(s.doneChan)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-s.stopChan
+		cancel()
+	}()
+
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		s.RunOnce(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+// RunOnce generates every report that is due and returns the number generated.
+func (s *InventoryService) RunOnce(ctx context.Context) int {
+	lockKey := lock.Keys.Inventory()
+	lockTTL := s.config.Interval / 2
+	if lockTTL < 5*time.Minute {
+		lockTTL = 5 * time.Minute
+	}
+
+	acquired, err := s.locker.Acquire(ctx, lockKey, lockTTL)
+	if err != nil {
+		s.logger.Error().Ctx(ctx).Err(err).Msg("Failed to acquire inventory lock")
+		return 0
+	}
+	if !acquired {
+		s.logger.Debug().Ctx(ctx).Msg("Inventory lock held by another process, skipping run")
+		return 0
+	}
+	defer func() {
+		if _, err := s.locker.Release(context.Background(), lockKey); err != nil {
+			s.logger.Error().Ctx(ctx).Err(err).Msg("Failed to release inventory lock")
+		}
+	}()
+
+	configs, err := s.inventoryRepo.ListEnabled(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			s.logger.Error().Ctx(ctx).Err(err).Msg("Failed to list inventory configurations")
+		}
+		return 0
+	}
+
+	generated := 0
+	for _, config := range configs {
+		if ctx.Err() != nil {
+			break
+		}
+		if !config.IsDue(s.now()) {
+			continue
+		}
+
+		manifest, err := s.Generate(ctx, config)
+		if err != nil {
+			if ctx.Err() == nil {
+				s.logger.Error().Ctx(ctx).Err(err).
+					Int64("bucket_id", config.BucketID).
+					Str("inventory", config.ID).
+					Msg("Failed to generate inventory report")
+			}
+			continue
+		}
+		generated++
+
+		s.logger.Info().Ctx(ctx).
+			Str("bucket", manifest.SourceBucket).
+			Str("inventory", config.ID).
+			Int("files", len(manifest.Files)).
+			Msg("Inventory report generated")
+	}
+	return generated
+}
+
+// =============================================================================
+// Reports
+// =============================================================================
+
+// InventoryManifest describes a generated report. It is stored as
+// manifest.json next to a manifest.checksum file holding its MD5.
+type InventoryManifest struct {
+	SourceBucket      string                  `json:"sourceBucket"`
+	DestinationBucket string                  `json:"destinationBucket"`
+	Version           string                  `json:"version"`
+	CreationTimestamp string                  `json:"creationTimestamp"`
+	FileFormat        domain.InventoryFormat  `json:"fileFormat"`
+	FileSchema        string                  `json:"fileSchema"`
+	Files             []InventoryManifestFile `json:"files"`
+}
+
+// InventoryManifestFile is a data file of a report.
+type InventoryManifestFile struct {
+	Key         string `json:"key"`
+	Size        int64  `json:"size"`
+	MD5Checksum string `json:"MD5checksum"`
+}
+
+// inventoryTimeFormat is the format of LastModifiedDate in CSV reports.
+const inventoryTimeFormat = "2006-01-02T15:04:05.000Z"
+
+// inventoryColumn is a column of a report.
+type inventoryColumn struct {
+	// header is the column name in the manifest schema of CSV reports.
+	header string
+
+	// parquet describes the column in Parquet reports.
+	parquet parquet.Column
+
+	// value returns the value of an entry, nil if it has none.
+	value func(entry *domain.InventoryEntry) any
+}
+
+// inventoryColumns returns the columns of a configuration's reports.
+func inventoryColumns(config *domain.InventoryConfig, bucket string) []inventoryColumn {
+	columns := []inventoryColumn{
+		{"Bucket", parquet.Column{Name: "bucket", Type: parquet.String},
+			func(*domain.InventoryEntry) any { return bucket }},
+		{"Key", parquet.Column{Name: "key", Type: parquet.String},
+			func(e *domain.InventoryEntry) any { return e.Key }},
+	}
+	if config.AllVersions {
+		columns = append(columns,
+			inventoryColumn{"VersionId", parquet.Column{Name: "version_id", Type: parquet.String},
+				func(e *domain.InventoryEntry) any { return e.VersionID }},
+			inventoryColumn{"IsLatest", parquet.Column{Name: "is_latest", Type: parquet.Boolean},
+				func(e *domain.InventoryEntry) any { return e.IsLatest }},
+			inventoryColumn{"IsDeleteMarker", parquet.Column{Name: "is_delete_marker", Type: parquet.Boolean},
+				func(e *domain.InventoryEntry) any { return e.IsDeleteMarker }},
+		)
+	}
+
+	// Delete markers have no content, so content fields are left empty
+	content := func(value func(e *domain.InventoryEntry) any) func(e *domain.InventoryEntry) any {
+		return func(e *domain.InventoryEntry) any {
+			if e.IsDeleteMarker {
+				return nil
+			}
+			return value(e)
+		}
+	}
+
+	for _, field := range domain.InventoryFields {
+		if !config.HasField(field) {
+			continue
+		}
+		switch field {
+		case domain.InventoryFieldSize:
+			columns = append(columns, inventoryColumn{"Size",
+				parquet.Column{Name: "size", Type: parquet.Int64, Optional: true},
+				content(func(e *domain.InventoryEntry) any { return e.Size })})
+		case domain.InventoryFieldLastModifiedDate:
+			columns = append(columns, inventoryColumn{"LastModifiedDate",
+				parquet.Column{Name: "last_modified_date", Type: parquet.Timestamp},
+				func(e *domain.InventoryEntry) any { return e.LastModified.UTC() }})
+		case domain.InventoryFieldETag:
+			columns = append(columns, inventoryColumn{"ETag",
+				parquet.Column{Name: "e_tag", Type: parquet.String, Optional: true},
+				content(func(e *domain.InventoryEntry) any { return strings.Trim(e.ETag, `"`) })})
+		case domain.InventoryFieldStorageClass:
+			columns = append(columns, inventoryColumn{"StorageClass",
+				parquet.Column{Name: "storage_class", Type: parquet.String, Optional: true},
+				content(func(e *domain.InventoryEntry) any { return string(e.StorageClass) })})
+		case domain.InventoryFieldEncryptionStatus:
+			columns = append(columns, inventoryColumn{"EncryptionStatus",
+				parquet.Column{Name: "encryption_status", Type: parquet.String, Optional: true},
+				content(func(e *domain.InventoryEntry) any {
+					if e.Encrypted {
+						return "SSE-S3"
+					}
+					return "NOT-SSE"
+				})})
+		case domain.InventoryFieldReplicationStatus:
+			columns = append(columns, inventoryColumn{"ReplicationStatus",
+				parquet.Column{Name: "replication_status", Type: parquet.String, Optional: true},
+				func(e *domain.InventoryEntry) any {
+					if e.ReplicationStatus == domain.ReplicationStatusNone {
+						return nil
+					}
+					return string(e.ReplicationStatus)
+				}})
+		}
+	}
+	return columns
+}
+
+// Generate writes a report of config to its destination bucket now and
+// records the run. Data files are written first, so a manifest only ever
+// names complete files.
+func (s *InventoryService) Generate(ctx context.Context, config *domain.InventoryConfig) (*InventoryManifest, error) {
+	source, err := s.bucketRepo.GetByID(ctx, config.BucketID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get source bucket: %w", err)
+	}
+	destination, err := s.bucket(ctx, config.DestinationBucket, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get destination bucket: %w", err)
+	}
+	if destination.OwnerID != source.OwnerID {
+		return nil, ErrInventoryDestinationNotOwned
+	}
+
+	now := s.now().UTC()
+	columns := inventoryColumns(config, source.Name)
+	manifest := &InventoryManifest{
+		SourceBucket:      source.Name,
+		DestinationBucket: domain.PolicyResourcePrefix + destination.Name,
+		Version:           "2016-11-30",
+		CreationTimestamp: strconv.FormatInt(now.UnixMilli(), 10),
+		FileFormat:        config.Format,
+		FileSchema:        inventorySchema(config.Format, columns),
+		Files:             []InventoryManifestFile{},
+	}
+	prefix := config.ReportPrefix(source.Name)
+
+	var file *inventoryFile
+	defer func() {
+		if file != nil {
+			file.discard()
+		}
+	}()
+	upload := func() error {
+		uploaded, err := s.upload(ctx, destination.Name, prefix, config.Format, file)
+		file = nil
+		if err != nil {
+			return err
+		}
+		manifest.Files = append(manifest.Files, *uploaded)
+		return nil
+	}
+
+	var after *domain.InventoryEntry
+	for {
+		entries, err := s.inventoryRepo.ListEntries(ctx, config.BucketID, repository.InventoryListOptions{
+			Prefix:      config.Prefix,
+			AllVersions: config.AllVersions,
+			After:       after,
+			Limit:       s.config.BatchSize,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list objects: %w", err)
+		}
+
+		for _, entry := range entries {
+			if file == nil {
+				if file, err = s.newInventoryFile(config.Format, columns); err != nil {
+					return nil, err
+				}
+			}
+			if err := file.write(columns, entry); err != nil {
+				return nil, err
+			}
+			if file.rows >= s.config.MaxFileRows {
+				if err := upload(); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		if len(entries) < s.config.BatchSize {
+			break
+		}
+		after = entries[len(entries)-1]
+	}
+
+	// An empty listing still gets an empty data file, so consumers always
+	// find at least one
+	if file == nil && len(manifest.Files) == 0 {
+		if file, err = s.newInventoryFile(config.Format, columns); err != nil {
+			return nil, err
+		}
+	}
+	if file != nil {
+		if err := upload(); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	sum := md5.Sum(data)
+	manifestPrefix := prefix + now.Format("2006-01-02T15-04Z") + "/"
+	if err := s.put(ctx, destination.Name, manifestPrefix+"manifest.json", "application/json", data); err != nil {
+		return nil, err
+	}
+	checksum := []byte(hex.EncodeToString(sum[:]))
+	if err := s.put(ctx, destination.Name, manifestPrefix+"manifest.checksum", "text/plain", checksum); err != nil {
+		return nil, err
+	}
+
+	if err := s.inventoryRepo.MarkRun(ctx, config.BucketID, config.ID, now); err != nil {
+		return nil, err
+	}
+	config.LastRunAt = &now
+	return manifest, nil
+}
+
+// inventorySchema returns the fileSchema of a manifest.
+func inventorySchema(format domain.InventoryFormat, columns []inventoryColumn) string {
+	if format == domain.InventoryFormatParquet {
+		parquetColumns := make([]parquet.Column, len(columns))
+		for i, column := range columns {
+			parquetColumns[i] = column.parquet
+		}
+		return parquet.Schema("s3_inventory", parquetColumns)
+	}
+
+	headers := make([]string, len(columns))
+	for i, column := range columns {
+		headers[i] = column.header
+	}
+	return strings.Join(headers, ", ")
+}
+
+// upload stores a finished data file in the destination bucket.
+func (s *InventoryService) upload(ctx context.Context, bucket, prefix string, format domain.InventoryFormat, file *inventoryFile) (*InventoryManifestFile, error) {
+	defer file.discard()
+
+	if err := file.close(); err != nil {
+		return nil, err
+	}
+	size, err := file.f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to size inventory file: %w", err)
+	}
+	if _, err := file.f.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to rewind inventory file: %w", err)
+	}
+
+	key := prefix + "data/" + uuid.NewString()
+	contentType := "application/octet-stream"
+	if format == domain.InventoryFormatParquet {
+		key += ".parquet"
+	} else {
+		key += ".csv.gz"
+		contentType = "application/gzip"
+	}
+
+	_, err = s.objects.PutObject(ctx, PutObjectInput{
+		BucketName:  bucket,
+		Key:         key,
+		Body:        file.f,
+		Size:        size,
+		ContentType: contentType,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store inventory file: %w", err)
+	}
+
+	return &InventoryManifestFile{
+		Key:         key,
+		Size:        size,
+		MD5Checksum: hex.EncodeToString(file.md5.Sum(nil)),
+	}, nil
+}
+
+// put stores a small object in the destination bucket.
+func (s *InventoryService) put(ctx context.Context, bucket, key, contentType string, data []byte) error {
+	_, err := s.objects.PutObject(ctx, PutObjectInput{
+		BucketName:  bucket,
+		Key:         key,
+		Body:        bytes.NewReader(data),
+		Size:        int64(len(data)),
+		ContentType: contentType,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store %s: %w", key, err)
+	}
+	return nil
+}
+
+// inventoryFile is a data file being written to a temporary file.
+type inventoryFile struct {
+	f    *os.File
+	md5  hash.Hash
+	rows int
+
+	// Exactly one of these is set
+	gz      *gzip.Writer
+	csv     *csv.Writer
+	parquet *parquet.Writer
+
+	record []string
+	row    []any
+}
+
+func (s *InventoryService) newInventoryFile(format domain.InventoryFormat, columns []inventoryColumn) (*inventoryFile, error) {
+	f, err := os.CreateTemp(s.config.TempDir, "inventory-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create inventory file: %w", err)
+	}
+
+	file := &inventoryFile{f: f, md5: md5.New()}
+	w := io.MultiWriter(f, file.md5)
+	if format == domain.InventoryFormatParquet {
+		parquetColumns := make([]parquet.Column, len(columns))
+		for i, column := range columns {
+			parquetColumns[i] = column.parquet
+		}
+		file.parquet = parquet.NewWriter(w, parquetColumns)
+		file.row = make([]any, len(columns))
+	} else {
+		file.gz = gzip.NewWriter(w)
+		file.csv = csv.NewWriter(file.gz)
+		file.record = make([]string, len(columns))
+	}
+	return file, nil
+}
+
+func (file *inventoryFile) write(columns []inventoryColumn, entry *domain.InventoryEntry) error {
+	file.rows++
+	if file.parquet != nil {
+		for i, column := range columns {
+			file.row[i] = column.value(entry)
+		}
+		return file.parquet.Write(file.row)
+	}
+
+	for i, column := range columns {
+		switch v := column.value(entry).(type) {
+		case nil:
+			file.record[i] = ""
+		case string:
+			file.record[i] = v
+		case int64:
+			file.record[i] = strconv.FormatInt(v, 10)
+		case bool:
+			file.record[i] = strconv.FormatBool(v)
+		case time.Time:
+			file.record[i] = v.Format(inventoryTimeFormat)
+		}
+	}
+	return file.csv.Write(file.record)
+}
+
+// close finishes the file's encoding.
+func (file *inventoryFile) close() error {
+	if file.parquet != nil {
+		if err := file.parquet.Close(); err != nil {
+			return fmt.Errorf("failed to write inventory file: %w", err)
+		}
+		return nil
+	}
+
+	file.csv.Flush()
+	if err := file.csv.Error(); err != nil {
+		return fmt.Errorf("failed to write inventory file: %w", err)
+	}
+	if err := file.gz.Close(); err != nil {
+		return fmt.Errorf("failed to write inventory file: %w", err)
+	}
+	return nil
+}
+
+// discard removes the temporary file.
+func (file *inventoryFile) discard() {
+	file.f.Close()
+	os.Remove(file.f.Name())
+}
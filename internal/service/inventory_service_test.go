@@ -0,0 +1,267 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/md5"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/lock"
+	"github.com/prn-tf/alexander-storage/internal/repository/sqlite"
+	"github.com/prn-tf/alexander-storage/internal/storage/filesystem"
+)
+
+type inventoryTestEnv struct {
+	objects   *ObjectService
+	inventory *InventoryService
+	ownerID   int64
+	otherID   int64
+}
+
+// newInventoryTestEnv returns services backed by SQLite and the filesystem,
+// with a versioned bucket "src" and a bucket "reports" of the same owner,
+// and a bucket "foreign" of another user. The filesystem backend stores
+// blobs unencrypted.
+func newInventoryTestEnv(t *testing.T) *inventoryTestEnv {
+	t.Helper()
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	db, err := sqlite.NewDB(ctx, sqlite.DefaultConfig(filepath.Join(dir, "alexander.db")), zerolog.Nop())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, db.Migrate(ctx))
+
+	store, err := filesystem.NewStorage(filesystem.Config{
+		DataDir: filepath.Join(dir, "blobs"),
+		TempDir: filepath.Join(dir, "tmp"),
+	}, zerolog.Nop())
+	require.NoError(t, err)
+
+	userRepo := sqlite.NewUserRepository(db)
+	owner := domain.NewUser("owner", "owner@example.com", "hash")
+	require.NoError(t, userRepo.Create(ctx, owner))
+	other := domain.NewUser("other", "other@example.com", "hash")
+	require.NoError(t, userRepo.Create(ctx, other))
+
+	bucketRepo := sqlite.NewBucketRepository(db)
+	src := domain.NewBucket(owner.ID, "src")
+	src.Versioning = domain.VersioningEnabled
+	require.NoError(t, bucketRepo.Create(ctx, src))
+	require.NoError(t, bucketRepo.Create(ctx, domain.NewBucket(owner.ID, "reports")))
+	require.NoError(t, bucketRepo.Create(ctx, domain.NewBucket(other.ID, "foreign")))
+
+	locker := lock.NewMemoryLocker()
+	objects := NewObjectService(
+		sqlite.NewObjectRepository(db),
+		sqlite.NewBlobRepository(db),
+		bucketRepo,
+		sqlite.NewEventRepository(db),
+		sqlite.NewReplicationRepository(db),
+		sqlite.NewLifecycleRepository(db),
+		sqlite.NewStagedObjectRepository(db),
+		sqlite.NewBatchRepository(db),
+		nil,
+		sqlite.NewTxManager(db),
+		store,
+		locker,
+		nil,
+		zerolog.Nop(),
+	)
+
+	config := DefaultInventoryConfig()
+	config.BatchSize = 2
+	config.TempDir = dir
+	inventory := NewInventoryService(sqlite.NewInventoryRepository(db), bucketRepo, objects, locker, zerolog.Nop(), config)
+
+	return &inventoryTestEnv{objects: objects, inventory: inventory, ownerID: owner.ID, otherID: other.ID}
+}
+
+func (env *inventoryTestEnv) get(t *testing.T, bucket, key string) []byte {
+	t.Helper()
+	output, err := env.objects.GetObject(context.Background(), GetObjectInput{BucketName: bucket, Key: key})
+	require.NoError(t, err)
+	defer output.Body.Close()
+	data, err := io.ReadAll(output.Body)
+	require.NoError(t, err)
+	return data
+}
+
+// manifest returns the only manifest under prefix and checks its checksum.
+func (env *inventoryTestEnv) manifest(t *testing.T, prefix string) *InventoryManifest {
+	t.Helper()
+	output, err := env.objects.ListObjects(context.Background(), ListObjectsInput{BucketName: "reports", Prefix: prefix, MaxKeys: 1000})
+	require.NoError(t, err)
+
+	var manifestKey string
+	for _, obj := range output.Contents {
+		if strings.HasSuffix(obj.Key, "/manifest.json") {
+			require.Empty(t, manifestKey, "more than one manifest")
+			manifestKey = obj.Key
+		}
+	}
+	require.NotEmpty(t, manifestKey)
+
+	data := env.get(t, "reports", manifestKey)
+	sum := md5.Sum(data)
+	checksum := env.get(t, "reports", strings.TrimSuffix(manifestKey, "json")+"checksum")
+	require.Equal(t, hex.EncodeToString(sum[:]), string(checksum))
+
+	manifest := &InventoryManifest{}
+	require.NoError(t, json.Unmarshal(data, manifest))
+	return manifest
+}
+
+func TestInventoryService_Configuration(t *testing.T) {
+	env := newInventoryTestEnv(t)
+	ctx := context.Background()
+
+	config := func(id, destination string, format domain.InventoryFormat) *domain.InventoryConfig {
+		return &domain.InventoryConfig{
+			ID:                id,
+			Enabled:           true,
+			DestinationBucket: destination,
+			Format:            format,
+			Frequency:         domain.InventoryFrequencyDaily,
+		}
+	}
+
+	require.NoError(t, env.inventory.PutInventoryConfiguration(ctx, "src", env.ownerID, config("daily", "reports", domain.InventoryFormatCSV)))
+	require.ErrorIs(t, env.inventory.PutInventoryConfiguration(ctx, "src", env.ownerID, config("bad", "reports", "ORC")),
+		domain.ErrInvalidInventoryConfig)
+	require.ErrorIs(t, env.inventory.PutInventoryConfiguration(ctx, "src", env.ownerID, config("foreign", "foreign", domain.InventoryFormatCSV)),
+		ErrInventoryDestinationNotOwned)
+	require.ErrorIs(t, env.inventory.PutInventoryConfiguration(ctx, "src", env.otherID, config("daily", "reports", domain.InventoryFormatCSV)),
+		ErrBucketAccessDenied)
+
+	// Replacing keeps the ID
+	require.NoError(t, env.inventory.PutInventoryConfiguration(ctx, "src", env.ownerID, config("daily", "reports", domain.InventoryFormatParquet)))
+	configs, err := env.inventory.ListInventoryConfigurations(ctx, "src", env.ownerID)
+	require.NoError(t, err)
+	require.Len(t, configs, 1)
+	require.Equal(t, domain.InventoryFormatParquet, configs[0].Format)
+
+	require.NoError(t, env.inventory.DeleteInventoryConfiguration(ctx, "src", "daily", env.ownerID))
+	_, err = env.inventory.GetInventoryConfiguration(ctx, "src", "daily", env.ownerID)
+	require.ErrorIs(t, err, domain.ErrInventoryConfigNotFound)
+}
+
+func TestInventoryService_GenerateCSV(t *testing.T) {
+	env := newInventoryTestEnv(t)
+	ctx := context.Background()
+
+	put := func(key, body string) string {
+		output, err := putString(ctx, env.objects, "src", key, body, nil)
+		require.NoError(t, err)
+		return output.VersionID
+	}
+	a1 := put("a", "first")
+	a2 := put("a", "second")
+	b1 := put("b", "bee")
+	deleted, err := env.objects.DeleteObject(ctx, DeleteObjectInput{BucketName: "src", Key: "b"})
+	require.NoError(t, err)
+	c1 := put("c", "see")
+
+	require.NoError(t, env.inventory.PutInventoryConfiguration(ctx, "src", env.ownerID, &domain.InventoryConfig{
+		ID:                "all",
+		Enabled:           true,
+		DestinationBucket: "reports",
+		DestinationPrefix: "inventory/",
+		Format:            domain.InventoryFormatCSV,
+		Frequency:         domain.InventoryFrequencyDaily,
+		AllVersions:       true,
+		Fields:            []domain.InventoryField{domain.InventoryFieldSize, domain.InventoryFieldEncryptionStatus},
+	}))
+	// A prefix filter that includes nothing stays enabled and still reports
+	require.NoError(t, env.inventory.PutInventoryConfiguration(ctx, "src", env.ownerID, &domain.InventoryConfig{
+		ID:                "none",
+		Enabled:           true,
+		DestinationBucket: "reports",
+		Format:            domain.InventoryFormatCSV,
+		Frequency:         domain.InventoryFrequencyWeekly,
+		Prefix:            "missing/",
+	}))
+
+	// Small files, so the report spans several
+	env.inventory.config.MaxFileRows = 3
+	require.Equal(t, 2, env.inventory.RunOnce(ctx))
+	require.Equal(t, 0, env.inventory.RunOnce(ctx), "reports are not due again before their frequency")
+
+	manifest := env.manifest(t, "inventory/src/all/")
+	require.Equal(t, "src", manifest.SourceBucket)
+	require.Equal(t, "arn:aws:s3:::reports", manifest.DestinationBucket)
+	require.Equal(t, domain.InventoryFormatCSV, manifest.FileFormat)
+	require.Equal(t, "Bucket, Key, VersionId, IsLatest, IsDeleteMarker, Size, EncryptionStatus", manifest.FileSchema)
+	require.Len(t, manifest.Files, 2)
+
+	var rows [][]string
+	for _, file := range manifest.Files {
+		require.True(t, strings.HasPrefix(file.Key, "inventory/src/all/data/"))
+		data := env.get(t, "reports", file.Key)
+		require.Equal(t, file.Size, int64(len(data)))
+		sum := md5.Sum(data)
+		require.Equal(t, file.MD5Checksum, hex.EncodeToString(sum[:]))
+
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		require.NoError(t, err)
+		records, err := csv.NewReader(gz).ReadAll()
+		require.NoError(t, err)
+		rows = append(rows, records...)
+	}
+	require.Equal(t, [][]string{
+		{"src", "a", a2, "true", "false", "6", "NOT-SSE"},
+		{"src", "a", a1, "false", "false", "5", "NOT-SSE"},
+		{"src", "b", deleted.VersionID, "true", "true", "", ""},
+		{"src", "b", b1, "false", "false", "3", "NOT-SSE"},
+		{"src", "c", c1, "true", "false", "3", "NOT-SSE"},
+	}, rows)
+
+	empty := env.manifest(t, "src/none/")
+	require.Len(t, empty.Files, 1)
+
+	// Due again once the frequency has passed
+	env.inventory.now = func() time.Time { return time.Now().Add(25 * time.Hour) }
+	require.Equal(t, 1, env.inventory.RunOnce(ctx))
+}
+
+func TestInventoryService_GenerateParquet(t *testing.T) {
+	env := newInventoryTestEnv(t)
+	ctx := context.Background()
+
+	_, err := putString(ctx, env.objects, "src", "a", "content", nil)
+	require.NoError(t, err)
+
+	config := &domain.InventoryConfig{
+		ID:                "parquet",
+		Enabled:           true,
+		DestinationBucket: "reports",
+		Format:            domain.InventoryFormatParquet,
+		Frequency:         domain.InventoryFrequencyDaily,
+		Fields:            domain.InventoryFields,
+	}
+	require.NoError(t, env.inventory.PutInventoryConfiguration(ctx, "src", env.ownerID, config))
+
+	manifest, err := env.inventory.Generate(ctx, config)
+	require.NoError(t, err)
+	require.Equal(t, domain.InventoryFormatParquet, manifest.FileFormat)
+	require.Contains(t, manifest.FileSchema, "optional binary e_tag (UTF8)")
+	require.Len(t, manifest.Files, 1)
+	require.True(t, strings.HasSuffix(manifest.Files[0].Key, ".parquet"))
+
+	data := env.get(t, "reports", manifest.Files[0].Key)
+	require.Equal(t, "PAR1", string(data[:4]))
+	require.Equal(t, "PAR1", string(data[len(data)-4:]))
+	require.NotNil(t, config.LastRunAt)
+}
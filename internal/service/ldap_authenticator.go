@@ -0,0 +1,196 @@
+// Package service provides business logic services for Alexander Storage.
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/pkg/ldap"
+)
+
+// ExternalIdentity is a user authenticated by an external directory.
+type ExternalIdentity struct {
+	Username string
+	Email    string
+	Role     domain.UserRole
+}
+
+// ExternalAuthenticator verifies dashboard credentials against an external
+// directory. It returns ErrInvalidCredentials for unknown users or wrong
+// passwords; any other error means the directory could not be consulted.
+type ExternalAuthenticator interface {
+	Authenticate(ctx context.Context, username, password string) (*ExternalIdentity, error)
+}
+
+// LDAPConfig configures authentication against an LDAP or Active Directory server.
+type LDAPConfig struct {
+	// URL is the server address (ldap://host:389 or ldaps://host:636).
+	URL string
+
+	// StartTLS upgrades ldap:// connections to TLS before binding.
+	StartTLS bool
+
+	// InsecureSkipVerify disables server certificate verification (testing only).
+	InsecureSkipVerify bool
+
+	// BindDN and BindPassword are the service account used to look up users.
+	// If BindDN is empty the lookup is done anonymously.
+	BindDN       string
+	BindPassword string
+
+	// BaseDN is the subtree searched for users.
+	BaseDN string
+
+	// UserFilter finds the user entry; %s is replaced by the escaped username.
+	// Default: (uid=%s). Active Directory typically uses (sAMAccountName=%s).
+	UserFilter string
+
+	// EmailAttribute holds the user's email address. Default: mail.
+	EmailAttribute string
+
+	// GroupAttribute lists the DNs of the user's groups. Default: memberOf.
+	GroupAttribute string
+
+	// AdminGroups, OperatorGroups and ReadOnlyGroups are group DNs whose
+	// members get the admin, operator and read-only roles. A user in several
+	// groups gets the highest role; a user in none cannot use the dashboard.
+	AdminGroups    []string
+	OperatorGroups []string
+	ReadOnlyGroups []string
+
+	// Timeout bounds each directory request. Default: 10 seconds.
+	Timeout time.Duration
+}
+
+// LDAPAuthenticator authenticates users with a search-then-bind against an
+// LDAP directory and maps group membership to a user role.
+type LDAPAuthenticator struct {
+	config LDAPConfig
+	logger zerolog.Logger
+}
+
+// NewLDAPAuthenticator creates a new LDAPAuthenticator.
+func NewLDAPAuthenticator(config LDAPConfig, logger zerolog.Logger) *LDAPAuthenticator {
+	if config.UserFilter == "" {
+		config.UserFilter = "(uid=%s)"
+	}
+	if config.EmailAttribute == "" {
+		config.EmailAttribute = "mail"
+	}
+	if config.GroupAttribute == "" {
+		config.GroupAttribute = "memberOf"
+	}
+	if config.Timeout == 0 {
+		config.Timeout = 10 * time.Second
+	}
+
+	return &LDAPAuthenticator{
+		config: config,
+		logger: logger.With().Str("service", "ldap").Logger(),
+	}
+}
+
+// Authenticate looks up the user with the service account, then verifies the
+// password by binding as the user's entry.
+func (a *LDAPAuthenticator) Authenticate(ctx context.Context, username, password string) (*ExternalIdentity, error) {
+	if username == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, a.config.Timeout)
+	defer cancel()
+
+	conn, err := ldap.Dial(ctx, a.config.URL, ldap.Options{
+		TLSConfig: &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			InsecureSkipVerify: a.config.InsecureSkipVerify, //nolint:gosec // opt-in for test directories
+		},
+		StartTLS: a.config.StartTLS,
+		Timeout:  a.config.Timeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if a.config.BindDN != "" {
+		if err := conn.Bind(ctx, a.config.BindDN, a.config.BindPassword); err != nil {
+			return nil, fmt.Errorf("service account bind failed: %w", err)
+		}
+	}
+
+	entries, err := conn.Search(ctx, ldap.SearchRequest{
+		BaseDN:     a.config.BaseDN,
+		Filter:     strings.ReplaceAll(a.config.UserFilter, "%s", ldap.EscapeFilter(username)),
+		Attributes: []string{a.config.EmailAttribute, a.config.GroupAttribute},
+		SizeLimit:  2,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("user search failed: %w", err)
+	}
+	if len(entries) != 1 {
+		a.logger.Debug().Str("username", username).Int("matches", len(entries)).Msg("directory user not found or ambiguous")
+		return nil, ErrInvalidCredentials
+	}
+	entry := entries[0]
+
+	if err := conn.Bind(ctx, entry.DN, password); err != nil {
+		if errors.Is(err, ldap.ErrInvalidCredentials) {
+			a.logger.Debug().Str("username", username).Msg("directory bind rejected")
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("user bind failed: %w", err)
+	}
+
+	identity := &ExternalIdentity{Username: username}
+	if emails := entry.Values(a.config.EmailAttribute); len(emails) > 0 {
+		identity.Email = emails[0]
+	}
+	groups := entry.Values(a.config.GroupAttribute)
+	for _, mapping := range []struct {
+		role   domain.UserRole
+		groups []string
+	}{
+		{domain.RoleAdmin, a.config.AdminGroups},
+		{domain.RoleOperator, a.config.OperatorGroups},
+		{domain.RoleReadOnly, a.config.ReadOnlyGroups},
+	} {
+		if memberOfAny(groups, mapping.groups) {
+			identity.Role = mapping.role
+			break
+		}
+	}
+	return identity, nil
+}
+
+// memberOfAny returns true if any of the user's groups is one of the wanted groups.
+func memberOfAny(groups, wanted []string) bool {
+	for _, group := range groups {
+		for _, w := range wanted {
+			if strings.EqualFold(normalizeDN(group), normalizeDN(w)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// normalizeDN removes insignificant spaces around RDN separators so that
+// "cn=Admins, dc=example" matches "cn=Admins,dc=example".
+func normalizeDN(dn string) string {
+	parts := strings.Split(dn, ",")
+	for i, part := range parts {
+		parts[i] = strings.TrimSpace(part)
+	}
+	return strings.Join(parts, ",")
+}
+
+// Ensure LDAPAuthenticator implements ExternalAuthenticator.
+var _ ExternalAuthenticator = (*LDAPAuthenticator)(nil)
@@ -3,10 +3,12 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 
 	"github.com/prn-tf/alexander-storage/internal/domain"
@@ -21,16 +23,21 @@ type LifecycleService struct {
 	objectRepo    repository.ObjectRepository
 	bucketRepo    repository.BucketRepository
 	blobRepo      repository.BlobRepository
+	eventRepo     repository.EventRepository
+	readOnly      *ReadOnlyService
+	txManager     repository.TxManager
 	locker        lock.Locker
 	metrics       *metrics.Metrics
 	logger        zerolog.Logger
 	config        LifecycleConfig
 
 	// Scheduler control
-	mu       sync.Mutex
-	running  bool
-	stopChan chan struct{}
-	doneChan chan struct{}
+	mu           sync.Mutex
+	running      bool
+	lastResult   *LifecycleResult
+	stopChan     chan struct{}
+	doneChan     chan struct{}
+	reconfigured chan struct{}
 }
 
 // LifecycleConfig contains lifecycle service configuration.
@@ -59,11 +66,14 @@ func DefaultLifecycleConfig() LifecycleConfig {
 }
 
 // NewLifecycleService creates a new lifecycle service.
+// eventRepo may be nil, in which case expirations are not recorded as events.
 func NewLifecycleService(
 	lifecycleRepo repository.LifecycleRepository,
 	objectRepo repository.ObjectRepository,
 	bucketRepo repository.BucketRepository,
 	blobRepo repository.BlobRepository,
+	eventRepo repository.EventRepository,
+	txManager repository.TxManager,
 	locker lock.Locker,
 	m *metrics.Metrics,
 	logger zerolog.Logger,
@@ -74,15 +84,25 @@ func NewLifecycleService(
 		objectRepo:    objectRepo,
 		bucketRepo:    bucketRepo,
 		blobRepo:      blobRepo,
+		eventRepo:     eventRepo,
+		txManager:     txManager,
 		locker:        locker,
 		metrics:       m,
 		logger:        logger.With().Str("service", "lifecycle").Logger(),
 		config:        config,
 		stopChan:      make(chan struct{}),
 		doneChan:      make(chan struct{}),
+		reconfigured:  make(chan struct{}, 1),
 	}
 }
 
+// SetReadOnlyService pauses expiration while the whole service is in
+// read-only mode, not just in read-only buckets. It must be called before
+// the scheduler starts.
+func (s *LifecycleService) SetReadOnlyService(readOnly *ReadOnlyService) {
+	s.readOnly = readOnly
+}
+
 // CreateRuleInput contains data to create a lifecycle rule.
 type CreateRuleInput struct {
 	BucketName     string
@@ -100,7 +120,7 @@ func (s *LifecycleService) CreateRule(ctx context.Context, input CreateRuleInput
 		if err == repository.ErrNotFound {
 			return nil, fmt.Errorf("bucket not found: %s", input.BucketName)
 		}
-		s.logger.Error().Err(err).Str("bucket", input.BucketName).Msg("failed to get bucket")
+		s.logger.Error().Ctx(ctx).Err(err).Str("bucket", input.BucketName).Msg("failed to get bucket")
 		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 
@@ -121,7 +141,7 @@ func (s *LifecycleService) CreateRule(ctx context.Context, input CreateRuleInput
 	// Check for duplicate rule ID in bucket
 	existing, err := s.lifecycleRepo.ListByBucket(ctx, bucket.ID)
 	if err != nil && err != repository.ErrNotFound {
-		s.logger.Error().Err(err).Msg("failed to check existing rules")
+		s.logger.Error().Ctx(ctx).Err(err).Msg("failed to check existing rules")
 		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 	for _, r := range existing {
@@ -131,11 +151,11 @@ func (s *LifecycleService) CreateRule(ctx context.Context, input CreateRuleInput
 	}
 
 	if err := s.lifecycleRepo.Create(ctx, rule); err != nil {
-		s.logger.Error().Err(err).Msg("failed to create lifecycle rule")
+		s.logger.Error().Ctx(ctx).Err(err).Msg("failed to create lifecycle rule")
 		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 
-	s.logger.Info().
+	s.logger.Info().Ctx(ctx).
 		Str("bucket", input.BucketName).
 		Str("rule_id", input.RuleID).
 		Int("expiration_days", input.ExpirationDays).
@@ -189,9 +209,9 @@ func (s *LifecycleService) UpdateRule(ctx context.Context, ruleID int64, expirat
 		return fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 
-	s.logger.Info().
+	s.logger.Info().Ctx(ctx).
 		Int64("rule_id", ruleID).
-		Int("expiration_days", *rule.ExpirationDays).
+		Interface("expiration_days", rule.ExpirationDays).
 		Str("status", string(rule.Status)).
 		Msg("lifecycle rule updated")
 
@@ -207,7 +227,7 @@ func (s *LifecycleService) DeleteRule(ctx context.Context, ruleID int64) error {
 		return fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 
-	s.logger.Info().Int64("rule_id", ruleID).Msg("lifecycle rule deleted")
+	s.logger.Info().Ctx(ctx).Int64("rule_id", ruleID).Msg("lifecycle rule deleted")
 
 	return nil
 }
@@ -238,6 +258,124 @@ func (s *LifecycleService) DeleteRuleByName(ctx context.Context, bucketName, rul
 	return ErrLifecycleRuleNotFound
 }
 
+// =============================================================================
+// Bucket Lifecycle Configuration
+// =============================================================================
+
+// PutBucketLifecycle replaces the lifecycle rules of a bucket with those of
+// config. Rules without an ID are given a generated one.
+func (s *LifecycleService) PutBucketLifecycle(ctx context.Context, bucketName string, ownerID int64, config *domain.LifecycleConfiguration) error {
+	bucket, err := s.bucket(ctx, bucketName, ownerID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	for _, rule := range config.Rules {
+		if rule.RuleID == "" {
+			rule.RuleID = uuid.NewString()
+		}
+		rule.BucketID = bucket.ID
+		rule.CreatedAt = now
+		rule.UpdatedAt = now
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	err = s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		if err := s.lifecycleRepo.DeleteByBucket(ctx, bucket.ID); err != nil {
+			return err
+		}
+		for _, rule := range config.Rules {
+			if err := s.lifecycleRepo.Create(ctx, rule); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	s.logger.Info().Ctx(ctx).
+		Str("bucket", bucket.Name).
+		Int("rules", len(config.Rules)).
+		Msg("bucket lifecycle configured")
+	return nil
+}
+
+// GetBucketLifecycle returns the lifecycle rules of a bucket, or
+// domain.ErrLifecycleConfigNotFound if it has none.
+func (s *LifecycleService) GetBucketLifecycle(ctx context.Context, bucketName string, ownerID int64) (*domain.LifecycleConfiguration, error) {
+	bucket, err := s.bucket(ctx, bucketName, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := s.lifecycleRepo.ListByBucket(ctx, bucket.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	if len(rules) == 0 {
+		return nil, domain.ErrLifecycleConfigNotFound
+	}
+	return &domain.LifecycleConfiguration{Rules: rules}, nil
+}
+
+// DeleteBucketLifecycle removes every lifecycle rule of a bucket.
+func (s *LifecycleService) DeleteBucketLifecycle(ctx context.Context, bucketName string, ownerID int64) error {
+	bucket, err := s.bucket(ctx, bucketName, ownerID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.lifecycleRepo.DeleteByBucket(ctx, bucket.ID); err != nil {
+		return fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	s.logger.Info().Ctx(ctx).Str("bucket", bucket.Name).Msg("bucket lifecycle removed")
+	return nil
+}
+
+// bucket returns a bucket by name, checking that ownerID owns it unless
+// ownerID is 0.
+func (s *LifecycleService) bucket(ctx context.Context, name string, ownerID int64) (*domain.Bucket, error) {
+	bucket, err := s.bucketRepo.GetByName(ctx, name)
+	if err != nil {
+		if errors.Is(err, domain.ErrBucketNotFound) {
+			return nil, domain.ErrBucketNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	if ownerID > 0 && bucket.OwnerID != ownerID {
+		return nil, ErrBucketAccessDenied
+	}
+	return bucket, nil
+}
+
+// Reconfigure changes the settings of a running scheduler. The next run
+// uses the new batch size, and the schedule restarts with the new interval.
+// Enabled is ignored.
+func (s *LifecycleService) Reconfigure(config LifecycleConfig) {
+	s.mu.Lock()
+	config.Enabled = s.config.Enabled
+	s.config = config
+	s.mu.Unlock()
+
+	select {
+	case s.reconfigured <- struct{}{}:
+	default:
+	}
+}
+
+// settings returns the current configuration.
+func (s *LifecycleService) settings() LifecycleConfig {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.config
+}
+
 // Start begins the lifecycle scheduler.
 func (s *LifecycleService) Start() {
 	s.mu.Lock()
@@ -246,12 +384,13 @@ func (s *LifecycleService) Start() {
 		return
 	}
 	s.running = true
+	config := s.config
 	s.mu.Unlock()
 
 	s.logger.Info().
-		Dur("interval", s.config.Interval).
-		Int("batch_size", s.config.BatchSize).
-		Bool("dry_run", s.config.DryRun).
+		Dur("interval", config.Interval).
+		Int("batch_size", config.BatchSize).
+		Bool("dry_run", config.DryRun).
 		Msg("Starting lifecycle scheduler")
 
 	go s.runLoop()
@@ -280,13 +419,15 @@ func (s *LifecycleService) runLoop() {
 	// Run immediately on start
 	s.runOnce()
 
-	ticker := time.NewTicker(s.config.Interval)
+	ticker := time.NewTicker(s.settings().Interval)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ticker.C:
 			s.runOnce()
+		case <-s.reconfigured:
+			ticker.Reset(s.settings().Interval)
 		case <-s.stopChan:
 			return
 		}
@@ -295,6 +436,7 @@ func (s *LifecycleService) runLoop() {
 
 // LifecycleResult contains the result of a lifecycle evaluation run.
 type LifecycleResult struct {
+	StartedAt        time.Time
 	ObjectsExpired   int
 	BytesFreed       int64
 	RulesEvaluated   int
@@ -308,6 +450,19 @@ func (s *LifecycleService) RunOnce(ctx context.Context) LifecycleResult {
 	return s.runWithContext(ctx)
 }
 
+// LastResult returns the result of the last run this service completed
+// itself, or nil if there is none. Runs skipped because another process
+// holds the lifecycle lock leave it unchanged.
+func (s *LifecycleService) LastResult() *LifecycleResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.lastResult == nil {
+		return nil
+	}
+	result := *s.lastResult
+	return &result
+}
+
 // runOnce is called by the scheduler loop.
 func (s *LifecycleService) runOnce() {
 	ctx := context.Background()
@@ -317,46 +472,51 @@ func (s *LifecycleService) runOnce() {
 // runWithContext executes lifecycle evaluation with the given context.
 func (s *LifecycleService) runWithContext(ctx context.Context) LifecycleResult {
 	start := time.Now()
-	result := LifecycleResult{}
+	result := LifecycleResult{StartedAt: start}
 
-	s.logger.Debug().Msg("Starting lifecycle evaluation run")
+	s.logger.Debug().Ctx(ctx).Msg("Starting lifecycle evaluation run")
 
 	// Acquire distributed lock
 	lockKey := "lifecycle:evaluation"
-	lockTTL := s.config.Interval / 2
+	lockTTL := s.settings().Interval / 2
 	if lockTTL < 5*time.Minute {
 		lockTTL = 5 * time.Minute
 	}
 
 	acquired, err := s.locker.Acquire(ctx, lockKey, lockTTL)
 	if err != nil {
-		s.logger.Error().Err(err).Msg("Failed to acquire lifecycle lock")
+		s.logger.Error().Ctx(ctx).Err(err).Msg("Failed to acquire lifecycle lock")
 		result.Errors++
 		result.Duration = time.Since(start)
 		return result
 	}
 	if !acquired {
-		s.logger.Debug().Msg("Lifecycle lock held by another process, skipping run")
+		s.logger.Debug().Ctx(ctx).Msg("Lifecycle lock held by another process, skipping run")
 		result.Duration = time.Since(start)
 		return result
 	}
 	defer func() {
 		if _, err := s.locker.Release(ctx, lockKey); err != nil {
-			s.logger.Error().Err(err).Msg("Failed to release lifecycle lock")
+			s.logger.Error().Ctx(ctx).Err(err).Msg("Failed to release lifecycle lock")
 		}
 	}()
+	defer func() {
+		s.mu.Lock()
+		s.lastResult = &result
+		s.mu.Unlock()
+	}()
 
 	// Get all enabled rules
 	rules, err := s.lifecycleRepo.ListAllEnabled(ctx)
 	if err != nil {
-		s.logger.Error().Err(err).Msg("Failed to list enabled rules")
+		s.logger.Error().Ctx(ctx).Err(err).Msg("Failed to list enabled rules")
 		result.Errors++
 		result.Duration = time.Since(start)
 		return result
 	}
 
 	if len(rules) == 0 {
-		s.logger.Debug().Msg("No enabled lifecycle rules found")
+		s.logger.Debug().Ctx(ctx).Msg("No enabled lifecycle rules found")
 		result.Duration = time.Since(start)
 		return result
 	}
@@ -379,9 +539,12 @@ func (s *LifecycleService) runWithContext(ctx context.Context) LifecycleResult {
 	}
 
 	result.Duration = time.Since(start)
+	if s.metrics != nil {
+		s.metrics.RecordLifecycleRun(result.Duration.Seconds(), result.ObjectsExpired, result.Errors, result.BytesFreed)
+	}
 
 	if result.ObjectsExpired > 0 || result.Errors > 0 {
-		s.logger.Info().
+		s.logger.Info().Ctx(ctx).
 			Int("objects_expired", result.ObjectsExpired).
 			Int64("bytes_freed", result.BytesFreed).
 			Int("rules_evaluated", result.RulesEvaluated).
@@ -390,7 +553,7 @@ func (s *LifecycleService) runWithContext(ctx context.Context) LifecycleResult {
 			Dur("duration", result.Duration).
 			Msg("Lifecycle evaluation completed")
 	} else {
-		s.logger.Debug().
+		s.logger.Debug().Ctx(ctx).
 			Int("rules_evaluated", result.RulesEvaluated).
 			Dur("duration", result.Duration).
 			Msg("Lifecycle evaluation completed, no objects expired")
@@ -404,10 +567,16 @@ func (s *LifecycleService) processBucketRules(ctx context.Context, bucketID int6
 	// Get bucket info for logging
 	bucket, err := s.bucketRepo.GetByID(ctx, bucketID)
 	if err != nil {
-		s.logger.Error().Err(err).Int64("bucket_id", bucketID).Msg("Failed to get bucket")
+		s.logger.Error().Ctx(ctx).Err(err).Int64("bucket_id", bucketID).Msg("Failed to get bucket")
 		return 0, 0, 1
 	}
 
+	// Expiration is a write, so it waits until the bucket is writable again
+	if err := checkWritable(ctx, s.readOnly, bucket); err != nil {
+		s.logger.Debug().Ctx(ctx).Err(err).Str("bucket", bucket.Name).Msg("Skipping lifecycle rules of read-only bucket")
+		return 0, 0, 0
+	}
+
 	for _, rule := range rules {
 		e, b, errs := s.evaluateRule(ctx, bucket, rule)
 		expired += e
@@ -418,50 +587,89 @@ func (s *LifecycleService) processBucketRules(ctx context.Context, bucketID int6
 	return expired, bytesFreed, errors
 }
 
-// evaluateRule evaluates a single lifecycle rule against objects in a bucket.
+// evaluateRule applies each action of a lifecycle rule to the objects of a
+// bucket that its filter selects. Aborting incomplete multipart uploads is
+// left to the multipart reaper, as uploads take the rule's abort date as
+// their expiry when they are initiated.
 func (s *LifecycleService) evaluateRule(ctx context.Context, bucket *domain.Bucket, rule *domain.LifecycleRule) (expired int, bytesFreed int64, errors int) {
-	// Skip rules without expiration
-	if !rule.HasExpiration() {
+	// Objects carry no tags, so a rule filtering on tags applies to none
+	if len(rule.Tags) > 0 {
 		return 0, 0, 0
 	}
 
-	// Calculate expiration cutoff time
-	cutoff := time.Now().UTC().AddDate(0, 0, -*rule.ExpirationDays)
+	now := time.Now().UTC()
+	batchSize := s.settings().BatchSize
 
-	s.logger.Debug().
+	s.logger.Debug().Ctx(ctx).
 		Str("bucket", bucket.Name).
 		Str("rule_id", rule.RuleID).
 		Str("prefix", rule.Prefix).
-		Int("expiration_days", *rule.ExpirationDays).
-		Time("cutoff", cutoff).
 		Msg("Evaluating lifecycle rule")
 
-	// List objects matching prefix that are older than cutoff
-	// For versioned buckets, we only expire the latest version
-	objects, err := s.objectRepo.ListExpiredObjects(ctx, bucket.ID, rule.Prefix, cutoff, s.config.BatchSize)
-	if err != nil {
-		s.logger.Error().Err(err).Str("bucket", bucket.Name).Str("rule_id", rule.RuleID).Msg("Failed to list expired objects")
-		return 0, 0, 1
+	if rule.HasExpiration() {
+		// A date expires everything created before it once it has passed
+		cutoff := now
+		if rule.ExpirationDays != nil {
+			cutoff = now.AddDate(0, 0, -*rule.ExpirationDays)
+		}
+		if rule.ExpirationDate == nil || !now.Before(*rule.ExpirationDate) {
+			objects, err := s.objectRepo.ListExpiredObjects(ctx, bucket.ID, rule.LifecycleFilter, cutoff, batchSize)
+			if err != nil {
+				s.logger.Error().Ctx(ctx).Err(err).Str("bucket", bucket.Name).Str("rule_id", rule.RuleID).Msg("Failed to list expired objects")
+				errors++
+			}
+			e, b, errs := s.apply(ctx, bucket, objects, "expire object", s.expireObject)
+			expired, bytesFreed, errors = expired+e, bytesFreed+b, errors+errs
+		}
+	}
+
+	if rule.NoncurrentDays != nil {
+		cutoff := now.AddDate(0, 0, -*rule.NoncurrentDays)
+		versions, err := s.objectRepo.ListNoncurrentVersions(ctx, bucket.ID, rule.LifecycleFilter, cutoff, batchSize)
+		if err != nil {
+			s.logger.Error().Ctx(ctx).Err(err).Str("bucket", bucket.Name).Str("rule_id", rule.RuleID).Msg("Failed to list noncurrent versions")
+			errors++
+		}
+		e, b, errs := s.apply(ctx, bucket, versions, "remove noncurrent version", s.removeVersion)
+		expired, bytesFreed, errors = expired+e, bytesFreed+b, errors+errs
+	}
+
+	// Markers left alone by removing noncurrent versions go in the same run
+	if rule.ExpiredObjectDeleteMarker {
+		markers, err := s.objectRepo.ListExpiredDeleteMarkers(ctx, bucket.ID, rule.Prefix, batchSize)
+		if err != nil {
+			s.logger.Error().Ctx(ctx).Err(err).Str("bucket", bucket.Name).Str("rule_id", rule.RuleID).Msg("Failed to list expired delete markers")
+			errors++
+		}
+		e, b, errs := s.apply(ctx, bucket, markers, "remove delete marker", s.removeVersion)
+		expired, bytesFreed, errors = expired+e, bytesFreed+b, errors+errs
 	}
 
+	return expired, bytesFreed, errors
+}
+
+// apply runs an action on each object, or only logs it in a dry run.
+func (s *LifecycleService) apply(ctx context.Context, bucket *domain.Bucket, objects []*domain.Object, action string, fn func(context.Context, *domain.Bucket, *domain.Object) error) (expired int, bytesFreed int64, errors int) {
+	dryRun := s.settings().DryRun
 	for _, obj := range objects {
-		if s.config.DryRun {
-			s.logger.Info().
+		if dryRun {
+			s.logger.Info().Ctx(ctx).
 				Str("bucket", bucket.Name).
 				Str("key", obj.Key).
+				Str("version_id", obj.GetVersionIDString()).
 				Time("created_at", obj.CreatedAt).
-				Msg("[DRY RUN] Would expire object")
+				Msg("[DRY RUN] Would " + action)
 			expired++
 			bytesFreed += obj.Size
 			continue
 		}
 
-		// Delete the object
-		if err := s.expireObject(ctx, bucket, obj); err != nil {
-			s.logger.Error().Err(err).
+		if err := fn(ctx, bucket, obj); err != nil {
+			s.logger.Error().Ctx(ctx).Err(err).
 				Str("bucket", bucket.Name).
 				Str("key", obj.Key).
-				Msg("Failed to expire object")
+				Str("version_id", obj.GetVersionIDString()).
+				Msg("Failed to " + action)
 			errors++
 			continue
 		}
@@ -469,45 +677,57 @@ func (s *LifecycleService) evaluateRule(ctx context.Context, bucket *domain.Buck
 		expired++
 		bytesFreed += obj.Size
 
-		s.logger.Debug().
+		s.logger.Debug().Ctx(ctx).
 			Str("bucket", bucket.Name).
 			Str("key", obj.Key).
+			Str("version_id", obj.GetVersionIDString()).
 			Int64("size", obj.Size).
-			Msg("Object expired")
+			Msg("Lifecycle action applied: " + action)
 	}
 
 	return expired, bytesFreed, errors
 }
 
-// expireObject deletes an object due to lifecycle expiration.
+// expireObject deletes the current version of an object due to lifecycle
+// expiration. Versioned buckets keep it as a noncurrent version below a new
+// delete marker, unless versioning is suspended and it is the null version.
 func (s *LifecycleService) expireObject(ctx context.Context, bucket *domain.Bucket, obj *domain.Object) error {
-	// For versioned buckets, insert a delete marker
-	// For non-versioned buckets, delete the object directly
+	if !bucket.IsVersioningEverEnabled() {
+		return s.removeVersion(ctx, bucket, obj)
+	}
 
-	if bucket.Versioning == domain.VersioningEnabled {
-		// Create delete marker
+	return s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		// Expiration is a write like any other and advances the key's sequence
 		deleteMarker := domain.NewDeleteMarker(bucket.ID, obj.Key)
+		if err := assignSequence(ctx, s.objectRepo, deleteMarker, nil); err != nil {
+			return err
+		}
+		if err := supersedeLatest(ctx, s.objectRepo, s.blobRepo, s.logger, bucket, deleteMarker); err != nil {
+			return err
+		}
 		if err := s.objectRepo.Create(ctx, deleteMarker); err != nil {
 			return fmt.Errorf("failed to create delete marker: %w", err)
 		}
+		return recordEvent(ctx, s.eventRepo, domain.ObjectEventLifecycleExpirationDeleteMarker, bucket, deleteMarker)
+	})
+}
 
-		// Mark previous version as not latest
-		if err := s.objectRepo.MarkNotLatest(ctx, bucket.ID, obj.Key); err != nil {
-			return fmt.Errorf("failed to mark not latest: %w", err)
+// removeVersion permanently deletes a version of an object and releases
+// its blob.
+func (s *LifecycleService) removeVersion(ctx context.Context, bucket *domain.Bucket, obj *domain.Object) error {
+	return s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		removal := &domain.Object{BucketID: bucket.ID, Key: obj.Key}
+		if err := assignSequence(ctx, s.objectRepo, removal, nil); err != nil {
+			return err
 		}
-	} else {
-		// Decrement blob reference count
 		if obj.ContentHash != nil {
-			if _, err := s.blobRepo.DecrementRef(ctx, *obj.ContentHash); err != nil {
-				s.logger.Warn().Err(err).Str("content_hash", *obj.ContentHash).Msg("Failed to decrement blob ref")
+			if err := releaseBlobRef(ctx, s.blobRepo, s.logger, *obj.ContentHash); err != nil {
+				return err
 			}
 		}
-
-		// Delete object
 		if err := s.objectRepo.Delete(ctx, obj.ID); err != nil {
 			return fmt.Errorf("failed to delete object: %w", err)
 		}
-	}
-
-	return nil
+		return recordEvent(ctx, s.eventRepo, domain.ObjectEventLifecycleExpirationDelete, bucket, obj)
+	})
 }
@@ -0,0 +1,296 @@
+package service
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/lock"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+	"github.com/prn-tf/alexander-storage/internal/repository/sqlite"
+	"github.com/prn-tf/alexander-storage/internal/storage/filesystem"
+)
+
+// lifecycleTestEnv is a lifecycle service backed by SQLite and the
+// filesystem, with an unversioned bucket "logs" and a versioned bucket
+// "archive" of the owner.
+type lifecycleTestEnv struct {
+	db        *sqlite.DB
+	objects   *ObjectService
+	lifecycle *LifecycleService
+	ownerID   int64
+}
+
+func newLifecycleTestEnv(t *testing.T) *lifecycleTestEnv {
+	t.Helper()
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	db, err := sqlite.NewDB(ctx, sqlite.DefaultConfig(filepath.Join(dir, "alexander.db")), zerolog.Nop())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, db.Migrate(ctx))
+
+	store, err := filesystem.NewStorage(filesystem.Config{
+		DataDir: filepath.Join(dir, "blobs"),
+		TempDir: filepath.Join(dir, "tmp"),
+	}, zerolog.Nop())
+	require.NoError(t, err)
+
+	owner := domain.NewUser("owner", "owner@example.com", "hash")
+	require.NoError(t, sqlite.NewUserRepository(db).Create(ctx, owner))
+
+	bucketRepo := sqlite.NewBucketRepository(db)
+	require.NoError(t, bucketRepo.Create(ctx, domain.NewBucket(owner.ID, "logs")))
+	archive := domain.NewBucket(owner.ID, "archive")
+	archive.Versioning = domain.VersioningEnabled
+	require.NoError(t, bucketRepo.Create(ctx, archive))
+
+	objects := NewObjectService(
+		sqlite.NewObjectRepository(db),
+		sqlite.NewBlobRepository(db),
+		bucketRepo,
+		nil,
+		nil,
+		sqlite.NewLifecycleRepository(db),
+		nil,
+		nil,
+		nil,
+		sqlite.NewTxManager(db),
+		store,
+		lock.NewMemoryLocker(),
+		nil,
+		zerolog.Nop(),
+	)
+	lifecycle := NewLifecycleService(
+		sqlite.NewLifecycleRepository(db),
+		sqlite.NewObjectRepository(db),
+		bucketRepo,
+		sqlite.NewBlobRepository(db),
+		sqlite.NewEventRepository(db),
+		sqlite.NewTxManager(db),
+		lock.NewMemoryLocker(),
+		nil,
+		zerolog.Nop(),
+		DefaultLifecycleConfig(),
+	)
+	return &lifecycleTestEnv{db: db, objects: objects, lifecycle: lifecycle, ownerID: owner.ID}
+}
+
+func (e *lifecycleTestEnv) put(t *testing.T, bucket, key, body string) {
+	t.Helper()
+	_, err := e.objects.PutObject(context.Background(), PutObjectInput{
+		BucketName: bucket,
+		Key:        key,
+		Body:       strings.NewReader(body),
+		Size:       int64(len(body)),
+		OwnerID:    e.ownerID,
+	})
+	require.NoError(t, err)
+}
+
+// versions returns the number of live versions, delete markers included, of
+// a key.
+func (e *lifecycleTestEnv) versions(t *testing.T, bucket, key string) int {
+	t.Helper()
+	var n int
+	err := e.db.QueryRowContext(context.Background(), `
+		SELECT COUNT(*) FROM objects o JOIN buckets b ON b.id = o.bucket_id
+		WHERE b.name = ? AND o.key = ? AND o.deleted_at IS NULL
+	`, bucket, key).Scan(&n)
+	require.NoError(t, err)
+	return n
+}
+
+// events returns the types and keys of the events recorded for a bucket.
+// Only the lifecycle service records events in this environment.
+func (e *lifecycleTestEnv) events(t *testing.T, bucket string) []string {
+	t.Helper()
+	events, err := sqlite.NewEventRepository(e.db).List(context.Background(), repository.EventFilter{BucketName: bucket})
+	require.NoError(t, err)
+	var got []string
+	for _, event := range events {
+		got = append(got, string(event.Type)+" "+event.Key)
+	}
+	return got
+}
+
+func TestLifecycleService_Configuration(t *testing.T) {
+	env := newLifecycleTestEnv(t)
+	ctx := context.Background()
+	days := 7
+
+	require.ErrorIs(t, env.lifecycle.PutBucketLifecycle(ctx, "logs", env.ownerID, &domain.LifecycleConfiguration{
+		Rules: []*domain.LifecycleRule{{RuleID: "no-action", Status: domain.LifecycleEnabled}},
+	}), domain.ErrInvalidLifecycleRule)
+	require.ErrorIs(t, env.lifecycle.PutBucketLifecycle(ctx, "logs", env.ownerID+1, &domain.LifecycleConfiguration{
+		Rules: []*domain.LifecycleRule{{RuleID: "expire", Status: domain.LifecycleEnabled, ExpirationDays: &days}},
+	}), ErrBucketAccessDenied)
+
+	_, err := env.lifecycle.GetBucketLifecycle(ctx, "logs", env.ownerID)
+	require.ErrorIs(t, err, domain.ErrLifecycleConfigNotFound)
+
+	// Rules without an ID are given one
+	require.NoError(t, env.lifecycle.PutBucketLifecycle(ctx, "logs", env.ownerID, &domain.LifecycleConfiguration{
+		Rules: []*domain.LifecycleRule{
+			{RuleID: "expire", Status: domain.LifecycleEnabled, ExpirationDays: &days},
+			{Status: domain.LifecycleEnabled, AbortIncompleteUploadDays: &days},
+		},
+	}))
+	config, err := env.lifecycle.GetBucketLifecycle(ctx, "logs", env.ownerID)
+	require.NoError(t, err)
+	require.Len(t, config.Rules, 2)
+	for _, rule := range config.Rules {
+		require.NotEmpty(t, rule.RuleID)
+	}
+
+	// A configuration replaces every rule
+	large := int64(1024)
+	require.NoError(t, env.lifecycle.PutBucketLifecycle(ctx, "logs", env.ownerID, &domain.LifecycleConfiguration{
+		Rules: []*domain.LifecycleRule{{
+			RuleID:          "large",
+			Status:          domain.LifecycleEnabled,
+			LifecycleFilter: domain.LifecycleFilter{Prefix: "tmp/", ObjectSizeGreaterThan: &large},
+			ExpirationDays:  &days,
+		}},
+	}))
+	config, err = env.lifecycle.GetBucketLifecycle(ctx, "logs", env.ownerID)
+	require.NoError(t, err)
+	require.Len(t, config.Rules, 1)
+	require.Equal(t, "tmp/", config.Rules[0].Prefix)
+	require.Equal(t, large, *config.Rules[0].ObjectSizeGreaterThan)
+
+	require.NoError(t, env.lifecycle.DeleteBucketLifecycle(ctx, "logs", env.ownerID))
+	_, err = env.lifecycle.GetBucketLifecycle(ctx, "logs", env.ownerID)
+	require.ErrorIs(t, err, domain.ErrLifecycleConfigNotFound)
+}
+
+func TestLifecycleService_RunOnce(t *testing.T) {
+	env := newLifecycleTestEnv(t)
+	ctx := context.Background()
+	day := 1
+	five := int64(5)
+
+	env.put(t, "logs", "logs/big", "0123456789")
+	env.put(t, "logs", "logs/small", "abc")
+	env.put(t, "logs", "other/big", "9876543210")
+
+	env.put(t, "archive", "doc", "first draft")
+	env.put(t, "archive", "doc", "second draft")
+	env.put(t, "archive", "gone", "removed later")
+	_, err := env.objects.DeleteObject(ctx, DeleteObjectInput{BucketName: "archive", Key: "gone", OwnerID: env.ownerID})
+	require.NoError(t, err)
+
+	require.NoError(t, env.lifecycle.PutBucketLifecycle(ctx, "logs", env.ownerID, &domain.LifecycleConfiguration{
+		Rules: []*domain.LifecycleRule{
+			{
+				RuleID:          "large-logs",
+				Status:          domain.LifecycleEnabled,
+				LifecycleFilter: domain.LifecycleFilter{Prefix: "logs/", ObjectSizeGreaterThan: &five},
+				ExpirationDays:  &day,
+			},
+			{
+				// Objects carry no tags, so this rule applies to none
+				RuleID:          "tagged",
+				Status:          domain.LifecycleEnabled,
+				LifecycleFilter: domain.LifecycleFilter{Tags: []domain.Tag{{Key: "class", Value: "temp"}}},
+				ExpirationDays:  &day,
+			},
+		},
+	}))
+	require.NoError(t, env.lifecycle.PutBucketLifecycle(ctx, "archive", env.ownerID, &domain.LifecycleConfiguration{
+		Rules: []*domain.LifecycleRule{{
+			RuleID:                    "old-versions",
+			Status:                    domain.LifecycleEnabled,
+			NoncurrentDays:            &day,
+			ExpiredObjectDeleteMarker: true,
+		}},
+	}))
+
+	// Nothing is old enough yet
+	result := env.lifecycle.RunOnce(ctx)
+	require.Zero(t, result.ObjectsExpired)
+
+	aged := time.Now().UTC().AddDate(0, 0, -3).Format(time.RFC3339)
+	_, err = env.db.ExecContext(ctx, `UPDATE objects SET created_at = ?`, aged)
+	require.NoError(t, err)
+
+	// The large log expires, the first draft and the version below the
+	// marker are removed, and then the marker left without versions
+	result = env.lifecycle.RunOnce(ctx)
+	require.Zero(t, result.Errors)
+	require.Equal(t, 4, result.ObjectsExpired)
+
+	require.Zero(t, env.versions(t, "logs", "logs/big"))
+	require.Equal(t, 1, env.versions(t, "logs", "logs/small"))
+	require.Equal(t, 1, env.versions(t, "logs", "other/big"))
+	require.Equal(t, 1, env.versions(t, "archive", "doc"))
+	require.Zero(t, env.versions(t, "archive", "gone"))
+
+	require.Equal(t, []string{"s3:LifecycleExpiration:Delete logs/big"}, env.events(t, "logs"))
+	require.ElementsMatch(t, []string{
+		"s3:LifecycleExpiration:Delete doc",
+		"s3:LifecycleExpiration:Delete gone",
+		"s3:LifecycleExpiration:Delete gone",
+	}, env.events(t, "archive"))
+
+	result = env.lifecycle.RunOnce(ctx)
+	require.Zero(t, result.ObjectsExpired)
+}
+
+func TestLifecycleService_Reconfigure(t *testing.T) {
+	env := newLifecycleTestEnv(t)
+	ctx := context.Background()
+	day := 1
+
+	for _, key := range []string{"a", "b", "c"} {
+		env.put(t, "logs", key, "old log")
+	}
+	aged := time.Now().UTC().AddDate(0, 0, -3).Format(time.RFC3339)
+	_, err := env.db.ExecContext(ctx, `UPDATE objects SET created_at = ?`, aged)
+	require.NoError(t, err)
+	require.NoError(t, env.lifecycle.PutBucketLifecycle(ctx, "logs", env.ownerID, &domain.LifecycleConfiguration{
+		Rules: []*domain.LifecycleRule{{RuleID: "expire", Status: domain.LifecycleEnabled, ExpirationDays: &day}},
+	}))
+
+	config := DefaultLifecycleConfig()
+	config.BatchSize = 1
+	env.lifecycle.Reconfigure(config)
+	require.Equal(t, 1, env.lifecycle.RunOnce(ctx).ObjectsExpired)
+
+	// A larger batch applies to the next run; Enabled cannot change
+	config.BatchSize = 10
+	config.Enabled = false
+	env.lifecycle.Reconfigure(config)
+	require.True(t, env.lifecycle.settings().Enabled)
+	require.Equal(t, 2, env.lifecycle.RunOnce(ctx).ObjectsExpired)
+}
+
+func TestLifecycleService_ExpireVersionedObject(t *testing.T) {
+	env := newLifecycleTestEnv(t)
+	ctx := context.Background()
+	day := 1
+
+	env.put(t, "archive", "report", "quarterly numbers")
+	require.NoError(t, env.lifecycle.PutBucketLifecycle(ctx, "archive", env.ownerID, &domain.LifecycleConfiguration{
+		Rules: []*domain.LifecycleRule{{RuleID: "expire", Status: domain.LifecycleEnabled, ExpirationDays: &day}},
+	}))
+	_, err := env.db.ExecContext(ctx, `UPDATE objects SET created_at = ?`, time.Now().UTC().AddDate(0, 0, -3).Format(time.RFC3339))
+	require.NoError(t, err)
+
+	result := env.lifecycle.RunOnce(ctx)
+	require.Equal(t, 1, result.ObjectsExpired)
+
+	// The version is kept below a delete marker, which is the latest
+	require.Equal(t, 2, env.versions(t, "archive", "report"))
+	latest, err := env.lifecycle.objectRepo.GetByKey(ctx, 2, "report")
+	require.NoError(t, err)
+	require.True(t, latest.IsDeleteMarker)
+	require.Equal(t, []string{"s3:LifecycleExpiration:DeleteMarkerCreated report"}, env.events(t, "archive"))
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// ManifestService lists stored blobs for external dedup, audit and scanning tools.
+//
+// Listings are snapshot-consistent: the first page fixes a snapshot time and
+// every page only returns blobs created before it, ordered by content hash.
+// A scan that follows the cursors to the end therefore sees every blob that
+// existed at the snapshot exactly once, unless it was deleted during the scan.
+type ManifestService struct {
+	blobRepo    repository.BlobRepository
+	maxPageSize int
+	logger      zerolog.Logger
+}
+
+// NewManifestService creates a new ManifestService.
+func NewManifestService(blobRepo repository.BlobRepository, maxPageSize int, logger zerolog.Logger) *ManifestService {
+	if maxPageSize <= 0 {
+		maxPageSize = 1000
+	}
+	return &ManifestService{
+		blobRepo:    blobRepo,
+		maxPageSize: maxPageSize,
+		logger:      logger.With().Str("service", "manifest").Logger(),
+	}
+}
+
+// ListManifestInput contains the data needed to list a manifest page.
+type ListManifestInput struct {
+	Cursor string // Empty for the first page
+	Limit  int    // Optional - defaults to the maximum page size
+}
+
+// ListManifestOutput contains a page of the blob manifest.
+type ListManifestOutput struct {
+	Snapshot    time.Time
+	Entries     []ManifestEntry
+	NextCursor  string
+	IsTruncated bool
+}
+
+// ManifestEntry describes a single blob in the manifest.
+type ManifestEntry struct {
+	ContentHash string
+	Size        int64
+	StoragePath string
+	RefCount    int32
+	IsEncrypted bool
+	CreatedAt   time.Time
+}
+
+// manifestCursor is the decoded form of a manifest cursor.
+type manifestCursor struct {
+	snapshot  time.Time
+	afterHash string
+}
+
+// ListManifest returns one page of the blob manifest.
+func (s *ManifestService) ListManifest(ctx context.Context, input ListManifestInput) (*ListManifestOutput, error) {
+	limit := input.Limit
+	if limit <= 0 || limit > s.maxPageSize {
+		limit = s.maxPageSize
+	}
+
+	cursor := manifestCursor{
+		// Whole seconds so the bound matches timestamps stored with second precision.
+		snapshot: time.Now().UTC().Truncate(time.Second),
+	}
+	if input.Cursor != "" {
+		var err error
+		cursor, err = decodeManifestCursor(input.Cursor)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Fetch one extra row to detect truncation
+	blobs, err := s.blobRepo.ListCreatedBefore(ctx, cursor.snapshot, cursor.afterHash, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	output := &ListManifestOutput{Snapshot: cursor.snapshot}
+	if len(blobs) > limit {
+		blobs = blobs[:limit]
+		output.IsTruncated = true
+	}
+
+	output.Entries = make([]ManifestEntry, len(blobs))
+	for i, blob := range blobs {
+		output.Entries[i] = ManifestEntry{
+			ContentHash: blob.ContentHash,
+			Size:        blob.Size,
+			StoragePath: blob.StoragePath,
+			RefCount:    blob.RefCount,
+			IsEncrypted: blob.IsEncrypted,
+			CreatedAt:   blob.CreatedAt,
+		}
+	}
+
+	if output.IsTruncated {
+		output.NextCursor = encodeManifestCursor(manifestCursor{
+			snapshot:  cursor.snapshot,
+			afterHash: blobs[len(blobs)-1].ContentHash,
+		})
+	}
+
+	return output, nil
+}
+
+// encodeManifestCursor encodes a cursor as "<snapshot unix seconds>:<last hash>".
+func encodeManifestCursor(c manifestCursor) string {
+	raw := strconv.FormatInt(c.snapshot.Unix(), 10) + ":" + c.afterHash
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeManifestCursor parses a cursor produced by encodeManifestCursor.
+func decodeManifestCursor(token string) (manifestCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return manifestCursor{}, ErrInvalidManifestCursor
+	}
+
+	secs, afterHash, ok := strings.Cut(string(raw), ":")
+	if !ok || afterHash == "" {
+		return manifestCursor{}, ErrInvalidManifestCursor
+	}
+
+	unix, err := strconv.ParseInt(secs, 10, 64)
+	if err != nil || unix <= 0 {
+		return manifestCursor{}, ErrInvalidManifestCursor
+	}
+
+	return manifestCursor{snapshot: time.Unix(unix, 0).UTC(), afterHash: afterHash}, nil
+}
@@ -0,0 +1,51 @@
+// Package service provides business logic services for Alexander Storage.
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+)
+
+func TestManifestService_ListManifest_Pagination(t *testing.T) {
+	blobRepo := new(mockBlobRepository2)
+	svc := NewManifestService(blobRepo, 2, zerolog.Nop())
+	ctx := context.Background()
+
+	page1 := []*domain.Blob{{ContentHash: "aa", Size: 1}, {ContentHash: "bb", Size: 2}, {ContentHash: "cc", Size: 3}}
+	blobRepo.On("ListCreatedBefore", mock.Anything, mock.AnythingOfType("time.Time"), "", 3).Return(page1, nil).Once()
+
+	first, err := svc.ListManifest(ctx, ListManifestInput{})
+	require.NoError(t, err)
+	require.True(t, first.IsTruncated)
+	require.Len(t, first.Entries, 2)
+	require.Equal(t, "bb", first.Entries[1].ContentHash)
+	require.NotEmpty(t, first.NextCursor)
+
+	// The second page must reuse the snapshot of the first and continue after "bb"
+	blobRepo.On("ListCreatedBefore", mock.Anything, first.Snapshot, "bb", 3).Return(page1[2:], nil).Once()
+
+	second, err := svc.ListManifest(ctx, ListManifestInput{Cursor: first.NextCursor})
+	require.NoError(t, err)
+	require.False(t, second.IsTruncated)
+	require.Empty(t, second.NextCursor)
+	require.Len(t, second.Entries, 1)
+	require.True(t, second.Snapshot.Equal(first.Snapshot))
+
+	blobRepo.AssertExpectations(t)
+}
+
+func TestManifestService_ListManifest_InvalidCursor(t *testing.T) {
+	svc := NewManifestService(new(mockBlobRepository2), 10, zerolog.Nop())
+
+	for _, cursor := range []string{"not base64!", "bm9jb2xvbg", encodeManifestCursor(manifestCursor{snapshot: time.Unix(1, 0)})} {
+		_, err := svc.ListManifest(context.Background(), ListManifestInput{Cursor: cursor})
+		require.ErrorIs(t, err, ErrInvalidManifestCursor, "cursor %q", cursor)
+	}
+}
@@ -0,0 +1,338 @@
+// Package service provides business logic services for Alexander Storage.
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// MeteringService aggregates usage per bucket, access key and hour for billing.
+// Request counters are buffered in memory and flushed periodically; bytes
+// stored are snapshotted once per hour.
+type MeteringService struct {
+	usageRepo repository.UsageRepository
+	txManager repository.TxManager
+	logger    zerolog.Logger
+	config    MeteringConfig
+
+	// Buffered counters, keyed by hour, bucket and access key
+	pendingMu sync.Mutex
+	pending   map[usageKey]*domain.UsageRecord
+
+	// flushMu serializes flushes; lastStorageHour is the last hour whose
+	// storage snapshot was recorded.
+	flushMu         sync.Mutex
+	lastStorageHour time.Time
+
+	// Control
+	mu       sync.Mutex
+	running  bool
+	stopChan chan struct{}
+	doneChan chan struct{}
+
+	// now is replaceable in tests.
+	now func() time.Time
+}
+
+// MeteringConfig contains metering configuration.
+type MeteringConfig struct {
+	// FlushInterval is how often buffered counters are written to the database.
+	FlushInterval time.Duration
+}
+
+// usageKey identifies an hourly usage row.
+type usageKey struct {
+	hour        time.Time
+	bucketName  string
+	accessKeyID string
+}
+
+// NewMeteringService creates a new metering service.
+func NewMeteringService(
+	usageRepo repository.UsageRepository,
+	txManager repository.TxManager,
+	logger zerolog.Logger,
+	config MeteringConfig,
+) *MeteringService {
+	return &MeteringService{
+		usageRepo: usageRepo,
+		txManager: txManager,
+		logger:    logger.With().Str("service", "metering").Logger(),
+		config:    config,
+		pending:   make(map[usageKey]*domain.UsageRecord),
+		stopChan:  make(chan struct{}),
+		doneChan:  make(chan struct{}),
+		now:       time.Now,
+	}
+}
+
+// Record adds one request and its transferred bytes to the current hour.
+// An empty accessKeyID records an anonymous request. It implements
+// middleware.UsageRecorder and never blocks on the database.
+func (s *MeteringService) Record(accessKeyID, bucketName string, bytesIn, bytesOut int64) {
+	if accessKeyID == "" {
+		accessKeyID = domain.AnonymousAccessKeyID
+	}
+	key := usageKey{
+		hour:        domain.UsageHour(s.now()),
+		bucketName:  bucketName,
+		accessKeyID: accessKeyID,
+	}
+
+	s.pendingMu.Lock()
+	defer s.pendingMu.Unlock()
+
+	rec, ok := s.pending[key]
+	if !ok {
+		rec = &domain.UsageRecord{Hour: key.hour, BucketName: bucketName, AccessKeyID: accessKeyID}
+		s.pending[key] = rec
+	}
+	rec.BytesIn += bytesIn
+	rec.BytesOut += bytesOut
+	rec.Requests++
+}
+
+// Start begins the periodic flush loop.
+func (s *MeteringService) Start() {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = true
+	s.mu.Unlock()
+
+	s.logger.Info().Dur("flush_interval", s.config.FlushInterval).Msg("Starting usage metering")
+
+	go s.runLoop()
+}
+
+// Stop stops the flush loop and writes any buffered counters.
+func (s *MeteringService) Stop() {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = false
+	s.mu.Unlock()
+
+	close(s.stopChan)
+	<-s.doneChan
+
+	if err := s.Flush(context.Background()); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to flush usage on shutdown")
+	}
+
+	s.logger.Info().Msg("Usage metering stopped")
+}
+
+// runLoop is the main flush loop.
+func (s *MeteringService) runLoop() {
+	defer close(s.doneChan)
+
+	ticker := time.NewTicker(s.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Flush(context.Background()); err != nil {
+				s.logger.Error().Err(err).Msg("Failed to flush usage")
+			}
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+// Flush writes buffered counters to the database in a single transaction and
+// takes the storage snapshot for the current hour if it is still missing.
+// On failure the counters are kept and retried on the next flush.
+func (s *MeteringService) Flush(ctx context.Context) error {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	s.pendingMu.Lock()
+	batch := s.pending
+	s.pending = make(map[usageKey]*domain.UsageRecord)
+	s.pendingMu.Unlock()
+
+	if len(batch) > 0 {
+		records := make([]*domain.UsageRecord, 0, len(batch))
+		for _, rec := range batch {
+			records = append(records, rec)
+		}
+
+		err := s.txManager.WithTx(ctx, func(ctx context.Context) error {
+			return s.usageRepo.AddTraffic(ctx, records)
+		})
+		if err != nil {
+			s.requeue(batch)
+			return fmt.Errorf("%w: %v", ErrInternalError, err)
+		}
+	}
+
+	hour := domain.UsageHour(s.now())
+	if hour.After(s.lastStorageHour) {
+		if err := s.usageRepo.RecordStorage(ctx, hour); err != nil {
+			return fmt.Errorf("%w: %v", ErrInternalError, err)
+		}
+		s.lastStorageHour = hour
+	}
+
+	return nil
+}
+
+// requeue merges counters from a failed flush back into the buffer.
+func (s *MeteringService) requeue(batch map[usageKey]*domain.UsageRecord) {
+	s.pendingMu.Lock()
+	defer s.pendingMu.Unlock()
+
+	for key, rec := range batch {
+		if cur, ok := s.pending[key]; ok {
+			cur.BytesIn += rec.BytesIn
+			cur.BytesOut += rec.BytesOut
+			cur.Requests += rec.Requests
+			continue
+		}
+		s.pending[key] = rec
+	}
+}
+
+// UsageReportInput contains the parameters for a usage report.
+type UsageReportInput struct {
+	// From is the inclusive start of the reporting period.
+	From time.Time
+
+	// To is the exclusive end of the reporting period.
+	To time.Time
+
+	// BucketName optionally restricts the report to a single bucket.
+	BucketName string
+
+	// AccessKeyID optionally restricts the report to a single access key.
+	AccessKeyID string
+}
+
+// UsageReportOutput contains usage aggregated over the reporting period.
+type UsageReportOutput struct {
+	From    time.Time     `json:"from"`
+	To      time.Time     `json:"to"`
+	Buckets []BucketUsage `json:"buckets"`
+}
+
+// BucketUsage is the usage of a single bucket.
+type BucketUsage struct {
+	BucketName string `json:"bucket_name"`
+
+	// Storage, from the hourly snapshots
+	PeakBytesStored    int64 `json:"peak_bytes_stored"`
+	AverageBytesStored int64 `json:"average_bytes_stored"`
+	StorageByteHours   int64 `json:"storage_byte_hours"`
+
+	// Traffic, summed over all access keys
+	BytesIn  int64 `json:"bytes_in"`
+	BytesOut int64 `json:"bytes_out"`
+	Requests int64 `json:"requests"`
+
+	AccessKeys []AccessKeyUsage `json:"access_keys"`
+}
+
+// AccessKeyUsage is the traffic of a single access key in a bucket.
+type AccessKeyUsage struct {
+	AccessKeyID string `json:"access_key_id"`
+	BytesIn     int64  `json:"bytes_in"`
+	BytesOut    int64  `json:"bytes_out"`
+	Requests    int64  `json:"requests"`
+}
+
+// ParseUsageMonth parses a "YYYY-MM" month into its [from, to) UTC range.
+func ParseUsageMonth(month string) (from, to time.Time, err error) {
+	from, err = time.Parse("2006-01", month)
+	if err != nil {
+		return time.Time{}, time.Time{}, ErrInvalidUsagePeriod
+	}
+	return from, from.AddDate(0, 1, 0), nil
+}
+
+// Report aggregates hourly usage over a period, per bucket and access key.
+func (s *MeteringService) Report(ctx context.Context, input UsageReportInput) (*UsageReportOutput, error) {
+	if !input.To.After(input.From) {
+		return nil, ErrInvalidUsagePeriod
+	}
+
+	records, err := s.usageRepo.List(ctx, input.From, input.To, repository.UsageFilter{
+		BucketName:  input.BucketName,
+		AccessKeyID: input.AccessKeyID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	buckets := make(map[string]*BucketUsage)
+	keys := make(map[string]map[string]*AccessKeyUsage)
+	snapshots := make(map[string]int64)
+
+	for _, rec := range records {
+		bu, ok := buckets[rec.BucketName]
+		if !ok {
+			bu = &BucketUsage{BucketName: rec.BucketName}
+			buckets[rec.BucketName] = bu
+			keys[rec.BucketName] = make(map[string]*AccessKeyUsage)
+		}
+
+		// Storage snapshot rows carry an empty access key
+		if rec.AccessKeyID == "" {
+			snapshots[rec.BucketName]++
+			bu.StorageByteHours += rec.BytesStored
+			bu.PeakBytesStored = max(bu.PeakBytesStored, rec.BytesStored)
+			continue
+		}
+
+		bu.BytesIn += rec.BytesIn
+		bu.BytesOut += rec.BytesOut
+		bu.Requests += rec.Requests
+
+		ku, ok := keys[rec.BucketName][rec.AccessKeyID]
+		if !ok {
+			ku = &AccessKeyUsage{AccessKeyID: rec.AccessKeyID}
+			keys[rec.BucketName][rec.AccessKeyID] = ku
+		}
+		ku.BytesIn += rec.BytesIn
+		ku.BytesOut += rec.BytesOut
+		ku.Requests += rec.Requests
+	}
+
+	output := &UsageReportOutput{
+		From:    input.From.UTC(),
+		To:      input.To.UTC(),
+		Buckets: make([]BucketUsage, 0, len(buckets)),
+	}
+	for name, bu := range buckets {
+		if n := snapshots[name]; n > 0 {
+			bu.AverageBytesStored = bu.StorageByteHours / n
+		}
+		bu.AccessKeys = make([]AccessKeyUsage, 0, len(keys[name]))
+		for _, ku := range keys[name] {
+			bu.AccessKeys = append(bu.AccessKeys, *ku)
+		}
+		sort.Slice(bu.AccessKeys, func(i, j int) bool {
+			return bu.AccessKeys[i].AccessKeyID < bu.AccessKeys[j].AccessKeyID
+		})
+		output.Buckets = append(output.Buckets, *bu)
+	}
+	sort.Slice(output.Buckets, func(i, j int) bool {
+		return output.Buckets[i].BucketName < output.Buckets[j].BucketName
+	})
+
+	return output, nil
+}
@@ -0,0 +1,122 @@
+// Package service provides business logic services for Alexander Storage.
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// mockUsageRepository is a mock implementation of UsageRepository.
+type mockUsageRepository struct {
+	mock.Mock
+}
+
+func (m *mockUsageRepository) AddTraffic(ctx context.Context, records []*domain.UsageRecord) error {
+	args := m.Called(ctx, records)
+	return args.Error(0)
+}
+
+func (m *mockUsageRepository) RecordStorage(ctx context.Context, hour time.Time) error {
+	args := m.Called(ctx, hour)
+	return args.Error(0)
+}
+
+func (m *mockUsageRepository) List(ctx context.Context, from, to time.Time, filter repository.UsageFilter) ([]*domain.UsageRecord, error) {
+	args := m.Called(ctx, from, to, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.UsageRecord), args.Error(1)
+}
+
+func (m *mockUsageRepository) ListStorage(ctx context.Context, from, to time.Time) ([]*domain.StorageSample, error) {
+	args := m.Called(ctx, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.StorageSample), args.Error(1)
+}
+
+func TestMeteringService_FlushAggregatesAndRetries(t *testing.T) {
+	usageRepo := new(mockUsageRepository)
+	svc := NewMeteringService(usageRepo, &mockTxManager{}, zerolog.Nop(), MeteringConfig{})
+	now := time.Date(2024, 6, 3, 10, 42, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	svc.Record("AKIA1", "photos", 100, 0)
+	svc.Record("AKIA1", "photos", 50, 10)
+	svc.Record("", "public", 0, 500)
+
+	// A failed flush keeps the counters for the next attempt
+	usageRepo.On("AddTraffic", mock.Anything, mock.Anything).Return(errors.New("db down")).Once()
+	require.ErrorIs(t, svc.Flush(ctx), ErrInternalError)
+
+	svc.Record("AKIA1", "photos", 1, 1)
+
+	var flushed []*domain.UsageRecord
+	usageRepo.On("AddTraffic", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		flushed = args.Get(1).([]*domain.UsageRecord)
+	}).Return(nil).Once()
+	usageRepo.On("RecordStorage", mock.Anything, domain.UsageHour(now)).Return(nil).Once()
+	require.NoError(t, svc.Flush(ctx))
+
+	byKey := make(map[string]*domain.UsageRecord)
+	for _, rec := range flushed {
+		require.Equal(t, time.Date(2024, 6, 3, 10, 0, 0, 0, time.UTC), rec.Hour)
+		byKey[rec.BucketName+"/"+rec.AccessKeyID] = rec
+	}
+	require.Len(t, byKey, 2)
+	require.Equal(t, domain.UsageRecord{Hour: flushed[0].Hour, BucketName: "photos", AccessKeyID: "AKIA1", BytesIn: 151, BytesOut: 11, Requests: 3}, *byKey["photos/AKIA1"])
+	require.EqualValues(t, 500, byKey["public/"+domain.AnonymousAccessKeyID].BytesOut)
+
+	// Nothing buffered and the storage snapshot for this hour is already taken
+	require.NoError(t, svc.Flush(ctx))
+	usageRepo.AssertExpectations(t)
+}
+
+func TestMeteringService_Report(t *testing.T) {
+	usageRepo := new(mockUsageRepository)
+	svc := NewMeteringService(usageRepo, &mockTxManager{}, zerolog.Nop(), MeteringConfig{})
+	from, to, err := ParseUsageMonth("2024-06")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC), to)
+
+	h0 := from
+	h1 := from.Add(time.Hour)
+	usageRepo.On("List", mock.Anything, from, to, repository.UsageFilter{}).Return([]*domain.UsageRecord{
+		{Hour: h0, BucketName: "photos", BytesStored: 100},
+		{Hour: h0, BucketName: "photos", AccessKeyID: "AKIA1", BytesIn: 10, BytesOut: 1, Requests: 2},
+		{Hour: h1, BucketName: "photos", BytesStored: 300},
+		{Hour: h1, BucketName: "photos", AccessKeyID: "AKIA1", BytesIn: 5, Requests: 1},
+		{Hour: h1, BucketName: "photos", AccessKeyID: "AKIA2", BytesOut: 7, Requests: 1},
+	}, nil)
+
+	report, err := svc.Report(context.Background(), UsageReportInput{From: from, To: to})
+	require.NoError(t, err)
+	require.Len(t, report.Buckets, 1)
+
+	b := report.Buckets[0]
+	require.EqualValues(t, 300, b.PeakBytesStored)
+	require.EqualValues(t, 200, b.AverageBytesStored)
+	require.EqualValues(t, 400, b.StorageByteHours)
+	require.EqualValues(t, 15, b.BytesIn)
+	require.EqualValues(t, 8, b.BytesOut)
+	require.EqualValues(t, 4, b.Requests)
+	require.Equal(t, []AccessKeyUsage{
+		{AccessKeyID: "AKIA1", BytesIn: 15, BytesOut: 1, Requests: 3},
+		{AccessKeyID: "AKIA2", BytesOut: 7, Requests: 1},
+	}, b.AccessKeys)
+
+	_, _, err = ParseUsageMonth("June")
+	require.ErrorIs(t, err, ErrInvalidUsagePeriod)
+}
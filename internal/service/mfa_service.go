@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/pkg/crypto"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// mfaIssuer names the service in authenticator apps.
+const mfaIssuer = "Alexander Storage"
+
+// MFAService enrolls the virtual MFA devices of users and checks the
+// x-amz-mfa header of requests that buckets with MFA delete protect.
+type MFAService struct {
+	mfaRepo   repository.MFADeviceRepository
+	userRepo  repository.UserRepository
+	encryptor *crypto.Encryptor
+	logger    zerolog.Logger
+
+	// now is replaceable in tests.
+	now func() time.Time
+}
+
+// NewMFAService creates a new MFAService. TOTP secrets are stored
+// encrypted with encryptor.
+func NewMFAService(
+	mfaRepo repository.MFADeviceRepository,
+	userRepo repository.UserRepository,
+	encryptor *crypto.Encryptor,
+	logger zerolog.Logger,
+) *MFAService {
+	return &MFAService{
+		mfaRepo:   mfaRepo,
+		userRepo:  userRepo,
+		encryptor: encryptor,
+		logger:    logger.With().Str("service", "mfa").Logger(),
+		now:       time.Now,
+	}
+}
+
+// EnrollMFADeviceOutput contains the result of enrolling an MFA device.
+// Note: Secret and URI are only available at enrollment and should be shown to the user once.
+type EnrollMFADeviceOutput struct {
+	Device *domain.MFADevice
+	Secret string // Plaintext base32 TOTP secret - only shown once!
+	URI    string // otpauth:// URI of the secret, for QR codes
+}
+
+// Enroll creates a virtual MFA device for a user, who must not have one.
+func (s *MFAService) Enroll(ctx context.Context, userID int64) (*EnrollMFADeviceOutput, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	secret, err := crypto.GenerateTOTPSecret()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	encryptedSecret, err := s.encryptor.EncryptString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to encrypt MFA secret: %v", ErrInternalError, err)
+	}
+
+	device := domain.NewMFADevice(user, encryptedSecret)
+	if err := s.mfaRepo.Create(ctx, device); err != nil {
+		if errors.Is(err, domain.ErrMFADeviceExists) {
+			return nil, domain.ErrMFADeviceExists
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	s.logger.Info().Ctx(ctx).
+		Int64("user_id", userID).
+		Str("serial_number", device.SerialNumber).
+		Msg("MFA device enrolled")
+
+	return &EnrollMFADeviceOutput{
+		Device: device,
+		Secret: secret,
+		URI:    crypto.TOTPURI(mfaIssuer, user.Username, secret),
+	}, nil
+}
+
+// Remove deletes the MFA device of a user. Buckets of the user with MFA
+// delete enabled then refuse the requests it protects until a new device
+// is enrolled.
+func (s *MFAService) Remove(ctx context.Context, userID int64) error {
+	if err := s.mfaRepo.Delete(ctx, userID); err != nil {
+		if errors.Is(err, domain.ErrMFADeviceNotFound) {
+			return domain.ErrMFADeviceNotFound
+		}
+		return fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	s.logger.Info().Ctx(ctx).Int64("user_id", userID).Msg("MFA device removed")
+	return nil
+}
+
+// Verify checks an x-amz-mfa header against the device of a user. It
+// returns domain.ErrMFARequired if the header is empty and
+// domain.ErrInvalidMFA if it does not carry a current code of that device.
+func (s *MFAService) Verify(ctx context.Context, userID int64, header string) error {
+	if header == "" {
+		return domain.ErrMFARequired
+	}
+	serialNumber, code, err := domain.ParseMFAHeader(header)
+	if err != nil {
+		return err
+	}
+
+	device, err := s.mfaRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrMFADeviceNotFound) {
+			return fmt.Errorf("%w: no MFA device is enrolled", domain.ErrInvalidMFA)
+		}
+		return fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	if device.SerialNumber != serialNumber {
+		return fmt.Errorf("%w: unknown serial number", domain.ErrInvalidMFA)
+	}
+
+	secret, err := s.encryptor.DecryptString(device.EncryptedSecret)
+	if err != nil {
+		return fmt.Errorf("%w: failed to decrypt MFA secret: %v", ErrInternalError, err)
+	}
+	if !crypto.ValidateTOTP(secret, code, s.now()) {
+		s.logger.Warn().Ctx(ctx).Int64("user_id", userID).Msg("invalid MFA code")
+		return fmt.Errorf("%w: wrong code", domain.ErrInvalidMFA)
+	}
+	return nil
+}
+
+// checkMFADelete returns nil unless bucket has MFA delete enabled and a
+// request of ownerID does not carry a valid x-amz-mfa header for the
+// bucket owner's device. Requests without an owner, made by
+// administrators, are not checked. mfa may be nil, in which case protected
+// requests are refused.
+func checkMFADelete(ctx context.Context, mfa *MFAService, bucket *domain.Bucket, ownerID int64, header string) error {
+	if !bucket.MFADelete || ownerID == 0 {
+		return nil
+	}
+	if mfa == nil {
+		return domain.ErrMFARequired
+	}
+	return mfa.Verify(ctx, bucket.OwnerID, header)
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/lock"
+	"github.com/prn-tf/alexander-storage/internal/pkg/crypto"
+	"github.com/prn-tf/alexander-storage/internal/repository/sqlite"
+	"github.com/prn-tf/alexander-storage/internal/storage/filesystem"
+)
+
+type mfaFixture struct {
+	mfa     *MFAService
+	buckets *BucketService
+	objects *ObjectService
+	userID  int64
+	now     time.Time
+}
+
+// newMFAFixture returns services backed by SQLite and the filesystem with a
+// versioned bucket "vault" whose owner has no MFA device yet.
+func newMFAFixture(t *testing.T) *mfaFixture {
+	t.Helper()
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	db, err := sqlite.NewDB(ctx, sqlite.DefaultConfig(filepath.Join(dir, "alexander.db")), zerolog.Nop())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, db.Migrate(ctx))
+
+	store, err := filesystem.NewStorage(filesystem.Config{
+		DataDir: filepath.Join(dir, "blobs"),
+		TempDir: filepath.Join(dir, "tmp"),
+	}, zerolog.Nop())
+	require.NoError(t, err)
+
+	encryptor, err := crypto.NewEncryptor(bytes.Repeat([]byte{7}, crypto.KeySize))
+	require.NoError(t, err)
+
+	userRepo := sqlite.NewUserRepository(db)
+	user := domain.NewUser("owner", "owner@example.com", "hash")
+	require.NoError(t, userRepo.Create(ctx, user))
+
+	bucketRepo := sqlite.NewBucketRepository(db)
+	bucket := domain.NewBucket(user.ID, "vault")
+	bucket.Versioning = domain.VersioningEnabled
+	require.NoError(t, bucketRepo.Create(ctx, bucket))
+
+	f := &mfaFixture{userID: user.ID, now: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)}
+	f.mfa = NewMFAService(sqlite.NewMFADeviceRepository(db), userRepo, encryptor, zerolog.Nop())
+	f.mfa.now = func() time.Time { return f.now }
+	f.buckets = NewBucketService(bucketRepo, f.mfa, zerolog.Nop(), DefaultBucketConfig())
+	f.objects = NewObjectService(sqlite.NewObjectRepository(db), sqlite.NewBlobRepository(db), bucketRepo, nil, nil, nil, nil, nil,
+		f.mfa, sqlite.NewTxManager(db), store, lock.NewMemoryLocker(), nil, zerolog.Nop())
+	return f
+}
+
+// header returns an x-amz-mfa header with the code of secret at a time.
+func (f *mfaFixture) header(t *testing.T, serialNumber, secret string, at time.Time) string {
+	t.Helper()
+	code, err := crypto.TOTPCode(secret, at)
+	require.NoError(t, err)
+	return serialNumber + " " + code
+}
+
+func TestMFAService_EnrollAndVerify(t *testing.T) {
+	ctx := context.Background()
+	f := newMFAFixture(t)
+
+	err := f.mfa.Verify(ctx, f.userID, "")
+	assert.ErrorIs(t, err, domain.ErrMFARequired)
+
+	enrolled, err := f.mfa.Enroll(ctx, f.userID)
+	require.NoError(t, err)
+	assert.Equal(t, "arn:aws:iam::1:mfa/owner", enrolled.Device.SerialNumber)
+	assert.NotEqual(t, enrolled.Secret, enrolled.Device.EncryptedSecret)
+	assert.Contains(t, enrolled.URI, "secret="+enrolled.Secret)
+
+	_, err = f.mfa.Enroll(ctx, f.userID)
+	assert.ErrorIs(t, err, domain.ErrMFADeviceExists)
+
+	serial := enrolled.Device.SerialNumber
+	tests := []struct {
+		name    string
+		header  string
+		wantErr error
+	}{
+		{"current code", f.header(t, serial, enrolled.Secret, f.now), nil},
+		{"previous period", f.header(t, serial, enrolled.Secret, f.now.Add(-crypto.TOTPPeriod)), nil},
+		{"expired code", f.header(t, serial, enrolled.Secret, f.now.Add(-3*crypto.TOTPPeriod)), domain.ErrInvalidMFA},
+		{"other serial number", f.header(t, "arn:aws:iam::2:mfa/other", enrolled.Secret, f.now), domain.ErrInvalidMFA},
+		{"no code", serial, domain.ErrInvalidMFA},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := f.mfa.Verify(ctx, f.userID, tt.header)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+
+	require.NoError(t, f.mfa.Remove(ctx, f.userID))
+	err = f.mfa.Verify(ctx, f.userID, f.header(t, serial, enrolled.Secret, f.now))
+	assert.ErrorIs(t, err, domain.ErrInvalidMFA)
+	assert.ErrorIs(t, f.mfa.Remove(ctx, f.userID), domain.ErrMFADeviceNotFound)
+}
+
+func TestMFADelete_ProtectsVersionsAndVersioning(t *testing.T) {
+	ctx := context.Background()
+	f := newMFAFixture(t)
+
+	enrolled, err := f.mfa.Enroll(ctx, f.userID)
+	require.NoError(t, err)
+	validMFA := f.header(t, enrolled.Device.SerialNumber, enrolled.Secret, f.now)
+	enable := true
+
+	// Enabling MFA delete needs a code
+	err = f.buckets.PutBucketVersioning(ctx, PutBucketVersioningInput{
+		Name: "vault", OwnerID: f.userID, Status: domain.VersioningEnabled, MFADelete: &enable,
+	})
+	require.ErrorIs(t, err, domain.ErrMFARequired)
+	require.NoError(t, f.buckets.PutBucketVersioning(ctx, PutBucketVersioningInput{
+		Name: "vault", OwnerID: f.userID, Status: domain.VersioningEnabled, MFADelete: &enable, MFA: validMFA,
+	}))
+
+	versioning, err := f.buckets.GetBucketVersioning(ctx, GetBucketVersioningInput{Name: "vault", OwnerID: f.userID})
+	require.NoError(t, err)
+	assert.True(t, versioning.MFADelete)
+
+	put, err := f.objects.PutObject(ctx, PutObjectInput{
+		BucketName: "vault",
+		Key:        "ledger",
+		Body:       bytes.NewReader([]byte("v1")),
+		Size:       2,
+		OwnerID:    f.userID,
+	})
+	require.NoError(t, err)
+
+	// Delete markers need no code, permanent deletes of versions do
+	deleted, err := f.objects.DeleteObject(ctx, DeleteObjectInput{BucketName: "vault", Key: "ledger", OwnerID: f.userID})
+	require.NoError(t, err)
+	assert.True(t, deleted.DeleteMarker)
+
+	_, err = f.objects.DeleteObject(ctx, DeleteObjectInput{
+		BucketName: "vault", Key: "ledger", VersionID: put.VersionID, OwnerID: f.userID,
+	})
+	assert.ErrorIs(t, err, domain.ErrMFARequired)
+	_, err = f.objects.DeleteObject(ctx, DeleteObjectInput{
+		BucketName: "vault", Key: "ledger", VersionID: put.VersionID, OwnerID: f.userID,
+		MFA: f.header(t, enrolled.Device.SerialNumber, enrolled.Secret, f.now.Add(-time.Hour)),
+	})
+	assert.ErrorIs(t, err, domain.ErrInvalidMFA)
+	_, err = f.objects.DeleteObject(ctx, DeleteObjectInput{
+		BucketName: "vault", Key: "ledger", VersionID: put.VersionID, OwnerID: f.userID, MFA: validMFA,
+	})
+	require.NoError(t, err)
+
+	// Suspending versioning needs a code, keeping the state does not
+	require.NoError(t, f.buckets.PutBucketVersioning(ctx, PutBucketVersioningInput{
+		Name: "vault", OwnerID: f.userID, Status: domain.VersioningEnabled,
+	}))
+	err = f.buckets.PutBucketVersioning(ctx, PutBucketVersioningInput{
+		Name: "vault", OwnerID: f.userID, Status: domain.VersioningSuspended,
+	})
+	assert.ErrorIs(t, err, domain.ErrMFARequired)
+
+	// Administrators are not asked for a code
+	disable := false
+	require.NoError(t, f.buckets.PutBucketVersioning(ctx, PutBucketVersioningInput{
+		Name: "vault", Status: domain.VersioningSuspended, MFADelete: &disable,
+	}))
+	versioning, err = f.buckets.GetBucketVersioning(ctx, GetBucketVersioningInput{Name: "vault", OwnerID: f.userID})
+	require.NoError(t, err)
+	assert.Equal(t, domain.VersioningSuspended, versioning.Status)
+	assert.False(t, versioning.MFADelete)
+}
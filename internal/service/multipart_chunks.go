@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/google/uuid"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/lock"
+	"github.com/prn-tf/alexander-storage/internal/storage"
+)
+
+// Resumable uploads stage the chunks of an object as parts of a multipart
+// upload named by their SHA-256 hash rather than by a part number. A client
+// that lost its connection lists the staged parts, whose ETags derive from
+// the hash, sends only the chunks missing and commits the list of hashes in
+// object order.
+
+// StageChunkInput contains the data needed to stage a chunk of a resumable upload.
+type StageChunkInput struct {
+	BucketName  string
+	Key         string
+	UploadID    string
+	ContentHash string // Hex SHA-256 hash of Body
+	Body        io.Reader
+	Size        int64
+	OwnerID     int64
+}
+
+// StageChunkOutput contains the result of staging a chunk.
+type StageChunkOutput struct {
+	ETag       string
+	PartNumber int
+
+	// AlreadyStaged reports that the upload had the chunk, so Body was
+	// not read.
+	AlreadyStaged bool
+}
+
+// CommitChunksInput contains the data needed to commit a resumable upload.
+type CommitChunksInput struct {
+	BucketName string
+	Key        string
+	UploadID   string
+	Chunks     []string // Hex SHA-256 hashes of the chunks in object order
+	OwnerID    int64
+	BatchID    string // Optional - stage the object in this batch until it is committed
+}
+
+// StageChunk stores a chunk of a resumable upload as a part with the next
+// free part number. Staging a chunk the upload already has is a no-op.
+func (s *MultipartService) StageChunk(ctx context.Context, input StageChunkInput) (_ *StageChunkOutput, err error) {
+	ctx, op := startOperation(ctx, s.metrics, "stage_chunk")
+	var stored int64
+	defer func() { op.end(stored, err) }()
+
+	if err := domain.ValidateChunkHash(input.ContentHash); err != nil {
+		return nil, err
+	}
+	if input.Size > s.limits.MaxPartSize {
+		return nil, fmt.Errorf("%w: chunk is %d bytes, the maximum is %d bytes",
+			domain.ErrPartTooLarge, input.Size, s.limits.MaxPartSize)
+	}
+
+	bucket, upload, err := s.getInProgressUpload(ctx, input.BucketName, input.Key, input.UploadID, input.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkWritable(ctx, s.readOnly, bucket); err != nil {
+		return nil, err
+	}
+	if err := checkStorageQuota(ctx, s.tenants, bucket, input.Size); err != nil {
+		return nil, err
+	}
+	if upload.IsExpired() {
+		return nil, domain.ErrMultipartUploadExpired
+	}
+
+	existing, err := s.stagedChunk(ctx, upload.ID, input.ContentHash)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return chunkOutput(existing, true), nil
+	}
+
+	// Chunks of an upload with a checksum algorithm get a checksum of that
+	// algorithm like any other part
+	contentSum, err := newContentChecksum(upload.ChecksumAlgorithm, "")
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = storage.WithResidency(ctx, bucket.Residency)
+	contentHash, err := s.storage.Store(ctx, contentSum.wrap(input.Body), input.Size)
+	if err != nil {
+		s.logger.Error().Ctx(ctx).Err(err).Str("content_hash", input.ContentHash).Msg("failed to store chunk content")
+		return nil, storageError(err)
+	}
+
+	// A mismatched blob has no reference and is left for GC
+	if contentHash != input.ContentHash {
+		return nil, domain.ErrChecksumMismatch
+	}
+	checksum, err := contentSum.verify()
+	if err != nil {
+		return nil, err
+	}
+	if input.Size, err = checkStoredQuota(ctx, s.tenants, s.storage, bucket, contentHash, input.Size); err != nil {
+		return nil, err
+	}
+	stored = input.Size
+
+	// Part numbers are allocated under the upload's lock; a concurrent
+	// request may have staged the same chunk meanwhile
+	unlock, err := s.lockUpload(ctx, upload.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if existing, err = s.stagedChunk(ctx, upload.ID, contentHash); err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return chunkOutput(existing, true), nil
+	}
+	last, err := s.multipartRepo.LastPartNumber(ctx, upload.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	if last >= domain.MaxPartNumber {
+		return nil, fmt.Errorf("%w: part numbers up to %d are in use", domain.ErrTooManyParts, domain.MaxPartNumber)
+	}
+
+	part := domain.NewUploadPart(upload.ID, last+1, contentHash, calculatePartETag(contentHash), input.Size)
+	part.Checksum = checksum
+	if err := s.recordPart(ctx, part, storage.PathFor(ctx, s.storage, contentHash)); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info().Ctx(ctx).
+		Str("upload_id", input.UploadID).
+		Str("content_hash", contentHash).
+		Int("part_number", part.PartNumber).
+		Int64("size", input.Size).
+		Msg("chunk staged")
+
+	return chunkOutput(part, false), nil
+}
+
+// CommitChunks completes a resumable upload with its staged chunks in the
+// given order. A chunk may be listed more than once.
+func (s *MultipartService) CommitChunks(ctx context.Context, input CommitChunksInput) (_ *CompleteMultipartUploadOutput, err error) {
+	ctx, op := startOperation(ctx, s.metrics, "commit_chunks")
+	defer func() { op.end(0, err) }()
+
+	if len(input.Chunks) == 0 {
+		return nil, domain.ErrNoPartsProvided
+	}
+	if len(input.Chunks) > s.limits.MaxParts {
+		return nil, fmt.Errorf("%w: %d chunks, the maximum is %d",
+			domain.ErrTooManyParts, len(input.Chunks), s.limits.MaxParts)
+	}
+	for _, hash := range input.Chunks {
+		if err := domain.ValidateChunkHash(hash); err != nil {
+			return nil, err
+		}
+	}
+
+	bucket, upload, err := s.getInProgressUpload(ctx, input.BucketName, input.Key, input.UploadID, input.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkWritable(ctx, s.readOnly, bucket); err != nil {
+		return nil, err
+	}
+	batch, err := writeBatch(ctx, s.batchRepo, bucket, input.BatchID, input.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+
+	staged, err := s.multipartRepo.GetPartsByContentHash(ctx, upload.ID, input.Chunks)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	byHash := make(map[string]*domain.UploadPart, len(staged))
+	for _, part := range staged {
+		if _, ok := byHash[part.ContentHash]; !ok {
+			byHash[part.ContentHash] = part
+		}
+	}
+
+	parts := make([]*domain.UploadPart, len(input.Chunks))
+	for i, hash := range input.Chunks {
+		part, ok := byHash[hash]
+		if !ok {
+			return nil, fmt.Errorf("%w: chunk %s is not staged", domain.ErrPartNotFound, hash)
+		}
+		parts[i] = part
+	}
+
+	return s.completeUpload(ctx, bucket, upload, batch, parts)
+}
+
+// stagedChunk returns the part of an upload holding a chunk, or nil if the
+// chunk is not staged.
+func (s *MultipartService) stagedChunk(ctx context.Context, uploadID uuid.UUID, contentHash string) (*domain.UploadPart, error) {
+	parts, err := s.multipartRepo.GetPartsByContentHash(ctx, uploadID, []string{contentHash})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	if len(parts) == 0 {
+		return nil, nil
+	}
+	return parts[0], nil
+}
+
+// chunkOutput returns the result of staging a chunk as part.
+func chunkOutput(part *domain.UploadPart, alreadyStaged bool) *StageChunkOutput {
+	return &StageChunkOutput{ETag: part.ETag, PartNumber: part.PartNumber, AlreadyStaged: alreadyStaged}
+}
+
+// lockUpload takes the lock of a multipart upload, with the retries of
+// object write locks, and returns the function releasing it.
+func (s *MultipartService) lockUpload(ctx context.Context, uploadID uuid.UUID) (func(), error) {
+	lockKey := lock.Keys.MultipartUpload(uploadID.String())
+	acquired, err := s.locker.AcquireWithRetry(ctx, lockKey, objectLockTTL, objectLockRetries, objectLockRetryDelay)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		s.logger.Error().Ctx(ctx).Err(err).Str("upload_id", uploadID.String()).Msg("failed to acquire upload lock")
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	if !acquired {
+		return nil, ErrUploadBusy
+	}
+
+	return func() {
+		if _, err := s.locker.Release(context.WithoutCancel(ctx), lockKey); err != nil {
+			s.logger.Warn().Ctx(ctx).Err(err).Str("upload_id", uploadID.String()).Msg("failed to release upload lock")
+		}
+	}, nil
+}
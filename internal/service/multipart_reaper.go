@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/lock"
+	"github.com/prn-tf/alexander-storage/internal/metrics"
+)
+
+// MultipartReaper periodically aborts multipart uploads past their
+// expiry, releasing their part blobs to GC as AbortMultipartUpload does.
+type MultipartReaper struct {
+	multipart *MultipartService
+	locker    lock.Locker
+	metrics   *metrics.Metrics
+	logger    zerolog.Logger
+	config    MultipartReaperConfig
+
+	// Control
+	mu       sync.Mutex
+	running  bool
+	stopChan chan struct{}
+	doneChan chan struct{}
+}
+
+// MultipartReaperConfig contains multipart reaper configuration.
+type MultipartReaperConfig struct {
+	// Interval is how often to look for expired uploads.
+	Interval time.Duration
+
+	// BatchSize is the number of expired uploads listed at a time.
+	BatchSize int
+}
+
+// DefaultMultipartReaperConfig returns sensible defaults.
+func DefaultMultipartReaperConfig() MultipartReaperConfig {
+	return MultipartReaperConfig{
+		Interval:  1 * time.Hour,
+		BatchSize: 100,
+	}
+}
+
+// NewMultipartReaper creates a new multipart reaper.
+func NewMultipartReaper(
+	multipart *MultipartService,
+	locker lock.Locker,
+	m *metrics.Metrics,
+	logger zerolog.Logger,
+	config MultipartReaperConfig,
+) *MultipartReaper {
+	return &MultipartReaper{
+		multipart: multipart,
+		locker:    locker,
+		metrics:   m,
+		logger:    logger.With().Str("service", "multipart-reaper").Logger(),
+		config:    config,
+		stopChan:  make(chan struct{}),
+		doneChan:  make(chan struct{}),
+	}
+}
+
+// Start begins the reaper scheduler.
+func (r *MultipartReaper) Start() {
+	r.mu.Lock()
+	if r.running {
+		r.mu.Unlock()
+		return
+	}
+	r.running = true
+	r.mu.Unlock()
+
+	r.logger.Info().
+		Dur("interval", r.config.Interval).
+		Msg("Starting multipart reaper")
+
+	go r.runLoop()
+}
+
+// Stop stops the reaper scheduler, interrupting a run in progress.
+func (r *MultipartReaper) Stop() {
+	r.mu.Lock()
+	if !r.running {
+		r.mu.Unlock()
+		return
+	}
+	r.running = false
+	r.mu.Unlock()
+
+	close(r.stopChan)
+	<-r.doneChan
+
+	r.logger.Info().Msg("Multipart reaper stopped")
+}
+
+// runLoop is the main reaper loop.
+func (r *MultipartReaper) runLoop() {
+	defer close(r.doneChan)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-r.stopChan
+		cancel()
+	}()
+
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		r.RunOnce(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-r.stopChan:
+			return
+		}
+	}
+}
+
+// MultipartReapResult contains the result of a reaper run.
+type MultipartReapResult struct {
+	// Uploads is the number of expired uploads aborted.
+	Uploads int `json:"uploads"`
+
+	// Parts is the number of part blob references released.
+	Parts int `json:"parts"`
+
+	// Errors is the number of uploads that could not be aborted.
+	Errors int `json:"errors"`
+
+	// Duration is how long the run took.
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// RunOnce aborts every upload that has expired.
+// This can be called manually or by the scheduler.
+func (r *MultipartReaper) RunOnce(ctx context.Context) MultipartReapResult {
+	start := time.Now()
+	result := MultipartReapResult{}
+
+	lockKey := lock.Keys.MultipartGC()
+	lockTTL := r.config.Interval / 2
+	if lockTTL < 5*time.Minute {
+		lockTTL = 5 * time.Minute
+	}
+
+	acquired, err := r.locker.Acquire(ctx, lockKey, lockTTL)
+	if err != nil {
+		r.logger.Error().Err(err).Msg("Failed to acquire multipart reaper lock")
+		result.Errors++
+		result.Duration = time.Since(start)
+		return result
+	}
+	if !acquired {
+		r.logger.Debug().Msg("Multipart reaper lock held by another process, skipping run")
+		result.Duration = time.Since(start)
+		return result
+	}
+	defer func() {
+		if _, err := r.locker.Release(context.Background(), lockKey); err != nil {
+			r.logger.Error().Err(err).Msg("Failed to release multipart reaper lock")
+		}
+	}()
+
+	// Aborted uploads are gone from the next page, so paging ends once all
+	// are processed; a page with failures ends the run to avoid listing
+	// the same uploads again
+	for ctx.Err() == nil {
+		uploads, err := r.multipart.multipartRepo.ListExpired(ctx, start, r.config.BatchSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				r.logger.Error().Err(err).Msg("Failed to list expired multipart uploads")
+				result.Errors++
+			}
+			break
+		}
+
+		failed := result.Errors
+		for _, upload := range uploads {
+			if ctx.Err() != nil {
+				break
+			}
+			r.reap(ctx, upload, &result)
+		}
+
+		if len(uploads) < r.config.BatchSize || result.Errors > failed {
+			break
+		}
+	}
+
+	result.Duration = time.Since(start)
+
+	if r.metrics != nil {
+		r.metrics.RecordMultipartReap(result.Uploads, result.Parts, result.Errors)
+	}
+
+	if result.Uploads+result.Errors == 0 {
+		r.logger.Debug().Msg("No expired multipart uploads")
+		return result
+	}
+
+	r.logger.Info().
+		Int("uploads", result.Uploads).
+		Int("parts", result.Parts).
+		Int("errors", result.Errors).
+		Dur("duration", result.Duration).
+		Msg("Multipart reaper run completed")
+
+	return result
+}
+
+// reap aborts one expired upload.
+func (r *MultipartReaper) reap(ctx context.Context, upload *domain.MultipartUpload, result *MultipartReapResult) {
+	parts, err := r.multipart.abortUpload(ctx, upload.ID)
+	switch {
+	case err == nil:
+	case errors.Is(err, domain.ErrMultipartUploadNotFound):
+		// Completed or aborted since it was listed
+		return
+	default:
+		if ctx.Err() == nil {
+			r.logger.Warn().Err(err).Str("upload_id", upload.ID.String()).Msg("Failed to abort expired multipart upload")
+			result.Errors++
+		}
+		return
+	}
+
+	r.logger.Debug().
+		Str("upload_id", upload.ID.String()).
+		Int64("bucket_id", upload.BucketID).
+		Str("key", upload.Key).
+		Time("expires_at", upload.ExpiresAt).
+		Int("parts", parts).
+		Msg("Aborted expired multipart upload")
+	result.Uploads++
+	result.Parts += parts
+}
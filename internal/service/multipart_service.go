@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,6 +16,7 @@ import (
 
 	"github.com/prn-tf/alexander-storage/internal/domain"
 	"github.com/prn-tf/alexander-storage/internal/lock"
+	"github.com/prn-tf/alexander-storage/internal/metrics"
 	"github.com/prn-tf/alexander-storage/internal/repository"
 	"github.com/prn-tf/alexander-storage/internal/storage"
 )
@@ -25,19 +27,65 @@ type MultipartService struct {
 	objectRepo    repository.ObjectRepository
 	blobRepo      repository.BlobRepository
 	bucketRepo    repository.BucketRepository
+	eventRepo     repository.EventRepository
+	replRepo      repository.ReplicationRepository
+	lifeRepo      repository.LifecycleRepository
+	stagedRepo    repository.StagedObjectRepository
+	batchRepo     repository.BatchRepository
+	readOnly      *ReadOnlyService
+	tenants       *TenantService
+	txManager     repository.TxManager
 	storage       storage.Backend
 	locker        lock.Locker
+	metrics       *metrics.Metrics
 	logger        zerolog.Logger
+	limits        MultipartLimits
+}
+
+// MultipartLimits bounds the parts of multipart uploads.
+type MultipartLimits struct {
+	// MinPartSize is the minimum size of every part of a completed upload
+	// except the last.
+	MinPartSize int64
+
+	// MaxPartSize is the maximum size of a part.
+	MaxPartSize int64
+
+	// MaxParts is the maximum number of parts of a completed upload.
+	MaxParts int
+}
+
+// DefaultMultipartLimits returns the S3 limits.
+func DefaultMultipartLimits() MultipartLimits {
+	return MultipartLimits{
+		MinPartSize: domain.MinPartSize,
+		MaxPartSize: domain.MaxPartSize,
+		MaxParts:    domain.MaxPartNumber,
+	}
 }
 
 // NewMultipartService creates a new MultipartService.
+// The part limits are DefaultMultipartLimits until SetLimits is called.
+// eventRepo may be nil, in which case no change events are recorded.
+// replRepo may be nil, in which case no changes are queued for replication.
+// lifeRepo may be nil, in which case uploads are only aborted at their default expiry.
+// stagedRepo may be nil if no bucket has quarantine enabled and batchRepo is nil.
+// batchRepo may be nil, in which case writes naming a batch are rejected.
+// m may be nil, in which case no operation metrics are recorded.
 func NewMultipartService(
 	multipartRepo repository.MultipartUploadRepository,
 	objectRepo repository.ObjectRepository,
 	blobRepo repository.BlobRepository,
 	bucketRepo repository.BucketRepository,
+	eventRepo repository.EventRepository,
+	replRepo repository.ReplicationRepository,
+	lifeRepo repository.LifecycleRepository,
+	stagedRepo repository.StagedObjectRepository,
+	batchRepo repository.BatchRepository,
+	txManager repository.TxManager,
 	storage storage.Backend,
 	locker lock.Locker,
+	m *metrics.Metrics,
 	logger zerolog.Logger,
 ) *MultipartService {
 	return &MultipartService{
@@ -45,12 +93,39 @@ func NewMultipartService(
 		objectRepo:    objectRepo,
 		blobRepo:      blobRepo,
 		bucketRepo:    bucketRepo,
+		eventRepo:     eventRepo,
+		replRepo:      replRepo,
+		lifeRepo:      lifeRepo,
+		stagedRepo:    stagedRepo,
+		batchRepo:     batchRepo,
+		txManager:     txManager,
 		storage:       storage,
 		locker:        locker,
+		metrics:       m,
 		logger:        logger.With().Str("service", "multipart").Logger(),
+		limits:        DefaultMultipartLimits(),
 	}
 }
 
+// SetLimits sets the part limits. It must be called before the service
+// handles requests.
+func (s *MultipartService) SetLimits(limits MultipartLimits) {
+	s.limits = limits
+}
+
+// SetReadOnlyService makes uploads also check the service-wide read-only
+// mode, not just the read-only flag of their bucket. Aborting uploads is
+// always allowed. It must be called before the service handles requests.
+func (s *MultipartService) SetReadOnlyService(readOnly *ReadOnlyService) {
+	s.readOnly = readOnly
+}
+
+// SetTenantService makes part uploads enforce the storage quota of their
+// bucket's tenant. It must be called before the service handles requests.
+func (s *MultipartService) SetTenantService(tenants *TenantService) {
+	s.tenants = tenants
+}
+
 // =============================================================================
 // Input/Output Structs
 // =============================================================================
@@ -61,15 +136,30 @@ type InitiateMultipartUploadInput struct {
 	Key          string
 	ContentType  string
 	Metadata     map[string]string
-	StorageClass domain.StorageClass
+	StorageClass domain.StorageClass // Optional - the bucket's default, or STANDARD, if empty
+	ACL          domain.ObjectACL    // Optional - private if empty
 	OwnerID      int64
+
+	// DetectContentType guesses the Content-Type from the key's extension
+	// if the client sent none and the bucket maps no type to it.
+	DetectContentType bool
+
+	// ChecksumAlgorithm is an optional additional checksum computed for
+	// every part and combined into a composite checksum of the object.
+	ChecksumAlgorithm domain.ChecksumAlgorithm
 }
 
 // InitiateMultipartUploadOutput contains the result of initiating a multipart upload.
 type InitiateMultipartUploadOutput struct {
-	Bucket   string
-	Key      string
-	UploadID string
+	Bucket            string
+	Key               string
+	UploadID          string
+	ChecksumAlgorithm domain.ChecksumAlgorithm
+
+	// AbortDate and AbortRuleID are set if a lifecycle rule aborts the
+	// upload unless it completes before AbortDate.
+	AbortDate   *time.Time
+	AbortRuleID string
 }
 
 // UploadPartInput contains the data needed to upload a part.
@@ -81,11 +171,18 @@ type UploadPartInput struct {
 	Body       io.Reader
 	Size       int64
 	OwnerID    int64
+
+	// ChecksumAlgorithm and Checksum are the optional checksum the client
+	// sent with the part. The algorithm must be the upload's.
+	ChecksumAlgorithm domain.ChecksumAlgorithm
+	Checksum          string
 }
 
 // UploadPartOutput contains the result of uploading a part.
 type UploadPartOutput struct {
-	ETag string
+	ETag              string
+	ChecksumAlgorithm domain.ChecksumAlgorithm
+	Checksum          string
 }
 
 // CompleteMultipartUploadInput contains the data needed to complete a multipart upload.
@@ -95,6 +192,7 @@ type CompleteMultipartUploadInput struct {
 	UploadID   string
 	Parts      []domain.CompletedPart
 	OwnerID    int64
+	BatchID    string // Optional - stage the object in this batch until it is committed
 }
 
 // CompleteMultipartUploadOutput contains the result of completing a multipart upload.
@@ -104,6 +202,11 @@ type CompleteMultipartUploadOutput struct {
 	Key       string
 	ETag      string
 	VersionID string
+	Sequence  int64
+	StagingID string // Set instead of VersionID when the object is staged by quarantine or a batch
+
+	ChecksumAlgorithm domain.ChecksumAlgorithm
+	Checksum          string // Composite checksum of the parts
 }
 
 // AbortMultipartUploadInput contains the data needed to abort a multipart upload.
@@ -169,6 +272,7 @@ type ListPartsOutput struct {
 	IsTruncated          bool
 	Parts                []PartInfo
 	StorageClass         domain.StorageClass
+	ChecksumAlgorithm    domain.ChecksumAlgorithm
 }
 
 // PartInfo represents a part in list output.
@@ -177,6 +281,7 @@ type PartInfo struct {
 	LastModified time.Time
 	ETag         string
 	Size         int64
+	Checksum     string
 }
 
 // =============================================================================
@@ -184,11 +289,24 @@ type PartInfo struct {
 // =============================================================================
 
 // InitiateMultipartUpload starts a new multipart upload.
-func (s *MultipartService) InitiateMultipartUpload(ctx context.Context, input InitiateMultipartUploadInput) (*InitiateMultipartUploadOutput, error) {
+func (s *MultipartService) InitiateMultipartUpload(ctx context.Context, input InitiateMultipartUploadInput) (_ *InitiateMultipartUploadOutput, err error) {
+	ctx, op := startOperation(ctx, s.metrics, "create_multipart_upload")
+	defer func() { op.end(0, err) }()
+
 	// Validate key
 	if err := validateObjectKey(input.Key); err != nil {
 		return nil, err
 	}
+	acl, err := objectACL(input.ACL)
+	if err != nil {
+		return nil, err
+	}
+	checksumAlgorithm := input.ChecksumAlgorithm
+	if checksumAlgorithm != "" {
+		if checksumAlgorithm, err = domain.ParseChecksumAlgorithm(string(checksumAlgorithm)); err != nil {
+			return nil, err
+		}
+	}
 
 	// Get bucket
 	bucket, err := s.bucketRepo.GetByName(ctx, input.BucketName)
@@ -196,7 +314,7 @@ func (s *MultipartService) InitiateMultipartUpload(ctx context.Context, input In
 		if errors.Is(err, domain.ErrBucketNotFound) {
 			return nil, domain.ErrBucketNotFound
 		}
-		s.logger.Error().Err(err).Str("bucket", input.BucketName).Msg("failed to get bucket")
+		s.logger.Error().Ctx(ctx).Err(err).Str("bucket", input.BucketName).Msg("failed to get bucket")
 		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 
@@ -205,89 +323,107 @@ func (s *MultipartService) InitiateMultipartUpload(ctx context.Context, input In
 		return nil, ErrBucketAccessDenied
 	}
 
+	if err := checkWritable(ctx, s.readOnly, bucket); err != nil {
+		return nil, err
+	}
+
+	storageClass, err := uploadStorageClass(bucket, input.StorageClass)
+	if err != nil {
+		return nil, err
+	}
+
+	// The completed object takes its Content-Type from the metadata
+	metadata := applyDefaultMetadata(bucket, input.Metadata)
+	if contentType := uploadContentType(bucket, input.Key, input.ContentType, input.DetectContentType); contentType != "" {
+		if metadata == nil {
+			metadata = make(map[string]string)
+		}
+		metadata["Content-Type"] = contentType
+	}
+
 	// Create multipart upload
 	upload := domain.NewMultipartUpload(bucket.ID, input.Key, input.OwnerID)
-	if input.StorageClass != "" {
-		upload.StorageClass = input.StorageClass
+	upload.StorageClass = storageClass
+	upload.ACL = acl
+	upload.ChecksumAlgorithm = checksumAlgorithm
+	if metadata != nil {
+		upload.Metadata = metadata
 	}
-	if input.Metadata != nil {
-		upload.Metadata = input.Metadata
+
+	// A rule aborting the upload sooner than the default expiry shortens it
+	abort := s.abortRule(ctx, bucket, upload)
+	if abort != nil && abort.Date.Before(upload.ExpiresAt) {
+		upload.ExpiresAt = abort.Date
 	}
 
 	if err := s.multipartRepo.Create(ctx, upload); err != nil {
-		s.logger.Error().Err(err).Str("key", input.Key).Msg("failed to create multipart upload")
+		s.logger.Error().Ctx(ctx).Err(err).Str("key", input.Key).Msg("failed to create multipart upload")
 		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 
-	s.logger.Info().
+	s.logger.Info().Ctx(ctx).
 		Str("bucket", input.BucketName).
 		Str("key", input.Key).
 		Str("upload_id", upload.ID.String()).
 		Msg("multipart upload initiated")
 
-	return &InitiateMultipartUploadOutput{
-		Bucket:   input.BucketName,
-		Key:      input.Key,
-		UploadID: upload.ID.String(),
-	}, nil
-}
-
-// UploadPart uploads a part of a multipart upload.
-func (s *MultipartService) UploadPart(ctx context.Context, input UploadPartInput) (*UploadPartOutput, error) {
-	// Validate part number
-	if err := domain.ValidatePartNumber(input.PartNumber); err != nil {
-		return nil, err
+	output := &InitiateMultipartUploadOutput{
+		Bucket:            input.BucketName,
+		Key:               input.Key,
+		UploadID:          upload.ID.String(),
+		ChecksumAlgorithm: upload.ChecksumAlgorithm,
 	}
+	if abort != nil {
+		output.AbortDate = &upload.ExpiresAt
+		output.AbortRuleID = abort.RuleID
+	}
+	return output, nil
+}
 
-	// Validate part size (5MB minimum except for last part, 5GB maximum)
-	// Minimum part size for validation (currently unused, kept for future use).
-	const _ = 5 * 1024 * 1024                  // 5MB minPartSize
-	const maxPartSize = 5 * 1024 * 1024 * 1024 // 5GB
-
-	if input.Size > maxPartSize {
-		return nil, domain.ErrPartTooLarge
+// abortRule returns when the bucket's lifecycle rules abort upload, if they
+// do. A failure to load the rules is logged and the upload is left to its
+// default expiry.
+func (s *MultipartService) abortRule(ctx context.Context, bucket *domain.Bucket, upload *domain.MultipartUpload) *domain.ObjectExpiration {
+	if s.lifeRepo == nil {
+		return nil
 	}
 
-	// Parse upload ID
-	uploadID, err := uuid.Parse(input.UploadID)
+	rules, err := s.lifeRepo.ListEnabledByBucket(ctx, bucket.ID)
 	if err != nil {
-		return nil, domain.ErrMultipartUploadNotFound
+		s.logger.Warn().Ctx(ctx).Err(err).Str("bucket", bucket.Name).Msg("failed to load lifecycle rules")
+		return nil
 	}
 
-	// Get bucket
-	bucket, err := s.bucketRepo.GetByName(ctx, input.BucketName)
-	if err != nil {
-		if errors.Is(err, domain.ErrBucketNotFound) {
-			return nil, domain.ErrBucketNotFound
-		}
-		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	config := &domain.LifecycleConfiguration{Rules: rules}
+	return config.AbortIncompleteUpload(upload.Key, upload.InitiatedAt)
+}
+
+// UploadPart uploads a part of a multipart upload.
+func (s *MultipartService) UploadPart(ctx context.Context, input UploadPartInput) (_ *UploadPartOutput, err error) {
+	ctx, op := startOperation(ctx, s.metrics, "upload_part")
+	defer func() { op.end(input.Size, err) }()
+
+	// Validate part number
+	if err := domain.ValidatePartNumber(input.PartNumber); err != nil {
+		return nil, err
 	}
 
-	// Check ownership
-	if input.OwnerID > 0 && bucket.OwnerID != input.OwnerID {
-		return nil, ErrBucketAccessDenied
+	// Validate part size; the minimum only applies to the parts that are not
+	// last, which is known once the upload is completed
+	if input.Size > s.limits.MaxPartSize {
+		return nil, fmt.Errorf("%w: part %d is %d bytes, the maximum is %d bytes",
+			domain.ErrPartTooLarge, input.PartNumber, input.Size, s.limits.MaxPartSize)
 	}
 
-	// Get multipart upload
-	upload, err := s.multipartRepo.GetByID(ctx, uploadID)
+	bucket, upload, err := s.getInProgressUpload(ctx, input.BucketName, input.Key, input.UploadID, input.OwnerID)
 	if err != nil {
-		if errors.Is(err, domain.ErrMultipartUploadNotFound) {
-			return nil, domain.ErrMultipartUploadNotFound
-		}
-		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+		return nil, err
 	}
-
-	// Verify upload is for the correct bucket and key
-	if upload.BucketID != bucket.ID || upload.Key != input.Key {
-		return nil, domain.ErrMultipartUploadNotFound
+	if err := checkWritable(ctx, s.readOnly, bucket); err != nil {
+		return nil, err
 	}
-
-	// Check upload status
-	if upload.Status != domain.MultipartStatusInProgress {
-		if upload.Status == domain.MultipartStatusCompleted {
-			return nil, domain.ErrMultipartUploadCompleted
-		}
-		return nil, domain.ErrMultipartUploadAborted
+	if err := checkStorageQuota(ctx, s.tenants, bucket, input.Size); err != nil {
+		return nil, err
 	}
 
 	// Check if upload is expired
@@ -295,91 +431,84 @@ func (s *MultipartService) UploadPart(ctx context.Context, input UploadPartInput
 		return nil, domain.ErrMultipartUploadExpired
 	}
 
-	// Store part content in CAS storage
-	contentHash, err := s.storage.Store(ctx, input.Body, input.Size)
+	// Every part of an upload with a checksum algorithm gets a checksum
+	// of that algorithm
+	if input.ChecksumAlgorithm != "" && !strings.EqualFold(string(input.ChecksumAlgorithm), string(upload.ChecksumAlgorithm)) {
+		return nil, fmt.Errorf("%w: checksum type %s does not match the upload's checksum type %q",
+			domain.ErrInvalidChecksum, input.ChecksumAlgorithm, upload.ChecksumAlgorithm)
+	}
+	contentSum, err := newContentChecksum(upload.ChecksumAlgorithm, input.Checksum)
 	if err != nil {
-		s.logger.Error().Err(err).Int("part", input.PartNumber).Msg("failed to store part content")
-		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+		return nil, err
 	}
 
-	// Get storage path for blob
-	storagePath := s.storage.GetPath(contentHash)
+	// Store part content in CAS storage, on the backend for the bucket's residency
+	ctx = storage.WithResidency(ctx, bucket.Residency)
+	contentHash, err := s.storage.Store(ctx, contentSum.wrap(input.Body), input.Size)
+	if err != nil {
+		s.logger.Error().Ctx(ctx).Err(err).Int("part", input.PartNumber).Msg("failed to store part content")
+		return nil, storageError(err)
+	}
 
-	// Upsert blob metadata
-	_, err = s.blobRepo.UpsertWithRefIncrement(ctx, contentHash, input.Size, storagePath)
+	// A mismatched blob has no reference and is left for GC
+	checksum, err := contentSum.verify()
 	if err != nil {
-		s.logger.Error().Err(err).Str("content_hash", contentHash).Msg("failed to upsert blob")
-		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+		return nil, err
 	}
+	if input.Size, err = checkStoredQuota(ctx, s.tenants, s.storage, bucket, contentHash, input.Size); err != nil {
+		return nil, err
+	}
+
+	// Get storage path for blob
+	storagePath := storage.PathFor(ctx, s.storage, contentHash)
 
 	// Calculate ETag (MD5 of content hash)
 	etag := calculatePartETag(contentHash)
 
-	// Create/update part record
-	part := domain.NewUploadPart(uploadID, input.PartNumber, contentHash, etag, input.Size)
-	if err := s.multipartRepo.CreatePart(ctx, part); err != nil {
-		s.logger.Error().Err(err).Int("part", input.PartNumber).Msg("failed to create part record")
-		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	// Record the part
+	part := domain.NewUploadPart(upload.ID, input.PartNumber, contentHash, etag, input.Size)
+	part.Checksum = checksum
+	if err := s.recordPart(ctx, part, storagePath); err != nil {
+		return nil, err
 	}
 
-	s.logger.Info().
+	s.logger.Info().Ctx(ctx).
 		Str("upload_id", input.UploadID).
 		Int("part_number", input.PartNumber).
 		Int64("size", input.Size).
 		Msg("part uploaded")
 
 	return &UploadPartOutput{
-		ETag: etag,
+		ETag:              etag,
+		ChecksumAlgorithm: upload.ChecksumAlgorithm,
+		Checksum:          checksum,
 	}, nil
 }
 
 // CompleteMultipartUpload completes a multipart upload by combining all parts.
-func (s *MultipartService) CompleteMultipartUpload(ctx context.Context, input CompleteMultipartUploadInput) (*CompleteMultipartUploadOutput, error) {
+func (s *MultipartService) CompleteMultipartUpload(ctx context.Context, input CompleteMultipartUploadInput) (_ *CompleteMultipartUploadOutput, err error) {
+	ctx, op := startOperation(ctx, s.metrics, "complete_multipart_upload")
+	defer func() { op.end(0, err) }()
+
 	// Validate parts provided
 	if len(input.Parts) == 0 {
 		return nil, domain.ErrNoPartsProvided
 	}
-
-	// Parse upload ID
-	uploadID, err := uuid.Parse(input.UploadID)
-	if err != nil {
-		return nil, domain.ErrMultipartUploadNotFound
+	if len(input.Parts) > s.limits.MaxParts {
+		return nil, fmt.Errorf("%w: %d parts, the maximum is %d",
+			domain.ErrTooManyParts, len(input.Parts), s.limits.MaxParts)
 	}
 
-	// Get bucket
-	bucket, err := s.bucketRepo.GetByName(ctx, input.BucketName)
+	bucket, upload, err := s.getInProgressUpload(ctx, input.BucketName, input.Key, input.UploadID, input.OwnerID)
 	if err != nil {
-		if errors.Is(err, domain.ErrBucketNotFound) {
-			return nil, domain.ErrBucketNotFound
-		}
-		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+		return nil, err
 	}
-
-	// Check ownership
-	if input.OwnerID > 0 && bucket.OwnerID != input.OwnerID {
-		return nil, ErrBucketAccessDenied
+	if err := checkWritable(ctx, s.readOnly, bucket); err != nil {
+		return nil, err
 	}
-
-	// Get multipart upload
-	upload, err := s.multipartRepo.GetByID(ctx, uploadID)
+	batch, err := writeBatch(ctx, s.batchRepo, bucket, input.BatchID, input.OwnerID)
 	if err != nil {
-		if errors.Is(err, domain.ErrMultipartUploadNotFound) {
-			return nil, domain.ErrMultipartUploadNotFound
-		}
-		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
-	}
-
-	// Verify upload is for the correct bucket and key
-	if upload.BucketID != bucket.ID || upload.Key != input.Key {
-		return nil, domain.ErrMultipartUploadNotFound
-	}
-
-	// Check upload status
-	if upload.Status != domain.MultipartStatusInProgress {
-		if upload.Status == domain.MultipartStatusCompleted {
-			return nil, domain.ErrMultipartUploadCompleted
-		}
-		return nil, domain.ErrMultipartUploadAborted
+		return nil, err
 	}
 
 	// Validate part numbers are in ascending order
@@ -396,7 +525,7 @@ func (s *MultipartService) CompleteMultipartUpload(ctx context.Context, input Co
 	}
 
 	// Get parts from database
-	parts, err := s.multipartRepo.GetPartsForCompletion(ctx, uploadID, partNumbers)
+	parts, err := s.multipartRepo.GetPartsForCompletion(ctx, upload.ID, partNumbers)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
@@ -407,9 +536,7 @@ func (s *MultipartService) CompleteMultipartUpload(ctx context.Context, input Co
 		partMap[p.PartNumber] = p
 	}
 
-	var totalSize int64
-	etagParts := make([]string, len(input.Parts))
-	orderedContentHashes := make([]string, len(input.Parts))
+	completed := make([]*domain.UploadPart, len(input.Parts))
 	for i, requestedPart := range input.Parts {
 		storedPart, exists := partMap[requestedPart.PartNumber]
 		if !exists {
@@ -418,82 +545,174 @@ func (s *MultipartService) CompleteMultipartUpload(ctx context.Context, input Co
 		if storedPart.ETag != requestedPart.ETag {
 			return nil, domain.ErrPartETagMismatch
 		}
-		totalSize += storedPart.Size
+		if requestedPart.Checksum != "" && requestedPart.Checksum != storedPart.Checksum {
+			return nil, domain.ErrPartChecksumMismatch
+		}
+		completed[i] = storedPart
+	}
+
+	return s.completeUpload(ctx, bucket, upload, batch, completed)
+}
+
+// completeUpload combines parts, in order, into the object of an upload.
+// A part may appear more than once.
+func (s *MultipartService) completeUpload(ctx context.Context, bucket *domain.Bucket, upload *domain.MultipartUpload, batch *domain.Batch, parts []*domain.UploadPart) (_ *CompleteMultipartUploadOutput, err error) {
+	var totalSize int64
+	etagParts := make([]string, len(parts))
+	partChecksums := make([]string, len(parts))
+	orderedContentHashes := make([]string, len(parts))
+	partSizes := make([]int64, len(parts))
+	for i, part := range parts {
+		if i < len(parts)-1 && part.Size < s.limits.MinPartSize {
+			return nil, fmt.Errorf("%w: part %d is %d bytes, the minimum is %d bytes",
+				domain.ErrPartTooSmall, part.PartNumber, part.Size, s.limits.MinPartSize)
+		}
+		totalSize += part.Size
 		// Collect ETags for composite ETag calculation
-		etagParts[i] = storedPart.ETag
-		orderedContentHashes[i] = storedPart.ContentHash
+		etagParts[i] = part.ETag
+		partChecksums[i] = part.Checksum
+		orderedContentHashes[i] = part.ContentHash
+		// Part boundaries are kept so GET/HEAD can address parts by number
+		partSizes[i] = part.Size
 	}
+	stage := bucket.Quarantine || batch != nil
 
 	// Calculate composite ETag (MD5 of concatenated part MD5s + "-" + partCount)
 	compositeETag := calculateCompositeETag(etagParts)
 
-	// Concatenate all parts into a single blob
-	// Create a multi-reader that streams all parts sequentially
-	contentHash, err := s.concatenateParts(ctx, orderedContentHashes, totalSize)
-	if err != nil {
-		s.logger.Error().Err(err).Str("upload_id", input.UploadID).Msg("failed to concatenate parts")
-		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
-	}
-
-	// Register the new combined blob
-	storagePath := s.storage.GetPath(contentHash)
-	_, err = s.blobRepo.UpsertWithRefIncrement(ctx, contentHash, totalSize, storagePath)
-	if err != nil {
-		s.logger.Error().Err(err).Str("content_hash", contentHash).Msg("failed to upsert combined blob")
-		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	var compositeChecksum string
+	if upload.ChecksumAlgorithm != "" {
+		compositeChecksum, err = domain.CompositeChecksum(upload.ChecksumAlgorithm, partChecksums)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+		}
 	}
 
-	// Handle versioning for destination bucket
-	if bucket.IsVersioningEnabled() {
-		_ = s.objectRepo.MarkNotLatest(ctx, bucket.ID, input.Key)
-	} else {
-		// Non-versioned: clean up existing object
-		existingObj, err := s.objectRepo.GetByKey(ctx, bucket.ID, input.Key)
-		if err == nil && existingObj.ContentHash != nil {
-			_, _ = s.blobRepo.DecrementRef(ctx, *existingObj.ContentHash)
+	// A single part already holds the object's content; more are
+	// concatenated into a new blob
+	ctx = storage.WithResidency(ctx, bucket.Residency)
+	contentHash := orderedContentHashes[0]
+	if len(orderedContentHashes) > 1 {
+		contentHash, err = storage.Concat(ctx, s.storage, orderedContentHashes, totalSize)
+		if err != nil {
+			s.logger.Error().Ctx(ctx).Err(err).Str("upload_id", upload.ID.String()).Msg("failed to concatenate parts")
+			return nil, storageError(err)
 		}
-		_ = s.objectRepo.MarkNotLatest(ctx, bucket.ID, input.Key)
 	}
 
+	storagePath := storage.PathFor(ctx, s.storage, contentHash)
+
 	// Create final object
 	contentType := "application/octet-stream"
 	if ct, ok := upload.Metadata["Content-Type"]; ok {
 		contentType = ct
 	}
 
-	obj := domain.NewObject(bucket.ID, input.Key, contentHash, contentType, compositeETag, totalSize)
+	obj := domain.NewObject(bucket.ID, upload.Key, contentHash, contentType, compositeETag, totalSize)
 	obj.Metadata = upload.Metadata
 	obj.StorageClass = upload.StorageClass
-
-	if err := s.objectRepo.Create(ctx, obj); err != nil {
-		s.logger.Error().Err(err).Str("key", input.Key).Msg("failed to create final object")
-		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	if upload.ACL != "" {
+		obj.ACL = upload.ACL
+	}
+	if compositeChecksum != "" {
+		obj.ChecksumAlgorithm = upload.ChecksumAlgorithm
+		obj.Checksum = compositeChecksum
 	}
+	obj.PartSizes = partSizes
+
+	// Register the combined blob, release the parts, switch the latest
+	// version, create the object and mark the upload completed in one
+	// transaction
+	var staged *domain.StagedObject
+	err = s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		// Staged writes get their version and sequence when published
+		if !stage {
+			if err := assignSequence(ctx, s.objectRepo, obj, nil); err != nil {
+				return err
+			}
+		}
 
-	// Update upload status
-	if err := s.multipartRepo.UpdateStatus(ctx, uploadID, domain.MultipartStatusCompleted); err != nil {
-		s.logger.Error().Err(err).Str("upload_id", input.UploadID).Msg("failed to update upload status")
+		if _, err := s.blobRepo.UpsertWithRefIncrement(ctx, contentHash, totalSize, storagePath); err != nil {
+			return fmt.Errorf("failed to upsert combined blob: %w", err)
+		}
+		// The object holds its own reference now, so a reused single part
+		// keeps its blob when the part's reference is released
+		if _, err := s.releaseParts(ctx, upload.ID); err != nil {
+			return err
+		}
+
+		if stage {
+			staged = domain.NewStagedObject(obj, domain.ObjectEventCreatedMultipart)
+			if batch != nil {
+				err = stageInBatch(ctx, s.batchRepo, s.stagedRepo, batch, staged)
+			} else {
+				err = s.stagedRepo.Create(ctx, staged)
+			}
+			if err != nil {
+				return err
+			}
+			return s.multipartRepo.UpdateStatus(ctx, upload.ID, domain.MultipartStatusCompleted)
+		}
+
+		if err := supersedeLatest(ctx, s.objectRepo, s.blobRepo, s.logger, bucket, obj); err != nil {
+			return err
+		}
+
+		task, err := prepareReplication(ctx, s.replRepo, bucket, obj, domain.ReplicationOperationPut)
+		if err != nil {
+			return err
+		}
+		if err := s.objectRepo.Create(ctx, obj); err != nil {
+			return fmt.Errorf("failed to create final object: %w", err)
+		}
+		if err := enqueueReplication(ctx, s.replRepo, obj, task); err != nil {
+			return err
+		}
+
+		if err := s.multipartRepo.UpdateStatus(ctx, upload.ID, domain.MultipartStatusCompleted); err != nil {
+			return fmt.Errorf("failed to update upload status: %w", err)
+		}
+		return recordEvent(ctx, s.eventRepo, domain.ObjectEventCreatedMultipart, bucket, obj)
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrBatchNotFound) || errors.Is(err, domain.ErrBatchTooLarge) {
+			return nil, err
+		}
+		s.logger.Error().Ctx(ctx).Err(err).Str("upload_id", upload.ID.String()).Str("key", upload.Key).Msg("failed to complete multipart upload")
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 
-	s.logger.Info().
-		Str("bucket", input.BucketName).
-		Str("key", input.Key).
-		Str("upload_id", input.UploadID).
+	s.logger.Info().Ctx(ctx).
+		Str("bucket", bucket.Name).
+		Str("key", upload.Key).
+		Str("upload_id", upload.ID.String()).
 		Int64("total_size", totalSize).
-		Int("part_count", len(input.Parts)).
+		Int("part_count", len(parts)).
 		Msg("multipart upload completed")
 
-	return &CompleteMultipartUploadOutput{
-		Location:  fmt.Sprintf("/%s/%s", input.BucketName, input.Key),
-		Bucket:    input.BucketName,
-		Key:       input.Key,
-		ETag:      compositeETag,
-		VersionID: obj.GetVersionIDString(),
-	}, nil
+	output := &CompleteMultipartUploadOutput{
+		Location: fmt.Sprintf("/%s/%s", bucket.Name, upload.Key),
+		Bucket:   bucket.Name,
+		Key:      upload.Key,
+		ETag:     compositeETag,
+
+		ChecksumAlgorithm: obj.ChecksumAlgorithm,
+		Checksum:          obj.Checksum,
+	}
+	if staged != nil {
+		output.StagingID = staged.ID.String()
+	} else {
+		output.VersionID = obj.GetVersionIDString()
+		output.Sequence = obj.Sequence
+	}
+	return output, nil
 }
 
 // AbortMultipartUpload aborts a multipart upload and cleans up parts.
-func (s *MultipartService) AbortMultipartUpload(ctx context.Context, input AbortMultipartUploadInput) error {
+func (s *MultipartService) AbortMultipartUpload(ctx context.Context, input AbortMultipartUploadInput) (err error) {
+	ctx, op := startOperation(ctx, s.metrics, "abort_multipart_upload")
+	defer func() { op.end(0, err) }()
+
 	// Parse upload ID
 	uploadID, err := uuid.Parse(input.UploadID)
 	if err != nil {
@@ -528,25 +747,15 @@ func (s *MultipartService) AbortMultipartUpload(ctx context.Context, input Abort
 		return domain.ErrMultipartUploadNotFound
 	}
 
-	// Get all parts to decrement blob ref counts
-	partsResult, err := s.multipartRepo.ListParts(ctx, uploadID, repository.PartListOptions{MaxParts: 10000})
-	if err == nil {
-		for _, part := range partsResult.Parts {
-			// Get full part info to get content hash
-			fullPart, err := s.multipartRepo.GetPart(ctx, uploadID, part.PartNumber)
-			if err == nil {
-				_, _ = s.blobRepo.DecrementRef(ctx, fullPart.ContentHash)
-			}
+	if _, err := s.abortUpload(ctx, uploadID); err != nil {
+		if errors.Is(err, domain.ErrMultipartUploadNotFound) {
+			return err
 		}
-	}
-
-	// Delete multipart upload (cascades to parts)
-	if err := s.multipartRepo.Delete(ctx, uploadID); err != nil {
-		s.logger.Error().Err(err).Str("upload_id", input.UploadID).Msg("failed to delete multipart upload")
+		s.logger.Error().Ctx(ctx).Err(err).Str("upload_id", input.UploadID).Msg("failed to delete multipart upload")
 		return fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 
-	s.logger.Info().
+	s.logger.Info().Ctx(ctx).
 		Str("bucket", input.BucketName).
 		Str("key", input.Key).
 		Str("upload_id", input.UploadID).
@@ -555,8 +764,139 @@ func (s *MultipartService) AbortMultipartUpload(ctx context.Context, input Abort
 	return nil
 }
 
+// recordPart registers the blob of a stored part and the part record
+// atomically.
+func (s *MultipartService) recordPart(ctx context.Context, part *domain.UploadPart, storagePath string) error {
+	err := s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		if _, err := s.blobRepo.UpsertWithRefIncrement(ctx, part.ContentHash, part.Size, storagePath); err != nil {
+			return fmt.Errorf("failed to upsert blob: %w", err)
+		}
+		if err := s.multipartRepo.CreatePart(ctx, part); err != nil {
+			return fmt.Errorf("failed to create part record: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Error().Ctx(ctx).Err(err).Int("part", part.PartNumber).Str("content_hash", part.ContentHash).Msg("failed to record part")
+		return fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	return nil
+}
+
+// getInProgressUpload returns an in-progress upload of key in the named
+// bucket, and the bucket.
+func (s *MultipartService) getInProgressUpload(ctx context.Context, bucketName, key, uploadIDStr string, ownerID int64) (*domain.Bucket, *domain.MultipartUpload, error) {
+	// Parse upload ID
+	uploadID, err := uuid.Parse(uploadIDStr)
+	if err != nil {
+		return nil, nil, domain.ErrMultipartUploadNotFound
+	}
+
+	// Get bucket
+	bucket, err := s.bucketRepo.GetByName(ctx, bucketName)
+	if err != nil {
+		if errors.Is(err, domain.ErrBucketNotFound) {
+			return nil, nil, domain.ErrBucketNotFound
+		}
+		return nil, nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	// Check ownership
+	if ownerID > 0 && bucket.OwnerID != ownerID {
+		return nil, nil, ErrBucketAccessDenied
+	}
+
+	// Get multipart upload
+	upload, err := s.multipartRepo.GetByID(ctx, uploadID)
+	if err != nil {
+		if errors.Is(err, domain.ErrMultipartUploadNotFound) {
+			return nil, nil, domain.ErrMultipartUploadNotFound
+		}
+		return nil, nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	// Verify upload is for the correct bucket and key
+	if upload.BucketID != bucket.ID || upload.Key != key {
+		return nil, nil, domain.ErrMultipartUploadNotFound
+	}
+
+	// Check upload status
+	if upload.Status != domain.MultipartStatusInProgress {
+		if upload.Status == domain.MultipartStatusCompleted {
+			return nil, nil, domain.ErrMultipartUploadCompleted
+		}
+		return nil, nil, domain.ErrMultipartUploadAborted
+	}
+
+	return bucket, upload, nil
+}
+
+// abortUpload releases the part blob references of an in-progress upload
+// and deletes it together. Returns the number of parts released.
+func (s *MultipartService) abortUpload(ctx context.Context, uploadID uuid.UUID) (int, error) {
+	released := 0
+	err := s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		// A completed upload's parts belong to its object now
+		upload, err := s.multipartRepo.GetByID(ctx, uploadID)
+		if err != nil {
+			return err
+		}
+		if upload.Status != domain.MultipartStatusInProgress {
+			return domain.ErrMultipartUploadNotFound
+		}
+
+		released, err = s.releaseParts(ctx, uploadID)
+		if err != nil {
+			return err
+		}
+
+		// Delete multipart upload (cascades to parts)
+		return s.multipartRepo.Delete(ctx, uploadID)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return released, nil
+}
+
+// releaseParts releases the blob references held by every part of an
+// upload, including parts left out of its completion, and deletes the part
+// records. It runs in the caller's transaction and returns the number of
+// parts released.
+func (s *MultipartService) releaseParts(ctx context.Context, uploadID uuid.UUID) (int, error) {
+	listed, err := s.multipartRepo.ListParts(ctx, uploadID, repository.PartListOptions{MaxParts: domain.MaxPartNumber})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list parts: %w", err)
+	}
+	if len(listed.Parts) == 0 {
+		return 0, nil
+	}
+
+	partNumbers := make([]int, len(listed.Parts))
+	for i, part := range listed.Parts {
+		partNumbers[i] = part.PartNumber
+	}
+	parts, err := s.multipartRepo.GetPartsForCompletion(ctx, uploadID, partNumbers)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get parts: %w", err)
+	}
+	for _, part := range parts {
+		if err := releaseBlobRef(ctx, s.blobRepo, s.logger, part.ContentHash); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := s.multipartRepo.DeleteParts(ctx, uploadID); err != nil {
+		return 0, fmt.Errorf("failed to delete parts: %w", err)
+	}
+	return len(parts), nil
+}
+
 // ListMultipartUploads lists in-progress multipart uploads.
-func (s *MultipartService) ListMultipartUploads(ctx context.Context, input ListMultipartUploadsInput) (*ListMultipartUploadsOutput, error) {
+func (s *MultipartService) ListMultipartUploads(ctx context.Context, input ListMultipartUploadsInput) (_ *ListMultipartUploadsOutput, err error) {
+	ctx, op := startOperation(ctx, s.metrics, "list_multipart_uploads")
+	defer func() { op.end(0, err) }()
+
 	// Get bucket
 	bucket, err := s.bucketRepo.GetByName(ctx, input.BucketName)
 	if err != nil {
@@ -619,7 +959,10 @@ func (s *MultipartService) ListMultipartUploads(ctx context.Context, input ListM
 }
 
 // ListParts lists parts of a multipart upload.
-func (s *MultipartService) ListParts(ctx context.Context, input ListPartsInput) (*ListPartsOutput, error) {
+func (s *MultipartService) ListParts(ctx context.Context, input ListPartsInput) (_ *ListPartsOutput, err error) {
+	ctx, op := startOperation(ctx, s.metrics, "list_parts")
+	defer func() { op.end(0, err) }()
+
 	// Parse upload ID
 	uploadID, err := uuid.Parse(input.UploadID)
 	if err != nil {
@@ -680,6 +1023,7 @@ func (s *MultipartService) ListParts(ctx context.Context, input ListPartsInput)
 			LastModified: p.LastModified,
 			ETag:         p.ETag,
 			Size:         p.Size,
+			Checksum:     p.Checksum,
 		}
 	}
 
@@ -693,6 +1037,7 @@ func (s *MultipartService) ListParts(ctx context.Context, input ListPartsInput)
 		IsTruncated:          result.IsTruncated,
 		Parts:                parts,
 		StorageClass:         upload.StorageClass,
+		ChecksumAlgorithm:    upload.ChecksumAlgorithm,
 	}, nil
 }
 
@@ -718,40 +1063,3 @@ func calculateCompositeETag(partETags []string) string {
 	}
 	return fmt.Sprintf("\"%s-%d\"", hex.EncodeToString(h.Sum(nil)), len(partETags))
 }
-
-// concatenateParts concatenates multiple part blobs into a single new blob.
-// It reads each part sequentially and writes them to a new combined blob.
-// Returns the content hash of the combined blob.
-func (s *MultipartService) concatenateParts(ctx context.Context, contentHashes []string, totalSize int64) (string, error) {
-	// Create a multi-reader that streams all parts sequentially
-	readers := make([]io.Reader, 0, len(contentHashes))
-	closers := make([]io.Closer, 0, len(contentHashes))
-
-	// Ensure all readers are closed on exit
-	defer func() {
-		for _, c := range closers {
-			_ = c.Close()
-		}
-	}()
-
-	// Open readers for each part
-	for _, hash := range contentHashes {
-		reader, err := s.storage.Retrieve(ctx, hash)
-		if err != nil {
-			return "", fmt.Errorf("failed to retrieve part %s: %w", hash, err)
-		}
-		readers = append(readers, reader)
-		closers = append(closers, reader)
-	}
-
-	// Create a multi-reader that concatenates all parts
-	multiReader := io.MultiReader(readers...)
-
-	// Store the concatenated content
-	contentHash, err := s.storage.Store(ctx, multiReader, totalSize)
-	if err != nil {
-		return "", fmt.Errorf("failed to store concatenated blob: %w", err)
-	}
-
-	return contentHash, nil
-}
@@ -4,7 +4,14 @@ package service
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"path/filepath"
+	"strings"
 	"testing"
+	"testing/iotest"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,6 +22,9 @@ import (
 	"github.com/prn-tf/alexander-storage/internal/domain"
 	"github.com/prn-tf/alexander-storage/internal/lock"
 	"github.com/prn-tf/alexander-storage/internal/repository"
+	"github.com/prn-tf/alexander-storage/internal/repository/sqlite"
+	"github.com/prn-tf/alexander-storage/internal/storage"
+	"github.com/prn-tf/alexander-storage/internal/storage/filesystem"
 )
 
 // =============================================================================
@@ -64,11 +74,27 @@ func (m *mockMultipartRepository) Delete(ctx context.Context, uploadID uuid.UUID
 	return args.Error(0)
 }
 
-func (m *mockMultipartRepository) DeleteExpired(ctx context.Context) (int64, error) {
-	args := m.Called(ctx)
+func (m *mockMultipartRepository) ListExpired(ctx context.Context, before time.Time, limit int) ([]*domain.MultipartUpload, error) {
+	args := m.Called(ctx, before, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.MultipartUpload), args.Error(1)
+}
+
+func (m *mockMultipartRepository) CountInProgress(ctx context.Context, bucketID int64) (int64, error) {
+	args := m.Called(ctx, bucketID)
 	return args.Get(0).(int64), args.Error(1)
 }
 
+func (m *mockMultipartRepository) GetStats(ctx context.Context) (*domain.MultipartStats, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.MultipartStats), args.Error(1)
+}
+
 func (m *mockMultipartRepository) CreatePart(ctx context.Context, part *domain.UploadPart) error {
 	args := m.Called(ctx, part)
 	return args.Error(0)
@@ -103,6 +129,19 @@ func (m *mockMultipartRepository) GetPartsForCompletion(ctx context.Context, upl
 	return args.Get(0).([]*domain.UploadPart), args.Error(1)
 }
 
+func (m *mockMultipartRepository) GetPartsByContentHash(ctx context.Context, uploadID uuid.UUID, contentHashes []string) ([]*domain.UploadPart, error) {
+	args := m.Called(ctx, uploadID, contentHashes)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.UploadPart), args.Error(1)
+}
+
+func (m *mockMultipartRepository) LastPartNumber(ctx context.Context, uploadID uuid.UUID) (int, error) {
+	args := m.Called(ctx, uploadID)
+	return args.Int(0), args.Error(1)
+}
+
 // =============================================================================
 // Helper Functions
 // =============================================================================
@@ -116,7 +155,7 @@ func newTestMultipartService(t *testing.T) (*MultipartService, *mockMultipartRep
 	locker := lock.NewNoOpLocker()
 
 	logger := zerolog.Nop()
-	svc := NewMultipartService(multipartRepo, objectRepo, blobRepo, bucketRepo, storage, locker, logger)
+	svc := NewMultipartService(multipartRepo, objectRepo, blobRepo, bucketRepo, nil, nil, nil, nil, nil, &mockTxManager{}, storage, locker, nil, logger)
 
 	return svc, multipartRepo, objectRepo, blobRepo, bucketRepo, storage
 }
@@ -266,6 +305,263 @@ func TestMultipartService_UploadPart_UploadNotFound(t *testing.T) {
 	require.ErrorIs(t, err, domain.ErrMultipartUploadNotFound)
 }
 
+// newSQLiteMultipartService returns a MultipartService backed by SQLite and
+// the filesystem, with one bucket named "uploads".
+func newSQLiteMultipartService(t *testing.T) (*MultipartService, *filesystem.Storage) {
+	t.Helper()
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	db, err := sqlite.NewDB(ctx, sqlite.DefaultConfig(filepath.Join(dir, "alexander.db")), zerolog.Nop())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, db.Migrate(ctx))
+
+	store, err := filesystem.NewStorage(filesystem.Config{
+		DataDir: filepath.Join(dir, "blobs"),
+		TempDir: filepath.Join(dir, "tmp"),
+	}, zerolog.Nop())
+	require.NoError(t, err)
+
+	user := domain.NewUser("owner", "owner@example.com", "hash")
+	require.NoError(t, sqlite.NewUserRepository(db).Create(ctx, user))
+	bucketRepo := sqlite.NewBucketRepository(db)
+	require.NoError(t, bucketRepo.Create(ctx, domain.NewBucket(user.ID, "uploads")))
+
+	svc := NewMultipartService(
+		sqlite.NewMultipartRepository(db),
+		sqlite.NewObjectRepository(db),
+		sqlite.NewBlobRepository(db),
+		bucketRepo,
+		sqlite.NewEventRepository(db),
+		sqlite.NewReplicationRepository(db),
+		sqlite.NewLifecycleRepository(db),
+		sqlite.NewStagedObjectRepository(db),
+		nil,
+		sqlite.NewTxManager(db),
+		store,
+		lock.NewMemoryLocker(),
+		nil,
+		zerolog.Nop(),
+	)
+	// The tests upload parts of a few bytes
+	limits := DefaultMultipartLimits()
+	limits.MinPartSize = 1
+	svc.SetLimits(limits)
+	return svc, store
+}
+
+func TestMultipartService_CompleteMultipartUpload_ReleasesParts(t *testing.T) {
+	ctx := context.Background()
+	svc, store := newSQLiteMultipartService(t)
+
+	upload := func(key string, parts ...string) *domain.Object {
+		initiated, err := svc.InitiateMultipartUpload(ctx, InitiateMultipartUploadInput{BucketName: "uploads", Key: key})
+		require.NoError(t, err)
+
+		completed := make([]domain.CompletedPart, len(parts))
+		for i, content := range parts {
+			out, err := svc.UploadPart(ctx, UploadPartInput{
+				BucketName: "uploads",
+				Key:        key,
+				UploadID:   initiated.UploadID,
+				PartNumber: i + 1,
+				Body:       bytes.NewReader([]byte(content)),
+				Size:       int64(len(content)),
+			})
+			require.NoError(t, err)
+			completed[i] = domain.CompletedPart{PartNumber: i + 1, ETag: out.ETag}
+		}
+		_, err = svc.CompleteMultipartUpload(ctx, CompleteMultipartUploadInput{
+			BucketName: "uploads",
+			Key:        key,
+			UploadID:   initiated.UploadID,
+			Parts:      completed,
+		})
+		require.NoError(t, err)
+
+		obj, err := svc.objectRepo.GetByKey(ctx, 1, key)
+		require.NoError(t, err)
+		reader, err := store.Retrieve(ctx, *obj.ContentHash)
+		require.NoError(t, err)
+		data, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		require.NoError(t, reader.Close())
+		require.Equal(t, strings.Join(parts, ""), string(data))
+		return obj
+	}
+	countBlobs := func() int {
+		n := 0
+		require.NoError(t, store.Walk(ctx, func(storage.BlobInfo) error {
+			n++
+			return nil
+		}))
+		return n
+	}
+
+	refCount := func(content string) int32 {
+		sum := sha256.Sum256([]byte(content))
+		refs, err := svc.blobRepo.GetRefCount(ctx, hex.EncodeToString(sum[:]))
+		require.NoError(t, err)
+		return refs
+	}
+
+	// A single part becomes the object's blob without being copied, and
+	// the part's reference passes to the object
+	upload("single", "only part")
+	require.Equal(t, 1, countBlobs())
+	require.Equal(t, int32(1), refCount("only part"))
+
+	// Identical parts are stored once, and the combined blob once more.
+	// Only the object references anything once the upload completes
+	upload("repeated", "abc", "abc", "def")
+	require.Equal(t, 4, countBlobs())
+	require.Equal(t, int32(0), refCount("abc"))
+	require.Equal(t, int32(0), refCount("def"))
+	require.Equal(t, int32(1), refCount("abcabcdef"))
+
+	// Completing the same content again reuses the combined blob
+	upload("again", "abc", "abc", "def")
+	require.Equal(t, 4, countBlobs())
+	require.Equal(t, int32(2), refCount("abcabcdef"))
+}
+
+func TestMultipartService_PartLimits(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newSQLiteMultipartService(t)
+	svc.SetLimits(MultipartLimits{MinPartSize: 4, MaxPartSize: 8, MaxParts: 2})
+
+	initiated, err := svc.InitiateMultipartUpload(ctx, InitiateMultipartUploadInput{BucketName: "uploads", Key: "parts"})
+	require.NoError(t, err)
+	upload := func(partNumber int, content string) (domain.CompletedPart, error) {
+		out, err := svc.UploadPart(ctx, UploadPartInput{
+			BucketName: "uploads",
+			Key:        "parts",
+			UploadID:   initiated.UploadID,
+			PartNumber: partNumber,
+			Body:       strings.NewReader(content),
+			Size:       int64(len(content)),
+		})
+		if err != nil {
+			return domain.CompletedPart{}, err
+		}
+		return domain.CompletedPart{PartNumber: partNumber, ETag: out.ETag}, nil
+	}
+	complete := func(parts ...domain.CompletedPart) error {
+		_, err := svc.CompleteMultipartUpload(ctx, CompleteMultipartUploadInput{
+			BucketName: "uploads",
+			Key:        "parts",
+			UploadID:   initiated.UploadID,
+			Parts:      parts,
+		})
+		return err
+	}
+
+	_, err = upload(1, "too large")
+	require.ErrorIs(t, err, domain.ErrPartTooLarge)
+
+	small, err := upload(1, "abc")
+	require.NoError(t, err)
+	large, err := upload(2, "abcd")
+	require.NoError(t, err)
+	last, err := upload(3, "a")
+	require.NoError(t, err)
+
+	err = complete(small, large, last)
+	require.ErrorIs(t, err, domain.ErrTooManyParts)
+
+	// Only the parts before the last must reach the minimum size
+	err = complete(small, large)
+	require.ErrorIs(t, err, domain.ErrPartTooSmall)
+	require.ErrorContains(t, err, "part 1 is 3 bytes")
+
+	require.NoError(t, complete(large, last))
+}
+
+func TestMultipartService_ResumableChunks(t *testing.T) {
+	ctx := context.Background()
+	svc, store := newSQLiteMultipartService(t)
+
+	initiated, err := svc.InitiateMultipartUpload(ctx, InitiateMultipartUploadInput{BucketName: "uploads", Key: "resumed"})
+	require.NoError(t, err)
+	hashOf := func(content string) string {
+		sum := sha256.Sum256([]byte(content))
+		return hex.EncodeToString(sum[:])
+	}
+	stage := func(content, hash string) (*StageChunkOutput, error) {
+		return svc.StageChunk(ctx, StageChunkInput{
+			BucketName:  "uploads",
+			Key:         "resumed",
+			UploadID:    initiated.UploadID,
+			ContentHash: hash,
+			Body:        strings.NewReader(content),
+			Size:        int64(len(content)),
+		})
+	}
+	commit := func(chunks ...string) (*CompleteMultipartUploadOutput, error) {
+		return svc.CommitChunks(ctx, CommitChunksInput{
+			BucketName: "uploads",
+			Key:        "resumed",
+			UploadID:   initiated.UploadID,
+			Chunks:     chunks,
+		})
+	}
+
+	_, err = stage("abc", "ABC")
+	require.ErrorIs(t, err, domain.ErrInvalidChunkHash)
+	_, err = stage("abc", hashOf("abd"))
+	require.ErrorIs(t, err, domain.ErrChecksumMismatch)
+
+	// Chunks get the next part numbers, and staging one again reads nothing
+	first, err := stage("abc", hashOf("abc"))
+	require.NoError(t, err)
+	require.Equal(t, 1, first.PartNumber)
+	require.Equal(t, calculatePartETag(hashOf("abc")), first.ETag)
+	again, err := svc.StageChunk(ctx, StageChunkInput{
+		BucketName:  "uploads",
+		Key:         "resumed",
+		UploadID:    initiated.UploadID,
+		ContentHash: hashOf("abc"),
+		Body:        iotest.ErrReader(io.ErrUnexpectedEOF),
+		Size:        3,
+	})
+	require.NoError(t, err)
+	require.True(t, again.AlreadyStaged)
+	require.Equal(t, 1, again.PartNumber)
+
+	_, err = commit(hashOf("abc"), hashOf("def"))
+	require.ErrorIs(t, err, domain.ErrPartNotFound)
+
+	second, err := stage("def", hashOf("def"))
+	require.NoError(t, err)
+	require.Equal(t, 2, second.PartNumber)
+
+	// Chunks are committed in the order listed, and may repeat
+	out, err := commit(hashOf("def"), hashOf("abc"), hashOf("def"))
+	require.NoError(t, err)
+	require.Equal(t, calculateCompositeETag([]string{second.ETag, first.ETag, second.ETag}), out.ETag)
+
+	obj, err := svc.objectRepo.GetByKey(ctx, 1, "resumed")
+	require.NoError(t, err)
+	require.Equal(t, []int64{3, 3, 3}, obj.PartSizes)
+	reader, err := store.Retrieve(ctx, *obj.ContentHash)
+	require.NoError(t, err)
+	data, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.NoError(t, reader.Close())
+	require.Equal(t, "defabcdef", string(data))
+
+	// Only the object references a blob once the upload is committed
+	for _, content := range []string{"abc", "def"} {
+		refs, err := svc.blobRepo.GetRefCount(ctx, hashOf(content))
+		require.NoError(t, err)
+		require.Zero(t, refs, content)
+	}
+
+	_, err = stage("ghi", hashOf("ghi"))
+	require.ErrorIs(t, err, domain.ErrMultipartUploadCompleted)
+}
+
 // =============================================================================
 // AbortMultipartUpload Tests
 // =============================================================================
@@ -293,11 +589,12 @@ func TestMultipartService_AbortMultipartUpload_Success(t *testing.T) {
 		},
 		IsTruncated: false,
 	}, nil)
-	multipartRepo.On("GetPart", mock.Anything, uploadID, 1).Return(&domain.UploadPart{
+	multipartRepo.On("GetPartsForCompletion", mock.Anything, uploadID, []int{1}).Return([]*domain.UploadPart{{
 		UploadID:    uploadID,
 		PartNumber:  1,
 		ContentHash: "abc123hash",
-	}, nil)
+	}}, nil)
+	multipartRepo.On("DeleteParts", mock.Anything, uploadID).Return(nil)
 	blobRepo.On("DecrementRef", mock.Anything, "abc123hash").Return(int32(0), nil)
 	multipartRepo.On("Delete", mock.Anything, uploadID).Return(nil)
 
@@ -342,6 +639,51 @@ func TestMultipartService_AbortMultipartUpload_InvalidUploadID(t *testing.T) {
 	require.ErrorIs(t, err, domain.ErrMultipartUploadNotFound)
 }
 
+func TestMultipartReaper_AbortsExpiredUploads(t *testing.T) {
+	svc, multipartRepo, _, blobRepo, _, _ := newTestMultipartService(t)
+	expired := &domain.MultipartUpload{
+		ID:        uuid.New(),
+		BucketID:  1,
+		Key:       "abandoned.bin",
+		Status:    domain.MultipartStatusInProgress,
+		ExpiresAt: time.Now().Add(-time.Hour),
+	}
+	// Completed between listing and aborting
+	completed := &domain.MultipartUpload{
+		ID:        uuid.New(),
+		BucketID:  1,
+		Key:       "finished.bin",
+		Status:    domain.MultipartStatusCompleted,
+		ExpiresAt: time.Now().Add(-time.Hour),
+	}
+
+	multipartRepo.On("ListExpired", mock.Anything, mock.AnythingOfType("time.Time"), 100).
+		Return([]*domain.MultipartUpload{expired, completed}, nil).Once()
+	multipartRepo.On("GetByID", mock.Anything, expired.ID).Return(expired, nil)
+	multipartRepo.On("GetByID", mock.Anything, completed.ID).Return(completed, nil)
+	multipartRepo.On("ListParts", mock.Anything, expired.ID, mock.AnythingOfType("repository.PartListOptions")).Return(&repository.PartListResult{
+		Parts: []*domain.PartInfo{{PartNumber: 1}, {PartNumber: 2}},
+	}, nil)
+	multipartRepo.On("GetPartsForCompletion", mock.Anything, expired.ID, []int{1, 2}).Return([]*domain.UploadPart{
+		{PartNumber: 1, ContentHash: "hash1"},
+		{PartNumber: 2, ContentHash: "hash2"},
+	}, nil)
+	multipartRepo.On("DeleteParts", mock.Anything, expired.ID).Return(nil).Once()
+	blobRepo.On("DecrementRef", mock.Anything, "hash1").Return(int32(0), nil).Once()
+	blobRepo.On("DecrementRef", mock.Anything, "hash2").Return(int32(0), nil).Once()
+	multipartRepo.On("Delete", mock.Anything, expired.ID).Return(nil).Once()
+
+	reaper := NewMultipartReaper(svc, lock.NewNoOpLocker(), nil, zerolog.Nop(), DefaultMultipartReaperConfig())
+	result := reaper.RunOnce(context.Background())
+
+	require.Equal(t, 1, result.Uploads)
+	require.Equal(t, 2, result.Parts)
+	require.Zero(t, result.Errors)
+	multipartRepo.AssertExpectations(t)
+	blobRepo.AssertExpectations(t)
+	multipartRepo.AssertNotCalled(t, "Delete", mock.Anything, completed.ID)
+}
+
 // =============================================================================
 // ListMultipartUploads Tests
 // =============================================================================
@@ -467,3 +809,131 @@ func TestMultipartService_ListParts_InvalidUploadID(t *testing.T) {
 
 	require.ErrorIs(t, err, domain.ErrMultipartUploadNotFound)
 }
+
+func TestMultipartService_CompletePropagatesACL(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newSQLiteMultipartService(t)
+
+	_, err := svc.InitiateMultipartUpload(ctx, InitiateMultipartUploadInput{BucketName: "uploads", Key: "big.iso", ACL: "public-read-write"})
+	require.ErrorIs(t, err, ErrInvalidObjectACL)
+
+	initiated, err := svc.InitiateMultipartUpload(ctx, InitiateMultipartUploadInput{BucketName: "uploads", Key: "big.iso", ACL: domain.ObjectACLPublicRead})
+	require.NoError(t, err)
+	part, err := svc.UploadPart(ctx, UploadPartInput{
+		BucketName: "uploads",
+		Key:        "big.iso",
+		UploadID:   initiated.UploadID,
+		PartNumber: 1,
+		Body:       bytes.NewReader([]byte("part")),
+		Size:       4,
+	})
+	require.NoError(t, err)
+	_, err = svc.CompleteMultipartUpload(ctx, CompleteMultipartUploadInput{
+		BucketName: "uploads",
+		Key:        "big.iso",
+		UploadID:   initiated.UploadID,
+		Parts:      []domain.CompletedPart{{PartNumber: 1, ETag: part.ETag}},
+	})
+	require.NoError(t, err)
+
+	obj, err := svc.objectRepo.GetByKey(ctx, 1, "big.iso")
+	require.NoError(t, err)
+	require.Equal(t, domain.ObjectACLPublicRead, obj.ACL)
+}
+
+func TestMultipartService_CompositeChecksum(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newSQLiteMultipartService(t)
+
+	initiated, err := svc.InitiateMultipartUpload(ctx, InitiateMultipartUploadInput{BucketName: "uploads", Key: "big.iso", ChecksumAlgorithm: "sha256"})
+	require.NoError(t, err)
+	require.Equal(t, domain.ChecksumSHA256, initiated.ChecksumAlgorithm)
+
+	upload := func(partNumber int, content string, algorithm domain.ChecksumAlgorithm, checksum string) (*UploadPartOutput, error) {
+		return svc.UploadPart(ctx, UploadPartInput{
+			BucketName:        "uploads",
+			Key:               "big.iso",
+			UploadID:          initiated.UploadID,
+			PartNumber:        partNumber,
+			Body:              strings.NewReader(content),
+			Size:              int64(len(content)),
+			ChecksumAlgorithm: algorithm,
+			Checksum:          checksum,
+		})
+	}
+
+	sums := make([]string, 2)
+	composite := sha256.New()
+	for i, content := range []string{"first", "second"} {
+		sum := sha256.Sum256([]byte(content))
+		sums[i] = base64.StdEncoding.EncodeToString(sum[:])
+		composite.Write(sum[:])
+	}
+
+	_, err = upload(1, "first", domain.ChecksumCRC32, "")
+	require.ErrorIs(t, err, domain.ErrInvalidChecksum, "parts use the upload's algorithm")
+	_, err = upload(1, "first", domain.ChecksumSHA256, sums[1])
+	require.ErrorIs(t, err, domain.ErrChecksumMismatch)
+
+	first, err := upload(1, "first", domain.ChecksumSHA256, sums[0])
+	require.NoError(t, err)
+	require.Equal(t, sums[0], first.Checksum)
+	second, err := upload(2, "second", "", "")
+	require.NoError(t, err)
+	require.Equal(t, sums[1], second.Checksum, "the checksum is computed without one sent")
+
+	listed, err := svc.ListParts(ctx, ListPartsInput{BucketName: "uploads", Key: "big.iso", UploadID: initiated.UploadID})
+	require.NoError(t, err)
+	require.Equal(t, domain.ChecksumSHA256, listed.ChecksumAlgorithm)
+	require.Equal(t, sums[1], listed.Parts[1].Checksum)
+
+	parts := []domain.CompletedPart{
+		{PartNumber: 1, ETag: first.ETag, Checksum: sums[1]},
+		{PartNumber: 2, ETag: second.ETag},
+	}
+	_, err = svc.CompleteMultipartUpload(ctx, CompleteMultipartUploadInput{BucketName: "uploads", Key: "big.iso", UploadID: initiated.UploadID, Parts: parts})
+	require.ErrorIs(t, err, domain.ErrPartChecksumMismatch)
+
+	parts[0].Checksum = sums[0]
+	completed, err := svc.CompleteMultipartUpload(ctx, CompleteMultipartUploadInput{BucketName: "uploads", Key: "big.iso", UploadID: initiated.UploadID, Parts: parts})
+	require.NoError(t, err)
+	want := base64.StdEncoding.EncodeToString(composite.Sum(nil)) + "-2"
+	require.Equal(t, want, completed.Checksum)
+
+	obj, err := svc.objectRepo.GetByKey(ctx, 1, "big.iso")
+	require.NoError(t, err)
+	require.Equal(t, domain.ChecksumSHA256, obj.ChecksumAlgorithm)
+	require.Equal(t, want, obj.Checksum)
+}
+
+func TestMultipartService_InitiateMultipartUpload_AbortRule(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newSQLiteMultipartService(t)
+
+	initiated, err := svc.InitiateMultipartUpload(ctx, InitiateMultipartUploadInput{BucketName: "uploads", Key: "tmp/a"})
+	require.NoError(t, err)
+	require.Nil(t, initiated.AbortDate)
+
+	days := 2
+	rule := domain.NewLifecycleRule(1, "abort-tmp")
+	rule.Prefix = "tmp/"
+	rule.AbortIncompleteUploadDays = &days
+	require.NoError(t, svc.lifeRepo.Create(ctx, rule))
+
+	// Uploads under the rule's prefix expire at its abort date
+	initiated, err = svc.InitiateMultipartUpload(ctx, InitiateMultipartUploadInput{BucketName: "uploads", Key: "tmp/b"})
+	require.NoError(t, err)
+	require.NotNil(t, initiated.AbortDate)
+	require.Equal(t, "abort-tmp", initiated.AbortRuleID)
+
+	uploadID, err := uuid.Parse(initiated.UploadID)
+	require.NoError(t, err)
+	upload, err := svc.multipartRepo.GetByID(ctx, uploadID)
+	require.NoError(t, err)
+	require.Equal(t, upload.InitiatedAt.AddDate(0, 0, days).Unix(), upload.ExpiresAt.Unix())
+	require.Equal(t, upload.ExpiresAt.Unix(), initiated.AbortDate.Unix())
+
+	initiated, err = svc.InitiateMultipartUpload(ctx, InitiateMultipartUploadInput{BucketName: "uploads", Key: "keep/c"})
+	require.NoError(t, err)
+	require.Nil(t, initiated.AbortDate)
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+	"github.com/prn-tf/alexander-storage/internal/tiering"
+)
+
+// ObjectRestorer copies blobs that were moved to an archive tier back to the
+// hot tier for a number of days. tiering.TieringController implements it.
+type ObjectRestorer interface {
+	// Restore queues a restore of the blob, or moves the expiry of one that
+	// is already restored.
+	Restore(ctx context.Context, contentHash string, days int) (tiering.RestoreStatus, error)
+
+	// GetRestoreStatus returns the restore of the blob, if any.
+	GetRestoreStatus(contentHash string) (*tiering.RestoreStatus, bool)
+}
+
+// SetRestorer lets RestoreArchivedObject restore objects whose blob was
+// moved to an archive tier, and reports restores on HeadObject and
+// GetObject. Without one, RestoreArchivedObject returns
+// ErrRestoreNotSupported. It must be called before the service handles
+// requests.
+func (s *ObjectService) SetRestorer(restorer ObjectRestorer) {
+	s.restorer = restorer
+}
+
+// RestoreArchivedObjectInput contains the data needed to restore an
+// archived object.
+type RestoreArchivedObjectInput struct {
+	BucketName string
+	Key        string
+	VersionID  string // Optional
+	OwnerID    int64
+	Days       int
+}
+
+// RestoreArchivedObjectOutput contains the result of restoring an archived
+// object.
+type RestoreArchivedObjectOutput struct {
+	Restore tiering.RestoreStatus
+
+	// AlreadyRestored is true if the object was restored before the request,
+	// which only moved the expiry.
+	AlreadyRestored bool
+}
+
+// RestoreArchivedObject queues a copy of an archived object's blob back to
+// the hot tier for input.Days days, after which the tiering controller moves
+// it back to its archive tier. It returns tiering.ErrNotArchived for objects
+// in the hot tier and tiering.ErrRestoreInProgress while a restore of the
+// object is queued.
+func (s *ObjectService) RestoreArchivedObject(ctx context.Context, input RestoreArchivedObjectInput) (_ *RestoreArchivedObjectOutput, err error) {
+	ctx, op := startOperation(ctx, s.metrics, "restore_archived_object")
+	defer func() { op.end(0, err) }()
+
+	if s.restorer == nil {
+		return nil, ErrRestoreNotSupported
+	}
+	if input.Days <= 0 {
+		return nil, tiering.ErrInvalidRestoreDays
+	}
+
+	// Get bucket
+	bucket, err := s.bucketRepo.GetByName(ctx, input.BucketName)
+	if err != nil {
+		if errors.Is(err, domain.ErrBucketNotFound) {
+			return nil, domain.ErrBucketNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	// Check ownership
+	if input.OwnerID > 0 && bucket.OwnerID != input.OwnerID {
+		return nil, ErrBucketAccessDenied
+	}
+
+	// Get object
+	var obj *domain.Object
+	if input.VersionID != "" {
+		versionUUID, parseErr := domain.ParseVersionID(input.VersionID)
+		if parseErr != nil {
+			return nil, parseErr
+		}
+		obj, err = s.objectRepo.GetByKeyAndVersion(ctx, bucket.ID, input.Key, versionUUID)
+	} else {
+		obj, err = s.objectRepo.GetByKey(repository.WithObjectCache(ctx), bucket.ID, input.Key)
+	}
+	if err != nil {
+		if errors.Is(err, domain.ErrObjectNotFound) {
+			return nil, domain.ErrObjectNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	if obj.IsDeleteMarker || obj.ContentHash == nil {
+		return nil, domain.ErrObjectDeleted
+	}
+
+	restore, err := s.restorer.Restore(ctx, *obj.ContentHash, input.Days)
+	if err != nil {
+		return nil, err
+	}
+	// Only a newly queued restore is ongoing
+	alreadyRestored := !restore.Ongoing
+
+	s.logger.Info().
+		Str("bucket", input.BucketName).
+		Str("key", input.Key).
+		Int("days", input.Days).
+		Bool("already_restored", alreadyRestored).
+		Msg("Archived object restore requested")
+
+	return &RestoreArchivedObjectOutput{Restore: restore, AlreadyRestored: alreadyRestored}, nil
+}
+
+// restoreStatus returns the restore of obj's blob, if any.
+func (s *ObjectService) restoreStatus(obj *domain.Object) *tiering.RestoreStatus {
+	if s.restorer == nil || obj.ContentHash == nil {
+		return nil
+	}
+	restore, ok := s.restorer.GetRestoreStatus(*obj.ContentHash)
+	if !ok {
+		return nil
+	}
+	return restore
+}
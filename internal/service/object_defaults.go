@@ -0,0 +1,55 @@
+package service
+
+import (
+	"mime"
+	"path"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+)
+
+// uploadContentType returns the Content-Type of an upload: the one the
+// client sent, else the one the bucket maps the key's extension to, else,
+// if detect is set, a guess from the extension. It is empty if none applies.
+func uploadContentType(bucket *domain.Bucket, key, contentType string, detect bool) string {
+	if contentType != "" {
+		return contentType
+	}
+	if contentType = bucket.ObjectDefaults.ContentTypeFor(key); contentType != "" {
+		return contentType
+	}
+	if detect {
+		return mime.TypeByExtension(path.Ext(key))
+	}
+	return ""
+}
+
+// uploadStorageClass returns the storage class of an upload: the one the
+// client sent, else the bucket's default, else STANDARD.
+func uploadStorageClass(bucket *domain.Bucket, storageClass domain.StorageClass) (domain.StorageClass, error) {
+	if storageClass != "" {
+		if !domain.IsValidStorageClass(string(storageClass)) {
+			return "", domain.ErrInvalidStorageClass
+		}
+		return storageClass, nil
+	}
+	if bucket.ObjectDefaults != nil && bucket.ObjectDefaults.StorageClass != "" {
+		return bucket.ObjectDefaults.StorageClass, nil
+	}
+	return domain.StorageClassStandard, nil
+}
+
+// applyDefaultMetadata adds the bucket's default Cache-Control to the
+// metadata of an upload sent without one, and returns the metadata.
+func applyDefaultMetadata(bucket *domain.Bucket, metadata map[string]string) map[string]string {
+	if bucket.ObjectDefaults == nil || bucket.ObjectDefaults.CacheControl == "" {
+		return metadata
+	}
+	if _, ok := metadata["Cache-Control"]; ok {
+		return metadata
+	}
+	if metadata == nil {
+		metadata = make(map[string]string)
+	}
+	metadata["Cache-Control"] = bucket.ObjectDefaults.CacheControl
+	return metadata
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+)
+
+func TestUploadContentType(t *testing.T) {
+	bucket := &domain.Bucket{ObjectDefaults: &domain.ObjectDefaults{
+		ContentTypes: map[string]string{".html": "text/plain", ".log": "text/plain; charset=utf-8"},
+	}}
+	tests := []struct {
+		name   string
+		bucket *domain.Bucket
+		sent   string
+		key    string
+		detect bool
+		want   string
+	}{
+		{"sent", bucket, "application/json", "app.log", true, "application/json"},
+		{"mapped by bucket", bucket, "", "logs/app.log", false, "text/plain; charset=utf-8"},
+		{"bucket overrides detection", bucket, "", "index.HTML", true, "text/plain"},
+		{"detected", &domain.Bucket{}, "", "site/index.html", true, "text/html; charset=utf-8"},
+		{"detected upper case", &domain.Bucket{}, "", "photo.PNG", true, "image/png"},
+		{"unknown extension", bucket, "", "data.unknownext", true, ""},
+		{"no extension", bucket, "", "README", true, ""},
+		{"detection disabled", &domain.Bucket{}, "", "index.html", false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, uploadContentType(tt.bucket, tt.key, tt.sent, tt.detect))
+		})
+	}
+}
+
+func TestObjectService_PutObjectAppliesBucketDefaults(t *testing.T) {
+	ctx := context.Background()
+	svc := newConsistencyService(t, "assets", domain.VersioningDisabled)
+	buckets := NewBucketService(svc.bucketRepo, nil, zerolog.Nop(), DefaultBucketConfig())
+
+	err := buckets.SetBucketObjectDefaults(ctx, SetBucketObjectDefaultsInput{
+		Name:     "assets",
+		Defaults: &domain.ObjectDefaults{StorageClass: "glacier", ContentTypes: map[string]string{".log": "text/plain"}},
+	})
+	require.ErrorIs(t, err, domain.ErrInvalidObjectDefaults, "storage classes are upper case")
+
+	require.NoError(t, buckets.SetBucketObjectDefaults(ctx, SetBucketObjectDefaultsInput{
+		Name: "assets",
+		Defaults: &domain.ObjectDefaults{
+			CacheControl: "max-age=3600",
+			StorageClass: domain.StorageClassReducedRedundancy,
+			ContentTypes: map[string]string{"LOG": "text/plain"},
+		},
+	}))
+
+	put := func(input PutObjectInput) *HeadObjectOutput {
+		t.Helper()
+		input.BucketName = "assets"
+		input.Body = strings.NewReader("data")
+		input.Size = 4
+		input.OwnerID = consistencyOwnerID
+		_, err := svc.PutObject(ctx, input)
+		require.NoError(t, err)
+		head, err := svc.HeadObject(ctx, HeadObjectInput{BucketName: "assets", Key: input.Key, OwnerID: consistencyOwnerID})
+		require.NoError(t, err)
+		return head
+	}
+
+	// Uploads sent without the settings get the bucket's
+	head := put(PutObjectInput{Key: "app.log"})
+	assert.Equal(t, "text/plain", head.ContentType)
+	assert.Equal(t, domain.StorageClassReducedRedundancy, head.StorageClass)
+	assert.Equal(t, "max-age=3600", head.Metadata["Cache-Control"])
+
+	// ... and keep their own
+	head = put(PutObjectInput{
+		Key:          "app.log",
+		ContentType:  "application/json",
+		StorageClass: domain.StorageClassStandard,
+		Metadata:     map[string]string{"Cache-Control": "no-store"},
+	})
+	assert.Equal(t, "application/json", head.ContentType)
+	assert.Equal(t, domain.StorageClassStandard, head.StorageClass)
+	assert.Equal(t, "no-store", head.Metadata["Cache-Control"])
+
+	head = put(PutObjectInput{Key: "data.bin"})
+	assert.Equal(t, "application/octet-stream", head.ContentType)
+
+	_, err = svc.PutObject(ctx, PutObjectInput{
+		BucketName:   "assets",
+		Key:          "cold.bin",
+		Body:         strings.NewReader("data"),
+		Size:         4,
+		OwnerID:      consistencyOwnerID,
+		StorageClass: "COLD",
+	})
+	require.ErrorIs(t, err, domain.ErrInvalidStorageClass)
+
+	// Without defaults, uploads get the server's
+	require.NoError(t, buckets.SetBucketObjectDefaults(ctx, SetBucketObjectDefaultsInput{Name: "assets"}))
+	bucket, err := svc.bucketRepo.GetByName(ctx, "assets")
+	require.NoError(t, err)
+	assert.Nil(t, bucket.ObjectDefaults)
+	head = put(PutObjectInput{Key: "other.log"})
+	assert.Equal(t, "application/octet-stream", head.ContentType)
+	assert.Equal(t, domain.StorageClassStandard, head.StorageClass)
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/lock"
+	"github.com/prn-tf/alexander-storage/internal/metrics"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// ObjectPurger periodically removes object versions that were soft-deleted
+// longer ago than the retention window. Their blob references were
+// released when they were deleted, so purging only removes the rows.
+type ObjectPurger struct {
+	objectRepo repository.ObjectRepository
+	locker     lock.Locker
+	metrics    *metrics.Metrics
+	logger     zerolog.Logger
+	config     ObjectPurgeConfig
+
+	// Control
+	mu       sync.Mutex
+	running  bool
+	stopChan chan struct{}
+	doneChan chan struct{}
+}
+
+// ObjectPurgeConfig contains object purge configuration.
+type ObjectPurgeConfig struct {
+	// Interval is how often to purge.
+	Interval time.Duration
+
+	// Retention is how long soft-deleted versions are kept.
+	Retention time.Duration
+
+	// BatchSize is the number of versions removed at a time.
+	BatchSize int
+}
+
+// DefaultObjectPurgeConfig returns sensible defaults.
+func DefaultObjectPurgeConfig() ObjectPurgeConfig {
+	return ObjectPurgeConfig{
+		Interval:  6 * time.Hour,
+		Retention: 7 * 24 * time.Hour,
+		BatchSize: 1000,
+	}
+}
+
+// NewObjectPurger creates a new object purger.
+func NewObjectPurger(
+	objectRepo repository.ObjectRepository,
+	locker lock.Locker,
+	m *metrics.Metrics,
+	logger zerolog.Logger,
+	config ObjectPurgeConfig,
+) *ObjectPurger {
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultObjectPurgeConfig().BatchSize
+	}
+	return &ObjectPurger{
+		objectRepo: objectRepo,
+		locker:     locker,
+		metrics:    m,
+		logger:     logger.With().Str("service", "object-purge").Logger(),
+		config:     config,
+		stopChan:   make(chan struct{}),
+		doneChan:   make(chan struct{}),
+	}
+}
+
+// Start begins the purge scheduler.
+func (p *ObjectPurger) Start() {
+	p.mu.Lock()
+	if p.running {
+		p.mu.Unlock()
+		return
+	}
+	p.running = true
+	p.mu.Unlock()
+
+	p.logger.Info().
+		Dur("interval", p.config.Interval).
+		Dur("retention", p.config.Retention).
+		Msg("Starting object purger")
+
+	go p.runLoop()
+}
+
+// Stop stops the purge scheduler, interrupting a run in progress.
+func (p *ObjectPurger) Stop() {
+	p.mu.Lock()
+	if !p.running {
+		p.mu.Unlock()
+		return
+	}
+	p.running = false
+	p.mu.Unlock()
+
+	close(p.stopChan)
+	<-p.doneChan
+
+	p.logger.Info().Msg("Object purger stopped")
+}
+
+// runLoop is the main purge loop.
+func (p *ObjectPurger) runLoop() {
+	defer close(p.doneChan)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-p.stopChan
+		cancel()
+	}()
+
+	ticker := time.NewTicker(p.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		p.RunOnce(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-p.stopChan:
+			return
+		}
+	}
+}
+
+// ObjectPurgeResult contains the result of a purge run.
+type ObjectPurgeResult struct {
+	// Purged is the number of soft-deleted versions removed.
+	Purged int64 `json:"purged"`
+
+	// Errors is one if the run stopped early.
+	Errors int `json:"errors"`
+
+	// Duration is how long the run took.
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// RunOnce removes every version soft-deleted before the retention window.
+// This can be called manually or by the scheduler.
+func (p *ObjectPurger) RunOnce(ctx context.Context) ObjectPurgeResult {
+	start := time.Now()
+	result := ObjectPurgeResult{}
+
+	lockKey := lock.Keys.ObjectPurge()
+	lockTTL := p.config.Interval / 2
+	if lockTTL < 5*time.Minute {
+		lockTTL = 5 * time.Minute
+	}
+
+	acquired, err := p.locker.Acquire(ctx, lockKey, lockTTL)
+	if err != nil {
+		p.logger.Error().Err(err).Msg("Failed to acquire object purge lock")
+		result.Errors++
+		result.Duration = time.Since(start)
+		return result
+	}
+	if !acquired {
+		p.logger.Debug().Msg("Object purge lock held by another process, skipping run")
+		result.Duration = time.Since(start)
+		return result
+	}
+	defer func() {
+		if _, err := p.locker.Release(context.Background(), lockKey); err != nil {
+			p.logger.Error().Err(err).Msg("Failed to release object purge lock")
+		}
+	}()
+
+	// Purged versions are gone from the next batch, so batches end once
+	// none are left before the cutoff
+	cutoff := start.Add(-p.config.Retention)
+	for ctx.Err() == nil {
+		purged, err := p.objectRepo.PurgeDeleted(ctx, cutoff, p.config.BatchSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				p.logger.Error().Err(err).Msg("Failed to purge deleted objects")
+				result.Errors++
+			}
+			break
+		}
+		result.Purged += purged
+		if purged < int64(p.config.BatchSize) {
+			break
+		}
+	}
+
+	result.Duration = time.Since(start)
+
+	if p.metrics != nil {
+		p.metrics.RecordObjectPurge(result.Purged)
+	}
+
+	if result.Purged == 0 && result.Errors == 0 {
+		p.logger.Debug().Msg("No deleted objects to purge")
+		return result
+	}
+
+	p.logger.Info().
+		Int64("purged", result.Purged).
+		Int("errors", result.Errors).
+		Time("cutoff", cutoff).
+		Dur("duration", result.Duration).
+		Msg("Object purge run completed")
+
+	return result
+}
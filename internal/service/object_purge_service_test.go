@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/lock"
+)
+
+func TestObjectPurger_RemovesDeletedVersionsPastRetention(t *testing.T) {
+	ctx := context.Background()
+	svc := newConsistencyService(t, "photos", domain.VersioningEnabled)
+	bucket, err := svc.bucketRepo.GetByName(ctx, "photos")
+	require.NoError(t, err)
+
+	// Two versions of one key share a blob with the other key
+	for _, put := range []struct{ key, content string }{{"a.jpg", "v1"}, {"a.jpg", "v2"}, {"b.jpg", "v2"}} {
+		_, err := putString(ctx, svc, "photos", put.key, put.content, nil)
+		require.NoError(t, err)
+	}
+
+	err = svc.txManager.WithTx(ctx, func(ctx context.Context) error {
+		hashes, err := svc.objectRepo.DeleteAllVersions(ctx, bucket.ID, "a.jpg")
+		if err != nil {
+			return err
+		}
+		require.Len(t, hashes, 2)
+		for _, hash := range hashes {
+			if err := releaseBlobRef(ctx, svc.blobRepo, svc.logger, hash); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	// Deleting again finds nothing left to release
+	hashes, err := svc.objectRepo.DeleteAllVersions(ctx, bucket.ID, "a.jpg")
+	require.NoError(t, err)
+	require.Empty(t, hashes)
+
+	config := DefaultObjectPurgeConfig()
+	config.BatchSize = 1
+	purger := NewObjectPurger(svc.objectRepo, lock.NewMemoryLocker(), nil, zerolog.Nop(), config)
+
+	result := purger.RunOnce(ctx)
+	require.Zero(t, result.Errors)
+	require.Zero(t, result.Purged, "deleted versions are kept for the retention window")
+
+	purger.config.Retention = -time.Hour
+	result = purger.RunOnce(ctx)
+	require.Zero(t, result.Errors)
+	require.EqualValues(t, 2, result.Purged)
+
+	content, _ := getString(t, ctx, svc, "photos", "b.jpg")
+	require.Equal(t, "v2", content)
+
+	// References were released once, when the versions were deleted
+	reconciler := NewGCReconciler(svc.blobRepo, lock.NewMemoryLocker(), nil, zerolog.Nop(), DefaultGCReconcileConfig())
+	reconciled := reconciler.RunOnce(ctx)
+	require.Zero(t, reconciled.Errors)
+	require.Zero(t, reconciled.Drifted)
+}
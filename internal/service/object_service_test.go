@@ -4,6 +4,8 @@ package service
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
 	"io"
 	"testing"
 	"time"
@@ -24,6 +26,7 @@ import (
 
 type mockObjectRepository struct {
 	mock.Mock
+	sequence int64
 }
 
 func (m *mockObjectRepository) Create(ctx context.Context, obj *domain.Object) error {
@@ -81,14 +84,34 @@ func (m *mockObjectRepository) MarkNotLatest(ctx context.Context, bucketID int64
 	return args.Error(0)
 }
 
+func (m *mockObjectRepository) MarkLatest(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// NextSequence is not mocked; it hands out increasing numbers so tests that
+// do not care about sequences need no expectations for it.
+func (m *mockObjectRepository) NextSequence(ctx context.Context, bucketID int64, key string) (int64, error) {
+	m.sequence++
+	return m.sequence, nil
+}
+
 func (m *mockObjectRepository) Delete(ctx context.Context, id int64) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
-func (m *mockObjectRepository) DeleteAllVersions(ctx context.Context, bucketID int64, key string) error {
+func (m *mockObjectRepository) DeleteAllVersions(ctx context.Context, bucketID int64, key string) ([]string, error) {
 	args := m.Called(ctx, bucketID, key)
-	return args.Error(0)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *mockObjectRepository) PurgeDeleted(ctx context.Context, before time.Time, limit int) (int64, error) {
+	args := m.Called(ctx, before, limit)
+	return args.Get(0).(int64), args.Error(1)
 }
 
 func (m *mockObjectRepository) CountByBucket(ctx context.Context, bucketID int64) (int64, error) {
@@ -96,6 +119,22 @@ func (m *mockObjectRepository) CountByBucket(ctx context.Context, bucketID int64
 	return args.Get(0).(int64), args.Error(1)
 }
 
+func (m *mockObjectRepository) GetBucketStats(ctx context.Context, bucketID int64, largest int) (*domain.BucketStats, error) {
+	args := m.Called(ctx, bucketID, largest)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.BucketStats), args.Error(1)
+}
+
+func (m *mockObjectRepository) GetStats(ctx context.Context) (*domain.ObjectStats, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ObjectStats), args.Error(1)
+}
+
 func (m *mockObjectRepository) GetContentHashForVersion(ctx context.Context, bucketID int64, key string, versionID uuid.UUID) (*string, error) {
 	args := m.Called(ctx, bucketID, key, versionID)
 	if args.Get(0) == nil {
@@ -104,8 +143,24 @@ func (m *mockObjectRepository) GetContentHashForVersion(ctx context.Context, buc
 	return args.Get(0).(*string), args.Error(1)
 }
 
-func (m *mockObjectRepository) ListExpiredObjects(ctx context.Context, bucketID int64, prefix string, olderThan time.Time, limit int) ([]*domain.Object, error) {
-	args := m.Called(ctx, bucketID, prefix, olderThan, limit)
+func (m *mockObjectRepository) ListExpiredObjects(ctx context.Context, bucketID int64, filter domain.LifecycleFilter, olderThan time.Time, limit int) ([]*domain.Object, error) {
+	args := m.Called(ctx, bucketID, filter, olderThan, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Object), args.Error(1)
+}
+
+func (m *mockObjectRepository) ListNoncurrentVersions(ctx context.Context, bucketID int64, filter domain.LifecycleFilter, noncurrentBefore time.Time, limit int) ([]*domain.Object, error) {
+	args := m.Called(ctx, bucketID, filter, noncurrentBefore, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Object), args.Error(1)
+}
+
+func (m *mockObjectRepository) ListExpiredDeleteMarkers(ctx context.Context, bucketID int64, prefix string, limit int) ([]*domain.Object, error) {
+	args := m.Called(ctx, bucketID, prefix, limit)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -162,6 +217,47 @@ func (m *mockBlobRepository2) ListOrphans(ctx context.Context, gracePeriod time.
 	return args.Get(0).([]*domain.Blob), args.Error(1)
 }
 
+func (m *mockBlobRepository2) ListOrphansAfter(ctx context.Context, gracePeriod time.Duration, after string, limit int) ([]*domain.Blob, error) {
+	args := m.Called(ctx, gracePeriod, after, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Blob), args.Error(1)
+}
+
+func (m *mockBlobRepository2) ListAfter(ctx context.Context, after string, limit int) ([]*domain.Blob, error) {
+	args := m.Called(ctx, after, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Blob), args.Error(1)
+}
+
+func (m *mockBlobRepository2) GetGCCursor(ctx context.Context) (string, error) {
+	args := m.Called(ctx)
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockBlobRepository2) SaveGCCursor(ctx context.Context, after string) error {
+	args := m.Called(ctx, after)
+	return args.Error(0)
+}
+
+func (m *mockBlobRepository2) MarkGCPending(ctx context.Context, contentHash string) (bool, error) {
+	args := m.Called(ctx, contentHash)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockBlobRepository2) MarkGCStorageDeleted(ctx context.Context, contentHash string) (bool, error) {
+	args := m.Called(ctx, contentHash)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockBlobRepository2) RecordGCError(ctx context.Context, contentHash string, message string) error {
+	args := m.Called(ctx, contentHash, message)
+	return args.Error(0)
+}
+
 func (m *mockBlobRepository2) DeleteOrphans(ctx context.Context, gracePeriod time.Duration, limit int) ([]*domain.Blob, error) {
 	args := m.Called(ctx, gracePeriod, limit)
 	if args.Get(0) == nil {
@@ -185,8 +281,8 @@ func (m *mockBlobRepository2) UpsertEncrypted(ctx context.Context, contentHash s
 	return args.Bool(0), args.Error(1)
 }
 
-func (m *mockBlobRepository2) UpdateEncrypted(ctx context.Context, contentHash string, encryptionIV string) error {
-	args := m.Called(ctx, contentHash, encryptionIV)
+func (m *mockBlobRepository2) UpdateEncrypted(ctx context.Context, contentHash string, encryptionIV string, keyVersion int) error {
+	args := m.Called(ctx, contentHash, encryptionIV, keyVersion)
 	return args.Error(0)
 }
 
@@ -211,6 +307,14 @@ func (m *mockBlobRepository2) ListEncrypted(ctx context.Context, limit int, offs
 	return args.Get(0).([]*domain.Blob), args.Error(1)
 }
 
+func (m *mockBlobRepository2) ListEncryptedBelowKeyVersion(ctx context.Context, keyVersion int, after string, limit int) ([]*domain.Blob, error) {
+	args := m.Called(ctx, keyVersion, after, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Blob), args.Error(1)
+}
+
 func (m *mockBlobRepository2) ListAll(ctx context.Context, limit int) ([]*domain.Blob, error) {
 	args := m.Called(ctx, limit)
 	if args.Get(0) == nil {
@@ -219,6 +323,61 @@ func (m *mockBlobRepository2) ListAll(ctx context.Context, limit int) ([]*domain
 	return args.Get(0).([]*domain.Blob), args.Error(1)
 }
 
+func (m *mockBlobRepository2) ListCreatedBefore(ctx context.Context, before time.Time, afterHash string, limit int) ([]*domain.Blob, error) {
+	args := m.Called(ctx, before, afterHash, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Blob), args.Error(1)
+}
+
+func (m *mockBlobRepository2) ListUnverified(ctx context.Context, verifiedBefore time.Time, limit int) ([]*domain.Blob, error) {
+	args := m.Called(ctx, verifiedBefore, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Blob), args.Error(1)
+}
+
+func (m *mockBlobRepository2) RecordVerification(ctx context.Context, contentHash string, verifiedAt time.Time, corrupt bool) error {
+	args := m.Called(ctx, contentHash, verifiedAt, corrupt)
+	return args.Error(0)
+}
+
+func (m *mockBlobRepository2) ListCorrupt(ctx context.Context, limit int) ([]*domain.Blob, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Blob), args.Error(1)
+}
+
+func (m *mockBlobRepository2) CountCorrupt(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockBlobRepository2) GetStats(ctx context.Context) (*domain.BlobStats, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.BlobStats), args.Error(1)
+}
+
+func (m *mockBlobRepository2) CountRefsAfter(ctx context.Context, after string, limit int) ([]*domain.BlobRefCount, error) {
+	args := m.Called(ctx, after, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.BlobRefCount), args.Error(1)
+}
+
+func (m *mockBlobRepository2) SetRefCount(ctx context.Context, contentHash string, expected, refCount int32) (bool, error) {
+	args := m.Called(ctx, contentHash, expected, refCount)
+	return args.Bool(0), args.Error(1)
+}
+
 type mockStorageBackend2 struct {
 	mock.Mock
 }
@@ -295,6 +454,14 @@ func (m *mockBucketRepository) List(ctx context.Context, ownerID int64) ([]*doma
 	return args.Get(0).([]*domain.Bucket), args.Error(1)
 }
 
+func (m *mockBucketRepository) ListPage(ctx context.Context, ownerID int64, opts repository.BucketListOptions) (*repository.BucketListResult, error) {
+	args := m.Called(ctx, ownerID, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.BucketListResult), args.Error(1)
+}
+
 func (m *mockBucketRepository) Update(ctx context.Context, bucket *domain.Bucket) error {
 	args := m.Called(ctx, bucket)
 	return args.Error(0)
@@ -330,11 +497,34 @@ func (m *mockBucketRepository) IsEmpty(ctx context.Context, id int64) (bool, err
 	return args.Bool(0), args.Error(1)
 }
 
+func (m *mockBucketRepository) StorageBytes(ctx context.Context, id int64) (int64, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 func (m *mockBucketRepository) UpdateVersioning(ctx context.Context, id int64, status domain.VersioningStatus) error {
 	args := m.Called(ctx, id, status)
 	return args.Error(0)
 }
 
+func (m *mockBucketRepository) GetTags(ctx context.Context, id int64) ([]domain.Tag, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Tag), args.Error(1)
+}
+
+func (m *mockBucketRepository) PutTags(ctx context.Context, id int64, tags []domain.Tag) error {
+	args := m.Called(ctx, id, tags)
+	return args.Error(0)
+}
+
+func (m *mockBucketRepository) DeleteTags(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
 func (m *mockBucketRepository) GetACLByName(ctx context.Context, name string) (domain.BucketACL, error) {
 	args := m.Called(ctx, name)
 	return args.Get(0).(domain.BucketACL), args.Error(1)
@@ -345,6 +535,17 @@ func (m *mockBucketRepository) UpdateACL(ctx context.Context, id int64, acl doma
 	return args.Error(0)
 }
 
+// mockTxManager runs the transaction function directly.
+type mockTxManager struct{}
+
+func (m *mockTxManager) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (m *mockTxManager) WithTxOptions(ctx context.Context, opts repository.TxOptions, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
 // =============================================================================
 // Helper Functions
 // =============================================================================
@@ -357,7 +558,7 @@ func newTestObjectService() (*ObjectService, *mockObjectRepository, *mockBlobRep
 	locker := lock.NewNoOpLocker()
 	logger := zerolog.Nop()
 
-	svc := NewObjectService(objectRepo, blobRepo, bucketRepo, storageBackend, locker, logger)
+	svc := NewObjectService(objectRepo, blobRepo, bucketRepo, nil, nil, nil, nil, nil, nil, &mockTxManager{}, storageBackend, locker, nil, logger)
 
 	return svc, objectRepo, blobRepo, bucketRepo, storageBackend
 }
@@ -401,7 +602,7 @@ func TestObjectService_PutObject(t *testing.T) {
 				blobRepo.On("UpsertWithRefIncrement", mock.Anything, "abc123hash", int64(11), "/data/ab/c1/abc123hash").Return(true, nil)
 
 				// Check for existing object - not found (for non-versioned bucket)
-				objRepo.On("GetByKey", mock.Anything, int64(1), "test-key.txt").Return(nil, repository.ErrNotFound)
+				objRepo.On("GetByKey", mock.Anything, int64(1), "test-key.txt").Return(nil, domain.ErrObjectNotFound)
 
 				// Mark any existing object as not latest (called for non-versioned buckets)
 				objRepo.On("MarkNotLatest", mock.Anything, int64(1), "test-key.txt").Return(nil)
@@ -1146,3 +1347,132 @@ func TestObjectService_ListObjectVersions(t *testing.T) {
 		})
 	}
 }
+
+func TestObjectService_ObjectACL(t *testing.T) {
+	ctx := context.Background()
+	svc := newConsistencyService(t, "photos", domain.VersioningEnabled)
+	adapter := NewObjectACLAdapter(svc)
+
+	_, err := svc.PutObject(ctx, PutObjectInput{
+		BucketName: "photos",
+		Key:        "a.jpg",
+		Body:       bytes.NewReader([]byte("v1")),
+		Size:       2,
+		OwnerID:    consistencyOwnerID,
+		ACL:        domain.ObjectACLPublicRead,
+	})
+	require.NoError(t, err)
+	first, err := svc.GetObjectACL(ctx, GetObjectACLInput{BucketName: "photos", Key: "a.jpg", OwnerID: consistencyOwnerID})
+	require.NoError(t, err)
+	require.Equal(t, domain.ObjectACLPublicRead, first.ACL)
+
+	// A new version starts private; the old one stays public
+	second, err := putString(ctx, svc, "photos", "a.jpg", "v2", nil)
+	require.NoError(t, err)
+	acl, err := adapter.GetObjectACL(ctx, "photos", "a.jpg", "")
+	require.NoError(t, err)
+	require.Equal(t, "private", acl)
+	acl, err = adapter.GetObjectACL(ctx, "photos", "a.jpg", first.VersionID)
+	require.NoError(t, err)
+	require.Equal(t, "public-read", acl)
+
+	err = svc.PutObjectACL(ctx, PutObjectACLInput{
+		BucketName: "photos",
+		Key:        "a.jpg",
+		VersionID:  second.VersionID,
+		OwnerID:    consistencyOwnerID,
+		ACL:        domain.ObjectACLPublicRead,
+	})
+	require.NoError(t, err)
+	acl, err = adapter.GetObjectACL(ctx, "photos", "a.jpg", "")
+	require.NoError(t, err)
+	require.Equal(t, "public-read", acl)
+	content, _ := getString(t, ctx, svc, "photos", "a.jpg")
+	require.Equal(t, "v2", content, "setting the ACL keeps the content")
+
+	err = svc.PutObjectACL(ctx, PutObjectACLInput{BucketName: "photos", Key: "a.jpg", OwnerID: consistencyOwnerID, ACL: "public-read-write"})
+	require.ErrorIs(t, err, ErrInvalidObjectACL)
+	err = svc.PutObjectACL(ctx, PutObjectACLInput{BucketName: "photos", Key: "a.jpg", OwnerID: consistencyOwnerID + 1, ACL: domain.ObjectACLPrivate})
+	require.ErrorIs(t, err, ErrBucketAccessDenied)
+
+	// Setting the ACL waits for the key's write lock
+	bucket, err := svc.bucketRepo.GetByName(ctx, "photos")
+	require.NoError(t, err)
+	lockKey := lock.Keys.ObjectWrite(bucket.ID, "a.jpg")
+	acquired, err := svc.locker.Acquire(ctx, lockKey, time.Minute)
+	require.NoError(t, err)
+	require.True(t, acquired)
+	waiting, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	err = svc.PutObjectACL(waiting, PutObjectACLInput{BucketName: "photos", Key: "a.jpg", OwnerID: consistencyOwnerID, ACL: domain.ObjectACLPrivate})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	_, err = svc.locker.Release(ctx, lockKey)
+	require.NoError(t, err)
+
+	// Deleted and missing keys have no ACL
+	_, err = svc.DeleteObject(ctx, DeleteObjectInput{BucketName: "photos", Key: "a.jpg", OwnerID: consistencyOwnerID})
+	require.NoError(t, err)
+	for _, key := range []string{"a.jpg", "missing.jpg"} {
+		acl, err = adapter.GetObjectACL(ctx, "photos", key, "")
+		require.NoError(t, err)
+		require.Empty(t, acl, key)
+	}
+}
+
+func TestObjectService_Checksums(t *testing.T) {
+	ctx := context.Background()
+	svc := newConsistencyService(t, "photos", domain.VersioningEnabled)
+	sum := sha256.Sum256([]byte("v1"))
+	checksum := base64.StdEncoding.EncodeToString(sum[:])
+	put := func(key string, algorithm domain.ChecksumAlgorithm, expected string) (*PutObjectOutput, error) {
+		return svc.PutObject(ctx, PutObjectInput{
+			BucketName:        "photos",
+			Key:               key,
+			Body:              bytes.NewReader([]byte("v1")),
+			Size:              2,
+			OwnerID:           consistencyOwnerID,
+			ChecksumAlgorithm: algorithm,
+			Checksum:          expected,
+		})
+	}
+
+	output, err := put("a.jpg", "sha256", checksum)
+	require.NoError(t, err)
+	require.Equal(t, domain.ChecksumSHA256, output.ChecksumAlgorithm)
+	require.Equal(t, checksum, output.Checksum)
+
+	head, err := svc.HeadObject(ctx, HeadObjectInput{BucketName: "photos", Key: "a.jpg", OwnerID: consistencyOwnerID})
+	require.NoError(t, err)
+	require.Equal(t, domain.ChecksumSHA256, head.ChecksumAlgorithm)
+	require.Equal(t, checksum, head.Checksum)
+
+	// The checksum is computed when only its algorithm is sent
+	output, err = put("b.jpg", domain.ChecksumCRC32C, "")
+	require.NoError(t, err)
+	require.Len(t, output.Checksum, 8)
+
+	// A mismatch stores no version
+	other := sha256.Sum256([]byte("v2"))
+	_, err = put("c.jpg", domain.ChecksumSHA256, base64.StdEncoding.EncodeToString(other[:]))
+	require.ErrorIs(t, err, domain.ErrChecksumMismatch)
+	_, err = svc.HeadObject(ctx, HeadObjectInput{BucketName: "photos", Key: "c.jpg", OwnerID: consistencyOwnerID})
+	require.ErrorIs(t, err, domain.ErrObjectNotFound)
+
+	_, err = put("c.jpg", "MD5", "")
+	require.ErrorIs(t, err, domain.ErrInvalidChecksum)
+	_, err = put("c.jpg", domain.ChecksumCRC32, checksum)
+	require.ErrorIs(t, err, domain.ErrInvalidChecksum, "a SHA-256 value is not a CRC32 checksum")
+
+	// Copies have the same content, and keep the checksum
+	_, err = svc.CopyObject(ctx, CopyObjectInput{
+		SourceBucket: "photos",
+		SourceKey:    "a.jpg",
+		DestBucket:   "photos",
+		DestKey:      "copy.jpg",
+		OwnerID:      consistencyOwnerID,
+	})
+	require.NoError(t, err)
+	head, err = svc.HeadObject(ctx, HeadObjectInput{BucketName: "photos", Key: "copy.jpg", OwnerID: consistencyOwnerID})
+	require.NoError(t, err)
+	require.Equal(t, checksum, head.Checksum)
+}
@@ -0,0 +1,157 @@
+// Package service provides business logic services for Alexander Storage.
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/pkg/oidc"
+)
+
+// OIDCConfig configures dashboard single sign-on with an OpenID Connect provider.
+type OIDCConfig struct {
+	// Issuer is the provider's issuer URL.
+	Issuer string
+
+	// ClientID and ClientSecret are the credentials registered with the provider.
+	ClientID     string
+	ClientSecret string
+
+	// RedirectURL is the dashboard callback, e.g. https://s3.example.com/dashboard/oidc/callback.
+	RedirectURL string
+
+	// Scopes are requested in addition to "openid". Default: email, profile.
+	Scopes []string
+
+	// EmailClaim is the ID token claim holding the email address that is
+	// matched against local users. Default: email.
+	EmailClaim string
+
+	// Timeout bounds each request to the provider. Default: 10 seconds.
+	Timeout time.Duration
+}
+
+// OIDCLogin is a started single sign-on login. State, Nonce and CodeVerifier
+// must be kept by the browser session and passed back to CompleteLogin.
+type OIDCLogin struct {
+	URL          string
+	State        string
+	Nonce        string
+	CodeVerifier string
+}
+
+// OIDCAuthenticator runs the OpenID Connect authorization code flow.
+// Provider discovery happens on first use, so an unreachable provider does
+// not prevent the server from starting.
+type OIDCAuthenticator struct {
+	config OIDCConfig
+	logger zerolog.Logger
+
+	mu     sync.Mutex
+	client *oidc.Client
+}
+
+// NewOIDCAuthenticator creates a new OIDCAuthenticator.
+func NewOIDCAuthenticator(config OIDCConfig, logger zerolog.Logger) *OIDCAuthenticator {
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"email", "profile"}
+	}
+	if config.EmailClaim == "" {
+		config.EmailClaim = "email"
+	}
+	if config.Timeout == 0 {
+		config.Timeout = 10 * time.Second
+	}
+
+	return &OIDCAuthenticator{
+		config: config,
+		logger: logger.With().Str("service", "oidc").Logger(),
+	}
+}
+
+// BeginLogin returns the provider URL to redirect the browser to.
+func (a *OIDCAuthenticator) BeginLogin(ctx context.Context) (*OIDCLogin, error) {
+	client, err := a.getClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	login := &OIDCLogin{}
+	for _, value := range []*string{&login.State, &login.Nonce, &login.CodeVerifier} {
+		if *value, err = oidc.RandomString(); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+		}
+	}
+	login.URL = client.AuthCodeURL(login.State, login.Nonce, login.CodeVerifier)
+	return login, nil
+}
+
+// CompleteLogin redeems the authorization code from the callback and returns
+// the authenticated identity. It returns ErrInvalidCredentials if the
+// provider's response cannot be trusted or has no usable email address.
+func (a *OIDCAuthenticator) CompleteLogin(ctx context.Context, code, codeVerifier, nonce string) (*ExternalIdentity, error) {
+	client, err := a.getClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, a.config.Timeout)
+	defer cancel()
+
+	claims, err := client.Exchange(ctx, code, codeVerifier, nonce)
+	if err != nil {
+		if errors.Is(err, oidc.ErrInvalidToken) {
+			a.logger.Warn().Err(err).Msg("rejected ID token")
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	email := claims.String(a.config.EmailClaim)
+	if email == "" {
+		a.logger.Warn().Str("subject", claims.Subject).Str("claim", a.config.EmailClaim).Msg("ID token has no email claim")
+		return nil, ErrInvalidCredentials
+	}
+	if a.config.EmailClaim == "email" && claims.EmailVerified != nil && !*claims.EmailVerified {
+		a.logger.Warn().Str("subject", claims.Subject).Msg("ID token email is not verified")
+		return nil, ErrInvalidCredentials
+	}
+
+	username := claims.PreferredUsername
+	if username == "" {
+		username = email
+	}
+	return &ExternalIdentity{Username: username, Email: email}, nil
+}
+
+// getClient returns the discovered provider client, retrying discovery until it succeeds.
+func (a *OIDCAuthenticator) getClient(ctx context.Context) (*oidc.Client, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.client != nil {
+		return a.client, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, a.config.Timeout)
+	defer cancel()
+
+	client, err := oidc.NewClient(ctx, oidc.Config{
+		Issuer:       a.config.Issuer,
+		ClientID:     a.config.ClientID,
+		ClientSecret: a.config.ClientSecret,
+		RedirectURL:  a.config.RedirectURL,
+		Scopes:       a.config.Scopes,
+	})
+	if err != nil {
+		a.logger.Error().Err(err).Str("issuer", a.config.Issuer).Msg("OIDC provider discovery failed")
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	a.client = client
+	return client, nil
+}
@@ -135,7 +135,7 @@ func (s *PresignService) GeneratePresignedURL(ctx context.Context, input Presign
 		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 
-	s.logger.Debug().
+	s.logger.Debug().Ctx(ctx).
 		Str("access_key_id", input.AccessKeyID).
 		Str("method", input.Method).
 		Str("bucket", input.Bucket).
@@ -0,0 +1,428 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/lock"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+	"github.com/prn-tf/alexander-storage/internal/storage"
+)
+
+// QuarantineService manages uploads staged in quarantine buckets. An
+// approver (a person or a scanner callback) promotes a staged upload to a
+// visible object version or rejects it; uploads nobody promotes expire.
+type QuarantineService struct {
+	stagedRepo repository.StagedObjectRepository
+	objectRepo repository.ObjectRepository
+	blobRepo   repository.BlobRepository
+	bucketRepo repository.BucketRepository
+	eventRepo  repository.EventRepository
+	replRepo   repository.ReplicationRepository
+	readOnly   *ReadOnlyService
+	txManager  repository.TxManager
+	storage    storage.Backend
+	locker     lock.Locker
+	logger     zerolog.Logger
+	config     QuarantineConfig
+
+	// Control
+	mu       sync.Mutex
+	running  bool
+	stopChan chan struct{}
+	doneChan chan struct{}
+}
+
+// QuarantineConfig contains upload quarantine configuration.
+type QuarantineConfig struct {
+	// ExpireAfter is how long a staged upload waits for promotion before
+	// it is discarded.
+	ExpireAfter time.Duration
+
+	// Interval is how often to look for expired uploads.
+	Interval time.Duration
+
+	// BatchSize is the number of expired uploads discarded at a time.
+	BatchSize int
+}
+
+// DefaultQuarantineConfig returns sensible defaults.
+func DefaultQuarantineConfig() QuarantineConfig {
+	return QuarantineConfig{
+		ExpireAfter: 7 * 24 * time.Hour,
+		Interval:    1 * time.Hour,
+		BatchSize:   100,
+	}
+}
+
+// NewQuarantineService creates a new QuarantineService.
+// eventRepo and replRepo may be nil, as for NewObjectService.
+func NewQuarantineService(
+	stagedRepo repository.StagedObjectRepository,
+	objectRepo repository.ObjectRepository,
+	blobRepo repository.BlobRepository,
+	bucketRepo repository.BucketRepository,
+	eventRepo repository.EventRepository,
+	replRepo repository.ReplicationRepository,
+	txManager repository.TxManager,
+	storage storage.Backend,
+	locker lock.Locker,
+	logger zerolog.Logger,
+	config QuarantineConfig,
+) *QuarantineService {
+	return &QuarantineService{
+		stagedRepo: stagedRepo,
+		objectRepo: objectRepo,
+		blobRepo:   blobRepo,
+		bucketRepo: bucketRepo,
+		eventRepo:  eventRepo,
+		replRepo:   replRepo,
+		txManager:  txManager,
+		storage:    storage,
+		locker:     locker,
+		logger:     logger.With().Str("service", "quarantine").Logger(),
+		config:     config,
+		stopChan:   make(chan struct{}),
+		doneChan:   make(chan struct{}),
+	}
+}
+
+// SetReadOnlyService makes promotions also check the service-wide read-only
+// mode, not just the read-only flag of their bucket. It must be called
+// before the service handles requests.
+func (s *QuarantineService) SetReadOnlyService(readOnly *ReadOnlyService) {
+	s.readOnly = readOnly
+}
+
+// StagedUpload is a staged upload with the name of its bucket.
+type StagedUpload struct {
+	*domain.StagedObject
+	BucketName string
+}
+
+// ListStaged returns up to limit staged uploads, oldest first. An empty
+// bucketName lists the uploads of all buckets.
+func (s *QuarantineService) ListStaged(ctx context.Context, bucketName string, limit int) ([]StagedUpload, error) {
+	var bucketID int64
+	if bucketName != "" {
+		bucket, err := s.bucketRepo.GetByName(ctx, bucketName)
+		if err != nil {
+			if errors.Is(err, domain.ErrBucketNotFound) {
+				return nil, domain.ErrBucketNotFound
+			}
+			return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+		}
+		bucketID = bucket.ID
+	}
+
+	staged, err := s.stagedRepo.List(ctx, bucketID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	names := make(map[int64]string)
+	uploads := make([]StagedUpload, len(staged))
+	for i, st := range staged {
+		name, ok := names[st.BucketID]
+		if !ok {
+			bucket, err := s.bucketRepo.GetByID(ctx, st.BucketID)
+			if err != nil && !errors.Is(err, domain.ErrBucketNotFound) {
+				return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+			}
+			if bucket != nil {
+				name = bucket.Name
+			}
+			names[st.BucketID] = name
+		}
+		uploads[i] = StagedUpload{StagedObject: st, BucketName: name}
+	}
+	return uploads, nil
+}
+
+// GetStaged returns a staged upload and its content, for scanners to inspect
+// before deciding. The caller must close the reader.
+func (s *QuarantineService) GetStaged(ctx context.Context, id string) (*domain.StagedObject, io.ReadCloser, error) {
+	staged, bucket, err := s.load(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reader, err := s.storage.Retrieve(storage.WithResidency(ctx, bucket.Residency), staged.ContentHash)
+	if err != nil {
+		return nil, nil, storageError(err)
+	}
+	return staged, reader, nil
+}
+
+// Promote makes a staged upload the latest version of its key, as if it had
+// been written now.
+func (s *QuarantineService) Promote(ctx context.Context, id string) (*domain.Object, error) {
+	staged, bucket, err := s.load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkWritable(ctx, s.readOnly, bucket); err != nil {
+		return nil, err
+	}
+
+	// Removing the staged row first makes a concurrent promote or reject of
+	// the same upload fail, so its blob reference moves to exactly one place
+	var obj *domain.Object
+	err = s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		if err := s.stagedRepo.Delete(ctx, staged.ID); err != nil {
+			return err
+		}
+		var err error
+		obj, err = publishStaged(ctx, s.objectRepo, s.blobRepo, s.replRepo, s.eventRepo, s.logger, bucket, staged)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrStagedObjectNotFound) {
+			return nil, err
+		}
+		s.logger.Error().Ctx(ctx).Err(err).Str("staging_id", id).Msg("failed to promote staged upload")
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	s.logger.Info().Ctx(ctx).
+		Str("bucket", bucket.Name).
+		Str("key", obj.Key).
+		Str("staging_id", id).
+		Str("version_id", obj.GetVersionIDString()).
+		Msg("staged upload promoted")
+
+	return obj, nil
+}
+
+// Reject discards a staged upload and releases its content.
+func (s *QuarantineService) Reject(ctx context.Context, id string) error {
+	staged, bucket, err := s.load(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.discard(ctx, staged); err != nil {
+		if errors.Is(err, domain.ErrStagedObjectNotFound) {
+			return err
+		}
+		s.logger.Error().Ctx(ctx).Err(err).Str("staging_id", id).Msg("failed to reject staged upload")
+		return fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	s.logger.Info().Ctx(ctx).
+		Str("bucket", bucket.Name).
+		Str("key", staged.Key).
+		Str("staging_id", id).
+		Msg("staged upload rejected")
+
+	return nil
+}
+
+// load returns a staged upload and its bucket.
+func (s *QuarantineService) load(ctx context.Context, id string) (*domain.StagedObject, *domain.Bucket, error) {
+	stagingID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, nil, domain.ErrStagedObjectNotFound
+	}
+
+	staged, err := s.stagedRepo.GetByID(ctx, stagingID)
+	if err != nil {
+		if errors.Is(err, domain.ErrStagedObjectNotFound) {
+			return nil, nil, err
+		}
+		return nil, nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	// Writes of a batch are committed or aborted with their batch
+	if staged.BatchID != nil {
+		return nil, nil, domain.ErrStagedObjectNotFound
+	}
+
+	bucket, err := s.bucketRepo.GetByID(ctx, staged.BucketID)
+	if err != nil {
+		// The staged row cascades with its bucket, so this is a race with
+		// bucket deletion
+		if errors.Is(err, domain.ErrBucketNotFound) {
+			return nil, nil, domain.ErrStagedObjectNotFound
+		}
+		return nil, nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	return staged, bucket, nil
+}
+
+// discard removes a staged upload and releases its blob reference, leaving
+// the blob for GC if nothing else references it.
+func (s *QuarantineService) discard(ctx context.Context, staged *domain.StagedObject) error {
+	return s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		if err := s.stagedRepo.Delete(ctx, staged.ID); err != nil {
+			return err
+		}
+		return releaseBlobRef(ctx, s.blobRepo, s.logger, staged.ContentHash)
+	})
+}
+
+// publishStaged makes a staged write the latest version of its key, as if
+// it had been written now. Callers run it inside the transaction that
+// deletes the staged row.
+func publishStaged(
+	ctx context.Context,
+	objectRepo repository.ObjectRepository,
+	blobRepo repository.BlobRepository,
+	replRepo repository.ReplicationRepository,
+	eventRepo repository.EventRepository,
+	logger zerolog.Logger,
+	bucket *domain.Bucket,
+	staged *domain.StagedObject,
+) (*domain.Object, error) {
+	obj := staged.Object()
+
+	if err := assignSequence(ctx, objectRepo, obj, nil); err != nil {
+		return nil, err
+	}
+
+	if err := supersedeLatest(ctx, objectRepo, blobRepo, logger, bucket, obj); err != nil {
+		return nil, err
+	}
+
+	task, err := prepareReplication(ctx, replRepo, bucket, obj, domain.ReplicationOperationPut)
+	if err != nil {
+		return nil, err
+	}
+	if err := objectRepo.Create(ctx, obj); err != nil {
+		return nil, fmt.Errorf("failed to create object: %w", err)
+	}
+	if err := enqueueReplication(ctx, replRepo, obj, task); err != nil {
+		return nil, err
+	}
+	if err := recordEvent(ctx, eventRepo, staged.EventType, bucket, obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// Start begins the expiry scheduler.
+func (s *QuarantineService) Start() {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = true
+	s.mu.Unlock()
+
+	s.logger.Info().
+		Dur("interval", s.config.Interval).
+		Dur("expire_after", s.config.ExpireAfter).
+		Msg("Starting quarantine expiry")
+
+	go s.runLoop()
+}
+
+// Stop stops the expiry scheduler.
+func (s *QuarantineService) Stop() {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = false
+	s.mu.Unlock()
+
+	close(s.stopChan)
+	<-s.doneChan
+
+	s.logger.Info().Msg("Quarantine expiry stopped")
+}
+
+// runLoop is the main expiry loop.
+func (s *QuarantineService) runLoop() {
+	defer close(s.doneChan)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-s.stopChan
+		cancel()
+	}()
+
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		s.ExpireOnce(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+// ExpireOnce discards every upload staged longer than ExpireAfter and
+// returns the number discarded.
+func (s *QuarantineService) ExpireOnce(ctx context.Context) int {
+	lockKey := lock.Keys.QuarantineExpiry()
+	lockTTL := s.config.Interval / 2
+	if lockTTL < 5*time.Minute {
+		lockTTL = 5 * time.Minute
+	}
+
+	acquired, err := s.locker.Acquire(ctx, lockKey, lockTTL)
+	if err != nil {
+		s.logger.Error().Ctx(ctx).Err(err).Msg("Failed to acquire quarantine expiry lock")
+		return 0
+	}
+	if !acquired {
+		s.logger.Debug().Ctx(ctx).Msg("Quarantine expiry lock held by another process, skipping run")
+		return 0
+	}
+	defer func() {
+		if _, err := s.locker.Release(context.Background(), lockKey); err != nil {
+			s.logger.Error().Ctx(ctx).Err(err).Msg("Failed to release quarantine expiry lock")
+		}
+	}()
+
+	// Discarded uploads are gone from the next page, so paging ends once
+	// all are processed; a page with failures ends the run to avoid
+	// listing the same uploads again
+	cutoff := time.Now().Add(-s.config.ExpireAfter)
+	expired := 0
+	for ctx.Err() == nil {
+		batch, err := s.stagedRepo.ListExpired(ctx, cutoff, s.config.BatchSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				s.logger.Error().Ctx(ctx).Err(err).Msg("Failed to list expired staged uploads")
+			}
+			break
+		}
+
+		failed := 0
+		for _, staged := range batch {
+			if err := s.discard(ctx, staged); err != nil && !errors.Is(err, domain.ErrStagedObjectNotFound) {
+				s.logger.Error().Ctx(ctx).Err(err).Str("staging_id", staged.ID.String()).Msg("Failed to expire staged upload")
+				failed++
+				continue
+			}
+			expired++
+		}
+
+		if len(batch) < s.config.BatchSize || failed > 0 {
+			break
+		}
+	}
+
+	if expired > 0 {
+		s.logger.Info().Ctx(ctx).Int("expired", expired).Msg("Expired unpromoted staged uploads")
+	}
+	return expired
+}
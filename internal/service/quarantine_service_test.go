@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/lock"
+	"github.com/prn-tf/alexander-storage/internal/repository/sqlite"
+)
+
+func TestQuarantine_PromoteRejectAndExpire(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	db, err := sqlite.NewDB(ctx, sqlite.DefaultConfig(filepath.Join(dir, "alexander.db")), zerolog.Nop())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, db.Migrate(ctx))
+	store := newTestFilesystem(t, dir)
+
+	user := domain.NewUser("owner", "owner@example.com", "hash")
+	require.NoError(t, sqlite.NewUserRepository(db).Create(ctx, user))
+	bucketRepo := sqlite.NewBucketRepository(db)
+	bucket := domain.NewBucket(user.ID, "inbox")
+	bucket.Quarantine = true
+	require.NoError(t, bucketRepo.Create(ctx, bucket))
+
+	objectRepo := sqlite.NewObjectRepository(db)
+	blobRepo := sqlite.NewBlobRepository(db)
+	stagedRepo := sqlite.NewStagedObjectRepository(db)
+	txManager := sqlite.NewTxManager(db)
+	objects := NewObjectService(objectRepo, blobRepo, bucketRepo, nil, nil, nil, stagedRepo, nil, nil, txManager, store, lock.NewMemoryLocker(), nil, zerolog.Nop())
+	config := DefaultQuarantineConfig()
+	quarantine := NewQuarantineService(stagedRepo, objectRepo, blobRepo, bucketRepo, nil, nil, txManager, store, lock.NewMemoryLocker(), zerolog.Nop(), config)
+
+	// An upload is staged and invisible until promoted
+	put, err := putString(ctx, objects, "inbox", "report.pdf", "clean", nil)
+	require.NoError(t, err)
+	require.NotEmpty(t, put.StagingID)
+	require.Empty(t, put.VersionID)
+	_, err = objects.GetObject(ctx, GetObjectInput{BucketName: "inbox", Key: "report.pdf"})
+	require.ErrorIs(t, err, domain.ErrObjectNotFound)
+
+	empty, err := bucketRepo.IsEmpty(ctx, bucket.ID)
+	require.NoError(t, err)
+	require.False(t, empty, "a bucket with staged uploads must not be deletable")
+
+	staged, err := quarantine.ListStaged(ctx, "inbox", 10)
+	require.NoError(t, err)
+	require.Len(t, staged, 1)
+	require.Equal(t, "inbox", staged[0].BucketName)
+
+	obj, err := quarantine.Promote(ctx, put.StagingID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), obj.Sequence)
+	body, sequence := getString(t, ctx, objects, "inbox", "report.pdf")
+	require.Equal(t, "clean", body)
+	require.Equal(t, int64(1), sequence)
+
+	_, err = quarantine.Promote(ctx, put.StagingID)
+	require.ErrorIs(t, err, domain.ErrStagedObjectNotFound)
+
+	// A rejected upload releases its blob reference
+	put, err = putString(ctx, objects, "inbox", "malware.exe", "infected", nil)
+	require.NoError(t, err)
+	require.NoError(t, quarantine.Reject(ctx, put.StagingID))
+	hash := sha256.Sum256([]byte("infected"))
+	blob, err := blobRepo.GetByHash(ctx, hex.EncodeToString(hash[:]))
+	require.NoError(t, err)
+	require.Zero(t, blob.RefCount)
+
+	// Uploads nobody decides on expire
+	_, err = putString(ctx, objects, "inbox", "forgotten.txt", "pending", nil)
+	require.NoError(t, err)
+	require.Zero(t, quarantine.ExpireOnce(ctx), "fresh uploads must not expire")
+
+	quarantine.config.ExpireAfter = -time.Minute
+	require.Equal(t, 1, quarantine.ExpireOnce(ctx))
+	staged, err = quarantine.ListStaged(ctx, "", 10)
+	require.NoError(t, err)
+	require.Empty(t, staged)
+	_, err = objects.GetObject(ctx, GetObjectInput{BucketName: "inbox", Key: "forgotten.txt"})
+	require.ErrorIs(t, err, domain.ErrObjectNotFound)
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/lock"
+	"github.com/prn-tf/alexander-storage/internal/repository/sqlite"
+	"github.com/prn-tf/alexander-storage/internal/storage/filesystem"
+)
+
+type readOnlyFixture struct {
+	readOnly    *ReadOnlyService
+	buckets     *BucketService
+	objects     *ObjectService
+	multipart   *MultipartService
+	deleter     *BucketDeleter
+	userID      int64
+	now         time.Time
+	otherServer *ReadOnlyService // another server sharing the database
+}
+
+// newReadOnlyFixture returns services backed by SQLite and the filesystem
+// with a bucket "photos" holding the object "cat.jpg".
+func newReadOnlyFixture(t *testing.T) *readOnlyFixture {
+	t.Helper()
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	db, err := sqlite.NewDB(ctx, sqlite.DefaultConfig(filepath.Join(dir, "alexander.db")), zerolog.Nop())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, db.Migrate(ctx))
+
+	store, err := filesystem.NewStorage(filesystem.Config{
+		DataDir: filepath.Join(dir, "blobs"),
+		TempDir: filepath.Join(dir, "tmp"),
+	}, zerolog.Nop())
+	require.NoError(t, err)
+
+	userRepo := sqlite.NewUserRepository(db)
+	user := domain.NewUser("owner", "owner@example.com", "hash")
+	require.NoError(t, userRepo.Create(ctx, user))
+
+	bucketRepo := sqlite.NewBucketRepository(db)
+	require.NoError(t, bucketRepo.Create(ctx, domain.NewBucket(user.ID, "photos")))
+
+	f := &readOnlyFixture{userID: user.ID, now: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)}
+	f.readOnly = NewReadOnlyService(sqlite.NewReadOnlyRepository(db), zerolog.Nop())
+	f.readOnly.now = func() time.Time { return f.now }
+	f.otherServer = NewReadOnlyService(sqlite.NewReadOnlyRepository(db), zerolog.Nop())
+	f.otherServer.now = func() time.Time { return f.now }
+
+	txManager := sqlite.NewTxManager(db)
+	f.buckets = NewBucketService(bucketRepo, nil, zerolog.Nop(), DefaultBucketConfig())
+	f.buckets.SetReadOnlyService(f.readOnly)
+	f.objects = NewObjectService(sqlite.NewObjectRepository(db), sqlite.NewBlobRepository(db), bucketRepo, nil, nil, nil, nil, nil,
+		nil, txManager, store, lock.NewMemoryLocker(), nil, zerolog.Nop())
+	f.objects.SetReadOnlyService(f.readOnly)
+	f.multipart = NewMultipartService(sqlite.NewMultipartRepository(db), sqlite.NewObjectRepository(db), sqlite.NewBlobRepository(db), bucketRepo,
+		nil, nil, nil, nil, nil, txManager, store, lock.NewMemoryLocker(), nil, zerolog.Nop())
+	f.multipart.SetReadOnlyService(f.readOnly)
+	f.deleter = NewBucketDeleter(f.objects, f.multipart, nil, zerolog.Nop(), DefaultBucketDeleteConfig())
+
+	_, err = f.put(ctx, "cat.jpg")
+	require.NoError(t, err)
+	return f
+}
+
+func (f *readOnlyFixture) put(ctx context.Context, key string) (*PutObjectOutput, error) {
+	return f.objects.PutObject(ctx, PutObjectInput{
+		BucketName: "photos",
+		Key:        key,
+		Body:       bytes.NewReader([]byte("meow")),
+		Size:       4,
+		OwnerID:    f.userID,
+	})
+}
+
+// assertWritesRefused checks that writes to "photos" fail with want while
+// reads are still served.
+func (f *readOnlyFixture) assertWritesRefused(t *testing.T, want error) {
+	t.Helper()
+	ctx := context.Background()
+
+	_, err := f.put(ctx, "dog.jpg")
+	assert.ErrorIs(t, err, want)
+	_, err = f.objects.DeleteObject(ctx, DeleteObjectInput{BucketName: "photos", Key: "cat.jpg", OwnerID: f.userID})
+	assert.ErrorIs(t, err, want)
+	_, err = f.objects.CopyObject(ctx, CopyObjectInput{
+		SourceBucket: "photos", SourceKey: "cat.jpg", DestBucket: "photos", DestKey: "copy.jpg", OwnerID: f.userID,
+	})
+	assert.ErrorIs(t, err, want)
+	_, err = f.multipart.InitiateMultipartUpload(ctx, InitiateMultipartUploadInput{BucketName: "photos", Key: "big.bin", OwnerID: f.userID})
+	assert.ErrorIs(t, err, want)
+	assert.ErrorIs(t, f.buckets.DeleteBucket(ctx, DeleteBucketInput{Name: "photos", OwnerID: f.userID}), want)
+	progress, err := f.deleter.ForceDelete(ctx, "photos", nil)
+	assert.ErrorIs(t, err, want)
+	assert.Zero(t, progress.Keys)
+
+	got, err := f.objects.GetObject(ctx, GetObjectInput{BucketName: "photos", Key: "cat.jpg", OwnerID: f.userID})
+	require.NoError(t, err)
+	body, err := io.ReadAll(got.Body)
+	got.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "meow", string(body))
+
+	head, err := f.buckets.HeadBucket(ctx, HeadBucketInput{Name: "photos", OwnerID: f.userID})
+	require.NoError(t, err)
+	assert.True(t, head.ReadOnly)
+}
+
+func TestReadOnly_Bucket(t *testing.T) {
+	ctx := context.Background()
+	f := newReadOnlyFixture(t)
+
+	// An upload started before the bucket turns read-only cannot finish
+	upload, err := f.multipart.InitiateMultipartUpload(ctx, InitiateMultipartUploadInput{BucketName: "photos", Key: "big.bin", OwnerID: f.userID})
+	require.NoError(t, err)
+
+	require.NoError(t, f.buckets.SetBucketReadOnly(ctx, SetBucketReadOnlyInput{Name: "photos", Enabled: true}))
+	f.assertWritesRefused(t, domain.ErrBucketReadOnly)
+
+	_, err = f.multipart.UploadPart(ctx, UploadPartInput{
+		BucketName: "photos", Key: "big.bin", UploadID: upload.UploadID, PartNumber: 1,
+		Body: bytes.NewReader([]byte("part")), Size: 4, OwnerID: f.userID,
+	})
+	assert.ErrorIs(t, err, domain.ErrBucketReadOnly)
+	require.NoError(t, f.multipart.AbortMultipartUpload(ctx, AbortMultipartUploadInput{
+		BucketName: "photos", Key: "big.bin", UploadID: upload.UploadID, OwnerID: f.userID,
+	}), "aborting only discards parts")
+
+	require.NoError(t, f.buckets.SetBucketReadOnly(ctx, SetBucketReadOnlyInput{Name: "photos", Enabled: false}))
+	_, err = f.put(ctx, "dog.jpg")
+	require.NoError(t, err)
+	head, err := f.buckets.HeadBucket(ctx, HeadBucketInput{Name: "photos", OwnerID: f.userID})
+	require.NoError(t, err)
+	assert.False(t, head.ReadOnly)
+}
+
+func TestReadOnly_Service(t *testing.T) {
+	ctx := context.Background()
+	f := newReadOnlyFixture(t)
+	assert.False(t, f.otherServer.Enabled(ctx))
+
+	require.NoError(t, f.readOnly.SetEnabled(ctx, true))
+	f.assertWritesRefused(t, domain.ErrServiceReadOnly)
+	_, err := f.buckets.CreateBucket(ctx, CreateBucketInput{OwnerID: f.userID, Name: "videos"})
+	assert.ErrorIs(t, err, domain.ErrServiceReadOnly)
+
+	// Other servers keep their cached state until the refresh interval passes
+	assert.False(t, f.otherServer.Enabled(ctx))
+	f.now = f.now.Add(readOnlyRefreshInterval)
+	assert.True(t, f.otherServer.Enabled(ctx))
+	assert.True(t, f.readOnly.Enabled(ctx))
+
+	require.NoError(t, f.otherServer.SetEnabled(ctx, false))
+	assert.True(t, f.readOnly.Enabled(ctx))
+	f.now = f.now.Add(readOnlyRefreshInterval)
+	assert.False(t, f.readOnly.Enabled(ctx))
+	_, err = f.put(ctx, "dog.jpg")
+	require.NoError(t, err)
+}
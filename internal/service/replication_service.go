@@ -0,0 +1,509 @@
+// Package service provides business logic services for Alexander Storage.
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/metrics"
+	"github.com/prn-tf/alexander-storage/internal/pkg/crypto"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+	"github.com/prn-tf/alexander-storage/internal/storage"
+)
+
+// ReplicationService manages bucket replication configurations and pushes
+// queued object changes to the replication destinations. Tasks are retried
+// with exponential backoff and marked failed after MaxAttempts.
+type ReplicationService struct {
+	replicationRepo repository.ReplicationRepository
+	objectRepo      repository.ObjectRepository
+	bucketRepo      repository.BucketRepository
+	storage         storage.Backend
+	encryptor       *crypto.Encryptor
+	metrics         *metrics.Metrics
+	logger          zerolog.Logger
+	config          ReplicationConfig
+
+	// Destination clients by bucket ID, rebuilt when the configuration changes
+	targetsMu sync.Mutex
+	targets   map[int64]cachedReplicationTarget
+
+	// Control
+	mu       sync.Mutex
+	running  bool
+	stopChan chan struct{}
+	doneChan chan struct{}
+
+	// now is replaceable in tests.
+	now func() time.Time
+}
+
+// ReplicationConfig contains replication worker configuration.
+type ReplicationConfig struct {
+	// Interval is how often the queue is polled when it is idle.
+	Interval time.Duration
+
+	// BatchSize is the maximum number of tasks claimed per poll.
+	BatchSize int
+
+	// Concurrency is the number of tasks replicated in parallel.
+	Concurrency int
+
+	// Lease is how long claimed tasks are hidden from other workers.
+	// It must exceed the time needed to replicate a batch.
+	Lease time.Duration
+
+	// MaxAttempts is the number of attempts before a version is marked failed.
+	MaxAttempts int
+
+	// MinBackoff is the delay before the first retry; it doubles with
+	// every failed attempt up to MaxBackoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// NewTarget connects to a destination. Default: NewS3ReplicationTarget.
+	NewTarget ReplicationTargetFactory
+}
+
+// ReplicationTarget is the destination bucket of a replication configuration.
+type ReplicationTarget interface {
+	// PutObject uploads an object version.
+	PutObject(ctx context.Context, key string, body io.Reader, size int64, contentType string, metadata map[string]string) error
+
+	// DeleteObject deletes a key, creating a delete marker in versioned buckets.
+	DeleteObject(ctx context.Context, key string) error
+}
+
+// ReplicationTargetFactory connects to the destination of a configuration.
+type ReplicationTargetFactory func(config *domain.ReplicationConfig, secretKey string) (ReplicationTarget, error)
+
+// cachedReplicationTarget is a destination client and the configuration
+// version it was built from.
+type cachedReplicationTarget struct {
+	target    ReplicationTarget
+	updatedAt time.Time
+}
+
+// errReplicationSuperseded reports a task whose version no longer exists.
+// Later tasks for the key carry the changes that replaced it.
+var errReplicationSuperseded = errors.New("replication task superseded")
+
+// NewReplicationService creates a new replication service.
+func NewReplicationService(
+	replicationRepo repository.ReplicationRepository,
+	objectRepo repository.ObjectRepository,
+	bucketRepo repository.BucketRepository,
+	storage storage.Backend,
+	encryptor *crypto.Encryptor,
+	m *metrics.Metrics,
+	logger zerolog.Logger,
+	config ReplicationConfig,
+) *ReplicationService {
+	if config.Interval <= 0 {
+		config.Interval = 5 * time.Second
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = 4
+	}
+	if config.Lease <= 0 {
+		config.Lease = 5 * time.Minute
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 10
+	}
+	if config.MinBackoff <= 0 {
+		config.MinBackoff = 10 * time.Second
+	}
+	if config.MaxBackoff < config.MinBackoff {
+		config.MaxBackoff = max(time.Hour, config.MinBackoff)
+	}
+	if config.NewTarget == nil {
+		config.NewTarget = NewS3ReplicationTarget
+	}
+
+	return &ReplicationService{
+		replicationRepo: replicationRepo,
+		objectRepo:      objectRepo,
+		bucketRepo:      bucketRepo,
+		storage:         storage,
+		encryptor:       encryptor,
+		metrics:         m,
+		logger:          logger.With().Str("service", "replication").Logger(),
+		config:          config,
+		targets:         make(map[int64]cachedReplicationTarget),
+		stopChan:        make(chan struct{}),
+		doneChan:        make(chan struct{}),
+		now:             time.Now,
+	}
+}
+
+// =============================================================================
+// Configuration
+// =============================================================================
+
+// PutBucketReplicationInput contains the data needed to configure replication.
+type PutBucketReplicationInput struct {
+	BucketName string
+	OwnerID    int64
+	Config     *domain.ReplicationConfig
+
+	// SecretKey is the destination secret key. If empty, the secret of the
+	// current configuration is kept, provided the access key is unchanged.
+	SecretKey string
+}
+
+// PutBucketReplication creates or replaces a bucket's replication configuration.
+// The bucket must have versioning enabled.
+func (s *ReplicationService) PutBucketReplication(ctx context.Context, input PutBucketReplicationInput) error {
+	bucket, err := s.ownedBucket(ctx, input.BucketName, input.OwnerID)
+	if err != nil {
+		return err
+	}
+	if !bucket.IsVersioningEnabled() {
+		return ErrReplicationRequiresVersioning
+	}
+
+	config := input.Config
+	config.BucketID = bucket.ID
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	if input.SecretKey != "" {
+		config.EncryptedSecretKey, err = s.encryptor.EncryptString(input.SecretKey)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrEncryptionFailed, err)
+		}
+	} else {
+		current, err := s.replicationRepo.GetConfig(ctx, bucket.ID)
+		if err != nil && !errors.Is(err, domain.ErrReplicationConfigNotFound) {
+			return fmt.Errorf("%w: %v", ErrInternalError, err)
+		}
+		if current == nil || current.AccessKeyID != config.AccessKeyID {
+			return ErrReplicationSecretRequired
+		}
+		config.EncryptedSecretKey = current.EncryptedSecretKey
+	}
+
+	now := s.now().UTC()
+	config.CreatedAt = now
+	config.UpdatedAt = now
+	if err := s.replicationRepo.PutConfig(ctx, config); err != nil {
+		return fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	s.logger.Info().Ctx(ctx).
+		Str("bucket", bucket.Name).
+		Str("endpoint", config.Endpoint).
+		Str("destination_bucket", config.DestinationBucket).
+		Int("rules", len(config.Rules)).
+		Msg("bucket replication configured")
+	return nil
+}
+
+// GetBucketReplication returns a bucket's replication configuration.
+func (s *ReplicationService) GetBucketReplication(ctx context.Context, bucketName string, ownerID int64) (*domain.ReplicationConfig, error) {
+	bucket, err := s.ownedBucket(ctx, bucketName, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := s.replicationRepo.GetConfig(ctx, bucket.ID)
+	if err != nil {
+		if errors.Is(err, domain.ErrReplicationConfigNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	return config, nil
+}
+
+// DeleteBucketReplication removes a bucket's replication configuration.
+// Versions not replicated yet are marked failed.
+func (s *ReplicationService) DeleteBucketReplication(ctx context.Context, bucketName string, ownerID int64) error {
+	bucket, err := s.ownedBucket(ctx, bucketName, ownerID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.replicationRepo.DeleteConfig(ctx, bucket.ID); err != nil {
+		if errors.Is(err, domain.ErrReplicationConfigNotFound) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	s.logger.Info().Ctx(ctx).Str("bucket", bucket.Name).Msg("bucket replication removed")
+	return nil
+}
+
+// ownedBucket returns the named bucket if ownerID may manage it.
+func (s *ReplicationService) ownedBucket(ctx context.Context, bucketName string, ownerID int64) (*domain.Bucket, error) {
+	bucket, err := s.bucketRepo.GetByName(ctx, bucketName)
+	if err != nil {
+		if errors.Is(err, domain.ErrBucketNotFound) {
+			return nil, domain.ErrBucketNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	if ownerID > 0 && bucket.OwnerID != ownerID {
+		return nil, ErrBucketAccessDenied
+	}
+	return bucket, nil
+}
+
+// =============================================================================
+// Worker
+// =============================================================================
+
+// Start begins replicating queued changes in the background.
+func (s *ReplicationService) Start() {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = true
+	s.mu.Unlock()
+
+	s.logger.Info().
+		Dur("interval", s.config.Interval).
+		Int("concurrency", s.config.Concurrency).
+		Int("max_attempts", s.config.MaxAttempts).
+		Msg("Starting replication worker")
+
+	go s.runLoop()
+}
+
+// Stop stops the worker after the tasks in progress.
+func (s *ReplicationService) Stop() {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = false
+	s.mu.Unlock()
+
+	close(s.stopChan)
+	<-s.doneChan
+
+	s.logger.Info().Msg("Replication worker stopped")
+}
+
+// runLoop polls the queue, without waiting as long as full batches come back.
+func (s *ReplicationService) runLoop() {
+	defer close(s.doneChan)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-s.stopChan
+		cancel()
+	}()
+
+	for {
+		claimed, err := s.ProcessDue(ctx)
+		if err != nil && ctx.Err() == nil {
+			s.logger.Error().Ctx(ctx).Err(err).Msg("Failed to process replication queue")
+		}
+		if claimed == s.config.BatchSize && err == nil {
+			continue
+		}
+
+		select {
+		case <-time.After(s.config.Interval):
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+// ProcessDue replicates one batch of due tasks and returns the number claimed.
+func (s *ReplicationService) ProcessDue(ctx context.Context) (int, error) {
+	now := s.now()
+	tasks, err := s.replicationRepo.ClaimDue(ctx, now, now.Add(s.config.Lease), s.config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	// A batch holds at most one task per key, so tasks can run in parallel
+	sem := make(chan struct{}, s.config.Concurrency)
+	var wg sync.WaitGroup
+	for _, task := range tasks {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(task *domain.ReplicationTask) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			s.process(ctx, task)
+		}(task)
+	}
+	wg.Wait()
+
+	s.recordBacklog(ctx)
+	return len(tasks), nil
+}
+
+// process replicates a task and records the outcome.
+func (s *ReplicationService) process(ctx context.Context, task *domain.ReplicationTask) {
+	err := s.replicate(ctx, task)
+	if ctx.Err() != nil {
+		// Shutting down; the task becomes due again when its lease expires
+		return
+	}
+
+	logger := s.logger.With().
+		Int64("task_id", task.ID).
+		Int64("bucket_id", task.BucketID).
+		Str("key", task.Key).
+		Str("operation", string(task.Operation)).
+		Logger()
+
+	switch {
+	case err == nil:
+		s.finish(ctx, task, domain.ReplicationStatusCompleted, "success", logger)
+	case errors.Is(err, errReplicationSuperseded):
+		s.finish(ctx, task, domain.ReplicationStatusNone, "superseded", logger)
+	default:
+		task.Attempts++
+		task.LastError = err.Error()
+		if task.Attempts >= s.config.MaxAttempts {
+			logger.Warn().Err(err).Int("attempts", task.Attempts).Msg("Replication failed; giving up")
+			s.finish(ctx, task, domain.ReplicationStatusFailed, "failed", logger)
+			return
+		}
+
+		task.NextAttemptAt = s.now().Add(s.backoff(task.Attempts))
+		logger.Debug().Err(err).Int("attempts", task.Attempts).Time("next_attempt_at", task.NextAttemptAt).Msg("Replication failed; retrying")
+		if err := s.replicationRepo.Retry(ctx, task); err != nil {
+			logger.Error().Err(err).Msg("Failed to reschedule replication task")
+		}
+		s.recordResult(task, "retry")
+	}
+}
+
+// finish removes a task and sets the status of its version.
+func (s *ReplicationService) finish(ctx context.Context, task *domain.ReplicationTask, status domain.ReplicationStatus, result string, logger zerolog.Logger) {
+	if err := s.replicationRepo.Complete(ctx, task, status); err != nil {
+		logger.Error().Err(err).Msg("Failed to complete replication task")
+		return
+	}
+	s.recordResult(task, result)
+}
+
+// replicate applies a task to its bucket's destination.
+func (s *ReplicationService) replicate(ctx context.Context, task *domain.ReplicationTask) error {
+	bucket, err := s.bucketRepo.GetByID(ctx, task.BucketID)
+	if err != nil {
+		if errors.Is(err, domain.ErrBucketNotFound) {
+			return errReplicationSuperseded
+		}
+		return fmt.Errorf("failed to get bucket: %w", err)
+	}
+
+	config, err := s.replicationRepo.GetConfig(ctx, task.BucketID)
+	if err != nil {
+		if errors.Is(err, domain.ErrReplicationConfigNotFound) {
+			return errReplicationSuperseded
+		}
+		return err
+	}
+
+	target, err := s.target(config)
+	if err != nil {
+		return err
+	}
+
+	obj, err := s.objectRepo.GetByID(ctx, task.ObjectID)
+	if err != nil {
+		if errors.Is(err, domain.ErrObjectNotFound) {
+			return errReplicationSuperseded
+		}
+		return fmt.Errorf("failed to get object: %w", err)
+	}
+
+	if task.Operation == domain.ReplicationOperationDelete {
+		return target.DeleteObject(ctx, task.Key)
+	}
+
+	if obj.ContentHash == nil {
+		return errReplicationSuperseded
+	}
+	reader, err := s.storage.Retrieve(storage.WithResidency(ctx, bucket.Residency), *obj.ContentHash)
+	if err != nil {
+		// Without versioning an overwritten version's content may be collected
+		if errors.Is(err, storage.ErrBlobNotFound) && !obj.IsLatest {
+			return errReplicationSuperseded
+		}
+		return fmt.Errorf("failed to read object content: %w", err)
+	}
+	defer reader.Close()
+
+	return target.PutObject(ctx, obj.Key, reader, obj.Size, obj.ContentType, obj.Metadata)
+}
+
+// target returns the destination client of a configuration.
+func (s *ReplicationService) target(config *domain.ReplicationConfig) (ReplicationTarget, error) {
+	s.targetsMu.Lock()
+	defer s.targetsMu.Unlock()
+
+	if cached, ok := s.targets[config.BucketID]; ok && cached.updatedAt.Equal(config.UpdatedAt) {
+		return cached.target, nil
+	}
+
+	secretKey, err := s.encryptor.DecryptString(config.EncryptedSecretKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDecryptionFailed, err)
+	}
+	target, err := s.config.NewTarget(config, secretKey)
+	if err != nil {
+		return nil, err
+	}
+
+	s.targets[config.BucketID] = cachedReplicationTarget{target: target, updatedAt: config.UpdatedAt}
+	return target, nil
+}
+
+// backoff returns the delay after the given number of failed attempts.
+func (s *ReplicationService) backoff(attempts int) time.Duration {
+	delay := s.config.MinBackoff
+	for i := 1; i < attempts && delay < s.config.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, s.config.MaxBackoff)
+}
+
+// recordResult counts a task outcome.
+func (s *ReplicationService) recordResult(task *domain.ReplicationTask, result string) {
+	if s.metrics != nil {
+		s.metrics.RecordReplication(string(task.Operation), result)
+	}
+}
+
+// recordBacklog exports the queue length and the age of the oldest task.
+func (s *ReplicationService) recordBacklog(ctx context.Context) {
+	if s.metrics == nil {
+		return
+	}
+	pending, oldest, err := s.replicationRepo.Backlog(ctx)
+	if err != nil {
+		s.logger.Warn().Ctx(ctx).Err(err).Msg("Failed to get replication backlog")
+		return
+	}
+	var lag time.Duration
+	if oldest != nil {
+		lag = s.now().Sub(*oldest)
+	}
+	s.metrics.RecordReplicationBacklog(pending, lag)
+}
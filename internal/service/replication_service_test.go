@@ -0,0 +1,232 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/lock"
+	"github.com/prn-tf/alexander-storage/internal/pkg/crypto"
+	"github.com/prn-tf/alexander-storage/internal/repository/sqlite"
+	"github.com/prn-tf/alexander-storage/internal/storage/filesystem"
+)
+
+// fakeReplicationTarget records the changes pushed to it and fails while err is set.
+type fakeReplicationTarget struct {
+	mu      sync.Mutex
+	objects map[string]string
+	deletes []string
+	err     error
+}
+
+func (f *fakeReplicationTarget) PutObject(_ context.Context, key string, body io.Reader, _ int64, _ string, _ map[string]string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	f.objects[key] = string(data)
+	return nil
+}
+
+func (f *fakeReplicationTarget) DeleteObject(_ context.Context, key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	delete(f.objects, key)
+	f.deletes = append(f.deletes, key)
+	return nil
+}
+
+type replicationFixture struct {
+	objects     *ObjectService
+	replication *ReplicationService
+	target      *fakeReplicationTarget
+	secretKey   string
+}
+
+// newReplicationFixture returns services backed by SQLite and the filesystem
+// with a versioned bucket "source" that replicates to a fake target.
+func newReplicationFixture(t *testing.T, config ReplicationConfig) *replicationFixture {
+	t.Helper()
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	db, err := sqlite.NewDB(ctx, sqlite.DefaultConfig(filepath.Join(dir, "alexander.db")), zerolog.Nop())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, db.Migrate(ctx))
+
+	store, err := filesystem.NewStorage(filesystem.Config{
+		DataDir: filepath.Join(dir, "blobs"),
+		TempDir: filepath.Join(dir, "tmp"),
+	}, zerolog.Nop())
+	require.NoError(t, err)
+
+	encryptor, err := crypto.NewEncryptor(bytes.Repeat([]byte{7}, crypto.KeySize))
+	require.NoError(t, err)
+
+	user := domain.NewUser("owner", "owner@example.com", "hash")
+	require.NoError(t, sqlite.NewUserRepository(db).Create(ctx, user))
+
+	bucketRepo := sqlite.NewBucketRepository(db)
+	bucket := domain.NewBucket(user.ID, "source")
+	bucket.Versioning = domain.VersioningEnabled
+	require.NoError(t, bucketRepo.Create(ctx, bucket))
+
+	f := &replicationFixture{target: &fakeReplicationTarget{objects: make(map[string]string)}}
+	config.NewTarget = func(_ *domain.ReplicationConfig, secretKey string) (ReplicationTarget, error) {
+		f.secretKey = secretKey
+		return f.target, nil
+	}
+
+	objectRepo := sqlite.NewObjectRepository(db)
+	replicationRepo := sqlite.NewReplicationRepository(db)
+	f.objects = NewObjectService(objectRepo, sqlite.NewBlobRepository(db), bucketRepo, nil, replicationRepo, nil, nil, nil, nil,
+		sqlite.NewTxManager(db), store, lock.NewMemoryLocker(), nil, zerolog.Nop())
+	f.replication = NewReplicationService(replicationRepo, objectRepo, bucketRepo, store, encryptor, nil, zerolog.Nop(), config)
+
+	err = f.replication.PutBucketReplication(ctx, PutBucketReplicationInput{
+		BucketName: "source",
+		OwnerID:    user.ID,
+		SecretKey:  "destination-secret",
+		Config: &domain.ReplicationConfig{
+			Endpoint:          "https://s3.example.com",
+			DestinationBucket: "replica",
+			AccessKeyID:       "AKIDEXAMPLE",
+			Rules: []domain.ReplicationRule{
+				{ID: "docs", Enabled: true, Prefix: "docs/", DeleteMarkers: true},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	return f
+}
+
+func (f *replicationFixture) put(t *testing.T, key, body string) {
+	t.Helper()
+	_, err := f.objects.PutObject(context.Background(), PutObjectInput{
+		BucketName: "source",
+		Key:        key,
+		Body:       bytes.NewReader([]byte(body)),
+		Size:       int64(len(body)),
+	})
+	require.NoError(t, err)
+}
+
+func (f *replicationFixture) status(t *testing.T, key, versionID string) domain.ReplicationStatus {
+	t.Helper()
+	output, err := f.objects.HeadObject(context.Background(), HeadObjectInput{BucketName: "source", Key: key, VersionID: versionID})
+	require.NoError(t, err)
+	return output.ReplicationStatus
+}
+
+func TestReplicationService_ReplicatesMatchingChanges(t *testing.T) {
+	ctx := context.Background()
+	f := newReplicationFixture(t, ReplicationConfig{})
+
+	f.put(t, "docs/a.txt", "first")
+	f.put(t, "docs/a.txt", "second")
+	f.put(t, "images/b.png", "not replicated")
+
+	assert.Equal(t, domain.ReplicationStatusPending, f.status(t, "docs/a.txt", ""))
+	assert.Equal(t, domain.ReplicationStatusNone, f.status(t, "images/b.png", ""))
+
+	// Changes to one key are applied one batch at a time, in order
+	claimed, err := f.replication.ProcessDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, claimed)
+	assert.Equal(t, "first", f.target.objects["docs/a.txt"])
+
+	claimed, err = f.replication.ProcessDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, claimed)
+	assert.Equal(t, "second", f.target.objects["docs/a.txt"])
+	assert.Equal(t, domain.ReplicationStatusCompleted, f.status(t, "docs/a.txt", ""))
+	assert.Equal(t, "destination-secret", f.secretKey)
+
+	_, err = f.objects.DeleteObject(ctx, DeleteObjectInput{BucketName: "source", Key: "docs/a.txt"})
+	require.NoError(t, err)
+	claimed, err = f.replication.ProcessDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, claimed)
+	assert.Equal(t, []string{"docs/a.txt"}, f.target.deletes)
+	assert.NotContains(t, f.target.objects, "docs/a.txt")
+
+	claimed, err = f.replication.ProcessDue(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, claimed)
+}
+
+func TestReplicationService_RetriesThenFails(t *testing.T) {
+	ctx := context.Background()
+	f := newReplicationFixture(t, ReplicationConfig{
+		MaxAttempts: 2,
+		MinBackoff:  time.Minute,
+		MaxBackoff:  time.Minute,
+	})
+	f.target.err = errors.New("destination unavailable")
+
+	f.put(t, "docs/a.txt", "content")
+
+	claimed, err := f.replication.ProcessDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, claimed)
+	assert.Equal(t, domain.ReplicationStatusPending, f.status(t, "docs/a.txt", ""))
+
+	// The retry is not due before the backoff has passed
+	claimed, err = f.replication.ProcessDue(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, claimed)
+
+	f.replication.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	claimed, err = f.replication.ProcessDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, claimed)
+	assert.Equal(t, domain.ReplicationStatusFailed, f.status(t, "docs/a.txt", ""))
+}
+
+func TestReplicationService_PutBucketReplication(t *testing.T) {
+	ctx := context.Background()
+	f := newReplicationFixture(t, ReplicationConfig{})
+
+	config := &domain.ReplicationConfig{
+		Endpoint:          "https://s3.example.com",
+		DestinationBucket: "replica",
+		AccessKeyID:       "AKIDOTHER",
+		Rules:             []domain.ReplicationRule{{ID: "all", Enabled: true}},
+	}
+
+	// A new access key needs its secret
+	err := f.replication.PutBucketReplication(ctx, PutBucketReplicationInput{BucketName: "source", Config: config})
+	assert.ErrorIs(t, err, ErrReplicationSecretRequired)
+
+	// The stored secret is kept while the access key is unchanged
+	config.AccessKeyID = "AKIDEXAMPLE"
+	require.NoError(t, f.replication.PutBucketReplication(ctx, PutBucketReplicationInput{BucketName: "source", Config: config}))
+
+	config.Rules = nil
+	err = f.replication.PutBucketReplication(ctx, PutBucketReplicationInput{BucketName: "source", Config: config})
+	assert.ErrorIs(t, err, domain.ErrInvalidReplicationConfig)
+
+	require.NoError(t, f.replication.DeleteBucketReplication(ctx, "source", 0))
+	_, err = f.replication.GetBucketReplication(ctx, "source", 0)
+	assert.ErrorIs(t, err, domain.ErrReplicationConfigNotFound)
+}
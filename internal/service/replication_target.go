@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+)
+
+// s3ReplicationTarget replicates to a bucket on an S3-compatible endpoint.
+type s3ReplicationTarget struct {
+	client *s3.Client
+	bucket string
+}
+
+// NewS3ReplicationTarget connects to the destination of a configuration
+// using path-style requests, which every S3-compatible server supports.
+func NewS3ReplicationTarget(config *domain.ReplicationConfig, secretKey string) (ReplicationTarget, error) {
+	region := config.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	client := s3.New(s3.Options{
+		Region:       region,
+		BaseEndpoint: aws.String(config.Endpoint),
+		Credentials:  credentials.NewStaticCredentialsProvider(config.AccessKeyID, secretKey, ""),
+		UsePathStyle: true,
+		// Trailing checksums need aws-chunked uploads, which not every
+		// S3-compatible server accepts
+		RequestChecksumCalculation: aws.RequestChecksumCalculationWhenRequired,
+	})
+
+	return &s3ReplicationTarget{client: client, bucket: config.DestinationBucket}, nil
+}
+
+// PutObject uploads an object version. The body is streamed from storage,
+// so the payload is sent unsigned instead of hashed up front.
+func (t *s3ReplicationTarget) PutObject(ctx context.Context, key string, body io.Reader, size int64, contentType string, metadata map[string]string) error {
+	_, err := t.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(t.bucket),
+		Key:           aws.String(key),
+		Body:          body,
+		ContentLength: aws.Int64(size),
+		ContentType:   aws.String(contentType),
+		Metadata:      metadata,
+	}, s3.WithAPIOptions(v4.SwapComputePayloadSHA256ForUnsignedPayloadMiddleware))
+	if err != nil {
+		return fmt.Errorf("failed to put object at destination: %w", err)
+	}
+	return nil
+}
+
+// DeleteObject deletes a key at the destination.
+func (t *s3ReplicationTarget) DeleteObject(ctx context.Context, key string) error {
+	_, err := t.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(t.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete object at destination: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,455 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/lock"
+	"github.com/prn-tf/alexander-storage/internal/metrics"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+	"github.com/prn-tf/alexander-storage/internal/storage"
+)
+
+// BlobScrubber periodically re-hashes stored blobs to detect content that no
+// longer matches its hash (bitrot), and optionally repairs corrupt blobs from
+// a second copy such as a replica or cold tier.
+//
+// Blobs encrypted at rest are skipped: their stored bytes are ciphertext and
+// do not hash to their content hash.
+type BlobScrubber struct {
+	blobRepo     repository.BlobRepository
+	storage      storage.Backend
+	repairSource storage.Backend
+	locker       lock.Locker
+	metrics      *metrics.Metrics
+	logger       zerolog.Logger
+	config       ScrubConfig
+
+	// Control
+	mu       sync.Mutex
+	running  bool
+	stopChan chan struct{}
+	doneChan chan struct{}
+}
+
+// ScrubConfig contains integrity scrub configuration.
+type ScrubConfig struct {
+	// Interval is how often to look for blobs due for verification.
+	Interval time.Duration
+
+	// VerifyAfter is how long a verification stays valid; blobs last
+	// verified longer ago are verified again.
+	VerifyAfter time.Duration
+
+	// BatchSize is the number of blobs listed at a time.
+	BatchSize int
+
+	// BytesPerSecond limits the read rate so scrubbing does not starve
+	// client traffic. Zero means unlimited.
+	BytesPerSecond int64
+
+	// TempDir holds repair downloads until they are verified. Empty uses
+	// the system temp directory.
+	TempDir string
+}
+
+// DefaultScrubConfig returns sensible defaults.
+func DefaultScrubConfig() ScrubConfig {
+	return ScrubConfig{
+		Interval:       1 * time.Hour,
+		VerifyAfter:    30 * 24 * time.Hour,
+		BatchSize:      100,
+		BytesPerSecond: 50 << 20,
+	}
+}
+
+// Scrub results reported in metrics.
+const (
+	scrubResultOK         = "ok"
+	scrubResultCorrupt    = "corrupt"
+	scrubResultRepaired   = "repaired"
+	scrubResultMissing    = "missing"
+	scrubResultUnreadable = "error"
+)
+
+// errRepairCopyCorrupt indicates that the repair source holds a bad copy too.
+var errRepairCopyCorrupt = errors.New("repair copy is corrupt too")
+
+// NewBlobScrubber creates a new integrity scrubber. repairSource may be nil,
+// in which case corrupt blobs are only flagged.
+func NewBlobScrubber(
+	blobRepo repository.BlobRepository,
+	storage storage.Backend,
+	repairSource storage.Backend,
+	locker lock.Locker,
+	m *metrics.Metrics,
+	logger zerolog.Logger,
+	config ScrubConfig,
+) *BlobScrubber {
+	return &BlobScrubber{
+		blobRepo:     blobRepo,
+		storage:      storage,
+		repairSource: repairSource,
+		locker:       locker,
+		metrics:      m,
+		logger:       logger.With().Str("service", "scrub").Logger(),
+		config:       config,
+		stopChan:     make(chan struct{}),
+		doneChan:     make(chan struct{}),
+	}
+}
+
+// Start begins the scrub scheduler.
+func (s *BlobScrubber) Start() {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = true
+	s.mu.Unlock()
+
+	s.logger.Info().
+		Dur("interval", s.config.Interval).
+		Dur("verify_after", s.config.VerifyAfter).
+		Int64("bytes_per_second", s.config.BytesPerSecond).
+		Bool("repair", s.repairSource != nil).
+		Msg("Starting blob scrubber")
+
+	go s.runLoop()
+}
+
+// Stop stops the scrub scheduler, interrupting a run in progress.
+func (s *BlobScrubber) Stop() {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = false
+	s.mu.Unlock()
+
+	close(s.stopChan)
+	<-s.doneChan
+
+	s.logger.Info().Msg("Blob scrubber stopped")
+}
+
+// runLoop is the main scrub loop.
+func (s *BlobScrubber) runLoop() {
+	defer close(s.doneChan)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-s.stopChan
+		cancel()
+	}()
+
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		s.RunOnce(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+// ScrubResult contains the result of a scrub run.
+type ScrubResult struct {
+	// Verified is the number of blobs whose content matched their hash.
+	Verified int `json:"verified"`
+
+	// Corrupt is the number of blobs found missing or not matching their
+	// hash and left flagged.
+	Corrupt int `json:"corrupt"`
+
+	// Repaired is the number of corrupt blobs restored from the repair source.
+	Repaired int `json:"repaired"`
+
+	// Bytes is the number of bytes re-hashed.
+	Bytes int64 `json:"bytes"`
+
+	// Errors is the number of blobs that could not be checked.
+	Errors int `json:"errors"`
+
+	// Duration is how long the run took.
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// RunOnce verifies every blob due for verification.
+// This can be called manually or by the scheduler.
+func (s *BlobScrubber) RunOnce(ctx context.Context) ScrubResult {
+	start := time.Now()
+	result := ScrubResult{}
+
+	lockKey := lock.Keys.BlobScrub()
+	lockTTL := s.config.Interval / 2
+	if lockTTL < 5*time.Minute {
+		lockTTL = 5 * time.Minute
+	}
+
+	acquired, err := s.locker.Acquire(ctx, lockKey, lockTTL)
+	if err != nil {
+		s.logger.Error().Ctx(ctx).Err(err).Msg("Failed to acquire scrub lock")
+		result.Errors++
+		result.Duration = time.Since(start)
+		return result
+	}
+	if !acquired {
+		s.logger.Debug().Ctx(ctx).Msg("Scrub lock held by another process, skipping run")
+		result.Duration = time.Since(start)
+		return result
+	}
+	defer func() {
+		if _, err := s.locker.Release(context.Background(), lockKey); err != nil {
+			s.logger.Error().Ctx(ctx).Err(err).Msg("Failed to release scrub lock")
+		}
+	}()
+
+	// Blobs verified during this run are newer than the cutoff, so paging
+	// ends once every due blob has been checked. A blob that cannot be
+	// checked stays due, so the run stops after a page with errors rather
+	// than listing it again.
+	cutoff := start.Add(-s.config.VerifyAfter)
+	for ctx.Err() == nil {
+		blobs, err := s.blobRepo.ListUnverified(ctx, cutoff, s.config.BatchSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				s.logger.Error().Ctx(ctx).Err(err).Msg("Failed to list blobs to verify")
+				result.Errors++
+			}
+			break
+		}
+
+		failed := result.Errors
+		for _, blob := range blobs {
+			if ctx.Err() != nil {
+				break
+			}
+			s.scrubBlob(ctx, blob, &result)
+		}
+
+		if len(blobs) < s.config.BatchSize || result.Errors > failed {
+			break
+		}
+	}
+
+	result.Duration = time.Since(start)
+
+	if s.metrics != nil {
+		s.metrics.ScrubLastRunTime.SetToCurrentTime()
+		if count, err := s.blobRepo.CountCorrupt(ctx); err == nil {
+			s.metrics.ScrubCorruptBlobs.Set(float64(count))
+		}
+	}
+
+	if result.Verified+result.Corrupt+result.Repaired+result.Errors == 0 {
+		s.logger.Debug().Ctx(ctx).Msg("No blobs due for verification")
+		return result
+	}
+
+	event := s.logger.Info().Ctx(ctx)
+	if result.Corrupt > 0 {
+		event = s.logger.Error().Ctx(ctx)
+	}
+	event.
+		Int("verified", result.Verified).
+		Int("corrupt", result.Corrupt).
+		Int("repaired", result.Repaired).
+		Int64("bytes", result.Bytes).
+		Int("errors", result.Errors).
+		Dur("duration", result.Duration).
+		Msg("Blob scrub run completed")
+
+	return result
+}
+
+// scrubBlob verifies one blob, repairs it if it is corrupt and a repair
+// source is configured, and records the outcome.
+func (s *BlobScrubber) scrubBlob(ctx context.Context, blob *domain.Blob, result *ScrubResult) {
+	logger := s.logger.With().Str("content_hash", blob.ContentHash).Logger()
+
+	n, err := s.verify(ctx, s.storage, blob.ContentHash)
+	result.Bytes += n
+	outcome := scrubResultOK
+	switch {
+	case err == nil:
+	case storage.IsNotFound(err):
+		outcome = scrubResultMissing
+	case storage.IsCorrupt(err):
+		outcome = scrubResultCorrupt
+	default:
+		if ctx.Err() == nil {
+			logger.Warn().Err(err).Msg("Failed to read blob for verification")
+			result.Errors++
+			s.recordMetric(scrubResultUnreadable, n)
+		}
+		return
+	}
+
+	if outcome != scrubResultOK {
+		logger.Error().Err(err).Int64("size", blob.Size).Msg("Blob content is corrupt")
+		if s.repairSource != nil {
+			if err := s.repair(ctx, blob.ContentHash); err != nil {
+				logger.Warn().Err(err).Msg("Failed to repair blob")
+			} else {
+				logger.Info().Msg("Repaired blob from repair source")
+				outcome = scrubResultRepaired
+			}
+		}
+	}
+
+	corrupt := outcome == scrubResultCorrupt || outcome == scrubResultMissing
+	if err := s.blobRepo.RecordVerification(ctx, blob.ContentHash, time.Now(), corrupt); err != nil {
+		logger.Warn().Err(err).Msg("Failed to record blob verification")
+		result.Errors++
+		return
+	}
+
+	switch outcome {
+	case scrubResultOK:
+		result.Verified++
+	case scrubResultRepaired:
+		result.Repaired++
+	default:
+		result.Corrupt++
+	}
+	s.recordMetric(outcome, n)
+}
+
+// verify re-hashes a blob's content from the given backend. Content that
+// does not match its hash is reported as storage.ErrBlobCorrupt. Returns
+// the number of bytes read.
+func (s *BlobScrubber) verify(ctx context.Context, backend storage.Backend, contentHash string) (int64, error) {
+	reader, err := backend.Retrieve(ctx, contentHash)
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+
+	hasher := sha256.New()
+	n, err := io.Copy(hasher, s.throttle(ctx, reader))
+	if err != nil {
+		return n, err
+	}
+	if hex.EncodeToString(hasher.Sum(nil)) != contentHash {
+		return n, storage.ErrBlobCorrupt
+	}
+	return n, nil
+}
+
+// repair replaces a corrupt blob with an intact copy from the repair
+// source.
+func (s *BlobScrubber) repair(ctx context.Context, contentHash string) error {
+	return restoreBlob(ctx, s.repairSource, s.storage, contentHash, s.config.TempDir, func(r io.Reader) io.Reader {
+		return s.throttle(ctx, r)
+	})
+}
+
+// restoreBlob copies a blob from src to dst, replacing any damaged content
+// dst holds. The copy is downloaded through wrap and verified before the
+// existing content is removed.
+func restoreBlob(ctx context.Context, src, dst storage.Backend, contentHash, tempDir string, wrap func(io.Reader) io.Reader) error {
+	reader, err := src.Retrieve(ctx, contentHash)
+	if err != nil {
+		return fmt.Errorf("failed to read repair copy: %w", err)
+	}
+	defer reader.Close()
+
+	temp, err := os.CreateTemp(tempDir, "scrub-repair-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer func() {
+		_ = temp.Close()
+		_ = os.Remove(temp.Name())
+	}()
+
+	hasher := sha256.New()
+	size, err := io.Copy(io.MultiWriter(temp, hasher), wrap(reader))
+	if err != nil {
+		return fmt.Errorf("failed to download repair copy: %w", err)
+	}
+	if hex.EncodeToString(hasher.Sum(nil)) != contentHash {
+		return errRepairCopyCorrupt
+	}
+	if _, err := temp.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to rewind repair copy: %w", err)
+	}
+
+	// Store keeps existing content, so the corrupt copy must go first
+	if err := dst.Delete(ctx, contentHash); err != nil && !storage.IsNotFound(err) {
+		return fmt.Errorf("failed to remove corrupt content: %w", err)
+	}
+	stored, err := dst.Store(ctx, temp, size)
+	if err != nil {
+		return fmt.Errorf("failed to store repair copy: %w", err)
+	}
+	if stored != contentHash {
+		return fmt.Errorf("repair copy stored as %s", stored)
+	}
+	return nil
+}
+
+// recordMetric records a verified blob if metrics are enabled.
+func (s *BlobScrubber) recordMetric(result string, bytes int64) {
+	if s.metrics != nil {
+		s.metrics.RecordScrubBlob(result, bytes)
+	}
+}
+
+// throttle limits reads from r to the configured rate.
+func (s *BlobScrubber) throttle(ctx context.Context, r io.Reader) io.Reader {
+	if s.config.BytesPerSecond <= 0 {
+		return r
+	}
+	return &throttledReader{ctx: ctx, r: r, rate: s.config.BytesPerSecond, start: time.Now()}
+}
+
+// throttledReader is a reader that sleeps as needed to keep its average
+// rate at or below rate bytes per second.
+type throttledReader struct {
+	ctx   context.Context
+	r     io.Reader
+	rate  int64
+	start time.Time
+	read  int64
+}
+
+// Read implements io.Reader.
+func (t *throttledReader) Read(p []byte) (int, error) {
+	// Small reads keep the sleeps short and the rate smooth
+	if limit := t.rate / 10; limit > 0 && int64(len(p)) > limit {
+		p = p[:limit]
+	}
+	n, err := t.r.Read(p)
+	t.read += int64(n)
+
+	due := time.Duration(float64(t.read) / float64(t.rate) * float64(time.Second))
+	if wait := due - time.Since(t.start); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-t.ctx.Done():
+			return n, t.ctx.Err()
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/lock"
+	"github.com/prn-tf/alexander-storage/internal/storage/filesystem"
+)
+
+func newTestFilesystem(t *testing.T, dir string) *filesystem.Storage {
+	t.Helper()
+	fs, err := filesystem.NewStorage(filesystem.Config{
+		DataDir: filepath.Join(dir, "data"),
+		TempDir: filepath.Join(dir, "tmp"),
+	}, zerolog.Nop())
+	require.NoError(t, err)
+	return fs
+}
+
+func TestBlobScrubber_DetectsAndRepairsCorruption(t *testing.T) {
+	ctx := context.Background()
+	primary := newTestFilesystem(t, t.TempDir())
+	replica := newTestFilesystem(t, t.TempDir())
+
+	content := []byte("the quick brown fox")
+	intact, err := primary.Store(ctx, bytes.NewReader([]byte("intact")), 6)
+	require.NoError(t, err)
+	damaged, err := primary.Store(ctx, bytes.NewReader(content), int64(len(content)))
+	require.NoError(t, err)
+	lost, err := primary.Store(ctx, bytes.NewReader([]byte("lost")), 4)
+	require.NoError(t, err)
+	_, err = replica.Store(ctx, bytes.NewReader(content), int64(len(content)))
+	require.NoError(t, err)
+
+	// Flip a byte in one blob and remove another, which has no replica
+	path := primary.GetPath(damaged)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	data[0] ^= 0xff
+	require.NoError(t, os.Chmod(path, 0600))
+	require.NoError(t, os.WriteFile(path, data, 0600))
+	require.NoError(t, primary.Delete(ctx, lost))
+
+	blobRepo := new(mockBlobRepository2)
+	blobRepo.On("ListUnverified", mock.Anything, mock.Anything, 10).Return([]*domain.Blob{
+		{ContentHash: intact, Size: 6},
+		{ContentHash: damaged, Size: int64(len(content))},
+		{ContentHash: lost, Size: 4},
+	}, nil).Once()
+	blobRepo.On("RecordVerification", mock.Anything, intact, mock.Anything, false).Return(nil).Once()
+	blobRepo.On("RecordVerification", mock.Anything, damaged, mock.Anything, false).Return(nil).Once()
+	blobRepo.On("RecordVerification", mock.Anything, lost, mock.Anything, true).Return(nil).Once()
+
+	config := DefaultScrubConfig()
+	config.BatchSize = 10
+	config.TempDir = t.TempDir()
+	scrubber := NewBlobScrubber(blobRepo, primary, replica, lock.NewNoOpLocker(), nil, zerolog.Nop(), config)
+
+	result := scrubber.RunOnce(ctx)
+
+	require.Equal(t, 1, result.Verified)
+	require.Equal(t, 1, result.Repaired)
+	require.Equal(t, 1, result.Corrupt)
+	require.Zero(t, result.Errors)
+	blobRepo.AssertExpectations(t)
+
+	repaired, err := os.ReadFile(primary.GetPath(damaged))
+	require.NoError(t, err)
+	require.Equal(t, content, repaired)
+}
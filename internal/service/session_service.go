@@ -3,9 +3,13 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 	"golang.org/x/crypto/bcrypt"
 
@@ -20,18 +24,58 @@ type SessionService struct {
 	logger      zerolog.Logger
 
 	// Session configuration
-	sessionDuration time.Duration
+	sessionDuration  time.Duration
+	idleTimeout      time.Duration
+	rememberDuration time.Duration
+
+	// External directory authentication
+	authenticator  ExternalAuthenticator
+	provisionUsers bool
+	localFallback  bool
+
+	// Single sign-on
+	ssoProvisionUsers bool
+	ssoDefaultRole    domain.UserRole
 }
 
 // SessionServiceConfig contains configuration for the session service.
 type SessionServiceConfig struct {
 	SessionDuration time.Duration // Default: 24 hours
+
+	// IdleTimeout ends a session that has not been used for this long.
+	// Default: 30 minutes; negative disables the idle timeout.
+	IdleTimeout time.Duration
+
+	// RememberDuration is how long a "remember me" login can resume expired
+	// or idle sessions. Default: 30 days; negative disables remember-me.
+	RememberDuration time.Duration
+
+	// Authenticator verifies logins against an external directory (e.g. LDAP).
+	// If nil, only local passwords are checked.
+	Authenticator ExternalAuthenticator
+
+	// ProvisionUsers creates a local user on the first directory login.
+	ProvisionUsers bool
+
+	// LocalFallback also accepts local passwords when the directory rejects
+	// the credentials or is unreachable, e.g. for a break-glass admin.
+	LocalFallback bool
+
+	// SSOProvisionUsers creates a local user on the first single sign-on
+	// login from an email address that matches no user.
+	SSOProvisionUsers bool
+
+	// SSODefaultRole is the role of users provisioned by single sign-on.
+	// With RoleNone an admin must assign a role before they can use the dashboard.
+	SSODefaultRole domain.UserRole
 }
 
 // DefaultSessionServiceConfig returns the default session service configuration.
 func DefaultSessionServiceConfig() SessionServiceConfig {
 	return SessionServiceConfig{
-		SessionDuration: 24 * time.Hour,
+		SessionDuration:  domain.DefaultSessionDuration,
+		IdleTimeout:      domain.DefaultSessionIdleTimeout,
+		RememberDuration: domain.DefaultRememberDuration,
 	}
 }
 
@@ -43,14 +87,28 @@ func NewSessionService(
 	config SessionServiceConfig,
 ) *SessionService {
 	if config.SessionDuration == 0 {
-		config.SessionDuration = 24 * time.Hour
+		config.SessionDuration = domain.DefaultSessionDuration
+	}
+	if config.IdleTimeout == 0 {
+		config.IdleTimeout = domain.DefaultSessionIdleTimeout
+	}
+	if config.RememberDuration == 0 {
+		config.RememberDuration = domain.DefaultRememberDuration
 	}
 
 	return &SessionService{
-		sessionRepo:     sessionRepo,
-		userRepo:        userRepo,
-		logger:          logger.With().Str("service", "session").Logger(),
-		sessionDuration: config.SessionDuration,
+		sessionRepo:      sessionRepo,
+		userRepo:         userRepo,
+		logger:           logger.With().Str("service", "session").Logger(),
+		sessionDuration:  config.SessionDuration,
+		idleTimeout:      config.IdleTimeout,
+		rememberDuration: config.RememberDuration,
+		authenticator:    config.Authenticator,
+		provisionUsers:   config.ProvisionUsers,
+		localFallback:    config.LocalFallback,
+
+		ssoProvisionUsers: config.SSOProvisionUsers,
+		ssoDefaultRole:    config.SSODefaultRole,
 	}
 }
 
@@ -60,6 +118,10 @@ type LoginInput struct {
 	Password  string
 	IPAddress string
 	UserAgent string
+
+	// RememberMe also issues a remember-me token that resumes the session
+	// after it expired, unless remember-me is disabled.
+	RememberMe bool
 }
 
 // LoginOutput contains the result of a successful login.
@@ -69,51 +131,67 @@ type LoginOutput struct {
 }
 
 // Login authenticates a user and creates a session.
-// Only admin users can log in to the dashboard.
+// Only admin users can log in to the dashboard. A local user whose password
+// must be changed gets ErrPasswordChangeRequired until it is changed with
+// UserService.UpdatePassword.
 func (s *SessionService) Login(ctx context.Context, input LoginInput) (*LoginOutput, error) {
-	// Get user by username
-	user, err := s.userRepo.GetByUsername(ctx, input.Username)
+	user, err := s.authenticate(ctx, input.Username, input.Password)
 	if err != nil {
-		if err == repository.ErrNotFound {
-			s.logger.Debug().Str("username", input.Username).Msg("login failed: user not found")
-			return nil, ErrInvalidCredentials
-		}
-		s.logger.Error().Err(err).Str("username", input.Username).Msg("failed to get user")
-		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+		return nil, err
 	}
 
-	// Check if user is active
-	if !user.IsActive {
-		s.logger.Debug().Str("username", input.Username).Msg("login failed: user inactive")
-		return nil, ErrUserInactive
-	}
+	return s.startSession(ctx, user, input.IPAddress, input.UserAgent, input.RememberMe)
+}
 
-	// Check if user is admin
-	if !user.IsAdmin {
-		s.logger.Debug().Str("username", input.Username).Msg("login failed: user is not admin")
-		return nil, ErrNotAdminUser
-	}
+// SSOLoginInput contains an identity verified by a single sign-on provider.
+type SSOLoginInput struct {
+	Identity  *ExternalIdentity
+	IPAddress string
+	UserAgent string
+}
 
-	// Verify password
-	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(input.Password))
+// LoginSSO creates a session for a single sign-on identity. The identity is
+// mapped to the local user with the same email address.
+func (s *SessionService) LoginSSO(ctx context.Context, input SSOLoginInput) (*LoginOutput, error) {
+	user, err := s.ssoUser(ctx, input.Identity)
 	if err != nil {
-		s.logger.Debug().Str("username", input.Username).Msg("login failed: invalid password")
-		return nil, ErrInvalidCredentials
+		return nil, err
+	}
+
+	return s.startSession(ctx, user, input.IPAddress, input.UserAgent, false)
+}
+
+// startSession checks that an authenticated user may use the dashboard and
+// creates a session.
+func (s *SessionService) startSession(ctx context.Context, user *domain.User, ipAddress, userAgent string, rememberMe bool) (*LoginOutput, error) {
+	if err := s.checkDashboardUser(ctx, user); err != nil {
+		return nil, err
 	}
 
 	// Create session
-	session, err := domain.NewSession(user.ID, input.IPAddress, input.UserAgent)
+	session, err := domain.NewSession(user.ID, ipAddress, userAgent)
 	if err != nil {
-		s.logger.Error().Err(err).Msg("failed to generate session token")
+		s.logger.Error().Ctx(ctx).Err(err).Msg("failed to generate session token")
 		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
+	session.ExpiresAt = session.CreatedAt.Add(s.sessionDuration)
+
+	if rememberMe && s.rememberDuration > 0 {
+		session.RememberToken, err = domain.GenerateSessionToken()
+		if err != nil {
+			s.logger.Error().Ctx(ctx).Err(err).Msg("failed to generate remember token")
+			return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+		}
+		rememberExpiresAt := session.CreatedAt.Add(s.rememberDuration)
+		session.RememberExpiresAt = &rememberExpiresAt
+	}
 
 	if err := s.sessionRepo.Create(ctx, session); err != nil {
-		s.logger.Error().Err(err).Int64("user_id", user.ID).Msg("failed to create session")
+		s.logger.Error().Ctx(ctx).Err(err).Int64("user_id", user.ID).Msg("failed to create session")
 		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 
-	s.logger.Info().
+	s.logger.Info().Ctx(ctx).
 		Int64("user_id", user.ID).
 		Str("username", user.Username).
 		Str("session_id", session.ID.String()).
@@ -125,7 +203,198 @@ func (s *SessionService) Login(ctx context.Context, input LoginInput) (*LoginOut
 	}, nil
 }
 
+// checkDashboardUser checks that a user may log in to the dashboard.
+func (s *SessionService) checkDashboardUser(ctx context.Context, user *domain.User) error {
+	// Check if user is active
+	if !user.IsActive {
+		s.logger.Debug().Ctx(ctx).Str("username", user.Username).Msg("login failed: user inactive")
+		return ErrUserInactive
+	}
+
+	// Check if user has a dashboard role
+	if user.Role == domain.RoleNone {
+		s.logger.Debug().Ctx(ctx).Str("username", user.Username).Msg("login failed: user has no dashboard role")
+		return ErrNoDashboardRole
+	}
+
+	return nil
+}
+
+// authenticate verifies credentials against the external directory, if one
+// is configured, and then against local passwords.
+func (s *SessionService) authenticate(ctx context.Context, username, password string) (*domain.User, error) {
+	if s.authenticator != nil {
+		identity, err := s.authenticator.Authenticate(ctx, username, password)
+		switch {
+		case err == nil:
+			return s.directoryUser(ctx, identity)
+		case errors.Is(err, ErrInvalidCredentials):
+			s.logger.Debug().Ctx(ctx).Str("username", username).Msg("directory rejected credentials")
+			if !s.localFallback {
+				return nil, ErrInvalidCredentials
+			}
+		default:
+			s.logger.Error().Ctx(ctx).Err(err).Str("username", username).Msg("directory authentication failed")
+			if !s.localFallback {
+				return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+			}
+		}
+	}
+
+	user, err := s.userRepo.GetByUsername(ctx, username)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			s.logger.Debug().Ctx(ctx).Str("username", username).Msg("login failed: user not found")
+			return nil, ErrInvalidCredentials
+		}
+		s.logger.Error().Ctx(ctx).Err(err).Str("username", username).Msg("failed to get user")
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	// Verify password
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		s.logger.Debug().Ctx(ctx).Str("username", username).Msg("login failed: invalid password")
+		return nil, ErrInvalidCredentials
+	}
+
+	if user.MustChangePassword && user.IsActive {
+		s.logger.Debug().Ctx(ctx).Str("username", username).Msg("login failed: password must be changed")
+		return nil, ErrPasswordChangeRequired
+	}
+
+	return user, nil
+}
+
+// directoryUser returns the local user for a directory identity, creating it
+// on first login if provisioning is enabled. The admin flag follows the
+// directory's group mapping on every login.
+func (s *SessionService) directoryUser(ctx context.Context, identity *ExternalIdentity) (*domain.User, error) {
+	user, err := s.userRepo.GetByUsername(ctx, identity.Username)
+	if err != nil && !errors.Is(err, domain.ErrUserNotFound) {
+		s.logger.Error().Ctx(ctx).Err(err).Str("username", identity.Username).Msg("failed to get user")
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	if user == nil {
+		if !s.provisionUsers {
+			s.logger.Debug().Ctx(ctx).Str("username", identity.Username).Msg("login failed: directory user not provisioned")
+			return nil, ErrInvalidCredentials
+		}
+		if identity.Email == "" {
+			s.logger.Warn().Ctx(ctx).Str("username", identity.Username).Msg("cannot provision directory user without an email address")
+			return nil, ErrInvalidCredentials
+		}
+
+		// Directory users never log in with a local password
+		passwordHash, err := unusablePasswordHash()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+		}
+
+		user = domain.NewUser(identity.Username, identity.Email, passwordHash)
+		user.Role = identity.Role
+		if err := s.userRepo.Create(ctx, user); err != nil {
+			s.logger.Error().Ctx(ctx).Err(err).Str("username", identity.Username).Msg("failed to provision directory user")
+			return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+		}
+
+		s.logger.Info().Ctx(ctx).
+			Int64("user_id", user.ID).
+			Str("username", user.Username).
+			Stringer("role", user.Role).
+			Msg("directory user provisioned")
+		return user, nil
+	}
+
+	if user.Role != identity.Role {
+		user.Role = identity.Role
+		user.UpdatedAt = time.Now().UTC()
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			s.logger.Error().Ctx(ctx).Err(err).Int64("user_id", user.ID).Msg("failed to sync role from directory")
+			return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+		}
+		s.logger.Info().Ctx(ctx).
+			Int64("user_id", user.ID).
+			Stringer("role", user.Role).
+			Msg("role synced from directory groups")
+	}
+
+	return user, nil
+}
+
+// ssoUser returns the local user with the identity's email address, creating
+// it if provisioning is enabled. Unlike directory logins, the role of
+// existing users is managed locally.
+func (s *SessionService) ssoUser(ctx context.Context, identity *ExternalIdentity) (*domain.User, error) {
+	user, err := s.userRepo.GetByEmail(ctx, identity.Email)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, domain.ErrUserNotFound) {
+		s.logger.Error().Ctx(ctx).Err(err).Str("email", identity.Email).Msg("failed to get user")
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	if !s.ssoProvisionUsers {
+		s.logger.Debug().Ctx(ctx).Str("email", identity.Email).Msg("login failed: no user with single sign-on email")
+		return nil, ErrInvalidCredentials
+	}
+
+	// Prefer the provider's username, falling back to the email address if
+	// it is already taken by another user
+	username := identity.Username
+	if len(username) < 3 {
+		username = identity.Email
+	}
+	if username != identity.Email {
+		if _, err := s.userRepo.GetByUsername(ctx, username); err == nil {
+			username = identity.Email
+		} else if !errors.Is(err, domain.ErrUserNotFound) {
+			return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+		}
+	}
+
+	// Single sign-on users never log in with a local password
+	passwordHash, err := unusablePasswordHash()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	user = domain.NewUser(username, identity.Email, passwordHash)
+	user.Role = s.ssoDefaultRole
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		s.logger.Error().Ctx(ctx).Err(err).Str("email", identity.Email).Msg("failed to provision single sign-on user")
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	s.logger.Info().Ctx(ctx).
+		Int64("user_id", user.ID).
+		Str("username", user.Username).
+		Stringer("role", user.Role).
+		Msg("single sign-on user provisioned")
+	return user, nil
+}
+
+// unusablePasswordHash returns the bcrypt hash of a random password nobody knows.
+func unusablePasswordHash() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(hex.EncodeToString(secret)), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// sessionTouchInterval limits how often a session's last activity is written.
+const sessionTouchInterval = time.Minute
+
 // ValidateSession validates a session token and returns the associated session and user.
+// Sessions expire after the session duration or after the idle timeout without
+// requests. Expired sessions that a remember-me token can still resume are kept
+// for ResumeSession.
 func (s *SessionService) ValidateSession(ctx context.Context, token string) (*domain.Session, *domain.User, error) {
 	// Get session by token
 	session, err := s.sessionRepo.GetByToken(ctx, token)
@@ -133,40 +402,114 @@ func (s *SessionService) ValidateSession(ctx context.Context, token string) (*do
 		if err == repository.ErrNotFound {
 			return nil, nil, ErrSessionNotFound
 		}
-		s.logger.Error().Err(err).Msg("failed to get session")
+		s.logger.Error().Ctx(ctx).Err(err).Msg("failed to get session")
 		return nil, nil, fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 
-	// Check if session is expired
-	if session.IsExpired() {
+	// Check if session is expired or idle
+	if session.IsExpired() || session.IsIdle(s.idleTimeout) {
 		// Clean up expired session
-		_ = s.sessionRepo.Delete(ctx, session.Token)
+		if !session.IsResumable() {
+			_ = s.sessionRepo.Delete(ctx, session.Token)
+		}
 		return nil, nil, ErrSessionExpired
 	}
 
-	// Get user
-	user, err := s.userRepo.GetByID(ctx, session.UserID)
+	user, err := s.sessionUser(ctx, session)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Record activity for the idle timeout
+	now := time.Now().UTC()
+	if now.Sub(session.LastSeenAt) > sessionTouchInterval {
+		session.LastSeenAt = now
+		if err := s.sessionRepo.Update(ctx, session); err != nil {
+			s.logger.Warn().Ctx(ctx).Err(err).Str("session_id", session.ID.String()).Msg("failed to record session activity")
+		}
+	}
+
+	return session, user, nil
+}
+
+// ResumeSession starts a new session period for a remember-me token after
+// the session expired or went idle. Both the session token and the
+// remember-me token are replaced, so a stolen token works only until the
+// owner's next resume.
+func (s *SessionService) ResumeSession(ctx context.Context, rememberToken, ipAddress, userAgent string) (*LoginOutput, error) {
+	session, err := s.sessionRepo.GetByRememberToken(ctx, rememberToken)
 	if err != nil {
 		if err == repository.ErrNotFound {
+			return nil, ErrSessionNotFound
+		}
+		s.logger.Error().Ctx(ctx).Err(err).Msg("failed to get session by remember token")
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	if !session.IsResumable() {
+		_ = s.sessionRepo.DeleteByID(ctx, session.ID)
+		return nil, ErrSessionExpired
+	}
+
+	user, err := s.sessionUser(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+
+	if session.Token, err = domain.GenerateSessionToken(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	if session.RememberToken, err = domain.GenerateSessionToken(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	now := time.Now().UTC()
+	session.ExpiresAt = now.Add(s.sessionDuration)
+	session.LastSeenAt = now
+	session.IPAddress = ipAddress
+	session.UserAgent = userAgent
+
+	if err := s.sessionRepo.Update(ctx, session); err != nil {
+		s.logger.Error().Ctx(ctx).Err(err).Str("session_id", session.ID.String()).Msg("failed to resume session")
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	s.logger.Info().Ctx(ctx).
+		Int64("user_id", user.ID).
+		Str("username", user.Username).
+		Str("session_id", session.ID.String()).
+		Msg("session resumed with remember-me token")
+
+	return &LoginOutput{
+		Session: session,
+		User:    user,
+	}, nil
+}
+
+// sessionUser returns the user of a session, deleting the session if the
+// user no longer exists or may not use the dashboard.
+func (s *SessionService) sessionUser(ctx context.Context, session *domain.Session) (*domain.User, error) {
+	user, err := s.userRepo.GetByID(ctx, session.UserID)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
 			// User was deleted, clean up session
-			_ = s.sessionRepo.Delete(ctx, session.Token)
-			return nil, nil, ErrSessionNotFound
+			_ = s.sessionRepo.DeleteByID(ctx, session.ID)
+			return nil, ErrSessionNotFound
 		}
-		s.logger.Error().Err(err).Int64("user_id", session.UserID).Msg("failed to get user")
-		return nil, nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+		s.logger.Error().Ctx(ctx).Err(err).Int64("user_id", session.UserID).Msg("failed to get user")
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 
-	// Check if user is still active and admin
+	// Check if user is still active and has a dashboard role
 	if !user.IsActive {
-		_ = s.sessionRepo.Delete(ctx, session.Token)
-		return nil, nil, ErrUserInactive
+		_ = s.sessionRepo.DeleteByID(ctx, session.ID)
+		return nil, ErrUserInactive
 	}
-	if !user.IsAdmin {
-		_ = s.sessionRepo.Delete(ctx, session.Token)
-		return nil, nil, ErrNotAdminUser
+	if user.Role == domain.RoleNone {
+		_ = s.sessionRepo.DeleteByID(ctx, session.ID)
+		return nil, ErrNoDashboardRole
 	}
 
-	return session, user, nil
+	return user, nil
 }
 
 // Logout terminates a session by token.
@@ -176,16 +519,16 @@ func (s *SessionService) Logout(ctx context.Context, token string) error {
 		if err == repository.ErrNotFound {
 			return nil // Session doesn't exist, consider it logged out
 		}
-		s.logger.Error().Err(err).Msg("failed to get session for logout")
+		s.logger.Error().Ctx(ctx).Err(err).Msg("failed to get session for logout")
 		return fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 
 	if err := s.sessionRepo.Delete(ctx, token); err != nil {
-		s.logger.Error().Err(err).Str("session_id", session.ID.String()).Msg("failed to delete session")
+		s.logger.Error().Ctx(ctx).Err(err).Str("session_id", session.ID.String()).Msg("failed to delete session")
 		return fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 
-	s.logger.Info().
+	s.logger.Info().Ctx(ctx).
 		Str("session_id", session.ID.String()).
 		Int64("user_id", session.UserID).
 		Msg("user logged out")
@@ -193,14 +536,42 @@ func (s *SessionService) Logout(ctx context.Context, token string) error {
 	return nil
 }
 
+// RevokeSession terminates one of a user's sessions by ID.
+// Returns ErrSessionNotFound if the session belongs to another user.
+func (s *SessionService) RevokeSession(ctx context.Context, userID int64, sessionID uuid.UUID) error {
+	sessions, err := s.sessionRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		s.logger.Error().Ctx(ctx).Err(err).Int64("user_id", userID).Msg("failed to get user sessions")
+		return fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	for _, session := range sessions {
+		if session.ID != sessionID {
+			continue
+		}
+		if err := s.sessionRepo.DeleteByID(ctx, sessionID); err != nil && err != repository.ErrNotFound {
+			s.logger.Error().Ctx(ctx).Err(err).Str("session_id", sessionID.String()).Msg("failed to delete session")
+			return fmt.Errorf("%w: %v", ErrInternalError, err)
+		}
+
+		s.logger.Info().Ctx(ctx).
+			Str("session_id", sessionID.String()).
+			Int64("user_id", userID).
+			Msg("session revoked")
+		return nil
+	}
+
+	return ErrSessionNotFound
+}
+
 // LogoutUser terminates all sessions for a user.
 func (s *SessionService) LogoutUser(ctx context.Context, userID int64) error {
 	if err := s.sessionRepo.DeleteByUserID(ctx, userID); err != nil {
-		s.logger.Error().Err(err).Int64("user_id", userID).Msg("failed to delete user sessions")
+		s.logger.Error().Ctx(ctx).Err(err).Int64("user_id", userID).Msg("failed to delete user sessions")
 		return fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 
-	s.logger.Info().Int64("user_id", userID).Msg("all user sessions terminated")
+	s.logger.Info().Ctx(ctx).Int64("user_id", userID).Msg("all user sessions terminated")
 
 	return nil
 }
@@ -210,29 +581,30 @@ func (s *SessionService) LogoutUser(ctx context.Context, userID int64) error {
 func (s *SessionService) CleanExpired(ctx context.Context) (int64, error) {
 	deleted, err := s.sessionRepo.DeleteExpired(ctx)
 	if err != nil {
-		s.logger.Error().Err(err).Msg("failed to clean expired sessions")
+		s.logger.Error().Ctx(ctx).Err(err).Msg("failed to clean expired sessions")
 		return 0, fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 
 	if deleted > 0 {
-		s.logger.Info().Int64("deleted", deleted).Msg("cleaned expired sessions")
+		s.logger.Info().Ctx(ctx).Int64("deleted", deleted).Msg("cleaned expired sessions")
 	}
 
 	return deleted, nil
 }
 
-// GetUserSessions returns all active sessions for a user.
+// GetUserSessions returns all active sessions for a user, including expired
+// sessions that a remember-me token can still resume.
 func (s *SessionService) GetUserSessions(ctx context.Context, userID int64) ([]*domain.Session, error) {
 	sessions, err := s.sessionRepo.GetByUserID(ctx, userID)
 	if err != nil {
-		s.logger.Error().Err(err).Int64("user_id", userID).Msg("failed to get user sessions")
+		s.logger.Error().Ctx(ctx).Err(err).Int64("user_id", userID).Msg("failed to get user sessions")
 		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 
 	// Filter out expired sessions
 	var activeSessions []*domain.Session
 	for _, session := range sessions {
-		if !session.IsExpired() {
+		if (!session.IsExpired() && !session.IsIdle(s.idleTimeout)) || session.IsResumable() {
 			activeSessions = append(activeSessions, session)
 		}
 	}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository/sqlite"
+)
+
+func TestSessionService_IdleTimeoutAndRememberMe(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqlite.NewDB(ctx, sqlite.DefaultConfig(filepath.Join(t.TempDir(), "alexander.db")), zerolog.Nop())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, db.Migrate(ctx))
+
+	userRepo := sqlite.NewUserRepository(db)
+	sessionRepo := sqlite.NewSessionRepository(db)
+	users := NewUserService(userRepo, domain.DefaultPasswordPolicy(), zerolog.Nop())
+	sessions := NewSessionService(sessionRepo, userRepo, zerolog.Nop(), DefaultSessionServiceConfig())
+
+	_, err = users.Create(ctx, CreateUserInput{Username: "alice", Email: "alice@example.com", Password: "Correct-Horse-42", Role: domain.RoleAdmin})
+	require.NoError(t, err)
+
+	plain, err := sessions.Login(ctx, LoginInput{Username: "alice", Password: "Correct-Horse-42"})
+	require.NoError(t, err)
+	assert.Empty(t, plain.Session.RememberToken)
+	assert.NotEmpty(t, plain.Session.CSRFToken)
+	remembered, err := sessions.Login(ctx, LoginInput{Username: "alice", Password: "Correct-Horse-42", RememberMe: true})
+	require.NoError(t, err)
+	require.NotEmpty(t, remembered.Session.RememberToken)
+
+	session, _, err := sessions.ValidateSession(ctx, remembered.Session.Token)
+	require.NoError(t, err)
+	assert.Equal(t, remembered.Session.CSRFToken, session.CSRFToken)
+
+	// Both sessions go idle
+	for _, s := range []*domain.Session{plain.Session, remembered.Session} {
+		s.LastSeenAt = time.Now().UTC().Add(-time.Hour)
+		require.NoError(t, sessionRepo.Update(ctx, s))
+	}
+	_, _, err = sessions.ValidateSession(ctx, plain.Session.Token)
+	assert.ErrorIs(t, err, ErrSessionExpired)
+	_, _, err = sessions.ValidateSession(ctx, remembered.Session.Token)
+	assert.ErrorIs(t, err, ErrSessionExpired)
+
+	// Only the remembered session is kept for resuming
+	deleted, err := sessions.CleanExpired(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, deleted)
+	active, err := sessions.GetUserSessions(ctx, remembered.User.ID)
+	require.NoError(t, err)
+	require.Len(t, active, 1)
+	assert.Equal(t, remembered.Session.ID, active[0].ID)
+
+	resumed, err := sessions.ResumeSession(ctx, remembered.Session.RememberToken, "10.0.0.2", "test")
+	require.NoError(t, err)
+	assert.Equal(t, remembered.Session.ID, resumed.Session.ID)
+	assert.NotEqual(t, remembered.Session.Token, resumed.Session.Token)
+	assert.NotEqual(t, remembered.Session.RememberToken, resumed.Session.RememberToken)
+
+	_, _, err = sessions.ValidateSession(ctx, resumed.Session.Token)
+	require.NoError(t, err)
+
+	// The replaced tokens no longer work
+	_, _, err = sessions.ValidateSession(ctx, remembered.Session.Token)
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+	_, err = sessions.ResumeSession(ctx, remembered.Session.RememberToken, "10.0.0.3", "test")
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+
+	// Sessions can be revoked by their owner only
+	assert.ErrorIs(t, sessions.RevokeSession(ctx, remembered.User.ID+1, resumed.Session.ID), ErrSessionNotFound)
+	require.NoError(t, sessions.RevokeSession(ctx, remembered.User.ID, resumed.Session.ID))
+	_, _, err = sessions.ValidateSession(ctx, resumed.Session.Token)
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+}
@@ -0,0 +1,428 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/lock"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+	"github.com/prn-tf/alexander-storage/internal/storage"
+)
+
+// SnapshotService manages bucket snapshots. A snapshot records the latest
+// version of every key in a bucket without copying content, and serves
+// read-only listings and GETs of that state until it is deleted or its
+// retention runs out.
+type SnapshotService struct {
+	snapshotRepo repository.BucketSnapshotRepository
+	bucketRepo   repository.BucketRepository
+	txManager    repository.TxManager
+	storage      storage.Backend
+	locker       lock.Locker
+	logger       zerolog.Logger
+	config       SnapshotConfig
+
+	// Control
+	mu       sync.Mutex
+	running  bool
+	stopChan chan struct{}
+	doneChan chan struct{}
+}
+
+// SnapshotConfig contains bucket snapshot configuration.
+type SnapshotConfig struct {
+	// DefaultRetention is how long a snapshot is kept when the request
+	// names no retention. Zero keeps snapshots until they are deleted.
+	DefaultRetention time.Duration
+
+	// Interval is how often to look for expired snapshots.
+	Interval time.Duration
+
+	// BatchSize is the number of expired snapshots deleted at a time.
+	BatchSize int
+}
+
+// DefaultSnapshotConfig returns sensible defaults.
+func DefaultSnapshotConfig() SnapshotConfig {
+	return SnapshotConfig{
+		DefaultRetention: 0,
+		Interval:         time.Hour,
+		BatchSize:        100,
+	}
+}
+
+// NewSnapshotService creates a new SnapshotService.
+func NewSnapshotService(
+	snapshotRepo repository.BucketSnapshotRepository,
+	bucketRepo repository.BucketRepository,
+	txManager repository.TxManager,
+	storage storage.Backend,
+	locker lock.Locker,
+	logger zerolog.Logger,
+	config SnapshotConfig,
+) *SnapshotService {
+	return &SnapshotService{
+		snapshotRepo: snapshotRepo,
+		bucketRepo:   bucketRepo,
+		txManager:    txManager,
+		storage:      storage,
+		locker:       locker,
+		logger:       logger.With().Str("service", "snapshot").Logger(),
+		config:       config,
+		stopChan:     make(chan struct{}),
+		doneChan:     make(chan struct{}),
+	}
+}
+
+// Create takes a snapshot of a bucket. It expires after retain, after the
+// default retention if retain is zero, or never if both are zero.
+func (s *SnapshotService) Create(ctx context.Context, bucketName, name string, ownerID int64, retain time.Duration) (*domain.BucketSnapshot, error) {
+	if err := domain.ValidateSnapshotName(name); err != nil {
+		return nil, err
+	}
+
+	bucket, err := s.bucket(ctx, bucketName, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if retain <= 0 {
+		retain = s.config.DefaultRetention
+	}
+	snapshot := domain.NewBucketSnapshot(bucket.ID, name, retain)
+
+	err = s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		return s.snapshotRepo.Create(ctx, snapshot)
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrSnapshotAlreadyExists) {
+			return nil, err
+		}
+		s.logger.Error().Ctx(ctx).Err(err).Str("bucket", bucketName).Str("snapshot", name).Msg("failed to create snapshot")
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	s.logger.Info().Ctx(ctx).
+		Str("bucket", bucketName).
+		Str("snapshot", name).
+		Int64("objects", snapshot.ObjectCount).
+		Int64("size", snapshot.TotalSize).
+		Msg("snapshot created")
+
+	return snapshot, nil
+}
+
+// List returns the snapshots of a bucket, oldest first.
+func (s *SnapshotService) List(ctx context.Context, bucketName string, ownerID int64) ([]*domain.BucketSnapshot, error) {
+	bucket, err := s.bucket(ctx, bucketName, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots, err := s.snapshotRepo.ListByBucket(ctx, bucket.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	return snapshots, nil
+}
+
+// Get returns a snapshot of a bucket.
+func (s *SnapshotService) Get(ctx context.Context, bucketName, name string, ownerID int64) (*domain.BucketSnapshot, error) {
+	_, snapshot, err := s.snapshot(ctx, bucketName, name, ownerID)
+	return snapshot, err
+}
+
+// Delete deletes a snapshot and releases the content it holds.
+func (s *SnapshotService) Delete(ctx context.Context, bucketName, name string, ownerID int64) error {
+	_, snapshot, err := s.snapshot(ctx, bucketName, name, ownerID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.delete(ctx, snapshot); err != nil {
+		if errors.Is(err, domain.ErrSnapshotNotFound) {
+			return err
+		}
+		s.logger.Error().Ctx(ctx).Err(err).Str("bucket", bucketName).Str("snapshot", name).Msg("failed to delete snapshot")
+		return fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	s.logger.Info().Ctx(ctx).
+		Str("bucket", bucketName).
+		Str("snapshot", name).
+		Msg("snapshot deleted")
+
+	return nil
+}
+
+// delete removes a snapshot and its blob references in one transaction.
+func (s *SnapshotService) delete(ctx context.Context, snapshot *domain.BucketSnapshot) error {
+	return s.txManager.WithTx(ctx, func(ctx context.Context) error {
+		return s.snapshotRepo.Delete(ctx, snapshot.ID)
+	})
+}
+
+// ListObjects lists the objects of a snapshot like ObjectService.ListObjects
+// lists the objects of its bucket. Delimiter is not supported.
+func (s *SnapshotService) ListObjects(ctx context.Context, name string, input ListObjectsInput) (*ListObjectsOutput, error) {
+	_, snapshot, err := s.snapshot(ctx, input.BucketName, name, input.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+
+	maxKeys := input.MaxKeys
+	if maxKeys <= 0 || maxKeys > 1000 {
+		maxKeys = 1000
+	}
+
+	startAfter := input.StartAfter
+	if startAfter == "" {
+		startAfter = input.Marker
+	}
+	if startAfter == "" && input.ContinuationToken != "" {
+		startAfter = decodeContinuationToken(input.ContinuationToken)
+	}
+
+	result, err := s.snapshotRepo.ListObjects(ctx, snapshot.ID, repository.ObjectListOptions{
+		Prefix:     input.Prefix,
+		StartAfter: startAfter,
+		MaxKeys:    maxKeys,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	contents := make([]ObjectInfo, len(result.Objects))
+	for i, obj := range result.Objects {
+		contents[i] = ObjectInfo{
+			Key:          obj.Key,
+			LastModified: obj.LastModified,
+			ETag:         obj.ETag,
+			Size:         obj.Size,
+			StorageClass: obj.StorageClass,
+		}
+	}
+
+	output := &ListObjectsOutput{
+		Name:        input.BucketName,
+		Prefix:      input.Prefix,
+		MaxKeys:     maxKeys,
+		IsTruncated: result.IsTruncated,
+		Contents:    contents,
+		KeyCount:    result.KeyCount,
+	}
+
+	if result.IsTruncated && len(contents) > 0 {
+		lastKey := contents[len(contents)-1].Key
+		output.NextMarker = lastKey
+		output.NextContinuationToken = encodeContinuationToken(lastKey)
+	}
+
+	return output, nil
+}
+
+// GetObject retrieves the object version a snapshot holds for a key.
+// input.VersionID is ignored.
+func (s *SnapshotService) GetObject(ctx context.Context, name string, input GetObjectInput) (*GetObjectOutput, error) {
+	bucket, snapshot, err := s.snapshot(ctx, input.BucketName, name, input.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, err := s.snapshotRepo.GetObject(ctx, snapshot.ID, input.Key)
+	if err != nil {
+		if errors.Is(err, domain.ErrObjectNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	if obj.ContentHash == nil {
+		return nil, domain.ErrObjectNotFound
+	}
+
+	byteRange := input.Range
+	if input.PartNumber > 0 {
+		byteRange, err = partByteRange(obj, input.PartNumber)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	reader, contentLength, contentRange, err := retrieveContent(ctx, s.storage, bucket, obj, byteRange)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GetObjectOutput{
+		Body:          reader,
+		ContentLength: contentLength,
+		ContentType:   obj.ContentType,
+		ETag:          obj.ETag,
+		LastModified:  obj.CreatedAt,
+		VersionID:     obj.GetVersionIDString(),
+		Metadata:      obj.Metadata,
+		ContentRange:  contentRange,
+		PartsCount:    obj.PartsCount(),
+		Sequence:      obj.Sequence,
+	}, nil
+}
+
+// bucket returns a bucket by name, checking that ownerID owns it unless
+// ownerID is 0.
+func (s *SnapshotService) bucket(ctx context.Context, name string, ownerID int64) (*domain.Bucket, error) {
+	bucket, err := s.bucketRepo.GetByName(ctx, name)
+	if err != nil {
+		if errors.Is(err, domain.ErrBucketNotFound) {
+			return nil, domain.ErrBucketNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	if ownerID > 0 && bucket.OwnerID != ownerID {
+		return nil, ErrBucketAccessDenied
+	}
+	return bucket, nil
+}
+
+// snapshot returns a bucket and its snapshot of the given name. Snapshots
+// past their retention are reported as not found before expiry deletes them.
+func (s *SnapshotService) snapshot(ctx context.Context, bucketName, name string, ownerID int64) (*domain.Bucket, *domain.BucketSnapshot, error) {
+	bucket, err := s.bucket(ctx, bucketName, ownerID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	snapshot, err := s.snapshotRepo.GetByName(ctx, bucket.ID, name)
+	if err != nil {
+		if errors.Is(err, domain.ErrSnapshotNotFound) {
+			return nil, nil, err
+		}
+		return nil, nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	if snapshot.IsExpired() {
+		return nil, nil, domain.ErrSnapshotNotFound
+	}
+	return bucket, snapshot, nil
+}
+
+// Start begins the expiry scheduler.
+func (s *SnapshotService) Start() {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = true
+	s.mu.Unlock()
+
+	s.logger.Info().
+		Dur("interval", s.config.Interval).
+		Dur("default_retention", s.config.DefaultRetention).
+		Msg("Starting snapshot expiry")
+
+	go s.runLoop()
+}
+
+// Stop stops the expiry scheduler.
+func (s *SnapshotService) Stop() {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = false
+	s.mu.Unlock()
+
+	close(s.stopChan)
+	<-s.doneChan
+
+	s.logger.Info().Msg("Snapshot expiry stopped")
+}
+
+// runLoop is the main expiry loop.
+func (s *SnapshotService) runLoop() {
+	defer close(s.doneChan)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-s.stopChan
+		cancel()
+	}()
+
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		s.ExpireOnce(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+// ExpireOnce deletes every snapshot past its retention and returns the
+// number deleted.
+func (s *SnapshotService) ExpireOnce(ctx context.Context) int {
+	lockKey := lock.Keys.SnapshotExpiry()
+	lockTTL := s.config.Interval / 2
+	if lockTTL < 5*time.Minute {
+		lockTTL = 5 * time.Minute
+	}
+
+	acquired, err := s.locker.Acquire(ctx, lockKey, lockTTL)
+	if err != nil {
+		s.logger.Error().Ctx(ctx).Err(err).Msg("Failed to acquire snapshot expiry lock")
+		return 0
+	}
+	if !acquired {
+		s.logger.Debug().Ctx(ctx).Msg("Snapshot expiry lock held by another process, skipping run")
+		return 0
+	}
+	defer func() {
+		if _, err := s.locker.Release(context.Background(), lockKey); err != nil {
+			s.logger.Error().Ctx(ctx).Err(err).Msg("Failed to release snapshot expiry lock")
+		}
+	}()
+
+	// Deleted snapshots are gone from the next page
+	now := time.Now()
+	expired := 0
+	for ctx.Err() == nil {
+		page, err := s.snapshotRepo.ListExpired(ctx, now, s.config.BatchSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				s.logger.Error().Ctx(ctx).Err(err).Msg("Failed to list expired snapshots")
+			}
+			break
+		}
+
+		failed := 0
+		for _, snapshot := range page {
+			if err := s.delete(ctx, snapshot); err != nil && !errors.Is(err, domain.ErrSnapshotNotFound) {
+				s.logger.Error().Ctx(ctx).Err(err).Int64("snapshot_id", snapshot.ID).Msg("Failed to expire snapshot")
+				failed++
+				continue
+			}
+			expired++
+		}
+
+		if len(page) < s.config.BatchSize || failed > 0 {
+			break
+		}
+	}
+
+	if expired > 0 {
+		s.logger.Info().Ctx(ctx).Int("expired", expired).Msg("Deleted expired snapshots")
+	}
+	return expired
+}
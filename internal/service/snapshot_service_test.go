@@ -0,0 +1,138 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/lock"
+	"github.com/prn-tf/alexander-storage/internal/repository/sqlite"
+)
+
+func TestSnapshot_ReadsPointInTimeStateAndExpires(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	db, err := sqlite.NewDB(ctx, sqlite.DefaultConfig(filepath.Join(dir, "alexander.db")), zerolog.Nop())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, db.Migrate(ctx))
+	store := newTestFilesystem(t, dir)
+
+	user := domain.NewUser("owner", "owner@example.com", "hash")
+	require.NoError(t, sqlite.NewUserRepository(db).Create(ctx, user))
+	bucketRepo := sqlite.NewBucketRepository(db)
+	require.NoError(t, bucketRepo.Create(ctx, domain.NewBucket(user.ID, "datasets")))
+
+	blobRepo := sqlite.NewBlobRepository(db)
+	txManager := sqlite.NewTxManager(db)
+	objects := NewObjectService(sqlite.NewObjectRepository(db), blobRepo, bucketRepo, nil, nil, nil, nil, nil, nil, txManager, store, lock.NewMemoryLocker(), nil, zerolog.Nop())
+	snapshots := NewSnapshotService(sqlite.NewBucketSnapshotRepository(db), bucketRepo, txManager, store, lock.NewMemoryLocker(), zerolog.Nop(), DefaultSnapshotConfig())
+
+	put := func(key, body string) {
+		_, err := objects.PutObject(ctx, PutObjectInput{
+			BucketName: "datasets",
+			Key:        key,
+			Body:       bytes.NewReader([]byte(body)),
+			Size:       int64(len(body)),
+			OwnerID:    user.ID,
+		})
+		require.NoError(t, err)
+	}
+	read := func(name, key string, byteRange *ByteRange) (string, error) {
+		output, err := snapshots.GetObject(ctx, name, GetObjectInput{BucketName: "datasets", Key: key, OwnerID: user.ID, Range: byteRange})
+		if err != nil {
+			return "", err
+		}
+		defer output.Body.Close()
+		data, err := io.ReadAll(output.Body)
+		require.NoError(t, err)
+		return string(data), nil
+	}
+	refCount := func(body string) int32 {
+		hash := sha256.Sum256([]byte(body))
+		blob, err := blobRepo.GetByHash(ctx, hex.EncodeToString(hash[:]))
+		require.NoError(t, err)
+		return blob.RefCount
+	}
+
+	put("train/part-0", "v1 rows")
+	put("train/part-1", "more rows")
+	put("README", "v1 rows")
+
+	snapshot, err := snapshots.Create(ctx, "datasets", "v1", user.ID, 0)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), snapshot.ObjectCount)
+	require.Nil(t, snapshot.ExpiresAt, "no retention keeps the snapshot")
+	require.Equal(t, int32(4), refCount("v1 rows"), "one reference per key in the bucket and the snapshot")
+
+	_, err = snapshots.Create(ctx, "datasets", "v1", user.ID, 0)
+	require.ErrorIs(t, err, domain.ErrSnapshotAlreadyExists)
+	_, err = snapshots.Create(ctx, "datasets", "../v2", user.ID, 0)
+	require.ErrorIs(t, err, domain.ErrInvalidSnapshotName)
+	_, err = snapshots.Create(ctx, "datasets", "v2", user.ID+1, 0)
+	require.ErrorIs(t, err, ErrBucketAccessDenied)
+
+	// Later writes and deletes do not change what the snapshot shows
+	put("train/part-0", "v2 rows")
+	_, err = objects.DeleteObject(ctx, DeleteObjectInput{BucketName: "datasets", Key: "train/part-1", OwnerID: user.ID})
+	require.NoError(t, err)
+
+	body, err := read("v1", "train/part-0", nil)
+	require.NoError(t, err)
+	require.Equal(t, "v1 rows", body)
+	body, err = read("v1", "train/part-1", &ByteRange{Start: 5, End: 8})
+	require.NoError(t, err)
+	require.Equal(t, "rows", body)
+	_, err = read("v1", "train/part-2", nil)
+	require.ErrorIs(t, err, domain.ErrObjectNotFound)
+
+	listing, err := snapshots.ListObjects(ctx, "v1", ListObjectsInput{BucketName: "datasets", Prefix: "train/", MaxKeys: 1, OwnerID: user.ID})
+	require.NoError(t, err)
+	require.True(t, listing.IsTruncated)
+	require.Equal(t, "train/part-0", listing.Contents[0].Key)
+	listing, err = snapshots.ListObjects(ctx, "v1", ListObjectsInput{BucketName: "datasets", Prefix: "train/", ContinuationToken: listing.NextContinuationToken, OwnerID: user.ID})
+	require.NoError(t, err)
+	require.False(t, listing.IsTruncated)
+	require.Equal(t, "train/part-1", listing.Contents[0].Key)
+
+	// A bucket with snapshots is not empty
+	isEmpty, err := bucketRepo.IsEmpty(ctx, snapshot.BucketID)
+	require.NoError(t, err)
+	require.False(t, isEmpty)
+
+	// Deleting the snapshot releases the content only it still holds
+	require.NoError(t, snapshots.Delete(ctx, "datasets", "v1", user.ID))
+	require.Equal(t, int32(0), refCount("more rows"))
+	require.Equal(t, int32(1), refCount("v1 rows"), "README still references the content")
+	_, err = read("v1", "train/part-0", nil)
+	require.ErrorIs(t, err, domain.ErrSnapshotNotFound)
+
+	// Snapshots past their retention expire
+	_, err = snapshots.Create(ctx, "datasets", "nightly", user.ID, time.Hour)
+	require.NoError(t, err)
+	_, err = snapshots.Create(ctx, "datasets", "release", user.ID, 0)
+	require.NoError(t, err)
+	require.Zero(t, snapshots.ExpireOnce(ctx), "snapshots within retention must not expire")
+
+	_, err = db.ExecContext(ctx, `UPDATE bucket_snapshots SET expires_at = ? WHERE name = 'nightly'`, time.Now().Add(-time.Minute).UTC().Format(time.RFC3339))
+	require.NoError(t, err)
+	_, err = read("nightly", "README", nil)
+	require.ErrorIs(t, err, domain.ErrSnapshotNotFound, "an expired snapshot is not readable")
+
+	require.Equal(t, 1, snapshots.ExpireOnce(ctx))
+	list, err := snapshots.List(ctx, "datasets", user.ID)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.Equal(t, "release", list[0].Name)
+	require.Equal(t, int32(2), refCount("v1 rows"))
+}
@@ -0,0 +1,140 @@
+// Package service provides business logic services for Alexander Storage.
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// StatsService gathers storage usage, garbage collection and lifecycle
+// statistics for operators.
+type StatsService struct {
+	blobRepo      repository.BlobRepository
+	objectRepo    repository.ObjectRepository
+	multipartRepo repository.MultipartUploadRepository
+	usageRepo     repository.UsageRepository
+	gc            *GarbageCollector
+	lifecycle     *LifecycleService
+	logger        zerolog.Logger
+
+	// now is replaceable in tests.
+	now func() time.Time
+}
+
+// NewStatsService creates a new stats service. gc and lifecycle are nil when
+// garbage collection or lifecycle evaluation is disabled.
+func NewStatsService(
+	blobRepo repository.BlobRepository,
+	objectRepo repository.ObjectRepository,
+	multipartRepo repository.MultipartUploadRepository,
+	usageRepo repository.UsageRepository,
+	gc *GarbageCollector,
+	lifecycle *LifecycleService,
+	logger zerolog.Logger,
+) *StatsService {
+	return &StatsService{
+		blobRepo:      blobRepo,
+		objectRepo:    objectRepo,
+		multipartRepo: multipartRepo,
+		usageRepo:     usageRepo,
+		gc:            gc,
+		lifecycle:     lifecycle,
+		logger:        logger.With().Str("service", "stats").Logger(),
+		now:           time.Now,
+	}
+}
+
+// SystemStatsInput contains the parameters for GetSystemStats.
+type SystemStatsInput struct {
+	// HistoryDays is how many days of storage usage to return.
+	HistoryDays int
+}
+
+// SystemStats is a snapshot of the whole system.
+type SystemStats struct {
+	Blobs     domain.BlobStats
+	Objects   domain.ObjectStats
+	Multipart domain.MultipartStats
+
+	// DedupRatio is the bytes stored without deduplication divided by the
+	// bytes actually stored; 1 when nothing is stored.
+	DedupRatio float64
+
+	// StorageHistory is the bytes stored at the last snapshot of each day,
+	// oldest first. It is empty unless metering takes storage snapshots.
+	StorageHistory []*domain.StorageSample
+
+	// GCEnabled and LifecycleEnabled report whether the background jobs run
+	// in this process; LastGC and LastLifecycle are nil until they complete
+	// a run.
+	GCEnabled        bool
+	LastGC           *GCResult
+	LifecycleEnabled bool
+	LastLifecycle    *LifecycleResult
+}
+
+// GetSystemStats returns storage usage and the state of the background jobs.
+func (s *StatsService) GetSystemStats(ctx context.Context, input SystemStatsInput) (*SystemStats, error) {
+	blobs, err := s.blobRepo.GetStats(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	objects, err := s.objectRepo.GetStats(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	multipart, err := s.multipartRepo.GetStats(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	stats := &SystemStats{
+		Blobs:      *blobs,
+		Objects:    *objects,
+		Multipart:  *multipart,
+		DedupRatio: 1,
+	}
+	if blobs.StoredBytes > 0 {
+		stats.DedupRatio = float64(blobs.ReferencedBytes) / float64(blobs.StoredBytes)
+	}
+
+	if input.HistoryDays > 0 {
+		to := domain.UsageHour(s.now()).Add(time.Hour)
+		from := to.AddDate(0, 0, -input.HistoryDays)
+		samples, err := s.usageRepo.ListStorage(ctx, from, to)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+		}
+		stats.StorageHistory = dailyStorage(samples)
+	}
+
+	if s.gc != nil {
+		stats.GCEnabled = true
+		stats.LastGC = s.gc.LastResult()
+	}
+	if s.lifecycle != nil {
+		stats.LifecycleEnabled = true
+		stats.LastLifecycle = s.lifecycle.LastResult()
+	}
+
+	return stats, nil
+}
+
+// dailyStorage keeps the last of the hourly samples of each day.
+func dailyStorage(samples []*domain.StorageSample) []*domain.StorageSample {
+	var daily []*domain.StorageSample
+	for _, sample := range samples {
+		if n := len(daily); n > 0 && daily[n-1].Hour.Truncate(24*time.Hour).Equal(sample.Hour.Truncate(24*time.Hour)) {
+			daily[n-1] = sample
+			continue
+		}
+		daily = append(daily, sample)
+	}
+	return daily
+}
@@ -0,0 +1,106 @@
+// Package service provides business logic services for Alexander Storage.
+package service
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/lock"
+	"github.com/prn-tf/alexander-storage/internal/repository/sqlite"
+	"github.com/prn-tf/alexander-storage/internal/storage/filesystem"
+)
+
+func TestStatsService_GetSystemStats(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	db, err := sqlite.NewDB(ctx, sqlite.DefaultConfig(filepath.Join(dir, "alexander.db")), zerolog.Nop())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, db.Migrate(ctx))
+
+	store, err := filesystem.NewStorage(filesystem.Config{
+		DataDir: filepath.Join(dir, "blobs"),
+		TempDir: filepath.Join(dir, "tmp"),
+	}, zerolog.Nop())
+	require.NoError(t, err)
+
+	user := domain.NewUser("owner", "owner@example.com", "hash")
+	require.NoError(t, sqlite.NewUserRepository(db).Create(ctx, user))
+	bucketRepo := sqlite.NewBucketRepository(db)
+	bucket := domain.NewBucket(user.ID, "photos")
+	bucket.Versioning = domain.VersioningEnabled
+	require.NoError(t, bucketRepo.Create(ctx, bucket))
+
+	objectRepo := sqlite.NewObjectRepository(db)
+	blobRepo := sqlite.NewBlobRepository(db)
+	multipartRepo := sqlite.NewMultipartRepository(db)
+	usageRepo := sqlite.NewUsageRepository(db)
+	objects := NewObjectService(
+		objectRepo,
+		blobRepo,
+		bucketRepo,
+		sqlite.NewEventRepository(db),
+		sqlite.NewReplicationRepository(db),
+		sqlite.NewLifecycleRepository(db),
+		sqlite.NewStagedObjectRepository(db),
+		sqlite.NewBatchRepository(db),
+		nil,
+		sqlite.NewTxManager(db),
+		store,
+		lock.NewMemoryLocker(),
+		nil,
+		zerolog.Nop(),
+	)
+
+	// Two keys share one blob, and one of them is deleted
+	for _, key := range []string{"a.jpg", "b.jpg"} {
+		_, err := putString(ctx, objects, "photos", key, "same content", nil)
+		require.NoError(t, err)
+	}
+	_, err = objects.DeleteObject(ctx, DeleteObjectInput{BucketName: "photos", Key: "b.jpg"})
+	require.NoError(t, err)
+	require.NoError(t, multipartRepo.Create(ctx, domain.NewMultipartUpload(bucket.ID, "big.iso", user.ID)))
+
+	// Two snapshots on the first day, one on the second
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	for _, hour := range []time.Time{day.Add(-48 * time.Hour), day.Add(10 * time.Hour), day.Add(20 * time.Hour), day.Add(25 * time.Hour)} {
+		require.NoError(t, usageRepo.RecordStorage(ctx, hour))
+	}
+
+	gc := NewGarbageCollector(blobRepo, nil, nil, store, lock.NewNoOpLocker(), nil, zerolog.Nop(), DefaultGCConfig())
+	stats := NewStatsService(blobRepo, objectRepo, multipartRepo, usageRepo, gc, nil, zerolog.Nop())
+	stats.now = func() time.Time { return day.Add(26 * time.Hour) }
+
+	output, err := stats.GetSystemStats(ctx, SystemStatsInput{HistoryDays: 2})
+	require.NoError(t, err)
+
+	assert.Equal(t, domain.ObjectStats{Objects: 1, LogicalBytes: 12, Versions: 2, DeleteMarkers: 1}, output.Objects)
+	assert.EqualValues(t, 1, output.Blobs.Blobs)
+	assert.InDelta(t, 2.0, output.DedupRatio, 0.001)
+	assert.Equal(t, domain.MultipartStats{Uploads: 1}, output.Multipart)
+
+	// The snapshot before the window is left out, and each day keeps its last
+	require.Len(t, output.StorageHistory, 2)
+	assert.Equal(t, day.Add(20*time.Hour), output.StorageHistory[0].Hour)
+	assert.Equal(t, day.Add(25*time.Hour), output.StorageHistory[1].Hour)
+	assert.EqualValues(t, 24, output.StorageHistory[1].BytesStored)
+
+	assert.True(t, output.GCEnabled)
+	assert.Nil(t, output.LastGC)
+	assert.False(t, output.LifecycleEnabled)
+
+	gc.RunOnce(ctx)
+	output, err = stats.GetSystemStats(ctx, SystemStatsInput{})
+	require.NoError(t, err)
+	require.NotNil(t, output.LastGC)
+	assert.False(t, output.LastGC.StartedAt.IsZero())
+	assert.Empty(t, output.StorageHistory)
+}
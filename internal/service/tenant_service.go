@@ -0,0 +1,527 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+	"github.com/prn-tf/alexander-storage/internal/storage"
+)
+
+// tenantRefreshInterval is how long a server trusts its cached tenants and
+// their storage usage, and so how long it takes to apply limits changed by
+// another process.
+const tenantRefreshInterval = 30 * time.Second
+
+// TenantConfig contains tenant configuration.
+type TenantConfig struct {
+	// NamespaceBuckets requires the names of new buckets to start with the
+	// name of their owner's tenant and a hyphen. Buckets of the default
+	// tenant are exempt, so that existing deployments can turn it on.
+	NamespaceBuckets bool
+}
+
+// TenantService manages tenants and enforces their quotas and rate limits,
+// along with the storage quotas of individual buckets. The bucket quota of
+// a tenant is checked against the current number of buckets. Storage
+// quotas are checked against usage that is cached for up to
+// tenantRefreshInterval, so writes that race with deletes and overwrites
+// may briefly exceed them.
+type TenantService struct {
+	tenantRepo repository.TenantRepository
+	userRepo   repository.UserRepository
+	bucketRepo repository.BucketRepository
+	config     TenantConfig
+	logger     zerolog.Logger
+
+	mu            sync.Mutex
+	tenants       map[int64]*domain.Tenant
+	loadedAt      time.Time
+	generation    int                     // incremented by invalidate
+	storage       map[int64]*storageUsage // by tenant ID
+	bucketStorage map[int64]*storageUsage // by bucket ID
+
+	// now is replaceable in tests.
+	now func() time.Time
+}
+
+// storageUsage is the cached storage usage of a tenant or bucket.
+type storageUsage struct {
+	bytes     int64
+	checkedAt time.Time
+}
+
+// NewTenantService creates a new TenantService.
+func NewTenantService(
+	tenantRepo repository.TenantRepository,
+	userRepo repository.UserRepository,
+	bucketRepo repository.BucketRepository,
+	config TenantConfig,
+	logger zerolog.Logger,
+) *TenantService {
+	return &TenantService{
+		tenantRepo:    tenantRepo,
+		userRepo:      userRepo,
+		bucketRepo:    bucketRepo,
+		config:        config,
+		logger:        logger.With().Str("service", "tenant").Logger(),
+		storage:       make(map[int64]*storageUsage),
+		bucketStorage: make(map[int64]*storageUsage),
+		now:           time.Now,
+	}
+}
+
+// =============================================================================
+// Input/Output Structs
+// =============================================================================
+
+// CreateTenantInput contains the data needed to create a tenant.
+type CreateTenantInput struct {
+	Name   string
+	Limits domain.TenantLimits
+}
+
+// UpdateTenantLimitsInput changes the limits of a tenant. Nil fields are
+// left unchanged; zero removes a limit.
+type UpdateTenantLimitsInput struct {
+	Name              string
+	MaxBuckets        *int
+	MaxStorageBytes   *int64
+	RequestsPerSecond *float64
+	BurstSize         *int
+}
+
+// =============================================================================
+// Service Methods
+// =============================================================================
+
+// Create creates a new tenant.
+func (s *TenantService) Create(ctx context.Context, input CreateTenantInput) (*domain.Tenant, error) {
+	if err := domain.ValidateTenantName(input.Name); err != nil {
+		return nil, err
+	}
+	if err := input.Limits.Validate(); err != nil {
+		return nil, err
+	}
+
+	tenant := domain.NewTenant(input.Name)
+	tenant.Limits = input.Limits
+	if err := s.tenantRepo.Create(ctx, tenant); err != nil {
+		if errors.Is(err, domain.ErrTenantAlreadyExists) {
+			return nil, err
+		}
+		s.logger.Error().Ctx(ctx).Err(err).Str("tenant", input.Name).Msg("failed to create tenant")
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	s.invalidate()
+
+	s.logger.Info().Ctx(ctx).Int64("tenant_id", tenant.ID).Str("tenant", tenant.Name).Msg("tenant created")
+	return tenant, nil
+}
+
+// Get returns a tenant by name.
+func (s *TenantService) Get(ctx context.Context, name string) (*domain.Tenant, error) {
+	tenant, err := s.tenantRepo.GetByName(ctx, name)
+	if err != nil {
+		if errors.Is(err, domain.ErrTenantNotFound) {
+			return nil, err
+		}
+		s.logger.Error().Ctx(ctx).Err(err).Str("tenant", name).Msg("failed to get tenant")
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	return tenant, nil
+}
+
+// GetByID returns a tenant by ID.
+func (s *TenantService) GetByID(ctx context.Context, id int64) (*domain.Tenant, error) {
+	tenant, err := s.tenantRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrTenantNotFound) {
+			return nil, err
+		}
+		s.logger.Error().Ctx(ctx).Err(err).Int64("tenant_id", id).Msg("failed to get tenant")
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	return tenant, nil
+}
+
+// List returns all tenants in name order.
+func (s *TenantService) List(ctx context.Context) ([]*domain.Tenant, error) {
+	tenants, err := s.tenantRepo.List(ctx)
+	if err != nil {
+		s.logger.Error().Ctx(ctx).Err(err).Msg("failed to list tenants")
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	return tenants, nil
+}
+
+// UpdateLimits changes the limits of a tenant and returns the tenant.
+func (s *TenantService) UpdateLimits(ctx context.Context, input UpdateTenantLimitsInput) (*domain.Tenant, error) {
+	tenant, err := s.Get(ctx, input.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	limits := tenant.Limits
+	if input.MaxBuckets != nil {
+		limits.MaxBuckets = *input.MaxBuckets
+	}
+	if input.MaxStorageBytes != nil {
+		limits.MaxStorageBytes = *input.MaxStorageBytes
+	}
+	if input.RequestsPerSecond != nil {
+		limits.RequestsPerSecond = *input.RequestsPerSecond
+	}
+	if input.BurstSize != nil {
+		limits.BurstSize = *input.BurstSize
+	}
+	if err := limits.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.tenantRepo.UpdateLimits(ctx, tenant.ID, limits); err != nil {
+		if errors.Is(err, domain.ErrTenantNotFound) {
+			return nil, err
+		}
+		s.logger.Error().Ctx(ctx).Err(err).Str("tenant", tenant.Name).Msg("failed to update tenant limits")
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	tenant.Limits = limits
+	tenant.UpdatedAt = s.now().UTC()
+	s.invalidate()
+
+	s.logger.Info().Ctx(ctx).
+		Str("tenant", tenant.Name).
+		Int("max_buckets", limits.MaxBuckets).
+		Int64("max_storage_bytes", limits.MaxStorageBytes).
+		Float64("requests_per_second", limits.RequestsPerSecond).
+		Int("burst_size", limits.BurstSize).
+		Msg("tenant limits updated")
+	return tenant, nil
+}
+
+// Delete deletes a tenant that has no users and no buckets left. The
+// default tenant cannot be deleted.
+func (s *TenantService) Delete(ctx context.Context, name string) error {
+	tenant, err := s.Get(ctx, name)
+	if err != nil {
+		return err
+	}
+	if tenant.IsDefault() {
+		return domain.ErrDefaultTenant
+	}
+
+	usage, err := s.Usage(ctx, name)
+	if err != nil {
+		return err
+	}
+	if usage.Users > 0 || usage.Buckets > 0 {
+		return domain.ErrTenantNotEmpty
+	}
+
+	if err := s.tenantRepo.Delete(ctx, tenant.ID); err != nil {
+		if errors.Is(err, domain.ErrTenantNotFound) {
+			return err
+		}
+		s.logger.Error().Ctx(ctx).Err(err).Str("tenant", name).Msg("failed to delete tenant")
+		return fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	s.invalidate()
+
+	s.logger.Info().Ctx(ctx).Str("tenant", name).Msg("tenant deleted")
+	return nil
+}
+
+// Usage returns what a tenant currently uses of its quotas.
+func (s *TenantService) Usage(ctx context.Context, name string) (*domain.TenantUsage, error) {
+	tenant, err := s.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	usage, err := s.tenantRepo.Usage(ctx, tenant.ID)
+	if err != nil {
+		s.logger.Error().Ctx(ctx).Err(err).Str("tenant", name).Msg("failed to get tenant usage")
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	return usage, nil
+}
+
+// MoveUser moves a user, the buckets they own and with them their access
+// keys to another tenant. This is how a single-tenant deployment is split
+// into tenants. Bucket names are kept, even if they are outside of the new
+// tenant's namespace, and the new tenant's quotas only apply to later writes.
+func (s *TenantService) MoveUser(ctx context.Context, userID int64, tenantName string) error {
+	tenant, err := s.Get(ctx, tenantName)
+	if err != nil {
+		return err
+	}
+
+	if err := s.tenantRepo.MoveUser(ctx, userID, tenant.ID); err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return ErrUserNotFound
+		}
+		s.logger.Error().Ctx(ctx).Err(err).Int64("user_id", userID).Str("tenant", tenantName).Msg("failed to move user")
+		return fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	s.invalidate()
+
+	s.logger.Info().Ctx(ctx).Int64("user_id", userID).Str("tenant", tenantName).Msg("user moved to tenant")
+	return nil
+}
+
+// RateLimit returns the request rate and burst size shared by the access
+// keys of a tenant. A zero rate means they are not limited.
+func (s *TenantService) RateLimit(ctx context.Context, tenantID int64) (float64, int) {
+	tenant, err := s.cachedTenant(ctx, tenantID)
+	if err != nil {
+		return 0, 0
+	}
+	return tenant.Limits.RequestsPerSecond, tenant.Limits.BurstSize
+}
+
+// =============================================================================
+// Quota Enforcement
+// =============================================================================
+
+// checkNewBucket returns the tenant of a bucket that ownerID is about to
+// create, after checking its name against the tenant's namespace and the
+// tenant's bucket quota.
+func (s *TenantService) checkNewBucket(ctx context.Context, ownerID int64, name string) (int64, error) {
+	owner, err := s.userRepo.GetByID(ctx, ownerID)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return 0, ErrUserNotFound
+		}
+		return 0, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	tenant, err := s.cachedTenant(ctx, owner.TenantID)
+	if err != nil {
+		return 0, err
+	}
+
+	if s.config.NamespaceBuckets && !tenant.IsDefault() && !strings.HasPrefix(name, tenant.BucketPrefix()) {
+		return 0, fmt.Errorf("%w: %q", domain.ErrBucketNameNotInNamespace, tenant.BucketPrefix())
+	}
+
+	if tenant.Limits.MaxBuckets > 0 {
+		usage, err := s.tenantRepo.Usage(ctx, tenant.ID)
+		if err != nil {
+			s.logger.Error().Ctx(ctx).Err(err).Str("tenant", tenant.Name).Msg("failed to get tenant usage")
+			return 0, fmt.Errorf("%w: %v", ErrInternalError, err)
+		}
+		if usage.Buckets >= int64(tenant.Limits.MaxBuckets) {
+			return 0, domain.ErrTenantBucketQuotaExceeded
+		}
+	}
+	return tenant.ID, nil
+}
+
+// checkStorageQuota returns domain.ErrBucketQuotaExceeded or
+// domain.ErrTenantStorageQuotaExceeded if writing size bytes to bucket
+// would take it or its tenant over their storage quotas. A negative size
+// is a write of unknown length, which is only rejected if the quotas are
+// already exceeded; checkStoredQuota counts it once it is stored. tenants
+// may be nil, in which case there is no quota.
+func checkStorageQuota(ctx context.Context, tenants *TenantService, bucket *domain.Bucket, size int64) error {
+	if tenants == nil {
+		return nil
+	}
+	size = max(size, 0)
+	if err := tenants.reserveBucketStorage(ctx, bucket, size); err != nil {
+		return err
+	}
+	if err := tenants.reserveStorage(ctx, bucket.TenantID, size); err != nil {
+		tenants.releaseBucketStorage(bucket.ID, size)
+		return err
+	}
+	return nil
+}
+
+// checkStoredQuota checks a write of unknown length against the storage
+// quotas of bucket now that its content is stored under contentHash, and
+// returns the stored size. Writes of known size were counted by
+// checkStorageQuota and return size unchanged. On error the blob has no
+// reference yet and is left for GC.
+func checkStoredQuota(ctx context.Context, tenants *TenantService, backend storage.Backend, bucket *domain.Bucket, contentHash string, size int64) (int64, error) {
+	if size >= 0 {
+		return size, nil
+	}
+	stored, err := backend.GetSize(ctx, contentHash)
+	if err != nil {
+		return 0, storageError(err)
+	}
+	if err := checkStorageQuota(ctx, tenants, bucket, stored); err != nil {
+		return 0, err
+	}
+	return stored, nil
+}
+
+// reserveBucketStorage checks a write of size bytes against the storage
+// quota of a bucket, in the same way as reserveStorage.
+func (s *TenantService) reserveBucketStorage(ctx context.Context, bucket *domain.Bucket, size int64) error {
+	if bucket.QuotaBytes == 0 {
+		return nil
+	}
+
+	err := s.reserve(ctx, s.bucketStorage, bucket.ID, bucket.QuotaBytes, size, func(ctx context.Context) (int64, error) {
+		return s.bucketRepo.StorageBytes(ctx, bucket.ID)
+	})
+	if errors.Is(err, errStorageQuotaExceeded) {
+		return domain.ErrBucketQuotaExceeded
+	}
+	if err != nil {
+		s.logger.Error().Ctx(ctx).Err(err).Str("bucket", bucket.Name).Msg("failed to get bucket usage")
+		return fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	return nil
+}
+
+// releaseBucketStorage takes back a reservation of reserveBucketStorage for
+// a write that was rejected.
+func (s *TenantService) releaseBucketStorage(bucketID, size int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if used, ok := s.bucketStorage[bucketID]; ok {
+		used.bytes -= size
+	}
+}
+
+// reserveStorage checks a write of size bytes against the storage quota of
+// a tenant and counts it towards the cached usage until the next refresh,
+// so that a burst of uploads cannot overshoot the quota. If the tenant or
+// its usage cannot be read, the write is rejected.
+func (s *TenantService) reserveStorage(ctx context.Context, tenantID, size int64) error {
+	tenant, err := s.cachedTenant(ctx, tenantID)
+	if errors.Is(err, domain.ErrTenantNotFound) {
+		// Deleted along with its quota
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if tenant.Limits.MaxStorageBytes == 0 {
+		return nil
+	}
+
+	err = s.reserve(ctx, s.storage, tenantID, tenant.Limits.MaxStorageBytes, size, func(ctx context.Context) (int64, error) {
+		usage, err := s.tenantRepo.Usage(ctx, tenantID)
+		if err != nil {
+			return 0, err
+		}
+		return usage.StorageBytes, nil
+	})
+	if errors.Is(err, errStorageQuotaExceeded) {
+		return domain.ErrTenantStorageQuotaExceeded
+	}
+	if err != nil {
+		s.logger.Error().Ctx(ctx).Err(err).Str("tenant", tenant.Name).Msg("failed to get tenant usage")
+		return fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	return nil
+}
+
+// errStorageQuotaExceeded is returned by reserve; callers replace it with
+// the error of their quota.
+var errStorageQuotaExceeded = errors.New("storage quota exceeded")
+
+// reserve adds size bytes to the cached usage of id in usages unless that
+// would exceed limit. A missing or stale usage is read with load first,
+// without holding s.mu so that other writes are not held up by the query;
+// a usage another request refreshed meanwhile is kept along with its
+// reservations.
+func (s *TenantService) reserve(ctx context.Context, usages map[int64]*storageUsage, id, limit, size int64, load func(context.Context) (int64, error)) error {
+	now := s.now()
+	s.mu.Lock()
+	used, ok := usages[id]
+	fresh := ok && now.Sub(used.checkedAt) < tenantRefreshInterval
+	s.mu.Unlock()
+
+	if !fresh {
+		bytes, err := load(ctx)
+		if err != nil {
+			return err
+		}
+		s.mu.Lock()
+		if used, ok = usages[id]; !ok || now.Sub(used.checkedAt) >= tenantRefreshInterval {
+			used = &storageUsage{bytes: bytes, checkedAt: now}
+			usages[id] = used
+		}
+		s.mu.Unlock()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if used.bytes+size > limit {
+		return errStorageQuotaExceeded
+	}
+	used.bytes += size
+	return nil
+}
+
+// cachedTenant returns a tenant from the cache, which is reloaded every
+// tenantRefreshInterval. If it cannot be reloaded, the last state is kept.
+// The tenants are read without holding s.mu.
+func (s *TenantService) cachedTenant(ctx context.Context, id int64) (*domain.Tenant, error) {
+	now := s.now()
+	s.mu.Lock()
+	stale := s.tenants == nil || now.Sub(s.loadedAt) >= tenantRefreshInterval
+	generation := s.generation
+	tenant, ok := s.tenants[id]
+	s.mu.Unlock()
+
+	if stale {
+		tenants, err := s.tenantRepo.List(ctx)
+		s.mu.Lock()
+		switch {
+		case err != nil:
+			s.logger.Warn().Ctx(ctx).Err(err).Msg("failed to load tenants, keeping last state")
+			s.loadedAt = now
+		case generation == s.generation:
+			// Not invalidated by a change made during the query
+			s.tenants = make(map[int64]*domain.Tenant, len(tenants))
+			for _, tenant := range tenants {
+				s.tenants[tenant.ID] = tenant
+			}
+			s.loadedAt = now
+		}
+		tenant, ok = s.tenants[id]
+		s.mu.Unlock()
+	}
+
+	if ok {
+		return tenant, nil
+	}
+
+	// Created by another process since the last reload
+	tenant, err := s.tenantRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrTenantNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tenants == nil {
+		s.tenants = make(map[int64]*domain.Tenant)
+	}
+	s.tenants[id] = tenant
+	return tenant, nil
+}
+
+// invalidate makes the next lookup reload the tenants and their usage.
+func (s *TenantService) invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tenants = nil
+	s.generation++
+	clear(s.storage)
+	clear(s.bucketStorage)
+}
@@ -0,0 +1,352 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/lock"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+	"github.com/prn-tf/alexander-storage/internal/repository/sqlite"
+	"github.com/prn-tf/alexander-storage/internal/storage/filesystem"
+)
+
+type tenantFixture struct {
+	tenants   *TenantService
+	buckets   *BucketService
+	objects   *ObjectService
+	multipart *MultipartService
+	users     *UserService
+	tenant    *domain.Tenant
+	userID    int64 // in tenant "acme"
+	now       time.Time
+}
+
+// newTenantFixture returns services backed by SQLite and the filesystem,
+// with bucket namespacing, a tenant "acme" and a user in it.
+func newTenantFixture(t *testing.T) *tenantFixture {
+	t.Helper()
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	db, err := sqlite.NewDB(ctx, sqlite.DefaultConfig(filepath.Join(dir, "alexander.db")), zerolog.Nop())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, db.Migrate(ctx))
+
+	store, err := filesystem.NewStorage(filesystem.Config{
+		DataDir: filepath.Join(dir, "blobs"),
+		TempDir: filepath.Join(dir, "tmp"),
+	}, zerolog.Nop())
+	require.NoError(t, err)
+
+	userRepo := sqlite.NewUserRepository(db)
+	bucketRepo := sqlite.NewBucketRepository(db)
+	f := &tenantFixture{now: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)}
+	f.tenants = NewTenantService(sqlite.NewTenantRepository(db), userRepo, bucketRepo, TenantConfig{NamespaceBuckets: true}, zerolog.Nop())
+	f.tenants.now = func() time.Time { return f.now }
+	f.users = NewUserService(userRepo, domain.DefaultPasswordPolicy(), zerolog.Nop())
+
+	txManager := sqlite.NewTxManager(db)
+	f.buckets = NewBucketService(bucketRepo, nil, zerolog.Nop(), DefaultBucketConfig())
+	f.buckets.SetTenantService(f.tenants)
+	f.objects = NewObjectService(sqlite.NewObjectRepository(db), sqlite.NewBlobRepository(db), bucketRepo, nil, nil, nil, nil, nil,
+		nil, txManager, store, lock.NewMemoryLocker(), nil, zerolog.Nop())
+	f.objects.SetTenantService(f.tenants)
+	f.multipart = NewMultipartService(sqlite.NewMultipartRepository(db), sqlite.NewObjectRepository(db), sqlite.NewBlobRepository(db), bucketRepo,
+		nil, nil, nil, nil, nil, txManager, store, lock.NewMemoryLocker(), nil, zerolog.Nop())
+	f.multipart.SetTenantService(f.tenants)
+
+	f.tenant, err = f.tenants.Create(ctx, CreateTenantInput{Name: "acme"})
+	require.NoError(t, err)
+	f.userID = f.createUser(t, "wile", f.tenant.ID)
+	return f
+}
+
+func (f *tenantFixture) createUser(t *testing.T, username string, tenantID int64) int64 {
+	t.Helper()
+	output, err := f.users.Create(context.Background(), CreateUserInput{
+		Username: username,
+		Email:    username + "@example.com",
+		Password: "correct-horse",
+		TenantID: tenantID,
+	})
+	require.NoError(t, err)
+	return output.User.ID
+}
+
+func (f *tenantFixture) put(ctx context.Context, bucket string, size int) error {
+	_, err := f.objects.PutObject(ctx, PutObjectInput{
+		BucketName: bucket,
+		Key:        "data.bin",
+		Body:       bytes.NewReader(make([]byte, size)),
+		Size:       int64(size),
+		OwnerID:    f.userID,
+	})
+	return err
+}
+
+// ptr returns a pointer to v, for optional input fields.
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestTenant_BucketNamespaceAndQuota(t *testing.T) {
+	ctx := context.Background()
+	f := newTenantFixture(t)
+	_, err := f.tenants.UpdateLimits(ctx, UpdateTenantLimitsInput{Name: "acme", MaxBuckets: ptr(1)})
+	require.NoError(t, err)
+
+	_, err = f.buckets.CreateBucket(ctx, CreateBucketInput{OwnerID: f.userID, Name: "anvils"})
+	assert.ErrorIs(t, err, domain.ErrBucketNameNotInNamespace)
+
+	output, err := f.buckets.CreateBucket(ctx, CreateBucketInput{OwnerID: f.userID, Name: "acme-anvils"})
+	require.NoError(t, err)
+	assert.Equal(t, f.tenant.ID, output.Bucket.TenantID)
+
+	_, err = f.buckets.CreateBucket(ctx, CreateBucketInput{OwnerID: f.userID, Name: "acme-rockets"})
+	assert.ErrorIs(t, err, domain.ErrTenantBucketQuotaExceeded)
+
+	// The default tenant has neither a namespace nor a quota
+	other := f.createUser(t, "roadrunner", 0)
+	output, err = f.buckets.CreateBucket(ctx, CreateBucketInput{OwnerID: other, Name: "rockets"})
+	require.NoError(t, err)
+	assert.Equal(t, domain.DefaultTenantID, output.Bucket.TenantID)
+}
+
+func TestTenant_StorageQuota(t *testing.T) {
+	ctx := context.Background()
+	f := newTenantFixture(t)
+	_, err := f.buckets.CreateBucket(ctx, CreateBucketInput{OwnerID: f.userID, Name: "acme-data"})
+	require.NoError(t, err)
+	_, err = f.tenants.UpdateLimits(ctx, UpdateTenantLimitsInput{Name: "acme", MaxStorageBytes: ptr(int64(100))})
+	require.NoError(t, err)
+
+	require.NoError(t, f.put(ctx, "acme-data", 60))
+	require.NoError(t, f.put(ctx, "acme-data", 30))
+	// Overwriting the object freed its space, but the cached usage only
+	// learns about that at the next refresh
+	assert.ErrorIs(t, f.put(ctx, "acme-data", 30), domain.ErrTenantStorageQuotaExceeded)
+	f.now = f.now.Add(tenantRefreshInterval)
+	require.NoError(t, f.put(ctx, "acme-data", 60))
+
+	upload, err := f.multipart.InitiateMultipartUpload(ctx, InitiateMultipartUploadInput{BucketName: "acme-data", Key: "big.bin", OwnerID: f.userID})
+	require.NoError(t, err)
+	_, err = f.multipart.UploadPart(ctx, UploadPartInput{
+		BucketName: "acme-data", Key: "big.bin", UploadID: upload.UploadID, PartNumber: 1,
+		Body: bytes.NewReader(make([]byte, 50)), Size: 50, OwnerID: f.userID,
+	})
+	assert.ErrorIs(t, err, domain.ErrTenantStorageQuotaExceeded)
+
+	_, err = f.tenants.UpdateLimits(ctx, UpdateTenantLimitsInput{Name: "acme", MaxStorageBytes: ptr(int64(0))})
+	require.NoError(t, err)
+	require.NoError(t, f.put(ctx, "acme-data", 200))
+}
+
+func TestBucket_StorageQuota(t *testing.T) {
+	ctx := context.Background()
+	f := newTenantFixture(t)
+	_, err := f.buckets.CreateBucket(ctx, CreateBucketInput{OwnerID: f.userID, Name: "acme-data"})
+	require.NoError(t, err)
+	_, err = f.buckets.CreateBucket(ctx, CreateBucketInput{OwnerID: f.userID, Name: "acme-other"})
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, f.buckets.SetBucketQuota(ctx, SetBucketQuotaInput{Name: "acme-data", Bytes: -1}), domain.ErrInvalidBucketQuota)
+	require.NoError(t, f.buckets.SetBucketQuota(ctx, SetBucketQuotaInput{Name: "acme-data", Bytes: 100}))
+
+	require.NoError(t, f.put(ctx, "acme-data", 60))
+	assert.ErrorIs(t, f.put(ctx, "acme-data", 60), domain.ErrBucketQuotaExceeded)
+	require.NoError(t, f.put(ctx, "acme-other", 60), "the quota only covers its own bucket")
+
+	// The rejected write is not counted; filling up to the quota is allowed
+	require.NoError(t, f.put(ctx, "acme-data", 40))
+
+	require.NoError(t, f.buckets.SetBucketQuota(ctx, SetBucketQuotaInput{Name: "acme-data", Bytes: 0}))
+	require.NoError(t, f.put(ctx, "acme-data", 200))
+}
+
+// putUnknownSize uploads size bytes to key without telling the service
+// their length, as a chunked upload does.
+func (f *tenantFixture) putUnknownSize(ctx context.Context, bucket, key string, size int) error {
+	_, err := f.objects.PutObject(ctx, PutObjectInput{
+		BucketName: bucket,
+		Key:        key,
+		Body:       bytes.NewReader(make([]byte, size)),
+		Size:       -1,
+		OwnerID:    f.userID,
+	})
+	return err
+}
+
+func TestStorageQuota_UnknownSize(t *testing.T) {
+	ctx := context.Background()
+	f := newTenantFixture(t)
+	_, err := f.buckets.CreateBucket(ctx, CreateBucketInput{OwnerID: f.userID, Name: "acme-data"})
+	require.NoError(t, err)
+	_, err = f.tenants.UpdateLimits(ctx, UpdateTenantLimitsInput{Name: "acme", MaxStorageBytes: ptr(int64(100))})
+	require.NoError(t, err)
+
+	// The stored size is counted and recorded
+	require.NoError(t, f.putUnknownSize(ctx, "acme-data", "first.bin", 60))
+	head, err := f.objects.HeadObject(ctx, HeadObjectInput{BucketName: "acme-data", Key: "first.bin", OwnerID: f.userID})
+	require.NoError(t, err)
+	assert.Equal(t, int64(60), head.ContentLength)
+
+	// A write that turns out to be over the quota is rejected after the
+	// upload and leaves no object behind
+	assert.ErrorIs(t, f.putUnknownSize(ctx, "acme-data", "second.bin", 60), domain.ErrTenantStorageQuotaExceeded)
+	_, err = f.objects.HeadObject(ctx, HeadObjectInput{BucketName: "acme-data", Key: "second.bin", OwnerID: f.userID})
+	assert.ErrorIs(t, err, domain.ErrObjectNotFound)
+
+	upload, err := f.multipart.InitiateMultipartUpload(ctx, InitiateMultipartUploadInput{BucketName: "acme-data", Key: "big.bin", OwnerID: f.userID})
+	require.NoError(t, err)
+	_, err = f.multipart.UploadPart(ctx, UploadPartInput{
+		BucketName: "acme-data", Key: "big.bin", UploadID: upload.UploadID, PartNumber: 1,
+		Body: bytes.NewReader(make([]byte, 50)), Size: -1, OwnerID: f.userID,
+	})
+	assert.ErrorIs(t, err, domain.ErrTenantStorageQuotaExceeded)
+
+	// Bucket quotas apply in the same way
+	f.now = f.now.Add(tenantRefreshInterval)
+	require.NoError(t, f.buckets.SetBucketQuota(ctx, SetBucketQuotaInput{Name: "acme-data", Bytes: 90}))
+	assert.ErrorIs(t, f.putUnknownSize(ctx, "acme-data", "second.bin", 50), domain.ErrBucketQuotaExceeded)
+	require.NoError(t, f.putUnknownSize(ctx, "acme-data", "second.bin", 30))
+}
+
+func TestCheckStorageQuota_ReleasesBucketReservation(t *testing.T) {
+	ctx := context.Background()
+	f := newTenantFixture(t)
+	output, err := f.buckets.CreateBucket(ctx, CreateBucketInput{OwnerID: f.userID, Name: "acme-data"})
+	require.NoError(t, err)
+	_, err = f.tenants.UpdateLimits(ctx, UpdateTenantLimitsInput{Name: "acme", MaxStorageBytes: ptr(int64(100))})
+	require.NoError(t, err)
+	bucket := output.Bucket
+	bucket.QuotaBytes = 150
+
+	// The bucket has room but the tenant does not; the write is not counted
+	// against the bucket
+	assert.ErrorIs(t, checkStorageQuota(ctx, f.tenants, bucket, 120), domain.ErrTenantStorageQuotaExceeded)
+	require.NoError(t, f.tenants.reserveBucketStorage(ctx, bucket, 150))
+}
+
+// usageFailure makes reads of storage usage fail with err, and blocks reads
+// of the usage of tenant blockID until unblock is closed.
+type usageFailure struct {
+	err     error
+	blockID int64
+	blocked chan struct{}
+	unblock chan struct{}
+}
+
+type failingTenantRepo struct {
+	repository.TenantRepository
+	*usageFailure
+}
+
+func (r failingTenantRepo) Usage(ctx context.Context, id int64) (*domain.TenantUsage, error) {
+	if id == r.blockID {
+		close(r.blocked)
+		<-r.unblock
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r.TenantRepository.Usage(ctx, id)
+}
+
+type failingBucketRepo struct {
+	repository.BucketRepository
+	*usageFailure
+}
+
+func (r failingBucketRepo) StorageBytes(ctx context.Context, id int64) (int64, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	return r.BucketRepository.StorageBytes(ctx, id)
+}
+
+func TestStorageQuota_UsageErrors(t *testing.T) {
+	ctx := context.Background()
+	f := newTenantFixture(t)
+	output, err := f.buckets.CreateBucket(ctx, CreateBucketInput{OwnerID: f.userID, Name: "acme-data"})
+	require.NoError(t, err)
+	bucket := output.Bucket
+	_, err = f.tenants.UpdateLimits(ctx, UpdateTenantLimitsInput{Name: "acme", MaxStorageBytes: ptr(int64(100))})
+	require.NoError(t, err)
+	require.NoError(t, f.buckets.SetBucketQuota(ctx, SetBucketQuotaInput{Name: "acme-data", Bytes: 100}))
+	bucket.QuotaBytes = 100
+
+	// Writes are rejected while the usage cannot be read
+	failure := &usageFailure{err: errors.New("database is down")}
+	f.tenants.tenantRepo = failingTenantRepo{f.tenants.tenantRepo, failure}
+	f.tenants.bucketRepo = failingBucketRepo{f.tenants.bucketRepo, failure}
+	assert.ErrorIs(t, f.put(ctx, "acme-data", 10), ErrInternalError)
+	assert.ErrorIs(t, f.tenants.reserveStorage(ctx, f.tenant.ID, 10), ErrInternalError)
+	failure.err = nil
+	require.NoError(t, f.put(ctx, "acme-data", 10))
+
+	// Reading a tenant's usage does not hold up other quota checks
+	f.now = f.now.Add(tenantRefreshInterval)
+	failure.blockID, failure.blocked, failure.unblock = f.tenant.ID, make(chan struct{}), make(chan struct{})
+	done := make(chan error, 1)
+	go func() { done <- f.tenants.reserveStorage(ctx, f.tenant.ID, 10) }()
+	<-failure.blocked
+	require.NoError(t, f.tenants.reserveBucketStorage(ctx, bucket, 10))
+	close(failure.unblock)
+	require.NoError(t, <-done)
+}
+
+func TestTenant_MoveUserAndDelete(t *testing.T) {
+	ctx := context.Background()
+	f := newTenantFixture(t)
+	_, err := f.buckets.CreateBucket(ctx, CreateBucketInput{OwnerID: f.userID, Name: "acme-anvils"})
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, f.tenants.Delete(ctx, "acme"), domain.ErrTenantNotEmpty)
+	assert.ErrorIs(t, f.tenants.Delete(ctx, domain.DefaultTenantName), domain.ErrDefaultTenant)
+	assert.ErrorIs(t, f.tenants.MoveUser(ctx, 999, "acme"), ErrUserNotFound)
+
+	require.NoError(t, f.tenants.MoveUser(ctx, f.userID, domain.DefaultTenantName))
+	bucket, err := f.buckets.GetBucket(ctx, GetBucketInput{Name: "acme-anvils", OwnerID: f.userID})
+	require.NoError(t, err)
+	assert.Equal(t, domain.DefaultTenantID, bucket.Bucket.TenantID)
+	users, err := f.users.List(ctx, ListUsersInput{TenantID: domain.DefaultTenantID})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), users.TotalCount)
+
+	usage, err := f.tenants.Usage(ctx, "acme")
+	require.NoError(t, err)
+	assert.Equal(t, domain.TenantUsage{}, *usage)
+	require.NoError(t, f.tenants.Delete(ctx, "acme"))
+	_, err = f.tenants.Get(ctx, "acme")
+	assert.ErrorIs(t, err, domain.ErrTenantNotFound)
+}
+
+func TestTenant_RateLimit(t *testing.T) {
+	ctx := context.Background()
+	f := newTenantFixture(t)
+
+	rate, _ := f.tenants.RateLimit(ctx, f.tenant.ID)
+	assert.Zero(t, rate)
+
+	_, err := f.tenants.UpdateLimits(ctx, UpdateTenantLimitsInput{Name: "acme", RequestsPerSecond: ptr(10.0)})
+	assert.ErrorIs(t, err, domain.ErrInvalidTenantLimits, "a request rate needs a burst size")
+	_, err = f.tenants.UpdateLimits(ctx, UpdateTenantLimitsInput{Name: "acme", RequestsPerSecond: ptr(10.0), BurstSize: ptr(20)})
+	require.NoError(t, err)
+
+	rate, burst := f.tenants.RateLimit(ctx, f.tenant.ID)
+	assert.Equal(t, 10.0, rate)
+	assert.Equal(t, 20, burst)
+
+	rate, _ = f.tenants.RateLimit(ctx, 999)
+	assert.Zero(t, rate, "unknown tenants are not limited")
+}
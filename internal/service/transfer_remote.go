@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// TransferEndpoint identifies a bucket on an S3-compatible endpoint.
+type TransferEndpoint struct {
+	Endpoint        string // Base URL, e.g. https://s3.example.com
+	Bucket          string
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// ParseTransferURL parses a bucket URL of the form s3://host[:port]/bucket
+// into an endpoint base URL and bucket name. The endpoint uses HTTPS unless
+// plainHTTP is set.
+func ParseTransferURL(raw string, plainHTTP bool) (endpoint, bucket string, err error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "s3" || u.Host == "" {
+		return "", "", fmt.Errorf("invalid bucket URL %q: expected s3://host/bucket", raw)
+	}
+
+	bucket = strings.Trim(u.Path, "/")
+	if bucket == "" || strings.Contains(bucket, "/") {
+		return "", "", fmt.Errorf("invalid bucket URL %q: expected s3://host/bucket", raw)
+	}
+
+	scheme := "https"
+	if plainHTTP {
+		scheme = "http"
+	}
+	return scheme + "://" + u.Host, bucket, nil
+}
+
+// s3TransferRemote is a bucket on an S3-compatible endpoint.
+type s3TransferRemote struct {
+	client *s3.Client
+	bucket string
+}
+
+// NewS3TransferRemote connects to a bucket on an S3-compatible endpoint
+// using path-style requests.
+func NewS3TransferRemote(endpoint TransferEndpoint) TransferRemote {
+	region := endpoint.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	client := s3.New(s3.Options{
+		Region:                     region,
+		BaseEndpoint:               aws.String(endpoint.Endpoint),
+		Credentials:                credentials.NewStaticCredentialsProvider(endpoint.AccessKeyID, endpoint.SecretAccessKey, ""),
+		UsePathStyle:               true,
+		RequestChecksumCalculation: aws.RequestChecksumCalculationWhenRequired,
+	})
+
+	return &s3TransferRemote{client: client, bucket: endpoint.Bucket}
+}
+
+// List returns a page of keys of the remote bucket.
+func (r *s3TransferRemote) List(ctx context.Context, after string, versions bool, limit int) ([][]TransferObject, bool, error) {
+	if versions {
+		return r.listVersions(ctx, after, limit)
+	}
+
+	output, err := r.client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+		Bucket:     aws.String(r.bucket),
+		StartAfter: aws.String(after),
+		MaxKeys:    aws.Int32(int32(limit)),
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to list remote objects: %w", err)
+	}
+
+	page := make([][]TransferObject, len(output.Contents))
+	for i, obj := range output.Contents {
+		page[i] = []TransferObject{{Key: aws.ToString(obj.Key), Size: aws.ToInt64(obj.Size)}}
+	}
+	return page, aws.ToBool(output.IsTruncated), nil
+}
+
+// listVersions lists versions until limit keys are complete. The versions
+// of a key may span several responses, so a key is only returned once a
+// later key or the end of the listing has been seen.
+func (r *s3TransferRemote) listVersions(ctx context.Context, after string, limit int) ([][]TransferObject, bool, error) {
+	type version struct {
+		obj          TransferObject
+		lastModified int64
+	}
+
+	var keys []string
+	byKey := make(map[string][]version)
+	add := func(key *string, versionID *string, deleteMarker bool, size *int64, lastModified int64) {
+		k := aws.ToString(key)
+		if _, ok := byKey[k]; !ok {
+			keys = append(keys, k)
+		}
+		byKey[k] = append(byKey[k], version{
+			obj:          TransferObject{Key: k, VersionID: aws.ToString(versionID), IsDeleteMarker: deleteMarker, Size: aws.ToInt64(size)},
+			lastModified: lastModified,
+		})
+	}
+
+	input := &s3.ListObjectVersionsInput{
+		Bucket:    aws.String(r.bucket),
+		KeyMarker: aws.String(after),
+		MaxKeys:   aws.Int32(int32(limit)),
+	}
+	truncated := true
+	for truncated && len(keys) <= limit {
+		output, err := r.client.ListObjectVersions(ctx, input)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to list remote versions: %w", err)
+		}
+		for _, v := range output.Versions {
+			add(v.Key, v.VersionId, false, v.Size, lastModifiedNanos(v.LastModified))
+		}
+		for _, m := range output.DeleteMarkers {
+			add(m.Key, m.VersionId, true, nil, lastModifiedNanos(m.LastModified))
+		}
+
+		truncated = aws.ToBool(output.IsTruncated)
+		input.KeyMarker = output.NextKeyMarker
+		input.VersionIdMarker = output.NextVersionIdMarker
+	}
+
+	sort.Strings(keys)
+	more := truncated
+	if truncated {
+		keys = keys[:len(keys)-1] // Possibly incomplete
+	}
+	if len(keys) > limit {
+		keys = keys[:limit]
+		more = true
+	}
+
+	page := make([][]TransferObject, len(keys))
+	for i, key := range keys {
+		versions := byKey[key]
+		sort.SliceStable(versions, func(a, b int) bool { return versions[a].lastModified < versions[b].lastModified })
+		page[i] = make([]TransferObject, len(versions))
+		for j, v := range versions {
+			page[i][j] = v.obj
+		}
+	}
+	return page, more, nil
+}
+
+// Get opens an object version of the remote bucket.
+func (r *s3TransferRemote) Get(ctx context.Context, obj TransferObject) (io.ReadCloser, TransferObject, error) {
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(r.bucket),
+		Key:    aws.String(obj.Key),
+	}
+	if obj.VersionID != "" {
+		input.VersionId = aws.String(obj.VersionID)
+	}
+
+	output, err := r.client.GetObject(ctx, input)
+	if err != nil {
+		return nil, obj, fmt.Errorf("failed to get remote object: %w", err)
+	}
+
+	obj.Size = aws.ToInt64(output.ContentLength)
+	obj.ContentType = aws.ToString(output.ContentType)
+	obj.Metadata = output.Metadata
+	return output.Body, obj, nil
+}
+
+// Put uploads an object to the remote bucket. The body is streamed, so the
+// payload is sent unsigned instead of hashed up front.
+func (r *s3TransferRemote) Put(ctx context.Context, obj TransferObject, body io.Reader) error {
+	input := &s3.PutObjectInput{
+		Bucket:        aws.String(r.bucket),
+		Key:           aws.String(obj.Key),
+		Body:          body,
+		ContentLength: aws.Int64(obj.Size),
+		Metadata:      obj.Metadata,
+	}
+	if obj.ContentType != "" {
+		input.ContentType = aws.String(obj.ContentType)
+	}
+
+	_, err := r.client.PutObject(ctx, input, s3.WithAPIOptions(v4.SwapComputePayloadSHA256ForUnsignedPayloadMiddleware))
+	if err != nil {
+		return fmt.Errorf("failed to put remote object: %w", err)
+	}
+	return nil
+}
+
+// Delete deletes a key of the remote bucket.
+func (r *s3TransferRemote) Delete(ctx context.Context, key string) error {
+	_, err := r.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(r.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete remote object: %w", err)
+	}
+	return nil
+}
+
+// lastModifiedNanos returns a version's modification time for ordering.
+func lastModifiedNanos(t *time.Time) int64 {
+	if t == nil {
+		return 0
+	}
+	return t.UnixNano()
+}
@@ -0,0 +1,615 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+	"github.com/prn-tf/alexander-storage/internal/storage"
+)
+
+// TransferService copies the objects of a bucket to or from a bucket on
+// another S3-compatible endpoint. Keys are copied in key order, a page at a
+// time, by concurrent workers; after each page the progress is written to a
+// checkpoint file, so an interrupted transfer resumes after the last
+// completed page instead of starting over.
+type TransferService struct {
+	objectService *ObjectService
+	objectRepo    repository.ObjectRepository
+	bucketRepo    repository.BucketRepository
+	snapshotRepo  repository.BucketSnapshotRepository
+	storage       storage.Backend
+	logger        zerolog.Logger
+	config        TransferConfig
+}
+
+// TransferConfig contains bucket transfer configuration.
+type TransferConfig struct {
+	// Workers is the number of keys copied concurrently.
+	Workers int
+
+	// PageSize is the number of keys copied between checkpoints.
+	PageSize int
+}
+
+// DefaultTransferConfig returns sensible defaults.
+func DefaultTransferConfig() TransferConfig {
+	return TransferConfig{
+		Workers:  8,
+		PageSize: 1000,
+	}
+}
+
+// NewTransferService creates a new TransferService. Imported objects are
+// written through objectService, so they are stored, versioned and
+// reported like any other write. snapshotRepo may be nil, in which case
+// exports cannot read from a snapshot.
+func NewTransferService(
+	objectService *ObjectService,
+	objectRepo repository.ObjectRepository,
+	bucketRepo repository.BucketRepository,
+	snapshotRepo repository.BucketSnapshotRepository,
+	storage storage.Backend,
+	logger zerolog.Logger,
+	config TransferConfig,
+) *TransferService {
+	if config.Workers < 1 {
+		config.Workers = 1
+	}
+	if config.PageSize < 1 {
+		config.PageSize = DefaultTransferConfig().PageSize
+	}
+	return &TransferService{
+		objectService: objectService,
+		objectRepo:    objectRepo,
+		bucketRepo:    bucketRepo,
+		snapshotRepo:  snapshotRepo,
+		storage:       storage,
+		logger:        logger.With().Str("service", "transfer").Logger(),
+		config:        config,
+	}
+}
+
+// TransferObject is an object version or delete marker being transferred.
+type TransferObject struct {
+	Key            string
+	VersionID      string // Empty for the latest version
+	IsDeleteMarker bool
+	Size           int64
+	ContentType    string
+	Metadata       map[string]string
+
+	// contentHash locates the content of a local object.
+	contentHash *string
+}
+
+// TransferRemote is a bucket on another S3-compatible endpoint.
+type TransferRemote interface {
+	// List returns up to limit keys after the given key, in key order, each
+	// with its versions oldest first, and whether more keys follow. Without
+	// versions, only the latest version of each key is listed.
+	List(ctx context.Context, after string, versions bool, limit int) ([][]TransferObject, bool, error)
+
+	// Get opens the content of an object version and returns the object
+	// with its size, content type and metadata.
+	Get(ctx context.Context, obj TransferObject) (io.ReadCloser, TransferObject, error)
+
+	// Put uploads an object.
+	Put(ctx context.Context, obj TransferObject, body io.Reader) error
+
+	// Delete deletes a key, leaving a delete marker in a versioned bucket.
+	Delete(ctx context.Context, key string) error
+}
+
+// TransferInput describes a bucket transfer.
+type TransferInput struct {
+	// BucketName is the local bucket. It must exist for an import.
+	BucketName string
+
+	// Remote is the bucket on the other endpoint, and RemoteURL names it in
+	// the checkpoint.
+	Remote    TransferRemote
+	RemoteURL string
+
+	// Versions transfers every version and delete marker, oldest first,
+	// instead of only the latest version of each key.
+	Versions bool
+
+	// Snapshot exports the objects of a bucket snapshot instead of the
+	// current objects, for a point-in-time copy. Export only.
+	Snapshot string
+
+	// Checkpoint is the path of the checkpoint file. If it exists, the
+	// transfer resumes from it. Empty disables checkpoints.
+	Checkpoint string
+
+	// Progress, if set, is called after each completed page.
+	Progress func(TransferProgress)
+}
+
+// TransferProgress is the state of a transfer, as kept in its checkpoint.
+type TransferProgress struct {
+	Direction     string `json:"direction"`
+	Bucket        string `json:"bucket"`
+	Remote        string `json:"remote"`
+	Snapshot      string `json:"snapshot,omitempty"`
+	Versions      bool   `json:"versions"`
+	After         string `json:"after"` // Last key of the last completed page
+	Objects       int64  `json:"objects"`
+	DeleteMarkers int64  `json:"delete_markers"`
+	Bytes         int64  `json:"bytes"`
+	Done          bool   `json:"done"`
+	Resumed       bool   `json:"-"`
+}
+
+// Transfer directions.
+const (
+	transferExport = "export"
+	transferImport = "import"
+)
+
+// transferSource lists and reads the keys being transferred.
+type transferSource interface {
+	list(ctx context.Context, after string, limit int) ([][]TransferObject, bool, error)
+	open(ctx context.Context, obj TransferObject) (io.ReadCloser, TransferObject, error)
+}
+
+// transferSink writes the keys being transferred.
+type transferSink interface {
+	put(ctx context.Context, obj TransferObject, body io.Reader) error
+	remove(ctx context.Context, key string) error
+}
+
+// Export copies a local bucket to the remote bucket.
+func (s *TransferService) Export(ctx context.Context, input TransferInput) (*TransferProgress, error) {
+	bucket, err := s.bucket(ctx, input.BucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	local := &localTransfer{s: s, bucket: bucket, versions: input.Versions}
+	if input.Snapshot != "" {
+		if input.Versions {
+			return nil, fmt.Errorf("a snapshot holds only the latest versions; export it without versions")
+		}
+		if s.snapshotRepo == nil {
+			return nil, domain.ErrSnapshotNotFound
+		}
+		local.snapshot, err = s.snapshotRepo.GetByName(ctx, bucket.ID, input.Snapshot)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s.transfer(ctx, transferExport, input, local, remoteTransfer{input.Remote, input.Versions})
+}
+
+// Import copies the remote bucket into a local bucket.
+func (s *TransferService) Import(ctx context.Context, input TransferInput) (*TransferProgress, error) {
+	if input.Snapshot != "" {
+		return nil, fmt.Errorf("snapshots can only be exported")
+	}
+
+	bucket, err := s.bucket(ctx, input.BucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	local := &localTransfer{s: s, bucket: bucket, versions: input.Versions}
+	return s.transfer(ctx, transferImport, input, remoteTransfer{input.Remote, input.Versions}, local)
+}
+
+// transfer copies every key of src to dst, page by page.
+func (s *TransferService) transfer(ctx context.Context, direction string, input TransferInput, src transferSource, dst transferSink) (*TransferProgress, error) {
+	progress := &TransferProgress{
+		Direction: direction,
+		Bucket:    input.BucketName,
+		Remote:    input.RemoteURL,
+		Snapshot:  input.Snapshot,
+		Versions:  input.Versions,
+	}
+	if input.Checkpoint != "" {
+		saved, err := loadTransferCheckpoint(input.Checkpoint)
+		if err != nil {
+			return nil, err
+		}
+		if saved != nil {
+			if saved.Direction != progress.Direction || saved.Bucket != progress.Bucket || saved.Remote != progress.Remote ||
+				saved.Snapshot != progress.Snapshot || saved.Versions != progress.Versions {
+				return nil, fmt.Errorf("checkpoint %s belongs to a different transfer (%s of %s with %s)",
+					input.Checkpoint, saved.Direction, saved.Bucket, saved.Remote)
+			}
+			progress = saved
+			progress.Resumed = true
+		}
+	}
+
+	for !progress.Done {
+		page, more, err := src.list(ctx, progress.After, s.config.PageSize)
+		if err != nil {
+			return progress, fmt.Errorf("failed to list keys after %q: %w", progress.After, err)
+		}
+
+		if err := s.copyPage(ctx, page, src, dst, progress); err != nil {
+			return progress, err
+		}
+
+		if len(page) > 0 {
+			progress.After = page[len(page)-1][0].Key
+		}
+		progress.Done = !more || len(page) == 0
+		if input.Checkpoint != "" {
+			if err := saveTransferCheckpoint(input.Checkpoint, progress); err != nil {
+				return progress, err
+			}
+		}
+		if input.Progress != nil {
+			input.Progress(*progress)
+		}
+	}
+
+	s.logger.Info().Ctx(ctx).
+		Str("direction", direction).
+		Str("bucket", input.BucketName).
+		Str("remote", input.RemoteURL).
+		Int64("objects", progress.Objects).
+		Int64("bytes", progress.Bytes).
+		Msg("bucket transfer complete")
+
+	return progress, nil
+}
+
+// copyPage copies a page of keys with the configured number of workers.
+// The versions of a key are copied by one worker, in order.
+func (s *TransferService) copyPage(ctx context.Context, page [][]TransferObject, src transferSource, dst transferSink, progress *TransferProgress) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	keys := make(chan []TransferObject)
+	var mu sync.Mutex
+	var firstErr error
+	var wg sync.WaitGroup
+
+	for i := 0; i < s.config.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for versions := range keys {
+				for _, obj := range versions {
+					err := s.copyObject(ctx, obj, src, dst)
+
+					mu.Lock()
+					switch {
+					case err != nil && firstErr == nil:
+						firstErr = fmt.Errorf("failed to copy %q: %w", obj.Key, err)
+						cancel()
+					case err == nil && obj.IsDeleteMarker:
+						progress.DeleteMarkers++
+					case err == nil:
+						progress.Objects++
+						progress.Bytes += obj.Size
+					}
+					mu.Unlock()
+
+					if err != nil {
+						break
+					}
+				}
+			}
+		}()
+	}
+
+	for _, versions := range page {
+		select {
+		case keys <- versions:
+		case <-ctx.Done():
+		}
+	}
+	close(keys)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// copyObject copies one object version or delete marker.
+func (s *TransferService) copyObject(ctx context.Context, obj TransferObject, src transferSource, dst transferSink) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if obj.IsDeleteMarker {
+		return dst.remove(ctx, obj.Key)
+	}
+
+	body, obj, err := src.open(ctx, obj)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	return dst.put(ctx, obj, body)
+}
+
+// bucket returns a local bucket by name.
+func (s *TransferService) bucket(ctx context.Context, name string) (*domain.Bucket, error) {
+	bucket, err := s.bucketRepo.GetByName(ctx, name)
+	if err != nil {
+		if errors.Is(err, domain.ErrBucketNotFound) {
+			return nil, domain.ErrBucketNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	return bucket, nil
+}
+
+// =============================================================================
+// Local Bucket
+// =============================================================================
+
+// localTransfer reads and writes a local bucket, or reads one of its snapshots.
+type localTransfer struct {
+	s        *TransferService
+	bucket   *domain.Bucket
+	versions bool
+	snapshot *domain.BucketSnapshot
+}
+
+// list returns a page of local keys.
+func (l *localTransfer) list(ctx context.Context, after string, limit int) ([][]TransferObject, bool, error) {
+	switch {
+	case l.snapshot != nil:
+		return l.listSnapshot(ctx, after, limit)
+	case l.versions:
+		return l.listVersions(ctx, after, limit)
+	default:
+		return l.listLatest(ctx, after, limit)
+	}
+}
+
+// listLatest returns the latest version of a page of keys. Keys whose
+// latest version is a delete marker are skipped.
+func (l *localTransfer) listLatest(ctx context.Context, after string, limit int) ([][]TransferObject, bool, error) {
+	result, err := l.s.objectRepo.List(ctx, l.bucket.ID, repository.ObjectListOptions{StartAfter: after, MaxKeys: limit})
+	if err != nil {
+		return nil, false, err
+	}
+
+	page := make([][]TransferObject, 0, len(result.Objects))
+	for _, info := range result.Objects {
+		obj, err := l.s.objectRepo.GetByKey(ctx, l.bucket.ID, info.Key)
+		if errors.Is(err, domain.ErrObjectNotFound) {
+			continue // Deleted since it was listed
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		if obj.IsDeleteMarker {
+			continue
+		}
+		page = append(page, []TransferObject{newTransferObject(obj, "")})
+	}
+	return page, result.IsTruncated, nil
+}
+
+// listVersions returns every version of a page of keys, oldest first.
+func (l *localTransfer) listVersions(ctx context.Context, after string, limit int) ([][]TransferObject, bool, error) {
+	// Versions are listed per row, so the last key of a truncated listing
+	// may be incomplete; rows are listed until limit whole keys are known.
+	maxKeys := limit
+	for {
+		result, err := l.s.objectRepo.ListVersions(ctx, l.bucket.ID, repository.ObjectListOptions{StartAfter: after, MaxKeys: maxKeys})
+		if err != nil {
+			return nil, false, err
+		}
+
+		var keys []string
+		ids := make(map[string][]string)
+		for _, v := range append(result.Versions, result.DeleteMarkers...) {
+			if _, ok := ids[v.Key]; !ok {
+				keys = append(keys, v.Key)
+			}
+			ids[v.Key] = append(ids[v.Key], v.VersionID)
+		}
+		sort.Strings(keys)
+
+		more := result.IsTruncated
+		if more {
+			if len(keys) <= limit {
+				maxKeys *= 2
+				continue
+			}
+			keys = keys[:len(keys)-1]
+		}
+		if len(keys) > limit {
+			keys = keys[:limit]
+			more = true
+		}
+
+		page := make([][]TransferObject, 0, len(keys))
+		for _, key := range keys {
+			versions, err := l.keyVersions(ctx, key, ids[key])
+			if err != nil {
+				return nil, false, err
+			}
+			if len(versions) > 0 {
+				page = append(page, versions)
+			}
+		}
+		return page, more, nil
+	}
+}
+
+// keyVersions loads the versions of a key, ordered by sequence.
+func (l *localTransfer) keyVersions(ctx context.Context, key string, versionIDs []string) ([]TransferObject, error) {
+	objects := make([]*domain.Object, 0, len(versionIDs))
+	for _, id := range versionIDs {
+		versionID, err := domain.ParseVersionID(id)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version ID %q of %q: %w", id, key, err)
+		}
+		obj, err := l.s.objectRepo.GetByKeyAndVersion(ctx, l.bucket.ID, key, versionID)
+		if errors.Is(err, domain.ErrObjectNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, obj)
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Sequence < objects[j].Sequence })
+
+	versions := make([]TransferObject, len(objects))
+	for i, obj := range objects {
+		versions[i] = newTransferObject(obj, obj.VersionID.String())
+	}
+	return versions, nil
+}
+
+// listSnapshot returns a page of the objects of a snapshot.
+func (l *localTransfer) listSnapshot(ctx context.Context, after string, limit int) ([][]TransferObject, bool, error) {
+	result, err := l.s.snapshotRepo.ListObjects(ctx, l.snapshot.ID, repository.ObjectListOptions{StartAfter: after, MaxKeys: limit})
+	if err != nil {
+		return nil, false, err
+	}
+
+	page := make([][]TransferObject, 0, len(result.Objects))
+	for _, info := range result.Objects {
+		obj, err := l.s.snapshotRepo.GetObject(ctx, l.snapshot.ID, info.Key)
+		if err != nil {
+			return nil, false, err
+		}
+		page = append(page, []TransferObject{newTransferObject(obj, "")})
+	}
+	return page, result.IsTruncated, nil
+}
+
+// open opens the content of a local object.
+func (l *localTransfer) open(ctx context.Context, obj TransferObject) (io.ReadCloser, TransferObject, error) {
+	if obj.contentHash == nil {
+		return io.NopCloser(bytes.NewReader(nil)), obj, nil
+	}
+	reader, err := l.s.storage.Retrieve(storage.WithResidency(ctx, l.bucket.Residency), *obj.contentHash)
+	if err != nil {
+		return nil, obj, storageError(err)
+	}
+	return reader, obj, nil
+}
+
+// put writes an object to the local bucket.
+func (l *localTransfer) put(ctx context.Context, obj TransferObject, body io.Reader) error {
+	_, err := l.s.objectService.PutObject(ctx, PutObjectInput{
+		BucketName:  l.bucket.Name,
+		Key:         obj.Key,
+		Body:        body,
+		Size:        obj.Size,
+		ContentType: obj.ContentType,
+		Metadata:    obj.Metadata,
+	})
+	return err
+}
+
+// remove deletes a key in the local bucket.
+func (l *localTransfer) remove(ctx context.Context, key string) error {
+	_, err := l.s.objectService.DeleteObject(ctx, DeleteObjectInput{BucketName: l.bucket.Name, Key: key})
+	return err
+}
+
+// newTransferObject describes a local object version.
+func newTransferObject(obj *domain.Object, versionID string) TransferObject {
+	return TransferObject{
+		Key:            obj.Key,
+		VersionID:      versionID,
+		IsDeleteMarker: obj.IsDeleteMarker,
+		Size:           obj.Size,
+		ContentType:    obj.ContentType,
+		Metadata:       obj.Metadata,
+		contentHash:    obj.ContentHash,
+	}
+}
+
+// =============================================================================
+// Remote Bucket
+// =============================================================================
+
+// remoteTransfer adapts a TransferRemote to a transfer source and sink.
+type remoteTransfer struct {
+	remote   TransferRemote
+	versions bool
+}
+
+func (r remoteTransfer) list(ctx context.Context, after string, limit int) ([][]TransferObject, bool, error) {
+	return r.remote.List(ctx, after, r.versions, limit)
+}
+
+func (r remoteTransfer) open(ctx context.Context, obj TransferObject) (io.ReadCloser, TransferObject, error) {
+	return r.remote.Get(ctx, obj)
+}
+
+func (r remoteTransfer) put(ctx context.Context, obj TransferObject, body io.Reader) error {
+	return r.remote.Put(ctx, obj, body)
+}
+
+func (r remoteTransfer) remove(ctx context.Context, key string) error {
+	return r.remote.Delete(ctx, key)
+}
+
+// =============================================================================
+// Checkpoints
+// =============================================================================
+
+// loadTransferCheckpoint reads a checkpoint file, or returns nil if it does
+// not exist.
+func loadTransferCheckpoint(path string) (*TransferProgress, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+
+	var progress TransferProgress
+	if err := json.Unmarshal(data, &progress); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint %s: %w", path, err)
+	}
+	return &progress, nil
+}
+
+// saveTransferCheckpoint writes a checkpoint file next to path and renames
+// it into place, so an interruption never leaves a truncated checkpoint.
+func saveTransferCheckpoint(path string, progress *TransferProgress) error {
+	data, err := json.MarshalIndent(progress, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if _, err := f.Write(data); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/lock"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+	"github.com/prn-tf/alexander-storage/internal/repository/sqlite"
+)
+
+// memoryTransferRemote is an in-memory versioned TransferRemote.
+type memoryTransferRemote struct {
+	mu       sync.Mutex
+	versions map[string][]memoryRemoteVersion
+	failKey  string
+}
+
+type memoryRemoteVersion struct {
+	obj  TransferObject
+	body string
+}
+
+func newMemoryTransferRemote() *memoryTransferRemote {
+	return &memoryTransferRemote{versions: make(map[string][]memoryRemoteVersion)}
+}
+
+func (m *memoryTransferRemote) List(ctx context.Context, after string, versions bool, limit int) ([][]TransferObject, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var keys []string
+	for key, v := range m.versions {
+		if key > after && (versions || !v[len(v)-1].obj.IsDeleteMarker) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	more := len(keys) > limit
+	if more {
+		keys = keys[:limit]
+	}
+
+	page := make([][]TransferObject, len(keys))
+	for i, key := range keys {
+		v := m.versions[key]
+		if !versions {
+			v = v[len(v)-1:]
+		}
+		for _, version := range v {
+			page[i] = append(page[i], version.obj)
+		}
+	}
+	return page, more, nil
+}
+
+func (m *memoryTransferRemote) Get(ctx context.Context, obj TransferObject) (io.ReadCloser, TransferObject, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, v := range m.versions[obj.Key] {
+		if obj.VersionID == "" || v.obj.VersionID == obj.VersionID {
+			found := v
+			if obj.VersionID == "" {
+				found = m.versions[obj.Key][len(m.versions[obj.Key])-1]
+			}
+			return io.NopCloser(bytes.NewReader([]byte(found.body))), found.obj, nil
+		}
+	}
+	return nil, obj, domain.ErrObjectNotFound
+}
+
+func (m *memoryTransferRemote) Put(ctx context.Context, obj TransferObject, body io.Reader) error {
+	if obj.Key == m.failKey {
+		return errors.New("connection reset")
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	obj.VersionID = string(rune('a' + len(m.versions[obj.Key])))
+	m.versions[obj.Key] = append(m.versions[obj.Key], memoryRemoteVersion{obj: obj, body: string(data)})
+	return nil
+}
+
+func (m *memoryTransferRemote) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	obj := TransferObject{Key: key, IsDeleteMarker: true, VersionID: string(rune('a' + len(m.versions[key])))}
+	m.versions[key] = append(m.versions[key], memoryRemoteVersion{obj: obj})
+	return nil
+}
+
+func (m *memoryTransferRemote) bodies(key string) []string {
+	var bodies []string
+	for _, v := range m.versions[key] {
+		if v.obj.IsDeleteMarker {
+			bodies = append(bodies, "<deleted>")
+		} else {
+			bodies = append(bodies, v.body)
+		}
+	}
+	return bodies
+}
+
+func TestTransfer_ExportImportRoundTripWithCheckpoints(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	db, err := sqlite.NewDB(ctx, sqlite.DefaultConfig(filepath.Join(dir, "alexander.db")), zerolog.Nop())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, db.Migrate(ctx))
+	store := newTestFilesystem(t, dir)
+
+	user := domain.NewUser("owner", "owner@example.com", "hash")
+	require.NoError(t, sqlite.NewUserRepository(db).Create(ctx, user))
+	bucketRepo := sqlite.NewBucketRepository(db)
+	photos := domain.NewBucket(user.ID, "photos")
+	require.NoError(t, bucketRepo.Create(ctx, photos))
+	require.NoError(t, bucketRepo.UpdateVersioning(ctx, photos.ID, domain.VersioningEnabled))
+	restored := domain.NewBucket(user.ID, "restored")
+	require.NoError(t, bucketRepo.Create(ctx, restored))
+	require.NoError(t, bucketRepo.UpdateVersioning(ctx, restored.ID, domain.VersioningEnabled))
+
+	objectRepo := sqlite.NewObjectRepository(db)
+	snapshotRepo := sqlite.NewBucketSnapshotRepository(db)
+	txManager := sqlite.NewTxManager(db)
+	objects := NewObjectService(objectRepo, sqlite.NewBlobRepository(db), bucketRepo, nil, nil, nil, nil, nil, nil, txManager, store, lock.NewMemoryLocker(), nil, zerolog.Nop())
+	snapshots := NewSnapshotService(snapshotRepo, bucketRepo, txManager, store, lock.NewMemoryLocker(), zerolog.Nop(), DefaultSnapshotConfig())
+	transfers := NewTransferService(objects, objectRepo, bucketRepo, snapshotRepo, store, zerolog.Nop(), TransferConfig{Workers: 4, PageSize: 2})
+
+	put := func(key, body string) {
+		_, err := objects.PutObject(ctx, PutObjectInput{
+			BucketName:  "photos",
+			Key:         key,
+			Body:        bytes.NewReader([]byte(body)),
+			Size:        int64(len(body)),
+			ContentType: "image/jpeg",
+			Metadata:    map[string]string{"camera": "x100"},
+			OwnerID:     user.ID,
+		})
+		require.NoError(t, err)
+	}
+
+	put("a.jpg", "a1")
+	put("b.jpg", "b1")
+	put("c.jpg", "c1")
+	_, err = snapshots.Create(ctx, "photos", "before", user.ID, 0)
+	require.NoError(t, err)
+	put("a.jpg", "a2")
+	put("d.jpg", "d1")
+	put("e.jpg", "e1")
+	_, err = objects.DeleteObject(ctx, DeleteObjectInput{BucketName: "photos", Key: "b.jpg", OwnerID: user.ID})
+	require.NoError(t, err)
+
+	// Latest versions only; deleted keys are skipped
+	latest := newMemoryTransferRemote()
+	progress, err := transfers.Export(ctx, TransferInput{BucketName: "photos", Remote: latest, RemoteURL: "s3://latest/photos"})
+	require.NoError(t, err)
+	require.True(t, progress.Done)
+	require.Equal(t, int64(4), progress.Objects)
+	require.Equal(t, []string{"a2"}, latest.bodies("a.jpg"))
+	require.Empty(t, latest.bodies("b.jpg"))
+	require.Equal(t, "image/jpeg", latest.versions["a.jpg"][0].obj.ContentType)
+	require.Equal(t, "x100", latest.versions["a.jpg"][0].obj.Metadata["camera"])
+
+	// A snapshot exports the bucket as it was
+	pointInTime := newMemoryTransferRemote()
+	progress, err = transfers.Export(ctx, TransferInput{BucketName: "photos", Snapshot: "before", Remote: pointInTime, RemoteURL: "s3://pit/photos"})
+	require.NoError(t, err)
+	require.Equal(t, int64(3), progress.Objects)
+	require.Equal(t, []string{"a1"}, pointInTime.bodies("a.jpg"))
+	require.Equal(t, []string{"b1"}, pointInTime.bodies("b.jpg"))
+	require.Empty(t, pointInTime.bodies("d.jpg"))
+
+	// A failed export resumes from its checkpoint
+	checkpoint := filepath.Join(dir, "export.json")
+	mirror := newMemoryTransferRemote()
+	mirror.failKey = "e.jpg"
+	input := TransferInput{BucketName: "photos", Versions: true, Remote: mirror, RemoteURL: "s3://mirror/photos", Checkpoint: checkpoint}
+	_, err = transfers.Export(ctx, input)
+	require.Error(t, err)
+
+	saved, err := loadTransferCheckpoint(checkpoint)
+	require.NoError(t, err)
+	require.Equal(t, "d.jpg", saved.After, "the first two pages completed")
+	require.False(t, saved.Done)
+
+	_, err = transfers.Export(ctx, TransferInput{BucketName: "photos", Remote: mirror, RemoteURL: "s3://mirror/photos", Checkpoint: checkpoint})
+	require.Error(t, err, "a checkpoint only resumes the same transfer")
+
+	mirror.failKey = ""
+	progress, err = transfers.Export(ctx, input)
+	require.NoError(t, err)
+	require.True(t, progress.Resumed)
+	require.True(t, progress.Done)
+	require.Equal(t, int64(6), progress.Objects)
+	require.Equal(t, int64(1), progress.DeleteMarkers)
+	require.Equal(t, []string{"a1", "a2"}, mirror.bodies("a.jpg"), "versions are copied oldest first")
+	require.Equal(t, []string{"b1", "<deleted>"}, mirror.bodies("b.jpg"))
+
+	// Importing the mirror recreates the version history
+	progress, err = transfers.Import(ctx, TransferInput{BucketName: "restored", Versions: true, Remote: mirror, RemoteURL: "s3://mirror/photos"})
+	require.NoError(t, err)
+	require.Equal(t, int64(6), progress.Objects)
+
+	output, err := objects.GetObject(ctx, GetObjectInput{BucketName: "restored", Key: "a.jpg", OwnerID: user.ID})
+	require.NoError(t, err)
+	body, err := io.ReadAll(output.Body)
+	output.Body.Close()
+	require.NoError(t, err)
+	require.Equal(t, "a2", string(body))
+	require.Equal(t, "x100", output.Metadata["camera"])
+
+	_, err = objects.GetObject(ctx, GetObjectInput{BucketName: "restored", Key: "b.jpg", OwnerID: user.ID})
+	require.ErrorIs(t, err, domain.ErrObjectDeleted)
+
+	versions, err := objectRepo.ListVersions(ctx, restored.ID, repository.ObjectListOptions{})
+	require.NoError(t, err)
+	require.Len(t, versions.Versions, 6)
+	require.Len(t, versions.DeleteMarkers, 1)
+
+	_, err = transfers.Import(ctx, TransferInput{BucketName: "missing", Remote: mirror})
+	require.ErrorIs(t, err, domain.ErrBucketNotFound)
+}
@@ -3,6 +3,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/mail"
 	"time"
@@ -16,24 +17,39 @@ import (
 
 // UserService handles user management operations.
 type UserService struct {
-	userRepo repository.UserRepository
-	logger   zerolog.Logger
+	userRepo       repository.UserRepository
+	passwordPolicy domain.PasswordPolicy
+	logger         zerolog.Logger
 }
 
-// NewUserService creates a new UserService.
-func NewUserService(userRepo repository.UserRepository, logger zerolog.Logger) *UserService {
+// NewUserService creates a new UserService. New passwords must satisfy
+// passwordPolicy.
+func NewUserService(userRepo repository.UserRepository, passwordPolicy domain.PasswordPolicy, logger zerolog.Logger) *UserService {
 	return &UserService{
-		userRepo: userRepo,
-		logger:   logger.With().Str("service", "user").Logger(),
+		userRepo:       userRepo,
+		passwordPolicy: passwordPolicy,
+		logger:         logger.With().Str("service", "user").Logger(),
 	}
 }
 
+// PasswordPolicy returns the policy new passwords must satisfy.
+func (s *UserService) PasswordPolicy() domain.PasswordPolicy {
+	return s.passwordPolicy
+}
+
 // CreateUserInput contains the data needed to create a new user.
 type CreateUserInput struct {
 	Username string
 	Email    string
 	Password string
-	IsAdmin  bool
+	Role     domain.UserRole
+
+	// MustChangePassword forces the user to choose a new password at the
+	// first login.
+	MustChangePassword bool
+
+	// TenantID is the tenant the user joins, the default tenant if 0.
+	TenantID int64
 }
 
 // CreateUserOutput contains the result of creating a user.
@@ -51,7 +67,7 @@ func (s *UserService) Create(ctx context.Context, input CreateUserInput) (*Creat
 	// Check if username already exists
 	exists, err := s.userRepo.ExistsByUsername(ctx, input.Username)
 	if err != nil {
-		s.logger.Error().Err(err).Str("username", input.Username).Msg("failed to check username existence")
+		s.logger.Error().Ctx(ctx).Err(err).Str("username", input.Username).Msg("failed to check username existence")
 		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 	if exists {
@@ -61,7 +77,7 @@ func (s *UserService) Create(ctx context.Context, input CreateUserInput) (*Creat
 	// Check if email already exists
 	exists, err = s.userRepo.ExistsByEmail(ctx, input.Email)
 	if err != nil {
-		s.logger.Error().Err(err).Str("email", input.Email).Msg("failed to check email existence")
+		s.logger.Error().Ctx(ctx).Err(err).Str("email", input.Email).Msg("failed to check email existence")
 		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 	if exists {
@@ -71,23 +87,27 @@ func (s *UserService) Create(ctx context.Context, input CreateUserInput) (*Creat
 	// Hash password
 	passwordHash, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
 	if err != nil {
-		s.logger.Error().Err(err).Msg("failed to hash password")
+		s.logger.Error().Ctx(ctx).Err(err).Msg("failed to hash password")
 		return nil, fmt.Errorf("%w: failed to hash password", ErrInternalError)
 	}
 
 	// Create user
 	user := domain.NewUser(input.Username, input.Email, string(passwordHash))
-	user.IsAdmin = input.IsAdmin
+	user.Role = input.Role
+	user.MustChangePassword = input.MustChangePassword
+	if input.TenantID != 0 {
+		user.TenantID = input.TenantID
+	}
 
 	if err := s.userRepo.Create(ctx, user); err != nil {
-		s.logger.Error().Err(err).Str("username", input.Username).Msg("failed to create user")
+		s.logger.Error().Ctx(ctx).Err(err).Str("username", input.Username).Msg("failed to create user")
 		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 
-	s.logger.Info().
+	s.logger.Info().Ctx(ctx).
 		Int64("user_id", user.ID).
 		Str("username", user.Username).
-		Bool("is_admin", user.IsAdmin).
+		Stringer("role", user.Role).
 		Msg("user created")
 
 	return &CreateUserOutput{User: user}, nil
@@ -98,21 +118,21 @@ func (s *UserService) Authenticate(ctx context.Context, username, password strin
 	user, err := s.userRepo.GetByUsername(ctx, username)
 	if err != nil {
 		// Log but don't expose whether username exists
-		s.logger.Debug().Str("username", username).Msg("user not found during authentication")
+		s.logger.Debug().Ctx(ctx).Str("username", username).Msg("user not found during authentication")
 		return nil, ErrInvalidCredentials
 	}
 
 	if !user.CanAuthenticate() {
-		s.logger.Debug().Str("username", username).Msg("inactive user attempted authentication")
+		s.logger.Debug().Ctx(ctx).Str("username", username).Msg("inactive user attempted authentication")
 		return nil, ErrUserInactive
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
-		s.logger.Debug().Str("username", username).Msg("invalid password during authentication")
+		s.logger.Debug().Ctx(ctx).Str("username", username).Msg("invalid password during authentication")
 		return nil, ErrInvalidCredentials
 	}
 
-	s.logger.Info().
+	s.logger.Info().Ctx(ctx).
 		Int64("user_id", user.ID).
 		Str("username", user.Username).
 		Msg("user authenticated")
@@ -124,10 +144,10 @@ func (s *UserService) Authenticate(ctx context.Context, username, password strin
 func (s *UserService) GetByID(ctx context.Context, id int64) (*domain.User, error) {
 	user, err := s.userRepo.GetByID(ctx, id)
 	if err != nil {
-		if err == repository.ErrNotFound {
+		if err == repository.ErrNotFound || errors.Is(err, domain.ErrUserNotFound) {
 			return nil, ErrUserNotFound
 		}
-		s.logger.Error().Err(err).Int64("user_id", id).Msg("failed to get user")
+		s.logger.Error().Ctx(ctx).Err(err).Int64("user_id", id).Msg("failed to get user")
 		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 	return user, nil
@@ -137,10 +157,10 @@ func (s *UserService) GetByID(ctx context.Context, id int64) (*domain.User, erro
 func (s *UserService) GetByUsername(ctx context.Context, username string) (*domain.User, error) {
 	user, err := s.userRepo.GetByUsername(ctx, username)
 	if err != nil {
-		if err == repository.ErrNotFound {
+		if err == repository.ErrNotFound || errors.Is(err, domain.ErrUserNotFound) {
 			return nil, ErrUserNotFound
 		}
-		s.logger.Error().Err(err).Str("username", username).Msg("failed to get user")
+		s.logger.Error().Ctx(ctx).Err(err).Str("username", username).Msg("failed to get user")
 		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 	return user, nil
@@ -158,7 +178,7 @@ func (s *UserService) UpdatePassword(ctx context.Context, input UpdatePasswordIn
 	// Get user
 	user, err := s.userRepo.GetByID(ctx, input.UserID)
 	if err != nil {
-		if err == repository.ErrNotFound {
+		if err == repository.ErrNotFound || errors.Is(err, domain.ErrUserNotFound) {
 			return ErrUserNotFound
 		}
 		return fmt.Errorf("%w: %v", ErrInternalError, err)
@@ -169,26 +189,70 @@ func (s *UserService) UpdatePassword(ctx context.Context, input UpdatePasswordIn
 		return ErrInvalidCredentials
 	}
 
-	// Validate new password
-	if len(input.NewPassword) < 8 {
-		return ErrInvalidPassword
+	if input.NewPassword == input.OldPassword {
+		return fmt.Errorf("%w: must differ from the current password", ErrInvalidPassword)
 	}
 
-	// Hash new password
-	newHash, err := bcrypt.GenerateFromPassword([]byte(input.NewPassword), bcrypt.DefaultCost)
+	// Changing the password satisfies a forced reset
+	if err := s.setPassword(ctx, user, input.NewPassword, false); err != nil {
+		return err
+	}
+
+	s.logger.Info().Ctx(ctx).Int64("user_id", user.ID).Msg("password updated")
+	return nil
+}
+
+// SetPasswordInput contains the data needed to reset a password.
+type SetPasswordInput struct {
+	UserID   int64
+	Password string
+
+	// MustChangePassword forces the user to choose a new password at the
+	// next login, so an administrator never knows a password in use.
+	MustChangePassword bool
+}
+
+// SetPassword resets a user's password without the old password, for
+// administrators.
+func (s *UserService) SetPassword(ctx context.Context, input SetPasswordInput) error {
+	user, err := s.userRepo.GetByID(ctx, input.UserID)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	if err := s.setPassword(ctx, user, input.Password, input.MustChangePassword); err != nil {
+		return err
+	}
+
+	s.logger.Info().Ctx(ctx).
+		Int64("user_id", user.ID).
+		Bool("must_change_password", input.MustChangePassword).
+		Msg("password reset")
+	return nil
+}
+
+// setPassword checks a new password against the policy and stores its hash.
+func (s *UserService) setPassword(ctx context.Context, user *domain.User, password string, mustChange bool) error {
+	if err := s.passwordPolicy.Validate(password); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPassword, err)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		return fmt.Errorf("%w: failed to hash password", ErrInternalError)
 	}
 
-	// Update user
-	user.PasswordHash = string(newHash)
+	user.PasswordHash = string(hash)
+	user.MustChangePassword = mustChange
 	user.UpdatedAt = time.Now().UTC()
 
 	if err := s.userRepo.Update(ctx, user); err != nil {
+		s.logger.Error().Ctx(ctx).Err(err).Int64("user_id", user.ID).Msg("failed to update password")
 		return fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
-
-	s.logger.Info().Int64("user_id", user.ID).Msg("password updated")
 	return nil
 }
 
@@ -196,7 +260,7 @@ func (s *UserService) UpdatePassword(ctx context.Context, input UpdatePasswordIn
 func (s *UserService) SetActive(ctx context.Context, userID int64, isActive bool) error {
 	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
-		if err == repository.ErrNotFound {
+		if err == repository.ErrNotFound || errors.Is(err, domain.ErrUserNotFound) {
 			return ErrUserNotFound
 		}
 		return fmt.Errorf("%w: %v", ErrInternalError, err)
@@ -209,7 +273,7 @@ func (s *UserService) SetActive(ctx context.Context, userID int64, isActive bool
 		return fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 
-	s.logger.Info().
+	s.logger.Info().Ctx(ctx).
 		Int64("user_id", user.ID).
 		Bool("is_active", isActive).
 		Msg("user active status updated")
@@ -217,27 +281,31 @@ func (s *UserService) SetActive(ctx context.Context, userID int64, isActive bool
 	return nil
 }
 
-// SetAdmin sets the admin status of a user.
-func (s *UserService) SetAdmin(ctx context.Context, userID int64, isAdmin bool) error {
+// SetRole sets the role of a user.
+func (s *UserService) SetRole(ctx context.Context, userID int64, role domain.UserRole) error {
+	if !role.IsValid() {
+		return domain.ErrInvalidUserRole
+	}
+
 	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
-		if err == repository.ErrNotFound {
+		if errors.Is(err, domain.ErrUserNotFound) {
 			return ErrUserNotFound
 		}
 		return fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 
-	user.IsAdmin = isAdmin
+	user.Role = role
 	user.UpdatedAt = time.Now().UTC()
 
 	if err := s.userRepo.Update(ctx, user); err != nil {
 		return fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 
-	s.logger.Info().
+	s.logger.Info().Ctx(ctx).
 		Int64("user_id", user.ID).
-		Bool("is_admin", isAdmin).
-		Msg("user admin status updated")
+		Stringer("role", role).
+		Msg("user role updated")
 
 	return nil
 }
@@ -245,13 +313,13 @@ func (s *UserService) SetAdmin(ctx context.Context, userID int64, isAdmin bool)
 // Delete deletes a user account.
 func (s *UserService) Delete(ctx context.Context, userID int64) error {
 	if err := s.userRepo.Delete(ctx, userID); err != nil {
-		if err == repository.ErrNotFound {
+		if err == repository.ErrNotFound || errors.Is(err, domain.ErrUserNotFound) {
 			return ErrUserNotFound
 		}
 		return fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 
-	s.logger.Info().Int64("user_id", userID).Msg("user deleted")
+	s.logger.Info().Ctx(ctx).Int64("user_id", userID).Msg("user deleted")
 	return nil
 }
 
@@ -259,6 +327,9 @@ func (s *UserService) Delete(ctx context.Context, userID int64) error {
 type ListUsersInput struct {
 	Limit  int
 	Offset int
+
+	// TenantID optionally restricts the list to the users of a tenant.
+	TenantID int64
 }
 
 // ListUsersOutput contains the result of listing users.
@@ -276,12 +347,19 @@ func (s *UserService) List(ctx context.Context, input ListUsersInput) (*ListUser
 		input.Limit = 100
 	}
 
-	result, err := s.userRepo.List(ctx, repository.ListOptions{
+	opts := repository.ListOptions{
 		Limit:  input.Limit,
 		Offset: input.Offset,
-	})
+	}
+	var result *repository.ListResult[domain.User]
+	var err error
+	if input.TenantID != 0 {
+		result, err = s.userRepo.ListByTenant(ctx, input.TenantID, opts)
+	} else {
+		result, err = s.userRepo.List(ctx, opts)
+	}
 	if err != nil {
-		s.logger.Error().Err(err).Msg("failed to list users")
+		s.logger.Error().Ctx(ctx).Err(err).Msg("failed to list users")
 		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
 	}
 
@@ -304,8 +382,12 @@ func (s *UserService) validateCreateInput(input CreateUserInput) error {
 	}
 
 	// Validate password
-	if len(input.Password) < 8 {
-		return ErrInvalidPassword
+	if err := s.passwordPolicy.Validate(input.Password); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPassword, err)
+	}
+
+	if !input.Role.IsValid() {
+		return domain.ErrInvalidUserRole
 	}
 
 	return nil
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository/sqlite"
+)
+
+func TestPasswordPolicy(t *testing.T) {
+	policy := domain.PasswordPolicy{
+		MinLength:     12,
+		RequireUpper:  true,
+		RequireDigit:  true,
+		RequireSymbol: true,
+		Banned:        []string{"Password123!"},
+	}
+
+	for password, ok := range map[string]bool{
+		"Sh0rt!":            false,
+		"alllowercase1!":    false,
+		"NoDigitsHere!!":    false,
+		"NoSymbols12345":    false,
+		"password123!":      false, // banned, ignoring case
+		"Correct-Horse-42":  true,
+		"Ünïcödé-Pässwörd1": true,
+	} {
+		assert.Equal(t, ok, policy.Validate(password) == nil, password)
+	}
+
+	// Lengths below the minimum of any policy are raised to it
+	assert.Error(t, domain.PasswordPolicy{MinLength: 4}.Validate("abc12"))
+
+	for range 20 {
+		password, err := policy.Generate(16)
+		require.NoError(t, err)
+		assert.Len(t, password, 16)
+		assert.NoError(t, policy.Validate(password))
+	}
+}
+
+func TestUserService_ForcedPasswordChange(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqlite.NewDB(ctx, sqlite.DefaultConfig(filepath.Join(t.TempDir(), "alexander.db")), zerolog.Nop())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, db.Migrate(ctx))
+
+	userRepo := sqlite.NewUserRepository(db)
+	users := NewUserService(userRepo, domain.PasswordPolicy{MinLength: 10, RequireDigit: true}, zerolog.Nop())
+	sessions := NewSessionService(sqlite.NewSessionRepository(db), userRepo, zerolog.Nop(), DefaultSessionServiceConfig())
+
+	_, err = users.Create(ctx, CreateUserInput{Username: "alice", Email: "alice@example.com", Password: "no-digits-here", Role: domain.RoleAdmin})
+	assert.ErrorIs(t, err, ErrInvalidPassword)
+
+	output, err := users.Create(ctx, CreateUserInput{
+		Username:           "alice",
+		Email:              "alice@example.com",
+		Password:           "initial-password-1",
+		Role:               domain.RoleAdmin,
+		MustChangePassword: true,
+	})
+	require.NoError(t, err)
+	userID := output.User.ID
+
+	login := LoginInput{Username: "alice", Password: "initial-password-1"}
+	_, err = sessions.Login(ctx, login)
+	assert.ErrorIs(t, err, ErrPasswordChangeRequired)
+
+	// A wrong password does not reveal that a change is required
+	_, err = sessions.Login(ctx, LoginInput{Username: "alice", Password: "wrong-password-1"})
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	// The new password must satisfy the policy and differ from the old one
+	change := UpdatePasswordInput{UserID: userID, OldPassword: "initial-password-1"}
+	for _, password := range []string{"short1", "initial-password-1"} {
+		change.NewPassword = password
+		assert.ErrorIs(t, users.UpdatePassword(ctx, change), ErrInvalidPassword)
+	}
+
+	change.NewPassword = "changed-password-2"
+	require.NoError(t, users.UpdatePassword(ctx, change))
+	_, err = sessions.Login(ctx, LoginInput{Username: "alice", Password: "changed-password-2"})
+	require.NoError(t, err)
+
+	// An administrator reset forces another change
+	require.NoError(t, users.SetPassword(ctx, SetPasswordInput{UserID: userID, Password: "reset-password-3", MustChangePassword: true}))
+	user, err := users.GetByID(ctx, userID)
+	require.NoError(t, err)
+	assert.True(t, user.MustChangePassword)
+	_, err = sessions.Login(ctx, LoginInput{Username: "alice", Password: "reset-password-3"})
+	assert.ErrorIs(t, err, ErrPasswordChangeRequired)
+
+	assert.ErrorIs(t, users.SetPassword(ctx, SetPasswordInput{UserID: userID, Password: "weak"}), ErrInvalidPassword)
+	assert.ErrorIs(t, users.SetPassword(ctx, SetPasswordInput{UserID: userID + 1, Password: "another-password-4"}), ErrUserNotFound)
+}
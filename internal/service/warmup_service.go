@@ -0,0 +1,242 @@
+// Package service provides business logic services for Alexander Storage.
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// WarmupConfig contains startup warmup configuration.
+type WarmupConfig struct {
+	// Lookback is how much usage history is used to pick the hot buckets
+	// and access keys. Default: 24 hours.
+	Lookback time.Duration
+
+	// MaxBuckets is the number of buckets warmed. Default: 20.
+	MaxBuckets int
+
+	// MaxAccessKeys is the number of access keys warmed. Default: 100.
+	MaxAccessKeys int
+
+	// KeysPerBucket is the number of object keys listed in each bucket.
+	// Default: 1000, one full ListObjects page.
+	KeysPerBucket int
+
+	// CachedKeysPerBucket is the number of the listed keys whose metadata
+	// is read individually, which loads it into the object cache if one
+	// wraps the object repository. Default: 0.
+	CachedKeysPerBucket int
+
+	// Timeout bounds the whole warmup. Default: 30 seconds.
+	Timeout time.Duration
+}
+
+// WarmupResult summarizes a warmup run.
+type WarmupResult struct {
+	Buckets    int
+	AccessKeys int
+	Objects    int
+	Cached     int
+	Duration   time.Duration
+}
+
+// WarmupService reads the data the first requests after a restart are most
+// likely to need, so the database page cache and the operating system's file
+// cache are warm before traffic arrives. Hot buckets and access keys are
+// ranked by request counts from usage metering; without metering data all
+// buckets are candidates, in name order.
+type WarmupService struct {
+	usageRepo     repository.UsageRepository
+	bucketRepo    repository.BucketRepository
+	objectRepo    repository.ObjectRepository
+	accessKeyRepo repository.AccessKeyRepository
+	logger        zerolog.Logger
+	config        WarmupConfig
+
+	// now is replaced in tests.
+	now func() time.Time
+}
+
+// NewWarmupService creates a new WarmupService. usageRepo may be nil.
+func NewWarmupService(
+	usageRepo repository.UsageRepository,
+	bucketRepo repository.BucketRepository,
+	objectRepo repository.ObjectRepository,
+	accessKeyRepo repository.AccessKeyRepository,
+	logger zerolog.Logger,
+	config WarmupConfig,
+) *WarmupService {
+	if config.Lookback <= 0 {
+		config.Lookback = 24 * time.Hour
+	}
+	if config.MaxBuckets <= 0 {
+		config.MaxBuckets = 20
+	}
+	if config.MaxAccessKeys <= 0 {
+		config.MaxAccessKeys = 100
+	}
+	if config.KeysPerBucket <= 0 {
+		config.KeysPerBucket = 1000
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 30 * time.Second
+	}
+
+	return &WarmupService{
+		usageRepo:     usageRepo,
+		bucketRepo:    bucketRepo,
+		objectRepo:    objectRepo,
+		accessKeyRepo: accessKeyRepo,
+		logger:        logger.With().Str("service", "warmup").Logger(),
+		config:        config,
+		now:           time.Now,
+	}
+}
+
+// Run performs the warmup. It stops at the configured timeout and returns
+// what was warmed so far; a timeout is not reported as an error.
+func (s *WarmupService) Run(ctx context.Context) (*WarmupResult, error) {
+	start := s.now()
+	ctx, cancel := context.WithTimeout(ctx, s.config.Timeout)
+	defer cancel()
+
+	result := &WarmupResult{}
+	err := s.run(ctx, result)
+	result.Duration = s.now().Sub(start)
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		s.logger.Warn().Ctx(ctx).Dur("timeout", s.config.Timeout).Msg("warmup timed out")
+		err = nil
+	}
+	if err != nil {
+		return result, err
+	}
+
+	s.logger.Info().Ctx(ctx).
+		Int("buckets", result.Buckets).
+		Int("access_keys", result.AccessKeys).
+		Int("objects", result.Objects).
+		Int("cached", result.Cached).
+		Dur("duration", result.Duration).
+		Msg("warmup completed")
+	return result, nil
+}
+
+func (s *WarmupService) run(ctx context.Context, result *WarmupResult) error {
+	bucketNames, accessKeyIDs, err := s.hotResources(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Access keys first: every request authenticates before it lists
+	for _, accessKeyID := range accessKeyIDs {
+		if _, err := s.accessKeyRepo.GetActiveByAccessKeyID(ctx, accessKeyID); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			continue // revoked or expired since
+		}
+		result.AccessKeys++
+	}
+
+	for _, name := range bucketNames {
+		bucket, err := s.bucketRepo.GetByName(ctx, name)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			continue // deleted since
+		}
+
+		listed, err := s.objectRepo.List(ctx, bucket.ID, repository.ObjectListOptions{MaxKeys: s.config.KeysPerBucket})
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			s.logger.Warn().Ctx(ctx).Err(err).Str("bucket", name).Msg("failed to warm bucket")
+			continue
+		}
+		result.Buckets++
+		result.Objects += len(listed.Objects)
+
+		for i, info := range listed.Objects {
+			if i == s.config.CachedKeysPerBucket {
+				break
+			}
+			if _, err := s.objectRepo.GetByKey(repository.WithObjectCache(ctx), bucket.ID, info.Key); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				continue // deleted since
+			}
+			result.Cached++
+		}
+	}
+
+	return nil
+}
+
+// hotResources returns the bucket names and access key IDs to warm, busiest first.
+func (s *WarmupService) hotResources(ctx context.Context) ([]string, []string, error) {
+	var records []*domain.UsageRecord
+	if s.usageRepo != nil {
+		now := s.now()
+		var err error
+		records, err = s.usageRepo.List(ctx, now.Add(-s.config.Lookback), now.Add(time.Hour), repository.UsageFilter{})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	bucketRequests := make(map[string]int64)
+	accessKeyRequests := make(map[string]int64)
+	for _, record := range records {
+		if record.BucketName != "" {
+			bucketRequests[record.BucketName] += record.Requests
+		}
+		if record.AccessKeyID != "" {
+			accessKeyRequests[record.AccessKeyID] += record.Requests
+		}
+	}
+
+	bucketNames := busiest(bucketRequests, s.config.MaxBuckets)
+	if len(bucketNames) == 0 {
+		buckets, err := s.bucketRepo.List(ctx, 0)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, bucket := range buckets {
+			if len(bucketNames) == s.config.MaxBuckets {
+				break
+			}
+			bucketNames = append(bucketNames, bucket.Name)
+		}
+	}
+
+	return bucketNames, busiest(accessKeyRequests, s.config.MaxAccessKeys), nil
+}
+
+// busiest returns up to limit names with the most requests, ties by name.
+func busiest(requests map[string]int64, limit int) []string {
+	names := make([]string, 0, len(requests))
+	for name := range requests {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if requests[names[i]] != requests[names[j]] {
+			return requests[names[i]] > requests[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	if len(names) > limit {
+		names = names[:limit]
+	}
+	return names
+}
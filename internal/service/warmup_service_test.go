@@ -0,0 +1,90 @@
+// Package service provides business logic services for Alexander Storage.
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// mockAccessKeyRepository mocks the access key lookup used by authentication.
+// Other methods are not implemented and panic if called.
+type mockAccessKeyRepository struct {
+	mock.Mock
+	repository.AccessKeyRepository
+}
+
+func (m *mockAccessKeyRepository) GetActiveByAccessKeyID(ctx context.Context, accessKeyID string) (*domain.AccessKey, error) {
+	args := m.Called(ctx, accessKeyID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.AccessKey), args.Error(1)
+}
+
+func TestWarmupService_WarmsBusiestResources(t *testing.T) {
+	usageRepo := new(mockUsageRepository)
+	bucketRepo := new(mockBucketRepository)
+	objectRepo := new(mockObjectRepository)
+	accessKeyRepo := new(mockAccessKeyRepository)
+	svc := NewWarmupService(usageRepo, bucketRepo, objectRepo, accessKeyRepo, zerolog.Nop(), WarmupConfig{
+		Lookback:      6 * time.Hour,
+		MaxBuckets:    2,
+		KeysPerBucket: 50,
+
+		CachedKeysPerBucket: 2,
+	})
+	now := time.Date(2024, 6, 3, 10, 42, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	usageRepo.On("List", mock.Anything, now.Add(-6*time.Hour), now.Add(time.Hour), repository.UsageFilter{}).Return([]*domain.UsageRecord{
+		{BucketName: "logs", AccessKeyID: "AKIA1", Requests: 10},
+		{BucketName: "photos", AccessKeyID: "AKIA1", Requests: 300},
+		{BucketName: "logs", AccessKeyID: "AKIA2", Requests: 200},
+		{BucketName: "archive", AccessKeyID: "AKIAREVOKED", Requests: 1},
+		{BucketName: "photos", Requests: 5}, // anonymous
+	}, nil)
+
+	accessKeyRepo.On("GetActiveByAccessKeyID", mock.Anything, "AKIA1").Return(&domain.AccessKey{}, nil).Once()
+	accessKeyRepo.On("GetActiveByAccessKeyID", mock.Anything, "AKIA2").Return(&domain.AccessKey{}, nil).Once()
+	accessKeyRepo.On("GetActiveByAccessKeyID", mock.Anything, "AKIAREVOKED").Return(nil, domain.ErrAccessKeyNotFound).Once()
+
+	// Only the two busiest buckets are warmed
+	bucketRepo.On("GetByName", mock.Anything, "photos").Return(&domain.Bucket{ID: 1, Name: "photos"}, nil).Once()
+	bucketRepo.On("GetByName", mock.Anything, "logs").Return(&domain.Bucket{ID: 2, Name: "logs"}, nil).Once()
+	photos := make([]*domain.ObjectInfo, 50)
+	for i := range photos {
+		photos[i] = &domain.ObjectInfo{Key: fmt.Sprintf("%02d.jpg", i)}
+	}
+	objectRepo.On("List", mock.Anything, int64(1), repository.ObjectListOptions{MaxKeys: 50}).
+		Return(&repository.ObjectListResult{Objects: photos}, nil).Once()
+	objectRepo.On("List", mock.Anything, int64(2), repository.ObjectListOptions{MaxKeys: 50}).
+		Return(&repository.ObjectListResult{Objects: []*domain.ObjectInfo{{Key: "a"}, {Key: "b"}, {Key: "c"}}}, nil).Once()
+
+	// The first listed keys of each bucket are loaded into the object cache
+	cachedLookup := mock.MatchedBy(repository.ObjectCacheFromContext)
+	objectRepo.On("GetByKey", cachedLookup, int64(1), "00.jpg").Return(&domain.Object{}, nil).Once()
+	objectRepo.On("GetByKey", cachedLookup, int64(1), "01.jpg").Return(&domain.Object{}, nil).Once()
+	objectRepo.On("GetByKey", cachedLookup, int64(2), "a").Return(&domain.Object{}, nil).Once()
+	objectRepo.On("GetByKey", cachedLookup, int64(2), "b").Return(nil, domain.ErrObjectNotFound).Once()
+
+	result, err := svc.Run(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, result.Buckets)
+	require.Equal(t, 2, result.AccessKeys)
+	require.Equal(t, 53, result.Objects)
+	require.Equal(t, 3, result.Cached)
+
+	bucketRepo.AssertExpectations(t)
+	objectRepo.AssertExpectations(t)
+	accessKeyRepo.AssertExpectations(t)
+}
@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/repository"
+)
+
+// WebsiteService manages bucket website configurations and answers
+// requests to website endpoints.
+type WebsiteService struct {
+	websiteRepo repository.WebsiteRepository
+	bucketRepo  repository.BucketRepository
+	objects     *ObjectService
+	logger      zerolog.Logger
+
+	// now is replaceable in tests.
+	now func() time.Time
+}
+
+// NewWebsiteService creates a new WebsiteService. Objects are read
+// through objects, without an owner, once the bucket is known to allow
+// anonymous reads.
+func NewWebsiteService(
+	websiteRepo repository.WebsiteRepository,
+	bucketRepo repository.BucketRepository,
+	objects *ObjectService,
+	logger zerolog.Logger,
+) *WebsiteService {
+	return &WebsiteService{
+		websiteRepo: websiteRepo,
+		bucketRepo:  bucketRepo,
+		objects:     objects,
+		logger:      logger.With().Str("service", "website").Logger(),
+		now:         time.Now,
+	}
+}
+
+// =============================================================================
+// Configuration
+// =============================================================================
+
+// PutBucketWebsite creates or replaces the website configuration of a bucket.
+func (s *WebsiteService) PutBucketWebsite(ctx context.Context, bucketName string, ownerID int64, config *domain.WebsiteConfig) error {
+	bucket, err := s.bucket(ctx, bucketName, ownerID)
+	if err != nil {
+		return err
+	}
+
+	config.BucketID = bucket.ID
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	now := s.now().UTC()
+	config.CreatedAt = now
+	config.UpdatedAt = now
+	if err := s.websiteRepo.PutConfig(ctx, config); err != nil {
+		return fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	s.logger.Info().Ctx(ctx).
+		Str("bucket", bucket.Name).
+		Str("index_document", config.IndexDocument).
+		Int("routing_rules", len(config.RoutingRules)).
+		Msg("bucket website configured")
+	return nil
+}
+
+// GetBucketWebsite returns the website configuration of a bucket.
+func (s *WebsiteService) GetBucketWebsite(ctx context.Context, bucketName string, ownerID int64) (*domain.WebsiteConfig, error) {
+	bucket, err := s.bucket(ctx, bucketName, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := s.websiteRepo.GetConfig(ctx, bucket.ID)
+	if err != nil {
+		if errors.Is(err, domain.ErrWebsiteConfigNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	return config, nil
+}
+
+// DeleteBucketWebsite removes the website configuration of a bucket.
+func (s *WebsiteService) DeleteBucketWebsite(ctx context.Context, bucketName string, ownerID int64) error {
+	bucket, err := s.bucket(ctx, bucketName, ownerID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.websiteRepo.DeleteConfig(ctx, bucket.ID); err != nil {
+		if errors.Is(err, domain.ErrWebsiteConfigNotFound) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	s.logger.Info().Ctx(ctx).Str("bucket", bucket.Name).Msg("bucket website removed")
+	return nil
+}
+
+// bucket returns a bucket by name, checking that ownerID owns it unless
+// ownerID is 0.
+func (s *WebsiteService) bucket(ctx context.Context, name string, ownerID int64) (*domain.Bucket, error) {
+	bucket, err := s.bucketRepo.GetByName(ctx, name)
+	if err != nil {
+		if errors.Is(err, domain.ErrBucketNotFound) {
+			return nil, domain.ErrBucketNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+	if ownerID > 0 && bucket.OwnerID != ownerID {
+		return nil, ErrBucketAccessDenied
+	}
+	return bucket, nil
+}
+
+// =============================================================================
+// Serving
+// =============================================================================
+
+// WebsiteRequest is a GET or HEAD request to a bucket's website endpoint.
+type WebsiteRequest struct {
+	BucketName string
+
+	// Key is the unescaped request path without its leading slash.
+	Key string
+
+	// Scheme and Host are those of the request, used to build redirects
+	// that keep them.
+	Scheme string
+	Host   string
+}
+
+// WebsiteResponse is the answer to a website request: a redirect to
+// Location, or Object served with StatusCode.
+type WebsiteResponse struct {
+	StatusCode int
+	Location   string
+	Object     *GetObjectOutput
+}
+
+// Serve answers a website request. Only buckets with a public-read ACL are
+// served; others return ErrBucketAccessDenied. Keys ending in "/" resolve
+// to their index document, and a key that only exists as a directory is
+// redirected to its "/" form. A missing key returns the error document
+// with status 404 if one is configured, otherwise domain.ErrObjectNotFound.
+func (s *WebsiteService) Serve(ctx context.Context, request WebsiteRequest) (*WebsiteResponse, error) {
+	bucket, err := s.bucket(ctx, request.BucketName, 0)
+	if err != nil {
+		return nil, err
+	}
+	if !bucket.ACL.AllowsAnonymousRead() {
+		return nil, ErrBucketAccessDenied
+	}
+
+	config, err := s.websiteRepo.GetConfig(ctx, bucket.ID)
+	if err != nil {
+		if errors.Is(err, domain.ErrWebsiteConfigNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInternalError, err)
+	}
+
+	if redirect := config.RedirectAllRequestsTo; redirect != nil {
+		return &WebsiteResponse{
+			StatusCode: http.StatusMovedPermanently,
+			Location:   redirect.Location(request.Key, request.Scheme),
+		}, nil
+	}
+	if rule := config.RuleFor(request.Key, 0); rule != nil {
+		return websiteRedirect(rule, request), nil
+	}
+
+	key := config.IndexKey(request.Key)
+	object, err := s.get(ctx, bucket.Name, key)
+	if err == nil {
+		return &WebsiteResponse{StatusCode: http.StatusOK, Object: object}, nil
+	}
+	if !objectMissing(err) {
+		return nil, err
+	}
+
+	// A key naming a directory with an index document is redirected, so
+	// relative links in the document resolve below it
+	if key == request.Key {
+		object, err := s.get(ctx, bucket.Name, key+"/"+config.IndexDocument)
+		if err == nil {
+			object.Body.Close()
+			return &WebsiteResponse{
+				StatusCode: http.StatusFound,
+				Location:   (&url.URL{Path: "/" + key + "/"}).String(),
+			}, nil
+		}
+		if !objectMissing(err) {
+			return nil, err
+		}
+	}
+
+	if rule := config.RuleFor(request.Key, http.StatusNotFound); rule != nil {
+		return websiteRedirect(rule, request), nil
+	}
+	if config.ErrorDocument != "" {
+		object, err := s.get(ctx, bucket.Name, config.ErrorDocument)
+		if err == nil {
+			return &WebsiteResponse{StatusCode: http.StatusNotFound, Object: object}, nil
+		}
+		if !objectMissing(err) {
+			return nil, err
+		}
+	}
+	return nil, domain.ErrObjectNotFound
+}
+
+// get returns the current version of an object, with a content type
+// guessed from the key's extension if it was stored without one.
+func (s *WebsiteService) get(ctx context.Context, bucketName, key string) (*GetObjectOutput, error) {
+	object, err := s.objects.GetObject(ctx, GetObjectInput{BucketName: bucketName, Key: key})
+	if err != nil {
+		return nil, err
+	}
+	switch object.ContentType {
+	case "", "application/octet-stream", "binary/octet-stream":
+		if contentType := mime.TypeByExtension(path.Ext(key)); contentType != "" {
+			object.ContentType = contentType
+		}
+	}
+	return object, nil
+}
+
+func objectMissing(err error) bool {
+	return errors.Is(err, domain.ErrObjectNotFound) || errors.Is(err, domain.ErrObjectDeleted)
+}
+
+func websiteRedirect(rule *domain.WebsiteRoutingRule, request WebsiteRequest) *WebsiteResponse {
+	return &WebsiteResponse{
+		StatusCode: rule.StatusCode(),
+		Location:   rule.Location(request.Key, request.Scheme, request.Host),
+	}
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/domain"
+	"github.com/prn-tf/alexander-storage/internal/lock"
+	"github.com/prn-tf/alexander-storage/internal/repository/sqlite"
+	"github.com/prn-tf/alexander-storage/internal/storage/filesystem"
+)
+
+// newWebsiteTestEnv returns services backed by SQLite and the filesystem,
+// with a public-read bucket "site" and a private bucket "private" of the
+// returned owner.
+func newWebsiteTestEnv(t *testing.T) (*ObjectService, *WebsiteService, int64) {
+	t.Helper()
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	db, err := sqlite.NewDB(ctx, sqlite.DefaultConfig(filepath.Join(dir, "alexander.db")), zerolog.Nop())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, db.Migrate(ctx))
+
+	store, err := filesystem.NewStorage(filesystem.Config{
+		DataDir: filepath.Join(dir, "blobs"),
+		TempDir: filepath.Join(dir, "tmp"),
+	}, zerolog.Nop())
+	require.NoError(t, err)
+
+	owner := domain.NewUser("owner", "owner@example.com", "hash")
+	require.NoError(t, sqlite.NewUserRepository(db).Create(ctx, owner))
+
+	bucketRepo := sqlite.NewBucketRepository(db)
+	site := domain.NewBucket(owner.ID, "site")
+	site.ACL = domain.ACLPublicRead
+	require.NoError(t, bucketRepo.Create(ctx, site))
+	require.NoError(t, bucketRepo.Create(ctx, domain.NewBucket(owner.ID, "private")))
+
+	objects := NewObjectService(
+		sqlite.NewObjectRepository(db),
+		sqlite.NewBlobRepository(db),
+		bucketRepo,
+		sqlite.NewEventRepository(db),
+		sqlite.NewReplicationRepository(db),
+		sqlite.NewLifecycleRepository(db),
+		sqlite.NewStagedObjectRepository(db),
+		sqlite.NewBatchRepository(db),
+		nil,
+		sqlite.NewTxManager(db),
+		store,
+		lock.NewMemoryLocker(),
+		nil,
+		zerolog.Nop(),
+	)
+	websites := NewWebsiteService(sqlite.NewWebsiteRepository(db), bucketRepo, objects, zerolog.Nop())
+	return objects, websites, owner.ID
+}
+
+func TestWebsiteService_Configuration(t *testing.T) {
+	_, websites, ownerID := newWebsiteTestEnv(t)
+	ctx := context.Background()
+
+	require.ErrorIs(t, websites.PutBucketWebsite(ctx, "site", ownerID, &domain.WebsiteConfig{IndexDocument: "a/index.html"}),
+		domain.ErrInvalidWebsiteConfig)
+	require.ErrorIs(t, websites.PutBucketWebsite(ctx, "site", ownerID, &domain.WebsiteConfig{
+		IndexDocument:         "index.html",
+		RedirectAllRequestsTo: &domain.WebsiteRedirectAll{HostName: "example.com"},
+	}), domain.ErrInvalidWebsiteConfig)
+	require.ErrorIs(t, websites.PutBucketWebsite(ctx, "site", ownerID+1, &domain.WebsiteConfig{IndexDocument: "index.html"}),
+		ErrBucketAccessDenied)
+
+	config := &domain.WebsiteConfig{
+		IndexDocument: "index.html",
+		ErrorDocument: "404.html",
+		RoutingRules: []domain.WebsiteRoutingRule{{
+			Condition: domain.WebsiteCondition{KeyPrefixEquals: "docs/"},
+			Redirect:  domain.WebsiteRedirect{ReplaceKeyPrefixWith: "documents/"},
+		}},
+	}
+	require.NoError(t, websites.PutBucketWebsite(ctx, "site", ownerID, config))
+	stored, err := websites.GetBucketWebsite(ctx, "site", ownerID)
+	require.NoError(t, err)
+	require.Equal(t, config.RoutingRules, stored.RoutingRules)
+	require.Equal(t, "404.html", stored.ErrorDocument)
+
+	require.NoError(t, websites.DeleteBucketWebsite(ctx, "site", ownerID))
+	_, err = websites.GetBucketWebsite(ctx, "site", ownerID)
+	require.ErrorIs(t, err, domain.ErrWebsiteConfigNotFound)
+	require.ErrorIs(t, websites.DeleteBucketWebsite(ctx, "site", ownerID), domain.ErrWebsiteConfigNotFound)
+}
+
+func TestWebsiteService_Serve(t *testing.T) {
+	objects, websites, ownerID := newWebsiteTestEnv(t)
+	ctx := context.Background()
+
+	put := func(bucket, key, contentType, body string) {
+		_, err := objects.PutObject(ctx, PutObjectInput{
+			BucketName:  bucket,
+			Key:         key,
+			Body:        strings.NewReader(body),
+			Size:        int64(len(body)),
+			ContentType: contentType,
+			OwnerID:     ownerID,
+		})
+		require.NoError(t, err)
+	}
+	put("site", "index.html", "", "home")
+	put("site", "blog/index.html", "text/html", "blog")
+	put("site", "style.css", "application/octet-stream", "css")
+	put("site", "404.html", "text/html", "not found")
+	put("private", "index.html", "text/html", "secret")
+
+	serve := func(bucket, key string) (*WebsiteResponse, string, error) {
+		response, err := websites.Serve(ctx, WebsiteRequest{BucketName: bucket, Key: key, Scheme: "http", Host: bucket + ".web.test"})
+		if err != nil || response.Object == nil {
+			return response, "", err
+		}
+		defer response.Object.Body.Close()
+		body, err := io.ReadAll(response.Object.Body)
+		require.NoError(t, err)
+		return response, string(body), nil
+	}
+
+	_, _, err := serve("site", "")
+	require.ErrorIs(t, err, domain.ErrWebsiteConfigNotFound)
+
+	require.NoError(t, websites.PutBucketWebsite(ctx, "site", ownerID, &domain.WebsiteConfig{
+		IndexDocument: "index.html",
+		ErrorDocument: "404.html",
+		RoutingRules: []domain.WebsiteRoutingRule{
+			{
+				Condition: domain.WebsiteCondition{KeyPrefixEquals: "docs/"},
+				Redirect:  domain.WebsiteRedirect{HostName: "docs.example.com", ReplaceKeyPrefixWith: "manual/"},
+			},
+			{
+				Condition: domain.WebsiteCondition{KeyPrefixEquals: "old/", HTTPErrorCodeReturnedEquals: 404},
+				Redirect:  domain.WebsiteRedirect{ReplaceKeyWith: "index.html", HTTPRedirectCode: 302},
+			},
+		},
+	}))
+	require.NoError(t, websites.PutBucketWebsite(ctx, "private", ownerID, &domain.WebsiteConfig{IndexDocument: "index.html"}))
+
+	// Index documents, with content types guessed for untyped objects
+	response, body, err := serve("site", "")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.Equal(t, "home", body)
+	require.Equal(t, "text/html; charset=utf-8", response.Object.ContentType)
+
+	response, body, err = serve("site", "blog/")
+	require.NoError(t, err)
+	require.Equal(t, "blog", body)
+
+	response, body, err = serve("site", "style.css")
+	require.NoError(t, err)
+	require.Equal(t, "css", body)
+	require.Equal(t, "text/css; charset=utf-8", response.Object.ContentType)
+
+	// Directories without their slash are redirected
+	response, _, err = serve("site", "blog")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusFound, response.StatusCode)
+	require.Equal(t, "/blog/", response.Location)
+
+	// Routing rules before and after the lookup
+	response, _, err = serve("site", "docs/intro.html")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusMovedPermanently, response.StatusCode)
+	require.Equal(t, "http://docs.example.com/manual/intro.html", response.Location)
+
+	response, _, err = serve("site", "old/page.html")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusFound, response.StatusCode)
+	require.Equal(t, "http://site.web.test/index.html", response.Location)
+
+	// Missing keys get the error document
+	response, body, err = serve("site", "missing.html")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNotFound, response.StatusCode)
+	require.Equal(t, "not found", body)
+
+	// Private buckets are not served, even with a configuration
+	_, _, err = serve("private", "")
+	require.ErrorIs(t, err, ErrBucketAccessDenied)
+
+	_, _, err = serve("missing", "")
+	require.ErrorIs(t, err, domain.ErrBucketNotFound)
+
+	// Redirecting everything excludes the rest
+	require.NoError(t, websites.PutBucketWebsite(ctx, "site", ownerID, &domain.WebsiteConfig{
+		RedirectAllRequestsTo: &domain.WebsiteRedirectAll{HostName: "www.example.com", Protocol: "https"},
+	}))
+	response, _, err = serve("site", "a b.html")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusMovedPermanently, response.StatusCode)
+	require.Equal(t, "https://www.example.com/a%20b.html", response.Location)
+}
@@ -0,0 +1,196 @@
+// Package storage defines interfaces for blob storage backends.
+package storage
+
+import (
+	"io"
+	"sync"
+)
+
+// ReadAheadConfig controls reading large blobs in parallel chunks, which
+// keeps several reads in flight while the consumer, typically a network
+// connection, drains earlier chunks.
+type ReadAheadConfig struct {
+	// Threshold is the read length from which chunked reads are used.
+	// Zero disables them.
+	Threshold int64
+
+	// ChunkSize is the number of bytes per read. Defaults to 1MB.
+	ChunkSize int
+
+	// Concurrency is the number of chunks read ahead. Defaults to 4.
+	Concurrency int
+}
+
+// Applies reports whether a read of length bytes is chunked.
+func (c ReadAheadConfig) Applies(length int64) bool {
+	return c.Threshold > 0 && length >= c.Threshold
+}
+
+// chunk is one read of a chunkReader.
+type chunk struct {
+	buf  []byte
+	n    int
+	err  error
+	done chan struct{}
+}
+
+// chunkReader reads a section of an io.ReaderAt in chunks, keeping up to
+// Concurrency reads in flight and returning the data in order.
+type chunkReader struct {
+	ra     io.ReaderAt
+	closer io.Closer
+
+	pending chan *chunk // chunks in read order
+	free    chan []byte // buffers available for reading, nil until allocated
+	stop    chan struct{}
+	wg      sync.WaitGroup
+	once    sync.Once
+
+	cur *chunk
+	off int
+	err error
+}
+
+// NewChunkReader returns a reader over length bytes of ra starting at
+// offset, read in parallel chunks as configured. Closing it stops reading
+// ahead and closes closer.
+func NewChunkReader(ra io.ReaderAt, closer io.Closer, offset, length int64, cfg ReadAheadConfig) io.ReadCloser {
+	chunkSize := cfg.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = 1024 * 1024
+	}
+	concurrency := cfg.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+
+	r := &chunkReader{
+		ra:      ra,
+		closer:  closer,
+		pending: make(chan *chunk, concurrency),
+		free:    make(chan []byte, concurrency+1),
+		stop:    make(chan struct{}),
+	}
+	// One buffer per queued chunk and one for the chunk being consumed
+	for i := 0; i < concurrency+1; i++ {
+		r.free <- nil
+	}
+
+	r.wg.Add(1)
+	go r.produce(offset, length, chunkSize)
+	return r
+}
+
+// produce starts a read for each chunk of the section, waiting for a free
+// buffer before each one.
+func (r *chunkReader) produce(offset, length int64, chunkSize int) {
+	defer r.wg.Done()
+	defer close(r.pending)
+
+	end := offset + length
+	for pos := offset; pos < end; pos += int64(chunkSize) {
+		var buf []byte
+		select {
+		case buf = <-r.free:
+		case <-r.stop:
+			return
+		}
+		if buf == nil {
+			buf = make([]byte, chunkSize)
+		}
+
+		c := &chunk{buf: buf[:min(int64(chunkSize), end-pos)], done: make(chan struct{})}
+		r.wg.Add(1)
+		go func(pos int64) {
+			defer r.wg.Done()
+			defer close(c.done)
+			c.n, c.err = r.ra.ReadAt(c.buf, pos)
+			switch {
+			case c.n == len(c.buf):
+				c.err = nil
+			case c.err == nil || c.err == io.EOF:
+				// The section extends past the end of the blob
+				c.err = io.ErrUnexpectedEOF
+			}
+		}(pos)
+
+		// Queued chunks are always drained by Close, so this cannot block
+		// forever
+		r.pending <- c
+	}
+}
+
+// next makes the following chunk current, releasing the current one.
+func (r *chunkReader) next() error {
+	if r.cur != nil {
+		r.free <- r.cur.buf[:cap(r.cur.buf)]
+		r.cur = nil
+	}
+	if r.err != nil {
+		return r.err
+	}
+
+	c, ok := <-r.pending
+	if !ok {
+		r.err = io.EOF
+		return r.err
+	}
+	<-c.done
+	if c.err != nil {
+		r.free <- c.buf[:cap(c.buf)]
+		r.err = c.err
+		return r.err
+	}
+	r.cur, r.off = c, 0
+	return nil
+}
+
+// Read implements io.Reader.
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for r.cur == nil || r.off == r.cur.n {
+		if err := r.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.cur.buf[r.off:r.cur.n])
+	r.off += n
+	return n, nil
+}
+
+// WriteTo implements io.WriterTo, writing chunks without copying them.
+func (r *chunkReader) WriteTo(w io.Writer) (int64, error) {
+	var written int64
+	for {
+		if r.cur != nil && r.off < r.cur.n {
+			n, err := w.Write(r.cur.buf[r.off:r.cur.n])
+			written += int64(n)
+			r.off += n
+			if err != nil {
+				return written, err
+			}
+		}
+		if err := r.next(); err != nil {
+			if err == io.EOF {
+				return written, nil
+			}
+			return written, err
+		}
+	}
+}
+
+// Close stops reading ahead, waits for reads in flight and closes the
+// underlying reader.
+func (r *chunkReader) Close() error {
+	var err error
+	r.once.Do(func() {
+		close(r.stop)
+		for c := range r.pending {
+			<-c.done
+		}
+		r.wg.Wait()
+		if r.closer != nil {
+			err = r.closer.Close()
+		}
+	})
+	return err
+}
@@ -0,0 +1,131 @@
+package storage_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/storage"
+)
+
+// slowReaderAt adds a fixed latency to every read, like a cold disk or a
+// remote range request.
+type slowReaderAt struct {
+	data    []byte
+	latency time.Duration
+}
+
+func (s *slowReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	time.Sleep(s.latency)
+	return bytes.NewReader(s.data).ReadAt(p, off)
+}
+
+// slowWriter adds a fixed latency per write, like a network connection.
+type slowWriter struct {
+	latency time.Duration
+}
+
+func (s slowWriter) Write(p []byte) (int, error) {
+	time.Sleep(s.latency)
+	return len(p), nil
+}
+
+type closeCounter struct{ closed int }
+
+func (c *closeCounter) Close() error {
+	c.closed++
+	return nil
+}
+
+func TestChunkReader(t *testing.T) {
+	data := make([]byte, 1000)
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+	ra := bytes.NewReader(data)
+	cfg := storage.ReadAheadConfig{Threshold: 1, ChunkSize: 64, Concurrency: 3}
+
+	for _, tc := range []struct {
+		name           string
+		offset, length int64
+	}{
+		{"whole", 0, 1000},
+		{"range", 100, 500},
+		{"partial chunk", 990, 10},
+		{"empty", 0, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			closer := &closeCounter{}
+			r := storage.NewChunkReader(ra, closer, tc.offset, tc.length, cfg)
+			got, err := io.ReadAll(r)
+			require.NoError(t, err)
+			require.Equal(t, data[tc.offset:tc.offset+tc.length], got)
+			require.NoError(t, r.Close())
+			require.NoError(t, r.Close())
+			require.Equal(t, 1, closer.closed)
+		})
+	}
+
+	t.Run("write to", func(t *testing.T) {
+		r := storage.NewChunkReader(ra, nil, 0, 1000, cfg)
+		var buf bytes.Buffer
+		n, err := io.Copy(&buf, r)
+		require.NoError(t, err)
+		require.Equal(t, int64(1000), n)
+		require.Equal(t, data, buf.Bytes())
+		require.NoError(t, r.Close())
+	})
+
+	t.Run("past end", func(t *testing.T) {
+		r := storage.NewChunkReader(ra, nil, 900, 200, cfg)
+		_, err := io.ReadAll(r)
+		require.True(t, errors.Is(err, io.ErrUnexpectedEOF))
+		require.NoError(t, r.Close())
+	})
+
+	t.Run("close early", func(t *testing.T) {
+		r := storage.NewChunkReader(ra, nil, 0, 1000, cfg)
+		_, err := r.Read(make([]byte, 10))
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+	})
+}
+
+// BenchmarkChunkReader copies a blob from storage with per-read latency to
+// a consumer with per-write latency, sequentially and with reads in flight
+// while earlier chunks are written.
+func BenchmarkChunkReader(b *testing.B) {
+	const size = 16 * 1024 * 1024
+	const chunkSize = 1024 * 1024
+	ra := &slowReaderAt{data: make([]byte, size), latency: 4 * time.Millisecond}
+	w := slowWriter{latency: time.Millisecond}
+
+	b.Run("sequential", func(b *testing.B) {
+		b.SetBytes(size)
+		for i := 0; i < b.N; i++ {
+			buf := make([]byte, chunkSize)
+			if _, err := io.CopyBuffer(w, io.NewSectionReader(ra, 0, size), buf); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	for _, concurrency := range []int{1, 4, 8} {
+		b.Run(fmt.Sprintf("chunked-%d", concurrency), func(b *testing.B) {
+			cfg := storage.ReadAheadConfig{Threshold: 1, ChunkSize: chunkSize, Concurrency: concurrency}
+			b.SetBytes(size)
+			for i := 0; i < b.N; i++ {
+				r := storage.NewChunkReader(ra, nil, 0, size, cfg)
+				if _, err := io.Copy(w, r); err != nil {
+					b.Fatal(err)
+				}
+				_ = r.Close()
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+// Package storage defines interfaces for blob storage backends.
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// Concat stores the concatenation of the given blobs on backend and returns
+// its content hash. Backends implementing Concatenator build it themselves;
+// for others the blobs are streamed back through Store.
+func Concat(ctx context.Context, backend Backend, contentHashes []string, size int64) (string, error) {
+	if concatenator, ok := backend.(Concatenator); ok {
+		return concatenator.Concat(ctx, contentHashes, size)
+	}
+
+	readers := make([]io.Reader, 0, len(contentHashes))
+	defer func() {
+		for _, r := range readers {
+			_ = r.(io.ReadCloser).Close()
+		}
+	}()
+	for _, hash := range contentHashes {
+		reader, err := backend.Retrieve(ctx, hash)
+		if err != nil {
+			return "", fmt.Errorf("failed to retrieve part %s: %w", hash, err)
+		}
+		readers = append(readers, reader)
+	}
+
+	contentHash, err := backend.Store(ctx, io.MultiReader(readers...), size)
+	if err != nil {
+		return "", fmt.Errorf("failed to store concatenated blob: %w", err)
+	}
+	return contentHash, nil
+}
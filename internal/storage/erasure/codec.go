@@ -0,0 +1,157 @@
+package erasure
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Codec errors.
+var (
+	// ErrTooFewShards indicates that fewer shards than data shards survive.
+	ErrTooFewShards = errors.New("too few shards to reconstruct")
+
+	// ErrShardSize indicates shards of different or zero length.
+	ErrShardSize = errors.New("shards must have the same non-zero length")
+)
+
+// Codec is a systematic Reed-Solomon code: the data shards are stored as
+// they are, and any dataShards of the dataShards+parityShards shards are
+// enough to recover the others.
+type Codec struct {
+	dataShards   int
+	parityShards int
+
+	// matrix maps the data shards to all shards; its top rows are the
+	// identity and the rest compute parity.
+	matrix matrix
+}
+
+// NewCodec creates a codec for the given number of data and parity shards.
+func NewCodec(dataShards, parityShards int) (*Codec, error) {
+	if dataShards < 1 || parityShards < 0 || dataShards+parityShards > 256 {
+		return nil, fmt.Errorf("invalid shard counts %d+%d", dataShards, parityShards)
+	}
+
+	// Multiplying a Vandermonde matrix by the inverse of its top square
+	// keeps every square selection of rows invertible and makes the top
+	// the identity, so data shards pass through unchanged
+	total := dataShards + parityShards
+	vm := vandermonde(total, dataShards)
+	top := make(matrix, dataShards)
+	copy(top, vm[:dataShards])
+	topInverse, err := top.invert()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Codec{
+		dataShards:   dataShards,
+		parityShards: parityShards,
+		matrix:       vm.multiply(topInverse),
+	}, nil
+}
+
+// DataShards returns the number of data shards.
+func (c *Codec) DataShards() int {
+	return c.dataShards
+}
+
+// ParityShards returns the number of parity shards.
+func (c *Codec) ParityShards() int {
+	return c.parityShards
+}
+
+// TotalShards returns the number of data and parity shards.
+func (c *Codec) TotalShards() int {
+	return c.dataShards + c.parityShards
+}
+
+// Encode computes the parity shards from the data shards. shards holds all
+// shards, data first, each of the same length.
+func (c *Codec) Encode(shards [][]byte) error {
+	if len(shards) != c.TotalShards() {
+		return fmt.Errorf("expected %d shards, got %d", c.TotalShards(), len(shards))
+	}
+	size := len(shards[0])
+	for _, shard := range shards {
+		if len(shard) != size || size == 0 {
+			return ErrShardSize
+		}
+	}
+
+	for p := c.dataShards; p < len(shards); p++ {
+		c.encodeRow(c.matrix[p], shards[:c.dataShards], shards[p])
+	}
+	return nil
+}
+
+// Reconstruct recomputes the missing shards, given as nil, from at least
+// dataShards present ones. Recomputed shards are newly allocated.
+func (c *Codec) Reconstruct(shards [][]byte) error {
+	if len(shards) != c.TotalShards() {
+		return fmt.Errorf("expected %d shards, got %d", c.TotalShards(), len(shards))
+	}
+
+	size := 0
+	present := make([]int, 0, c.dataShards)
+	for i, shard := range shards {
+		if shard == nil {
+			continue
+		}
+		if size == 0 {
+			size = len(shard)
+		}
+		if len(shard) != size || size == 0 {
+			return ErrShardSize
+		}
+		if len(present) < c.dataShards {
+			present = append(present, i)
+		}
+	}
+	if len(present) < c.dataShards {
+		return ErrTooFewShards
+	}
+
+	// Recover the data shards by inverting the rows of the shards used
+	missingData := false
+	for i := 0; i < c.dataShards; i++ {
+		if shards[i] == nil {
+			missingData = true
+			break
+		}
+	}
+	if missingData {
+		rows := make(matrix, c.dataShards)
+		inputs := make([][]byte, c.dataShards)
+		for i, index := range present {
+			rows[i] = c.matrix[index]
+			inputs[i] = shards[index]
+		}
+		decode, err := rows.invert()
+		if err != nil {
+			return err
+		}
+		for i := 0; i < c.dataShards; i++ {
+			if shards[i] == nil {
+				shards[i] = make([]byte, size)
+				c.encodeRow(decode[i], inputs, shards[i])
+			}
+		}
+	}
+
+	for p := c.dataShards; p < len(shards); p++ {
+		if shards[p] == nil {
+			shards[p] = make([]byte, size)
+			c.encodeRow(c.matrix[p], shards[:c.dataShards], shards[p])
+		}
+	}
+	return nil
+}
+
+// encodeRow sets out to the combination of inputs given by coefficients.
+func (c *Codec) encodeRow(coefficients []byte, inputs [][]byte, out []byte) {
+	clear(out)
+	for i, input := range inputs {
+		mulAdd(coefficients[i], input, out)
+	}
+}
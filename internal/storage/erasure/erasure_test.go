@@ -0,0 +1,141 @@
+package erasure
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/storage"
+)
+
+func TestCodec_ReconstructAnyLoss(t *testing.T) {
+	codec, err := NewCodec(4, 2)
+	require.NoError(t, err)
+
+	rng := rand.New(rand.NewSource(1))
+	original := make([][]byte, 6)
+	for i := range original {
+		original[i] = make([]byte, 100)
+		if i < 4 {
+			rng.Read(original[i])
+		}
+	}
+	require.NoError(t, codec.Encode(original))
+
+	// Every pair of lost shards is recoverable
+	for a := 0; a < 6; a++ {
+		for b := a + 1; b < 6; b++ {
+			shards := make([][]byte, 6)
+			copy(shards, original)
+			shards[a], shards[b] = nil, nil
+			require.NoError(t, codec.Reconstruct(shards))
+			assert.Equal(t, original, shards, "lost %d and %d", a, b)
+		}
+	}
+
+	shards := make([][]byte, 6)
+	copy(shards, original[:3])
+	assert.ErrorIs(t, codec.Reconstruct(shards), ErrTooFewShards)
+}
+
+func newTestStorage(t *testing.T) *Storage {
+	t.Helper()
+	dir := t.TempDir()
+	dirs := make([]string, 6)
+	for i := range dirs {
+		dirs[i] = filepath.Join(dir, "drive"+string(rune('0'+i)))
+	}
+	s, err := NewStorage(Config{
+		DataDirs:     dirs,
+		TempDir:      filepath.Join(dir, "tmp"),
+		DataShards:   4,
+		ParityShards: 2,
+		BlockSize:    1000,
+	}, zerolog.Nop())
+	require.NoError(t, err)
+	return s
+}
+
+func readAll(t *testing.T, s *Storage, contentHash string, offset, length int64) []byte {
+	t.Helper()
+	reader, err := s.RetrieveRange(context.Background(), contentHash, offset, length)
+	require.NoError(t, err)
+	defer reader.Close()
+	content, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	return content
+}
+
+func TestStorage_DegradedReadAndScrub(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+
+	data := make([]byte, 3500)
+	rand.New(rand.NewSource(2)).Read(data)
+	contentHash, err := s.Store(ctx, bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+
+	assert.Equal(t, data, readAll(t, s, contentHash, 0, 0))
+	assert.Equal(t, data[990:2010], readAll(t, s, contentHash, 990, 1020))
+	size, err := s.GetSize(ctx, contentHash)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(data)), size)
+
+	// Lose one drive's shard and corrupt another's
+	require.NoError(t, os.Remove(s.shardPath(contentHash, 1)))
+	corrupt := s.shardPath(contentHash, 4)
+	shard, err := os.ReadFile(corrupt)
+	require.NoError(t, err)
+	shard[headerSize+10] ^= 0xff
+	require.NoError(t, os.WriteFile(corrupt, shard, 0600))
+
+	assert.Equal(t, data, readAll(t, s, contentHash, 0, 0))
+	assert.Equal(t, data[2500:], readAll(t, s, contentHash, 2500, 0))
+
+	result, err := s.ScrubAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, ScrubResult{Blobs: 1, Repaired: 1}, result)
+
+	repaired, err := s.Scrub(ctx, contentHash)
+	require.NoError(t, err)
+	assert.False(t, repaired)
+
+	// The repaired shards alone are enough to read again
+	for _, i := range []int{0, 2} {
+		require.NoError(t, os.Remove(s.shardPath(contentHash, i)))
+	}
+	assert.Equal(t, data, readAll(t, s, contentHash, 0, 0))
+
+	// A third lost shard makes the blob unreadable
+	require.NoError(t, os.Remove(s.shardPath(contentHash, 3)))
+	_, err = s.Retrieve(ctx, contentHash)
+	assert.ErrorIs(t, err, ErrShardsCorrupt)
+
+	require.NoError(t, s.Delete(ctx, contentHash))
+	exists, err := s.Exists(ctx, contentHash)
+	require.NoError(t, err)
+	assert.False(t, exists)
+	assert.ErrorIs(t, s.Delete(ctx, contentHash), storage.ErrBlobNotFound)
+}
+
+func TestStorage_EmptyBlob(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+
+	contentHash, err := s.Store(ctx, bytes.NewReader(nil), 0)
+	require.NoError(t, err)
+	assert.Empty(t, readAll(t, s, contentHash, 0, 0))
+
+	exists, err := s.Exists(ctx, contentHash)
+	require.NoError(t, err)
+	assert.True(t, exists)
+	require.NoError(t, s.HealthCheck(ctx))
+}
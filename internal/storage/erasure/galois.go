@@ -0,0 +1,155 @@
+package erasure
+
+import "errors"
+
+// errSingularMatrix indicates a matrix that cannot be inverted.
+var errSingularMatrix = errors.New("matrix is singular")
+
+// Arithmetic in GF(2^8) with the generator polynomial
+// x^8 + x^4 + x^3 + x^2 + 1 (0x11d), as used by most Reed-Solomon codes.
+var (
+	expTable [510]byte
+	logTable [256]byte
+	mulTable [256][256]byte
+)
+
+func init() {
+	x := 1
+	for i := 0; i < 255; i++ {
+		expTable[i] = byte(x)
+		expTable[i+255] = byte(x)
+		logTable[x] = byte(i)
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11d
+		}
+	}
+	for a := 1; a < 256; a++ {
+		for b := 1; b < 256; b++ {
+			mulTable[a][b] = expTable[int(logTable[a])+int(logTable[b])]
+		}
+	}
+}
+
+// gfMul multiplies two field elements.
+func gfMul(a, b byte) byte {
+	return mulTable[a][b]
+}
+
+// gfInv returns the multiplicative inverse of a non-zero element.
+func gfInv(a byte) byte {
+	return expTable[255-int(logTable[a])]
+}
+
+// gfExp returns a raised to the power n.
+func gfExp(a byte, n int) byte {
+	if n == 0 {
+		return 1
+	}
+	if a == 0 {
+		return 0
+	}
+	return expTable[(int(logTable[a])*n)%255]
+}
+
+// mulAdd adds c times in to out, element-wise.
+func mulAdd(c byte, in, out []byte) {
+	if c == 0 {
+		return
+	}
+	table := &mulTable[c]
+	for i, v := range in {
+		out[i] ^= table[v]
+	}
+}
+
+// matrix is a matrix over GF(2^8), by rows.
+type matrix [][]byte
+
+// newMatrix returns a zero matrix.
+func newMatrix(rows, cols int) matrix {
+	m := make(matrix, rows)
+	for r := range m {
+		m[r] = make([]byte, cols)
+	}
+	return m
+}
+
+// identityMatrix returns the n by n identity matrix.
+func identityMatrix(n int) matrix {
+	m := newMatrix(n, n)
+	for i := range m {
+		m[i][i] = 1
+	}
+	return m
+}
+
+// vandermonde returns a matrix whose element (r, c) is r^c. Any square
+// selection of its rows is invertible.
+func vandermonde(rows, cols int) matrix {
+	m := newMatrix(rows, cols)
+	for r := range m {
+		for c := range m[r] {
+			m[r][c] = gfExp(byte(r), c)
+		}
+	}
+	return m
+}
+
+// multiply returns m times o.
+func (m matrix) multiply(o matrix) matrix {
+	result := newMatrix(len(m), len(o[0]))
+	for r := range result {
+		for c := range result[r] {
+			var v byte
+			for i := range o {
+				v ^= gfMul(m[r][i], o[i][c])
+			}
+			result[r][c] = v
+		}
+	}
+	return result
+}
+
+// invert returns the inverse of a square matrix by Gauss-Jordan elimination.
+func (m matrix) invert() (matrix, error) {
+	n := len(m)
+	work := newMatrix(n, 2*n)
+	for r := range m {
+		copy(work[r], m[r])
+		work[r][n+r] = 1
+	}
+
+	for c := 0; c < n; c++ {
+		// Find a pivot and move it to the diagonal
+		pivot := c
+		for pivot < n && work[pivot][c] == 0 {
+			pivot++
+		}
+		if pivot == n {
+			return nil, errSingularMatrix
+		}
+		work[c], work[pivot] = work[pivot], work[c]
+
+		// Scale the pivot row to 1
+		if v := work[c][c]; v != 1 {
+			inv := gfInv(v)
+			for i := range work[c] {
+				work[c][i] = gfMul(work[c][i], inv)
+			}
+		}
+
+		// Clear the column in every other row
+		for r := 0; r < n; r++ {
+			if r != c && work[r][c] != 0 {
+				mulAdd(work[r][c], work[c], work[r])
+			}
+		}
+	}
+
+	inverse := newMatrix(n, n)
+	for r := range inverse {
+		copy(inverse[r], work[r][n:])
+	}
+	return inverse, nil
+}
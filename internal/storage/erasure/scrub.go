@@ -0,0 +1,252 @@
+package erasure
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/storage"
+)
+
+// ScrubResult summarizes a scrub pass.
+type ScrubResult struct {
+	// Blobs is the number of blobs checked.
+	Blobs int
+
+	// Repaired is the number of blobs with shards rewritten.
+	Repaired int
+
+	// Unrecoverable is the number of blobs with too few intact shards.
+	Unrecoverable int
+}
+
+// Scrub verifies every chunk of a blob's shards and rewrites the shard
+// files that are missing or hold a corrupt chunk. Returns whether any
+// shard was rewritten.
+func (s *Storage) Scrub(ctx context.Context, contentHash string) (bool, error) {
+	lock := s.lockFor(contentHash)
+	lock.Lock()
+	defer lock.Unlock()
+
+	set, err := s.openShards(contentHash)
+	if err != nil {
+		return false, err
+	}
+	defer set.Close()
+
+	damaged := make(map[int]bool)
+	for i, file := range set.files {
+		if file == nil {
+			damaged[i] = true
+		}
+	}
+	shards := make([][]byte, s.codec.TotalShards())
+	for stripe := int64(0); stripe < set.header.stripes(); stripe++ {
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+		bad, err := set.readStripe(s.codec, stripe, shards, true)
+		if err != nil {
+			return false, err
+		}
+		for _, i := range bad {
+			damaged[i] = true
+		}
+	}
+	if len(damaged) == 0 {
+		return false, nil
+	}
+
+	indices := make([]int, 0, len(damaged))
+	for i := range damaged {
+		indices = append(indices, i)
+	}
+	sort.Ints(indices)
+
+	written, err := s.writeShards(contentHash, set.header, indices, func(stripe int64, out [][]byte) error {
+		_, err := set.readStripe(s.codec, stripe, out, true)
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	if written < len(indices) {
+		return true, fmt.Errorf("rewrote %d of %d damaged shards", written, len(indices))
+	}
+
+	s.logger.Info().
+		Str("content_hash", contentHash).
+		Ints("shards", indices).
+		Msg("repaired blob shards")
+	return true, nil
+}
+
+// ScrubAll scrubs every blob with a shard in any data directory.
+func (s *Storage) ScrubAll(ctx context.Context) (ScrubResult, error) {
+	var result ScrubResult
+
+	for _, contentHash := range s.listHashes() {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		result.Blobs++
+		repaired, err := s.Scrub(ctx, contentHash)
+		switch {
+		case errors.Is(err, storage.ErrBlobNotFound):
+			// Deleted since it was listed
+			result.Blobs--
+		case errors.Is(err, ErrShardsCorrupt):
+			result.Unrecoverable++
+			s.logger.Error().Err(err).Str("content_hash", contentHash).Msg("blob is unrecoverable")
+		case err != nil:
+			s.logger.Warn().Err(err).Str("content_hash", contentHash).Msg("failed to scrub blob")
+		}
+		if repaired {
+			result.Repaired++
+		}
+	}
+	return result, nil
+}
+
+// listHashes returns the content hashes of the shard files in all data
+// directories, skipping in-progress temp files.
+func (s *Storage) listHashes() []string {
+	seen := make(map[string]bool)
+	for _, dir := range s.dirs {
+		err := filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+			if err != nil {
+				if path == dir {
+					return err
+				}
+				return nil
+			}
+			name := entry.Name()
+			if entry.IsDir() || strings.HasPrefix(name, ".") {
+				return nil
+			}
+			if contentHash, _, ok := strings.Cut(name, "."); ok && len(contentHash) == sha256.Size*2 {
+				seen[contentHash] = true
+			}
+			return nil
+		})
+		if err != nil {
+			s.logger.Warn().Err(err).Str("data_dir", dir).Msg("failed to list data directory")
+		}
+	}
+
+	hashes := make([]string, 0, len(seen))
+	for contentHash := range seen {
+		hashes = append(hashes, contentHash)
+	}
+	sort.Strings(hashes)
+	return hashes
+}
+
+// Scrubber periodically scrubs all blobs of an erasure-coded storage.
+type Scrubber struct {
+	storage  *Storage
+	interval time.Duration
+	logger   zerolog.Logger
+
+	// Control
+	mu       sync.Mutex
+	running  bool
+	stopChan chan struct{}
+	doneChan chan struct{}
+}
+
+// NewScrubber creates a scrubber that runs every interval.
+func NewScrubber(s *Storage, interval time.Duration, logger zerolog.Logger) *Scrubber {
+	return &Scrubber{
+		storage:  s,
+		interval: interval,
+		logger:   logger.With().Str("component", "erasure-scrubber").Logger(),
+		stopChan: make(chan struct{}),
+		doneChan: make(chan struct{}),
+	}
+}
+
+// Start starts the scrub loop. The first pass runs after one interval.
+func (s *Scrubber) Start() {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = true
+	s.mu.Unlock()
+
+	s.logger.Info().
+		Dur("interval", s.interval).
+		Msg("Starting erasure scrubber")
+
+	go s.runLoop()
+}
+
+// Stop stops the scrubber, interrupting a pass in progress.
+func (s *Scrubber) Stop() {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = false
+	s.mu.Unlock()
+
+	close(s.stopChan)
+	<-s.doneChan
+
+	s.logger.Info().Msg("Erasure scrubber stopped")
+}
+
+// runLoop runs passes until Stop is called.
+func (s *Scrubber) runLoop() {
+	defer close(s.doneChan)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-s.stopChan
+		cancel()
+	}()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopChan:
+			return
+		case <-ticker.C:
+		}
+
+		start := time.Now()
+		result, err := s.storage.ScrubAll(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				s.logger.Error().Err(err).Msg("Scrub pass failed")
+			}
+			continue
+		}
+		event := s.logger.Info()
+		if result.Unrecoverable > 0 {
+			event = s.logger.Error()
+		}
+		event.
+			Int("blobs", result.Blobs).
+			Int("repaired", result.Repaired).
+			Int("unrecoverable", result.Unrecoverable).
+			Dur("duration", time.Since(start)).
+			Msg("Scrub pass completed")
+	}
+}
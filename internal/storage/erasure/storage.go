@@ -0,0 +1,860 @@
+// Package erasure provides an erasure-coded blob storage backend that
+// spreads each blob over several data directories, typically one per drive.
+package erasure
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog"
+
+	"github.com/prn-tf/alexander-storage/internal/pkg/telemetry"
+	"github.com/prn-tf/alexander-storage/internal/storage"
+)
+
+const (
+	// DefaultBlockSize is the number of blob bytes encoded per stripe.
+	DefaultBlockSize = 1024 * 1024
+
+	// dirMode is the permission mode of created directories.
+	dirMode os.FileMode = 0700
+
+	// headerSize is the size of the header at the start of a shard file.
+	headerSize = 24
+
+	// crcSize is the size of the checksum after each chunk.
+	crcSize = 4
+
+	headerMagic   = "AXEC"
+	headerVersion = 1
+)
+
+// ErrShardsCorrupt indicates a blob with too few intact shards to be read.
+var ErrShardsCorrupt = fmt.Errorf("erasure shards corrupt: %w", storage.ErrBlobCorrupt)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// Config holds configuration for the erasure-coded storage.
+type Config struct {
+	// DataDirs holds one directory per shard, ideally each on its own drive.
+	DataDirs []string
+
+	// TempDir holds uploads until their hash is known.
+	TempDir string
+
+	// DataShards and ParityShards set the code, e.g. 4+2 survives the loss
+	// of any two directories.
+	DataShards   int
+	ParityShards int
+
+	// BlockSize is the number of blob bytes encoded per stripe.
+	BlockSize int
+}
+
+// Storage implements storage.Backend by splitting each blob into stripes
+// of BlockSize bytes, encoding each stripe into DataShards+ParityShards
+// chunks, and appending chunk i to the shard file in DataDirs[i]. Every
+// chunk carries a CRC-32C, so corrupt chunks are treated like missing
+// ones. Reads reconstruct missing data from parity; Scrub rewrites
+// damaged shard files.
+//
+// Shard file layout:
+//
+//	header (24 bytes): "AXEC", version, data shards, parity shards,
+//	                   shard index, block size (uint32), blob size (uint64),
+//	                   CRC-32C of the preceding 20 bytes
+//	per stripe:        chunk, CRC-32C of the chunk
+type Storage struct {
+	dirs      []string
+	tempDir   string
+	codec     *Codec
+	blockSize int
+	logger    zerolog.Logger
+
+	locks  [256]sync.RWMutex
+	tempMu sync.Mutex
+}
+
+// NewStorage creates an erasure-coded storage backend.
+func NewStorage(cfg Config, logger zerolog.Logger) (*Storage, error) {
+	if total := cfg.DataShards + cfg.ParityShards; len(cfg.DataDirs) != total || total > 255 {
+		return nil, fmt.Errorf("need one data directory per shard: %d data dirs for %d+%d shards",
+			len(cfg.DataDirs), cfg.DataShards, cfg.ParityShards)
+	}
+	codec, err := NewCodec(cfg.DataShards, cfg.ParityShards)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.BlockSize <= 0 {
+		cfg.BlockSize = DefaultBlockSize
+	}
+
+	dirs := make([]string, len(cfg.DataDirs))
+	seen := make(map[string]bool, len(cfg.DataDirs))
+	for i, dir := range cfg.DataDirs {
+		if err := os.MkdirAll(dir, dirMode); err != nil {
+			return nil, fmt.Errorf("failed to create data directory: %w", err)
+		}
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get absolute path for data dir: %w", err)
+		}
+		if seen[abs] {
+			return nil, fmt.Errorf("data directory %s is listed twice", abs)
+		}
+		seen[abs] = true
+		dirs[i] = abs
+	}
+	if err := os.MkdirAll(cfg.TempDir, dirMode); err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	tempDir, err := filepath.Abs(cfg.TempDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path for temp dir: %w", err)
+	}
+
+	logger = logger.With().Str("component", "erasure-storage").Logger()
+	logger.Info().
+		Strs("data_dirs", dirs).
+		Str("temp_dir", tempDir).
+		Int("data_shards", cfg.DataShards).
+		Int("parity_shards", cfg.ParityShards).
+		Int("block_size", cfg.BlockSize).
+		Msg("erasure-coded storage initialized")
+
+	return &Storage{
+		dirs:      dirs,
+		tempDir:   tempDir,
+		codec:     codec,
+		blockSize: cfg.BlockSize,
+		logger:    logger,
+	}, nil
+}
+
+// =============================================================================
+// Layout
+// =============================================================================
+
+// shardHeader describes a shard file.
+type shardHeader struct {
+	dataShards   int
+	parityShards int
+	index        int
+	blockSize    int
+	size         int64
+}
+
+func (h shardHeader) marshal() []byte {
+	buf := make([]byte, headerSize)
+	copy(buf, headerMagic)
+	buf[4] = headerVersion
+	buf[5] = byte(h.dataShards)
+	buf[6] = byte(h.parityShards)
+	buf[7] = byte(h.index)
+	binary.BigEndian.PutUint32(buf[8:12], uint32(h.blockSize))
+	binary.BigEndian.PutUint64(buf[12:20], uint64(h.size))
+	binary.BigEndian.PutUint32(buf[20:24], crc32.Checksum(buf[:20], castagnoli))
+	return buf
+}
+
+func parseHeader(buf []byte) (shardHeader, error) {
+	if len(buf) != headerSize || string(buf[:4]) != headerMagic || buf[4] != headerVersion ||
+		binary.BigEndian.Uint32(buf[20:24]) != crc32.Checksum(buf[:20], castagnoli) {
+		return shardHeader{}, errors.New("invalid shard header")
+	}
+	return shardHeader{
+		dataShards:   int(buf[5]),
+		parityShards: int(buf[6]),
+		index:        int(buf[7]),
+		blockSize:    int(binary.BigEndian.Uint32(buf[8:12])),
+		size:         int64(binary.BigEndian.Uint64(buf[12:20])),
+	}, nil
+}
+
+// stripes returns the number of stripes of the blob.
+func (h shardHeader) stripes() int64 {
+	return (h.size + int64(h.blockSize) - 1) / int64(h.blockSize)
+}
+
+// stripeLen returns the number of blob bytes in a stripe.
+func (h shardHeader) stripeLen(stripe int64) int {
+	return int(min(int64(h.blockSize), h.size-stripe*int64(h.blockSize)))
+}
+
+// chunkLen returns the size of each shard's chunk of a stripe.
+func (h shardHeader) chunkLen(stripe int64) int {
+	return (h.stripeLen(stripe) + h.dataShards - 1) / h.dataShards
+}
+
+// chunkOffset returns the position of a stripe's chunk in a shard file.
+// All stripes but the last are full.
+func (h shardHeader) chunkOffset(stripe int64) int64 {
+	full := int64((h.blockSize+h.dataShards-1)/h.dataShards) + crcSize
+	return headerSize + stripe*full
+}
+
+// shardPath returns the path of a blob's shard file.
+func (s *Storage) shardPath(contentHash string, index int) string {
+	dir := storage.GetShardPath(storage.DefaultPathConfig(s.dirs[index]), contentHash)
+	return filepath.Join(dir, contentHash+"."+strconv.Itoa(index))
+}
+
+// lockFor returns the lock of a content hash.
+func (s *Storage) lockFor(contentHash string) *sync.RWMutex {
+	if b, err := hex.DecodeString(contentHash[:min(2, len(contentHash))]); err == nil && len(b) == 1 {
+		return &s.locks[b[0]]
+	}
+	return &s.locks[0]
+}
+
+// =============================================================================
+// storage.Backend
+// =============================================================================
+
+// Store hashes content into a temp file, then encodes it into shard files.
+// A blob is stored once all but one parity shard are written, since a
+// missing shard is rebuilt by Scrub; with no parity every shard is needed.
+func (s *Storage) Store(ctx context.Context, reader io.Reader, size int64) (_ string, err error) {
+	_, span := telemetry.Start(ctx, "storage.store")
+	span.SetAttribute("storage.backend", "erasure")
+	defer func() { span.SetError(err); span.End() }()
+
+	s.tempMu.Lock()
+	tempFile, err := os.CreateTemp(s.tempDir, "upload-*")
+	s.tempMu.Unlock()
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer func() {
+		_ = tempFile.Close()
+		_ = os.Remove(tempFile.Name())
+	}()
+
+	hasher := sha256.New()
+	written, err := io.Copy(tempFile, io.TeeReader(reader, hasher))
+	if err != nil {
+		return "", fmt.Errorf("failed to write to temp file: %w", err)
+	}
+	if size > 0 && written != size {
+		return "", fmt.Errorf("size mismatch: expected %d, got %d", size, written)
+	}
+	contentHash := hex.EncodeToString(hasher.Sum(nil))
+
+	lock := s.lockFor(contentHash)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if s.complete(contentHash) {
+		s.logger.Debug().Str("content_hash", contentHash).Msg("blob already exists, skipping storage")
+		return contentHash, nil
+	}
+
+	header := s.header(written)
+	stripe := make([]byte, header.chunkLen(0)*s.codec.DataShards())
+	parity := make([][]byte, s.codec.ParityShards())
+	for i := range parity {
+		parity[i] = make([]byte, header.chunkLen(0))
+	}
+
+	indices := make([]int, s.codec.TotalShards())
+	for i := range indices {
+		indices[i] = i
+	}
+	stored, err := s.writeShards(contentHash, header, indices, func(n int64, shards [][]byte) error {
+		length, chunk := header.stripeLen(n), header.chunkLen(n)
+		buf := stripe[:chunk*s.codec.DataShards()]
+		if _, err := tempFile.ReadAt(buf[:length], n*int64(header.blockSize)); err != nil {
+			return fmt.Errorf("failed to read temp file: %w", err)
+		}
+		clear(buf[length:])
+		for i := 0; i < s.codec.DataShards(); i++ {
+			shards[i] = buf[i*chunk : (i+1)*chunk]
+		}
+		for i := range parity {
+			shards[s.codec.DataShards()+i] = parity[i][:chunk]
+		}
+		return s.codec.Encode(shards)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	quorum := min(s.codec.TotalShards(), s.codec.DataShards()+1)
+	if stored < quorum {
+		s.removeShards(contentHash)
+		return "", fmt.Errorf("%w: wrote %d of %d shards", ErrShardsCorrupt, stored, s.codec.TotalShards())
+	}
+	if stored < s.codec.TotalShards() {
+		s.logger.Warn().
+			Str("content_hash", contentHash).
+			Int("shards", stored).
+			Msg("blob stored degraded, missing shards are rebuilt by scrub")
+	}
+
+	s.logger.Debug().
+		Str("content_hash", contentHash).
+		Int64("size", written).
+		Msg("blob stored successfully")
+
+	return contentHash, nil
+}
+
+// Retrieve returns a reader that decodes the blob, reconstructing missing
+// or corrupt chunks from parity.
+func (s *Storage) Retrieve(ctx context.Context, contentHash string) (io.ReadCloser, error) {
+	return s.RetrieveRange(ctx, contentHash, 0, 0)
+}
+
+// RetrieveRange returns a reader for a range of the blob. A length of 0
+// reads to the end.
+func (s *Storage) RetrieveRange(ctx context.Context, contentHash string, offset, length int64) (_ io.ReadCloser, err error) {
+	_, span := telemetry.Start(ctx, "storage.retrieve_range")
+	span.SetAttribute("storage.backend", "erasure")
+	span.SetAttribute("content_hash", contentHash)
+	defer func() { span.SetError(err); span.End() }()
+
+	lock := s.lockFor(contentHash)
+	lock.RLock()
+	defer lock.RUnlock()
+
+	set, err := s.openShards(contentHash)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := set.header.size - offset
+	if length > 0 {
+		remaining = min(remaining, length)
+	}
+	reader := &blobReader{
+		storage:     s,
+		contentHash: contentHash,
+		set:         set,
+		stripe:      offset / int64(set.header.blockSize),
+		skip:        int(offset % int64(set.header.blockSize)),
+		remaining:   max(remaining, 0),
+		shards:      make([][]byte, s.codec.TotalShards()),
+		out:         make([]byte, 0, set.header.blockSize),
+	}
+	return reader, nil
+}
+
+// Delete removes all shard files of a blob.
+func (s *Storage) Delete(ctx context.Context, contentHash string) (err error) {
+	_, span := telemetry.Start(ctx, "storage.delete")
+	span.SetAttribute("storage.backend", "erasure")
+	span.SetAttribute("content_hash", contentHash)
+	defer func() { span.SetError(err); span.End() }()
+
+	lock := s.lockFor(contentHash)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if s.removeShards(contentHash) == 0 {
+		return storage.ErrBlobNotFound
+	}
+
+	s.logger.Debug().Str("content_hash", contentHash).Msg("blob deleted successfully")
+	return nil
+}
+
+// Exists reports whether enough shard files exist to read the blob.
+func (s *Storage) Exists(ctx context.Context, contentHash string) (bool, error) {
+	lock := s.lockFor(contentHash)
+	lock.RLock()
+	defer lock.RUnlock()
+
+	found := 0
+	for i := range s.dirs {
+		_, err := os.Stat(s.shardPath(contentHash, i))
+		if err == nil {
+			found++
+			continue
+		}
+		if !os.IsNotExist(err) {
+			s.logger.Warn().Err(err).Str("data_dir", s.dirs[i]).Msg("shard not accessible")
+		}
+	}
+	return found >= s.codec.DataShards(), nil
+}
+
+// GetSize returns the blob size recorded in its shard headers.
+func (s *Storage) GetSize(ctx context.Context, contentHash string) (int64, error) {
+	lock := s.lockFor(contentHash)
+	lock.RLock()
+	defer lock.RUnlock()
+
+	set, err := s.openShards(contentHash)
+	if err != nil {
+		return 0, err
+	}
+	defer set.Close()
+	return set.header.size, nil
+}
+
+// GetPath returns the path of the blob's first shard.
+func (s *Storage) GetPath(contentHash string) string {
+	return s.shardPath(contentHash, 0)
+}
+
+// Walk calls fn for each blob with a shard in any data directory, in
+// content hash order. The size is that of all its shard files.
+func (s *Storage) Walk(ctx context.Context, fn func(storage.BlobInfo) error) error {
+	for _, contentHash := range s.listHashes() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !storage.ValidContentHash(contentHash) {
+			continue
+		}
+
+		info := storage.BlobInfo{ContentHash: contentHash}
+		found := false
+		lock := s.lockFor(contentHash)
+		lock.RLock()
+		for i := range s.dirs {
+			stat, err := os.Stat(s.shardPath(contentHash, i))
+			if err != nil {
+				continue
+			}
+			found = true
+			info.Size += stat.Size()
+			if stat.ModTime().After(info.ModTime) {
+				info.ModTime = stat.ModTime()
+			}
+		}
+		lock.RUnlock()
+
+		// Deleted since it was listed
+		if !found {
+			continue
+		}
+		if err := fn(info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Quarantine moves all shard files of a blob into dir.
+func (s *Storage) Quarantine(ctx context.Context, contentHash, dir string) error {
+	lock := s.lockFor(contentHash)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if err := os.MkdirAll(dir, dirMode); err != nil {
+		return fmt.Errorf("failed to create quarantine directory: %w", err)
+	}
+
+	moved := 0
+	for i := range s.dirs {
+		path := s.shardPath(contentHash, i)
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			continue
+		}
+		if err := moveFile(path, filepath.Join(dir, filepath.Base(path))); err != nil {
+			return fmt.Errorf("failed to move shard to quarantine: %w", err)
+		}
+		moved++
+		s.cleanupEmptyDirs(s.dirs[i], filepath.Dir(path))
+	}
+	if moved == 0 {
+		return storage.ErrBlobNotFound
+	}
+
+	s.logger.Info().
+		Str("content_hash", contentHash).
+		Str("quarantine_dir", dir).
+		Int("shards", moved).
+		Msg("blob quarantined")
+	return nil
+}
+
+// HealthCheck verifies that the temp directory and enough data directories
+// are writable. Up to ParityShards failed directories only degrade the
+// backend: blobs stay readable and are repaired by scrub.
+func (s *Storage) HealthCheck(ctx context.Context) error {
+	if err := checkWritable(s.tempDir); err != nil {
+		return fmt.Errorf("temp directory not accessible: %w", err)
+	}
+
+	var failed []string
+	for _, dir := range s.dirs {
+		if err := checkWritable(dir); err != nil {
+			s.logger.Warn().Err(err).Str("data_dir", dir).Msg("data directory not accessible")
+			failed = append(failed, dir)
+		}
+	}
+	if len(failed) > s.codec.ParityShards() {
+		return fmt.Errorf("%d data directories not accessible: %s", len(failed), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// checkWritable creates and removes a test file in dir.
+func checkWritable(dir string) error {
+	testPath := filepath.Join(dir, ".health-check")
+	if err := os.WriteFile(testPath, []byte("ok"), 0600); err != nil {
+		return err
+	}
+	return os.Remove(testPath)
+}
+
+// moveFile renames src to dst, copying when they are on different
+// filesystems, as data directories usually are.
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
+
+// =============================================================================
+// Shard files
+// =============================================================================
+
+// header returns the header of a new blob of the given size.
+func (s *Storage) header(size int64) shardHeader {
+	return shardHeader{
+		dataShards:   s.codec.DataShards(),
+		parityShards: s.codec.ParityShards(),
+		blockSize:    s.blockSize,
+		size:         size,
+	}
+}
+
+// complete reports whether every shard file of a blob exists.
+func (s *Storage) complete(contentHash string) bool {
+	for i := range s.dirs {
+		if _, err := os.Stat(s.shardPath(contentHash, i)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// removeShards deletes a blob's shard files and returns how many existed.
+func (s *Storage) removeShards(contentHash string) int {
+	removed := 0
+	for i := range s.dirs {
+		path := s.shardPath(contentHash, i)
+		if err := os.Remove(path); err != nil {
+			if !os.IsNotExist(err) {
+				s.logger.Warn().Err(err).Str("path", path).Msg("failed to delete shard")
+			}
+			continue
+		}
+		removed++
+		s.cleanupEmptyDirs(s.dirs[i], filepath.Dir(path))
+	}
+	return removed
+}
+
+// cleanupEmptyDirs removes empty parent directories up to the data directory.
+func (s *Storage) cleanupEmptyDirs(root, dir string) {
+	for dir != root && dir != "" {
+		if err := os.Remove(dir); err != nil {
+			break
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// writeShards writes the shard files with the given indices, calling fill
+// for each stripe to set all shards' chunks. Each file is written to a
+// temp file next to its final path and renamed once complete, replacing
+// any damaged file. A shard whose directory fails is dropped; the number
+// of shards written is returned.
+func (s *Storage) writeShards(contentHash string, header shardHeader, indices []int, fill func(stripe int64, shards [][]byte) error) (int, error) {
+	files := make(map[int]*os.File, len(indices))
+	drop := func(index int, err error) {
+		s.logger.Warn().Err(err).
+			Str("content_hash", contentHash).
+			Str("data_dir", s.dirs[index]).
+			Msg("failed to write shard")
+		_ = files[index].Close()
+		_ = os.Remove(files[index].Name())
+		delete(files, index)
+	}
+	defer func() {
+		for _, file := range files {
+			_ = file.Close()
+			_ = os.Remove(file.Name())
+		}
+	}()
+
+	for _, index := range indices {
+		dir := filepath.Dir(s.shardPath(contentHash, index))
+		if err := os.MkdirAll(dir, dirMode); err != nil {
+			s.logger.Warn().Err(err).Str("data_dir", s.dirs[index]).Msg("failed to create shard directory")
+			continue
+		}
+		file, err := os.CreateTemp(dir, "."+contentHash+".*.tmp")
+		if err != nil {
+			s.logger.Warn().Err(err).Str("data_dir", s.dirs[index]).Msg("failed to create shard")
+			continue
+		}
+		files[index] = file
+
+		h := header
+		h.index = index
+		if _, err := file.Write(h.marshal()); err != nil {
+			drop(index, err)
+		}
+	}
+
+	shards := make([][]byte, s.codec.TotalShards())
+	crc := make([]byte, crcSize)
+	for stripe := int64(0); stripe < header.stripes() && len(files) > 0; stripe++ {
+		clear(shards)
+		if err := fill(stripe, shards); err != nil {
+			return 0, err
+		}
+		for index, file := range files {
+			binary.BigEndian.PutUint32(crc, crc32.Checksum(shards[index], castagnoli))
+			if _, err := file.Write(shards[index]); err != nil {
+				drop(index, err)
+				continue
+			}
+			if _, err := file.Write(crc); err != nil {
+				drop(index, err)
+			}
+		}
+	}
+
+	written := 0
+	for index, file := range files {
+		if err := file.Close(); err != nil {
+			drop(index, err)
+			continue
+		}
+		if err := os.Rename(file.Name(), s.shardPath(contentHash, index)); err != nil {
+			drop(index, err)
+			continue
+		}
+		delete(files, index)
+		written++
+	}
+	return written, nil
+}
+
+// shardSet holds the open shard files of a blob; missing or invalid ones
+// are nil.
+type shardSet struct {
+	files  []*os.File
+	header shardHeader
+	bufs   [][]byte
+}
+
+// openShards opens a blob's shard files. Returns storage.ErrBlobNotFound if
+// none exist, or ErrShardsCorrupt if too few are valid to read the blob.
+func (s *Storage) openShards(contentHash string) (*shardSet, error) {
+	set := &shardSet{
+		files: make([]*os.File, s.codec.TotalShards()),
+		bufs:  make([][]byte, s.codec.TotalShards()),
+	}
+
+	found, valid := 0, 0
+	var reference *shardHeader
+	for i := range s.dirs {
+		file, err := os.Open(s.shardPath(contentHash, i))
+		if err != nil {
+			if !os.IsNotExist(err) {
+				s.logger.Warn().Err(err).Str("data_dir", s.dirs[i]).Msg("shard not accessible")
+			}
+			continue
+		}
+		found++
+
+		buf := make([]byte, headerSize)
+		header, err := shardHeader{}, error(nil)
+		if _, err = io.ReadFull(file, buf); err == nil {
+			header, err = parseHeader(buf)
+		}
+		if err == nil && (header.index != i || header.dataShards != s.codec.DataShards() ||
+			header.parityShards != s.codec.ParityShards() || header.blockSize <= 0 ||
+			(reference != nil && (header.size != reference.size || header.blockSize != reference.blockSize))) {
+			err = errors.New("shard header does not match")
+		}
+		if err != nil {
+			s.logger.Warn().Err(err).Str("content_hash", contentHash).Int("shard", i).Msg("ignoring invalid shard")
+			_ = file.Close()
+			continue
+		}
+
+		if reference == nil {
+			reference = &header
+		}
+		set.files[i] = file
+		valid++
+	}
+
+	if found == 0 {
+		return nil, storage.ErrBlobNotFound
+	}
+	if valid < s.codec.DataShards() {
+		set.Close()
+		return nil, fmt.Errorf("%w: %s has %d valid shards, needs %d", ErrShardsCorrupt, contentHash, valid, s.codec.DataShards())
+	}
+	set.header = *reference
+	return set, nil
+}
+
+// readChunk reads and verifies a shard's chunk of a stripe. Returns nil if
+// the shard is missing or the chunk is corrupt.
+func (set *shardSet) readChunk(index int, stripe int64) []byte {
+	file := set.files[index]
+	if file == nil {
+		return nil
+	}
+	chunk := set.header.chunkLen(stripe)
+	if cap(set.bufs[index]) < chunk+crcSize {
+		set.bufs[index] = make([]byte, chunk+crcSize)
+	}
+	buf := set.bufs[index][:chunk+crcSize]
+	if _, err := file.ReadAt(buf, set.header.chunkOffset(stripe)); err != nil {
+		return nil
+	}
+	if binary.BigEndian.Uint32(buf[chunk:]) != crc32.Checksum(buf[:chunk], castagnoli) {
+		return nil
+	}
+	return buf[:chunk]
+}
+
+// readStripe sets shards to a stripe's chunks, reconstructing missing ones.
+// Only the data chunks are read unless one of them is bad or all is set.
+// Returns the indices of the shards that were bad.
+func (set *shardSet) readStripe(codec *Codec, stripe int64, shards [][]byte, all bool) ([]int, error) {
+	clear(shards)
+	var bad []int
+	for i := 0; i < codec.DataShards(); i++ {
+		if shards[i] = set.readChunk(i, stripe); shards[i] == nil {
+			bad = append(bad, i)
+		}
+	}
+	if len(bad) == 0 && !all {
+		return nil, nil
+	}
+
+	for i := codec.DataShards(); i < codec.TotalShards(); i++ {
+		if shards[i] = set.readChunk(i, stripe); shards[i] == nil {
+			bad = append(bad, i)
+		}
+	}
+	if len(bad) == 0 {
+		return nil, nil
+	}
+	if err := codec.Reconstruct(shards); err != nil {
+		return bad, fmt.Errorf("%w: stripe %d: %v", ErrShardsCorrupt, stripe, err)
+	}
+	return bad, nil
+}
+
+// Close closes the shard files.
+func (set *shardSet) Close() {
+	for _, file := range set.files {
+		if file != nil {
+			_ = file.Close()
+		}
+	}
+}
+
+// blobReader decodes a blob stripe by stripe.
+type blobReader struct {
+	storage     *Storage
+	contentHash string
+	set         *shardSet
+	stripe      int64
+	skip        int
+	remaining   int64
+	shards      [][]byte
+	out         []byte
+	buffered    []byte
+	degraded    bool
+}
+
+func (r *blobReader) Read(p []byte) (int, error) {
+	if r.remaining <= 0 {
+		return 0, io.EOF
+	}
+	if len(r.buffered) == 0 {
+		if err := r.next(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, r.buffered[:min(int64(len(r.buffered)), r.remaining)])
+	r.buffered = r.buffered[n:]
+	r.remaining -= int64(n)
+	return n, nil
+}
+
+// next decodes the next stripe into buffered.
+func (r *blobReader) next() error {
+	if r.stripe >= r.set.header.stripes() {
+		return io.ErrUnexpectedEOF
+	}
+	bad, err := r.set.readStripe(r.storage.codec, r.stripe, r.shards, false)
+	if err != nil {
+		return err
+	}
+	if len(bad) > 0 && !r.degraded {
+		r.degraded = true
+		r.storage.logger.Warn().
+			Str("content_hash", r.contentHash).
+			Ints("shards", bad).
+			Msg("reconstructed blob from parity, scrub will repair it")
+	}
+
+	r.out = r.out[:0]
+	for i := 0; i < r.storage.codec.DataShards(); i++ {
+		r.out = append(r.out, r.shards[i]...)
+	}
+	r.buffered = r.out[r.skip:r.set.header.stripeLen(r.stripe)]
+	r.skip = 0
+	r.stripe++
+	return nil
+}
+
+func (r *blobReader) Close() error {
+	r.set.Close()
+	return nil
+}
+
+// Ensure Storage implements storage.Backend
+var _ storage.Backend = (*Storage)(nil)
+
+// Ensure Storage can be cross-checked against the blob table
+var (
+	_ storage.Walker      = (*Storage)(nil)
+	_ storage.Quarantiner = (*Storage)(nil)
+)
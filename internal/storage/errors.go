@@ -15,9 +15,18 @@ var (
 
 	// ErrInvalidContentHash indicates that the content hash is invalid.
 	ErrInvalidContentHash = errors.New("invalid content hash")
+
+	// ErrBlobCorrupt indicates that stored content is damaged beyond what
+	// the backend can recover.
+	ErrBlobCorrupt = errors.New("blob content corrupt")
 )
 
 // IsNotFound returns true if the error is ErrBlobNotFound.
 func IsNotFound(err error) bool {
 	return errors.Is(err, ErrBlobNotFound)
 }
+
+// IsCorrupt returns true if the error is ErrBlobCorrupt.
+func IsCorrupt(err error) bool {
+	return errors.Is(err, ErrBlobCorrupt)
+}
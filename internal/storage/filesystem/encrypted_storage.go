@@ -26,14 +26,18 @@ type EncryptedConfig struct {
 	DataDir   string
 	TempDir   string
 	MasterKey []byte // 32-byte master key for SSE-S3
+
+	// Permissions controls startup directory checks (see Config).
+	Permissions PermissionPolicy
 }
 
 // NewEncryptedStorage creates a new encrypted filesystem storage backend.
 func NewEncryptedStorage(cfg EncryptedConfig, logger zerolog.Logger) (*EncryptedStorage, error) {
 	// Create underlying storage
 	baseCfg := Config{
-		DataDir: cfg.DataDir,
-		TempDir: cfg.TempDir,
+		DataDir:     cfg.DataDir,
+		TempDir:     cfg.TempDir,
+		Permissions: cfg.Permissions,
 	}
 	baseStorage, err := NewStorage(baseCfg, logger)
 	if err != nil {
@@ -99,12 +103,12 @@ func (s *EncryptedStorage) Store(ctx context.Context, reader io.Reader, size int
 
 	// Create target directory
 	targetDir := storage.ComputeDir(s.storage.pathConfig, contentHash)
-	if err := os.MkdirAll(targetDir, 0755); err != nil {
+	if err := os.MkdirAll(targetDir, dirMode); err != nil {
 		return "", fmt.Errorf("failed to create target directory: %w", err)
 	}
 
 	// Write encrypted content to file
-	if err := os.WriteFile(fullPath, ciphertext, 0644); err != nil {
+	if err := os.WriteFile(fullPath, ciphertext, 0600); err != nil {
 		return "", fmt.Errorf("failed to write encrypted blob: %w", err)
 	}
 
@@ -229,7 +233,7 @@ func (s *EncryptedStorage) EncryptExistingBlob(ctx context.Context, contentHash
 
 	// Write encrypted content (atomic via temp file)
 	tempPath := fullPath + ".encrypting"
-	if err := os.WriteFile(tempPath, ciphertext, 0644); err != nil {
+	if err := os.WriteFile(tempPath, ciphertext, 0600); err != nil {
 		return fmt.Errorf("failed to write encrypted blob: %w", err)
 	}
 
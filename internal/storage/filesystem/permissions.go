@@ -0,0 +1,170 @@
+package filesystem
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// dirMode is the permission mode required for data and temp directories.
+	// Blob content must never be readable by other local users.
+	dirMode os.FileMode = 0700
+)
+
+// PermissionPolicy controls how directory ownership and permissions are checked.
+type PermissionPolicy struct {
+	// Enforce causes NewStorage to fail if a directory is insecure
+	// and could not be fixed automatically.
+	Enforce bool
+
+	// AutoFix tightens directory modes to 0700 when the directory is
+	// owned by the current user.
+	AutoFix bool
+}
+
+// PermissionIssue describes a single security finding for a storage directory.
+type PermissionIssue struct {
+	// Path is the directory the issue applies to.
+	Path string
+
+	// Problem is a human-readable description of the issue.
+	Problem string
+
+	// Fixed is true if AutoFix resolved the issue.
+	Fixed bool
+
+	// Advisory is true for recommendations (e.g. mount options) that
+	// never cause startup to fail.
+	Advisory bool
+}
+
+// String returns a human-readable representation of the issue.
+func (i PermissionIssue) String() string {
+	return fmt.Sprintf("%s: %s", i.Path, i.Problem)
+}
+
+// CheckDirPermissions inspects dir for ownership, mode, and mount option problems.
+// If policy.AutoFix is set, overly permissive modes are tightened to 0700 where possible.
+// The returned error is non-nil only if dir cannot be inspected at all.
+func CheckDirPermissions(dir string, policy PermissionPolicy) ([]PermissionIssue, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %s: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	// Platforms without POSIX ownership (Windows) use ACLs instead of mode bits.
+	uid, ok := fileOwner(info)
+	if !ok {
+		return nil, nil
+	}
+
+	var issues []PermissionIssue
+
+	// Ownership: the directory must belong to the user running the server.
+	ownedByUs := uid == os.Geteuid()
+	if !ownedByUs {
+		issues = append(issues, PermissionIssue{
+			Path:    dir,
+			Problem: fmt.Sprintf("owned by uid %d, expected uid %d", uid, os.Geteuid()),
+		})
+	}
+
+	// Mode: no group or other access.
+	if perm := info.Mode().Perm(); perm&^dirMode != 0 {
+		issue := PermissionIssue{
+			Path:    dir,
+			Problem: fmt.Sprintf("mode %04o is too permissive, expected %04o", perm, dirMode),
+		}
+		if policy.AutoFix && ownedByUs {
+			if err := os.Chmod(dir, dirMode); err == nil {
+				issue.Fixed = true
+			}
+		}
+		issues = append(issues, issue)
+	}
+
+	// Mount options: blob data never needs to be executed or used as a device node.
+	for _, opt := range missingMountOptions(dir, "noexec", "nodev") {
+		issues = append(issues, PermissionIssue{
+			Path:     dir,
+			Problem:  fmt.Sprintf("filesystem is not mounted with %s (recommended)", opt),
+			Advisory: true,
+		})
+	}
+
+	return issues, nil
+}
+
+// IsRoot returns true if the process is running with root privileges.
+func IsRoot() bool {
+	return os.Geteuid() == 0
+}
+
+// missingMountOptions returns the subset of wanted options that are not set on
+// the mount containing path. It returns nil where mount information is not
+// available (non-Linux platforms or restricted /proc).
+func missingMountOptions(path string, wanted ...string) []string {
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil
+	}
+	if resolved, err := filepath.EvalSymlinks(absPath); err == nil {
+		absPath = resolved
+	}
+
+	// Find the longest mount point that is a prefix of path.
+	var bestMount string
+	var bestOpts []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// Format: id parent major:minor root mountpoint options ...
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 6 {
+			continue
+		}
+		mountPoint := fields[4]
+		if !pathHasPrefix(absPath, mountPoint) || len(mountPoint) < len(bestMount) {
+			continue
+		}
+		bestMount = mountPoint
+		bestOpts = strings.Split(fields[5], ",")
+	}
+	if bestMount == "" {
+		return nil
+	}
+
+	var missing []string
+	for _, w := range wanted {
+		found := false
+		for _, o := range bestOpts {
+			if o == w {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, w)
+		}
+	}
+	return missing
+}
+
+// pathHasPrefix reports whether path is equal to or below dir.
+func pathHasPrefix(path, dir string) bool {
+	if dir == "/" || path == dir {
+		return true
+	}
+	return strings.HasPrefix(path, dir+string(filepath.Separator))
+}
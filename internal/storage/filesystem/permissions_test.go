@@ -0,0 +1,83 @@
+//go:build unix
+
+package filesystem
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func requireMode(t *testing.T, path string, want os.FileMode) {
+	t.Helper()
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, want, info.Mode().Perm(), path)
+}
+
+func TestNewStorage_CreatesPrivateFilesAndDirectories(t *testing.T) {
+	// A permissive umask must not loosen the modes storage asks for
+	defer syscall.Umask(syscall.Umask(0))
+
+	dir := t.TempDir()
+	s, err := NewStorage(Config{
+		DataDir: filepath.Join(dir, "data"),
+		TempDir: filepath.Join(dir, "temp"),
+	}, zerolog.Nop())
+	require.NoError(t, err)
+	requireMode(t, s.GetDataDir(), dirMode)
+	requireMode(t, s.GetTempDir(), dirMode)
+
+	hash, err := s.Store(context.Background(), bytes.NewReader([]byte("secret")), 6)
+	require.NoError(t, err)
+	path := s.GetPath(hash)
+	requireMode(t, path, 0600)
+	for d := filepath.Dir(path); d != s.GetDataDir(); d = filepath.Dir(d) {
+		requireMode(t, d, dirMode)
+	}
+}
+
+func TestNewStorage_PermissionPolicy(t *testing.T) {
+	newDirs := func(t *testing.T) Config {
+		dir := t.TempDir()
+		cfg := Config{DataDir: filepath.Join(dir, "data"), TempDir: filepath.Join(dir, "temp")}
+		require.NoError(t, os.Mkdir(cfg.DataDir, 0755))
+		require.NoError(t, os.Chmod(cfg.DataDir, 0755))
+		require.NoError(t, os.Mkdir(cfg.TempDir, dirMode))
+		return cfg
+	}
+
+	t.Run("warn", func(t *testing.T) {
+		cfg := newDirs(t)
+		_, err := NewStorage(cfg, zerolog.Nop())
+		require.NoError(t, err)
+		requireMode(t, cfg.DataDir, 0755)
+	})
+
+	t.Run("enforce", func(t *testing.T) {
+		cfg := newDirs(t)
+		cfg.Permissions = PermissionPolicy{Enforce: true}
+		_, err := NewStorage(cfg, zerolog.Nop())
+		require.ErrorContains(t, err, "mode 0755 is too permissive")
+	})
+
+	t.Run("auto fix", func(t *testing.T) {
+		cfg := newDirs(t)
+		cfg.Permissions = PermissionPolicy{Enforce: true, AutoFix: true}
+		_, err := NewStorage(cfg, zerolog.Nop())
+		require.NoError(t, err)
+		requireMode(t, cfg.DataDir, dirMode)
+
+		issues, err := CheckDirPermissions(cfg.DataDir, cfg.Permissions)
+		require.NoError(t, err)
+		for _, issue := range issues {
+			require.True(t, issue.Advisory, issue.String())
+		}
+	})
+}
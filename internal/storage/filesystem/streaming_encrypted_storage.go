@@ -30,6 +30,9 @@ type StreamingEncryptedConfig struct {
 	TempDir   string
 	MasterKey []byte // 32-byte master key
 	ChunkSize int    // Optional: custom chunk size (default 16MB)
+
+	// Permissions controls startup directory checks (see Config).
+	Permissions PermissionPolicy
 }
 
 // NewStreamingEncryptedStorage creates a new streaming encrypted filesystem storage backend.
@@ -37,8 +40,9 @@ type StreamingEncryptedConfig struct {
 func NewStreamingEncryptedStorage(cfg StreamingEncryptedConfig, logger zerolog.Logger) (*StreamingEncryptedStorage, error) {
 	// Create underlying storage
 	baseCfg := Config{
-		DataDir: cfg.DataDir,
-		TempDir: cfg.TempDir,
+		DataDir:     cfg.DataDir,
+		TempDir:     cfg.TempDir,
+		Permissions: cfg.Permissions,
 	}
 	baseStorage, err := NewStorage(baseCfg, logger)
 	if err != nil {
@@ -121,7 +125,7 @@ func (s *StreamingEncryptedStorage) Store(ctx context.Context, reader io.Reader,
 
 	// Create target directory
 	targetDir := storage.ComputeDir(s.storage.pathConfig, contentHash)
-	if err := os.MkdirAll(targetDir, 0755); err != nil {
+	if err := os.MkdirAll(targetDir, dirMode); err != nil {
 		return "", fmt.Errorf("failed to create target directory: %w", err)
 	}
 
@@ -407,7 +411,7 @@ func (s *StreamingEncryptedStorage) MigrateFromAES(ctx context.Context, contentH
 
 	// Write to temp file
 	tempPath := fullPath + ".migrating"
-	if err := os.WriteFile(tempPath, chachaCiphertext, 0644); err != nil {
+	if err := os.WriteFile(tempPath, chachaCiphertext, 0600); err != nil {
 		return fmt.Errorf("failed to write migrated blob: %w", err)
 	}
 
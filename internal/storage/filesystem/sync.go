@@ -0,0 +1,182 @@
+// Package filesystem provides a filesystem-based blob storage backend.
+package filesystem
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// SyncPolicy controls when stored blobs are flushed to stable storage.
+type SyncPolicy string
+
+const (
+	// SyncOff leaves flushing to the operating system. A crash can lose
+	// or truncate recently stored blobs.
+	SyncOff SyncPolicy = "off"
+
+	// SyncAlways flushes each blob and its directory before Store returns.
+	SyncAlways SyncPolicy = "always"
+
+	// SyncBatch flushes the blobs stored since the last flush once per
+	// interval. A crash can lose blobs stored within the last interval.
+	SyncBatch SyncPolicy = "batch"
+)
+
+// Valid reports whether p is a known policy. The empty policy is SyncOff.
+func (p SyncPolicy) Valid() bool {
+	switch p {
+	case "", SyncOff, SyncAlways, SyncBatch:
+		return true
+	}
+	return false
+}
+
+// tempPrefixes name the partial files written to the temp directory.
+var tempPrefixes = []string{"upload-", "concat-", "stream-encrypt-", "scrub-repair-"}
+
+// syncFile flushes a file's content to stable storage.
+func syncFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return f.Sync()
+}
+
+// batchSyncer flushes stored blobs and the directories naming them on a
+// timer, so many stores share the cost of one round of fsyncs.
+type batchSyncer struct {
+	logger   zerolog.Logger
+	mu       sync.Mutex
+	pending  map[string]bool // path -> is a directory
+	closed   bool
+	stopChan chan struct{}
+	doneChan chan struct{}
+}
+
+// newBatchSyncer starts a syncer flushing every interval.
+func newBatchSyncer(interval time.Duration, logger zerolog.Logger) *batchSyncer {
+	b := &batchSyncer{
+		logger:   logger,
+		pending:  make(map[string]bool),
+		stopChan: make(chan struct{}),
+		doneChan: make(chan struct{}),
+	}
+	go b.run(interval)
+	return b
+}
+
+// add queues a file and the directories naming it for the next flush.
+func (b *batchSyncer) add(file string, dirs ...string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	b.pending[file] = false
+	for _, dir := range dirs {
+		b.pending[dir] = true
+	}
+}
+
+// run flushes on every tick until stopped, then flushes once more.
+func (b *batchSyncer) run(interval time.Duration) {
+	defer close(b.doneChan)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			b.flush()
+		case <-b.stopChan:
+			b.flush()
+			return
+		}
+	}
+}
+
+// flush syncs files before the directories naming them. Blobs deleted
+// since they were queued are skipped.
+func (b *batchSyncer) flush() {
+	b.mu.Lock()
+	pending := b.pending
+	b.pending = make(map[string]bool)
+	b.mu.Unlock()
+
+	failed := 0
+	for _, dirs := range []bool{false, true} {
+		for path, isDir := range pending {
+			if isDir != dirs {
+				continue
+			}
+			var err error
+			if isDir {
+				err = syncDir(path)
+			} else {
+				err = syncFile(path)
+			}
+			if err != nil && !os.IsNotExist(err) {
+				b.logger.Warn().Err(err).Str("path", path).Msg("failed to sync stored blob")
+				failed++
+			}
+		}
+	}
+	if failed > 0 {
+		b.logger.Error().Int("failed", failed).Int("paths", len(pending)).Msg("batched sync incomplete")
+	}
+}
+
+// close stops the syncer after a final flush.
+func (b *batchSyncer) close() {
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+
+	close(b.stopChan)
+	<-b.doneChan
+}
+
+// sweepTemp deletes partial files left in dir by interrupted writes that
+// are older than maxAge, and returns how many were removed.
+func sweepTemp(dir string, maxAge time.Duration) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read temp directory: %w", err)
+	}
+
+	cutoff := time.Now().Add(-maxAge)
+	removed := 0
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !hasTempPrefix(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil && !os.IsNotExist(err) {
+			return removed, fmt.Errorf("failed to remove stale temp file: %w", err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// hasTempPrefix reports whether name is a partial file written by storage.
+func hasTempPrefix(name string) bool {
+	for _, prefix := range tempPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package filesystem
+
+// syncDir is a no-op on this platform, where directories cannot be
+// opened for syncing and renames are made durable with the file.
+func syncDir(dir string) error {
+	return nil
+}
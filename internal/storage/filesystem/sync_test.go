@@ -0,0 +1,77 @@
+package filesystem
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewStorage_SweepsStaleTempFiles(t *testing.T) {
+	dir := t.TempDir()
+	tempDir := filepath.Join(dir, "temp")
+	require.NoError(t, os.MkdirAll(tempDir, dirMode))
+
+	old := time.Now().Add(-48 * time.Hour)
+	for _, name := range []string{"upload-1", "concat-2", "notes.txt"} {
+		path := filepath.Join(tempDir, name)
+		require.NoError(t, os.WriteFile(path, []byte("partial"), 0600))
+		require.NoError(t, os.Chtimes(path, old, old))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "upload-3"), []byte("in progress"), 0600))
+
+	_, err := NewStorage(Config{
+		DataDir:    filepath.Join(dir, "data"),
+		TempDir:    tempDir,
+		TempMaxAge: 24 * time.Hour,
+	}, zerolog.Nop())
+	require.NoError(t, err)
+
+	// Only stale partial files written by storage are removed
+	require.NoFileExists(t, filepath.Join(tempDir, "upload-1"))
+	require.NoFileExists(t, filepath.Join(tempDir, "concat-2"))
+	require.FileExists(t, filepath.Join(tempDir, "notes.txt"))
+	require.FileExists(t, filepath.Join(tempDir, "upload-3"))
+}
+
+func TestStorage_SyncPolicies(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := NewStorage(Config{DataDir: t.TempDir(), TempDir: t.TempDir(), Sync: "sometimes"}, zerolog.Nop())
+	require.Error(t, err)
+
+	for _, policy := range []SyncPolicy{SyncOff, SyncAlways, SyncBatch} {
+		t.Run(string(policy), func(t *testing.T) {
+			s, err := NewStorage(Config{
+				DataDir:      t.TempDir(),
+				TempDir:      t.TempDir(),
+				Sync:         policy,
+				SyncInterval: 10 * time.Millisecond,
+			}, zerolog.Nop())
+			require.NoError(t, err)
+
+			content := []byte("durable content")
+			hash, err := s.Store(ctx, bytes.NewReader(content), int64(len(content)))
+			require.NoError(t, err)
+
+			// A truncated file left by a crash is replaced, not deduplicated
+			require.NoError(t, os.Truncate(s.GetPath(hash), 3))
+			_, err = s.Store(ctx, bytes.NewReader(content), int64(len(content)))
+			require.NoError(t, err)
+			require.NoError(t, s.Close())
+
+			reader, err := s.Retrieve(ctx, hash)
+			require.NoError(t, err)
+			data, err := io.ReadAll(reader)
+			require.NoError(t, err)
+			require.NoError(t, reader.Close())
+			require.Equal(t, content, data)
+		})
+	}
+}
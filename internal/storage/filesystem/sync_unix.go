@@ -0,0 +1,8 @@
+//go:build unix
+
+package filesystem
+
+// syncDir flushes a directory, making the names created in it durable.
+func syncDir(dir string) error {
+	return syncFile(dir)
+}
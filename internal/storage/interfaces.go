@@ -6,6 +6,7 @@ package storage
 import (
 	"context"
 	"io"
+	"time"
 )
 
 // Backend defines the interface for storage backends.
@@ -91,6 +92,43 @@ type Backend interface {
 	HealthCheck(ctx context.Context) error
 }
 
+// BlobInfo describes a blob found by walking a backend.
+type BlobInfo struct {
+	// ContentHash is the SHA-256 hash the blob is stored under.
+	ContentHash string
+
+	// Size is the stored size in bytes.
+	Size int64
+
+	// ModTime is when the blob was last written.
+	ModTime time.Time
+}
+
+// Walker is implemented by backends that can enumerate the blobs they hold,
+// so storage can be cross-checked against the blob table.
+type Walker interface {
+	// Walk calls fn for each stored blob in content hash order. Files not
+	// named and placed for a content hash are skipped. An error returned
+	// by fn stops the walk and is returned.
+	Walk(ctx context.Context, fn func(BlobInfo) error) error
+}
+
+// Quarantiner is implemented by backends that can move a blob aside
+// instead of deleting it.
+type Quarantiner interface {
+	// Quarantine moves a blob's stored files into dir, where they are no
+	// longer served. Returns ErrBlobNotFound if the blob is not stored.
+	Quarantine(ctx context.Context, contentHash, dir string) error
+}
+
+// Concatenator is implemented by backends that can build a blob from
+// stored blobs without streaming the content through the caller.
+type Concatenator interface {
+	// Concat stores the concatenation of the given blobs, in order, and
+	// returns its content hash. size is the expected total size.
+	Concat(ctx context.Context, contentHashes []string, size int64) (contentHash string, err error)
+}
+
 // ContentAddressableStorage extends Backend with reference counting support.
 // This interface is used when deduplication tracking is managed by the storage layer.
 // In our architecture, reference counting is handled by PostgreSQL, but this interface
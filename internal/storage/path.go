@@ -115,3 +115,16 @@ func GetShardPath(config PathConfig, contentHash string) string {
 func ComputeDir(config PathConfig, contentHash string) string {
 	return GetShardPath(config, contentHash)
 }
+
+// ValidContentHash reports whether s is a lowercase hex SHA-256 digest.
+func ValidContentHash(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,365 @@
+// Package storage defines interfaces for blob storage backends.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/rs/zerolog"
+)
+
+// ErrResidencyViolation indicates a blob would be written to or read from a
+// backend that the bucket's residency tag does not permit.
+var ErrResidencyViolation = errors.New("storage backend not permitted by bucket residency")
+
+// residencyKey is the context key for the residency tag.
+type residencyKey struct{}
+
+// WithResidency returns a context that routes storage operations to the
+// backend configured for the residency tag. An empty tag uses the default backend.
+func WithResidency(ctx context.Context, residency string) context.Context {
+	return context.WithValue(ctx, residencyKey{}, residency)
+}
+
+// ResidencyFromContext returns the residency tag carried by ctx, if any.
+func ResidencyFromContext(ctx context.Context) string {
+	residency, _ := ctx.Value(residencyKey{}).(string)
+	return residency
+}
+
+// ContextPather is implemented by backends whose blob location depends on
+// the request context.
+type ContextPather interface {
+	GetPathContext(ctx context.Context, contentHash string) string
+}
+
+// PathFor returns the storage path of a blob, honoring residency routing.
+func PathFor(ctx context.Context, backend Backend, contentHash string) string {
+	if pather, ok := backend.(ContextPather); ok {
+		return pather.GetPathContext(ctx, contentHash)
+	}
+	return backend.GetPath(contentHash)
+}
+
+// rangeRetriever is implemented by backends that support range reads.
+type rangeRetriever interface {
+	RetrieveRange(ctx context.Context, contentHash string, offset, length int64) (io.ReadCloser, error)
+}
+
+// ResidencyRouter routes blob operations to a backend chosen by the residency
+// tag in the context (see WithResidency). Untagged operations go to the default
+// backend. A tag without a configured backend is a residency violation: the
+// operation is refused and logged rather than falling back to the default.
+//
+// Blobs are deduplicated per backend, so content shared by buckets with
+// different residencies is stored once in each backend.
+type ResidencyRouter struct {
+	defaultBackend Backend
+	backends       map[string]Backend
+	logger         zerolog.Logger
+}
+
+// NewResidencyRouter creates a router over the default backend and the
+// backends for each residency tag.
+func NewResidencyRouter(defaultBackend Backend, backends map[string]Backend, logger zerolog.Logger) *ResidencyRouter {
+	return &ResidencyRouter{
+		defaultBackend: defaultBackend,
+		backends:       backends,
+		logger:         logger.With().Str("component", "residency-router").Logger(),
+	}
+}
+
+// Residencies returns the configured residency tags, sorted.
+func (r *ResidencyRouter) Residencies() []string {
+	tags := make([]string, 0, len(r.backends))
+	for tag := range r.backends {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// backendFor returns the backend for the context's residency tag.
+func (r *ResidencyRouter) backendFor(ctx context.Context, op, contentHash string) (Backend, error) {
+	residency := ResidencyFromContext(ctx)
+	if residency == "" {
+		return r.defaultBackend, nil
+	}
+
+	backend, ok := r.backends[residency]
+	if !ok {
+		r.logger.Warn().
+			Str("event", "residency_violation").
+			Str("residency", residency).
+			Str("operation", op).
+			Str("content_hash", contentHash).
+			Msg("no storage backend configured for residency; operation refused")
+		return nil, fmt.Errorf("%w: %q", ErrResidencyViolation, residency)
+	}
+	return backend, nil
+}
+
+// Store stores content on the backend for the context's residency.
+func (r *ResidencyRouter) Store(ctx context.Context, reader io.Reader, size int64) (string, error) {
+	backend, err := r.backendFor(ctx, "store", "")
+	if err != nil {
+		return "", err
+	}
+	return backend.Store(ctx, reader, size)
+}
+
+// Concat concatenates blobs on the backend for the context's residency.
+func (r *ResidencyRouter) Concat(ctx context.Context, contentHashes []string, size int64) (string, error) {
+	backend, err := r.backendFor(ctx, "store", "")
+	if err != nil {
+		return "", err
+	}
+	return Concat(ctx, backend, contentHashes, size)
+}
+
+// Retrieve retrieves content from the backend for the context's residency.
+// It never falls back to another backend.
+func (r *ResidencyRouter) Retrieve(ctx context.Context, contentHash string) (io.ReadCloser, error) {
+	backend, err := r.backendFor(ctx, "retrieve", contentHash)
+	if err != nil {
+		return nil, err
+	}
+	return backend.Retrieve(ctx, contentHash)
+}
+
+// RetrieveRange retrieves a byte range from the backend for the context's
+// residency, emulating it with a full read if the backend has no range support.
+func (r *ResidencyRouter) RetrieveRange(ctx context.Context, contentHash string, offset, length int64) (io.ReadCloser, error) {
+	backend, err := r.backendFor(ctx, "retrieve", contentHash)
+	if err != nil {
+		return nil, err
+	}
+	if ranged, ok := backend.(rangeRetriever); ok {
+		return ranged.RetrieveRange(ctx, contentHash, offset, length)
+	}
+
+	reader, err := backend.Retrieve(ctx, contentHash)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.CopyN(io.Discard, reader, offset); err != nil {
+		reader.Close()
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(reader, length), reader}, nil
+}
+
+// Delete removes content from every backend that holds it, since the blob
+// metadata does not record which residencies referenced it. It returns
+// ErrBlobNotFound only if no backend had the content.
+func (r *ResidencyRouter) Delete(ctx context.Context, contentHash string) error {
+	found := false
+	for _, backend := range r.all() {
+		err := backend.Delete(ctx, contentHash)
+		switch {
+		case err == nil:
+			found = true
+		case IsNotFound(err):
+		default:
+			return err
+		}
+	}
+	if !found {
+		return ErrBlobNotFound
+	}
+	return nil
+}
+
+// Quarantine moves content aside on every backend that holds it, like
+// Delete.
+func (r *ResidencyRouter) Quarantine(ctx context.Context, contentHash, dir string) error {
+	found := false
+	for _, backend := range r.all() {
+		quarantiner, ok := backend.(Quarantiner)
+		if !ok {
+			return fmt.Errorf("storage backend %T cannot quarantine blobs", backend)
+		}
+		err := quarantiner.Quarantine(ctx, contentHash, dir)
+		switch {
+		case err == nil:
+			found = true
+		case IsNotFound(err):
+		default:
+			return err
+		}
+	}
+	if !found {
+		return ErrBlobNotFound
+	}
+	return nil
+}
+
+// Walk merges the walks of every backend into one in content hash order.
+// Content held by several backends is reported once, as found on the
+// first of them.
+func (r *ResidencyRouter) Walk(ctx context.Context, fn func(BlobInfo) error) error {
+	backends := r.all()
+	walkers := make([]Walker, len(backends))
+	for i, backend := range backends {
+		walker, ok := backend.(Walker)
+		if !ok {
+			return fmt.Errorf("storage backend %T cannot be walked", backend)
+		}
+		walkers[i] = walker
+	}
+	if len(walkers) == 1 {
+		return walkers[0].Walk(ctx, fn)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Each backend is walked in its own goroutine; the merge takes the
+	// smallest hash at the head of the streams
+	type stream struct {
+		blobs chan BlobInfo
+		err   error
+	}
+	streams := make([]*stream, len(walkers))
+	for i, walker := range walkers {
+		st := &stream{blobs: make(chan BlobInfo, 64)}
+		streams[i] = st
+		go func() {
+			defer close(st.blobs)
+			st.err = walker.Walk(ctx, func(info BlobInfo) error {
+				select {
+				case st.blobs <- info:
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			})
+		}()
+	}
+
+	heads := make([]*BlobInfo, len(streams))
+	advance := func(i int) error {
+		info, ok := <-streams[i].blobs
+		if !ok {
+			heads[i] = nil
+			return streams[i].err
+		}
+		heads[i] = &info
+		return nil
+	}
+	for i := range streams {
+		if err := advance(i); err != nil {
+			return err
+		}
+	}
+
+	for {
+		first := -1
+		for i, head := range heads {
+			if head != nil && (first < 0 || head.ContentHash < heads[first].ContentHash) {
+				first = i
+			}
+		}
+		if first < 0 {
+			return nil
+		}
+
+		info := *heads[first]
+		for i, head := range heads {
+			if head != nil && head.ContentHash == info.ContentHash {
+				if err := advance(i); err != nil {
+					return err
+				}
+			}
+		}
+		if err := fn(info); err != nil {
+			return err
+		}
+	}
+}
+
+// Exists checks for content on the backend for the context's residency.
+func (r *ResidencyRouter) Exists(ctx context.Context, contentHash string) (bool, error) {
+	backend, err := r.backendFor(ctx, "exists", contentHash)
+	if err != nil {
+		return false, err
+	}
+	return backend.Exists(ctx, contentHash)
+}
+
+// GetSize returns the size of content on the backend for the context's residency.
+func (r *ResidencyRouter) GetSize(ctx context.Context, contentHash string) (int64, error) {
+	backend, err := r.backendFor(ctx, "get_size", contentHash)
+	if err != nil {
+		return 0, err
+	}
+	return backend.GetSize(ctx, contentHash)
+}
+
+// GetPath returns the path of content on the default backend.
+func (r *ResidencyRouter) GetPath(contentHash string) string {
+	return r.defaultBackend.GetPath(contentHash)
+}
+
+// GetPathContext returns the path of content on the backend for the
+// context's residency.
+func (r *ResidencyRouter) GetPathContext(ctx context.Context, contentHash string) string {
+	backend, ok := r.backends[ResidencyFromContext(ctx)]
+	if !ok {
+		return r.defaultBackend.GetPath(contentHash)
+	}
+	return backend.GetPath(contentHash)
+}
+
+// HealthCheck checks every backend.
+func (r *ResidencyRouter) HealthCheck(ctx context.Context) error {
+	if err := r.defaultBackend.HealthCheck(ctx); err != nil {
+		return err
+	}
+	for _, tag := range r.Residencies() {
+		if err := r.backends[tag].HealthCheck(ctx); err != nil {
+			return fmt.Errorf("residency %q: %w", tag, err)
+		}
+	}
+	return nil
+}
+
+// Close closes every backend that holds resources, such as pending syncs.
+func (r *ResidencyRouter) Close() error {
+	var errs []error
+	for _, backend := range r.all() {
+		if closer, ok := backend.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// all returns the default backend followed by the residency backends.
+func (r *ResidencyRouter) all() []Backend {
+	backends := []Backend{r.defaultBackend}
+	for _, tag := range r.Residencies() {
+		backends = append(backends, r.backends[tag])
+	}
+	return backends
+}
+
+// Ensure ResidencyRouter implements Backend.
+var _ Backend = (*ResidencyRouter)(nil)
+
+// Ensure ResidencyRouter can be cross-checked against the blob table.
+var (
+	_ Walker      = (*ResidencyRouter)(nil)
+	_ Quarantiner = (*ResidencyRouter)(nil)
+)
+
+// Ensure ResidencyRouter passes concatenation through to its backends.
+var _ Concatenator = (*ResidencyRouter)(nil)
@@ -0,0 +1,139 @@
+package storage_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/storage"
+	"github.com/prn-tf/alexander-storage/internal/storage/filesystem"
+)
+
+func newFilesystem(t *testing.T) *filesystem.Storage {
+	t.Helper()
+	fs, err := filesystem.NewStorage(filesystem.Config{DataDir: t.TempDir(), TempDir: t.TempDir()}, zerolog.Nop())
+	require.NoError(t, err)
+	return fs
+}
+
+func TestResidencyRouter(t *testing.T) {
+	defaultFS, euFS := newFilesystem(t), newFilesystem(t)
+	router := storage.NewResidencyRouter(defaultFS, map[string]storage.Backend{"eu": euFS}, zerolog.Nop())
+	ctx := context.Background()
+	euCtx := storage.WithResidency(ctx, "eu")
+	content := []byte("personal data")
+
+	hash, err := router.Store(euCtx, bytes.NewReader(content), int64(len(content)))
+	require.NoError(t, err)
+
+	// Tagged content stays on its backend and is not served from another one
+	exists, err := euFS.Exists(ctx, hash)
+	require.NoError(t, err)
+	require.True(t, exists)
+	exists, err = defaultFS.Exists(ctx, hash)
+	require.NoError(t, err)
+	require.False(t, exists)
+	_, err = router.Retrieve(ctx, hash)
+	require.ErrorIs(t, err, storage.ErrBlobNotFound)
+
+	reader, err := router.RetrieveRange(euCtx, hash, 9, 4)
+	require.NoError(t, err)
+	data, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.NoError(t, reader.Close())
+	require.Equal(t, "data", string(data))
+	require.Equal(t, euFS.GetPath(hash), storage.PathFor(euCtx, router, hash))
+
+	// A residency without a backend is refused, never written to the default
+	_, err = router.Store(storage.WithResidency(ctx, "us"), bytes.NewReader(content), int64(len(content)))
+	require.ErrorIs(t, err, storage.ErrResidencyViolation)
+
+	// Deletion removes the content wherever it is stored
+	_, err = router.Store(ctx, bytes.NewReader(content), int64(len(content)))
+	require.NoError(t, err)
+	require.NoError(t, router.Delete(ctx, hash))
+	require.ErrorIs(t, router.Delete(ctx, hash), storage.ErrBlobNotFound)
+}
+
+func TestResidencyRouter_WalkAndQuarantine(t *testing.T) {
+	defaultFS, euFS := newFilesystem(t), newFilesystem(t)
+	router := storage.NewResidencyRouter(defaultFS, map[string]storage.Backend{"eu": euFS}, zerolog.Nop())
+	ctx := context.Background()
+	euCtx := storage.WithResidency(ctx, "eu")
+
+	var want []string
+	for i, content := range []string{"alpha", "bravo", "charlie", "delta", "echo"} {
+		storeCtx := ctx
+		if i%2 == 1 {
+			storeCtx = euCtx
+		}
+		hash, err := router.Store(storeCtx, bytes.NewReader([]byte(content)), int64(len(content)))
+		require.NoError(t, err)
+		want = append(want, hash)
+	}
+	// Content held by both backends is reported once
+	shared := []byte("alpha")
+	sharedHash, err := router.Store(euCtx, bytes.NewReader(shared), int64(len(shared)))
+	require.NoError(t, err)
+	// Stray files are not blobs
+	require.NoError(t, os.WriteFile(filepath.Join(defaultFS.GetDataDir(), "notes.txt"), []byte("x"), 0600))
+	sort.Strings(want)
+
+	var got []string
+	require.NoError(t, router.Walk(ctx, func(info storage.BlobInfo) error {
+		got = append(got, info.ContentHash)
+		return nil
+	}))
+	require.Equal(t, want, got)
+
+	// Quarantine moves the content out of every backend that holds it
+	quarantineDir := t.TempDir()
+	require.NoError(t, router.Quarantine(ctx, sharedHash, quarantineDir))
+	require.FileExists(t, filepath.Join(quarantineDir, sharedHash))
+	for _, backend := range []*filesystem.Storage{defaultFS, euFS} {
+		exists, err := backend.Exists(ctx, sharedHash)
+		require.NoError(t, err)
+		require.False(t, exists)
+	}
+	require.ErrorIs(t, router.Quarantine(ctx, sharedHash, quarantineDir), storage.ErrBlobNotFound)
+}
+
+func TestResidencyRouter_Concat(t *testing.T) {
+	defaultFS, euFS := newFilesystem(t), newFilesystem(t)
+	router := storage.NewResidencyRouter(defaultFS, map[string]storage.Backend{"eu": euFS}, zerolog.Nop())
+	ctx := context.Background()
+	euCtx := storage.WithResidency(ctx, "eu")
+
+	var parts []string
+	for _, content := range []string{"hello ", "hello ", "world"} {
+		hash, err := router.Store(euCtx, bytes.NewReader([]byte(content)), int64(len(content)))
+		require.NoError(t, err)
+		parts = append(parts, hash)
+	}
+
+	hash, err := router.Concat(euCtx, parts, 17)
+	require.NoError(t, err)
+	reader, err := router.Retrieve(euCtx, hash)
+	require.NoError(t, err)
+	data, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.NoError(t, reader.Close())
+	require.Equal(t, "hello hello world", string(data))
+
+	// Concatenating again finds the stored result
+	again, err := router.Concat(euCtx, parts, 17)
+	require.NoError(t, err)
+	require.Equal(t, hash, again)
+
+	_, err = router.Concat(euCtx, parts, 16)
+	require.Error(t, err)
+	_, err = router.Concat(ctx, parts, 17)
+	require.ErrorIs(t, err, storage.ErrBlobNotFound)
+}
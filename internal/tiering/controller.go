@@ -153,6 +153,12 @@ type MigrationStatus struct {
 	BytesTransferred int64 `json:"bytes_transferred"`
 }
 
+// tierUpdater is implemented by access trackers that record the tier of a
+// blob, such as MemoryAccessTracker.
+type tierUpdater interface {
+	UpdateTier(ctx context.Context, contentHash string, tier Tier) error
+}
+
 // AccessTracker tracks blob access patterns.
 type AccessTracker interface {
 	// RecordAccess records an access to a blob.
@@ -211,6 +217,10 @@ type TieringController struct {
 	migrationsMu sync.RWMutex
 	migrations   map[string]*MigrationStatus // contentHash -> status
 
+	// Restores of archived blobs to the hot tier
+	restoresMu sync.Mutex
+	restores   map[string]*RestoreStatus // contentHash -> restore
+
 	// Migration semaphore
 	migrationSem chan struct{}
 
@@ -245,6 +255,7 @@ func NewTieringController(
 		accessTracker: accessTracker,
 		policies:      make(map[string]PolicyConfig),
 		migrations:    make(map[string]*MigrationStatus),
+		restores:      make(map[string]*RestoreStatus),
 		migrationSem:  make(chan struct{}, config.MaxConcurrentMigrations),
 		shutdownCh:    make(chan struct{}),
 	}
@@ -302,6 +313,8 @@ func (c *TieringController) scanLoop(ctx context.Context) {
 func (c *TieringController) scan(ctx context.Context) {
 	c.logger.Debug().Msg("Starting tiering scan")
 
+	c.expireRestores(ctx)
+
 	c.policiesMu.RLock()
 	policies := make([]PolicyConfig, 0, len(c.policies))
 	for _, p := range c.policies {
@@ -383,7 +396,7 @@ func (c *TieringController) evaluateBlob(blob *BlobAccessInfo, policy PolicyConf
 	_, inProgress := c.migrations[blob.ContentHash]
 	c.migrationsMu.RUnlock()
 
-	if inProgress {
+	if inProgress || c.restoring(blob.ContentHash) {
 		return nil
 	}
 
@@ -561,6 +574,12 @@ func (c *TieringController) migrateBlob(ctx context.Context, decision *TieringDe
 	}
 	c.clusterMgr.RegisterBlobLocation(ctx, newLocation)
 
+	if tracker, ok := c.accessTracker.(tierUpdater); ok {
+		if err := tracker.UpdateTier(ctx, decision.ContentHash, decision.TargetTier); err != nil {
+			logger.Warn().Err(err).Msg("Failed to record the new tier of the blob")
+		}
+	}
+
 	status.Status = "completed"
 	status.CompletedAt = time.Now()
 
@@ -647,9 +666,11 @@ func (c *TieringController) TriggerScan(ctx context.Context) {
 
 // ForceMove immediately moves a blob to a specific tier.
 func (c *TieringController) ForceMove(ctx context.Context, contentHash string, targetTier Tier) error {
-	// Check if migration is already in progress
+	// Check if migration is already in progress. Finished migrations are
+	// kept for a while and do not count.
 	c.migrationsMu.RLock()
-	_, inProgress := c.migrations[contentHash]
+	previous, exists := c.migrations[contentHash]
+	inProgress := exists && (previous.Status == "pending" || previous.Status == "in_progress")
 	c.migrationsMu.RUnlock()
 
 	if inProgress {
@@ -0,0 +1,209 @@
+package tiering
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Restore errors.
+var (
+	ErrInvalidRestoreDays = errors.New("restore days must be positive")
+	ErrNotArchived        = errors.New("blob is not in an archive tier")
+	ErrRestoreInProgress  = errors.New("restore already in progress for this blob")
+)
+
+// RestoreStatus describes a temporary copy of an archived blob in the hot tier.
+type RestoreStatus struct {
+	// ContentHash is the blob identifier.
+	ContentHash string `json:"content_hash"`
+
+	// ArchiveTier is the tier the blob is moved back to when the restore expires.
+	ArchiveTier Tier `json:"archive_tier"`
+
+	// Days is how long the blob stays in the hot tier once restored.
+	Days int `json:"days"`
+
+	// Ongoing is true until the blob has been copied to the hot tier.
+	Ongoing bool `json:"ongoing"`
+
+	// RequestedAt is when the restore was requested.
+	RequestedAt time.Time `json:"requested_at"`
+
+	// ExpiresAt is when the blob is moved back to its archive tier. It is
+	// zero while the restore is ongoing.
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
+
+// Header formats the status as the value of the x-amz-restore header.
+func (s RestoreStatus) Header() string {
+	if s.Ongoing {
+		return `ongoing-request="true"`
+	}
+	return fmt.Sprintf(`ongoing-request="false", expiry-date="%s"`, s.ExpiresAt.UTC().Format(http.TimeFormat))
+}
+
+// restoreExpiry returns when a restore completed at completedAt expires. Like
+// S3, the window is rounded up to midnight UTC.
+func restoreExpiry(completedAt time.Time, days int) time.Time {
+	day := 24 * time.Hour
+	return completedAt.UTC().Add(time.Duration(days) * day).Truncate(day).Add(day)
+}
+
+// Restore queues a copy of an archived blob back to the hot tier, where it
+// stays for the given number of days before it is moved back to the tier it
+// came from. Restoring a blob that is already restored only moves its expiry.
+// The returned status is ongoing for a newly queued restore.
+//
+// Restores are only kept in memory. A restarted controller forgets them, and
+// their blobs stay in the hot tier until a policy moves them.
+func (c *TieringController) Restore(ctx context.Context, contentHash string, days int) (RestoreStatus, error) {
+	if days <= 0 {
+		return RestoreStatus{}, ErrInvalidRestoreDays
+	}
+
+	c.restoresMu.Lock()
+	if restore, ok := c.restores[contentHash]; ok {
+		defer c.restoresMu.Unlock()
+		if restore.Ongoing {
+			return *restore, ErrRestoreInProgress
+		}
+		restore.Days = days
+		restore.ExpiresAt = restoreExpiry(time.Now(), days)
+		return *restore, nil
+	}
+	c.restoresMu.Unlock()
+
+	accessInfo, err := c.accessTracker.GetAccessInfo(ctx, contentHash)
+	if err != nil {
+		return RestoreStatus{}, err
+	}
+	if accessInfo.CurrentTier == TierHot {
+		return RestoreStatus{}, ErrNotArchived
+	}
+
+	restore := &RestoreStatus{
+		ContentHash: contentHash,
+		ArchiveTier: accessInfo.CurrentTier,
+		Days:        days,
+		Ongoing:     true,
+		RequestedAt: time.Now(),
+	}
+
+	// Another request may have queued the same blob while the tracker was
+	// queried
+	c.restoresMu.Lock()
+	if existing, ok := c.restores[contentHash]; ok {
+		c.restoresMu.Unlock()
+		return *existing, ErrRestoreInProgress
+	}
+	c.restores[contentHash] = restore
+	status := *restore
+	c.restoresMu.Unlock()
+
+	c.logger.Info().
+		Str("content_hash", contentHash).
+		Str("archive_tier", string(restore.ArchiveTier)).
+		Int("days", days).
+		Msg("Restore queued")
+
+	// The job outlives the request that queued it
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		c.runRestore(context.WithoutCancel(ctx), restore)
+	}()
+
+	return status, nil
+}
+
+// runRestore copies a queued blob to the hot tier and starts its restore
+// window. A failed restore is dropped so that it can be requested again.
+func (c *TieringController) runRestore(ctx context.Context, restore *RestoreStatus) {
+	logger := c.logger.With().Str("content_hash", restore.ContentHash).Logger()
+
+	select {
+	case c.migrationSem <- struct{}{}:
+	case <-c.shutdownCh:
+		c.restoresMu.Lock()
+		delete(c.restores, restore.ContentHash)
+		c.restoresMu.Unlock()
+		return
+	}
+	defer func() { <-c.migrationSem }()
+
+	err := c.ForceMove(ctx, restore.ContentHash, TierHot)
+
+	c.restoresMu.Lock()
+	defer c.restoresMu.Unlock()
+
+	if err != nil {
+		logger.Error().Err(err).Msg("Restore failed")
+		delete(c.restores, restore.ContentHash)
+		return
+	}
+
+	restore.Ongoing = false
+	restore.ExpiresAt = restoreExpiry(time.Now(), restore.Days)
+
+	logger.Info().Time("expires_at", restore.ExpiresAt).Msg("Restore completed")
+}
+
+// expireRestores moves blobs whose restore window has passed back to their
+// archive tier. A failed move is retried on the next scan.
+func (c *TieringController) expireRestores(ctx context.Context) {
+	now := time.Now()
+
+	c.restoresMu.Lock()
+	var expired []RestoreStatus
+	for _, restore := range c.restores {
+		if !restore.Ongoing && !restore.ExpiresAt.After(now) {
+			expired = append(expired, *restore)
+		}
+	}
+	c.restoresMu.Unlock()
+
+	for _, restore := range expired {
+		logger := c.logger.With().
+			Str("content_hash", restore.ContentHash).
+			Str("archive_tier", string(restore.ArchiveTier)).
+			Logger()
+
+		if err := c.ForceMove(ctx, restore.ContentHash, restore.ArchiveTier); err != nil {
+			logger.Error().Err(err).Msg("Failed to move expired restore back to its archive tier")
+			continue
+		}
+
+		c.restoresMu.Lock()
+		delete(c.restores, restore.ContentHash)
+		c.restoresMu.Unlock()
+
+		logger.Info().Msg("Restore expired")
+	}
+}
+
+// restoring reports whether the blob has a restore that has not been moved
+// back to its archive tier yet. Such blobs are left alone by tiering policies.
+func (c *TieringController) restoring(contentHash string) bool {
+	c.restoresMu.Lock()
+	defer c.restoresMu.Unlock()
+
+	_, ok := c.restores[contentHash]
+	return ok
+}
+
+// GetRestoreStatus returns the restore of a blob, if any.
+func (c *TieringController) GetRestoreStatus(contentHash string) (*RestoreStatus, bool) {
+	c.restoresMu.Lock()
+	defer c.restoresMu.Unlock()
+
+	restore, exists := c.restores[contentHash]
+	if !exists {
+		return nil, false
+	}
+
+	restoreCopy := *restore
+	return &restoreCopy, true
+}
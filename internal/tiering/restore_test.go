@@ -0,0 +1,187 @@
+package tiering
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prn-tf/alexander-storage/internal/cluster"
+)
+
+// fakeCluster keeps blobs in memory on one node per tier. Transfers wait
+// for gate when it is set.
+type fakeCluster struct {
+	cluster.ClusterManager
+	cluster.NodeSelector
+
+	mu    sync.Mutex
+	blobs map[string]map[string][]byte // nodeID -> contentHash -> data
+	gate  chan struct{}
+}
+
+func newFakeCluster() *fakeCluster {
+	return &fakeCluster{blobs: map[string]map[string][]byte{
+		string(TierHot):  {},
+		string(TierWarm): {},
+		string(TierCold): {},
+	}}
+}
+
+func (f *fakeCluster) has(nodeID, contentHash string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.blobs[nodeID][contentHash]
+	return ok
+}
+
+func (f *fakeCluster) SelectForTiering(ctx context.Context, contentHash string, targetRole cluster.NodeRole) (*cluster.Node, error) {
+	return &cluster.Node{ID: string(targetRole), Role: targetRole, Status: cluster.NodeStatusHealthy}, nil
+}
+
+func (f *fakeCluster) GetBlobLocations(ctx context.Context, contentHash string) ([]*cluster.BlobLocation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var locations []*cluster.BlobLocation
+	for nodeID, blobs := range f.blobs {
+		if _, ok := blobs[contentHash]; ok {
+			locations = append(locations, &cluster.BlobLocation{ContentHash: contentHash, NodeID: nodeID})
+		}
+	}
+	return locations, nil
+}
+
+func (f *fakeCluster) RegisterBlobLocation(ctx context.Context, location *cluster.BlobLocation) error {
+	return nil
+}
+
+func (f *fakeCluster) GetClientForNode(ctx context.Context, nodeID string) (cluster.NodeClient, error) {
+	return &fakeNodeClient{cluster: f, nodeID: nodeID}, nil
+}
+
+type fakeNodeClient struct {
+	cluster.NodeClient
+	cluster *fakeCluster
+	nodeID  string
+}
+
+func (c *fakeNodeClient) RetrieveBlob(ctx context.Context, contentHash string) (io.ReadCloser, error) {
+	c.cluster.mu.Lock()
+	defer c.cluster.mu.Unlock()
+	data, ok := c.cluster.blobs[c.nodeID][contentHash]
+	if !ok {
+		return nil, errors.New("blob not found")
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (c *fakeNodeClient) TransferBlob(ctx context.Context, contentHash string, size int64, reader io.Reader) error {
+	if c.cluster.gate != nil {
+		<-c.cluster.gate
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	c.cluster.mu.Lock()
+	defer c.cluster.mu.Unlock()
+	c.cluster.blobs[c.nodeID][contentHash] = data
+	return nil
+}
+
+func TestRestoreExpiry(t *testing.T) {
+	completed := time.Date(2026, 10, 15, 13, 30, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC), restoreExpiry(completed, 1))
+	assert.Equal(t, time.Date(2026, 10, 23, 0, 0, 0, 0, time.UTC), restoreExpiry(completed, 7))
+
+	// Other zones round to midnight UTC too
+	tokyo := time.FixedZone("JST", 9*60*60)
+	assert.Equal(t, time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC), restoreExpiry(completed.In(tokyo), 1))
+}
+
+func TestRestoreStatus_Header(t *testing.T) {
+	assert.Equal(t, `ongoing-request="true"`, RestoreStatus{Ongoing: true}.Header())
+	assert.Equal(t, `ongoing-request="false", expiry-date="Sat, 17 Oct 2026 00:00:00 GMT"`,
+		RestoreStatus{ExpiresAt: time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)}.Header())
+}
+
+func TestTieringController_Restore(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeCluster()
+	tracker := NewMemoryAccessTracker(zerolog.Nop())
+	c := NewTieringController(DefaultControllerConfig(), fake, fake, tracker, zerolog.Nop())
+	t.Cleanup(func() { _ = c.Stop() })
+
+	longAgo := time.Now().Add(-365 * 24 * time.Hour)
+	require.NoError(t, tracker.RegisterBlob(ctx, &BlobAccessInfo{ContentHash: "archived", CurrentTier: TierCold, Size: 4, LastAccessedAt: longAgo}))
+	require.NoError(t, tracker.RegisterBlob(ctx, &BlobAccessInfo{ContentHash: "lost", CurrentTier: TierCold, Size: 4, LastAccessedAt: longAgo}))
+	require.NoError(t, tracker.RegisterBlob(ctx, &BlobAccessInfo{ContentHash: "current", CurrentTier: TierHot, Size: 4}))
+	fake.blobs[string(TierCold)]["archived"] = []byte("data")
+
+	_, err := c.Restore(ctx, "archived", 0)
+	assert.ErrorIs(t, err, ErrInvalidRestoreDays)
+	_, err = c.Restore(ctx, "current", 1)
+	assert.ErrorIs(t, err, ErrNotArchived)
+
+	// The copy runs in the background and can't be requested twice
+	fake.gate = make(chan struct{})
+	status, err := c.Restore(ctx, "archived", 1)
+	require.NoError(t, err)
+	assert.True(t, status.Ongoing)
+	assert.Equal(t, TierCold, status.ArchiveTier)
+	assert.Equal(t, `ongoing-request="true"`, status.Header())
+	_, err = c.Restore(ctx, "archived", 1)
+	assert.ErrorIs(t, err, ErrRestoreInProgress)
+	close(fake.gate)
+
+	require.Eventually(t, func() bool {
+		restore, ok := c.GetRestoreStatus("archived")
+		return ok && !restore.Ongoing
+	}, 5*time.Second, 10*time.Millisecond)
+	restore, _ := c.GetRestoreStatus("archived")
+	assert.Equal(t, restoreExpiry(time.Now(), 1), restore.ExpiresAt)
+	assert.True(t, fake.has(string(TierHot), "archived"))
+	info, err := tracker.GetAccessInfo(ctx, "archived")
+	require.NoError(t, err)
+	assert.Equal(t, TierHot, info.CurrentTier)
+
+	// Policies leave a restored blob in the hot tier despite its age
+	assert.Nil(t, c.evaluateBlob(info, DefaultPolicyConfig()))
+
+	// Restoring again only moves the expiry
+	status, err = c.Restore(ctx, "archived", 5)
+	require.NoError(t, err)
+	assert.False(t, status.Ongoing)
+	assert.Equal(t, restoreExpiry(time.Now(), 5), status.ExpiresAt)
+
+	// A failed copy drops the restore
+	_, err = c.Restore(ctx, "lost", 1)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		_, ok := c.GetRestoreStatus("lost")
+		return !ok
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Once the window has passed, a scan moves the blob back
+	c.restoresMu.Lock()
+	c.restores["archived"].ExpiresAt = time.Now().Add(-time.Minute)
+	c.restoresMu.Unlock()
+	fake.mu.Lock()
+	delete(fake.blobs[string(TierCold)], "archived")
+	fake.mu.Unlock()
+	c.scan(ctx)
+
+	_, ok := c.GetRestoreStatus("archived")
+	assert.False(t, ok)
+	assert.True(t, fake.has(string(TierCold), "archived"))
+	info, err = tracker.GetAccessInfo(ctx, "archived")
+	require.NoError(t, err)
+	assert.Equal(t, TierCold, info.CurrentTier)
+}
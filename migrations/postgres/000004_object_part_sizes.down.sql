@@ -0,0 +1,5 @@
+-- Alexander Storage Database Schema
+-- Migration: 000004_object_part_sizes
+-- Description: Rollback - Remove part sizes from objects
+
+ALTER TABLE objects DROP COLUMN IF EXISTS part_sizes;
@@ -0,0 +1,7 @@
+-- Alexander Storage Database Schema
+-- Migration: 000004_object_part_sizes
+-- Description: Record original part sizes of multipart objects for partNumber GET/HEAD
+
+-- Part sizes in part order; NULL for single-part objects
+ALTER TABLE objects
+ADD COLUMN IF NOT EXISTS part_sizes BIGINT[];
@@ -0,0 +1,5 @@
+-- Alexander Storage Database Schema
+-- Migration: 000005_blob_encryption_iv
+-- Description: Rollback - Remove encryption IV from blobs
+
+ALTER TABLE blobs DROP COLUMN IF EXISTS encryption_iv;
@@ -0,0 +1,7 @@
+-- Alexander Storage Database Schema
+-- Migration: 000005_blob_encryption_iv
+-- Description: Add the encryption IV column used by the blob repository
+
+-- Base64 IV/nonce for encrypted blobs; NULL for unencrypted blobs
+ALTER TABLE blobs
+ADD COLUMN IF NOT EXISTS encryption_iv TEXT;
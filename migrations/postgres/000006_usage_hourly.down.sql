@@ -0,0 +1,5 @@
+-- Alexander Storage Database Schema
+-- Migration: 000006_usage_hourly
+-- Description: Rollback - Remove hourly usage accounting
+
+DROP TABLE IF EXISTS usage_hourly;
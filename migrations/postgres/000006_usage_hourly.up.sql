@@ -0,0 +1,19 @@
+-- Alexander Storage Database Schema
+-- Migration: 000006_usage_hourly
+-- Description: Hourly usage accounting per bucket and access key for billing
+
+CREATE TABLE IF NOT EXISTS usage_hourly (
+    hour            TIMESTAMPTZ NOT NULL,
+    bucket_name     VARCHAR(63) NOT NULL,
+    -- Empty for storage snapshot rows, which are not attributable to a key
+    access_key_id   VARCHAR(128) NOT NULL DEFAULT '',
+    bytes_in        BIGINT NOT NULL DEFAULT 0,
+    bytes_out       BIGINT NOT NULL DEFAULT 0,
+    requests        BIGINT NOT NULL DEFAULT 0,
+    bytes_stored    BIGINT NOT NULL DEFAULT 0,
+
+    PRIMARY KEY (hour, bucket_name, access_key_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_usage_hourly_bucket ON usage_hourly (bucket_name, hour);
+CREATE INDEX IF NOT EXISTS idx_usage_hourly_access_key ON usage_hourly (access_key_id, hour);
@@ -0,0 +1,9 @@
+-- Alexander Storage Database Schema
+-- Migration: 000007_blob_gc_state
+-- Description: Rollback - Remove blob garbage collection state
+
+DROP INDEX IF EXISTS idx_blobs_gc_state;
+ALTER TABLE blobs
+DROP COLUMN IF EXISTS gc_last_error,
+DROP COLUMN IF EXISTS gc_attempts,
+DROP COLUMN IF EXISTS gc_state;
@@ -0,0 +1,12 @@
+-- Alexander Storage Database Schema
+-- Migration: 000007_blob_gc_state
+-- Description: Track per-blob garbage collection progress so interrupted deletions resume
+
+-- '' (live), 'pending' (claimed, deleting content), 'storage_deleted' (content gone, row remains)
+ALTER TABLE blobs
+ADD COLUMN IF NOT EXISTS gc_state VARCHAR(16) NOT NULL DEFAULT ''
+    CONSTRAINT blobs_gc_state_valid CHECK (gc_state IN ('', 'pending', 'storage_deleted')),
+ADD COLUMN IF NOT EXISTS gc_attempts INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS gc_last_error TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_blobs_gc_state ON blobs (gc_state) WHERE gc_state <> '';
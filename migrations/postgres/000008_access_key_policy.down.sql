@@ -0,0 +1,6 @@
+-- Alexander Storage Database Schema
+-- Migration: 000008_access_key_policy
+-- Description: Rollback - Remove access key policies
+
+ALTER TABLE access_keys
+DROP COLUMN IF EXISTS policy;
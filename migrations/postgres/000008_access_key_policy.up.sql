@@ -0,0 +1,7 @@
+-- Alexander Storage Database Schema
+-- Migration: 000008_access_key_policy
+-- Description: Store an optional scoping policy (JSON) with each access key
+
+-- NULL means the key has full access to its owner's buckets
+ALTER TABLE access_keys
+ADD COLUMN IF NOT EXISTS policy JSONB;
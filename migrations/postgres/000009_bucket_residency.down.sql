@@ -0,0 +1,6 @@
+-- Alexander Storage Database Schema
+-- Migration: 000009_bucket_residency
+-- Description: Rollback - Remove bucket residency tags
+
+ALTER TABLE buckets
+DROP COLUMN IF EXISTS residency;
@@ -0,0 +1,7 @@
+-- Alexander Storage Database Schema
+-- Migration: 000009_bucket_residency
+-- Description: Data residency tag per bucket, restricting which storage backend holds its blobs
+
+-- '' stores blobs on the default backend
+ALTER TABLE buckets
+ADD COLUMN IF NOT EXISTS residency VARCHAR(32) NOT NULL DEFAULT '';
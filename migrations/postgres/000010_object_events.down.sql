@@ -0,0 +1,5 @@
+-- Alexander Storage Database Schema
+-- Migration: 000010_object_events
+-- Description: Rollback - Remove the object event outbox
+
+DROP TABLE IF EXISTS object_events;
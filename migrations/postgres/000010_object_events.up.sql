@@ -0,0 +1,19 @@
+-- Alexander Storage Database Schema
+-- Migration: 000010_object_events
+-- Description: Outbox of object change events for watch clients
+
+CREATE TABLE IF NOT EXISTS object_events (
+    -- Watch cursor; IDs are assigned at insert, so readers skip a settle
+    -- window to avoid missing events from transactions still in flight
+    id              BIGSERIAL PRIMARY KEY,
+    event_type      VARCHAR(64) NOT NULL,
+    bucket_name     VARCHAR(63) NOT NULL,
+    object_key      VARCHAR(1024) NOT NULL,
+    version_id      VARCHAR(64) NOT NULL DEFAULT '',
+    size            BIGINT NOT NULL DEFAULT 0,
+    etag            VARCHAR(64) NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_object_events_bucket ON object_events (bucket_name, id);
+CREATE INDEX IF NOT EXISTS idx_object_events_created ON object_events (created_at);
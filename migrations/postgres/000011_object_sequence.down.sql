@@ -0,0 +1,8 @@
+-- Alexander Storage Database Schema
+-- Migration: 000011_object_sequence
+-- Description: Rollback - Remove per-key write sequence numbers
+
+DROP TABLE IF EXISTS object_sequences;
+
+ALTER TABLE objects
+DROP COLUMN IF EXISTS sequence;
@@ -0,0 +1,22 @@
+-- Alexander Storage Database Schema
+-- Migration: 000011_object_sequence
+-- Description: Per-key write sequence numbers (x-alexander-sequence)
+
+SET lock_timeout = '5s';
+
+-- Sequence of the write that created this version (or delete marker).
+-- Existing versions keep 0 until the object_sequence backfill numbers them.
+ALTER TABLE objects
+ADD COLUMN IF NOT EXISTS sequence BIGINT NOT NULL DEFAULT 0;
+
+-- Last sequence issued per key; kept separately so it survives deletes.
+-- Writers increment it with a row lock, which orders writes to the same key.
+CREATE TABLE IF NOT EXISTS object_sequences (
+    bucket_id       BIGINT NOT NULL,
+    key             VARCHAR(1024) NOT NULL,
+    sequence        BIGINT NOT NULL,
+
+    PRIMARY KEY (bucket_id, key),
+    CONSTRAINT fk_object_sequences_bucket FOREIGN KEY (bucket_id)
+        REFERENCES buckets(id) ON DELETE CASCADE
+);
@@ -0,0 +1,6 @@
+-- Alexander Storage Database Schema
+-- Migration: 000012_user_roles
+-- Description: Rollback - Remove user roles
+
+ALTER TABLE users
+DROP COLUMN IF EXISTS role;
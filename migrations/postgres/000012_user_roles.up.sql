@@ -0,0 +1,13 @@
+-- Alexander Storage Database Schema
+-- Migration: 000012_user_roles
+-- Description: Dashboard and admin API roles for users
+
+-- '' (S3 API only), 'read-only', 'operator' or 'admin'.
+-- is_admin is kept in sync with role = 'admin' so this migration can be rolled back.
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS role VARCHAR(16) NOT NULL DEFAULT '';
+
+ALTER TABLE users
+ADD CONSTRAINT users_role_valid CHECK (role IN ('', 'read-only', 'operator', 'admin'));
+
+UPDATE users SET role = 'admin' WHERE is_admin;
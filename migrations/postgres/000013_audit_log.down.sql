@@ -0,0 +1,6 @@
+-- Alexander Storage Database Schema
+-- Migration: 000013_audit_log
+-- Description: Rollback - Remove the audit log
+
+DROP TABLE IF EXISTS audit_log;
+DROP FUNCTION IF EXISTS audit_log_append_only();
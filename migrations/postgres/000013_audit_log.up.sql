@@ -0,0 +1,39 @@
+-- Alexander Storage Database Schema
+-- Migration: 000013_audit_log
+-- Description: Append-only audit log of writes and administrative actions
+
+CREATE TABLE IF NOT EXISTS audit_log (
+    id              BIGSERIAL PRIMARY KEY,
+    time            TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    user_id         BIGINT NOT NULL DEFAULT 0,          -- No foreign key: events outlive users
+    actor           VARCHAR(255) NOT NULL,
+    access_key_id   VARCHAR(20) NOT NULL DEFAULT '',
+    operation       VARCHAR(64) NOT NULL,
+    bucket_name     VARCHAR(63) NOT NULL DEFAULT '',
+    object_key      VARCHAR(1024) NOT NULL DEFAULT '',
+    source_ip       VARCHAR(64) NOT NULL DEFAULT '',
+    request_id      VARCHAR(64) NOT NULL DEFAULT '',
+    result          VARCHAR(16) NOT NULL,
+    status_code     INTEGER NOT NULL DEFAULT 0,
+    detail          TEXT NOT NULL DEFAULT '',
+
+    CONSTRAINT audit_log_result_valid CHECK (result IN ('success', 'denied', 'failure'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_time ON audit_log (time);
+CREATE INDEX IF NOT EXISTS idx_audit_log_user ON audit_log (user_id, id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_bucket ON audit_log (bucket_name, id);
+
+-- The audit log is immutable
+CREATE OR REPLACE FUNCTION audit_log_append_only()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_audit_log_append_only ON audit_log;
+CREATE TRIGGER trg_audit_log_append_only
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW
+    EXECUTE FUNCTION audit_log_append_only();
@@ -0,0 +1,5 @@
+-- Alexander Storage Database Schema
+-- Migration: 000014_backfill_progress
+-- Description: Rollback - Remove backfill progress tracking
+
+DROP TABLE IF EXISTS backfill_progress;
@@ -0,0 +1,12 @@
+-- Alexander Storage Database Schema
+-- Migration: 000014_backfill_progress
+-- Description: Progress of batched data migrations (see migrations/postgres/README.md)
+
+CREATE TABLE IF NOT EXISTS backfill_progress (
+    name            VARCHAR(128) PRIMARY KEY,
+    cursor          BIGINT NOT NULL DEFAULT 0,          -- Last row ID processed
+    rows_done       BIGINT NOT NULL DEFAULT 0,
+    started_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at    TIMESTAMPTZ
+);
@@ -0,0 +1,5 @@
+-- Alexander Storage Database Schema
+-- Migration: 000015_objects_storage_index
+-- Description: Rollback - Remove the bytes-stored covering index
+
+DROP INDEX CONCURRENTLY IF EXISTS idx_objects_bucket_size;
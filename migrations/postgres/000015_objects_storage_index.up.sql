@@ -0,0 +1,10 @@
+-- Alexander Storage Database Schema
+-- Migration: 000015_objects_storage_index
+-- Description: Covering index for the hourly bytes-stored snapshot
+--
+-- Online change: CONCURRENTLY builds the index without blocking writes. It
+-- cannot run inside a transaction, so this file holds a single statement.
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_objects_bucket_size
+    ON objects (bucket_id) INCLUDE (size)
+    WHERE is_delete_marker = FALSE;
@@ -0,0 +1,8 @@
+-- Alexander Storage Database Schema
+-- Migration: 000016_bucket_replication
+-- Description: Rollback - Remove bucket replication
+
+DROP TABLE IF EXISTS replication_tasks;
+DROP TABLE IF EXISTS bucket_replication;
+
+ALTER TABLE objects DROP COLUMN IF EXISTS replication_status;
@@ -0,0 +1,44 @@
+-- Alexander Storage Database Schema
+-- Migration: 000016_bucket_replication
+-- Description: Bucket replication to remote S3-compatible endpoints
+
+SET lock_timeout = '5s';
+
+-- Replication state of each version (x-amz-replication-status); empty if not replicated
+ALTER TABLE objects
+ADD COLUMN IF NOT EXISTS replication_status VARCHAR(16) NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS bucket_replication (
+    bucket_id               BIGINT PRIMARY KEY,
+    endpoint                VARCHAR(2048) NOT NULL,
+    region                  VARCHAR(64) NOT NULL DEFAULT '',
+    destination_bucket      VARCHAR(63) NOT NULL,
+    access_key_id           VARCHAR(128) NOT NULL,
+    encrypted_secret_key    TEXT NOT NULL,              -- AES-256-GCM, like access key secrets
+    rules                   JSONB NOT NULL,
+    created_at              TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at              TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_bucket_replication_bucket FOREIGN KEY (bucket_id)
+        REFERENCES buckets(id) ON DELETE CASCADE
+);
+
+-- Queue of changes to push to replication destinations, written in the
+-- transaction of the change. Tasks of one key are applied in ID order.
+CREATE TABLE IF NOT EXISTS replication_tasks (
+    id                  BIGSERIAL PRIMARY KEY,
+    bucket_id           BIGINT NOT NULL,
+    object_id           BIGINT NOT NULL DEFAULT 0,      -- No FK: the version may be gone by the time the task runs
+    key                 VARCHAR(1024) NOT NULL,
+    operation           VARCHAR(16) NOT NULL,           -- put, delete
+    attempts            INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_error          TEXT NOT NULL DEFAULT '',
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_replication_tasks_bucket FOREIGN KEY (bucket_id)
+        REFERENCES buckets(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_replication_tasks_due ON replication_tasks (next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_replication_tasks_key ON replication_tasks (bucket_id, key, id);
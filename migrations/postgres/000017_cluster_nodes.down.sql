@@ -0,0 +1,5 @@
+-- Alexander Storage Database Schema
+-- Migration: 000017_cluster_nodes
+-- Description: Rollback - Remove cluster membership
+
+DROP TABLE IF EXISTS cluster_nodes;
@@ -0,0 +1,10 @@
+-- Alexander Storage Database Schema
+-- Migration: 000017_cluster_nodes
+-- Description: Cluster membership; every node upserts its row as a heartbeat
+
+CREATE TABLE IF NOT EXISTS cluster_nodes (
+    id              VARCHAR(64) PRIMARY KEY,
+    address         VARCHAR(255) NOT NULL,              -- host:port of the internal blob API
+    started_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_heartbeat  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
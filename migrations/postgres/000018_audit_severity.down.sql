@@ -0,0 +1,15 @@
+-- Alexander Storage Database Schema
+-- Migration: 000018_audit_severity
+-- Description: Rollback - Remove audit event categories, severities and pruning
+
+SET lock_timeout = '5s';
+
+CREATE OR REPLACE FUNCTION audit_log_append_only()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE audit_log DROP COLUMN IF EXISTS severity;
+ALTER TABLE audit_log DROP COLUMN IF EXISTS category;
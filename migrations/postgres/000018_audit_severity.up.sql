@@ -0,0 +1,26 @@
+-- Alexander Storage Database Schema
+-- Migration: 000018_audit_severity
+-- Description: Audit event categories and severities, and retention pruning
+--
+-- Events recorded before this migration keep the defaults, since the audit
+-- log cannot be updated.
+
+SET lock_timeout = '5s';
+
+ALTER TABLE audit_log
+ADD COLUMN IF NOT EXISTS category VARCHAR(16) NOT NULL DEFAULT 'operational';
+
+ALTER TABLE audit_log
+ADD COLUMN IF NOT EXISTS severity VARCHAR(16) NOT NULL DEFAULT 'info';
+
+-- The audit log stays immutable, except that retention pruning deletes
+-- expired events in a transaction with alexander.audit_prune set
+CREATE OR REPLACE FUNCTION audit_log_append_only()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' AND current_setting('alexander.audit_prune', true) = 'on' THEN
+        RETURN OLD;
+    END IF;
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,5 @@
+-- Alexander Storage Database Schema
+-- Migration: 000019_audit_log_category_index
+-- Description: Rollback - Remove the audit log pruning index
+
+DROP INDEX CONCURRENTLY IF EXISTS idx_audit_log_category_time;
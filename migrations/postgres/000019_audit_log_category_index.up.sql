@@ -0,0 +1,9 @@
+-- Alexander Storage Database Schema
+-- Migration: 000019_audit_log_category_index
+-- Description: Index for pruning audit events by category and age
+--
+-- Online change: CONCURRENTLY builds the index without blocking writes. It
+-- cannot run inside a transaction, so this file holds a single statement.
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_audit_log_category_time
+    ON audit_log (category, time);
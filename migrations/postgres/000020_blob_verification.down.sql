@@ -0,0 +1,9 @@
+-- Alexander Storage Database Schema
+-- Migration: 000020_blob_verification
+-- Description: Rollback - Remove blob verification state
+
+SET lock_timeout = '5s';
+
+ALTER TABLE blobs
+DROP COLUMN IF EXISTS corrupted_at,
+DROP COLUMN IF EXISTS last_verified_at;
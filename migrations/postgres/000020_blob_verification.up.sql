@@ -0,0 +1,10 @@
+-- Alexander Storage Database Schema
+-- Migration: 000020_blob_verification
+-- Description: Bitrot scrubbing: when each blob was last re-hashed and
+-- whether its content was found corrupt
+
+SET lock_timeout = '5s';
+
+ALTER TABLE blobs
+ADD COLUMN IF NOT EXISTS last_verified_at TIMESTAMPTZ,      -- NULL until first verified
+ADD COLUMN IF NOT EXISTS corrupted_at TIMESTAMPTZ;          -- NULL unless the content does not match its hash
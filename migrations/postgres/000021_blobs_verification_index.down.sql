@@ -0,0 +1,5 @@
+-- Alexander Storage Database Schema
+-- Migration: 000021_blobs_verification_index
+-- Description: Rollback - Remove the blob verification index
+
+DROP INDEX CONCURRENTLY IF EXISTS idx_blobs_last_verified;
@@ -0,0 +1,10 @@
+-- Alexander Storage Database Schema
+-- Migration: 000021_blobs_verification_index
+-- Description: Index for finding the blobs verified longest ago
+--
+-- Online change: CONCURRENTLY builds the index without blocking writes. It
+-- cannot run inside a transaction, so this file holds a single statement.
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_blobs_last_verified
+    ON blobs (last_verified_at ASC NULLS FIRST, content_hash)
+    WHERE ref_count > 0;
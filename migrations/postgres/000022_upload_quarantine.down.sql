@@ -0,0 +1,7 @@
+-- Alexander Storage Database Schema
+-- Migration: 000022_upload_quarantine
+-- Description: Rollback - Remove upload quarantine
+
+DROP TABLE IF EXISTS staged_objects;
+
+ALTER TABLE buckets DROP COLUMN IF EXISTS quarantine;
@@ -0,0 +1,34 @@
+-- Alexander Storage Database Schema
+-- Migration: 000022_upload_quarantine
+-- Description: Quarantine buckets: uploads are staged until an approver
+-- promotes them to a visible object version
+
+SET lock_timeout = '5s';
+
+ALTER TABLE buckets
+ADD COLUMN IF NOT EXISTS quarantine BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Uploads waiting for promotion. Each row holds a reference on its blob,
+-- released when the upload is rejected or expires.
+CREATE TABLE IF NOT EXISTS staged_objects (
+    id                  UUID PRIMARY KEY,               -- Staging ID returned to the uploader
+    bucket_id           BIGINT NOT NULL,
+    key                 VARCHAR(1024) NOT NULL,
+    content_hash        CHAR(64) NOT NULL,
+    size                BIGINT NOT NULL DEFAULT 0,
+    content_type        VARCHAR(255) NOT NULL DEFAULT 'application/octet-stream',
+    etag                VARCHAR(64) NOT NULL DEFAULT '',
+    storage_class       storage_class NOT NULL DEFAULT 'STANDARD',
+    metadata            JSONB DEFAULT '{}',
+    part_sizes          BIGINT[],
+    event_type          VARCHAR(64) NOT NULL,           -- Event recorded on promotion
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_staged_objects_bucket FOREIGN KEY (bucket_id)
+        REFERENCES buckets(id) ON DELETE CASCADE,
+    CONSTRAINT fk_staged_objects_blob FOREIGN KEY (content_hash)
+        REFERENCES blobs(content_hash) ON DELETE RESTRICT
+);
+
+CREATE INDEX IF NOT EXISTS idx_staged_objects_created ON staged_objects (created_at);
+CREATE INDEX IF NOT EXISTS idx_staged_objects_bucket ON staged_objects (bucket_id, created_at);
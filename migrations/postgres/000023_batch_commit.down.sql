@@ -0,0 +1,9 @@
+-- Alexander Storage Database Schema
+-- Migration: 000023_batch_commit
+-- Description: Rollback - Remove batch commit
+
+SET lock_timeout = '5s';
+
+ALTER TABLE staged_objects DROP COLUMN IF EXISTS batch_id;
+
+DROP TABLE IF EXISTS batches;
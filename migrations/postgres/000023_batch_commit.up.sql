@@ -0,0 +1,24 @@
+-- Alexander Storage Database Schema
+-- Migration: 000023_batch_commit
+-- Description: Batches of staged writes that become visible together on commit
+
+SET lock_timeout = '5s';
+
+CREATE TABLE IF NOT EXISTS batches (
+    id                  UUID PRIMARY KEY,               -- Batch ID sent with each write
+    owner_id            BIGINT NOT NULL,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at          TIMESTAMPTZ NOT NULL,
+
+    CONSTRAINT fk_batches_owner FOREIGN KEY (owner_id)
+        REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_batches_expires ON batches (expires_at);
+
+-- Writes of a batch are staged like quarantined uploads. The check is
+-- deferred so a commit can delete the batch before its staged rows, which
+-- makes a write racing with the commit fail instead of being left behind.
+ALTER TABLE staged_objects
+ADD COLUMN IF NOT EXISTS batch_id UUID
+    CONSTRAINT fk_staged_objects_batch REFERENCES batches(id) DEFERRABLE INITIALLY DEFERRED;
@@ -0,0 +1,5 @@
+-- Alexander Storage Database Schema
+-- Migration: 000024_staged_objects_batch_index
+-- Description: Rollback - Remove the staged writes batch index
+
+DROP INDEX CONCURRENTLY IF EXISTS idx_staged_objects_batch;
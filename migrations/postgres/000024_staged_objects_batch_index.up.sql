@@ -0,0 +1,10 @@
+-- Alexander Storage Database Schema
+-- Migration: 000024_staged_objects_batch_index
+-- Description: Index for listing the staged writes of a batch
+--
+-- Online change: CONCURRENTLY builds the index without blocking writes. It
+-- cannot run inside a transaction, so this file holds a single statement.
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_staged_objects_batch
+    ON staged_objects (batch_id, created_at)
+    WHERE batch_id IS NOT NULL;
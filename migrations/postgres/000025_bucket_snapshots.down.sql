@@ -0,0 +1,6 @@
+-- Alexander Storage Database Schema
+-- Migration: 000025_bucket_snapshots
+-- Description: Rollback - Remove bucket snapshots
+
+DROP TABLE IF EXISTS bucket_snapshot_objects;
+DROP TABLE IF EXISTS bucket_snapshots;
@@ -0,0 +1,48 @@
+-- Alexander Storage Database Schema
+-- Migration: 000025_bucket_snapshots
+-- Description: Read-only point-in-time snapshots of a bucket's latest versions
+
+SET lock_timeout = '5s';
+
+CREATE TABLE IF NOT EXISTS bucket_snapshots (
+    id                  BIGSERIAL PRIMARY KEY,
+    bucket_id           BIGINT NOT NULL,
+    name                VARCHAR(128) NOT NULL,
+    object_count        BIGINT NOT NULL DEFAULT 0,
+    total_size          BIGINT NOT NULL DEFAULT 0,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at          TIMESTAMPTZ,                    -- NULL keeps the snapshot
+
+    CONSTRAINT fk_bucket_snapshots_bucket FOREIGN KEY (bucket_id)
+        REFERENCES buckets(id) ON DELETE CASCADE,
+    CONSTRAINT uq_bucket_snapshots_name UNIQUE (bucket_id, name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_bucket_snapshots_expires ON bucket_snapshots (expires_at)
+    WHERE expires_at IS NOT NULL;
+
+-- Metadata of each object version in a snapshot. Each row holds a
+-- reference on its blob, released when the snapshot is deleted.
+CREATE TABLE IF NOT EXISTS bucket_snapshot_objects (
+    snapshot_id         BIGINT NOT NULL,
+    key                 VARCHAR(1024) NOT NULL,
+    version_id          UUID NOT NULL,
+    content_hash        CHAR(64),
+    size                BIGINT NOT NULL DEFAULT 0,
+    content_type        VARCHAR(255) NOT NULL DEFAULT 'application/octet-stream',
+    etag                VARCHAR(64),
+    storage_class       storage_class NOT NULL DEFAULT 'STANDARD',
+    metadata            JSONB DEFAULT '{}',
+    part_sizes          BIGINT[],
+    sequence            BIGINT NOT NULL DEFAULT 0,
+    created_at          TIMESTAMPTZ NOT NULL,           -- Of the object version
+
+    CONSTRAINT pk_bucket_snapshot_objects PRIMARY KEY (snapshot_id, key),
+    CONSTRAINT fk_bucket_snapshot_objects_snapshot FOREIGN KEY (snapshot_id)
+        REFERENCES bucket_snapshots(id) ON DELETE CASCADE,
+    CONSTRAINT fk_bucket_snapshot_objects_blob FOREIGN KEY (content_hash)
+        REFERENCES blobs(content_hash) ON DELETE RESTRICT
+);
+
+CREATE INDEX IF NOT EXISTS idx_bucket_snapshot_objects_blob ON bucket_snapshot_objects (content_hash)
+    WHERE content_hash IS NOT NULL;
@@ -0,0 +1,5 @@
+-- Alexander Storage Database Schema
+-- Migration: 000026_gc_cursor
+-- Description: Rollback - Remove the garbage collection cursor
+
+DROP TABLE IF EXISTS gc_cursor;
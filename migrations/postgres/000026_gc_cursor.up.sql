@@ -0,0 +1,9 @@
+-- Alexander Storage Database Schema
+-- Migration: 000026_gc_cursor
+-- Description: Position of garbage collection in its walk over orphan blobs, so interrupted runs resume
+
+CREATE TABLE IF NOT EXISTS gc_cursor (
+    id              SMALLINT PRIMARY KEY CHECK (id = 1),    -- Single row
+    after_hash      VARCHAR(64) NOT NULL DEFAULT '',        -- Content hash the next run starts after
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
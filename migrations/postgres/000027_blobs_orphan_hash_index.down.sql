@@ -0,0 +1,5 @@
+-- Alexander Storage Database Schema
+-- Migration: 000027_blobs_orphan_hash_index
+-- Description: Rollback - Remove the orphan blob hash index
+
+DROP INDEX CONCURRENTLY IF EXISTS idx_blobs_orphan_hash;
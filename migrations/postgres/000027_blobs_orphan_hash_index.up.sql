@@ -0,0 +1,10 @@
+-- Alexander Storage Database Schema
+-- Migration: 000027_blobs_orphan_hash_index
+-- Description: Index for walking orphan blobs in content hash order
+--
+-- Online change: CONCURRENTLY builds the index without blocking writes. It
+-- cannot run inside a transaction, so this file holds a single statement.
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_blobs_orphan_hash
+    ON blobs (content_hash)
+    WHERE ref_count <= 0;
@@ -0,0 +1,5 @@
+-- Alexander Storage Database Schema
+-- Migration: 000028_bucket_inventory
+-- Description: Rollback - Remove bucket inventory configurations
+
+DROP TABLE IF EXISTS bucket_inventory_configs;
@@ -0,0 +1,25 @@
+-- Alexander Storage Database Schema
+-- Migration: 000028_bucket_inventory
+-- Description: Scheduled inventory report configurations per bucket
+
+SET lock_timeout = '5s';
+
+CREATE TABLE IF NOT EXISTS bucket_inventory_configs (
+    bucket_id           BIGINT NOT NULL,
+    config_id           VARCHAR(64) NOT NULL,
+    enabled             BOOLEAN NOT NULL DEFAULT TRUE,
+    destination_bucket  VARCHAR(63) NOT NULL,
+    destination_prefix  VARCHAR(512) NOT NULL DEFAULT '',
+    format              VARCHAR(16) NOT NULL,           -- CSV or Parquet
+    frequency           VARCHAR(16) NOT NULL,           -- Daily or Weekly
+    prefix              VARCHAR(1024) NOT NULL DEFAULT '',
+    all_versions        BOOLEAN NOT NULL DEFAULT FALSE,
+    fields              JSONB NOT NULL DEFAULT '[]',
+    last_run_at         TIMESTAMPTZ,                    -- NULL if never run
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT pk_bucket_inventory_configs PRIMARY KEY (bucket_id, config_id),
+    CONSTRAINT fk_bucket_inventory_configs_bucket FOREIGN KEY (bucket_id)
+        REFERENCES buckets(id) ON DELETE CASCADE
+);
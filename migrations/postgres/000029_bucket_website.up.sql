@@ -0,0 +1,16 @@
+-- Alexander Storage Database Schema
+-- Migration: 000029_bucket_website
+-- Description: Static website configurations per bucket
+
+SET lock_timeout = '5s';
+
+CREATE TABLE IF NOT EXISTS bucket_websites (
+    bucket_id           BIGINT NOT NULL,
+    config              JSONB NOT NULL,                 -- index/error documents, redirects and routing rules
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT pk_bucket_websites PRIMARY KEY (bucket_id),
+    CONSTRAINT fk_bucket_websites_bucket FOREIGN KEY (bucket_id)
+        REFERENCES buckets(id) ON DELETE CASCADE
+);
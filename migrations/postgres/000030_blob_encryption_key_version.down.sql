@@ -0,0 +1,5 @@
+-- Alexander Storage Database Schema
+-- Migration: 000030_blob_encryption_key_version
+-- Description: Rollback - Remove the encryption key version from blobs
+
+ALTER TABLE blobs DROP COLUMN IF EXISTS encryption_key_version;
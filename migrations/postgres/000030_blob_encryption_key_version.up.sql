@@ -0,0 +1,9 @@
+-- Alexander Storage Database Schema
+-- Migration: 000030_blob_encryption_key_version
+-- Description: Record which version of the SSE master key encrypted a blob
+
+SET lock_timeout = '5s';
+
+-- Blobs encrypted before keys had versions used version 1
+ALTER TABLE blobs
+ADD COLUMN IF NOT EXISTS encryption_key_version INTEGER NOT NULL DEFAULT 1;
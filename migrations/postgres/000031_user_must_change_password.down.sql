@@ -0,0 +1,5 @@
+-- Alexander Storage Database Schema
+-- Migration: 000031_user_must_change_password
+-- Description: Rollback - Remove the forced password change flag from users
+
+ALTER TABLE users DROP COLUMN IF EXISTS must_change_password;
@@ -0,0 +1,8 @@
+-- Alexander Storage Database Schema
+-- Migration: 000031_user_must_change_password
+-- Description: Force users to choose a new password at their next login
+
+SET lock_timeout = '5s';
+
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS must_change_password BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,11 @@
+-- Alexander Storage Database Schema
+-- Migration: 000032_session_hardening
+-- Description: Rollback - Remove CSRF, idle and remember-me columns from sessions
+
+DROP INDEX IF EXISTS idx_sessions_remember_token;
+
+ALTER TABLE sessions
+DROP COLUMN IF EXISTS remember_expires_at,
+DROP COLUMN IF EXISTS remember_token,
+DROP COLUMN IF EXISTS last_seen_at,
+DROP COLUMN IF EXISTS csrf_token;
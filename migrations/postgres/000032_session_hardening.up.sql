@@ -0,0 +1,13 @@
+-- Alexander Storage Database Schema
+-- Migration: 000032_session_hardening
+-- Description: Per-session CSRF tokens, idle expiration and remember-me tokens
+
+SET lock_timeout = '5s';
+
+ALTER TABLE sessions
+ADD COLUMN IF NOT EXISTS csrf_token VARCHAR(64) NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS last_seen_at TIMESTAMPTZ,
+ADD COLUMN IF NOT EXISTS remember_token VARCHAR(64),
+ADD COLUMN IF NOT EXISTS remember_expires_at TIMESTAMPTZ;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sessions_remember_token ON sessions (remember_token) WHERE remember_token IS NOT NULL;
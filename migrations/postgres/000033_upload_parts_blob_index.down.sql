@@ -0,0 +1,5 @@
+-- Alexander Storage Database Schema
+-- Migration: 000033_upload_parts_blob_index
+-- Description: Rollback - Remove the upload parts blob index
+
+DROP INDEX CONCURRENTLY IF EXISTS idx_upload_parts_content_hash;
@@ -0,0 +1,9 @@
+-- Alexander Storage Database Schema
+-- Migration: 000033_upload_parts_blob_index
+-- Description: Index for counting the blob references held by upload parts
+--
+-- Online change: CONCURRENTLY builds the index without blocking writes. It
+-- cannot run inside a transaction, so this file holds a single statement.
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_upload_parts_content_hash
+    ON upload_parts (content_hash);
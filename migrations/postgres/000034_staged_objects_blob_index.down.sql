@@ -0,0 +1,5 @@
+-- Alexander Storage Database Schema
+-- Migration: 000034_staged_objects_blob_index
+-- Description: Rollback - Remove the staged objects blob index
+
+DROP INDEX CONCURRENTLY IF EXISTS idx_staged_objects_content_hash;
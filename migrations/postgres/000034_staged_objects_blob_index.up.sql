@@ -0,0 +1,9 @@
+-- Alexander Storage Database Schema
+-- Migration: 000034_staged_objects_blob_index
+-- Description: Index for counting the blob references held by staged objects
+--
+-- Online change: CONCURRENTLY builds the index without blocking writes. It
+-- cannot run inside a transaction, so this file holds a single statement.
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_staged_objects_content_hash
+    ON staged_objects (content_hash);
@@ -0,0 +1,5 @@
+-- Alexander Storage Database Schema
+-- Migration: 000035_objects_deleted_index
+-- Description: Rollback - Remove the soft-deleted objects index
+
+DROP INDEX CONCURRENTLY IF EXISTS idx_objects_deleted;
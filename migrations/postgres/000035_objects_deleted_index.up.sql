@@ -0,0 +1,9 @@
+-- Alexander Storage Database Schema
+-- Migration: 000035_objects_deleted_index
+-- Description: Index for purging soft-deleted object versions
+--
+-- Online change: CONCURRENTLY builds the index without blocking writes. It
+-- cannot run inside a transaction, so this file holds a single statement.
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_objects_deleted
+    ON objects (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,7 @@
+-- Alexander Storage Database Schema
+-- Migration: 000036_object_acl
+-- Description: Rollback - Remove object ACLs
+
+ALTER TABLE multipart_uploads DROP COLUMN IF EXISTS acl;
+ALTER TABLE staged_objects DROP COLUMN IF EXISTS acl;
+ALTER TABLE objects DROP COLUMN IF EXISTS acl;
@@ -0,0 +1,15 @@
+-- Alexander Storage Database Schema
+-- Migration: 000036_object_acl
+-- Description: Canned ACL of each object version, carried by staged and
+-- multipart uploads to the version they become
+
+SET lock_timeout = '5s';
+
+ALTER TABLE objects
+ADD COLUMN IF NOT EXISTS acl VARCHAR(32) NOT NULL DEFAULT 'private';
+
+ALTER TABLE staged_objects
+ADD COLUMN IF NOT EXISTS acl VARCHAR(32) NOT NULL DEFAULT 'private';
+
+ALTER TABLE multipart_uploads
+ADD COLUMN IF NOT EXISTS acl VARCHAR(32) NOT NULL DEFAULT 'private';
@@ -0,0 +1,5 @@
+-- Alexander Storage Database Schema
+-- Migration: 000037_bucket_tags
+-- Description: Rollback - Remove bucket tags
+
+ALTER TABLE buckets DROP COLUMN IF EXISTS tags;
@@ -0,0 +1,8 @@
+-- Alexander Storage Database Schema
+-- Migration: 000037_bucket_tags
+-- Description: Tag set of each bucket
+
+SET lock_timeout = '5s';
+
+ALTER TABLE buckets
+ADD COLUMN IF NOT EXISTS tags JSONB;                    -- array of {key, value}; NULL if untagged
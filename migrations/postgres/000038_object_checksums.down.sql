@@ -0,0 +1,8 @@
+-- Alexander Storage Database Schema
+-- Migration: 000038_object_checksums
+-- Description: Rollback - Remove object checksums
+
+ALTER TABLE upload_parts DROP COLUMN IF EXISTS checksum;
+ALTER TABLE multipart_uploads DROP COLUMN IF EXISTS checksum_algorithm;
+ALTER TABLE staged_objects DROP COLUMN IF EXISTS checksum, DROP COLUMN IF EXISTS checksum_algorithm;
+ALTER TABLE objects DROP COLUMN IF EXISTS checksum, DROP COLUMN IF EXISTS checksum_algorithm;
@@ -0,0 +1,20 @@
+-- Alexander Storage Database Schema
+-- Migration: 000038_object_checksums
+-- Description: Additional checksums (x-amz-checksum-*) of object versions,
+-- carried by staged uploads, and of multipart uploads and their parts
+
+SET lock_timeout = '5s';
+
+ALTER TABLE objects
+ADD COLUMN IF NOT EXISTS checksum_algorithm VARCHAR(16) NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS checksum TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE staged_objects
+ADD COLUMN IF NOT EXISTS checksum_algorithm VARCHAR(16) NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS checksum TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE multipart_uploads
+ADD COLUMN IF NOT EXISTS checksum_algorithm VARCHAR(16) NOT NULL DEFAULT '';
+
+ALTER TABLE upload_parts
+ADD COLUMN IF NOT EXISTS checksum TEXT NOT NULL DEFAULT '';
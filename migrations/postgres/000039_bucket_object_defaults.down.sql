@@ -0,0 +1,5 @@
+-- Alexander Storage Database Schema
+-- Migration: 000039_bucket_object_defaults
+-- Description: Rollback - Remove bucket object defaults
+
+ALTER TABLE buckets DROP COLUMN IF EXISTS object_defaults;
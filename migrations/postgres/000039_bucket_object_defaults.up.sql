@@ -0,0 +1,8 @@
+-- Alexander Storage Database Schema
+-- Migration: 000039_bucket_object_defaults
+-- Description: Settings given to uploads that do not specify them
+
+SET lock_timeout = '5s';
+
+ALTER TABLE buckets
+ADD COLUMN IF NOT EXISTS object_defaults JSONB;         -- NULL if the bucket has none
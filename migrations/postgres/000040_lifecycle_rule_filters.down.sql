@@ -0,0 +1,12 @@
+-- Alexander Storage Database Schema
+-- Migration: 000040_lifecycle_rule_filters
+-- Description: Rollback - Remove lifecycle rule filters and actions
+
+ALTER TABLE lifecycle_rules
+DROP COLUMN IF EXISTS abort_incomplete_upload_days,
+DROP COLUMN IF EXISTS noncurrent_days,
+DROP COLUMN IF EXISTS expired_object_delete_marker,
+DROP COLUMN IF EXISTS expiration_date,
+DROP COLUMN IF EXISTS object_size_less_than,
+DROP COLUMN IF EXISTS object_size_greater_than,
+DROP COLUMN IF EXISTS filter_tags;
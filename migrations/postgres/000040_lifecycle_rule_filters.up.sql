@@ -0,0 +1,14 @@
+-- Alexander Storage Database Schema
+-- Migration: 000040_lifecycle_rule_filters
+-- Description: Tag and object size filters and further actions for lifecycle rules
+
+SET lock_timeout = '5s';
+
+ALTER TABLE lifecycle_rules
+ADD COLUMN IF NOT EXISTS filter_tags JSONB,                             -- NULL if none
+ADD COLUMN IF NOT EXISTS object_size_greater_than BIGINT,
+ADD COLUMN IF NOT EXISTS object_size_less_than BIGINT,
+ADD COLUMN IF NOT EXISTS expiration_date TIMESTAMPTZ,                   -- Midnight UTC
+ADD COLUMN IF NOT EXISTS expired_object_delete_marker BOOLEAN NOT NULL DEFAULT FALSE,
+ADD COLUMN IF NOT EXISTS noncurrent_days INTEGER,
+ADD COLUMN IF NOT EXISTS abort_incomplete_upload_days INTEGER;
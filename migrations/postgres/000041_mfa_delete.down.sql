@@ -0,0 +1,7 @@
+-- Alexander Storage Database Schema
+-- Migration: 000041_mfa_delete
+-- Description: Rollback - Remove MFA devices and MFA delete protection
+
+ALTER TABLE buckets DROP COLUMN IF EXISTS mfa_delete;
+
+DROP TABLE IF EXISTS mfa_devices;
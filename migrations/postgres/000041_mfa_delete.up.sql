@@ -0,0 +1,20 @@
+-- Alexander Storage Database Schema
+-- Migration: 000041_mfa_delete
+-- Description: MFA devices of users and MFA delete protection of buckets
+
+SET lock_timeout = '5s';
+
+CREATE TABLE IF NOT EXISTS mfa_devices (
+    user_id             BIGINT NOT NULL,
+    serial_number       VARCHAR(512) NOT NULL,
+    encrypted_secret    TEXT NOT NULL,                  -- AES-256-GCM encrypted TOTP secret
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT pk_mfa_devices PRIMARY KEY (user_id),
+    CONSTRAINT uq_mfa_devices_serial_number UNIQUE (serial_number),
+    CONSTRAINT fk_mfa_devices_user FOREIGN KEY (user_id)
+        REFERENCES users(id) ON DELETE CASCADE
+);
+
+ALTER TABLE buckets
+ADD COLUMN IF NOT EXISTS mfa_delete BOOLEAN NOT NULL DEFAULT FALSE;
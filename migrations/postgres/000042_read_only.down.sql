@@ -0,0 +1,7 @@
+-- Alexander Storage Database Schema
+-- Migration: 000042_read_only
+-- Description: Rollback - Remove read-only mode
+
+ALTER TABLE buckets DROP COLUMN IF EXISTS read_only;
+
+DROP TABLE IF EXISTS read_only_mode;
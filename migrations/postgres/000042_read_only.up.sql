@@ -0,0 +1,14 @@
+-- Alexander Storage Database Schema
+-- Migration: 000042_read_only
+-- Description: Read-only mode of buckets and of the whole service, for freezing writes during migrations
+
+SET lock_timeout = '5s';
+
+CREATE TABLE IF NOT EXISTS read_only_mode (
+    id              SMALLINT PRIMARY KEY CHECK (id = 1),    -- Single row
+    enabled         BOOLEAN NOT NULL DEFAULT FALSE,         -- Rejects writes to every bucket
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE buckets
+ADD COLUMN IF NOT EXISTS read_only BOOLEAN NOT NULL DEFAULT FALSE;
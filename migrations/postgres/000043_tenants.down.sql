@@ -0,0 +1,14 @@
+-- Alexander Storage Database Schema
+-- Migration: 000043_tenants
+-- Description: Rollback - Remove tenants
+
+DROP INDEX IF EXISTS idx_buckets_tenant_id;
+DROP INDEX IF EXISTS idx_users_tenant_id;
+
+ALTER TABLE buckets DROP CONSTRAINT IF EXISTS fk_buckets_tenant;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS fk_users_tenant;
+
+ALTER TABLE buckets DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE users DROP COLUMN IF EXISTS tenant_id;
+
+DROP TABLE IF EXISTS tenants;
@@ -0,0 +1,32 @@
+-- Alexander Storage Database Schema
+-- Migration: 000043_tenants
+-- Description: Tenants with quotas and rate limits; existing users and buckets join the default tenant
+
+SET lock_timeout = '5s';
+
+CREATE TABLE IF NOT EXISTS tenants (
+    id                  BIGSERIAL PRIMARY KEY,
+    name                VARCHAR(32) NOT NULL,
+    max_buckets         INTEGER NOT NULL DEFAULT 0,             -- 0 = unlimited
+    max_storage_bytes   BIGINT NOT NULL DEFAULT 0,              -- 0 = unlimited
+    requests_per_second DOUBLE PRECISION NOT NULL DEFAULT 0,    -- 0 = unlimited
+    burst_size          INTEGER NOT NULL DEFAULT 0,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT uq_tenants_name UNIQUE (name)
+);
+
+INSERT INTO tenants (id, name) VALUES (1, 'default') ON CONFLICT (id) DO NOTHING;
+SELECT setval(pg_get_serial_sequence('tenants', 'id'), GREATEST((SELECT MAX(id) FROM tenants), 1));
+
+-- A constant default does not rewrite the tables. The foreign keys to
+-- tenants are added and validated by the tenant_foreign_keys backfill.
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS tenant_id BIGINT NOT NULL DEFAULT 1;
+
+ALTER TABLE buckets
+ADD COLUMN IF NOT EXISTS tenant_id BIGINT NOT NULL DEFAULT 1;
+
+CREATE INDEX IF NOT EXISTS idx_users_tenant_id ON users (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_buckets_tenant_id ON buckets (tenant_id);
@@ -0,0 +1,5 @@
+-- Alexander Storage Database Schema
+-- Migration: 000044_bucket_quotas
+-- Description: Rollback - Remove bucket storage quotas
+
+ALTER TABLE buckets DROP COLUMN IF EXISTS quota_bytes;
@@ -0,0 +1,8 @@
+-- Alexander Storage Database Schema
+-- Migration: 000044_bucket_quotas
+-- Description: Storage quotas of buckets
+
+SET lock_timeout = '5s';
+
+ALTER TABLE buckets
+ADD COLUMN IF NOT EXISTS quota_bytes BIGINT NOT NULL DEFAULT 0;    -- 0 = unlimited
@@ -0,0 +1,366 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// AdminPathPrefix is the path prefix of the admin API.
+const AdminPathPrefix = "/_alexander/admin/"
+
+// AdminClient is a client of the admin API, which manages users, access keys
+// and buckets, and runs garbage collection. Requests carry the bearer token
+// of Config.AdminToken.
+type AdminClient struct {
+	c *Client
+}
+
+// Admin returns the client of the admin API.
+func (c *Client) Admin() *AdminClient {
+	return &AdminClient{c: c}
+}
+
+// User is a user account.
+type User struct {
+	ID                 int64     `json:"id"`
+	Username           string    `json:"username"`
+	Email              string    `json:"email"`
+	IsActive           bool      `json:"is_active"`
+	Role               string    `json:"role"` // "", "read-only", "operator" or "admin"
+	MustChangePassword bool      `json:"must_change_password"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// UserList is a page of users.
+type UserList struct {
+	Users      []*User `json:"users"`
+	TotalCount int64   `json:"total_count"`
+}
+
+// CreateUserRequest describes a user to create.
+type CreateUserRequest struct {
+	Username           string `json:"username"`
+	Email              string `json:"email"`
+	Password           string `json:"password"`
+	Role               string `json:"role,omitempty"`
+	MustChangePassword bool   `json:"must_change_password,omitempty"`
+}
+
+// UpdateUserRequest changes a user. Nil fields are left unchanged.
+type UpdateUserRequest struct {
+	Role     *string `json:"role,omitempty"`
+	IsActive *bool   `json:"is_active,omitempty"`
+}
+
+// AccessKey is an access key, without its secret.
+type AccessKey struct {
+	ID          int64      `json:"id"`
+	UserID      int64      `json:"user_id"`
+	AccessKeyID string     `json:"access_key_id"`
+	Description string     `json:"description,omitempty"`
+	Status      string     `json:"status"` // "Active" or "Inactive"
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+
+	// Policy is the IAM-style policy restricting the key, if any.
+	Policy json.RawMessage `json:"policy,omitempty"`
+}
+
+// CreatedAccessKey is a new access key with its secret, which the server
+// does not return again.
+type CreatedAccessKey struct {
+	AccessKey
+	SecretKey string `json:"secret_key"`
+}
+
+// CreateAccessKeyRequest describes an access key to create.
+type CreateAccessKeyRequest struct {
+	Description string          `json:"description,omitempty"`
+	ExpiresAt   *time.Time      `json:"expires_at,omitempty"`
+	Policy      json.RawMessage `json:"policy,omitempty"`
+}
+
+// Bucket is a bucket.
+type Bucket struct {
+	ID         int64     `json:"id"`
+	OwnerID    int64     `json:"owner_id"`
+	Name       string    `json:"name"`
+	Region     string    `json:"region"`
+	Versioning string    `json:"versioning"`
+	ACL        string    `json:"acl"`
+	ObjectLock bool      `json:"object_lock"`
+	CreatedAt  time.Time `json:"created_at"`
+	Residency  string    `json:"residency,omitempty"`
+	Quarantine bool      `json:"quarantine,omitempty"`
+}
+
+// CreateBucketRequest describes a bucket to create.
+type CreateBucketRequest struct {
+	Name      string `json:"name"`
+	OwnerID   int64  `json:"owner_id"`
+	Region    string `json:"region,omitempty"`
+	Residency string `json:"residency,omitempty"`
+}
+
+// UpdateBucketRequest changes a bucket. Nil fields are left unchanged.
+type UpdateBucketRequest struct {
+	Versioning *string `json:"versioning,omitempty"` // "Enabled" or "Suspended"
+	Quarantine *bool   `json:"quarantine,omitempty"`
+}
+
+// GCStatus describes the blobs garbage collection would delete.
+type GCStatus struct {
+	OrphanBlobs      int    `json:"orphan_blobs"`
+	OrphanBytes      int64  `json:"orphan_bytes"`
+	HasMoreOrphans   bool   `json:"has_more_orphans"`
+	GracePeriod      string `json:"grace_period"`
+	Interval         string `json:"interval"`
+	CollectorEnabled bool   `json:"collector_enabled"`
+}
+
+// GCResult is the result of a garbage collection pass.
+type GCResult struct {
+	BlobsDeleted         int     `json:"blobs_deleted"`
+	BytesFreed           int64   `json:"bytes_freed"`
+	Errors               int     `json:"errors"`
+	Resumed              int     `json:"resumed"`
+	Skipped              int     `json:"skipped"`
+	OrphanBlobsRemaining int     `json:"orphan_blobs_remaining"`
+	DurationSeconds      float64 `json:"duration_seconds"`
+}
+
+// Stats are storage statistics.
+type Stats struct {
+	Blobs           int64   `json:"blobs"`
+	OrphanBlobs     int64   `json:"orphan_blobs"`
+	StoredBytes     int64   `json:"stored_bytes"`
+	ReferencedBytes int64   `json:"referenced_bytes"`
+	DedupRatio      float64 `json:"dedup_ratio"`
+	Buckets         int     `json:"buckets"`
+	Users           int64   `json:"users"`
+}
+
+// =============================================================================
+// Users
+// =============================================================================
+
+// ListUsers returns a page of users. A zero limit uses the server's default.
+func (a *AdminClient) ListUsers(ctx context.Context, limit, offset int) (*UserList, error) {
+	query := url.Values{}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	if offset > 0 {
+		query.Set("offset", strconv.Itoa(offset))
+	}
+	var list UserList
+	if err := a.call(ctx, http.MethodGet, withQuery(a.url("users"), query), nil, &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// CreateUser creates a user.
+func (a *AdminClient) CreateUser(ctx context.Context, req CreateUserRequest) (*User, error) {
+	var user User
+	if err := a.call(ctx, http.MethodPost, a.url("users"), req, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetUser returns a user.
+func (a *AdminClient) GetUser(ctx context.Context, id int64) (*User, error) {
+	var user User
+	if err := a.call(ctx, http.MethodGet, a.url("users", strconv.FormatInt(id, 10)), nil, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UpdateUser changes the role or active state of a user and returns it.
+func (a *AdminClient) UpdateUser(ctx context.Context, id int64, req UpdateUserRequest) (*User, error) {
+	var user User
+	if err := a.call(ctx, http.MethodPatch, a.url("users", strconv.FormatInt(id, 10)), req, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// DeleteUser deletes a user.
+func (a *AdminClient) DeleteUser(ctx context.Context, id int64) error {
+	return a.call(ctx, http.MethodDelete, a.url("users", strconv.FormatInt(id, 10)), nil, nil)
+}
+
+// =============================================================================
+// Access Keys
+// =============================================================================
+
+// ListAccessKeys returns the access keys of a user, or only the active ones.
+func (a *AdminClient) ListAccessKeys(ctx context.Context, userID int64, activeOnly bool) ([]*AccessKey, error) {
+	query := url.Values{}
+	if activeOnly {
+		query.Set("active", "true")
+	}
+	var result struct {
+		AccessKeys []*AccessKey `json:"access_keys"`
+	}
+	if err := a.call(ctx, http.MethodGet, withQuery(a.url("users", strconv.FormatInt(userID, 10), "keys"), query), nil, &result); err != nil {
+		return nil, err
+	}
+	return result.AccessKeys, nil
+}
+
+// CreateAccessKey creates an access key of a user.
+func (a *AdminClient) CreateAccessKey(ctx context.Context, userID int64, req CreateAccessKeyRequest) (*CreatedAccessKey, error) {
+	var key CreatedAccessKey
+	if err := a.call(ctx, http.MethodPost, a.url("users", strconv.FormatInt(userID, 10), "keys"), req, &key); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// GetAccessKey returns an access key.
+func (a *AdminClient) GetAccessKey(ctx context.Context, accessKeyID string) (*AccessKey, error) {
+	var key AccessKey
+	if err := a.call(ctx, http.MethodGet, a.url("keys", accessKeyID), nil, &key); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// SetAccessKeyStatus activates ("Active") or deactivates ("Inactive") an
+// access key and returns it.
+func (a *AdminClient) SetAccessKeyStatus(ctx context.Context, accessKeyID, status string) (*AccessKey, error) {
+	var key AccessKey
+	body := map[string]string{"status": status}
+	if err := a.call(ctx, http.MethodPatch, a.url("keys", accessKeyID), body, &key); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// DeleteAccessKey deletes an access key.
+func (a *AdminClient) DeleteAccessKey(ctx context.Context, accessKeyID string) error {
+	return a.call(ctx, http.MethodDelete, a.url("keys", accessKeyID), nil, nil)
+}
+
+// =============================================================================
+// Buckets
+// =============================================================================
+
+// ListBuckets returns all buckets, or those of an owner if ownerID is not
+// zero.
+func (a *AdminClient) ListBuckets(ctx context.Context, ownerID int64) ([]*Bucket, error) {
+	query := url.Values{}
+	if ownerID != 0 {
+		query.Set("owner_id", strconv.FormatInt(ownerID, 10))
+	}
+	var result struct {
+		Buckets []*Bucket `json:"buckets"`
+	}
+	if err := a.call(ctx, http.MethodGet, withQuery(a.url("buckets"), query), nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Buckets, nil
+}
+
+// CreateBucket creates a bucket for an existing user.
+func (a *AdminClient) CreateBucket(ctx context.Context, req CreateBucketRequest) (*Bucket, error) {
+	var bucket Bucket
+	if err := a.call(ctx, http.MethodPost, a.url("buckets"), req, &bucket); err != nil {
+		return nil, err
+	}
+	return &bucket, nil
+}
+
+// GetBucket returns a bucket.
+func (a *AdminClient) GetBucket(ctx context.Context, name string) (*Bucket, error) {
+	var bucket Bucket
+	if err := a.call(ctx, http.MethodGet, a.url("buckets", name), nil, &bucket); err != nil {
+		return nil, err
+	}
+	return &bucket, nil
+}
+
+// UpdateBucket changes the versioning or quarantine of a bucket and returns
+// it.
+func (a *AdminClient) UpdateBucket(ctx context.Context, name string, req UpdateBucketRequest) (*Bucket, error) {
+	var bucket Bucket
+	if err := a.call(ctx, http.MethodPatch, a.url("buckets", name), req, &bucket); err != nil {
+		return nil, err
+	}
+	return &bucket, nil
+}
+
+// DeleteBucket deletes an empty bucket.
+func (a *AdminClient) DeleteBucket(ctx context.Context, name string) error {
+	return a.call(ctx, http.MethodDelete, a.url("buckets", name), nil, nil)
+}
+
+// =============================================================================
+// Garbage Collection and Statistics
+// =============================================================================
+
+// GCStatus returns the blobs the next garbage collection pass would delete.
+func (a *AdminClient) GCStatus(ctx context.Context) (*GCStatus, error) {
+	var status GCStatus
+	if err := a.call(ctx, http.MethodGet, a.url("gc"), nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// RunGC runs a garbage collection pass and waits for it to finish.
+func (a *AdminClient) RunGC(ctx context.Context) (*GCResult, error) {
+	var result GCResult
+	if err := a.call(ctx, http.MethodPost, a.url("gc"), nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Stats returns storage statistics.
+func (a *AdminClient) Stats(ctx context.Context) (*Stats, error) {
+	var stats Stats
+	if err := a.call(ctx, http.MethodGet, a.url("stats"), nil, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// =============================================================================
+// Helpers
+// =============================================================================
+
+// call sends an admin API request with the bearer token.
+func (a *AdminClient) call(ctx context.Context, method, u string, body, result any) error {
+	return a.c.call(ctx, jsonRequest{
+		method: method,
+		url:    u,
+		body:   body,
+		authorize: func(_ context.Context, r *http.Request, _ string) error {
+			r.Header.Set("Authorization", "Bearer "+a.c.config.AdminToken)
+			return nil
+		},
+	}, result)
+}
+
+// url returns the URL of the admin API path made of segments, which are
+// escaped.
+func (a *AdminClient) url(segments ...string) string {
+	u := a.c.config.AdminEndpoint + AdminPathPrefix
+	for i, segment := range segments {
+		if i > 0 {
+			u += "/"
+		}
+		u += url.PathEscape(segment)
+	}
+	return u
+}
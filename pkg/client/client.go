@@ -0,0 +1,262 @@
+// Package client is a Go client for Alexander Storage. It gives an S3 client
+// of the AWS SDK configured for the server, and covers the APIs only
+// Alexander has: the admin API, usage reports and resumable uploads.
+//
+//	c := client.New(client.Config{
+//		Endpoint:        "https://s3.example.com",
+//		AccessKeyID:     id,
+//		SecretAccessKey: secret,
+//		AdminToken:      token, // only for Admin
+//	})
+//	buckets, err := c.S3().ListBuckets(ctx, &s3.ListBucketsInput{})
+//	link, err := c.PresignGetObject(ctx, "photos", "cat.jpg", time.Hour)
+//	report, err := c.Usage(ctx, client.UsageQuery{Month: "2024-06"})
+//	user, err := c.Admin().CreateUser(ctx, client.CreateUserRequest{Username: "ci", ...})
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/prn-tf/alexander-storage/pkg/resumable"
+)
+
+// maxResponseSize bounds the JSON responses read.
+const maxResponseSize = 16 << 20
+
+// Config configures a Client.
+type Config struct {
+	// Endpoint is the base URL of the S3 API, such as "https://s3.example.com".
+	Endpoint string
+
+	// Region is the signing region. Default: us-east-1.
+	Region string
+
+	// AccessKeyID and SecretAccessKey sign S3 requests, presigned URLs and
+	// usage report requests.
+	AccessKeyID     string
+	SecretAccessKey string
+
+	// AdminEndpoint is the base URL of the admin API when the server serves
+	// it on its own port. Default: Endpoint.
+	AdminEndpoint string
+
+	// AdminToken is the bearer token of the admin API.
+	AdminToken string
+
+	// ChunkSize and Retries configure resumable uploads; see
+	// resumable.Config.
+	ChunkSize int64
+	Retries   int
+
+	// HTTPClient is used for all requests. Default: http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// Client is a client of an Alexander Storage server. It is safe for
+// concurrent use.
+type Client struct {
+	config   Config
+	creds    aws.Credentials
+	signer   *v4.Signer
+	s3       *s3.Client
+	presign  *s3.PresignClient
+	uploader *resumable.Uploader
+}
+
+// Error is an error response of the admin or usage API.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (%d): %s", http.StatusText(e.StatusCode), e.StatusCode, e.Message)
+}
+
+// New returns a Client for config.
+func New(config Config) *Client {
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = http.DefaultClient
+	}
+	config.Endpoint = strings.TrimSuffix(config.Endpoint, "/")
+	config.AdminEndpoint = strings.TrimSuffix(config.AdminEndpoint, "/")
+	if config.AdminEndpoint == "" {
+		config.AdminEndpoint = config.Endpoint
+	}
+
+	s3Client := s3.New(s3.Options{
+		Region:       config.Region,
+		BaseEndpoint: aws.String(config.Endpoint),
+		Credentials:  credentials.NewStaticCredentialsProvider(config.AccessKeyID, config.SecretAccessKey, ""),
+		UsePathStyle: true,
+		HTTPClient:   config.HTTPClient,
+		// The server does not accept the aws-chunked uploads that trailing
+		// checksums need
+		RequestChecksumCalculation: aws.RequestChecksumCalculationWhenRequired,
+	})
+
+	return &Client{
+		config:  config,
+		creds:   aws.Credentials{AccessKeyID: config.AccessKeyID, SecretAccessKey: config.SecretAccessKey},
+		signer:  v4.NewSigner(),
+		s3:      s3Client,
+		presign: s3.NewPresignClient(s3Client),
+		uploader: resumable.NewUploader(resumable.Config{
+			Endpoint:        config.Endpoint,
+			Region:          config.Region,
+			AccessKeyID:     config.AccessKeyID,
+			SecretAccessKey: config.SecretAccessKey,
+			ChunkSize:       config.ChunkSize,
+			Retries:         config.Retries,
+			HTTPClient:      config.HTTPClient,
+		}),
+	}
+}
+
+// S3 returns the S3 client, which uses path-style bucket addressing.
+func (c *Client) S3() *s3.Client {
+	return c.s3
+}
+
+// Uploader returns the client of resumable uploads.
+func (c *Client) Uploader() *resumable.Uploader {
+	return c.uploader
+}
+
+// PresignGetObject returns a URL that downloads an object without
+// credentials until it expires. A zero expiry means 15 minutes.
+func (c *Client) PresignGetObject(ctx context.Context, bucket, key string, expires time.Duration) (string, error) {
+	req, err := c.presign.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}, presignExpires(expires))
+	if err != nil {
+		return "", err
+	}
+	return req.URL, nil
+}
+
+// PresignPutObject returns a URL that uploads an object without credentials
+// until it expires. A zero expiry means 15 minutes.
+func (c *Client) PresignPutObject(ctx context.Context, bucket, key string, expires time.Duration) (string, error) {
+	req, err := c.presign.PresignPutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}, presignExpires(expires))
+	if err != nil {
+		return "", err
+	}
+	return req.URL, nil
+}
+
+// presignExpires sets the expiry of a presigned URL, if not zero.
+func presignExpires(expires time.Duration) func(*s3.PresignOptions) {
+	return func(o *s3.PresignOptions) {
+		if expires > 0 {
+			o.Expires = expires
+		}
+	}
+}
+
+// =============================================================================
+// JSON APIs
+// =============================================================================
+
+// jsonRequest is a request to one of the JSON APIs.
+type jsonRequest struct {
+	method string
+	url    string
+	body   any // Encoded as JSON unless nil
+
+	// authorize adds credentials to the request, whose body hashes to
+	// payloadHash.
+	authorize func(ctx context.Context, r *http.Request, payloadHash string) error
+}
+
+// call sends a JSON API request and decodes the response into result unless
+// it is nil.
+func (c *Client) call(ctx context.Context, req jsonRequest, result any) error {
+	var body []byte
+	if req.body != nil {
+		var err error
+		if body, err = json.Marshal(req.body); err != nil {
+			return fmt.Errorf("encoding request: %w", err)
+		}
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, req.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	sum := sha256.Sum256(body)
+	if err := req.authorize(ctx, httpReq, hex.EncodeToString(sum[:])); err != nil {
+		return err
+	}
+
+	resp, err := c.config.HTTPClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		var body struct {
+			Error string `json:"error"`
+		}
+		_ = json.Unmarshal(data, &body)
+		return &Error{StatusCode: resp.StatusCode, Message: body.Error}
+	}
+	if result != nil {
+		if err := json.Unmarshal(data, result); err != nil {
+			return fmt.Errorf("decoding response: %w", err)
+		}
+	}
+	return nil
+}
+
+// signV4 signs a request with the access key, as the usage API requires.
+func (c *Client) signV4(ctx context.Context, r *http.Request, payloadHash string) error {
+	r.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if err := c.signer.SignHTTP(ctx, c.creds, r, payloadHash, "s3", c.config.Region, time.Now()); err != nil {
+		return fmt.Errorf("signing request: %w", err)
+	}
+	return nil
+}
+
+// withQuery returns u with the non-empty values of query.
+func withQuery(u string, query url.Values) string {
+	for name, values := range query {
+		if len(values) == 0 || values[0] == "" {
+			delete(query, name)
+		}
+	}
+	if len(query) == 0 {
+		return u
+	}
+	return u + "?" + query.Encode()
+}
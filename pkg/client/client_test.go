@@ -0,0 +1,124 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminClient_SendsTokenAndDecodes(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer admin-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			io.WriteString(w, `{"error":"invalid or missing bearer token"}`)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, r.Method+" "+r.URL.RequestURI()+" "+string(body))
+
+		switch r.Method + " " + r.URL.EscapedPath() {
+		case "POST /_alexander/admin/users":
+			w.WriteHeader(http.StatusCreated)
+			io.WriteString(w, `{"id":7,"username":"ci","role":"operator","is_active":true}`)
+		case "POST /_alexander/admin/users/7/keys":
+			w.WriteHeader(http.StatusCreated)
+			io.WriteString(w, `{"id":1,"user_id":7,"access_key_id":"AKID","status":"Active","secret_key":"s3cr3t"}`)
+		case "GET /_alexander/admin/buckets":
+			io.WriteString(w, `{"buckets":[{"name":"photos","owner_id":7,"versioning":"Enabled"}]}`)
+		case "DELETE /_alexander/admin/buckets/a%20b":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `{"error":"bucket not found"}`)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	admin := New(Config{Endpoint: "http://unused.invalid", AdminEndpoint: server.URL + "/", AdminToken: "admin-token"}).Admin()
+
+	user, err := admin.CreateUser(ctx, CreateUserRequest{Username: "ci", Email: "ci@example.com", Password: "password1", Role: "operator"})
+	require.NoError(t, err)
+	require.Equal(t, int64(7), user.ID)
+	require.Equal(t, "operator", user.Role)
+
+	key, err := admin.CreateAccessKey(ctx, user.ID, CreateAccessKeyRequest{Policy: json.RawMessage(`{"Statement":[]}`)})
+	require.NoError(t, err)
+	require.Equal(t, "AKID", key.AccessKeyID)
+	require.Equal(t, "s3cr3t", key.SecretKey)
+
+	buckets, err := admin.ListBuckets(ctx, 7)
+	require.NoError(t, err)
+	require.Len(t, buckets, 1)
+	require.Equal(t, "Enabled", buckets[0].Versioning)
+
+	require.NoError(t, admin.DeleteBucket(ctx, "a b"))
+
+	_, err = admin.GetBucket(ctx, "missing")
+	var apiErr *Error
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	require.Equal(t, "bucket not found", apiErr.Message)
+
+	require.Equal(t, []string{
+		`POST /_alexander/admin/users {"username":"ci","email":"ci@example.com","password":"password1","role":"operator"}`,
+		`POST /_alexander/admin/users/7/keys {"policy":{"Statement":[]}}`,
+		`GET /_alexander/admin/buckets?owner_id=7 `,
+		`DELETE /_alexander/admin/buckets/a%20b `,
+		`GET /_alexander/admin/buckets/missing `,
+	}, requests)
+
+	// Without the token
+	_, err = New(Config{Endpoint: server.URL}).Admin().Stats(ctx)
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+}
+
+func TestClient_Usage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, UsagePath, r.URL.Path)
+		require.Equal(t, url.Values{"month": {"2024-06"}, "bucket": {"photos"}}, r.URL.Query())
+		require.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/"))
+		require.NotEmpty(t, r.Header.Get("X-Amz-Content-Sha256"))
+		io.WriteString(w, `{"from":"2024-06-01T00:00:00Z","to":"2024-07-01T00:00:00Z","buckets":[
+			{"bucket_name":"photos","bytes_in":10,"access_keys":[{"access_key_id":"AKID","requests":3}]}]}`)
+	}))
+	t.Cleanup(server.Close)
+
+	c := New(Config{Endpoint: server.URL, AccessKeyID: "AKID", SecretAccessKey: "secret"})
+	report, err := c.Usage(context.Background(), UsageQuery{Month: "2024-06", Bucket: "photos"})
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC), report.To)
+	require.Len(t, report.Buckets, 1)
+	require.Equal(t, int64(10), report.Buckets[0].BytesIn)
+	require.Equal(t, int64(3), report.Buckets[0].AccessKeys[0].Requests)
+}
+
+func TestClient_Presign(t *testing.T) {
+	c := New(Config{Endpoint: "https://s3.example.com", AccessKeyID: "AKID", SecretAccessKey: "secret"})
+
+	link, err := c.PresignGetObject(context.Background(), "photos", "2024/cat.jpg", time.Hour)
+	require.NoError(t, err)
+	u, err := url.Parse(link)
+	require.NoError(t, err)
+	require.Equal(t, "s3.example.com", u.Host)
+	require.Equal(t, "/photos/2024/cat.jpg", u.Path)
+	require.Equal(t, "3600", u.Query().Get("X-Amz-Expires"))
+	require.NotEmpty(t, u.Query().Get("X-Amz-Signature"))
+
+	link, err = c.PresignPutObject(context.Background(), "photos", "cat.jpg", 0)
+	require.NoError(t, err)
+	u, err = url.Parse(link)
+	require.NoError(t, err)
+	require.Equal(t, "900", u.Query().Get("X-Amz-Expires"))
+	require.Equal(t, "host", u.Query().Get("X-Amz-SignedHeaders"))
+}
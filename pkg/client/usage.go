@@ -0,0 +1,68 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// UsagePath is the path of the usage report API.
+const UsagePath = "/admin/usage"
+
+// UsageQuery selects a usage report. The access key must belong to a user
+// with at least the read-only role.
+type UsageQuery struct {
+	// Month is the reporting period as "YYYY-MM".
+	Month string
+
+	// Bucket and AccessKeyID optionally restrict the report to a single
+	// bucket or access key.
+	Bucket      string
+	AccessKeyID string
+}
+
+// UsageReport is the usage of the buckets over a reporting period.
+type UsageReport struct {
+	From    time.Time     `json:"from"`
+	To      time.Time     `json:"to"`
+	Buckets []BucketUsage `json:"buckets"`
+}
+
+// BucketUsage is the usage of a single bucket.
+type BucketUsage struct {
+	BucketName         string           `json:"bucket_name"`
+	PeakBytesStored    int64            `json:"peak_bytes_stored"`
+	AverageBytesStored int64            `json:"average_bytes_stored"`
+	StorageByteHours   int64            `json:"storage_byte_hours"`
+	BytesIn            int64            `json:"bytes_in"`
+	BytesOut           int64            `json:"bytes_out"`
+	Requests           int64            `json:"requests"`
+	AccessKeys         []AccessKeyUsage `json:"access_keys"`
+}
+
+// AccessKeyUsage is the traffic of a single access key in a bucket.
+type AccessKeyUsage struct {
+	AccessKeyID string `json:"access_key_id"`
+	BytesIn     int64  `json:"bytes_in"`
+	BytesOut    int64  `json:"bytes_out"`
+	Requests    int64  `json:"requests"`
+}
+
+// Usage returns a usage report for billing.
+func (c *Client) Usage(ctx context.Context, query UsageQuery) (*UsageReport, error) {
+	var report UsageReport
+	err := c.call(ctx, jsonRequest{
+		method: http.MethodGet,
+		url: withQuery(c.config.Endpoint+UsagePath, url.Values{
+			"month":      {query.Month},
+			"bucket":     {query.Bucket},
+			"access_key": {query.AccessKeyID},
+		}),
+		authorize: c.signV4,
+	}, &report)
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
@@ -0,0 +1,382 @@
+// Package resumable uploads large objects to Alexander Storage in chunks, so
+// an upload interrupted by a dropped connection or a crash resumes where it
+// stopped instead of starting over.
+//
+// An upload is a multipart upload whose chunks are named by their SHA-256
+// hash. Upload lists the chunks the server already has, sends the others
+// with an x-alexander-chunk-sha256 header and commits the list of hashes with
+// an x-alexander-commit-chunks request, which assembles the object on the
+// server. Requests are signed with AWS Signature Version 4.
+//
+//	u := resumable.NewUploader(resumable.Config{Endpoint: "https://s3.example.com", AccessKeyID: id, SecretAccessKey: secret})
+//	uploadID, err := u.Start(ctx, "backups", "db.dump")
+//	// Keep uploadID; after a failure, call Upload again with it
+//	result, err := u.Upload(ctx, "backups", "db.dump", uploadID, file, size)
+package resumable
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+const (
+	// ChunkSHA256Header names the hex SHA-256 hash of a staged chunk.
+	ChunkSHA256Header = "x-alexander-chunk-sha256"
+
+	// CommitChunksHeader marks the request committing an upload.
+	CommitChunksHeader = "x-alexander-commit-chunks"
+
+	// DefaultChunkSize is the chunk size used unless configured otherwise.
+	DefaultChunkSize = 8 << 20
+
+	// maxResponseSize bounds the XML responses read.
+	maxResponseSize = 4 << 20
+)
+
+// Config configures an Uploader.
+type Config struct {
+	// Endpoint is the base URL of the server, such as "https://s3.example.com".
+	Endpoint string
+
+	// Region is the signing region. Default: us-east-1.
+	Region string
+
+	// AccessKeyID and SecretAccessKey sign the requests.
+	AccessKeyID     string
+	SecretAccessKey string
+
+	// ChunkSize is the size of every chunk but the last. It must not be
+	// below the server's minimum part size. Default: DefaultChunkSize.
+	ChunkSize int64
+
+	// Retries is the number of times a failed request is retried after
+	// network errors, server errors and conflicts. Default: 3; negative
+	// disables retries.
+	Retries int
+
+	// RetryDelay is the wait before the first retry, doubled for every
+	// further one. Default: 1 second.
+	RetryDelay time.Duration
+
+	// HTTPClient is used for all requests. Default: http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// Uploader uploads objects in resumable chunks.
+type Uploader struct {
+	config Config
+	creds  aws.Credentials
+	signer *v4.Signer
+}
+
+// Result is the object written by a committed upload.
+type Result struct {
+	ETag      string
+	VersionID string
+
+	// ChunksSent is the number of chunks sent by the call to Upload; the
+	// others were staged by an earlier attempt.
+	ChunksSent int
+}
+
+// Error is an error response of the server.
+type Error struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (%d): %s", e.Code, e.StatusCode, e.Message)
+}
+
+// NewUploader returns an Uploader for config.
+func NewUploader(config Config) *Uploader {
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	if config.ChunkSize <= 0 {
+		config.ChunkSize = DefaultChunkSize
+	}
+	if config.Retries == 0 {
+		config.Retries = 3
+	}
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = time.Second
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = http.DefaultClient
+	}
+	config.Endpoint = strings.TrimSuffix(config.Endpoint, "/")
+
+	return &Uploader{
+		config: config,
+		creds:  aws.Credentials{AccessKeyID: config.AccessKeyID, SecretAccessKey: config.SecretAccessKey},
+		// S3 signs the path as sent rather than escaping it again
+		signer: v4.NewSigner(func(o *v4.SignerOptions) { o.DisableURIPathEscaping = true }),
+	}
+}
+
+// Start initiates an upload of key and returns its upload ID. The caller
+// keeps the ID to resume the upload after a failure.
+func (u *Uploader) Start(ctx context.Context, bucket, key string) (string, error) {
+	var result struct {
+		UploadID string `xml:"UploadId"`
+	}
+	req := request{method: http.MethodPost, bucket: bucket, key: key, query: url.Values{"uploads": {""}}}
+	if _, err := u.do(ctx, req, &result); err != nil {
+		return "", fmt.Errorf("starting upload: %w", err)
+	}
+	return result.UploadID, nil
+}
+
+// Upload sends the chunks of body, size bytes read from offset 0, that the
+// upload does not have yet and commits the upload. If it fails, calling it
+// again with the same upload ID and content sends only the chunks missing.
+func (u *Uploader) Upload(ctx context.Context, bucket, key, uploadID string, body io.ReaderAt, size int64) (*Result, error) {
+	staged, err := u.stagedChunks(ctx, bucket, key, uploadID)
+	if err != nil {
+		return nil, err
+	}
+
+	// An empty object is a single empty chunk
+	chunks := max(1, (size+u.config.ChunkSize-1)/u.config.ChunkSize)
+	hashes := make([]string, chunks)
+	sent := 0
+	for i := range chunks {
+		offset := i * u.config.ChunkSize
+		chunk := io.NewSectionReader(body, offset, min(u.config.ChunkSize, size-offset))
+		hash, err := sha256Hex(chunk)
+		if err != nil {
+			return nil, fmt.Errorf("reading chunk at offset %d: %w", offset, err)
+		}
+		hashes[i] = hash
+
+		if staged[chunkETag(hash)] {
+			continue
+		}
+		req := request{
+			method: http.MethodPut, bucket: bucket, key: key,
+			query:  url.Values{"uploadId": {uploadID}},
+			header: http.Header{ChunkSHA256Header: {hash}},
+			body:   chunk, bodyHash: hash,
+		}
+		if _, err := u.do(ctx, req, nil); err != nil {
+			return nil, fmt.Errorf("sending chunk at offset %d: %w", offset, err)
+		}
+		staged[chunkETag(hash)] = true
+		sent++
+	}
+
+	result, err := u.commit(ctx, bucket, key, uploadID, hashes)
+	if err != nil {
+		return nil, err
+	}
+	result.ChunksSent = sent
+	return result, nil
+}
+
+// Abort aborts an upload and discards its chunks.
+func (u *Uploader) Abort(ctx context.Context, bucket, key, uploadID string) error {
+	req := request{method: http.MethodDelete, bucket: bucket, key: key, query: url.Values{"uploadId": {uploadID}}}
+	if _, err := u.do(ctx, req, nil); err != nil {
+		return fmt.Errorf("aborting upload: %w", err)
+	}
+	return nil
+}
+
+// stagedChunks returns the ETags of the parts of an upload. The ETag of a
+// part staged as a chunk derives from its hash; see chunkETag.
+func (u *Uploader) stagedChunks(ctx context.Context, bucket, key, uploadID string) (map[string]bool, error) {
+	staged := make(map[string]bool)
+	query := url.Values{"uploadId": {uploadID}}
+	for {
+		var result struct {
+			IsTruncated          bool `xml:"IsTruncated"`
+			NextPartNumberMarker int  `xml:"NextPartNumberMarker"`
+			Parts                []struct {
+				ETag string `xml:"ETag"`
+			} `xml:"Part"`
+		}
+		req := request{method: http.MethodGet, bucket: bucket, key: key, query: query}
+		if _, err := u.do(ctx, req, &result); err != nil {
+			return nil, fmt.Errorf("listing staged chunks: %w", err)
+		}
+		for _, part := range result.Parts {
+			staged[part.ETag] = true
+		}
+		if !result.IsTruncated {
+			return staged, nil
+		}
+		query.Set("part-number-marker", strconv.Itoa(result.NextPartNumberMarker))
+	}
+}
+
+// commit assembles the object from the chunks with the given hashes.
+func (u *Uploader) commit(ctx context.Context, bucket, key, uploadID string, hashes []string) (*Result, error) {
+	var result struct {
+		ETag string `xml:"ETag"`
+	}
+	list := []byte(strings.Join(hashes, "\n"))
+	req := request{
+		method: http.MethodPost, bucket: bucket, key: key,
+		query:  url.Values{"uploadId": {uploadID}},
+		header: http.Header{CommitChunksHeader: {"true"}},
+		body:   io.NewSectionReader(bytes.NewReader(list), 0, int64(len(list))),
+	}
+	resp, err := u.do(ctx, req, &result)
+	if err != nil {
+		return nil, fmt.Errorf("committing upload: %w", err)
+	}
+	return &Result{ETag: result.ETag, VersionID: resp.Header.Get("x-amz-version-id")}, nil
+}
+
+// request is a request to the server.
+type request struct {
+	method      string
+	bucket, key string
+	query       url.Values
+	header      http.Header
+
+	// body is sent from its start on every attempt. bodyHash is its hex
+	// SHA-256 hash, computed if empty.
+	body     *io.SectionReader
+	bodyHash string
+}
+
+// do sends a signed request, retrying transient failures, and decodes an
+// XML response into result unless it is nil.
+func (u *Uploader) do(ctx context.Context, req request, result any) (*http.Response, error) {
+	if req.body != nil && req.bodyHash == "" {
+		hash, err := sha256Hex(req.body)
+		if err != nil {
+			return nil, err
+		}
+		req.bodyHash = hash
+	}
+
+	delay := u.config.RetryDelay
+	for attempt := 0; ; attempt++ {
+		resp, err := u.send(ctx, req, result)
+		if err == nil || attempt >= u.config.Retries || !retryable(err) {
+			return resp, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// send sends a signed request once.
+func (u *Uploader) send(ctx context.Context, req request, result any) (*http.Response, error) {
+	target := u.config.Endpoint + "/" + url.PathEscape(req.bucket) + "/" + escapeKey(req.key)
+	if len(req.query) > 0 {
+		target += "?" + req.query.Encode()
+	}
+	payloadHash := emptyPayloadHash
+	var body io.Reader
+	if req.body != nil {
+		payloadHash = req.bodyHash
+		body = io.NewSectionReader(req.body, 0, req.body.Size())
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	if req.body != nil {
+		httpReq.ContentLength = req.body.Size()
+	}
+	for name, values := range req.header {
+		httpReq.Header[http.CanonicalHeaderKey(name)] = values
+	}
+	httpReq.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if err := u.signer.SignHTTP(ctx, u.creds, httpReq, payloadHash, "s3", u.config.Region, time.Now()); err != nil {
+		return nil, fmt.Errorf("signing request: %w", err)
+	}
+
+	resp, err := u.config.HTTPClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		var body struct {
+			Code    string `xml:"Code"`
+			Message string `xml:"Message"`
+		}
+		_ = xml.Unmarshal(data, &body)
+		if body.Code == "" {
+			body.Code = http.StatusText(resp.StatusCode)
+		}
+		return nil, &Error{StatusCode: resp.StatusCode, Code: body.Code, Message: body.Message}
+	}
+	if result != nil {
+		if err := xml.Unmarshal(data, result); err != nil {
+			return nil, fmt.Errorf("decoding response: %w", err)
+		}
+	}
+	return resp, nil
+}
+
+// emptyPayloadHash is the hex SHA-256 hash of an empty body.
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// retryable reports whether a failed request may succeed when retried:
+// network errors, server errors and conflicting operations.
+func retryable(err error) bool {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	return apiErr.StatusCode >= 500 || apiErr.StatusCode == http.StatusConflict
+}
+
+// chunkETag returns the ETag the server gives a part with the given hash:
+// the quoted hex MD5 of the hex hash.
+func chunkETag(hash string) string {
+	sum := md5.Sum([]byte(hash))
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+// sha256Hex returns the hex SHA-256 hash of the content of r.
+func sha256Hex(r *io.SectionReader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, io.NewSectionReader(r, 0, r.Size())); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// escapeKey escapes every segment of an object key as S3 signs it.
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,154 @@
+package resumable
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeServer implements the resumable upload requests of one upload.
+type fakeServer struct {
+	mu      sync.Mutex
+	chunks  map[string][]byte // staged chunks by hash
+	order   []string          // hashes in staging order, for ListParts
+	failPut int               // PUT requests to fail before accepting one
+	puts    int               // chunks accepted
+	object  []byte            // committed content
+}
+
+func (f *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	query := r.URL.Query()
+	body, _ := io.ReadAll(r.Body)
+
+	switch {
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		fmt.Fprint(w, `<InitiateMultipartUploadResult><UploadId>upload-1</UploadId></InitiateMultipartUploadResult>`)
+	case r.Method == http.MethodGet:
+		fmt.Fprint(w, `<ListPartsResult><IsTruncated>false</IsTruncated>`)
+		for _, hash := range f.order {
+			fmt.Fprintf(w, `<Part><ETag>%s</ETag></Part>`, chunkETag(hash))
+		}
+		fmt.Fprint(w, `</ListPartsResult>`)
+	case r.Method == http.MethodPut:
+		if f.failPut > 0 {
+			f.failPut--
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `<Error><Code>InternalError</Code><Message>boom</Message></Error>`)
+			return
+		}
+		sum := sha256.Sum256(body)
+		hash := r.Header.Get(ChunkSHA256Header)
+		if hash != hex.EncodeToString(sum[:]) || r.Header.Get("X-Amz-Content-Sha256") != hash {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `<Error><Code>BadDigest</Code></Error>`)
+			return
+		}
+		if _, ok := f.chunks[hash]; !ok {
+			f.chunks[hash] = body
+			f.order = append(f.order, hash)
+		}
+		f.puts++
+		w.Header().Set("ETag", chunkETag(hash))
+	case r.Method == http.MethodPost && r.Header.Get(CommitChunksHeader) != "":
+		var object []byte
+		for _, hash := range strings.Fields(string(body)) {
+			chunk, ok := f.chunks[hash]
+			if !ok {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `<Error><Code>InvalidPart</Code></Error>`)
+				return
+			}
+			object = append(object, chunk...)
+		}
+		f.object = object
+		w.Header().Set("x-amz-version-id", "v1")
+		fmt.Fprint(w, `<CompleteMultipartUploadResult><ETag>"etag-2"</ETag></CompleteMultipartUploadResult>`)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestUploader_ResumesAfterFailure(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeServer{chunks: make(map[string][]byte)}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	u := NewUploader(Config{
+		Endpoint:        server.URL,
+		AccessKeyID:     "AKID",
+		SecretAccessKey: "secret",
+		ChunkSize:       4,
+		Retries:         -1,
+	})
+
+	content := []byte("0123456789abcd")
+	uploadID, err := u.Start(ctx, "bucket", "dir/file name")
+	require.NoError(t, err)
+	require.Equal(t, "upload-1", uploadID)
+
+	// Reading the third chunk fails and nothing is committed
+	failing := &failingReaderAt{ReaderAt: bytes.NewReader(content), failFrom: 8}
+	_, err = u.Upload(ctx, "bucket", "dir/file name", uploadID, failing, int64(len(content)))
+	require.Error(t, err)
+	require.Nil(t, fake.object)
+	require.Equal(t, 2, fake.puts)
+
+	// Resuming sends only the chunks missing, retrying server errors
+	fake.failPut = 1
+	u.config.Retries, u.config.RetryDelay = 1, 1
+	result, err := u.Upload(ctx, "bucket", "dir/file name", uploadID, bytes.NewReader(content), int64(len(content)))
+	require.NoError(t, err)
+	require.Equal(t, 2, result.ChunksSent)
+	require.Equal(t, `"etag-2"`, result.ETag)
+	require.Equal(t, "v1", result.VersionID)
+	require.Equal(t, content, fake.object)
+	require.Equal(t, 4, fake.puts)
+}
+
+func TestUploader_ReportsServerErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `<Error><Code>NoSuchUpload</Code><Message>The specified multipart upload does not exist.</Message></Error>`)
+	}))
+	t.Cleanup(server.Close)
+
+	u := NewUploader(Config{Endpoint: server.URL, AccessKeyID: "AKID", SecretAccessKey: "secret"})
+	_, err := u.Upload(context.Background(), "bucket", "key", "missing", strings.NewReader("data"), 4)
+
+	var apiErr *Error
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	require.Equal(t, "NoSuchUpload", apiErr.Code)
+}
+
+// failingReaderAt fails reads at or after an offset, like a file on a
+// volume that went away.
+type failingReaderAt struct {
+	io.ReaderAt
+	failFrom int64
+}
+
+func (r *failingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off >= r.failFrom {
+		return 0, io.ErrUnexpectedEOF
+	}
+	return r.ReaderAt.ReadAt(p, off)
+}